@@ -0,0 +1,338 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/scitix/sichek/cmd/command/component"
+	"github.com/scitix/sichek/cmd/command/spec"
+	"github.com/scitix/sichek/components/pcie/topotest"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/bundle"
+	"github.com/scitix/sichek/pkg/history"
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/scitix/sichek/pkg/utils"
+	"github.com/scitix/sichek/service"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	fabricManagerLogPath = "/var/log/fabricmanager.log"
+	// bundleLogTailBytes bounds the bytes of each log file read for the bundle.
+	bundleLogTailBytes = 64 << 20
+	bundleCmdTimeout   = 30 * time.Second
+)
+
+// syslogPaths are the syslog files of the Debian and the RedHat families.
+var syslogPaths = []string{"/var/log/syslog", "/var/log/messages"}
+
+// BundleOptions sets what NewBundleCmd gathers into the bundle.
+type BundleOptions struct {
+	CfgFile          string
+	SpecFile         string
+	EnableComponents string
+	IgnoreComponents string
+	IgnoredCheckers  string
+	SkipChecks       bool
+	Addr             string
+	CacheResults     int
+	Since            time.Duration
+	MaxLogLines      int
+}
+
+// NewBundleCmd creates the "bundle" command which gathers the sichek reports,
+// histories, logs, specs and topology of the node into a tar.gz to attach to
+// the support tickets of the hardware vendors.
+func NewBundleCmd() *cobra.Command {
+	var (
+		opts     BundleOptions
+		output   string
+		redact   []string
+		patterns []string
+		verbos   bool
+	)
+	bundleCmd := &cobra.Command{
+		Use:   "bundle",
+		Short: "Gather the reports, logs, specs and topology of the node into a tar.gz for support tickets",
+		Long: "Gather into a single tar.gz, to attach to the support tickets of the hardware vendors:\n" +
+			"  export.json             the results and infos of the health checks (sichek export)\n" +
+			"  history/                the results cached by the running daemon and the persisted history\n" +
+			"  logs/                   the NVRM and mlx5 lines of dmesg, the fabricmanager log and syslog excerpts\n" +
+			"  specs/                  the user config, its webhook URLs, secrets and tokens masked, and the spec files\n" +
+			"  topology/               the PCIe/NVLink topology, nvidia-smi topo -m and lspci -tv\n" +
+			"  manifest.json           the files of the bundle and the items that could not be gathered\n\n" +
+			"With --redact, the hostnames, IP and MAC addresses and serial numbers are replaced by pseudonyms,\n" +
+			"the same value getting the same pseudonym across the files, e.g.\n\n" +
+			"  sichek bundle --redact all -o /tmp/case-1234.tar.gz",
+		Run: func(cmd *cobra.Command, args []string) {
+			if !verbos {
+				logrus.SetLevel(logrus.ErrorLevel)
+			}
+			hostname, err := os.Hostname()
+			if err != nil {
+				logrus.WithField("bundle", "cmd").Errorf("get hostname failed: %v", err)
+			}
+			redactor, err := bundle.NewRedactor(redact, []string{hostname}, patterns)
+			if err != nil {
+				logrus.WithField("bundle", "cmd").Errorf("%v", err)
+				os.Exit(1)
+			}
+			now := time.Now()
+			node := hostname
+			if redactor.Enabled() {
+				node = redactor.RedactString(hostname)
+			}
+			dir := fmt.Sprintf("sichek-bundle-%s-%s", strings.Trim(node, "<>"), now.Format("20060102-150405"))
+			if output == "" {
+				output = dir + ".tar.gz"
+			}
+			f, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+			if err != nil {
+				logrus.WithField("bundle", "cmd").Errorf("create bundle failed: %v", err)
+				os.Exit(1)
+			}
+			defer f.Close()
+
+			w := bundle.NewWriter(f, dir, redactor, bundle.Manifest{Node: hostname, Version: versionString(), Time: now})
+			GatherBundle(context.Background(), w, opts)
+			if err := w.Close(); err != nil {
+				logrus.WithField("bundle", "cmd").Errorf("write bundle failed: %v", err)
+				os.Exit(1)
+			}
+			manifest := w.Manifest()
+			printer.Printf("bundle with %d files written to %s\n", len(manifest.Files)+1, output)
+			for item, msg := range manifest.Errors {
+				printer.Warnf("  %s%s not gathered: %s%s\n", consts.Yellow, item, msg, consts.Reset)
+			}
+		},
+	}
+
+	bundleCmd.Flags().StringVarP(&output, "output", "o", "", "Path to the bundle (default ./sichek-bundle-<node>-<time>.tar.gz)")
+	bundleCmd.Flags().StringVarP(&opts.CfgFile, "cfg", "c", "", "Path to the user config file")
+	bundleCmd.Flags().StringVarP(&opts.SpecFile, "spec", "s", "", "Path to the sichek specification file")
+	bundleCmd.Flags().StringVarP(&opts.EnableComponents, "enable-components", "E", "", "Enabled components, joined by ','")
+	bundleCmd.Flags().StringVarP(&opts.IgnoreComponents, "ignore-components", "I", "podlog,gpuevents,syslog", "Ignored components")
+	bundleCmd.Flags().StringVarP(&opts.IgnoredCheckers, "ignored-checkers", "i", "", "Ignored checkers")
+	bundleCmd.Flags().BoolVar(&opts.SkipChecks, "skip-checks", false, "Do not run the health checks, e.g. on a node where they hang")
+	bundleCmd.Flags().StringVar(&opts.Addr, "addr", "", "Address of the daemon API (default api_server.addr of the user config)")
+	bundleCmd.Flags().IntVar(&opts.CacheResults, "history", 20, "Number of results cached by the daemon to gather per component, 0 for all")
+	bundleCmd.Flags().DurationVar(&opts.Since, "since", 7*24*time.Hour, "Gather the persisted history of this duration")
+	bundleCmd.Flags().IntVar(&opts.MaxLogLines, "max-log-lines", 5000, "Number of the last matching lines gathered per log, 0 for all")
+	bundleCmd.Flags().StringSliceVar(&redact, "redact", nil, "Redact "+strings.Join(bundle.RedactCategories, ",")+" or "+bundle.RedactAll)
+	bundleCmd.Flags().StringArrayVar(&patterns, "redact-pattern", nil, "Also redact the matches of this regexp, repeatable")
+	bundleCmd.Flags().BoolVarP(&verbos, "verbos", "v", false, "Enable verbose output")
+	return bundleCmd
+}
+
+// GatherBundle writes the items of the bundle with w, the items that cannot
+// be gathered are recorded in the manifest and do not stop the others.
+func GatherBundle(ctx context.Context, w *bundle.Writer, opts BundleOptions) {
+	cfgFile, err := spec.EnsureCfgFile(opts.CfgFile)
+	if err != nil {
+		logrus.WithField("bundle", "cmd").Warnf("failed to load cfgFile: %v", err)
+	}
+	specFile, err := spec.EnsureSpecFile(opts.SpecFile)
+	if err != nil {
+		logrus.WithField("bundle", "cmd").Warnf("failed to load specFile: %v", err)
+	}
+
+	items := []struct {
+		name   string
+		gather func() error
+	}{
+		{"export", func() error {
+			if opts.SkipChecks {
+				return fmt.Errorf("skipped with --skip-checks")
+			}
+			return gatherExport(ctx, w, opts, cfgFile, specFile)
+		}},
+		{"cache-history", func() error { return gatherCacheHistory(ctx, w, opts, cfgFile) }},
+		{"history", func() error { return gatherHistory(w, opts, cfgFile) }},
+		{"dmesg", func() error { return gatherDmesg(ctx, w, opts) }},
+		{"fabricmanager", func() error {
+			return gatherLogFile(w, "logs/fabricmanager.log", []string{fabricManagerLogPath}, nil, opts.MaxLogLines)
+		}},
+		{"syslog", func() error {
+			return gatherLogFile(w, "logs/syslog.txt", syslogPaths, bundle.SyslogPatterns, opts.MaxLogLines)
+		}},
+		{"user-config", func() error { return gatherUserConfig(w, cfgFile) }},
+		{"spec", func() error { return gatherFile(w, "specs", specFile) }},
+		{"topology", func() error { return gatherTopology(w) }},
+		{"nvidia-smi-topo", func() error {
+			return gatherCommand(ctx, w, "topology/nvidia-smi-topo.txt", "nvidia-smi", "topo", "-m")
+		}},
+		{"lspci-tree", func() error { return gatherCommand(ctx, w, "topology/lspci-tree.txt", "lspci", "-tv") }},
+	}
+	for _, item := range items {
+		if err := item.gather(); err != nil {
+			logrus.WithField("bundle", "cmd").Warnf("gather %s failed: %v", item.name, err)
+			w.AddError(item.name, err)
+		}
+	}
+}
+
+func gatherExport(ctx context.Context, w *bundle.Writer, opts BundleOptions, cfgFile, specFile string) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.AllCmdTimeout)
+	defer cancel()
+	var ignoredCheckers []string
+	if len(opts.IgnoredCheckers) > 0 {
+		ignoredCheckers = strings.Split(opts.IgnoredCheckers, ",")
+	}
+	componentsToCheck := component.DetermineComponentsToCheck(opts.EnableComponents, opts.IgnoreComponents, cfgFile, "bundle")
+	checkResults, errs := component.RunComponentChecks(ctx, componentsToCheck, cfgFile, specFile, ignoredCheckers)
+	content, err := component.BuildReport(checkResults, errs).JSON()
+	if err != nil {
+		return err
+	}
+	return w.AddFile("export.json", "sichek export", []byte(content))
+}
+
+func gatherCacheHistory(ctx context.Context, w *bundle.Writer, opts BundleOptions, cfgFile string) error {
+	addr := opts.Addr
+	if addr == "" {
+		apiCfg, err := service.LoadAPIServerConfig(cfgFile)
+		if err != nil {
+			return err
+		}
+		addr = apiCfg.Addr
+	}
+	ctx, cancel := context.WithTimeout(ctx, bundleCmdTimeout)
+	defer cancel()
+	client := NewAPIClient(addr)
+	statuses, err := client.ListComponents(ctx)
+	if err != nil {
+		return fmt.Errorf("list the components of the daemon at %s: %w", addr, err)
+	}
+	var histories []*service.ComponentHistory
+	for _, status := range statuses {
+		h, err := client.ComponentHistory(ctx, status.Name, opts.CacheResults, true)
+		if err != nil {
+			return fmt.Errorf("get the history of %s from %s: %w", status.Name, addr, err)
+		}
+		histories = append(histories, h)
+	}
+	return w.AddJSON("history/cache.json", addr, histories)
+}
+
+func gatherHistory(w *bundle.Writer, opts BundleOptions, cfgFile string) error {
+	cfg := history.LoadConfig(cfgFile)
+	if _, err := os.Stat(cfg.History.Path); err != nil {
+		return err
+	}
+	store, err := history.NewJSONLStore(cfg.History.Path, 0)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	records, err := store.Query(history.Query{Since: time.Now().Add(-opts.Since)})
+	if err != nil {
+		return err
+	}
+	return w.AddJSON("history/results.json", cfg.History.Path, records)
+}
+
+func gatherDmesg(ctx context.Context, w *bundle.Writer, opts BundleOptions) error {
+	ctx, cancel := context.WithTimeout(ctx, bundleCmdTimeout)
+	defer cancel()
+	output, err := utils.ExecCommand(ctx, "dmesg", "-T")
+	if err != nil {
+		return err
+	}
+	return w.AddFile("logs/dmesg.txt", "dmesg -T", bundle.FilterLines(output, bundle.DmesgPatterns, opts.MaxLogLines))
+}
+
+// gatherLogFile writes the lines of the first of paths existing on the host
+// matching patterns, or its last lines when patterns is nil.
+func gatherLogFile(w *bundle.Writer, name string, paths []string, patterns []*regexp.Regexp, maxLines int) error {
+	for _, path := range paths {
+		data, err := bundle.ReadTail(hostfs.Path(path), bundleLogTailBytes)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if patterns != nil {
+			data = bundle.FilterLines(data, patterns, maxLines)
+		} else {
+			data = bundle.TailLines(data, maxLines)
+		}
+		return w.AddFile(name, path, data)
+	}
+	return fmt.Errorf("none of %s found", strings.Join(paths, ", "))
+}
+
+func gatherFile(w *bundle.Writer, dir, path string) error {
+	if path == "" {
+		return fmt.Errorf("file not found")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return w.AddFile(dir+"/"+filepath.Base(path), path, data)
+}
+
+// gatherUserConfig writes the user config with the webhook URLs, secrets and
+// tokens masked, whatever the redaction of the bundle.
+func gatherUserConfig(w *bundle.Writer, path string) error {
+	if path == "" {
+		return fmt.Errorf("file not found")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	masked, err := bundle.MaskSecrets(data)
+	if err != nil {
+		return err
+	}
+	return w.AddFile("specs/"+filepath.Base(path), path, masked)
+}
+
+func gatherTopology(w *bundle.Writer) error {
+	graph, err := topotest.CollectTopoGraph()
+	if err != nil {
+		return err
+	}
+	data, err := graph.JSON()
+	if err != nil {
+		return err
+	}
+	if err := w.AddFile("topology/topo.json", "sichek topo export", append(data, '\n')); err != nil {
+		return err
+	}
+	return w.AddFile("topology/topo.dot", "sichek topo export", []byte(graph.DOT()))
+}
+
+func gatherCommand(ctx context.Context, w *bundle.Writer, name string, command string, args ...string) error {
+	ctx, cancel := context.WithTimeout(ctx, bundleCmdTimeout)
+	defer cancel()
+	output, err := utils.ExecCommand(ctx, command, args...)
+	if err != nil {
+		return err
+	}
+	return w.AddFile(name, strings.Join(append([]string{command}, args...), " "), output)
+}
@@ -17,11 +17,19 @@ package command
 
 import (
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/scitix/sichek/cmd/command/component"
+	"github.com/scitix/sichek/components/common/remediator"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/capability"
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/scitix/sichek/pkg/silence"
 	"github.com/scitix/sichek/pkg/utils"
 
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -32,7 +40,51 @@ func NewRootCmd() *cobra.Command {
 		Short: "Hardware health check tool",
 		Long:  "A command - line tool for performing operations related to different hardware components",
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			logFormat, _ := cmd.Flags().GetString("log-format")
+			if err := utils.SetLogFormat(logFormat); err != nil {
+				return err
+			}
+			if err := setPrinter(cmd); err != nil {
+				return err
+			}
+			hostRoot, _ := cmd.Flags().GetString("host-root")
+			if !cmd.Flags().Changed("host-root") && os.Getenv("SICHEK_HOST_ROOT") != "" {
+				hostRoot = os.Getenv("SICHEK_HOST_ROOT")
+			}
+			if hostRoot != "" {
+				if err := hostfs.SetRoot(hostRoot); err != nil {
+					return err
+				}
+			}
+			autoFix, _ := cmd.Flags().GetBool("auto-fix")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			if autoFix || dryRun {
+				remediator.SetDefault(remediator.New(remediator.Config{Enable: autoFix, DryRun: dryRun}))
+			}
+			cfgFile, _ := cmd.Flags().GetString("cfg")
+			component.SilencePath = silence.LoadPath(cfgFile)
+			failOn, _ := cmd.Flags().GetString("fail-on")
+			if cmd.Flags().Changed("fail-on") {
+				level, err := component.ParseFailOnLevel(failOn)
+				if err != nil {
+					return err
+				}
+				component.FailOnLevel = level
+			} else {
+				level, err := component.LoadFailOnLevel(cfgFile)
+				if err != nil {
+					return err
+				}
+				component.FailOnLevel = level
+			}
+			// commandsRequireRoot refuse to run without root, their result must not
+			// miss any check, e.g. the acceptance of a node
 			commandsRequireRoot := map[string]bool{
+				"accept": true,
+			}
+			// commandsPreferRoot run degraded without root: the checkers needing a
+			// privilege sichek lacks are reported as skipped
+			commandsPreferRoot := map[string]bool{
 				"gpu":        true,
 				"g":          true,
 				"infiniband": true,
@@ -40,24 +92,61 @@ func NewRootCmd() *cobra.Command {
 				"gpuevents":  true,
 				"h":          true,
 				"all":        true,
+				"export":     true,
+				"diff":       true,
 				"run":        true,
 				"ethernet":   true,
 				"e":          true,
+				"amd":        true,
+				"pcie":       true,
+				"bmc":        true,
+				"storage":    true,
+				"watch":      true,
 			}
 
-			if commandsRequireRoot[cmd.Use] {
-				root := utils.IsRoot()
-				if !root {
-					fmt.Printf("[ERROR] Command '%s' requires root privileges. Please run as root.\n", cmd.Use)
+			if !utils.IsRoot() {
+				if commandsRequireRoot[cmd.Use] {
+					printer.Warnf("%s[ERROR] Command '%s' requires root privileges. Please run as root.%s\n", consts.Red, cmd.Use, consts.Reset)
 					os.Exit(-1)
 				}
+				if commandsPreferRoot[cmd.Use] {
+					missing := capability.Detect().Missing(capability.SysAdmin, capability.PCIConfig, capability.Kmsg, capability.IPMI)
+					if len(missing) > 0 {
+						printer.Warnf("%s[WARN] Command '%s' runs without root privileges, the checkers requiring %s are skipped.%s\n",
+							consts.Yellow, cmd.Use, capability.Requirement(missing), consts.Reset)
+					}
+				}
+			}
+			// in a pod, the namespaces and devices of the host the checks need
+			// may be hidden
+			if commandsRequireRoot[cmd.Use] || commandsPreferRoot[cmd.Use] {
+				env := hostfs.DetectEnvironment()
+				hostfs.NetNSPath = env.HostNetNS
+				for _, warning := range env.Warnings {
+					printer.Warnf("%s[WARN] %s.%s\n", consts.Yellow, warning, consts.Reset)
+				}
 			}
 			return nil
 		},
 	}
 
+	rootCmd.PersistentFlags().String("log-format", utils.LogFormatText, "Log format of logrus output (text, json)")
+	rootCmd.PersistentFlags().String("host-root", "", "Directory the / of the host is mounted on when sichek runs in a container, e.g. /host, the sysfs and procfs paths are read under it (default $SICHEK_HOST_ROOT or /)")
+	rootCmd.PersistentFlags().Bool("auto-fix", false, "Apply the remediation actions of the abnormal checkers, e.g. load nvidia_peermem or disable PCIe ACS")
+	rootCmd.PersistentFlags().Bool("dry-run", false, "Print the remediation actions --auto-fix would apply without applying them")
+	rootCmd.PersistentFlags().Bool("json", false, "Print the report as a JSON document on stdout, the warnings and progress go to stderr")
+	rootCmd.PersistentFlags().String("color", printer.ColorAuto, "Color the report (auto, always, never), auto colors a terminal unless NO_COLOR is set")
+	rootCmd.PersistentFlags().Bool("quiet", false, "Print nothing, the exit code alone tells whether the checks passed")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Stream the progress of each component and checker to stderr")
+	rootCmd.PersistentFlags().String("fail-on", consts.LevelWarning, "Lowest level of a failed component that makes sichek exit non-zero (warning, critical, fatal)")
+
 	rootCmd.AddCommand(component.NewCPUCmd())
 	rootCmd.AddCommand(component.NewNvidiaCmd())
+	rootCmd.AddCommand(component.NewAmdCmd())
+	rootCmd.AddCommand(component.NewPcieCmd())
+	rootCmd.AddCommand(component.NewBmcCmd())
+	rootCmd.AddCommand(component.NewStorageCmd())
+	rootCmd.AddCommand(component.NewContainerRuntimeCmd())
 	rootCmd.AddCommand(component.NewInfinibandCmd())
 	rootCmd.AddCommand(component.NewEthernetCmd())
 	rootCmd.AddCommand(component.NewGpfsCmd())
@@ -66,6 +155,12 @@ func NewRootCmd() *cobra.Command {
 	rootCmd.AddCommand(component.NewGpuEventsCommand())
 	rootCmd.AddCommand(component.NewMemoryCmd())
 	rootCmd.AddCommand(component.NewAllCmd())
+	rootCmd.AddCommand(component.NewAcceptCmd())
+	rootCmd.AddCommand(component.NewExportCmd())
+	rootCmd.AddCommand(component.NewDiffCmd())
+	rootCmd.AddCommand(component.NewDriftCmd())
+	rootCmd.AddCommand(component.NewWatchCmd())
+	rootCmd.AddCommand(component.NewRemoteCmd())
 	rootCmd.AddCommand(NewVersionCmd())
 	rootCmd.AddCommand(NewDaemonCmd())
 	rootCmd.AddCommand(NewExporterCmd())
@@ -75,10 +170,50 @@ func NewRootCmd() *cobra.Command {
 	rootCmd.AddCommand(component.NewRoCEGidEqualCheckCmd())
 	rootCmd.AddCommand(component.NewIBPerftestCmd())
 	rootCmd.AddCommand(component.NewNcclPerftestCmd())
+	rootCmd.AddCommand(component.NewNvlinkPerftestCmd())
+	rootCmd.AddCommand(component.NewGpuDiagCmd())
+	rootCmd.AddCommand(component.NewGpuBurnCmd())
+	rootCmd.AddCommand(component.NewCudaTestCmd())
 	rootCmd.AddCommand(component.NewRoCEPerftestCmd())
+	rootCmd.AddCommand(component.NewMemTestCmd())
 	rootCmd.AddCommand(component.NewSyslogCmd())
 	rootCmd.AddCommand(component.NewTransceiverCmd())
 	rootCmd.AddCommand(component.NewLldpCmd())
+	rootCmd.AddCommand(component.NewInventoryCmd())
+	rootCmd.AddCommand(component.NewNCCLEnvCmd())
 	rootCmd.AddCommand(NewConfigCmd())
+	rootCmd.AddCommand(NewSpecCmd())
+	rootCmd.AddCommand(NewHistoryCmd())
+	rootCmd.AddCommand(NewStatusCmd())
+	rootCmd.AddCommand(NewSilenceCmd())
+	rootCmd.AddCommand(NewErrorsCmd())
+	rootCmd.AddCommand(NewBundleCmd())
 	return rootCmd
 }
+
+// setPrinter installs the printer of the --json, --color, --quiet and
+// --verbose flags. The commands with their own --json or --verbose flag set
+// the printer with it too.
+func setPrinter(cmd *cobra.Command) error {
+	jsonOut, _ := cmd.Flags().GetBool("json")
+	color, _ := cmd.Flags().GetString("color")
+	quiet, _ := cmd.Flags().GetBool("quiet")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+	if quiet && verbose {
+		return fmt.Errorf("--quiet and --verbose are mutually exclusive")
+	}
+	mode, err := printer.ParseMode(jsonOut, color)
+	if err != nil {
+		return err
+	}
+	verbosity := printer.VerbosityNormal
+	switch {
+	case quiet:
+		verbosity = printer.VerbosityQuiet
+		logrus.SetOutput(io.Discard)
+	case verbose:
+		verbosity = printer.VerbosityVerbose
+	}
+	printer.SetDefault(printer.New(mode, verbosity, os.Stdout, os.Stderr))
+	return nil
+}
@@ -0,0 +1,363 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/scitix/sichek/cmd/command/spec"
+	"github.com/scitix/sichek/components/pcie/topotest"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/scitix/sichek/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	AcceptStageHardware = "hardware"
+	AcceptStageGpuBurn  = "gpuburn"
+	AcceptStageNccl     = "nccltest"
+	AcceptStageIBPerf   = "ibperf"
+	AcceptStagePcieTopo = "pcie_topo"
+
+	AcceptPass    = "pass"
+	AcceptFail    = "fail"
+	AcceptSkipped = "skipped"
+)
+
+// DefaultAcceptStages is the battery run by sichek accept, in order.
+var DefaultAcceptStages = []string{AcceptStageHardware, AcceptStageGpuBurn, AcceptStageNccl, AcceptStageIBPerf, AcceptStagePcieTopo}
+
+// AcceptReport is the machine-readable verdict of sichek accept.
+type AcceptReport struct {
+	Node            string               `json:"node"`
+	Verdict         string               `json:"verdict"`
+	FailOn          string               `json:"fail_on"`
+	StartTime       time.Time            `json:"start_time"`
+	DurationSeconds float64              `json:"duration_seconds"`
+	Stages          []*AcceptStageResult `json:"stages"`
+}
+
+type AcceptStageResult struct {
+	Name            string            `json:"name"`
+	Status          string            `json:"status"`
+	Reason          string            `json:"reason,omitempty"`
+	Items           map[string]string `json:"items,omitempty"`
+	StartTime       time.Time         `json:"start_time"`
+	DurationSeconds float64           `json:"duration_seconds"`
+}
+
+// acceptStage is a step of the battery. skip returns the reason to skip the
+// stage on this node, run records its results in ComponentStatuses.
+type acceptStage struct {
+	name string
+	skip func() string
+	run  func()
+}
+
+type acceptOptions struct {
+	cfgFile         string
+	specFile        string
+	ignoredCheckers string
+	gpuBurnDuration time.Duration
+	verbose         bool
+}
+
+func NewAcceptCmd() *cobra.Command {
+	var (
+		opts     acceptOptions
+		stages   string
+		output   string
+		failFast bool
+	)
+	acceptCmd := &cobra.Command{
+		Use:   "accept",
+		Short: "Run the node acceptance battery: hardware checks, gpuburn, nccltest, ibperf and PCIe topology",
+		Run: func(cmd *cobra.Command, args []string) {
+			if !opts.verbose {
+				logrus.SetLevel(logrus.ErrorLevel)
+			}
+			resolvedCfgFile, err := spec.EnsureCfgFile(opts.cfgFile)
+			if err != nil {
+				logrus.WithField("component", "accept").Errorf("failed to load cfgFile: %v", err)
+			}
+			opts.cfgFile = resolvedCfgFile
+			resolvedSpecFile, err := spec.EnsureSpecFile(opts.specFile)
+			if err != nil {
+				logrus.WithField("component", "accept").Errorf("failed to load specFile: %v", err)
+			}
+			opts.specFile = resolvedSpecFile
+
+			var selected []*acceptStage
+			for _, name := range strings.Split(stages, ",") {
+				name = strings.TrimSpace(name)
+				if name == "" {
+					continue
+				}
+				stage, err := newAcceptStage(name, opts)
+				if err != nil {
+					printer.Printf("%s%v%s\n", consts.Red, err, consts.Reset)
+					SetComponentStatus("accept", false, consts.LevelFatal)
+					return
+				}
+				selected = append(selected, stage)
+			}
+
+			// keep stdout for the JSON report only: the stages, the acceptance
+			// summary and the final Summary section go to stderr
+			stdout := os.Stdout
+			if output == "-" {
+				os.Stdout = os.Stderr
+				printer.SetDefault(printer.Default().WithOutput(os.Stderr))
+			}
+			report := RunAcceptStages(selected, failFast)
+			PrintAcceptReport(report)
+			if output == "-" {
+				if err := writeAcceptReportTo(report, stdout); err != nil {
+					logrus.WithField("component", "accept").Error(err)
+				}
+			} else if output != "" {
+				if err := WriteAcceptReport(report, output); err != nil {
+					logrus.WithField("component", "accept").Error(err)
+					printer.Printf("%sfailed to write the acceptance report: %v%s\n", consts.Red, err, consts.Reset)
+				} else {
+					printer.Printf("Acceptance report written to %s\n", output)
+				}
+			}
+		},
+	}
+
+	acceptCmd.Flags().StringVarP(&opts.cfgFile, "cfg", "c", "", "Path to the user config file")
+	acceptCmd.Flags().StringVarP(&opts.specFile, "spec", "s", "", "Path to the sichek specification file")
+	acceptCmd.Flags().StringVarP(&opts.ignoredCheckers, "ignored-checkers", "i", "", "Ignored checkers of the hardware stage")
+	acceptCmd.Flags().StringVar(&stages, "stages", strings.Join(DefaultAcceptStages, ","), "Stages to run in order, joined by ','")
+	acceptCmd.Flags().DurationVar(&opts.gpuBurnDuration, "gpuburn-duration", 10*time.Minute, "Duration of the gpuburn stage")
+	acceptCmd.Flags().StringVarP(&output, "output", "o", "", "Write the JSON acceptance report to this file, - for stdout")
+	acceptCmd.Flags().BoolVar(&failFast, "fail-fast", false, "Stop at the first failed stage")
+	acceptCmd.Flags().BoolVarP(&opts.verbose, "verbose", "v", false, "Enable verbose output")
+
+	return acceptCmd
+}
+
+func newAcceptStage(name string, opts acceptOptions) (*acceptStage, error) {
+	noGPU := func() string {
+		if !utils.IsNvidiaGPUExist() {
+			return "no NVIDIA GPU found"
+		}
+		return ""
+	}
+	switch name {
+	case AcceptStageHardware:
+		return &acceptStage{name: name, skip: func() string { return "" }, run: func() {
+			var ignoredCheckers []string
+			if opts.ignoredCheckers != "" {
+				ignoredCheckers = strings.Split(opts.ignoredCheckers, ",")
+			}
+			// the PCIe topology is validated by its own stage
+			components := slices.DeleteFunc(DetermineComponentsToCheck("", "podlog,gpuevents,syslog", opts.cfgFile, "accept"), func(c string) bool {
+				return c == consts.ComponentNamePcieTopo
+			})
+			ctx, cancel := context.WithTimeout(context.Background(), consts.AllCmdTimeout)
+			defer cancel()
+			// components not supported on this node are not reported, while a
+			// component that fails to initialize or to check fails the stage
+			checkResults, errs := RunComponentChecks(ctx, components, opts.cfgFile, opts.specFile, ignoredCheckers)
+			for _, checkResult := range checkResults {
+				if checkResult != nil {
+					PrintCheckResults(true, checkResult)
+				}
+			}
+			for name, err := range errs {
+				printer.Printf("%s%s check failed: %v%s\n", consts.Red, name, err, consts.Reset)
+				SetComponentStatus(name, false, "")
+			}
+		}}, nil
+	case AcceptStageGpuBurn:
+		return &acceptStage{name: name, skip: noGPU, run: func() {
+			runSubCommand(NewGpuBurnCmd(), "--duration", opts.gpuBurnDuration.String())
+		}}, nil
+	case AcceptStageNccl:
+		return &acceptStage{name: name, skip: noGPU, run: func() {
+			runSubCommand(NewNcclPerftestCmd(), "--begin", "2g", "--end", "2g")
+		}}, nil
+	case AcceptStageIBPerf:
+		return &acceptStage{name: name, skip: func() string {
+			if !utils.IsInfinibandExist() {
+				return "no IB device found"
+			}
+			return ""
+		}, run: func() {
+			runSubCommand(NewIBPerftestCmd())
+		}}, nil
+	case AcceptStagePcieTopo:
+		return &acceptStage{name: name, skip: noGPU, run: func() {
+			res, err := topotest.CheckGPUTopology(opts.specFile)
+			if err != nil {
+				printer.Printf("%scheck PCIe topology failed: %v%s\n", consts.Red, err, consts.Reset)
+				SetComponentStatus(consts.ComponentNamePcieTopo, false, "")
+				return
+			}
+			SetComponentStatus(res.Item, topotest.PrintInfo(res, opts.verbose), res.Level)
+		}}, nil
+	}
+	return nil, fmt.Errorf("unknown acceptance stage %q, expected one of %s", name, strings.Join(DefaultAcceptStages, ","))
+}
+
+// runSubCommand runs a test command the same way as sichek all runs nccltest.
+func runSubCommand(cmd *cobra.Command, args ...string) {
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		printer.Printf("failed to run %s: %v\n", cmd.Use, err)
+		SetComponentStatus(cmd.Use, false, "")
+	}
+}
+
+// RunAcceptStages runs the stages in order and times them. The statuses a
+// stage records are attributed to it and judged with FailOnLevel; a stage
+// that records none, e.g. a test bypassed on this node, is skipped.
+func RunAcceptStages(stages []*acceptStage, failFast bool) *AcceptReport {
+	hostname, _ := os.Hostname()
+	report := &AcceptReport{
+		Node:      hostname,
+		Verdict:   AcceptPass,
+		FailOn:    FailOnLevel,
+		StartTime: time.Now(),
+	}
+	failed := false
+	for _, stage := range stages {
+		res := &AcceptStageResult{Name: stage.name, StartTime: time.Now()}
+		report.Stages = append(report.Stages, res)
+		if failed && failFast {
+			res.Status = AcceptSkipped
+			res.Reason = "a previous stage failed"
+			continue
+		}
+		if reason := stage.skip(); reason != "" {
+			res.Status = AcceptSkipped
+			res.Reason = reason
+			continue
+		}
+		printer.Printf("==> Running acceptance stage %s\n", stage.name)
+		statuses := isolateStatuses(stage.run)
+		res.DurationSeconds = time.Since(res.StartTime).Seconds()
+		res.Status = judgeStage(res, statuses)
+		if res.Status == AcceptFail {
+			failed = true
+			report.Verdict = AcceptFail
+		}
+	}
+	report.DurationSeconds = time.Since(report.StartTime).Seconds()
+	return report
+}
+
+// isolateStatuses runs fn with an empty ComponentStatuses and returns what fn
+// recorded, merged back so the Summary section still lists every item.
+func isolateStatuses(fn func()) map[string]bool {
+	StatusMutex.Lock()
+	prev := ComponentStatuses
+	ComponentStatuses = make(map[string]bool)
+	StatusMutex.Unlock()
+
+	fn()
+
+	StatusMutex.Lock()
+	defer StatusMutex.Unlock()
+	recorded := ComponentStatuses
+	for name, passed := range recorded {
+		prev[name] = passed
+	}
+	ComponentStatuses = prev
+	return recorded
+}
+
+func judgeStage(res *AcceptStageResult, statuses map[string]bool) string {
+	if len(statuses) == 0 {
+		res.Reason = "no result recorded"
+		return AcceptSkipped
+	}
+	StatusMutex.Lock()
+	defer StatusMutex.Unlock()
+	status := AcceptPass
+	res.Items = make(map[string]string, len(statuses))
+	for name, passed := range statuses {
+		switch {
+		case passed:
+			res.Items[name] = AcceptPass
+		case isBlocking(ComponentLevels[name], FailOnLevel):
+			res.Items[name] = AcceptFail
+			status = AcceptFail
+		default:
+			// below the fail-on level, reported but not blocking
+			res.Items[name] = ComponentLevels[name]
+		}
+	}
+	return status
+}
+
+func PrintAcceptReport(report *AcceptReport) {
+	utils.PrintTitle("Acceptance", "-")
+	for _, stage := range report.Stages {
+		var status string
+		switch stage.Status {
+		case AcceptPass:
+			status = fmt.Sprintf("%sPASS%s", consts.Green, consts.Reset)
+		case AcceptFail:
+			status = fmt.Sprintf("%sFAIL%s", consts.Red, consts.Reset)
+		default:
+			status = fmt.Sprintf("%sSKIP%s (%s)", consts.Yellow, consts.Reset, stage.Reason)
+		}
+		printer.Printf(" - %-10s %s %8.1fs\n", stage.Name, status, stage.DurationSeconds)
+	}
+	verdict := fmt.Sprintf("%sPASS%s", consts.Green, consts.Reset)
+	if report.Verdict == AcceptFail {
+		verdict = fmt.Sprintf("%sFAIL%s", consts.Red, consts.Reset)
+	}
+	printer.Printf("Acceptance verdict of %s: %s in %.1fs (fail-on: %s)\n", report.Node, verdict, report.DurationSeconds, report.FailOn)
+}
+
+// WriteAcceptReport writes the report as JSON to file, or to stdout for "-".
+func WriteAcceptReport(report *AcceptReport, file string) error {
+	if file == "-" {
+		return writeAcceptReportTo(report, os.Stdout)
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal acceptance report: %w", err)
+	}
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		return fmt.Errorf("write acceptance report %s: %w", file, err)
+	}
+	return nil
+}
+
+func writeAcceptReportTo(report *AcceptReport, w io.Writer) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal acceptance report: %w", err)
+	}
+	if _, err := fmt.Fprintln(w, string(data)); err != nil {
+		return fmt.Errorf("write acceptance report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/scitix/sichek/consts"
+)
+
+func TestRunAcceptStages(t *testing.T) {
+	oldStatuses, oldLevels, oldFailOn := ComponentStatuses, ComponentLevels, FailOnLevel
+	defer func() { ComponentStatuses, ComponentLevels, FailOnLevel = oldStatuses, oldLevels, oldFailOn }()
+	ComponentStatuses, ComponentLevels, FailOnLevel = map[string]bool{}, map[string]string{}, consts.LevelCritical
+
+	noSkip := func() string { return "" }
+	stages := []*acceptStage{
+		{name: AcceptStageHardware, skip: noSkip, run: func() {
+			SetComponentStatus("cpu", true, consts.LevelInfo)
+			SetComponentStatus("nvidia", false, consts.LevelWarning)
+		}},
+		{name: AcceptStageIBPerf, skip: func() string { return "no IB device found" }, run: func() {
+			t.Errorf("skipped stage must not run")
+		}},
+		{name: AcceptStageNccl, skip: noSkip, run: func() {}},
+		{name: AcceptStageGpuBurn, skip: noSkip, run: func() {
+			SetComponentStatus(GpuBurnTestName, false, consts.LevelCritical)
+		}},
+		{name: AcceptStagePcieTopo, skip: noSkip, run: func() {
+			t.Errorf("stage after a failure must not run with fail-fast")
+		}},
+	}
+	report := RunAcceptStages(stages, true)
+
+	want := []string{AcceptPass, AcceptSkipped, AcceptSkipped, AcceptFail, AcceptSkipped}
+	for i, stage := range report.Stages {
+		if stage.Status != want[i] {
+			t.Errorf("stage %s: status = %s, want %s (%s)", stage.Name, stage.Status, want[i], stage.Reason)
+		}
+	}
+	if report.Verdict != AcceptFail {
+		t.Errorf("verdict = %s, want %s", report.Verdict, AcceptFail)
+	}
+	// the warning of the hardware stage is reported with its level but does not block
+	if got := report.Stages[0].Items["nvidia"]; got != consts.LevelWarning {
+		t.Errorf("nvidia item = %q, want %q", got, consts.LevelWarning)
+	}
+	if len(ComponentStatuses) != 3 {
+		t.Errorf("expected every stage item in ComponentStatuses, got %v", ComponentStatuses)
+	}
+
+	if err := WriteAcceptReport(report, filepath.Join(t.TempDir(), "accept.json")); err != nil {
+		t.Errorf("WriteAcceptReport() error = %v", err)
+	}
+}
@@ -17,55 +17,71 @@ package component
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"slices"
 	"strings"
-	"sync"
+	"time"
 
 	"github.com/scitix/sichek/cmd/command/spec"
+	"github.com/scitix/sichek/components/amd"
+	"github.com/scitix/sichek/components/bmc"
 	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/containerruntime"
+	crcollector "github.com/scitix/sichek/components/containerruntime/collector"
 	"github.com/scitix/sichek/components/cpu"
 	"github.com/scitix/sichek/components/dmesg"
 	"github.com/scitix/sichek/components/ethernet"
 	"github.com/scitix/sichek/components/gpfs"
 	gpuevents "github.com/scitix/sichek/components/gpuevents"
 	"github.com/scitix/sichek/components/infiniband"
+	"github.com/scitix/sichek/components/inventory"
 	"github.com/scitix/sichek/components/lldp"
+	"github.com/scitix/sichek/components/ncclenv"
 	"github.com/scitix/sichek/components/nvidia"
-	"github.com/scitix/sichek/components/pcie/topotest"
+	"github.com/scitix/sichek/components/pcie"
+	"github.com/scitix/sichek/components/pcietopo"
+	"github.com/scitix/sichek/components/plugin"
 	"github.com/scitix/sichek/components/podlog"
+	"github.com/scitix/sichek/components/storage"
 	"github.com/scitix/sichek/components/syslog"
 	"github.com/scitix/sichek/components/transceiver"
 	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/capability"
+	"github.com/scitix/sichek/pkg/printer"
 	"github.com/scitix/sichek/pkg/utils"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
 // NewAllCmd creates a new cobra.Command for performing health checks on all components.
-// It sets up the command with a context that times out after AllCmdTimeout, and defines the
-// command's usage, short description, and long description. The command iterates over
-// a list of default components, performs health checks on each, and prints the results.
+// It sets up the command with a context that times out after --timeout, and defines the
+// command's usage, short description, and long description. The command creates and checks
+// the selected components, at most --parallel at a time, and prints the results.
 // Flags:
 // - verbos: Enable verbose output (default: false)
 // - eventonly: Print events output only (default: false)
+// - components / skip-components: Select the components to check
+// - parallel: Components created and checked at the same time (default: AllCmdParallel)
+// - timeout: Bound of the whole health check (default: AllCmdTimeout)
 func NewAllCmd() *cobra.Command {
 	var (
 		cfgFile          string
 		specFile         string
 		enableComponents string
 		ignoreComponents string
+		skipComponents   string
 		ignoredCheckers  string
 		verbos           bool
 		eventonly        bool
+		startupReport    bool
+		parallel         int
+		timeout          time.Duration
 	)
 	allCmd := &cobra.Command{
 		Use:   "all",
 		Short: "Perform all components check",
 		Run: func(cmd *cobra.Command, args []string) {
-			ctx, cancel := context.WithTimeout(context.Background(), consts.AllCmdTimeout)
-			defer cancel()
-
 			if !verbos {
 				logrus.SetLevel(logrus.ErrorLevel)
 			}
@@ -88,35 +104,31 @@ func NewAllCmd() *cobra.Command {
 				ignoredCheckersList = strings.Split(ignoredCheckers, ",")
 			}
 
-			componentsToCheck := DetermineComponentsToCheck(enableComponents, ignoreComponents, resolvedCfgFile, "all")
-			checkResults := make([]*CheckResults, len(componentsToCheck))
-			var wg sync.WaitGroup
-			for idx, componentName := range componentsToCheck {
-				if componentName == consts.ComponentNameInfiniband && !utils.IsInfinibandExist() {
-					continue
-				}
-				if !slices.Contains(consts.DefaultComponents, componentName) {
-					continue
-				}
-				wg.Add(1)
-				go func(idx int, componentName string) {
-					defer wg.Done()
-					component, err := NewComponent(componentName, resolvedCfgFile, resolvedSpecFile, ignoredCheckersList)
-					if err != nil {
-						logrus.WithField("component", componentName).Errorf("failed to create component: %v", err)
-						return
-					}
-					checkResults[idx], _ = RunComponentCheck(ctx, component, consts.AllCmdTimeout)
-				}(idx, componentName)
+			componentsToCheck := SkipComponents(DetermineComponentsToCheck(enableComponents, ignoreComponents, resolvedCfgFile, "all"), skipComponents)
+			if unknown := UnknownComponents(resolvedCfgFile, strings.Split(enableComponents+","+skipComponents, ",")); len(unknown) > 0 {
+				printer.Warnf("%sunknown components %s%s\n", consts.Red, strings.Join(unknown, ","), consts.Reset)
+			}
 
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			checkResults, errs, report := RunComponentChecksWithReport(ctx, componentsToCheck, resolvedCfgFile, resolvedSpecFile, ignoredCheckersList, parallel)
+			if startupReport {
+				report.Print(printer.Default())
 			}
-			wg.Wait()
 			for _, checkResult := range checkResults {
 				if checkResult == nil {
 					continue
 				}
 				PrintCheckResults(!eventonly, checkResult)
 			}
+			// a component cut by the timeout fails the run, it would otherwise
+			// be left out of the Summary
+			for name, err := range errs {
+				if errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil {
+					printer.Warnf("%s%s check did not complete within %s: %v%s\n", consts.Red, name, timeout, err, consts.Reset)
+					SetComponentStatus(name, false, "")
+				}
+			}
 
 			if utils.IsNvidiaGPUExist() {
 
@@ -125,20 +137,10 @@ func NewAllCmd() *cobra.Command {
 					ncclCmd := NewNcclPerftestCmd()
 					args := []string{"--begin", "2g", "--end", "2g"}
 					ncclCmd.SetArgs(args)
-					fmt.Printf("Running NCCL performance test with args: %v\n", args)
+					printer.Printf("Running NCCL performance test with args: %v\n", args)
 					if err := ncclCmd.Execute(); err != nil {
-						fmt.Printf("failed to run NCCL test: %v\n", err)
-					}
-				}
-				// check pcie topology
-				if slices.Contains(componentsToCheck, "pcie_topo") {
-					res, err := topotest.CheckGPUTopology(resolvedSpecFile)
-					if err != nil {
-						logrus.WithField("component", "pcie_topo").Errorf("check pcie_topo err: %v", err)
-						return
+						printer.Printf("failed to run NCCL test: %v\n", err)
 					}
-					passed := topotest.PrintInfo(res, !eventonly && verbos)
-					ComponentStatuses[res.Item] = passed
 				}
 			}
 		},
@@ -146,10 +148,15 @@ func NewAllCmd() *cobra.Command {
 
 	allCmd.Flags().BoolVarP(&verbos, "verbos", "v", false, "Enable verbose output")
 	allCmd.Flags().BoolVarP(&eventonly, "eventonly", "e", false, "Print events output only")
+	allCmd.Flags().BoolVar(&startupReport, "startup-report", false, "Print how long each component took to initialize and why any was skipped")
 	allCmd.Flags().StringVarP(&cfgFile, "cfg", "c", "", "Path to the user config file")
 	allCmd.Flags().StringVarP(&specFile, "spec", "s", "", "Path to the sichek specification file")
 	allCmd.Flags().StringVarP(&enableComponents, "enable-components", "E", "", "Enabled components, joined by ','")
+	allCmd.Flags().StringVar(&enableComponents, "components", "", "Components to check, joined by ',', same as --enable-components")
 	allCmd.Flags().StringVarP(&ignoreComponents, "ignore-components", "I", "podlog,gpuevents,syslog", "Ignored components")
+	allCmd.Flags().StringVar(&skipComponents, "skip-components", "", "Components to skip, joined by ',', also applied to --components")
+	allCmd.Flags().IntVar(&parallel, "parallel", consts.AllCmdParallel, "Components created and checked at the same time")
+	allCmd.Flags().DurationVar(&timeout, "timeout", consts.AllCmdTimeout, "Bound of the whole health check")
 	allCmd.Flags().StringVarP(&ignoredCheckers, "ignored-checkers", "i", "", "Ignored checkers")
 
 	return allCmd
@@ -164,11 +171,14 @@ func NewComponent(componentName string, cfgFile string, specFile string, ignored
 	case consts.ComponentNameInfiniband:
 		return infiniband.NewInfinibandComponent(cfgFile, specFile, ignoredCheckers)
 	case consts.ComponentNameDmesg:
+		if missing := capability.Detect().Missing(capability.Kmsg); len(missing) > 0 {
+			return nil, fmt.Errorf("%w: skipped: requires %s. Bypassing Dmesg HealthCheck", ErrComponentNotSupported, capability.Requirement(missing))
+		}
 		// if skipPercent is -1, use the value from the config file (default: 100)
 		return dmesg.NewComponent(cfgFile, specFile, -1)
 	case consts.ComponentNameGpuEvents:
 		if !utils.IsNvidiaGPUExist() {
-			return nil, fmt.Errorf("nvidia GPU is not Exist. Bypassing GpuEvents HealthCheck")
+			return nil, fmt.Errorf("%w: nvidia GPU is not Exist. Bypassing GpuEvents HealthCheck", ErrComponentNotSupported)
 		}
 		_, err := nvidia.NewComponent(cfgFile, specFile, ignoredCheckers)
 		if err != nil {
@@ -177,12 +187,24 @@ func NewComponent(componentName string, cfgFile string, specFile string, ignored
 		return gpuevents.NewComponent(cfgFile, specFile)
 	case consts.ComponentNameNvidia:
 		if !utils.IsNvidiaGPUExist() {
-			return nil, fmt.Errorf("nvidia GPU is not Exist. Bypassing Nvidia GPU HealthCheck")
+			return nil, fmt.Errorf("%w: nvidia GPU is not Exist. Bypassing Nvidia GPU HealthCheck", ErrComponentNotSupported)
 		}
 		return nvidia.NewComponent(cfgFile, specFile, ignoredCheckers)
+	case consts.ComponentNameAmd:
+		if !utils.IsAmdGPUExist() {
+			return nil, fmt.Errorf("%w: AMD GPU is not Exist. Bypassing AMD GPU HealthCheck", ErrComponentNotSupported)
+		}
+		return amd.NewComponent(cfgFile, specFile, ignoredCheckers)
+	case consts.ComponentNameBMC:
+		if !utils.IsIPMIExist() {
+			return nil, fmt.Errorf("%w: IPMI device is not Exist. Bypassing BMC HealthCheck", ErrComponentNotSupported)
+		}
+		return bmc.NewComponent(cfgFile, specFile, ignoredCheckers)
+	case consts.ComponentNameStorage:
+		return storage.NewComponent(cfgFile, specFile, ignoredCheckers)
 	case consts.ComponentNamePodlog:
 		if !utils.IsNvidiaGPUExist() {
-			return nil, fmt.Errorf("nvidia GPU is not Exist. Bypassing PodLog HealthCheck")
+			return nil, fmt.Errorf("%w: nvidia GPU is not Exist. Bypassing PodLog HealthCheck", ErrComponentNotSupported)
 		}
 		// if skipPercent is -1, use the value from the config file (default: 100)
 		return podlog.NewComponent(cfgFile, specFile, true, -1) // default to only check running pods
@@ -195,7 +217,26 @@ func NewComponent(componentName string, cfgFile string, specFile string, ignored
 		return transceiver.NewComponent(cfgFile, specFile, ignoredCheckers)
 	case consts.ComponentNameLLDP:
 		return lldp.NewComponent(cfgFile, specFile)
+	case consts.ComponentNameInventory:
+		return inventory.NewComponent(cfgFile, specFile)
+	case consts.ComponentNameNCCLEnv:
+		return ncclenv.NewComponent(cfgFile, specFile, ignoredCheckers)
+	case consts.ComponentNameContainerRuntime:
+		if !crcollector.RuntimeExist() {
+			return nil, fmt.Errorf("%w: neither containerd nor docker is running. Bypassing Container Runtime HealthCheck", ErrComponentNotSupported)
+		}
+		return containerruntime.NewComponent(cfgFile, specFile, ignoredCheckers)
+	case consts.ComponentNamePCIE:
+		return pcie.NewComponent(cfgFile, specFile, ignoredCheckers)
+	case consts.ComponentNamePcieTopo:
+		if !utils.IsNvidiaGPUExist() {
+			return nil, fmt.Errorf("%w: nvidia GPU is not Exist. Bypassing PCIe Topology HealthCheck", ErrComponentNotSupported)
+		}
+		return pcietopo.NewComponent(cfgFile, specFile, ignoredCheckers)
 	default:
+		if plugin.IsPlugin(cfgFile, componentName) {
+			return plugin.NewComponent(componentName, cfgFile, specFile)
+		}
 		return nil, fmt.Errorf("invalid component name: %s", componentName)
 	}
 }
@@ -17,6 +17,7 @@ package component
 
 import (
 	"context"
+	"errors"
 	"path/filepath"
 	"slices"
 	"strings"
@@ -24,28 +25,53 @@ import (
 	"time"
 
 	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/common/remediator"
+	"github.com/scitix/sichek/components/plugin"
+	pluginconfig "github.com/scitix/sichek/components/plugin/config"
 	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/scitix/sichek/pkg/silence"
 
 	"github.com/sirupsen/logrus"
 )
 
 var (
-	ComponentStatuses = make(map[string]bool) // Tracks pass/fail status for each component
-	StatusMutex       sync.Mutex              // Ensures thread-safe updates
+	ComponentStatuses  = make(map[string]bool)           // Tracks pass/fail status for each component
+	ComponentLevels    = make(map[string]string)         // Tracks the aggregated result level for each component
+	ComponentDurations = make(map[string]time.Duration)  // Tracks how long the health check of each component took
+	ComponentResults   = make(map[string]*common.Result) // Tracks the result of each component for the JSON report
+	StatusMutex        sync.Mutex                        // Ensures thread-safe updates
 )
 
+// ErrComponentNotSupported is returned by NewComponent when the hardware the
+// component checks is absent on this node, e.g. the nvidia component on a
+// CPU-only node, or when sichek lacks the privileges the whole component
+// needs, e.g. reading /dev/kmsg rootless. It is not a failure of the node.
+var ErrComponentNotSupported = errors.New("component not supported on this node")
+
 type CheckResults struct {
 	component common.Component
 	result    *common.Result
 	info      common.Info
+	duration  time.Duration
 }
 
+// SilencePath is the silences file applied to the results, the root command
+// sets it from the user config.
+var SilencePath = consts.DefaultSilencePath
+
 func RunComponentCheck(ctx context.Context, comp common.Component, timeout time.Duration) (*CheckResults, error) {
+	start := time.Now()
+	printer.Progressf("[%s] checking\n", comp.Name())
 	result, err := common.RunHealthCheckWithTimeout(ctx, timeout, comp.Name(), comp.HealthCheck)
 	if err != nil {
+		printer.Progressf("[%s] failed after %s: %v\n", comp.Name(), time.Since(start).Round(time.Millisecond), err)
 		logrus.WithField("component", comp.Name()).Error(err) // Updated to use comp.Name()
 		return nil, err
 	}
+	printer.Progressf("[%s] %s (%s) in %s\n", comp.Name(), result.Status, result.Level, time.Since(start).Round(time.Millisecond))
+	result, _ = remediator.Default().Remediate(ctx, result)
+	result = silence.NewStore(SilencePath).Apply(result)
 
 	info, err := comp.LastInfo()
 	if err != nil && (comp.Name() != consts.ComponentNameSyslog && comp.Name() != consts.ComponentNamePodlog) {
@@ -56,14 +82,96 @@ func RunComponentCheck(ctx context.Context, comp common.Component, timeout time.
 		component: comp,
 		result:    result,
 		info:      info,
+		duration:  time.Since(start),
 	}, nil
 }
 
+// RunComponentChecks creates the given components and runs their health checks concurrently.
+// Components that are not supported on this node (see ErrComponentNotSupported) are skipped,
+// while components that fail to be created or checked are returned in the error map keyed
+// by component name.
+// The returned results keep the order of componentsToCheck; skipped or failed entries are nil.
+func RunComponentChecks(ctx context.Context, componentsToCheck []string, cfgFile string, specFile string, ignoredCheckers []string) ([]*CheckResults, map[string]error) {
+	checkResults, errs, _ := RunComponentChecksWithReport(ctx, componentsToCheck, cfgFile, specFile, ignoredCheckers, 0)
+	return checkResults, errs
+}
+
+// RunComponentChecksWithReport is RunComponentChecks also returning how the
+// creation of each component went, see InitComponents. At most parallel
+// components are created and checked at the same time; 0 keeps the default
+// creation parallelism and checks all components at once. Each check is
+// bounded by the deadline of ctx, or by AllCmdTimeout without one.
+func RunComponentChecksWithReport(ctx context.Context, componentsToCheck []string, cfgFile string, specFile string, ignoredCheckers []string, parallel int) ([]*CheckResults, map[string]error, *StartupReport) {
+	components, report := InitComponents(ctx, componentsToCheck, cfgFile, specFile, ignoredCheckers, parallel, consts.ComponentInitTimeout)
+	checkResults := make([]*CheckResults, len(componentsToCheck))
+	errs := report.Errors()
+	timeout := consts.AllCmdTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	checkers := parallel
+	if checkers <= 0 {
+		checkers = len(componentsToCheck)
+	}
+	sem := make(chan struct{}, max(checkers, 1))
+	var errMtx sync.Mutex
+	var wg sync.WaitGroup
+	for idx, componentName := range componentsToCheck {
+		component, ok := components[componentName]
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func(idx int, componentName string, component common.Component) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			var err error
+			checkResults[idx], err = RunComponentCheck(ctx, component, timeout)
+			if err != nil {
+				errMtx.Lock()
+				errs[componentName] = err
+				errMtx.Unlock()
+			}
+		}(idx, componentName, component)
+	}
+	wg.Wait()
+	return checkResults, errs, report
+}
+
 func PrintCheckResults(summaryPrint bool, checkResult *CheckResults) {
 	passed := checkResult.component.PrintInfo(checkResult.info, checkResult.result, summaryPrint)
-	StatusMutex.Lock()
-	ComponentStatuses[checkResult.component.Name()] = passed
-	StatusMutex.Unlock()
+	// the components print silenced checkers as failed, they do not fail the run
+	passed = passed || silence.IsSilenced(checkResult.result)
+	printSkippedCheckers(checkResult.result)
+	SetComponentStatus(checkResult.component.Name(), passed, checkResult.result.Level)
+	SetComponentDurations(checkResult.component.Name(), checkResult.duration)
+	SetComponentResult(checkResult.component.Name(), checkResult.result)
+}
+
+// printSkippedCheckers lists the checkers not run for lack of privileges, the
+// components do not print them as they are neither passed nor failed.
+func printSkippedCheckers(result *common.Result) {
+	if result == nil {
+		return
+	}
+	printed := false
+	for _, checker := range result.Checkers {
+		if checker == nil || checker.Status != consts.StatusSkipped {
+			continue
+		}
+		if !printed {
+			printer.Printf("\nSkipped Checkers:\n")
+			printed = true
+		}
+		printer.Printf("\t%s%s%s -> %s\n", consts.Yellow, checker.Name, consts.Reset, checker.Detail)
+	}
+}
+
+// IsKnownComponent reports whether name is a built-in component or a plugin
+// of the user config.
+func IsKnownComponent(cfgFile string, name string) bool {
+	return slices.Contains(consts.DefaultComponents, name) || plugin.IsPlugin(cfgFile, name)
 }
 
 // GetComponentsFromConfig extracts component names from default_user_config.yaml.
@@ -126,7 +234,8 @@ func DetermineComponentsToCheck(enableComponents string, ignoredComponents strin
 		if len(ignoredComponents) > 0 {
 			ignoredComponentsList = strings.Split(ignoredComponents, ",")
 		}
-		for _, comp := range configComponents {
+		// the plugins are a list in the config, they are enabled once configured
+		for _, comp := range append(configComponents, pluginconfig.Names(cfgFile)...) {
 			if !slices.Contains(ignoredComponentsList, comp) {
 				componentsToCheck = append(componentsToCheck, comp)
 			}
@@ -135,3 +244,32 @@ func DetermineComponentsToCheck(enableComponents string, ignoredComponents strin
 	}
 	return componentsToCheck
 }
+
+// SkipComponents removes the comma separated components of skip from
+// components, keeping their order.
+func SkipComponents(components []string, skip string) []string {
+	var skipped []string
+	for _, comp := range strings.Split(skip, ",") {
+		if comp = strings.TrimSpace(comp); comp != "" {
+			skipped = append(skipped, comp)
+		}
+	}
+	if len(skipped) == 0 {
+		return components
+	}
+	return slices.DeleteFunc(slices.Clone(components), func(comp string) bool {
+		return slices.Contains(skipped, comp)
+	})
+}
+
+// UnknownComponents returns the non empty names that are neither a built-in
+// component nor a plugin of the user config.
+func UnknownComponents(cfgFile string, names []string) []string {
+	var unknown []string
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" && !IsKnownComponent(cfgFile, name) {
+			unknown = append(unknown, name)
+		}
+	}
+	return unknown
+}
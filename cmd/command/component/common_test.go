@@ -433,3 +433,52 @@ func TestDetermineComponentsToCheck_EdgeCases(t *testing.T) {
 		}
 	}
 }
+
+func TestDetermineComponentsToCheck_Plugins(t *testing.T) {
+	cfgFile := filepath.Join(t.TempDir(), "test_config.yaml")
+	configData := `
+cpu:
+  query_interval: 10s
+
+plugins:
+  - name: license_server
+    command: /opt/site/check-license
+  - name: fabric
+    command: /opt/site/check-fabric
+  - name: cpu
+    command: /opt/site/check-cpu
+`
+	if err := os.WriteFile(cfgFile, []byte(configData), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	got := DetermineComponentsToCheck("", "fabric", cfgFile, "test")
+	slices.Sort(got)
+	if want := []string{"cpu", "license_server"}; !slices.Equal(got, want) {
+		t.Errorf("DetermineComponentsToCheck() = %v, want %v", got, want)
+	}
+	if !IsKnownComponent(cfgFile, "license_server") || !IsKnownComponent(cfgFile, "cpu") {
+		t.Error("IsKnownComponent() = false for a plugin or built-in component")
+	}
+	if IsKnownComponent(cfgFile, "nccltest") {
+		t.Error("IsKnownComponent() = true for a config section that is not a component")
+	}
+}
+
+func TestSkipComponents(t *testing.T) {
+	components := []string{consts.ComponentNameCPU, consts.ComponentNameNvidia, consts.ComponentNameInfiniband}
+	if got := SkipComponents(components, ""); !slices.Equal(got, components) {
+		t.Errorf("SkipComponents() without skip = %v, want %v", got, components)
+	}
+	got := SkipComponents(components, " nvidia ,no-such-component")
+	if want := []string{consts.ComponentNameCPU, consts.ComponentNameInfiniband}; !slices.Equal(got, want) {
+		t.Errorf("SkipComponents() = %v, want %v", got, want)
+	}
+	if len(components) != 3 {
+		t.Errorf("SkipComponents() modified its input: %v", components)
+	}
+
+	if got := UnknownComponents("", []string{"cpu", "", " no-such-component"}); !slices.Equal(got, []string{"no-such-component"}) {
+		t.Errorf("UnknownComponents() = %v", got)
+	}
+}
@@ -0,0 +1,331 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/scitix/sichek/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	CudaTestName         = "CudaTest"
+	defaultCudaSanityBin = "cuda_sanity"
+)
+
+// CudaTestGPU is a GPU enumerated by NVML, the one cuda_sanity must reach.
+type CudaTestGPU struct {
+	Index int
+	UUID  string
+	BDF   string
+}
+
+// CudaTestRun is the outcome of cuda_sanity on a GPU, parsed from its RESULT
+// line, e.g.
+//
+//	RESULT device=0 bdf=0000:18:00.0 status=ok launch_ms=35.2 matmul_ms=1.3 h2d_GBps=11.8 d2h_GBps=12.4 max_err=0
+type CudaTestRun struct {
+	GPU      CudaTestGPU
+	OK       bool
+	Stage    string
+	Error    string
+	LaunchMs float64
+	MatmulMs float64
+	H2DGBps  float64
+	D2HGBps  float64
+	Elapsed  time.Duration
+	TimedOut bool
+}
+
+func NewCudaTestCmd() *cobra.Command {
+	cudaTestCmd := &cobra.Command{
+		Use:   "cudatest",
+		Short: "Launch a small matmul and memcpy on every GPU to verify the CUDA runtime works",
+		Run: func(cmd *cobra.Command, args []string) {
+			verbose, err := cmd.Flags().GetBool("verbose")
+			if err != nil {
+				logrus.WithField("cudatest", "nvidia").Errorf("get to ge the verbose: %v", err)
+			}
+			if !verbose {
+				logrus.SetLevel(logrus.ErrorLevel)
+			}
+			if !utils.IsNvidiaGPUExist() {
+				logrus.Warn("nvidia GPU is not Exist. Bypassing CUDA sanity test")
+				return
+			}
+			binPath, err := cmd.Flags().GetString("bin")
+			if err != nil {
+				logrus.WithField("cudatest", "nvidia").Error(err)
+				return
+			}
+			size, err := cmd.Flags().GetInt("size")
+			if err != nil {
+				logrus.WithField("cudatest", "nvidia").Error(err)
+				return
+			}
+			timeout, err := cmd.Flags().GetDuration("timeout")
+			if err != nil {
+				logrus.WithField("cudatest", "nvidia").Error(err)
+				return
+			}
+
+			printer.Printf("Running CUDA sanity test, %dx%d matmul on each GPU within %s\n", size, size, timeout)
+			res, err := CheckCudaSanity(binPath, size, timeout)
+			if err != nil {
+				logrus.WithField("cudatest", "nvidia").Error(err)
+				printer.Printf("%s%v%s\n", consts.Red, err, consts.Reset)
+				SetComponentStatus(CudaTestName, false, "")
+				return
+			}
+			passed := PrintCudaTestInfo(res)
+			SetComponentStatus(res.Item, passed, res.Level)
+			for _, checkerResult := range res.Checkers {
+				if checkerResult.Status == consts.StatusAbnormal && checkerResult.Device != "" {
+					SetComponentStatus(fmt.Sprintf("%s %s", res.Item, checkerResult.Device), false, checkerResult.Level)
+				}
+			}
+		},
+	}
+
+	cudaTestCmd.Flags().String("bin", "", "Path to the cuda_sanity binary (default: cuda_sanity in PATH or sichek scripts dir)")
+	cudaTestCmd.Flags().IntP("size", "n", 512, "Size of the square matrices multiplied on each GPU")
+	cudaTestCmd.Flags().DurationP("timeout", "t", time.Minute, "Time bound of the test on each GPU")
+	cudaTestCmd.Flags().BoolP("verbose", "v", false, "Enable verbose output")
+
+	return cudaTestCmd
+}
+
+func resolveCudaSanityPath(binPath string) (string, error) {
+	if binPath != "" {
+		return binPath, nil
+	}
+	if path, err := exec.LookPath(defaultCudaSanityBin); err == nil {
+		return path, nil
+	}
+	return GetDefaultNcclTestPath(defaultCudaSanityBin)
+}
+
+// listCudaTestGPUs enumerates the GPUs with NVML.
+func listCudaTestGPUs() ([]CudaTestGPU, error) {
+	nvmlInst := nvml.New()
+	if ret := nvmlInst.Init(); !errors.Is(ret, nvml.SUCCESS) {
+		return nil, fmt.Errorf("failed to initialize NVML: %v", nvml.ErrorString(ret))
+	}
+	defer nvmlInst.Shutdown()
+	deviceCount, ret := nvmlInst.DeviceGetCount()
+	if !errors.Is(ret, nvml.SUCCESS) {
+		return nil, fmt.Errorf("failed to get device count: %s", nvml.ErrorString(ret))
+	}
+	gpus := make([]CudaTestGPU, 0, deviceCount)
+	for i := 0; i < deviceCount; i++ {
+		device, ret := nvmlInst.DeviceGetHandleByIndex(i)
+		if !errors.Is(ret, nvml.SUCCESS) {
+			return nil, fmt.Errorf("failed to get device %d: %s", i, nvml.ErrorString(ret))
+		}
+		uuid, ret := device.GetUUID()
+		if !errors.Is(ret, nvml.SUCCESS) {
+			return nil, fmt.Errorf("failed to get UUID of device %d: %s", i, nvml.ErrorString(ret))
+		}
+		gpu := CudaTestGPU{Index: i, UUID: uuid}
+		if pciInfo, ret := device.GetPciInfo(); errors.Is(ret, nvml.SUCCESS) {
+			gpu.BDF = fmt.Sprintf("%04x:%02x:%02x.0", pciInfo.Domain, pciInfo.Bus, pciInfo.Device)
+		}
+		gpus = append(gpus, gpu)
+	}
+	return gpus, nil
+}
+
+// CheckCudaSanity runs cuda_sanity on every GPU enumerated by NVML, one
+// process per GPU so that a GPU hanging at its first kernel launch does not
+// hold up the others.
+func CheckCudaSanity(binPath string, size int, timeout time.Duration) (*common.Result, error) {
+	path, err := resolveCudaSanityPath(binPath)
+	if err != nil {
+		return nil, fmt.Errorf("resolve cuda_sanity path failed: %w", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("cuda_sanity not found: %w", err)
+	}
+	gpus, err := listCudaTestGPUs()
+	if err != nil {
+		return nil, err
+	}
+	if len(gpus) == 0 {
+		return nil, fmt.Errorf("no GPU enumerated by NVML")
+	}
+	runs := make([]CudaTestRun, len(gpus))
+	var wg sync.WaitGroup
+	for i, gpu := range gpus {
+		wg.Add(1)
+		go func(i int, gpu CudaTestGPU) {
+			defer wg.Done()
+			runs[i] = runCudaSanity(path, gpu, size, timeout)
+		}(i, gpu)
+	}
+	wg.Wait()
+	return checkCudaTestRuns(runs, timeout), nil
+}
+
+// runCudaSanity runs cuda_sanity on a single GPU, selected by its UUID.
+func runCudaSanity(path string, gpu CudaTestGPU, size int, timeout time.Duration) CudaTestRun {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path, "-n", strconv.Itoa(size))
+	cmd.Env = append(os.Environ(), "CUDA_VISIBLE_DEVICES="+gpu.UUID)
+	logrus.WithField("cudatest", "nvidia").Infof("Command: CUDA_VISIBLE_DEVICES=%s %s", gpu.UUID, cmd.String())
+	start := time.Now()
+	output, err := cmd.CombinedOutput()
+	run := CudaTestRun{GPU: gpu, Elapsed: time.Since(start)}
+	logrus.WithField("cudatest", "nvidia").Infof("GPU%d output: %s", gpu.Index, string(output))
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		// cuda_sanity prints nothing before its result, the GPU hung in the
+		// CUDA init, a kernel or a copy
+		run.TimedOut = true
+		run.Error = fmt.Sprintf("did not complete within %s", timeout)
+		return run
+	}
+	parsed, ok := parseCudaSanityOutput(string(output))
+	if !ok {
+		run.Stage = "init"
+		run.Error = fmt.Sprintf("no result printed: %v, output: %s", err, strings.TrimSpace(string(output)))
+		return run
+	}
+	parsed.GPU, parsed.Elapsed = gpu, run.Elapsed
+	if parsed.OK && err != nil {
+		parsed.OK = false
+		parsed.Stage = "exit"
+		parsed.Error = err.Error()
+	}
+	return parsed
+}
+
+// parseCudaSanityOutput parses the RESULT line of cuda_sanity run on a single GPU.
+func parseCudaSanityOutput(output string) (CudaTestRun, bool) {
+	var run CudaTestRun
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "RESULT ") {
+			continue
+		}
+		// error is the last field and may contain spaces
+		fields, errMsg, _ := strings.Cut(strings.TrimPrefix(line, "RESULT "), " error=")
+		run = CudaTestRun{Error: errMsg}
+		for _, field := range strings.Fields(fields) {
+			key, value, _ := strings.Cut(field, "=")
+			number, _ := strconv.ParseFloat(value, 64)
+			switch key {
+			case "status":
+				run.OK = value == "ok"
+			case "stage":
+				run.Stage = value
+			case "launch_ms":
+				run.LaunchMs = number
+			case "matmul_ms":
+				run.MatmulMs = number
+			case "h2d_GBps":
+				run.H2DGBps = number
+			case "d2h_GBps":
+				run.D2HGBps = number
+			}
+		}
+		return run, true
+	}
+	return run, false
+}
+
+func checkCudaTestRuns(runs []CudaTestRun, timeout time.Duration) *common.Result {
+	res := &common.Result{
+		Item:   CudaTestName,
+		Status: consts.StatusNormal,
+		Level:  consts.LevelInfo,
+		Time:   time.Now(),
+	}
+	var slowestLaunch float64
+	for _, run := range runs {
+		slowestLaunch = max(slowestLaunch, run.LaunchMs)
+		if run.OK {
+			continue
+		}
+		gpu := fmt.Sprintf("GPU%d", run.GPU.Index)
+		curr := "failed at " + run.Stage
+		if run.TimedOut {
+			curr = "timeout"
+		}
+		errorName, suggestion := "CudaTestFailed", "Check the GPU with nvidia-smi -q and the dmesg for xids"
+		switch {
+		case run.TimedOut:
+			errorName, suggestion = "CudaTestTimeout", "Reset the GPU device, replace it if it hangs again"
+		case run.Stage == "verify":
+			errorName, suggestion = "CudaTestWrongResult", "Replace the GPU device"
+		case run.Stage == "init" || run.Stage == "launch":
+			errorName, suggestion = "CudaTestLaunchError", "Reset the GPU device and check the dmesg for xids"
+		}
+		res.Status = consts.StatusAbnormal
+		res.Level = consts.LevelCritical
+		res.Checkers = append(res.Checkers, &common.CheckerResult{
+			Name:        "CudaSanityTest",
+			Description: "CUDA kernel launch, memcpy and matmul on the GPU",
+			Device:      gpu,
+			Spec:        fmt.Sprintf("correct within %s", timeout),
+			Curr:        curr,
+			Status:      consts.StatusAbnormal,
+			Level:       consts.LevelCritical,
+			Detail:      fmt.Sprintf("%s (%s, %s) failed the CUDA sanity test, %s: %s", gpu, run.GPU.BDF, run.GPU.UUID, curr, run.Error),
+			ErrorName:   errorName,
+			Suggestion:  suggestion,
+		})
+	}
+	if res.Status == consts.StatusNormal {
+		res.Checkers = append(res.Checkers, &common.CheckerResult{
+			Name:        "CudaSanityTest",
+			Description: "CUDA kernel launch, memcpy and matmul on the GPU",
+			Spec:        fmt.Sprintf("correct within %s", timeout),
+			Curr:        "OK",
+			Status:      consts.StatusNormal,
+			Level:       consts.LevelInfo,
+			Detail:      fmt.Sprintf("CUDA sanity test passed on %d GPUs, slowest first launch %.1f ms", len(runs), slowestLaunch),
+			ErrorName:   "CudaTestFailed",
+		})
+	}
+	return res
+}
+
+func PrintCudaTestInfo(result *common.Result) bool {
+	for _, checkerResult := range result.Checkers {
+		if checkerResult.Status == consts.StatusAbnormal {
+			printer.Printf("%s%s%s\n", consts.Red, checkerResult.Detail, consts.Reset)
+		} else {
+			printer.Printf("%s%s%s\n", consts.Green, checkerResult.Detail, consts.Reset)
+		}
+	}
+	return result.Status == consts.StatusNormal
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/consts"
+)
+
+func TestParseCudaSanityOutput(t *testing.T) {
+	run, ok := parseCudaSanityOutput("RESULT device=0 bdf=0000:18:00.0 status=ok launch_ms=35.2 matmul_ms=1.3 h2d_GBps=11.8 d2h_GBps=12.4 max_err=0\n")
+	if !ok || !run.OK || run.LaunchMs != 35.2 || run.MatmulMs != 1.3 || run.H2DGBps != 11.8 || run.D2HGBps != 12.4 {
+		t.Errorf("unexpected run %+v", run)
+	}
+	run, ok = parseCudaSanityOutput("some warning\nRESULT device=0 bdf=0000:18:00.0 status=fail stage=launch error=unspecified launch failure\n")
+	if !ok || run.OK || run.Stage != "launch" || run.Error != "unspecified launch failure" {
+		t.Errorf("unexpected run %+v", run)
+	}
+	if _, ok := parseCudaSanityOutput("cuda_sanity: error while loading shared libraries: libcudart.so.12"); ok {
+		t.Error("expected no result")
+	}
+}
+
+func writeFakeCudaSanity(t *testing.T, script string) string {
+	path := filepath.Join(t.TempDir(), "cuda_sanity")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunCudaSanity(t *testing.T) {
+	gpu := CudaTestGPU{Index: 2, UUID: "GPU-2", BDF: "0000:2a:00.0"}
+	cases := []struct {
+		name     string
+		script   string
+		ok       bool
+		stage    string
+		timedOut bool
+	}{
+		{
+			name:   "ok",
+			script: `[ "$CUDA_VISIBLE_DEVICES" = GPU-2 ] && [ "$2" = 64 ] && echo "RESULT device=0 bdf=0000:2a:00.0 status=ok launch_ms=20.0 matmul_ms=0.1 h2d_GBps=10.0 d2h_GBps=10.0 max_err=0"`,
+			ok:     true,
+		},
+		{
+			name:   "wrong result",
+			script: "echo 'RESULT device=0 bdf=0000:2a:00.0 status=fail stage=verify error=max error 3'; exit 1",
+			stage:  "verify",
+		},
+		{
+			name:     "hang",
+			script:   "exec sleep 10",
+			timedOut: true,
+		},
+		{
+			name:   "crash",
+			script: "echo 'Segmentation fault'; exit 139",
+			stage:  "init",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			run := runCudaSanity(writeFakeCudaSanity(t, c.script), gpu, 64, 500*time.Millisecond)
+			if run.OK != c.ok || run.Stage != c.stage || run.TimedOut != c.timedOut || run.GPU != gpu {
+				t.Errorf("unexpected run %+v", run)
+			}
+		})
+	}
+}
+
+func TestCheckCudaTestRuns(t *testing.T) {
+	runs := []CudaTestRun{
+		{GPU: CudaTestGPU{Index: 0}, OK: true, LaunchMs: 30},
+		{GPU: CudaTestGPU{Index: 1}, OK: true, LaunchMs: 45},
+	}
+	res := checkCudaTestRuns(runs, time.Minute)
+	if res.Status != consts.StatusNormal || len(res.Checkers) != 1 {
+		t.Fatalf("expected the test passed, got %+v", res.Checkers[0])
+	}
+
+	runs = append(runs,
+		CudaTestRun{GPU: CudaTestGPU{Index: 2}, TimedOut: true, Error: "did not complete within 1m0s"},
+		CudaTestRun{GPU: CudaTestGPU{Index: 3}, Stage: "launch", Error: "unspecified launch failure"},
+		CudaTestRun{GPU: CudaTestGPU{Index: 4}, Stage: "verify", Error: "max error 3"},
+		CudaTestRun{GPU: CudaTestGPU{Index: 5}, Stage: "memcpy_d2h", Error: "an illegal memory access was encountered"},
+	)
+	res = checkCudaTestRuns(runs, time.Minute)
+	if res.Status != consts.StatusAbnormal || res.Level != consts.LevelCritical {
+		t.Fatalf("expected the test failed, got %s/%s", res.Status, res.Level)
+	}
+	want := map[string]string{
+		"GPU2": "CudaTestTimeout",
+		"GPU3": "CudaTestLaunchError",
+		"GPU4": "CudaTestWrongResult",
+		"GPU5": "CudaTestFailed",
+	}
+	if len(res.Checkers) != len(want) {
+		t.Fatalf("expected %d failed GPUs, got %d", len(want), len(res.Checkers))
+	}
+	for _, checker := range res.Checkers {
+		if want[checker.Device] != checker.ErrorName {
+			t.Errorf("%s: got %s, want %s", checker.Device, checker.ErrorName, want[checker.Device])
+		}
+	}
+}
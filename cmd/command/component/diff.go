@@ -0,0 +1,378 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/scitix/sichek/cmd/command/spec"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultDiffIgnore are the info fields that change between two runs on the
+// same node, e.g. temperatures, utilization and error counters. They would
+// drown the configuration differences diff is meant to surface.
+var DefaultDiffIgnore = []string{
+	"time", "timestamp", "*_time", "uptime",
+	"*temperature*", "*temp_*", "*_dbm", "fans",
+	"*usage*", "*utilization*", "*percent*", "*violation*",
+	"*errors", "*_count", "ib_counters", "*clock_events*",
+	"cur_*", "current_*", "*_state_ts",
+}
+
+// DiffKind tells on which side of the comparison a field differs.
+type DiffKind string
+
+const (
+	DiffChanged       DiffKind = "changed"
+	DiffOnlyLocal     DiffKind = "only-local"
+	DiffOnlyReference DiffKind = "only-reference"
+)
+
+// DiffEntry is a leaf field of a component info that differs between the
+// local node and the reference.
+type DiffEntry struct {
+	Path      string
+	Kind      DiffKind
+	Local     string
+	Reference string
+}
+
+// ComponentDiff holds the differing fields of one component.
+type ComponentDiff struct {
+	Name    string
+	Entries []DiffEntry
+}
+
+// NodeSnapshot is the collected info of each component of a node, decoded
+// from a report written by `sichek export`.
+type NodeSnapshot struct {
+	Node       string
+	Components map[string]interface{}
+}
+
+// NewDiffCmd creates the "diff" command which collects the info of the
+// selected components and compares it field by field against a snapshot of
+// a golden node or the live export of another node.
+func NewDiffCmd() *cobra.Command {
+	var (
+		cfgFile          string
+		specFile         string
+		enableComponents string
+		ignoreComponents string
+		against          string
+		node             string
+		ignoreFields     string
+		allFields        bool
+		verbos           bool
+	)
+	diffCmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Compare the collected node state against a golden snapshot or another node",
+		Long: "Collect the info of the selected components and print the fields that differ from a report\n" +
+			"written by `sichek export` (a local file or URL) or from the export of a remote node run over ssh.\n" +
+			"Create a golden snapshot on a healthy node with `sichek export -o golden.json`.",
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), consts.AllCmdTimeout)
+			defer cancel()
+
+			if !verbos {
+				logrus.SetLevel(logrus.ErrorLevel)
+			}
+			if (against == "") == (node == "") {
+				logrus.WithField("component", "diff").Error("exactly one of --against or --node is required")
+				os.Exit(1)
+			}
+			resolvedCfgFile, err := spec.EnsureCfgFile(cfgFile)
+			if err != nil {
+				logrus.WithField("component", "diff").Errorf("failed to load cfgFile: %v", err)
+			}
+			resolvedSpecFile, err := spec.EnsureSpecFile(specFile)
+			if err != nil {
+				logrus.WithField("component", "diff").Errorf("failed to load specFile: %v", err)
+			}
+
+			componentsToCheck := DetermineComponentsToCheck(enableComponents, ignoreComponents, resolvedCfgFile, "diff")
+			reference, label, err := loadDiffReference(ctx, against, node, componentsToCheck)
+			if err != nil {
+				logrus.WithField("component", "diff").Errorf("failed to load the reference: %v", err)
+				os.Exit(1)
+			}
+
+			checkResults, errs := RunComponentChecks(ctx, componentsToCheck, resolvedCfgFile, resolvedSpecFile, nil)
+			content, err := BuildReport(checkResults, errs).JSON()
+			if err != nil {
+				logrus.WithField("component", "diff").Errorf("failed to marshal report: %v", err)
+				os.Exit(1)
+			}
+			local, err := ParseNodeSnapshot([]byte(content))
+			if err != nil {
+				logrus.WithField("component", "diff").Errorf("failed to decode local report: %v", err)
+				os.Exit(1)
+			}
+
+			var ignore []string
+			if !allFields {
+				ignore = append(ignore, DefaultDiffIgnore...)
+			}
+			if ignoreFields != "" {
+				ignore = append(ignore, strings.Split(ignoreFields, ",")...)
+			}
+			diffs := DiffSnapshots(local, reference, ignore)
+			PrintDiffReport(os.Stdout, local.Node, label, diffs)
+			if len(diffs) > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	diffCmd.Flags().StringVarP(&against, "against", "a", "", "Snapshot to compare with, a `sichek export` JSON/YAML file or URL")
+	diffCmd.Flags().StringVarP(&node, "node", "n", "", "Remote node to compare with, its export is collected over ssh")
+	diffCmd.Flags().StringVar(&ignoreFields, "ignore-fields", "", "Additional info fields to ignore, joined by ','; a name pattern or a dotted path prefix")
+	diffCmd.Flags().BoolVar(&allFields, "all-fields", false, "Also compare the volatile fields ignored by default, e.g. temperatures and counters")
+	diffCmd.Flags().BoolVarP(&verbos, "verbos", "v", false, "Enable verbose output")
+	diffCmd.Flags().StringVarP(&cfgFile, "cfg", "c", "", "Path to the user config file")
+	diffCmd.Flags().StringVarP(&specFile, "spec", "s", "", "Path to the sichek specification file")
+	diffCmd.Flags().StringVarP(&enableComponents, "enable-components", "E", "", "Enabled components, joined by ','")
+	diffCmd.Flags().StringVarP(&ignoreComponents, "ignore-components", "I", "podlog,gpuevents,syslog,dmesg", "Ignored components")
+	return diffCmd
+}
+
+// loadDiffReference reads the report to compare with and returns it with a
+// label naming where it came from.
+func loadDiffReference(ctx context.Context, against, node string, components []string) (*NodeSnapshot, string, error) {
+	var (
+		data  []byte
+		err   error
+		label = against
+	)
+	switch {
+	case node != "":
+		label = node
+		data, err = remoteExport(ctx, node, components)
+	case strings.HasPrefix(against, "http://") || strings.HasPrefix(against, "https://"):
+		data, err = fetchReport(ctx, against)
+	default:
+		data, err = os.ReadFile(against)
+	}
+	if err != nil {
+		return nil, label, err
+	}
+	snapshot, err := ParseNodeSnapshot(data)
+	if err != nil {
+		return nil, label, fmt.Errorf("%s: %w", label, err)
+	}
+	if snapshot.Node != "" && snapshot.Node != label {
+		label = fmt.Sprintf("%s (%s)", snapshot.Node, filepath.Base(label))
+	}
+	return snapshot, label, nil
+}
+
+// remoteExport runs `sichek export` of the same components on node over ssh.
+func remoteExport(ctx context.Context, node string, components []string) ([]byte, error) {
+	args := []string{"-o", "BatchMode=yes", "-o", "ConnectTimeout=10", node,
+		"sichek", "export", "-f", ExportFormatJSON, "-E", strings.Join(components, ",")}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "ssh", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ssh %s sichek export: %v: %s", node, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+func fetchReport(ctx context.Context, url string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("GET %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: status %d", url, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// ParseNodeSnapshot decodes a JSON or YAML report written by `sichek export`.
+// Components that failed on that node are kept with a nil info.
+func ParseNodeSnapshot(data []byte) (*NodeSnapshot, error) {
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid report: %w", err)
+	}
+	var report struct {
+		Node       string `json:"node"`
+		Components []struct {
+			Name string      `json:"name"`
+			Info interface{} `json:"info"`
+		} `json:"components"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(jsonData))
+	dec.UseNumber()
+	if err := dec.Decode(&report); err != nil {
+		return nil, fmt.Errorf("invalid report: %w", err)
+	}
+	snapshot := &NodeSnapshot{Node: report.Node, Components: make(map[string]interface{}, len(report.Components))}
+	for _, c := range report.Components {
+		snapshot.Components[c.Name] = c.Info
+	}
+	return snapshot, nil
+}
+
+// DiffSnapshots compares the info of the components checked locally with the
+// reference. Components the reference has but were not checked locally are
+// skipped, as are the fields matching an ignore pattern.
+func DiffSnapshots(local, reference *NodeSnapshot, ignore []string) []ComponentDiff {
+	names := make([]string, 0, len(local.Components))
+	for name := range local.Components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var diffs []ComponentDiff
+	for _, name := range names {
+		refInfo, ok := reference.Components[name]
+		if !ok {
+			diffs = append(diffs, ComponentDiff{Name: name, Entries: []DiffEntry{{Kind: DiffOnlyLocal, Local: "checked", Reference: "not checked"}}})
+			continue
+		}
+		localFields := make(map[string]string)
+		refFields := make(map[string]string)
+		flattenInfo("", local.Components[name], ignore, localFields)
+		flattenInfo("", refInfo, ignore, refFields)
+
+		var entries []DiffEntry
+		for path, lv := range localFields {
+			rv, ok := refFields[path]
+			switch {
+			case !ok:
+				entries = append(entries, DiffEntry{Path: path, Kind: DiffOnlyLocal, Local: lv})
+			case lv != rv:
+				entries = append(entries, DiffEntry{Path: path, Kind: DiffChanged, Local: lv, Reference: rv})
+			}
+		}
+		for path, rv := range refFields {
+			if _, ok := localFields[path]; !ok {
+				entries = append(entries, DiffEntry{Path: path, Kind: DiffOnlyReference, Reference: rv})
+			}
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+		diffs = append(diffs, ComponentDiff{Name: name, Entries: entries})
+	}
+	return diffs
+}
+
+// flattenInfo records the leaves of v keyed by their dotted path, list
+// elements are addressed by index, e.g. "device_info[3].pcie.link_width".
+func flattenInfo(path string, v interface{}, ignore []string, out map[string]string) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			childPath := k
+			if path != "" {
+				childPath = path + "." + k
+			}
+			if diffIgnored(childPath, k, ignore) {
+				continue
+			}
+			flattenInfo(childPath, child, ignore, out)
+		}
+	case []interface{}:
+		for i, child := range val {
+			flattenInfo(fmt.Sprintf("%s[%d]", path, i), child, ignore, out)
+		}
+	case nil:
+		// absent and null fields are the same
+	default:
+		out[path] = fmt.Sprint(val)
+	}
+}
+
+// diffIgnored matches the key against the name patterns and the path against
+// the dotted path prefixes of ignore.
+func diffIgnored(path, key string, ignore []string) bool {
+	for _, pattern := range ignore {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if strings.Contains(pattern, ".") {
+			if path == pattern || strings.HasPrefix(path, pattern+".") || strings.HasPrefix(path, pattern+"[") {
+				return true
+			}
+			continue
+		}
+		if ok, _ := filepath.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// PrintDiffReport prints the differences per component: changed fields in
+// yellow, fields only the local node has in green and fields only the
+// reference has in red.
+func PrintDiffReport(w io.Writer, localNode, reference string, diffs []ComponentDiff) {
+	fmt.Fprintf(w, "Diff of %s against %s\n", localNode, reference)
+	if len(diffs) == 0 {
+		fmt.Fprintf(w, "%sNo difference%s\n", consts.Green, consts.Reset)
+		return
+	}
+	total := 0
+	for _, d := range diffs {
+		fmt.Fprintf(w, "\n%s%s%s\n", consts.Cyan, d.Name, consts.Reset)
+		for _, e := range d.Entries {
+			total++
+			path := e.Path
+			if path == "" {
+				path = "(component)"
+			}
+			switch e.Kind {
+			case DiffChanged:
+				fmt.Fprintf(w, "  %s~ %s: %s -> %s%s\n", consts.Yellow, path, e.Reference, e.Local, consts.Reset)
+			case DiffOnlyLocal:
+				fmt.Fprintf(w, "  %s+ %s: %s%s\n", consts.Green, path, e.Local, consts.Reset)
+			case DiffOnlyReference:
+				fmt.Fprintf(w, "  %s- %s: %s%s\n", consts.Red, path, e.Reference, consts.Reset)
+			}
+		}
+	}
+	fmt.Fprintf(w, "\n%d differences in %d components (- %s, + %s)\n", total, len(diffs), reference, localNode)
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const goldenReport = `{
+  "node": "golden-node",
+  "components": [
+    {"name": "nvidia", "info": {
+      "time": "2026-01-01T00:00:00Z",
+      "software": {"driver_version": "550.54.15", "cuda_version": "12.4"},
+      "device_info": [
+        {"pcie": {"link_width": 16, "link_gen": 5}, "temperature": {"gpu": 40}},
+        {"pcie": {"link_width": 16, "link_gen": 5}, "temperature": {"gpu": 41}}
+      ]
+    }},
+    {"name": "infiniband", "info": {"hca": {"mlx5_0": {"fw_ver": "28.39.1002"}}}}
+  ]
+}`
+
+func TestParseNodeSnapshot(t *testing.T) {
+	snapshot, err := ParseNodeSnapshot([]byte(goldenReport))
+	require.NoError(t, err)
+	assert.Equal(t, "golden-node", snapshot.Node)
+	assert.Len(t, snapshot.Components, 2)
+
+	yamlReport := "node: n1\ncomponents:\n- name: cpu\n  info:\n    kernel: 5.15.0\n"
+	snapshot, err = ParseNodeSnapshot([]byte(yamlReport))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"kernel": "5.15.0"}, snapshot.Components["cpu"])
+
+	_, err = ParseNodeSnapshot([]byte("components: ["))
+	assert.Error(t, err)
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	reference, err := ParseNodeSnapshot([]byte(goldenReport))
+	require.NoError(t, err)
+	local, err := ParseNodeSnapshot([]byte(`{
+  "node": "slow-node",
+  "components": [
+    {"name": "nvidia", "info": {
+      "time": "2026-02-01T00:00:00Z",
+      "software": {"driver_version": "535.104.05", "cuda_version": "12.4"},
+      "device_info": [
+        {"pcie": {"link_width": 16, "link_gen": 5}, "temperature": {"gpu": 70}},
+        {"pcie": {"link_width": 8, "link_gen": 5}, "temperature": {"gpu": 71}},
+        {"pcie": {"link_width": 16, "link_gen": 5}}
+      ]
+    }},
+    {"name": "cpu", "info": {"kernel": "5.15.0"}}
+  ]
+}`))
+	require.NoError(t, err)
+
+	diffs := DiffSnapshots(local, reference, DefaultDiffIgnore)
+	require.Len(t, diffs, 2)
+
+	assert.Equal(t, "cpu", diffs[0].Name)
+	assert.Equal(t, DiffOnlyLocal, diffs[0].Entries[0].Kind)
+
+	assert.Equal(t, "nvidia", diffs[1].Name)
+	assert.Equal(t, []DiffEntry{
+		{Path: "device_info[1].pcie.link_width", Kind: DiffChanged, Local: "8", Reference: "16"},
+		{Path: "device_info[2].pcie.link_gen", Kind: DiffOnlyLocal, Local: "5"},
+		{Path: "device_info[2].pcie.link_width", Kind: DiffOnlyLocal, Local: "16"},
+		{Path: "software.driver_version", Kind: DiffChanged, Local: "535.104.05", Reference: "550.54.15"},
+	}, diffs[1].Entries)
+
+	// dotted path prefixes ignore whole subtrees
+	diffs = DiffSnapshots(local, reference, append([]string{"device_info", "software.driver_version"}, DefaultDiffIgnore...))
+	require.Len(t, diffs, 1)
+	assert.Equal(t, "cpu", diffs[0].Name)
+
+	// without the default ignores the volatile fields differ too
+	diffs = DiffSnapshots(local, reference, nil)
+	var paths []string
+	for _, e := range diffs[1].Entries {
+		paths = append(paths, e.Path)
+	}
+	assert.Contains(t, paths, "time")
+	assert.Contains(t, paths, "device_info[0].temperature.gpu")
+}
+
+func TestPrintDiffReport(t *testing.T) {
+	var b bytes.Buffer
+	PrintDiffReport(&b, "slow-node", "golden-node", nil)
+	assert.Contains(t, b.String(), "No difference")
+
+	b.Reset()
+	PrintDiffReport(&b, "slow-node", "golden-node", []ComponentDiff{{
+		Name: "nvidia",
+		Entries: []DiffEntry{
+			{Path: "software.driver_version", Kind: DiffChanged, Local: "535.104.05", Reference: "550.54.15"},
+			{Path: "device_info[2].pcie.link_width", Kind: DiffOnlyLocal, Local: "16"},
+		},
+	}})
+	out := b.String()
+	assert.Contains(t, out, "software.driver_version: 550.54.15 -> 535.104.05")
+	assert.Contains(t, out, "+ device_info[2].pcie.link_width: 16")
+	assert.True(t, strings.HasSuffix(out, "2 differences in 1 components (- golden-node, + slow-node)\n"))
+}
@@ -0,0 +1,403 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+// DriftField is a version reported by a component info, located by a dotted
+// path in which "*" matches every key of a map or element of a list.
+type DriftField struct {
+	Name      string
+	Component string
+	Path      string
+}
+
+// DefaultDriftFields are the driver and library versions compared by `sichek drift`.
+var DefaultDriftFields = []DriftField{
+	{Name: "gpu_driver", Component: "nvidia", Path: "software_info.driver_version"},
+	{Name: "cuda", Component: "nvidia", Path: "software_info.cuda_version"},
+	{Name: "ofed", Component: "infiniband", Path: "ib_software_info.ofed_ver"},
+	{Name: "hca_fw", Component: "infiniband", Path: "ib_hardware_info.*.fw_ver"},
+	{Name: "kernel", Component: "cpu", Path: "host_info.kernel_version"},
+}
+
+// NodeVersions are the versions of the drift fields found in the export of a
+// node. A field the export does not report is absent.
+type NodeVersions struct {
+	Node     string            `json:"node"`
+	Versions map[string]string `json:"versions"`
+}
+
+// FieldDrift lists the nodes per version of a field. The majority version is
+// the most common one, the nodes running another version are outliers.
+type FieldDrift struct {
+	Field    string              `json:"field"`
+	Majority string              `json:"majority"`
+	Versions map[string][]string `json:"versions"`
+	Outliers []string            `json:"outliers,omitempty"`
+}
+
+// VersionGroup is a combination of versions and the nodes running it.
+type VersionGroup struct {
+	Versions map[string]string `json:"versions"`
+	Nodes    []string          `json:"nodes"`
+}
+
+// DriftReport is the version drift across the nodes of a fleet snapshot.
+type DriftReport struct {
+	Nodes  int            `json:"nodes"`
+	Fields []FieldDrift   `json:"fields"`
+	Groups []VersionGroup `json:"groups"`
+}
+
+// Drifted reports whether a field has more than one version across the fleet.
+func (r *DriftReport) Drifted() bool {
+	for _, f := range r.Fields {
+		if len(f.Versions) > 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// NewDriftCmd creates the "drift" command which compares the driver and
+// library versions of the export reports of many nodes.
+func NewDriftCmd() *cobra.Command {
+	var (
+		format string
+		verbos bool
+	)
+	driftCmd := &cobra.Command{
+		Use:   "drift <report|dir|url>...",
+		Short: "Report GPU driver, CUDA, OFED, HCA FW and kernel version drift across a fleet",
+		Long: "Read the reports written by `sichek export` on many nodes, from files, directories of reports or URLs\n" +
+			"returning one report or a JSON list of reports, group the nodes by their combination of GPU driver,\n" +
+			"CUDA, OFED, HCA firmware and kernel versions and flag the nodes that differ from the majority.\n" +
+			"The command exits non-zero when a version drifts.",
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), consts.CmdTimeout)
+			defer cancel()
+
+			if !verbos {
+				logrus.SetLevel(logrus.ErrorLevel)
+			}
+			format = strings.ToLower(format)
+			if format != "table" && format != ExportFormatJSON {
+				logrus.WithField("component", "drift").Errorf("unsupported output format %q, expected table or %s", format, ExportFormatJSON)
+				os.Exit(1)
+			}
+			snapshots, err := LoadFleetSnapshots(ctx, args)
+			if err != nil {
+				logrus.WithField("component", "drift").Errorf("failed to load the reports: %v", err)
+				os.Exit(1)
+			}
+			if len(snapshots) == 0 {
+				logrus.WithField("component", "drift").Error("no report found")
+				os.Exit(1)
+			}
+			nodes := make([]NodeVersions, 0, len(snapshots))
+			for _, snapshot := range snapshots {
+				nodes = append(nodes, ExtractNodeVersions(snapshot, DefaultDriftFields))
+			}
+			report := BuildDriftReport(nodes, DefaultDriftFields)
+			switch {
+			case printer.Default().IsJSON():
+				if err := printer.JSON(report); err != nil {
+					logrus.WithField("component", "drift").Errorf("failed to marshal drift report: %v", err)
+					os.Exit(1)
+				}
+			case format == ExportFormatJSON:
+				data, err := json.MarshalIndent(report, "", "  ")
+				if err != nil {
+					logrus.WithField("component", "drift").Errorf("failed to marshal drift report: %v", err)
+					os.Exit(1)
+				}
+				printer.Println(string(data))
+			default:
+				PrintDriftReport(printer.Default(), report)
+			}
+			if report.Drifted() {
+				os.Exit(1)
+			}
+		},
+	}
+
+	driftCmd.Flags().StringVarP(&format, "format", "f", "table", "Output format, table or json")
+	driftCmd.Flags().BoolVarP(&verbos, "verbos", "v", false, "Enable verbose output")
+	return driftCmd
+}
+
+// LoadFleetSnapshots reads the reports of the sources. A directory source
+// contributes its .json, .yaml and .yml files. A report without node name is
+// named after its file.
+func LoadFleetSnapshots(ctx context.Context, sources []string) ([]*NodeSnapshot, error) {
+	var snapshots []*NodeSnapshot
+	load := func(label string, data []byte) error {
+		parsed, err := ParseNodeSnapshots(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", label, err)
+		}
+		for i, snapshot := range parsed {
+			if snapshot.Node == "" {
+				snapshot.Node = strings.TrimSuffix(filepath.Base(label), filepath.Ext(label))
+				if len(parsed) > 1 {
+					snapshot.Node = fmt.Sprintf("%s[%d]", snapshot.Node, i)
+				}
+			}
+		}
+		snapshots = append(snapshots, parsed...)
+		return nil
+	}
+	for _, source := range sources {
+		if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+			data, err := fetchReport(ctx, source)
+			if err != nil {
+				return nil, err
+			}
+			if err := load(source, data); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		st, err := os.Stat(source)
+		if err != nil {
+			return nil, err
+		}
+		files := []string{source}
+		if st.IsDir() {
+			files = nil
+			entries, err := os.ReadDir(source)
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range entries {
+				switch filepath.Ext(entry.Name()) {
+				case ".json", ".yaml", ".yml":
+					if !entry.IsDir() {
+						files = append(files, filepath.Join(source, entry.Name()))
+					}
+				}
+			}
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			if err := load(file, data); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return snapshots, nil
+}
+
+// ParseNodeSnapshots decodes a single report or a list of reports.
+func ParseNodeSnapshots(data []byte) ([]*NodeSnapshot, error) {
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid report: %w", err)
+	}
+	jsonData = bytes.TrimSpace(jsonData)
+	if !bytes.HasPrefix(jsonData, []byte("[")) {
+		snapshot, err := ParseNodeSnapshot(jsonData)
+		if err != nil {
+			return nil, err
+		}
+		return []*NodeSnapshot{snapshot}, nil
+	}
+	var reports []json.RawMessage
+	if err := json.Unmarshal(jsonData, &reports); err != nil {
+		return nil, fmt.Errorf("invalid report list: %w", err)
+	}
+	snapshots := make([]*NodeSnapshot, 0, len(reports))
+	for i, report := range reports {
+		snapshot, err := ParseNodeSnapshot(report)
+		if err != nil {
+			return nil, fmt.Errorf("report %d: %w", i, err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, nil
+}
+
+// ExtractNodeVersions looks the fields up in the snapshot. A field matching
+// several values, e.g. the firmware of each HCA, joins the distinct ones.
+func ExtractNodeVersions(snapshot *NodeSnapshot, fields []DriftField) NodeVersions {
+	nv := NodeVersions{Node: snapshot.Node, Versions: make(map[string]string)}
+	for _, field := range fields {
+		info, ok := snapshot.Components[field.Component]
+		if !ok || info == nil {
+			continue
+		}
+		var values []string
+		lookupInfo(info, strings.Split(field.Path, "."), &values)
+		if len(values) == 0 {
+			continue
+		}
+		sort.Strings(values)
+		distinct := values[:1]
+		for _, v := range values[1:] {
+			if v != distinct[len(distinct)-1] {
+				distinct = append(distinct, v)
+			}
+		}
+		nv.Versions[field.Name] = strings.Join(distinct, ",")
+	}
+	return nv
+}
+
+func lookupInfo(v interface{}, path []string, out *[]string) {
+	if len(path) == 0 {
+		if v == nil {
+			return
+		}
+		if s := strings.TrimSpace(fmt.Sprint(v)); s != "" {
+			*out = append(*out, s)
+		}
+		return
+	}
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if path[0] == "*" {
+			for _, child := range val {
+				lookupInfo(child, path[1:], out)
+			}
+			return
+		}
+		if child, ok := val[path[0]]; ok {
+			lookupInfo(child, path[1:], out)
+		}
+	case []interface{}:
+		if path[0] == "*" {
+			for _, child := range val {
+				lookupInfo(child, path[1:], out)
+			}
+		}
+	}
+}
+
+// BuildDriftReport groups the nodes per version of each field and per
+// combination of versions. The majority of a field is its most common
+// version, ties go to the lowest version string so the report is stable.
+func BuildDriftReport(nodes []NodeVersions, fields []DriftField) *DriftReport {
+	report := &DriftReport{Nodes: len(nodes)}
+	for _, field := range fields {
+		fd := FieldDrift{Field: field.Name, Versions: make(map[string][]string)}
+		for _, n := range nodes {
+			if v, ok := n.Versions[field.Name]; ok {
+				fd.Versions[v] = append(fd.Versions[v], n.Node)
+			}
+		}
+		if len(fd.Versions) == 0 {
+			continue
+		}
+		for v, members := range fd.Versions {
+			sort.Strings(members)
+			if fd.Majority == "" || len(members) > len(fd.Versions[fd.Majority]) ||
+				(len(members) == len(fd.Versions[fd.Majority]) && v < fd.Majority) {
+				fd.Majority = v
+			}
+		}
+		for v, members := range fd.Versions {
+			if v != fd.Majority {
+				fd.Outliers = append(fd.Outliers, members...)
+			}
+		}
+		sort.Strings(fd.Outliers)
+		report.Fields = append(report.Fields, fd)
+	}
+
+	groups := make(map[string]*VersionGroup)
+	for _, n := range nodes {
+		parts := make([]string, 0, len(fields))
+		for _, field := range fields {
+			parts = append(parts, n.Versions[field.Name])
+		}
+		key := strings.Join(parts, "\x00")
+		group, ok := groups[key]
+		if !ok {
+			group = &VersionGroup{Versions: n.Versions}
+			groups[key] = group
+		}
+		group.Nodes = append(group.Nodes, n.Node)
+	}
+	for _, group := range groups {
+		sort.Strings(group.Nodes)
+		report.Groups = append(report.Groups, *group)
+	}
+	sort.Slice(report.Groups, func(i, j int) bool {
+		if len(report.Groups[i].Nodes) != len(report.Groups[j].Nodes) {
+			return len(report.Groups[i].Nodes) > len(report.Groups[j].Nodes)
+		}
+		return report.Groups[i].Nodes[0] < report.Groups[j].Nodes[0]
+	})
+	return report
+}
+
+// PrintDriftReport prints the version of every field across the fleet, the
+// outlier nodes in red, followed by the nodes grouped by version combination.
+func PrintDriftReport(w io.Writer, report *DriftReport) {
+	fmt.Fprintf(w, "Version drift across %d nodes\n", report.Nodes)
+	for _, f := range report.Fields {
+		if len(f.Versions) == 1 {
+			fmt.Fprintf(w, "\n%s%-10s%s %s%s%s (%d nodes)\n", consts.Cyan, f.Field, consts.Reset, consts.Green, f.Majority, consts.Reset, len(f.Versions[f.Majority]))
+			continue
+		}
+		fmt.Fprintf(w, "\n%s%-10s%s %s%d versions%s, majority %s (%d nodes)\n", consts.Cyan, f.Field, consts.Reset, consts.Yellow, len(f.Versions), consts.Reset, f.Majority, len(f.Versions[f.Majority]))
+		versions := make([]string, 0, len(f.Versions))
+		for v := range f.Versions {
+			if v != f.Majority {
+				versions = append(versions, v)
+			}
+		}
+		sort.Strings(versions)
+		for _, v := range versions {
+			fmt.Fprintf(w, "  %s%s%s: %s\n", consts.Red, v, consts.Reset, strings.Join(f.Versions[v], ","))
+		}
+	}
+
+	fmt.Fprintf(w, "\n%d version combinations\n", len(report.Groups))
+	for i, g := range report.Groups {
+		parts := make([]string, 0, len(report.Fields))
+		for _, f := range report.Fields {
+			v := g.Versions[f.Field]
+			if v == "" {
+				v = "-"
+			}
+			parts = append(parts, fmt.Sprintf("%s=%s", f.Field, v))
+		}
+		fmt.Fprintf(w, "  #%d %d nodes: %s\n", i+1, len(g.Nodes), strings.Join(parts, " "))
+		fmt.Fprintf(w, "     %s\n", strings.Join(g.Nodes, ","))
+	}
+}
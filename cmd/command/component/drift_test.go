@@ -0,0 +1,119 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func driftReport(node, driver, fw0, fw1, kernel string) string {
+	return `{"node": "` + node + `", "components": [
+  {"name": "nvidia", "info": {"software_info": {"driver_version": "` + driver + `", "cuda_version": "12.4"}}},
+  {"name": "infiniband", "info": {"ib_software_info": {"ofed_ver": "MLNX_OFED_LINUX-23.10-1.1.9.0"},
+    "ib_hardware_info": {"mlx5_0": {"fw_ver": "` + fw0 + `"}, "mlx5_1": {"fw_ver": "` + fw1 + `"}}}},
+  {"name": "cpu", "info": {"host_info": {"kernel_version": "` + kernel + `"}}}
+]}`
+}
+
+func TestExtractNodeVersions(t *testing.T) {
+	snapshot, err := ParseNodeSnapshot([]byte(driftReport("n1", "550.54.15", "28.39.1002", "28.38.1002", "5.15.0")))
+	require.NoError(t, err)
+	nv := ExtractNodeVersions(snapshot, DefaultDriftFields)
+	assert.Equal(t, "n1", nv.Node)
+	assert.Equal(t, map[string]string{
+		"gpu_driver": "550.54.15",
+		"cuda":       "12.4",
+		"ofed":       "MLNX_OFED_LINUX-23.10-1.1.9.0",
+		"hca_fw":     "28.38.1002,28.39.1002",
+		"kernel":     "5.15.0",
+	}, nv.Versions)
+
+	// a CPU only node has no GPU and HCA versions
+	snapshot, err = ParseNodeSnapshot([]byte(`{"node": "cpu1", "components": [{"name": "cpu", "info": {"host_info": {"kernel_version": "5.15.0"}}}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"kernel": "5.15.0"}, ExtractNodeVersions(snapshot, DefaultDriftFields).Versions)
+}
+
+func TestBuildDriftReport(t *testing.T) {
+	var nodes []NodeVersions
+	for _, r := range []string{
+		driftReport("n1", "550.54.15", "28.39.1002", "28.39.1002", "5.15.0"),
+		driftReport("n2", "550.54.15", "28.39.1002", "28.39.1002", "5.15.0"),
+		driftReport("n3", "535.104.05", "28.39.1002", "28.39.1002", "5.15.0"),
+		driftReport("n4", "550.54.15", "28.39.1002", "28.38.1002", "5.15.0"),
+	} {
+		snapshot, err := ParseNodeSnapshot([]byte(r))
+		require.NoError(t, err)
+		nodes = append(nodes, ExtractNodeVersions(snapshot, DefaultDriftFields))
+	}
+	report := BuildDriftReport(nodes, DefaultDriftFields)
+	assert.Equal(t, 4, report.Nodes)
+	assert.True(t, report.Drifted())
+	require.Len(t, report.Fields, 5)
+
+	driver := report.Fields[0]
+	assert.Equal(t, "gpu_driver", driver.Field)
+	assert.Equal(t, "550.54.15", driver.Majority)
+	assert.Equal(t, []string{"n3"}, driver.Outliers)
+	fw := report.Fields[3]
+	assert.Equal(t, "hca_fw", fw.Field)
+	assert.Equal(t, []string{"n4"}, fw.Outliers)
+	assert.Empty(t, report.Fields[4].Outliers)
+
+	require.Len(t, report.Groups, 3)
+	assert.Equal(t, []string{"n1", "n2"}, report.Groups[0].Nodes)
+	assert.Equal(t, []string{"n3"}, report.Groups[1].Nodes)
+	assert.Equal(t, []string{"n4"}, report.Groups[2].Nodes)
+
+	var buf bytes.Buffer
+	PrintDriftReport(&buf, report)
+	out := buf.String()
+	assert.Contains(t, out, "Version drift across 4 nodes")
+	assert.Contains(t, out, "535.104.05")
+	assert.Contains(t, out, "3 version combinations")
+
+	report = BuildDriftReport(nodes[:2], DefaultDriftFields)
+	assert.False(t, report.Drifted())
+	assert.Len(t, report.Groups, 1)
+}
+
+func TestLoadFleetSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "n1.json"), []byte(driftReport("n1", "550.54.15", "28.39.1002", "28.39.1002", "5.15.0")), 0644))
+	list := "[" + driftReport("n2", "550.54.15", "28.39.1002", "28.39.1002", "5.15.0") + "," +
+		strings.Replace(driftReport("", "550.54.15", "28.39.1002", "28.39.1002", "5.15.0"), `"node": "",`, "", 1) + "]"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fleet.json"), []byte(list), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a report"), 0644))
+
+	snapshots, err := LoadFleetSnapshots(context.Background(), []string{dir})
+	require.NoError(t, err)
+	var names []string
+	for _, s := range snapshots {
+		names = append(names, s.Node)
+	}
+	assert.ElementsMatch(t, []string{"n1", "n2", "fleet[1]"}, names)
+
+	_, err = LoadFleetSnapshots(context.Background(), []string{filepath.Join(dir, "missing.json")})
+	assert.Error(t, err)
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/scitix/sichek/pkg/utils"
+)
+
+// FailOnLevel is the lowest level of a failed component that makes the process exit non-zero.
+// The default keeps the historical behavior, i.e. any warning, critical or fatal failure blocks.
+var FailOnLevel = consts.LevelWarning
+
+type exitPolicyFile struct {
+	ExitPolicy struct {
+		FailOn string `json:"fail_on" yaml:"fail_on"`
+	} `json:"exit_policy" yaml:"exit_policy"`
+}
+
+// ParseFailOnLevel validates a --fail-on / exit_policy.fail_on value.
+func ParseFailOnLevel(level string) (string, error) {
+	switch level {
+	case consts.LevelWarning, consts.LevelCritical, consts.LevelFatal:
+		return level, nil
+	}
+	return "", fmt.Errorf("invalid fail-on level %q, expected one of warning, critical, fatal", level)
+}
+
+// LoadFailOnLevel reads exit_policy.fail_on from the user config, defaulting to warning.
+func LoadFailOnLevel(cfgFile string) (string, error) {
+	var f exitPolicyFile
+	if err := common.LoadUserConfig(cfgFile, &f); err != nil || f.ExitPolicy.FailOn == "" {
+		return consts.LevelWarning, nil
+	}
+	return ParseFailOnLevel(f.ExitPolicy.FailOn)
+}
+
+// SetComponentStatus records the pass/fail status and the aggregated level of a component.
+func SetComponentStatus(name string, passed bool, level string) {
+	StatusMutex.Lock()
+	defer StatusMutex.Unlock()
+	ComponentStatuses[name] = passed
+	ComponentLevels[name] = level
+}
+
+// SetComponentDurations records how long the health check of a component took.
+func SetComponentDurations(name string, duration time.Duration) {
+	StatusMutex.Lock()
+	defer StatusMutex.Unlock()
+	ComponentDurations[name] = duration
+}
+
+// SetComponentResult records the result of a component for the JSON report.
+func SetComponentResult(name string, result *common.Result) {
+	StatusMutex.Lock()
+	defer StatusMutex.Unlock()
+	ComponentResults[name] = result
+}
+
+// isBlocking reports whether a failed component with the given level fails the run.
+// Failures without a known level, e.g. from the perftest commands, always block.
+func isBlocking(level string, failOn string) bool {
+	priority, ok := consts.LevelPriority[level]
+	if !ok {
+		return true
+	}
+	return priority >= consts.LevelPriority[failOn]
+}
+
+// IsAllPassed reports whether no failed component reaches FailOnLevel.
+func IsAllPassed() bool {
+	StatusMutex.Lock()
+	defer StatusMutex.Unlock()
+	for name, passed := range ComponentStatuses {
+		if !passed && isBlocking(ComponentLevels[name], FailOnLevel) {
+			return false
+		}
+	}
+	return true
+}
+
+// componentStatus returns PASS, WARN for a failure below FailOnLevel or FAIL.
+func componentStatus(name string) string {
+	if ComponentStatuses[name] {
+		return "PASS"
+	}
+	if isBlocking(ComponentLevels[name], FailOnLevel) {
+		return "FAIL"
+	}
+	return "WARN"
+}
+
+// ComponentSummary is a component of the JSON summary of the CLI.
+type ComponentSummary struct {
+	Name     string         `json:"name"`
+	Status   string         `json:"status"`
+	Level    string         `json:"level,omitempty"`
+	Duration string         `json:"duration,omitempty"`
+	Result   *common.Result `json:"result,omitempty"`
+}
+
+// SummaryReport is the JSON summary of the CLI, printed instead of the
+// Summary section in the JSON mode.
+type SummaryReport struct {
+	Components []ComponentSummary `json:"components"`
+	Overall    string             `json:"overall"`
+	FailOn     string             `json:"fail_on"`
+}
+
+// BuildSummaryReport returns the JSON summary of the components checked so far.
+func BuildSummaryReport() *SummaryReport {
+	StatusMutex.Lock()
+	defer StatusMutex.Unlock()
+	report := &SummaryReport{Components: []ComponentSummary{}, Overall: "PASS", FailOn: FailOnLevel}
+	for _, name := range sortedComponentNames() {
+		entry := ComponentSummary{
+			Name:   name,
+			Status: componentStatus(name),
+			Result: ComponentResults[name],
+		}
+		if entry.Status != "PASS" {
+			entry.Level = ComponentLevels[name]
+		}
+		if entry.Status == "FAIL" {
+			report.Overall = "FAIL"
+		}
+		if duration, ok := ComponentDurations[name]; ok {
+			entry.Duration = duration.Round(time.Millisecond).String()
+		}
+		report.Components = append(report.Components, entry)
+	}
+	return report
+}
+
+func sortedComponentNames() []string {
+	names := make([]string, 0, len(ComponentStatuses))
+	for name := range ComponentStatuses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PrintComponentStatuses prints the Summary section, marking failures below
+// FailOnLevel as WARN, or the JSON report in the JSON mode.
+func PrintComponentStatuses() {
+	if printer.Default().IsJSON() {
+		if err := printer.Default().JSON(BuildSummaryReport()); err != nil {
+			printer.Warnf("print the JSON summary failed: %v\n", err)
+		}
+		return
+	}
+	StatusMutex.Lock()
+	defer StatusMutex.Unlock()
+	utils.PrintTitle("Summary", "-")
+	blocked := false
+	for _, name := range sortedComponentNames() {
+		statusStr := fmt.Sprintf("%s%s%s", consts.Green, "PASS", consts.Reset)
+		switch componentStatus(name) {
+		case "FAIL":
+			blocked = true
+			statusStr = fmt.Sprintf("%s%s%s", consts.Red, "FAIL", consts.Reset)
+		case "WARN":
+			statusStr = fmt.Sprintf("%s%s%s", consts.Yellow, "WARN", consts.Reset)
+		}
+		if level := ComponentLevels[name]; !ComponentStatuses[name] && level != "" {
+			statusStr += fmt.Sprintf(" (%s)", level)
+		}
+		if duration, ok := ComponentDurations[name]; ok {
+			statusStr += fmt.Sprintf(" [%s]", duration.Round(time.Millisecond))
+		}
+		printer.Printf(" - %s: %s\n", name, statusStr)
+	}
+	overall := fmt.Sprintf("%s%s%s", consts.Green, "PASS", consts.Reset)
+	if blocked {
+		overall = fmt.Sprintf("%s%s%s", consts.Red, "FAIL", consts.Reset)
+	}
+	printer.Printf("Overall: %s (fail-on: %s)\n", overall, FailOnLevel)
+}
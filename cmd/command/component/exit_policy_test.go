@@ -0,0 +1,143 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/scitix/sichek/pkg/utils"
+)
+
+func TestIsAllPassed(t *testing.T) {
+	tests := []struct {
+		name     string
+		failOn   string
+		statuses map[string]bool
+		levels   map[string]string
+		want     bool
+	}{
+		{
+			name:     "warning blocks by default",
+			failOn:   consts.LevelWarning,
+			statuses: map[string]bool{"cpu": true, "nvidia": false},
+			levels:   map[string]string{"nvidia": consts.LevelWarning},
+			want:     false,
+		},
+		{
+			name:     "warning ignored with fail-on critical",
+			failOn:   consts.LevelCritical,
+			statuses: map[string]bool{"cpu": true, "nvidia": false},
+			levels:   map[string]string{"nvidia": consts.LevelWarning},
+			want:     true,
+		},
+		{
+			name:     "critical blocks with fail-on critical",
+			failOn:   consts.LevelCritical,
+			statuses: map[string]bool{"infiniband": false, "nvidia": false},
+			levels:   map[string]string{"infiniband": consts.LevelWarning, "nvidia": consts.LevelCritical},
+			want:     false,
+		},
+		{
+			name:     "critical ignored with fail-on fatal",
+			failOn:   consts.LevelFatal,
+			statuses: map[string]bool{"nvidia": false},
+			levels:   map[string]string{"nvidia": consts.LevelCritical},
+			want:     true,
+		},
+		{
+			name:     "failure without level always blocks",
+			failOn:   consts.LevelFatal,
+			statuses: map[string]bool{"NcclPerf": false},
+			levels:   map[string]string{},
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldStatuses, oldLevels, oldFailOn := ComponentStatuses, ComponentLevels, FailOnLevel
+			defer func() { ComponentStatuses, ComponentLevels, FailOnLevel = oldStatuses, oldLevels, oldFailOn }()
+			ComponentStatuses, ComponentLevels, FailOnLevel = tt.statuses, tt.levels, tt.failOn
+			if got := IsAllPassed(); got != tt.want {
+				t.Errorf("IsAllPassed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFailOnLevel(t *testing.T) {
+	dir := t.TempDir()
+	cfgFile := filepath.Join(dir, "user_config.yaml")
+	if err := os.WriteFile(cfgFile, []byte("exit_policy:\n  fail_on: critical\n"), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	level, err := LoadFailOnLevel(cfgFile)
+	if err != nil {
+		t.Fatalf("LoadFailOnLevel() error = %v", err)
+	}
+	if level != consts.LevelCritical {
+		t.Errorf("LoadFailOnLevel() = %q, want %q", level, consts.LevelCritical)
+	}
+
+	if err := os.WriteFile(cfgFile, []byte("exit_policy:\n  fail_on: info\n"), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if _, err := LoadFailOnLevel(cfgFile); err == nil {
+		t.Errorf("expected an error for fail_on: info")
+	}
+}
+
+func TestPrintComponentStatusesJSON(t *testing.T) {
+	oldStatuses, oldLevels, oldResults, oldFailOn := ComponentStatuses, ComponentLevels, ComponentResults, FailOnLevel
+	oldPrinter := printer.Default()
+	defer func() {
+		ComponentStatuses, ComponentLevels, ComponentResults, FailOnLevel = oldStatuses, oldLevels, oldResults, oldFailOn
+		printer.SetDefault(oldPrinter)
+	}()
+	ComponentStatuses = map[string]bool{"cpu": true, "nvidia": false, "infiniband": false}
+	ComponentLevels = map[string]string{"nvidia": consts.LevelCritical, "infiniband": consts.LevelWarning}
+	ComponentResults = map[string]*common.Result{"nvidia": {Item: "nvidia", Status: consts.StatusAbnormal, Level: consts.LevelCritical}}
+	FailOnLevel = consts.LevelCritical
+
+	var out, errOut bytes.Buffer
+	printer.SetDefault(printer.New(printer.ModeJSON, printer.VerbosityNormal, &out, &errOut))
+	utils.PrintTitle("Summary", "-")
+	PrintComponentStatuses()
+
+	var report SummaryReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("stdout is not a JSON document: %v\n%s", err, out.String())
+	}
+	if report.Overall != "FAIL" || report.FailOn != consts.LevelCritical || len(report.Components) != 3 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	want := map[string]string{"cpu": "PASS", "infiniband": "WARN", "nvidia": "FAIL"}
+	for _, component := range report.Components {
+		if component.Status != want[component.Name] {
+			t.Errorf("%s status = %s, want %s", component.Name, component.Status, want[component.Name])
+		}
+	}
+	if report.Components[2].Result == nil || report.Components[2].Result.Level != consts.LevelCritical {
+		t.Errorf("nvidia result missing from the report: %+v", report.Components[2])
+	}
+}
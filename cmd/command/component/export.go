@@ -0,0 +1,143 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/scitix/sichek/cmd/command/spec"
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	ExportFormatJSON = "json"
+	ExportFormatYAML = "yaml"
+)
+
+// NewExportCmd creates the "export" command which runs the health checks of the selected
+// components and dumps their results and collected infos as a single JSON/YAML report,
+// so that CI pipelines and fleet tooling can consume sichek output programmatically.
+func NewExportCmd() *cobra.Command {
+	var (
+		cfgFile          string
+		specFile         string
+		enableComponents string
+		ignoreComponents string
+		ignoredCheckers  string
+		format           string
+		output           string
+		verbos           bool
+	)
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export all components health results as a JSON/YAML report",
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), consts.AllCmdTimeout)
+			defer cancel()
+
+			if !verbos {
+				logrus.SetLevel(logrus.ErrorLevel)
+			}
+			format = strings.ToLower(format)
+			if format != ExportFormatJSON && format != ExportFormatYAML {
+				logrus.WithField("component", "export").Errorf("unsupported export format %q, expected %s or %s", format, ExportFormatJSON, ExportFormatYAML)
+				os.Exit(1)
+			}
+			resolvedCfgFile, err := spec.EnsureCfgFile(cfgFile)
+			if err != nil {
+				logrus.WithField("component", "export").Errorf("failed to load cfgFile: %v", err)
+			}
+			resolvedSpecFile, err := spec.EnsureSpecFile(specFile)
+			if err != nil {
+				logrus.WithField("component", "export").Errorf("failed to load specFile: %v", err)
+			}
+			var ignoredCheckersList []string
+			if len(ignoredCheckers) > 0 {
+				ignoredCheckersList = strings.Split(ignoredCheckers, ",")
+			}
+
+			componentsToCheck := DetermineComponentsToCheck(enableComponents, ignoreComponents, resolvedCfgFile, "export")
+			checkResults, errs := RunComponentChecks(ctx, componentsToCheck, resolvedCfgFile, resolvedSpecFile, ignoredCheckersList)
+			report := BuildReport(checkResults, errs)
+
+			if (output == "" || output == "-") && printer.Default().IsJSON() {
+				if err := printer.JSON(report); err != nil {
+					logrus.WithField("component", "export").Errorf("failed to marshal report: %v", err)
+					os.Exit(1)
+				}
+				return
+			}
+			var content string
+			if format == ExportFormatYAML {
+				content, err = report.YAML()
+			} else {
+				content, err = report.JSON()
+			}
+			if err != nil {
+				logrus.WithField("component", "export").Errorf("failed to marshal report: %v", err)
+				os.Exit(1)
+			}
+			if output == "" || output == "-" {
+				printer.Println(content)
+				return
+			}
+			if err := os.WriteFile(output, []byte(content), 0644); err != nil {
+				logrus.WithField("component", "export").Errorf("failed to write report to %s: %v", output, err)
+				os.Exit(1)
+			}
+			logrus.WithField("component", "export").Infof("report written to %s", output)
+		},
+	}
+
+	exportCmd.Flags().StringVarP(&format, "format", "f", ExportFormatJSON, "Output format, json or yaml")
+	exportCmd.Flags().StringVarP(&output, "output", "o", "", "Path to the output file (default stdout)")
+	exportCmd.Flags().BoolVarP(&verbos, "verbos", "v", false, "Enable verbose output")
+	exportCmd.Flags().StringVarP(&cfgFile, "cfg", "c", "", "Path to the user config file")
+	exportCmd.Flags().StringVarP(&specFile, "spec", "s", "", "Path to the sichek specification file")
+	exportCmd.Flags().StringVarP(&enableComponents, "enable-components", "E", "", "Enabled components, joined by ','")
+	exportCmd.Flags().StringVarP(&ignoreComponents, "ignore-components", "I", "podlog,gpuevents,syslog", "Ignored components")
+	exportCmd.Flags().StringVarP(&ignoredCheckers, "ignored-checkers", "i", "", "Ignored checkers")
+	return exportCmd
+}
+
+// BuildReport aggregates the check results and component errors into a common.Report.
+// errs is expected to hold real create or check failures only, as components not
+// supported on this node are already dropped by RunComponentChecks.
+func BuildReport(checkResults []*CheckResults, errs map[string]error) *common.Report {
+	hostname, err := os.Hostname()
+	if err != nil {
+		logrus.WithField("component", "export").Errorf("get hostname failed: %v", err)
+	}
+	report := common.NewReport(hostname)
+	for _, checkResult := range checkResults {
+		if checkResult == nil || checkResult.result == nil {
+			continue
+		}
+		checkResult.result.Node = hostname
+		report.AddResult(checkResult.result, checkResult.info)
+	}
+	for componentName, err := range errs {
+		report.AddError(componentName, err)
+	}
+	report.Sort()
+	return report
+}
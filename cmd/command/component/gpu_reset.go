@@ -0,0 +1,406 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+	"github.com/scitix/sichek/cmd/command/spec"
+	"github.com/scitix/sichek/components/common/remediator"
+	"github.com/scitix/sichek/components/nvidia"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/scitix/sichek/pkg/k8s"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/scitix/sichek/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	GPUResetMethodAuto = "auto"
+	GPUResetMethodSMI  = "nvidia-smi"
+	GPUResetMethodFLR  = "flr"
+)
+
+// 00000000:18:00.0 or 0000:18:00.0
+var gpuBDFRe = regexp.MustCompile(`^(?:[0-9a-fA-F]{4}|[0-9a-fA-F]{8}):[0-9a-fA-F]{2}:[0-9a-fA-F]{2}\.[0-7]$`)
+
+// gpuResetTarget is the GPU a reset is requested for.
+type gpuResetTarget struct {
+	Index int
+	UUID  string
+	// BDF is the PCI address of the GPU as in sysfs, e.g. 0000:18:00.0
+	BDF string
+}
+
+func (t gpuResetTarget) String() string {
+	return fmt.Sprintf("GPU %d (%s, %s)", t.Index, t.UUID, t.BDF)
+}
+
+func NewGpuResetCmd() *cobra.Command {
+	gpuResetCmd := &cobra.Command{
+		Use:   "reset <index|uuid|bdf>",
+		Short: "Reset a GPU once no process nor pod uses it, then re-run the GPU HealthCheck",
+		Long: "Reset a GPU safely: refuse while a process runs on it or a pod is allocated it, reset it with\n" +
+			"nvidia-smi --gpu-reset or a PCIe function level reset, wait for the GPU to enumerate again and\n" +
+			"re-run the nvidia HealthCheck. The reset is recorded in the remediation audit log.",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			verbos, _ := cmd.Flags().GetBool("verbos")
+			if !verbos {
+				logrus.SetLevel(logrus.ErrorLevel)
+			}
+			if !utils.IsNvidiaGPUExist() {
+				printer.Println("nvidia GPU is not Exist, nothing to reset")
+				os.Exit(1)
+			}
+			method, _ := cmd.Flags().GetString("method")
+			if method != GPUResetMethodAuto && method != GPUResetMethodSMI && method != GPUResetMethodFLR {
+				printer.Printf("invalid --method %q, expected %s, %s or %s\n", method, GPUResetMethodAuto, GPUResetMethodSMI, GPUResetMethodFLR)
+				os.Exit(1)
+			}
+			force, _ := cmd.Flags().GetBool("force")
+			// the global remediation --dry-run runs the safety checks only
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+
+			target, blockers, err := inspectGPUForReset(args[0])
+			if err != nil {
+				printer.Printf("%s\n", err)
+				os.Exit(1)
+			}
+			printer.Printf("Target: %s\n", target)
+			if blockers.Refuse(force) {
+				printer.Printf("Refusing to reset %s:\n%s", target, blockers)
+				os.Exit(1)
+			}
+			if len(blockers.Pods) > 0 {
+				printer.Printf("Resetting %s although it is allocated to %s (--force)\n", target, strings.Join(blockers.Pods, ", "))
+			}
+
+			record := &remediator.AuditRecord{
+				Time:        time.Now(),
+				Component:   consts.ComponentNameNvidia,
+				Checker:     "manual",
+				Action:      remediator.ActionGPUReset,
+				Target:      target.BDF,
+				Description: fmt.Sprintf("reset %s with method %s", target, method),
+			}
+			if dryRun {
+				record.Status = remediator.AuditStatusDryRun
+				remediator.Default().Audit(record)
+				printer.Printf("[dry-run] would %s\n", record.Description)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout+consts.CmdTimeout)
+			defer cancel()
+			used, err := resetGPU(ctx, target.BDF, method)
+			record.Description = fmt.Sprintf("reset %s with %s", target, used)
+			if err == nil {
+				err = waitForGPU(ctx, target, timeout)
+			}
+			if err != nil {
+				record.Status = remediator.AuditStatusFailed
+				record.Error = err.Error()
+				remediator.Default().Audit(record)
+				printer.Printf("Reset of %s failed: %v\n", target, err)
+				os.Exit(1)
+			}
+			record.Status = remediator.AuditStatusApplied
+			remediator.Default().Audit(record)
+			printer.Printf("Reset %s with %s, the GPU enumerated again\n", target, used)
+
+			if !runGPUHealthCheckAfterReset(cmd) {
+				os.Exit(1)
+			}
+		},
+	}
+
+	gpuResetCmd.Flags().StringP("cfg", "c", "", "Path to the user config file")
+	gpuResetCmd.Flags().StringP("spec", "s", "", "Path to the nvidia specification file")
+	gpuResetCmd.Flags().String("method", GPUResetMethodAuto, "Reset method: auto (nvidia-smi, falling back to FLR), nvidia-smi or flr")
+	gpuResetCmd.Flags().Bool("force", false, "Reset the GPU even if a pod is allocated it, running processes still refuse the reset")
+	gpuResetCmd.Flags().Duration("timeout", 60*time.Second, "Time to wait for the GPU to enumerate again after the reset")
+	gpuResetCmd.Flags().BoolP("verbos", "v", false, "Enable verbose output")
+
+	return gpuResetCmd
+}
+
+// gpuResetBlockers is what makes a reset unsafe.
+type gpuResetBlockers struct {
+	// Processes is the processes running on the GPU, e.g. "pid 1234 (python)"
+	Processes []string
+	// Pods is the pods the kubelet allocated the GPU to, e.g. "default/train-0"
+	Pods []string
+}
+
+// Refuse reports whether the reset must not run, force only overrides the
+// pod allocations: a reset under a running process kills its CUDA context.
+func (b gpuResetBlockers) Refuse(force bool) bool {
+	return len(b.Processes) > 0 || (len(b.Pods) > 0 && !force)
+}
+
+func (b gpuResetBlockers) String() string {
+	var sb strings.Builder
+	for _, p := range b.Processes {
+		fmt.Fprintf(&sb, "  process %s runs on the GPU\n", p)
+	}
+	for _, p := range b.Pods {
+		fmt.Fprintf(&sb, "  pod %s is allocated the GPU, drain it first or use --force\n", p)
+	}
+	return sb.String()
+}
+
+// inspectGPUForReset resolves id to a GPU with NVML and lists the processes
+// and the pods using it. NVML is shut down on return so that sichek does not
+// hold the GPU open during the reset.
+func inspectGPUForReset(id string) (gpuResetTarget, gpuResetBlockers, error) {
+	var blockers gpuResetBlockers
+	nvmlInst := nvml.New()
+	if ret := nvmlInst.Init(); !errors.Is(ret, nvml.SUCCESS) {
+		return gpuResetTarget{}, blockers, fmt.Errorf("failed to initialize NVML: %s", nvml.ErrorString(ret))
+	}
+	defer nvmlInst.Shutdown()
+
+	device, err := gpuDeviceByID(nvmlInst, id)
+	if err != nil {
+		return gpuResetTarget{}, blockers, err
+	}
+	target := gpuResetTarget{Index: -1}
+	if index, ret := device.GetIndex(); errors.Is(ret, nvml.SUCCESS) {
+		target.Index = index
+	}
+	uuid, ret := device.GetUUID()
+	if !errors.Is(ret, nvml.SUCCESS) {
+		return target, blockers, fmt.Errorf("failed to get the UUID of GPU %s: %s", id, nvml.ErrorString(ret))
+	}
+	target.UUID = uuid
+	pciInfo, ret := device.GetPciInfo()
+	if !errors.Is(ret, nvml.SUCCESS) {
+		return target, blockers, fmt.Errorf("failed to get the PCI address of GPU %s: %s", id, nvml.ErrorString(ret))
+	}
+	target.BDF = sysfsBDF(nvmlBusID(pciInfo.BusId[:]))
+
+	for _, list := range []func() ([]nvml.ProcessInfo, nvml.Return){device.GetComputeRunningProcesses, device.GetGraphicsRunningProcesses} {
+		procs, ret := list()
+		if !errors.Is(ret, nvml.SUCCESS) {
+			return target, blockers, fmt.Errorf("failed to list the processes of %s: %s", target, nvml.ErrorString(ret))
+		}
+		for _, proc := range procs {
+			blockers.Processes = append(blockers.Processes, describeGPUProcess(proc.Pid))
+		}
+	}
+
+	if mapper := k8s.NewPodResourceMapper(); mapper != nil {
+		deviceToPodMap, err := mapper.GetDeviceToPodMap()
+		if err != nil {
+			return target, blockers, fmt.Errorf("failed to get the pods using the GPUs from the kubelet: %w", err)
+		}
+		blockers.Pods = podsOfGPU(deviceToPodMap, target.UUID)
+	}
+	return target, blockers, nil
+}
+
+// gpuDeviceByID resolves a GPU index, UUID or PCI address.
+func gpuDeviceByID(nvmlInst nvml.Interface, id string) (nvml.Device, error) {
+	var (
+		device nvml.Device
+		ret    nvml.Return
+	)
+	switch {
+	case gpuBDFRe.MatchString(id):
+		device, ret = nvmlInst.DeviceGetHandleByPciBusId(id)
+	case strings.HasPrefix(id, "GPU-") || strings.HasPrefix(id, "MIG-"):
+		device, ret = nvmlInst.DeviceGetHandleByUUID(id)
+	default:
+		index, err := strconv.Atoi(id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GPU %q, expected an index, a UUID or a PCI address", id)
+		}
+		device, ret = nvmlInst.DeviceGetHandleByIndex(index)
+	}
+	if !errors.Is(ret, nvml.SUCCESS) {
+		return nil, fmt.Errorf("GPU %s not found: %s", id, nvml.ErrorString(ret))
+	}
+	return device, nil
+}
+
+// podsOfGPU returns the pods allocated the GPU, the device plugin registers
+// the GPUs by UUID.
+func podsOfGPU(deviceToPodMap map[string]*k8s.PodInfo, uuid string) []string {
+	var pods []string
+	if pod, ok := deviceToPodMap[uuid]; ok && pod != nil {
+		pods = append(pods, pod.Namespace+"/"+pod.PodName)
+	}
+	return pods
+}
+
+func describeGPUProcess(pid uint32) string {
+	comm, err := os.ReadFile(hostfs.Path("/proc", strconv.FormatUint(uint64(pid), 10), "comm"))
+	if err != nil {
+		return fmt.Sprintf("pid %d", pid)
+	}
+	return fmt.Sprintf("pid %d (%s)", pid, strings.TrimSpace(string(comm)))
+}
+
+func nvmlBusID(busID []int8) string {
+	var sb strings.Builder
+	for _, c := range busID {
+		if c == 0 {
+			break
+		}
+		sb.WriteByte(byte(c))
+	}
+	return sb.String()
+}
+
+// sysfsBDF turns the 8 digit PCI domain of NVML, e.g. 00000000:18:00.0, into
+// the 4 digit one of sysfs, 0000:18:00.0.
+func sysfsBDF(busID string) string {
+	busID = strings.ToLower(busID)
+	if domain, rest, ok := strings.Cut(busID, ":"); ok && len(domain) > 4 {
+		return domain[len(domain)-4:] + ":" + rest
+	}
+	return busID
+}
+
+// resetGPU resets the GPU at bdf and returns the method used.
+func resetGPU(ctx context.Context, bdf, method string) (string, error) {
+	switch method {
+	case GPUResetMethodSMI:
+		return GPUResetMethodSMI, resetGPUWithSMI(ctx, bdf)
+	case GPUResetMethodFLR:
+		return GPUResetMethodFLR, resetGPUWithFLR(bdf)
+	}
+	err := resetGPUWithSMI(ctx, bdf)
+	if err == nil {
+		return GPUResetMethodSMI, nil
+	}
+	logrus.WithField("component", "gpu-reset").Warnf("nvidia-smi failed to reset %s, falling back to FLR: %v", bdf, err)
+	if flrErr := resetGPUWithFLR(bdf); flrErr != nil {
+		return GPUResetMethodFLR, fmt.Errorf("%v, then FLR: %w", err, flrErr)
+	}
+	return GPUResetMethodFLR, nil
+}
+
+func resetGPUWithSMI(ctx context.Context, bdf string) error {
+	output, err := utils.ExecCommand(ctx, "nvidia-smi", "--gpu-reset", "-i", bdf)
+	if err != nil {
+		return fmt.Errorf("nvidia-smi --gpu-reset: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// resetGPUWithFLR triggers a function level reset through the reset
+// attribute of the device in sysfs.
+func resetGPUWithFLR(bdf string) error {
+	path := hostfs.Path("/sys/bus/pci/devices", bdf, "reset")
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("%s does not support a function level reset: %w", bdf, err)
+	}
+	if err := os.WriteFile(path, []byte("1"), 0200); err != nil {
+		return fmt.Errorf("FLR of %s: %w", bdf, err)
+	}
+	return nil
+}
+
+// waitForGPU waits for the GPU to enumerate again at the same PCI address with
+// the same UUID.
+func waitForGPU(ctx context.Context, target gpuResetTarget, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	var lastErr error
+	for {
+		if lastErr = gpuEnumerated(target); lastErr == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s did not enumerate again within %s: %w", target, timeout, lastErr)
+		case <-ticker.C:
+		}
+	}
+}
+
+func gpuEnumerated(target gpuResetTarget) error {
+	if _, err := os.Stat(hostfs.Path("/sys/bus/pci/devices", target.BDF)); err != nil {
+		return err
+	}
+	nvmlInst := nvml.New()
+	if ret := nvmlInst.Init(); !errors.Is(ret, nvml.SUCCESS) {
+		return fmt.Errorf("NVML: %s", nvml.ErrorString(ret))
+	}
+	defer nvmlInst.Shutdown()
+	device, err := gpuDeviceByID(nvmlInst, target.BDF)
+	if err != nil {
+		return err
+	}
+	uuid, ret := device.GetUUID()
+	if !errors.Is(ret, nvml.SUCCESS) {
+		return fmt.Errorf("NVML: %s", nvml.ErrorString(ret))
+	}
+	if uuid != target.UUID {
+		return fmt.Errorf("%s enumerated as %s", target.BDF, uuid)
+	}
+	return nil
+}
+
+// runGPUHealthCheckAfterReset runs the nvidia HealthCheck and prints it,
+// returning whether the GPUs are healthy.
+func runGPUHealthCheckAfterReset(cmd *cobra.Command) bool {
+	cfgFile, _ := cmd.Flags().GetString("cfg")
+	resolvedCfgFile, err := spec.EnsureCfgFile(cfgFile)
+	if err != nil {
+		logrus.WithField("component", "gpu-reset").Errorf("failed to load cfgFile: %v", err)
+	}
+	specFile, _ := cmd.Flags().GetString("spec")
+	resolvedSpecFile, err := spec.EnsureSpecFile(specFile)
+	if err != nil {
+		logrus.WithField("component", "gpu-reset").Errorf("failed to load specFile: %v", err)
+	}
+	component, err := nvidia.NewComponent(resolvedCfgFile, resolvedSpecFile, nil)
+	if err != nil {
+		printer.Printf("Failed to create the nvidia component for the HealthCheck after the reset: %v\n", err)
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), consts.CmdTimeout)
+	defer cancel()
+	result, err := RunComponentCheck(ctx, component, consts.CmdTimeout)
+	if err != nil {
+		printer.Printf("The nvidia HealthCheck after the reset failed: %v\n", err)
+		return false
+	}
+	PrintCheckResults(true, result)
+	if result.result.Status != consts.StatusNormal {
+		printer.Println("The GPUs are still unhealthy after the reset")
+		return false
+	}
+	printer.Println("The GPUs are healthy after the reset")
+	return true
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/scitix/sichek/pkg/hostfs/hostfstest"
+	"github.com/scitix/sichek/pkg/k8s"
+)
+
+func TestSysfsBDF(t *testing.T) {
+	cases := map[string]string{
+		"00000000:18:00.0": "0000:18:00.0",
+		"0000:3B:00.0":     "0000:3b:00.0",
+	}
+	for in, want := range cases {
+		if got := sysfsBDF(in); got != want {
+			t.Errorf("sysfsBDF(%q) = %q, want %q", in, got, want)
+		}
+	}
+	for _, id := range []string{"00000000:18:00.0", "0000:18:00.0"} {
+		if !gpuBDFRe.MatchString(id) {
+			t.Errorf("expected %q to be a PCI address", id)
+		}
+	}
+	if gpuBDFRe.MatchString("3") || gpuBDFRe.MatchString("GPU-1234") {
+		t.Errorf("expected an index or a UUID not to be a PCI address")
+	}
+}
+
+func TestGPUResetBlockers(t *testing.T) {
+	pods := podsOfGPU(map[string]*k8s.PodInfo{
+		"GPU-a": {Namespace: "default", PodName: "train-0"},
+		"GPU-b": {Namespace: "default", PodName: "train-1"},
+	}, "GPU-a")
+	if len(pods) != 1 || pods[0] != "default/train-0" {
+		t.Fatalf("unexpected pods %v", pods)
+	}
+
+	allocated := gpuResetBlockers{Pods: pods}
+	if !allocated.Refuse(false) {
+		t.Errorf("expected a GPU allocated to a pod to refuse the reset")
+	}
+	if allocated.Refuse(true) {
+		t.Errorf("expected --force to override the pod allocation")
+	}
+	busy := gpuResetBlockers{Processes: []string{"pid 1234 (python)"}}
+	if !busy.Refuse(true) {
+		t.Errorf("expected a running process to refuse the reset even with --force")
+	}
+	if !strings.Contains(busy.String(), "pid 1234") {
+		t.Errorf("unexpected blockers %q", busy.String())
+	}
+	if (gpuResetBlockers{}).Refuse(false) {
+		t.Errorf("expected an idle GPU to be reset")
+	}
+}
+
+func TestResetGPUWithFLR(t *testing.T) {
+	dir := filepath.Join(hostfstest.Build(t, ""), "sys/bus/pci/devices")
+
+	bdf := "0000:18:00.0"
+	if err := os.MkdirAll(filepath.Join(dir, bdf), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := resetGPU(context.Background(), bdf, GPUResetMethodFLR); err == nil {
+		t.Errorf("expected a device without the reset attribute to fail")
+	}
+	if err := os.WriteFile(filepath.Join(dir, bdf, "reset"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	method, err := resetGPU(context.Background(), bdf, GPUResetMethodFLR)
+	if err != nil || method != GPUResetMethodFLR {
+		t.Fatalf("resetGPU = %s, %v", method, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, bdf, "reset")); string(data) != "1" {
+		t.Errorf("expected 1 written to the reset attribute, got %q", data)
+	}
+}
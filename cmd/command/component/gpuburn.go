@@ -0,0 +1,442 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/nvidia"
+	"github.com/scitix/sichek/components/nvidia/collector"
+	nvidiaconfig "github.com/scitix/sichek/components/nvidia/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/scitix/sichek/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	GpuBurnTestName   = "GpuBurn"
+	defaultGpuBurnBin = "gpu_burn"
+)
+
+// gpuBurnStatusRegexp matches the final verdict of gpu_burn, e.g. "GPU 0: OK" or "GPU 3: FAULTY".
+var gpuBurnStatusRegexp = regexp.MustCompile(`GPU (\d+): (OK|FAULTY)`)
+
+// gpuBurnDevice accumulates the samples of a GPU collected during the burn.
+type gpuBurnDevice struct {
+	Index         int
+	Samples       int
+	MaxTemp       uint32
+	SlowdownTemp  uint32
+	MaxPowerMW    uint32
+	PeakSMClk     uint32
+	MinSMClk      uint32
+	BaseCorrected uint64
+	BaseUncorrect uint64
+	Corrected     uint64
+	Uncorrected   uint64
+	ClockEvents   map[string]struct{}
+	BurnFaulty    bool
+}
+
+// gpuBurnMonitor watches the GPUs with the nvidia collector and the xid poller while gpu_burn runs.
+type gpuBurnMonitor struct {
+	mu      sync.Mutex
+	devices map[int]*gpuBurnDevice
+	// xids are keyed by the GPU module id reported by the xid poller, not the index.
+	xids map[int][]uint64
+	// clockDrop is the ratio of the peak SM clock under which the clock is considered dropped.
+	clockDrop float64
+}
+
+func newGpuBurnMonitor(clockDropPercent float64) *gpuBurnMonitor {
+	return &gpuBurnMonitor{devices: make(map[int]*gpuBurnDevice), xids: make(map[int][]uint64), clockDrop: clockDropPercent / 100}
+}
+
+func (m *gpuBurnMonitor) device(index int) *gpuBurnDevice {
+	dev, ok := m.devices[index]
+	if !ok {
+		dev = &gpuBurnDevice{Index: index, ClockEvents: make(map[string]struct{})}
+		m.devices[index] = dev
+	}
+	return dev
+}
+
+// Observe records a sample of the collector. The ECC counters of the first
+// sample are the baseline, only the errors raised during the burn count.
+// The SM clock is only tracked while the GPU is busy, the clock of an idle GPU
+// drops by design.
+func (m *gpuBurnMonitor) Observe(info *collector.NvidiaInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range info.DevicesInfo {
+		dev := m.device(d.Index)
+		ecc := d.MemoryErrors.VolatileECC.Total
+		if dev.Samples == 0 {
+			dev.BaseCorrected, dev.BaseUncorrect = ecc.Corrected, ecc.Uncorrected
+		}
+		dev.Samples++
+		dev.Corrected = ecc.Corrected - min(ecc.Corrected, dev.BaseCorrected)
+		dev.Uncorrected = ecc.Uncorrected - min(ecc.Uncorrected, dev.BaseUncorrect)
+		dev.MaxTemp = max(dev.MaxTemp, d.Temperature.GPUCurTemperature)
+		dev.SlowdownTemp = d.Temperature.GPUThresholdTemperatureSlowdown
+		dev.MaxPowerMW = max(dev.MaxPowerMW, d.Power.PowerUsage)
+		for _, event := range d.ClockEvents.CriticalClockEvents {
+			dev.ClockEvents[event.Name] = struct{}{}
+		}
+		if d.ClockEvents.GpuIdle || d.Utilization.GPUUsagePercent < 90 {
+			continue
+		}
+		dev.PeakSMClk = max(dev.PeakSMClk, d.Clock.CurSMClk)
+		if dev.MinSMClk == 0 || d.Clock.CurSMClk < dev.MinSMClk {
+			dev.MinSMClk = d.Clock.CurSMClk
+		}
+	}
+}
+
+// ObserveXid records the xid events reported by the xid poller.
+func (m *gpuBurnMonitor) ObserveXid(result *common.Result) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, checker := range result.Checkers {
+		var moduleID int
+		var xid uint64
+		if _, err := fmt.Sscanf(checker.Detail, "GPU device %d detect critical xid event %d", &moduleID, &xid); err != nil {
+			logrus.WithField("gpuburn", "xid").Warnf("unexpected xid event detail %q", checker.Detail)
+			continue
+		}
+		m.xids[moduleID] = append(m.xids[moduleID], xid)
+	}
+}
+
+// ObserveBurnOutput records the per GPU verdict printed by gpu_burn.
+func (m *gpuBurnMonitor) ObserveBurnOutput(line string) {
+	match := gpuBurnStatusRegexp.FindStringSubmatch(line)
+	if match == nil {
+		return
+	}
+	index, _ := strconv.Atoi(match[1])
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	dev := m.device(index)
+	dev.BurnFaulty = match[2] == "FAULTY"
+}
+
+func (m *gpuBurnMonitor) sortedDevices() []*gpuBurnDevice {
+	devices := make([]*gpuBurnDevice, 0, len(m.devices))
+	for _, dev := range m.devices {
+		devices = append(devices, dev)
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Index < devices[j].Index })
+	return devices
+}
+
+// Result turns the observations into a result, burnErr is the error of the gpu_burn run.
+func (m *gpuBurnMonitor) Result(burnErr error) *common.Result {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	res := &common.Result{
+		Item:   GpuBurnTestName,
+		Status: consts.StatusNormal,
+		Level:  consts.LevelInfo,
+		Time:   time.Now(),
+	}
+	fail := func(device, errorName, detail, suggestion string) {
+		res.Status = consts.StatusAbnormal
+		res.Level = consts.LevelCritical
+		res.Checkers = append(res.Checkers, &common.CheckerResult{
+			Name:        "GpuBurnTest",
+			Description: "GPU burn-in test",
+			Device:      device,
+			Status:      consts.StatusAbnormal,
+			Level:       consts.LevelCritical,
+			Detail:      detail,
+			ErrorName:   errorName,
+			Suggestion:  suggestion,
+		})
+	}
+	if burnErr != nil {
+		fail("", "GpuBurnFailed", fmt.Sprintf("gpu_burn failed: %v", burnErr), "Check the gpu_burn output with -v")
+	}
+	for _, dev := range m.sortedDevices() {
+		gpu := fmt.Sprintf("GPU%d", dev.Index)
+		if dev.BurnFaulty {
+			fail(gpu, "GpuBurnComputeError", fmt.Sprintf("%s computed wrong results under load", gpu), "Replace the GPU device")
+		}
+		if dev.Uncorrected > 0 {
+			fail(gpu, "GpuBurnEccError", fmt.Sprintf("%s raised %d uncorrectable ECC errors under load", gpu, dev.Uncorrected), "Reset the GPU device and check the remapped rows")
+		}
+		if len(dev.ClockEvents) > 0 {
+			events := make([]string, 0, len(dev.ClockEvents))
+			for name := range dev.ClockEvents {
+				events = append(events, name)
+			}
+			sort.Strings(events)
+			fail(gpu, "GpuBurnThrottling", fmt.Sprintf("%s throttled under load: %s", gpu, strings.Join(events, ", ")), "Check the cooling and power supply of the node")
+		}
+		if dev.SlowdownTemp > 0 && dev.MaxTemp >= dev.SlowdownTemp {
+			fail(gpu, "GpuBurnOverheat", fmt.Sprintf("%s reached %d C, slowdown threshold is %d C", gpu, dev.MaxTemp, dev.SlowdownTemp), "Check the cooling of the node")
+		}
+		if dev.PeakSMClk > 0 && float64(dev.MinSMClk) < float64(dev.PeakSMClk)*(1-m.clockDrop) {
+			fail(gpu, "GpuBurnClockDrop", fmt.Sprintf("%s SM clock dropped to %d MHz under load, peak is %d MHz", gpu, dev.MinSMClk, dev.PeakSMClk), "Check the clock events and power limit with nvidia-smi -q -d PERFORMANCE")
+		}
+	}
+	moduleIDs := make([]int, 0, len(m.xids))
+	for moduleID := range m.xids {
+		moduleIDs = append(moduleIDs, moduleID)
+	}
+	sort.Ints(moduleIDs)
+	for _, moduleID := range moduleIDs {
+		gpu := fmt.Sprintf("GPU module %d", moduleID)
+		fail(gpu, "GpuBurnXidError", fmt.Sprintf("%s raised xid %v under load", gpu, m.xids[moduleID]), "Check the xid with nvidia-bug-report.sh")
+	}
+	if res.Status == consts.StatusNormal {
+		res.Checkers = append(res.Checkers, &common.CheckerResult{
+			Name:        "GpuBurnTest",
+			Description: "GPU burn-in test",
+			Status:      consts.StatusNormal,
+			Level:       consts.LevelInfo,
+			Detail:      fmt.Sprintf("GPU burn-in test passed on %d GPUs", len(m.devices)),
+			ErrorName:   "GpuBurnFailed",
+		})
+	}
+	return res
+}
+
+func NewGpuBurnCmd() *cobra.Command {
+	gpuBurnCmd := &cobra.Command{
+		Use:   "gpuburn",
+		Short: "Perform GPU burn-in test and monitor throttling, ECC errors, clocks and xids",
+		Run: func(cmd *cobra.Command, args []string) {
+			verbose, err := cmd.Flags().GetBool("verbose")
+			if err != nil {
+				logrus.WithField("gpuburn", "nvidia").Errorf("get to ge the verbose: %v", err)
+			}
+			if !verbose {
+				logrus.SetLevel(logrus.ErrorLevel)
+			}
+			if !utils.IsNvidiaGPUExist() {
+				logrus.Warn("nvidia GPU is not Exist. Bypassing GPU burn-in test")
+				return
+			}
+			binPath, err := cmd.Flags().GetString("bin")
+			if err != nil {
+				logrus.WithField("gpuburn", "nvidia").Error(err)
+				return
+			}
+			duration, err := cmd.Flags().GetDuration("duration")
+			if err != nil {
+				logrus.WithField("gpuburn", "nvidia").Error(err)
+				return
+			}
+			interval, err := cmd.Flags().GetDuration("interval")
+			if err != nil {
+				logrus.WithField("gpuburn", "nvidia").Error(err)
+				return
+			}
+			memory, err := cmd.Flags().GetString("memory")
+			if err != nil {
+				logrus.WithField("gpuburn", "nvidia").Error(err)
+				return
+			}
+			tensorCores, err := cmd.Flags().GetBool("tensor-cores")
+			if err != nil {
+				logrus.WithField("gpuburn", "nvidia").Error(err)
+				return
+			}
+			clockDrop, err := cmd.Flags().GetFloat64("clock-drop")
+			if err != nil {
+				logrus.WithField("gpuburn", "nvidia").Error(err)
+				return
+			}
+
+			printer.Printf("Running GPU burn-in test for %s\n", duration)
+			res, err := CheckGpuBurn(binPath, duration, interval, memory, tensorCores, clockDrop, verbose)
+			if err != nil {
+				logrus.WithField("gpuburn", "nvidia").Error(err)
+				printer.Printf("%s%v%s\n", consts.Red, err, consts.Reset)
+				SetComponentStatus(GpuBurnTestName, false, "")
+				return
+			}
+			passed := PrintGpuBurnInfo(res)
+			SetComponentStatus(res.Item, passed, res.Level)
+			for _, checkerResult := range res.Checkers {
+				if checkerResult.Status == consts.StatusAbnormal && checkerResult.Device != "" {
+					SetComponentStatus(fmt.Sprintf("%s %s", res.Item, checkerResult.Device), false, checkerResult.Level)
+				}
+			}
+		},
+	}
+
+	gpuBurnCmd.Flags().String("bin", "", "Path to the gpu_burn binary (default: gpu_burn in PATH or sichek scripts dir)")
+	gpuBurnCmd.Flags().Duration("duration", 10*time.Minute, "Duration of the burn-in test")
+	gpuBurnCmd.Flags().Duration("interval", 5*time.Second, "Interval to sample the GPU status during the test")
+	gpuBurnCmd.Flags().StringP("memory", "m", "90%", "GPU memory to use, in MB or percentage of the free memory")
+	gpuBurnCmd.Flags().Bool("tensor-cores", false, "Use tensor cores")
+	gpuBurnCmd.Flags().Float64("clock-drop", 20, "Fail if the SM clock under load drops more than this percentage of its peak")
+	gpuBurnCmd.Flags().BoolP("verbose", "v", false, "Enable verbose output")
+
+	return gpuBurnCmd
+}
+
+func resolveGpuBurnPath(binPath string) (string, error) {
+	if binPath != "" {
+		return binPath, nil
+	}
+	if path, err := exec.LookPath(defaultGpuBurnBin); err == nil {
+		return path, nil
+	}
+	return GetDefaultNcclTestPath(defaultGpuBurnBin)
+}
+
+// CheckGpuBurn runs gpu_burn on all GPUs and samples them with the nvidia collector every interval.
+func CheckGpuBurn(binPath string, duration, interval time.Duration, memory string, tensorCores bool, clockDropPercent float64, verbose bool) (*common.Result, error) {
+	path, err := resolveGpuBurnPath(binPath)
+	if err != nil {
+		return nil, fmt.Errorf("resolve gpu_burn path failed: %w", err)
+	}
+
+	nvmlInst := nvml.New()
+	if ret := nvmlInst.Init(); !errors.Is(ret, nvml.SUCCESS) {
+		return nil, fmt.Errorf("failed to initialize NVML: %v", nvml.ErrorString(ret))
+	}
+	defer nvmlInst.Shutdown()
+	deviceCount, ret := nvmlInst.DeviceGetCount()
+	if !errors.Is(ret, nvml.SUCCESS) {
+		return nil, fmt.Errorf("failed to get device count: %s", nvml.ErrorString(ret))
+	}
+
+	// Leave gpu_burn a minute to initialize and report the verdict.
+	ctx, cancel := context.WithTimeout(context.Background(), duration+time.Minute)
+	defer cancel()
+	nvidiaCollector, err := collector.NewNvidiaCollector(ctx, &nvmlInst, deviceCount, "")
+	if err != nil {
+		return nil, fmt.Errorf("create nvidia collector failed: %w", err)
+	}
+	monitor := newGpuBurnMonitor(clockDropPercent)
+	if info, err := nvidiaCollector.Collect(ctx); err == nil {
+		monitor.Observe(info)
+	} else {
+		return nil, fmt.Errorf("collect nvidia info before burn failed: %w", err)
+	}
+
+	var nvmlMtx sync.RWMutex
+	xidEvents := common.NewResultQueue(consts.ComponentNameNvidia, 64)
+	xidPoller, err := nvidia.NewXidEventPoller(ctx, &nvidiaconfig.NvidiaUserConfig{}, nvmlInst, &nvmlMtx, xidEvents, nil, nil)
+	if err != nil {
+		logrus.WithField("gpuburn", "nvidia").Warnf("xid poller not available, xids are not monitored: %v", err)
+	} else {
+		go func() {
+			if err := xidPoller.Start(); err != nil {
+				logrus.WithField("gpuburn", "nvidia").Warnf("start xid poller failed: %v", err)
+			}
+		}()
+		defer xidPoller.Stop()
+	}
+
+	args := []string{"-m", memory}
+	if tensorCores {
+		args = append(args, "-tc")
+	}
+	args = append(args, strconv.Itoa(int(duration.Seconds())))
+	burn := exec.CommandContext(ctx, path, args...)
+	// gpu_burn loads compare.ptx from its working directory.
+	burn.Dir = filepath.Dir(path)
+	// Number the GPUs of gpu_burn in the same order as NVML.
+	burn.Env = append(os.Environ(), "CUDA_DEVICE_ORDER=PCI_BUS_ID")
+	stdout, err := burn.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("gpu_burn stdout pipe failed: %w", err)
+	}
+	burn.Stderr = burn.Stdout
+	logrus.WithField("gpuburn", "nvidia").Infof("Command: %s\n", burn.String())
+	if err := burn.Start(); err != nil {
+		return nil, fmt.Errorf("start gpu_burn failed: %w", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if verbose {
+				printer.Println(line)
+			}
+			monitor.ObserveBurnOutput(line)
+		}
+		done <- burn.Wait()
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var burnErr error
+	for running := true; running; {
+		select {
+		case burnErr = <-done:
+			running = false
+		case result := <-xidEvents.C():
+			monitor.ObserveXid(result)
+		case <-ticker.C:
+			nvmlMtx.RLock()
+			info, err := nvidiaCollector.Collect(ctx)
+			nvmlMtx.RUnlock()
+			if err != nil {
+				logrus.WithField("gpuburn", "nvidia").Warnf("collect nvidia info failed: %v", err)
+				continue
+			}
+			monitor.Observe(info)
+		}
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		burnErr = fmt.Errorf("gpu_burn did not finish within %s", duration+time.Minute)
+	}
+	// Drain the xids raised at the end of the run.
+	for drained := false; !drained; {
+		select {
+		case result := <-xidEvents.C():
+			monitor.ObserveXid(result)
+		default:
+			drained = true
+		}
+	}
+	return monitor.Result(burnErr), nil
+}
+
+func PrintGpuBurnInfo(result *common.Result) bool {
+	for _, checkerResult := range result.Checkers {
+		if checkerResult.Status == consts.StatusAbnormal {
+			printer.Printf("%s%s%s\n", consts.Red, checkerResult.Detail, consts.Reset)
+		} else {
+			printer.Printf("%s%s%s\n", consts.Green, checkerResult.Detail, consts.Reset)
+		}
+	}
+	return result.Status == consts.StatusNormal
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"testing"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/nvidia/collector"
+	"github.com/scitix/sichek/consts"
+)
+
+func burnSample(index int, temp, smClk uint32, uncorrected uint64, events ...collector.ClockEvent) collector.DeviceInfo {
+	var d collector.DeviceInfo
+	d.Index = index
+	d.Temperature.GPUCurTemperature = temp
+	d.Temperature.GPUThresholdTemperatureSlowdown = 87
+	d.Clock.CurSMClk = smClk
+	d.Utilization.GPUUsagePercent = 100
+	d.MemoryErrors.VolatileECC.Total.Uncorrected = uncorrected
+	d.ClockEvents.CriticalClockEvents = events
+	return d
+}
+
+func TestGpuBurnMonitorPassed(t *testing.T) {
+	m := newGpuBurnMonitor(20)
+	// The ECC errors raised before the burn are the baseline.
+	m.Observe(&collector.NvidiaInfo{DevicesInfo: []collector.DeviceInfo{burnSample(0, 40, 1980, 2), burnSample(1, 40, 1980, 0)}})
+	m.Observe(&collector.NvidiaInfo{DevicesInfo: []collector.DeviceInfo{burnSample(0, 75, 1800, 2), burnSample(1, 76, 1755, 0)}})
+	m.ObserveBurnOutput("GPU 0: OK")
+	m.ObserveBurnOutput("GPU 1: OK")
+
+	res := m.Result(nil)
+	if res.Status != consts.StatusNormal {
+		t.Fatalf("expected burn passed, got %+v", res.Checkers[0])
+	}
+}
+
+func TestGpuBurnMonitorFailed(t *testing.T) {
+	m := newGpuBurnMonitor(20)
+	thermal := collector.CriticalClockEvents[0x40]
+	m.Observe(&collector.NvidiaInfo{DevicesInfo: []collector.DeviceInfo{burnSample(0, 40, 1980, 0), burnSample(1, 40, 1980, 0), burnSample(2, 40, 1980, 0)}})
+	m.Observe(&collector.NvidiaInfo{DevicesInfo: []collector.DeviceInfo{burnSample(0, 88, 1200, 0, thermal), burnSample(1, 70, 1900, 1), burnSample(2, 70, 1900, 0)}})
+	m.ObserveBurnOutput("GPU 2: FAULTY")
+	m.ObserveXid(&common.Result{Checkers: []*common.CheckerResult{{Detail: "GPU device 3 detect critical xid event 79"}}})
+
+	res := m.Result(nil)
+	if res.Status != consts.StatusAbnormal || res.Level != consts.LevelCritical {
+		t.Fatalf("expected burn failed, got %s/%s", res.Status, res.Level)
+	}
+	got := make(map[string][]string)
+	for _, checker := range res.Checkers {
+		got[checker.Device] = append(got[checker.Device], checker.ErrorName)
+	}
+	want := map[string][]string{
+		"GPU0":         {"GpuBurnThrottling", "GpuBurnOverheat", "GpuBurnClockDrop"},
+		"GPU1":         {"GpuBurnEccError"},
+		"GPU2":         {"GpuBurnComputeError"},
+		"GPU module 3": {"GpuBurnXidError"},
+	}
+	for device, errs := range want {
+		if len(got[device]) != len(errs) {
+			t.Errorf("%s: got %v, want %v", device, got[device], errs)
+			continue
+		}
+		for i := range errs {
+			if got[device][i] != errs[i] {
+				t.Errorf("%s: got %v, want %v", device, got[device], errs)
+			}
+		}
+	}
+}
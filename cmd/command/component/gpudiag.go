@@ -0,0 +1,289 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/scitix/sichek/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const GpuDiagTestName = "GpuDiag"
+
+// dcgmDiagOutput is the structured output of `dcgmi diag -j`, e.g.
+//
+//	{"DCGM GPU Diagnostic": {"test_categories": [{"category": "Integration",
+//	  "tests": [{"name": "PCIe", "results": [{"gpu_id": "0", "status": "Fail",
+//	  "warnings": [{"warning": "..."}]}]}]}], "version": "3.3.5"}}
+type dcgmDiagOutput struct {
+	Diag struct {
+		Categories []struct {
+			Category string `json:"category"`
+			Tests    []struct {
+				Name    string           `json:"name"`
+				Results []dcgmDiagResult `json:"results"`
+			} `json:"tests"`
+		} `json:"test_categories"`
+		Version string `json:"version"`
+	} `json:"DCGM GPU Diagnostic"`
+}
+
+// dcgmDiagResult is the result of a test on one GPU, gpu_id is absent for
+// system wide tests. DCGM versions differ in how they encode the gpu id and
+// the warnings, so both are decoded lazily.
+type dcgmDiagResult struct {
+	GpuID    json.RawMessage `json:"gpu_id"`
+	GpuIDs   json.RawMessage `json:"gpu_ids"`
+	Status   string          `json:"status"`
+	Warnings json.RawMessage `json:"warnings"`
+	Info     json.RawMessage `json:"info"`
+}
+
+func NewGpuDiagCmd() *cobra.Command {
+	gpuDiagCmd := &cobra.Command{
+		Use:   "gpudiag",
+		Short: "Perform active GPU diagnostics with DCGM (dcgmi diag)",
+		Run: func(cmd *cobra.Command, args []string) {
+			verbose, err := cmd.Flags().GetBool("verbose")
+			if err != nil {
+				logrus.WithField("gpudiag", "dcgm").Errorf("get to ge the verbose: %v", err)
+			}
+			if !verbose {
+				logrus.SetLevel(logrus.ErrorLevel)
+			}
+			if !utils.IsNvidiaGPUExist() {
+				logrus.Warn("nvidia GPU is not Exist. Bypassing GPU diagnostics")
+				return
+			}
+			binPath, err := cmd.Flags().GetString("bin")
+			if err != nil {
+				logrus.WithField("gpudiag", "dcgm").Error(err)
+				return
+			}
+			level, err := cmd.Flags().GetInt("level")
+			if err != nil {
+				logrus.WithField("gpudiag", "dcgm").Error(err)
+				return
+			}
+			timeout, err := cmd.Flags().GetInt("timeout")
+			if err != nil {
+				logrus.WithField("gpudiag", "dcgm").Error(err)
+				return
+			}
+
+			printer.Printf("Running DCGM diagnostics at level %d, timeout %ds\n", level, timeout)
+			res, err := CheckGpuDiag(binPath, level, timeout)
+			if err != nil {
+				logrus.WithField("gpudiag", "dcgm").Error(err)
+				printer.Printf("%s%v%s\n", consts.Red, err, consts.Reset)
+				SetComponentStatus(GpuDiagTestName, false, "")
+				return
+			}
+			passed := PrintGpuDiagInfo(res)
+			SetComponentStatus(res.Item, passed, res.Level)
+			for _, checkerResult := range res.Checkers {
+				if checkerResult.Status == consts.StatusAbnormal && checkerResult.Device != "" {
+					SetComponentStatus(fmt.Sprintf("%s %s", res.Item, checkerResult.Device), false, checkerResult.Level)
+				}
+			}
+		},
+	}
+
+	gpuDiagCmd.Flags().String("bin", "", "Path to the dcgmi binary (default: dcgmi in PATH)")
+	gpuDiagCmd.Flags().IntP("level", "r", 1, "DCGM diagnostic level: 1 (quick), 2 (medium), 3 (long), 4 (extended)")
+	gpuDiagCmd.Flags().BoolP("verbose", "v", false, "Enable verbose output")
+	gpuDiagCmd.Flags().IntP("timeout", "t", 1800, "Timeout in seconds")
+
+	return gpuDiagCmd
+}
+
+func runDcgmDiag(binPath string, level, timeout int) ([]byte, error) {
+	if binPath == "" {
+		path, err := exec.LookPath("dcgmi")
+		if err != nil {
+			return nil, fmt.Errorf("dcgmi not found in PATH, please install DCGM: %w", err)
+		}
+		binPath = path
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, binPath, "diag", "-r", fmt.Sprint(level), "-j")
+	logrus.WithField("gpudiag", "dcgm").Infof("Command: %s\n", cmd.String())
+	output, err := cmd.Output()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("dcgmi diag timed out after %d seconds", timeout)
+	}
+	// dcgmi exits non-zero when a test fails, the JSON output is still valid then.
+	if err != nil && !json.Valid(output) {
+		return nil, fmt.Errorf("dcgmi diag command failed: %v. output: %s", err, string(output))
+	}
+	logrus.WithField("gpudiag", "dcgm").Infof("output: %s\n", string(output))
+	return output, nil
+}
+
+func parseDcgmDiag(output []byte) (*dcgmDiagOutput, error) {
+	var diag dcgmDiagOutput
+	if err := json.Unmarshal(output, &diag); err != nil {
+		return nil, fmt.Errorf("parse dcgmi diag output failed: %w", err)
+	}
+	if len(diag.Diag.Categories) == 0 {
+		return nil, fmt.Errorf("no test found in dcgmi diag output")
+	}
+	return &diag, nil
+}
+
+// dcgmDiagMessages flattens the warnings or info of a result, which is either
+// a string, a list of strings or a list of {"warning": "..."} objects.
+func dcgmDiagMessages(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return string(raw)
+	}
+	var msgs []string
+	for _, item := range items {
+		if err := json.Unmarshal(item, &s); err == nil {
+			msgs = append(msgs, s)
+			continue
+		}
+		var obj struct {
+			Warning string `json:"warning"`
+		}
+		if err := json.Unmarshal(item, &obj); err == nil && obj.Warning != "" {
+			msgs = append(msgs, obj.Warning)
+		}
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (r *dcgmDiagResult) device() string {
+	for _, raw := range []json.RawMessage{r.GpuID, r.GpuIDs} {
+		id := strings.Trim(string(raw), `"`)
+		if id != "" && id != "null" {
+			return "GPU" + id
+		}
+	}
+	return ""
+}
+
+func checkDcgmDiag(diag *dcgmDiagOutput, level int) *common.Result {
+	res := &common.Result{
+		Item:   GpuDiagTestName,
+		Status: consts.StatusNormal,
+		Level:  consts.LevelInfo,
+		Time:   time.Now(),
+	}
+	tests := 0
+	for _, category := range diag.Diag.Categories {
+		for _, test := range category.Tests {
+			tests++
+			for _, result := range test.Results {
+				var checkLevel string
+				switch strings.ToLower(result.Status) {
+				case "fail":
+					checkLevel = consts.LevelCritical
+				case "warn":
+					checkLevel = consts.LevelWarning
+				default:
+					continue
+				}
+				detail := dcgmDiagMessages(result.Warnings)
+				if detail == "" {
+					detail = dcgmDiagMessages(result.Info)
+				}
+				res.Status = consts.StatusAbnormal
+				if consts.LevelPriority[checkLevel] > consts.LevelPriority[res.Level] {
+					res.Level = checkLevel
+				}
+				res.Checkers = append(res.Checkers, &common.CheckerResult{
+					Name:        "DCGMDiag" + strings.ReplaceAll(test.Name, " ", ""),
+					Description: fmt.Sprintf("DCGM %s diagnostic %s", category.Category, test.Name),
+					Device:      result.device(),
+					Spec:        "Pass",
+					Curr:        result.Status,
+					Status:      consts.StatusAbnormal,
+					Level:       checkLevel,
+					Detail:      fmt.Sprintf("DCGM diagnostic %s %s on %s: %s", test.Name, result.Status, deviceOrNode(result.device()), detail),
+					ErrorName:   "GpuDiagTestError",
+					Suggestion:  "Drain the node and check the GPU with dcgmi diag -r 3 before returning it to the pool",
+				})
+			}
+		}
+	}
+	if res.Status == consts.StatusNormal {
+		res.Checkers = append(res.Checkers, &common.CheckerResult{
+			Name:        "DCGMDiag",
+			Description: "DCGM GPU diagnostics",
+			Spec:        "Pass",
+			Curr:        "Pass",
+			Status:      consts.StatusNormal,
+			Level:       consts.LevelInfo,
+			Detail:      fmt.Sprintf("DCGM level %d diagnostics passed, %d tests run (DCGM %s).", level, tests, diag.Diag.Version),
+			ErrorName:   "GpuDiagTestError",
+		})
+	}
+	return res
+}
+
+func deviceOrNode(device string) string {
+	if device == "" {
+		return "node"
+	}
+	return device
+}
+
+func CheckGpuDiag(binPath string, level, timeout int) (*common.Result, error) {
+	if level < 1 || level > 4 {
+		return nil, fmt.Errorf("invalid dcgm diagnostic level %d, expected 1-4", level)
+	}
+	output, err := runDcgmDiag(binPath, level, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("run dcgmi diag fail: %v", err)
+	}
+	diag, err := parseDcgmDiag(output)
+	if err != nil {
+		return nil, err
+	}
+	return checkDcgmDiag(diag, level), nil
+}
+
+func PrintGpuDiagInfo(result *common.Result) bool {
+	for _, checkerResult := range result.Checkers {
+		if checkerResult.Status == consts.StatusAbnormal {
+			printer.Printf("%s%s%s\n", consts.LevelColor(checkerResult.Level), checkerResult.Detail, consts.Reset)
+		} else {
+			printer.Printf("%s%s%s\n", consts.Green, checkerResult.Detail, consts.Reset)
+		}
+	}
+	return result.Status == consts.StatusNormal
+}
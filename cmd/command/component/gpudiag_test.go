@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"testing"
+
+	"github.com/scitix/sichek/consts"
+)
+
+const dcgmDiagOutputJSON = `{
+  "DCGM GPU Diagnostic": {
+    "test_categories": [
+      {
+        "category": "Deployment",
+        "tests": [
+          {"name": "Denylist", "results": [{"status": "Pass"}]},
+          {"name": "Persistence Mode", "results": [{"status": "Warn", "warnings": "Persistence mode for GPU 1 is disabled."}]}
+        ]
+      },
+      {
+        "category": "Integration",
+        "tests": [
+          {"name": "PCIe", "results": [
+            {"gpu_id": "0", "status": "Pass"},
+            {"gpu_id": "1", "status": "Fail", "warnings": [{"warning": "GPU 1 PCIe replay rate exceeded", "error_id": 54}]}
+          ]}
+        ]
+      }
+    ],
+    "version": "3.3.5"
+  }
+}`
+
+func TestCheckDcgmDiag(t *testing.T) {
+	diag, err := parseDcgmDiag([]byte(dcgmDiagOutputJSON))
+	if err != nil {
+		t.Fatalf("parseDcgmDiag: %v", err)
+	}
+	res := checkDcgmDiag(diag, 1)
+	if res.Status != consts.StatusAbnormal || res.Level != consts.LevelCritical {
+		t.Fatalf("expected abnormal critical result, got %s/%s", res.Status, res.Level)
+	}
+	if len(res.Checkers) != 2 {
+		t.Fatalf("expected 2 checker results, got %+v", res.Checkers)
+	}
+	warn, fail := res.Checkers[0], res.Checkers[1]
+	if warn.Level != consts.LevelWarning || warn.Device != "" {
+		t.Errorf("unexpected persistence mode result: %+v", warn)
+	}
+	if fail.Level != consts.LevelCritical || fail.Device != "GPU1" || fail.Name != "DCGMDiagPCIe" {
+		t.Errorf("unexpected PCIe result: %+v", fail)
+	}
+	if want := "DCGM diagnostic PCIe Fail on GPU1: GPU 1 PCIe replay rate exceeded"; fail.Detail != want {
+		t.Errorf("Detail=%q, want %q", fail.Detail, want)
+	}
+}
+
+func TestCheckDcgmDiagPassed(t *testing.T) {
+	diag, err := parseDcgmDiag([]byte(`{"DCGM GPU Diagnostic": {"test_categories": [{"category": "Deployment",
+		"tests": [{"name": "Denylist", "results": [{"status": "Pass"}]}]}], "version": "3.3.5"}}`))
+	if err != nil {
+		t.Fatalf("parseDcgmDiag: %v", err)
+	}
+	res := checkDcgmDiag(diag, 1)
+	if res.Status != consts.StatusNormal || len(res.Checkers) != 1 {
+		t.Fatalf("expected normal result, got %+v", res)
+	}
+	if _, err := parseDcgmDiag([]byte(`{}`)); err == nil {
+		t.Error("expected error on empty output")
+	}
+}
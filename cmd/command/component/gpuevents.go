@@ -17,12 +17,12 @@ package component
 
 import (
 	"context"
-	"fmt"
 	"sync"
 	"time"
 
 	"github.com/scitix/sichek/components/common"
 	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -84,10 +84,10 @@ func NewGpuEventsCommand() *cobra.Command {
 				defer wg.Done()
 				select {
 				case <-ctx.Done():
-					fmt.Println("Timeout! Task canceled.")
+					printer.Println("Timeout! Task canceled.")
 					return
 				default:
-					fmt.Println("Task running...")
+					printer.Println("Task running...")
 					begin := time.Now()
 					for time.Since(begin).Seconds() < 720 {
 						_, err := component.HealthCheck(ctx)
@@ -97,7 +97,7 @@ func NewGpuEventsCommand() *cobra.Command {
 						}
 						time.Sleep(10 * time.Second)
 					}
-					fmt.Println("...Task finished")
+					printer.Println("...Task finished")
 				}
 			}(subctx)
 			wg.Wait()
@@ -114,9 +114,7 @@ func NewGpuEventsCommand() *cobra.Command {
 				logrus.WithField("component", "all").Errorf("get to ge the LastInfo: %v", err)
 			}
 			pass := component.PrintInfo(info, result, true)
-			StatusMutex.Lock()
-			ComponentStatuses[consts.ComponentNameGpuEvents] = pass
-			StatusMutex.Unlock()
+			SetComponentStatus(consts.ComponentNameGpuEvents, pass, result.Level)
 		},
 	}
 
@@ -17,11 +17,11 @@ package component
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/scitix/sichek/components/hca/config"
 	"github.com/scitix/sichek/components/infiniband/perftest"
 	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -29,8 +29,9 @@ import (
 func NewIBPerftestCmd() *cobra.Command {
 
 	ibPerftestCmd := &cobra.Command{
-		Use:   "ibtest",
-		Short: "Perform Infiniband performance tests",
+		Use:     "ibtest",
+		Aliases: []string{"ibperf"},
+		Short:   "Perform Infiniband performance tests",
 		Run: func(cmd *cobra.Command, args []string) {
 			_, cancel := context.WithTimeout(context.Background(), consts.IbPerfTestTimeout)
 			passed := true
@@ -110,25 +111,31 @@ func NewIBPerftestCmd() *cobra.Command {
 				logrus.WithField("perftest", "infiniband").Error(err)
 				passed = false
 			}
+			var thresholds map[string]perftest.PerfThreshold
 			if expectedBandwidthGbps == 0 && expectedLatencyUs == 0 {
 				specs, err := config.LoadSpec("/var/sichek/config/default_spec.yaml")
 				if err != nil {
 					logrus.WithField("perftest", "infiniband").Errorf("failed to load HCA spec config: %v", err)
-					fmt.Println("No expected bandwidth or latency specified, using 0 Gbps and 0 us")
+					printer.Println("No expected bandwidth or latency specified, using 0 Gbps and 0 us")
 				} else {
-					for _, spec := range specs.GetMap() {
-						expectedBandwidthGbps = spec.Perf.OneWayBW
-						expectedLatencyUs = spec.Perf.AvgLatency
-						fmt.Printf("Using %s expected bandwidth: %.2f Gbps and latency: %.2f us\n", spec.Hardware.VPD, spec.Perf.OneWayBW, spec.Perf.AvgLatency)
-						break // Use the first spec, assuming all have the same perf values
+					// Each HCA type (e.g. ConnectX-7 400G) is checked against the perf of its own board ID.
+					thresholds = make(map[string]perftest.PerfThreshold)
+					for boardID, spec := range specs.GetMap() {
+						thresholds[boardID] = perftest.PerfThreshold{BandwidthGbps: spec.Perf.OneWayBW, LatencyUs: spec.Perf.AvgLatency}
+						printer.Printf("Using %s (%s) expected bandwidth: %.2f Gbps and latency: %.2f us\n", spec.Hardware.VPD, boardID, spec.Perf.OneWayBW, spec.Perf.AvgLatency)
+						if expectedBandwidthGbps == 0 && expectedLatencyUs == 0 {
+							// Fallback for the HCAs whose board ID is not in the spec.
+							expectedBandwidthGbps = spec.Perf.OneWayBW
+							expectedLatencyUs = spec.Perf.AvgLatency
+						}
 					}
 				}
 			} else {
-				fmt.Printf("Using provided expected bandwidth: %.2f Gbps and latency: %.2f us\n", expectedBandwidthGbps, expectedLatencyUs)
+				printer.Printf("Using provided expected bandwidth: %.2f Gbps and latency: %.2f us\n", expectedBandwidthGbps, expectedLatencyUs)
 			}
 
 			if passed {
-				res, err := perftest.CheckNodeIBPerfHealth(testType, expectedBandwidthGbps, expectedLatencyUs, ibDevice, size, duration, gid, qpNum, numaAware, useGDR, rdmaCM, verbose)
+				res, err := perftest.CheckNodeIBPerfHealth(testType, expectedBandwidthGbps, expectedLatencyUs, thresholds, ibDevice, size, duration, gid, qpNum, numaAware, useGDR, rdmaCM, verbose)
 				if err != nil {
 					logrus.WithField("perftest", "infiniband").Error(err)
 				}
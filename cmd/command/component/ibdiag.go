@@ -0,0 +1,236 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// ibdiagStageRegexp matches a stage of the ibdiagnet summary, e.g.
+// "-I- Links Check               2          1".
+var ibdiagStageRegexp = regexp.MustCompile(`^-I-\s+(\S.*?)\s+(\d+)\s+(\d+)\s*(.*)$`)
+
+// maxIBDiagErrors caps the ibdiagnet errors printed.
+const maxIBDiagErrors = 20
+
+var ibSysfsClassDir = "/sys/class/infiniband"
+
+// ibdiagStage is a stage of the ibdiagnet summary.
+type ibdiagStage struct {
+	Name     string
+	Warnings int
+	Errors   int
+	Comment  string
+}
+
+// ibdiagSummary is what ibdiagnet found, Errors are its "-E-" lines.
+type ibdiagSummary struct {
+	Stages []ibdiagStage
+	Errors []string
+}
+
+func (s *ibdiagSummary) ErrorCount() int {
+	total := 0
+	for _, stage := range s.Stages {
+		total += stage.Errors
+	}
+	return total
+}
+
+// NewIBDiagCmd creates the "infiniband ibdiag" command which runs ibdiagnet
+// on demand, scoped to the local HCAs by default, to surface the fabric side
+// issues (bad links of the switches, duplicated GUIDs or LIDs, SM issues)
+// the local port state cannot show.
+func NewIBDiagCmd() *cobra.Command {
+	var (
+		device     string
+		port       int
+		scopeFile  string
+		guids      string
+		full       bool
+		outputDir  string
+		timeoutSec int
+		verbose    bool
+	)
+	ibdiagCmd := &cobra.Command{
+		Use:   "ibdiag",
+		Short: "Run a scoped ibdiagnet to detect InfiniBand fabric issues",
+		Example: `sichek infiniband ibdiag -d mlx5_0
+sichek infiniband ibdiag --guids 0x248a070300f0d2c0,0x248a070300f0d2c8
+sichek infiniband ibdiag --full --timeout 1800`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if !verbose {
+				logrus.SetLevel(logrus.ErrorLevel)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutSec)*time.Second)
+			defer cancel()
+
+			if err := os.MkdirAll(outputDir, 0o755); err != nil {
+				logrus.WithField("component", "ibdiag").Errorf("failed to create %s: %v", outputDir, err)
+				os.Exit(1)
+			}
+			ibdiagArgs := []string{"-o", outputDir}
+			if device != "" {
+				ibdiagArgs = append(ibdiagArgs, "-i", device, "-p", strconv.Itoa(port))
+			}
+			if !full {
+				if scopeFile == "" {
+					scopeGUIDs := splitGUIDs(guids)
+					if len(scopeGUIDs) == 0 {
+						scopeGUIDs = localNodeGUIDs(device)
+					}
+					if len(scopeGUIDs) == 0 {
+						logrus.WithField("component", "ibdiag").Error("no local HCA to scope ibdiagnet to, use --guids, --scope-file or --full")
+						os.Exit(1)
+					}
+					scopeFile = filepath.Join(outputDir, "sichek-scope.txt")
+					if err := os.WriteFile(scopeFile, []byte(strings.Join(scopeGUIDs, "\n")+"\n"), 0o644); err != nil {
+						logrus.WithField("component", "ibdiag").Errorf("failed to write the scope file: %v", err)
+						os.Exit(1)
+					}
+				}
+				ibdiagArgs = append(ibdiagArgs, "--scope", scopeFile)
+			}
+
+			printer.Printf("Running ibdiagnet %s\n", strings.Join(ibdiagArgs, " "))
+			output, err := exec.CommandContext(ctx, "ibdiagnet", ibdiagArgs...).CombinedOutput()
+			if ctx.Err() == context.DeadlineExceeded {
+				logrus.WithField("component", "ibdiag").Errorf("ibdiagnet timed out after %ds", timeoutSec)
+				os.Exit(1)
+			}
+			summary := parseIBDiagOutput(string(output))
+			if err != nil && len(summary.Stages) == 0 {
+				logrus.WithField("component", "ibdiag").Errorf("ibdiagnet failed: %v\n%s", err, output)
+				os.Exit(1)
+			}
+			printIBDiagSummary(summary, outputDir)
+			ComponentStatuses["ibdiag"] = summary.ErrorCount() == 0
+		},
+	}
+
+	ibdiagCmd.Flags().StringVarP(&device, "device", "d", "", "Local HCA to run ibdiagnet from, e.g. mlx5_0, the first active port if empty")
+	ibdiagCmd.Flags().IntVarP(&port, "port", "p", 1, "Port of the local HCA")
+	ibdiagCmd.Flags().StringVar(&scopeFile, "scope-file", "", "ibdiagnet scope file listing the node GUIDs to diagnose")
+	ibdiagCmd.Flags().StringVar(&guids, "guids", "", "Node GUIDs to diagnose, joined by `,`, the local HCAs if empty")
+	ibdiagCmd.Flags().BoolVar(&full, "full", false, "Diagnose the whole fabric instead of a scope, slow on large fabrics")
+	ibdiagCmd.Flags().StringVarP(&outputDir, "output", "o", "/var/tmp/sichek-ibdiagnet", "Directory of the ibdiagnet reports")
+	ibdiagCmd.Flags().IntVarP(&timeoutSec, "timeout", "t", 600, "Timeout in seconds for ibdiagnet")
+	ibdiagCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
+	return ibdiagCmd
+}
+
+// localNodeGUIDs returns the node GUIDs of the local HCAs, or of device when
+// set, in the 0x-prefixed form of the ibdiagnet scope file.
+func localNodeGUIDs(device string) []string {
+	devs := []string{device}
+	if device == "" {
+		entries, err := os.ReadDir(ibSysfsClassDir)
+		if err != nil {
+			return nil
+		}
+		devs = devs[:0]
+		for _, entry := range entries {
+			devs = append(devs, entry.Name())
+		}
+	}
+	var guids []string
+	seen := make(map[string]bool)
+	for _, dev := range devs {
+		data, err := os.ReadFile(filepath.Join(ibSysfsClassDir, dev, "node_guid"))
+		if err != nil {
+			continue
+		}
+		guid := "0x" + strings.ReplaceAll(strings.TrimSpace(string(data)), ":", "")
+		if guid == "0x" || guid == "0x0000000000000000" || seen[guid] {
+			continue
+		}
+		seen[guid] = true
+		guids = append(guids, guid)
+	}
+	return guids
+}
+
+func splitGUIDs(guids string) []string {
+	var result []string
+	for _, guid := range strings.Split(guids, ",") {
+		if guid = strings.TrimSpace(guid); guid != "" {
+			result = append(result, guid)
+		}
+	}
+	return result
+}
+
+// parseIBDiagOutput parses the stages of the summary of ibdiagnet, and its
+// "-E-" error lines.
+func parseIBDiagOutput(output string) *ibdiagSummary {
+	summary := &ibdiagSummary{}
+	inSummary := false
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "Summary" {
+			inSummary = true
+			continue
+		}
+		if strings.HasPrefix(line, "-E-") {
+			summary.Errors = append(summary.Errors, strings.TrimSpace(strings.TrimPrefix(line, "-E-")))
+			continue
+		}
+		if !inSummary {
+			continue
+		}
+		m := ibdiagStageRegexp.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		warnings, _ := strconv.Atoi(m[2])
+		errors, _ := strconv.Atoi(m[3])
+		summary.Stages = append(summary.Stages, ibdiagStage{Name: m[1], Warnings: warnings, Errors: errors, Comment: m[4]})
+	}
+	return summary
+}
+
+func printIBDiagSummary(summary *ibdiagSummary, outputDir string) {
+	printer.Printf("%-30s %-10s %-10s %s\n", "Stage", "Warnings", "Errors", "Comment")
+	for _, stage := range summary.Stages {
+		printer.Printf("%-30s %-10d %-10d %s\n", stage.Name, stage.Warnings, stage.Errors, stage.Comment)
+	}
+	if summary.ErrorCount() == 0 {
+		printer.Println("✅ ibdiagnet found no fabric error.")
+		return
+	}
+	printer.Printf("⚠️ ibdiagnet found %d fabric errors:\n", summary.ErrorCount())
+	for i, e := range summary.Errors {
+		if i == maxIBDiagErrors {
+			printer.Printf(" - ... %d more\n", len(summary.Errors)-maxIBDiagErrors)
+			break
+		}
+		printer.Println(" - ", e)
+	}
+	printer.Printf("See the reports in %s\n", outputDir)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+const ibdiagOutput = `Loading IBDIAGNET from: /usr/lib64/ibdiagnet2.1.1
+-I- Discovering ... 12 nodes (2 Switches & 10 CA-s) discovered.
+-E- Link: S248a070300f0d2c0/U1/P7<-->S0c42a10300ab12cd/U1/P1 - Unexpected actual link speed 25 (enable_speed1="2.5 or 5 or 10", enable_speed2="2.5 or 5 or 10")
+-E- Duplicated Node GUID 0x248a070300f0d2c8
+---------------------------------------------
+Summary
+-I- Stage                     Warnings   Errors     Comment
+-I- Discovery                 0          1
+-I- Lids Check                0          0
+-I- Links Check               2          1
+-I- Subnet Manager            0          0
+-I- Port Counters             3          0          skipped
+-I- You can find detailed errors/warnings in: /var/tmp/ibdiagnet2/ibdiagnet2.log
+`
+
+func TestParseIBDiagOutput(t *testing.T) {
+	summary := parseIBDiagOutput(ibdiagOutput)
+	if len(summary.Stages) != 5 {
+		t.Fatalf("expected 5 stages, got %+v", summary.Stages)
+	}
+	if got := summary.Stages[2]; got != (ibdiagStage{Name: "Links Check", Warnings: 2, Errors: 1}) {
+		t.Errorf("unexpected Links Check stage %+v", got)
+	}
+	if summary.Stages[4].Comment != "skipped" {
+		t.Errorf("unexpected comment %q", summary.Stages[4].Comment)
+	}
+	if summary.ErrorCount() != 2 || len(summary.Errors) != 2 {
+		t.Errorf("expected 2 errors, got %d and %v", summary.ErrorCount(), summary.Errors)
+	}
+	if parseIBDiagOutput("ibdiagnet: command not found").ErrorCount() != 0 {
+		t.Error("expected no stage in an unexpected output")
+	}
+}
+
+func TestLocalNodeGUIDs(t *testing.T) {
+	dir := t.TempDir()
+	old := ibSysfsClassDir
+	ibSysfsClassDir = dir
+	defer func() { ibSysfsClassDir = old }()
+	for dev, guid := range map[string]string{"mlx5_0": "248a:0703:00f0:d2c0", "mlx5_1": "248a:0703:00f0:d2c8", "mlx5_bond_0": "0000:0000:0000:0000"} {
+		if err := os.MkdirAll(filepath.Join(dir, dev), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, dev, "node_guid"), []byte(guid+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	guids := localNodeGUIDs("")
+	slices.Sort(guids)
+	if want := []string{"0x248a070300f0d2c0", "0x248a070300f0d2c8"}; !slices.Equal(guids, want) {
+		t.Errorf("localNodeGUIDs() = %v, want %v", guids, want)
+	}
+	if guids := localNodeGUIDs("mlx5_1"); !slices.Equal(guids, []string{"0x248a070300f0d2c8"}) {
+		t.Errorf("localNodeGUIDs(mlx5_1) = %v", guids)
+	}
+}
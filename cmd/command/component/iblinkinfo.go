@@ -23,6 +23,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/scitix/sichek/pkg/printer"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -53,14 +54,14 @@ func NewIBLinkCheckCmd() *cobra.Command {
 
 			badLinks := parseIbLinkInfo(output)
 			if len(badLinks) > 0 {
-				fmt.Printf("⚠️ Detected %d InfiniBand connection issues:\n", len(badLinks))
+				printer.Printf("⚠️ Detected %d InfiniBand connection issues:\n", len(badLinks))
 				for _, line := range badLinks {
-					fmt.Println(" - ", line)
+					printer.Println(" - ", line)
 					break
 				}
 				ComponentStatuses["iblink"] = false
 			} else {
-				fmt.Println("✅ All InfiniBand links are healthy.")
+				printer.Println("✅ All InfiniBand links are healthy.")
 				ComponentStatuses["iblink"] = true
 			}
 		},
@@ -88,6 +88,7 @@ func NewInfinibandCmd() *cobra.Command {
 	infinibandCmd.Flags().StringVarP(&specFile, "spec", "s", "", "Path to the Infiniband specification file")
 	infinibandCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
 	infinibandCmd.Flags().StringVarP(&ignoredCheckers, "ignored-checkers", "i", "", "Ignored checkers")
+	infinibandCmd.AddCommand(NewIBDiagCmd())
 
 	return infinibandCmd
 }
@@ -0,0 +1,69 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"context"
+
+	"github.com/scitix/sichek/components/inventory"
+	"github.com/scitix/sichek/consts"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func NewInventoryCmd() *cobra.Command {
+	inventoryCmd := &cobra.Command{
+		Use:     "inventory",
+		Aliases: []string{"inv"},
+		Short:   "Show the node identity and the serials of its GPUs, HCAs and DIMMs",
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), consts.CmdTimeout)
+			defer cancel()
+
+			verbose, _ := cmd.Flags().GetBool("verbos")
+			if !verbose {
+				logrus.SetLevel(logrus.ErrorLevel)
+			}
+
+			cfgFile, _ := cmd.Flags().GetString("cfg")
+			specFile, _ := cmd.Flags().GetString("spec")
+
+			if accept, _ := cmd.Flags().GetBool("accept-changes"); accept {
+				if err := inventory.AcceptDeviceChanges(cfgFile); err != nil {
+					logrus.WithField("component", "inventory").Errorf("failed to reset the device identities: %v", err)
+					return
+				}
+			}
+
+			c, err := inventory.NewComponent(cfgFile, specFile)
+			if err != nil {
+				logrus.WithField("component", "inventory").Error(err)
+				return
+			}
+			result, err := RunComponentCheck(ctx, c, consts.CmdTimeout)
+			if err != nil {
+				return
+			}
+			PrintCheckResults(true, result)
+		},
+	}
+	inventoryCmd.Flags().StringP("cfg", "c", "", "Path to the inventory cfg")
+	inventoryCmd.Flags().StringP("spec", "s", "", "Unused for inventory; kept for symmetry with other components")
+	inventoryCmd.Flags().BoolP("verbos", "v", false, "Enable verbose output")
+	inventoryCmd.Flags().Bool("accept-changes", false, "Record the current GPUs and HCAs as the expected devices, after a planned hardware swap")
+	return inventoryCmd
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scitix/sichek/cmd/command/spec"
+	"github.com/scitix/sichek/components/common"
+	cpucollector "github.com/scitix/sichek/components/cpu/collector"
+	invcollector "github.com/scitix/sichek/components/inventory/collector"
+	memconfig "github.com/scitix/sichek/components/memory/config"
+	"github.com/scitix/sichek/components/memory/memtest"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// MemTestOptions are the flags of `sichek memtest`.
+type MemTestOptions struct {
+	SpecFile  string
+	ArrayMB   int64
+	NTimes    int
+	Threads   int
+	Nodes     string
+	ExpectBw  float64
+	Imbalance float64
+	Timeout   time.Duration
+}
+
+func NewMemTestCmd() *cobra.Command {
+	var opts MemTestOptions
+	memTestCmd := &cobra.Command{
+		Use:   "memtest",
+		Short: "Measure the memory bandwidth of each NUMA node and check the DIMM population",
+		Long: `Measure the memory bandwidth of each NUMA node with a STREAM like benchmark.
+
+The copy, scale, add and triad kernels run on threads pinned to the CPUs of
+the node, on arrays allocated on the node. The triad bandwidth of each node is
+compared with the memory spec of the CPU model, split between the NUMA nodes
+of a socket, and with the best node. The memory of the NUMA nodes and the
+DIMMs reported by dmidecode are checked for missing, mixed or slow DIMMs.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			verbose, err := cmd.Flags().GetBool("verbose")
+			if err != nil {
+				logrus.WithField("memtest", "memory").Errorf("get to ge the verbose: %v", err)
+			}
+			if !verbose {
+				logrus.SetLevel(logrus.ErrorLevel)
+			}
+			res, err := CheckMemTest(opts)
+			if err != nil {
+				logrus.WithField("memtest", "memory").Error(err)
+				printer.Printf("%s%v%s\n", consts.Red, err, consts.Reset)
+				SetComponentStatus(memtest.MemTestName, false, "")
+				return
+			}
+			passed := PrintMemTestInfo(res)
+			SetComponentStatus(res.Item, passed, res.Level)
+			for _, checkerResult := range res.Checkers {
+				if checkerResult.Status == consts.StatusAbnormal && checkerResult.Device != "" {
+					SetComponentStatus(fmt.Sprintf("%s %s", res.Item, checkerResult.Device), false, checkerResult.Level)
+				}
+			}
+		},
+	}
+
+	memTestCmd.Flags().StringVarP(&opts.SpecFile, "spec", "s", "", "Path to the memory specification file")
+	memTestCmd.Flags().Int64Var(&opts.ArrayMB, "size", 256, "Size in MB of each of the 3 arrays of a NUMA node, at least 4 times the last level caches")
+	memTestCmd.Flags().IntVar(&opts.NTimes, "ntimes", 10, "Number of runs of each kernel, the best run counts")
+	memTestCmd.Flags().IntVar(&opts.Threads, "threads", 0, "Threads per NUMA node (default: one per CPU of the node)")
+	memTestCmd.Flags().StringVar(&opts.Nodes, "nodes", "", "NUMA nodes to test, e.g. 0,1 (default: all the nodes with CPUs)")
+	memTestCmd.Flags().Float64Var(&opts.ExpectBw, "expect-bw", 0, "Expected triad bandwidth of each NUMA node in GB/s (default: from the spec of the CPU model)")
+	memTestCmd.Flags().Float64Var(&opts.Imbalance, "imbalance", 15, "Fail the NUMA nodes whose bandwidth or memory is this percentage below the best node, 0 disables it")
+	memTestCmd.Flags().DurationVarP(&opts.Timeout, "timeout", "t", 10*time.Minute, "Timeout of the test")
+	memTestCmd.Flags().BoolP("verbose", "v", false, "Enable verbose output")
+
+	return memTestCmd
+}
+
+// selectMemTestNodes returns the NUMA nodes with CPUs listed in nodes, all of
+// them when nodes is empty.
+func selectMemTestNodes(all []*memtest.NumaNode, nodes string) ([]*memtest.NumaNode, error) {
+	wanted := make(map[int]bool)
+	for _, field := range strings.Split(nodes, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		id, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NUMA node %q", field)
+		}
+		wanted[id] = true
+	}
+	var selected []*memtest.NumaNode
+	for _, node := range all {
+		if len(node.CPUs) == 0 || (len(wanted) > 0 && !wanted[node.ID]) {
+			continue
+		}
+		selected = append(selected, node)
+		delete(wanted, node.ID)
+	}
+	for id := range wanted {
+		return nil, fmt.Errorf("NUMA node %d does not exist or has no CPU", id)
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no NUMA node with CPUs to test")
+	}
+	return selected, nil
+}
+
+// memTestLimits returns the check options from the flags and the memory spec
+// of the CPU model.
+func memTestLimits(ctx context.Context, opts MemTestOptions, nodes []*memtest.NumaNode) memtest.CheckOptions {
+	limits := memtest.CheckOptions{ExpectedTriad: opts.ExpectBw, ImbalancePercent: opts.Imbalance}
+	cpuInfo := &cpucollector.CPUArchInfo{}
+	if err := cpuInfo.Get(ctx); err != nil {
+		logrus.WithField("memtest", "memory").Warnf("failed to get the CPU model: %v, using the default memory spec", err)
+	}
+	specFile, err := spec.EnsureSpecFile(opts.SpecFile)
+	if err != nil {
+		logrus.WithField("memtest", "memory").Debugf("spec file not resolved: %v, using 0 expected bandwidth", err)
+		return limits
+	}
+	memSpec, err := memconfig.LoadSpec(specFile, cpuInfo.ModelName)
+	if err != nil {
+		logrus.WithField("memtest", "memory").Debugf("failed to load spec: %v, using 0 expected bandwidth", err)
+		return limits
+	}
+	withCPUs := 0
+	for _, node := range nodes {
+		if len(node.CPUs) > 0 {
+			withCPUs++
+		}
+	}
+	if limits.ExpectedTriad == 0 {
+		limits.ExpectedTriad = memtest.ExpectedTriadPerNode(memSpec.MinTriadBandwidthPerSocket, cpuInfo.Sockets, withCPUs)
+		if limits.ExpectedTriad > 0 {
+			printer.Printf("Using the expected bandwidth of %s: %.1f GB/s per NUMA node\n", cpuInfo.ModelName, limits.ExpectedTriad)
+		}
+	}
+	limits.DIMMs = memSpec.DIMMs
+	limits.MinSpeed = memSpec.MinSpeed
+	return limits
+}
+
+// CheckMemTest runs the benchmark on each selected NUMA node, one after the
+// other so that the nodes do not compete for the memory interconnect.
+func CheckMemTest(opts MemTestOptions) (*common.Result, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+
+	all, err := memtest.GetNumaNodes()
+	if err != nil {
+		return nil, fmt.Errorf("get NUMA nodes failed: %w", err)
+	}
+	nodes, err := selectMemTestNodes(all, opts.Nodes)
+	if err != nil {
+		return nil, err
+	}
+	limits := memTestLimits(ctx, opts, all)
+
+	var results []*memtest.StreamResult
+	for _, node := range nodes {
+		printer.Printf("Running the memory bandwidth test on NUMA node %d\n", node.ID)
+		res, err := memtest.RunStream(ctx, node, memtest.StreamOptions{ArrayBytes: opts.ArrayMB << 20, NTimes: opts.NTimes, Threads: opts.Threads})
+		if err != nil {
+			return nil, fmt.Errorf("memory bandwidth test on NUMA node %d failed: %w", node.ID, err)
+		}
+		printer.Println(res.String())
+		results = append(results, res)
+	}
+
+	dimms, err := invcollector.CollectDIMMs(ctx)
+	if err != nil {
+		logrus.WithField("memtest", "memory").Warnf("DIMMs not available, skipping the DIMM checks: %v", err)
+		dimms = nil
+	}
+	return memtest.Check(results, all, dimms, limits), nil
+}
+
+func PrintMemTestInfo(result *common.Result) bool {
+	for _, checkerResult := range result.Checkers {
+		if checkerResult.Status == consts.StatusAbnormal {
+			printer.Printf("%s%s%s\n", consts.Red, checkerResult.Detail, consts.Reset)
+		} else {
+			printer.Printf("%s%s%s\n", consts.Green, checkerResult.Detail, consts.Reset)
+		}
+	}
+	return result.Status == consts.StatusNormal
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/perfhistory"
+	"github.com/sirupsen/logrus"
+)
+
+const ncclPerfTestName = "nccltest"
+
+// NcclBaseline records the bus bandwidth of the nccltest runs in the perf
+// history of the node. With Compare, a run whose bandwidth is more than
+// MaxDropPercent below the median of the previous runs of the same GPU count
+// and message sizes fails, on top of the static expected bandwidth.
+type NcclBaseline struct {
+	Store          *perfhistory.Store
+	Compare        bool
+	MaxDropPercent float64
+}
+
+// ncclRunParams are the parameters making two nccltest runs comparable.
+func ncclRunParams(cfg Config) map[string]string {
+	params := map[string]string{
+		"gpus":         strconv.Itoa(cfg.NumGpus),
+		"begin":        cfg.beginBuffer,
+		"end":          cfg.endBuffer,
+		"disable_nvls": strconv.FormatBool(cfg.DisableNvls),
+	}
+	if cfg.Gpulist != "" {
+		params["gpulist"] = cfg.Gpulist
+	}
+	if len(cfg.Hosts) > 0 {
+		params["hosts"] = strings.Join(cfg.Hosts, ",")
+		params["np"] = strconv.Itoa(cfg.NumProcs)
+		delete(params, "gpus")
+	}
+	return params
+}
+
+// Apply compares busBw against the baseline of the run of cfg, updates res
+// with the verdict and records the run. A nil baseline does nothing.
+func (b *NcclBaseline) Apply(res *common.Result, cfg Config, busBw float64) {
+	if b == nil || b.Store == nil || res == nil || len(res.Checkers) == 0 {
+		return
+	}
+	params := ncclRunParams(cfg)
+	key := perfhistory.Key(params)
+	if b.Compare {
+		history, err := b.Store.Runs(ncclPerfTestName, key)
+		if err != nil {
+			logrus.WithField("perftest", "nccl").Warnf("failed to read the nccltest history: %v", err)
+		} else {
+			applyNcclComparison(res, perfhistory.Compare(history, busBw, b.MaxDropPercent, perfhistory.DefaultMinRuns), len(history), b.MaxDropPercent)
+		}
+	}
+	node, _ := os.Hostname()
+	run := perfhistory.Run{
+		Time:   time.Now(),
+		Node:   node,
+		Test:   ncclPerfTestName,
+		Key:    key,
+		Params: params,
+		Value:  busBw,
+		Unit:   "Gbps",
+	}
+	if err := b.Store.Append(run); err != nil {
+		logrus.WithField("perftest", "nccl").Warnf("failed to record the nccltest run: %v", err)
+	}
+}
+
+func applyNcclComparison(res *common.Result, cmp *perfhistory.Comparison, runs int, maxDropPercent float64) {
+	checker := res.Checkers[0]
+	switch {
+	case cmp == nil:
+		checker.Detail += fmt.Sprintf("No baseline yet, %d of the %d previous runs it needs are recorded.\n", runs, perfhistory.DefaultMinRuns)
+	case cmp.Regressed:
+		checker.Status = consts.StatusAbnormal
+		checker.Detail += fmt.Sprintf("NCCL allreduce bandwidth regressed, avgBusBandwidth %.2f Gbps is %.1f%% below the median %.2f Gbps of the last %d runs, more than the allowed %.1f%%.\n",
+			cmp.Value, cmp.DropPercent, cmp.Median, cmp.Runs, maxDropPercent)
+		res.Status = consts.StatusAbnormal
+	default:
+		checker.Detail += fmt.Sprintf("avgBusBandwidth is within %.1f%% of the median %.2f Gbps of the last %d runs.\n", maxDropPercent, cmp.Median, cmp.Runs)
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"context"
+	"strings"
+
+	"github.com/scitix/sichek/cmd/command/spec"
+	"github.com/scitix/sichek/components/ncclenv"
+	"github.com/scitix/sichek/consts"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// NewNCCLEnvCmd creates the "nccl-env" command which checks the node environment NCCL
+// relies on: GPUDirect RDMA, NCCL_IB_HCA, the RoCE GIDs, MTU and PFC of the RDMA ports.
+// Run it inside a job container to check the NCCL_* variables of the job as well.
+func NewNCCLEnvCmd() *cobra.Command {
+	var (
+		cfgFile            string
+		ignoredCheckersStr string
+		verbose            bool
+	)
+	ncclEnvCmd := &cobra.Command{
+		Use:   "nccl-env",
+		Short: "Check the GPUDirect RDMA, NCCL_IB_HCA, RoCE GID, MTU and PFC settings NCCL relies on",
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), consts.CmdTimeout)
+			defer cancel()
+			if !verbose {
+				logrus.SetLevel(logrus.ErrorLevel)
+			} else {
+				logrus.SetLevel(logrus.DebugLevel)
+			}
+
+			resolvedCfgFile, err := spec.EnsureCfgFile(cfgFile)
+			if err != nil {
+				logrus.WithField("daemon", "nccl_env").Errorf("failed to load cfgFile: %v", err)
+			} else {
+				logrus.WithField("daemon", "nccl_env").Info("load cfgFile: " + resolvedCfgFile)
+			}
+
+			var ignoredCheckers []string
+			if len(ignoredCheckersStr) > 0 {
+				ignoredCheckers = strings.Split(ignoredCheckersStr, ",")
+			}
+
+			component, err := ncclenv.NewComponent(resolvedCfgFile, "", ignoredCheckers)
+			if err != nil {
+				logrus.WithField("component", "nccl_env").Error(err)
+				return
+			}
+			result, err := RunComponentCheck(ctx, component, consts.CmdTimeout)
+			if err != nil {
+				return
+			}
+			PrintCheckResults(true, result)
+		},
+	}
+
+	ncclEnvCmd.Flags().StringVarP(&cfgFile, "cfg", "c", "", "Path to the user config file")
+	ncclEnvCmd.Flags().StringVarP(&ignoredCheckersStr, "ignored-checkers", "i", "", "Ignored checkers")
+	ncclEnvCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
+
+	return ncclEnvCmd
+}
@@ -25,6 +25,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -35,6 +36,8 @@ import (
 	ibcollector "github.com/scitix/sichek/components/infiniband/collector"
 	"github.com/scitix/sichek/components/nvidia/config"
 	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/perfhistory"
+	"github.com/scitix/sichek/pkg/printer"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -54,6 +57,11 @@ type Config struct {
 	//   anything else  → strict whitelist; "=" prefix is added automatically
 	//                    when missing
 	IBHCA string
+	// Hosts switches to the multi-node mode: the test is launched by mpirun
+	// across the hosts with one rank per GPU, a host may be given as host:slots.
+	Hosts []string
+	// NumProcs is the total number of ranks of the multi-node mode.
+	NumProcs int
 }
 
 func NewNcclPerftestCmd() *cobra.Command {
@@ -83,20 +91,34 @@ func NewNcclPerftestCmd() *cobra.Command {
 				logrus.WithField("perftest", "nccl").Error(err)
 				return
 			}
-			nvmlInst := nvml.New()
-			if ret := nvmlInst.Init(); !errors.Is(ret, nvml.SUCCESS) {
-				logrus.WithField("perftest", "nccl").Errorf("failed to initialize NVML: %v", nvml.ErrorString(ret))
+			hostsStr, err := cmd.Flags().GetString("hosts")
+			if err != nil {
+				logrus.WithField("perftest", "nccl").Error(err)
 				return
 			}
-			defer nvmlInst.Shutdown()
-			deviceCount, ret := nvmlInst.DeviceGetCount()
-			if !errors.Is(ret, nvml.SUCCESS) {
-				logrus.WithField("perftest", "nccl").Errorf("failed to get device count: %s", nvml.ErrorString(ret))
+			numProcs, err := cmd.Flags().GetInt("np")
+			if err != nil {
+				logrus.WithField("perftest", "nccl").Error(err)
 				return
 			}
-			if numGpus > deviceCount {
-				logrus.WithField("perftest", "nccl").Warnf("num-gpus %d is greater than available GPUs %d, setting to %d", numGpus, deviceCount, deviceCount)
-				numGpus = deviceCount
+			multiNode := hostsStr != ""
+			// the GPUs of the remote hosts are not visible here, the launching host may even have none
+			if !multiNode {
+				nvmlInst := nvml.New()
+				if ret := nvmlInst.Init(); !errors.Is(ret, nvml.SUCCESS) {
+					logrus.WithField("perftest", "nccl").Errorf("failed to initialize NVML: %v", nvml.ErrorString(ret))
+					return
+				}
+				defer nvmlInst.Shutdown()
+				deviceCount, ret := nvmlInst.DeviceGetCount()
+				if !errors.Is(ret, nvml.SUCCESS) {
+					logrus.WithField("perftest", "nccl").Errorf("failed to get device count: %s", nvml.ErrorString(ret))
+					return
+				}
+				if numGpus > deviceCount {
+					logrus.WithField("perftest", "nccl").Warnf("num-gpus %d is greater than available GPUs %d, setting to %d", numGpus, deviceCount, deviceCount)
+					numGpus = deviceCount
+				}
 			}
 			gpulist, err := cmd.Flags().GetString("gpulist")
 			if err != nil {
@@ -136,10 +158,26 @@ func NewNcclPerftestCmd() *cobra.Command {
 				logrus.WithField("perftest", "nccl").Error(err)
 				return
 			}
+			compareBaseline, err := cmd.Flags().GetBool("compare-baseline")
+			if err != nil {
+				logrus.WithField("perftest", "nccl").Error(err)
+				return
+			}
+			regressionThreshold, err := cmd.Flags().GetFloat64("regression-threshold")
+			if err != nil {
+				logrus.WithField("perftest", "nccl").Error(err)
+				return
+			}
+			historyFile, err := cmd.Flags().GetString("history-file")
+			if err != nil {
+				logrus.WithField("perftest", "nccl").Error(err)
+				return
+			}
 			// If both begin and end buffers are 8 bytes by default, disable bandwidth check
-			if beginBuffer == "8" && endBuffer == "8" {
+			connectivityOnly := beginBuffer == "8" && endBuffer == "8"
+			if connectivityOnly {
 				expectedBandwidthGbps = 0
-				fmt.Println("8-byte message size detected, skipping bandwidth check (connectivity test only)")
+				printer.Println("8-byte message size detected, skipping bandwidth check (connectivity test only)")
 			} else if expectedBandwidthGbps == 0 {
 				specFile, err := spec.EnsureSpecFile("")
 				if err != nil {
@@ -148,12 +186,31 @@ func NewNcclPerftestCmd() *cobra.Command {
 					nvidiaSpecCfg, err := config.LoadSpec(specFile)
 					if err != nil {
 						logrus.WithField("perftest", "nccl").Debugf("failed to load spec: %v, using 0 expected bandwidth", err)
-					} else if nvidiaSpecCfg.Perf.NcclAllReduceBw > 0 {
+					} else if multiNode && nvidiaSpecCfg.Perf.NcclAllReduceBwMultiNode > 0 {
+						expectedBandwidthGbps = nvidiaSpecCfg.Perf.NcclAllReduceBwMultiNode
+						printer.Printf("Using default multi-node expected bandwidth: %.2f Gbps\n", expectedBandwidthGbps)
+					} else if !multiNode && nvidiaSpecCfg.Perf.NcclAllReduceBw > 0 {
 						expectedBandwidthGbps = nvidiaSpecCfg.Perf.NcclAllReduceBw
-						fmt.Printf("Using default expected bandwidth: %.2f Gbps\n", expectedBandwidthGbps)
+						printer.Printf("Using default expected bandwidth: %.2f Gbps\n", expectedBandwidthGbps)
 					}
 				}
 			}
+			// the bandwidth of the connectivity test is meaningless, it is not recorded
+			var baseline *NcclBaseline
+			switch {
+			case connectivityOnly:
+				if compareBaseline {
+					printer.Println("--compare-baseline is ignored by the connectivity test")
+				}
+			case historyFile != "":
+				baseline = &NcclBaseline{
+					Store:          perfhistory.NewStore(historyFile),
+					Compare:        compareBaseline,
+					MaxDropPercent: regressionThreshold,
+				}
+			case compareBaseline:
+				printer.Println("--compare-baseline needs --history-file, skipping the baseline comparison")
+			}
 			timeout, err := cmd.Flags().GetInt("timeout")
 			if err != nil {
 				logrus.WithField("perftest", "nccl").Error(err)
@@ -166,27 +223,45 @@ func NewNcclPerftestCmd() *cobra.Command {
 			}
 			var res *common.Result
 			result := 0
-			fmt.Printf("Running NCCL performance test with %d GPUs, begin buffer: %s, end buffer: %s, disable NVLinks: %t, expected bandwidth: %.2f Gbps\n", numGpus, beginBuffer, endBuffer, disableNvls, expectedBandwidthGbps)
-			if scale {
-				for g := 2; g <= numGpus; g++ {
-					res, err = CheckNcclPerf(g, gpulist, beginBuffer, endBuffer, disableNvls, expectedBandwidthGbps, timeout, ibHCA)
-					if err != nil {
-						logrus.WithField("perftest", "nccl").Error(err)
-						result = -1
-					}
+			if multiNode {
+				hosts, np, err := parseNcclHosts(hostsStr, numProcs, numGpus)
+				if err != nil {
+					logrus.WithField("perftest", "nccl").Error(err)
+					SetComponentStatus("NcclPerf", false, "")
+					return
 				}
-			} else {
-				res, err = CheckNcclPerf(numGpus, gpulist, beginBuffer, endBuffer, disableNvls, expectedBandwidthGbps, timeout, ibHCA)
+				if scale {
+					printer.Println("--scale-gpus is ignored in the multi-node mode")
+				}
+				printer.Printf("Running multi-node NCCL performance test with %d ranks on %s, begin buffer: %s, end buffer: %s, disable NVLinks: %t, expected bandwidth: %.2f Gbps\n", np, strings.Join(hosts, ","), beginBuffer, endBuffer, disableNvls, expectedBandwidthGbps)
+				res, err = CheckNcclPerfMultiNode(hosts, np, gpulist, beginBuffer, endBuffer, disableNvls, expectedBandwidthGbps, timeout, ibHCA, baseline)
 				if err != nil {
 					logrus.WithField("perftest", "nccl").Error(err)
 					result = -1
 				}
+			} else {
+				printer.Printf("Running NCCL performance test with %d GPUs, begin buffer: %s, end buffer: %s, disable NVLinks: %t, expected bandwidth: %.2f Gbps\n", numGpus, beginBuffer, endBuffer, disableNvls, expectedBandwidthGbps)
+				if scale {
+					for g := 2; g <= numGpus; g++ {
+						res, err = CheckNcclPerf(g, gpulist, beginBuffer, endBuffer, disableNvls, expectedBandwidthGbps, timeout, ibHCA, baseline)
+						if err != nil {
+							logrus.WithField("perftest", "nccl").Error(err)
+							result = -1
+						}
+					}
+				} else {
+					res, err = CheckNcclPerf(numGpus, gpulist, beginBuffer, endBuffer, disableNvls, expectedBandwidthGbps, timeout, ibHCA, baseline)
+					if err != nil {
+						logrus.WithField("perftest", "nccl").Error(err)
+						result = -1
+					}
+				}
 			}
 			if result == 0 {
 				passed := PrintNcclPerfInfo(res)
-				ComponentStatuses[res.Item] = passed
+				SetComponentStatus(res.Item, passed, res.Level)
 			} else {
-				ComponentStatuses["NcclPerf"] = false
+				SetComponentStatus("NcclPerf", false, "")
 			}
 		},
 	}
@@ -200,6 +275,11 @@ func NewNcclPerftestCmd() *cobra.Command {
 	ncclPerftestCmd.Flags().Float64("expect-bw", 0, "Expected bandwidth in Gbps")
 	ncclPerftestCmd.Flags().BoolP("verbose", "v", false, "Enable verbose output")
 	ncclPerftestCmd.Flags().IntP("timeout", "t", 120, "Timeout in seconds")
+	ncclPerftestCmd.Flags().String("hosts", "", "Run the test across nodes with mpirun, e.g. node1,node2 or node1:8,node2:8")
+	ncclPerftestCmd.Flags().Int("np", 0, "Total number of ranks of the multi-node test, default is num-gpus per host")
+	ncclPerftestCmd.Flags().Bool("compare-baseline", false, "Fail when the bandwidth drops more than --regression-threshold below the median of the previous runs with the same GPUs and message sizes")
+	ncclPerftestCmd.Flags().Float64("regression-threshold", 10, "Allowed bandwidth drop in percent versus the baseline of --compare-baseline")
+	ncclPerftestCmd.Flags().String("history-file", consts.DefaultPerfHistoryPath, "File recording the bandwidth of the runs for --compare-baseline, empty disables the recording")
 	ncclPerftestCmd.Flags().String("ib-hca", "", "NCCL_IB_HCA control: empty=auto-detect active RoCE VFs (respects external NCCL_IB_HCA); 'off'/'none'/'disable'=skip; otherwise a strict HCA whitelist (e.g. 'roce_vf_r0,roce_vf_r1')")
 
 	return ncclPerftestCmd
@@ -230,27 +310,27 @@ func GetDefaultNcclTestPath(testBin string) (string, error) {
 func applyIBHCA(envMap map[string]string, ibHCA string) {
 	switch strings.ToLower(strings.TrimSpace(ibHCA)) {
 	case "off", "none", "disable":
-		fmt.Println("NCCL_IB_HCA auto-detection disabled by flag")
+		printer.Println("NCCL_IB_HCA auto-detection disabled by flag")
 		return
 	case "":
 		if _, ok := envMap["NCCL_IB_HCA"]; ok {
-			fmt.Printf("NCCL_IB_HCA already set in environment (%q), skipping auto-detect\n", envMap["NCCL_IB_HCA"])
+			printer.Printf("NCCL_IB_HCA already set in environment (%q), skipping auto-detect\n", envMap["NCCL_IB_HCA"])
 			return
 		}
 		vfs := ibcollector.ListActiveRoceVFs()
 		if len(vfs) == 0 {
-			fmt.Println("No active RoCE VFs detected, leaving NCCL_IB_HCA at NCCL default")
+			printer.Println("No active RoCE VFs detected, leaving NCCL_IB_HCA at NCCL default")
 			return
 		}
 		envMap["NCCL_IB_HCA"] = "=" + strings.Join(vfs, ",")
-		fmt.Printf("Auto-detected RoCE VFs for NCCL_IB_HCA: %s\n", envMap["NCCL_IB_HCA"])
+		printer.Printf("Auto-detected RoCE VFs for NCCL_IB_HCA: %s\n", envMap["NCCL_IB_HCA"])
 	default:
 		val := strings.TrimSpace(ibHCA)
 		if !strings.HasPrefix(val, "=") {
 			val = "=" + val
 		}
 		envMap["NCCL_IB_HCA"] = val
-		fmt.Printf("Using user-specified NCCL_IB_HCA: %s\n", val)
+		printer.Printf("Using user-specified NCCL_IB_HCA: %s\n", val)
 	}
 }
 
@@ -264,8 +344,13 @@ func buildNcclTestCmd(cfg Config) *exec.Cmd {
 	args := []string{
 		testPath,
 	}
-	if cfg.NumGpus != 0 {
-		args = append(args, fmt.Sprintf("-g %d", cfg.NumGpus))
+	numGpus := cfg.NumGpus
+	if len(cfg.Hosts) > 0 {
+		// every rank drives a single GPU in the multi-node mode
+		numGpus = 1
+	}
+	if numGpus != 0 {
+		args = append(args, fmt.Sprintf("-g %d", numGpus))
 	}
 	if cfg.beginBuffer != "" {
 		args = append(args, fmt.Sprintf("-b %s", cfg.beginBuffer))
@@ -273,8 +358,6 @@ func buildNcclTestCmd(cfg Config) *exec.Cmd {
 	if cfg.endBuffer != "" {
 		args = append(args, fmt.Sprintf("-e %s", cfg.endBuffer))
 	}
-	fmt.Printf("== Run %d GPU nccl all_reduce test ==\n", cfg.NumGpus)
-	cmd := exec.Command("bash", args...)
 
 	// Start with current environment variables to inherit all existing env vars
 	envMap := make(map[string]string)
@@ -290,13 +373,22 @@ func buildNcclTestCmd(cfg Config) *exec.Cmd {
 		envMap["NCCL_NVLS_ENABLE"] = "0"
 	}
 	if cfg.Gpulist != "" {
-		fmt.Printf("CUDA_VISIBLE_DEVICES: %s\n", cfg.Gpulist)
+		printer.Printf("CUDA_VISIBLE_DEVICES: %s\n", cfg.Gpulist)
 		envMap["CUDA_VISIBLE_DEVICES"] = cfg.Gpulist
 	}
 	envMap["UCX_TLS"] = ""
 	envMap["OMPI_MCA_pml"] = "^ucx"
 	applyIBHCA(envMap, cfg.IBHCA)
 
+	var cmd *exec.Cmd
+	if len(cfg.Hosts) > 0 {
+		printer.Printf("== Run %d ranks nccl all_reduce test on %s ==\n", cfg.NumProcs, strings.Join(cfg.Hosts, ","))
+		cmd = exec.Command("mpirun", buildMpirunArgs(cfg, envMap, append([]string{"bash"}, args...))...)
+	} else {
+		printer.Printf("== Run %d GPU nccl all_reduce test ==\n", cfg.NumGpus)
+		cmd = exec.Command("bash", args...)
+	}
+
 	// Convert map back to slice of "KEY=VALUE" format
 	env := make([]string, 0, len(envMap))
 	for key, value := range envMap {
@@ -308,6 +400,60 @@ func buildNcclTestCmd(cfg Config) *exec.Cmd {
 	return cmd
 }
 
+// buildMpirunArgs builds the OpenMPI arguments launching testArgs on every
+// host. The NCCL, CUDA and UCX variables of envMap are exported to the remote
+// ranks, the nccl_perf script sets the rest of the MPI environment itself.
+func buildMpirunArgs(cfg Config, envMap map[string]string, testArgs []string) []string {
+	args := []string{
+		"--allow-run-as-root",
+		"-np", strconv.Itoa(cfg.NumProcs),
+		"-H", strings.Join(cfg.Hosts, ","),
+		"--bind-to", "none",
+		"--mca", "pml", "^ucx",
+		"--mca", "btl", "self,tcp",
+	}
+	keys := make([]string, 0, len(envMap))
+	for key := range envMap {
+		if strings.HasPrefix(key, "NCCL_") || strings.HasPrefix(key, "UCX_") ||
+			key == "CUDA_VISIBLE_DEVICES" || key == "LD_LIBRARY_PATH" || key == "PATH" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, "-x", key)
+	}
+	return append(args, testArgs...)
+}
+
+// parseNcclHosts splits --hosts and returns the number of ranks, by default
+// gpusPerHost per host. A host without explicit slots gets its share of the
+// ranks, e.g. "node1,node2" with 16 ranks is node1:8,node2:8.
+func parseNcclHosts(hostsStr string, numProcs, gpusPerHost int) ([]string, int, error) {
+	var hosts []string
+	for _, host := range strings.Split(hostsStr, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	if len(hosts) == 0 {
+		return nil, 0, fmt.Errorf("no host in %q", hostsStr)
+	}
+	if numProcs == 0 {
+		numProcs = len(hosts) * gpusPerHost
+	}
+	if numProcs%len(hosts) != 0 {
+		return nil, 0, fmt.Errorf("np %d is not a multiple of the %d hosts", numProcs, len(hosts))
+	}
+	slots := numProcs / len(hosts)
+	for i, host := range hosts {
+		if !strings.Contains(host, ":") {
+			hosts[i] = fmt.Sprintf("%s:%d", host, slots)
+		}
+	}
+	return hosts, numProcs, nil
+}
+
 func runNcclTest(cfg Config, timeout int) ([]float64, error) {
 	cmd := buildNcclTestCmd(cfg)
 	if cmd == nil {
@@ -406,9 +552,15 @@ func runNcclTest(cfg Config, timeout int) ([]float64, error) {
 	return res, nil
 }
 
-func checkBandwidth(avgBusBandwidths []float64, exceptBwGbps float64) *common.Result {
+func averageBandwidth(avgBusBandwidths []float64) float64 {
 	var sum float64
+	for _, bw := range avgBusBandwidths {
+		sum += bw
+	}
+	return sum / float64(len(avgBusBandwidths))
+}
 
+func checkBandwidth(avgBusBandwidths []float64, exceptBwGbps float64) *common.Result {
 	resItem := &common.CheckerResult{
 		Name:        "NCCLPerfTest",
 		Description: "",
@@ -419,10 +571,7 @@ func checkBandwidth(avgBusBandwidths []float64, exceptBwGbps float64) *common.Re
 		Suggestion:  "Check Nccl Bandwidth",
 	}
 
-	for _, bw := range avgBusBandwidths {
-		sum += bw
-	}
-	avgBusBandwidth := sum / float64(len(avgBusBandwidths))
+	avgBusBandwidth := averageBandwidth(avgBusBandwidths)
 
 	if avgBusBandwidth < exceptBwGbps {
 		resItem.Status = consts.StatusAbnormal
@@ -442,7 +591,7 @@ func checkBandwidth(avgBusBandwidths []float64, exceptBwGbps float64) *common.Re
 
 }
 
-func CheckNcclPerf(numGpus int, gpulist, beginBuffer, endBuffer string, disableNvls bool, exceptBwGbps float64, timeout int, ibHCA string) (*common.Result, error) {
+func CheckNcclPerf(numGpus int, gpulist, beginBuffer, endBuffer string, disableNvls bool, exceptBwGbps float64, timeout int, ibHCA string, baseline *NcclBaseline) (*common.Result, error) {
 	jobCfg := Config{
 		NumGpus:     numGpus,
 		Gpulist:     gpulist,
@@ -461,7 +610,34 @@ func CheckNcclPerf(numGpus int, gpulist, beginBuffer, endBuffer string, disableN
 		return nil, fmt.Errorf("get no avg bus bandwidth res")
 	}
 	res := checkBandwidth(records, exceptBwGbps)
+	baseline.Apply(res, jobCfg, averageBandwidth(records))
+
+	return res, nil
+}
 
+// CheckNcclPerfMultiNode runs all_reduce with one rank per GPU across the hosts
+// through mpirun, so that the bandwidth of the inter-node fabric is measured.
+func CheckNcclPerfMultiNode(hosts []string, numProcs int, gpulist, beginBuffer, endBuffer string, disableNvls bool, exceptBwGbps float64, timeout int, ibHCA string, baseline *NcclBaseline) (*common.Result, error) {
+	jobCfg := Config{
+		Gpulist:     gpulist,
+		TestBin:     "nccl_perf",
+		DisableNvls: disableNvls,
+		beginBuffer: beginBuffer,
+		endBuffer:   endBuffer,
+		IBHCA:       ibHCA,
+		Hosts:       hosts,
+		NumProcs:    numProcs,
+	}
+	records, err := runNcclTest(jobCfg, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("run multi-node nccl test fail: %v", err)
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("get no avg bus bandwidth res")
+	}
+	res := checkBandwidth(records, exceptBwGbps)
+	baseline.Apply(res, jobCfg, averageBandwidth(records))
 	return res, nil
 }
 
@@ -469,10 +645,10 @@ func PrintNcclPerfInfo(result *common.Result) bool {
 	checkerResults := result.Checkers
 	for _, result := range checkerResults {
 		if result.Status == consts.StatusAbnormal {
-			fmt.Printf("%s%s%s\n", consts.Red, result.Detail, consts.Reset)
+			printer.Printf("%s%s%s\n", consts.Red, result.Detail, consts.Reset)
 			return false
 		} else {
-			fmt.Printf("%s%s%s\n", consts.Green, result.Detail, consts.Reset)
+			printer.Printf("%s%s%s\n", consts.Green, result.Detail, consts.Reset)
 			return true
 		}
 	}
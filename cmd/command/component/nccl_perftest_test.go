@@ -0,0 +1,95 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/perfhistory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNcclHosts(t *testing.T) {
+	hosts, np, err := parseNcclHosts("node1, node2,", 0, 8)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"node1:8", "node2:8"}, hosts)
+	assert.Equal(t, 16, np)
+
+	hosts, np, err = parseNcclHosts("node1,node2:2", 4, 8)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"node1:2", "node2:2"}, hosts)
+	assert.Equal(t, 4, np)
+
+	_, _, err = parseNcclHosts("node1,node2,node3", 16, 8)
+	assert.Error(t, err)
+	_, _, err = parseNcclHosts(" , ", 0, 8)
+	assert.Error(t, err)
+}
+
+func TestBuildMpirunArgs(t *testing.T) {
+	cfg := Config{Hosts: []string{"node1:8", "node2:8"}, NumProcs: 16}
+	envMap := map[string]string{
+		"NCCL_IB_HCA":      "=mlx5_0,mlx5_1",
+		"NCCL_NVLS_ENABLE": "0",
+		"HOME":             "/root",
+		"UCX_TLS":          "",
+	}
+	args := buildMpirunArgs(cfg, envMap, []string{"bash", "/var/sichek/scripts/nccl_perf", "-g 1"})
+	cmdline := strings.Join(args, " ")
+
+	assert.Contains(t, cmdline, "-np 16 -H node1:8,node2:8")
+	assert.Contains(t, cmdline, "-x NCCL_IB_HCA -x NCCL_NVLS_ENABLE -x UCX_TLS")
+	assert.NotContains(t, cmdline, "HOME")
+	assert.True(t, strings.HasSuffix(cmdline, "bash /var/sichek/scripts/nccl_perf -g 1"))
+}
+
+func TestNcclBaseline(t *testing.T) {
+	store := perfhistory.NewStore(filepath.Join(t.TempDir(), "perf-history.jsonl"))
+	baseline := &NcclBaseline{Store: store, Compare: true, MaxDropPercent: 10}
+	cfg := Config{NumGpus: 8, beginBuffer: "1G", endBuffer: "8G"}
+
+	// the first runs build the baseline and pass
+	for _, bw := range []float64{180, 182, 178} {
+		res := checkBandwidth([]float64{bw}, 100)
+		baseline.Apply(res, cfg, bw)
+		assert.Equal(t, consts.StatusNormal, res.Status)
+		assert.Contains(t, res.Checkers[0].Detail, "No baseline yet")
+	}
+
+	// above the static threshold, but 20% below the median of the node
+	res := checkBandwidth([]float64{144}, 100)
+	baseline.Apply(res, cfg, 144)
+	assert.Equal(t, consts.StatusAbnormal, res.Status)
+	assert.Equal(t, consts.StatusAbnormal, res.Checkers[0].Status)
+	assert.Contains(t, res.Checkers[0].Detail, "below the median 180.00 Gbps of the last 3 runs")
+
+	// another GPU count has its own baseline
+	res = checkBandwidth([]float64{60}, 50)
+	baseline.Apply(res, Config{NumGpus: 2, beginBuffer: "1G", endBuffer: "8G"}, 60)
+	assert.Equal(t, consts.StatusNormal, res.Status)
+
+	runs, err := store.Runs(ncclPerfTestName, perfhistory.Key(ncclRunParams(cfg)))
+	require.NoError(t, err)
+	assert.Len(t, runs, 4)
+
+	var none *NcclBaseline
+	none.Apply(res, cfg, 1)
+}
@@ -17,7 +17,6 @@ package component
 
 import (
 	"context"
-	"fmt"
 	"strings"
 
 	"github.com/scitix/sichek/cmd/command/spec"
@@ -51,7 +50,7 @@ func NewNvidiaCmd() *cobra.Command {
 				defer cancel()
 			} else {
 				defer func() {
-					logrus.WithField("component", "nvidia").Info(fmt.Printf("Run NVIDIA HealthCheck Cmd context canceled"))
+					logrus.WithField("component", "nvidia").Info("Run NVIDIA HealthCheck Cmd context canceled")
 					cancel()
 				}()
 			}
@@ -96,6 +95,7 @@ func NewNvidiaCmd() *cobra.Command {
 	NvidaCmd.Flags().StringP("spec", "s", "", "Path to the nvidia specification file")
 	NvidaCmd.Flags().BoolP("verbos", "v", false, "Enable verbose output")
 	NvidaCmd.Flags().StringP("ignored-checkers", "i", "", "Ignored checkers")
+	NvidaCmd.AddCommand(NewGpuResetCmd())
 
 	return NvidaCmd
 }
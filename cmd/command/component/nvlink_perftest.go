@@ -0,0 +1,282 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scitix/sichek/cmd/command/spec"
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/nvidia/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	NvlinkPerfTestName         = "NvlinkPerf"
+	defaultNvbandwidthTestcase = "device_to_device_memcpy_read_ce"
+)
+
+// NvlinkPair is the p2p measurement between two GPUs as reported by nvbandwidth,
+// Src is the row and Dst is the column of the nvbandwidth matrix.
+type NvlinkPair struct {
+	Src   int
+	Dst   int
+	Value float64
+}
+
+func NewNvlinkPerftestCmd() *cobra.Command {
+	nvlinkPerftestCmd := &cobra.Command{
+		Use:   "nvlinktest",
+		Short: "Perform NVLink p2p bandwidth tests between GPU pairs",
+		Run: func(cmd *cobra.Command, args []string) {
+			verbose, err := cmd.Flags().GetBool("verbose")
+			if err != nil {
+				logrus.WithField("perftest", "nvlink").Errorf("get to ge the verbose: %v", err)
+			}
+			if !verbose {
+				logrus.SetLevel(logrus.ErrorLevel)
+			}
+			binPath, err := cmd.Flags().GetString("bin")
+			if err != nil {
+				logrus.WithField("perftest", "nvlink").Error(err)
+				return
+			}
+			testcase, err := cmd.Flags().GetString("testcase")
+			if err != nil {
+				logrus.WithField("perftest", "nvlink").Error(err)
+				return
+			}
+			expectedBandwidthGBps, err := cmd.Flags().GetFloat64("expect-bw")
+			if err != nil {
+				logrus.WithField("perftest", "nvlink").Error(err)
+				return
+			}
+			if expectedBandwidthGBps == 0 {
+				specFile, err := spec.EnsureSpecFile("")
+				if err != nil {
+					logrus.WithField("perftest", "nvlink").Debugf("spec file not resolved: %v, using 0 expected bandwidth", err)
+				} else {
+					nvidiaSpecCfg, err := config.LoadSpec(specFile)
+					if err != nil {
+						logrus.WithField("perftest", "nvlink").Debugf("failed to load spec: %v, using 0 expected bandwidth", err)
+					} else if nvidiaSpecCfg.Perf.NvlinkP2PBw > 0 {
+						expectedBandwidthGBps = nvidiaSpecCfg.Perf.NvlinkP2PBw
+						printer.Printf("Using default expected bandwidth: %.2f GB/s\n", expectedBandwidthGBps)
+					}
+				}
+			}
+			timeout, err := cmd.Flags().GetInt("timeout")
+			if err != nil {
+				logrus.WithField("perftest", "nvlink").Error(err)
+				return
+			}
+
+			printer.Printf("Running NVLink p2p test %s, expected bandwidth: %.2f GB/s\n", testcase, expectedBandwidthGBps)
+			res, err := CheckNvlinkPerf(binPath, testcase, expectedBandwidthGBps, timeout)
+			if err != nil {
+				logrus.WithField("perftest", "nvlink").Error(err)
+				printer.Printf("%s%v%s\n", consts.Red, err, consts.Reset)
+				SetComponentStatus(NvlinkPerfTestName, false, "")
+				return
+			}
+			passed := PrintNvlinkPerfInfo(res)
+			SetComponentStatus(res.Item, passed, res.Level)
+			for _, checkerResult := range res.Checkers {
+				if checkerResult.Status == consts.StatusAbnormal && checkerResult.Device != "" {
+					SetComponentStatus(fmt.Sprintf("%s %s", res.Item, checkerResult.Device), false, checkerResult.Level)
+				}
+			}
+		},
+	}
+
+	nvlinkPerftestCmd.Flags().String("bin", "", "Path to the nvbandwidth binary (default: nvbandwidth in PATH or sichek scripts dir)")
+	nvlinkPerftestCmd.Flags().String("testcase", defaultNvbandwidthTestcase, "nvbandwidth testcase to run")
+	nvlinkPerftestCmd.Flags().Float64("expect-bw", 0, "Expected p2p bandwidth of each GPU pair in GB/s")
+	nvlinkPerftestCmd.Flags().BoolP("verbose", "v", false, "Enable verbose output")
+	nvlinkPerftestCmd.Flags().IntP("timeout", "t", 300, "Timeout in seconds")
+
+	return nvlinkPerftestCmd
+}
+
+func resolveNvbandwidthPath(binPath string) (string, error) {
+	if binPath != "" {
+		return binPath, nil
+	}
+	if path, err := exec.LookPath("nvbandwidth"); err == nil {
+		return path, nil
+	}
+	return GetDefaultNcclTestPath("nvbandwidth")
+}
+
+func runNvbandwidth(binPath, testcase string, timeout int) (string, error) {
+	path, err := resolveNvbandwidthPath(binPath)
+	if err != nil {
+		return "", fmt.Errorf("resolve nvbandwidth path failed: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path, "-t", testcase)
+	logrus.WithField("perftest", "nvlink").Infof("Command: %s\n", cmd.String())
+	output, err := cmd.CombinedOutput()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "", fmt.Errorf("nvbandwidth timed out after %d seconds", timeout)
+	}
+	if err != nil {
+		return "", fmt.Errorf("nvbandwidth command failed: %v. output: %s", err, string(output))
+	}
+	logrus.WithField("perftest", "nvlink").Infof("output: %s\n", string(output))
+	return string(output), nil
+}
+
+// parseNvbandwidthMatrix parses the first GPU x GPU matrix printed by nvbandwidth, e.g.
+//
+//	memcpy CE GPU(row) <- GPU(column) bandwidth (GB/s)
+//	           0         1
+//	 0       N/A    389.15
+//	 1    389.26       N/A
+func parseNvbandwidthMatrix(output string) ([]NvlinkPair, error) {
+	var (
+		columns []int
+		pairs   []NvlinkPair
+	)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			if len(pairs) > 0 {
+				break
+			}
+			continue
+		}
+		if columns == nil {
+			if strings.Contains(line, "GPU(row)") {
+				columns = []int{}
+			}
+			continue
+		}
+		if len(columns) == 0 {
+			for _, field := range fields {
+				idx, err := strconv.Atoi(field)
+				if err != nil {
+					return nil, fmt.Errorf("parse matrix header %q failed: %w", line, err)
+				}
+				columns = append(columns, idx)
+			}
+			continue
+		}
+		src, err := strconv.Atoi(fields[0])
+		if err != nil || len(fields)-1 != len(columns) {
+			break
+		}
+		for i, field := range fields[1:] {
+			if field == "N/A" {
+				continue
+			}
+			value, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, fmt.Errorf("parse matrix value %q failed: %w", field, err)
+			}
+			pairs = append(pairs, NvlinkPair{Src: src, Dst: columns[i], Value: value})
+		}
+	}
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("no GPU pair found in nvbandwidth output")
+	}
+	return pairs, nil
+}
+
+func checkNvlinkBandwidth(pairs []NvlinkPair, expectBwGBps float64) *common.Result {
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Src != pairs[j].Src {
+			return pairs[i].Src < pairs[j].Src
+		}
+		return pairs[i].Dst < pairs[j].Dst
+	})
+	res := &common.Result{
+		Item:   NvlinkPerfTestName,
+		Status: consts.StatusNormal,
+		Level:  consts.LevelInfo,
+		Time:   time.Now(),
+	}
+	minBw := pairs[0].Value
+	for _, pair := range pairs {
+		if pair.Value < minBw {
+			minBw = pair.Value
+		}
+		if pair.Value >= expectBwGBps {
+			continue
+		}
+		res.Status = consts.StatusAbnormal
+		res.Level = consts.LevelCritical
+		res.Checkers = append(res.Checkers, &common.CheckerResult{
+			Name:        "NVLinkPerfTest",
+			Description: "NVLink p2p bandwidth between GPU pair is degraded",
+			Device:      fmt.Sprintf("GPU%d->GPU%d", pair.Src, pair.Dst),
+			Spec:        fmt.Sprintf("%.2f GB/s", expectBwGBps),
+			Curr:        fmt.Sprintf("%.2f GB/s", pair.Value),
+			Status:      consts.StatusAbnormal,
+			Level:       consts.LevelCritical,
+			Detail:      fmt.Sprintf("NVLink p2p bandwidth GPU%d->GPU%d is %.2f GB/s, but expected >= %.2f GB/s.", pair.Src, pair.Dst, pair.Value, expectBwGBps),
+			ErrorName:   "NvlinkPerfTestError",
+			Suggestion:  "Check the NVLink status of the GPU pair with nvidia-smi nvlink -s",
+		})
+	}
+	if res.Status == consts.StatusNormal {
+		res.Checkers = append(res.Checkers, &common.CheckerResult{
+			Name:        "NVLinkPerfTest",
+			Description: "NVLink p2p bandwidth between GPU pairs",
+			Spec:        fmt.Sprintf("%.2f GB/s", expectBwGBps),
+			Curr:        fmt.Sprintf("%.2f GB/s", minBw),
+			Status:      consts.StatusNormal,
+			Level:       consts.LevelInfo,
+			Detail:      fmt.Sprintf("NVLink p2p bandwidth test passed on %d GPU pairs, min bandwidth = %.2f GB/s.", len(pairs), minBw),
+			ErrorName:   "NvlinkPerfTestError",
+		})
+	}
+	return res
+}
+
+func CheckNvlinkPerf(binPath, testcase string, expectBwGBps float64, timeout int) (*common.Result, error) {
+	output, err := runNvbandwidth(binPath, testcase, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("run nvbandwidth fail: %v", err)
+	}
+	pairs, err := parseNvbandwidthMatrix(output)
+	if err != nil {
+		return nil, err
+	}
+	return checkNvlinkBandwidth(pairs, expectBwGBps), nil
+}
+
+func PrintNvlinkPerfInfo(result *common.Result) bool {
+	for _, checkerResult := range result.Checkers {
+		if checkerResult.Status == consts.StatusAbnormal {
+			printer.Printf("%s%s%s\n", consts.Red, checkerResult.Detail, consts.Reset)
+		} else {
+			printer.Printf("%s%s%s\n", consts.Green, checkerResult.Detail, consts.Reset)
+		}
+	}
+	return result.Status == consts.StatusNormal
+}
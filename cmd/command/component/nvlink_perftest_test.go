@@ -0,0 +1,68 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"testing"
+
+	"github.com/scitix/sichek/consts"
+)
+
+const nvbandwidthOutput = `nvbandwidth Version: v0.7
+Built from Git version: v0.7
+
+NOTE: This tool reports current measured bandwidth on your system.
+Additional system-specific tuning may be required to achieve maximal peak bandwidth.
+
+CUDA Runtime Version: 12040
+Driver Version: 550.54.15
+
+Running device_to_device_memcpy_read_ce.
+memcpy CE GPU(row) <- GPU(column) bandwidth (GB/s)
+           0         1         2
+ 0       N/A    389.15    389.36
+ 1    389.26       N/A    120.50
+ 2    389.31    389.12       N/A
+
+SUM device_to_device_memcpy_read_ce 2056.70
+`
+
+func TestParseNvbandwidthMatrix(t *testing.T) {
+	pairs, err := parseNvbandwidthMatrix(nvbandwidthOutput)
+	if err != nil {
+		t.Fatalf("parseNvbandwidthMatrix: %v", err)
+	}
+	if len(pairs) != 6 {
+		t.Fatalf("expected 6 GPU pairs, got %d: %+v", len(pairs), pairs)
+	}
+
+	res := checkNvlinkBandwidth(pairs, 350)
+	if res.Status != consts.StatusAbnormal {
+		t.Fatalf("expected abnormal result, got %s", res.Status)
+	}
+	if len(res.Checkers) != 1 || res.Checkers[0].Device != "GPU1->GPU2" {
+		t.Errorf("expected only GPU1->GPU2 to be degraded, got %+v", res.Checkers)
+	}
+
+	res = checkNvlinkBandwidth(pairs, 100)
+	if res.Status != consts.StatusNormal {
+		t.Errorf("expected normal result, got %s", res.Status)
+	}
+
+	if _, err := parseNvbandwidthMatrix("no matrix here"); err == nil {
+		t.Errorf("expected error on output without matrix")
+	}
+}
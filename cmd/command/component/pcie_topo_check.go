@@ -21,6 +21,7 @@ import (
 
 	"github.com/scitix/sichek/components/pcie/topotest"
 	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -62,12 +63,69 @@ func NewPcieTopoCmd() *cobra.Command {
 				os.Exit(-1)
 			}
 			passed := topotest.PrintInfo(res, verbose)
-			ComponentStatuses[res.Item] = passed
+			SetComponentStatus(res.Item, passed, res.Level)
 		},
 	}
 
 	pcieTopoCmd.Flags().StringP("spec", "s", "", "Path to the topo test specification file")
 	pcieTopoCmd.Flags().BoolP("verbose", "v", false, "Enable verbose output")
+	pcieTopoCmd.AddCommand(NewPcieTopoExportCmd())
 
 	return pcieTopoCmd
 }
+
+// NewPcieTopoExportCmd writes the GPUs, HCAs, PCIe switches, NUMA nodes and
+// NVLink connections of the node as Graphviz DOT or JSON.
+func NewPcieTopoExportCmd() *cobra.Command {
+	var (
+		format string
+		output string
+	)
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the PCIe/NVLink topology as Graphviz DOT or JSON",
+		Long: `Export the PCIe/NVLink topology of the node, e.g.
+
+  sichek topo export --format dot | dot -Tsvg -o topo.svg
+  sichek topo export --format json -o topo.json`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if format != "dot" && format != "json" {
+				logrus.WithField("component", "topo").Errorf("unsupported format %q, must be dot or json", format)
+				os.Exit(1)
+			}
+			graph, err := topotest.CollectTopoGraph()
+			if err != nil {
+				logrus.WithField("component", "topo").Errorf("collect topology err: %v", err)
+				os.Exit(1)
+			}
+			var data []byte
+			if format == "json" {
+				data, err = graph.JSON()
+				if err != nil {
+					logrus.WithField("component", "topo").Errorf("failed to marshal topology: %v", err)
+					os.Exit(1)
+				}
+				data = append(data, '\n')
+			} else {
+				data = []byte(graph.DOT())
+			}
+			if output == "" || output == "-" {
+				if _, err := os.Stdout.Write(data); err != nil {
+					logrus.WithField("component", "topo").Errorf("failed to write topology: %v", err)
+					os.Exit(1)
+				}
+				return
+			}
+			if err := os.WriteFile(output, data, 0644); err != nil {
+				logrus.WithField("component", "topo").Errorf("failed to write topology to %s: %v", output, err)
+				os.Exit(1)
+			}
+			printer.Printf("[topo export] topology written to %s\n", output)
+		},
+	}
+
+	exportCmd.Flags().StringVarP(&format, "format", "f", "dot", "Output format: dot or json")
+	exportCmd.Flags().StringVarP(&output, "output", "o", "", "Path to the output file (default stdout)")
+
+	return exportCmd
+}
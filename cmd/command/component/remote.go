@@ -0,0 +1,444 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// DefaultRemoteBinaryPath is where --deploy copies the local binary on each host.
+	DefaultRemoteBinaryPath = "/tmp/sichek-remote"
+	DefaultRemoteParallel   = 16
+	DefaultRemoteTimeout    = 5 * time.Minute
+)
+
+// RemoteOptions controls how `sichek remote` reaches the hosts.
+type RemoteOptions struct {
+	User             string
+	SSHOpts          []string
+	Sudo             bool
+	Deploy           bool
+	LocalBinary      string
+	RemoteBinary     string
+	APIPort          int
+	EnableComponents string
+	IgnoreComponents string
+}
+
+// RemoteComponentStatus is the status of one component in the report of a host.
+type RemoteComponentStatus struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Level  string `json:"level"`
+	Error  string `json:"error,omitempty"`
+}
+
+// RemoteResult is the outcome of the check of one host. Err is set when the
+// report could not be collected, e.g. the host is unreachable.
+type RemoteResult struct {
+	Host       string                  `json:"host"`
+	Node       string                  `json:"node,omitempty"`
+	Status     string                  `json:"status,omitempty"`
+	Level      string                  `json:"level,omitempty"`
+	Components []RemoteComponentStatus `json:"components,omitempty"`
+	Err        error                   `json:"-"`
+	Error      string                  `json:"error,omitempty"`
+}
+
+// RemoteRunner returns the export report of a host.
+type RemoteRunner func(ctx context.Context, host string) ([]byte, error)
+
+// NewRemoteCmd creates the "remote" command which runs sichek on a list of
+// hosts over ssh, or queries their daemon API, and prints the status of each
+// component per node as a matrix.
+func NewRemoteCmd() *cobra.Command {
+	var (
+		hostsFile string
+		opts      RemoteOptions
+		sshOpts   string
+		parallel  int
+		timeout   time.Duration
+		format    string
+		verbos    bool
+	)
+	remoteCmd := &cobra.Command{
+		Use:   "remote",
+		Short: "Check a list of hosts over ssh and print a cluster summary matrix",
+		Long: "Run `sichek export` on every host of --hosts over ssh, optionally copying the local binary there first\n" +
+			"with --deploy, or fetch the /v1/summary of their daemon API with --api-port, and print the status of\n" +
+			"each component per node. The command exits non-zero when a node is abnormal or unreachable.",
+		Run: func(cmd *cobra.Command, args []string) {
+			if !verbos {
+				logrus.SetLevel(logrus.ErrorLevel)
+			}
+			format = strings.ToLower(format)
+			if format != "table" && format != ExportFormatJSON {
+				logrus.WithField("component", "remote").Errorf("unsupported output format %q, expected table or %s", format, ExportFormatJSON)
+				os.Exit(1)
+			}
+			f, err := os.Open(hostsFile)
+			if err != nil {
+				logrus.WithField("component", "remote").Errorf("failed to open hosts file: %v", err)
+				os.Exit(1)
+			}
+			hosts, err := ParseHostsFile(f)
+			f.Close()
+			if err != nil {
+				logrus.WithField("component", "remote").Errorf("failed to read hosts file %s: %v", hostsFile, err)
+				os.Exit(1)
+			}
+			if len(hosts) == 0 {
+				logrus.WithField("component", "remote").Errorf("no host in %s", hostsFile)
+				os.Exit(1)
+			}
+			if sshOpts != "" {
+				opts.SSHOpts = strings.Fields(sshOpts)
+			}
+
+			var runner RemoteRunner
+			if opts.APIPort > 0 {
+				runner = apiRemoteRunner(opts.APIPort)
+			} else {
+				if opts.Deploy {
+					opts.LocalBinary, err = os.Executable()
+					if err != nil {
+						logrus.WithField("component", "remote").Errorf("failed to locate the local binary: %v", err)
+						os.Exit(1)
+					}
+				}
+				runner = sshRemoteRunner(opts, execRemote)
+			}
+			results := RunRemote(context.Background(), hosts, parallel, timeout, runner)
+
+			switch {
+			case printer.Default().IsJSON():
+				if err := printer.JSON(results); err != nil {
+					logrus.WithField("component", "remote").Errorf("failed to marshal results: %v", err)
+					os.Exit(1)
+				}
+			case format == ExportFormatJSON:
+				data, err := json.MarshalIndent(results, "", "  ")
+				if err != nil {
+					logrus.WithField("component", "remote").Errorf("failed to marshal results: %v", err)
+					os.Exit(1)
+				}
+				printer.Println(string(data))
+			default:
+				PrintRemoteMatrix(printer.Default(), results)
+			}
+			for _, r := range results {
+				if r.Err != nil || r.Status == consts.StatusAbnormal {
+					os.Exit(1)
+				}
+			}
+		},
+	}
+
+	remoteCmd.Flags().StringVarP(&hostsFile, "hosts", "H", "", "File listing the hosts to check, one [user@]host per line")
+	remoteCmd.Flags().StringVarP(&opts.User, "user", "u", "", "ssh user of the hosts without one")
+	remoteCmd.Flags().StringVar(&sshOpts, "ssh-opts", "", "Additional ssh/scp options, e.g. \"-i ~/.ssh/id_fleet -p 2222\"")
+	remoteCmd.Flags().BoolVar(&opts.Sudo, "sudo", false, "Run sichek on the hosts with sudo -n")
+	remoteCmd.Flags().BoolVar(&opts.Deploy, "deploy", false, "Copy the local sichek binary to the hosts and run it, then remove it")
+	remoteCmd.Flags().StringVar(&opts.RemoteBinary, "remote-path", DefaultRemoteBinaryPath, "Path of the binary copied by --deploy on the hosts")
+	remoteCmd.Flags().IntVar(&opts.APIPort, "api-port", 0, "Query the /v1/summary of the sichek daemon API on this port instead of ssh, the api_server.addr of the daemons must not be 127.0.0.1")
+	remoteCmd.Flags().IntVarP(&parallel, "parallel", "p", DefaultRemoteParallel, "Number of hosts checked at the same time")
+	remoteCmd.Flags().DurationVarP(&timeout, "timeout", "t", DefaultRemoteTimeout, "Timeout of the check of each host")
+	remoteCmd.Flags().StringVarP(&format, "format", "f", "table", "Output format, table or json")
+	remoteCmd.Flags().BoolVarP(&verbos, "verbos", "v", false, "Enable verbose output")
+	remoteCmd.Flags().StringVarP(&opts.EnableComponents, "enable-components", "E", "", "Enabled components, joined by ','")
+	remoteCmd.Flags().StringVarP(&opts.IgnoreComponents, "ignore-components", "I", "podlog,gpuevents,syslog", "Ignored components")
+	_ = remoteCmd.MarkFlagRequired("hosts")
+	return remoteCmd
+}
+
+// ParseHostsFile reads one host per line. Blank lines and everything after
+// a '#' are skipped, and duplicated hosts are checked once.
+func ParseHostsFile(r io.Reader) ([]string, error) {
+	var hosts []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) > 1 {
+			return nil, fmt.Errorf("invalid host line %q", strings.TrimSpace(line))
+		}
+		if err := validateHost(fields[0]); err != nil {
+			return nil, err
+		}
+		if host := fields[0]; !seen[host] {
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts, scanner.Err()
+}
+
+// validateHost refuses a host ssh would parse as an option.
+func validateHost(host string) error {
+	if strings.HasPrefix(host, "-") {
+		return fmt.Errorf("invalid host %q, a host cannot begin with '-'", host)
+	}
+	return nil
+}
+
+// RunRemote collects the report of every host, at most parallel at a time,
+// and returns the results in the order of hosts.
+func RunRemote(ctx context.Context, hosts []string, parallel int, timeout time.Duration, runner RemoteRunner) []RemoteResult {
+	if parallel <= 0 {
+		parallel = 1
+	}
+	results := make([]RemoteResult, len(hosts))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		go func(i int, host string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			hostCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			data, err := runner(hostCtx, host)
+			if err == nil {
+				results[i], err = ParseRemoteReport(host, data)
+			}
+			if err != nil {
+				logrus.WithField("component", "remote").Warnf("check of %s failed: %v", host, err)
+				results[i] = RemoteResult{Host: host, Err: err, Error: err.Error()}
+			}
+		}(i, host)
+	}
+	wg.Wait()
+	return results
+}
+
+// ParseRemoteReport decodes the component statuses of a report written by
+// `sichek export` or served by the daemon /v1/summary.
+func ParseRemoteReport(host string, data []byte) (RemoteResult, error) {
+	var report struct {
+		Node       string                  `json:"node"`
+		Status     string                  `json:"status"`
+		Level      string                  `json:"level"`
+		Components []RemoteComponentStatus `json:"components"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return RemoteResult{}, fmt.Errorf("invalid report: %w", err)
+	}
+	if report.Status == "" {
+		return RemoteResult{}, fmt.Errorf("invalid report: no status")
+	}
+	sort.Slice(report.Components, func(i, j int) bool { return report.Components[i].Name < report.Components[j].Name })
+	return RemoteResult{
+		Host:       host,
+		Node:       report.Node,
+		Status:     report.Status,
+		Level:      report.Level,
+		Components: report.Components,
+	}, nil
+}
+
+// sshRemoteRunner runs the export on a host with ssh, after copying the local
+// binary there with scp when opts.Deploy is set.
+func sshRemoteRunner(opts RemoteOptions, run func(ctx context.Context, name string, args ...string) ([]byte, error)) RemoteRunner {
+	return func(ctx context.Context, host string) ([]byte, error) {
+		if err := validateHost(host); err != nil {
+			return nil, err
+		}
+		target := host
+		if opts.User != "" && !strings.Contains(host, "@") {
+			target = opts.User + "@" + host
+		}
+		baseOpts := append([]string{"-o", "BatchMode=yes", "-o", "ConnectTimeout=10"}, opts.SSHOpts...)
+		binary := "sichek"
+		if opts.Deploy {
+			binary = opts.RemoteBinary
+			scpArgs := append(append([]string{}, baseOpts...), opts.LocalBinary, target+":"+binary)
+			if _, err := run(ctx, "scp", scpArgs...); err != nil {
+				return nil, err
+			}
+		}
+		sshArgs := append(append([]string{}, baseOpts...), target, remoteExportCommand(opts, binary))
+		return run(ctx, "ssh", sshArgs...)
+	}
+}
+
+// remoteExportCommand is the shell command run on a host, its arguments quoted
+// for the remote shell. A deployed binary is removed afterwards, keeping the
+// exit code of the export.
+func remoteExportCommand(opts RemoteOptions, binary string) string {
+	args := []string{binary, "export", "-f", ExportFormatJSON}
+	if opts.EnableComponents != "" {
+		args = append(args, "-E", opts.EnableComponents)
+	}
+	if opts.IgnoreComponents != "" {
+		args = append(args, "-I", opts.IgnoreComponents)
+	}
+	if opts.Sudo {
+		args = append([]string{"sudo", "-n"}, args...)
+	}
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	command := strings.Join(quoted, " ")
+	if opts.Deploy {
+		command = fmt.Sprintf("%s; rc=$?; rm -f -- %s; exit $rc", command, shellQuote(binary))
+	}
+	return command
+}
+
+// shellSafe matches the words the shell reads literally.
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_./,:=@%+-]+$`)
+
+// shellQuote quotes s as a single word of a POSIX shell.
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func execRemote(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// apiRemoteRunner fetches the last results of the daemon of a host, without
+// running any check on it. The daemon API listens on 127.0.0.1 by default,
+// its api_server.addr must be bound to an address reachable from here.
+func apiRemoteRunner(port int) RemoteRunner {
+	return func(ctx context.Context, host string) ([]byte, error) {
+		if i := strings.LastIndexByte(host, '@'); i >= 0 {
+			host = host[i+1:]
+		}
+		data, err := fetchReport(ctx, fmt.Sprintf("http://%s:%d/v1/summary", host, port))
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return nil, fmt.Errorf("%w: the api_server.addr of the daemon must listen on an address reachable from here, the default 127.0.0.1 only serves local clients", err)
+		}
+		return data, err
+	}
+}
+
+// remoteCell is the text of the matrix cell of a component.
+func remoteCell(c RemoteComponentStatus) (string, string) {
+	switch {
+	case c.Error != "":
+		return "error", consts.Red
+	case c.Status == consts.StatusAbnormal:
+		return c.Level, consts.LevelColor(c.Level)
+	default:
+		return "ok", consts.Green
+	}
+}
+
+// PrintRemoteMatrix prints a row per host with the status of each component
+// checked on any of them, followed by the unreachable hosts and a summary.
+func PrintRemoteMatrix(w io.Writer, results []RemoteResult) {
+	nameSet := make(map[string]bool)
+	for _, r := range results {
+		for _, c := range r.Components {
+			nameSet[c.Name] = true
+		}
+	}
+	names := make([]string, 0, len(nameSet))
+	for name := range nameSet {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	hostWidth := len("NODE")
+	for _, r := range results {
+		hostWidth = max(hostWidth, len(r.Host))
+	}
+	widths := make([]int, len(names))
+	for i, name := range names {
+		widths[i] = max(len(name), len("critical"))
+	}
+
+	fmt.Fprintf(w, "%-*s", hostWidth, "NODE")
+	for i, name := range names {
+		fmt.Fprintf(w, "  %-*s", widths[i], name)
+	}
+	fmt.Fprintln(w)
+
+	var normal, abnormal int
+	var unreachable []RemoteResult
+	for _, r := range results {
+		if r.Err != nil {
+			unreachable = append(unreachable, r)
+			continue
+		}
+		if r.Status == consts.StatusAbnormal {
+			abnormal++
+		} else {
+			normal++
+		}
+		cells := make(map[string]RemoteComponentStatus, len(r.Components))
+		for _, c := range r.Components {
+			cells[c.Name] = c
+		}
+		fmt.Fprintf(w, "%-*s", hostWidth, r.Host)
+		for i, name := range names {
+			c, ok := cells[name]
+			if !ok {
+				fmt.Fprintf(w, "  %-*s", widths[i], "-")
+				continue
+			}
+			text, color := remoteCell(c)
+			fmt.Fprintf(w, "  %s%-*s%s", color, widths[i], text, consts.Reset)
+		}
+		fmt.Fprintln(w)
+	}
+	for _, r := range unreachable {
+		fmt.Fprintf(w, "%-*s  %sunreachable: %s%s\n", hostWidth, r.Host, consts.Red, r.Error, consts.Reset)
+	}
+	fmt.Fprintf(w, "\n%d nodes: %d normal, %d abnormal, %d unreachable\n", len(results), normal, abnormal, len(unreachable))
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHostsFile(t *testing.T) {
+	hosts, err := ParseHostsFile(strings.NewReader("# gpu rack 1\nnode-1\n\n  root@node-2  # spare\nnode-1\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"node-1", "root@node-2"}, hosts)
+
+	_, err = ParseHostsFile(strings.NewReader("node-1 node-2\n"))
+	assert.Error(t, err)
+	_, err = ParseHostsFile(strings.NewReader("-oProxyCommand=touch\n"))
+	assert.Error(t, err)
+}
+
+func remoteReport(node, status, level string, components ...string) []byte {
+	return []byte(fmt.Sprintf(`{"node": %q, "status": %q, "level": %q, "components": [%s]}`,
+		node, status, level, strings.Join(components, ",")))
+}
+
+func TestRunRemote(t *testing.T) {
+	var running, peak int32
+	runner := func(ctx context.Context, host string) ([]byte, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		switch host {
+		case "node-2":
+			return nil, errors.New("ssh: connect to host node-2 port 22: No route to host")
+		case "node-3":
+			return []byte("sudo: a password is required"), nil
+		}
+		return remoteReport(host, "abnormal", "critical",
+			`{"name": "nvidia", "status": "abnormal", "level": "critical"}`,
+			`{"name": "cpu", "status": "normal", "level": "info"}`), nil
+	}
+	hosts := []string{"node-1", "node-2", "node-3", "node-4"}
+	results := RunRemote(context.Background(), hosts, 2, time.Second, runner)
+	require.Len(t, results, 4)
+	for i, r := range results {
+		assert.Equal(t, hosts[i], r.Host)
+	}
+	assert.LessOrEqual(t, atomic.LoadInt32(&peak), int32(2))
+
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, "abnormal", results[0].Status)
+	require.Len(t, results[0].Components, 2)
+	assert.Equal(t, "cpu", results[0].Components[0].Name)
+	assert.ErrorContains(t, results[1].Err, "No route to host")
+	assert.ErrorContains(t, results[2].Err, "invalid report")
+}
+
+func TestSSHRemoteRunner(t *testing.T) {
+	var calls []string
+	run := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		calls = append(calls, name+" "+strings.Join(args, " "))
+		return nil, nil
+	}
+	opts := RemoteOptions{
+		User:             "ops",
+		SSHOpts:          []string{"-p", "2222"},
+		Sudo:             true,
+		Deploy:           true,
+		LocalBinary:      "/usr/local/bin/sichek",
+		RemoteBinary:     DefaultRemoteBinaryPath,
+		IgnoreComponents: "podlog",
+	}
+	_, err := sshRemoteRunner(opts, run)(context.Background(), "node-1")
+	require.NoError(t, err)
+	require.Len(t, calls, 2)
+	assert.Equal(t, "scp -o BatchMode=yes -o ConnectTimeout=10 -p 2222 /usr/local/bin/sichek ops@node-1:/tmp/sichek-remote", calls[0])
+	assert.Equal(t, "ssh -o BatchMode=yes -o ConnectTimeout=10 -p 2222 ops@node-1 "+
+		"sudo -n /tmp/sichek-remote export -f json -I podlog; rc=$?; rm -f -- /tmp/sichek-remote; exit $rc", calls[1])
+
+	calls = nil
+	_, err = sshRemoteRunner(RemoteOptions{User: "ops", EnableComponents: "nvidia,cpu"}, run)(context.Background(), "root@node-2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ssh -o BatchMode=yes -o ConnectTimeout=10 root@node-2 sichek export -f json -E nvidia,cpu"}, calls)
+
+	_, err = sshRemoteRunner(RemoteOptions{}, run)(context.Background(), "-oProxyCommand=touch")
+	assert.Error(t, err)
+}
+
+func TestRemoteExportCommandQuoting(t *testing.T) {
+	command := remoteExportCommand(RemoteOptions{
+		Deploy:           true,
+		EnableComponents: "nvidia;reboot",
+		IgnoreComponents: "it's",
+	}, "/tmp/my sichek")
+	assert.Equal(t, `'/tmp/my sichek' export -f json -E 'nvidia;reboot' -I 'it'\''s'; rc=$?; rm -f -- '/tmp/my sichek'; exit $rc`, command)
+}
+
+func TestPrintRemoteMatrix(t *testing.T) {
+	ok, err := ParseRemoteReport("node-1", remoteReport("node-1", "normal", "info",
+		`{"name": "cpu", "status": "normal", "level": "info"}`))
+	require.NoError(t, err)
+	bad, err := ParseRemoteReport("node-2", remoteReport("node-2", "abnormal", "critical",
+		`{"name": "cpu", "status": "normal", "level": "info"}`,
+		`{"name": "nvidia", "status": "abnormal", "level": "critical"}`,
+		`{"name": "infiniband", "status": "abnormal", "level": "critical", "error": "create failed"}`))
+	require.NoError(t, err)
+	down := RemoteResult{Host: "node-3", Err: errors.New("timeout"), Error: "timeout"}
+
+	var buf bytes.Buffer
+	PrintRemoteMatrix(&buf, []RemoteResult{ok, bad, down})
+	lines := strings.Split(stripColors(buf.String()), "\n")
+	assert.Equal(t, "NODE    cpu       infiniband  nvidia", strings.TrimRight(lines[0], " "))
+	assert.Equal(t, "node-1  ok        -           -", strings.TrimRight(lines[1], " "))
+	assert.Equal(t, "node-2  ok        error       critical", strings.TrimRight(lines[2], " "))
+	assert.Equal(t, "node-3  unreachable: timeout", lines[3])
+	assert.Contains(t, buf.String(), "3 nodes: 1 normal, 1 abnormal, 1 unreachable")
+}
+
+var ansiColor = regexp.MustCompile("\033\\[[0-9;]*m")
+
+func stripColors(s string) string {
+	return ansiColor.ReplaceAllString(s, "")
+}
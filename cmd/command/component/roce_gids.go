@@ -23,6 +23,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/scitix/sichek/pkg/printer"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -40,12 +41,12 @@ func NewRoCEGidsCheckCmd() *cobra.Command {
 
 			ok, issues := checkExpectedGidIndexLayout(verbose)
 			if ok {
-				fmt.Println("✅ All IB ports have expected GID types at indexes 0-3.")
+				printer.Println("✅ All IB ports have expected GID types at indexes 0-3.")
 				ComponentStatuses["roce-gid-layout"] = true
 			} else {
-				fmt.Println("❌ Found IB ports have unexpected GID types or layout:")
+				printer.Println("❌ Found IB ports have unexpected GID types or layout:")
 				for _, line := range issues {
-					fmt.Println("  -", line)
+					printer.Println("  -", line)
 				}
 				ComponentStatuses["roce-gid-layout"] = false
 			}
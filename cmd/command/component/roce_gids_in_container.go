@@ -22,6 +22,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/scitix/sichek/pkg/printer"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -38,12 +39,12 @@ func NewRoCEGidEqualCheckCmd() *cobra.Command {
 			}
 			ok, details := checkIPv4RoCEv2GidIndexEqual(verbose)
 			if ok {
-				fmt.Println("✅ All IB ports have the same IPv4 RoCEv2 GID.")
+				printer.Println("✅ All IB ports have the same IPv4 RoCEv2 GID.")
 				ComponentStatuses["rocev2-gid-equal"] = true
 			} else {
-				fmt.Println("❌ Detected inconsistency in IPv4 RoCEv2 GIDs across IB ports:")
+				printer.Println("❌ Detected inconsistency in IPv4 RoCEv2 GIDs across IB ports:")
 				for _, d := range details {
-					fmt.Println("  -", d)
+					printer.Println("  -", d)
 				}
 				ComponentStatuses["rocev2-gid-equal"] = false
 			}
@@ -17,11 +17,11 @@ package component
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/scitix/sichek/components/hca/config"
 	"github.com/scitix/sichek/components/infiniband/perftest"
 	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -118,17 +118,17 @@ func NewRoCEPerftestCmd() *cobra.Command {
 				specs, err := config.LoadSpec("/var/sichek/config/default_spec.yaml")
 				if err != nil {
 					logrus.WithField("perftest", "roce").Errorf("failed to load HCA spec config: %v", err)
-					fmt.Println("No expected bandwidth or latency specified, using 0 Gbps and 0 us")
+					printer.Println("No expected bandwidth or latency specified, using 0 Gbps and 0 us")
 				} else {
 					for _, spec := range specs .GetMap() {
 						expectedBandwidthGbps = spec.Perf.OneWayBW
 						expectedLatencyUs = spec.Perf.AvgLatency
-						fmt.Printf("Using %s expected bandwidth: %.2f Gbps and latency: %.2f us\n", spec.Hardware.VPD, spec.Perf.OneWayBW, spec.Perf.AvgLatency)
+						printer.Printf("Using %s expected bandwidth: %.2f Gbps and latency: %.2f us\n", spec.Hardware.VPD, spec.Perf.OneWayBW, spec.Perf.AvgLatency)
 						break // Use the first spec, assuming all have the same perf values
 					}
 				}
 			} else {
-				fmt.Printf("Using provided expected bandwidth: %.2f Gbps and latency: %.2f us\n", expectedBandwidthGbps, expectedLatencyUs)
+				printer.Printf("Using provided expected bandwidth: %.2f Gbps and latency: %.2f us\n", expectedBandwidthGbps, expectedLatencyUs)
 			}
 
 			if passed {
@@ -0,0 +1,213 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// InitStatus is the outcome of the creation of a component.
+type InitStatus string
+
+const (
+	InitStatusInitialized InitStatus = "initialized"
+	InitStatusSkipped     InitStatus = "skipped"
+	InitStatusFailed      InitStatus = "failed"
+	InitStatusTimeout     InitStatus = "timeout"
+)
+
+// ComponentInit records how the creation of a component went.
+type ComponentInit struct {
+	Name     string        `json:"name"`
+	Status   InitStatus    `json:"status"`
+	Duration time.Duration `json:"duration"`
+	Reason   string        `json:"reason,omitempty"`
+}
+
+// StartupReport lists the components in the order they were requested, with
+// how long each took to initialize and why the others were left out.
+type StartupReport struct {
+	Components []ComponentInit `json:"components"`
+	Duration   time.Duration   `json:"duration"`
+}
+
+// newComponent creates a component, replaced in tests.
+var newComponent = NewComponent
+
+// InitComponents creates the components concurrently, at most parallel at a
+// time, giving each timeout to initialize. A component that fails, hangs,
+// e.g. in an NVML or OFED call, or is not supported on this node is left out
+// and the others are created anyway. A component that completes after its
+// timeout is discarded.
+func InitComponents(ctx context.Context, componentNames []string, cfgFile string, specFile string, ignoredCheckers []string, parallel int, timeout time.Duration) (map[string]common.Component, *StartupReport) {
+	if parallel <= 0 {
+		parallel = consts.ComponentInitParallel
+	}
+	if timeout <= 0 {
+		timeout = consts.ComponentInitTimeout
+	}
+	start := time.Now()
+	report := &StartupReport{Components: make([]ComponentInit, len(componentNames))}
+	components := make(map[string]common.Component, len(componentNames))
+	var mu sync.Mutex
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for idx, componentName := range componentNames {
+		report.Components[idx] = ComponentInit{Name: componentName, Status: InitStatusSkipped}
+		if componentName == consts.ComponentNameInfiniband && !utils.IsInfinibandExist() {
+			report.Components[idx].Reason = "no InfiniBand device"
+			continue
+		}
+		if !IsKnownComponent(cfgFile, componentName) {
+			report.Components[idx].Reason = "unknown component"
+			continue
+		}
+		wg.Add(1)
+		go func(idx int, componentName string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				report.Components[idx] = ComponentInit{Name: componentName, Status: InitStatusTimeout, Reason: ctx.Err().Error()}
+				return
+			}
+			component, init := initComponent(ctx, componentName, cfgFile, specFile, ignoredCheckers, timeout)
+			report.Components[idx] = init
+			if component != nil {
+				mu.Lock()
+				components[componentName] = component
+				mu.Unlock()
+			}
+		}(idx, componentName)
+	}
+	wg.Wait()
+	report.Duration = time.Since(start)
+	return components, report
+}
+
+// initComponent creates a component within timeout.
+func initComponent(ctx context.Context, componentName string, cfgFile string, specFile string, ignoredCheckers []string, timeout time.Duration) (common.Component, ComponentInit) {
+	type created struct {
+		component common.Component
+		err       error
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	create := newComponent
+	done := make(chan created, 1)
+	go func() {
+		component, err := create(componentName, cfgFile, specFile, ignoredCheckers)
+		done <- created{component, err}
+	}()
+
+	init := ComponentInit{Name: componentName}
+	select {
+	case c := <-done:
+		init.Duration = time.Since(start)
+		switch {
+		case errors.Is(c.err, ErrComponentNotSupported):
+			init.Status = InitStatusSkipped
+			init.Reason = c.err.Error()
+			logrus.WithField("component", componentName).Infof("skip component: %v", c.err)
+		case c.err != nil:
+			init.Status = InitStatusFailed
+			init.Reason = c.err.Error()
+			logrus.WithField("component", componentName).Errorf("failed to create component: %v", c.err)
+		case c.component == nil:
+			init.Status = InitStatusFailed
+			init.Reason = "component is nil after creation"
+			logrus.WithField("component", componentName).Error("component is nil after creation")
+		default:
+			init.Status = InitStatusInitialized
+			return c.component, init
+		}
+		return nil, init
+	case <-ctx.Done():
+		init.Duration = time.Since(start)
+		init.Status = InitStatusTimeout
+		init.Reason = fmt.Sprintf("not initialized within %s", timeout)
+		logrus.WithField("component", componentName).Errorf("failed to create component: %s", init.Reason)
+		go func() {
+			if c := <-done; c.err == nil && c.component != nil {
+				logrus.WithField("component", componentName).Warnf("component initialized after %s, discarded", time.Since(start).Round(time.Millisecond))
+			}
+		}()
+		return nil, init
+	}
+}
+
+// Errors returns the components that failed or timed out, keyed by name.
+func (r *StartupReport) Errors() map[string]error {
+	errs := make(map[string]error)
+	for _, c := range r.Components {
+		if c.Status == InitStatusFailed || c.Status == InitStatusTimeout {
+			errs[c.Name] = errors.New(c.Reason)
+		}
+	}
+	return errs
+}
+
+// Print writes the report as a table, one component per line.
+func (r *StartupReport) Print(w io.Writer) {
+	nameWidth := len("COMPONENT")
+	for _, c := range r.Components {
+		nameWidth = max(nameWidth, len(c.Name))
+	}
+	fmt.Fprintf(w, "%-*s  %-11s  %8s  %s\n", nameWidth, "COMPONENT", "STATUS", "DURATION", "REASON")
+	for _, c := range r.Components {
+		color := consts.Green
+		switch c.Status {
+		case InitStatusSkipped:
+			color = consts.Yellow
+		case InitStatusFailed, InitStatusTimeout:
+			color = consts.Red
+		}
+		fmt.Fprintf(w, "%-*s  %s%-11s%s  %8s  %s\n", nameWidth, c.Name, color, c.Status, consts.Reset, c.Duration.Round(time.Millisecond), c.Reason)
+	}
+	fmt.Fprintf(w, "startup took %s\n", r.Duration.Round(time.Millisecond))
+}
+
+// Log writes the report to the logs of the daemon.
+func (r *StartupReport) Log() {
+	for _, c := range r.Components {
+		entry := logrus.WithFields(logrus.Fields{
+			"component": c.Name,
+			"status":    c.Status,
+			"duration":  c.Duration.Round(time.Millisecond).String(),
+		})
+		switch c.Status {
+		case InitStatusInitialized:
+			entry.Info("component initialized")
+		case InitStatusSkipped:
+			entry.Infof("component skipped: %s", c.Reason)
+		default:
+			entry.Errorf("component not initialized: %s", c.Reason)
+		}
+	}
+	logrus.WithField("daemon", "run").Infof("components initialized in %s", r.Duration.Round(time.Millisecond))
+}
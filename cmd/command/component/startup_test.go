@@ -0,0 +1,179 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+)
+
+func TestInitComponents(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	origNewComponent := newComponent
+	defer func() { newComponent = origNewComponent }()
+	newComponent = func(componentName string, cfgFile string, specFile string, ignoredCheckers []string) (common.Component, error) {
+		switch componentName {
+		case consts.ComponentNameNvidia:
+			<-release
+		case consts.ComponentNameGpfs:
+			return nil, fmt.Errorf("gpfs: %w", ErrComponentNotSupported)
+		case consts.ComponentNameBMC:
+			return nil, errors.New("ipmitool not found")
+		}
+		return &fakeWatchComponent{name: componentName}, nil
+	}
+
+	names := []string{consts.ComponentNameCPU, consts.ComponentNameNvidia, consts.ComponentNameGpfs, consts.ComponentNameBMC, "no-such-component"}
+	components, report := InitComponents(context.Background(), names, "", "", nil, 2, 100*time.Millisecond)
+
+	if len(components) != 1 || components[consts.ComponentNameCPU] == nil {
+		t.Fatalf("expected only the cpu component, got %v", components)
+	}
+	want := []InitStatus{InitStatusInitialized, InitStatusTimeout, InitStatusSkipped, InitStatusFailed, InitStatusSkipped}
+	if len(report.Components) != len(want) {
+		t.Fatalf("expected %d components in the report, got %d", len(want), len(report.Components))
+	}
+	for i, c := range report.Components {
+		if c.Name != names[i] || c.Status != want[i] {
+			t.Errorf("component %d: expected %s %s, got %s %s (%s)", i, names[i], want[i], c.Name, c.Status, c.Reason)
+		}
+	}
+	if reason := report.Components[4].Reason; reason != "unknown component" {
+		t.Errorf("unexpected reason of the unknown component: %q", reason)
+	}
+	if d := report.Components[1].Duration; d < 100*time.Millisecond {
+		t.Errorf("expected the hung component to wait for its timeout, got %s", d)
+	}
+
+	errs := report.Errors()
+	if len(errs) != 2 || errs[consts.ComponentNameNvidia] == nil || errs[consts.ComponentNameBMC] == nil {
+		t.Errorf("expected errors of nvidia and bmc, got %v", errs)
+	}
+
+	var buf bytes.Buffer
+	report.Print(&buf)
+	for _, want := range []string{"COMPONENT", "nvidia", "timeout", "not initialized within 100ms", "ipmitool not found", "startup took"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %q in the report:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestInitComponentsParallel(t *testing.T) {
+	origNewComponent := newComponent
+	defer func() { newComponent = origNewComponent }()
+	var running, peak int32
+	newComponent = func(componentName string, cfgFile string, specFile string, ignoredCheckers []string) (common.Component, error) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return &fakeWatchComponent{name: componentName}, nil
+	}
+
+	names := []string{consts.ComponentNameCPU, consts.ComponentNameNvidia, consts.ComponentNameGpfs, consts.ComponentNameBMC, consts.ComponentNameDmesg, consts.ComponentNameStorage}
+	components, report := InitComponents(context.Background(), names, "", "", nil, 2, time.Second)
+	if len(components) != len(names) {
+		t.Fatalf("expected %d components, got %d", len(names), len(components))
+	}
+	if peak > 2 {
+		t.Errorf("expected at most 2 components created at the same time, got %d", peak)
+	}
+	if report.Duration < 60*time.Millisecond {
+		t.Errorf("expected the creations to be throttled, startup took %s", report.Duration)
+	}
+}
+
+// fakeCheckComponent is a component whose health check takes delay.
+type fakeCheckComponent struct {
+	common.Component
+	name    string
+	delay   time.Duration
+	running *int32
+	peak    *int32
+}
+
+func (c *fakeCheckComponent) Name() string { return c.name }
+
+func (c *fakeCheckComponent) HealthCheck(ctx context.Context) (*common.Result, error) {
+	n := atomic.AddInt32(c.running, 1)
+	defer atomic.AddInt32(c.running, -1)
+	for {
+		p := atomic.LoadInt32(c.peak)
+		if n <= p || atomic.CompareAndSwapInt32(c.peak, p, n) {
+			break
+		}
+	}
+	select {
+	case <-time.After(c.delay):
+		return &common.Result{Item: c.name, Status: consts.StatusNormal, Level: consts.LevelInfo}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *fakeCheckComponent) LastInfo() (common.Info, error) { return nil, nil }
+
+func TestRunComponentChecksWithReport(t *testing.T) {
+	origNewComponent := newComponent
+	defer func() { newComponent = origNewComponent }()
+	var running, peak int32
+	newComponent = func(componentName string, cfgFile string, specFile string, ignoredCheckers []string) (common.Component, error) {
+		delay := 20 * time.Millisecond
+		if componentName == consts.ComponentNameStorage {
+			delay = time.Minute
+		}
+		return &fakeCheckComponent{name: componentName, delay: delay, running: &running, peak: &peak}, nil
+	}
+
+	names := []string{consts.ComponentNameCPU, consts.ComponentNameNvidia, consts.ComponentNameGpfs, consts.ComponentNameBMC, consts.ComponentNameStorage}
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	checkResults, errs, _ := RunComponentChecksWithReport(ctx, names, "", "", nil, 2)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the hung check to be bounded by the deadline, took %s", elapsed)
+	}
+	if peak > 2 {
+		t.Errorf("expected at most 2 components checked at the same time, got %d", peak)
+	}
+	for i, name := range names[:4] {
+		if checkResults[i] == nil || checkResults[i].duration < 20*time.Millisecond {
+			t.Errorf("expected the result and duration of %s, got %+v", name, checkResults[i])
+		}
+	}
+	if len(errs) != 0 {
+		t.Errorf("expected no errors, got %v", errs)
+	}
+	if res := checkResults[4]; res == nil || res.result.Status != consts.StatusAbnormal || res.result.Level != consts.LevelCritical {
+		t.Errorf("expected the storage check to time out, got %+v", res)
+	}
+}
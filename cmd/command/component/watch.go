@@ -0,0 +1,233 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/scitix/sichek/cmd/command/spec"
+	amdcollector "github.com/scitix/sichek/components/amd/collector"
+	"github.com/scitix/sichek/components/common"
+	ibcollector "github.com/scitix/sichek/components/infiniband/collector"
+	nvcollector "github.com/scitix/sichek/components/nvidia/collector"
+	pciecollector "github.com/scitix/sichek/components/pcie/collector"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// clearScreen moves the cursor home and clears the terminal before each frame,
+// it is only written to a colored terminal so redirected output stays plain.
+const clearScreen = "\033[H\033[2J"
+
+// NewWatchCmd creates the "watch" command which reruns the health checks of all
+// components periodically and redraws a dashboard of their statuses, the failing
+// checkers and the key metrics, like `nvidia-smi dmon` across all components.
+func NewWatchCmd() *cobra.Command {
+	var (
+		cfgFile          string
+		specFile         string
+		enableComponents string
+		ignoreComponents string
+		ignoredCheckers  string
+		interval         time.Duration
+		count            int
+		verbos           bool
+	)
+	watchCmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Watch the health of all components in a live refreshing dashboard",
+		Run: func(cmd *cobra.Command, args []string) {
+			if !verbos {
+				logrus.SetLevel(logrus.ErrorLevel)
+			}
+			if interval <= 0 {
+				logrus.WithField("component", "watch").Errorf("invalid interval %s", interval)
+				os.Exit(1)
+			}
+			resolvedCfgFile, err := spec.EnsureCfgFile(cfgFile)
+			if err != nil {
+				logrus.WithField("component", "watch").Errorf("failed to load cfgFile: %v", err)
+			}
+			resolvedSpecFile, err := spec.EnsureSpecFile(specFile)
+			if err != nil {
+				logrus.WithField("component", "watch").Errorf("failed to load specFile: %v", err)
+			}
+			var ignoredCheckersList []string
+			if len(ignoredCheckers) > 0 {
+				ignoredCheckersList = strings.Split(ignoredCheckers, ",")
+			}
+			componentsToCheck := DetermineComponentsToCheck(enableComponents, ignoreComponents, resolvedCfgFile, "watch")
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			hostname, _ := os.Hostname()
+			for round := 1; ; round++ {
+				checkCtx, cancel := context.WithTimeout(ctx, consts.AllCmdTimeout)
+				checkResults, errs := RunComponentChecks(checkCtx, componentsToCheck, resolvedCfgFile, resolvedSpecFile, ignoredCheckersList)
+				cancel()
+				if ctx.Err() != nil {
+					return
+				}
+				switch {
+				case printer.Default().IsJSON():
+					if err := printer.JSON(BuildReport(checkResults, errs)); err != nil {
+						logrus.WithField("component", "watch").Errorf("failed to marshal report: %v", err)
+					}
+				case printer.Default().Mode() == printer.ModeColor:
+					printer.Print(clearScreen + RenderWatchFrame(hostname, time.Now(), interval, checkResults, errs))
+				default:
+					printer.Print(RenderWatchFrame(hostname, time.Now(), interval, checkResults, errs))
+				}
+				if count > 0 && round >= count {
+					return
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(interval):
+				}
+			}
+		},
+	}
+
+	watchCmd.Flags().StringVarP(&cfgFile, "cfg", "c", "", "Path to the user config file")
+	watchCmd.Flags().StringVarP(&specFile, "spec", "s", "", "Path to the sichek specification file")
+	watchCmd.Flags().StringVarP(&enableComponents, "enable-components", "E", "", "Enabled components, joined by ','")
+	watchCmd.Flags().StringVarP(&ignoreComponents, "ignore-components", "I", "podlog,gpuevents,syslog", "Ignored components")
+	watchCmd.Flags().StringVarP(&ignoredCheckers, "ignored-checkers", "i", "", "Ignored checkers")
+	watchCmd.Flags().DurationVarP(&interval, "interval", "n", 5*time.Second, "Refresh interval")
+	watchCmd.Flags().IntVar(&count, "count", 0, "Exit after this many refreshes, 0 runs until interrupted")
+	watchCmd.Flags().BoolVarP(&verbos, "verbos", "v", false, "Enable verbose output")
+
+	return watchCmd
+}
+
+// RenderWatchFrame renders one dashboard frame: a status line per component,
+// followed by the key metrics of the components that collected any.
+func RenderWatchFrame(hostname string, now time.Time, interval time.Duration, checkResults []*CheckResults, errs map[string]error) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "sichek watch   node: %s   every %s   %s   (Ctrl-C to exit)\n\n", hostname, interval, now.Format("2006-01-02 15:04:05"))
+
+	fmt.Fprintf(&b, "%-14s %-10s %-10s %s\n", "COMPONENT", "STATUS", "LEVEL", "FAILED CHECKERS")
+	for _, checkResult := range checkResults {
+		if checkResult == nil || checkResult.result == nil {
+			continue
+		}
+		result := checkResult.result
+		color := consts.Green
+		if result.Status != consts.StatusNormal {
+			color = consts.LevelColor(result.Level)
+		}
+		fmt.Fprintf(&b, "%-14s %s%-10s%s %-10s %s\n", checkResult.component.Name(), color, result.Status, consts.Reset, result.Level, failedCheckers(result))
+	}
+	errNames := make([]string, 0, len(errs))
+	for name := range errs {
+		errNames = append(errNames, name)
+	}
+	sort.Strings(errNames)
+	for _, name := range errNames {
+		fmt.Fprintf(&b, "%-14s %s%-10s%s %-10s %v\n", name, consts.Red, "Error", consts.Reset, "-", errs[name])
+	}
+
+	for _, checkResult := range checkResults {
+		if checkResult == nil {
+			continue
+		}
+		renderWatchMetrics(&b, checkResult.info)
+	}
+	return b.String()
+}
+
+// failedCheckers lists the abnormal checkers of a result with their devices.
+func failedCheckers(result *common.Result) string {
+	var failed []string
+	for _, checker := range result.Checkers {
+		if checker == nil || checker.Status == consts.StatusNormal {
+			continue
+		}
+		if checker.Device != "" {
+			failed = append(failed, fmt.Sprintf("%s(%s)", checker.Name, checker.Device))
+		} else {
+			failed = append(failed, checker.Name)
+		}
+	}
+	if len(failed) == 0 {
+		return "-"
+	}
+	return strings.Join(failed, ", ")
+}
+
+func renderWatchMetrics(b *strings.Builder, info common.Info) {
+	switch info := info.(type) {
+	case *nvcollector.NvidiaInfo:
+		if len(info.DevicesInfo) == 0 {
+			return
+		}
+		fmt.Fprintf(b, "\n%-5s %-18s %-8s %-8s %-8s %-10s %-10s %s\n", "GPU", "BDF", "Temp(C)", "Mem(C)", "Util(%)", "Power(W)", "ECC(vol)", "ECC(agg)")
+		for _, device := range info.DevicesInfo {
+			fmt.Fprintf(b, "%-5d %-18s %-8d %-8d %-8d %-10.0f %-10d %d\n", device.Index, device.PCIeInfo.BDFID,
+				device.Temperature.GPUCurTemperature, device.Temperature.MemoryCurTemperature, device.Utilization.GPUUsagePercent,
+				float64(device.Power.PowerUsage)/1000, device.MemoryErrors.TotalVolatileECC, device.MemoryErrors.TotalAggregateECC)
+		}
+	case *amdcollector.AmdInfo:
+		if len(info.Devices) == 0 {
+			return
+		}
+		fmt.Fprintf(b, "\n%-5s %-14s %-12s %-10s %-10s %s\n", "GPU", "BDF", "Junction(C)", "Mem(C)", "ECC(UE)", "ECC(CE)")
+		for _, device := range info.Devices {
+			fmt.Fprintf(b, "%-5d %-14s %-12.0f %-10.0f %-10d %d\n", device.Index, device.PCIBusID,
+				device.Temperature.Junction, device.Temperature.Memory, device.Ecc.Uncorrectable, device.Ecc.Correctable)
+		}
+	case *ibcollector.InfinibandInfo:
+		info.RLock()
+		defer info.RUnlock()
+		if len(info.IBHardWareInfo) == 0 {
+			return
+		}
+		keys := make([]string, 0, len(info.IBHardWareInfo))
+		for key := range info.IBHardWareInfo {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fmt.Fprintf(b, "\n%-12s %-6s %-14s %-14s %s\n", "IB Dev", "Port", "State", "Phy State", "Speed")
+		for _, key := range keys {
+			hw := info.IBHardWareInfo[key]
+			fmt.Fprintf(b, "%-12s %-6d %-14s %-14s %s\n", hw.IBDev, hw.Port, hw.PortState, hw.PhyState, hw.PortSpeed)
+		}
+	case *pciecollector.PCIeInfo:
+		// only the devices reporting AER errors, a healthy node has none
+		header := false
+		for _, device := range info.Devices {
+			if device.AER == (pciecollector.AERCounters{}) {
+				continue
+			}
+			if !header {
+				fmt.Fprintf(b, "\n%-14s %-6s %-12s %-10s %s\n", "PCIe BDF", "Type", "AER(Corr)", "AER(NF)", "AER(Fatal)")
+				header = true
+			}
+			fmt.Fprintf(b, "%-14s %-6s %-12d %-10d %d\n", device.BDF, device.Type, device.AER.Correctable, device.AER.NonFatal, device.AER.Fatal)
+		}
+	}
+}
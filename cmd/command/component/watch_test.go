@@ -0,0 +1,93 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	ibcollector "github.com/scitix/sichek/components/infiniband/collector"
+	nvcollector "github.com/scitix/sichek/components/nvidia/collector"
+	pciecollector "github.com/scitix/sichek/components/pcie/collector"
+	"github.com/scitix/sichek/consts"
+)
+
+type fakeWatchComponent struct {
+	common.Component
+	name string
+}
+
+func (c *fakeWatchComponent) Name() string { return c.name }
+
+func TestRenderWatchFrame(t *testing.T) {
+	nvidiaInfo := &nvcollector.NvidiaInfo{DevicesInfo: []nvcollector.DeviceInfo{{Index: 3}}}
+	nvidiaInfo.DevicesInfo[0].PCIeInfo.BDFID = "0000:18:00.0"
+	nvidiaInfo.DevicesInfo[0].Temperature.GPUCurTemperature = 71
+	nvidiaInfo.DevicesInfo[0].MemoryErrors.TotalVolatileECC = 2
+
+	ibInfo := &ibcollector.InfinibandInfo{}
+	ibInfo.IBHardWareInfo = map[string]ibcollector.IBHardWareInfo{
+		"mlx5_0": {IBDev: "mlx5_0", Port: 1, PortState: "4: ACTIVE", PhyState: "5: LinkUp", PortSpeed: "400 Gb/sec"},
+	}
+
+	pcieInfo := &pciecollector.PCIeInfo{Devices: []*pciecollector.PCIeDevice{
+		{BDF: "0000:18:00.0", Type: "gpu", AER: pciecollector.AERCounters{Correctable: 12}},
+		{BDF: "0000:19:00.0", Type: "hca"},
+	}}
+
+	checkResults := []*CheckResults{
+		{
+			component: &fakeWatchComponent{name: "nvidia"},
+			result: &common.Result{Status: consts.StatusAbnormal, Level: consts.LevelCritical, Checkers: []*common.CheckerResult{
+				{Name: "gpu-temperature", Device: "3", Status: consts.StatusAbnormal},
+				{Name: "gpu-ecc", Status: consts.StatusNormal},
+			}},
+			info: nvidiaInfo,
+		},
+		{
+			component: &fakeWatchComponent{name: "infiniband"},
+			result:    &common.Result{Status: consts.StatusNormal, Level: consts.LevelInfo},
+			info:      ibInfo,
+		},
+		{
+			component: &fakeWatchComponent{name: "pcie"},
+			result:    &common.Result{Status: consts.StatusNormal, Level: consts.LevelInfo},
+			info:      pcieInfo,
+		},
+	}
+	errs := map[string]error{"ethernet": errors.New("component not found")}
+
+	frame := RenderWatchFrame("node-1", time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), 5*time.Second, checkResults, errs)
+
+	for _, want := range []string{
+		"node-1", "every 5s", "2025-01-02 03:04:05",
+		"gpu-temperature(3)", "0000:18:00.0", "mlx5_0", "4: ACTIVE", "400 Gb/sec",
+		"ethernet", "component not found",
+	} {
+		if !strings.Contains(frame, want) {
+			t.Errorf("frame missing %q:\n%s", want, frame)
+		}
+	}
+	if strings.Contains(frame, "gpu-ecc") {
+		t.Errorf("frame lists a normal checker as failed:\n%s", frame)
+	}
+	if strings.Contains(frame, "0000:19:00.0") {
+		t.Errorf("frame lists a PCIe device without AER errors:\n%s", frame)
+	}
+}
@@ -27,5 +27,6 @@ func NewConfigCmd() *cobra.Command {
 		Short: "Manage sichek configuration files",
 	}
 	configCmd.AddCommand(config.NewSyncCmd())
+	configCmd.AddCommand(config.NewSetIntervalCmd())
 	return configCmd
 }
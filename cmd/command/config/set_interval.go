@@ -0,0 +1,115 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/scitix/sichek/service"
+	"github.com/spf13/cobra"
+)
+
+// NewSetIntervalCmd creates the "config set-interval" subcommand that changes
+// the query interval of a component of the running daemon through its API.
+func NewSetIntervalCmd() *cobra.Command {
+	var (
+		component string
+		addr      string
+	)
+
+	setIntervalCmd := &cobra.Command{
+		Use:   "set-interval <duration>",
+		Short: "Change the query interval of a component of the running daemon",
+		Long: `Change the query interval of a component of the running daemon, e.g.
+
+  sichek config set-interval --component infiniband 10s
+
+The interval applies until the daemon restarts, the adaptive interval, if
+enabled, starts again from it. Requires api_server.enable in the user config.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			interval, err := time.ParseDuration(args[0])
+			if err != nil || interval <= 0 {
+				fmt.Fprintf(os.Stderr, "[config set-interval] invalid interval %q\n", args[0])
+				os.Exit(1)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			current, err := setComponentInterval(ctx, addr, component, interval)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "[config set-interval] %v\n", err)
+				os.Exit(1)
+			}
+			printer.Printf("[config set-interval] %s: query interval %s (base %s)\n", current.Name, current.Interval, current.Base)
+		},
+	}
+
+	setIntervalCmd.Flags().StringVar(&component, "component", "", "component to change the query interval of")
+	setIntervalCmd.Flags().StringVar(&addr, "addr", "127.0.0.1:19092", "address of the daemon API server")
+	_ = setIntervalCmd.MarkFlagRequired("component")
+
+	return setIntervalCmd
+}
+
+// setComponentInterval calls PUT /v1/components/{name}/interval of the daemon at addr.
+func setComponentInterval(ctx context.Context, addr, component string, interval time.Duration) (*common.ModuleInterval, error) {
+	body, err := json.Marshal(service.SetIntervalRequest{Interval: interval.String()})
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	url := fmt.Sprintf("%s/v1/components/%s/interval", strings.TrimRight(addr, "/"), component)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("PUT %s: %w (is the daemon running with api_server enabled?)", url, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error != "" {
+			return nil, fmt.Errorf("PUT %s: %s", url, apiErr.Error)
+		}
+		return nil, fmt.Errorf("PUT %s: status %d", url, resp.StatusCode)
+	}
+	var current common.ModuleInterval
+	if err := json.Unmarshal(data, &current); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	return &current, nil
+}
@@ -16,11 +16,11 @@ limitations under the License.
 package config
 
 import (
-	"fmt"
 	"os"
 
 	"github.com/scitix/sichek/components/common"
 	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -55,7 +55,7 @@ and files are downloaded from SICHEK_SPEC_URL. Use flags to override.`,
 				logrus.WithField("config", "sync").Errorf("failed to sync spec: %v", err)
 				hasError = true
 			} else {
-				fmt.Printf("[config sync] spec: %s\n", specPath)
+				printer.Printf("[config sync] spec: %s\n", specPath)
 			}
 
 			// Sync user config file
@@ -64,13 +64,13 @@ and files are downloaded from SICHEK_SPEC_URL. Use flags to override.`,
 				logrus.WithField("config", "sync").Errorf("failed to sync user config: %v", err)
 				hasError = true
 			} else {
-				fmt.Printf("[config sync] user config: %s\n", cfgPath)
+				printer.Printf("[config sync] user config: %s\n", cfgPath)
 			}
 
 			if hasError {
 				os.Exit(1)
 			}
-			fmt.Println("[config sync] done")
+			printer.Println("[config sync] done")
 		},
 	}
 
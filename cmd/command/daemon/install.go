@@ -0,0 +1,105 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/scitix/sichek/consts"
+	pkgsystemd "github.com/scitix/sichek/pkg/systemd"
+	pkgutils "github.com/scitix/sichek/pkg/utils"
+	"github.com/scitix/sichek/systemd"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// NewDaemonInstallCmd creates and returns a subcommand instance for installing the sichek systemd unit, configuring the basic attributes of the command.
+func NewDaemonInstallCmd() *cobra.Command {
+	daemonInstallCmd := &cobra.Command{
+		Use:   "install",
+		Short: "Install and enable the sichek systemd unit",
+		Long:  "Install the sichek systemd unit and enable it on boot. The `daemon run` flags given here are written to " + systemd.DefaultEnvFile + " and used by the unit.",
+		Run: func(cmd *cobra.Command, args []string) {
+			flags, err := unitFlags(cmd)
+			if err != nil {
+				logrus.WithField("daemon", "install").Error(err)
+				os.Exit(1)
+			}
+			if err := installUnit(flags); err != nil {
+				logrus.WithField("daemon", "install").Error(err)
+				os.Exit(1)
+			}
+			if err := pkgsystemd.EnableSystemdService(consts.ServiceName); err != nil {
+				logrus.WithField("daemon", "install").Errorf("failed to enable systemd unit '%s': %v", consts.ServiceName, err)
+				os.Exit(1)
+			}
+			logrus.WithField("daemon", "install").Infof("installed %s with FLAGS=%q, run `sichek daemon start` to start it", systemd.DefaultUnitFile, strings.Join(flags, " "))
+		},
+	}
+	addRunFlags(daemonInstallCmd)
+	return daemonInstallCmd
+}
+
+// installUnit installs the unit with the given flags and reloads systemd.
+func installUnit(flags []string) error {
+	if exist, _ := pkgsystemd.SystemctlExists(); !exist {
+		return fmt.Errorf("sichek daemon requires systemd")
+	}
+	if !pkgutils.IsRoot() {
+		return fmt.Errorf("sichek daemon requires root to run with systemd")
+	}
+	if err := systemd.InstallUnit(flags); err != nil {
+		return err
+	}
+	if out, err := pkgsystemd.DaemonReload(context.Background()); err != nil {
+		return fmt.Errorf("systemctl daemon-reload failed: %w output: %s", err, out)
+	}
+	return nil
+}
+
+// unitFlags returns the `daemon run` flags set on the command line, in the
+// form the unit passes them on. The config and spec paths are made absolute
+// since the unit does not run in the current directory.
+func unitFlags(cmd *cobra.Command) ([]string, error) {
+	flags := make([]string, 0)
+	var err error
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if f.Name == "foreground" || err != nil {
+			return
+		}
+		value := f.Value.String()
+		if f.Name == "cfg" || f.Name == "spec" {
+			// the spec may also be a spec name resolved by the daemon
+			if _, statErr := os.Stat(value); statErr == nil {
+				if value, err = filepath.Abs(value); err != nil {
+					return
+				}
+			}
+		}
+		if strings.ContainsAny(value, " \t\"") {
+			err = fmt.Errorf("--%s %q: the unit does not support spaces or quotes in flag values", f.Name, value)
+			return
+		}
+		flags = append(flags, fmt.Sprintf("--%s=%s", f.Name, value))
+	})
+	return flags, err
+}
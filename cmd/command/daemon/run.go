@@ -19,7 +19,6 @@ import (
 	"context"
 	"os"
 	"os/signal"
-	"slices"
 	"time"
 
 	"github.com/scitix/sichek/cmd/command/component"
@@ -27,7 +26,6 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
-	"github.com/scitix/sichek/components/common"
 	"github.com/scitix/sichek/consts"
 	"github.com/scitix/sichek/pkg/systemd"
 	"github.com/scitix/sichek/pkg/utils"
@@ -39,155 +37,161 @@ func NewDaemonRunCmd() *cobra.Command {
 		Use:   "run",
 		Short: "Run sichek daemon process",
 		Run: func(cmd *cobra.Command, args []string) {
-			_, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
-			defer cancel()
-
-			// Get log file configuration
-			logFile, _ := cmd.Flags().GetString("log-file")
-			logMaxSize, _ := cmd.Flags().GetInt("log-max-size")
-			logMaxBackups, _ := cmd.Flags().GetInt("log-max-backups")
-			logMaxAge, _ := cmd.Flags().GetInt("log-max-age")
-			logCompress, _ := cmd.Flags().GetBool("log-compress")
-			logAlsoStdout, _ := cmd.Flags().GetBool("log-also-stdout")
-
-			// Get log level
-			logLevelStr, _ := cmd.Flags().GetString("log-level")
-			logLevel := logrus.WarnLevel // default level
-			if logLevelStr != "" {
-				parsedLevel, err := logrus.ParseLevel(logLevelStr)
-				if err != nil {
-					logrus.WithField("daemon", "run").Warnf("invalid log level '%s', using default 'warn': %v", logLevelStr, err)
-				} else {
-					logLevel = parsedLevel
-					logrus.WithField("daemon", "run").Infof("using log level: %s", logLevelStr)
-				}
-			}
-
-			// Initialize logger with file rotation support
-			logConfig := utils.LogConfig{
-				LogFile:            logFile,
-				MaxSize:            logMaxSize,
-				MaxBackups:         logMaxBackups,
-				MaxAge:             logMaxAge,
-				Compress:           logCompress,
-				AlsoOutputToStdout: logAlsoStdout,
-			}
-			utils.InitLoggerWithConfig(logLevel, false, logConfig)
-			cfgFile, err := cmd.Flags().GetString("cfg")
-			if err != nil {
-				logrus.WithField("daemon", "run").Error(err)
-			} else {
-				cfgFile, err = spec.EnsureCfgFile(cfgFile)
-				if err != nil {
-					logrus.WithField("daemon", "run").Errorf("using default cfgFile: %v", err)
-				} else {
-					logrus.WithField("daemon", "run").Info("using cfgFile: " + cfgFile)
-				}
-			}
-
-			specFile, err := cmd.Flags().GetString("spec")
-			if err != nil {
-				logrus.WithField("daemon", "run").Error(err)
-			} else {
-				specFile, err = spec.EnsureSpecFile(specFile)
-				if err != nil {
-					logrus.WithField("daemon", "run").Errorf("using default specFile: %v", err)
-				} else {
-					logrus.WithField("daemon", "run").Info("using specFile: " + specFile)
-				}
-			}
-
-			usedComponentStr, err := cmd.Flags().GetString("enable-components")
-			if err != nil {
-				logrus.WithField("daemon", "run").Error(err)
-			} else {
-				logrus.WithField("daemon", "run").Infof("enable components = %v", usedComponentStr)
-			}
-			ignoreComponentStr, err := cmd.Flags().GetString("ignore-components")
-			if err != nil {
-				logrus.WithField("daemon", "run").Error(err)
-			} else {
-				logrus.WithField("daemon", "run").Infof("ignore-components = %v", ignoreComponentStr)
-			}
-			annoKey, err := cmd.Flags().GetString("annotation-key")
-			if err != nil {
-				logrus.WithField("daemon", "run").Error(err)
-			} else {
-				logrus.WithField("daemon", "run").Infof("set annotation-key %s", annoKey)
-			}
-			metricsPort, err := cmd.Flags().GetInt("metrics-port")
-			if err != nil {
-				logrus.WithField("daemon", "run").Error(err)
-				metricsPort = 0
-			} else if metricsPort > 0 {
-				logrus.WithField("daemon", "run").Infof("using metrics port from command line: %d", metricsPort)
-			}
-			metricsSocket, _ := cmd.Flags().GetString("metrics-socket")
-			if metricsSocket != "" {
-				logrus.WithField("daemon", "run").Infof("using metrics socket from command line: %s", metricsSocket)
-			}
-
-			start := time.Now()
-			signals := make(chan os.Signal, 2048)
-			serviceChan := make(chan service.Service, 1)
-
-			logrus.WithField("daemon", "run").Info("starting sichek daemon service")
-			done := service.HandleSignals(cancel, signals, serviceChan)
-			signal.Notify(signals, service.AllowedSignals...)
-			components := make(map[string]common.Component)
-
-			componentsToCheck := component.DetermineComponentsToCheck(usedComponentStr, ignoreComponentStr, cfgFile, "daemon")
-			for _, componentName := range componentsToCheck {
-				if componentName == consts.ComponentNameInfiniband && !utils.IsInfinibandExist() {
-					continue
-				}
-				if !slices.Contains(consts.DefaultComponents, componentName) {
-					continue
-				}
-				component, err := component.NewComponent(componentName, cfgFile, specFile, nil)
-				if err != nil {
-					logrus.WithField("daemon", "run").Errorf("failed to create component %s: %v, skipping", componentName, err)
-					continue
-				}
-				if component == nil {
-					logrus.WithField("daemon", "run").Errorf("component %s is nil after creation, skipping", componentName)
-					continue
-				}
-				components[componentName] = component
-			}
-			daemonService, err := service.NewService(components, annoKey, cfgFile, metricsPort, metricsSocket)
-			if err != nil {
-				logrus.WithField("daemon", "run").Errorf("create daemon service failed: %v", err)
-				return
-			}
-			serviceChan <- daemonService
-			go daemonService.Run()
-
-			if exist, _ := systemd.SystemctlExists(); exist {
-				if err := service.NotifyReady(); err != nil {
-					logrus.WithField("daemon", "run").Warn("notify is not ready")
-				}
-			} else {
-				logrus.WithField("daemon", "run").Debug("skip sd notify as systemd not exist")
-			}
-
-			logrus.WithField("daemon", "run").Infof("sichek daemon service run succeed, take %f seconds", time.Since(start).Seconds())
-			<-done
+			runDaemon(cmd)
 		},
 	}
-	daemonRunCmd.Flags().StringP("cfg", "c", "", "Path to the user config file")
-	daemonRunCmd.Flags().StringP("spec", "s", "", "Path to the specification file")
-	daemonRunCmd.Flags().StringP("enable-components", "E", "", "Enabled components, joined by `,`")
-	daemonRunCmd.Flags().StringP("ignore-components", "I", "", "Ignored components")
-	daemonRunCmd.Flags().StringP("annotation-key", "A", "", "k8s node annotation key")
-	daemonRunCmd.Flags().IntP("metrics-port", "p", 0, "Prometheus metrics server TCP port (0 means use config file)")
-	daemonRunCmd.Flags().String("metrics-socket", "", "Prometheus metrics Unix socket path (if set, listen on socket instead of TCP)")
-	daemonRunCmd.Flags().StringP("log-file", "f", "/tmp/sichek.log", "Path to log file (enables file logging with rotation)")
-	daemonRunCmd.Flags().StringP("log-level", "l", "debug", "Log level (trace, debug, info, warn, error, fatal, panic)")
-	daemonRunCmd.Flags().Int("log-max-size", 10, "Maximum size in megabytes of the log file before rotation")
-	daemonRunCmd.Flags().Int("log-max-backups", 10, "Maximum number of old log files to retain")
-	daemonRunCmd.Flags().Int("log-max-age", 10, "Maximum number of days to retain old log files")
-	daemonRunCmd.Flags().Bool("log-compress", false, "Compress rotated log files")
-	daemonRunCmd.Flags().Bool("log-also-stdout", true, "Also output logs to stdout in addition to file")
+	addRunFlags(daemonRunCmd)
 	return daemonRunCmd
-}
\ No newline at end of file
+}
+
+// runDaemon runs the daemon in the foreground until it receives a stop
+// signal, with the flags registered by addRunFlags.
+func runDaemon(cmd *cobra.Command) {
+	_, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	// Get log file configuration
+	logFile, _ := cmd.Flags().GetString("log-file")
+	logMaxSize, _ := cmd.Flags().GetInt("log-max-size")
+	logMaxBackups, _ := cmd.Flags().GetInt("log-max-backups")
+	logMaxAge, _ := cmd.Flags().GetInt("log-max-age")
+	logCompress, _ := cmd.Flags().GetBool("log-compress")
+	logAlsoStdout, _ := cmd.Flags().GetBool("log-also-stdout")
+
+	// Get log level
+	logLevelStr, _ := cmd.Flags().GetString("log-level")
+	logLevel := logrus.WarnLevel // default level
+	if logLevelStr != "" {
+		parsedLevel, err := logrus.ParseLevel(logLevelStr)
+		if err != nil {
+			logrus.WithField("daemon", "run").Warnf("invalid log level '%s', using default 'warn': %v", logLevelStr, err)
+		} else {
+			logLevel = parsedLevel
+			logrus.WithField("daemon", "run").Infof("using log level: %s", logLevelStr)
+		}
+	}
+
+	// Initialize logger with file rotation support
+	logConfig := utils.LogConfig{
+		LogFile:            logFile,
+		MaxSize:            logMaxSize,
+		MaxBackups:         logMaxBackups,
+		MaxAge:             logMaxAge,
+		Compress:           logCompress,
+		AlsoOutputToStdout: logAlsoStdout,
+	}
+	utils.InitLoggerWithConfig(logLevel, utils.IsJSONLogFormat(), logConfig)
+	cfgFile, err := cmd.Flags().GetString("cfg")
+	if err != nil {
+		logrus.WithField("daemon", "run").Error(err)
+	} else {
+		cfgFile, err = spec.EnsureCfgFile(cfgFile)
+		if err != nil {
+			logrus.WithField("daemon", "run").Errorf("using default cfgFile: %v", err)
+		} else {
+			logrus.WithField("daemon", "run").Info("using cfgFile: " + cfgFile)
+		}
+	}
+	logRouteConfig, err := utils.LoadLogRouteConfig(cfgFile)
+	if err != nil {
+		logrus.WithField("daemon", "run").Warnf("failed to load log config: %v", err)
+	}
+	if err := utils.InitLogRouting(logRouteConfig, utils.IsJSONLogFormat()); err != nil {
+		logrus.WithField("daemon", "run").Errorf("failed to route component logs: %v", err)
+	}
+
+	specName, err := cmd.Flags().GetString("spec")
+	specFile := specName
+	if err != nil {
+		logrus.WithField("daemon", "run").Error(err)
+	} else {
+		specFile, err = spec.EnsureSpecFile(specName)
+		if err != nil {
+			logrus.WithField("daemon", "run").Errorf("using default specFile: %v", err)
+		} else {
+			logrus.WithField("daemon", "run").Info("using specFile: " + specFile)
+		}
+	}
+
+	usedComponentStr, err := cmd.Flags().GetString("enable-components")
+	if err != nil {
+		logrus.WithField("daemon", "run").Error(err)
+	} else {
+		logrus.WithField("daemon", "run").Infof("enable components = %v", usedComponentStr)
+	}
+	ignoreComponentStr, err := cmd.Flags().GetString("ignore-components")
+	if err != nil {
+		logrus.WithField("daemon", "run").Error(err)
+	} else {
+		logrus.WithField("daemon", "run").Infof("ignore-components = %v", ignoreComponentStr)
+	}
+	annoKey, err := cmd.Flags().GetString("annotation-key")
+	if err != nil {
+		logrus.WithField("daemon", "run").Error(err)
+	} else {
+		logrus.WithField("daemon", "run").Infof("set annotation-key %s", annoKey)
+	}
+	metricsPort, err := cmd.Flags().GetInt("metrics-port")
+	if err != nil {
+		logrus.WithField("daemon", "run").Error(err)
+		metricsPort = 0
+	} else if metricsPort > 0 {
+		logrus.WithField("daemon", "run").Infof("using metrics port from command line: %d", metricsPort)
+	}
+	metricsSocket, _ := cmd.Flags().GetString("metrics-socket")
+	if metricsSocket != "" {
+		logrus.WithField("daemon", "run").Infof("using metrics socket from command line: %s", metricsSocket)
+	}
+
+	start := time.Now()
+	signals := make(chan os.Signal, 2048)
+	serviceChan := make(chan service.Service, 1)
+
+	logrus.WithField("daemon", "run").Info("starting sichek daemon service")
+	done := service.HandleSignals(cancel, signals, serviceChan)
+	signal.Notify(signals, service.AllowedSignals...)
+	componentsToCheck := component.DetermineComponentsToCheck(usedComponentStr, ignoreComponentStr, cfgFile, "daemon")
+	initParallel, _ := cmd.Flags().GetInt("init-parallel")
+	initTimeout, _ := cmd.Flags().GetDuration("init-timeout")
+	components, report := component.InitComponents(context.Background(), componentsToCheck, cfgFile, specFile, nil, initParallel, initTimeout)
+	report.Log()
+	daemonService, err := service.NewService(components, annoKey, cfgFile, specName, specFile, metricsPort, metricsSocket)
+	if err != nil {
+		logrus.WithField("daemon", "run").Errorf("create daemon service failed: %v", err)
+		return
+	}
+	serviceChan <- daemonService
+	go daemonService.Run()
+
+	if exist, _ := systemd.SystemctlExists(); exist {
+		if err := service.NotifyReady(); err != nil {
+			logrus.WithField("daemon", "run").Warn("notify is not ready")
+		}
+	} else {
+		logrus.WithField("daemon", "run").Debug("skip sd notify as systemd not exist")
+	}
+
+	logrus.WithField("daemon", "run").Infof("sichek daemon service run succeed, take %f seconds", time.Since(start).Seconds())
+	<-done
+}
+
+// addRunFlags registers the flags of `sichek daemon run`, which install and
+// start pass on to the unit.
+func addRunFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("cfg", "c", "", "Path to the user config file")
+	cmd.Flags().StringP("spec", "s", "", "Path to the specification file")
+	cmd.Flags().StringP("enable-components", "E", "", "Enabled components, joined by `,`")
+	cmd.Flags().StringP("ignore-components", "I", "", "Ignored components")
+	cmd.Flags().StringP("annotation-key", "A", "", "k8s node annotation key")
+	cmd.Flags().IntP("metrics-port", "p", 0, "Prometheus metrics server TCP port (0 means use config file)")
+	cmd.Flags().String("metrics-socket", "", "Prometheus metrics Unix socket path (if set, listen on socket instead of TCP)")
+	cmd.Flags().Int("init-parallel", consts.ComponentInitParallel, "Number of components initialized at the same time")
+	cmd.Flags().Duration("init-timeout", consts.ComponentInitTimeout, "Timeout of the initialization of each component, a component exceeding it is skipped")
+	cmd.Flags().StringP("log-file", "f", "/tmp/sichek.log", "Path to log file (enables file logging with rotation)")
+	cmd.Flags().StringP("log-level", "l", "debug", "Log level (trace, debug, info, warn, error, fatal, panic)")
+	cmd.Flags().Int("log-max-size", 10, "Maximum size in megabytes of the log file before rotation")
+	cmd.Flags().Int("log-max-backups", 10, "Maximum number of old log files to retain")
+	cmd.Flags().Int("log-max-age", 10, "Maximum number of days to retain old log files")
+	cmd.Flags().Bool("log-compress", false, "Compress rotated log files")
+	cmd.Flags().Bool("log-also-stdout", true, "Also output logs to stdout in addition to file")
+}
@@ -16,11 +16,8 @@ limitations under the License.
 package daemon
 
 import (
-	"os"
-
 	"github.com/scitix/sichek/consts"
 	pkgsystemd "github.com/scitix/sichek/pkg/systemd"
-	pkgutils "github.com/scitix/sichek/pkg/utils"
 	"github.com/scitix/sichek/systemd"
 
 	"github.com/sirupsen/logrus"
@@ -32,31 +29,23 @@ func NewDaemonStartCmd() *cobra.Command {
 	daemonStartCmd := &cobra.Command{
 		Use:   "start",
 		Short: "Startup sichek daemon process in the background",
+		Long:  "Start the sichek systemd unit, installing it first when needed. The `daemon run` flags given here replace the ones of the installed unit. With --foreground the daemon runs in the current process instead.",
 		Run: func(cmd *cobra.Command, args []string) {
-			if exist, _ := pkgsystemd.SystemctlExists(); !exist {
-				logrus.WithField("daemon", "start").Error("sichek start requires systemd")
-				return
-			}
-			if !pkgutils.IsRoot() {
-				logrus.WithField("daemon", "start").Error("sichek start requires root to run with systemd")
+			if foreground, _ := cmd.Flags().GetBool("foreground"); foreground {
+				runDaemon(cmd)
 				return
 			}
-			if !systemd.DefaultBinExists() {
-				logrus.WithField("daemon", "start").Errorf("sichek binary not found at %s", systemd.DefaultBinPath)
+			flags, err := unitFlags(cmd)
+			if err != nil {
+				logrus.WithField("daemon", "start").Error(err)
 				return
 			}
-			if err := systemd.CreateDefaultEnvFile(); err != nil {
-				logrus.WithField("daemon", "start").Error("failed to create systemd env file")
-				return
+			if len(flags) == 0 {
+				// keep the flags of a previous install
+				flags = nil
 			}
-			systemdFileData := systemd.SichekService
-			if err := os.WriteFile(systemd.DefaultUnitFile, []byte(systemdFileData), 0644); err != nil {
-				logrus.WithField("daemon", "start").Error("failed to write systemd unit file")
-				return
-			}
-
-			if err := systemd.LogrotateInit(); err != nil {
-				logrus.WithField("daemon", "start").Error("failed to initialize logrotate for sichek log")
+			if err := installUnit(flags); err != nil {
+				logrus.WithField("daemon", "start").Error(err)
 				return
 			}
 
@@ -69,8 +58,10 @@ func NewDaemonStartCmd() *cobra.Command {
 				return
 			}
 
-			logrus.WithField("daemon", "start").Info("start sichek service succeed")
+			logrus.WithField("daemon", "start").Infof("start sichek service succeed, see `sichek daemon status` and %s", systemd.DefaultEnvFile)
 		},
 	}
+	addRunFlags(daemonStartCmd)
+	daemonStartCmd.Flags().Bool("foreground", false, "Run the daemon in the foreground instead of through systemd")
 	return daemonStartCmd
 }
@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package daemon
+
+import (
+	"os"
+
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
+	pkgsystemd "github.com/scitix/sichek/pkg/systemd"
+	"github.com/scitix/sichek/systemd"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// DaemonStatus is the state of the sichek systemd unit printed by sichek daemon status.
+type DaemonStatus struct {
+	Unit      string `json:"unit"`
+	Installed bool   `json:"installed"`
+	Flags     string `json:"flags,omitempty"`
+	Enabled   bool   `json:"enabled"`
+	Active    bool   `json:"active"`
+}
+
+// NewDaemonStatusCmd creates and returns a subcommand instance for showing the state of the sichek systemd unit, configuring the basic attributes of the command.
+func NewDaemonStatusCmd() *cobra.Command {
+	daemonStatusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the state of the sichek daemon service",
+		Long:  "Show whether the sichek systemd unit is installed, enabled and active, and the flags it runs with. Exits non-zero when the daemon is not active.",
+		Run: func(cmd *cobra.Command, args []string) {
+			if exist, _ := pkgsystemd.SystemctlExists(); !exist {
+				logrus.WithField("daemon", "status").Error("sichek status requires systemd")
+				os.Exit(1)
+			}
+			status := DaemonStatus{Unit: systemd.DefaultUnitFile, Installed: systemd.UnitInstalled()}
+			if flags, err := systemd.ReadEnvFlags(); err == nil {
+				status.Flags = flags
+			}
+			var err error
+			status.Enabled, err = pkgsystemd.IsEnabled(consts.ServiceName)
+			if err != nil {
+				logrus.WithField("daemon", "status").Warnf("failed to check if %s is enabled: %v", consts.ServiceName, err)
+			}
+			status.Active, err = pkgsystemd.IsActive(consts.ServiceName)
+			if err != nil {
+				logrus.WithField("daemon", "status").Warnf("failed to check if %s is active: %v", consts.ServiceName, err)
+			}
+			if printer.Default().IsJSON() {
+				if err := printer.JSON(status); err != nil {
+					logrus.WithField("daemon", "status").Errorf("failed to marshal the status: %v", err)
+				}
+			} else {
+				printer.Printf("unit:      %s (installed: %t)\n", status.Unit, status.Installed)
+				if status.Flags != "" {
+					printer.Printf("flags:     %s\n", status.Flags)
+				}
+				printer.Printf("enabled:   %t\n", status.Enabled)
+				printer.Printf("active:    %t\n", status.Active)
+			}
+			if !status.Active {
+				// same exit code as `systemctl status` for an inactive unit
+				os.Exit(3)
+			}
+		},
+	}
+	return daemonStatusCmd
+}
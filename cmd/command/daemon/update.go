@@ -16,12 +16,8 @@ limitations under the License.
 package daemon
 
 import (
-	"os"
-
 	"github.com/scitix/sichek/consts"
 	pkgsystemd "github.com/scitix/sichek/pkg/systemd"
-	pkgutils "github.com/scitix/sichek/pkg/utils"
-	"github.com/scitix/sichek/systemd"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -33,25 +29,8 @@ func NewDaemonUpdateCmd() *cobra.Command {
 		Use:   "update",
 		Short: "updateup sichek daemon process in the background",
 		Run: func(cmd *cobra.Command, args []string) {
-			if exist, _ := pkgsystemd.SystemctlExists(); !exist {
-				logrus.WithField("daemon", "update").Error("sichek update requires systemd")
-				return
-			}
-			if !pkgutils.IsRoot() {
-				logrus.WithField("daemon", "update").Error("sichek update requires root to run with systemd")
-				return
-			}
-			if !systemd.DefaultBinExists() {
-				logrus.WithField("daemon", "update").Errorf("sichek binary not found at %s", systemd.DefaultBinPath)
-				return
-			}
-			if err := systemd.CreateDefaultEnvFile(); err != nil {
-				logrus.WithField("daemon", "update").Error("failed to create systemd env file")
-				return
-			}
-			systemdFileData := systemd.SichekService
-			if err := os.WriteFile(systemd.DefaultUnitFile, []byte(systemdFileData), 0644); err != nil {
-				logrus.WithField("daemon", "update").Error("failed to write systemd unit file")
+			if err := installUnit(nil); err != nil {
+				logrus.WithField("daemon", "update").Error(err)
 				return
 			}
 
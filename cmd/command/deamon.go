@@ -30,9 +30,11 @@ func NewDaemonCmd() *cobra.Command {
 		Long:    "Start the application in daemon mode for continuous monitoring or other background tasks",
 	}
 
+	daemonCmd.AddCommand(daemon.NewDaemonInstallCmd())
 	daemonCmd.AddCommand(daemon.NewDaemonRunCmd())
 	daemonCmd.AddCommand(daemon.NewDaemonStartCmd())
 	daemonCmd.AddCommand(daemon.NewDaemonStopCmd())
+	daemonCmd.AddCommand(daemon.NewDaemonStatusCmd())
 	daemonCmd.AddCommand(daemon.NewDaemonUpdateCmd())
 	return daemonCmd
 }
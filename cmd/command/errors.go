@@ -0,0 +1,93 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/scitix/sichek/consts/errdef"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// NewErrorsCmd creates the "errors" command which prints the error catalog.
+func NewErrorsCmd() *cobra.Command {
+	errorsCmd := &cobra.Command{
+		Use:   "errors",
+		Short: "Show the catalog of the error names and their stable codes",
+		Long: "Every error name reported by the checkers is registered with a stable code, e.g. GPU-0001, a category " +
+			"and a default severity. The results carry the code as error_code, automation should key off it.",
+	}
+	errorsCmd.AddCommand(newErrorsListCmd())
+	return errorsCmd
+}
+
+func newErrorsListCmd() *cobra.Command {
+	var (
+		category  string
+		component string
+		jsonOut   bool
+	)
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List the registered errors",
+		Example: "sichek errors list --category gpu\nsichek errors list --json",
+		Run: func(cmd *cobra.Command, args []string) {
+			if category != "" && !slices.Contains(errdef.Categories(), errdef.Category(category)) {
+				logrus.WithField("errors", "list").Errorf("unknown category %q, expected one of %v", category, errdef.Categories())
+				os.Exit(1)
+			}
+			var defs []errdef.ErrorDef
+			for _, def := range errdef.All() {
+				if (category == "" || string(def.Category) == category) && (component == "" || def.Component == component) {
+					defs = append(defs, def)
+				}
+			}
+			if printer.Default().IsJSON() {
+				if err := printer.JSON(defs); err != nil {
+					logrus.WithField("errors", "list").Errorf("marshal errors failed: %v", err)
+					os.Exit(1)
+				}
+				return
+			}
+			PrintErrorDefs(printer.Default(), defs)
+		},
+	}
+	listCmd.Flags().StringVar(&category, "category", "", "Only list the errors of a category, e.g. gpu")
+	listCmd.Flags().StringVar(&component, "component", "", "Only list the errors of a component, e.g. nvidia")
+	listCmd.Flags().BoolVar(&jsonOut, "json", false, "Print the errors as JSON, with their remediation and document")
+	return listCmd
+}
+
+// PrintErrorDefs prints one line per error.
+func PrintErrorDefs(w io.Writer, defs []errdef.ErrorDef) {
+	if len(defs) == 0 {
+		fmt.Fprintln(w, "No errors found")
+		return
+	}
+	fmt.Fprintf(w, "%-10s %-38s %-12s %-10s %s\n", "Code", "Name", "Component", "Severity", "Description")
+	for _, def := range defs {
+		component := def.Component
+		if component == "" {
+			component = "*"
+		}
+		fmt.Fprintf(w, "%-10s %-38s %-12s %-10s %s\n", def.Code, def.Name, component, def.Severity, def.Description)
+	}
+}
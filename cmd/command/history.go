@@ -0,0 +1,119 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package command
+
+import (
+	"os"
+	"time"
+
+	"github.com/scitix/sichek/cmd/command/spec"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/history"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// NewHistoryCmd creates the "history" command which reviews the results persisted by the daemon.
+func NewHistoryCmd() *cobra.Command {
+	var (
+		cfgFile   string
+		component string
+		since     time.Duration
+		all       bool
+		jsonOut   bool
+		verbos    bool
+	)
+	historyCmd := &cobra.Command{
+		Use:   "history",
+		Short: "Review the health check history recorded by the daemon",
+		Run: func(cmd *cobra.Command, args []string) {
+			if !verbos {
+				logrus.SetLevel(logrus.ErrorLevel)
+			}
+			resolvedCfgFile, err := spec.EnsureCfgFile(cfgFile)
+			if err != nil {
+				logrus.WithField("history", "cmd").Errorf("failed to load cfgFile: %v", err)
+			}
+			cfg := history.LoadConfig(resolvedCfgFile)
+			if _, err := os.Stat(cfg.History.Path); os.IsNotExist(err) {
+				printer.Printf("No health check history found in %s\n", cfg.History.Path)
+				return
+			}
+			store, err := history.NewJSONLStore(cfg.History.Path, 0)
+			if err != nil {
+				logrus.WithField("history", "cmd").Errorf("open history failed: %v", err)
+				os.Exit(1)
+			}
+			defer store.Close()
+
+			records, err := store.Query(history.Query{
+				Component:    component,
+				Since:        time.Now().Add(-since),
+				AbnormalOnly: !all,
+			})
+			if err != nil {
+				logrus.WithField("history", "cmd").Errorf("query history failed: %v", err)
+				os.Exit(1)
+			}
+			if printer.Default().IsJSON() {
+				if err := printer.JSON(records); err != nil {
+					logrus.WithField("history", "cmd").Errorf("marshal history failed: %v", err)
+					os.Exit(1)
+				}
+				return
+			}
+			PrintHistory(records)
+		},
+	}
+
+	historyCmd.Flags().StringVarP(&cfgFile, "cfg", "c", "", "Path to the user config file")
+	historyCmd.Flags().StringVar(&component, "component", "", "Only show the history of this component (default all)")
+	historyCmd.Flags().DurationVar(&since, "since", 24*time.Hour, "Show the history of this duration, e.g. 24h")
+	historyCmd.Flags().BoolVarP(&all, "all", "a", false, "Also show the normal results")
+	historyCmd.Flags().BoolVar(&jsonOut, "json", false, "Print the records as JSON")
+	historyCmd.Flags().BoolVarP(&verbos, "verbos", "v", false, "Enable verbose output")
+	return historyCmd
+}
+
+// PrintHistory prints one line per abnormal checker, or per result when it is normal.
+func PrintHistory(records []*history.Record) {
+	if len(records) == 0 {
+		printer.Println("No health check history found")
+		return
+	}
+	printer.Printf("%-20s %-12s %-9s %-32s %-16s %s\n", "Time", "Component", "Level", "Error", "Device", "Detail")
+	for _, record := range records {
+		if record.Result == nil {
+			continue
+		}
+		ts := record.Time.Local().Format("2006-01-02 15:04:05")
+		if record.Result.Status != consts.StatusAbnormal {
+			printer.Printf("%-20s %-12s %s%-9s%s %-32s %-16s %s\n", ts, record.Component, consts.Green, consts.StatusNormal, consts.Reset, "-", "-", "-")
+			continue
+		}
+		for _, checker := range record.Result.Checkers {
+			if checker.Status != consts.StatusAbnormal {
+				continue
+			}
+			device := checker.Device
+			if device == "" {
+				device = "-"
+			}
+			printer.Printf("%-20s %-12s %s%-9s%s %-32s %-16s %s\n", ts, record.Component, consts.LevelColor(checker.Level), checker.Level, consts.Reset, checker.ErrorName, device, checker.Detail)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package command
+
+import (
+	"os"
+	"slices"
+	"time"
+
+	pluginconfig "github.com/scitix/sichek/components/plugin/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/scitix/sichek/pkg/silence"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// NewSilenceCmd creates the "silence" command which manages the maintenance windows of known issues.
+func NewSilenceCmd() *cobra.Command {
+	var path, cfgFile string
+	silenceCmd := &cobra.Command{
+		Use:   "silence",
+		Short: "Silence the failures of a checker for a maintenance window",
+		Long: "Silenced checkers are still reported, with the status silenced, but they no longer fail the health check, " +
+			"the node annotation and condition, or the exit code of sichek, until the silence expires or is removed.",
+	}
+	silenceCmd.PersistentFlags().StringVar(&path, "path", "", "Path to the silences file read by the daemon, defaults to silence.path of the user config or "+consts.DefaultSilencePath+" under --host-root")
+	silenceCmd.PersistentFlags().StringVarP(&cfgFile, "cfg", "c", "", "Path to the user config file")
+
+	// the store is resolved once the flags and --host-root are parsed
+	store := func() *silence.Store {
+		if path != "" {
+			return silence.NewStore(path)
+		}
+		return silence.NewStore(silence.LoadPath(cfgFile))
+	}
+	silenceCmd.AddCommand(newSilenceAddCmd(store))
+	silenceCmd.AddCommand(newSilenceListCmd(store))
+	silenceCmd.AddCommand(newSilenceRemoveCmd(store))
+	return silenceCmd
+}
+
+func newSilenceAddCmd(store func() *silence.Store) *cobra.Command {
+	var (
+		component string
+		checker   string
+		duration  time.Duration
+		reason    string
+	)
+	addCmd := &cobra.Command{
+		Use:     "add",
+		Short:   "Add a silence",
+		Example: `sichek silence add --component nvidia --checker xid-79 --duration 2h --reason "RMA pending"`,
+		Run: func(cmd *cobra.Command, args []string) {
+			plugins := pluginconfig.Names("")
+			if !slices.Contains(consts.DefaultComponents, component) && !slices.Contains(plugins, component) {
+				logrus.WithField("silence", "add").Errorf("unknown component %q, expected one of %v", component, append(slices.Clone(consts.DefaultComponents), plugins...))
+				os.Exit(1)
+			}
+			if duration <= 0 {
+				logrus.WithField("silence", "add").Error("--duration must be positive")
+				os.Exit(1)
+			}
+			now := time.Now()
+			added, err := store().Add(&silence.Silence{
+				Component: component,
+				Checker:   checker,
+				Reason:    reason,
+				StartsAt:  now,
+				EndsAt:    now.Add(duration),
+			})
+			if err != nil {
+				logrus.WithField("silence", "add").Errorf("add silence failed: %v", err)
+				os.Exit(1)
+			}
+			printer.Printf("Added silence %s until %s\n", added.ID, added.EndsAt.Local().Format("2006-01-02 15:04:05"))
+		},
+	}
+	addCmd.Flags().StringVar(&component, "component", "", "Component of the silenced checker, e.g. nvidia")
+	addCmd.Flags().StringVar(&checker, "checker", "", "Name or error name of the silenced checker (default all checkers of the component)")
+	addCmd.Flags().DurationVar(&duration, "duration", 0, "How long the silence lasts, e.g. 2h")
+	addCmd.Flags().StringVar(&reason, "reason", "", "Why the failure is silenced, e.g. RMA pending")
+	_ = addCmd.MarkFlagRequired("component")
+	_ = addCmd.MarkFlagRequired("duration")
+	_ = addCmd.MarkFlagRequired("reason")
+	return addCmd
+}
+
+func newSilenceListCmd(store func() *silence.Store) *cobra.Command {
+	var (
+		all     bool
+		jsonOut bool
+	)
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the active silences",
+		Run: func(cmd *cobra.Command, args []string) {
+			store := store()
+			var (
+				silences []*silence.Silence
+				err      error
+			)
+			if all {
+				silences, err = store.List()
+			} else {
+				silences, err = store.Active(time.Now())
+			}
+			if err != nil {
+				logrus.WithField("silence", "list").Errorf("list silences failed: %v", err)
+				os.Exit(1)
+			}
+			if printer.Default().IsJSON() {
+				if err := printer.JSON(silences); err != nil {
+					logrus.WithField("silence", "list").Errorf("marshal silences failed: %v", err)
+					os.Exit(1)
+				}
+				return
+			}
+			PrintSilences(silences)
+		},
+	}
+	listCmd.Flags().BoolVarP(&all, "all", "a", false, "Also list the expired silences")
+	listCmd.Flags().BoolVar(&jsonOut, "json", false, "Print the silences as JSON")
+	return listCmd
+}
+
+func newSilenceRemoveCmd(store func() *silence.Store) *cobra.Command {
+	return &cobra.Command{
+		Use:     "remove <id>",
+		Aliases: []string{"rm"},
+		Short:   "Remove a silence before it expires",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := store().Remove(args[0]); err != nil {
+				logrus.WithField("silence", "remove").Errorf("remove silence failed: %v", err)
+				os.Exit(1)
+			}
+			printer.Printf("Removed silence %s\n", args[0])
+		},
+	}
+}
+
+// PrintSilences prints one line per silence.
+func PrintSilences(silences []*silence.Silence) {
+	if len(silences) == 0 {
+		printer.Println("No silences found")
+		return
+	}
+	printer.Printf("%-10s %-12s %-24s %-20s %s\n", "ID", "Component", "Checker", "Ends", "Reason")
+	for _, s := range silences {
+		checker := s.Checker
+		if checker == "" {
+			checker = "*"
+		}
+		printer.Printf("%-10s %-12s %-24s %-20s %s\n", s.ID, s.Component, checker, s.EndsAt.Local().Format("2006-01-02 15:04:05"), s.Reason)
+	}
+}
@@ -0,0 +1,34 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package command
+
+import (
+	"github.com/scitix/sichek/cmd/command/spec"
+	"github.com/spf13/cobra"
+)
+
+// NewSpecCmd creates the "spec" command group.
+func NewSpecCmd() *cobra.Command {
+	specCmd := &cobra.Command{
+		Use:   "spec",
+		Short: "Manage sichek spec files",
+	}
+	specCmd.AddCommand(spec.NewCreateCmd())
+	specCmd.AddCommand(spec.NewValidateCmd())
+	specCmd.AddCommand(spec.NewSignCmd())
+	specCmd.AddCommand(spec.NewKeygenCmd())
+	return specCmd
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package spec
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	hcaConfig "github.com/scitix/sichek/components/hca/config"
+	ibCollector "github.com/scitix/sichek/components/infiniband/collector"
+	ibConfig "github.com/scitix/sichek/components/infiniband/config"
+	nvidiaConfig "github.com/scitix/sichek/components/nvidia/config"
+	pcieConfig "github.com/scitix/sichek/components/pcie/config"
+	"github.com/scitix/sichek/components/pcie/topotest"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/scitix/sichek/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// NodeSpec is the spec file generated from the local node, with the same
+// top level sections as default_spec.yaml.
+type NodeSpec struct {
+	Nvidia     map[string]*nvidiaConfig.NvidiaSpec `yaml:"nvidia,omitempty"`
+	Infiniband map[string]*ibConfig.InfinibandSpec `yaml:"infiniband,omitempty"`
+	Hca        map[string]*hcaConfig.HCASpec       `yaml:"hca,omitempty"`
+	PcieTopo   map[string]*pcieConfig.PcieTopoSpec `yaml:"pcie_topo,omitempty"`
+}
+
+// NewCreateCmd creates the "spec create" subcommand.
+func NewCreateCmd() *cobra.Command {
+	var (
+		fromNode bool
+		output   string
+		cluster  string
+		verbose  bool
+	)
+	createCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a spec file",
+		Long: `Create a spec file for a new cluster SKU.
+
+With --from-node the nvidia, infiniband, hca and pcie_topo sections are
+filled from the hardware of the current node. Review the generated
+thresholds and perf baselines before uploading the file to SICHEK_SPEC_URL.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if !verbose {
+				logrus.SetLevel(logrus.ErrorLevel)
+			}
+			if !fromNode {
+				logrus.WithField("spec", "create").Error("only --from-node is supported")
+				os.Exit(1)
+			}
+			if cluster == "" {
+				cluster = utils.ExtractClusterName()
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), consts.CmdTimeout)
+			defer cancel()
+
+			nodeSpec := GenerateNodeSpec(ctx, cluster)
+			if (output == "" || output == "-") && printer.Default().IsJSON() {
+				if err := printer.JSON(nodeSpec); err != nil {
+					logrus.WithField("spec", "create").Errorf("failed to marshal spec: %v", err)
+					os.Exit(1)
+				}
+				return
+			}
+			data, err := yaml.Marshal(nodeSpec)
+			if err != nil {
+				logrus.WithField("spec", "create").Errorf("failed to marshal spec: %v", err)
+				os.Exit(1)
+			}
+			if output == "" || output == "-" {
+				printer.Print(string(data))
+				return
+			}
+			if err := os.WriteFile(output, data, 0644); err != nil {
+				logrus.WithField("spec", "create").Errorf("failed to write spec to %s: %v", output, err)
+				os.Exit(1)
+			}
+			printer.Printf("[spec create] spec written to %s\n", output)
+		},
+	}
+
+	createCmd.Flags().BoolVar(&fromNode, "from-node", false, "Generate the spec from the hardware of the current node")
+	createCmd.Flags().StringVarP(&output, "output", "o", "", "Path to the output file (default stdout)")
+	createCmd.Flags().StringVar(&cluster, "cluster", "", "Key of the infiniband spec (default: derived from NODE_NAME or hostname)")
+	createCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
+
+	return createCmd
+}
+
+// GenerateNodeSpec introspects the local node. A section whose hardware is
+// absent or cannot be read is skipped, so a CPU only node still gets its
+// infiniband and hca sections.
+func GenerateNodeSpec(ctx context.Context, cluster string) *NodeSpec {
+	nodeSpec := &NodeSpec{}
+
+	if utils.IsNvidiaGPUExist() {
+		if deviceID, nvidiaSpec, err := nvidiaConfig.GenerateSpec(ctx); err != nil {
+			logrus.WithField("spec", "create").Warnf("skip nvidia spec: %v", err)
+		} else {
+			nodeSpec.Nvidia = map[string]*nvidiaConfig.NvidiaSpec{deviceID: nvidiaSpec}
+		}
+		if deviceID, pcieSpec, err := topotest.GenerateSpec(); err != nil {
+			logrus.WithField("spec", "create").Warnf("skip pcie_topo spec: %v", err)
+		} else {
+			nodeSpec.PcieTopo = map[string]*pcieConfig.PcieTopoSpec{deviceID: pcieSpec}
+		}
+	}
+
+	ibInfo, err := collectInfinibandInfo(ctx)
+	if err != nil {
+		logrus.WithField("spec", "create").Warnf("skip infiniband and hca spec: %v", err)
+		return nodeSpec
+	}
+	nodeSpec.Infiniband = map[string]*ibConfig.InfinibandSpec{cluster: ibConfig.NewSpecFromInfo(ibInfo)}
+	if hcaSpecs := hcaConfig.NewSpecsFromInfo(ibInfo.IBHardWareInfo); len(hcaSpecs) > 0 {
+		nodeSpec.Hca = hcaSpecs
+	}
+	return nodeSpec
+}
+
+func collectInfinibandInfo(ctx context.Context) (*ibCollector.InfinibandInfo, error) {
+	collector, err := ibCollector.NewIBCollector(ctx)
+	if err != nil {
+		return nil, err
+	}
+	info, err := collector.Collect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ibInfo, ok := info.(*ibCollector.InfinibandInfo)
+	if !ok || len(ibInfo.IBPFDevs) == 0 {
+		return nil, fmt.Errorf("no infiniband device found")
+	}
+	return ibInfo, nil
+}
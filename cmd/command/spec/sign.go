@@ -0,0 +1,159 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package spec
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/scitix/sichek/pkg/specsign"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// NewSignCmd creates the "spec sign" subcommand.
+func NewSignCmd() *cobra.Command {
+	var (
+		keyFile  string
+		manifest bool
+		cluster  string
+	)
+	signCmd := &cobra.Command{
+		Use:   "sign --key <private-key> [file...]",
+		Short: "Sign spec files before uploading them to SICHEK_SPEC_URL",
+		Long: `Sign spec files before uploading them to SICHEK_SPEC_URL.
+
+By default a detached signature <file>.sig is written next to every file. With
+--manifest the files, which must share a directory, are listed with their
+sha256 in manifest.json, which is signed as manifest.json.sig: the spec bundle
+of a cluster is then signed at once. Upload the signatures with the files.
+
+The key is an ed25519 private key in PEM, as written by "sichek spec keygen"
+or "openssl genpkey -algorithm ed25519". The nodes verify the downloaded specs
+with the public key of ` + specsign.EnvPublicKeys + ` or ` + specsign.PublicKeyPath() + `.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := signSpecs(keyFile, manifest, cluster, args); err != nil {
+				logrus.WithField("spec", "sign").Error(err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	signCmd.Flags().StringVarP(&keyFile, "key", "k", "", "Path to the ed25519 private key in PEM")
+	signCmd.Flags().BoolVarP(&manifest, "manifest", "m", false, "Sign the files in a manifest.json instead of one .sig per file")
+	signCmd.Flags().StringVar(&cluster, "cluster", "", "Cluster name recorded in the manifest")
+	_ = signCmd.MarkFlagRequired("key")
+
+	return signCmd
+}
+
+func signSpecs(keyFile string, manifest bool, cluster string, files []string) error {
+	if len(files) == 0 {
+		return fmt.Errorf("no spec file given")
+	}
+	keyData, err := os.ReadFile(keyFile)
+	if err != nil {
+		return err
+	}
+	key, err := specsign.ParsePrivateKey(keyData)
+	if err != nil {
+		return fmt.Errorf("%s: %w", keyFile, err)
+	}
+
+	contents := make(map[string][]byte, len(files))
+	dir := filepath.Dir(files[0])
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if !manifest {
+			if err := os.WriteFile(file+specsign.SignatureSuffix, specsign.Sign(key, data), 0644); err != nil {
+				return err
+			}
+			printer.Printf("[spec sign] %s → %s\n", file, file+specsign.SignatureSuffix)
+			continue
+		}
+		if filepath.Dir(file) != dir {
+			return fmt.Errorf("the files of a manifest must share a directory, %s is not in %s", file, dir)
+		}
+		name := filepath.Base(file)
+		if name == specsign.ManifestName || strings.HasSuffix(name, specsign.SignatureSuffix) {
+			return fmt.Errorf("%s is a signature file", file)
+		}
+		contents[name] = data
+	}
+	if !manifest {
+		return nil
+	}
+
+	data, err := specsign.NewManifest(cluster, contents).Marshal()
+	if err != nil {
+		return err
+	}
+	manifestFile := filepath.Join(dir, specsign.ManifestName)
+	if err := os.WriteFile(manifestFile, data, 0644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(manifestFile+specsign.SignatureSuffix, specsign.Sign(key, data), 0644); err != nil {
+		return err
+	}
+	printer.Printf("[spec sign] %d files → %s, %s\n", len(contents), manifestFile, manifestFile+specsign.SignatureSuffix)
+	return nil
+}
+
+// NewKeygenCmd creates the "spec keygen" subcommand.
+func NewKeygenCmd() *cobra.Command {
+	var outDir string
+	keygenCmd := &cobra.Command{
+		Use:   "keygen",
+		Short: "Generate the key pair signing the spec files",
+		Long: `Generate an ed25519 key pair signing the spec files.
+
+The private key spec_signing.key stays with whoever uploads the specs, the
+public key ` + specsign.PublicKeyName + ` is installed on the nodes under the config
+directory or passed in ` + specsign.EnvPublicKeys + `.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			pubPEM, privPEM, err := specsign.GenerateKey()
+			if err != nil {
+				logrus.WithField("spec", "keygen").Error(err)
+				os.Exit(1)
+			}
+			privFile := filepath.Join(outDir, "spec_signing.key")
+			pubFile := filepath.Join(outDir, specsign.PublicKeyName)
+			if _, err := os.Stat(privFile); err == nil {
+				logrus.WithField("spec", "keygen").Errorf("%s already exists", privFile)
+				os.Exit(1)
+			}
+			if err := os.WriteFile(privFile, privPEM, 0600); err != nil {
+				logrus.WithField("spec", "keygen").Error(err)
+				os.Exit(1)
+			}
+			if err := os.WriteFile(pubFile, pubPEM, 0644); err != nil {
+				logrus.WithField("spec", "keygen").Error(err)
+				os.Exit(1)
+			}
+			printer.Printf("[spec keygen] private key → %s, public key → %s\n", privFile, pubFile)
+		},
+	}
+
+	keygenCmd.Flags().StringVarP(&outDir, "output", "o", ".", "Directory to write the key pair to")
+
+	return keygenCmd
+}
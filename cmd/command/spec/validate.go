@@ -0,0 +1,98 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package spec
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/scitix/sichek/pkg/specvalidate"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// NewValidateCmd creates the "spec validate" subcommand.
+func NewValidateCmd() *cobra.Command {
+	var (
+		strict bool
+		schema bool
+	)
+	validateCmd := &cobra.Command{
+		Use:   "validate [file...]",
+		Short: "Validate spec files",
+		Long: `Validate spec files before uploading them to SICHEK_SPEC_URL.
+
+Each section is checked against the JSON schema generated from the types the
+components load it into, then against semantic rules: gpu_nums within 1..16,
+active_nvlink_num consistent with nvlink_supported and the GPU model, nvidia
+device IDs and IB port speeds in the format the node reports them, transceiver
+warning thresholds below the critical ones.
+
+Every issue is reported with its line and column. Unknown fields, which the
+components silently ignore, are warnings unless --strict is set. The command
+exits with 1 when an error is found. With --schema the JSON schema of a spec
+file is printed instead.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if schema {
+				if printer.Default().IsJSON() {
+					if err := printer.JSON(specvalidate.SpecSchema()); err != nil {
+						logrus.WithField("spec", "validate").Errorf("failed to marshal the schema: %v", err)
+						os.Exit(1)
+					}
+					return
+				}
+				data, err := json.MarshalIndent(specvalidate.SpecSchema(), "", "  ")
+				if err != nil {
+					logrus.WithField("spec", "validate").Errorf("failed to marshal the schema: %v", err)
+					os.Exit(1)
+				}
+				printer.Println(string(data))
+				return
+			}
+			if len(args) == 0 {
+				logrus.WithField("spec", "validate").Error("no spec file given")
+				os.Exit(1)
+			}
+			failed := false
+			for _, file := range args {
+				issues, err := specvalidate.ValidateFile(file)
+				if err != nil {
+					printer.Printf("%s: error: %v\n", file, err)
+					failed = true
+					continue
+				}
+				errors := 0
+				for _, issue := range issues {
+					printer.Printf("%s:%s\n", file, issue)
+					if issue.Severity == specvalidate.SeverityError {
+						errors++
+					}
+				}
+				printer.Printf("[spec validate] %s: %d errors, %d warnings\n", file, errors, len(issues)-errors)
+				failed = failed || specvalidate.HasErrors(issues, strict)
+			}
+			if failed {
+				os.Exit(1)
+			}
+		},
+	}
+
+	validateCmd.Flags().BoolVar(&strict, "strict", false, "Treat warnings as errors")
+	validateCmd.Flags().BoolVar(&schema, "schema", false, "Print the JSON schema of a spec file and exit")
+
+	return validateCmd
+}
@@ -0,0 +1,230 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/scitix/sichek/cmd/command/spec"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/scitix/sichek/service"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// NewStatusCmd creates the "status" command which dumps the results cached by
+// a running daemon through its API.
+func NewStatusCmd() *cobra.Command {
+	var (
+		cfgFile   string
+		addr      string
+		component string
+		n         int
+		infos     bool
+		jsonOut   bool
+		verbos    bool
+	)
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the last results cached by the running daemon and the checkers that flapped",
+		Long: "Fetch the results cached in memory by the running sichek daemon from its API (api_server in the user config)\n" +
+			"and print the last --history results of each component with their time, and the checkers that were abnormal\n" +
+			"in some of them only, to see intermittent failures without waiting for them to recur.",
+		Run: func(cmd *cobra.Command, args []string) {
+			if !verbos {
+				logrus.SetLevel(logrus.ErrorLevel)
+			}
+			if addr == "" {
+				resolvedCfgFile, err := spec.EnsureCfgFile(cfgFile)
+				if err != nil {
+					logrus.WithField("status", "cmd").Warnf("failed to load cfgFile: %v", err)
+				}
+				apiCfg, err := service.LoadAPIServerConfig(resolvedCfgFile)
+				if err != nil {
+					logrus.WithField("status", "cmd").Errorf("%v", err)
+					os.Exit(1)
+				}
+				addr = apiCfg.Addr
+			}
+			client := NewAPIClient(addr)
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			components := []string{component}
+			if component == "" {
+				statuses, err := client.ListComponents(ctx)
+				if err != nil {
+					printer.Warnf("%sfailed to list the components of the daemon at %s: %v%s\n", consts.Red, addr, err, consts.Reset)
+					os.Exit(1)
+				}
+				components = components[:0]
+				for _, status := range statuses {
+					components = append(components, status.Name)
+				}
+			}
+			var histories []*service.ComponentHistory
+			for _, name := range components {
+				history, err := client.ComponentHistory(ctx, name, n, infos)
+				if err != nil {
+					printer.Warnf("%sfailed to get the history of %s from %s: %v%s\n", consts.Red, name, addr, err, consts.Reset)
+					os.Exit(1)
+				}
+				histories = append(histories, history)
+			}
+			if printer.Default().IsJSON() {
+				if err := printer.JSON(histories); err != nil {
+					logrus.WithField("status", "cmd").Errorf("marshal history failed: %v", err)
+					os.Exit(1)
+				}
+				return
+			}
+			for _, history := range histories {
+				PrintComponentHistory(printer.Default(), history)
+			}
+		},
+	}
+
+	statusCmd.Flags().StringVarP(&cfgFile, "cfg", "c", "", "Path to the user config file, to find the daemon API address")
+	statusCmd.Flags().StringVar(&addr, "addr", "", "Address of the daemon API, host:port or unix:///path (default api_server.addr of the user config)")
+	statusCmd.Flags().StringVar(&component, "component", "", "Only show this component (default all)")
+	statusCmd.Flags().IntVar(&n, "history", 10, "Number of cached results to show, 0 for all")
+	statusCmd.Flags().BoolVar(&infos, "infos", false, "Also dump the collected infos of the results, with --json")
+	statusCmd.Flags().BoolVar(&jsonOut, "json", false, "Print the history as JSON")
+	statusCmd.Flags().BoolVarP(&verbos, "verbos", "v", false, "Enable verbose output")
+	return statusCmd
+}
+
+// APIClient queries the daemon API over TCP or a unix socket.
+type APIClient struct {
+	client  *http.Client
+	baseURL string
+}
+
+// NewAPIClient creates a client of the daemon API listening on addr, a
+// host:port or a unix:///path socket.
+func NewAPIClient(addr string) *APIClient {
+	if path, ok := strings.CutPrefix(addr, "unix://"); ok {
+		return &APIClient{
+			client: &http.Client{Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return (&net.Dialer{}).DialContext(ctx, "unix", path)
+				},
+			}},
+			baseURL: "http://unix",
+		}
+	}
+	baseURL := addr
+	if !strings.HasPrefix(addr, "http://") && !strings.HasPrefix(addr, "https://") {
+		baseURL = "http://" + addr
+	}
+	return &APIClient{client: &http.Client{}, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// ListComponents returns the components of the daemon.
+func (c *APIClient) ListComponents(ctx context.Context) ([]service.ComponentStatus, error) {
+	var statuses []service.ComponentStatus
+	err := c.get(ctx, "/v1/components", &statuses)
+	return statuses, err
+}
+
+// ComponentHistory returns the last n results cached by a component.
+func (c *APIClient) ComponentHistory(ctx context.Context, component string, n int, infos bool) (*service.ComponentHistory, error) {
+	query := url.Values{}
+	query.Set("n", fmt.Sprint(n))
+	if infos {
+		query.Set("infos", "true")
+	}
+	history := &service.ComponentHistory{}
+	err := c.get(ctx, "/v1/components/"+url.PathEscape(component)+"/history?"+query.Encode(), history)
+	return history, err
+}
+
+func (c *APIClient) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w (is the daemon running with api_server enabled?)", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("%s", apiErr.Error)
+		}
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, v)
+}
+
+// PrintComponentHistory prints a line per cached result with its abnormal
+// checkers, followed by the checkers that flapped.
+func PrintComponentHistory(w io.Writer, history *service.ComponentHistory) {
+	fmt.Fprintf(w, "%s%s%s on %s, last %d results\n", consts.Cyan, history.Component, consts.Reset, history.Node, len(history.Entries))
+	if len(history.Entries) == 0 {
+		fmt.Fprintln(w, "  no result cached yet")
+		return
+	}
+	fmt.Fprintf(w, "  %-20s %-9s %s\n", "Time", "Level", "Abnormal checkers")
+	for _, entry := range history.Entries {
+		result := entry.Result
+		ts := result.Time.Local().Format("2006-01-02 15:04:05")
+		if result.Status != consts.StatusAbnormal {
+			fmt.Fprintf(w, "  %-20s %s%-9s%s %s\n", ts, consts.Green, consts.StatusNormal, consts.Reset, "-")
+			continue
+		}
+		var abnormal []string
+		for _, checker := range result.Checkers {
+			if checker.Status != consts.StatusAbnormal {
+				continue
+			}
+			name := checker.Name
+			if checker.Device != "" {
+				name += "(" + checker.Device + ")"
+			}
+			abnormal = append(abnormal, name)
+		}
+		fmt.Fprintf(w, "  %-20s %s%-9s%s %s\n", ts, consts.LevelColor(result.Level), result.Level, consts.Reset, strings.Join(abnormal, ", "))
+	}
+	if len(history.Flapping) == 0 {
+		fmt.Fprintf(w, "  %sno checker flapped%s\n", consts.Green, consts.Reset)
+		return
+	}
+	fmt.Fprintf(w, "  %sFlapping checkers:%s\n", consts.Yellow, consts.Reset)
+	fmt.Fprintf(w, "  %-32s %-10s %-8s %s\n", "Checker", "Abnormal", "Changes", "Last abnormal")
+	for _, flap := range history.Flapping {
+		fmt.Fprintf(w, "  %-32s %-10s %-8d %s\n", flap.Name, fmt.Sprintf("%d/%d", flap.Abnormal, flap.Samples), flap.Transitions,
+			flap.LastAbnormal.Local().Format("2006-01-02 15:04:05"))
+	}
+}
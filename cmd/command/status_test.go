@@ -0,0 +1,80 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package command
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/service"
+)
+
+func TestAPIClientComponentHistory(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "api.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := service.NewHTTPServer(service.APIServerConfig{}, map[string]common.Component{}, "node-1")
+	ts := httptest.NewUnstartedServer(srv.Handler())
+	ts.Listener = listener
+	ts.Start()
+	defer ts.Close()
+
+	client := NewAPIClient("unix://" + socketPath)
+	statuses, err := client.ListComponents(context.Background())
+	if err != nil || len(statuses) != 0 {
+		t.Fatalf("ListComponents: %v, %v", statuses, err)
+	}
+	_, err = client.ComponentHistory(context.Background(), consts.ComponentNameCPU, 5, false)
+	if err == nil || !strings.Contains(err.Error(), "component cpu not found") {
+		t.Errorf("expected the error of the API, got %v", err)
+	}
+}
+
+func TestPrintComponentHistory(t *testing.T) {
+	at := time.Date(2025, 3, 1, 10, 0, 0, 0, time.Local)
+	history := &service.ComponentHistory{
+		Component: consts.ComponentNameInfiniband,
+		Node:      "node-1",
+		Entries: []service.HistoryEntry{
+			{Result: &common.Result{Status: consts.StatusNormal, Time: at}},
+			{Result: &common.Result{Status: consts.StatusAbnormal, Level: consts.LevelCritical, Time: at.Add(time.Minute), Checkers: []*common.CheckerResult{
+				{Name: "ib-port-state", Device: "mlx5_1", Status: consts.StatusAbnormal},
+				{Name: "ib-fw-version", Status: consts.StatusNormal},
+			}}},
+		},
+		Flapping: []service.CheckerFlap{{Name: "ib-port-state", Samples: 2, Abnormal: 1, Transitions: 1, LastAbnormal: at.Add(time.Minute)}},
+	}
+	var buf bytes.Buffer
+	PrintComponentHistory(&buf, history)
+	for _, want := range []string{"infiniband", "last 2 results", "2025-03-01 10:01:00", "ib-port-state(mlx5_1)", "Flapping checkers", "1/2"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "ib-fw-version") {
+		t.Errorf("normal checker printed:\n%s", buf.String())
+	}
+}
@@ -44,7 +44,6 @@ func NewVersionCmd() *cobra.Command {
 		Short:   "Print the version number of sichek",
 		Long:    "All software has versions. This is sichek's",
 		Run: func(cmd *cobra.Command, args []string) {
-			var version string
 			if GitCommit == "none" {
 				GitCommit = getGitCommit()
 			}
@@ -55,19 +54,23 @@ func NewVersionCmd() *cobra.Command {
 				now := time.Now()
 				BuildTime = now.Format("2006-01-02T15:04:05")
 			}
-			if Version != "" {
-				version = Version
-			} else if Major == "" {
-				version = "dev-" + GitCommit
-			} else {
-				version = "v" + Major + "." + Minor + "." + Patch
-			}
+			version := versionString()
 			cmd.Printf("Version: %s\nGit Commit: %s\nGo Version: %s\nBuildTime: %s\n", version, GitCommit, GoVersion, BuildTime)
 		},
 	}
 	return VersionCmd
 }
 
+// versionString returns the version of sichek set at build time.
+func versionString() string {
+	if Version != "" {
+		return Version
+	} else if Major == "" {
+		return "dev-" + GitCommit
+	}
+	return "v" + Major + "." + Minor + "." + Patch
+}
+
 func getGitCommitWithShell() string {
 	cmd := exec.Command("git", "rev-parse", "HEAD")
 	output, err := cmd.Output()
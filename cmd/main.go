@@ -16,13 +16,10 @@ limitations under the License.
 package main
 
 import (
-	"fmt"
 	"os"
 
 	"github.com/scitix/sichek/cmd/command"
 	"github.com/scitix/sichek/cmd/command/component"
-	"github.com/scitix/sichek/consts"
-	"github.com/scitix/sichek/pkg/utils"
 )
 
 func main() {
@@ -31,35 +28,11 @@ func main() {
 		panic(err)
 	}
 	if len(component.ComponentStatuses) != 0 {
-		printComponentStatuses()
+		component.PrintComponentStatuses()
 	}
-	if !isAllPassed() {
+	if !component.IsAllPassed() {
 		os.Exit(-1)
 	} else {
 		os.Exit(0)
 	}
 }
-
-func isAllPassed() bool {
-	component.StatusMutex.Lock()
-	defer component.StatusMutex.Unlock()
-	for _, passed := range component.ComponentStatuses {
-		if !passed {
-			return false
-		}
-	}
-	return true
-}
-
-func printComponentStatuses() {
-	component.StatusMutex.Lock()
-	defer component.StatusMutex.Unlock()
-	utils.PrintTitle("Summary", "-")
-	for componentItem, status := range component.ComponentStatuses {
-		statusStr := fmt.Sprintf("%s%s%s", consts.Green, "PASS", consts.Reset)
-		if !status {
-			statusStr = fmt.Sprintf("%s%s%s", consts.Red, "FAIL", consts.Reset)
-		}
-		fmt.Printf(" - %s: %s\n", componentItem, statusStr)
-	}
-}
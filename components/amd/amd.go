@@ -0,0 +1,311 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package amd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/scitix/sichek/components/amd/checker"
+	"github.com/scitix/sichek/components/amd/collector"
+	"github.com/scitix/sichek/components/amd/config"
+	amdmetrics "github.com/scitix/sichek/components/amd/metrics"
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/scitix/sichek/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+type component struct {
+	ctx           context.Context
+	cancel        context.CancelFunc
+	componentName string
+	cfg           *config.AmdUserConfig
+	cfgMutex      sync.Mutex
+	spec          *config.AmdSpec
+	collector     *collector.AmdCollector
+	checkers      []common.Checker
+	specMtx       sync.RWMutex
+	metrics       *amdmetrics.AmdMetrics
+
+	cacheMtx    sync.RWMutex
+	cacheBuffer []*common.Result
+	cacheInfo   []common.Info
+	currIndex   int64
+	cacheSize   int64
+
+	service *common.CommonService
+}
+
+var (
+	amdComponent     *component
+	amdComponentOnce sync.Once
+)
+
+func NewComponent(cfgFile string, specFile string, ignoredCheckers []string) (common.Component, error) {
+	var err error
+	amdComponentOnce.Do(func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic occurred when create component amd: %v", r)
+			}
+		}()
+		amdComponent, err = newComponent(cfgFile, specFile, ignoredCheckers)
+	})
+	return amdComponent, err
+}
+
+func newComponent(cfgFile string, specFile string, ignoredCheckers []string) (comp *component, err error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		if err != nil {
+			cancel()
+		}
+	}()
+
+	cfg := &config.AmdUserConfig{}
+	err = common.LoadUserConfig(cfgFile, cfg)
+	if err != nil || cfg.Amd == nil {
+		logrus.WithField("component", "amd").Warnf("get user config failed or amd config is nil, using default config")
+		cfg.Amd = &config.AmdConfig{
+			QueryInterval: common.Duration{Duration: 10 * time.Second},
+			CacheSize:     5,
+		}
+	}
+	if len(ignoredCheckers) > 0 {
+		cfg.Amd.IgnoredCheckers = ignoredCheckers
+	}
+
+	spec, specErr := config.LoadSpec(specFile)
+	if specErr != nil {
+		logrus.WithField("component", "amd").Warnf("failed to load spec %s: %v", specFile, specErr)
+	}
+
+	collectorInst, err := collector.NewAmdCollector()
+	if err != nil {
+		logrus.WithField("component", "amd").Errorf("create amd collector failed: %v", err)
+		return nil, err
+	}
+
+	checkers, err := common.CheckersOrSpecMissing(consts.ComponentNameAmd, spec, specErr, func(spec *config.AmdSpec) ([]common.Checker, error) {
+		return checker.NewCheckers(cfg, spec)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cacheSize := cfg.Amd.CacheSize
+	if cacheSize == 0 {
+		cacheSize = 5
+	}
+
+	comp = &component{
+		ctx:           ctx,
+		cancel:        cancel,
+		componentName: consts.ComponentNameAmd,
+		cfg:           cfg,
+		spec:          spec,
+		collector:     collectorInst,
+		checkers:      checkers,
+		cacheBuffer:   make([]*common.Result, cacheSize),
+		cacheInfo:     make([]common.Info, cacheSize),
+		cacheSize:     cacheSize,
+	}
+	if cfg.Amd.EnableMetrics {
+		comp.metrics = amdmetrics.NewAmdMetrics()
+	}
+	service := common.NewCommonService(ctx, cfg, comp.componentName, comp.GetTimeout(), comp.HealthCheck)
+	comp.service = service
+
+	return comp, nil
+}
+
+func (c *component) Name() string {
+	return c.componentName
+}
+
+func (c *component) HealthCheck(ctx context.Context) (*common.Result, error) {
+	timer := common.NewTimer(fmt.Sprintf("%s-HealthCheck-Cost", c.componentName))
+	info, err := common.Collect(ctx, c.componentName, c.collector.Collect)
+	if err != nil {
+		logrus.WithField("component", "amd").Errorf("failed to collect amd gpu info: %v", err)
+		return nil, err
+	}
+	amdInfo, ok := info.(*collector.AmdInfo)
+	if !ok {
+		return nil, fmt.Errorf("wrong info type, expected *collector.AmdInfo")
+	}
+	timer.Mark("amd-collect")
+
+	if c.metrics != nil {
+		c.metrics.ExportMetrics(amdInfo)
+	}
+
+	c.specMtx.RLock()
+	checkers := c.checkers
+	c.specMtx.RUnlock()
+	result := common.Check(ctx, c.componentName, amdInfo, checkers)
+	timer.Mark("amd-check")
+
+	c.cacheMtx.Lock()
+	c.cacheBuffer[c.currIndex] = result
+	c.cacheInfo[c.currIndex] = amdInfo
+	c.currIndex = (c.currIndex + 1) % c.cacheSize
+	c.cacheMtx.Unlock()
+
+	if result.Status == consts.StatusAbnormal && consts.LevelPriority[result.Level] > consts.LevelPriority[consts.LevelInfo] {
+		logrus.WithField("component", "amd").Errorf("Health Check Failed")
+	} else {
+		logrus.WithField("component", "amd").Infof("Health Check PASSED")
+	}
+
+	return result, nil
+}
+
+func (c *component) CacheResults() ([]*common.Result, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	return c.cacheBuffer, nil
+}
+
+func (c *component) LastResult() (*common.Result, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	result := c.cacheBuffer[c.currIndex]
+	if c.currIndex == 0 {
+		result = c.cacheBuffer[c.cacheSize-1]
+	}
+	return result, nil
+}
+
+func (c *component) CacheInfos() ([]common.Info, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	return c.cacheInfo, nil
+}
+
+func (c *component) LastInfo() (common.Info, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	var info common.Info
+	if c.currIndex == 0 {
+		info = c.cacheInfo[c.cacheSize-1]
+	} else {
+		info = c.cacheInfo[c.currIndex-1]
+	}
+	return info, nil
+}
+
+func (c *component) Start() <-chan *common.Result {
+	return c.service.Start()
+}
+
+func (c *component) Stop() error {
+	return c.service.Stop()
+}
+
+func (c *component) Update(cfg common.ComponentUserConfig) error {
+	c.cfgMutex.Lock()
+	configPointer, ok := cfg.(*config.AmdUserConfig)
+	if !ok {
+		c.cfgMutex.Unlock()
+		return fmt.Errorf("update wrong config type for amd")
+	}
+	c.cfg = configPointer
+	c.cfgMutex.Unlock()
+	return c.service.Update(cfg)
+}
+
+// UpdateSpec reloads the amd spec and rebuilds the checkers from it.
+func (c *component) UpdateSpec(specFile string) error {
+	spec, err := config.LoadSpec(specFile)
+	if err != nil {
+		return fmt.Errorf("load amd spec %s: %w", specFile, err)
+	}
+	if spec == nil {
+		return fmt.Errorf("amd spec is nil after loading from %s", specFile)
+	}
+	c.cfgMutex.Lock()
+	cfg := c.cfg
+	c.cfgMutex.Unlock()
+	checkers, err := checker.NewCheckers(cfg, spec)
+	if err != nil {
+		return err
+	}
+	c.specMtx.Lock()
+	c.spec = spec
+	c.checkers = checkers
+	c.specMtx.Unlock()
+	logrus.WithField("component", "amd").Infof("reloaded spec from %s", specFile)
+	return nil
+}
+
+func (c *component) Status() bool {
+	return c.service.Status()
+}
+
+func (c *component) GetTimeout() time.Duration {
+	return c.cfg.GetQueryInterval().Duration
+}
+
+func (c *component) PrintInfo(info common.Info, result *common.Result, summaryPrint bool) bool {
+	checkAllPassed := true
+	if result.Status == consts.StatusAbnormal && consts.LevelPriority[result.Level] > consts.LevelPriority[consts.LevelInfo] {
+		checkAllPassed = false
+	}
+
+	utils.PrintTitle("AMD GPU", "-")
+
+	amdInfo, ok := info.(*collector.AmdInfo)
+	if !ok || amdInfo == nil {
+		printer.Println("No AMD GPU info available")
+		return checkAllPassed
+	}
+
+	printer.Printf("Driver Version: %s\tGPU Count: %d\n\n", amdInfo.DriverVersion, amdInfo.DeviceCount)
+	if len(amdInfo.Devices) > 0 {
+		printer.Printf("%-6s %-14s %-24s %-10s %-14s %-12s %-10s %-10s\n",
+			"Index", "PCIBusID", "Name", "Edge(C)", "Junction(C)", "Memory(C)", "ECC(UE)", "ECC(CE)")
+		for _, device := range amdInfo.Devices {
+			printer.Printf("%-6d %-14s %-24s %-10.1f %-14.1f %-12.1f %-10d %-10d\n",
+				device.Index, device.PCIBusID, device.Name, device.Temperature.Edge, device.Temperature.Junction,
+				device.Temperature.Memory, device.Ecc.Uncorrectable, device.Ecc.Correctable)
+		}
+	}
+
+	hasErrors := false
+	if result != nil {
+		for _, res := range result.Checkers {
+			if res.Status != consts.StatusNormal && res.Level != consts.LevelInfo {
+				if !hasErrors {
+					printer.Printf("\nErrors Events:\n")
+					hasErrors = true
+				}
+				printer.Printf("\tEvent: %s%s%s -> %s\n", consts.LevelColor(res.Level), res.ErrorName, consts.Reset, res.Detail)
+			}
+		}
+	}
+	if !hasErrors {
+		printer.Printf("\nErrors Events:\n\tNo AMD GPU Events Detected\n")
+	}
+
+	printer.Println()
+	return checkAllPassed
+}
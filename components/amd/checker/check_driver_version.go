@@ -0,0 +1,64 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/scitix/sichek/components/amd/collector"
+	"github.com/scitix/sichek/components/amd/config"
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+)
+
+type DriverVersionChecker struct {
+	name string
+	spec *config.AmdSpec
+}
+
+func NewDriverVersionChecker(spec *config.AmdSpec) (common.Checker, error) {
+	return &DriverVersionChecker{
+		name: config.DriverVersionCheckerName,
+		spec: spec,
+	}, nil
+}
+
+func (c *DriverVersionChecker) Name() string {
+	return c.name
+}
+
+func (c *DriverVersionChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	amdInfo, ok := data.(*collector.AmdInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid data type, expected AmdInfo")
+	}
+
+	result := config.AmdCheckItems[c.name]
+	result.Spec = c.spec.DriverVersion
+	result.Curr = amdInfo.DriverVersion
+	if c.spec.DriverVersion == "" {
+		result.Suggestion = ""
+		return &result, nil
+	}
+	if amdInfo.DriverVersion == "" || !common.CompareVersion(c.spec.DriverVersion, amdInfo.DriverVersion) {
+		result.Status = consts.StatusAbnormal
+		result.Detail = fmt.Sprintf("amdgpu driver version is %q, expected version is %s", amdInfo.DriverVersion, c.spec.DriverVersion)
+	} else {
+		result.Suggestion = ""
+	}
+	return &result, nil
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/scitix/sichek/components/amd/collector"
+	"github.com/scitix/sichek/components/amd/config"
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+)
+
+// EccUncorrectableChecker reports GPUs whose uncorrectable RAS error count
+// exceeds the spec threshold (0 by default).
+type EccUncorrectableChecker struct {
+	name string
+	spec *config.AmdSpec
+}
+
+func NewEccUncorrectableChecker(spec *config.AmdSpec) (common.Checker, error) {
+	return &EccUncorrectableChecker{
+		name: config.EccUncorrectableCheckerName,
+		spec: spec,
+	}, nil
+}
+
+func (c *EccUncorrectableChecker) Name() string {
+	return c.name
+}
+
+func (c *EccUncorrectableChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	amdInfo, ok := data.(*collector.AmdInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid data type, expected AmdInfo")
+	}
+	return checkEcc(c.name, amdInfo, c.spec.EccThreshold.Uncorrectable, func(ecc collector.EccInfo) uint64 {
+		return ecc.Uncorrectable
+	}), nil
+}
+
+// EccCorrectableChecker reports GPUs whose correctable RAS error count
+// exceeds the spec threshold, an early sign of failing HBM.
+type EccCorrectableChecker struct {
+	name string
+	spec *config.AmdSpec
+}
+
+func NewEccCorrectableChecker(spec *config.AmdSpec) (common.Checker, error) {
+	return &EccCorrectableChecker{
+		name: config.EccCorrectableCheckerName,
+		spec: spec,
+	}, nil
+}
+
+func (c *EccCorrectableChecker) Name() string {
+	return c.name
+}
+
+func (c *EccCorrectableChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	amdInfo, ok := data.(*collector.AmdInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid data type, expected AmdInfo")
+	}
+	return checkEcc(c.name, amdInfo, c.spec.EccThreshold.Correctable, func(ecc collector.EccInfo) uint64 {
+		return ecc.Correctable
+	}), nil
+}
+
+func checkEcc(checkerName string, amdInfo *collector.AmdInfo, threshold uint64, count func(collector.EccInfo) uint64) *common.CheckerResult {
+	result := config.AmdCheckItems[checkerName]
+
+	var (
+		abnormalDevices []string
+		detail          string
+	)
+	for _, device := range amdInfo.Devices {
+		if errCount := count(device.Ecc); errCount > threshold {
+			abnormalDevices = append(abnormalDevices, device.PCIBusID)
+			detail += fmt.Sprintf("GPU %d (%s) has %d %s errors, threshold is %d\n", device.Index, device.PCIBusID, errCount, checkerName, threshold)
+		}
+	}
+	result.Spec = fmt.Sprintf("<=%d", threshold)
+	if len(abnormalDevices) > 0 {
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(abnormalDevices, ",")
+		result.Curr = fmt.Sprintf("%d GPUs abnormal", len(abnormalDevices))
+		result.Detail = detail
+	} else {
+		result.Curr = "OK"
+		result.Suggestion = ""
+	}
+	return &result
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/scitix/sichek/components/amd/collector"
+	"github.com/scitix/sichek/components/amd/config"
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+)
+
+// TemperatureChecker checks the edge, junction and memory temperature of each
+// GPU against the spec. A threshold of 0 or a sensor that is not exposed is skipped.
+type TemperatureChecker struct {
+	name string
+	spec *config.AmdSpec
+}
+
+func NewTemperatureChecker(spec *config.AmdSpec) (common.Checker, error) {
+	return &TemperatureChecker{
+		name: config.TemperatureCheckerName,
+		spec: spec,
+	}, nil
+}
+
+func (c *TemperatureChecker) Name() string {
+	return c.name
+}
+
+func (c *TemperatureChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	amdInfo, ok := data.(*collector.AmdInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid data type, expected AmdInfo")
+	}
+
+	result := config.AmdCheckItems[c.name]
+	threshold := c.spec.TemperatureThreshold
+
+	var (
+		abnormalDevices []string
+		detail          string
+	)
+	for _, device := range amdInfo.Devices {
+		sensors := []struct {
+			name      string
+			curr      float64
+			threshold int
+		}{
+			{"edge", device.Temperature.Edge, threshold.Edge},
+			{"junction", device.Temperature.Junction, threshold.Junction},
+			{"memory", device.Temperature.Memory, threshold.Memory},
+		}
+		overheat := false
+		for _, sensor := range sensors {
+			if sensor.threshold == 0 || sensor.curr == 0 || sensor.curr <= float64(sensor.threshold) {
+				continue
+			}
+			overheat = true
+			detail += fmt.Sprintf("GPU %d (%s) %s temperature is %.1f C, threshold is %d C\n", device.Index, device.PCIBusID, sensor.name, sensor.curr, sensor.threshold)
+		}
+		if overheat {
+			abnormalDevices = append(abnormalDevices, device.PCIBusID)
+		}
+	}
+	result.Spec = fmt.Sprintf("edge<=%d,junction<=%d,memory<=%d", threshold.Edge, threshold.Junction, threshold.Memory)
+	if len(abnormalDevices) > 0 {
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(abnormalDevices, ",")
+		result.Curr = fmt.Sprintf("%d GPUs overheat", len(abnormalDevices))
+		result.Detail = detail
+	} else {
+		result.Curr = "OK"
+		result.Suggestion = ""
+	}
+	return &result, nil
+}
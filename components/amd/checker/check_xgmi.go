@@ -0,0 +1,81 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/scitix/sichek/components/amd/collector"
+	"github.com/scitix/sichek/components/amd/config"
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+)
+
+// XgmiChecker verifies that each GPU is connected to the expected number of
+// peers over XGMI, e.g. 7 on a fully connected MI300X baseboard.
+type XgmiChecker struct {
+	name string
+	spec *config.AmdSpec
+}
+
+func NewXgmiChecker(spec *config.AmdSpec) (common.Checker, error) {
+	return &XgmiChecker{
+		name: config.XgmiCheckerName,
+		spec: spec,
+	}, nil
+}
+
+func (c *XgmiChecker) Name() string {
+	return c.name
+}
+
+func (c *XgmiChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	amdInfo, ok := data.(*collector.AmdInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid data type, expected AmdInfo")
+	}
+
+	result := config.AmdCheckItems[c.name]
+	result.Spec = fmt.Sprintf("%d", c.spec.XgmiLinks)
+	if c.spec.XgmiLinks == 0 {
+		result.Curr = "skipped"
+		result.Suggestion = ""
+		return &result, nil
+	}
+
+	var (
+		abnormalDevices []string
+		detail          string
+	)
+	for _, device := range amdInfo.Devices {
+		if device.XgmiLinks < c.spec.XgmiLinks {
+			abnormalDevices = append(abnormalDevices, device.PCIBusID)
+			detail += fmt.Sprintf("GPU %d (%s) has %d XGMI links, expected %d\n", device.Index, device.PCIBusID, device.XgmiLinks, c.spec.XgmiLinks)
+		}
+	}
+	if len(abnormalDevices) > 0 {
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(abnormalDevices, ",")
+		result.Curr = fmt.Sprintf("%d GPUs with XGMI links down", len(abnormalDevices))
+		result.Detail = detail
+	} else {
+		result.Curr = "OK"
+		result.Suggestion = ""
+	}
+	return &result, nil
+}
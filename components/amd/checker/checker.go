@@ -0,0 +1,52 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"github.com/scitix/sichek/components/amd/config"
+	"github.com/scitix/sichek/components/common"
+)
+
+// NewCheckers creates all AMD GPU checkers, filtering out any in the ignored list.
+func NewCheckers(cfg *config.AmdUserConfig, spec *config.AmdSpec) ([]common.Checker, error) {
+	checkerConstructors := map[string]func(*config.AmdSpec) (common.Checker, error){
+		config.EccUncorrectableCheckerName: NewEccUncorrectableChecker,
+		config.EccCorrectableCheckerName:   NewEccCorrectableChecker,
+		config.TemperatureCheckerName:      NewTemperatureChecker,
+		config.XgmiCheckerName:             NewXgmiChecker,
+		config.DriverVersionCheckerName:    NewDriverVersionChecker,
+	}
+
+	ignoredSet := make(map[string]struct{})
+	if cfg != nil && cfg.Amd != nil {
+		for _, v := range cfg.Amd.IgnoredCheckers {
+			ignoredSet[v] = struct{}{}
+		}
+	}
+
+	checkers := make([]common.Checker, 0, len(checkerConstructors))
+	for checkerName, constructor := range checkerConstructors {
+		if _, found := ignoredSet[checkerName]; found {
+			continue
+		}
+		checker, err := constructor(spec)
+		if err != nil {
+			return nil, err
+		}
+		checkers = append(checkers, checker)
+	}
+	return checkers, nil
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/scitix/sichek/components/amd/collector"
+	"github.com/scitix/sichek/components/amd/config"
+	"github.com/scitix/sichek/consts"
+)
+
+func testSpec() *config.AmdSpec {
+	return &config.AmdSpec{
+		Name:          "MI300X",
+		GpuNums:       2,
+		DriverVersion: ">=6.7.0",
+		XgmiLinks:     1,
+		EccThreshold:  config.EccThreshold{Uncorrectable: 0, Correctable: 100},
+		TemperatureThreshold: config.TemperatureThreshold{
+			Edge:     90,
+			Junction: 100,
+			Memory:   95,
+		},
+	}
+}
+
+func testInfo() *collector.AmdInfo {
+	return &collector.AmdInfo{
+		DriverVersion: "6.8.5",
+		DeviceCount:   2,
+		Devices: []*collector.DeviceInfo{
+			{Index: 0, PCIBusID: "0000:05:00.0", Temperature: collector.Temperature{Edge: 40, Junction: 50}, XgmiLinks: 1},
+			{Index: 1, PCIBusID: "0000:15:00.0", Temperature: collector.Temperature{Edge: 41, Junction: 51}, XgmiLinks: 1},
+		},
+	}
+}
+
+func TestCheckersHealthy(t *testing.T) {
+	checkers, err := NewCheckers(&config.AmdUserConfig{Amd: &config.AmdConfig{}}, testSpec())
+	if err != nil {
+		t.Fatalf("NewCheckers: %v", err)
+	}
+	if len(checkers) != len(config.AmdCheckItems) {
+		t.Fatalf("expected %d checkers, got %d", len(config.AmdCheckItems), len(checkers))
+	}
+	for _, checker := range checkers {
+		result, err := checker.Check(context.Background(), testInfo())
+		if err != nil {
+			t.Fatalf("%s: %v", checker.Name(), err)
+		}
+		if result.Status != consts.StatusNormal {
+			t.Errorf("%s: expected normal, got %+v", checker.Name(), result)
+		}
+	}
+}
+
+func TestCheckersAbnormal(t *testing.T) {
+	info := testInfo()
+	info.DriverVersion = "6.3.6"
+	info.Devices[1].Ecc = collector.EccInfo{Uncorrectable: 2, Correctable: 500}
+	info.Devices[1].Temperature.Junction = 105
+	info.Devices[1].XgmiLinks = 0
+
+	checkers, err := NewCheckers(nil, testSpec())
+	if err != nil {
+		t.Fatalf("NewCheckers: %v", err)
+	}
+	for _, checker := range checkers {
+		result, err := checker.Check(context.Background(), info)
+		if err != nil {
+			t.Fatalf("%s: %v", checker.Name(), err)
+		}
+		if result.Status != consts.StatusAbnormal {
+			t.Errorf("%s: expected abnormal, got %+v", checker.Name(), result)
+		}
+		if checker.Name() != config.DriverVersionCheckerName && result.Device != "0000:15:00.0" {
+			t.Errorf("%s: unexpected device %q", checker.Name(), result.Device)
+		}
+	}
+}
+
+func TestNewCheckersIgnored(t *testing.T) {
+	cfg := &config.AmdUserConfig{Amd: &config.AmdConfig{IgnoredCheckers: []string{config.XgmiCheckerName}}}
+	checkers, err := NewCheckers(cfg, testSpec())
+	if err != nil {
+		t.Fatalf("NewCheckers: %v", err)
+	}
+	for _, checker := range checkers {
+		if checker.Name() == config.XgmiCheckerName {
+			t.Errorf("ignored checker %s is still active", config.XgmiCheckerName)
+		}
+	}
+}
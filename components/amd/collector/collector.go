@@ -0,0 +1,311 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/scitix/sichek/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	AmdVendorID          = "0x1002"
+	defaultDrmPath       = "/sys/class/drm"
+	defaultDriverVerPath = "/sys/module/amdgpu/version"
+)
+
+var topoLinkTypeRegexp = regexp.MustCompile(`Link type between DRM devices (\d+) and (\d+)`)
+
+type AmdInfo struct {
+	DriverVersion string        `json:"driver_version"`
+	DeviceCount   int           `json:"device_count"`
+	Devices       []*DeviceInfo `json:"devices"`
+	Time          time.Time     `json:"time"`
+}
+
+type DeviceInfo struct {
+	Index       int         `json:"index"`
+	Card        string      `json:"card"`
+	PCIBusID    string      `json:"pci_bus_id"`
+	DeviceID    string      `json:"device_id"`
+	Name        string      `json:"name"`
+	Temperature Temperature `json:"temperature"`
+	Ecc         EccInfo     `json:"ecc"`
+	XgmiLinks   int         `json:"xgmi_links"`
+}
+
+// Temperature in degrees Celsius, 0 means the sensor is not exposed.
+type Temperature struct {
+	Edge     float64 `json:"edge"`
+	Junction float64 `json:"junction"`
+	Memory   float64 `json:"memory"`
+}
+
+// EccInfo sums the RAS error counters of all IP blocks (umc, gfx, sdma, ...).
+type EccInfo struct {
+	Uncorrectable uint64 `json:"uncorrectable"`
+	Correctable   uint64 `json:"correctable"`
+}
+
+func (info *AmdInfo) JSON() (string, error) {
+	data, err := json.Marshal(info)
+	return string(data), err
+}
+
+type AmdCollector struct {
+	name          string
+	drmPath       string
+	driverVerPath string
+	// runRocmSmi allows tests to inject the rocm-smi output.
+	runRocmSmi func(ctx context.Context, args ...string) ([]byte, error)
+}
+
+func NewAmdCollector() (*AmdCollector, error) {
+	return &AmdCollector{
+		name:          "AmdCollector",
+		drmPath:       hostfs.Path(defaultDrmPath),
+		driverVerPath: hostfs.Path(defaultDriverVerPath),
+		runRocmSmi: func(ctx context.Context, args ...string) ([]byte, error) {
+			return utils.ExecCommand(ctx, "rocm-smi", args...)
+		},
+	}, nil
+}
+
+func (c *AmdCollector) Name() string {
+	return c.name
+}
+
+func (c *AmdCollector) Collect(ctx context.Context) (common.Info, error) {
+	cards, err := listAmdCards(c.drmPath)
+	if err != nil {
+		return nil, err
+	}
+	info := &AmdInfo{
+		DriverVersion: c.getDriverVersion(ctx),
+		DeviceCount:   len(cards),
+		Devices:       make([]*DeviceInfo, 0, len(cards)),
+		Time:          time.Now(),
+	}
+	for idx, card := range cards {
+		cardPath := filepath.Join(c.drmPath, card, "device")
+		device := &DeviceInfo{
+			Index:       idx,
+			Card:        card,
+			DeviceID:    readDeviceID(cardPath),
+			Name:        readSysfs(filepath.Join(cardPath, "product_name")),
+			Temperature: readTemperature(cardPath),
+			Ecc:         readEcc(cardPath),
+		}
+		if link, err := os.Readlink(cardPath); err == nil {
+			device.PCIBusID = filepath.Base(link)
+		}
+		info.Devices = append(info.Devices, device)
+	}
+
+	xgmiLinks, err := c.getXgmiLinks(ctx)
+	if err != nil {
+		logrus.WithField("component", "amd").Warnf("get xgmi links failed: %v", err)
+	}
+	for _, device := range info.Devices {
+		device.XgmiLinks = xgmiLinks[device.Index]
+	}
+	return info, nil
+}
+
+func (c *AmdCollector) getDriverVersion(ctx context.Context) string {
+	if version := readSysfs(c.driverVerPath); version != "" {
+		return version
+	}
+	// inbox amdgpu does not expose /sys/module/amdgpu/version
+	output, err := c.runRocmSmi(ctx, "--showdriverversion", "--json")
+	if err != nil {
+		logrus.WithField("component", "amd").Warnf("get driver version failed: %v", err)
+		return ""
+	}
+	var data map[string]map[string]string
+	if err := json.Unmarshal(output, &data); err != nil {
+		logrus.WithField("component", "amd").Warnf("parse rocm-smi driver version failed: %v", err)
+		return ""
+	}
+	return data["system"]["Driver version"]
+}
+
+// getXgmiLinks returns the number of XGMI peers of each GPU index from
+// `rocm-smi --showtopotype --json`.
+func (c *AmdCollector) getXgmiLinks(ctx context.Context) (map[int]int, error) {
+	links := make(map[int]int)
+	output, err := c.runRocmSmi(ctx, "--showtopotype", "--json")
+	if err != nil {
+		return links, err
+	}
+	return parseXgmiLinks(output)
+}
+
+func parseXgmiLinks(output []byte) (map[int]int, error) {
+	links := make(map[int]int)
+	var data map[string]map[string]string
+	if err := json.Unmarshal(output, &data); err != nil {
+		return links, fmt.Errorf("parse rocm-smi topology failed: %w", err)
+	}
+	seen := make(map[[2]int]bool)
+	for _, fields := range data {
+		for key, linkType := range fields {
+			matches := topoLinkTypeRegexp.FindStringSubmatch(key)
+			if matches == nil || !strings.EqualFold(strings.TrimSpace(linkType), "XGMI") {
+				continue
+			}
+			src, _ := strconv.Atoi(matches[1])
+			dst, _ := strconv.Atoi(matches[2])
+			if src == dst {
+				continue
+			}
+			pair := [2]int{min(src, dst), max(src, dst)}
+			if seen[pair] {
+				continue
+			}
+			seen[pair] = true
+			links[src]++
+			links[dst]++
+		}
+	}
+	return links, nil
+}
+
+// GetDeviceID returns the device ID of the first AMD GPU in the form
+// "0x<device><vendor>", e.g. "0x74a11002" for MI300X.
+func GetDeviceID() (string, error) {
+	drmPath := hostfs.Path(defaultDrmPath)
+	cards, err := listAmdCards(drmPath)
+	if err != nil {
+		return "", err
+	}
+	for _, card := range cards {
+		if deviceID := readDeviceID(filepath.Join(drmPath, card, "device")); deviceID != "" {
+			return deviceID, nil
+		}
+	}
+	return "", fmt.Errorf("no valid AMD GPU device ID found")
+}
+
+// listAmdCards returns the DRM cards (card0, card1, ...) driven by an AMD GPU,
+// ordered by card number, which is also the device index used by rocm-smi.
+func listAmdCards(drmPath string) ([]string, error) {
+	entries, err := os.ReadDir(drmPath)
+	if err != nil {
+		return nil, fmt.Errorf("read %s failed: %w", drmPath, err)
+	}
+	var cards []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, "card") || strings.Contains(name, "-") {
+			continue
+		}
+		if _, err := strconv.Atoi(strings.TrimPrefix(name, "card")); err != nil {
+			continue
+		}
+		if readSysfs(filepath.Join(drmPath, name, "device", "vendor")) != AmdVendorID {
+			continue
+		}
+		cards = append(cards, name)
+	}
+	sort.Slice(cards, func(i, j int) bool {
+		ni, _ := strconv.Atoi(strings.TrimPrefix(cards[i], "card"))
+		nj, _ := strconv.Atoi(strings.TrimPrefix(cards[j], "card"))
+		return ni < nj
+	})
+	if len(cards) == 0 {
+		return nil, fmt.Errorf("no AMD GPU found in %s", drmPath)
+	}
+	return cards, nil
+}
+
+func readDeviceID(devicePath string) string {
+	device := readSysfs(filepath.Join(devicePath, "device"))
+	vendor := readSysfs(filepath.Join(devicePath, "vendor"))
+	if device == "" || vendor == "" {
+		return ""
+	}
+	return device + strings.TrimPrefix(vendor, "0x")
+}
+
+func readTemperature(devicePath string) Temperature {
+	var temp Temperature
+	labels, _ := filepath.Glob(filepath.Join(devicePath, "hwmon", "hwmon*", "temp*_label"))
+	for _, labelPath := range labels {
+		inputPath := strings.TrimSuffix(labelPath, "_label") + "_input"
+		milli, err := strconv.ParseFloat(readSysfs(inputPath), 64)
+		if err != nil {
+			continue
+		}
+		switch readSysfs(labelPath) {
+		case "edge":
+			temp.Edge = milli / 1000
+		case "junction":
+			temp.Junction = milli / 1000
+		case "mem":
+			temp.Memory = milli / 1000
+		}
+	}
+	return temp
+}
+
+// readEcc parses the ras/*_err_count files whose content looks like:
+//
+//	ue: 0
+//	ce: 12
+func readEcc(devicePath string) EccInfo {
+	var ecc EccInfo
+	files, _ := filepath.Glob(filepath.Join(devicePath, "ras", "*_err_count"))
+	for _, file := range files {
+		for _, line := range strings.Split(readSysfs(file), "\n") {
+			key, value, ok := strings.Cut(line, ":")
+			if !ok {
+				continue
+			}
+			count, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+			if err != nil {
+				continue
+			}
+			switch strings.TrimSpace(key) {
+			case "ue":
+				ecc.Uncorrectable += count
+			case "ce":
+				ecc.Correctable += count
+			}
+		}
+	}
+	return ecc
+}
+
+func readSysfs(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
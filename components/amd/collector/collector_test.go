@@ -0,0 +1,97 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const topoJSON = `{"card0": {"(Topology) Link type between DRM devices 0 and 1": "XGMI", "(Topology) Link type between DRM devices 0 and 2": "XGMI"},
+"card1": {"(Topology) Link type between DRM devices 1 and 0": "XGMI", "(Topology) Link type between DRM devices 1 and 2": "PCIE"},
+"card2": {"(Topology) Link type between DRM devices 2 and 0": "XGMI", "(Topology) Link type between DRM devices 2 and 1": "PCIE"}}`
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+}
+
+func TestParseXgmiLinks(t *testing.T) {
+	links, err := parseXgmiLinks([]byte(topoJSON))
+	if err != nil {
+		t.Fatalf("parseXgmiLinks: %v", err)
+	}
+	if links[0] != 2 || links[1] != 1 || links[2] != 1 {
+		t.Errorf("unexpected xgmi links: %v", links)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	root := t.TempDir()
+	card0 := filepath.Join(root, "card0", "device")
+	writeFile(t, filepath.Join(card0, "vendor"), "0x1002\n")
+	writeFile(t, filepath.Join(card0, "device"), "0x74a1\n")
+	writeFile(t, filepath.Join(card0, "product_name"), "AMD Instinct MI300X\n")
+	writeFile(t, filepath.Join(card0, "hwmon", "hwmon3", "temp1_label"), "edge\n")
+	writeFile(t, filepath.Join(card0, "hwmon", "hwmon3", "temp1_input"), "45000\n")
+	writeFile(t, filepath.Join(card0, "hwmon", "hwmon3", "temp2_label"), "junction\n")
+	writeFile(t, filepath.Join(card0, "hwmon", "hwmon3", "temp2_input"), "52000\n")
+	writeFile(t, filepath.Join(card0, "ras", "umc_err_count"), "ue: 1\nce: 20\n")
+	writeFile(t, filepath.Join(card0, "ras", "gfx_err_count"), "ue: 0\nce: 3\n")
+	// a non AMD card and a connector must be ignored
+	writeFile(t, filepath.Join(root, "card1", "device", "vendor"), "0x1a03\n")
+	writeFile(t, filepath.Join(root, "card0-DP-1", "status"), "disconnected\n")
+
+	c := &AmdCollector{
+		name:          "AmdCollector",
+		drmPath:       root,
+		driverVerPath: filepath.Join(root, "version"),
+		runRocmSmi: func(ctx context.Context, args ...string) ([]byte, error) {
+			if args[0] == "--showdriverversion" {
+				return []byte(`{"system": {"Driver version": "6.8.5"}}`), nil
+			}
+			return []byte(topoJSON), nil
+		},
+	}
+	info, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	amdInfo := info.(*AmdInfo)
+	if amdInfo.DeviceCount != 1 || amdInfo.DriverVersion != "6.8.5" {
+		t.Fatalf("unexpected info: %+v", amdInfo)
+	}
+	device := amdInfo.Devices[0]
+	if device.DeviceID != "0x74a11002" || device.Name != "AMD Instinct MI300X" {
+		t.Errorf("unexpected device: %+v", device)
+	}
+	if device.Temperature.Edge != 45 || device.Temperature.Junction != 52 {
+		t.Errorf("unexpected temperature: %+v", device.Temperature)
+	}
+	if device.Ecc.Uncorrectable != 1 || device.Ecc.Correctable != 23 {
+		t.Errorf("unexpected ecc: %+v", device.Ecc)
+	}
+	if device.XgmiLinks != 2 {
+		t.Errorf("unexpected xgmi links: %d", device.XgmiLinks)
+	}
+}
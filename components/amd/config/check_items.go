@@ -0,0 +1,78 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+)
+
+const (
+	EccUncorrectableCheckerName = "ecc-uncorrectable"
+	EccCorrectableCheckerName   = "ecc-high-correctable"
+	TemperatureCheckerName      = "temperature"
+	XgmiCheckerName             = "xgmi"
+	DriverVersionCheckerName    = "driver-version"
+)
+
+// AmdCheckItems is a map of check items for AMD GPU
+var AmdCheckItems = map[string]common.CheckerResult{
+	EccUncorrectableCheckerName: {
+		Name:        EccUncorrectableCheckerName,
+		Description: "Check if the GPU has uncorrectable ECC errors",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelCritical,
+		Detail:      "No uncorrectable ECC error",
+		ErrorName:   "GPUECCUncorrectable",
+		Suggestion:  "Drain the node and reset the GPU, replace the GPU if the errors persist",
+	},
+	EccCorrectableCheckerName: {
+		Name:        EccCorrectableCheckerName,
+		Description: "Check if the correctable ECC errors of the GPU exceed the threshold",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelWarning,
+		Detail:      "Correctable ECC errors are below the threshold",
+		ErrorName:   "GPUECCHighCorrectable",
+		Suggestion:  "Monitor the GPU memory health and plan the GPU replacement",
+	},
+	TemperatureCheckerName: {
+		Name:        TemperatureCheckerName,
+		Description: "Check if the GPU edge, junction and memory temperature are below the threshold",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelWarning,
+		Detail:      "GPU temperature is normal",
+		ErrorName:   "GPUOverheat",
+		Suggestion:  "Check the cooling and airflow of the node",
+	},
+	XgmiCheckerName: {
+		Name:        XgmiCheckerName,
+		Description: "Check if each GPU has the expected number of XGMI links to its peers",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelCritical,
+		Detail:      "All XGMI links are up",
+		ErrorName:   "XGMILinkDown",
+		Suggestion:  "Check the XGMI link status with `rocm-smi --showtopotype`, reboot the node or replace the baseboard if the links stay down",
+	},
+	DriverVersionCheckerName: {
+		Name:        DriverVersionCheckerName,
+		Description: "Check if the amdgpu driver version matches the spec",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelWarning,
+		Detail:      "amdgpu driver version matches the spec",
+		ErrorName:   "AmdDriverVersionMismatch",
+		Suggestion:  "Upgrade the amdgpu driver to the version in the spec",
+	},
+}
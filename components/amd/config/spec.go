@@ -0,0 +1,151 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/scitix/sichek/components/amd/collector"
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/httpclient"
+	"github.com/sirupsen/logrus"
+)
+
+type AmdSpec struct {
+	Name                 string               `json:"name" yaml:"name"`
+	GpuNums              int                  `json:"gpu_nums" yaml:"gpu_nums"`
+	GpuMemory            int                  `json:"gpu_memory" yaml:"gpu_memory"`
+	DriverVersion        string               `json:"driver_version" yaml:"driver_version"`
+	XgmiLinks            int                  `json:"xgmi_links" yaml:"xgmi_links"`
+	EccThreshold         EccThreshold         `json:"ecc_threshold" yaml:"ecc_threshold"`
+	TemperatureThreshold TemperatureThreshold `json:"temperature_threshold" yaml:"temperature_threshold"`
+}
+
+type AmdSpecs struct {
+	Specs map[string]*AmdSpec `json:"amd" yaml:"amd"`
+}
+
+type EccThreshold struct {
+	Uncorrectable uint64 `json:"uncorrectable" yaml:"uncorrectable"`
+	Correctable   uint64 `json:"correctable" yaml:"correctable"`
+}
+
+type TemperatureThreshold struct {
+	Edge     int `json:"edge" yaml:"edge"`
+	Junction int `json:"junction" yaml:"junction"`
+	Memory   int `json:"memory" yaml:"memory"`
+}
+
+// ─── EnsureSpec ──────────────────────────────────────────────────────────────
+
+// EnsureSpec ensures that `file` contains a spec entry for the local AMD GPU.
+//
+// It detects the GPU device ID via sysfs. If the entry is already present in
+// `file`, it returns immediately. Otherwise it downloads the per-device spec
+// from SICHEK_SPEC_URL and merges it into `file`.
+func EnsureSpec(file string) (string, error) {
+	const comp = "amd/spec"
+
+	deviceID, err := collector.GetDeviceID()
+	if err != nil {
+		return file, fmt.Errorf("EnsureSpec: cannot detect GPU device ID: %w", err)
+	}
+	logrus.WithField("component", comp).Infof("local GPU device ID: %s", deviceID)
+
+	var s AmdSpecs
+	if err := common.LoadSpec(file, &s); err == nil {
+		if s.Specs != nil {
+			if _, ok := s.Specs[deviceID]; ok {
+				logrus.WithField("component", comp).Infof("spec for GPU %s already in %s, skipping download", deviceID, file)
+				return file, nil
+			}
+		}
+	} else {
+		logrus.WithField("component", comp).Debugf("LoadSpec failed during EnsureSpec (may be new file): %v", err)
+	}
+
+	// Download {SICHEK_SPEC_URL}/amd/{deviceID}.yaml
+	ossBase := httpclient.GetSichekSpecURL()
+	if ossBase == "" {
+		return file, fmt.Errorf("EnsureSpec: GPU %s not in spec and SICHEK_SPEC_URL not set", deviceID)
+	}
+
+	tmpFile := filepath.Join(os.TempDir(), fmt.Sprintf("amd_%s.yaml", deviceID))
+	perDevURL := fmt.Sprintf("%s/%s/%s.yaml",
+		strings.TrimRight(ossBase, "/"), consts.ComponentNameAmd, deviceID)
+
+	logrus.WithField("component", comp).Infof("downloading per-device spec from %s", perDevURL)
+	if err := common.DownloadSpecFile(perDevURL, tmpFile, comp); err != nil {
+		return file, fmt.Errorf("EnsureSpec: download failed: %w", err)
+	}
+
+	var perDevice AmdSpecs
+	if err := common.LoadSpec(tmpFile, &perDevice); err != nil {
+		return file, fmt.Errorf("EnsureSpec: parse per-device spec: %w", err)
+	}
+
+	if err := common.MergeAndWriteSpec(
+		file,
+		"amd",
+		perDevice.Specs,
+		func(c *AmdSpecs) map[string]*AmdSpec { return c.Specs },
+		func(c *AmdSpecs, m map[string]*AmdSpec) { c.Specs = m },
+	); err != nil {
+		return file, fmt.Errorf("EnsureSpec: merge failed: %w", err)
+	}
+
+	logrus.WithField("component", comp).Infof("merged GPU %s spec into %s", deviceID, file)
+	return file, nil
+}
+
+// ─── LoadSpec ────────────────────────────────────────────────────────────────
+
+// LoadSpec reads the AMD multi-spec YAML at `file`, detects the local GPU,
+// and overwrites `file` with only that GPU's spec.
+func LoadSpec(file string) (*AmdSpec, error) {
+	if file == "" {
+		return nil, fmt.Errorf("amd spec file path is empty")
+	}
+
+	if _, err := EnsureSpec(file); err != nil {
+		logrus.WithField("component", "amd/spec").Warnf("EnsureSpec failed: %v", err)
+	}
+
+	deviceID, err := collector.GetDeviceID()
+	if err != nil {
+		return nil, fmt.Errorf("LoadSpec: cannot detect GPU device ID: %w", err)
+	}
+	return FilterSpec(file, deviceID)
+}
+
+// ─── FilterSpec ──────────────────────────────────────────────────────────────
+
+// FilterSpec selects the entry for `deviceID` from the multi-spec YAML at
+// `file`, overwrites `file` with that single entry and returns the spec.
+func FilterSpec(file, deviceID string) (*AmdSpec, error) {
+	logrus.WithField("component", "amd").Infof(
+		"filtering spec for GPU %s in %s", deviceID, file)
+	return common.FilterSpec(file, "amd", deviceID,
+		func(c *AmdSpecs, id string) (*AmdSpec, bool) {
+			spec, ok := c.Specs[id]
+			return spec, ok
+		},
+	)
+}
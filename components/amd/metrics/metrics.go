@@ -0,0 +1,50 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package metrics
+
+import (
+	"strconv"
+
+	"github.com/scitix/sichek/components/amd/collector"
+	common "github.com/scitix/sichek/metrics"
+)
+
+const (
+	MetricPrefix = "sichek_amd"
+	TagPrefix    = "json"
+)
+
+type AmdMetrics struct {
+	AmdDevCntGauge *common.GaugeVecMetricExporter
+	AmdDeviceGauge *common.GaugeVecMetricExporter
+}
+
+func NewAmdMetrics() *AmdMetrics {
+	return &AmdMetrics{
+		AmdDevCntGauge: common.NewGaugeVecMetricExporter(MetricPrefix, nil),
+		AmdDeviceGauge: common.NewGaugeVecMetricExporter(MetricPrefix, []string{"index", "pci_bus_id"}),
+	}
+}
+
+func (m *AmdMetrics) ExportMetrics(info *collector.AmdInfo) {
+	if info == nil {
+		return
+	}
+	m.AmdDevCntGauge.SetMetric("device_count", nil, float64(info.DeviceCount))
+	for _, device := range info.Devices {
+		m.AmdDeviceGauge.ExportStruct(device, []string{strconv.Itoa(device.Index), device.PCIBusID}, TagPrefix)
+	}
+}
@@ -0,0 +1,322 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package bmc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scitix/sichek/components/bmc/checker"
+	"github.com/scitix/sichek/components/bmc/collector"
+	"github.com/scitix/sichek/components/bmc/config"
+	bmcmetrics "github.com/scitix/sichek/components/bmc/metrics"
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/scitix/sichek/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+type component struct {
+	ctx           context.Context
+	cancel        context.CancelFunc
+	componentName string
+	cfg           *config.BmcUserConfig
+	cfgMutex      sync.Mutex
+	spec          *config.BmcSpec
+	collector     *collector.BMCCollector
+	checkers      []common.Checker
+	specMtx       sync.RWMutex
+	metrics       *bmcmetrics.BmcMetrics
+
+	cacheMtx    sync.RWMutex
+	cacheBuffer []*common.Result
+	cacheInfo   []common.Info
+	currIndex   int64
+	cacheSize   int64
+
+	service *common.CommonService
+}
+
+var (
+	bmcComponent     *component
+	bmcComponentOnce sync.Once
+)
+
+func NewComponent(cfgFile string, specFile string, ignoredCheckers []string) (common.Component, error) {
+	var err error
+	bmcComponentOnce.Do(func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic occurred when create component bmc: %v", r)
+			}
+		}()
+		bmcComponent, err = newComponent(cfgFile, specFile, ignoredCheckers)
+	})
+	return bmcComponent, err
+}
+
+func newComponent(cfgFile string, specFile string, ignoredCheckers []string) (comp *component, err error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		if err != nil {
+			cancel()
+		}
+	}()
+
+	cfg := &config.BmcUserConfig{}
+	err = common.LoadUserConfig(cfgFile, cfg)
+	if err != nil || cfg.Bmc == nil {
+		logrus.WithField("component", "bmc").Warnf("get user config failed or bmc config is nil, using default config")
+		cfg.Bmc = &config.BmcConfig{
+			QueryInterval: common.Duration{Duration: 60 * time.Second},
+			CacheSize:     5,
+		}
+	}
+	if len(ignoredCheckers) > 0 {
+		cfg.Bmc.IgnoredCheckers = ignoredCheckers
+	}
+
+	spec, specErr := config.LoadSpec(specFile)
+	if specErr != nil {
+		logrus.WithField("component", "bmc").Warnf("failed to load spec %s: %v", specFile, specErr)
+	}
+
+	collectorInst, err := collector.NewBMCCollector()
+	if err != nil {
+		logrus.WithField("component", "bmc").Errorf("create bmc collector failed: %v", err)
+		return nil, err
+	}
+
+	cacheSize := cfg.Bmc.CacheSize
+	if cacheSize == 0 {
+		cacheSize = 5
+	}
+
+	comp = &component{
+		ctx:           ctx,
+		cancel:        cancel,
+		componentName: consts.ComponentNameBMC,
+		cfg:           cfg,
+		spec:          spec,
+		collector:     collectorInst,
+		cacheBuffer:   make([]*common.Result, cacheSize),
+		cacheInfo:     make([]common.Info, cacheSize),
+		cacheSize:     cacheSize,
+	}
+
+	comp.checkers, err = common.CheckersOrSpecMissing(consts.ComponentNameBMC, spec, specErr, func(spec *config.BmcSpec) ([]common.Checker, error) {
+		return checker.NewCheckers(cfg, spec)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Bmc.EnableMetrics {
+		comp.metrics = bmcmetrics.NewBmcMetrics()
+	}
+	service := common.NewCommonService(ctx, cfg, comp.componentName, comp.GetTimeout(), comp.HealthCheck)
+	comp.service = service
+
+	return comp, nil
+}
+
+func (c *component) Name() string {
+	return c.componentName
+}
+
+func (c *component) HealthCheck(ctx context.Context) (*common.Result, error) {
+	timer := common.NewTimer(fmt.Sprintf("%s-HealthCheck-Cost", c.componentName))
+	c.specMtx.RLock()
+	checkers := c.checkers
+	c.specMtx.RUnlock()
+
+	var bmcInfo *collector.BMCInfo
+	if common.AllSkipped(checkers) {
+		// ipmitool would fail without the privileges, report the checkers as skipped instead
+		bmcInfo = &collector.BMCInfo{Time: time.Now()}
+	} else {
+		var err error
+		bmcInfo, err = common.Collect(ctx, c.componentName, c.collector.Collect)
+		if err != nil {
+			logrus.WithField("component", "bmc").Errorf("failed to collect bmc info: %v", err)
+			return nil, err
+		}
+		timer.Mark("bmc-collect")
+
+		if c.metrics != nil {
+			c.metrics.ExportMetrics(bmcInfo)
+		}
+	}
+
+	result := common.Check(ctx, c.componentName, bmcInfo, checkers)
+	timer.Mark("bmc-check")
+
+	c.cacheMtx.Lock()
+	c.cacheBuffer[c.currIndex] = result
+	c.cacheInfo[c.currIndex] = bmcInfo
+	c.currIndex = (c.currIndex + 1) % c.cacheSize
+	c.cacheMtx.Unlock()
+
+	if result.Status == consts.StatusAbnormal && consts.LevelPriority[result.Level] > consts.LevelPriority[consts.LevelInfo] {
+		logrus.WithField("component", "bmc").Errorf("Health Check Failed")
+	} else {
+		logrus.WithField("component", "bmc").Infof("Health Check PASSED")
+	}
+
+	return result, nil
+}
+
+func (c *component) CacheResults() ([]*common.Result, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	return c.cacheBuffer, nil
+}
+
+func (c *component) LastResult() (*common.Result, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	result := c.cacheBuffer[c.currIndex]
+	if c.currIndex == 0 {
+		result = c.cacheBuffer[c.cacheSize-1]
+	}
+	return result, nil
+}
+
+func (c *component) CacheInfos() ([]common.Info, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	return c.cacheInfo, nil
+}
+
+func (c *component) LastInfo() (common.Info, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	var info common.Info
+	if c.currIndex == 0 {
+		info = c.cacheInfo[c.cacheSize-1]
+	} else {
+		info = c.cacheInfo[c.currIndex-1]
+	}
+	return info, nil
+}
+
+func (c *component) Start() <-chan *common.Result {
+	return c.service.Start()
+}
+
+func (c *component) Stop() error {
+	return c.service.Stop()
+}
+
+func (c *component) Update(cfg common.ComponentUserConfig) error {
+	c.cfgMutex.Lock()
+	configPointer, ok := cfg.(*config.BmcUserConfig)
+	if !ok {
+		c.cfgMutex.Unlock()
+		return fmt.Errorf("update wrong config type for bmc")
+	}
+	c.cfg = configPointer
+	c.cfgMutex.Unlock()
+	return c.service.Update(cfg)
+}
+
+// UpdateSpec reloads the sensor limits and SEL events and rebuilds the checkers from them.
+func (c *component) UpdateSpec(specFile string) error {
+	spec, err := config.LoadSpec(specFile)
+	if err != nil {
+		return fmt.Errorf("load bmc spec %s: %w", specFile, err)
+	}
+	if spec == nil {
+		return fmt.Errorf("bmc spec is nil after loading from %s", specFile)
+	}
+	c.cfgMutex.Lock()
+	cfg := c.cfg
+	c.cfgMutex.Unlock()
+	checkers, err := checker.NewCheckers(cfg, spec)
+	if err != nil {
+		return err
+	}
+	c.specMtx.Lock()
+	c.spec = spec
+	c.checkers = checkers
+	c.specMtx.Unlock()
+	logrus.WithField("component", "bmc").Infof("reloaded spec from %s", specFile)
+	return nil
+}
+
+func (c *component) Status() bool {
+	return c.service.Status()
+}
+
+func (c *component) GetTimeout() time.Duration {
+	return c.cfg.GetQueryInterval().Duration
+}
+
+func (c *component) PrintInfo(info common.Info, result *common.Result, summaryPrint bool) bool {
+	checkAllPassed := true
+	if result.Status == consts.StatusAbnormal && consts.LevelPriority[result.Level] > consts.LevelPriority[consts.LevelInfo] {
+		checkAllPassed = false
+	}
+
+	utils.PrintTitle("BMC", "-")
+
+	bmcInfo, ok := info.(*collector.BMCInfo)
+	if !ok || bmcInfo == nil {
+		printer.Println("No BMC info available")
+		return checkAllPassed
+	}
+
+	printer.Printf("%-24s %-12s %-14s %-8s\n", "Sensor", "Value", "Unit", "Status")
+	for _, sensor := range append(append([]*collector.SensorReading{}, bmcInfo.Fans...), bmcInfo.Temperatures...) {
+		value := "na"
+		if sensor.HasValue {
+			value = fmt.Sprintf("%.1f", sensor.Value)
+		}
+		printer.Printf("%-24s %-12s %-14s %-8s\n", sensor.Name, value, sensor.Unit, sensor.Status)
+	}
+	printer.Println()
+	printer.Printf("%-24s %-8s %-8s %s\n", "Power Supply", "Present", "Failed", "States")
+	for _, psu := range bmcInfo.PSUs {
+		printer.Printf("%-24s %-8t %-8t %s\n", psu.Name, psu.Present, psu.Failed, strings.Join(psu.States, ", "))
+	}
+	for _, e := range bmcInfo.Errors {
+		printer.Printf("%s%s%s\n", consts.Yellow, e, consts.Reset)
+	}
+
+	hasErrors := false
+	if result != nil {
+		for _, res := range result.Checkers {
+			if res.Status != consts.StatusNormal && res.Level != consts.LevelInfo {
+				if !hasErrors {
+					printer.Printf("\nErrors Events:\n")
+					hasErrors = true
+				}
+				printer.Printf("\tEvent: %s%s%s -> %s\n", consts.LevelColor(res.Level), res.ErrorName, consts.Reset, res.Detail)
+			}
+		}
+	}
+	if !hasErrors {
+		printer.Printf("\nErrors Events:\n\tNo BMC Events Detected\n")
+	}
+
+	printer.Println()
+	return checkAllPassed
+}
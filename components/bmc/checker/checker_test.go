@@ -0,0 +1,146 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/bmc/collector"
+	"github.com/scitix/sichek/components/bmc/config"
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSpec() *config.BmcSpec {
+	return &config.BmcSpec{
+		Fan:         config.FanSpec{MinRPM: 1000},
+		PSU:         config.PSUSpec{MinPresent: 2},
+		Temperature: config.TemperatureSpec{MaxCelsius: 85},
+		SEL: config.SELSpec{
+			Lookback:       common.Duration{Duration: 24 * time.Hour},
+			CriticalEvents: []string{"Uncorrectable ECC", "Power Supply AC lost"},
+		},
+	}
+}
+
+func checkersByName(t *testing.T, ignored ...string) map[string]common.Checker {
+	t.Helper()
+	checkers, err := NewCheckers(&config.BmcUserConfig{Bmc: &config.BmcConfig{IgnoredCheckers: ignored}}, newSpec())
+	require.NoError(t, err)
+	byName := make(map[string]common.Checker)
+	for _, c := range checkers {
+		byName[c.Name()] = c
+	}
+	return byName
+}
+
+func TestNewCheckersIgnored(t *testing.T) {
+	byName := checkersByName(t, config.SELCheckerName)
+	assert.Len(t, byName, 3)
+	assert.NotContains(t, byName, config.SELCheckerName)
+}
+
+func TestSensorCheckers(t *testing.T) {
+	byName := checkersByName(t)
+	ctx := context.Background()
+	info := &collector.BMCInfo{
+		Time: time.Now(),
+		Fans: []*collector.SensorReading{
+			{Name: "FAN1", Value: 5400, HasValue: true, Status: collector.SensorStatusOK},
+			{Name: "FAN2", Value: 600, HasValue: true, Status: collector.SensorStatusOK},
+			{Name: "FAN3", Status: "ns"},
+		},
+		Temperatures: []*collector.SensorReading{
+			{Name: "Inlet Temp", Value: 24, HasValue: true, Status: collector.SensorStatusOK},
+			{Name: "CPU1 Temp", Value: 80, HasValue: true, Status: collector.SensorStatusCritical},
+		},
+	}
+
+	res, err := byName[config.FanCheckerName].Check(ctx, info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusAbnormal, res.Status)
+	assert.Equal(t, "FAN2", res.Device)
+	assert.Contains(t, res.Detail, "600 RPM is below 1000 RPM")
+
+	res, err = byName[config.TemperatureCheckerName].Check(ctx, info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusAbnormal, res.Status)
+	assert.Equal(t, "CPU1 Temp", res.Device)
+	assert.Contains(t, res.Detail, "sensor status is cr")
+
+	info.Fans = info.Fans[:1]
+	res, err = byName[config.FanCheckerName].Check(ctx, info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusNormal, res.Status, res.Detail)
+}
+
+func TestPSUChecker(t *testing.T) {
+	checker := checkersByName(t)[config.PSUCheckerName]
+	ctx := context.Background()
+
+	info := &collector.BMCInfo{PSUs: []*collector.PSUStatus{
+		{Name: "PSU1 Status", Present: true},
+		{Name: "PSU2 Status", Present: true},
+	}}
+	res, err := checker.Check(ctx, info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusNormal, res.Status, res.Detail)
+
+	info.PSUs[1].Failed = true
+	info.PSUs[1].States = []string{"Presence detected", "Power Supply AC lost"}
+	res, err = checker.Check(ctx, info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusAbnormal, res.Status)
+	assert.Equal(t, "PSU2 Status", res.Device)
+
+	info.PSUs = info.PSUs[:1]
+	res, err = checker.Check(ctx, info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusAbnormal, res.Status)
+	assert.Contains(t, res.Detail, "1 power supplies present, expected 2")
+}
+
+func TestSELChecker(t *testing.T) {
+	checker := checkersByName(t)[config.SELCheckerName]
+	ctx := context.Background()
+	now := time.Now()
+
+	info := &collector.BMCInfo{
+		Time: now,
+		SEL: []*collector.SELEvent{
+			{ID: "1", Sensor: "Memory #0x87", Event: "Uncorrectable ECC", Direction: "Asserted"},
+			{ID: "2", Time: now.Add(-48 * time.Hour), Sensor: "Memory #0x87", Event: "Uncorrectable ECC", Direction: "Asserted"},
+			{ID: "3", Time: now.Add(-time.Hour), Sensor: "Power Supply PSU2", Event: "Power Supply AC lost", Direction: "Deasserted"},
+			{ID: "4", Time: now.Add(-time.Hour), Sensor: "Watchdog2", Event: "Timer interrupt", Direction: "Asserted"},
+		},
+	}
+	res, err := checker.Check(ctx, info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusNormal, res.Status, res.Detail)
+
+	info.SEL = append(info.SEL, &collector.SELEvent{
+		ID: "5", Time: now.Add(-time.Hour), Sensor: "Memory #0x87", Event: "Uncorrectable ECC", Direction: "Asserted",
+	})
+	res, err = checker.Check(ctx, info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusAbnormal, res.Status)
+	assert.Equal(t, "1 critical events", res.Curr)
+	assert.Contains(t, res.Detail, "Uncorrectable ECC")
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/scitix/sichek/components/bmc/collector"
+	"github.com/scitix/sichek/components/bmc/config"
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+// SELChecker flags the asserted SEL events of the lookback window that match
+// one of the critical events of the spec. Old events are ignored so that a
+// repaired node recovers without clearing the SEL.
+type SELChecker struct {
+	name           string
+	lookback       time.Duration
+	criticalEvents []string
+}
+
+func NewSELChecker(spec *config.BmcSpec) (common.Checker, error) {
+	criticalEvents := make([]string, 0, len(spec.SEL.CriticalEvents))
+	for _, event := range spec.SEL.CriticalEvents {
+		criticalEvents = append(criticalEvents, strings.ToLower(event))
+	}
+	return &SELChecker{
+		name:           config.SELCheckerName,
+		lookback:       spec.SEL.Lookback.Duration,
+		criticalEvents: criticalEvents,
+	}, nil
+}
+
+func (c *SELChecker) Name() string {
+	return c.name
+}
+
+func (c *SELChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	info, ok := data.(*collector.BMCInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid BMCInfo type")
+	}
+
+	result := config.BmcCheckItems[c.name]
+	result.Status = consts.StatusNormal
+
+	var detail string
+	count := 0
+	for _, event := range info.SEL {
+		if !c.isCritical(event, info.Time) {
+			continue
+		}
+		count++
+		detail += fmt.Sprintf("%s %s | %s | %s\n", event.ID, event.Time.Format(time.RFC3339), event.Sensor, event.Event)
+	}
+
+	if count > 0 {
+		result.Status = consts.StatusAbnormal
+		result.Curr = fmt.Sprintf("%d critical events", count)
+		result.Detail = detail
+		logrus.WithField("component", "bmc").Errorf("%s failed: %s", c.name, detail)
+	} else {
+		result.Curr = "OK"
+	}
+	return &result, nil
+}
+
+func (c *SELChecker) isCritical(event *collector.SELEvent, now time.Time) bool {
+	if strings.EqualFold(event.Direction, "Deasserted") {
+		return false
+	}
+	// events logged before the BMC clock was set cannot be placed in the window
+	if event.Time.IsZero() {
+		return false
+	}
+	if c.lookback > 0 && now.Sub(event.Time) > c.lookback {
+		return false
+	}
+	text := strings.ToLower(event.Sensor + " " + event.Event)
+	for _, critical := range c.criticalEvents {
+		if strings.Contains(text, critical) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/scitix/sichek/components/bmc/collector"
+	"github.com/scitix/sichek/components/bmc/config"
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+// SensorChecker flags the fans or temperatures whose sensor is in a critical
+// state according to the BMC thresholds, or beyond the limit of the spec.
+type SensorChecker struct {
+	name string
+	// exceeds reports why a reading with a value breaks the spec, "" if it does not.
+	exceeds func(*collector.SensorReading) string
+	sensors func(*collector.BMCInfo) []*collector.SensorReading
+}
+
+func NewFanChecker(spec *config.BmcSpec) (common.Checker, error) {
+	minRPM := spec.Fan.MinRPM
+	return &SensorChecker{
+		name: config.FanCheckerName,
+		exceeds: func(s *collector.SensorReading) string {
+			if minRPM > 0 && s.Value < minRPM {
+				return fmt.Sprintf("%.0f RPM is below %.0f RPM", s.Value, minRPM)
+			}
+			return ""
+		},
+		sensors: func(info *collector.BMCInfo) []*collector.SensorReading { return info.Fans },
+	}, nil
+}
+
+func NewTemperatureChecker(spec *config.BmcSpec) (common.Checker, error) {
+	maxCelsius := spec.Temperature.MaxCelsius
+	return &SensorChecker{
+		name: config.TemperatureCheckerName,
+		exceeds: func(s *collector.SensorReading) string {
+			if maxCelsius > 0 && s.Value > maxCelsius {
+				return fmt.Sprintf("%.1f C is above %.1f C", s.Value, maxCelsius)
+			}
+			return ""
+		},
+		sensors: func(info *collector.BMCInfo) []*collector.SensorReading { return info.Temperatures },
+	}, nil
+}
+
+func (c *SensorChecker) Name() string {
+	return c.name
+}
+
+func (c *SensorChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	info, ok := data.(*collector.BMCInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid BMCInfo type")
+	}
+
+	result := config.BmcCheckItems[c.name]
+	result.Status = consts.StatusNormal
+
+	var abnormal []string
+	var detail string
+	for _, sensor := range c.sensors(info) {
+		var reason string
+		switch sensor.Status {
+		case collector.SensorStatusCritical, collector.SensorStatusNonRecoverable:
+			reason = fmt.Sprintf("sensor status is %s", sensor.Status)
+		default:
+			// sensors without reading are absent fans or probes, e.g. an empty fan slot
+			if sensor.HasValue {
+				reason = c.exceeds(sensor)
+			}
+		}
+		if reason == "" {
+			continue
+		}
+		abnormal = append(abnormal, sensor.Name)
+		detail += fmt.Sprintf("%s: %s\n", sensor.Name, reason)
+	}
+
+	if len(abnormal) > 0 {
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(abnormal, ",")
+		result.Curr = fmt.Sprintf("%d sensors abnormal", len(abnormal))
+		result.Detail = detail
+		logrus.WithField("component", "bmc").Errorf("%s failed: %s", c.name, detail)
+	} else {
+		result.Curr = "OK"
+	}
+	return &result, nil
+}
+
+// PSUChecker flags the failed power supplies and a chassis with less power
+// supplies than expected.
+type PSUChecker struct {
+	name       string
+	minPresent int
+}
+
+func NewPSUChecker(spec *config.BmcSpec) (common.Checker, error) {
+	return &PSUChecker{
+		name:       config.PSUCheckerName,
+		minPresent: spec.PSU.MinPresent,
+	}, nil
+}
+
+func (c *PSUChecker) Name() string {
+	return c.name
+}
+
+func (c *PSUChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	info, ok := data.(*collector.BMCInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid BMCInfo type")
+	}
+
+	result := config.BmcCheckItems[c.name]
+	result.Status = consts.StatusNormal
+
+	var failed []string
+	var detail string
+	present := 0
+	for _, psu := range info.PSUs {
+		if psu.Present {
+			present++
+		}
+		if psu.Failed {
+			failed = append(failed, psu.Name)
+			detail += fmt.Sprintf("%s: %s\n", psu.Name, strings.Join(psu.States, ", "))
+		}
+	}
+	if present < c.minPresent {
+		detail += fmt.Sprintf("%d power supplies present, expected %d\n", present, c.minPresent)
+	}
+
+	if detail != "" {
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(failed, ",")
+		result.Curr = fmt.Sprintf("%d present, %d failed", present, len(failed))
+		result.Detail = detail
+		logrus.WithField("component", "bmc").Errorf("%s failed: %s", c.name, detail)
+	} else {
+		result.Curr = fmt.Sprintf("%d present", present)
+	}
+	return &result, nil
+}
@@ -0,0 +1,269 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scitix/sichek/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	SensorTypeFan         = "fan"
+	SensorTypeTemperature = "temperature"
+
+	// SensorStatusOK and the others are the threshold states of `ipmitool sensor`:
+	// ok, nc (non-critical), cr (critical), nr (non-recoverable), ns (no reading)
+	// and na (not available).
+	SensorStatusOK             = "ok"
+	SensorStatusNonCritical    = "nc"
+	SensorStatusCritical       = "cr"
+	SensorStatusNonRecoverable = "nr"
+
+	// selTimeLayout is the date and time of `ipmitool sel elist`.
+	selTimeLayout = "01/02/2006 15:04:05"
+	// maxSELEvents bounds the SEL entries read on each collect.
+	maxSELEvents = 256
+)
+
+// psuFailureEvents are the sensor-specific offsets of the Power Supply sensor
+// type (IPMI 2.0 table 42-3) and of its redundancy sensor that mean a PSU is not
+// delivering power.
+var psuFailureEvents = []string{
+	"Failure detected",
+	"Predictive failure",
+	"Power Supply AC lost",
+	"AC lost or out-of-range",
+	"AC out-of-range, but present",
+	"Config Error",
+	"Redundancy Lost",
+}
+
+// SensorReading is a threshold sensor of `ipmitool sensor`.
+type SensorReading struct {
+	Name     string  `json:"name"`
+	Type     string  `json:"type" metric:"-"`
+	Value    float64 `json:"value"`
+	HasValue bool    `json:"has_value" metric:"-"`
+	Unit     string  `json:"unit" metric:"-"`
+	Status   string  `json:"status" metric:"-"`
+	// UpperCritical is the upper critical threshold, 0 if the BMC has none.
+	UpperCritical float64 `json:"upper_critical,omitempty" metric:"-"`
+}
+
+// PSUStatus is a Power Supply sensor of the SDR with its asserted states.
+type PSUStatus struct {
+	Name    string   `json:"name"`
+	Present bool     `json:"present"`
+	Failed  bool     `json:"failed"`
+	States  []string `json:"states,omitempty"`
+}
+
+// SELEvent is an entry of the System Event Log.
+type SELEvent struct {
+	ID        string    `json:"id"`
+	Time      time.Time `json:"time"`
+	Sensor    string    `json:"sensor"`
+	Event     string    `json:"event"`
+	Direction string    `json:"direction"`
+}
+
+type BMCInfo struct {
+	Time         time.Time        `json:"time"`
+	Fans         []*SensorReading `json:"fans"`
+	Temperatures []*SensorReading `json:"temperatures"`
+	PSUs         []*PSUStatus     `json:"psus"`
+	SEL          []*SELEvent      `json:"sel"`
+	// Errors are the ipmitool commands that failed, the other sections are still filled.
+	Errors []string `json:"errors,omitempty"`
+}
+
+func (i *BMCInfo) JSON() (string, error) {
+	data, err := json.Marshal(i)
+	return string(data), err
+}
+
+// BMCCollector collects the fans, temperatures, PSUs and SEL from the BMC with ipmitool.
+type BMCCollector struct {
+	name string
+}
+
+func NewBMCCollector() (*BMCCollector, error) {
+	return &BMCCollector{name: "BMCCollector"}, nil
+}
+
+func (c *BMCCollector) Name() string {
+	return c.name
+}
+
+func (c *BMCCollector) Collect(ctx context.Context) (*BMCInfo, error) {
+	info := &BMCInfo{Time: time.Now()}
+
+	out, err := utils.ExecCommand(ctx, "ipmitool", "sensor")
+	if err != nil {
+		// without the sensors the BMC is most likely not reachable at all
+		return nil, fmt.Errorf("ipmitool sensor failed: %w", err)
+	}
+	for _, sensor := range ParseSensors(string(out)) {
+		switch sensor.Type {
+		case SensorTypeFan:
+			info.Fans = append(info.Fans, sensor)
+		case SensorTypeTemperature:
+			info.Temperatures = append(info.Temperatures, sensor)
+		}
+	}
+
+	out, err = utils.ExecCommand(ctx, "ipmitool", "sdr", "type", "Power Supply")
+	if err != nil {
+		logrus.WithField("component", "bmc").Warnf("failed to read the power supplies: %v", err)
+		info.Errors = append(info.Errors, fmt.Sprintf("ipmitool sdr type Power Supply: %v", err))
+	} else {
+		info.PSUs = ParsePSUs(string(out))
+	}
+
+	out, err = utils.ExecCommand(ctx, "ipmitool", "sel", "elist", "last", strconv.Itoa(maxSELEvents))
+	if err != nil {
+		logrus.WithField("component", "bmc").Warnf("failed to read the SEL: %v", err)
+		info.Errors = append(info.Errors, fmt.Sprintf("ipmitool sel elist: %v", err))
+	} else {
+		info.SEL = ParseSEL(string(out), time.Local)
+	}
+	return info, nil
+}
+
+// ParseSensors parses the output of `ipmitool sensor`:
+//
+//	FAN1             | 5400.000   | RPM        | ok    | na        | 300.000   | 500.000   | na        | na        | na
+//	Inlet Temp       | 24.000     | degrees C  | ok    | na        | na        | na        | 40.000    | 45.000    | na
+//
+// The columns are name, value, unit, status and the lower non-recoverable,
+// lower critical, lower non-critical, upper non-critical, upper critical and
+// upper non-recoverable thresholds. Only the fans and temperatures are kept.
+func ParseSensors(output string) []*SensorReading {
+	var sensors []*SensorReading
+	for _, line := range strings.Split(output, "\n") {
+		fields := splitFields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		sensor := &SensorReading{
+			Name:   fields[0],
+			Unit:   fields[2],
+			Status: strings.ToLower(fields[3]),
+		}
+		switch {
+		case strings.EqualFold(sensor.Unit, "RPM"):
+			sensor.Type = SensorTypeFan
+		case strings.EqualFold(sensor.Unit, "degrees C"):
+			sensor.Type = SensorTypeTemperature
+		default:
+			continue
+		}
+		if value, err := strconv.ParseFloat(fields[1], 64); err == nil {
+			sensor.Value = value
+			sensor.HasValue = true
+		}
+		if len(fields) > 8 {
+			if ucr, err := strconv.ParseFloat(fields[8], 64); err == nil {
+				sensor.UpperCritical = ucr
+			}
+		}
+		sensors = append(sensors, sensor)
+	}
+	return sensors
+}
+
+// ParsePSUs parses the output of `ipmitool sdr type "Power Supply"`:
+//
+//	PSU1 Status      | 73h | ok  | 10.1 | Presence detected
+//	PSU2 Status      | 74h | ok  | 10.2 | Presence detected, Power Supply AC lost
+//	PS Redundancy    | 77h | ok  | 7.1  | Fully Redundant
+func ParsePSUs(output string) []*PSUStatus {
+	var psus []*PSUStatus
+	for _, line := range strings.Split(output, "\n") {
+		fields := splitFields(line)
+		if len(fields) < 5 {
+			continue
+		}
+		psu := &PSUStatus{Name: fields[0]}
+		for _, state := range strings.Split(fields[4], ",") {
+			state = strings.TrimSpace(state)
+			if state == "" {
+				continue
+			}
+			psu.States = append(psu.States, state)
+			if strings.EqualFold(state, "Presence detected") {
+				psu.Present = true
+			}
+			for _, failure := range psuFailureEvents {
+				if strings.EqualFold(state, failure) {
+					psu.Failed = true
+				}
+			}
+		}
+		psus = append(psus, psu)
+	}
+	return psus
+}
+
+// ParseSEL parses the output of `ipmitool sel elist`, the times are in loc:
+//
+//	1 | 04/01/2024 | 10:00:00 | Power Supply PSU2 Status | Power Supply AC lost | Asserted
+//
+// Entries logged before the BMC clock was set ("Pre-Init") have a zero time.
+func ParseSEL(output string, loc *time.Location) []*SELEvent {
+	var events []*SELEvent
+	for _, line := range strings.Split(output, "\n") {
+		fields := splitFields(line)
+		if len(fields) < 5 {
+			continue
+		}
+		event := &SELEvent{
+			ID:     fields[0],
+			Sensor: fields[3],
+			Event:  fields[4],
+		}
+		if len(fields) > 5 {
+			event.Direction = fields[5]
+		}
+		// newer ipmitool versions append the time zone to the time
+		clock := strings.Fields(fields[2])
+		if len(clock) > 0 {
+			if t, err := time.ParseInLocation(selTimeLayout, fields[1]+" "+clock[0], loc); err == nil {
+				event.Time = t
+			}
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func splitFields(line string) []string {
+	if !strings.Contains(line, "|") {
+		return nil
+	}
+	fields := strings.Split(line, "|")
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	return fields
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSensors(t *testing.T) {
+	output := `FAN1             | 5400.000   | RPM        | ok    | na        | 300.000   | 500.000   | na        | na        | na
+FAN2             | 200.000    | RPM        | cr    | na        | 300.000   | 500.000   | na        | na        | na
+FAN3             | na         | RPM        | na    | na        | na        | na        | na        | na        | na
+Inlet Temp       | 24.000     | degrees C  | ok    | na        | na        | na        | 40.000    | 45.000    | na
+PSU1 Input       | 230.000    | Volts      | ok    | na        | na        | na        | na        | na        | na
+`
+	sensors := ParseSensors(output)
+	require.Len(t, sensors, 4)
+
+	assert.Equal(t, &SensorReading{Name: "FAN1", Type: SensorTypeFan, Value: 5400, HasValue: true, Unit: "RPM", Status: SensorStatusOK}, sensors[0])
+	assert.Equal(t, SensorStatusCritical, sensors[1].Status)
+	assert.False(t, sensors[2].HasValue)
+	assert.Equal(t, SensorTypeTemperature, sensors[3].Type)
+	assert.Equal(t, 24.0, sensors[3].Value)
+	assert.Equal(t, 45.0, sensors[3].UpperCritical)
+}
+
+func TestParsePSUs(t *testing.T) {
+	output := `PSU1 Status      | 73h | ok  | 10.1 | Presence detected
+PSU2 Status      | 74h | ok  | 10.2 | Presence detected, Power Supply AC lost
+PS Redundancy    | 77h | ok  | 7.1  | Redundancy Lost
+`
+	psus := ParsePSUs(output)
+	require.Len(t, psus, 3)
+
+	assert.True(t, psus[0].Present)
+	assert.False(t, psus[0].Failed)
+	assert.True(t, psus[1].Present)
+	assert.True(t, psus[1].Failed)
+	assert.Equal(t, []string{"Presence detected", "Power Supply AC lost"}, psus[1].States)
+	assert.False(t, psus[2].Present)
+	assert.True(t, psus[2].Failed)
+}
+
+func TestParseSEL(t *testing.T) {
+	output := `   1 | Pre-Init  |0000000000| System Event #0x01 | Timestamp Clock Sync | Asserted
+   2 | 04/01/2024 | 10:00:00 | Power Supply PSU2 Status | Power Supply AC lost | Asserted
+   3 | 04/01/2024 | 10:05:00 CST | Memory #0x87 | Uncorrectable ECC | Asserted
+`
+	events := ParseSEL(output, time.UTC)
+	require.Len(t, events, 3)
+
+	assert.True(t, events[0].Time.IsZero())
+	assert.Equal(t, &SELEvent{
+		ID:        "2",
+		Time:      time.Date(2024, 4, 1, 10, 0, 0, 0, time.UTC),
+		Sensor:    "Power Supply PSU2 Status",
+		Event:     "Power Supply AC lost",
+		Direction: "Asserted",
+	}, events[1])
+	assert.Equal(t, time.Date(2024, 4, 1, 10, 5, 0, 0, time.UTC), events[2].Time)
+	assert.Equal(t, "Uncorrectable ECC", events[2].Event)
+}
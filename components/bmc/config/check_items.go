@@ -0,0 +1,68 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+)
+
+const (
+	FanCheckerName         = "bmc-fan"
+	PSUCheckerName         = "bmc-psu"
+	TemperatureCheckerName = "bmc-temperature"
+	SELCheckerName         = "bmc-sel"
+)
+
+// BmcCheckItems is a map of check items for the sensors and the SEL of the BMC
+var BmcCheckItems = map[string]common.CheckerResult{
+	FanCheckerName: {
+		Name:        FanCheckerName,
+		Description: "Check if every fan spins above the minimum speed and no fan sensor is in a critical state",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelCritical,
+		Detail:      "All fans are OK",
+		ErrorName:   "BMCFanFailure",
+		Suggestion:  "Check the fan module reported by `ipmitool sensor` and replace it, the GPUs throttle without enough airflow",
+	},
+	PSUCheckerName: {
+		Name:        PSUCheckerName,
+		Description: "Check if the expected power supplies are present and none of them reports a failure",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelCritical,
+		Detail:      "All power supplies are OK",
+		ErrorName:   "BMCPSUFailure",
+		Suggestion:  "Check the power cord and the PSU reported by `ipmitool sdr type \"Power Supply\"`, the node has no power redundancy",
+	},
+	TemperatureCheckerName: {
+		Name:        TemperatureCheckerName,
+		Description: "Check if the chassis temperatures are below the maximum and no temperature sensor is in a critical state",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelCritical,
+		Detail:      "All temperatures are OK",
+		ErrorName:   "BMCOverTemperature",
+		Suggestion:  "Check the fans, the air inlet and the cooling of the rack",
+	},
+	SELCheckerName: {
+		Name:        SELCheckerName,
+		Description: "Check if the BMC System Event Log has critical events in the lookback window",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelCritical,
+		Detail:      "No critical SEL event",
+		ErrorName:   "BMCSELCriticalEvent",
+		Suggestion:  "Check `ipmitool sel elist` and the BMC web console, clear the SEL after the hardware is fixed",
+	},
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/httpclient"
+	"github.com/sirupsen/logrus"
+)
+
+type BmcSpecs struct {
+	Specs map[string]*BmcSpec `json:"bmc" yaml:"bmc"`
+}
+
+type BmcSpec struct {
+	Fan         FanSpec         `json:"fan" yaml:"fan"`
+	PSU         PSUSpec         `json:"psu" yaml:"psu"`
+	Temperature TemperatureSpec `json:"temperature" yaml:"temperature"`
+	SEL         SELSpec         `json:"sel" yaml:"sel"`
+}
+
+type FanSpec struct {
+	// MinRPM is the lowest speed of a spinning fan, 0 only relies on the sensor status.
+	MinRPM float64 `json:"min_rpm" yaml:"min_rpm"`
+}
+
+type PSUSpec struct {
+	// MinPresent is the number of power supplies expected in the chassis.
+	MinPresent int `json:"min_present" yaml:"min_present"`
+}
+
+type TemperatureSpec struct {
+	// MaxCelsius applies to every temperature sensor, 0 only relies on the sensor status.
+	MaxCelsius float64 `json:"max_celsius" yaml:"max_celsius"`
+}
+
+type SELSpec struct {
+	// Lookback bounds the age of the SEL events taken into account.
+	Lookback common.Duration `json:"lookback" yaml:"lookback"`
+	// CriticalEvents are matched case-insensitively against the sensor and the event of a SEL entry.
+	CriticalEvents []string `json:"critical_events" yaml:"critical_events"`
+}
+
+// EnsureSpec ensures that `file` contains the "default" bmc spec entry,
+// potentially downloading it from OSS.
+func EnsureSpec(file string) (string, error) {
+	const comp = "bmc/spec"
+	const specID = "default"
+
+	var s BmcSpecs
+	if err := common.LoadSpec(file, &s); err == nil {
+		if s.Specs != nil {
+			if _, ok := s.Specs[specID]; ok {
+				logrus.WithField("component", comp).Infof("spec for bmc %s already in %s, skipping download", specID, file)
+				return file, nil
+			}
+		}
+	} else {
+		logrus.WithField("component", comp).Debugf("LoadSpec failed during EnsureSpec (may be new file): %v", err)
+	}
+
+	// Download {SICHEK_SPEC_URL}/bmc/default.yaml
+	ossBase := httpclient.GetSichekSpecURL()
+	if ossBase == "" {
+		return file, fmt.Errorf("EnsureSpec: bmc %s not in spec and SICHEK_SPEC_URL not set", specID)
+	}
+
+	tmpFile := filepath.Join(os.TempDir(), fmt.Sprintf("bmc_%s.yaml", specID))
+	url := fmt.Sprintf("%s/%s/%s.yaml", strings.TrimRight(ossBase, "/"), consts.ComponentNameBMC, specID)
+
+	logrus.WithField("component", comp).Infof("downloading bmc spec from %s", url)
+	if err := common.DownloadSpecFile(url, tmpFile, comp); err != nil {
+		return file, fmt.Errorf("EnsureSpec: download failed: %w", err)
+	}
+
+	var downloaded BmcSpecs
+	if err := common.LoadSpec(tmpFile, &downloaded); err != nil {
+		return file, fmt.Errorf("EnsureSpec: parse downloaded spec: %w", err)
+	}
+
+	if err := common.MergeAndWriteSpec(
+		file,
+		"bmc",
+		downloaded.Specs,
+		func(c *BmcSpecs) map[string]*BmcSpec { return c.Specs },
+		func(c *BmcSpecs, m map[string]*BmcSpec) { c.Specs = m },
+	); err != nil {
+		return file, fmt.Errorf("EnsureSpec: merge failed: %w", err)
+	}
+
+	logrus.WithField("component", comp).Infof("merged bmc %s spec into %s", specID, file)
+	return file, nil
+}
+
+// LoadSpec reads the bmc multi-spec YAML at `file`, ensures the "default"
+// entry is present and returns it.
+func LoadSpec(file string) (*BmcSpec, error) {
+	if file == "" {
+		return nil, fmt.Errorf("bmc spec file path is empty")
+	}
+
+	if _, err := EnsureSpec(file); err != nil {
+		logrus.WithField("component", "bmc/spec").Warnf("EnsureSpec failed: %v", err)
+	}
+
+	return common.FilterSpec(file, "bmc", "default",
+		func(c *BmcSpecs, id string) (*BmcSpec, bool) {
+			spec, ok := c.Specs[id]
+			return spec, ok
+		},
+	)
+}
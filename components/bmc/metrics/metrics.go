@@ -0,0 +1,60 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package metrics
+
+import (
+	"github.com/scitix/sichek/components/bmc/collector"
+	common "github.com/scitix/sichek/metrics"
+)
+
+const (
+	MetricPrefix = "sichek_bmc"
+)
+
+type BmcMetrics struct {
+	SensorGauge *common.GaugeVecMetricExporter
+}
+
+func NewBmcMetrics() *BmcMetrics {
+	return &BmcMetrics{
+		SensorGauge: common.NewGaugeVecMetricExporter(MetricPrefix, []string{"sensor"}),
+	}
+}
+
+// ExportMetrics exports sichek_bmc_fan_rpm, sichek_bmc_temperature_celsius and
+// sichek_bmc_psu_failed per sensor. Sensors without reading are skipped.
+func (m *BmcMetrics) ExportMetrics(info *collector.BMCInfo) {
+	if info == nil {
+		return
+	}
+	for _, fan := range info.Fans {
+		if fan.HasValue {
+			m.SensorGauge.SetMetric("fan_rpm", []string{fan.Name}, fan.Value)
+		}
+	}
+	for _, temp := range info.Temperatures {
+		if temp.HasValue {
+			m.SensorGauge.SetMetric("temperature_celsius", []string{temp.Name}, temp.Value)
+		}
+	}
+	for _, psu := range info.PSUs {
+		failed := 0.0
+		if psu.Failed {
+			failed = 1
+		}
+		m.SensorGauge.SetMetric("psu_failed", []string{psu.Name}, failed)
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/capability"
+)
+
+// detectCapabilities is a var so that tests can run the checkers with or
+// without privileges regardless of the user running the tests.
+var detectCapabilities = capability.Detect
+
+// privilegedChecker runs the wrapped checker only when sichek holds caps.
+type privilegedChecker struct {
+	Checker
+	caps []capability.Capability
+}
+
+// RequireCapabilities wraps checker so that it reports skipped, instead of
+// an error caused by the missing privileges, when sichek lacks one of caps,
+// e.g. when it runs rootless.
+func RequireCapabilities(checker Checker, caps ...capability.Capability) Checker {
+	return &privilegedChecker{Checker: checker, caps: caps}
+}
+
+func (c *privilegedChecker) Check(ctx context.Context, data any) (*CheckerResult, error) {
+	if missing := c.missing(); len(missing) > 0 {
+		return SkippedResult(c.Name(), missing), nil
+	}
+	return c.Checker.Check(ctx, data)
+}
+
+func (c *privilegedChecker) missing() []capability.Capability {
+	return detectCapabilities().Missing(c.caps...)
+}
+
+// SkippedResult is the result of a checker not run for lack of the missing capabilities.
+func SkippedResult(name string, missing []capability.Capability) *CheckerResult {
+	requirement := capability.Requirement(missing)
+	return &CheckerResult{
+		Name:       name,
+		Status:     consts.StatusSkipped,
+		Level:      consts.LevelInfo,
+		Curr:       consts.StatusSkipped,
+		Detail:     fmt.Sprintf("skipped: requires %s", requirement),
+		Suggestion: fmt.Sprintf("run sichek with %s to enable this check", requirement),
+	}
+}
+
+// AllSkipped reports whether every checker lacks its capabilities, so that
+// the component can skip a collection whose data no checker would use.
+func AllSkipped(checkers []Checker) bool {
+	if len(checkers) == 0 {
+		return false
+	}
+	for _, checker := range checkers {
+		privileged, ok := checker.(*privilegedChecker)
+		if !ok || len(privileged.missing()) == 0 {
+			return false
+		}
+	}
+	return true
+}
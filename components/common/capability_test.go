@@ -0,0 +1,71 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/capability"
+)
+
+type failingPCIChecker struct{}
+
+func (c *failingPCIChecker) Name() string { return "pcie-acs" }
+
+func (c *failingPCIChecker) Check(ctx context.Context, data any) (*CheckerResult, error) {
+	return &CheckerResult{Name: c.Name(), Status: consts.StatusAbnormal, Level: consts.LevelCritical}, nil
+}
+
+func useCapabilities(t *testing.T, caps ...capability.Capability) {
+	t.Helper()
+	orig := detectCapabilities
+	detectCapabilities = func() *capability.Set { return capability.NewSet(caps...) }
+	t.Cleanup(func() { detectCapabilities = orig })
+}
+
+func TestRequireCapabilitiesRootless(t *testing.T) {
+	useCapabilities(t)
+	checkers := []Checker{RequireCapabilities(&failingPCIChecker{}, capability.PCIConfig)}
+	if !AllSkipped(checkers) {
+		t.Errorf("expected the checkers to be skipped without privileges")
+	}
+	result := Check(context.Background(), "nvidia", nil, checkers)
+	if result.Status != consts.StatusNormal || len(result.Checkers) != 1 {
+		t.Fatalf("expected a skipped checker not to fail the component, got %+v", result)
+	}
+	skipped := result.Checkers[0]
+	if skipped.Name != "pcie-acs" || skipped.Status != consts.StatusSkipped || skipped.Level != consts.LevelInfo {
+		t.Errorf("unexpected skipped result %+v", skipped)
+	}
+	if !strings.HasPrefix(skipped.Detail, "skipped: requires root/CAP_SYS_ADMIN") {
+		t.Errorf("unexpected detail %q", skipped.Detail)
+	}
+}
+
+func TestRequireCapabilitiesPrivileged(t *testing.T) {
+	useCapabilities(t, capability.Root, capability.PCIConfig)
+	checkers := []Checker{RequireCapabilities(&failingPCIChecker{}, capability.PCIConfig), &failingPCIChecker{}}
+	if AllSkipped(checkers) {
+		t.Errorf("expected the checkers to run with privileges")
+	}
+	result := Check(context.Background(), "nvidia", nil, checkers)
+	if result.Status != consts.StatusAbnormal || result.Checkers[0].Status != consts.StatusAbnormal {
+		t.Errorf("expected the wrapped checker to run, got %+v", result.Checkers[0])
+	}
+}
@@ -23,6 +23,8 @@ import (
 	"time"
 
 	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/scitix/sichek/pkg/telemetry"
 	"github.com/sirupsen/logrus"
 )
 
@@ -116,16 +118,30 @@ func CompareVersion(spec, version string) bool {
 
 func Check(ctx context.Context, componentName string, data any, checkers []Checker) *Result {
 	checkerResults := make([]*CheckerResult, len(checkers))
+	budgetResults := make([]*CheckerResult, len(checkers))
+	durations := GetDurationTracker()
 	wg := sync.WaitGroup{}
 	for idx, each := range checkers {
 		wg.Add(1)
 		go func(idx int, each Checker) {
 			defer wg.Done()
+			ctx, span := telemetry.StartChecker(ctx, componentName, each.Name())
+			start := time.Now()
 			checkResult, err := each.Check(ctx, data)
+			budgetResults[idx] = durations.Observe(componentName, each.Name(), time.Since(start))
+			if checkResult != nil {
+				span.End(checkResult.Status, err)
+			} else {
+				span.End("", err)
+			}
 			if err != nil {
+				printer.Progressf("[%s] %s: error after %s: %v\n", componentName, each.Name(), time.Since(start).Round(time.Millisecond), err)
 				logrus.WithField("component", componentName).Errorf("[%s]failed to check: %v", each.Name(), err)
 				return
 			}
+			if checkResult != nil {
+				printer.Progressf("[%s] %s: %s in %s\n", componentName, each.Name(), checkResult.Status, time.Since(start).Round(time.Millisecond))
+			}
 			if checkResult != nil && checkResult.Status == consts.StatusAbnormal {
 				logrus.WithFields(logrus.Fields{
 					"component": componentName,
@@ -142,7 +158,9 @@ func Check(ctx context.Context, componentName string, data any, checkers []Check
 		Item: componentName,
 		Time: time.Now(),
 	}
-	for _, checkItem := range checkerResults {
+	// the collector and the checkers running over their duration budget
+	budgetResults = append(budgetResults, durations.TakePending(componentName))
+	for _, checkItem := range append(checkerResults, budgetResults...) {
 		if checkItem == nil {
 			continue
 		}
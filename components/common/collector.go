@@ -18,7 +18,8 @@ package common
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+
+	"github.com/sirupsen/logrus"
 )
 
 type Collector interface {
@@ -39,7 +40,7 @@ func JSON(v interface{}) ([]byte, error) {
 func ToString(v interface{}) string {
 	jsonData, err := json.MarshalIndent(v, "", "  ")
 	if err != nil {
-		fmt.Printf("Error converting struct to JSON: %v\n", err)
+		logrus.Errorf("Error converting struct to JSON: %v", err)
 		return ""
 	}
 	return string(jsonData)
@@ -18,9 +18,11 @@ package common
 import (
 	"context"
 	"encoding/json"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/scitix/sichek/consts"
 	"github.com/sirupsen/logrus"
 )
 
@@ -51,6 +53,8 @@ type Result struct {
 	Level    string           `json:"level"`
 	Checkers []*CheckerResult `json:"checkers"`
 	Time     time.Time        `json:"time"`
+	// Action is the lifecycle action recommended by the abnormal checkers, see LifecycleAdvisor.
+	Action *LifecycleAdvice `json:"action,omitempty"`
 }
 
 func (r *Result) JSON() (string, error) {
@@ -69,6 +73,89 @@ type CheckerResult struct {
 	Suggestion  string `json:"suggestion"`
 	Detail      string `json:"detail"`
 	ErrorName   string `json:"error_name"`
+	// ErrorCode is the stable code of ErrorName in the consts/errdef catalog.
+	ErrorCode string `json:"error_code,omitempty" metric:"-"`
+	// Devices are the devices the checker found unhealthy, Device lists the same devices as a string.
+	Devices []*DeviceResult `json:"devices,omitempty"`
+	// Remediations are the actions fixing the abnormal state online, they are
+	// only applied by the remediator when auto-fix is enabled.
+	Remediations []*RemediationAction `json:"remediations,omitempty"`
+	// SilencedBy is the id of the silence that turned the abnormal status into silenced.
+	SilencedBy string `json:"silenced_by,omitempty" metric:"-"`
+	// Action is the lifecycle action the abnormal state calls for, e.g. a GPU reset to apply a pending row remap.
+	Action LifecycleAction `json:"action,omitempty" metric:"-"`
+}
+
+// RemediationAction is a change of the node a checker proposes to fix what it
+// found, e.g. loading a kernel module or restarting a service.
+type RemediationAction struct {
+	// Name is the kind of action, e.g. "modprobe", allowed actions are configured by name.
+	Name string `json:"name"`
+	// Target is what the action changes, e.g. the module, unit or device.
+	Target      string `json:"target"`
+	Description string `json:"description"`
+	// Apply performs the change, it must be safe to run again on the next check.
+	Apply func(ctx context.Context) error `json:"-" yaml:"-"`
+}
+
+// DeviceResult identifies a single device of an abnormal checker, so that the
+// device can be quarantined instead of the whole node.
+type DeviceResult struct {
+	Index int    `json:"index"`
+	UUID  string `json:"uuid,omitempty"`
+	BDF   string `json:"bdf,omitempty"`
+	// Pods are the namespace/name of the pods using the device when the
+	// checker found it unhealthy, so that the impact can be told per tenant.
+	Pods []string `json:"pods,omitempty"`
+}
+
+// SpecUpdater is implemented by the components whose checkers are built from
+// the spec, so that a changed spec is applied without restarting the daemon.
+type SpecUpdater interface {
+	// UpdateSpec loads the spec from specFile and swaps in the checkers built
+	// from it. The running checkers are kept if the spec cannot be loaded.
+	UpdateSpec(specFile string) error
+}
+
+// DeviceReporter is implemented by the components that report which of their
+// devices are unhealthy.
+type DeviceReporter interface {
+	// UnhealthyDevices returns the devices of the abnormal checkers of the last
+	// result with one of the given levels, all levels count if none is given.
+	UnhealthyDevices(levels ...string) ([]*DeviceResult, error)
+}
+
+// UnhealthyDevices returns the devices reported by the abnormal checkers of
+// result with one of the given levels, deduplicated and sorted by index.
+func UnhealthyDevices(result *Result, levels ...string) []*DeviceResult {
+	if result == nil {
+		return nil
+	}
+	levelSet := make(map[string]bool, len(levels))
+	for _, level := range levels {
+		levelSet[level] = true
+	}
+	devices := make(map[int]*DeviceResult)
+	for _, checker := range result.Checkers {
+		if checker == nil || checker.Status != consts.StatusAbnormal {
+			continue
+		}
+		if len(levelSet) > 0 && !levelSet[checker.Level] {
+			continue
+		}
+		for _, device := range checker.Devices {
+			if existing, ok := devices[device.Index]; ok && existing.UUID != "" {
+				continue
+			}
+			devices[device.Index] = device
+		}
+	}
+	unhealthy := make([]*DeviceResult, 0, len(devices))
+	for _, device := range devices {
+		unhealthy = append(unhealthy, device)
+	}
+	sort.Slice(unhealthy, func(i, j int) bool { return unhealthy[i].Index < unhealthy[j].Index })
+	return unhealthy
 }
 
 func (c *CheckerResult) JSON() ([]byte, error) {
@@ -90,15 +177,17 @@ type CommonService struct {
 	checkTimeout    time.Duration
 	componentName   string
 
-	mutex         sync.RWMutex
-	running       bool
-	resultChannel chan *Result
+	mutex   sync.RWMutex
+	running bool
+	results *ResultQueue
 }
 
 type HealthCheckFunc func(ctx context.Context) (*Result, error)
 
 func NewCommonService(ctx context.Context, cfg ComponentUserConfig, componentName string, checkTimeout time.Duration, analyze HealthCheckFunc) *CommonService {
 	cctx, ccancel := context.WithCancel(ctx)
+	// registered so that the interval can be changed at runtime and adapted to the results
+	GetFreqController().RegisterModule(componentName, cfg)
 
 	return &CommonService{
 		ctx:             cctx,
@@ -107,7 +196,7 @@ func NewCommonService(ctx context.Context, cfg ComponentUserConfig, componentNam
 		checkTimeout:    checkTimeout,
 		healthCheckFunc: analyze,
 		componentName:   componentName,
-		resultChannel:   make(chan *Result),
+		results:         NewResultQueue(componentName, DefaultResultQueueSize),
 	}
 }
 
@@ -115,7 +204,7 @@ func (s *CommonService) Start() <-chan *Result {
 	s.mutex.Lock()
 	if s.running {
 		s.mutex.Unlock()
-		return s.resultChannel
+		return s.results.C()
 	}
 	s.running = true
 	s.mutex.Unlock()
@@ -138,7 +227,7 @@ func (s *CommonService) Start() <-chan *Result {
 				// Check if need to update ticker
 				newInterval := s.cfg.GetQueryInterval()
 				if newInterval.Duration != interval.Duration {
-					logrus.WithField("component", "nvidia").Infof("Updating ticker interval from %s to %s", interval.Duration, newInterval.Duration)
+					logrus.WithField("component", s.componentName).Infof("Updating ticker interval from %s to %s", interval.Duration, newInterval.Duration)
 					ticker.Stop()
 					ticker = time.NewTicker(newInterval.Duration)
 					interval = newInterval
@@ -151,23 +240,21 @@ func (s *CommonService) Start() <-chan *Result {
 					continue
 				}
 
-				s.mutex.Lock()
-				s.resultChannel <- result
-				s.mutex.Unlock()
+				s.results.Send(result)
 			}
 		}
 	}()
 	s.mutex.Lock()
 	s.running = true
 	s.mutex.Unlock()
-	return s.resultChannel
+	return s.results.C()
 }
 
 // Stop is used for systemd stop
 func (s *CommonService) Stop() error {
 	s.cancel()
 	s.mutex.Lock()
-	close(s.resultChannel)
+	s.results.Close()
 	s.running = false
 	s.mutex.Unlock()
 	return nil
@@ -179,6 +266,7 @@ func (s *CommonService) Update(cfg ComponentUserConfig) error {
 	s.cfgMutex.Lock()
 	s.cfg = cfg
 	s.cfgMutex.Unlock()
+	GetFreqController().RegisterModule(s.componentName, cfg)
 	return nil
 }
 
@@ -0,0 +1,45 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"testing"
+
+	"github.com/scitix/sichek/consts"
+)
+
+func TestUnhealthyDevices(t *testing.T) {
+	result := &Result{
+		Status: consts.StatusAbnormal,
+		Checkers: []*CheckerResult{
+			{Status: consts.StatusAbnormal, Level: consts.LevelCritical, Devices: []*DeviceResult{{Index: 5}, {Index: 1, UUID: "GPU-1"}}},
+			{Status: consts.StatusAbnormal, Level: consts.LevelCritical, Devices: []*DeviceResult{{Index: 5, UUID: "GPU-5"}}},
+			{Status: consts.StatusAbnormal, Level: consts.LevelWarning, Devices: []*DeviceResult{{Index: 2}}},
+			{Status: consts.StatusNormal, Level: consts.LevelCritical, Devices: []*DeviceResult{{Index: 7}}},
+		},
+	}
+
+	devices := UnhealthyDevices(result, consts.LevelCritical, consts.LevelFatal)
+	if len(devices) != 2 || devices[0].Index != 1 || devices[1].Index != 5 || devices[1].UUID != "GPU-5" {
+		t.Errorf("unexpected critical devices: %+v", devices)
+	}
+	if devices := UnhealthyDevices(result); len(devices) != 3 {
+		t.Errorf("expected 3 devices of any level, got %+v", devices)
+	}
+	if devices := UnhealthyDevices(nil); len(devices) != 0 {
+		t.Errorf("expected no devices for a nil result, got %+v", devices)
+	}
+}
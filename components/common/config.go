@@ -164,17 +164,66 @@ func LoadUserConfig(file string, config interface{}) error {
 	return err
 }
 
+// AdaptiveIntervalConfig slows down the health checks of a component while it
+// stays healthy and speeds them up again once it turns abnormal.
+type AdaptiveIntervalConfig struct {
+	Enable bool `json:"enable" yaml:"enable"`
+	// HealthyChecks is the count of consecutive normal results before each slowdown.
+	HealthyChecks int `json:"healthy_checks" yaml:"healthy_checks"`
+	// BackoffFactor multiplies the interval on each slowdown.
+	BackoffFactor float64 `json:"backoff_factor" yaml:"backoff_factor"`
+	// MaxFactor bounds the interval to the base interval times MaxFactor.
+	MaxFactor float64 `json:"max_factor" yaml:"max_factor"`
+	// FailureFactor sets the interval to the base interval times FailureFactor
+	// after an abnormal result, below 1 to check a failing component faster.
+	FailureFactor float64 `json:"failure_factor" yaml:"failure_factor"`
+}
+
+func DefaultAdaptiveIntervalConfig() AdaptiveIntervalConfig {
+	return AdaptiveIntervalConfig{
+		Enable:        false,
+		HealthyChecks: 10,
+		BackoffFactor: 2,
+		MaxFactor:     4,
+		FailureFactor: 0.5,
+	}
+}
+
+// minAdaptiveInterval keeps FailureFactor from turning the interval into a busy loop.
+const minAdaptiveInterval = time.Second
+
+// ModuleInterval is the query interval of a module registered in the FreqController.
+type ModuleInterval struct {
+	Name string `json:"name"`
+	// Interval is the interval in use, Base the configured one the adaptive
+	// interval starts from.
+	Interval string `json:"interval"`
+	Base     string `json:"base"`
+}
+
+// freqModule is a module of the FreqController with its adaptive state.
+type freqModule struct {
+	cfg  ComponentUserConfig
+	base time.Duration
+	// adapted is the last interval the controller set, an interval set by
+	// someone else, e.g. the gpuevents hang checker, pauses the adaptation.
+	adapted time.Duration
+	healthy int
+}
+
 // FreqController controls the frequency of component queries.
 type FreqController struct {
-	mu      sync.Mutex
-	modules map[string]ComponentUserConfig
+	mu       sync.Mutex
+	modules  map[string]*freqModule
+	adaptive AdaptiveIntervalConfig
 }
 
 // RegisterModule registers a new module with its configuration.
 func (fc *FreqController) RegisterModule(moduleName string, moduleCfg ComponentUserConfig) {
 	fc.mu.Lock()
 	defer fc.mu.Unlock()
-	fc.modules[moduleName] = moduleCfg
+	interval := moduleCfg.GetQueryInterval().Duration
+	fc.modules[moduleName] = &freqModule{cfg: moduleCfg, base: interval, adapted: interval}
 }
 
 // SetModuleQueryInterval sets the query interval for a specific module.
@@ -182,7 +231,7 @@ func (fc *FreqController) SetModuleQueryInterval(moduleName string, newInterval
 	fc.mu.Lock()
 	defer fc.mu.Unlock()
 	if module, exists := fc.modules[moduleName]; exists {
-		module.SetQueryInterval(newInterval)
+		module.cfg.SetQueryInterval(newInterval)
 	}
 }
 
@@ -191,7 +240,7 @@ func (fc *FreqController) GetModuleQueryInterval(moduleName string) Duration {
 	fc.mu.Lock()
 	defer fc.mu.Unlock()
 	if module, exists := fc.modules[moduleName]; exists {
-		return module.GetQueryInterval()
+		return module.cfg.GetQueryInterval()
 	} else {
 		// If the module is not registered, return a default value.
 		logrus.WithField("component", "FreqController").Errorf("module %s not registered", moduleName)
@@ -199,6 +248,106 @@ func (fc *FreqController) GetModuleQueryInterval(moduleName string) Duration {
 	}
 }
 
+// SetModuleBaseInterval replaces the configured interval of a module at
+// runtime, the adaptive interval restarts from it.
+func (fc *FreqController) SetModuleBaseInterval(moduleName string, newInterval time.Duration) error {
+	if newInterval <= 0 {
+		return fmt.Errorf("invalid query interval %s", newInterval)
+	}
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	module, exists := fc.modules[moduleName]
+	if !exists {
+		return fmt.Errorf("module %s not registered", moduleName)
+	}
+	module.base = newInterval
+	module.healthy = 0
+	fc.apply(moduleName, module, newInterval)
+	return nil
+}
+
+// ModuleInterval returns the intervals of a module, false if it is not registered.
+func (fc *FreqController) ModuleInterval(moduleName string) (ModuleInterval, bool) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	module, exists := fc.modules[moduleName]
+	if !exists {
+		return ModuleInterval{}, false
+	}
+	return ModuleInterval{
+		Name:     moduleName,
+		Interval: module.cfg.GetQueryInterval().Duration.String(),
+		Base:     module.base.String(),
+	}, true
+}
+
+// SetAdaptive configures the adaptive intervals of all the modules.
+func (fc *FreqController) SetAdaptive(cfg AdaptiveIntervalConfig) {
+	defaults := DefaultAdaptiveIntervalConfig()
+	if cfg.HealthyChecks <= 0 {
+		cfg.HealthyChecks = defaults.HealthyChecks
+	}
+	if cfg.BackoffFactor <= 1 {
+		cfg.BackoffFactor = defaults.BackoffFactor
+	}
+	if cfg.MaxFactor < 1 {
+		cfg.MaxFactor = defaults.MaxFactor
+	}
+	if cfg.FailureFactor <= 0 || cfg.FailureFactor > 1 {
+		cfg.FailureFactor = defaults.FailureFactor
+	}
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	fc.adaptive = cfg
+}
+
+// Observe adapts the interval of a module to its last result: every
+// HealthyChecks consecutive normal results multiply the interval by
+// BackoffFactor up to MaxFactor times the base interval, an abnormal result
+// drops it to FailureFactor times the base interval.
+func (fc *FreqController) Observe(moduleName string, result *Result) {
+	if result == nil {
+		return
+	}
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	module, exists := fc.modules[moduleName]
+	if !exists || !fc.adaptive.Enable {
+		return
+	}
+	if module.cfg.GetQueryInterval().Duration != module.adapted {
+		// overridden by someone else until they restore the interval
+		return
+	}
+	if result.Status != consts.StatusNormal {
+		module.healthy = 0
+		fc.apply(moduleName, module, max(time.Duration(float64(module.base)*fc.adaptive.FailureFactor), min(module.base, minAdaptiveInterval)))
+		return
+	}
+	module.healthy++
+	if module.healthy < fc.adaptive.HealthyChecks {
+		return
+	}
+	module.healthy = 0
+	next := time.Duration(float64(module.adapted) * fc.adaptive.BackoffFactor)
+	if next < module.base {
+		// back from the failure interval
+		next = module.base
+	}
+	fc.apply(moduleName, module, min(next, time.Duration(float64(module.base)*fc.adaptive.MaxFactor)))
+}
+
+// apply sets the interval of a module, fc.mu must be held.
+func (fc *FreqController) apply(moduleName string, module *freqModule, interval time.Duration) {
+	if interval == module.cfg.GetQueryInterval().Duration {
+		module.adapted = interval
+		return
+	}
+	logrus.WithField("component", "FreqController").Infof("set the query interval of %s from %s to %s", moduleName, module.cfg.GetQueryInterval().Duration, interval)
+	module.cfg.SetQueryInterval(Duration{interval})
+	module.adapted = interval
+}
+
 // Global instance for the frequency controller.
 var (
 	freqController     *FreqController
@@ -209,7 +358,8 @@ var (
 func GetFreqController() *FreqController {
 	freqControllerOnce.Do(func() {
 		freqController = &FreqController{
-			modules: make(map[string]ComponentUserConfig),
+			modules:  make(map[string]*freqModule),
+			adaptive: DefaultAdaptiveIntervalConfig(),
 		}
 	})
 	return freqController
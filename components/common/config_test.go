@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/consts"
+)
+
+type fakeUserConfig struct {
+	interval Duration
+}
+
+func (c *fakeUserConfig) GetQueryInterval() Duration            { return c.interval }
+func (c *fakeUserConfig) SetQueryInterval(newInterval Duration) { c.interval = newInterval }
+
+func newTestFreqController() *FreqController {
+	return &FreqController{modules: make(map[string]*freqModule)}
+}
+
+func TestFreqController_Adaptive(t *testing.T) {
+	fc := newTestFreqController()
+	fc.SetAdaptive(AdaptiveIntervalConfig{Enable: true, HealthyChecks: 2, BackoffFactor: 2, MaxFactor: 4, FailureFactor: 0.5})
+	cfg := &fakeUserConfig{interval: Duration{10 * time.Second}}
+	fc.RegisterModule("cpu", cfg)
+
+	normal := &Result{Status: consts.StatusNormal}
+	abnormal := &Result{Status: consts.StatusAbnormal}
+	steps := []struct {
+		result *Result
+		want   time.Duration
+	}{
+		{normal, 10 * time.Second},
+		{normal, 20 * time.Second},
+		{normal, 20 * time.Second},
+		{normal, 40 * time.Second},
+		{normal, 40 * time.Second},
+		{normal, 40 * time.Second}, // bounded to MaxFactor
+		{abnormal, 5 * time.Second},
+		{normal, 5 * time.Second},
+		{normal, 10 * time.Second}, // back to the base interval
+	}
+	for i, step := range steps {
+		fc.Observe("cpu", step.result)
+		if cfg.interval.Duration != step.want {
+			t.Fatalf("step %d: interval %s, want %s", i, cfg.interval.Duration, step.want)
+		}
+	}
+
+	// an interval set by someone else pauses the adaptation
+	fc.SetModuleQueryInterval("cpu", Duration{time.Second})
+	fc.Observe("cpu", abnormal)
+	if cfg.interval.Duration != time.Second {
+		t.Errorf("overridden interval changed to %s", cfg.interval.Duration)
+	}
+}
+
+func TestFreqController_SetModuleBaseInterval(t *testing.T) {
+	fc := newTestFreqController()
+	cfg := &fakeUserConfig{interval: Duration{10 * time.Second}}
+	fc.RegisterModule("infiniband", cfg)
+
+	if err := fc.SetModuleBaseInterval("infiniband", 30*time.Second); err != nil {
+		t.Fatalf("SetModuleBaseInterval: %v", err)
+	}
+	interval, ok := fc.ModuleInterval("infiniband")
+	if !ok || interval.Interval != "30s" || interval.Base != "30s" || cfg.interval.Duration != 30*time.Second {
+		t.Errorf("unexpected interval %+v, config %s", interval, cfg.interval.Duration)
+	}
+	if err := fc.SetModuleBaseInterval("unknown", time.Second); err == nil {
+		t.Errorf("expected an error for an unregistered module")
+	}
+	if err := fc.SetModuleBaseInterval("infiniband", 0); err == nil {
+		t.Errorf("expected an error for a zero interval")
+	}
+	// disabled by default
+	fc.Observe("infiniband", &Result{Status: consts.StatusAbnormal})
+	if cfg.interval.Duration != 30*time.Second {
+		t.Errorf("interval adapted while disabled: %s", cfg.interval.Duration)
+	}
+}
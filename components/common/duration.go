@@ -0,0 +1,230 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+// CollectStepName is the checker name the duration of the collector of a
+// component is tracked under, e.g. pcie/collect.
+const CollectStepName = "collect"
+
+// ErrorNameDurationBudget is the error name of the warning raised for a
+// checker repeatedly running longer than its budget.
+const ErrorNameDurationBudget = "CheckerDurationBudgetExceeded"
+
+// DurationBudgetConfig sets how long the checkers may run. A checker running
+// longer than its budget in Consecutive runs in a row raises a warning, e.g.
+// a collector exec'ing lspci on a node whose PCIe config reads hang.
+type DurationBudgetConfig struct {
+	Enable bool `json:"enable" yaml:"enable"`
+	// Default is the budget of the checkers without their own, 0 for none.
+	Default Duration `json:"default" yaml:"default"`
+	// Consecutive is the count of runs in a row over budget before the warning.
+	Consecutive int `json:"consecutive" yaml:"consecutive"`
+	// Budgets are keyed by "<component>/<checker>" or "<checker>", the
+	// collector of a component is "<component>/collect".
+	Budgets map[string]Duration `json:"budgets,omitempty" yaml:"budgets,omitempty"`
+}
+
+func DefaultDurationBudgetConfig() DurationBudgetConfig {
+	return DurationBudgetConfig{
+		Enable:      false,
+		Consecutive: 3,
+	}
+}
+
+// Budget returns the budget of a checker of component, 0 if it has none.
+func (c DurationBudgetConfig) Budget(component, checker string) time.Duration {
+	if budget, ok := c.Budgets[component+"/"+checker]; ok {
+		return budget.Duration
+	}
+	if budget, ok := c.Budgets[checker]; ok {
+		return budget.Duration
+	}
+	return c.Default.Duration
+}
+
+// durationBuckets spread from the checkers reading sysfs to the ones waiting
+// on a hung command.
+var durationBuckets = []float64{0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+
+// budgetState is the budget tracking of a checker.
+type budgetState struct {
+	over int
+	// reported is set once the warning was raised, until the checker is back
+	// within its budget.
+	reported bool
+}
+
+// DurationTracker keeps the latency histogram of each checker, collector and
+// Timer step, exported as sichek_checker_duration_seconds and
+// sichek_step_duration_seconds, and raises the duration budget warnings.
+type DurationTracker struct {
+	mu     sync.Mutex
+	cfg    DurationBudgetConfig
+	states map[string]*budgetState
+	// pending are the results of the collectors, raised with the results of
+	// the next Check of their component.
+	pending map[string]*CheckerResult
+
+	node       string
+	checkerVec *prometheus.HistogramVec
+	stepVec    *prometheus.HistogramVec
+}
+
+func newDurationTracker(registerer prometheus.Registerer) *DurationTracker {
+	node, err := os.Hostname()
+	if err != nil {
+		node = "unknown"
+	}
+	t := &DurationTracker{
+		cfg:     DefaultDurationBudgetConfig(),
+		states:  make(map[string]*budgetState),
+		pending: make(map[string]*CheckerResult),
+		node:    node,
+		checkerVec: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "sichek_checker_duration_seconds",
+			Help:    "Duration of the sichek checkers and collectors",
+			Buckets: durationBuckets,
+		}, []string{"component", "checker", "node"}),
+		stepVec: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "sichek_step_duration_seconds",
+			Help:    "Duration of the steps marked by the sichek timers",
+			Buckets: durationBuckets,
+		}, []string{"timer", "step", "node"}),
+	}
+	for _, collector := range []prometheus.Collector{t.checkerVec, t.stepVec} {
+		if err := registerer.Register(collector); err != nil {
+			logrus.WithField("component", "DurationTracker").Warnf("register duration histogram failed: %v", err)
+		}
+	}
+	return t
+}
+
+// Global instance for the duration tracker.
+var (
+	durationTracker     *DurationTracker
+	durationTrackerOnce sync.Once
+)
+
+// GetDurationTracker creates and returns a singleton instance of DurationTracker.
+func GetDurationTracker() *DurationTracker {
+	durationTrackerOnce.Do(func() {
+		durationTracker = newDurationTracker(prometheus.DefaultRegisterer)
+	})
+	return durationTracker
+}
+
+// SetConfig configures the duration budgets, the runs already over budget are forgotten.
+func (t *DurationTracker) SetConfig(cfg DurationBudgetConfig) {
+	if cfg.Consecutive <= 0 {
+		cfg.Consecutive = DefaultDurationBudgetConfig().Consecutive
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cfg = cfg
+	t.states = make(map[string]*budgetState)
+	t.pending = make(map[string]*CheckerResult)
+}
+
+// ObserveStep records the duration of a step of a Timer.
+func (t *DurationTracker) ObserveStep(timer, step string, elapsed time.Duration) {
+	t.stepVec.WithLabelValues(timer, step, t.node).Observe(elapsed.Seconds())
+}
+
+// Observe records the duration of a checker of component and compares it
+// with its budget. It returns the warning once the checker ran over budget
+// in Consecutive runs in a row, a normal result once it is back within its
+// budget after the warning, nil otherwise.
+func (t *DurationTracker) Observe(component, checker string, elapsed time.Duration) *CheckerResult {
+	t.checkerVec.WithLabelValues(component, checker, t.node).Observe(elapsed.Seconds())
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.cfg.Enable {
+		return nil
+	}
+	budget := t.cfg.Budget(component, checker)
+	if budget <= 0 {
+		return nil
+	}
+	key := component + "/" + checker
+	state, ok := t.states[key]
+	if !ok {
+		state = &budgetState{}
+		t.states[key] = state
+	}
+	if elapsed <= budget {
+		state.over = 0
+		if state.reported {
+			state.reported = false
+			return durationBudgetResult(component, checker, consts.StatusNormal,
+				fmt.Sprintf("%s/%s took %s, back within its budget of %s", component, checker, elapsed.Round(time.Millisecond), budget))
+		}
+		return nil
+	}
+	state.over++
+	if state.over < t.cfg.Consecutive {
+		return nil
+	}
+	state.reported = true
+	logrus.WithField("component", component).Warnf("checker %s ran over its budget of %s in %d consecutive runs, last run took %s", checker, budget, state.over, elapsed)
+	return durationBudgetResult(component, checker, consts.StatusAbnormal,
+		fmt.Sprintf("%s/%s took %s, over its budget of %s in %d consecutive runs", component, checker, elapsed.Round(time.Millisecond), budget, state.over))
+}
+
+// ObserveCollect records the duration of the collector of component, its
+// budget result is raised by the next Check of the component.
+func (t *DurationTracker) ObserveCollect(component string, elapsed time.Duration) {
+	result := t.Observe(component, CollectStepName, elapsed)
+	if result == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pending[component] = result
+}
+
+// TakePending returns and forgets the collector result of component, nil if there is none.
+func (t *DurationTracker) TakePending(component string) *CheckerResult {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	result := t.pending[component]
+	delete(t.pending, component)
+	return result
+}
+
+func durationBudgetResult(component, checker, status, detail string) *CheckerResult {
+	return &CheckerResult{
+		Name:        checker + "-duration-budget",
+		Description: fmt.Sprintf("Check if the %s checker of %s runs within its duration budget", checker, component),
+		Device:      component + "/" + checker,
+		Status:      status,
+		Level:       consts.LevelWarning,
+		Detail:      detail,
+		ErrorName:   ErrorNameDurationBudget,
+		Suggestion:  "Check the load of the node and the commands the checker runs, e.g. a hung lspci or nvidia-smi, with the sichek_checker_duration_seconds histogram",
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/scitix/sichek/consts"
+)
+
+func TestDurationBudgetLookup(t *testing.T) {
+	cfg := DurationBudgetConfig{
+		Default: Duration{time.Second},
+		Budgets: map[string]Duration{
+			"pcie/collect": {10 * time.Second},
+			"collect":      {5 * time.Second},
+		},
+	}
+	cases := []struct {
+		component, checker string
+		want               time.Duration
+	}{
+		{"pcie", "collect", 10 * time.Second},
+		{"nvidia", "collect", 5 * time.Second},
+		{"nvidia", "GPUPersistenceMode", time.Second},
+	}
+	for _, c := range cases {
+		if got := cfg.Budget(c.component, c.checker); got != c.want {
+			t.Errorf("Budget(%s, %s) = %s, want %s", c.component, c.checker, got, c.want)
+		}
+	}
+}
+
+func TestDurationTrackerObserve(t *testing.T) {
+	tracker := newDurationTracker(prometheus.NewRegistry())
+	tracker.SetConfig(DurationBudgetConfig{
+		Enable:      true,
+		Consecutive: 3,
+		Budgets:     map[string]Duration{"pcie/lspci": {time.Second}},
+	})
+
+	if r := tracker.Observe("pcie", "lspci", 5*time.Second); r != nil {
+		t.Fatalf("expected no result after one slow run, got %+v", r)
+	}
+	if r := tracker.Observe("pcie", "lspci", 100*time.Millisecond); r != nil {
+		t.Fatalf("expected no result within budget, got %+v", r)
+	}
+	for i := 0; i < 2; i++ {
+		if r := tracker.Observe("pcie", "lspci", 5*time.Second); r != nil {
+			t.Fatalf("run %d: the fast run must reset the count, got %+v", i, r)
+		}
+	}
+	r := tracker.Observe("pcie", "lspci", 5*time.Second)
+	if r == nil || r.Status != consts.StatusAbnormal || r.Level != consts.LevelWarning || r.ErrorName != ErrorNameDurationBudget {
+		t.Fatalf("expected a warning after 3 slow runs, got %+v", r)
+	}
+	if r.Name != "lspci-duration-budget" || r.Device != "pcie/lspci" {
+		t.Errorf("unexpected result name or device: %+v", r)
+	}
+	if r := tracker.Observe("pcie", "lspci", 100*time.Millisecond); r == nil || r.Status != consts.StatusNormal {
+		t.Fatalf("expected a normal result once back within budget, got %+v", r)
+	}
+	if r := tracker.Observe("pcie", "lspci", 100*time.Millisecond); r != nil {
+		t.Fatalf("expected no result after recovery, got %+v", r)
+	}
+	if r := tracker.Observe("pcie", "other", time.Hour); r != nil {
+		t.Fatalf("a checker without budget must not be reported, got %+v", r)
+	}
+}
+
+func TestDurationTrackerCollect(t *testing.T) {
+	tracker := newDurationTracker(prometheus.NewRegistry())
+	tracker.SetConfig(DurationBudgetConfig{Enable: true, Consecutive: 1, Default: Duration{time.Second}})
+
+	tracker.ObserveCollect("infiniband", 2*time.Second)
+	r := tracker.TakePending("infiniband")
+	if r == nil || r.Device != "infiniband/"+CollectStepName || r.Status != consts.StatusAbnormal {
+		t.Fatalf("expected the collector warning, got %+v", r)
+	}
+	if r := tracker.TakePending("infiniband"); r != nil {
+		t.Fatalf("expected the pending result to be taken once, got %+v", r)
+	}
+
+	tracker.SetConfig(DurationBudgetConfig{Enable: false, Default: Duration{time.Second}})
+	if r := tracker.Observe("infiniband", "port", time.Minute); r != nil {
+		t.Fatalf("expected no result with budgets disabled, got %+v", r)
+	}
+}
@@ -17,6 +17,7 @@ package eventfilter
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
@@ -148,6 +149,9 @@ func (f *EventFilter) matchLineAndUpdateResult(fileName string, line string, res
 		if entry.Regex.MatchOneLine(line) {
 			rule := entry.Rule
 			name := rule.Name
+			if fields := entry.Regex.ExtractFields(line); fields != "" {
+				line = fmt.Sprintf("%s [%s]", line, fields)
+			}
 			res, exists := resultMap[name]
 			if !exists {
 				resultMap[name] = &common.CheckerResult{
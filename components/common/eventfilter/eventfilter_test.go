@@ -128,3 +128,17 @@ func TestEventFilter(t *testing.T) {
 		})
 	}
 }
+
+func TestRegexFilterExtractFields(t *testing.T) {
+	regex := NewRegexFilter("NCCLNetIBCompletion", `NET/IB: Got completion from peer (?P<peer>\S+) with status=(?P<status>\d+)`)
+	if err := regex.Compile(); err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	line := "node1:123 NCCL WARN NET/IB: Got completion from peer 10.0.0.2<4567> with status=12 opcode=0"
+	if got, want := regex.ExtractFields(line), "peer=10.0.0.2<4567> status=12"; got != want {
+		t.Errorf("ExtractFields=%q, want %q", got, want)
+	}
+	if got := regex.ExtractFields("unrelated"); got != "" {
+		t.Errorf("expected no fields without a match, got %q", got)
+	}
+}
@@ -16,7 +16,9 @@ limitations under the License.
 package eventfilter
 
 import (
+	"fmt"
 	"regexp"
+	"strings"
 	"sync"
 
 	"github.com/sirupsen/logrus"
@@ -80,3 +82,25 @@ func (f *RegexFilter) MatchBytes(line []byte) bool {
 	}
 	return false
 }
+
+// ExtractFields returns the named groups of the regex matched in line, e.g.
+// "peer=10.0.0.2 status=12" for `from peer (?P<peer>\S+) with status=(?P<status>\d+)`.
+// It returns "" if the regex has no named group or does not match.
+func (f *RegexFilter) ExtractFields(line string) string {
+	if f.RegexObj == nil {
+		return ""
+	}
+	names := f.RegexObj.SubexpNames()
+	match := f.RegexObj.FindStringSubmatch(line)
+	if match == nil {
+		return ""
+	}
+	var fields []string
+	for i, name := range names {
+		if i == 0 || name == "" {
+			continue
+		}
+		fields = append(fields, fmt.Sprintf("%s=%s", name, match[i]))
+	}
+	return strings.Join(fields, " ")
+}
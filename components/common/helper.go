@@ -20,12 +20,25 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/consts/errdef"
+	"github.com/scitix/sichek/pkg/telemetry"
 	"github.com/sirupsen/logrus"
 )
 
+// healthCheckLocks serializes the HealthCheck of each component, keyed by
+// component name, e.g. an on-demand check of the daemon API and the periodic
+// check both update the cache and the last result of the component.
+var healthCheckLocks sync.Map
+
+func healthCheckLock(componentName string) *sync.Mutex {
+	lock, _ := healthCheckLocks.LoadOrStore(componentName, &sync.Mutex{})
+	return lock.(*sync.Mutex)
+}
+
 // RunHealthCheckWithContext wraps the HealthCheck call and ensures it respects the provided context timeout or cancellation
 func RunHealthCheckWithTimeout(ctx context.Context, timeout time.Duration, componentName string, fn func(ctx context.Context) (*Result, error)) (*Result, error) {
 	timer := NewTimer(fmt.Sprintf("%s-HealthCheck-Cost", componentName))
@@ -33,8 +46,9 @@ func RunHealthCheckWithTimeout(ctx context.Context, timeout time.Duration, compo
 	ctx, cancel := context.WithTimeout(ctx, timeout) // Use the timeout context
 	defer cancel()
 	// Create channels for result and error
-	resultChan := make(chan *Result) // Channel for result
-	errorChan := make(chan error)    // Channel for error
+	// Buffered so that a check finishing after the timeout does not block forever
+	resultChan := make(chan *Result, 1) // Channel for result
+	errorChan := make(chan error, 1)    // Channel for error
 
 	// Run the function in a goroutine; recover from panic so the process does not crash
 	// and a panic result can be returned and exported as an anomaly metric.
@@ -46,7 +60,20 @@ func RunHealthCheckWithTimeout(ctx context.Context, timeout time.Duration, compo
 				resultChan <- createPanicResult(componentName, r)
 			}
 		}()
-		res, err := fn(ctx)
+		res, err := func() (*Result, error) {
+			// wait for a running check of the same component, and give up
+			// if the caller timed out meanwhile
+			lock := healthCheckLock(componentName)
+			lock.Lock()
+			defer lock.Unlock()
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			ctx, span := telemetry.StartHealthCheck(ctx, componentName)
+			res, err := fn(ctx)
+			span.End(resultStatus(res), err)
+			return res, err
+		}()
 		if err != nil {
 			errorChan <- err // Send error to the error channel
 		} else {
@@ -76,6 +103,8 @@ func createTimeoutResult(componentName string, timeout time.Duration) (*Result,
 		Suggestion:  fmt.Sprintf("Please check the %s status", componentName),
 	}
 
+	timeoutCheckerResult.ErrorCode = errdef.Code(timeoutCheckerResult.ErrorName)
+
 	timeoutResult := &Result{
 		Item:     componentName,
 		Status:   consts.StatusAbnormal,
@@ -101,6 +130,7 @@ func createPanicResult(componentName string, panicValue interface{}) *Result {
 		ErrorName:   panicName,
 		Suggestion:  fmt.Sprintf("Please check the %s status (e.g. GPU/NVML stability)", componentName),
 	}
+	panicCheckerResult.ErrorCode = errdef.Code(panicName)
 	return &Result{
 		Item:     componentName,
 		Status:   consts.StatusAbnormal,
@@ -124,6 +154,11 @@ func handleResult(result *Result, componentName string) (*Result, error) {
 		return nil, nil
 	}
 	result.Checkers = append(result.Checkers, timeoutResolvedResult)
+	for _, checker := range result.Checkers {
+		if checker != nil && checker.ErrorCode == "" {
+			checker.ErrorCode = errdef.Code(checker.ErrorName)
+		}
+	}
 	return result, nil
 }
 
@@ -133,6 +168,23 @@ type Timer struct {
 	name      string
 }
 
+// Collect runs the collect of a component in a telemetry span.
+func Collect[T any](ctx context.Context, componentName string, collect func(ctx context.Context) (T, error)) (T, error) {
+	ctx, span := telemetry.StartCollect(ctx, componentName)
+	start := time.Now()
+	info, err := collect(ctx)
+	GetDurationTracker().ObserveCollect(componentName, time.Since(start))
+	span.End("", err)
+	return info, err
+}
+
+func resultStatus(result *Result) string {
+	if result == nil {
+		return ""
+	}
+	return result.Status
+}
+
 func NewTimer(name string) *Timer {
 	return &Timer{
 		start:     time.Now(),
@@ -141,8 +193,11 @@ func NewTimer(name string) *Timer {
 	}
 }
 
+// Mark logs the time since the previous step and records it in the
+// sichek_step_duration_seconds histogram of the timer.
 func (t *Timer) Mark(step string) {
 	elapsed := time.Since(t.stepStart)
+	GetDurationTracker().ObserveStep(t.name, step, elapsed)
 	logrus.WithFields(logrus.Fields{
 		"func": t.name,
 		"step": step,
@@ -0,0 +1,73 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/consts"
+)
+
+func TestRunHealthCheckWithTimeout_SerializesComponent(t *testing.T) {
+	var running, overlaps int32
+	check := func(ctx context.Context) (*Result, error) {
+		if atomic.AddInt32(&running, 1) > 1 {
+			atomic.AddInt32(&overlaps, 1)
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return &Result{Item: "serialized", Status: consts.StatusNormal}, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := RunHealthCheckWithTimeout(context.Background(), time.Second, "serialized", check); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if overlaps != 0 {
+		t.Fatalf("expected health checks of one component not to overlap, got %d overlaps", overlaps)
+	}
+}
+
+func TestRunHealthCheckWithTimeout_ErrorCodes(t *testing.T) {
+	check := func(ctx context.Context) (*Result, error) {
+		return &Result{Item: "codes", Checkers: []*CheckerResult{
+			{Name: "gpu-lost", ErrorName: "GPULost"},
+			{Name: "plugin", ErrorName: "my_plugin_check"},
+		}}, nil
+	}
+	result, err := RunHealthCheckWithTimeout(context.Background(), time.Second, "codes", check)
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes := make(map[string]string)
+	for _, checker := range result.Checkers {
+		codes[checker.ErrorName] = checker.ErrorCode
+	}
+	if codes["GPULost"] != "GPU-0001" || codes["my_plugin_check"] != "" || codes["codesHealthCheckTimeout"] != "SCK-0002" {
+		t.Errorf("unexpected error codes %v", codes)
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/scitix/sichek/consts"
+)
+
+// LifecycleAction is the step an operator or automation should take on a
+// node or device to recover from what a checker found.
+type LifecycleAction string
+
+const (
+	LifecycleActionNone LifecycleAction = "none"
+	// LifecycleActionResetGPU clears the state of the GPUs, e.g. applies a
+	// pending row remap, without rebooting the node.
+	LifecycleActionResetGPU LifecycleAction = "reset-gpu"
+	// LifecycleActionDrainReboot drains the workloads of the node and reboots it.
+	LifecycleActionDrainReboot LifecycleAction = "drain-reboot"
+	// LifecycleActionRMA drains the node and replaces the device.
+	LifecycleActionRMA LifecycleAction = "rma"
+)
+
+// lifecycleActionPriority orders the actions so that the most disruptive
+// recommendation of a result wins.
+var lifecycleActionPriority = map[LifecycleAction]int{
+	LifecycleActionNone:        0,
+	LifecycleActionResetGPU:    1,
+	LifecycleActionDrainReboot: 2,
+	LifecycleActionRMA:         3,
+}
+
+// LifecycleRule maps the abnormal result of a checker to an action.
+type LifecycleRule struct {
+	// Checker is the checker name, e.g. "remmaped-rows-pending" or "xid-79".
+	Checker string          `json:"checker" yaml:"checker"`
+	Action  LifecycleAction `json:"action" yaml:"action"`
+}
+
+// LifecycleAdvice is the action recommended for a result, with the checkers
+// and devices that call for it. It is machine-readable so that automation
+// can act on it.
+type LifecycleAdvice struct {
+	Action   LifecycleAction `json:"action"`
+	Checkers []string        `json:"checkers"`
+	Devices  []*DeviceResult `json:"devices,omitempty"`
+}
+
+// LifecycleAdvisor recommends lifecycle actions from a rules table.
+type LifecycleAdvisor struct {
+	rules map[string]LifecycleAction
+}
+
+// NewLifecycleAdvisor builds an advisor from defaults overridden by
+// overrides, a rule with action "none" disables the default of its checker.
+func NewLifecycleAdvisor(defaults, overrides []LifecycleRule) (*LifecycleAdvisor, error) {
+	a := &LifecycleAdvisor{rules: make(map[string]LifecycleAction, len(defaults)+len(overrides))}
+	for _, rule := range append(append([]LifecycleRule{}, defaults...), overrides...) {
+		if _, ok := lifecycleActionPriority[rule.Action]; !ok {
+			return nil, fmt.Errorf("invalid lifecycle action %q for checker %s", rule.Action, rule.Checker)
+		}
+		a.rules[rule.Checker] = rule.Action
+	}
+	return a, nil
+}
+
+// Advise sets the action of every abnormal checker of result that has a
+// rule, and aggregates them into the action of the result.
+func (a *LifecycleAdvisor) Advise(result *Result) {
+	if a == nil || result == nil {
+		return
+	}
+	for _, checker := range result.Checkers {
+		if checker == nil || checker.Status != consts.StatusAbnormal {
+			continue
+		}
+		if action, ok := a.rules[checker.Name]; ok && action != LifecycleActionNone {
+			checker.Action = action
+		}
+	}
+	result.Action = AggregateLifecycle(result)
+}
+
+// AggregateLifecycle returns the most disruptive action of the abnormal
+// checkers of result, with the checkers and devices that recommend it, or
+// nil when no checker recommends one. It is recomputed whenever the status
+// of the checkers changes, e.g. when some of them are silenced.
+func AggregateLifecycle(result *Result) *LifecycleAdvice {
+	if result == nil {
+		return nil
+	}
+	var advice *LifecycleAdvice
+	seen := make(map[int]bool)
+	for _, checker := range result.Checkers {
+		if checker == nil || checker.Status != consts.StatusAbnormal || checker.Action == "" || checker.Action == LifecycleActionNone {
+			continue
+		}
+		if advice == nil || lifecycleActionPriority[checker.Action] > lifecycleActionPriority[advice.Action] {
+			advice = &LifecycleAdvice{Action: checker.Action}
+			seen = make(map[int]bool)
+		} else if checker.Action != advice.Action {
+			continue
+		}
+		advice.Checkers = append(advice.Checkers, checker.Name)
+		for _, device := range checker.Devices {
+			if !seen[device.Index] {
+				seen[device.Index] = true
+				advice.Devices = append(advice.Devices, device)
+			}
+		}
+	}
+	if advice != nil {
+		sort.Slice(advice.Devices, func(i, j int) bool { return advice.Devices[i].Index < advice.Devices[j].Index })
+	}
+	return advice
+}
+
+// MoreDisruptive reports whether action a calls for more than action b.
+func (a LifecycleAction) MoreDisruptive(b LifecycleAction) bool {
+	return lifecycleActionPriority[a] > lifecycleActionPriority[b]
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/scitix/sichek/consts"
+)
+
+func lifecycleTestResult() *Result {
+	return &Result{
+		Item:   "nvidia",
+		Status: consts.StatusAbnormal,
+		Level:  consts.LevelCritical,
+		Checkers: []*CheckerResult{
+			{Name: "remmaped-rows-pending", Status: consts.StatusAbnormal, Devices: []*DeviceResult{{Index: 3}, {Index: 1}}},
+			{Name: "xid-48", Status: consts.StatusAbnormal, Devices: []*DeviceResult{{Index: 3}}},
+			{Name: "remmaped-rows-failure", Status: consts.StatusNormal},
+			{Name: "temperature", Status: consts.StatusAbnormal},
+		},
+	}
+}
+
+var lifecycleTestRules = []LifecycleRule{
+	{Checker: "remmaped-rows-pending", Action: LifecycleActionResetGPU},
+	{Checker: "remmaped-rows-failure", Action: LifecycleActionRMA},
+	{Checker: "xid-48", Action: LifecycleActionResetGPU},
+	{Checker: "xid-79", Action: LifecycleActionDrainReboot},
+}
+
+func TestLifecycleAdvisor(t *testing.T) {
+	advisor, err := NewLifecycleAdvisor(lifecycleTestRules, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result := lifecycleTestResult()
+	advisor.Advise(result)
+
+	if result.Action == nil || result.Action.Action != LifecycleActionResetGPU {
+		t.Fatalf("expected reset-gpu, got %+v", result.Action)
+	}
+	if got := strings.Join(result.Action.Checkers, ","); got != "remmaped-rows-pending,xid-48" {
+		t.Errorf("unexpected checkers: %s", got)
+	}
+	if len(result.Action.Devices) != 2 || result.Action.Devices[0].Index != 1 || result.Action.Devices[1].Index != 3 {
+		t.Errorf("expected devices 1,3 deduplicated and sorted, got %+v", result.Action.Devices)
+	}
+	if result.Checkers[2].Action != "" || result.Checkers[3].Action != "" {
+		t.Errorf("normal checkers and checkers without a rule must have no action")
+	}
+
+	// a more disruptive action replaces the checkers and devices of the advice
+	result.Checkers = append(result.Checkers, &CheckerResult{Name: "xid-79", Status: consts.StatusAbnormal, Devices: []*DeviceResult{{Index: 5}}})
+	advisor.Advise(result)
+	if result.Action.Action != LifecycleActionDrainReboot || len(result.Action.Devices) != 1 || result.Action.Devices[0].Index != 5 {
+		t.Errorf("expected drain-reboot of GPU 5, got %+v", result.Action)
+	}
+}
+
+func TestLifecycleAdvisorOverrides(t *testing.T) {
+	advisor, err := NewLifecycleAdvisor(lifecycleTestRules, []LifecycleRule{
+		{Checker: "remmaped-rows-pending", Action: LifecycleActionNone},
+		{Checker: "xid-48", Action: LifecycleActionRMA},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result := lifecycleTestResult()
+	advisor.Advise(result)
+	if result.Action == nil || result.Action.Action != LifecycleActionRMA || strings.Join(result.Action.Checkers, ",") != "xid-48" {
+		t.Errorf("expected rma from xid-48 only, got %+v", result.Action)
+	}
+
+	if _, err := NewLifecycleAdvisor(nil, []LifecycleRule{{Checker: "xid-48", Action: "replace"}}); err == nil {
+		t.Error("expected error for an unknown action")
+	}
+}
+
+func TestAggregateLifecycleNoAction(t *testing.T) {
+	result := lifecycleTestResult()
+	if advice := AggregateLifecycle(result); advice != nil {
+		t.Errorf("expected no advice without rules, got %+v", advice)
+	}
+	var advisor *LifecycleAdvisor
+	advisor.Advise(result)
+	if result.Action != nil {
+		t.Errorf("nil advisor must leave the action unset")
+	}
+}
+
+func TestReportLifecycleAction(t *testing.T) {
+	report := NewReport("node-1")
+	report.AddResult(&Result{Item: "nvidia", Status: consts.StatusAbnormal, Action: &LifecycleAdvice{Action: LifecycleActionRMA}}, nil)
+	report.AddResult(&Result{Item: "gpuevents", Status: consts.StatusAbnormal, Action: &LifecycleAdvice{Action: LifecycleActionResetGPU}}, nil)
+	if report.Action != LifecycleActionRMA {
+		t.Errorf("expected node action rma, got %q", report.Action)
+	}
+	if report.Components[1].Action == nil || report.Components[1].Action.Action != LifecycleActionResetGPU {
+		t.Errorf("expected component action reset-gpu, got %+v", report.Components[1].Action)
+	}
+}
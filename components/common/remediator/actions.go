@@ -0,0 +1,157 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package remediator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/scitix/sichek/pkg/systemd"
+	"github.com/scitix/sichek/pkg/utils"
+)
+
+const (
+	ActionSetPCIeMRR     = "set-pcie-mrr"
+	ActionModprobe       = "modprobe"
+	ActionRestartService = "restart-service"
+	ActionDisableACS     = "disable-acs"
+	ActionGPUPersistence = "enable-gpu-persistence"
+	ActionSetCPUGovernor = "set-cpu-governor"
+	ActionGPUReset       = "gpu-reset"
+)
+
+// NewSetPCIeMRRAction sets the PCIe Max Read Request size of the device at bdf
+// to size bytes (128 to 4096) through its Device Control register.
+func NewSetPCIeMRRAction(bdf string, size string) (*common.RemediationAction, error) {
+	encoding, err := mrrEncoding(size)
+	if err != nil {
+		return nil, err
+	}
+	return &common.RemediationAction{
+		Name:        ActionSetPCIeMRR,
+		Target:      bdf,
+		Description: fmt.Sprintf("set the PCIe MaxReadReq of %s to %s bytes", bdf, size),
+		Apply: func(ctx context.Context) error {
+			return utils.ModifyPCIeMaxReadRequest(ctx, bdf, "", encoding)
+		},
+	}, nil
+}
+
+// NewModprobeAction loads the kernel module.
+func NewModprobeAction(module string) *common.RemediationAction {
+	return &common.RemediationAction{
+		Name:        ActionModprobe,
+		Target:      module,
+		Description: fmt.Sprintf("load the kernel module %s with modprobe", module),
+		Apply: func(ctx context.Context) error {
+			_, err := utils.ExecCommand(ctx, "modprobe", module)
+			return err
+		},
+	}
+}
+
+// NewRestartServiceAction restarts the systemd unit.
+func NewRestartServiceAction(unit string) *common.RemediationAction {
+	return &common.RemediationAction{
+		Name:        ActionRestartService,
+		Target:      unit,
+		Description: fmt.Sprintf("restart the systemd service %s", unit),
+		Apply: func(ctx context.Context) error {
+			return systemd.RestartSystemdService(unit)
+		},
+	}
+}
+
+// NewDisableACSAction clears the ACS control register of the PCIe device at bdf.
+func NewDisableACSAction(bdf string) *common.RemediationAction {
+	return &common.RemediationAction{
+		Name:        ActionDisableACS,
+		Target:      bdf,
+		Description: fmt.Sprintf("disable the PCIe ACS of %s", bdf),
+		Apply: func(ctx context.Context) error {
+			return utils.DisableACS(ctx, bdf)
+		},
+	}
+}
+
+// mrrEncoding returns the Max_Read_Request_Size field of a size in bytes,
+// the field encodes 128 << n.
+func mrrEncoding(size string) (int, error) {
+	bytes, err := strconv.Atoi(size)
+	if err != nil {
+		return 0, fmt.Errorf("invalid PCIe MaxReadReq %q: %w", size, err)
+	}
+	for n := 0; n <= 5; n++ {
+		if 128<<n == bytes {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid PCIe MaxReadReq %d, expected a power of two from 128 to 4096", bytes)
+}
+
+// NewGPUPersistenceAction starts nvidia-persistenced, which enables the
+// persistence mode of every GPU.
+func NewGPUPersistenceAction() *common.RemediationAction {
+	return &common.RemediationAction{
+		Name:        ActionGPUPersistence,
+		Target:      "nvidia-persistenced",
+		Description: "start nvidia-persistenced to enable the GPU persistence mode",
+		Apply: func(ctx context.Context) error {
+			_, err := utils.ExecCommand(ctx, "nvidia-persistenced")
+			return err
+		},
+	}
+}
+
+// cpuGovernorGlob matches the cpufreq governor of every logical CPU of the host.
+const cpuGovernorGlob = "/sys/devices/system/cpu/cpu*/cpufreq/scaling_governor"
+
+// NewSetCPUGovernorAction sets the cpufreq governor of every CPU, e.g. to performance.
+func NewSetCPUGovernorAction(governor string) *common.RemediationAction {
+	return &common.RemediationAction{
+		Name:        ActionSetCPUGovernor,
+		Target:      governor,
+		Description: fmt.Sprintf("set the cpufreq governor of all CPUs to %s", governor),
+		Apply: func(ctx context.Context) error {
+			return setCPUGovernor(hostfs.Path(cpuGovernorGlob), governor)
+		},
+	}
+}
+
+func setCPUGovernor(pattern, governor string) error {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return fmt.Errorf("failed to list CPU governor files: %w", err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no CPU governor files found")
+	}
+	var failed []string
+	for _, file := range files {
+		if err := os.WriteFile(file, []byte(governor), 0644); err != nil {
+			failed = append(failed, file)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to set governor %s on %d CPUs, e.g. %s", governor, len(failed), failed[0])
+	}
+	return nil
+}
@@ -0,0 +1,330 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package remediator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	AuditStatusApplied = "applied"
+	AuditStatusFailed  = "failed"
+	AuditStatusDryRun  = "dry-run"
+)
+
+// Config controls whether the remediation actions of the checkers are applied.
+type Config struct {
+	Enable bool `json:"enable" yaml:"enable"`
+	// DryRun prints and audits the actions that would be applied without applying them.
+	DryRun bool `json:"dry_run" yaml:"dry_run"`
+	// AuditLog is the JSON lines file recording every action applied or planned.
+	AuditLog string `json:"audit_log" yaml:"audit_log"`
+	// Actions restricts the action names that may be applied, empty allows all.
+	Actions []string `json:"actions,omitempty" yaml:"actions,omitempty"`
+	// Backoff is the minimal interval between two runs of the same action while
+	// the checker stays abnormal. An action runs again right away once its
+	// checker recovered and failed again.
+	Backoff time.Duration `json:"backoff,omitempty" yaml:"backoff,omitempty"`
+}
+
+type remediationFile struct {
+	Remediation Config `json:"remediation" yaml:"remediation"`
+}
+
+// DefaultBackoff keeps a persistent fault from restarting a service or
+// reloading a module on every check interval of the daemon.
+const DefaultBackoff = 30 * time.Minute
+
+func defaultConfig() Config {
+	return Config{
+		AuditLog: filepath.Join(consts.DefaultLogDir, "remediation-audit.log"),
+		Backoff:  DefaultBackoff,
+	}
+}
+
+// LoadConfig parses the remediation block from cfgFile.
+// If cfgFile is "" or missing, returns defaults, i.e. remediation disabled.
+func LoadConfig(cfgFile string) (Config, error) {
+	cfg := defaultConfig()
+	if cfgFile == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(cfgFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return Config{}, fmt.Errorf("load remediation config: %w", err)
+	}
+	f := remediationFile{Remediation: cfg}
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return Config{}, fmt.Errorf("load remediation config: %w", err)
+	}
+	if f.Remediation.AuditLog == "" {
+		f.Remediation.AuditLog = cfg.AuditLog
+	}
+	if f.Remediation.Backoff <= 0 {
+		f.Remediation.Backoff = cfg.Backoff
+	}
+	return f.Remediation, nil
+}
+
+// AuditRecord is a line of the audit log.
+type AuditRecord struct {
+	Time        time.Time `json:"time"`
+	Component   string    `json:"component"`
+	Checker     string    `json:"checker"`
+	Action      string    `json:"action"`
+	Target      string    `json:"target"`
+	Description string    `json:"description"`
+	Status      string    `json:"status"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// Remediator applies the remediation actions of the abnormal checkers.
+type Remediator struct {
+	cfg     Config
+	auditMu sync.Mutex
+
+	// lastRun is when each action last ran, keyed by actionKey, until its
+	// checker is seen normal again
+	lastRunMu sync.Mutex
+	lastRun   map[string]time.Time
+}
+
+func New(cfg Config) *Remediator {
+	if cfg.AuditLog == "" {
+		cfg.AuditLog = defaultConfig().AuditLog
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = DefaultBackoff
+	}
+	return &Remediator{cfg: cfg, lastRun: make(map[string]time.Time)}
+}
+
+// Config returns the configuration of the remediator.
+func (r *Remediator) Config() Config {
+	return r.cfg
+}
+
+var (
+	defaultMu         sync.RWMutex
+	defaultRemediator = New(defaultConfig())
+)
+
+// Default returns the remediator of the process, disabled unless SetDefault
+// was called from the --auto-fix flag or the remediation config.
+func Default() *Remediator {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultRemediator
+}
+
+func SetDefault(r *Remediator) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultRemediator = r
+}
+
+// Remediate runs the remediation actions of the abnormal checkers of result.
+// When auto-fix is disabled the actions are only logged. An action runs once
+// when its checker turns abnormal and at most once per Backoff while it stays
+// abnormal.
+//
+// result is left untouched as it is the cached result of the component. The
+// returned copy reports a checker whose actions all succeeded as remediated and
+// no longer abnormal, the next check confirms the fix.
+func (r *Remediator) Remediate(ctx context.Context, result *common.Result) (*common.Result, []*AuditRecord) {
+	if result == nil {
+		return nil, nil
+	}
+	remediatedResult := copyResult(result)
+	var records []*AuditRecord
+	remediated := false
+	for _, checker := range remediatedResult.Checkers {
+		if checker == nil {
+			continue
+		}
+		if checker.Status != consts.StatusAbnormal {
+			r.forget(result.Item, checker.Name)
+			continue
+		}
+		if len(checker.Remediations) == 0 {
+			continue
+		}
+		applied := 0
+		for _, action := range checker.Remediations {
+			if !r.cfg.Enable && !r.cfg.DryRun {
+				logrus.WithField("component", result.Item).Infof("remediation available for %s: %s, run with --auto-fix to apply it", checker.Name, action.Description)
+				continue
+			}
+			if len(r.cfg.Actions) > 0 && !slices.Contains(r.cfg.Actions, action.Name) {
+				logrus.WithField("component", result.Item).Infof("remediation %s of %s is not allowed by the config, skipping", action.Name, checker.Name)
+				continue
+			}
+			key := actionKey(result.Item, checker.Name, action)
+			if last, ok := r.lastRunAt(key); ok && time.Since(last) < r.cfg.Backoff {
+				logrus.WithField("component", result.Item).Debugf("remediation %s of %s already ran at %s, backing off", action.Name, action.Target, last.Format(time.RFC3339))
+				continue
+			}
+			r.markRun(key)
+			record := &AuditRecord{
+				Time:        time.Now(),
+				Component:   result.Item,
+				Checker:     checker.Name,
+				Action:      action.Name,
+				Target:      action.Target,
+				Description: action.Description,
+			}
+			switch {
+			case r.cfg.DryRun:
+				record.Status = AuditStatusDryRun
+				logrus.WithField("component", result.Item).Infof("[dry-run] %s: would %s", checker.Name, action.Description)
+			case action.Apply == nil:
+				record.Status = AuditStatusFailed
+				record.Error = "action has no apply function"
+			default:
+				if err := action.Apply(ctx); err != nil {
+					record.Status = AuditStatusFailed
+					record.Error = err.Error()
+					logrus.WithField("component", result.Item).Errorf("remediation %s of %s failed: %v", action.Name, action.Target, err)
+				} else {
+					record.Status = AuditStatusApplied
+					applied++
+					logrus.WithField("component", result.Item).Warnf("remediation applied: %s", action.Description)
+				}
+			}
+			r.Audit(record)
+			records = append(records, record)
+		}
+		if applied > 0 && applied == len(checker.Remediations) {
+			checker.Status = consts.StatusNormal
+			checker.Curr = "RemediatedOnline"
+			checker.Detail = fmt.Sprintf("%s. Remediated online: %s", checker.Detail, describe(checker.Remediations))
+			remediated = true
+		}
+	}
+	if remediated {
+		updateStatus(remediatedResult)
+	}
+	return remediatedResult, records
+}
+
+// copyResult copies result and its checkers, so that remediation can be
+// recorded without changing the result cached by the component.
+func copyResult(result *common.Result) *common.Result {
+	copied := *result
+	copied.Checkers = make([]*common.CheckerResult, len(result.Checkers))
+	for i, checker := range result.Checkers {
+		if checker == nil {
+			continue
+		}
+		checkerCopy := *checker
+		copied.Checkers[i] = &checkerCopy
+	}
+	return &copied
+}
+
+func actionKey(component, checker string, action *common.RemediationAction) string {
+	return strings.Join([]string{component, checker, action.Name, action.Target}, "/")
+}
+
+func (r *Remediator) lastRunAt(key string) (time.Time, bool) {
+	r.lastRunMu.Lock()
+	defer r.lastRunMu.Unlock()
+	last, ok := r.lastRun[key]
+	return last, ok
+}
+
+func (r *Remediator) markRun(key string) {
+	r.lastRunMu.Lock()
+	defer r.lastRunMu.Unlock()
+	r.lastRun[key] = time.Now()
+}
+
+// forget resets the backoff of the actions of a checker that recovered.
+func (r *Remediator) forget(component, checker string) {
+	prefix := component + "/" + checker + "/"
+	r.lastRunMu.Lock()
+	defer r.lastRunMu.Unlock()
+	for key := range r.lastRun {
+		if strings.HasPrefix(key, prefix) {
+			delete(r.lastRun, key)
+		}
+	}
+}
+
+// Audit appends record to the audit log, e.g. for the actions run by hand
+// such as `sichek gpu reset`.
+func (r *Remediator) Audit(record *AuditRecord) {
+	r.auditMu.Lock()
+	defer r.auditMu.Unlock()
+	data, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(r.cfg.AuditLog), 0755); err != nil {
+		logrus.WithField("remediator", "audit").Errorf("failed to create the audit log dir: %v", err)
+		return
+	}
+	f, err := os.OpenFile(r.cfg.AuditLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		logrus.WithField("remediator", "audit").Errorf("failed to open the audit log %s: %v", r.cfg.AuditLog, err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		logrus.WithField("remediator", "audit").Errorf("failed to write the audit log %s: %v", r.cfg.AuditLog, err)
+	}
+}
+
+func describe(actions []*common.RemediationAction) string {
+	descriptions := make([]string, 0, len(actions))
+	for _, action := range actions {
+		descriptions = append(descriptions, action.Description)
+	}
+	return strings.Join(descriptions, "; ")
+}
+
+// updateStatus recomputes the status and level of result from its checkers, like common.Check.
+func updateStatus(result *common.Result) {
+	status := consts.StatusNormal
+	level := consts.LevelInfo
+	for _, checker := range result.Checkers {
+		if checker == nil || checker.Status != consts.StatusAbnormal {
+			continue
+		}
+		status = consts.StatusAbnormal
+		if consts.LevelPriority[level] < consts.LevelPriority[checker.Level] {
+			level = checker.Level
+		}
+	}
+	result.Status = status
+	result.Level = level
+}
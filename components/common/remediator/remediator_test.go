@@ -0,0 +1,200 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package remediator
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/hostfs/hostfstest"
+)
+
+func newResult(applied *int, applyErr error) *common.Result {
+	action := &common.RemediationAction{
+		Name:        ActionModprobe,
+		Target:      "nvidia_peermem",
+		Description: "load the kernel module nvidia_peermem with modprobe",
+		Apply: func(ctx context.Context) error {
+			*applied++
+			return applyErr
+		},
+	}
+	return &common.Result{
+		Item:   "nvidia",
+		Status: consts.StatusAbnormal,
+		Level:  consts.LevelCritical,
+		Checkers: []*common.CheckerResult{
+			{Name: "nvidia-peermem", Status: consts.StatusAbnormal, Level: consts.LevelCritical, Curr: "NotLoaded", Remediations: []*common.RemediationAction{action}},
+			{Name: "pcie-acs", Status: consts.StatusNormal, Level: consts.LevelCritical},
+		},
+	}
+}
+
+func TestRemediate(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          Config
+		applyErr     error
+		wantApplied  int
+		wantRecords  []string
+		wantStatus   string
+		wantCheckCur string
+	}{
+		{name: "disabled", cfg: Config{}, wantStatus: consts.StatusAbnormal, wantCheckCur: "NotLoaded"},
+		{name: "dry-run", cfg: Config{DryRun: true}, wantRecords: []string{AuditStatusDryRun}, wantStatus: consts.StatusAbnormal, wantCheckCur: "NotLoaded"},
+		{name: "dry-run wins over enable", cfg: Config{Enable: true, DryRun: true}, wantRecords: []string{AuditStatusDryRun}, wantStatus: consts.StatusAbnormal, wantCheckCur: "NotLoaded"},
+		{name: "applied", cfg: Config{Enable: true}, wantApplied: 1, wantRecords: []string{AuditStatusApplied}, wantStatus: consts.StatusNormal, wantCheckCur: "RemediatedOnline"},
+		{name: "failed", cfg: Config{Enable: true}, applyErr: errors.New("modprobe: FATAL"), wantApplied: 1, wantRecords: []string{AuditStatusFailed}, wantStatus: consts.StatusAbnormal, wantCheckCur: "NotLoaded"},
+		{name: "allowed", cfg: Config{Enable: true, Actions: []string{ActionModprobe}}, wantApplied: 1, wantRecords: []string{AuditStatusApplied}, wantStatus: consts.StatusNormal, wantCheckCur: "RemediatedOnline"},
+		{name: "not allowed", cfg: Config{Enable: true, Actions: []string{ActionDisableACS}}, wantStatus: consts.StatusAbnormal, wantCheckCur: "NotLoaded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.AuditLog = filepath.Join(t.TempDir(), "audit.log")
+			applied := 0
+			result := newResult(&applied, tt.applyErr)
+			remediated, records := New(tt.cfg).Remediate(context.Background(), result)
+
+			if applied != tt.wantApplied {
+				t.Errorf("applied %d times, want %d", applied, tt.wantApplied)
+			}
+			if len(records) != len(tt.wantRecords) {
+				t.Fatalf("got %d audit records, want %d", len(records), len(tt.wantRecords))
+			}
+			for i, record := range records {
+				if record.Status != tt.wantRecords[i] {
+					t.Errorf("record %d status = %s, want %s", i, record.Status, tt.wantRecords[i])
+				}
+			}
+			if remediated.Status != tt.wantStatus {
+				t.Errorf("result status = %s, want %s", remediated.Status, tt.wantStatus)
+			}
+			if tt.wantStatus == consts.StatusNormal && remediated.Level != consts.LevelInfo {
+				t.Errorf("result level = %s, want %s", remediated.Level, consts.LevelInfo)
+			}
+			if remediated.Checkers[0].Curr != tt.wantCheckCur {
+				t.Errorf("checker curr = %s, want %s", remediated.Checkers[0].Curr, tt.wantCheckCur)
+			}
+			if result.Status != consts.StatusAbnormal || result.Checkers[0].Curr != "NotLoaded" {
+				t.Errorf("the input result was modified: %s/%s", result.Status, result.Checkers[0].Curr)
+			}
+
+			data, err := os.ReadFile(tt.cfg.AuditLog)
+			if len(tt.wantRecords) == 0 {
+				if err == nil {
+					t.Errorf("expected no audit log, got %q", data)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("read audit log: %v", err)
+			}
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			if len(lines) != len(tt.wantRecords) || !strings.Contains(lines[0], `"action":"modprobe"`) {
+				t.Errorf("unexpected audit log %q", data)
+			}
+		})
+	}
+}
+
+func TestRemediateBackoff(t *testing.T) {
+	r := New(Config{Enable: true, Backoff: time.Hour, AuditLog: filepath.Join(t.TempDir(), "audit.log")})
+	applied := 0
+	ctx := context.Background()
+
+	// the fault persists: the action runs once and is backed off afterwards
+	for i := 0; i < 3; i++ {
+		r.Remediate(ctx, newResult(&applied, errors.New("still broken")))
+	}
+	if applied != 1 {
+		t.Fatalf("applied %d times while the checker stayed abnormal, want 1", applied)
+	}
+
+	// the checker recovered and failed again: the action runs right away
+	recovered := newResult(&applied, nil)
+	recovered.Checkers[0].Status = consts.StatusNormal
+	r.Remediate(ctx, recovered)
+	r.Remediate(ctx, newResult(&applied, nil))
+	if applied != 2 {
+		t.Errorf("applied %d times after the checker failed again, want 2", applied)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	cfgFile := filepath.Join(t.TempDir(), "user_config.yaml")
+	content := "remediation:\n  enable: true\n  actions: [modprobe]\n  backoff: 10m\n"
+	if err := os.WriteFile(cfgFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(cfgFile)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if !cfg.Enable || cfg.DryRun || len(cfg.Actions) != 1 || cfg.Actions[0] != ActionModprobe || cfg.Backoff != 10*time.Minute {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+	if cfg.AuditLog != filepath.Join(consts.DefaultLogDir, "remediation-audit.log") {
+		t.Errorf("unexpected default audit log %s", cfg.AuditLog)
+	}
+
+	cfg, err = LoadConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil || cfg.Enable || cfg.Backoff != DefaultBackoff {
+		t.Errorf("expected disabled defaults for a missing file, got %+v, %v", cfg, err)
+	}
+}
+
+func TestMRREncoding(t *testing.T) {
+	for size, want := range map[string]int{"128": 0, "512": 2, "4096": 5} {
+		got, err := mrrEncoding(size)
+		if err != nil || got != want {
+			t.Errorf("mrrEncoding(%s) = %d, %v, want %d", size, got, err, want)
+		}
+	}
+	for _, size := range []string{"100", "8192", "abc"} {
+		if _, err := mrrEncoding(size); err == nil {
+			t.Errorf("mrrEncoding(%s) expected an error", size)
+		}
+	}
+}
+
+func TestSetCPUGovernor(t *testing.T) {
+	root := hostfstest.Build(t, `
+-- sys/devices/system/cpu/cpu0/cpufreq/scaling_governor --
+powersave
+-- sys/devices/system/cpu/cpu1/cpufreq/scaling_governor --
+powersave
+`)
+	dir := filepath.Join(root, "sys/devices/system/cpu")
+	if err := NewSetCPUGovernorAction("performance").Apply(context.Background()); err != nil {
+		t.Fatalf("set the governor under the host root: %v", err)
+	}
+	for _, cpu := range []string{"cpu0", "cpu1"} {
+		data, _ := os.ReadFile(filepath.Join(dir, cpu, "cpufreq", "scaling_governor"))
+		if string(data) != "performance" {
+			t.Errorf("%s governor=%q, want performance", cpu, data)
+		}
+	}
+	if err := setCPUGovernor(filepath.Join(dir, "none*"), "performance"); err == nil {
+		t.Errorf("expected an error without governor files")
+	}
+}
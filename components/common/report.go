@@ -0,0 +1,128 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/scitix/sichek/consts"
+	"sigs.k8s.io/yaml"
+)
+
+// ReportSchemaVersion is bumped whenever a field of Report is renamed or removed.
+const ReportSchemaVersion = "v1"
+
+// Report is the machine-readable aggregate of the health results of all
+// components checked on a node.
+type Report struct {
+	SchemaVersion string             `json:"schema_version"`
+	Node          string             `json:"node"`
+	Time          time.Time          `json:"time"`
+	Status        string             `json:"status"`
+	Level         string             `json:"level"`
+	Action        LifecycleAction    `json:"action,omitempty"`
+	Components    []*ComponentReport `json:"components"`
+}
+
+// ComponentReport holds the last Result and Info of a single component.
+// Error is set instead of Checkers when the component could not be checked.
+type ComponentReport struct {
+	Name     string           `json:"name"`
+	Status   string           `json:"status"`
+	Level    string           `json:"level"`
+	Time     time.Time        `json:"time"`
+	Checkers []*CheckerResult `json:"checkers"`
+	Action   *LifecycleAdvice `json:"action,omitempty"`
+	Info     Info             `json:"info,omitempty"`
+	Error    string           `json:"error,omitempty"`
+}
+
+// NewReport creates an empty report for the given node.
+func NewReport(node string) *Report {
+	return &Report{
+		SchemaVersion: ReportSchemaVersion,
+		Node:          node,
+		Time:          time.Now(),
+		Status:        consts.StatusNormal,
+		Level:         consts.LevelInfo,
+		Components:    make([]*ComponentReport, 0),
+	}
+}
+
+// AddResult appends the result and collected info of a component and
+// escalates the overall status, level and lifecycle action of the report
+// accordingly.
+func (r *Report) AddResult(result *Result, info Info) {
+	if result == nil {
+		return
+	}
+	r.Components = append(r.Components, &ComponentReport{
+		Name:     result.Item,
+		Status:   result.Status,
+		Level:    result.Level,
+		Time:     result.Time,
+		Checkers: result.Checkers,
+		Action:   result.Action,
+		Info:     info,
+	})
+	if result.Action != nil && result.Action.Action.MoreDisruptive(r.Action) {
+		r.Action = result.Action.Action
+	}
+	if result.Status == consts.StatusAbnormal {
+		r.Status = consts.StatusAbnormal
+		if consts.LevelPriority[r.Level] < consts.LevelPriority[result.Level] {
+			r.Level = result.Level
+		}
+	}
+}
+
+// AddError records a component that failed to be created or checked.
+// Such a component is reported as abnormal with critical level.
+func (r *Report) AddError(componentName string, err error) {
+	if err == nil {
+		return
+	}
+	r.Components = append(r.Components, &ComponentReport{
+		Name:   componentName,
+		Status: consts.StatusAbnormal,
+		Level:  consts.LevelCritical,
+		Time:   time.Now(),
+		Error:  err.Error(),
+	})
+	r.Status = consts.StatusAbnormal
+	if consts.LevelPriority[r.Level] < consts.LevelPriority[consts.LevelCritical] {
+		r.Level = consts.LevelCritical
+	}
+}
+
+// Sort orders the components by name so that the report output is stable.
+func (r *Report) Sort() {
+	sort.Slice(r.Components, func(i, j int) bool {
+		return r.Components[i].Name < r.Components[j].Name
+	})
+}
+
+func (r *Report) JSON() (string, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	return string(data), err
+}
+
+func (r *Report) YAML() (string, error) {
+	data, err := yaml.Marshal(r)
+	return string(data), err
+}
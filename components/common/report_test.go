@@ -0,0 +1,87 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/scitix/sichek/consts"
+)
+
+func TestReportAggregation(t *testing.T) {
+	report := NewReport("node-1")
+	report.AddResult(&Result{Item: "nvidia", Status: consts.StatusNormal, Level: consts.LevelInfo}, nil)
+	if report.Status != consts.StatusNormal || report.Level != consts.LevelInfo {
+		t.Fatalf("expected normal/info, got %s/%s", report.Status, report.Level)
+	}
+	report.AddResult(&Result{
+		Item:   "cpu",
+		Status: consts.StatusAbnormal,
+		Level:  consts.LevelWarning,
+		Checkers: []*CheckerResult{
+			{Name: "cpu-performance", Status: consts.StatusAbnormal, Level: consts.LevelWarning},
+		},
+	}, nil)
+	if report.Status != consts.StatusAbnormal || report.Level != consts.LevelWarning {
+		t.Fatalf("expected abnormal/warning, got %s/%s", report.Status, report.Level)
+	}
+	report.AddError("infiniband", errors.New("no ib devices"))
+	if report.Level != consts.LevelCritical {
+		t.Fatalf("expected critical after component error, got %s", report.Level)
+	}
+	report.AddResult(nil, nil)
+	report.AddError("gpfs", nil)
+	if len(report.Components) != 3 {
+		t.Fatalf("expected 3 components, got %d", len(report.Components))
+	}
+
+	report.Sort()
+	names := []string{report.Components[0].Name, report.Components[1].Name, report.Components[2].Name}
+	if strings.Join(names, ",") != "cpu,infiniband,nvidia" {
+		t.Fatalf("unexpected component order: %v", names)
+	}
+}
+
+func TestReportMarshal(t *testing.T) {
+	report := NewReport("node-1")
+	report.AddResult(&Result{Item: "cpu", Status: consts.StatusNormal, Level: consts.LevelInfo}, nil)
+
+	data, err := report.JSON()
+	if err != nil {
+		t.Fatalf("JSON() failed: %v", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal([]byte(data), &decoded); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+	if decoded.Node != "node-1" || decoded.SchemaVersion != ReportSchemaVersion || len(decoded.Components) != 1 {
+		t.Fatalf("unexpected decoded report: %+v", decoded)
+	}
+	if strings.Contains(data, `"info":`) {
+		t.Fatalf("nil info should be omitted: %s", data)
+	}
+
+	yamlData, err := report.YAML()
+	if err != nil {
+		t.Fatalf("YAML() failed: %v", err)
+	}
+	if !strings.Contains(yamlData, "schema_version: v1") || !strings.Contains(yamlData, "node: node-1") {
+		t.Fatalf("unexpected yaml output: %s", yamlData)
+	}
+}
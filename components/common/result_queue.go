@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultResultQueueSize is the number of results a component keeps for a
+// stalled consumer before it drops the oldest ones.
+const DefaultResultQueueSize = 16
+
+var (
+	droppedMtx     sync.Mutex
+	droppedResults = make(map[string]uint64)
+)
+
+// DroppedResults returns the number of results each component dropped
+// because its consumer did not keep up.
+func DroppedResults() map[string]uint64 {
+	droppedMtx.Lock()
+	defer droppedMtx.Unlock()
+	dropped := make(map[string]uint64, len(droppedResults))
+	for component, n := range droppedResults {
+		dropped[component] = n
+	}
+	return dropped
+}
+
+func recordDroppedResult(component string) {
+	droppedMtx.Lock()
+	droppedResults[component]++
+	droppedMtx.Unlock()
+}
+
+// ResultQueue delivers the results of a component through a buffered channel.
+// Send never blocks the health check loop: when the consumer stalls and the
+// buffer is full, the oldest result is dropped, since a newer result of the
+// same component supersedes it.
+type ResultQueue struct {
+	component string
+	mtx       sync.Mutex
+	ch        chan *Result
+	closed    bool
+}
+
+func NewResultQueue(component string, size int) *ResultQueue {
+	if size <= 0 {
+		size = DefaultResultQueueSize
+	}
+	return &ResultQueue{
+		component: component,
+		ch:        make(chan *Result, size),
+	}
+}
+
+// C returns the channel the consumer receives the results from.
+func (q *ResultQueue) C() <-chan *Result {
+	return q.ch
+}
+
+// Send queues the result, dropping the oldest queued one if the queue is
+// full. It returns false if the result could not be queued because the queue
+// is closed.
+func (q *ResultQueue) Send(result *Result) bool {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if q.closed {
+		return false
+	}
+	for {
+		select {
+		case q.ch <- result:
+			return true
+		default:
+		}
+		select {
+		case <-q.ch:
+			recordDroppedResult(q.component)
+			logrus.WithField("component", q.component).Warnf("result queue is full, dropped the oldest result")
+		default:
+			// the consumer drained the queue meanwhile
+		}
+	}
+}
+
+// Close closes the channel, the results still queued can be received.
+func (q *ResultQueue) Close() {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if !q.closed {
+		q.closed = true
+		close(q.ch)
+	}
+}
+
+// ReceiveBatch blocks for a result of ch and returns it with the results
+// already queued behind it, at most max. ok is false once ch is closed and
+// drained, or ctx is done.
+func ReceiveBatch(ctx context.Context, ch <-chan *Result, max int) (batch []*Result, ok bool) {
+	select {
+	case <-ctx.Done():
+		return nil, false
+	case result, ok := <-ch:
+		if !ok {
+			return nil, false
+		}
+		batch = append(batch, result)
+	}
+	for len(batch) < max {
+		select {
+		case result, ok := <-ch:
+			if !ok {
+				return batch, true
+			}
+			batch = append(batch, result)
+		default:
+			return batch, true
+		}
+	}
+	return batch, true
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestResultQueueDropsOldest(t *testing.T) {
+	q := NewResultQueue("test-drop-oldest", 2)
+	for _, item := range []string{"a", "b", "c"} {
+		if !q.Send(&Result{Item: item}) {
+			t.Fatalf("failed to send %s", item)
+		}
+	}
+	if got := DroppedResults()["test-drop-oldest"]; got != 1 {
+		t.Errorf("expected 1 dropped result, got %d", got)
+	}
+	batch, ok := ReceiveBatch(context.Background(), q.C(), 10)
+	if !ok || len(batch) != 2 || batch[0].Item != "b" || batch[1].Item != "c" {
+		t.Fatalf("expected the batch b, c, got %v, ok=%v", batch, ok)
+	}
+
+	q.Close()
+	if q.Send(&Result{Item: "d"}) {
+		t.Errorf("expected a send to a closed queue to fail")
+	}
+	if _, ok := ReceiveBatch(context.Background(), q.C(), 10); ok {
+		t.Errorf("expected a closed queue to end the batches")
+	}
+}
+
+func TestReceiveBatchMax(t *testing.T) {
+	q := NewResultQueue("test-batch-max", 4)
+	for i := 0; i < 3; i++ {
+		q.Send(&Result{})
+	}
+	batch, _ := ReceiveBatch(context.Background(), q.C(), 2)
+	if len(batch) != 2 {
+		t.Errorf("expected a batch of 2, got %d", len(batch))
+	}
+	batch, _ = ReceiveBatch(context.Background(), q.C(), 2)
+	if len(batch) != 1 {
+		t.Errorf("expected a batch of 1, got %d", len(batch))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, ok := ReceiveBatch(ctx, q.C(), 2); ok {
+		t.Errorf("expected the batch of a done context to fail")
+	}
+}
+
+func TestResultQueueSendDoesNotBlock(t *testing.T) {
+	q := NewResultQueue("test-no-block", 1)
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 100; i++ {
+			q.Send(&Result{})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Send blocked on a stalled consumer")
+	}
+}
@@ -23,6 +23,7 @@ limitations under the License.
 package common
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"net/http"
@@ -33,6 +34,7 @@ import (
 	"time"
 
 	"github.com/scitix/sichek/pkg/httpclient"
+	"github.com/scitix/sichek/pkg/specsign"
 	"github.com/sirupsen/logrus"
 	"sigs.k8s.io/yaml"
 )
@@ -40,7 +42,9 @@ import (
 // ─── LoadSpec ────────────────────────────────────────────────────────────────
 
 // LoadSpec reads the YAML file at `file` and unmarshals it into `out`.
-// This is a pure read operation: no network calls, no writes.
+// A file declaring `base:` is deep-merged onto the base specs it inherits
+// from first (see httpclient.SpecBaseKey). Apart from fetching such bases
+// this is a pure read operation: no writes.
 func LoadSpec[T any](file string, out *T) error {
 	if file == "" {
 		return fmt.Errorf("spec file path is empty")
@@ -49,6 +53,10 @@ func LoadSpec[T any](file string, out *T) error {
 	if err != nil {
 		return fmt.Errorf("failed to read spec file %s: %w", file, err)
 	}
+	data, err = httpclient.ResolveSpecOverlay(data, file)
+	if err != nil {
+		return fmt.Errorf("failed to resolve base specs of %s: %w", file, err)
+	}
 	if err := yaml.Unmarshal(data, out); err != nil {
 		return fmt.Errorf("failed to unmarshal YAML from %s: %w", file, err)
 	}
@@ -76,7 +84,10 @@ func LoadSpec[T any](file string, out *T) error {
 //  4. specName is bare filename → check default dir first, then try SICHEK_SPEC_URL
 //  5. Fall back: use existing canonical file if already present
 //
-// Every overwrite is preceded by a .bak backup and traced via logrus.
+// Every overwrite is preceded by a .bak backup and traced via logrus. A source
+// that inherits from base specs is flattened into the canonical file, with
+// its bases resolved against the source location, so that FilterSpec and
+// later runs see the complete spec.
 func EnsureSpecFile(specName, defaultFileName string) (string, error) {
 	const comp = "common/spec"
 	targetDir := defaultProductionCfgPath()
@@ -85,11 +96,8 @@ func EnsureSpecFile(specName, defaultFileName string) (string, error) {
 	destPath := filepath.Join(targetDir, defaultFileName)
 
 	// Resolve the source spec name
-	sourceName := specName
-	if sourceName == "" {
-		cluster := extractClusterName()
-		suffix := strings.TrimPrefix(defaultFileName, "default_")
-		sourceName = fmt.Sprintf("%s_%s", cluster, suffix)
+	sourceName := specSourceName(specName, defaultFileName)
+	if specName == "" {
 		logrus.WithField("component", comp).Infof("derived spec name from cluster: %s", sourceName)
 	}
 
@@ -98,6 +106,7 @@ func EnsureSpecFile(specName, defaultFileName string) (string, error) {
 		if err := DownloadSpecFile(sourceName, destPath, comp); err != nil {
 			logrus.WithField("component", comp).Warnf("URL download failed (%v); falling back to existing %s", err, destPath)
 		} else {
+			flattenSpecOverlay(destPath, sourceName, comp)
 			return destPath, nil
 		}
 	} else if fileExists(sourceName) && sourceName != destPath {
@@ -105,6 +114,7 @@ func EnsureSpecFile(specName, defaultFileName string) (string, error) {
 		if err := overwriteWithBackup(sourceName, destPath, comp); err != nil {
 			logrus.WithField("component", comp).Warnf("copy failed (%v); falling back to existing %s", err, destPath)
 		} else {
+			flattenSpecOverlay(destPath, sourceName, comp)
 			return destPath, nil
 		}
 	} else {
@@ -113,6 +123,7 @@ func EnsureSpecFile(specName, defaultFileName string) (string, error) {
 		if fileExists(clusterPath) && clusterPath != destPath {
 			logrus.WithField("component", comp).Infof("copying existing cluster file %s → %s", clusterPath, destPath)
 			if err := overwriteWithBackup(clusterPath, destPath, comp); err == nil {
+				flattenSpecOverlay(destPath, clusterPath, comp)
 				return destPath, nil
 			}
 		}
@@ -120,6 +131,7 @@ func EnsureSpecFile(specName, defaultFileName string) (string, error) {
 		if ossBase != "" {
 			fileURL := strings.TrimRight(ossBase, "/") + "/" + filepath.Base(sourceName)
 			if err := DownloadSpecFile(fileURL, destPath, comp); err == nil {
+				flattenSpecOverlay(destPath, fileURL, comp)
 				return destPath, nil
 			} else {
 				logrus.WithField("component", comp).Warnf("OSS download failed (%v); trying existing default", err)
@@ -138,6 +150,34 @@ func EnsureSpecFile(specName, defaultFileName string) (string, error) {
 	return "", fmt.Errorf("no spec file available at %s (SICHEK_SPEC_URL=%q)", destPath, httpclient.GetSichekSpecURL())
 }
 
+// SpecSourceURL returns the remote URL EnsureSpecFile downloads the spec from:
+// specName itself if it is a URL, otherwise the file of the same name (or of
+// the cluster when specName is empty) under SICHEK_SPEC_URL. It returns "" for
+// a local spec path or when SICHEK_SPEC_URL is not set.
+func SpecSourceURL(specName, defaultFileName string) string {
+	sourceName := specSourceName(specName, defaultFileName)
+	if isHTTP(sourceName) {
+		return sourceName
+	}
+	if specName != "" && fileExists(specName) {
+		return ""
+	}
+	ossBase := httpclient.GetSichekSpecURL()
+	if ossBase == "" {
+		return ""
+	}
+	return strings.TrimRight(ossBase, "/") + "/" + filepath.Base(sourceName)
+}
+
+func specSourceName(specName, defaultFileName string) string {
+	if specName != "" {
+		return specName
+	}
+	cluster := extractClusterName()
+	suffix := strings.TrimPrefix(defaultFileName, "default_")
+	return fmt.Sprintf("%s_%s", cluster, suffix)
+}
+
 // ─── FilterSpec ──────────────────────────────────────────────────────────────
 
 // FilterSpec loads the multi-spec container at `file`, selects the entry for
@@ -356,8 +396,91 @@ func DownloadSpecFile(fileURL, destPath, logComp string) error {
 	return nil
 }
 
+// DownloadSpecFileIfModified downloads the spec at `fileURL` into `destPath`
+// unless the server reports it unchanged since `etag` (HTTP 304). It returns
+// the ETag of the remote file and whether `destPath` was rewritten.
+func DownloadSpecFileIfModified(fileURL, destPath, etag, logComp string) (string, bool, error) {
+	req, err := http.NewRequest(http.MethodGet, fileURL, nil)
+	if err != nil {
+		return etag, false, fmt.Errorf("new request %s: %w", fileURL, err)
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return etag, false, fmt.Errorf("GET %s: %w", fileURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return etag, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return etag, false, fmt.Errorf("GET %s: status %d", fileURL, resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return etag, false, fmt.Errorf("read body from %s: %w", fileURL, err)
+	}
+	if err := specsign.Verify(fileURL, data); err != nil {
+		return etag, false, err
+	}
+	newETag := resp.Header.Get("ETag")
+	if existing, err := os.ReadFile(destPath); err == nil && bytes.Equal(existing, data) {
+		return newETag, false, nil
+	}
+	if fileExists(destPath) {
+		bak := destPath + ".bak"
+		if err := copyFile(destPath, bak); err != nil {
+			return etag, false, fmt.Errorf("backup failed: %w", err)
+		}
+		logrus.WithField("component", logComp).Infof("backed up %s → %s", destPath, bak)
+	}
+	tmp := destPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return etag, false, err
+	}
+	if err := os.Rename(tmp, destPath); err != nil {
+		_ = os.Remove(tmp)
+		return etag, false, fmt.Errorf("rename failed: %w", err)
+	}
+	logrus.WithField("component", logComp).Infof(
+		"downloaded %s → %s (etag=%s, ts=%s)", fileURL, destPath, newETag, time.Now().Format(time.RFC3339))
+	return newETag, true, nil
+}
+
 // ─── internal helpers ────────────────────────────────────────────────────────
 
+// flattenSpecOverlay merges the bases of the spec copied from origin into
+// destPath. On failure the overlay is left as is; LoadSpec resolves its bases
+// again, relative to destPath.
+func flattenSpecOverlay(destPath, origin, logComp string) {
+	data, err := os.ReadFile(destPath)
+	if err != nil {
+		return
+	}
+	merged, err := httpclient.ResolveSpecOverlay(data, origin)
+	if err != nil {
+		logrus.WithField("component", logComp).Warnf("failed to resolve base specs of %s: %v", origin, err)
+		return
+	}
+	if bytes.Equal(merged, data) {
+		return
+	}
+	tmp := destPath + ".tmp"
+	if err := os.WriteFile(tmp, merged, 0644); err != nil {
+		logrus.WithField("component", logComp).Warnf("failed to write flattened spec %s: %v", tmp, err)
+		return
+	}
+	if err := os.Rename(tmp, destPath); err != nil {
+		_ = os.Remove(tmp)
+		logrus.WithField("component", logComp).Warnf("failed to write flattened spec %s: %v", destPath, err)
+		return
+	}
+	logrus.WithField("component", logComp).Infof("flattened base specs of %s into %s", origin, destPath)
+}
+
 func defaultProductionCfgPath() string {
 	if p := os.Getenv("SICHEK_CONFIG_DIR"); p != "" {
 		return p
@@ -425,6 +548,9 @@ func httpDownload(fileURL, destPath string) error {
 	if err != nil {
 		return fmt.Errorf("read body from %s: %w", fileURL, err)
 	}
+	if err := specsign.Verify(fileURL, data); err != nil {
+		return err
+	}
 	return os.WriteFile(destPath, data, 0644)
 }
 
@@ -0,0 +1,78 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/scitix/sichek/consts"
+)
+
+const SpecMissingCheckerName = "SpecMissing"
+
+// specMissingChecker stands in for the spec-driven checkers of a component whose
+// spec file is absent or unparsable, so the component keeps collecting and
+// reports a warning instead of failing NewComponent.
+type specMissingChecker struct {
+	componentName string
+	specErr       error
+}
+
+// NewSpecMissingChecker returns a checker that always reports SpecMissing with
+// LevelWarning for the given component.
+func NewSpecMissingChecker(componentName string, specErr error) Checker {
+	return &specMissingChecker{
+		componentName: componentName,
+		specErr:       specErr,
+	}
+}
+
+func (c *specMissingChecker) Name() string {
+	return SpecMissingCheckerName
+}
+
+func (c *specMissingChecker) Check(ctx context.Context, data any) (*CheckerResult, error) {
+	curr := "spec not found"
+	if c.specErr != nil {
+		curr = c.specErr.Error()
+	}
+	return &CheckerResult{
+		Name:        SpecMissingCheckerName,
+		Description: fmt.Sprintf("%s spec is missing or invalid, spec based checks are skipped", c.componentName),
+		Device:      "",
+		Spec:        "",
+		Curr:        curr,
+		Status:      consts.StatusAbnormal,
+		Level:       consts.LevelWarning,
+		Detail:      "",
+		ErrorName:   SpecMissingCheckerName,
+		Suggestion:  fmt.Sprintf("Please provide a valid spec for %s via the --spec flag or %s", c.componentName, consts.DefaultProductionCfgPath),
+	}, nil
+}
+
+// CheckersOrSpecMissing returns the checkers build creates from spec. When the
+// spec failed to load it returns a SpecMissing checker instead, so that the
+// component keeps collecting and surfaces the missing spec as a warning.
+func CheckersOrSpecMissing[S any](componentName string, spec *S, specErr error, build func(*S) ([]Checker, error)) ([]Checker, error) {
+	if spec != nil {
+		return build(spec)
+	}
+	if specErr == nil {
+		specErr = fmt.Errorf("%s spec is nil after loading", componentName)
+	}
+	return []Checker{NewSpecMissingChecker(componentName, specErr)}, nil
+}
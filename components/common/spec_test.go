@@ -16,6 +16,7 @@ limitations under the License.
 package common
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -24,6 +25,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/scitix/sichek/consts"
 	"sigs.k8s.io/yaml"
 )
 
@@ -80,6 +82,67 @@ func TestLoadSpec_TypeMismatch(t *testing.T) {
 	}
 }
 
+func TestLoadSpec_BaseOverlay(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "h100_base.yaml"), []byte(`
+items:
+  h100:
+    name: H100
+    version: v1
+  h800:
+    name: H800
+    version: v1
+`), 0644)
+	f := filepath.Join(dir, "cluster_spec.yaml")
+	os.WriteFile(f, []byte(`
+base: h100_base.yaml
+items:
+  h100:
+    version: v2
+  h800: null
+`), 0644)
+
+	var out multiItemSpec
+	if err := LoadSpec(f, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := out.Items["h100"]; got == nil || got.Name != "H100" || got.Version != "v2" {
+		t.Errorf("expected h100 name inherited and version overridden, got %+v", got)
+	}
+	if _, ok := out.Items["h800"]; ok {
+		t.Errorf("expected h800 removed by the null overlay, got %+v", out.Items)
+	}
+}
+
+func TestLoadSpec_BaseChainAndList(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.yaml"), []byte("name: a\nversion: v1\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "b.yaml"), []byte("base: a.yaml\nversion: v2\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "c.yaml"), []byte("name: c\n"), 0644)
+	f := filepath.Join(dir, "top.yaml")
+	os.WriteFile(f, []byte("base: [b.yaml, c.yaml]\n"), 0644)
+
+	var out itemSpec
+	if err := LoadSpec(f, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Name != "c" || out.Version != "v2" {
+		t.Errorf("expected later bases to win, got %+v", out)
+	}
+}
+
+func TestLoadSpec_BaseCycle(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.yaml"), []byte("base: b.yaml\nname: a\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "b.yaml"), []byte("base: a.yaml\nname: b\n"), 0644)
+
+	var out itemSpec
+	err := LoadSpec(filepath.Join(dir, "a.yaml"), &out)
+	if err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("expected inheritance cycle error, got %v", err)
+	}
+}
+
 // ─── EnsureSpecFile ──────────────────────────────────────────────────────────
 
 func TestEnsureSpecFile_ExistingLocalPath(t *testing.T) {
@@ -137,6 +200,39 @@ func TestEnsureSpecFile_DownloadAndBackup(t *testing.T) {
 	}
 }
 
+func TestEnsureSpecFile_FlattensRemoteOverlay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/specs/cluster_spec.yaml":
+			fmt.Fprintln(w, "base: base_spec.yaml\nversion: v2")
+		case "/specs/base_spec.yaml":
+			fmt.Fprintln(w, "name: remote\nversion: v1")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	t.Setenv("SICHEK_CONFIG_DIR", dir)
+
+	got, err := EnsureSpecFile(srv.URL+"/specs/cluster_spec.yaml", "default_spec.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	raw, _ := os.ReadFile(got)
+	if strings.Contains(string(raw), "base:") {
+		t.Errorf("expected canonical spec to be flattened, got:\n%s", raw)
+	}
+	var out itemSpec
+	if err := LoadSpec(got, &out); err != nil {
+		t.Fatalf("LoadSpec: %v", err)
+	}
+	if out.Name != "remote" || out.Version != "v2" {
+		t.Errorf("got %+v", out)
+	}
+}
+
 // ─── FilterSpec ──────────────────────────────────────────────────────────────
 
 func filterItem(c *multiItemSpec, id string) (*itemSpec, bool) {
@@ -299,3 +395,40 @@ func writeTmpYAMLTo(t *testing.T, path string, v interface{}) {
 		t.Fatalf("WriteFile: %v", err)
 	}
 }
+
+// ─── SpecMissing ─────────────────────────────────────────────────────────────
+
+func TestSpecMissingChecker(t *testing.T) {
+	checkers := []Checker{NewSpecMissingChecker("demo", fmt.Errorf("spec file path is empty"))}
+	result := Check(context.Background(), "demo", nil, checkers)
+	if result.Status != consts.StatusAbnormal || result.Level != consts.LevelWarning {
+		t.Fatalf("expected abnormal/warning, got %s/%s", result.Status, result.Level)
+	}
+	if len(result.Checkers) != 1 || result.Checkers[0].Name != SpecMissingCheckerName {
+		t.Fatalf("expected a single %s checker result, got %+v", SpecMissingCheckerName, result.Checkers)
+	}
+	if result.Checkers[0].Curr != "spec file path is empty" {
+		t.Errorf("unexpected curr: %s", result.Checkers[0].Curr)
+	}
+}
+
+func TestCheckersOrSpecMissing(t *testing.T) {
+	build := func(spec *itemSpec) ([]Checker, error) {
+		return []Checker{NewSpecMissingChecker(spec.Name, nil)}, nil
+	}
+	checkers, err := CheckersOrSpecMissing("demo", &itemSpec{Name: "built"}, nil, build)
+	if err != nil || len(checkers) != 1 {
+		t.Fatalf("expected the checkers built from the spec, got %v %v", checkers, err)
+	}
+	if result, _ := checkers[0].Check(context.Background(), nil); !strings.HasPrefix(result.Description, "built ") {
+		t.Errorf("checker not built from the spec: %s", result.Description)
+	}
+	checkers, err = CheckersOrSpecMissing("demo", nil, nil, build)
+	if err != nil || len(checkers) != 1 || checkers[0].Name() != SpecMissingCheckerName {
+		t.Fatalf("expected a %s checker without a spec, got %v %v", SpecMissingCheckerName, checkers, err)
+	}
+	result, _ := checkers[0].Check(context.Background(), nil)
+	if result.Curr != "demo spec is nil after loading" {
+		t.Errorf("unexpected curr: %s", result.Curr)
+	}
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/containerruntime/config"
+)
+
+// NewCheckers creates all container runtime checkers, filtering out any in the ignored list.
+func NewCheckers(cfg *config.ContainerRuntimeUserConfig, spec *config.ContainerRuntimeSpec) ([]common.Checker, error) {
+	checkerConstructors := map[string]func(*config.ContainerRuntimeSpec) (common.Checker, error){
+		config.RuntimeResponsiveCheckerName: NewRuntimeResponsiveChecker,
+		config.ToolkitInstalledCheckerName:  NewToolkitInstalledChecker,
+		config.ToolkitVersionCheckerName:    NewToolkitVersionChecker,
+		config.GPUDefaultRuntimeCheckerName: NewGPUDefaultRuntimeChecker,
+		config.KubeletPLEGCheckerName:       NewKubeletPLEGChecker,
+	}
+
+	ignoredSet := make(map[string]struct{})
+	if cfg != nil && cfg.ContainerRuntime != nil {
+		for _, v := range cfg.ContainerRuntime.IgnoredCheckers {
+			ignoredSet[v] = struct{}{}
+		}
+	}
+
+	checkers := make([]common.Checker, 0, len(checkerConstructors))
+	for checkerName, constructor := range checkerConstructors {
+		if _, found := ignoredSet[checkerName]; found {
+			continue
+		}
+		checker, err := constructor(spec)
+		if err != nil {
+			return nil, err
+		}
+		checkers = append(checkers, checker)
+	}
+	return checkers, nil
+}
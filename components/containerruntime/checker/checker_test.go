@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/containerruntime/collector"
+	"github.com/scitix/sichek/components/containerruntime/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCheckers(t *testing.T) map[string]common.Checker {
+	t.Helper()
+	spec := &config.ContainerRuntimeSpec{
+		MaxResponseTime:          common.Duration{Duration: 2 * time.Second},
+		NvidiaToolkit:            config.ToolkitSpec{Version: ">=1.14.0"},
+		RequireGPUDefaultRuntime: true,
+		AllowCDI:                 true,
+		MaxPLEGRelistLatency:     common.Duration{Duration: time.Second},
+	}
+	checkers, err := NewCheckers(&config.ContainerRuntimeUserConfig{ContainerRuntime: &config.ContainerRuntimeConfig{}}, spec)
+	require.NoError(t, err)
+	byName := make(map[string]common.Checker)
+	for _, c := range checkers {
+		byName[c.Name()] = c
+	}
+	require.Len(t, byName, 5)
+	return byName
+}
+
+func check(t *testing.T, checker common.Checker, info *collector.ContainerRuntimeInfo) *common.CheckerResult {
+	t.Helper()
+	res, err := checker.Check(context.Background(), info)
+	require.NoError(t, err)
+	return res
+}
+
+func TestRuntimeResponsiveChecker(t *testing.T) {
+	checker := newCheckers(t)[config.RuntimeResponsiveCheckerName]
+	info := &collector.ContainerRuntimeInfo{Daemons: []*collector.RuntimeDaemon{
+		{Name: "containerd", Socket: "/run/containerd/containerd.sock", Responsive: true, ResponseSeconds: 0.003},
+	}}
+	assert.Equal(t, consts.StatusNormal, check(t, checker, info).Status)
+
+	info.Daemons = append(info.Daemons,
+		&collector.RuntimeDaemon{Name: "docker", Socket: "/var/run/docker.sock", Responsive: true, ResponseSeconds: 3.5})
+	res := check(t, checker, info)
+	assert.Equal(t, consts.StatusAbnormal, res.Status)
+	assert.Equal(t, "docker", res.Device)
+	assert.Contains(t, res.Detail, "answered in 3.5s, threshold is 2s")
+
+	info.Daemons[0].Responsive = false
+	info.Daemons[0].Error = "context deadline exceeded"
+	res = check(t, checker, info)
+	assert.Equal(t, consts.LevelCritical, res.Level)
+	assert.Equal(t, "containerd,docker", res.Device)
+	assert.Equal(t, "ContainerRuntimeUnresponsive", res.ErrorName)
+}
+
+func TestGPUDefaultRuntimeChecker(t *testing.T) {
+	checker := newCheckers(t)[config.GPUDefaultRuntimeCheckerName]
+	runc := &collector.RuntimeDaemon{Name: "containerd", DefaultRuntime: "runc", ConfigPath: "/etc/containerd/config.toml"}
+	info := &collector.ContainerRuntimeInfo{Daemons: []*collector.RuntimeDaemon{runc}}
+	assert.Equal(t, consts.StatusNormal, check(t, checker, info).Status, "a node without GPU is skipped")
+
+	info.NvidiaGPU = true
+	res := check(t, checker, info)
+	assert.Equal(t, consts.StatusAbnormal, res.Status)
+	assert.Contains(t, res.Detail, "default runtime of containerd is runc (/etc/containerd/config.toml)")
+
+	runc.CDIEnabled = true
+	assert.Equal(t, consts.StatusNormal, check(t, checker, info).Status)
+
+	info.Daemons[0] = &collector.RuntimeDaemon{Name: "containerd", DefaultRuntime: "nvidia", DefaultRuntimeBinary: "/usr/bin/nvidia-container-runtime"}
+	res = check(t, checker, info)
+	assert.Equal(t, consts.StatusNormal, res.Status)
+	assert.Equal(t, "containerd:nvidia", res.Curr)
+}
+
+func TestToolkitCheckers(t *testing.T) {
+	checkers := newCheckers(t)
+	installed, version := checkers[config.ToolkitInstalledCheckerName], checkers[config.ToolkitVersionCheckerName]
+	info := &collector.ContainerRuntimeInfo{NvidiaGPU: true, Toolkit: &collector.NvidiaToolkit{}}
+
+	res := check(t, installed, info)
+	assert.Equal(t, consts.StatusAbnormal, res.Status)
+	assert.Equal(t, "NvidiaContainerToolkitMissing", res.ErrorName)
+	assert.Equal(t, consts.StatusNormal, check(t, version, info).Status, "a missing toolkit is reported once")
+
+	info.Toolkit = &collector.NvidiaToolkit{HookPath: "/usr/bin/nvidia-container-runtime-hook", Version: "1.13.5"}
+	assert.Equal(t, consts.StatusNormal, check(t, installed, info).Status)
+	res = check(t, version, info)
+	assert.Equal(t, consts.StatusAbnormal, res.Status)
+	assert.Equal(t, consts.LevelWarning, res.Level)
+	assert.Equal(t, "nvidia-container-toolkit version is 1.13.5, spec is >=1.14.0", res.Detail)
+
+	info.Toolkit.Version = "1.17.3"
+	assert.Equal(t, consts.StatusNormal, check(t, version, info).Status)
+}
+
+func TestKubeletPLEGChecker(t *testing.T) {
+	checker := newCheckers(t)[config.KubeletPLEGCheckerName]
+	info := &collector.ContainerRuntimeInfo{}
+	assert.Equal(t, "Skipped", check(t, checker, info).Curr, "a node without kubelet is skipped")
+
+	info.PLEG = &collector.KubeletPLEG{RelistP99Seconds: 0.1, Relists: 60, LastSeenAgeSeconds: 1}
+	assert.Equal(t, consts.StatusNormal, check(t, checker, info).Status)
+
+	info.PLEG.RelistP99Seconds = 2.5
+	res := check(t, checker, info)
+	assert.Equal(t, consts.StatusAbnormal, res.Status)
+	assert.Contains(t, res.Detail, "p99 of the kubelet PLEG relist duration is 2.5s over 60 relists")
+
+	info.PLEG = &collector.KubeletPLEG{LastSeenAgeSeconds: 200}
+	res = check(t, checker, info)
+	assert.Equal(t, consts.StatusAbnormal, res.Status)
+	assert.Equal(t, "the kubelet PLEG last relisted 3m20s ago", res.Detail)
+}
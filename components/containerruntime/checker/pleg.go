@@ -0,0 +1,87 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/containerruntime/collector"
+	"github.com/scitix/sichek/components/containerruntime/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+// plegStallThreshold is the time without relist after which the kubelet
+// reports its PLEG not healthy and the node NotReady.
+const plegStallThreshold = 3 * time.Minute
+
+// KubeletPLEGChecker flags a kubelet whose PLEG relists slower than the spec,
+// stalled or is reported not healthy in the Ready condition of the node.
+type KubeletPLEGChecker struct {
+	name       string
+	maxLatency time.Duration
+}
+
+func NewKubeletPLEGChecker(spec *config.ContainerRuntimeSpec) (common.Checker, error) {
+	return &KubeletPLEGChecker{
+		name:       config.KubeletPLEGCheckerName,
+		maxLatency: spec.MaxPLEGRelistLatency.Duration,
+	}, nil
+}
+
+func (c *KubeletPLEGChecker) Name() string {
+	return c.name
+}
+
+func (c *KubeletPLEGChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	info, ok := data.(*collector.ContainerRuntimeInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid ContainerRuntimeInfo type")
+	}
+
+	result := config.ContainerRuntimeCheckItems[c.name]
+	result.Status = consts.StatusNormal
+	if c.maxLatency > 0 {
+		result.Spec = c.maxLatency.String()
+	}
+	pleg := info.PLEG
+	if pleg == nil {
+		result.Curr = "Skipped"
+		return &result, nil
+	}
+
+	p99 := time.Duration(pleg.RelistP99Seconds * float64(time.Second))
+	lastSeen := time.Duration(pleg.LastSeenAgeSeconds * float64(time.Second)).Round(time.Second)
+	result.Curr = p99.String()
+	switch {
+	case pleg.NotHealthy != "":
+		result.Status = consts.StatusAbnormal
+		result.Detail = pleg.NotHealthy
+	case lastSeen > plegStallThreshold:
+		result.Status = consts.StatusAbnormal
+		result.Detail = fmt.Sprintf("the kubelet PLEG last relisted %s ago", lastSeen)
+	case c.maxLatency > 0 && p99 > c.maxLatency:
+		result.Status = consts.StatusAbnormal
+		result.Detail = fmt.Sprintf("p99 of the kubelet PLEG relist duration is %s over %.0f relists, threshold is %s", p99, pleg.Relists, c.maxLatency)
+	}
+	if result.Status == consts.StatusAbnormal {
+		logrus.WithField("component", "container_runtime").Errorf("%s failed: %s", c.name, result.Detail)
+	}
+	return &result, nil
+}
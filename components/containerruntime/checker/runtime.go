@@ -0,0 +1,145 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/containerruntime/collector"
+	"github.com/scitix/sichek/components/containerruntime/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+// RuntimeResponsiveChecker flags the containerd and docker daemons failing to
+// answer their probe or answering slower than the response time of the spec.
+type RuntimeResponsiveChecker struct {
+	name            string
+	maxResponseTime time.Duration
+}
+
+func NewRuntimeResponsiveChecker(spec *config.ContainerRuntimeSpec) (common.Checker, error) {
+	return &RuntimeResponsiveChecker{
+		name:            config.RuntimeResponsiveCheckerName,
+		maxResponseTime: spec.MaxResponseTime.Duration,
+	}, nil
+}
+
+func (c *RuntimeResponsiveChecker) Name() string {
+	return c.name
+}
+
+func (c *RuntimeResponsiveChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	info, ok := data.(*collector.ContainerRuntimeInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid ContainerRuntimeInfo type")
+	}
+
+	result := config.ContainerRuntimeCheckItems[c.name]
+	result.Status = consts.StatusNormal
+
+	var abnormal []string
+	var detail string
+	for _, daemon := range info.Daemons {
+		responseTime := time.Duration(daemon.ResponseSeconds * float64(time.Second)).Round(time.Millisecond)
+		switch {
+		case !daemon.Responsive:
+			detail += fmt.Sprintf("%s does not answer on %s: %s\n", daemon.Name, daemon.Socket, daemon.Error)
+		case c.maxResponseTime > 0 && responseTime > c.maxResponseTime:
+			detail += fmt.Sprintf("%s answered in %s, threshold is %s\n", daemon.Name, responseTime, c.maxResponseTime)
+		default:
+			continue
+		}
+		abnormal = append(abnormal, daemon.Name)
+	}
+
+	if len(abnormal) > 0 {
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(abnormal, ",")
+		result.Curr = fmt.Sprintf("%d runtimes unresponsive", len(abnormal))
+		result.Detail = detail
+		logrus.WithField("component", "container_runtime").Errorf("%s failed: %s", c.name, detail)
+	} else {
+		result.Curr = "OK"
+	}
+	return &result, nil
+}
+
+// GPUDefaultRuntimeChecker flags the runtimes of a GPU node whose default
+// runtime does not inject the GPU devices, so that a pod requesting
+// nvidia.com/gpu starts without them unless it selects the nvidia runtime.
+type GPUDefaultRuntimeChecker struct {
+	name     string
+	required bool
+	allowCDI bool
+}
+
+func NewGPUDefaultRuntimeChecker(spec *config.ContainerRuntimeSpec) (common.Checker, error) {
+	return &GPUDefaultRuntimeChecker{
+		name:     config.GPUDefaultRuntimeCheckerName,
+		required: spec.RequireGPUDefaultRuntime,
+		allowCDI: spec.AllowCDI,
+	}, nil
+}
+
+func (c *GPUDefaultRuntimeChecker) Name() string {
+	return c.name
+}
+
+func (c *GPUDefaultRuntimeChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	info, ok := data.(*collector.ContainerRuntimeInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid ContainerRuntimeInfo type")
+	}
+
+	result := config.ContainerRuntimeCheckItems[c.name]
+	result.Status = consts.StatusNormal
+	if !info.NvidiaGPU || !c.required {
+		result.Curr = "Skipped"
+		return &result, nil
+	}
+
+	var abnormal []string
+	var detail string
+	var curr []string
+	for _, daemon := range info.Daemons {
+		if daemon.GPUDefault() || (c.allowCDI && daemon.CDIEnabled) {
+			curr = append(curr, fmt.Sprintf("%s:%s", daemon.Name, daemon.DefaultRuntime))
+			continue
+		}
+		abnormal = append(abnormal, daemon.Name)
+		source := daemon.ConfigPath
+		if source == "" {
+			source = "built-in default"
+		}
+		detail += fmt.Sprintf("default runtime of %s is %s (%s), it does not inject the GPU devices\n", daemon.Name, daemon.DefaultRuntime, source)
+	}
+
+	if len(abnormal) > 0 {
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(abnormal, ",")
+		result.Curr = fmt.Sprintf("%d runtimes without GPU", len(abnormal))
+		result.Detail = detail
+		logrus.WithField("component", "container_runtime").Errorf("%s failed: %s", c.name, detail)
+	} else {
+		result.Curr = strings.Join(curr, ",")
+	}
+	return &result, nil
+}
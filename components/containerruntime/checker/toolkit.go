@@ -0,0 +1,115 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/containerruntime/collector"
+	"github.com/scitix/sichek/components/containerruntime/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+// ToolkitInstalledChecker flags a GPU node without the nvidia-container-toolkit hook.
+type ToolkitInstalledChecker struct {
+	name string
+}
+
+func NewToolkitInstalledChecker(spec *config.ContainerRuntimeSpec) (common.Checker, error) {
+	return &ToolkitInstalledChecker{name: config.ToolkitInstalledCheckerName}, nil
+}
+
+func (c *ToolkitInstalledChecker) Name() string {
+	return c.name
+}
+
+func (c *ToolkitInstalledChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	info, ok := data.(*collector.ContainerRuntimeInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid ContainerRuntimeInfo type")
+	}
+
+	result := config.ContainerRuntimeCheckItems[c.name]
+	result.Status = consts.StatusNormal
+	if !info.NvidiaGPU || info.Toolkit == nil {
+		result.Curr = "Skipped"
+		return &result, nil
+	}
+
+	if !info.Toolkit.Installed() {
+		result.Status = consts.StatusAbnormal
+		result.Curr = "NotInstalled"
+		result.Detail = fmt.Sprintf("neither nvidia-container-runtime-hook nor nvidia-ctk is installed in %s", strings.Join(collector.ToolkitDirs, ", "))
+		logrus.WithField("component", "container_runtime").Errorf("%s failed: %s", c.name, result.Detail)
+		return &result, nil
+	}
+	result.Curr = info.Toolkit.HookPath
+	if result.Curr == "" {
+		result.Curr = info.Toolkit.CTKPath
+	}
+	return &result, nil
+}
+
+// ToolkitVersionChecker compares the version of nvidia-container-toolkit with the spec.
+type ToolkitVersionChecker struct {
+	name    string
+	version string
+}
+
+func NewToolkitVersionChecker(spec *config.ContainerRuntimeSpec) (common.Checker, error) {
+	return &ToolkitVersionChecker{
+		name:    config.ToolkitVersionCheckerName,
+		version: spec.NvidiaToolkit.Version,
+	}, nil
+}
+
+func (c *ToolkitVersionChecker) Name() string {
+	return c.name
+}
+
+func (c *ToolkitVersionChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	info, ok := data.(*collector.ContainerRuntimeInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid ContainerRuntimeInfo type")
+	}
+
+	result := config.ContainerRuntimeCheckItems[c.name]
+	result.Status = consts.StatusNormal
+	result.Spec = c.version
+	// a missing toolkit is reported by the installed checker
+	if !info.NvidiaGPU || info.Toolkit == nil || !info.Toolkit.Installed() || c.version == "" {
+		result.Curr = "Skipped"
+		return &result, nil
+	}
+
+	result.Curr = info.Toolkit.Version
+	if info.Toolkit.Version == "" {
+		result.Status = consts.StatusAbnormal
+		result.Curr = "Unknown"
+		result.Detail = fmt.Sprintf("failed to read the nvidia-container-toolkit version: %s", info.Toolkit.Error)
+	} else if !common.CompareVersion(c.version, info.Toolkit.Version) {
+		result.Status = consts.StatusAbnormal
+		result.Detail = fmt.Sprintf("nvidia-container-toolkit version is %s, spec is %s", info.Toolkit.Version, c.version)
+	}
+	if result.Status == consts.StatusAbnormal {
+		logrus.WithField("component", "container_runtime").Errorf("%s failed: %s", c.name, result.Detail)
+	}
+	return &result, nil
+}
@@ -0,0 +1,304 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/scitix/sichek/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// The sockets and configs of the runtimes, vars so that tests can point them at a fake tree.
+var (
+	ContainerdSocketPath = "/run/containerd/containerd.sock"
+	ContainerdConfigPath = "/etc/containerd/config.toml"
+	DockerSocketPath     = "/var/run/docker.sock"
+	DockerConfigPath     = "/etc/docker/daemon.json"
+	// ToolkitDirs are searched for the binaries of nvidia-container-toolkit,
+	// the GPU operator installs them under /usr/local/nvidia/toolkit.
+	ToolkitDirs = []string{"/usr/bin", "/usr/local/bin", "/usr/local/nvidia/toolkit"}
+)
+
+const (
+	RuntimeContainerd = "containerd"
+	RuntimeDocker     = "docker"
+
+	toolkitHookBinary = "nvidia-container-runtime-hook"
+	toolkitCTKBinary  = "nvidia-ctk"
+	toolkitCLIBinary  = "nvidia-container-cli"
+
+	// defaultProbeTimeout bounds a probe when the spec sets no response time.
+	defaultProbeTimeout = 5 * time.Second
+)
+
+// RuntimeDaemon is a container runtime daemon found on the node.
+type RuntimeDaemon struct {
+	Name            string  `json:"name" metric:"-"`
+	Socket          string  `json:"socket" metric:"-"`
+	Version         string  `json:"version,omitempty" metric:"-"`
+	Responsive      bool    `json:"responsive"`
+	ResponseSeconds float64 `json:"response_seconds"`
+	Error           string  `json:"error,omitempty" metric:"-"`
+	// ConfigPath is the config the default runtime is read from, empty if the
+	// daemon runs with its built-in defaults.
+	ConfigPath     string `json:"config_path,omitempty" metric:"-"`
+	DefaultRuntime string `json:"default_runtime" metric:"-"`
+	// DefaultRuntimeBinary is the binary of the default runtime when the
+	// config sets one, e.g. /usr/bin/nvidia-container-runtime.
+	DefaultRuntimeBinary string `json:"default_runtime_binary,omitempty" metric:"-"`
+	CDIEnabled           bool   `json:"cdi_enabled"`
+}
+
+// GPUDefault reports whether the default runtime is the nvidia runtime, which
+// injects the GPU devices into every container.
+func (d *RuntimeDaemon) GPUDefault() bool {
+	return strings.Contains(d.DefaultRuntime, "nvidia") || strings.Contains(filepath.Base(d.DefaultRuntimeBinary), "nvidia")
+}
+
+// NvidiaToolkit is the nvidia-container-toolkit installed on the node.
+type NvidiaToolkit struct {
+	HookPath string `json:"hook_path,omitempty"`
+	CTKPath  string `json:"ctk_path,omitempty"`
+	Version  string `json:"version,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Installed reports whether the OCI hook or, for CDI, nvidia-ctk is installed.
+func (t *NvidiaToolkit) Installed() bool {
+	return t.HookPath != "" || t.CTKPath != ""
+}
+
+type ContainerRuntimeInfo struct {
+	Time      time.Time        `json:"time"`
+	NvidiaGPU bool             `json:"nvidia_gpu"`
+	Daemons   []*RuntimeDaemon `json:"daemons"`
+	Toolkit   *NvidiaToolkit   `json:"nvidia_toolkit,omitempty"`
+	// PLEG is nil when the node is not a Kubernetes node.
+	PLEG   *KubeletPLEG `json:"kubelet_pleg,omitempty"`
+	Errors []string     `json:"errors,omitempty"`
+}
+
+func (i *ContainerRuntimeInfo) JSON() (string, error) {
+	b, err := common.JSON(i)
+	return string(b), err
+}
+
+// RuntimeExist reports whether the socket of containerd or docker exists.
+func RuntimeExist() bool {
+	for _, socket := range []string{ContainerdSocketPath, DockerSocketPath} {
+		if _, err := os.Stat(hostfs.Path(socket)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainerRuntimeCollector probes containerd and docker, reads their default
+// runtime, the nvidia-container-toolkit installed and the kubelet PLEG latency.
+type ContainerRuntimeCollector struct {
+	name string
+	// probeTimeout is a time.Duration, the spec reload updates it while the
+	// collector runs.
+	probeTimeout atomic.Int64
+	pleg         *plegCollector
+}
+
+func NewContainerRuntimeCollector(probeTimeout time.Duration) (*ContainerRuntimeCollector, error) {
+	c := &ContainerRuntimeCollector{
+		name: "ContainerRuntimeCollector",
+		pleg: newPLEGCollector(),
+	}
+	c.SetProbeTimeout(probeTimeout)
+	return c, nil
+}
+
+func (c *ContainerRuntimeCollector) Name() string {
+	return c.name
+}
+
+// SetProbeTimeout updates the timeout of the socket probes, e.g. after a spec reload.
+func (c *ContainerRuntimeCollector) SetProbeTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = defaultProbeTimeout
+	}
+	c.probeTimeout.Store(int64(timeout))
+}
+
+func (c *ContainerRuntimeCollector) Collect(ctx context.Context) (*ContainerRuntimeInfo, error) {
+	info := &ContainerRuntimeInfo{
+		Time:      time.Now(),
+		NvidiaGPU: utils.IsNvidiaGPUExist(),
+	}
+
+	if socket := hostfs.Path(ContainerdSocketPath); exists(socket) {
+		daemon := &RuntimeDaemon{Name: RuntimeContainerd, Socket: ContainerdSocketPath}
+		c.probe(ctx, daemon, func(ctx context.Context) (string, error) { return probeContainerd(ctx, socket) })
+		if err := readContainerdConfig(daemon); err != nil {
+			info.Errors = append(info.Errors, err.Error())
+		}
+		info.Daemons = append(info.Daemons, daemon)
+	}
+	if socket := hostfs.Path(DockerSocketPath); exists(socket) {
+		daemon := &RuntimeDaemon{Name: RuntimeDocker, Socket: DockerSocketPath}
+		c.probe(ctx, daemon, func(ctx context.Context) (string, error) { return probeDocker(ctx, socket) })
+		if err := readDockerConfig(daemon); err != nil {
+			info.Errors = append(info.Errors, err.Error())
+		}
+		info.Daemons = append(info.Daemons, daemon)
+	}
+
+	if info.NvidiaGPU {
+		info.Toolkit = collectToolkit(ctx)
+	}
+	info.PLEG = c.pleg.Collect(ctx)
+	return info, nil
+}
+
+// probe runs the probe of the daemon within the probe timeout and records how
+// long the daemon took to answer.
+func (c *ContainerRuntimeCollector) probe(ctx context.Context, daemon *RuntimeDaemon, probe func(context.Context) (string, error)) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(c.probeTimeout.Load()))
+	defer cancel()
+	start := time.Now()
+	version, err := probe(ctx)
+	daemon.ResponseSeconds = time.Since(start).Seconds()
+	if err != nil {
+		daemon.Error = err.Error()
+		logrus.WithField("component", "container_runtime").Warnf("probe %s at %s failed: %v", daemon.Name, daemon.Socket, err)
+		return
+	}
+	daemon.Responsive = true
+	daemon.Version = version
+}
+
+// probeContainerd calls the gRPC health service containerd serves on its socket.
+func probeContainerd(ctx context.Context, socket string) (string, error) {
+	conn, err := grpc.NewClient("unix://"+socket, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return "", fmt.Errorf("connect containerd: %w", err)
+	}
+	defer conn.Close()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return "", fmt.Errorf("containerd health check: %w", err)
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return "", fmt.Errorf("containerd health status is %s", resp.GetStatus())
+	}
+	return "", nil
+}
+
+// probeDocker reads the version of the docker engine on its socket.
+func probeDocker(ctx context.Context, socket string) (string, error) {
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		},
+	}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://docker/version", nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("docker version: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("docker version: %s", resp.Status)
+	}
+	var version struct {
+		Version string `json:"Version"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&version); err != nil {
+		return "", fmt.Errorf("decode docker version: %w", err)
+	}
+	return version.Version, nil
+}
+
+// collectToolkit looks for the binaries of nvidia-container-toolkit and reads
+// its version from nvidia-ctk, or from the hook or nvidia-container-cli of
+// the releases without it.
+func collectToolkit(ctx context.Context) *NvidiaToolkit {
+	toolkit := &NvidiaToolkit{
+		HookPath: findToolkitBinary(toolkitHookBinary),
+		CTKPath:  findToolkitBinary(toolkitCTKBinary),
+	}
+	candidates := []string{toolkit.CTKPath, toolkit.HookPath, findToolkitBinary(toolkitCLIBinary)}
+	var errs []string
+	for _, binary := range candidates {
+		if binary == "" {
+			continue
+		}
+		output, err := utils.ExecCommand(ctx, binary, "--version")
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if version := ParseToolkitVersion(string(output)); version != "" {
+			toolkit.Version = version
+			return toolkit
+		}
+		errs = append(errs, fmt.Sprintf("no version in the output of `%s --version`", binary))
+	}
+	toolkit.Error = strings.Join(errs, "; ")
+	return toolkit
+}
+
+// findToolkitBinary returns the host path of the binary, empty if it is not installed.
+func findToolkitBinary(name string) string {
+	for _, dir := range ToolkitDirs {
+		path := filepath.Join(dir, name)
+		if exists(hostfs.Path(path)) {
+			return path
+		}
+	}
+	return ""
+}
+
+var toolkitVersionRegexp = regexp.MustCompile(`(?i)version:?\s+v?(\d+(?:\.\d+)+)`)
+
+// ParseToolkitVersion extracts the version from the output of `nvidia-ctk --version`
+// ("NVIDIA Container Toolkit CLI version 1.14.3"), of the hook or of
+// `nvidia-container-cli --version` ("cli-version: 1.14.3").
+func ParseToolkitVersion(output string) string {
+	if m := toolkitVersionRegexp.FindStringSubmatch(output); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
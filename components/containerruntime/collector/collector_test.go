@@ -0,0 +1,237 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestParseContainerdConfig(t *testing.T) {
+	data := `version = 2
+imports = ["/etc/containerd/conf.d/*.toml"]
+
+[plugins]
+  [plugins."io.containerd.grpc.v1.cri".containerd]
+    default_runtime_name = "nvidia" # set by nvidia-ctk
+    snapshotter = "overlayfs"
+
+    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.nvidia]
+      runtime_type = "io.containerd.runc.v2"
+      [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.nvidia.options]
+        BinaryName = "/usr/local/nvidia/toolkit/nvidia-container-runtime"
+
+    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc.options]
+      SystemdCgroup = true
+`
+	cfg := &ContainerdConfig{}
+	ParseContainerdConfig(data, cfg)
+	assert.Equal(t, 2, cfg.Version)
+	assert.Equal(t, "nvidia", cfg.DefaultRuntime)
+	assert.Equal(t, "/usr/local/nvidia/toolkit/nvidia-container-runtime", cfg.Binaries["nvidia"])
+	assert.Equal(t, []string{"/etc/containerd/conf.d/*.toml"}, cfg.Imports)
+	assert.False(t, cfg.CDIEnabled())
+
+	// containerd 2.x enables CDI by default, an import turns it off
+	cfg = &ContainerdConfig{}
+	ParseContainerdConfig("version = 3\n[plugins.'io.containerd.cri.v1.runtime'.containerd]\n  default_runtime_name = 'runc'\n", cfg)
+	assert.Equal(t, "runc", cfg.DefaultRuntime)
+	assert.True(t, cfg.CDIEnabled())
+	ParseContainerdConfig("[plugins.'io.containerd.cri.v1.runtime']\n  enable_cdi = false\n", cfg)
+	assert.False(t, cfg.CDIEnabled())
+}
+
+func TestReadRuntimeConfigs(t *testing.T) {
+	root := t.TempDir()
+	oldContainerd, oldDocker := ContainerdConfigPath, DockerConfigPath
+	ContainerdConfigPath = filepath.Join(root, "containerd", "config.toml")
+	DockerConfigPath = filepath.Join(root, "docker", "daemon.json")
+	defer func() { ContainerdConfigPath, DockerConfigPath = oldContainerd, oldDocker }()
+
+	// without config the daemons run runc
+	daemon := &RuntimeDaemon{Name: RuntimeContainerd}
+	require.NoError(t, readContainerdConfig(daemon))
+	assert.Equal(t, "runc", daemon.DefaultRuntime)
+	assert.False(t, daemon.GPUDefault())
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "containerd", "conf.d"), 0o755))
+	require.NoError(t, os.WriteFile(ContainerdConfigPath, []byte("version = 2\nimports = [\"conf.d/*.toml\"]\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "containerd", "conf.d", "nvidia.toml"), []byte(`
+[plugins."io.containerd.grpc.v1.cri".containerd]
+  default_runtime_name = "nvidia"
+[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.nvidia.options]
+  BinaryName = "/usr/bin/nvidia-container-runtime"
+`), 0o644))
+	daemon = &RuntimeDaemon{Name: RuntimeContainerd}
+	require.NoError(t, readContainerdConfig(daemon))
+	assert.Equal(t, "nvidia", daemon.DefaultRuntime)
+	assert.Equal(t, "/usr/bin/nvidia-container-runtime", daemon.DefaultRuntimeBinary)
+	assert.True(t, daemon.GPUDefault())
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(DockerConfigPath), 0o755))
+	require.NoError(t, os.WriteFile(DockerConfigPath, []byte(`{
+  "default-runtime": "gpu",
+  "runtimes": {"gpu": {"path": "/usr/bin/nvidia-container-runtime", "runtimeArgs": []}},
+  "features": {"cdi": true}
+}`), 0o644))
+	daemon = &RuntimeDaemon{Name: RuntimeDocker}
+	require.NoError(t, readDockerConfig(daemon))
+	assert.Equal(t, "gpu", daemon.DefaultRuntime)
+	assert.True(t, daemon.GPUDefault())
+	assert.True(t, daemon.CDIEnabled)
+}
+
+func TestParseToolkitVersion(t *testing.T) {
+	assert.Equal(t, "1.14.3", ParseToolkitVersion("NVIDIA Container Toolkit CLI version 1.14.3\ncommit: d167812ce3a55ec04ae2582eff1654ec812f42e1\n"))
+	assert.Equal(t, "1.17.0", ParseToolkitVersion("NVIDIA Container Runtime Hook version 1.17.0\ncommit: 5bc0315\n"))
+	assert.Equal(t, "1.13.5", ParseToolkitVersion("cli-version: 1.13.5\nlib-version: 1.13.5\nbuild date: 2023-07-18T11:37+00:00\n"))
+	assert.Equal(t, "", ParseToolkitVersion("nvidia-ctk: command not found"))
+}
+
+const kubeletMetrics = `# HELP kubelet_pleg_relist_duration_seconds [ALPHA] Duration in seconds for relisting pods in PLEG.
+# TYPE kubelet_pleg_relist_duration_seconds histogram
+kubelet_pleg_relist_duration_seconds_bucket{le="0.005"} 900
+kubelet_pleg_relist_duration_seconds_bucket{le="0.01"} 980
+kubelet_pleg_relist_duration_seconds_bucket{le="0.1"} 995
+kubelet_pleg_relist_duration_seconds_bucket{le="1"} 1000
+kubelet_pleg_relist_duration_seconds_bucket{le="+Inf"} 1000
+kubelet_pleg_relist_duration_seconds_sum 3.2
+kubelet_pleg_relist_duration_seconds_count 1000
+# TYPE kubelet_pleg_last_seen_seconds gauge
+kubelet_pleg_last_seen_seconds 1.7e+09
+kubelet_running_pods 12
+`
+
+func TestParsePLEGMetrics(t *testing.T) {
+	hist, lastSeen := ParsePLEGMetrics(kubeletMetrics)
+	require.NotNil(t, hist)
+	assert.Equal(t, 1.7e9, lastSeen)
+	assert.Equal(t, float64(1000), hist.Count)
+	require.Len(t, hist.Buckets, 5)
+	assert.Equal(t, 0.1, hist.Quantile(0.99))
+	assert.Equal(t, 0.005, hist.Quantile(0.5))
+
+	// 100 relists since the previous collection, 20 of them slower than 1s
+	next := &Histogram{Count: 1100, Buckets: []Bucket{{0.005, 960}, {0.01, 1050}, {0.1, 1075}, {1, 1080}, {math.Inf(1), 1100}}}
+	window := next.Sub(hist)
+	assert.Equal(t, float64(100), window.Count)
+	assert.Equal(t, 1.0, window.Quantile(0.99), "the largest finite bound when the quantile is in +Inf")
+
+	// a restart of the kubelet resets the counters
+	assert.Same(t, hist, hist.Sub(next))
+}
+
+type fakeKubelet struct {
+	metrics string
+	message string
+}
+
+func (f *fakeKubelet) GetKubeletMetrics(ctx context.Context) ([]byte, error) {
+	return []byte(f.metrics), nil
+}
+
+func (f *fakeKubelet) GetCurrNode(ctx context.Context) (*v1.Node, error) {
+	return &v1.Node{Status: v1.NodeStatus{Conditions: []v1.NodeCondition{
+		{Type: v1.NodeReady, Status: v1.ConditionFalse, Message: f.message},
+	}}}, nil
+}
+
+func TestPLEGCollector(t *testing.T) {
+	source := &fakeKubelet{metrics: kubeletMetrics}
+	p := &plegCollector{source: source, now: func() time.Time { return time.Unix(1700000030, 0) }}
+	pleg := p.Collect(context.Background())
+	require.NotNil(t, pleg)
+	assert.Equal(t, 0.1, pleg.RelistP99Seconds)
+	assert.Equal(t, float64(1000), pleg.Relists)
+	assert.InDelta(t, 30, pleg.LastSeenAgeSeconds, 0.001)
+	assert.Empty(t, pleg.NotHealthy)
+
+	// no relist since the previous collection
+	source.message = "container runtime is down, PLEG is not healthy: pleg was last seen active 3m10s ago; threshold is 3m0s"
+	pleg = p.Collect(context.Background())
+	assert.Equal(t, float64(0), pleg.Relists)
+	assert.Equal(t, float64(0), pleg.RelistP99Seconds)
+	assert.Contains(t, pleg.NotHealthy, "PLEG is not healthy")
+}
+
+// socketDir returns a short directory, the path of a unix socket is limited to 108 bytes.
+func socketDir(t *testing.T) string {
+	dir, err := os.MkdirTemp("", "crt")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func TestProbeContainerd(t *testing.T) {
+	socket := filepath.Join(socketDir(t), "containerd.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = probeContainerd(ctx, socket)
+	assert.NoError(t, err)
+
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	_, err = probeContainerd(ctx, socket)
+	assert.ErrorContains(t, err, "NOT_SERVING")
+}
+
+func TestProbeDocker(t *testing.T) {
+	socket := filepath.Join(socketDir(t), "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/version" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"Version":"24.0.7","ApiVersion":"1.43"}`))
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	c, err := NewContainerRuntimeCollector(time.Second)
+	require.NoError(t, err)
+	daemon := &RuntimeDaemon{Name: RuntimeDocker, Socket: socket}
+	c.probe(context.Background(), daemon, func(ctx context.Context) (string, error) { return probeDocker(ctx, socket) })
+	assert.True(t, daemon.Responsive)
+	assert.Equal(t, "24.0.7", daemon.Version)
+
+	server.Close()
+	daemon = &RuntimeDaemon{Name: RuntimeDocker, Socket: socket}
+	c.probe(context.Background(), daemon, func(ctx context.Context) (string, error) { return probeDocker(ctx, socket) })
+	assert.False(t, daemon.Responsive)
+	assert.NotEmpty(t, daemon.Error)
+}
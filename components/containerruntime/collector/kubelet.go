@@ -0,0 +1,232 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"bufio"
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scitix/sichek/pkg/k8s"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	plegRelistMetric   = "kubelet_pleg_relist_duration_seconds"
+	plegLastSeenMetric = "kubelet_pleg_last_seen_seconds"
+	// plegQuantile is the quantile of the relist duration compared with the spec.
+	plegQuantile = 0.99
+	// plegNotHealthy is the message of the Ready condition of the node once
+	// the PLEG of the kubelet stalled for 3 minutes.
+	plegNotHealthy = "PLEG is not healthy"
+)
+
+// KubeletPLEG is the latency of the pod lifecycle event generator of the
+// kubelet, which relists the containers of the runtime every second.
+type KubeletPLEG struct {
+	// RelistP99Seconds is the p99 of the relist duration since the previous
+	// collection, or since the kubelet started on the first one.
+	RelistP99Seconds float64 `json:"relist_p99_seconds"`
+	Relists          float64 `json:"relists"`
+	// LastSeenAgeSeconds is the time since the last relist, 0 if the kubelet
+	// does not export it.
+	LastSeenAgeSeconds float64 `json:"last_seen_age_seconds"`
+	// NotHealthy is the message of the Ready condition of the node when the
+	// kubelet reports its PLEG not healthy.
+	NotHealthy string `json:"not_healthy,omitempty" metric:"-"`
+	Error      string `json:"error,omitempty" metric:"-"`
+}
+
+// kubeletSource reads the metrics of the kubelet and the node it runs.
+type kubeletSource interface {
+	GetKubeletMetrics(ctx context.Context) ([]byte, error)
+	GetCurrNode(ctx context.Context) (*v1.Node, error)
+}
+
+type plegCollector struct {
+	source kubeletSource
+	// prev is the relist histogram of the previous collection.
+	prev *Histogram
+	now  func() time.Time
+}
+
+func newPLEGCollector() *plegCollector {
+	return &plegCollector{now: time.Now}
+}
+
+// Collect returns nil when the node is not a Kubernetes node.
+func (p *plegCollector) Collect(ctx context.Context) *KubeletPLEG {
+	if p.source == nil {
+		client, err := k8s.NewClient()
+		if err != nil || client == nil {
+			logrus.WithField("component", "container_runtime").Debugf("skip the kubelet PLEG, no kubernetes client: %v", err)
+			return nil
+		}
+		p.source = client
+	}
+
+	pleg := &KubeletPLEG{}
+	var errs []string
+	if node, err := p.source.GetCurrNode(ctx); err != nil {
+		errs = append(errs, err.Error())
+	} else {
+		for _, cond := range node.Status.Conditions {
+			if cond.Type == v1.NodeReady && strings.Contains(cond.Message, plegNotHealthy) {
+				pleg.NotHealthy = cond.Message
+			}
+		}
+	}
+
+	data, err := p.source.GetKubeletMetrics(ctx)
+	if err != nil {
+		errs = append(errs, err.Error())
+	} else {
+		hist, lastSeen := ParsePLEGMetrics(string(data))
+		if hist != nil {
+			window := hist.Sub(p.prev)
+			pleg.Relists = window.Count
+			pleg.RelistP99Seconds = window.Quantile(plegQuantile)
+			p.prev = hist
+		}
+		if lastSeen > 0 {
+			pleg.LastSeenAgeSeconds = math.Max(0, p.now().Sub(time.Unix(0, int64(lastSeen*float64(time.Second)))).Seconds())
+		}
+	}
+	pleg.Error = strings.Join(errs, "; ")
+	return pleg
+}
+
+// Bucket is a cumulative bucket of a Prometheus histogram.
+type Bucket struct {
+	UpperBound float64
+	Count      float64
+}
+
+// Histogram is a Prometheus histogram, its buckets sorted by upper bound.
+type Histogram struct {
+	Buckets []Bucket
+	Count   float64
+}
+
+// Sub returns the observations of h made after prev, h itself when prev is
+// nil or the counters were reset by a restart of the kubelet.
+func (h *Histogram) Sub(prev *Histogram) *Histogram {
+	if prev == nil || prev.Count > h.Count || len(prev.Buckets) != len(h.Buckets) {
+		return h
+	}
+	delta := &Histogram{Count: h.Count - prev.Count, Buckets: make([]Bucket, len(h.Buckets))}
+	for i, b := range h.Buckets {
+		delta.Buckets[i] = Bucket{UpperBound: b.UpperBound, Count: b.Count - prev.Buckets[i].Count}
+	}
+	return delta
+}
+
+// Quantile estimates the quantile q as the upper bound of the first bucket
+// holding it, the largest finite bound when it falls in the +Inf bucket.
+func (h *Histogram) Quantile(q float64) float64 {
+	if h.Count <= 0 || len(h.Buckets) == 0 {
+		return 0
+	}
+	rank := q * h.Count
+	largest := 0.0
+	for _, b := range h.Buckets {
+		if math.IsInf(b.UpperBound, 1) {
+			break
+		}
+		largest = b.UpperBound
+		if b.Count >= rank {
+			return b.UpperBound
+		}
+	}
+	return largest
+}
+
+// ParsePLEGMetrics reads the relist duration histogram and the time of the
+// last relist from the Prometheus text exposition of the kubelet metrics.
+func ParsePLEGMetrics(data string) (*Histogram, float64) {
+	var hist *Histogram
+	var lastSeen float64
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, labels, value, ok := parseSample(line)
+		if !ok {
+			continue
+		}
+		switch name {
+		case plegRelistMetric + "_bucket":
+			le, err := strconv.ParseFloat(labels["le"], 64)
+			if err != nil {
+				continue
+			}
+			if hist == nil {
+				hist = &Histogram{}
+			}
+			hist.Buckets = append(hist.Buckets, Bucket{UpperBound: le, Count: value})
+		case plegRelistMetric + "_count":
+			if hist == nil {
+				hist = &Histogram{}
+			}
+			hist.Count = value
+		case plegLastSeenMetric:
+			lastSeen = value
+		}
+	}
+	if hist != nil {
+		sort.Slice(hist.Buckets, func(i, j int) bool { return hist.Buckets[i].UpperBound < hist.Buckets[j].UpperBound })
+	}
+	return hist, lastSeen
+}
+
+// parseSample splits a sample line, e.g. `name{le="0.005"} 12`, the
+// timestamp following the value is ignored.
+func parseSample(line string) (string, map[string]string, float64, bool) {
+	name, rest := line, ""
+	labels := make(map[string]string)
+	if i := strings.IndexByte(line, '{'); i >= 0 {
+		j := strings.LastIndexByte(line, '}')
+		if j < i {
+			return "", nil, 0, false
+		}
+		name, rest = line[:i], line[j+1:]
+		for _, pair := range strings.Split(line[i+1:j], ",") {
+			key, value, ok := strings.Cut(pair, "=")
+			if ok {
+				labels[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"`)
+			}
+		}
+	} else if i := strings.IndexAny(line, " \t"); i >= 0 {
+		name, rest = line[:i], line[i:]
+	}
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return "", nil, 0, false
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return "", nil, 0, false
+	}
+	return name, labels, value, true
+}
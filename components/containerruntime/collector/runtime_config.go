@@ -0,0 +1,204 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/scitix/sichek/pkg/hostfs"
+)
+
+// defaultRuntime is the runtime containerd and docker use when their config sets none.
+const defaultRuntime = "runc"
+
+// ContainerdConfig is the part of the containerd config deciding how the
+// containers of the CRI plugin get the GPU devices.
+type ContainerdConfig struct {
+	Version        int
+	DefaultRuntime string
+	// Binaries maps the runtimes to the BinaryName of their options.
+	Binaries map[string]string
+	// CDI is nil when the config does not set enable_cdi.
+	CDI     *bool
+	Imports []string
+}
+
+// ParseContainerdConfig reads the keys of a containerd TOML config it needs
+// line by line: default_runtime_name of the CRI plugin, the BinaryName of the
+// runtimes, enable_cdi and the imports. The values set by data override the
+// ones already in cfg, so that the imported files can be merged.
+func ParseContainerdConfig(data string, cfg *ContainerdConfig) {
+	if cfg.Binaries == nil {
+		cfg.Binaries = make(map[string]string)
+	}
+	table := ""
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(stripTOMLComment(line))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			table = strings.TrimSpace(strings.Trim(line, "[]"))
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		switch {
+		case table == "" && key == "version":
+			cfg.Version, _ = strconv.Atoi(value)
+		case table == "" && key == "imports":
+			cfg.Imports = append(cfg.Imports, tomlStringArray(value)...)
+		case key == "default_runtime_name" && strings.HasSuffix(table, ".containerd"):
+			cfg.DefaultRuntime = tomlString(value)
+		case key == "enable_cdi":
+			cdi := value == "true"
+			cfg.CDI = &cdi
+		case key == "BinaryName":
+			if name := runtimeOfOptions(table); name != "" {
+				cfg.Binaries[name] = tomlString(value)
+			}
+		}
+	}
+}
+
+// runtimeOfOptions returns the runtime of an options table, e.g. nvidia for
+// plugins."io.containerd.grpc.v1.cri".containerd.runtimes.nvidia.options.
+func runtimeOfOptions(table string) string {
+	_, rest, ok := strings.Cut(table, ".runtimes.")
+	if !ok || !strings.HasSuffix(rest, ".options") {
+		return ""
+	}
+	return tomlString(strings.TrimSuffix(rest, ".options"))
+}
+
+// CDIEnabled reports whether the CRI plugin injects the CDI devices, which
+// containerd 2.x, i.e. config version 3, does by default.
+func (c *ContainerdConfig) CDIEnabled() bool {
+	if c.CDI != nil {
+		return *c.CDI
+	}
+	return c.Version >= 3
+}
+
+// readContainerdConfig fills the default runtime of containerd from its
+// config and the files it imports.
+func readContainerdConfig(daemon *RuntimeDaemon) error {
+	daemon.DefaultRuntime = defaultRuntime
+	data, err := os.ReadFile(hostfs.Path(ContainerdConfigPath))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read containerd config: %w", err)
+	}
+	cfg := &ContainerdConfig{}
+	ParseContainerdConfig(string(data), cfg)
+	for _, pattern := range cfg.Imports {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(ContainerdConfigPath), pattern)
+		}
+		matches, _ := filepath.Glob(hostfs.Path(pattern))
+		for _, match := range matches {
+			if imported, err := os.ReadFile(match); err == nil {
+				ParseContainerdConfig(string(imported), cfg)
+			}
+		}
+	}
+	daemon.ConfigPath = ContainerdConfigPath
+	if cfg.DefaultRuntime != "" {
+		daemon.DefaultRuntime = cfg.DefaultRuntime
+	}
+	daemon.DefaultRuntimeBinary = cfg.Binaries[daemon.DefaultRuntime]
+	daemon.CDIEnabled = cfg.CDIEnabled()
+	return nil
+}
+
+// DockerConfig is the part of /etc/docker/daemon.json deciding how the
+// containers get the GPU devices.
+type DockerConfig struct {
+	DefaultRuntime string `json:"default-runtime"`
+	Runtimes       map[string]struct {
+		Path string `json:"path"`
+	} `json:"runtimes"`
+	Features map[string]bool `json:"features"`
+}
+
+// readDockerConfig fills the default runtime of docker from daemon.json.
+func readDockerConfig(daemon *RuntimeDaemon) error {
+	daemon.DefaultRuntime = defaultRuntime
+	data, err := os.ReadFile(hostfs.Path(DockerConfigPath))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read docker config: %w", err)
+	}
+	var cfg DockerConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("parse %s: %w", DockerConfigPath, err)
+	}
+	daemon.ConfigPath = DockerConfigPath
+	if cfg.DefaultRuntime != "" {
+		daemon.DefaultRuntime = cfg.DefaultRuntime
+	}
+	daemon.DefaultRuntimeBinary = cfg.Runtimes[daemon.DefaultRuntime].Path
+	daemon.CDIEnabled = cfg.Features["cdi"]
+	return nil
+}
+
+// stripTOMLComment drops the comment of a line, a # inside a string is kept.
+func stripTOMLComment(line string) string {
+	quote := byte(0)
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func tomlString(value string) string {
+	return strings.Trim(strings.TrimSpace(value), `"'`)
+}
+
+// tomlStringArray parses an array of strings written on one line.
+func tomlStringArray(value string) []string {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, "[") || !strings.HasSuffix(value, "]") {
+		return nil
+	}
+	var items []string
+	for _, item := range strings.Split(strings.Trim(value, "[]"), ",") {
+		if item = tomlString(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+)
+
+const (
+	RuntimeResponsiveCheckerName = "container-runtime-responsive"
+	ToolkitInstalledCheckerName  = "container-runtime-nvidia-toolkit"
+	ToolkitVersionCheckerName    = "container-runtime-nvidia-toolkit-version"
+	GPUDefaultRuntimeCheckerName = "container-runtime-gpu-default"
+	KubeletPLEGCheckerName       = "container-runtime-kubelet-pleg"
+)
+
+// ContainerRuntimeCheckItems is a map of check items for the container runtimes and the GPU hook they run
+var ContainerRuntimeCheckItems = map[string]common.CheckerResult{
+	RuntimeResponsiveCheckerName: {
+		Name:        RuntimeResponsiveCheckerName,
+		Description: "Check if containerd and docker answer on their socket within the response time of the spec",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelCritical,
+		Detail:      "All container runtimes are responsive",
+		ErrorName:   "ContainerRuntimeUnresponsive",
+		Suggestion:  "Check `systemctl status containerd` and the containerd log, restart containerd if it hangs",
+	},
+	ToolkitInstalledCheckerName: {
+		Name:        ToolkitInstalledCheckerName,
+		Description: "Check if the nvidia-container-toolkit hook is installed on a GPU node",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelCritical,
+		Detail:      "nvidia-container-toolkit is installed",
+		ErrorName:   "NvidiaContainerToolkitMissing",
+		Suggestion:  "Install nvidia-container-toolkit or check the toolkit daemonset of the GPU operator",
+	},
+	ToolkitVersionCheckerName: {
+		Name:        ToolkitVersionCheckerName,
+		Description: "Check if the version of nvidia-container-toolkit matches the spec",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelWarning,
+		Detail:      "The nvidia-container-toolkit version matches the spec",
+		ErrorName:   "NvidiaContainerToolkitVersionMismatch",
+		Suggestion:  "Upgrade nvidia-container-toolkit to the version of the spec",
+	},
+	GPUDefaultRuntimeCheckerName: {
+		Name:        GPUDefaultRuntimeCheckerName,
+		Description: "Check if the default runtime of containerd and docker injects the GPU devices, i.e. it is the nvidia runtime or CDI is enabled",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelCritical,
+		Detail:      "The default runtime handles the GPU devices",
+		ErrorName:   "DefaultRuntimeNotGPU",
+		Suggestion:  "Run `nvidia-ctk runtime configure --runtime=containerd --set-as-default` and restart containerd",
+	},
+	KubeletPLEGCheckerName: {
+		Name:        KubeletPLEGCheckerName,
+		Description: "Check if the kubelet PLEG relists the containers faster than the latency of the spec and is reported healthy",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelWarning,
+		Detail:      "The kubelet PLEG is healthy",
+		ErrorName:   "KubeletPLEGSlow",
+		Suggestion:  "Check the load of containerd and the number of containers on the node, the node turns NotReady once PLEG stalls for 3 minutes",
+	},
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/httpclient"
+	"github.com/sirupsen/logrus"
+)
+
+type ContainerRuntimeSpecs struct {
+	Specs map[string]*ContainerRuntimeSpec `json:"container_runtime" yaml:"container_runtime"`
+}
+
+type ContainerRuntimeSpec struct {
+	// MaxResponseTime bounds the time containerd and docker take to answer a
+	// probe on their socket.
+	MaxResponseTime common.Duration `json:"max_response_time" yaml:"max_response_time"`
+	NvidiaToolkit   ToolkitSpec     `json:"nvidia_toolkit" yaml:"nvidia_toolkit"`
+	// RequireGPUDefaultRuntime requires the default runtime to inject the GPU
+	// devices, a cluster selecting the nvidia runtime with a RuntimeClass
+	// turns it off.
+	RequireGPUDefaultRuntime bool `json:"require_gpu_default_runtime" yaml:"require_gpu_default_runtime"`
+	// AllowCDI accepts a runtime with CDI enabled in place of the nvidia runtime.
+	AllowCDI bool `json:"allow_cdi" yaml:"allow_cdi"`
+	// MaxPLEGRelistLatency bounds the p99 of the kubelet PLEG relist duration.
+	MaxPLEGRelistLatency common.Duration `json:"max_pleg_relist_latency" yaml:"max_pleg_relist_latency"`
+}
+
+type ToolkitSpec struct {
+	// Version is compared with common.CompareVersion, e.g. ">=1.14.0".
+	Version string `json:"version" yaml:"version"`
+}
+
+// EnsureSpec ensures that `file` contains the "default" container runtime
+// spec entry, potentially downloading it from OSS.
+func EnsureSpec(file string) (string, error) {
+	const comp = "container_runtime/spec"
+	const specID = "default"
+
+	var s ContainerRuntimeSpecs
+	if err := common.LoadSpec(file, &s); err == nil {
+		if s.Specs != nil {
+			if _, ok := s.Specs[specID]; ok {
+				logrus.WithField("component", comp).Infof("spec for container runtime %s already in %s, skipping download", specID, file)
+				return file, nil
+			}
+		}
+	} else {
+		logrus.WithField("component", comp).Debugf("LoadSpec failed during EnsureSpec (may be new file): %v", err)
+	}
+
+	// Download {SICHEK_SPEC_URL}/container_runtime/default.yaml
+	ossBase := httpclient.GetSichekSpecURL()
+	if ossBase == "" {
+		return file, fmt.Errorf("EnsureSpec: container runtime %s not in spec and SICHEK_SPEC_URL not set", specID)
+	}
+
+	tmpFile := filepath.Join(os.TempDir(), fmt.Sprintf("container_runtime_%s.yaml", specID))
+	url := fmt.Sprintf("%s/%s/%s.yaml", strings.TrimRight(ossBase, "/"), consts.ComponentNameContainerRuntime, specID)
+
+	logrus.WithField("component", comp).Infof("downloading container runtime spec from %s", url)
+	if err := common.DownloadSpecFile(url, tmpFile, comp); err != nil {
+		return file, fmt.Errorf("EnsureSpec: download failed: %w", err)
+	}
+
+	var downloaded ContainerRuntimeSpecs
+	if err := common.LoadSpec(tmpFile, &downloaded); err != nil {
+		return file, fmt.Errorf("EnsureSpec: parse downloaded spec: %w", err)
+	}
+
+	if err := common.MergeAndWriteSpec(
+		file,
+		"container_runtime",
+		downloaded.Specs,
+		func(c *ContainerRuntimeSpecs) map[string]*ContainerRuntimeSpec { return c.Specs },
+		func(c *ContainerRuntimeSpecs, m map[string]*ContainerRuntimeSpec) { c.Specs = m },
+	); err != nil {
+		return file, fmt.Errorf("EnsureSpec: merge failed: %w", err)
+	}
+
+	logrus.WithField("component", comp).Infof("merged container runtime %s spec into %s", specID, file)
+	return file, nil
+}
+
+// LoadSpec reads the container runtime multi-spec YAML at `file`, ensures the
+// "default" entry is present and returns it.
+func LoadSpec(file string) (*ContainerRuntimeSpec, error) {
+	if file == "" {
+		return nil, fmt.Errorf("container runtime spec file path is empty")
+	}
+
+	if _, err := EnsureSpec(file); err != nil {
+		logrus.WithField("component", "container_runtime/spec").Warnf("EnsureSpec failed: %v", err)
+	}
+
+	return common.FilterSpec(file, "container_runtime", "default",
+		func(c *ContainerRuntimeSpecs, id string) (*ContainerRuntimeSpec, bool) {
+			spec, ok := c.Specs[id]
+			return spec, ok
+		},
+	)
+}
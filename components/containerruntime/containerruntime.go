@@ -0,0 +1,333 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package containerruntime
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/containerruntime/checker"
+	"github.com/scitix/sichek/components/containerruntime/collector"
+	"github.com/scitix/sichek/components/containerruntime/config"
+	runtimemetrics "github.com/scitix/sichek/components/containerruntime/metrics"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/scitix/sichek/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+type component struct {
+	ctx           context.Context
+	cancel        context.CancelFunc
+	componentName string
+	cfg           *config.ContainerRuntimeUserConfig
+	cfgMutex      sync.Mutex
+	spec          *config.ContainerRuntimeSpec
+	collector     *collector.ContainerRuntimeCollector
+	checkers      []common.Checker
+	specMtx       sync.RWMutex
+	metrics       *runtimemetrics.ContainerRuntimeMetrics
+
+	cacheMtx    sync.RWMutex
+	cacheBuffer []*common.Result
+	cacheInfo   []common.Info
+	currIndex   int64
+	cacheSize   int64
+
+	service *common.CommonService
+}
+
+var (
+	runtimeComponent     *component
+	runtimeComponentOnce sync.Once
+)
+
+func NewComponent(cfgFile string, specFile string, ignoredCheckers []string) (common.Component, error) {
+	var err error
+	runtimeComponentOnce.Do(func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic occurred when create component container_runtime: %v", r)
+			}
+		}()
+		runtimeComponent, err = newComponent(cfgFile, specFile, ignoredCheckers)
+	})
+	return runtimeComponent, err
+}
+
+func newComponent(cfgFile string, specFile string, ignoredCheckers []string) (comp *component, err error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		if err != nil {
+			cancel()
+		}
+	}()
+
+	cfg := &config.ContainerRuntimeUserConfig{}
+	err = common.LoadUserConfig(cfgFile, cfg)
+	if err != nil || cfg.ContainerRuntime == nil {
+		logrus.WithField("component", "container_runtime").Warnf("get user config failed or container_runtime config is nil, using default config")
+		cfg.ContainerRuntime = &config.ContainerRuntimeConfig{
+			QueryInterval: common.Duration{Duration: 60 * time.Second},
+			CacheSize:     5,
+		}
+	}
+	if len(ignoredCheckers) > 0 {
+		cfg.ContainerRuntime.IgnoredCheckers = ignoredCheckers
+	}
+
+	spec, specErr := config.LoadSpec(specFile)
+	if specErr != nil {
+		logrus.WithField("component", "container_runtime").Warnf("failed to load spec %s: %v", specFile, specErr)
+	}
+
+	var probeTimeout time.Duration
+	if spec != nil {
+		probeTimeout = spec.MaxResponseTime.Duration
+	}
+	collectorInst, err := collector.NewContainerRuntimeCollector(probeTimeout)
+	if err != nil {
+		logrus.WithField("component", "container_runtime").Errorf("create container runtime collector failed: %v", err)
+		return nil, err
+	}
+
+	cacheSize := cfg.ContainerRuntime.CacheSize
+	if cacheSize == 0 {
+		cacheSize = 5
+	}
+
+	comp = &component{
+		ctx:           ctx,
+		cancel:        cancel,
+		componentName: consts.ComponentNameContainerRuntime,
+		cfg:           cfg,
+		spec:          spec,
+		collector:     collectorInst,
+		cacheBuffer:   make([]*common.Result, cacheSize),
+		cacheInfo:     make([]common.Info, cacheSize),
+		cacheSize:     cacheSize,
+	}
+
+	comp.checkers, err = common.CheckersOrSpecMissing(consts.ComponentNameContainerRuntime, spec, specErr, func(spec *config.ContainerRuntimeSpec) ([]common.Checker, error) {
+		return checker.NewCheckers(cfg, spec)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.ContainerRuntime.EnableMetrics {
+		comp.metrics = runtimemetrics.NewContainerRuntimeMetrics()
+	}
+	service := common.NewCommonService(ctx, cfg, comp.componentName, comp.GetTimeout(), comp.HealthCheck)
+	comp.service = service
+
+	return comp, nil
+}
+
+func (c *component) Name() string {
+	return c.componentName
+}
+
+func (c *component) HealthCheck(ctx context.Context) (*common.Result, error) {
+	timer := common.NewTimer(fmt.Sprintf("%s-HealthCheck-Cost", c.componentName))
+	runtimeInfo, err := common.Collect(ctx, c.componentName, c.collector.Collect)
+	if err != nil {
+		logrus.WithField("component", "container_runtime").Errorf("failed to collect container runtime info: %v", err)
+		return nil, err
+	}
+	timer.Mark("container-runtime-collect")
+
+	if c.metrics != nil {
+		c.metrics.ExportMetrics(runtimeInfo)
+	}
+
+	c.specMtx.RLock()
+	checkers := c.checkers
+	c.specMtx.RUnlock()
+	result := common.Check(ctx, c.componentName, runtimeInfo, checkers)
+	timer.Mark("container-runtime-check")
+
+	c.cacheMtx.Lock()
+	c.cacheBuffer[c.currIndex] = result
+	c.cacheInfo[c.currIndex] = runtimeInfo
+	c.currIndex = (c.currIndex + 1) % c.cacheSize
+	c.cacheMtx.Unlock()
+
+	if result.Status == consts.StatusAbnormal && consts.LevelPriority[result.Level] > consts.LevelPriority[consts.LevelInfo] {
+		logrus.WithField("component", "container_runtime").Errorf("Health Check Failed")
+	} else {
+		logrus.WithField("component", "container_runtime").Infof("Health Check PASSED")
+	}
+
+	return result, nil
+}
+
+func (c *component) CacheResults() ([]*common.Result, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	return c.cacheBuffer, nil
+}
+
+func (c *component) LastResult() (*common.Result, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	result := c.cacheBuffer[c.currIndex]
+	if c.currIndex == 0 {
+		result = c.cacheBuffer[c.cacheSize-1]
+	}
+	return result, nil
+}
+
+func (c *component) CacheInfos() ([]common.Info, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	return c.cacheInfo, nil
+}
+
+func (c *component) LastInfo() (common.Info, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	var info common.Info
+	if c.currIndex == 0 {
+		info = c.cacheInfo[c.cacheSize-1]
+	} else {
+		info = c.cacheInfo[c.currIndex-1]
+	}
+	return info, nil
+}
+
+func (c *component) Start() <-chan *common.Result {
+	return c.service.Start()
+}
+
+func (c *component) Stop() error {
+	return c.service.Stop()
+}
+
+func (c *component) Update(cfg common.ComponentUserConfig) error {
+	c.cfgMutex.Lock()
+	configPointer, ok := cfg.(*config.ContainerRuntimeUserConfig)
+	if !ok {
+		c.cfgMutex.Unlock()
+		return fmt.Errorf("update wrong config type for container_runtime")
+	}
+	c.cfg = configPointer
+	c.cfgMutex.Unlock()
+	return c.service.Update(cfg)
+}
+
+// UpdateSpec reloads the response time, toolkit version and PLEG latency of the spec and rebuilds the checkers from them.
+func (c *component) UpdateSpec(specFile string) error {
+	spec, err := config.LoadSpec(specFile)
+	if err != nil {
+		return fmt.Errorf("load container runtime spec %s: %w", specFile, err)
+	}
+	if spec == nil {
+		return fmt.Errorf("container runtime spec is nil after loading from %s", specFile)
+	}
+	c.cfgMutex.Lock()
+	cfg := c.cfg
+	c.cfgMutex.Unlock()
+	checkers, err := checker.NewCheckers(cfg, spec)
+	if err != nil {
+		return err
+	}
+	c.specMtx.Lock()
+	c.spec = spec
+	c.checkers = checkers
+	c.collector.SetProbeTimeout(spec.MaxResponseTime.Duration)
+	c.specMtx.Unlock()
+	logrus.WithField("component", "container_runtime").Infof("reloaded spec from %s", specFile)
+	return nil
+}
+
+func (c *component) Status() bool {
+	return c.service.Status()
+}
+
+func (c *component) GetTimeout() time.Duration {
+	return c.cfg.GetQueryInterval().Duration
+}
+
+func (c *component) PrintInfo(info common.Info, result *common.Result, summaryPrint bool) bool {
+	checkAllPassed := true
+	if result.Status == consts.StatusAbnormal && consts.LevelPriority[result.Level] > consts.LevelPriority[consts.LevelInfo] {
+		checkAllPassed = false
+	}
+
+	utils.PrintTitle("Container Runtime", "-")
+
+	runtimeInfo, ok := info.(*collector.ContainerRuntimeInfo)
+	if !ok || runtimeInfo == nil {
+		printer.Println("No container runtime info available")
+		return checkAllPassed
+	}
+
+	if len(runtimeInfo.Daemons) > 0 {
+		printer.Printf("%-12s %-10s %-10s %-10s %-40s %-4s\n", "Runtime", "Version", "Responsive", "Latency", "Default Runtime", "CDI")
+		for _, daemon := range runtimeInfo.Daemons {
+			defaultRuntime := daemon.DefaultRuntime
+			if daemon.DefaultRuntimeBinary != "" {
+				defaultRuntime = fmt.Sprintf("%s (%s)", daemon.DefaultRuntime, daemon.DefaultRuntimeBinary)
+			}
+			latency := time.Duration(daemon.ResponseSeconds * float64(time.Second)).Round(time.Millisecond)
+			printer.Printf("%-12s %-10s %-10t %-10s %-40s %-4t\n", daemon.Name, daemon.Version, daemon.Responsive, latency, defaultRuntime, daemon.CDIEnabled)
+		}
+	} else {
+		printer.Println("No containerd or docker found")
+	}
+	if toolkit := runtimeInfo.Toolkit; toolkit != nil {
+		if toolkit.Installed() {
+			printer.Printf("nvidia-container-toolkit: %s (hook %s, nvidia-ctk %s)\n", toolkit.Version, toolkit.HookPath, toolkit.CTKPath)
+		} else {
+			printer.Println("nvidia-container-toolkit: not installed")
+		}
+	}
+	if pleg := runtimeInfo.PLEG; pleg != nil {
+		printer.Printf("Kubelet PLEG: relist p99 %s over %.0f relists, last relist %s ago\n",
+			time.Duration(pleg.RelistP99Seconds*float64(time.Second)), pleg.Relists,
+			time.Duration(pleg.LastSeenAgeSeconds*float64(time.Second)).Round(time.Second))
+		if pleg.Error != "" {
+			printer.Printf("%s%s%s\n", consts.Yellow, pleg.Error, consts.Reset)
+		}
+	}
+	for _, e := range runtimeInfo.Errors {
+		printer.Printf("%s%s%s\n", consts.Yellow, e, consts.Reset)
+	}
+
+	hasErrors := false
+	if result != nil {
+		for _, res := range result.Checkers {
+			if res.Status != consts.StatusNormal && res.Level != consts.LevelInfo {
+				if !hasErrors {
+					printer.Printf("\nErrors Events:\n")
+					hasErrors = true
+				}
+				printer.Printf("\tEvent: %s%s%s -> %s\n", consts.LevelColor(res.Level), res.ErrorName, consts.Reset, res.Detail)
+			}
+		}
+	}
+	if !hasErrors {
+		printer.Printf("\nErrors Events:\n\tNo Container Runtime Events Detected\n")
+	}
+
+	printer.Println()
+	return checkAllPassed
+}
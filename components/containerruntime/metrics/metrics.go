@@ -0,0 +1,53 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package metrics
+
+import (
+	"github.com/scitix/sichek/components/containerruntime/collector"
+	common "github.com/scitix/sichek/metrics"
+)
+
+const (
+	MetricPrefix = "sichek_container_runtime"
+	TagPrefix    = "json"
+)
+
+type ContainerRuntimeMetrics struct {
+	DaemonGauge *common.GaugeVecMetricExporter
+	PLEGGauge   *common.GaugeVecMetricExporter
+}
+
+func NewContainerRuntimeMetrics() *ContainerRuntimeMetrics {
+	return &ContainerRuntimeMetrics{
+		DaemonGauge: common.NewGaugeVecMetricExporter(MetricPrefix+"_daemon", []string{"runtime"}),
+		PLEGGauge:   common.NewGaugeVecMetricExporter(MetricPrefix+"_kubelet_pleg", nil),
+	}
+}
+
+// ExportMetrics exports the probe of the runtimes, e.g.
+// sichek_container_runtime_daemon_response_seconds, and the kubelet PLEG
+// latency, e.g. sichek_container_runtime_kubelet_pleg_relist_p99_seconds.
+func (m *ContainerRuntimeMetrics) ExportMetrics(info *collector.ContainerRuntimeInfo) {
+	if info == nil {
+		return
+	}
+	for _, daemon := range info.Daemons {
+		m.DaemonGauge.ExportStruct(*daemon, []string{daemon.Name}, TagPrefix)
+	}
+	if info.PLEG != nil {
+		m.PLEGGauge.ExportStruct(*info.PLEG, nil, TagPrefix)
+	}
+}
@@ -18,36 +18,50 @@ package checker
 import (
 	"context"
 	"testing"
-	"time"
 
+	"github.com/scitix/sichek/components/cpu/collector"
 	"github.com/scitix/sichek/consts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCPUPerfChecker_Check(t *testing.T) {
-	_, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	// disable perfomance mode for testing
-	// echo performance > /sys/devices/system/cpu/cpu*/cpufreq/scaling_governor
-	err := setCPUMode("powersave")
-	if err != nil {
-		t.Fatalf("failed to set CPU governor to powersave: %v", err)
-	}
-	cpuPerformanceEnable, _ := checkCPUPerformance()
-	if cpuPerformanceEnable {
-		t.Fatalf("unexpected cpu_performance_enable")
-	}
-
 	cpuPerfChecker, err := NewCPUPerfChecker()
-	if err != nil {
-		t.Fatalf("failed to create CPUPerfChecker: %v", err)
-	}
+	require.NoError(t, err)
 
-	result, err := cpuPerfChecker.Check(context.Background(), nil)
-	if err != nil {
-		t.Fatalf("failed to check cpu performance: %v", err)
-	}
-	if result.Status != consts.StatusNormal {
-		t.Fatalf("unexpected result status: %v, expected: %v\n", result.Status, consts.StatusNormal)
+	output := &collector.CPUOutput{FreqInfo: collector.CPUFreqInfo{
+		Available: true,
+		Cores: []collector.CoreFreq{
+			{CPU: 0, Governor: "performance"},
+			{CPU: 1, Governor: "powersave"},
+			{CPU: 2, Governor: "powersave"},
+			{CPU: 3, Governor: "schedutil"},
+		},
+	}}
+	result, err := cpuPerfChecker.Check(context.Background(), output)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusAbnormal, result.Status)
+	assert.Equal(t, "1-3", result.Device)
+	require.Len(t, result.Remediations, 1)
+	assert.Equal(t, "performance", result.Remediations[0].Target)
+
+	for i := range output.FreqInfo.Cores {
+		output.FreqInfo.Cores[i].Governor = "performance"
 	}
-	t.Logf("result: %+v", result)
+	result, err = cpuPerfChecker.Check(context.Background(), output)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusNormal, result.Status)
+	assert.Empty(t, result.Remediations)
+
+	// Without cpufreq, e.g. in a VM, there is nothing to check.
+	result, err = cpuPerfChecker.Check(context.Background(), &collector.CPUOutput{})
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusNormal, result.Status)
+	assert.Equal(t, "NotSupported", result.Curr)
+}
+
+func TestFormatCPUList(t *testing.T) {
+	assert.Equal(t, "0-2,5,7-8", formatCPUList([]int{8, 0, 1, 2, 5, 7}))
+	assert.Equal(t, "", formatCPUList(nil))
 }
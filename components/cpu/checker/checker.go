@@ -29,6 +29,12 @@ func NewCheckers() ([]common.Checker, error) {
 	}
 	checkers = append(checkers, checker)
 
+	freqChecker, err := NewCPUFreqChecker()
+	if err != nil {
+		return nil, fmt.Errorf("create cpu frequency checker failed: %v", err)
+	}
+	checkers = append(checkers, freqChecker)
+
 	clockSyncSvc, err := NewClockSyncServiceChecker()
 	if err != nil {
 		return nil, fmt.Errorf("create clock sync service checker failed: %v", err)
@@ -0,0 +1,111 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/cpu/collector"
+	"github.com/scitix/sichek/components/cpu/config"
+	"github.com/scitix/sichek/consts"
+
+	"github.com/sirupsen/logrus"
+)
+
+const CPUFreqCheckerName = "cpu-frequency"
+
+// CPUFreqChecker reports what keeps the cores below their nominal frequency
+// even with the performance governor: a lowered scaling_max_freq, turbo
+// disabled, thermald, a RAPL power limit and cores being thermally throttled.
+type CPUFreqChecker struct {
+	name string
+}
+
+func NewCPUFreqChecker() (common.Checker, error) {
+	return &CPUFreqChecker{
+		name: CPUFreqCheckerName,
+	}, nil
+}
+
+func (c *CPUFreqChecker) Name() string {
+	return c.name
+}
+
+func (c *CPUFreqChecker) GetSpec() common.CheckerSpec {
+	return nil
+}
+
+func (c *CPUFreqChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	cpuOutput, ok := data.(*collector.CPUOutput)
+	if !ok {
+		return nil, fmt.Errorf("invalid data type, expected *collector.CPUOutput")
+	}
+
+	result := config.CPUCheckItems[CPUFreqCheckerName]
+	freq := &cpuOutput.FreqInfo
+	if !freq.Available {
+		result.Status = consts.StatusNormal
+		result.Curr = "NotSupported"
+		result.Detail = "cpufreq is not exposed on this node"
+		result.Suggestion = ""
+		return &result, nil
+	}
+
+	var reasons []string
+	var cappedCPUs, throttledCPUs []int
+	for _, core := range freq.Cores {
+		if core.HWMaxFreq > 0 && core.MaxFreq > 0 && core.MaxFreq < core.HWMaxFreq {
+			cappedCPUs = append(cappedCPUs, core.CPU)
+		}
+		if core.NewThrottles > 0 {
+			throttledCPUs = append(throttledCPUs, core.CPU)
+		}
+	}
+	if len(cappedCPUs) > 0 {
+		reasons = append(reasons, fmt.Sprintf("scaling_max_freq is pinned below cpuinfo_max_freq on CPUs %s", formatCPUList(cappedCPUs)))
+	}
+	if freq.Turbo == collector.TurboDisabled {
+		reasons = append(reasons, "turbo boost is disabled")
+	}
+	if freq.ThermaldActive {
+		reasons = append(reasons, "thermald is running and may cap the CPU frequency")
+	}
+	for _, cap := range freq.PowerCaps {
+		if cap.Capped() {
+			reasons = append(reasons, fmt.Sprintf("RAPL %s power is limited to %dW of %dW", cap.Zone, cap.LimitUW/1e6, cap.MaxLimitUW/1e6))
+		}
+	}
+	if len(throttledCPUs) > 0 {
+		reasons = append(reasons, fmt.Sprintf("CPUs %s were thermally throttled since the last check", formatCPUList(throttledCPUs)))
+		result.Device = formatCPUList(throttledCPUs)
+	}
+
+	if len(reasons) == 0 {
+		result.Status = consts.StatusNormal
+		result.Curr = "Unrestricted"
+		result.Detail = fmt.Sprintf("All %d CPUs may run at their max frequency", len(freq.Cores))
+		result.Suggestion = ""
+		return &result, nil
+	}
+	result.Status = consts.StatusAbnormal
+	result.Curr = "Restricted"
+	result.Detail = strings.Join(reasons, "; ")
+	logrus.WithField("checker", c.Name()).Warnf("CPU frequency restricted: %s", result.Detail)
+	return &result, nil
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/scitix/sichek/components/cpu/collector"
+	"github.com/scitix/sichek/consts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCPUFreqChecker(t *testing.T) {
+	healthy := func() collector.CPUFreqInfo {
+		return collector.CPUFreqInfo{
+			Available: true,
+			Turbo:     collector.TurboEnabled,
+			Cores: []collector.CoreFreq{
+				{CPU: 0, Governor: "performance", MaxFreq: 3800000, HWMaxFreq: 3800000},
+				{CPU: 1, Governor: "performance", MaxFreq: 3800000, HWMaxFreq: 3800000},
+			},
+			PowerCaps: []collector.PowerCap{{Zone: "package-0", Enabled: true, LimitUW: 350000000, MaxLimitUW: 350000000}},
+		}
+	}
+	tests := []struct {
+		name       string
+		mutate     func(*collector.CPUFreqInfo)
+		wantStatus string
+	}{
+		{"unrestricted", func(*collector.CPUFreqInfo) {}, consts.StatusNormal},
+		{"max freq pinned", func(f *collector.CPUFreqInfo) { f.Cores[1].MaxFreq = 2000000 }, consts.StatusAbnormal},
+		{"turbo disabled", func(f *collector.CPUFreqInfo) { f.Turbo = collector.TurboDisabled }, consts.StatusAbnormal},
+		{"thermald", func(f *collector.CPUFreqInfo) { f.ThermaldActive = true }, consts.StatusAbnormal},
+		{"power capped", func(f *collector.CPUFreqInfo) { f.PowerCaps[0].LimitUW = 200000000 }, consts.StatusAbnormal},
+		{"throttled", func(f *collector.CPUFreqInfo) { f.Cores[0].NewThrottles = 4 }, consts.StatusAbnormal},
+		{"not available", func(f *collector.CPUFreqInfo) { *f = collector.CPUFreqInfo{} }, consts.StatusNormal},
+	}
+
+	chk, err := NewCPUFreqChecker()
+	require.NoError(t, err)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := &collector.CPUOutput{FreqInfo: healthy()}
+			tt.mutate(&output.FreqInfo)
+			result, err := chk.Check(context.Background(), output)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, result.Status, result.Detail)
+		})
+	}
+}
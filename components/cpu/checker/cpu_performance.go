@@ -18,18 +18,24 @@ package checker
 import (
 	"context"
 	"fmt"
-	"os"
-	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/common/remediator"
+	"github.com/scitix/sichek/components/cpu/collector"
 	"github.com/scitix/sichek/components/cpu/config"
 	"github.com/scitix/sichek/consts"
 
 	"github.com/sirupsen/logrus"
 )
 
-const CPUPerfCheckerName = "cpu-performance"
+const (
+	CPUPerfCheckerName = "cpu-performance"
+
+	performanceGovernor = "performance"
+)
 
 type CPUPerfChecker struct {
 	name string
@@ -49,134 +55,82 @@ func (c *CPUPerfChecker) GetSpec() common.CheckerSpec {
 	return nil
 }
 
-// Check Checks if all CPUs are in "performance" mode
+// Check Checks if all CPUs are in "performance" mode. Setting the governor is
+// left to the remediation action of the result.
 func (c *CPUPerfChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
-	cpuPerformanceEnable, err := checkCPUPerformance()
-	if err != nil {
-		return nil, fmt.Errorf("fail to check cpu performance: %v", err)
+	cpuOutput, ok := data.(*collector.CPUOutput)
+	if !ok {
+		return nil, fmt.Errorf("invalid data type, expected *collector.CPUOutput")
 	}
 
 	result := config.CPUCheckItems[CPUPerfCheckerName]
+	freq := &cpuOutput.FreqInfo
+	if !freq.Available {
+		result.Status = consts.StatusNormal
+		result.Curr = "NotSupported"
+		result.Detail = "cpufreq is not exposed on this node, the governor is managed by the platform"
+		result.Suggestion = ""
+		return &result, nil
+	}
 
-	if !cpuPerformanceEnable {
-		err := setCPUMode("performance")
-		if err == nil {
-			cpuPerformanceEnable, err := checkCPUPerformance()
-			if err != nil {
-				return nil, fmt.Errorf("fail to check cpu performance2: %v", err)
-			}
-			if cpuPerformanceEnable {
-				result.Status = consts.StatusNormal
-				result.Detail = "Not all CPUs are in \"performance\" mode. Already set all CPUs to \"performance\" mode successfully"
-				result.Suggestion = ""
-				result.Status = consts.StatusAbnormal
-				result.Detail = "Not all CPUs are in \"performance\" mode. And failed to set all CPUs to \"performance\" mode"
-				logrus.WithFields(logrus.Fields{
-					"checker": c.Name(),
-					"target": "performance",
-				}).Errorf("CPU performance mode check abnormal: Not all CPUs achieved performance mode after setting")
-			}
-		} else {
-			result.Status = consts.StatusAbnormal
-			result.Curr = "NotAllEnabled"
-			result.Detail = fmt.Sprintf("Not all CPUs are in \"performance\" mode. And failed to set all CPUs to \"performance\" mode: %v", err)
-			logrus.WithFields(logrus.Fields{
-				"checker": c.Name(),
-				"curr_state": "NotAllEnabled",
-				"error": err,
-			}).Errorf("CPU performance mode check abnormal")
+	var cpus []int
+	governors := make(map[string]int)
+	for _, core := range freq.Cores {
+		if core.Governor != performanceGovernor {
+			cpus = append(cpus, core.CPU)
+			governors[core.Governor]++
 		}
-	} else {
+	}
+	if len(cpus) == 0 {
 		result.Status = consts.StatusNormal
 		result.Curr = "Enabled"
-		result.Detail = "All CPUs are in \"performance\" mode"
+		result.Detail = fmt.Sprintf("All %d CPUs are in \"performance\" mode", len(freq.Cores))
 		result.Suggestion = ""
+		return &result, nil
 	}
+
+	result.Status = consts.StatusAbnormal
+	result.Curr = "NotAllEnabled"
+	result.Device = formatCPUList(cpus)
+	result.Detail = fmt.Sprintf("%d of %d CPUs are not in \"performance\" mode (%s): %s",
+		len(cpus), len(freq.Cores), formatGovernors(governors), result.Device)
+	result.Remediations = append(result.Remediations, remediator.NewSetCPUGovernorAction(performanceGovernor))
+	logrus.WithFields(logrus.Fields{
+		"checker":    c.Name(),
+		"curr_state": result.Curr,
+	}).Errorf("CPU performance mode check abnormal: %s", result.Detail)
 	return &result, nil
 }
 
-func checkCPUPerformance() (bool, error) {
-	cpuPerformanceEnable := true
-	// Path pattern to check CPU governor
-	pattern := "/sys/devices/system/cpu/cpu*/cpufreq/scaling_governor"
-
-	// Get all files matching the pattern
-	files, err := filepath.Glob(pattern)
-	if err != nil {
-		cpuPerformanceEnable = false
-		err = fmt.Errorf("failed to list CPU governor files: %w", err)
-		return cpuPerformanceEnable, err
-	}
-
-	// If no governor files are found, return an error
-	if len(files) == 0 {
-		cpuPerformanceEnable = false
-		err = fmt.Errorf("no CPU governor files found")
-		return cpuPerformanceEnable, err
-	}
-
-	// Check each CPU's governor
-	var errCpus []string
-	for _, file := range files {
-		data, err := os.ReadFile(file)
-		if err != nil {
-			err = fmt.Errorf("failed to read %s: %w", file, err)
-			if errCpus == nil {
-				errCpus = make([]string, 0, len(files))
-			}
-			cpuPerformanceEnable = false
-			errCpus = append(errCpus, file)
-			fmt.Println(err)
-			continue
+// formatCPUList renders CPU ids as ranges, e.g. [0 1 2 5] -> "0-2,5".
+func formatCPUList(cpus []int) string {
+	sorted := append([]int(nil), cpus...)
+	sort.Ints(sorted)
+	var parts []string
+	for i := 0; i < len(sorted); {
+		j := i
+		for j+1 < len(sorted) && sorted[j+1] == sorted[j]+1 {
+			j++
 		}
-
-		// Trim whitespace and check the mode
-		cpuMode := strings.TrimSpace(string(data))
-		if cpuMode != "performance" {
-			if errCpus == nil {
-				errCpus = make([]string, 0, len(files))
-			}
-			cpuPerformanceEnable = false
-			errCpus = append(errCpus, file)
+		if i == j {
+			parts = append(parts, strconv.Itoa(sorted[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", sorted[i], sorted[j]))
 		}
+		i = j + 1
 	}
-	// ret := fmt.Errorf("the following CPUs is not in performance mode: %v", err_cpus)
-	return cpuPerformanceEnable, nil
+	return strings.Join(parts, ",")
 }
 
-func setCPUMode(mode string) error {
-	// Path pattern to check CPU governor
-	pattern := "/sys/devices/system/cpu/cpu*/cpufreq/scaling_governor"
-
-	// Get all files matching the pattern
-	files, err := filepath.Glob(pattern)
-	if err != nil {
-		err = fmt.Errorf("failed to list CPU governor files: %w", err)
-		return err
-	}
-
-	// If no governor files are found, return an error
-	if len(files) == 0 {
-		err = fmt.Errorf("no CPU governor files found")
-		return err
+func formatGovernors(governors map[string]int) string {
+	names := make([]string, 0, len(governors))
+	for name := range governors {
+		names = append(names, name)
 	}
-
-	// Check each CPU's governor
-	var ret error
-	for _, file := range files {
-		f, err := os.OpenFile(file, os.O_WRONLY, 0644)
-		if err != nil {
-			ret = fmt.Errorf("failed to open %s: %v", file, err)
-			continue
-		}
-
-		// Write mode(e.g. "performance") to the governor file
-		_, err = f.WriteString(mode)
-		if err != nil {
-			ret = fmt.Errorf("failed to write %s to %s: %v", mode, file, err)
-			logrus.WithField("component", "cpu").Errorf("%v", ret)
-			continue
-		}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%d", name, governors[name]))
 	}
-	return ret
+	return strings.Join(parts, ",")
 }
@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/pkg/hostfs"
 )
 
 type CPUOutput struct {
@@ -35,6 +36,7 @@ type CPUOutput struct {
 	Uptime      string      `json:"uptime"`
 	PTPInfo     PTPInfo     `json:"ptp_info"`
 	MCEInfo     MCEInfo     `json:"mce_info"`
+	FreqInfo    CPUFreqInfo `json:"freq_info"`
 }
 
 func (o *CPUOutput) JSON() (string, error) {
@@ -46,11 +48,14 @@ type Collector struct {
 	name        string
 	CPUArchInfo *CPUArchInfo `json:"cpu_arch_info"`
 	HostInfo    *HostInfo    `json:"host_info"`
+	// throttles keeps the thermal throttle count of each core between collections
+	throttles map[int]int64
 }
 
 func NewCpuCollector(ctx context.Context) (*Collector, error) {
 	collector := &Collector{
-		name: "CPUCollector",
+		name:      "CPUCollector",
+		throttles: make(map[int]int64),
 	}
 	collector.CPUArchInfo = &CPUArchInfo{}
 	if err := collector.CPUArchInfo.Get(ctx); err != nil {
@@ -85,6 +90,7 @@ func (c *Collector) Collect(ctx context.Context) (common.Info, error) {
 
 	cpuOutput.PTPInfo.Get()
 	cpuOutput.MCEInfo.Get()
+	cpuOutput.FreqInfo.Get(c.throttles)
 
 	return cpuOutput, nil
 }
@@ -92,7 +98,7 @@ func (c *Collector) Collect(ctx context.Context) (common.Info, error) {
 // GetUptime returns the system uptime as a formatted string.
 func GetUptime() (string, error) {
 	// Get uptime (Linux-specific example)
-	uptimeBytes, err := os.ReadFile("/proc/uptime")
+	uptimeBytes, err := os.ReadFile(hostfs.Path("/proc/uptime"))
 	if err != nil {
 		return "", fmt.Errorf("failed to ReadFile /proc/uptime: %w", err)
 	}
@@ -25,6 +25,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/scitix/sichek/pkg/hostfs"
 	"github.com/shirou/gopsutil/cpu"
 	"github.com/shirou/gopsutil/v4/host"
 	"github.com/sirupsen/logrus"
@@ -206,7 +207,7 @@ func (usage *Usage) Get() error {
 
 // getLoadAvg parses /proc/loadavg to get CPU load averages and runnable tasks
 func (usage *Usage) getLoadAvg() error {
-	file, err := os.Open("/proc/loadavg")
+	file, err := os.Open(hostfs.Path("/proc/loadavg"))
 	if err != nil {
 		return fmt.Errorf("failed to open /proc/loadavg: %v", err)
 	}
@@ -255,7 +256,7 @@ func (usage *Usage) getLoadAvg() error {
 
 // Read /proc/stat for usage statistics
 func (usage *Usage) getProcStats() error {
-	return usage.getProcStats_("/proc/stat")
+	return usage.getProcStats_(hostfs.Path("/proc/stat"))
 }
 
 func (usage *Usage) getProcStats_(filename string) error {
@@ -330,7 +331,7 @@ func (usage *Usage) getProcStats_(filename string) error {
 
 func GetTotalThreads() (int, error) {
 	threadCount := 0
-	procDir := "/proc"
+	procDir := hostfs.Path("/proc")
 
 	// Read all directories in /proc
 	dirEntries, err := os.ReadDir(procDir)
@@ -0,0 +1,163 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/scitix/sichek/pkg/hostfs"
+)
+
+const (
+	cpuSysfsPath      = "/sys/devices/system/cpu"
+	powercapSysfsPath = "/sys/class/powercap"
+
+	TurboEnabled  = "enabled"
+	TurboDisabled = "disabled"
+)
+
+// CPUFreqInfo holds the frequency scaling state of the cores and what may
+// keep them below their nominal frequency: a non performance governor, a
+// lowered max frequency, turbo disabled, thermald or a RAPL power limit.
+type CPUFreqInfo struct {
+	// Available is false on nodes without cpufreq, e.g. most VMs
+	Available      bool       `json:"available"`
+	Driver         string     `json:"driver,omitempty"`
+	Turbo          string     `json:"turbo,omitempty"`
+	ThermaldActive bool       `json:"thermald_active"`
+	Cores          []CoreFreq `json:"cores,omitempty"`
+	PowerCaps      []PowerCap `json:"power_caps,omitempty"`
+}
+
+// CoreFreq is the cpufreq state of one logical CPU, frequencies in kHz.
+type CoreFreq struct {
+	CPU       int    `json:"cpu"`
+	Governor  string `json:"governor"`
+	CurFreq   int64  `json:"cur_freq_khz"`
+	MinFreq   int64  `json:"scaling_min_freq_khz"`
+	MaxFreq   int64  `json:"scaling_max_freq_khz"`
+	HWMinFreq int64  `json:"cpuinfo_min_freq_khz"`
+	HWMaxFreq int64  `json:"cpuinfo_max_freq_khz"`
+	// ThrottleCount is the thermal throttle events of the core since boot,
+	// NewThrottles the ones since the previous collection.
+	ThrottleCount int64 `json:"throttle_count"`
+	NewThrottles  int64 `json:"new_throttles"`
+}
+
+// PowerCap is a RAPL power limit zone, e.g. package-0.
+type PowerCap struct {
+	Zone       string `json:"zone"`
+	Enabled    bool   `json:"enabled"`
+	LimitUW    int64  `json:"limit_uw"`
+	MaxLimitUW int64  `json:"max_limit_uw"`
+}
+
+// Capped reports whether the zone limits the power below its max.
+func (p *PowerCap) Capped() bool {
+	return p.Enabled && p.MaxLimitUW > 0 && p.LimitUW < p.MaxLimitUW
+}
+
+// Get populates CPUFreqInfo from the default sysfs paths. prevThrottles holds
+// the throttle counts of the previous collection, it is updated in place.
+func (f *CPUFreqInfo) Get(prevThrottles map[int]int64) {
+	f.getFromDirs(hostfs.Path(cpuSysfsPath), hostfs.Path(powercapSysfsPath), prevThrottles)
+	f.ThermaldActive = isServiceActive("thermald")
+}
+
+func (f *CPUFreqInfo) getFromDirs(cpuDir, powercapDir string, prevThrottles map[int]int64) {
+	f.Cores = nil
+	f.PowerCaps = nil
+	cpuDirs, _ := filepath.Glob(filepath.Join(cpuDir, "cpu[0-9]*"))
+	for _, dir := range cpuDirs {
+		cpu, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(dir), "cpu"))
+		if err != nil {
+			continue
+		}
+		freqDir := filepath.Join(dir, "cpufreq")
+		governor, err := readStringFile(filepath.Join(freqDir, "scaling_governor"))
+		if err != nil {
+			continue
+		}
+		core := CoreFreq{
+			CPU:           cpu,
+			Governor:      governor,
+			CurFreq:       readIntFile(filepath.Join(freqDir, "scaling_cur_freq")),
+			MinFreq:       readIntFile(filepath.Join(freqDir, "scaling_min_freq")),
+			MaxFreq:       readIntFile(filepath.Join(freqDir, "scaling_max_freq")),
+			HWMinFreq:     readIntFile(filepath.Join(freqDir, "cpuinfo_min_freq")),
+			HWMaxFreq:     readIntFile(filepath.Join(freqDir, "cpuinfo_max_freq")),
+			ThrottleCount: readIntFile(filepath.Join(dir, "thermal_throttle", "core_throttle_count")),
+		}
+		if prev, ok := prevThrottles[cpu]; ok && core.ThrottleCount > prev {
+			core.NewThrottles = core.ThrottleCount - prev
+		}
+		if prevThrottles != nil {
+			prevThrottles[cpu] = core.ThrottleCount
+		}
+		f.Cores = append(f.Cores, core)
+	}
+	sort.Slice(f.Cores, func(i, j int) bool { return f.Cores[i].CPU < f.Cores[j].CPU })
+	f.Available = len(f.Cores) > 0
+	if !f.Available {
+		return
+	}
+	f.Driver, _ = readStringFile(filepath.Join(cpuDir, "cpu0", "cpufreq", "scaling_driver"))
+
+	// intel_pstate exposes no_turbo, acpi-cpufreq and amd-pstate expose boost
+	f.Turbo = ""
+	if noTurbo, err := readStringFile(filepath.Join(cpuDir, "intel_pstate", "no_turbo")); err == nil {
+		f.Turbo = turboState(noTurbo == "0")
+	} else if boost, err := readStringFile(filepath.Join(cpuDir, "cpufreq", "boost")); err == nil {
+		f.Turbo = turboState(boost == "1")
+	}
+
+	zones, _ := filepath.Glob(filepath.Join(powercapDir, "intel-rapl:[0-9]*"))
+	for _, zone := range zones {
+		// only the package zones, subzones such as intel-rapl:0:0 are skipped
+		if strings.Count(filepath.Base(zone), ":") != 1 {
+			continue
+		}
+		name, err := readStringFile(filepath.Join(zone, "name"))
+		if err != nil {
+			continue
+		}
+		f.PowerCaps = append(f.PowerCaps, PowerCap{
+			Zone:       name,
+			Enabled:    readIntFile(filepath.Join(zone, "enabled")) == 1,
+			LimitUW:    readIntFile(filepath.Join(zone, "constraint_0_power_limit_uw")),
+			MaxLimitUW: readIntFile(filepath.Join(zone, "constraint_0_max_power_uw")),
+		})
+	}
+}
+
+func turboState(enabled bool) string {
+	if enabled {
+		return TurboEnabled
+	}
+	return TurboDisabled
+}
+
+func readStringFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
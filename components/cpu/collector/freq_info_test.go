@@ -0,0 +1,102 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scitix/sichek/pkg/hostfs/hostfstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeSysfsFile(t *testing.T, path, value string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(value+"\n"), 0644))
+}
+
+func TestCPUFreqInfoGetFromDirs(t *testing.T) {
+	cpuDir := t.TempDir()
+	powercapDir := t.TempDir()
+	for cpu, governor := range map[string]string{"cpu0": "performance", "cpu1": "powersave"} {
+		writeSysfsFile(t, filepath.Join(cpuDir, cpu, "cpufreq", "scaling_governor"), governor)
+		writeSysfsFile(t, filepath.Join(cpuDir, cpu, "cpufreq", "scaling_max_freq"), "3000000")
+		writeSysfsFile(t, filepath.Join(cpuDir, cpu, "cpufreq", "cpuinfo_max_freq"), "3800000")
+		writeSysfsFile(t, filepath.Join(cpuDir, cpu, "thermal_throttle", "core_throttle_count"), "5")
+	}
+	writeSysfsFile(t, filepath.Join(cpuDir, "cpu0", "cpufreq", "scaling_driver"), "intel_pstate")
+	writeSysfsFile(t, filepath.Join(cpuDir, "intel_pstate", "no_turbo"), "1")
+	writeSysfsFile(t, filepath.Join(powercapDir, "intel-rapl:0", "name"), "package-0")
+	writeSysfsFile(t, filepath.Join(powercapDir, "intel-rapl:0", "enabled"), "1")
+	writeSysfsFile(t, filepath.Join(powercapDir, "intel-rapl:0", "constraint_0_power_limit_uw"), "200000000")
+	writeSysfsFile(t, filepath.Join(powercapDir, "intel-rapl:0", "constraint_0_max_power_uw"), "350000000")
+	writeSysfsFile(t, filepath.Join(powercapDir, "intel-rapl:0:0", "name"), "dram")
+
+	throttles := make(map[int]int64)
+	f := &CPUFreqInfo{}
+	f.getFromDirs(cpuDir, powercapDir, throttles)
+
+	assert.True(t, f.Available)
+	assert.Equal(t, "intel_pstate", f.Driver)
+	assert.Equal(t, TurboDisabled, f.Turbo)
+	require.Len(t, f.Cores, 2)
+	assert.Equal(t, "powersave", f.Cores[1].Governor)
+	assert.Equal(t, int64(3000000), f.Cores[0].MaxFreq)
+	assert.Equal(t, int64(0), f.Cores[0].NewThrottles, "the first collection has no baseline")
+	require.Len(t, f.PowerCaps, 1)
+	assert.True(t, f.PowerCaps[0].Capped())
+
+	writeSysfsFile(t, filepath.Join(cpuDir, "cpu1", "thermal_throttle", "core_throttle_count"), "8")
+	f.getFromDirs(cpuDir, powercapDir, throttles)
+	assert.Equal(t, int64(0), f.Cores[0].NewThrottles)
+	assert.Equal(t, int64(3), f.Cores[1].NewThrottles)
+}
+
+func TestCPUFreqInfoGetFromDirs_NotAvailable(t *testing.T) {
+	f := &CPUFreqInfo{}
+	f.getFromDirs(t.TempDir(), t.TempDir(), nil)
+	assert.False(t, f.Available)
+	assert.Empty(t, f.Cores)
+}
+
+func TestCPUFreqInfoGetFixture(t *testing.T) {
+	hostfstest.Load(t, "h100-cx7")
+	hostfstest.WriteFile(t, "/sys/devices/system/cpu/cpu1/cpufreq/scaling_governor", "powersave\n")
+
+	var f CPUFreqInfo
+	f.Get(nil)
+	require.True(t, f.Available)
+	require.Len(t, f.Cores, 2)
+	assert.Equal(t, "intel_pstate", f.Driver)
+	assert.Equal(t, TurboEnabled, f.Turbo)
+	assert.Equal(t, "performance", f.Cores[0].Governor)
+	assert.Equal(t, "powersave", f.Cores[1].Governor)
+	assert.Equal(t, int64(3800000), f.Cores[0].HWMaxFreq)
+	require.Len(t, f.PowerCaps, 2)
+	assert.False(t, f.PowerCaps[0].Capped())
+
+	var m MCEInfo
+	m.Get()
+	assert.True(t, m.Available)
+	assert.Equal(t, int64(0), m.UncorrectedCount)
+
+	uptime, err := GetUptime()
+	require.NoError(t, err)
+	assert.NotEmpty(t, uptime)
+}
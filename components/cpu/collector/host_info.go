@@ -22,6 +22,7 @@ import (
 	"strings"
 
 	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/pkg/hostfs"
 	"github.com/scitix/sichek/pkg/utils"
 )
 
@@ -56,7 +57,7 @@ func (hostInfo *HostInfo) Get() error {
 	hostInfo.Hostname = hostname
 
 	// Get OS version (Linux-specific example)
-	osVersion, err := os.ReadFile("/etc/os-release")
+	osVersion, err := os.ReadFile(hostfs.Path("/etc/os-release"))
 	if err == nil {
 		for _, line := range strings.Split(string(osVersion), "\n") {
 			if strings.HasPrefix(line, "PRETTY_NAME") {
@@ -84,7 +85,7 @@ func (hostInfo *HostInfo) Get() error {
 // getSerialNumber reads the system serial number from DMI sysfs, falling back
 // to dmidecode. Returns "Unknown" when neither source is available.
 func getSerialNumber() string {
-	if data, err := os.ReadFile("/sys/class/dmi/id/product_serial"); err == nil {
+	if data, err := os.ReadFile(hostfs.Path("/sys/class/dmi/id/product_serial")); err == nil {
 		if sn := strings.TrimSpace(string(data)); sn != "" {
 			return sn
 		}
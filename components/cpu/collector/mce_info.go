@@ -20,6 +20,8 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+
+	"github.com/scitix/sichek/pkg/hostfs"
 )
 
 const mceSysfsPath = "/sys/devices/system/cpu/machinecheck"
@@ -33,7 +35,7 @@ type MCEInfo struct {
 
 // Get populates MCEInfo from the default sysfs path.
 func (m *MCEInfo) Get() {
-	m.getFromDir(mceSysfsPath)
+	m.getFromDir(hostfs.Path(mceSysfsPath))
 }
 
 // getFromDir reads machinecheck directories under the given path
@@ -30,7 +30,17 @@ var CPUCheckItems = map[string]common.CheckerResult{
 		Level:       consts.LevelWarning,
 		Detail:      "",
 		ErrorName:   "CPUPerfModeNotEnabled",
-		Suggestion:  "run `echo performance > /sys/devices/system/cpu/cpu*/cpufreq/scaling_governor` to set all cpus to performance mode. Ideally this will be done automatically online",
+		Suggestion:  "run `echo performance > /sys/devices/system/cpu/cpu*/cpufreq/scaling_governor` to set all cpus to performance mode. Run with --auto-fix to apply it online",
+	},
+	"cpu-frequency": {
+		Name:        "cpu-frequency",
+		Description: "Check if the CPUs may run at their max frequency: no pinned max frequency, turbo enabled, no thermald, power capping or thermal throttling",
+		Spec:        "Unrestricted",
+		Status:      "",
+		Level:       consts.LevelWarning,
+		Detail:      "",
+		ErrorName:   "CPUFrequencyRestricted",
+		Suggestion:  "restore scaling_max_freq to cpuinfo_max_freq, enable turbo in BIOS or intel_pstate/no_turbo, stop thermald, lift the RAPL power limit and check the CPU cooling",
 	},
 	"clock-sync-service": {
 		Name:        "clock-sync-service",
@@ -28,6 +28,7 @@ import (
 	"github.com/scitix/sichek/components/cpu/config"
 	"github.com/scitix/sichek/components/cpu/metrics"
 	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
 	"github.com/scitix/sichek/pkg/utils"
 	"github.com/sirupsen/logrus"
 )
@@ -135,7 +136,7 @@ func (c *component) Name() string {
 }
 
 func (c *component) HealthCheck(ctx context.Context) (*common.Result, error) {
-	info, err := c.collector.Collect(ctx)
+	info, err := common.Collect(ctx, c.componentName, c.collector.Collect)
 	if err != nil {
 		logrus.WithField("component", "cpu").Errorf("%v", err)
 		return nil, err
@@ -272,9 +273,9 @@ func (c *component) PrintInfo(info common.Info, result *common.Result, summaryPr
 	}
 	if summaryPrint {
 		if cpuInfo.HostInfo.MgmtIP != "" {
-			fmt.Printf("\nHostname: %s (MgmtIP: %s)\n\n", cpuInfo.HostInfo.Hostname, cpuInfo.HostInfo.MgmtIP)
+			printer.Printf("\nHostname: %s (MgmtIP: %s)\n\n", cpuInfo.HostInfo.Hostname, cpuInfo.HostInfo.MgmtIP)
 		} else {
-			fmt.Printf("\nHostname: %s\n\n", cpuInfo.HostInfo.Hostname)
+			printer.Printf("\nHostname: %s\n\n", cpuInfo.HostInfo.Hostname)
 		}
 		utils.PrintTitle("System", "-")
 		termWidth, err := utils.GetTerminalWidth()
@@ -282,16 +283,16 @@ func (c *component) PrintInfo(info common.Info, result *common.Result, summaryPr
 		if err == nil {
 			printInterval = termWidth / 3
 		}
-		fmt.Printf("%-*s%-*s\n", printInterval, osPrint, printInterval, modelNamePrint)
-		fmt.Printf("%-*s%-*s%-*s\n", printInterval, uptimePrint, printInterval, nuamNodes[0], printInterval, taskPrint)
+		printer.Printf("%-*s%-*s\n", printInterval, osPrint, printInterval, modelNamePrint)
+		printer.Printf("%-*s%-*s%-*s\n", printInterval, uptimePrint, printInterval, nuamNodes[0], printInterval, taskPrint)
 		if cpuInfo.CPUArchInfo.NumaNum > 1 {
-			fmt.Printf("%-*s%-*s%-*s\n", printInterval, consts.Green+""+consts.Reset, printInterval, nuamNodes[1], printInterval, loadAvgPrint)
+			printer.Printf("%-*s%-*s%-*s\n", printInterval, consts.Green+""+consts.Reset, printInterval, nuamNodes[1], printInterval, loadAvgPrint)
 		} else {
-			fmt.Printf("%-*s%-*s%-*s\n", printInterval, consts.Green+""+consts.Reset, printInterval, consts.Green+""+consts.Reset, printInterval, loadAvgPrint)
+			printer.Printf("%-*s%-*s%-*s\n", printInterval, consts.Green+""+consts.Reset, printInterval, consts.Green+""+consts.Reset, printInterval, loadAvgPrint)
 		}
 		// TODO: more numa node
-		fmt.Printf("%-*s%-*s\n", printInterval, consts.Green+""+consts.Reset, printInterval, performanceModePrint)
-		fmt.Println()
+		printer.Printf("%-*s%-*s\n", printInterval, consts.Green+""+consts.Reset, printInterval, performanceModePrint)
+		printer.Println()
 	}
 	return checkAllPassed
 }
@@ -27,6 +27,8 @@ type DmesgConfig struct {
 	QueryInterval common.Duration `json:"query_interval" yaml:"query_interval"`
 	CacheSize     int64           `json:"cache_size" yaml:"cache_size"`
 	SkipPercent   int64           `json:"skip_percent" yaml:"skip_percent"`
+	// MCEWindow is the period the decoded machine checks are counted per CPU over, 24h by default
+	MCEWindow common.Duration `json:"mce_window,omitempty" yaml:"mce_window,omitempty"`
 }
 
 func (c *DmesgUserConfig) GetQueryInterval() common.Duration {
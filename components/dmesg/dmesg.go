@@ -25,6 +25,7 @@ import (
 	"github.com/scitix/sichek/components/common"
 	"github.com/scitix/sichek/components/dmesg/config"
 	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
 	"github.com/scitix/sichek/pkg/utils"
 
 	"github.com/sirupsen/logrus"
@@ -41,6 +42,7 @@ type component struct {
 	kmsgReader *KmsgReader
 	eventCache *EventCache
 	kmsgOnce   sync.Once
+	stopOnce   sync.Once
 
 	cacheMtx          sync.RWMutex
 	cacheInfoBuffer   []common.Info
@@ -109,6 +111,7 @@ func newComponent(cfgFile string, specFile string, skipPercent int64) (comp comm
 	}
 
 	eventCache := NewEventCache(eventRules)
+	eventCache.SetMCEWindow(dmsgCfg.Dmesg.MCEWindow.Duration)
 
 	component := &component{
 		ctx:           ctx,
@@ -133,6 +136,7 @@ func (c *component) Name() string {
 }
 
 func (c *component) HealthCheck(ctx context.Context) (*common.Result, error) {
+	c.eventCache.AddMissed(c.kmsgReader.Missed())
 	result := c.eventCache.Drain()
 	result.Item = consts.ComponentNameDmesg
 	result.Time = time.Now()
@@ -190,15 +194,15 @@ func (c *component) Metrics(ctx context.Context, since time.Time) (interface{},
 func (c *component) Start() <-chan *common.Result {
 	c.kmsgOnce.Do(func() {
 		logrus.WithField("component", "dmesg").Info("start /dev/kmsg reader")
-		c.kmsgReader.Start(func(line string) {
-			c.eventCache.MatchLine(line)
-		})
+		c.kmsgReader.Start(c.eventCache.Match)
 	})
 	return c.service.Start()
 }
 
 func (c *component) Stop() error {
-	return c.service.Stop()
+	err := c.service.Stop()
+	c.stopOnce.Do(c.kmsgReader.Stop)
+	return err
 }
 
 func (c *component) Update(cfg common.ComponentUserConfig) error {
@@ -232,12 +236,12 @@ func (c *component) PrintInfo(info common.Info, result *common.Result, summaryPr
 
 	utils.PrintTitle("Dmesg", "-")
 	if len(dmesgEvent) == 0 {
-		fmt.Printf("%sNo Dmesg event detected%s\n", consts.Green, consts.Reset)
+		printer.Printf("%sNo Dmesg event detected%s\n", consts.Green, consts.Reset)
 		return checkAllPassed
 	}
-	fmt.Printf("%sDetected %d Types of Abnormal Dmesg Events:%s\n", consts.LevelColor(result.Level), len(dmesgEvent), consts.Reset)
+	printer.Printf("%sDetected %d Types of Abnormal Dmesg Events:%s\n", consts.LevelColor(result.Level), len(dmesgEvent), consts.Reset)
 	for n := range dmesgEvent {
-		fmt.Printf("\t%s%s%s Events:\n %s\n", consts.Yellow, n, consts.Reset, dmesgEvent[n])
+		printer.Printf("\t%s%s%s Events:\n %s\n", consts.Yellow, n, consts.Reset, dmesgEvent[n])
 	}
 	return checkAllPassed
 }
@@ -16,9 +16,12 @@ limitations under the License.
 package dmesg
 
 import (
+	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/scitix/sichek/components/common"
 	"github.com/scitix/sichek/consts"
@@ -27,6 +30,9 @@ import (
 
 const (
 	MaxDetailLines = 3
+	// KmsgMissedName is the event reported when kernel records were overwritten
+	// in the ring buffer before being matched, anomalies may have been missed.
+	KmsgMissedName = "KmsgRecordsMissed"
 )
 
 // RuntimeEventRule Config -> Runtime, only compile once at init
@@ -44,6 +50,10 @@ type EventCache struct {
 	// immutable
 	runtimeEventRules map[string]RuntimeEventRule
 
+	// mceDecoder decodes the machine checks, mceStats counts them per CPU across cycles
+	mceDecoder *MCEDecoder
+	mceStats   *MCEStats
+
 	// mutable per-cycle state
 	result          *common.Result
 	eventsResultMap map[string]*common.CheckerResult
@@ -67,24 +77,119 @@ func NewEventCache(eventRules common.EventRuleGroup) *EventCache {
 
 	eventCache := &EventCache{
 		runtimeEventRules: runtimeRules,
+		mceDecoder:        NewMCEDecoder(),
+		mceStats:          NewMCEStats(DefaultMCEWindow),
 	}
 
 	eventCache.reset()
 	return eventCache
 }
 
-func (c *EventCache) MatchLine(line string) {
+// Match matches a kernel record against the rules, the detail of a matched
+// event carries the precise kernel timestamp of the record.
+func (c *EventCache) Match(record KmsgRecord) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	for name, eventRule := range c.runtimeEventRules {
-		if eventRule.RegexObj.MatchString(line) {
-			logrus.WithField("EventCache", "MatchLine").Infof("matched line: %s for rule: %s", line, name)
-			c.add(name, line)
+		if eventRule.RegexObj.MatchString(record.Message) {
+			logrus.WithField("EventCache", "Match").Infof("matched record %d: %s for rule: %s", record.Seq, record.Message, name)
+			c.add(name, record.Stamp()+" "+record.Message)
+		}
+	}
+	for _, mce := range c.mceDecoder.Feed(record) {
+		c.addMCE(mce)
+	}
+}
+
+// SetMCEWindow sets the period the machine checks are counted per CPU over,
+// DefaultMCEWindow if not positive.
+func (c *EventCache) SetMCEWindow(window time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if window <= 0 {
+		window = DefaultMCEWindow
+	}
+	c.mceStats.window = window
+}
+
+// addMCE reports a decoded machine check as a corrected or an uncorrected
+// event, located by its CPU, socket, core, bank and memory channel.
+func (c *EventCache) addMCE(mce *MCERecord) {
+	c.mceStats.Add(mce)
+	name, level := MCECorrectedName, consts.LevelWarning
+	description := "corrected machine checks decoded from dmesg"
+	suggestion := "monitor the corrected errors of the CPU or DIMM and schedule a preventive maintenance if they keep growing"
+	if mce.Uncorrected() {
+		name, level = MCEUncorrectedName, consts.LevelCritical
+		description = "uncorrected machine checks decoded from dmesg"
+		suggestion = "drain the node and check the CPU or DIMM at the reported location"
+	}
+	device := fmt.Sprintf("CPU%d", mce.CPU)
+	entry, exists := c.eventsResultMap[name]
+	if !exists {
+		entry = &common.CheckerResult{
+			Name:        name,
+			Description: description,
+			Curr:        "1",
+			Device:      device,
+			Status:      consts.StatusAbnormal,
+			Level:       level,
+			ErrorName:   name,
+			Suggestion:  suggestion,
+			Detail:      mce.String(),
+		}
+		c.eventsResultMap[name] = entry
+		c.result.Checkers = append(c.result.Checkers, entry)
+		c.result.Status = consts.StatusAbnormal
+		if consts.LevelPriority[level] > consts.LevelPriority[c.result.Level] {
+			c.result.Level = level
 		}
+		return
+	}
+	curr, _ := strconv.Atoi(entry.Curr)
+	curr++
+	entry.Curr = strconv.Itoa(curr)
+	if curr <= MaxDetailLines {
+		entry.Detail += "\n" + mce.String()
+	}
+	if !containsDevice(entry.Device, device) {
+		entry.Device += "," + device
 	}
 }
 
+func containsDevice(devices, device string) bool {
+	for _, d := range strings.Split(devices, ",") {
+		if d == device {
+			return true
+		}
+	}
+	return false
+}
+
+// AddMissed reports the kernel records lost to ring buffer overruns since the
+// last HealthCheck, as the events they carried could not be matched.
+func (c *EventCache) AddMissed(missed uint64) {
+	if missed == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	checkResult := &common.CheckerResult{
+		Name:        KmsgMissedName,
+		Description: "kernel records overwritten in the ring buffer before being read",
+		Curr:        strconv.FormatUint(missed, 10),
+		Status:      consts.StatusAbnormal,
+		Level:       consts.LevelInfo,
+		ErrorName:   KmsgMissedName,
+		Suggestion:  "check the burst of kernel messages with dmesg, events in it may have been missed",
+		Detail:      fmt.Sprintf("%d kernel records were lost before being read", missed),
+	}
+	c.result.Checkers = append(c.result.Checkers, checkResult)
+	c.result.Status = consts.StatusAbnormal
+}
+
 func (c *EventCache) add(name, detail string) {
 	if entry, exists := c.eventsResultMap[name]; !exists {
 		eventRule := c.runtimeEventRules[name]
@@ -124,6 +229,15 @@ func (c *EventCache) Drain() *common.Result {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	for _, mce := range c.mceDecoder.Flush() {
+		c.addMCE(mce)
+	}
+	summary := c.mceStats.Summary(time.Now())
+	for _, name := range []string{MCECorrectedName, MCEUncorrectedName} {
+		if entry, exists := c.eventsResultMap[name]; exists && summary != "" {
+			entry.Detail += "\n" + summary
+		}
+	}
 	ev := c.result
 	c.reset()
 	return ev
@@ -121,6 +121,23 @@ func TestEventRulesComprehensive(t *testing.T) {
 			},
 			ExpectedCount: 2,
 		},
+		"MCEHardwareError": {
+			RuleName: "MCEHardwareError",
+			MockLogLines: []string{
+				"mce: [Hardware Error]: Machine check events logged",
+				"mce: [Hardware Error]: CPU 12: Machine Check: 0 Bank 13: cc00008000010090",
+			},
+			ExpectedCount: 2,
+		},
+		"IBHCAError": {
+			RuleName: "IBHCAError",
+			MockLogLines: []string{
+				"mlx5_core 0000:5e:00.0: Port module event[error]: module 0, Cable error, Bus stuck (I2C or data shorted)",
+				"mlx5_core 0000:86:00.0: poll_health:801:(pid 0): device's health compromised - reached miss count",
+				"mlx5_core 0000:86:00.0: mlx5e_open_locked: opened 63 channels",
+			},
+			ExpectedCount: 2,
+		},
 	}
 
 	// Test each rule
@@ -147,15 +164,13 @@ func TestEventRulesComprehensive(t *testing.T) {
 			eventCache := NewEventCache(rules)
 
 			// Start reader -> EventCache
-			reader.Start(func(line string) {
-				eventCache.MatchLine(line)
-			})
+			reader.Start(eventCache.Match)
 			defer reader.Stop()
 
 			// Write mock log lines (formatted as kmsg format)
-			for _, line := range testCase.MockLogLines {
+			for i, line := range testCase.MockLogLines {
 				// Format as kmsg: <pri>,<seq>,<ts>,<flags>;message
-				kmsgLine := fmt.Sprintf("6,1,0,-;%s\n", line)
+				kmsgLine := fmt.Sprintf("6,%d,0,-;%s\n", i+1, line)
 				_, err := pw.Write([]byte(kmsgLine))
 				if err != nil {
 					t.Fatalf("write pipe failed: %v", err)
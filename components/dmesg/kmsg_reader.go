@@ -17,21 +17,59 @@ package dmesg
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
 	"io"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sys/unix"
 )
 
+// kmsgRecordMax is the read buffer size, the kernel rejects reads of
+// /dev/kmsg with a buffer smaller than the next record.
+const kmsgRecordMax = 8192
+
+// KmsgRecord is a record of the kernel ring buffer with its sequence number
+// and the precise timestamp the kernel logged it at.
+type KmsgRecord struct {
+	Seq      uint64
+	Priority int
+	// Monotonic is the kernel timestamp of the record, in microseconds precision.
+	Monotonic time.Duration
+	// Time is Monotonic converted to the wall clock.
+	Time    time.Time
+	Message string
+}
+
+// Line formats the record as `dmesg -T` does.
+func (r KmsgRecord) Line() string {
+	return r.Time.Format("[Mon Jan _2 15:04:05 2006] ") + r.Message
+}
+
+// Stamp is the precise timestamp of the record, both as wall clock and as
+// kernel time so that it can be correlated with `dmesg` output.
+func (r KmsgRecord) Stamp() string {
+	return fmt.Sprintf("[%s] [%5d.%06d]", r.Time.Format("2006-01-02T15:04:05.000000Z07:00"),
+		r.Monotonic/time.Second, (r.Monotonic%time.Second)/time.Microsecond)
+}
+
 type KmsgReader struct {
 	// file uses io.Reader interface instead of *os.File to enable dependency injection
 	// for unit testing while maintaining production functionality. *os.File implements io.Reader.
 	file        io.Reader
 	skipPercent int64
 	stop        chan struct{}
+
+	// lastSeq is the sequence number of the last record read, 0 before the first one.
+	lastSeq uint64
+	// missed counts the records overwritten in the ring buffer before they were read.
+	missed atomic.Uint64
 }
 
 func NewKmsgReader(r io.Reader, skipPercent int64) (*KmsgReader, error) {
@@ -46,7 +84,10 @@ func NewKmsgReader(r io.Reader, skipPercent int64) (*KmsgReader, error) {
 	}, nil
 }
 
-func (r *KmsgReader) Start(onLine func(string)) {
+// Start follows the kernel ring buffer in the background and calls onRecord
+// for each record. A record overwritten before it was read, e.g. during a
+// burst of messages, is accounted in Missed rather than stopping the reader.
+func (r *KmsgReader) Start(onRecord func(KmsgRecord)) {
 	if r.skipPercent == 100 {
 		if seeker, ok := r.file.(io.Seeker); ok {
 			if _, err := seeker.Seek(0, io.SeekEnd); err != nil {
@@ -72,48 +113,83 @@ func (r *KmsgReader) Start(onLine func(string)) {
 	monoNow := time.Duration(ts.Sec)*time.Second + time.Duration(ts.Nsec)
 
 	go func() {
-		scanner := bufio.NewScanner(r.file)
-		for scanner.Scan() {
+		// every read of /dev/kmsg returns exactly one record
+		buf := make([]byte, kmsgRecordMax)
+		for {
+			n, err := r.file.Read(buf)
 			select {
 			case <-r.stop:
 				return
 			default:
 			}
-
-			line := scanner.Text()
-			// <pri>,<seq>,<ts>,<flags>;message
-			idx := strings.Index(line, ";")
-			if idx == -1 {
-				continue
+			for _, line := range bytes.Split(buf[:n], []byte("\n")) {
+				record, ok := parseKmsgRecord(string(line))
+				if !ok {
+					continue
+				}
+				record.Time = wallNow.Add(record.Monotonic - monoNow)
+				r.track(record.Seq)
+				onRecord(record)
 			}
-
-			meta := line[:idx]
-			msg := line[idx+1:]
-
-			fields := strings.Split(meta, ",")
-			if len(fields) < 3 {
+			if err == nil {
 				continue
 			}
-
-			tsNano, err := strconv.ParseInt(fields[2], 10, 64)
-			if err != nil {
+			if errors.Is(err, syscall.EPIPE) {
+				// the records since the last read were overwritten, the next
+				// read returns the oldest record still in the ring buffer
+				logrus.WithField("component", "dmesg").Warn("kmsg ring buffer overrun, records were lost")
 				continue
 			}
+			if !errors.Is(err, io.EOF) {
+				logrus.WithError(err).Error("read /dev/kmsg failed")
+			}
+			return
+		}
+	}()
+}
 
-			// monotonic → wall clock
-			eventMono := time.Duration(tsNano)
-			eventTime := wallNow.Add(eventMono - monoNow)
-
-			// dmesg -T Style
-			prefix := eventTime.Format("[Mon Jan _2 15:04:05 2006] ")
+// Missed returns the number of records lost since the last call.
+func (r *KmsgReader) Missed() uint64 {
+	return r.missed.Swap(0)
+}
 
-			onLine(prefix + msg)
-		}
+// track accounts the gap between the sequence numbers of consecutive records.
+func (r *KmsgReader) track(seq uint64) {
+	if r.lastSeq != 0 && seq > r.lastSeq+1 {
+		r.missed.Add(seq - r.lastSeq - 1)
+	}
+	r.lastSeq = seq
+}
 
-		if err := scanner.Err(); err != nil {
-			logrus.WithError(err).Error("read /dev/kmsg failed")
-		}
-	}()
+// parseKmsgRecord parses a `<pri>,<seq>,<ts>,<flags>;message` record, the
+// continuation lines of the record carrying its key/value dictionary are skipped.
+func parseKmsgRecord(line string) (KmsgRecord, bool) {
+	idx := strings.Index(line, ";")
+	if idx == -1 || strings.HasPrefix(line, " ") {
+		return KmsgRecord{}, false
+	}
+	fields := strings.Split(line[:idx], ",")
+	if len(fields) < 3 {
+		return KmsgRecord{}, false
+	}
+	pri, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return KmsgRecord{}, false
+	}
+	seq, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return KmsgRecord{}, false
+	}
+	usec, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return KmsgRecord{}, false
+	}
+	return KmsgRecord{
+		Seq:       seq,
+		Priority:  pri,
+		Monotonic: time.Duration(usec) * time.Microsecond,
+		Message:   line[idx+1:],
+	}, true
 }
 
 func (r *KmsgReader) Stop() {
@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package dmesg
+
+import (
+	"io"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakeKmsg returns one record per read like /dev/kmsg, an empty record stands
+// for a ring buffer overrun.
+type fakeKmsg struct {
+	records []string
+}
+
+func (f *fakeKmsg) Read(p []byte) (int, error) {
+	if len(f.records) == 0 {
+		return 0, io.EOF
+	}
+	record := f.records[0]
+	f.records = f.records[1:]
+	if record == "" {
+		return 0, syscall.EPIPE
+	}
+	return copy(p, record), nil
+}
+
+func TestParseKmsgRecord(t *testing.T) {
+	record, ok := parseKmsgRecord("3,1042,12345678901,-;NVRM: Xid (PCI:0000:01:00): 79, GPU has fallen off the bus.")
+	if !ok {
+		t.Fatal("expected the record to be parsed")
+	}
+	if record.Seq != 1042 || record.Priority != 3 {
+		t.Errorf("unexpected seq %d or priority %d", record.Seq, record.Priority)
+	}
+	if want := 12345*time.Second + 678901*time.Microsecond; record.Monotonic != want {
+		t.Errorf("expected kernel time %s, got %s", want, record.Monotonic)
+	}
+	if !strings.Contains(record.Stamp(), "[12345.678901]") {
+		t.Errorf("expected the kernel time in the stamp, got %s", record.Stamp())
+	}
+	if _, ok := parseKmsgRecord(" SUBSYSTEM=pci"); ok {
+		t.Error("expected continuation lines to be skipped")
+	}
+}
+
+func TestKmsgReaderTracksMissedRecords(t *testing.T) {
+	kmsg := &fakeKmsg{records: []string{
+		"6,10,1000,-;first\n",
+		"",
+		"6,15,2000,-;after overrun\n SUBSYSTEM=pci\n",
+		"6,16,3000,-;next\n",
+	}}
+	reader, err := NewKmsgReader(kmsg, 0)
+	if err != nil {
+		t.Fatalf("create KmsgReader failed: %v", err)
+	}
+	records := make(chan KmsgRecord, 10)
+	reader.Start(func(record KmsgRecord) { records <- record })
+
+	var got []string
+	for len(got) < 3 {
+		select {
+		case record := <-records:
+			got = append(got, record.Message)
+		case <-time.After(time.Second):
+			t.Fatalf("reader stopped after %v", got)
+		}
+	}
+	if strings.Join(got, ",") != "first,after overrun,next" {
+		t.Errorf("unexpected records %v", got)
+	}
+	if missed := reader.Missed(); missed != 4 {
+		t.Errorf("expected 4 missed records, got %d", missed)
+	}
+	if missed := reader.Missed(); missed != 0 {
+		t.Errorf("expected Missed to reset, got %d", missed)
+	}
+}
+
+func TestEventCacheReportsMissed(t *testing.T) {
+	cache := NewEventCache(nil)
+	cache.AddMissed(0)
+	if result := cache.Drain(); len(result.Checkers) != 0 {
+		t.Fatalf("expected no event without missed records, got %d", len(result.Checkers))
+	}
+	cache.AddMissed(7)
+	result := cache.Drain()
+	if len(result.Checkers) != 1 || result.Checkers[0].Name != KmsgMissedName || result.Checkers[0].Curr != "7" {
+		t.Fatalf("unexpected result %+v", result.Checkers)
+	}
+}
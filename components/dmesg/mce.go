@@ -0,0 +1,298 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package dmesg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scitix/sichek/pkg/hostfs"
+)
+
+const (
+	// MCECorrectedName and MCEUncorrectedName are the events of the machine
+	// checks decoded from the kernel log, reported with their hardware location
+	MCECorrectedName   = "MCECorrected"
+	MCEUncorrectedName = "MCEUncorrected"
+	// DefaultMCEWindow is the period the machine checks are counted per CPU over
+	DefaultMCEWindow = 24 * time.Hour
+)
+
+// Bits of the MCi_STATUS register of a machine check bank
+const (
+	mciStatusOver  = 1 << 62 // an error overflowed the bank, errors were lost
+	mciStatusUC    = 1 << 61 // uncorrected error
+	mciStatusAddrV = 1 << 58 // MCi_ADDR holds the address of the error
+	mciStatusPCC   = 1 << 57 // the processor context is corrupt
+)
+
+const cpuTopologyPath = "/sys/devices/system/cpu"
+
+var (
+	// mce: [Hardware Error]: CPU 12: Machine Check: 0 Bank 13: cc00008000010090
+	mceBankRegex = regexp.MustCompile(`mce: \[Hardware Error\]: CPU (\d+): Machine Check(?: Exception)?: [0-9a-fA-F]+ Bank (\d+): ([0-9a-fA-F]+)`)
+	// mce: [Hardware Error]: TSC 0 ADDR 3e4f2c000 MISC 200001c020002086
+	mceAddrRegex = regexp.MustCompile(`mce: \[Hardware Error\]:.*\bADDR ([0-9a-fA-F]+)`)
+	// mce: [Hardware Error]: PROCESSOR 0:50657 TIME 1600000000 SOCKET 1 APIC 24 microcode 5003006
+	mceProcessorRegex = regexp.MustCompile(`mce: \[Hardware Error\]: PROCESSOR \d+:[0-9a-fA-F]+ TIME \d+ SOCKET (\d+)`)
+)
+
+// MCERecord is a machine check decoded from the kernel log.
+type MCERecord struct {
+	Stamp  string
+	Time   time.Time
+	CPU    int
+	Bank   int
+	Status uint64
+	Addr   uint64
+	// Socket and Core locate the CPU, -1 when unknown
+	Socket int
+	Core   int
+}
+
+// Uncorrected tells whether the hardware could not correct the error.
+func (r *MCERecord) Uncorrected() bool {
+	return r.Status&mciStatusUC != 0
+}
+
+// Channel returns the memory channel of a memory controller error, -1 when
+// the error is not a memory controller error or the channel is unspecified.
+func (r *MCERecord) Channel() int {
+	code := r.Status & 0xffff
+	if code&0xef80 != 0x0080 || code&0xf == 0xf {
+		return -1
+	}
+	return int(code & 0xf)
+}
+
+var (
+	mceMemoryTransactions = []string{"generic", "read", "write", "address/command", "scrubbing"}
+	mceCacheRequests      = []string{"generic", "read", "write", "data read", "data write", "instruction fetch", "prefetch", "eviction", "snoop"}
+	mceCacheLevels        = []string{"L0", "L1", "L2", "generic"}
+)
+
+// ErrorType decodes the MCA error code of the status, the low 16 bits
+// whose layout is shared by Intel and AMD processors.
+func (r *MCERecord) ErrorType() string {
+	code := r.Status & 0xffff
+	switch {
+	case code&0xe800 == 0x0800:
+		return "bus/interconnect error"
+	case code&0xef00 == 0x0100:
+		request := "unknown"
+		if rrrr := int(code>>4) & 0xf; rrrr < len(mceCacheRequests) {
+			request = mceCacheRequests[rrrr]
+		}
+		return fmt.Sprintf("%s cache %s error", mceCacheLevels[code&3], request)
+	case code&0xef80 == 0x0080:
+		transaction := "unknown"
+		if mmm := int(code>>4) & 0x7; mmm < len(mceMemoryTransactions) {
+			transaction = mceMemoryTransactions[mmm]
+		}
+		return fmt.Sprintf("memory controller %s error", transaction)
+	case code&0xeff0 == 0x0010:
+		return fmt.Sprintf("%s TLB error", mceCacheLevels[code&3])
+	case code&0xfc00 == 0x0400:
+		return "internal error"
+	default:
+		return fmt.Sprintf("error code 0x%04x", code)
+	}
+}
+
+// Location returns the hardware location of the error.
+func (r *MCERecord) Location() string {
+	location := cpuLocation(r.CPU, r.Socket, r.Core) + fmt.Sprintf(" bank %d", r.Bank)
+	if channel := r.Channel(); channel >= 0 {
+		location += fmt.Sprintf(" channel %d", channel)
+	}
+	return location
+}
+
+func (r *MCERecord) String() string {
+	kind := "corrected"
+	if r.Uncorrected() {
+		kind = "uncorrected"
+	}
+	s := fmt.Sprintf("%s %s: %s %s, status=0x%016x", r.Stamp, r.Location(), kind, r.ErrorType(), r.Status)
+	if r.Status&mciStatusAddrV != 0 {
+		s += fmt.Sprintf(", addr=0x%x", r.Addr)
+	}
+	if r.Status&mciStatusPCC != 0 {
+		s += ", processor context corrupt"
+	}
+	if r.Status&mciStatusOver != 0 {
+		s += ", errors overflowed"
+	}
+	return s
+}
+
+func cpuLocation(cpu, socket, core int) string {
+	location := fmt.Sprintf("CPU %d", cpu)
+	var parts []string
+	if socket >= 0 {
+		parts = append(parts, fmt.Sprintf("socket %d", socket))
+	}
+	if core >= 0 {
+		parts = append(parts, fmt.Sprintf("core %d", core))
+	}
+	if len(parts) > 0 {
+		location += " (" + strings.Join(parts, ", ") + ")"
+	}
+	return location
+}
+
+// MCEDecoder assembles the machine check records the kernel logs over
+// several lines: the CPU, bank and status line, then the address line and
+// the processor line with the socket, which completes the record.
+type MCEDecoder struct {
+	pending *MCERecord
+	// topology returns the socket and core of a logical CPU, -1 when unknown
+	topology func(cpu int) (int, int)
+}
+
+func NewMCEDecoder() *MCEDecoder {
+	return &MCEDecoder{topology: sysfsCPUTopology}
+}
+
+// Feed decodes a kernel record and returns the machine checks it completed.
+func (d *MCEDecoder) Feed(record KmsgRecord) []*MCERecord {
+	if !strings.Contains(record.Message, "mce: [Hardware Error]") {
+		return nil
+	}
+	var completed []*MCERecord
+	if m := mceBankRegex.FindStringSubmatch(record.Message); m != nil {
+		status, err := strconv.ParseUint(m[3], 16, 64)
+		if err != nil {
+			return nil
+		}
+		completed = append(completed, d.Flush()...)
+		cpu, _ := strconv.Atoi(m[1])
+		bank, _ := strconv.Atoi(m[2])
+		d.pending = &MCERecord{Stamp: record.Stamp(), Time: record.Time, CPU: cpu, Bank: bank, Status: status, Socket: -1, Core: -1}
+		return completed
+	}
+	if d.pending == nil {
+		return nil
+	}
+	if m := mceAddrRegex.FindStringSubmatch(record.Message); m != nil {
+		d.pending.Addr, _ = strconv.ParseUint(m[1], 16, 64)
+	}
+	if m := mceProcessorRegex.FindStringSubmatch(record.Message); m != nil {
+		d.pending.Socket, _ = strconv.Atoi(m[1])
+		completed = append(completed, d.Flush()...)
+	}
+	return completed
+}
+
+// Flush completes the pending record, if any.
+func (d *MCEDecoder) Flush() []*MCERecord {
+	record := d.pending
+	if record == nil {
+		return nil
+	}
+	d.pending = nil
+	socket, core := d.topology(record.CPU)
+	if record.Socket < 0 {
+		record.Socket = socket
+	}
+	record.Core = core
+	return []*MCERecord{record}
+}
+
+// sysfsCPUTopology reads the package and core of a logical CPU from sysfs.
+func sysfsCPUTopology(cpu int) (int, int) {
+	dir := hostfs.Path(filepath.Join(cpuTopologyPath, fmt.Sprintf("cpu%d", cpu), "topology"))
+	return readTopologyID(filepath.Join(dir, "physical_package_id")), readTopologyID(filepath.Join(dir, "core_id"))
+}
+
+func readTopologyID(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return -1
+	}
+	id, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return -1
+	}
+	return id
+}
+
+// MCEStats counts the machine checks of each CPU over a sliding window.
+type MCEStats struct {
+	window  time.Duration
+	records []*MCERecord
+}
+
+func NewMCEStats(window time.Duration) *MCEStats {
+	if window <= 0 {
+		window = DefaultMCEWindow
+	}
+	return &MCEStats{window: window}
+}
+
+func (s *MCEStats) Add(record *MCERecord) {
+	s.records = append(s.records, record)
+}
+
+// Summary returns the corrected and uncorrected machine check counts of the
+// CPUs in the window ending at now, the older records are dropped.
+func (s *MCEStats) Summary(now time.Time) string {
+	kept := s.records[:0]
+	for _, record := range s.records {
+		if now.Sub(record.Time) <= s.window {
+			kept = append(kept, record)
+		}
+	}
+	s.records = kept
+	if len(kept) == 0 {
+		return ""
+	}
+
+	type cpuCounts struct {
+		location               string
+		corrected, uncorrected int
+	}
+	counts := make(map[int]*cpuCounts)
+	for _, record := range kept {
+		c, found := counts[record.CPU]
+		if !found {
+			c = &cpuCounts{location: cpuLocation(record.CPU, record.Socket, record.Core)}
+			counts[record.CPU] = c
+		}
+		if record.Uncorrected() {
+			c.uncorrected++
+		} else {
+			c.corrected++
+		}
+	}
+	cpus := make([]int, 0, len(counts))
+	for cpu := range counts {
+		cpus = append(cpus, cpu)
+	}
+	sort.Ints(cpus)
+	lines := make([]string, 0, len(cpus))
+	for _, cpu := range cpus {
+		c := counts[cpu]
+		lines = append(lines, fmt.Sprintf("%s: %d corrected, %d uncorrected", c.location, c.corrected, c.uncorrected))
+	}
+	return fmt.Sprintf("machine checks per CPU in the last %s: %s", s.window, strings.Join(lines, "; "))
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package dmesg
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/hostfs/hostfstest"
+)
+
+func mceRecords(now time.Time, messages ...string) []KmsgRecord {
+	records := make([]KmsgRecord, 0, len(messages))
+	for i, message := range messages {
+		records = append(records, KmsgRecord{Seq: uint64(i + 1), Time: now, Monotonic: time.Duration(i+1) * time.Second, Message: message})
+	}
+	return records
+}
+
+func TestMCEDecoder(t *testing.T) {
+	hostfstest.Build(t, `
+-- sys/devices/system/cpu/cpu12/topology/physical_package_id --
+1
+-- sys/devices/system/cpu/cpu12/topology/core_id --
+4
+`)
+	decoder := NewMCEDecoder()
+	var decoded []*MCERecord
+	for _, record := range mceRecords(time.Now(),
+		"mce: [Hardware Error]: Machine check events logged",
+		"mce: [Hardware Error]: CPU 12: Machine Check: 0 Bank 13: cc00008000010090",
+		"mce: [Hardware Error]: TSC 0 ADDR 3e4f2c000 MISC 200001c020002086",
+		"mce: [Hardware Error]: PROCESSOR 0:50657 TIME 1600000000 SOCKET 1 APIC 24 microcode 5003006",
+		"mce: [Hardware Error]: CPU 3: Machine Check Exception: 5 Bank 4: be00000000800400",
+		"mce: [Hardware Error]: Run the above through 'mcelog --ascii'",
+	) {
+		decoded = append(decoded, decoder.Feed(record)...)
+	}
+	decoded = append(decoded, decoder.Flush()...)
+	if len(decoded) != 2 {
+		t.Fatalf("expected 2 machine checks, got %d", len(decoded))
+	}
+
+	corrected := decoded[0]
+	if corrected.Uncorrected() || corrected.CPU != 12 || corrected.Bank != 13 || corrected.Socket != 1 || corrected.Core != 4 || corrected.Channel() != 0 {
+		t.Errorf("unexpected corrected machine check %+v", corrected)
+	}
+	for _, want := range []string{"CPU 12 (socket 1, core 4) bank 13 channel 0: corrected memory controller read error", "addr=0x3e4f2c000", "errors overflowed"} {
+		if !strings.Contains(corrected.String(), want) {
+			t.Errorf("expected %q in %q", want, corrected.String())
+		}
+	}
+
+	// the CPU without topology in sysfs is located by its number only
+	uncorrected := decoded[1]
+	if !uncorrected.Uncorrected() || uncorrected.Channel() != -1 || uncorrected.Socket != -1 {
+		t.Errorf("unexpected uncorrected machine check %+v", uncorrected)
+	}
+	if want := "CPU 3 bank 4: uncorrected internal error"; !strings.Contains(uncorrected.String(), want) || !strings.Contains(uncorrected.String(), "processor context corrupt") {
+		t.Errorf("expected %q in %q", want, uncorrected.String())
+	}
+}
+
+func TestMCEErrorType(t *testing.T) {
+	for status, want := range map[uint64]string{
+		0x0090: "memory controller read error",
+		0x00a3: "memory controller write error",
+		0x0135: "L1 cache data read error",
+		0x0014: "L0 TLB error",
+		0x0e0b: "bus/interconnect error",
+		0x0005: "error code 0x0005",
+	} {
+		record := &MCERecord{Status: status}
+		if got := record.ErrorType(); got != want {
+			t.Errorf("status 0x%x: expected %q, got %q", status, want, got)
+		}
+	}
+}
+
+func TestEventCacheMCE(t *testing.T) {
+	hostfstest.Build(t, ``)
+	cache := NewEventCache(nil)
+	cache.SetMCEWindow(time.Hour)
+	now := time.Now()
+	for _, record := range mceRecords(now.Add(-2*time.Hour),
+		"mce: [Hardware Error]: CPU 5: Machine Check: 0 Bank 7: 8c00004000010091",
+		"mce: [Hardware Error]: PROCESSOR 0:50657 TIME 1600000000 SOCKET 0 APIC 5 microcode 5003006",
+	) {
+		cache.Match(record)
+	}
+	cache.Drain()
+
+	for _, record := range mceRecords(now,
+		"mce: [Hardware Error]: CPU 5: Machine Check: 0 Bank 7: 8c00004000010091",
+		"mce: [Hardware Error]: PROCESSOR 0:50657 TIME 1600000000 SOCKET 0 APIC 5 microcode 5003006",
+		"mce: [Hardware Error]: CPU 9: Machine Check: 0 Bank 7: 8c00004000010091",
+		"mce: [Hardware Error]: PROCESSOR 0:50657 TIME 1600000000 SOCKET 1 APIC 9 microcode 5003006",
+		"mce: [Hardware Error]: CPU 5: Machine Check Exception: 5 Bank 7: bd80000000100134",
+		"mce: [Hardware Error]: PROCESSOR 0:50657 TIME 1600000000 SOCKET 0 APIC 5 microcode 5003006",
+	) {
+		cache.Match(record)
+	}
+	result := cache.Drain()
+	if result.Status != consts.StatusAbnormal || result.Level != consts.LevelCritical || len(result.Checkers) != 2 {
+		t.Fatalf("expected a corrected and an uncorrected event, got %+v", result)
+	}
+	corrected, uncorrected := result.Checkers[0], result.Checkers[1]
+	if corrected.Name != MCECorrectedName || corrected.Curr != "2" || corrected.Device != "CPU5,CPU9" || corrected.Level != consts.LevelWarning {
+		t.Errorf("unexpected corrected event %+v", corrected)
+	}
+	if uncorrected.Name != MCEUncorrectedName || uncorrected.Curr != "1" || uncorrected.Device != "CPU5" {
+		t.Errorf("unexpected uncorrected event %+v", uncorrected)
+	}
+	// the machine check older than the window is no longer counted
+	want := "machine checks per CPU in the last 1h0m0s: CPU 5 (socket 0): 1 corrected, 1 uncorrected; CPU 9 (socket 1): 1 corrected, 0 uncorrected"
+	if !strings.Contains(corrected.Detail, want) || !strings.Contains(uncorrected.Detail, want) {
+		t.Errorf("expected %q in the details:\n%s\n%s", want, corrected.Detail, uncorrected.Detail)
+	}
+	if !strings.Contains(corrected.Detail, "CPU 5 (socket 0) bank 7 channel 1: corrected memory controller read error") {
+		t.Errorf("expected the location in the detail:\n%s", corrected.Detail)
+	}
+}
@@ -40,6 +40,9 @@ func NewCheckers(cfg *config.EthernetUserConfig, spec *config.EthernetSpecConfig
 		&L4Checker{spec: spec},
 		&L5Checker{spec: spec},
 	}
+	if spec.RoCE != nil {
+		checkers = append(checkers, &RoCEChecker{spec: spec.RoCE})
+	}
 	// Filter skipped checkers
 	ignoredMap := make(map[string]bool)
 	if cfg != nil && cfg.Ethernet != nil {
@@ -0,0 +1,167 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/ethernet/collector"
+	"github.com/scitix/sichek/components/ethernet/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+// RoCEChecker checks the RoCE NICs against spec.RoCE, it is only created
+// when the spec has a roce section.
+type RoCEChecker struct{ spec *config.RoCESpecConfig }
+
+func (c *RoCEChecker) Name() string { return config.EthernetRoCECheckerName }
+
+func (c *RoCEChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	info, ok := data.(*collector.EthernetInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid data type")
+	}
+
+	result := &common.CheckerResult{
+		Name:        c.Name(),
+		Description: config.EthernetCheckItems[c.Name()],
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelInfo,
+		Curr:        "OK",
+	}
+
+	if len(info.RoCE) == 0 {
+		result.Status = consts.StatusAbnormal
+		result.Level = consts.LevelCritical
+		result.ErrorName = "NoRoCEInterface"
+		result.Detail = "No RoCE NIC found. Command: ls /sys/class/infiniband/*/ports/*/link_layer, Expected: at least one Ethernet link layer."
+		result.Suggestion = "Please check if the RDMA driver is loaded (e.g. mlx5_ib, bnxt_re) and the interfaces in the roce spec exist."
+		return result, nil
+	}
+
+	fail := func(nic, level, errorName, detail string) {
+		logrus.WithFields(logrus.Fields{
+			"checker": c.Name(),
+			"nic":     nic,
+		}).Errorf("%s: %s", errorName, detail)
+		result.Status = consts.StatusAbnormal
+		if consts.LevelPriority[level] > consts.LevelPriority[result.Level] {
+			result.Level = level
+			result.ErrorName = errorName
+		}
+		result.Detail += detail + "\n"
+	}
+
+	names := make([]string, 0, len(info.RoCE))
+	for name := range info.RoCE {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		state := info.RoCE[name]
+		if !state.IsUp {
+			fail(name, consts.LevelCritical, "RoCELinkDown",
+				fmt.Sprintf("RoCE NIC %s link not up. Command: cat /sys/class/net/%s/operstate, Expected: up, Actual: %s.", name, name, orUnknown(state.OperState)))
+			// speed and the congestion control settings are meaningless while down
+			continue
+		}
+		if c.spec.Speed > 0 && state.Speed != c.spec.Speed {
+			fail(name, consts.LevelWarning, "RoCESpeedMismatch",
+				fmt.Sprintf("RoCE NIC %s speed mismatch. Command: cat /sys/class/net/%s/speed, Expected: %dMb/s, Actual: %dMb/s.", name, name, c.spec.Speed, state.Speed))
+		}
+		if c.spec.MTU > 0 && state.MTU != c.spec.MTU {
+			fail(name, consts.LevelWarning, "RoCEMTUMismatch",
+				fmt.Sprintf("RoCE NIC %s mtu mismatch. Command: cat /sys/class/net/%s/mtu, Expected: %d, Actual: %d.", name, name, c.spec.MTU, state.MTU))
+		}
+		if len(c.spec.PFCPriorities) > 0 {
+			if expected, actual := formatPriorities(priorityMap(c.spec.PFCPriorities)), formatPriorities(state.PFC); expected != actual {
+				fail(name, consts.LevelCritical, "PFCMisconfigured",
+					fmt.Sprintf("RoCE NIC %s PFC priorities mismatch. Command: dcb pfc show dev %s, Expected: %s, Actual: %s.", name, name, expected, actual))
+			}
+		}
+		for _, prio := range c.spec.PFCPriorities {
+			if c.spec.ECN && !state.ECN[prio] {
+				fail(name, consts.LevelWarning, "ECNDisabled",
+					fmt.Sprintf("RoCE NIC %s ECN disabled on priority %d. Command: cat /sys/class/net/%s/ecn/roce_np/enable/%d, Expected: 1.", name, prio, name, prio))
+			}
+			if c.spec.DCQCN && !state.DCQCN[prio] {
+				fail(name, consts.LevelWarning, "DCQCNDisabled",
+					fmt.Sprintf("RoCE NIC %s DCQCN disabled on priority %d. Command: cat /sys/class/net/%s/ecn/roce_rp/enable/%d, Expected: 1.", name, prio, name, prio))
+			}
+		}
+		if c.spec.Driver != "" && state.Driver != c.spec.Driver {
+			fail(name, consts.LevelWarning, "RoCEDriverMismatch",
+				fmt.Sprintf("RoCE NIC %s driver mismatch. Command: ethtool -i %s, Expected: %s, Actual: %s.", name, name, c.spec.Driver, orUnknown(state.Driver)))
+		}
+		if c.spec.DriverVersion != "" && state.DriverVersion != c.spec.DriverVersion {
+			fail(name, consts.LevelWarning, "RoCEDriverVersionMismatch",
+				fmt.Sprintf("RoCE NIC %s driver version mismatch. Command: ethtool -i %s, Expected: %s, Actual: %s.", name, name, c.spec.DriverVersion, orUnknown(state.DriverVersion)))
+		}
+		if c.spec.FirmwareVersion != "" && state.FirmwareVersion != c.spec.FirmwareVersion {
+			fail(name, consts.LevelWarning, "RoCEFirmwareMismatch",
+				fmt.Sprintf("RoCE NIC %s firmware version mismatch. Command: ethtool -i %s, Expected: %s, Actual: %s.", name, name, c.spec.FirmwareVersion, orUnknown(state.FirmwareVersion)))
+		}
+	}
+
+	if result.Status != consts.StatusNormal {
+		result.Curr = "Err"
+		result.Suggestion = "Please check the RoCE NIC cable and switch port, the QoS config (dcb pfc / mlnx_qos, /sys/class/net/<nic>/ecn) and upgrade the driver/firmware to the spec version."
+	}
+	return result, nil
+}
+
+func priorityMap(priorities []int) map[int]bool {
+	m := make(map[int]bool, len(priorities))
+	for _, prio := range priorities {
+		m[prio] = true
+	}
+	return m
+}
+
+// formatPriorities formats the enabled priorities, e.g. "3,4", or "none".
+func formatPriorities(flags map[int]bool) string {
+	var enabled []int
+	for prio, on := range flags {
+		if on {
+			enabled = append(enabled, prio)
+		}
+	}
+	if len(enabled) == 0 {
+		return "none"
+	}
+	sort.Ints(enabled)
+	s := ""
+	for i, prio := range enabled {
+		if i > 0 {
+			s += ","
+		}
+		s += strconv.Itoa(prio)
+	}
+	return s
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
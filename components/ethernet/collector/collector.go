@@ -23,7 +23,9 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/scitix/sichek/pkg/hostfs"
 	"github.com/scitix/sichek/pkg/utils"
+	"github.com/sirupsen/logrus"
 )
 
 type BondState struct {
@@ -78,6 +80,7 @@ type EthernetInfo struct {
 	Stats          map[string]TrafficStats // Maps iface -> stats
 	Routes         RouteState
 	SyslogErrors   []string
+	RoCE           map[string]RoCEState // Maps RoCE netdev -> state
 
 	// Legacy string outputs (kept temporarily for backwards compatibility with un-migrated checkers)
 	ProcNetBonding map[string]string
@@ -107,6 +110,9 @@ type EthernetCollector struct {
 	name       string
 	info       *EthernetInfo
 	targetBond string
+
+	roceEnabled    bool
+	roceInterfaces []string
 }
 
 func NewEthernetCollector(targetBond string) (*EthernetCollector, error) {
@@ -166,7 +172,7 @@ func (c *EthernetCollector) Collect(ctx context.Context) (*EthernetInfo, error)
 		c.info.Bonds[bond] = BondState{Name: bond}
 		c.info.Slaves[bond] = make(map[string]SlaveState)
 
-		outProc, _ := utils.ExecCommand(ctx, "cat", "/proc/net/bonding/"+bond)
+		outProc, _ := utils.ExecCommand(ctx, "cat", hostfs.Path("/proc/net/bonding", bond))
 		c.info.ProcNetBonding[bond] = string(outProc)
 
 		// Parse BondState config from sysfs
@@ -175,7 +181,7 @@ func (c *EthernetCollector) Collect(ctx context.Context) (*EthernetInfo, error)
 			c.info.SysfsBonding[bond] = make(map[string]string)
 		}
 		for _, attr := range attrs {
-			outAttr, _ := utils.ExecCommand(ctx, "cat", "/sys/class/net/"+bond+"/bonding/"+attr)
+			outAttr, _ := utils.ExecCommand(ctx, "cat", hostfs.Path("/sys/class/net", bond, "bonding", attr))
 			c.info.SysfsBonding[bond][attr] = strings.TrimSpace(string(outAttr))
 		}
 
@@ -371,7 +377,7 @@ func (c *EthernetCollector) Collect(ctx context.Context) (*EthernetInfo, error)
 			if len(fields) >= 5 {
 				rState.GatewayIP = fields[2]
 				defaultDev := fields[4]
-				fmt.Printf("[debug] BondInterfaces=%v, defaultDev=%s, targetBond=%s\n", c.info.BondInterfaces, defaultDev, c.targetBond)
+				logrus.WithField("component", "ethernet").Debugf("BondInterfaces=%v, defaultDev=%s, targetBond=%s", c.info.BondInterfaces, defaultDev, c.targetBond)
 				if len(c.info.BondInterfaces) == 0 {
 					// 机器未配置 bond 接口，跳过 bond 路由检查
 					rState.DefaultRouteViaBond = true
@@ -427,6 +433,10 @@ func (c *EthernetCollector) Collect(ctx context.Context) (*EthernetInfo, error)
 		}
 	}
 
+	if c.roceEnabled {
+		c.info.RoCE = c.collectRoCE(ctx)
+	}
+
 	return c.info, nil
 }
 
@@ -0,0 +1,201 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/scitix/sichek/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// NetSysfsPath and IBSysfsPath are vars so that tests can point them to a
+// t.TempDir() holding a fake sysfs tree.
+var (
+	NetSysfsPath = "/sys/class/net"
+	IBSysfsPath  = "/sys/class/infiniband"
+)
+
+// RoCEState is the state of a RoCE capable Ethernet NIC. PFC, ECN and DCQCN
+// are keyed by priority; ECN is the notification point (roce_np) and DCQCN
+// the reaction point (roce_rp) of the mlx5 congestion control.
+type RoCEState struct {
+	Name            string       `json:"name"`
+	IBDev           string       `json:"ib_dev"`
+	OperState       string       `json:"oper_state"`
+	IsUp            bool         `json:"is_up"`
+	Speed           int          `json:"speed"` // Mbps
+	MTU             int          `json:"mtu"`
+	Driver          string       `json:"driver"`
+	DriverVersion   string       `json:"driver_version"`
+	FirmwareVersion string       `json:"firmware_version"`
+	PFC             map[int]bool `json:"pfc"`
+	ECN             map[int]bool `json:"ecn"`
+	DCQCN           map[int]bool `json:"dcqcn"`
+}
+
+// EnableRoCE makes Collect also gather the RoCE NIC states. An empty
+// interfaces list discovers the netdevs of all RDMA devices whose link layer
+// is Ethernet.
+func (c *EthernetCollector) EnableRoCE(interfaces []string) {
+	c.roceEnabled = true
+	c.roceInterfaces = interfaces
+}
+
+func (c *EthernetCollector) collectRoCE(ctx context.Context) map[string]RoCEState {
+	netdevs := findRoCENetdevs()
+	interfaces := c.roceInterfaces
+	if len(interfaces) == 0 {
+		for netdev := range netdevs {
+			interfaces = append(interfaces, netdev)
+		}
+		sort.Strings(interfaces)
+	}
+
+	states := make(map[string]RoCEState, len(interfaces))
+	for _, iface := range interfaces {
+		state := collectRoCESysfs(iface)
+		state.IBDev = netdevs[iface]
+
+		outEthI, err := utils.ExecCommand(ctx, "ethtool", "-i", iface)
+		if err != nil {
+			logrus.WithField("component", "ethernet").Warnf("failed to get driver info of %s: %v", iface, err)
+		}
+		state.Driver, state.DriverVersion, state.FirmwareVersion = parseEthtoolDriverInfo(string(outEthI))
+
+		outPFC, err := utils.ExecCommand(ctx, "dcb", "pfc", "show", "dev", iface)
+		if err != nil {
+			logrus.WithField("component", "ethernet").Warnf("failed to get pfc config of %s: %v", iface, err)
+		}
+		state.PFC = ParseDcbPfc(string(outPFC))
+		states[iface] = state
+	}
+	return states
+}
+
+// findRoCENetdevs maps the netdevs of the RDMA devices with an Ethernet link
+// layer to their RDMA device name.
+func findRoCENetdevs() map[string]string {
+	netdevs := make(map[string]string)
+	linkLayers, _ := filepath.Glob(hostfs.Path(IBSysfsPath, "*", "ports", "*", "link_layer"))
+	for _, linkLayer := range linkLayers {
+		if readSysfs(linkLayer) != "Ethernet" {
+			continue
+		}
+		ibDev := filepath.Base(filepath.Dir(filepath.Dir(filepath.Dir(linkLayer))))
+		nets, _ := filepath.Glob(hostfs.Path(IBSysfsPath, ibDev, "device", "net", "*"))
+		for _, net := range nets {
+			netdevs[filepath.Base(net)] = ibDev
+		}
+	}
+	return netdevs
+}
+
+func collectRoCESysfs(iface string) RoCEState {
+	base := hostfs.Path(NetSysfsPath, iface)
+	state := RoCEState{Name: iface}
+	state.OperState = readSysfs(filepath.Join(base, "operstate"))
+	state.IsUp = state.OperState == "up"
+	// speed reads -1 or fails with EINVAL while the link is down
+	state.Speed, _ = strconv.Atoi(readSysfs(filepath.Join(base, "speed")))
+	state.MTU, _ = strconv.Atoi(readSysfs(filepath.Join(base, "mtu")))
+	state.ECN = readPriorityFlags(filepath.Join(base, "ecn", "roce_np", "enable"))
+	state.DCQCN = readPriorityFlags(filepath.Join(base, "ecn", "roce_rp", "enable"))
+	return state
+}
+
+// readPriorityFlags reads the per priority 0/1 files of an ecn enable dir.
+func readPriorityFlags(dir string) map[int]bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	flags := make(map[int]bool, len(entries))
+	for _, entry := range entries {
+		prio, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		flags[prio] = readSysfs(filepath.Join(dir, entry.Name())) == "1"
+	}
+	return flags
+}
+
+func readSysfs(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// parseEthtoolDriverInfo parses the output of `ethtool -i <iface>`.
+func parseEthtoolDriverInfo(out string) (driver, version, firmware string) {
+	for _, line := range strings.Split(out, "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "driver":
+			driver = value
+		case "version":
+			version = value
+		case "firmware-version":
+			// drop the PSID suffix, e.g. "28.39.1002 (MT_0000000838)"
+			if fields := strings.Fields(value); len(fields) > 0 {
+				firmware = fields[0]
+			}
+		}
+	}
+	return driver, version, firmware
+}
+
+// ParseDcbPfc parses the prio-pfc field of `dcb pfc show dev <iface>`, e.g.
+// "pfc-cap 8 macsec-bypass off delay 0\nprio-pfc 0:off 1:off 2:off 3:on ...".
+func ParseDcbPfc(out string) map[int]bool {
+	var pfc map[int]bool
+	inPrioPfc := false
+	for _, field := range strings.Fields(out) {
+		if field == "prio-pfc" {
+			inPrioPfc = true
+			pfc = make(map[int]bool)
+			continue
+		}
+		if !inPrioPfc {
+			continue
+		}
+		prio, enabled, found := strings.Cut(field, ":")
+		if !found {
+			inPrioPfc = false
+			continue
+		}
+		p, err := strconv.Atoi(prio)
+		if err != nil {
+			inPrioPfc = false
+			continue
+		}
+		pfc[p] = enabled == "on"
+	}
+	return pfc
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeSysfs(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRoCESysfs(t *testing.T) {
+	root := t.TempDir()
+	oldNet, oldIB := NetSysfsPath, IBSysfsPath
+	NetSysfsPath, IBSysfsPath = filepath.Join(root, "net"), filepath.Join(root, "infiniband")
+	defer func() { NetSysfsPath, IBSysfsPath = oldNet, oldIB }()
+
+	writeSysfs(t, filepath.Join(IBSysfsPath, "mlx5_0", "ports", "1", "link_layer"), "InfiniBand")
+	writeSysfs(t, filepath.Join(IBSysfsPath, "mlx5_0", "device", "net", "ib0", "mtu"), "4092")
+	writeSysfs(t, filepath.Join(IBSysfsPath, "mlx5_2", "ports", "1", "link_layer"), "Ethernet")
+	writeSysfs(t, filepath.Join(IBSysfsPath, "mlx5_2", "device", "net", "eth2", "mtu"), "4200")
+
+	assert.Equal(t, map[string]string{"eth2": "mlx5_2"}, findRoCENetdevs())
+
+	eth2 := filepath.Join(NetSysfsPath, "eth2")
+	writeSysfs(t, filepath.Join(eth2, "operstate"), "up")
+	writeSysfs(t, filepath.Join(eth2, "speed"), "200000")
+	writeSysfs(t, filepath.Join(eth2, "mtu"), "4200")
+	for prio, enabled := range []string{"0", "0", "0", "1"} {
+		writeSysfs(t, filepath.Join(eth2, "ecn", "roce_np", "enable", strconv.Itoa(prio)), enabled)
+		writeSysfs(t, filepath.Join(eth2, "ecn", "roce_rp", "enable", strconv.Itoa(prio)), "0")
+	}
+
+	state := collectRoCESysfs("eth2")
+	assert.True(t, state.IsUp)
+	assert.Equal(t, 200000, state.Speed)
+	assert.Equal(t, 4200, state.MTU)
+	assert.Equal(t, map[int]bool{0: false, 1: false, 2: false, 3: true}, state.ECN)
+	assert.False(t, state.DCQCN[3])
+
+	// a netdev without the mlx5 ecn dir has no ECN/DCQCN info
+	assert.Nil(t, collectRoCESysfs("eth9").ECN)
+}
+
+func TestParseRoCECommands(t *testing.T) {
+	driver, version, firmware := parseEthtoolDriverInfo(`driver: mlx5_core
+version: 24.10-1.1.4
+firmware-version: 28.39.1002 (MT_0000000838)
+bus-info: 0000:1a:00.0
+`)
+	assert.Equal(t, "mlx5_core", driver)
+	assert.Equal(t, "24.10-1.1.4", version)
+	assert.Equal(t, "28.39.1002", firmware)
+
+	pfc := ParseDcbPfc(`pfc-cap 8 macsec-bypass off delay 0
+prio-pfc 0:off 1:off 2:off 3:on 4:off 5:off 6:off 7:off
+`)
+	assert.Len(t, pfc, 8)
+	assert.True(t, pfc[3])
+	assert.False(t, pfc[4])
+	assert.Nil(t, ParseDcbPfc(""))
+}
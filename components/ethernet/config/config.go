@@ -46,19 +46,21 @@ func (c *EthernetUserConfig) SetQueryInterval(newInterval common.Duration) {
 }
 
 const (
-	EthernetL1CheckerName = "L1(Physical Link)"
-	EthernetL2CheckerName = "L2(Bond)"
-	EthernetL3CheckerName = "L3(LACP)"
-	EthernetL4CheckerName = "L4(ARP)"
-	EthernetL5CheckerName = "L5(Routing)"
+	EthernetL1CheckerName   = "L1(Physical Link)"
+	EthernetL2CheckerName   = "L2(Bond)"
+	EthernetL3CheckerName   = "L3(LACP)"
+	EthernetL4CheckerName   = "L4(ARP)"
+	EthernetL5CheckerName   = "L5(Routing)"
+	EthernetRoCECheckerName = "RoCE(NIC)"
 )
 
 var EthernetCheckItems = map[string]string{
-	EthernetL1CheckerName: "Check Layer 1 properties",
-	EthernetL2CheckerName: "Check Layer 2 properties",
-	EthernetL3CheckerName: "Check Layer 3 properties",
-	EthernetL4CheckerName: "Check Layer 4 properties",
-	EthernetL5CheckerName: "Check Layer 5 properties",
+	EthernetL1CheckerName:   "Check Layer 1 properties",
+	EthernetL2CheckerName:   "Check Layer 2 properties",
+	EthernetL3CheckerName:   "Check Layer 3 properties",
+	EthernetL4CheckerName:   "Check Layer 4 properties",
+	EthernetL5CheckerName:   "Check Layer 5 properties",
+	EthernetRoCECheckerName: "Check RoCE NIC link, PFC/ECN/DCQCN and driver/firmware",
 }
 
 func LoadDefaultEventRules() (common.EventRuleGroup, error) {
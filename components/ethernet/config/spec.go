@@ -39,6 +39,23 @@ type EthernetSpecConfig struct {
 	Miimon         int    `json:"miimon" yaml:"miimon"`
 	UpDelay        int    `json:"updelay" yaml:"updelay"`
 	DownDelay      int    `json:"downdelay" yaml:"downdelay"`
+
+	RoCE *RoCESpecConfig `json:"roce,omitempty" yaml:"roce,omitempty"`
+}
+
+// RoCESpecConfig enables the RoCE NIC checks for RoCEv2 over plain Ethernet.
+// Empty fields are not checked.
+type RoCESpecConfig struct {
+	// Interfaces defaults to the netdevs of all RDMA devices with an Ethernet link layer.
+	Interfaces      []string `json:"interfaces,omitempty" yaml:"interfaces,omitempty"`
+	Speed           int      `json:"speed" yaml:"speed"` // Mbps
+	MTU             int      `json:"mtu" yaml:"mtu"`
+	PFCPriorities   []int    `json:"pfc_priorities" yaml:"pfc_priorities"`
+	ECN             bool     `json:"ecn" yaml:"ecn"`
+	DCQCN           bool     `json:"dcqcn" yaml:"dcqcn"`
+	Driver          string   `json:"driver" yaml:"driver"`
+	DriverVersion   string   `json:"driver_version" yaml:"driver_version"`
+	FirmwareVersion string   `json:"firmware_version" yaml:"firmware_version"`
 }
 
 type EthernetSpecs struct {
@@ -18,6 +18,8 @@ package ethernet
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -29,6 +31,7 @@ import (
 	"github.com/scitix/sichek/components/ethernet/config"
 	ethmetrics "github.com/scitix/sichek/components/ethernet/metrics"
 	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
 	"github.com/scitix/sichek/pkg/utils"
 
 	"github.com/sirupsen/logrus"
@@ -42,6 +45,7 @@ type component struct {
 	cfgMutex      sync.Mutex
 	collector     *collector.EthernetCollector
 	checkers      []common.Checker
+	specMtx       sync.RWMutex
 	filter        *filter.EventFilter
 	metrics       *ethmetrics.EthernetMetrics
 
@@ -104,23 +108,20 @@ func newEthernetComponent(cfgFile string, specFile string, ignoredCheckers []str
 		filterPointer = nil
 	}
 
-	spec, err := config.LoadSpec(specFile)
-	if err != nil {
-		logrus.WithField("component", "ethernet").Warnf("failed to load spec %s: %v", specFile, err)
+	spec, specErr := config.LoadSpec(specFile)
+	if specErr != nil {
+		logrus.WithField("component", "ethernet").Warnf("failed to load spec %s: %v", specFile, specErr)
 	}
 
-	targetBond := ""
-	if spec != nil {
-		targetBond = spec.TargetBond
-	}
-
-	collectorInst, err := collector.NewEthernetCollector(targetBond)
+	collectorInst, err := newCollector(spec)
 	if err != nil {
 		logrus.WithField("component", "ethernet").Errorf("NewEthernetComponent create collector failed: %v", err)
 		return nil, err
 	}
 
-	checkers, err := checker.NewCheckers(cfg, spec)
+	checkers, err := common.CheckersOrSpecMissing(consts.ComponentNameEthernet, spec, specErr, func(spec *config.EthernetSpecConfig) ([]common.Checker, error) {
+		return checker.NewCheckers(cfg, spec)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -155,7 +156,10 @@ func (c *component) Name() string {
 
 func (c *component) HealthCheck(ctx context.Context) (*common.Result, error) {
 	timer := common.NewTimer(fmt.Sprintf("%s-HealthCheck-Cost", c.componentName))
-	ethInfo, err := c.collector.Collect(ctx)
+	c.specMtx.RLock()
+	collectorInst, checkers := c.collector, c.checkers
+	c.specMtx.RUnlock()
+	ethInfo, err := common.Collect(ctx, c.componentName, collectorInst.Collect)
 	if err != nil {
 		logrus.WithField("component", "ethernet").Errorf("failed to collect ethernet info: %v", err)
 		return nil, err
@@ -165,7 +169,7 @@ func (c *component) HealthCheck(ctx context.Context) (*common.Result, error) {
 		c.metrics.ExportMetrics(ethInfo)
 	}
 
-	result := common.Check(ctx, c.componentName, ethInfo, c.checkers)
+	result := common.Check(ctx, c.componentName, ethInfo, checkers)
 	timer.Mark("ethernet-check")
 
 	if c.filter != nil {
@@ -250,6 +254,51 @@ func (c *component) Update(cfg common.ComponentUserConfig) error {
 	return c.service.Update(cfg)
 }
 
+// newCollector builds the collector for the target bond and RoCE interfaces of spec.
+func newCollector(spec *config.EthernetSpecConfig) (*collector.EthernetCollector, error) {
+	targetBond := ""
+	if spec != nil {
+		targetBond = spec.TargetBond
+	}
+	collectorInst, err := collector.NewEthernetCollector(targetBond)
+	if err != nil {
+		return nil, err
+	}
+	if spec != nil && spec.RoCE != nil {
+		collectorInst.EnableRoCE(spec.RoCE.Interfaces)
+	}
+	return collectorInst, nil
+}
+
+// UpdateSpec reloads the ethernet spec and rebuilds the collector and the
+// checkers, as the target bond and the RoCE interfaces come from the spec.
+func (c *component) UpdateSpec(specFile string) error {
+	spec, err := config.LoadSpec(specFile)
+	if err != nil {
+		return fmt.Errorf("load ethernet spec %s: %w", specFile, err)
+	}
+	if spec == nil {
+		return fmt.Errorf("ethernet spec is nil after loading from %s", specFile)
+	}
+	c.cfgMutex.Lock()
+	cfg := c.cfg
+	c.cfgMutex.Unlock()
+	checkers, err := checker.NewCheckers(cfg, spec)
+	if err != nil {
+		return err
+	}
+	collectorInst, err := newCollector(spec)
+	if err != nil {
+		return err
+	}
+	c.specMtx.Lock()
+	c.collector = collectorInst
+	c.checkers = checkers
+	c.specMtx.Unlock()
+	logrus.WithField("component", "ethernet").Infof("reloaded spec from %s", specFile)
+	return nil
+}
+
 func (c *component) Status() bool {
 	return c.service.Status()
 }
@@ -270,6 +319,7 @@ func (c *component) PrintInfo(info common.Info, result *common.Result, summaryPr
 	l3Print := fmt.Sprintf("L3(LACP): %sNot Checked%s", consts.Yellow, consts.Reset)
 	l4Print := fmt.Sprintf("L4(ARP) : %sNot Checked%s", consts.Yellow, consts.Reset)
 	l5Print := fmt.Sprintf("L5(Route): %sNot Checked%s", consts.Yellow, consts.Reset)
+	rocePrint := ""
 
 	utils.PrintTitle("Ethernet", "-")
 	checkerResults := result.Checkers
@@ -297,46 +347,78 @@ func (c *component) PrintInfo(info common.Info, result *common.Result, summaryPr
 			l4Print = fmt.Sprintf("L4(ARP)          : %s%s%s", statusColor, statusText, consts.Reset)
 		case config.EthernetL5CheckerName:
 			l5Print = fmt.Sprintf("L5(Routing)      : %s%s%s", statusColor, statusText, consts.Reset)
+		case config.EthernetRoCECheckerName:
+			rocePrint = fmt.Sprintf("RoCE(NIC)        : %s%s%s", statusColor, statusText, consts.Reset)
 		}
 	}
 
 	ethInfo, ok := info.(*collector.EthernetInfo)
 	if ok && len(ethInfo.BondInterfaces) > 0 {
 		for _, bond := range ethInfo.BondInterfaces {
-			fmt.Printf("Bond Interface: %s\n", bond)
+			printer.Printf("Bond Interface: %s\n", bond)
 			if sysfs, exists := ethInfo.SysfsBonding[bond]; exists {
 				mode := sysfs["mode"]
 				miimon := sysfs["miimon"]
 				lacpRate := sysfs["lacp_rate"]
 				slaves := strings.Join(ethInfo.BondSlaves[bond], ", ")
 
-				fmt.Printf("Bond Mode: %-25s ", mode)
-				fmt.Printf("MII Monitor: %-25s\n", miimon)
-				fmt.Printf("LACP Rate: %-25s ", lacpRate)
-				fmt.Printf("Slaves     : %-25s\n", slaves)
+				printer.Printf("Bond Mode: %-25s ", mode)
+				printer.Printf("MII Monitor: %-25s\n", miimon)
+				printer.Printf("LACP Rate: %-25s ", lacpRate)
+				printer.Printf("Slaves     : %-25s\n", slaves)
 			}
 
 			// Try parsing sysctl rp_filter
 			if rpFilter, exists := ethInfo.RPFilter[bond]; exists {
-				fmt.Printf("RP Filter: %-25s\n", rpFilter)
+				printer.Printf("RP Filter: %-25s\n", rpFilter)
 			}
-			fmt.Println()
+			printer.Println()
+		}
+	}
+
+	if ok && len(ethInfo.RoCE) > 0 {
+		names := make([]string, 0, len(ethInfo.RoCE))
+		for name := range ethInfo.RoCE {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		printer.Printf("%-12s%-12s%-8s%-10s%-8s%-10s%-10s%-10s%-10s%s\n", "RoCE NIC", "IB Dev", "State", "Speed", "MTU", "PFC", "ECN", "DCQCN", "Driver", "Firmware")
+		for _, name := range names {
+			s := ethInfo.RoCE[name]
+			printer.Printf("%-12s%-12s%-8s%-10d%-8d%-10s%-10s%-10s%-10s%s\n", s.Name, s.IBDev, s.OperState, s.Speed, s.MTU,
+				roceFlags(s.PFC), roceFlags(s.ECN), roceFlags(s.DCQCN), s.Driver, s.FirmwareVersion)
 		}
+		printer.Println()
 	}
 
-	fmt.Printf("%-35s%-35s\n", l1Print, l2Print)
-	fmt.Printf("%-35s%-35s\n", l3Print, l4Print)
-	fmt.Printf("%-35s\n", l5Print)
+	printer.Printf("%-35s%-35s\n", l1Print, l2Print)
+	printer.Printf("%-35s%-35s\n", l3Print, l4Print)
+	printer.Printf("%-35s%-35s\n", l5Print, rocePrint)
 
 	if len(ethEvent) == 0 {
-		fmt.Printf("\nErrors Events:\n\tNo Ethernet Events Detected\n")
+		printer.Printf("\nErrors Events:\n\tNo Ethernet Events Detected\n")
 	} else {
-		fmt.Printf("\nErrors Events:\n")
+		printer.Printf("\nErrors Events:\n")
 		for _, v := range ethEvent {
-			fmt.Printf("\t%s\n", v)
+			printer.Printf("\t%s\n", v)
 		}
 	}
 
-	fmt.Println()
+	printer.Println()
 	return checkAllPassed
 }
+
+// roceFlags formats the enabled priorities of a per priority flag map, e.g. "3".
+func roceFlags(flags map[int]bool) string {
+	var enabled []string
+	for prio, on := range flags {
+		if on {
+			enabled = append(enabled, strconv.Itoa(prio))
+		}
+	}
+	if len(enabled) == 0 {
+		return "-"
+	}
+	sort.Strings(enabled)
+	return strings.Join(enabled, ",")
+}
@@ -172,3 +172,61 @@ func TestEthernetCheckersFailures(t *testing.T) {
 		assert.Equal(t, consts.StatusAbnormal, res.Status, "Checker %s should have failed", c.Name())
 	}
 }
+
+func TestRoCEChecker(t *testing.T) {
+	spec := &config.EthernetSpecConfig{
+		RoCE: &config.RoCESpecConfig{
+			Speed:           200000,
+			MTU:             4200,
+			PFCPriorities:   []int{3},
+			ECN:             true,
+			DCQCN:           true,
+			Driver:          "mlx5_core",
+			FirmwareVersion: "28.39.1002",
+		},
+	}
+	healthy := collector.RoCEState{
+		Name: "eth2", IBDev: "mlx5_2", OperState: "up", IsUp: true, Speed: 200000, MTU: 4200,
+		Driver: "mlx5_core", FirmwareVersion: "28.39.1002",
+		PFC:   map[int]bool{0: false, 3: true},
+		ECN:   map[int]bool{3: true},
+		DCQCN: map[int]bool{3: true},
+	}
+
+	checkers, err := checker.NewCheckers(&config.EthernetUserConfig{
+		Ethernet: &config.EthernetConfig{IgnoredCheckers: []string{
+			config.EthernetL1CheckerName, config.EthernetL2CheckerName, config.EthernetL3CheckerName,
+			config.EthernetL4CheckerName, config.EthernetL5CheckerName,
+		}},
+	}, spec)
+	assert.NoError(t, err)
+	assert.Len(t, checkers, 1)
+	roceChecker := checkers[0]
+	assert.Equal(t, config.EthernetRoCECheckerName, roceChecker.Name())
+
+	ctx := context.Background()
+	res, err := roceChecker.Check(ctx, &collector.EthernetInfo{RoCE: map[string]collector.RoCEState{"eth2": healthy}})
+	assert.NoError(t, err)
+	assert.Equal(t, consts.StatusNormal, res.Status, res.Detail)
+
+	res, err = roceChecker.Check(ctx, &collector.EthernetInfo{})
+	assert.NoError(t, err)
+	assert.Equal(t, "NoRoCEInterface", res.ErrorName)
+
+	misconfigured := healthy
+	misconfigured.PFC = map[int]bool{3: false, 4: true}
+	misconfigured.DCQCN = map[int]bool{3: false}
+	misconfigured.FirmwareVersion = "28.36.1010"
+	down := healthy
+	down.Name, down.OperState, down.IsUp, down.Speed = "eth3", "down", false, -1
+	res, err = roceChecker.Check(ctx, &collector.EthernetInfo{RoCE: map[string]collector.RoCEState{"eth2": misconfigured, "eth3": down}})
+	assert.NoError(t, err)
+	assert.Equal(t, consts.StatusAbnormal, res.Status)
+	assert.Equal(t, consts.LevelCritical, res.Level)
+	// the first critical failure names the result, later warnings only add details
+	assert.Equal(t, "PFCMisconfigured", res.ErrorName)
+	assert.Contains(t, res.Detail, "Expected: 3, Actual: 4")
+	assert.Contains(t, res.Detail, "DCQCN disabled on priority 3")
+	assert.Contains(t, res.Detail, "firmware version mismatch")
+	assert.Contains(t, res.Detail, "RoCE NIC eth3 link not up")
+}
@@ -32,6 +32,7 @@ type EthernetMetrics struct {
 	TrafficStatsGauge *common.GaugeVecMetricExporter
 	LACPStatusGauge   *common.GaugeVecMetricExporter
 	SystemStatusGauge *common.GaugeVecMetricExporter
+	RoCEStatusGauge   *common.GaugeVecMetricExporter
 }
 
 func NewEthernetMetrics() *EthernetMetrics {
@@ -44,6 +45,7 @@ func NewEthernetMetrics() *EthernetMetrics {
 		TrafficStatsGauge: common.NewGaugeVecMetricExporter(MetricPrefix+"_traffic", []string{"interface"}),
 		LACPStatusGauge:   common.NewGaugeVecMetricExporter(MetricPrefix+"_lacp", []string{"bond"}),
 		SystemStatusGauge: common.NewGaugeVecMetricExporter(MetricPrefix+"_system", nil),
+		RoCEStatusGauge:   common.NewGaugeVecMetricExporter(MetricPrefix+"_roce", []string{"interface"}),
 	}
 }
 
@@ -80,4 +82,9 @@ func (m *EthernetMetrics) ExportMetrics(info *collector.EthernetInfo) {
 	// 6. Export System Info
 	m.SystemStatusGauge.SetMetric("syslog_error_count", nil, float64(len(info.SyslogErrors)))
 	m.SystemStatusGauge.SetMetric("bond_count", nil, float64(len(info.BondInterfaces)))
+
+	// 7. Export RoCE NIC Status
+	for ifaceName, roceState := range info.RoCE {
+		m.RoCEStatusGauge.ExportStruct(roceState, []string{ifaceName}, TagPrefix)
+	}
 }
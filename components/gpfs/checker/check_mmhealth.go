@@ -0,0 +1,216 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/gpfs/collector"
+	"github.com/scitix/sichek/components/gpfs/config"
+	"github.com/scitix/sichek/consts"
+)
+
+// maxWaiterDetails bounds the waiters listed in the detail, a stuck daemon
+// easily has hundreds of them.
+const maxWaiterDetails = 10
+
+var (
+	// mmhealthAbnormalStatus are the states reported, DEPEND only follows
+	// the failure of another component
+	mmhealthAbnormalStatus = []string{"DEGRADED", "FAILED"}
+	// unmountedEvents are the filesystem events of a filesystem that should
+	// be mounted but is not, or no longer answers
+	unmountedEvents = []string{"fs_forced_unmount", "stale_mount", "unmounted_fs_check"}
+)
+
+type MMHealthChecker struct {
+	name            string
+	waiterThreshold time.Duration
+}
+
+func NewMMHealthChecker(checkerName string, cfg *config.GpfsMMHealthConfig) (common.Checker, error) {
+	if _, ok := config.GPFSMMHealthCheckItems[checkerName]; !ok {
+		return nil, fmt.Errorf("unknown gpfs mmhealth checker %s", checkerName)
+	}
+	return &MMHealthChecker{
+		name:            checkerName,
+		waiterThreshold: cfg.WaiterThreshold.Duration,
+	}, nil
+}
+
+func (c *MMHealthChecker) Name() string {
+	return c.name
+}
+
+func (c *MMHealthChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	result := config.GPFSMMHealthCheckItems[c.name]
+	info, ok := data.(*collector.MMHealthInfo)
+	if !ok {
+		result.Status = consts.StatusAbnormal
+		result.Detail = "invalid mmhealthInfo type"
+		return &result, fmt.Errorf("invalid mmhealthInfo type")
+	}
+	if !info.Installed {
+		result.Status = consts.StatusNormal
+		result.Level = consts.LevelInfo
+		result.Curr = "N/A"
+		result.Detail = "mmhealth not found, the node is not a Storage Scale client"
+		result.Suggestion = ""
+		return &result, nil
+	}
+	queryErr := info.HealthError
+	if c.name == config.GPFSLongWaitersCheckerName {
+		queryErr = info.WaitersError
+	}
+	if queryErr != "" {
+		// the daemon not answering in time is itself a symptom, but not the
+		// one of this checker
+		result.Status = consts.StatusAbnormal
+		result.Level = consts.LevelWarning
+		result.Curr = "query failed"
+		result.Detail = queryErr
+		return &result, nil
+	}
+
+	var devices, details []string
+	switch c.name {
+	case config.GPFSLongWaitersCheckerName:
+		devices, details = c.longWaiters(info, &result)
+	case config.GPFSFSUnmountedCheckerName:
+		devices, details = unmountedFilesystems(info)
+	case config.GPFSQuorumCheckerName:
+		devices, details = quorumIssues(info)
+	case config.GPFSMMHealthCheckerName:
+		devices, details = degradedComponents(info)
+	}
+	if len(details) > 0 {
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(devices, ",")
+		result.Detail = strings.Join(details, "; ")
+		if result.Curr == "" {
+			result.Curr = fmt.Sprintf("%d abnormal", len(details))
+		}
+	} else {
+		result.Status = consts.StatusNormal
+		if result.Curr == "" {
+			result.Curr = "Healthy"
+		}
+		result.Suggestion = ""
+	}
+	return &result, nil
+}
+
+func (c *MMHealthChecker) longWaiters(info *collector.MMHealthInfo, result *common.CheckerResult) ([]string, []string) {
+	result.Spec = fmt.Sprintf("<%s", c.waiterThreshold)
+	result.Curr = "0s"
+	if len(info.Waiters) > 0 {
+		// the waiters are sorted, the longest first
+		result.Curr = info.Waiters[0].Wait.Round(time.Second).String()
+	}
+	var names, details []string
+	long := 0
+	for _, waiter := range info.Waiters {
+		if waiter.Wait < c.waiterThreshold {
+			continue
+		}
+		long++
+		if !slices.Contains(names, waiter.Name) {
+			names = append(names, waiter.Name)
+		}
+		if len(details) < maxWaiterDetails {
+			details = append(details, fmt.Sprintf("thread %s %s waiting %s: %s", waiter.Thread, waiter.Name, waiter.Wait.Round(time.Second), waiter.Reason))
+		}
+	}
+	if long > len(details) {
+		details = append(details, fmt.Sprintf("and %d more waiters", long-len(details)))
+	}
+	return names, details
+}
+
+func unmountedFilesystems(info *collector.MMHealthInfo) ([]string, []string) {
+	var filesystems, details []string
+	add := func(fs, detail string) {
+		if !slices.Contains(filesystems, fs) {
+			filesystems = append(filesystems, fs)
+		}
+		details = append(details, detail)
+	}
+	for _, state := range info.States {
+		if state.EntityType == "FILESYSTEM" && state.Status == "FAILED" {
+			add(state.Entity, fmt.Sprintf("filesystem %s is %s", state.Entity, state.Status))
+		}
+	}
+	for _, event := range info.Events {
+		if slices.Contains(unmountedEvents, event.Event) {
+			add(event.Entity, fmt.Sprintf("filesystem %s: %s", event.Entity, eventDetail(event)))
+		}
+	}
+	return filesystems, details
+}
+
+// quorumIssues reports the quorum events, e.g. quorum_down or quorum_warn,
+// but not the ones of a quorum restored.
+func quorumIssues(info *collector.MMHealthInfo) ([]string, []string) {
+	var entities, details []string
+	for _, event := range info.Events {
+		if !strings.Contains(event.Event, "quorum") || strings.HasSuffix(event.Event, "_up") || strings.HasSuffix(event.Event, "_ok") {
+			continue
+		}
+		if !slices.Contains(entities, event.Entity) {
+			entities = append(entities, event.Entity)
+		}
+		details = append(details, fmt.Sprintf("%s %s: %s", event.Component, event.Entity, eventDetail(event)))
+	}
+	return entities, details
+}
+
+// degradedComponents reports the components not healthy but the node summary
+// and the filesystems, which gpfs-fs-unmounted reports.
+func degradedComponents(info *collector.MMHealthInfo) ([]string, []string) {
+	var components, details []string
+	for _, state := range info.States {
+		if state.Component == "NODE" || state.Component == "FILESYSTEM" || !slices.Contains(mmhealthAbnormalStatus, state.Status) {
+			continue
+		}
+		if !slices.Contains(components, state.Component) {
+			components = append(components, state.Component)
+		}
+		detail := fmt.Sprintf("%s %s is %s", state.Component, state.Entity, state.Status)
+		var events []string
+		for _, event := range info.Events {
+			if event.Component == state.Component && event.Entity == state.Entity {
+				events = append(events, event.Event)
+			}
+		}
+		if len(events) > 0 {
+			detail += fmt.Sprintf(" (%s)", strings.Join(events, ","))
+		}
+		details = append(details, detail)
+	}
+	return components, details
+}
+
+func eventDetail(event collector.MMHealthEvent) string {
+	if event.Arguments == "" {
+		return event.Event
+	}
+	return fmt.Sprintf("%s(%s)", event.Event, event.Arguments)
+}
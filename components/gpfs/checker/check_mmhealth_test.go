@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/gpfs/collector"
+	"github.com/scitix/sichek/components/gpfs/config"
+	"github.com/scitix/sichek/consts"
+)
+
+func TestMMHealthChecker(t *testing.T) {
+	info := &collector.MMHealthInfo{
+		Installed: true,
+		States: []collector.MMHealthState{
+			{Component: "NODE", Entity: "node1", EntityType: "NODE", Status: "FAILED"},
+			{Component: "GPFS", Entity: "node1", EntityType: "NODE", Status: "FAILED"},
+			{Component: "NETWORK", Entity: "ib0", EntityType: "NIC", Status: "HEALTHY"},
+			{Component: "FILESYSTEM", Entity: "gpfs0", EntityType: "FILESYSTEM", Status: "HEALTHY"},
+		},
+		Events: []collector.MMHealthEvent{
+			{Component: "GPFS", Entity: "node1", Event: "quorum_down"},
+			{Component: "GPFS", Entity: "node1", Event: "quorum_up"},
+			{Component: "FILESYSTEM", Entity: "gpfs1", Event: "stale_mount", Arguments: "gpfs1"},
+		},
+		Waiters: []collector.MMWaiter{
+			{Thread: "20566", Name: "NSDThread", Wait: 412 * time.Second, Reason: "for I/O completion on disk sdb"},
+			{Thread: "4152", Name: "SharedHashTabFetchHandlerThread", Wait: time.Second},
+		},
+	}
+	cfg := &config.GpfsMMHealthConfig{WaiterThreshold: common.Duration{Duration: 300 * time.Second}}
+
+	tests := []struct {
+		name       string
+		info       *collector.MMHealthInfo
+		wantStatus string
+		wantDevice string
+	}{
+		{config.GPFSLongWaitersCheckerName, info, consts.StatusAbnormal, "NSDThread"},
+		{config.GPFSFSUnmountedCheckerName, info, consts.StatusAbnormal, "gpfs1"},
+		{config.GPFSQuorumCheckerName, info, consts.StatusAbnormal, "node1"},
+		{config.GPFSMMHealthCheckerName, info, consts.StatusAbnormal, "GPFS"},
+		{config.GPFSQuorumCheckerName, &collector.MMHealthInfo{Installed: true}, consts.StatusNormal, ""},
+		{config.GPFSLongWaitersCheckerName, &collector.MMHealthInfo{}, consts.StatusNormal, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker, err := NewMMHealthChecker(tt.name, cfg)
+			if err != nil {
+				t.Fatalf("NewMMHealthChecker() error = %v", err)
+			}
+			result, err := checker.Check(context.Background(), tt.info)
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if result.Status != tt.wantStatus || result.Device != tt.wantDevice {
+				t.Errorf("Check() status = %s device = %q, want %s %q (detail: %s)", result.Status, result.Device, tt.wantStatus, tt.wantDevice, result.Detail)
+			}
+		})
+	}
+}
+
+func TestMMHealthCheckerQueryFailed(t *testing.T) {
+	checker, err := NewMMHealthChecker(config.GPFSLongWaitersCheckerName, &config.GpfsMMHealthConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, _ := checker.Check(context.Background(), &collector.MMHealthInfo{Installed: true, WaitersError: "mmdiag --waiters: timed out"})
+	if result.Status != consts.StatusAbnormal || result.Level != consts.LevelWarning {
+		t.Errorf("expected a warning when mmdiag fails, got %s %s", result.Status, result.Level)
+	}
+}
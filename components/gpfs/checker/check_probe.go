@@ -0,0 +1,114 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/gpfs/collector"
+	"github.com/scitix/sichek/components/gpfs/config"
+	"github.com/scitix/sichek/consts"
+)
+
+type ProbeChecker struct {
+	name             string
+	latencyThreshold time.Duration
+}
+
+func NewProbeChecker(checkerName string, cfg *config.GpfsProbeConfig) (common.Checker, error) {
+	if _, ok := config.GPFSProbeCheckItems[checkerName]; !ok {
+		return nil, fmt.Errorf("unknown gpfs probe checker %s", checkerName)
+	}
+	return &ProbeChecker{
+		name:             checkerName,
+		latencyThreshold: cfg.LatencyThreshold.Duration,
+	}, nil
+}
+
+func (c *ProbeChecker) Name() string {
+	return c.name
+}
+
+func (c *ProbeChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	result := config.GPFSProbeCheckItems[c.name]
+	probeInfo, ok := data.(*collector.ProbeInfo)
+	if !ok {
+		result.Status = consts.StatusAbnormal
+		result.Detail = "invalid probeInfo type"
+		return &result, fmt.Errorf("invalid probeInfo type")
+	}
+
+	mountPoints := make([]string, 0, len(probeInfo.Mounts))
+	for mountPoint := range probeInfo.Mounts {
+		mountPoints = append(mountPoints, mountPoint)
+	}
+	sort.Strings(mountPoints)
+
+	var abnormalMounts, details []string
+	var maxP99 time.Duration
+	for _, mountPoint := range mountPoints {
+		mount := probeInfo.Mounts[mountPoint]
+		switch c.name {
+		case config.GPFSProbeHangCheckerName:
+			if mount.Hung {
+				abnormalMounts = append(abnormalMounts, mountPoint)
+				details = append(details, fmt.Sprintf("%s hung in %s", mountPoint, mount.HungOp))
+			}
+		case config.GPFSProbeIOErrorCheckerName:
+			if mount.Error != "" {
+				abnormalMounts = append(abnormalMounts, mountPoint)
+				details = append(details, mount.Error)
+			}
+		case config.GPFSProbeLatencyCheckerName:
+			slow := false
+			for _, op := range []string{collector.ProbeOpWrite, collector.ProbeOpRead, collector.ProbeOpStat} {
+				latency, ok := mount.Latencies[op]
+				if !ok {
+					continue
+				}
+				if latency.P99 > maxP99 {
+					maxP99 = latency.P99
+				}
+				if latency.P99 > c.latencyThreshold {
+					slow = true
+					details = append(details, fmt.Sprintf("%s %s p50=%s p90=%s p99=%s", mountPoint, op, latency.P50, latency.P90, latency.P99))
+				}
+			}
+			if slow {
+				abnormalMounts = append(abnormalMounts, mountPoint)
+			}
+		}
+	}
+
+	if c.name == config.GPFSProbeLatencyCheckerName {
+		result.Curr = maxP99.String()
+		result.Spec = c.latencyThreshold.String()
+	}
+	if len(abnormalMounts) > 0 {
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(abnormalMounts, ",")
+		result.Detail = strings.Join(details, "; ")
+	} else {
+		result.Status = consts.StatusNormal
+		result.Detail = fmt.Sprintf("%d mount points probed", len(mountPoints))
+	}
+	return &result, nil
+}
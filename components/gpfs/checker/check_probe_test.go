@@ -0,0 +1,68 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/gpfs/collector"
+	"github.com/scitix/sichek/components/gpfs/config"
+	"github.com/scitix/sichek/consts"
+)
+
+func TestProbeChecker(t *testing.T) {
+	info := &collector.ProbeInfo{
+		Mounts: map[string]*collector.MountProbeResult{
+			"/mnt/gpfs": {
+				MountPoint: "/mnt/gpfs",
+				Latencies: map[string]collector.ProbeLatency{
+					collector.ProbeOpWrite: {P50: time.Millisecond, P90: 2 * time.Millisecond, P99: 800 * time.Millisecond},
+					collector.ProbeOpRead:  {P50: time.Millisecond, P90: time.Millisecond, P99: time.Millisecond},
+				},
+			},
+			"/mnt/lustre": {MountPoint: "/mnt/lustre", Hung: true, HungOp: collector.ProbeOpStat},
+		},
+	}
+	cfg := &config.GpfsProbeConfig{LatencyThreshold: common.Duration{Duration: 500 * time.Millisecond}}
+
+	tests := []struct {
+		name       string
+		wantStatus string
+		wantDevice string
+	}{
+		{config.GPFSProbeHangCheckerName, consts.StatusAbnormal, "/mnt/lustre"},
+		{config.GPFSProbeIOErrorCheckerName, consts.StatusNormal, ""},
+		{config.GPFSProbeLatencyCheckerName, consts.StatusAbnormal, "/mnt/gpfs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker, err := NewProbeChecker(tt.name, cfg)
+			if err != nil {
+				t.Fatalf("NewProbeChecker() error = %v", err)
+			}
+			result, err := checker.Check(context.Background(), info)
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if result.Status != tt.wantStatus || result.Device != tt.wantDevice {
+				t.Errorf("Check() status = %s device = %q, want %s %q (detail: %s)", result.Status, result.Device, tt.wantStatus, tt.wantDevice, result.Detail)
+			}
+		})
+	}
+}
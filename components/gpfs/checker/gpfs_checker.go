@@ -16,6 +16,8 @@ limitations under the License.
 package checker
 
 import (
+	"slices"
+
 	"github.com/scitix/sichek/components/common"
 	"github.com/scitix/sichek/components/gpfs/config"
 
@@ -46,3 +48,44 @@ func NewCheckers(cfg *config.GpfsUserConfig) ([]common.Checker, error) {
 
 	return usedCheckers, nil
 }
+
+// NewProbeCheckers creates the checkers of the I/O probe, or none if no mount point is configured.
+func NewProbeCheckers(cfg *config.GpfsUserConfig) ([]common.Checker, error) {
+	probeCfg := cfg.Gpfs.GetProbeConfig()
+	if probeCfg == nil {
+		return nil, nil
+	}
+	ignoredSet := make(map[string]struct{})
+	for _, checker := range cfg.Gpfs.IgnoredCheckers {
+		ignoredSet[checker] = struct{}{}
+	}
+	usedCheckers := make([]common.Checker, 0)
+	for checkerName := range config.GPFSProbeCheckItems {
+		if _, found := ignoredSet[checkerName]; found {
+			continue
+		}
+		checker, err := NewProbeChecker(checkerName, probeCfg)
+		if err != nil {
+			return nil, err
+		}
+		usedCheckers = append(usedCheckers, checker)
+	}
+	return usedCheckers, nil
+}
+
+// NewMMHealthCheckers creates the checkers of mmhealth and mmdiag.
+func NewMMHealthCheckers(cfg *config.GpfsUserConfig) ([]common.Checker, error) {
+	mmhealthCfg := cfg.Gpfs.GetMMHealthConfig()
+	usedCheckers := make([]common.Checker, 0)
+	for checkerName := range config.GPFSMMHealthCheckItems {
+		if slices.Contains(cfg.Gpfs.IgnoredCheckers, checkerName) {
+			continue
+		}
+		checker, err := NewMMHealthChecker(checkerName, mmhealthCfg)
+		if err != nil {
+			return nil, err
+		}
+		usedCheckers = append(usedCheckers, checker)
+	}
+	return usedCheckers, nil
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/gpfs/config"
+	"github.com/scitix/sichek/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MMHealthState is a State row of mmhealth node show -Y, the health of a
+// component, e.g. GPFS, NETWORK or FILESYSTEM, or of one of its entities,
+// e.g. a filesystem.
+type MMHealthState struct {
+	Component  string `json:"component"`
+	Entity     string `json:"entity"`
+	EntityType string `json:"entity_type"`
+	Status     string `json:"status"`
+}
+
+// MMHealthEvent is an active event of mmhealth node show -Y.
+type MMHealthEvent struct {
+	Component string `json:"component"`
+	Entity    string `json:"entity"`
+	Event     string `json:"event"`
+	Arguments string `json:"arguments,omitempty"`
+}
+
+// MMWaiter is a thread of the GPFS daemon waiting in mmdiag --waiters.
+type MMWaiter struct {
+	Thread string        `json:"thread"`
+	Name   string        `json:"name"`
+	Wait   time.Duration `json:"wait"`
+	Reason string        `json:"reason"`
+}
+
+type MMHealthInfo struct {
+	Time time.Time `json:"time"`
+	// Installed is false when mmhealth is not found, the node is not a
+	// Storage Scale client
+	Installed    bool            `json:"installed"`
+	HealthError  string          `json:"health_error,omitempty"`
+	States       []MMHealthState `json:"states"`
+	Events       []MMHealthEvent `json:"events"`
+	WaitersError string          `json:"waiters_error,omitempty"`
+	Waiters      []MMWaiter      `json:"waiters"`
+}
+
+func (info *MMHealthInfo) JSON() (string, error) {
+	data, err := json.Marshal(info)
+	return string(data), err
+}
+
+func (info *MMHealthInfo) ToString() string {
+	return common.ToString(info)
+}
+
+// MMHealthCollector runs mmhealth node show and mmdiag --waiters, each with
+// its own timeout since both hang with the daemon they query.
+type MMHealthCollector struct {
+	cfg *config.GpfsMMHealthConfig
+}
+
+func NewMMHealthCollector(cfg *config.GpfsMMHealthConfig) *MMHealthCollector {
+	return &MMHealthCollector{cfg: cfg}
+}
+
+func (c *MMHealthCollector) Collect(ctx context.Context) *MMHealthInfo {
+	info := &MMHealthInfo{Time: time.Now()}
+	mmhealth := c.lookBinary(ctx, "mmhealth")
+	if mmhealth == "" {
+		logrus.WithField("component", "GPFS-Collector").Debugf("mmhealth not found, bypass the mmhealth checks")
+		return info
+	}
+	info.Installed = true
+
+	output, err := c.exec(ctx, mmhealth, "node", "show", "-Y")
+	if err != nil {
+		info.HealthError = err.Error()
+	} else {
+		info.States, info.Events = parseMMHealthOutput(string(output))
+	}
+
+	mmdiag := c.lookBinary(ctx, "mmdiag")
+	if mmdiag == "" {
+		info.WaitersError = "mmdiag not found"
+		return info
+	}
+	output, err = c.exec(ctx, mmdiag, "--waiters")
+	if err != nil {
+		info.WaitersError = err.Error()
+	} else {
+		info.Waiters = parseMMDiagWaiters(string(output))
+	}
+	return info
+}
+
+func (c *MMHealthCollector) exec(ctx context.Context, command string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout.Duration)
+	defer cancel()
+	output, err := utils.ExecCommand(ctx, command, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %v", filepath.Base(command), strings.Join(args, " "), err)
+	}
+	return output, nil
+}
+
+// lookBinary returns the command to run, the Storage Scale commands are
+// usually not on the PATH but under /usr/lpp/mmfs/bin.
+func (c *MMHealthCollector) lookBinary(ctx context.Context, name string) string {
+	if _, err := utils.ExecCommand(ctx, "which", name); err == nil {
+		return name
+	}
+	// test runs on the host, like the commands, when sichek runs in a pod
+	path := filepath.Join(c.cfg.BinDir, name)
+	if _, err := utils.ExecCommand(ctx, "test", "-x", path); err == nil {
+		return path
+	}
+	return ""
+}
+
+// parseMMHealthOutput parses the State and Event rows of mmhealth -Y. Each
+// section starts with a HEADER row naming its fields, the values are URL
+// encoded.
+func parseMMHealthOutput(output string) ([]MMHealthState, []MMHealthEvent) {
+	var states []MMHealthState
+	var events []MMHealthEvent
+	headers := make(map[string][]string)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), ":")
+		if len(fields) < 3 || fields[0] != "mmhealth" {
+			continue
+		}
+		section := fields[1]
+		if fields[2] == "HEADER" {
+			headers[section] = fields
+			continue
+		}
+		header, ok := headers[section]
+		if !ok {
+			continue
+		}
+		row := make(map[string]string, len(header))
+		for i, key := range header {
+			if i < len(fields) {
+				value, err := url.PathUnescape(fields[i])
+				if err != nil {
+					value = fields[i]
+				}
+				row[key] = value
+			}
+		}
+		switch section {
+		case "State":
+			states = append(states, MMHealthState{
+				Component:  row["component"],
+				Entity:     row["entityname"],
+				EntityType: row["entitytype"],
+				Status:     row["status"],
+			})
+		case "Event":
+			events = append(events, MMHealthEvent{
+				Component: row["component"],
+				Entity:    row["entityname"],
+				Event:     row["event"],
+				Arguments: row["arguments"],
+			})
+		}
+	}
+	return states, events
+}
+
+var (
+	// Waiting 12.3456 sec since 10:07:13, monitored, thread 20566 NSDThread: for I/O completion on disk sdb
+	mmWaiterPattern = regexp.MustCompile(`^Waiting ([0-9.]+) sec since [^,]*,(?: \w+,)? thread (\d+) ([^:]+): (.*)$`)
+	// 0x7F2A4C003C80 waiting 1234.5678 seconds, NSDThread: for I/O completion on disk sdb
+	mmWaiterLegacyPattern = regexp.MustCompile(`^(0x[0-9A-Fa-f]+) waiting ([0-9.]+) seconds, ([^:]+): (.*)$`)
+)
+
+// parseMMDiagWaiters parses the waiters of mmdiag --waiters, the longest first.
+func parseMMDiagWaiters(output string) []MMWaiter {
+	var waiters []MMWaiter
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		var thread, name, wait, reason string
+		if m := mmWaiterPattern.FindStringSubmatch(line); m != nil {
+			wait, thread, name, reason = m[1], m[2], m[3], m[4]
+		} else if m := mmWaiterLegacyPattern.FindStringSubmatch(line); m != nil {
+			thread, wait, name, reason = m[1], m[2], m[3], m[4]
+		} else {
+			continue
+		}
+		seconds, err := strconv.ParseFloat(wait, 64)
+		if err != nil {
+			continue
+		}
+		waiters = append(waiters, MMWaiter{
+			Thread: thread,
+			Name:   strings.TrimSpace(name),
+			Wait:   time.Duration(seconds * float64(time.Second)),
+			Reason: strings.TrimSpace(reason),
+		})
+	}
+	sort.SliceStable(waiters, func(i, j int) bool { return waiters[i].Wait > waiters[j].Wait })
+	return waiters
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/gpfs/config"
+)
+
+const testMMHealthOutput = `mmhealth:State:HEADER:version:reserved:reserved:node:component:entityname:entitytype:status:laststatuschange:
+mmhealth:State:0:1:::node1:NODE:node1:NODE:DEGRADED:2025-03-01 10%3A00%3A00.000000 CST:
+mmhealth:State:0:1:::node1:GPFS:node1:NODE:HEALTHY:2025-03-01 10%3A00%3A00.000000 CST:
+mmhealth:State:0:1:::node1:FILESYSTEM:gpfs0:FILESYSTEM:FAILED:2025-03-01 10%3A00%3A00.000000 CST:
+mmhealth:Event:HEADER:version:reserved:reserved:node:component:entityname:entitytype:event:arguments:activesince:identifier:ishidden:
+mmhealth:Event:0:1:::node1:FILESYSTEM:gpfs0:FILESYSTEM:stale_mount:gpfs0%3A/mnt/gpfs0:2025-03-01 10%3A00%3A00.000000 CST:gpfs0:no:
+`
+
+const testMMDiagOutput = `
+=== mmdiag: waiters ===
+Waiting 0.0172 sec since 10:07:25, monitored, thread 4152 SharedHashTabFetchHandlerThread: on ThCond 0x180029B8B58 (LkObjCondvar), reason 'waiting for LX lock'
+Waiting 412.5000 sec since 10:00:33, monitored, thread 20566 NSDThread: for I/O completion on disk sdb
+0x7F2A4C003C80 waiting 61.2500 seconds, RemoteRetrieveThread: on ThCond 0x7F2A4C003D00 (MsgRecordCondvar), reason 'RPC wait'
+`
+
+func TestParseMMHealthOutput(t *testing.T) {
+	states, events := parseMMHealthOutput(testMMHealthOutput)
+	if len(states) != 3 || len(events) != 1 {
+		t.Fatalf("expected 3 states and 1 event, got %+v %+v", states, events)
+	}
+	if got := states[2]; got != (MMHealthState{Component: "FILESYSTEM", Entity: "gpfs0", EntityType: "FILESYSTEM", Status: "FAILED"}) {
+		t.Errorf("unexpected state %+v", got)
+	}
+	if got := events[0]; got.Event != "stale_mount" || got.Arguments != "gpfs0:/mnt/gpfs0" {
+		t.Errorf("unexpected event %+v", got)
+	}
+}
+
+func TestParseMMDiagWaiters(t *testing.T) {
+	waiters := parseMMDiagWaiters(testMMDiagOutput)
+	if len(waiters) != 3 {
+		t.Fatalf("expected 3 waiters, got %+v", waiters)
+	}
+	want := MMWaiter{Thread: "20566", Name: "NSDThread", Wait: 412500 * time.Millisecond, Reason: "for I/O completion on disk sdb"}
+	if waiters[0] != want {
+		t.Errorf("expected the longest waiter first %+v, got %+v", want, waiters[0])
+	}
+	if waiters[1].Thread != "0x7F2A4C003C80" || waiters[1].Name != "RemoteRetrieveThread" {
+		t.Errorf("unexpected legacy waiter %+v", waiters[1])
+	}
+}
+
+func writeFakeCommand(t *testing.T, dir, name, script string) {
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMMHealthCollect(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.GpfsMMHealthConfig{BinDir: dir, Timeout: common.Duration{Duration: 500 * time.Millisecond}}
+	collector := NewMMHealthCollector(cfg)
+
+	if info := collector.Collect(context.Background()); info.Installed {
+		t.Fatalf("expected mmhealth not installed, got %+v", info)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "health.out"), []byte(testMMHealthOutput), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeFakeCommand(t, dir, "mmhealth", "cat "+filepath.Join(dir, "health.out"))
+	writeFakeCommand(t, dir, "mmdiag", "exec sleep 5")
+	info := collector.Collect(context.Background())
+	if !info.Installed || info.HealthError != "" || len(info.States) != 3 {
+		t.Errorf("unexpected mmhealth result %+v", info)
+	}
+	if !strings.Contains(info.WaitersError, "timed out") || info.Waiters != nil {
+		t.Errorf("expected mmdiag to time out, got %q", info.WaitersError)
+	}
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/gpfs/config"
+)
+
+const (
+	ProbeOpWrite = "write"
+	ProbeOpRead  = "read"
+	ProbeOpStat  = "stat"
+
+	probeOpRemove = "remove"
+	probeFileSize = 4096
+)
+
+var errProbeTimeout = errors.New("probe timed out")
+
+// ProbeLatency holds the latency percentiles of a probe operation.
+type ProbeLatency struct {
+	P50 time.Duration `json:"p50"`
+	P90 time.Duration `json:"p90"`
+	P99 time.Duration `json:"p99"`
+}
+
+type MountProbeResult struct {
+	MountPoint string                  `json:"mount_point"`
+	Hung       bool                    `json:"hung"`
+	HungOp     string                  `json:"hung_op,omitempty"`
+	Error      string                  `json:"error,omitempty"`
+	Latencies  map[string]ProbeLatency `json:"latencies"`
+}
+
+type ProbeInfo struct {
+	Time   time.Time                    `json:"time"`
+	Mounts map[string]*MountProbeResult `json:"mounts"`
+}
+
+func (info *ProbeInfo) JSON() (string, error) {
+	data, err := json.Marshal(info)
+	return string(data), err
+}
+
+func (info *ProbeInfo) ToString() string {
+	return common.ToString(info)
+}
+
+// Prober performs small timed write/read/stat operations on the configured mount points.
+// An operation on a hung mount blocks in the kernel and cannot be cancelled, so its goroutine
+// is left behind and the mount is reported hung without new probes until the operation returns.
+type Prober struct {
+	cfg      *config.GpfsProbeConfig
+	fileName string
+
+	mtx      sync.Mutex
+	inflight map[string]string // mount point -> operation still running
+}
+
+func NewProber(cfg *config.GpfsProbeConfig) *Prober {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "unknown"
+	}
+	return &Prober{
+		cfg:      cfg,
+		fileName: fmt.Sprintf(".sichek-probe-%s", hostname),
+		inflight: make(map[string]string),
+	}
+}
+
+// Collect probes all mount points concurrently.
+func (p *Prober) Collect(ctx context.Context) *ProbeInfo {
+	info := &ProbeInfo{
+		Time:   time.Now(),
+		Mounts: make(map[string]*MountProbeResult, len(p.cfg.MountPoints)),
+	}
+	var mtx sync.Mutex
+	var wg sync.WaitGroup
+	for _, mountPoint := range p.cfg.MountPoints {
+		wg.Add(1)
+		go func(mountPoint string) {
+			defer wg.Done()
+			res := p.probeMount(ctx, mountPoint)
+			mtx.Lock()
+			info.Mounts[mountPoint] = res
+			mtx.Unlock()
+		}(mountPoint)
+	}
+	wg.Wait()
+	return info
+}
+
+func (p *Prober) probeMount(ctx context.Context, mountPoint string) *MountProbeResult {
+	res := &MountProbeResult{
+		MountPoint: mountPoint,
+		Latencies:  make(map[string]ProbeLatency),
+	}
+	p.mtx.Lock()
+	op, stuck := p.inflight[mountPoint]
+	p.mtx.Unlock()
+	if stuck {
+		res.Hung = true
+		res.HungOp = op
+		return res
+	}
+
+	path := filepath.Join(mountPoint, p.fileName)
+	data := make([]byte, probeFileSize)
+	steps := []struct {
+		op string
+		fn func() error
+	}{
+		{ProbeOpWrite, func() error { return writeSync(path, data) }},
+		{ProbeOpRead, func() error { _, err := os.ReadFile(path); return err }},
+		{ProbeOpStat, func() error { _, err := os.Stat(path); return err }},
+	}
+	samples := make(map[string][]time.Duration, len(steps))
+	for i := 0; i < p.cfg.Iterations; i++ {
+		for _, step := range steps {
+			cost, err := p.timedOp(ctx, mountPoint, step.op, step.fn)
+			if errors.Is(err, errProbeTimeout) {
+				res.Hung = true
+				res.HungOp = step.op
+				return res
+			}
+			if err != nil {
+				res.Error = fmt.Sprintf("%s %s: %v", step.op, path, err)
+				return res
+			}
+			samples[step.op] = append(samples[step.op], cost)
+		}
+	}
+	if _, err := p.timedOp(ctx, mountPoint, probeOpRemove, func() error { return os.Remove(path) }); errors.Is(err, errProbeTimeout) {
+		res.Hung = true
+		res.HungOp = probeOpRemove
+		return res
+	}
+	for op, durations := range samples {
+		res.Latencies[op] = latencyPercentiles(durations)
+	}
+	return res
+}
+
+// timedOp runs fn in its own goroutine and gives up after the probe timeout.
+func (p *Prober) timedOp(ctx context.Context, mountPoint string, op string, fn func() error) (time.Duration, error) {
+	p.mtx.Lock()
+	p.inflight[mountPoint] = op
+	p.mtx.Unlock()
+
+	done := make(chan error, 1)
+	start := time.Now()
+	go func() {
+		err := fn()
+		p.mtx.Lock()
+		delete(p.inflight, mountPoint)
+		p.mtx.Unlock()
+		done <- err
+	}()
+
+	timer := time.NewTimer(p.cfg.Timeout.Duration)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return time.Since(start), err
+	case <-timer.C:
+		return 0, fmt.Errorf("%s %w after %s", op, errProbeTimeout, p.cfg.Timeout.Duration)
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+func writeSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func latencyPercentiles(durations []time.Duration) ProbeLatency {
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return ProbeLatency{
+		P50: percentile(sorted, 50),
+		P90: percentile(sorted, 90),
+		P99: percentile(sorted, 99),
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
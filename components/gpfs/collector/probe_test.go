@@ -0,0 +1,88 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/gpfs/config"
+)
+
+func newTestProber(mountPoints ...string) *Prober {
+	return NewProber(&config.GpfsProbeConfig{
+		MountPoints: mountPoints,
+		Iterations:  3,
+		Timeout:     common.Duration{Duration: 200 * time.Millisecond},
+	})
+}
+
+func TestProberCollect(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing")
+	info := newTestProber(dir, missing).Collect(context.Background())
+
+	res := info.Mounts[dir]
+	if res == nil || res.Hung || res.Error != "" {
+		t.Fatalf("expected a healthy probe of %s, got %+v", dir, res)
+	}
+	for _, op := range []string{ProbeOpWrite, ProbeOpRead, ProbeOpStat} {
+		if _, ok := res.Latencies[op]; !ok {
+			t.Errorf("missing %s latency", op)
+		}
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, ".sichek-probe-*")); len(matches) != 0 {
+		t.Errorf("probe file not removed: %v", matches)
+	}
+
+	if res := info.Mounts[missing]; res == nil || res.Error == "" {
+		t.Errorf("expected an I/O error for %s, got %+v", missing, res)
+	}
+}
+
+func TestProberHung(t *testing.T) {
+	dir := t.TempDir()
+	p := newTestProber(dir)
+	release := make(chan struct{})
+	defer close(release)
+
+	if _, err := p.timedOp(context.Background(), dir, ProbeOpStat, func() error {
+		<-release
+		return nil
+	}); err == nil {
+		t.Fatalf("expected a timeout")
+	}
+	// The stuck operation is still running, so the next probe must not touch the mount.
+	res := p.Collect(context.Background()).Mounts[dir]
+	if !res.Hung || res.HungOp != ProbeOpStat {
+		t.Errorf("expected %s to be hung in stat, got %+v", dir, res)
+	}
+}
+
+func TestLatencyPercentiles(t *testing.T) {
+	var durations []time.Duration
+	for i := 100; i >= 1; i-- {
+		durations = append(durations, time.Duration(i)*time.Millisecond)
+	}
+	got := latencyPercentiles(durations)
+	want := ProbeLatency{P50: 50 * time.Millisecond, P90: 90 * time.Millisecond, P99: 99 * time.Millisecond}
+	if got != want {
+		t.Errorf("latencyPercentiles() = %+v, want %+v", got, want)
+	}
+}
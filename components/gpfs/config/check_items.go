@@ -85,3 +85,85 @@ var GPFSCheckItems = map[string]common.CheckerResult{
 		Suggestion:  "Check node RDMA network and GPFS log",
 	},
 }
+
+const (
+	GPFSProbeHangCheckerName    = "gpfs-probe-hang"
+	GPFSProbeIOErrorCheckerName = "gpfs-probe-io-error"
+	GPFSProbeLatencyCheckerName = "gpfs-probe-latency"
+)
+
+var GPFSProbeCheckItems = map[string]common.CheckerResult{
+	GPFSProbeHangCheckerName: {
+		Name:        GPFSProbeHangCheckerName,
+		Description: "Check if the probe I/O on the parallel filesystem mounts completes in time",
+		Status:      "",
+		Level:       consts.LevelCritical,
+		Detail:      "",
+		ErrorName:   "GPFSMountHung",
+		Suggestion:  "Check the filesystem client state, e.g. mmhealth or lctl, and the processes in D state on the mount",
+	},
+	GPFSProbeIOErrorCheckerName: {
+		Name:        GPFSProbeIOErrorCheckerName,
+		Description: "Check if the probe I/O on the parallel filesystem mounts succeeds",
+		Status:      "",
+		Level:       consts.LevelCritical,
+		Detail:      "",
+		ErrorName:   "GPFSProbeIOError",
+		Suggestion:  "Check that the mount is present and writable and the filesystem client log",
+	},
+	GPFSProbeLatencyCheckerName: {
+		Name:        GPFSProbeLatencyCheckerName,
+		Description: "Check if the p99 latency of the probe I/O is below the threshold",
+		Status:      "",
+		Level:       consts.LevelWarning,
+		Detail:      "",
+		ErrorName:   "GPFSProbeSlow",
+		Suggestion:  "Check the filesystem server load and the storage network",
+	},
+}
+
+const (
+	GPFSLongWaitersCheckerName = "gpfs-long-waiters"
+	GPFSFSUnmountedCheckerName = "gpfs-fs-unmounted"
+	GPFSQuorumCheckerName      = "gpfs-quorum"
+	GPFSMMHealthCheckerName    = "gpfs-mmhealth-degraded"
+)
+
+var GPFSMMHealthCheckItems = map[string]common.CheckerResult{
+	GPFSLongWaitersCheckerName: {
+		Name:        GPFSLongWaitersCheckerName,
+		Description: "Check if no GPFS thread waits longer than the threshold in mmdiag --waiters",
+		Status:      "",
+		Level:       consts.LevelCritical,
+		Detail:      "",
+		ErrorName:   "GPFSLongWaiters",
+		Suggestion:  "Check the waiter reasons with mmdiag --waiters, e.g. the NSD servers, the disks or the network they wait for",
+	},
+	GPFSFSUnmountedCheckerName: {
+		Name:        GPFSFSUnmountedCheckerName,
+		Description: "Check if mmhealth reports no filesystem failed, unmounted or stale",
+		Status:      "",
+		Level:       consts.LevelCritical,
+		Detail:      "",
+		ErrorName:   "GPFSFilesystemUnmounted",
+		Suggestion:  "Check mmhealth node show FILESYSTEM and remount the filesystem with mmmount",
+	},
+	GPFSQuorumCheckerName: {
+		Name:        GPFSQuorumCheckerName,
+		Description: "Check if mmhealth reports no quorum issue",
+		Status:      "",
+		Level:       consts.LevelCritical,
+		Detail:      "",
+		ErrorName:   "GPFSQuorumLost",
+		Suggestion:  "Check the quorum nodes with mmgetstate -a and the network to them",
+	},
+	GPFSMMHealthCheckerName: {
+		Name:        GPFSMMHealthCheckerName,
+		Description: "Check if mmhealth reports no degraded or failed component",
+		Status:      "",
+		Level:       consts.LevelWarning,
+		Detail:      "",
+		ErrorName:   "GPFSComponentDegraded",
+		Suggestion:  "Check the events of the component with mmhealth node show <component>",
+	},
+}
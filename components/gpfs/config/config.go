@@ -16,6 +16,8 @@ limitations under the License.
 package config
 
 import (
+	"time"
+
 	"github.com/scitix/sichek/components/common"
 )
 
@@ -24,10 +26,75 @@ type GpfsUserConfig struct {
 }
 
 type GpfsConfig struct {
-	QueryInterval common.Duration `json:"query_interval" yaml:"query_interval"`
-	CacheSize     int64           `json:"cache_size" yaml:"cache_size"`
-	// EnableMetrics   bool            `json:"enable_metrics" yaml:"enable_metrics"`
-	IgnoredCheckers []string `json:"ignored_checkers" yaml:"ignored_checkers"`
+	QueryInterval   common.Duration     `json:"query_interval" yaml:"query_interval"`
+	CacheSize       int64               `json:"cache_size" yaml:"cache_size"`
+	EnableMetrics   bool                `json:"enable_metrics" yaml:"enable_metrics"`
+	IgnoredCheckers []string            `json:"ignored_checkers" yaml:"ignored_checkers"`
+	Probe           *GpfsProbeConfig    `json:"probe,omitempty" yaml:"probe,omitempty"`
+	MMHealth        *GpfsMMHealthConfig `json:"mmhealth,omitempty" yaml:"mmhealth,omitempty"`
+}
+
+// GpfsProbeConfig configures the active I/O probe of the parallel filesystem mounts.
+// Each path must be a writable directory on a GPFS or Lustre mount.
+type GpfsProbeConfig struct {
+	MountPoints      []string        `json:"mount_points" yaml:"mount_points"`
+	Iterations       int             `json:"iterations" yaml:"iterations"`
+	Timeout          common.Duration `json:"timeout" yaml:"timeout"`
+	LatencyThreshold common.Duration `json:"latency_threshold" yaml:"latency_threshold"`
+}
+
+// GpfsMMHealthConfig configures the queries of mmhealth and mmdiag, run when
+// the Storage Scale client is installed.
+type GpfsMMHealthConfig struct {
+	BinDir          string          `json:"bin_dir" yaml:"bin_dir"`
+	Timeout         common.Duration `json:"timeout" yaml:"timeout"`
+	WaiterThreshold common.Duration `json:"waiter_threshold" yaml:"waiter_threshold"`
+}
+
+const (
+	DefaultProbeIterations       = 5
+	DefaultProbeTimeout          = 10 * time.Second
+	DefaultProbeLatencyThreshold = 500 * time.Millisecond
+
+	DefaultMMBinDir          = "/usr/lpp/mmfs/bin"
+	DefaultMMHealthTimeout   = 30 * time.Second
+	DefaultMMWaiterThreshold = 300 * time.Second
+)
+
+// GetProbeConfig returns the probe config with defaults filled, or nil if no mount point is configured.
+func (c *GpfsConfig) GetProbeConfig() *GpfsProbeConfig {
+	if c.Probe == nil || len(c.Probe.MountPoints) == 0 {
+		return nil
+	}
+	probe := *c.Probe
+	if probe.Iterations <= 0 {
+		probe.Iterations = DefaultProbeIterations
+	}
+	if probe.Timeout.Duration <= 0 {
+		probe.Timeout.Duration = DefaultProbeTimeout
+	}
+	if probe.LatencyThreshold.Duration <= 0 {
+		probe.LatencyThreshold.Duration = DefaultProbeLatencyThreshold
+	}
+	return &probe
+}
+
+// GetMMHealthConfig returns the mmhealth config with defaults filled.
+func (c *GpfsConfig) GetMMHealthConfig() *GpfsMMHealthConfig {
+	mmhealth := GpfsMMHealthConfig{}
+	if c.MMHealth != nil {
+		mmhealth = *c.MMHealth
+	}
+	if mmhealth.BinDir == "" {
+		mmhealth.BinDir = DefaultMMBinDir
+	}
+	if mmhealth.Timeout.Duration <= 0 {
+		mmhealth.Timeout.Duration = DefaultMMHealthTimeout
+	}
+	if mmhealth.WaiterThreshold.Duration <= 0 {
+		mmhealth.WaiterThreshold.Duration = DefaultMMWaiterThreshold
+	}
+	return &mmhealth
 }
 
 func (c *GpfsUserConfig) GetQueryInterval() common.Duration {
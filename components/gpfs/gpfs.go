@@ -26,21 +26,28 @@ import (
 	"github.com/scitix/sichek/components/gpfs/checker"
 	"github.com/scitix/sichek/components/gpfs/collector"
 	"github.com/scitix/sichek/components/gpfs/config"
+	gpfsmetrics "github.com/scitix/sichek/components/gpfs/metrics"
 	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
 	"github.com/scitix/sichek/pkg/utils"
 
 	"github.com/sirupsen/logrus"
 )
 
 type component struct {
-	ctx           context.Context
-	cancel        context.CancelFunc
-	componentName string
-	cfg           *config.GpfsUserConfig
-	cfgMutex      sync.Mutex
-	collector     *collector.GPFSCollector
-	checkers      []common.Checker
-	filter        *filter.EventFilter
+	ctx              context.Context
+	cancel           context.CancelFunc
+	componentName    string
+	cfg              *config.GpfsUserConfig
+	cfgMutex         sync.Mutex
+	collector        *collector.GPFSCollector
+	checkers         []common.Checker
+	prober           *collector.Prober
+	probeCheckers    []common.Checker
+	mmhealth         *collector.MMHealthCollector
+	mmhealthCheckers []common.Checker
+	filter           *filter.EventFilter
+	metrics          *gpfsmetrics.GpfsMetrics
 
 	cacheMtx    sync.RWMutex
 	cacheBuffer []*common.Result
@@ -95,6 +102,13 @@ func newGpfsComponent(cfgFile string, specFile string) (comp *component, err err
 		filterPointer = nil
 	}
 
+	var prober *collector.Prober
+	if probeCfg := cfg.Gpfs.GetProbeConfig(); probeCfg != nil {
+		prober = collector.NewProber(probeCfg)
+	}
+
+	mmhealth := collector.NewMMHealthCollector(cfg.Gpfs.GetMMHealthConfig())
+
 	collector, err := collector.NewGPFSCollector()
 	if err != nil {
 		logrus.WithField("component", "gpfs").Errorf("NewGpfsComponent create collector failed: %v", err)
@@ -106,17 +120,34 @@ func newGpfsComponent(cfgFile string, specFile string) (comp *component, err err
 		return nil, err
 	}
 
+	probeCheckers, err := checker.NewProbeCheckers(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	mmhealthCheckers, err := checker.NewMMHealthCheckers(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	component := &component{
-		ctx:           ctx,
-		cancel:        cancel,
-		componentName: consts.ComponentNameGpfs,
-		collector:     collector,
-		checkers:      checkers,
-		filter:        filterPointer,
-		cfg:           cfg,
-		cacheBuffer:   make([]*common.Result, cfg.Gpfs.CacheSize),
-		cacheInfo:     make([]common.Info, cfg.Gpfs.CacheSize),
-		cacheSize:     cfg.Gpfs.CacheSize,
+		ctx:              ctx,
+		cancel:           cancel,
+		componentName:    consts.ComponentNameGpfs,
+		collector:        collector,
+		checkers:         checkers,
+		prober:           prober,
+		probeCheckers:    probeCheckers,
+		mmhealth:         mmhealth,
+		mmhealthCheckers: mmhealthCheckers,
+		filter:           filterPointer,
+		cfg:              cfg,
+		cacheBuffer:      make([]*common.Result, cfg.Gpfs.CacheSize),
+		cacheInfo:        make([]common.Info, cfg.Gpfs.CacheSize),
+		cacheSize:        cfg.Gpfs.CacheSize,
+	}
+	if cfg.Gpfs.EnableMetrics {
+		component.metrics = gpfsmetrics.NewGpfsMetrics()
 	}
 	service := common.NewCommonService(ctx, cfg, component.componentName, component.GetTimeout(), component.HealthCheck)
 	component.service = service
@@ -130,25 +161,39 @@ func (c *component) Name() string {
 
 func (c *component) HealthCheck(ctx context.Context) (*common.Result, error) {
 	timer := common.NewTimer(fmt.Sprintf("%s-HealthCheck-Cost", c.componentName))
-	xstorHealthInfo, err := c.collector.Collect(ctx)
+	xstorHealthInfo, err := common.Collect(ctx, c.componentName, c.collector.Collect)
 	if err != nil {
 		logrus.WithField("component", "gpfs").Errorf("failed to collect gpfs xstor health info: %v", err)
 		return nil, err
 	}
 	result := common.Check(ctx, c.componentName, xstorHealthInfo, c.checkers)
 	timer.Mark("xstorhealth-check")
+	if c.metrics != nil {
+		c.metrics.ExportMetrics(xstorHealthInfo)
+	}
+	if len(c.mmhealthCheckers) > 0 {
+		mmhealthInfo := c.mmhealth.Collect(ctx)
+		mergeResult(result, common.Check(ctx, c.componentName, mmhealthInfo, c.mmhealthCheckers))
+		timer.Mark("mmhealth")
+		if c.metrics != nil {
+			c.metrics.ExportMMHealthMetrics(mmhealthInfo, c.cfg.Gpfs.GetMMHealthConfig().WaiterThreshold.Duration)
+		}
+	}
+	if c.prober != nil {
+		probeInfo := c.prober.Collect(ctx)
+		mergeResult(result, common.Check(ctx, c.componentName, probeInfo, c.probeCheckers))
+		timer.Mark("io-probe")
+		if c.metrics != nil {
+			c.metrics.ExportProbeMetrics(probeInfo)
+		}
+	}
 	if c.filter != nil {
 		eventResult := c.filter.Check()
 		timer.Mark("event-filter")
-		if eventResult != nil {
-			result.Checkers = append(result.Checkers, eventResult.Checkers...)
-			if eventResult.Status == consts.StatusAbnormal {
-				result.Status = consts.StatusAbnormal
-				if consts.LevelPriority[result.Level] < consts.LevelPriority[eventResult.Level] {
-					result.Level = eventResult.Level
-				}
-			}
+		if c.metrics != nil {
+			c.metrics.ExportEventMetrics(eventResult)
 		}
+		mergeResult(result, eventResult)
 	}
 
 	c.cacheMtx.Lock()
@@ -165,6 +210,20 @@ func (c *component) HealthCheck(ctx context.Context) (*common.Result, error) {
 	return result, nil
 }
 
+// mergeResult appends the checkers of other to result and raises its status and level.
+func mergeResult(result *common.Result, other *common.Result) {
+	if other == nil {
+		return
+	}
+	result.Checkers = append(result.Checkers, other.Checkers...)
+	if other.Status == consts.StatusAbnormal {
+		result.Status = consts.StatusAbnormal
+		if consts.LevelPriority[result.Level] < consts.LevelPriority[other.Level] {
+			result.Level = other.Level
+		}
+	}
+}
+
 func (c *component) CacheResults() ([]*common.Result, error) {
 	c.cacheMtx.RLock()
 	defer c.cacheMtx.RUnlock()
@@ -245,10 +304,10 @@ func (c *component) PrintInfo(info common.Info, result *common.Result, summaryPr
 	}
 
 	if checkAllPassed {
-		fmt.Printf("GPFS: %sMounted%s\n", consts.Green, consts.Reset)
+		printer.Printf("GPFS: %sMounted%s\n", consts.Green, consts.Reset)
 	}
 	for _, v := range gpfsEvent {
-		fmt.Printf("\t%s\n", v)
+		printer.Printf("\t%s\n", v)
 	}
 	return checkAllPassed
 }
@@ -0,0 +1,124 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package metrics
+
+import (
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/gpfs/collector"
+	"github.com/scitix/sichek/consts"
+	metrics "github.com/scitix/sichek/metrics"
+)
+
+const (
+	MetricPrefix = "sichek_gpfs"
+)
+
+type GpfsMetrics struct {
+	XStorGauge    *metrics.GaugeVecMetricExporter
+	EventGauge    *metrics.GaugeVecMetricExporter
+	ProbeGauge    *metrics.GaugeVecMetricExporter
+	MountGauge    *metrics.GaugeVecMetricExporter
+	MMHealthGauge *metrics.GaugeVecMetricExporter
+	WaiterGauge   *metrics.GaugeVecMetricExporter
+}
+
+func NewGpfsMetrics() *GpfsMetrics {
+	return &GpfsMetrics{
+		XStorGauge:    metrics.NewGaugeVecMetricExporter(MetricPrefix, []string{"item", "dev"}),
+		EventGauge:    metrics.NewGaugeVecMetricExporter(MetricPrefix, []string{"event"}),
+		ProbeGauge:    metrics.NewGaugeVecMetricExporter(MetricPrefix, []string{"mount", "op", "quantile"}),
+		MountGauge:    metrics.NewGaugeVecMetricExporter(MetricPrefix, []string{"mount"}),
+		MMHealthGauge: metrics.NewGaugeVecMetricExporter(MetricPrefix, []string{"component", "entity"}),
+		WaiterGauge:   metrics.NewGaugeVecMetricExporter(MetricPrefix, nil),
+	}
+}
+
+// ExportMetrics exports sichek_gpfs_xstor_abnormal and sichek_gpfs_xstor_errno
+// per xstor-health item.
+func (m *GpfsMetrics) ExportMetrics(info *collector.XStorHealthInfo) {
+	if info == nil {
+		return
+	}
+	for _, item := range info.HealthItems {
+		abnormal := 0.0
+		if item.Status != consts.StatusNormal {
+			abnormal = 1
+		}
+		m.XStorGauge.SetMetric("xstor_abnormal", []string{item.Item, item.Dev}, abnormal)
+		m.XStorGauge.SetMetric("xstor_errno", []string{item.Item, item.Dev}, float64(item.Errno))
+	}
+}
+
+// ExportEventMetrics exports sichek_gpfs_event_count, the log lines matched by each event rule.
+func (m *GpfsMetrics) ExportEventMetrics(result *common.Result) {
+	m.EventGauge.ExportEventCounts("event_count", result)
+}
+
+// ExportProbeMetrics exports sichek_gpfs_probe_latency_seconds per mount, operation and quantile,
+// and sichek_gpfs_probe_hung and sichek_gpfs_probe_error per mount.
+func (m *GpfsMetrics) ExportProbeMetrics(info *collector.ProbeInfo) {
+	if info == nil {
+		return
+	}
+	for mountPoint, mount := range info.Mounts {
+		hung, ioErr := 0.0, 0.0
+		if mount.Hung {
+			hung = 1
+		}
+		if mount.Error != "" {
+			ioErr = 1
+		}
+		m.MountGauge.SetMetric("probe_hung", []string{mountPoint}, hung)
+		m.MountGauge.SetMetric("probe_error", []string{mountPoint}, ioErr)
+		for op, latency := range mount.Latencies {
+			m.ProbeGauge.SetMetric("probe_latency_seconds", []string{mountPoint, op, "0.5"}, latency.P50.Seconds())
+			m.ProbeGauge.SetMetric("probe_latency_seconds", []string{mountPoint, op, "0.9"}, latency.P90.Seconds())
+			m.ProbeGauge.SetMetric("probe_latency_seconds", []string{mountPoint, op, "0.99"}, latency.P99.Seconds())
+		}
+	}
+}
+
+// ExportMMHealthMetrics exports sichek_gpfs_mmhealth_abnormal per mmhealth
+// component and entity, and sichek_gpfs_waiter_max_seconds and
+// sichek_gpfs_long_waiters, the waiters longer than threshold.
+func (m *GpfsMetrics) ExportMMHealthMetrics(info *collector.MMHealthInfo, threshold time.Duration) {
+	if info == nil || !info.Installed {
+		return
+	}
+	if info.HealthError == "" {
+		m.MMHealthGauge.ResetMetric("mmhealth_abnormal")
+		for _, state := range info.States {
+			abnormal := 0.0
+			if state.Status == "DEGRADED" || state.Status == "FAILED" {
+				abnormal = 1
+			}
+			m.MMHealthGauge.SetMetric("mmhealth_abnormal", []string{state.Component, state.Entity}, abnormal)
+		}
+	}
+	if info.WaitersError == "" {
+		maxWait, long := 0.0, 0.0
+		for _, waiter := range info.Waiters {
+			maxWait = max(maxWait, waiter.Wait.Seconds())
+			if waiter.Wait >= threshold {
+				long++
+			}
+		}
+		m.WaiterGauge.SetMetric("waiter_max_seconds", nil, maxWait)
+		m.WaiterGauge.SetMetric("long_waiters", nil, long)
+	}
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/scitix/sichek/components/gpuevents/config"
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/scitix/sichek/pkg/k8s"
+	"github.com/sirupsen/logrus"
+)
+
+// podLogTailBytes bounds the bytes read from the end of each pod log file.
+const podLogTailBytes = 256 * 1024
+
+// podHang is a pod all of whose GPUs hang at the same time.
+type podHang struct {
+	pod     *k8s.PodInfo
+	devices []string
+}
+
+// correlateByPod keeps the hung GPUs of the pods all of whose GPUs hang and
+// the hung GPUs not assigned to a pod, and returns the pods that hang.
+func correlateByPod(hung map[string]bool, devicePods map[string]*k8s.PodInfo) (map[string]bool, []podHang) {
+	podDevices := make(map[string][]string)
+	pods := make(map[string]*k8s.PodInfo)
+	for device, pod := range devicePods {
+		if pod == nil {
+			continue
+		}
+		key := podKey(pod)
+		podDevices[key] = append(podDevices[key], device)
+		pods[key] = pod
+	}
+	kept := make(map[string]bool, len(hung))
+	for device := range hung {
+		if devicePods[device] == nil {
+			kept[device] = true
+		}
+	}
+	keys := make([]string, 0, len(podDevices))
+	for key := range podDevices {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var hangs []podHang
+	for _, key := range keys {
+		devices := podDevices[key]
+		allHung := true
+		for _, device := range devices {
+			allHung = allHung && hung[device]
+		}
+		if !allHung {
+			continue
+		}
+		sort.Strings(devices)
+		for _, device := range devices {
+			kept[device] = true
+		}
+		hangs = append(hangs, podHang{pod: pods[key], devices: devices})
+	}
+	return kept, hangs
+}
+
+func podKey(pod *k8s.PodInfo) string {
+	return pod.Namespace + "/" + pod.PodName
+}
+
+// hangEvidence describes the last values of the indicators of the hung GPUs,
+// the pods they are assigned to and the last NCCL lines of the logs of these
+// pods, for the postmortem of the hang.
+func hangEvidence(devices []string, states map[string]*IndicatorStates, spec *config.GpuEventRule, devicePods map[string]*k8s.PodInfo) string {
+	var builder strings.Builder
+	builder.WriteString("evidence:\n")
+	pods := make(map[string]*k8s.PodInfo)
+	for _, device := range devices {
+		pod := "none"
+		if p := devicePods[device]; p != nil {
+			pod = podKey(p)
+			pods[pod] = p
+		}
+		builder.WriteString(fmt.Sprintf("device=%s, pod=%s\n", device, pod))
+		state := states[device]
+		if state == nil {
+			continue
+		}
+		names := make([]string, 0, len(state.Indicators))
+		for name := range state.Indicators {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			indicator := state.Indicators[name]
+			rule := spec.Indicators[name]
+			if rule == nil {
+				continue
+			}
+			builder.WriteString(fmt.Sprintf("  %s: last=%v, spec=%ser-than-%d, hang_duration=%s\n",
+				name, indicator.Samples, rule.CompareType, rule.Threshold, indicator.Duration))
+		}
+	}
+	keys := make([]string, 0, len(pods))
+	for key := range pods {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		lines := podLogLines(pods[key], "NCCL", spec.NCCLLogLineCount())
+		if len(lines) == 0 {
+			continue
+		}
+		builder.WriteString(fmt.Sprintf("last NCCL log lines of pod %s:\n", key))
+		for _, line := range lines {
+			builder.WriteString("  " + line + "\n")
+		}
+	}
+	return builder.String()
+}
+
+// podLogLines returns the last n lines containing match of the container
+// logs of the pod under /var/log/pods/<namespace>_<pod>_<uid>/<container>/.
+func podLogLines(pod *k8s.PodInfo, match string, n int) []string {
+	pattern := hostfs.Path("/var/log/pods", pod.Namespace+"_"+pod.PodName+"_*", "*", "*.log")
+	files, err := filepath.Glob(pattern)
+	if err != nil || len(files) == 0 {
+		logrus.WithField("checker", "gpuevents").Debugf("no log of pod %s/%s: %v", pod.Namespace, pod.PodName, err)
+		return nil
+	}
+	sort.Strings(files)
+	var lines []string
+	for _, file := range files {
+		data, err := readTail(file, podLogTailBytes)
+		if err != nil {
+			logrus.WithField("checker", "gpuevents").Debugf("failed to read %s: %v", file, err)
+			continue
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(make([]byte, 0, 64*1024), podLogTailBytes)
+		for scanner.Scan() {
+			if line := scanner.Text(); strings.Contains(line, match) {
+				lines = append(lines, line)
+			}
+		}
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
+
+// readTail reads the last size bytes of the file, from the start of a line.
+func readTail(file string, size int64) ([]byte, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	offset := info.Size() - size
+	if offset <= 0 {
+		return io.ReadAll(f)
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	// drop the partial first line
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		data = data[i+1:]
+	}
+	return data, nil
+}
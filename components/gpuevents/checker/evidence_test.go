@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/gpuevents/config"
+	"github.com/scitix/sichek/pkg/hostfs/hostfstest"
+	"github.com/scitix/sichek/pkg/k8s"
+)
+
+func TestCorrelateByPod(t *testing.T) {
+	train := &k8s.PodInfo{Namespace: "default", PodName: "train-0"}
+	infer := &k8s.PodInfo{Namespace: "default", PodName: "infer-0"}
+	devicePods := map[string]*k8s.PodInfo{
+		"GPU-0": train, "GPU-1": train,
+		"GPU-2": infer, "GPU-3": infer,
+	}
+	// GPU-3 still runs, GPU-4 is not assigned to a pod
+	hung := map[string]bool{"GPU-0": true, "GPU-1": true, "GPU-2": true, "GPU-4": true}
+	kept, hangs := correlateByPod(hung, devicePods)
+	if !reflect.DeepEqual(kept, map[string]bool{"GPU-0": true, "GPU-1": true, "GPU-4": true}) {
+		t.Errorf("unexpected hung GPUs %v", kept)
+	}
+	if len(hangs) != 1 || hangs[0].pod != train || !reflect.DeepEqual(hangs[0].devices, []string{"GPU-0", "GPU-1"}) {
+		t.Errorf("unexpected pod hangs %+v", hangs)
+	}
+
+	// outside k8s every GPU is reported on its own
+	if kept, hangs := correlateByPod(hung, nil); len(kept) != len(hung) || len(hangs) != 0 {
+		t.Errorf("expected all hung GPUs without pods, got %v %v", kept, hangs)
+	}
+}
+
+func TestHangEvidence(t *testing.T) {
+	hostfstest.Build(t, `
+-- var/log/pods/default_train-0_0123/pytorch/0.log --
+2026-10-16T08:00:00Z stdout F step 100
+2026-10-16T08:00:01Z stdout F train-0:1:1 [0] NCCL INFO comm 0x1 rank 0 nranks 16
+2026-10-16T08:00:02Z stdout F train-0:1:1 [0] NCCL INFO AllReduce: opCount 2a
+2026-10-16T08:00:03Z stdout F step 101
+2026-10-16T08:00:04Z stdout F train-0:1:1 [0] NCCL WARN Timeout waiting for peer
+`)
+	spec := &config.GpuEventRule{
+		Indicators:   map[string]*config.HangIndicator{"pwr": {Threshold: 100, CompareType: "low"}},
+		NCCLLogLines: 2,
+	}
+	pod := &k8s.PodInfo{Namespace: "default", PodName: "train-0"}
+	states := map[string]*IndicatorStates{
+		"GPU-0": {Indicators: map[string]*IndicatorState{
+			"pwr": {Active: true, Value: 80, Duration: 2 * time.Minute, Samples: []int64{85, 82, 80}},
+		}},
+	}
+	evidence := hangEvidence([]string{"GPU-0"}, states, spec, map[string]*k8s.PodInfo{"GPU-0": pod})
+	for _, want := range []string{
+		"device=GPU-0, pod=default/train-0\n",
+		"  pwr: last=[85 82 80], spec=lower-than-100, hang_duration=2m0s\n",
+		"last NCCL log lines of pod default/train-0:\n",
+		"NCCL INFO AllReduce: opCount 2a\n",
+		"NCCL WARN Timeout waiting for peer\n",
+	} {
+		if !strings.Contains(evidence, want) {
+			t.Errorf("expected %q in the evidence:\n%s", want, evidence)
+		}
+	}
+	if strings.Contains(evidence, "nranks 16") || strings.Contains(evidence, "step 100") {
+		t.Errorf("expected the last 2 NCCL lines only:\n%s", evidence)
+	}
+}
+
+func TestAddSample(t *testing.T) {
+	var samples []int64
+	for i := int64(1); i <= 4; i++ {
+		samples = addSample(samples, i, 3)
+	}
+	if !reflect.DeepEqual(samples, []int64{2, 3, 4}) {
+		t.Errorf("unexpected samples %v", samples)
+	}
+}
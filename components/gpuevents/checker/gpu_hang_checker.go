@@ -18,6 +18,7 @@ package checker
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -44,6 +45,7 @@ type GpuHangChecker struct {
 	originalNvidiaQueryInterval common.Duration
 	abnormalDetectedTimes       uint32
 	podResourceMapper           *k8s.PodResourceMapper
+	scope                       *podScope
 }
 
 func NewGpuHangChecker(cfg *config.GpuCostomEventsUserConfig, spec *config.GpuEventRule) common.Checker {
@@ -59,6 +61,7 @@ func NewGpuHangChecker(cfg *config.GpuCostomEventsUserConfig, spec *config.GpuEv
 		originalNvidiaQueryInterval: common.Duration{Duration: 30 * time.Second},
 		abnormalDetectedTimes:       0,
 		podResourceMapper:           podResourceMapper,
+		scope:                       newPodScope(spec.PodSelector, podResourceMapper),
 	}
 }
 
@@ -67,11 +70,15 @@ func (c *GpuHangChecker) Name() string {
 }
 
 func (c *GpuHangChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
-	info, ok := data.(*collector.DeviceIndicatorValues)
+	samples, ok := indicatorSamples(data)
 	if !ok {
 		return nil, fmt.Errorf("wrong input of HangChecker")
 	}
-	c.OnData(info)
+	samples = c.scope.applySamples(samples, c.indicatorStates)
+	for _, sample := range samples {
+		c.OnData(sample)
+	}
+	info := samples[len(samples)-1]
 	var raw string
 	abnormalIndicatorNum := make(map[string]int64)
 	for uuid, devIndicatorStates := range c.indicatorStates {
@@ -109,25 +116,38 @@ func (c *GpuHangChecker) Check(ctx context.Context, data any) (*common.CheckerRe
 		ErrorName:   c.spec.Name,
 		Suggestion:  "",
 	}
-	// Check if all abnormal indicators exceeds their's threshold
+	// A GPU hangs when all its indicators exceed their thresholds
+	hung := make(map[string]bool)
 	for uuid, num := range abnormalIndicatorNum {
 		if num == int64(len(c.spec.Indicators)) {
-			gpuAbNum++
-			status = consts.StatusAbnormal
-			suggest = fmt.Sprintf("%ssuggest check gpu device=%s which probably hang\n", suggest, uuid)
-			var devicePod string
-			if _, found := deviceToPodMap[uuid]; found {
-				devicePod = fmt.Sprintf("%s:%s", uuid, deviceToPodMap[uuid])
-				nameSpace := deviceToPodMap[uuid].Namespace
-				if _, exist := c.cfg.UserConfig.ProcessedIgnoreNamespace[nameSpace]; exist {
-					result.Level = consts.LevelInfo
-					logrus.WithField("component", "gpuevents").Warningf("device=%s probably hang in pod=%+v", uuid, deviceToPodMap[uuid])
-				}
-			} else {
-				devicePod = fmt.Sprintf("%s:", uuid)
+			hung[uuid] = true
+		}
+	}
+	var podHangs []podHang
+	if c.spec.PodCorrelation && len(hung) > 0 {
+		hung, podHangs = correlateByPod(hung, deviceToPodMap)
+	}
+	hungDevices := make([]string, 0, len(hung))
+	for uuid := range hung {
+		hungDevices = append(hungDevices, uuid)
+	}
+	sort.Strings(hungDevices)
+	for _, uuid := range hungDevices {
+		gpuAbNum++
+		status = consts.StatusAbnormal
+		suggest = fmt.Sprintf("%ssuggest check gpu device=%s which probably hang\n", suggest, uuid)
+		var devicePod string
+		if _, found := deviceToPodMap[uuid]; found {
+			devicePod = fmt.Sprintf("%s:%s", uuid, deviceToPodMap[uuid])
+			nameSpace := deviceToPodMap[uuid].Namespace
+			if _, exist := c.cfg.UserConfig.ProcessedIgnoreNamespace[nameSpace]; exist {
+				result.Level = consts.LevelInfo
+				logrus.WithField("component", "gpuevents").Warningf("device=%s probably hang in pod=%+v", uuid, deviceToPodMap[uuid])
 			}
-			devices = append(devices, devicePod)
+		} else {
+			devicePod = fmt.Sprintf("%s:", uuid)
 		}
+		devices = append(devices, devicePod)
 	}
 
 	abnormalDetected := len(devices) > 0
@@ -179,6 +199,15 @@ func (c *GpuHangChecker) Check(ctx context.Context, data any) (*common.CheckerRe
 		}).Infof("GPU hang status resolved, restoring query intervals")
 	}
 
+	for _, hang := range podHangs {
+		raw = fmt.Sprintf("%sall %d GPUs of pod %s hang at the same time: %s\n", raw, len(hang.devices), podKey(hang.pod), strings.Join(hang.devices, ","))
+	}
+	if status == consts.StatusAbnormal {
+		result.Devices = deviceResults(hungDevices, info, deviceToPodMap)
+		raw += devicePodDetail(result.Devices)
+		raw += hangEvidence(hungDevices, c.indicatorStates, c.spec, deviceToPodMap)
+	}
+
 	result.Device = strings.Join(devices, ",")
 	result.Curr = strconv.Itoa(gpuAbNum)
 	result.Status = status
@@ -222,13 +251,16 @@ func (c *GpuHangChecker) OnData(IndicatorSnapshot *collector.DeviceIndicatorValu
 				infoValue = curIndicatorValues.Indicators[indicatorName]
 			}
 			duration := GetIndicatorDuration(indicatorName, infoValue, c.spec, curIndicatorValues.LastUpdate, c.LastUpdate)
+			samples := addSample(IndicatorStates[indicatorName].Samples, infoValue, c.spec.EvidenceSampleCount())
 			if duration == 0 {
 				IndicatorStates[indicatorName] = &IndicatorState{
 					Active:   false,
 					Value:    infoValue,
 					Duration: 0,
+					Samples:  samples,
 				}
 			} else {
+				IndicatorStates[indicatorName].Samples = samples
 				IndicatorStates[indicatorName].Active = true
 				IndicatorStates[indicatorName].Value = infoValue
 				IndicatorStates[indicatorName].Duration += time.Duration(duration) * time.Second
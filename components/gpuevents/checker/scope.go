@@ -0,0 +1,178 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/gpuevents/collector"
+	"github.com/scitix/sichek/pkg/k8s"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const podLabelsTimeout = 5 * time.Second
+
+// podScope restricts a rule to the GPUs of the pods matching its pod selector.
+type podScope struct {
+	selector   labels.Selector // nil when the rule applies to every GPU
+	devicePods func() (map[string]*k8s.PodInfo, error)
+	podLabels  func(namespace, name string) (map[string]string, error)
+	// labels caches the labels of the pods by namespace/name, the pods
+	// leaving the GPUs are evicted
+	labels map[string]map[string]string
+}
+
+// newPodScope returns the scope of the pod selector, the rule validated the selector.
+func newPodScope(selector string, podResourceMapper *k8s.PodResourceMapper) *podScope {
+	scope := &podScope{labels: make(map[string]map[string]string)}
+	if selector == "" {
+		return scope
+	}
+	parsed, err := labels.Parse(selector)
+	if err != nil {
+		logrus.WithField("component", "gpuevents").Errorf("invalid pod_selector %q, the rule applies to every GPU: %v", selector, err)
+		return scope
+	}
+	scope.selector = parsed
+	scope.devicePods = podResourceMapper.GetDeviceToPodMap
+	scope.podLabels = func(namespace, name string) (map[string]string, error) {
+		client, err := k8s.NewClient()
+		if err != nil || client == nil {
+			return nil, fmt.Errorf("no k8s client (non-K8s environment?): %v", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), podLabelsTimeout)
+		defer cancel()
+		return client.GetPodLabels(ctx, namespace, name)
+	}
+	return scope
+}
+
+// filter returns the indicator values of the GPUs in the scope, i.e. all of
+// them without selector, and the GPUs of the pods matching the selector
+// otherwise. The GPUs whose pod cannot be told are out of the scope.
+func (s *podScope) filter(info *collector.DeviceIndicatorValues) *collector.DeviceIndicatorValues {
+	if s.selector == nil {
+		return info
+	}
+	devicePods, err := s.devicePods()
+	if err != nil {
+		logrus.WithField("component", "gpuevents").Warnf("failed to GetDeviceToPodMap (non-K8s environment?): %v, no GPU matches pod_selector %q", err, s.selector)
+	}
+	scoped := &collector.DeviceIndicatorValues{
+		Indicators: make(map[string]*collector.IndicatorValues),
+		LastUpdate: info.LastUpdate,
+	}
+	seen := make(map[string]bool)
+	for uuid, values := range info.Indicators {
+		pod := devicePods[uuid]
+		if pod == nil {
+			continue
+		}
+		key := podKey(pod)
+		seen[key] = true
+		podLabels, cached := s.labels[key]
+		if !cached {
+			podLabels, err = s.podLabels(pod.Namespace, pod.PodName)
+			if err != nil {
+				logrus.WithField("component", "gpuevents").Warnf("failed to get the labels of pod %s: %v", key, err)
+				continue
+			}
+			s.labels[key] = podLabels
+		}
+		if s.selector.Matches(labels.Set(podLabels)) {
+			scoped.Indicators[uuid] = values
+		}
+	}
+	for key := range s.labels {
+		if !seen[key] {
+			delete(s.labels, key)
+		}
+	}
+	return scoped
+}
+
+// apply filters the indicator values to the GPUs in the scope and drops the
+// states of the GPUs which left the scope, so that their past values do not
+// raise an event.
+func (s *podScope) apply(info *collector.DeviceIndicatorValues, states map[string]*IndicatorStates) *collector.DeviceIndicatorValues {
+	if s.selector == nil {
+		return info
+	}
+	scoped := s.filter(info)
+	for uuid := range states {
+		if _, found := scoped.Indicators[uuid]; !found {
+			delete(states, uuid)
+		}
+	}
+	return scoped
+}
+
+// applySamples applies the scope to the last sample and keeps the same GPUs
+// in the earlier ones, the pods of the GPUs do not change within a series.
+func (s *podScope) applySamples(samples []*collector.DeviceIndicatorValues, states map[string]*IndicatorStates) []*collector.DeviceIndicatorValues {
+	last := s.apply(samples[len(samples)-1], states)
+	if s.selector == nil {
+		return samples
+	}
+	scoped := make([]*collector.DeviceIndicatorValues, 0, len(samples))
+	for _, sample := range samples[:len(samples)-1] {
+		filtered := &collector.DeviceIndicatorValues{
+			Indicators: make(map[string]*collector.IndicatorValues, len(last.Indicators)),
+			LastUpdate: sample.LastUpdate,
+		}
+		for uuid := range last.Indicators {
+			if values, found := sample.Indicators[uuid]; found {
+				filtered.Indicators[uuid] = values
+			}
+		}
+		scoped = append(scoped, filtered)
+	}
+	return append(scoped, last)
+}
+
+// deviceResults tags the abnormal GPUs with the pod owning them at the time
+// of the event.
+func deviceResults(uuids []string, info *collector.DeviceIndicatorValues, devicePods map[string]*k8s.PodInfo) []*common.DeviceResult {
+	results := make([]*common.DeviceResult, 0, len(uuids))
+	for _, uuid := range uuids {
+		device := &common.DeviceResult{Index: -1, UUID: uuid}
+		if values, found := info.Indicators[uuid]; found {
+			device.Index = values.Index
+		}
+		if pod := devicePods[uuid]; pod != nil {
+			device.Pods = []string{podKey(pod)}
+		}
+		results = append(results, device)
+	}
+	return results
+}
+
+// devicePodDetail describes the pod owning each abnormal GPU.
+func devicePodDetail(results []*common.DeviceResult) string {
+	var detail string
+	for _, device := range results {
+		pod := ""
+		if len(device.Pods) > 0 {
+			pod = device.Pods[0]
+		}
+		detail = fmt.Sprintf("%sdevice=%s, index=%d, pod=%s\n", detail, device.UUID, device.Index, pod)
+	}
+	return detail
+}
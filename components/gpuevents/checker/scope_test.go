@@ -0,0 +1,101 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/gpuevents/collector"
+	"github.com/scitix/sichek/pkg/k8s"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestPodScope(t *testing.T) {
+	train := &k8s.PodInfo{Namespace: "default", PodName: "train-0"}
+	infer := &k8s.PodInfo{Namespace: "default", PodName: "infer-0"}
+	devicePods := map[string]*k8s.PodInfo{"GPU-0": train, "GPU-1": infer}
+	podLabels := map[string]map[string]string{
+		"default/train-0": {"job-type": "training"},
+		"default/infer-0": {"job-type": "inference"},
+	}
+	lookups := 0
+	scope := &podScope{
+		selector:   labels.SelectorFromSet(labels.Set{"job-type": "training"}),
+		devicePods: func() (map[string]*k8s.PodInfo, error) { return devicePods, nil },
+		podLabels: func(namespace, name string) (map[string]string, error) {
+			lookups++
+			podLabels, found := podLabels[namespace+"/"+name]
+			if !found {
+				return nil, fmt.Errorf("pod %s/%s not found", namespace, name)
+			}
+			return podLabels, nil
+		},
+		labels: make(map[string]map[string]string),
+	}
+	info := &collector.DeviceIndicatorValues{
+		Indicators: map[string]*collector.IndicatorValues{
+			"GPU-0": {Index: 0}, "GPU-1": {Index: 1}, "GPU-2": {Index: 2},
+		},
+		LastUpdate: time.Now(),
+	}
+	states := map[string]*IndicatorStates{"GPU-0": {}, "GPU-1": {}, "GPU-2": {}}
+
+	scoped := scope.apply(info, states)
+	if len(scoped.Indicators) != 1 || scoped.Indicators["GPU-0"] == nil {
+		t.Errorf("expected only the GPU of the training pod in the scope, got %v", scoped.Indicators)
+	}
+	if len(states) != 1 || states["GPU-0"] == nil {
+		t.Errorf("expected the states of the GPUs out of the scope dropped, got %v", states)
+	}
+
+	// the labels are cached until the pod leaves its GPU
+	scope.apply(info, states)
+	if lookups != 2 {
+		t.Errorf("expected the pod labels cached, got %d lookups", lookups)
+	}
+	devicePods = map[string]*k8s.PodInfo{"GPU-1": train}
+	scoped = scope.apply(info, states)
+	if len(scoped.Indicators) != 1 || scoped.Indicators["GPU-1"] == nil || len(states) != 0 {
+		t.Errorf("expected the GPU of the training pod in the scope, got %v", scoped.Indicators)
+	}
+	if _, cached := scope.labels["default/infer-0"]; cached {
+		t.Errorf("expected the labels of the pod leaving its GPU evicted")
+	}
+
+	// without selector every GPU is in the scope
+	if all := (&podScope{}).apply(info, states); all != info {
+		t.Errorf("expected all GPUs in the scope without selector")
+	}
+}
+
+func TestDeviceResults(t *testing.T) {
+	info := &collector.DeviceIndicatorValues{
+		Indicators: map[string]*collector.IndicatorValues{"GPU-a": {Index: 3}, "GPU-b": {Index: 5}},
+	}
+	devicePods := map[string]*k8s.PodInfo{"GPU-a": {Namespace: "default", PodName: "train-0"}}
+	results := deviceResults([]string{"GPU-a", "GPU-b"}, info, devicePods)
+	if len(results) != 2 || results[0].Index != 3 || !reflect.DeepEqual(results[0].Pods, []string{"default/train-0"}) ||
+		results[1].Index != 5 || results[1].Pods != nil {
+		t.Errorf("unexpected device results %+v %+v", results[0], results[1])
+	}
+	want := "device=GPU-a, index=3, pod=default/train-0\ndevice=GPU-b, index=5, pod=\n"
+	if detail := devicePodDetail(results); detail != want {
+		t.Errorf("expected detail %q, got %q", want, detail)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/gpuevents/collector"
+	"github.com/scitix/sichek/components/gpuevents/config"
+	"github.com/scitix/sichek/consts"
+)
+
+// smclkSeries returns one sample per second of GPU-0, the ticks arrive a
+// little early as they do from a ticker.
+func smclkSeries(start time.Time, smclks ...int64) *collector.DeviceIndicatorSeries {
+	series := &collector.DeviceIndicatorSeries{}
+	for i, smclk := range smclks {
+		at := start.Add(time.Duration(i)*time.Second - time.Millisecond)
+		series.Samples = append(series.Samples, &collector.DeviceIndicatorValues{
+			Indicators: map[string]*collector.IndicatorValues{
+				"GPU-0": {Index: 0, Indicators: map[string]int64{"smclk": smclk, "gpuidle": 0}, LastUpdate: at},
+			},
+			LastUpdate: at,
+		})
+	}
+	return series
+}
+
+func TestSmClkStuckLowOnSeries(t *testing.T) {
+	newChecker := func() *SmClkStuckLowChecker {
+		cfg := &config.GpuCostomEventsUserConfig{UserConfig: &config.UserConfig{}}
+		spec := &config.GpuEventRule{
+			Name:              config.SmClkStuckLowCheckerName,
+			DurationThreshold: common.Duration{Duration: 5 * time.Second},
+			Level:             consts.LevelFatal,
+			Indicators: map[string]*config.HangIndicator{
+				"smclk":   {Threshold: 800, CompareType: string(config.CompareLow)},
+				"gpuidle": {Threshold: 0, CompareType: string(config.CompareEqual)},
+			},
+		}
+		return NewSmClkStuckLowChecker(cfg, spec).(*SmClkStuckLowChecker)
+	}
+	start := time.Now()
+
+	// 7 samples stuck low, the first one only starts the clock
+	chk := newChecker()
+	chk.LastUpdate = time.Time{}
+	result, err := chk.Check(context.Background(), smclkSeries(start, 300, 300, 300, 300, 300, 300, 300))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != consts.StatusAbnormal || result.Device != "GPU-0" {
+		t.Errorf("expected GPU-0 stuck low within one check, got %+v", result)
+	}
+
+	// a single sample at full clock resets the duration
+	chk = newChecker()
+	chk.LastUpdate = time.Time{}
+	result, err = chk.Check(context.Background(), smclkSeries(start, 300, 300, 300, 1980, 300, 300, 300))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != consts.StatusNormal {
+		t.Errorf("expected the recovery within the series to reset the duration, got %+v", result)
+	}
+
+	if _, err := chk.Check(context.Background(), &collector.DeviceIndicatorSeries{}); err == nil {
+		t.Error("expected an error for an empty series")
+	}
+}
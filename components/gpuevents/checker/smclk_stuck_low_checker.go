@@ -18,6 +18,7 @@ package checker
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -26,6 +27,7 @@ import (
 	"github.com/scitix/sichek/components/gpuevents/collector"
 	"github.com/scitix/sichek/components/gpuevents/config"
 	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/k8s"
 	"github.com/sirupsen/logrus"
 )
 
@@ -37,15 +39,20 @@ type SmClkStuckLowChecker struct {
 	indicatorStates map[string]*IndicatorStates
 	LastUpdate      time.Time // Timestamp of the last update
 
+	podResourceMapper *k8s.PodResourceMapper
+	scope             *podScope
 }
 
 func NewSmClkStuckLowChecker(cfg *config.GpuCostomEventsUserConfig, spec *config.GpuEventRule) common.Checker {
+	podResourceMapper := k8s.NewPodResourceMapper()
 	return &SmClkStuckLowChecker{
-		name:            config.SmClkStuckLowCheckerName,
-		cfg:             cfg,
-		spec:            spec,
-		indicatorStates: make(map[string]*IndicatorStates),
-		LastUpdate:      time.Now(),
+		name:              config.SmClkStuckLowCheckerName,
+		cfg:               cfg,
+		spec:              spec,
+		indicatorStates:   make(map[string]*IndicatorStates),
+		LastUpdate:        time.Now(),
+		podResourceMapper: podResourceMapper,
+		scope:             newPodScope(spec.PodSelector, podResourceMapper),
 	}
 }
 
@@ -54,11 +61,15 @@ func (c *SmClkStuckLowChecker) Name() string {
 }
 
 func (c *SmClkStuckLowChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
-	info, ok := data.(*collector.DeviceIndicatorValues)
+	samples, ok := indicatorSamples(data)
 	if !ok {
 		return nil, fmt.Errorf("wrong input of SmClkStuckLowChecker")
 	}
-	c.OnData(info)
+	samples = c.scope.applySamples(samples, c.indicatorStates)
+	for _, sample := range samples {
+		c.OnData(sample)
+	}
+	info := samples[len(samples)-1]
 	var raw string
 	abnormalIndicatorNum := make(map[string]int64)
 	var SmClkLowThreshold int64
@@ -98,6 +109,13 @@ func (c *SmClkStuckLowChecker) Check(ctx context.Context, data any) (*common.Che
 		}
 	}
 	if status == consts.StatusAbnormal {
+		sort.Strings(devices)
+		deviceToPodMap, err := c.podResourceMapper.GetDeviceToPodMap()
+		if err != nil {
+			logrus.WithField("component", "gpuevents").Warnf("failed to GetDeviceToPodMap (non-K8s environment?): %v, continuing without pod mapping", err)
+		}
+		result.Devices = deviceResults(devices, info, deviceToPodMap)
+		raw += devicePodDetail(result.Devices)
 		logrus.WithFields(logrus.Fields{
 			"checker": c.Name(),
 			"failed_gpus": devices,
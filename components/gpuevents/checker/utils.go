@@ -18,6 +18,7 @@ package checker
 import (
 	"time"
 
+	"github.com/scitix/sichek/components/gpuevents/collector"
 	"github.com/scitix/sichek/components/gpuevents/config"
 	"github.com/sirupsen/logrus"
 )
@@ -28,6 +29,16 @@ type IndicatorState struct {
 	Active   bool          // Whether the indicator currently meets the gpuevents condition
 	Value    int64         // The current value of the indicator
 	Duration time.Duration // Accumulated duration during which the condition is met
+	Samples  []int64       // The last values of the indicator, oldest first
+}
+
+// addSample appends value to samples, keeping the last n values.
+func addSample(samples []int64, value int64, n int) []int64 {
+	samples = append(samples, value)
+	if len(samples) > n {
+		samples = append([]int64(nil), samples[len(samples)-n:]...)
+	}
+	return samples
 }
 
 // IndicatorStates tracks the status of all indicators for a single device.
@@ -36,6 +47,18 @@ type IndicatorStates struct {
 	LastUpdate time.Time // Last update timestamp for this device's indicators
 }
 
+// indicatorSamples returns the indicator values to evaluate in time order,
+// the samples of the field sampler or the single values of the nvidia info.
+func indicatorSamples(data any) ([]*collector.DeviceIndicatorValues, bool) {
+	switch info := data.(type) {
+	case *collector.DeviceIndicatorValues:
+		return []*collector.DeviceIndicatorValues{info}, true
+	case *collector.DeviceIndicatorSeries:
+		return info.Samples, len(info.Samples) > 0
+	}
+	return nil, false
+}
+
 func absDiff(a, b int64) int64 {
 	if a > b {
 		return a - b
@@ -55,7 +78,9 @@ func GetIndicatorDuration(indicatorName string, infoValue int64, spec *config.Gp
 		(infoValue > indicator.Threshold && indicator.CompareType == string(config.CompareHigh)) ||
 		(infoValue == indicator.Threshold && indicator.CompareType == string(config.CompareEqual)) {
 		if !lastUpdate.IsZero() {
-			res = int64(now.Sub(lastUpdate).Seconds())
+			// the samples of the field sampler are about a second apart,
+			// round so that a tick arriving early still counts
+			res = int64(now.Sub(lastUpdate).Round(time.Second).Seconds())
 		}
 	}
 	return res
@@ -31,6 +31,7 @@ import (
 
 // IndicatorValues tracks the values of all indicators for a single device.
 type IndicatorValues struct {
+	Index      int // Index of the GPU
 	Indicators map[string]int64
 	LastUpdate time.Time // Last update timestamp for this device's indicators
 }
@@ -58,6 +59,11 @@ type GpuIndicatorSnapshot struct {
 	LastUpdate         time.Time // Timestamp of the last update
 
 	nvidiaComponent common.Component
+
+	// sampler is nil when the rules are evaluated on the nvidia info,
+	// lastSample is the time of the latest indicator values returned
+	sampler    *FieldSampler
+	lastSample time.Time
 }
 
 func NewGpuIndicatorSnapshot(cfg *config.GpuCostomEventsUserConfig) (*GpuIndicatorSnapshot, error) {
@@ -75,7 +81,7 @@ func NewGpuIndicatorSnapshot(cfg *config.GpuCostomEventsUserConfig) (*GpuIndicat
 		}
 	}
 
-	return &GpuIndicatorSnapshot{
+	snapshot := &GpuIndicatorSnapshot{
 		name: consts.ComponentNameGpuEvents,
 		cfg:  cfg,
 		devIndicatorValues: &DeviceIndicatorValues{
@@ -83,7 +89,11 @@ func NewGpuIndicatorSnapshot(cfg *config.GpuCostomEventsUserConfig) (*GpuIndicat
 		},
 		LastUpdate:      time.Now(),
 		nvidiaComponent: nvidiaComponent,
-	}, nil
+	}
+	if !cfg.UserConfig.Mock && !cfg.UserConfig.NVSMI && cfg.UserConfig.SampleInterval.Duration > 0 {
+		snapshot.sampler = NewFieldSampler(cfg.UserConfig.SampleInterval.Duration, cfg.UserConfig.SampleBufferSize)
+	}
+	return snapshot, nil
 }
 
 func (c *GpuIndicatorSnapshot) Name() string {
@@ -91,6 +101,9 @@ func (c *GpuIndicatorSnapshot) Name() string {
 }
 
 func (c *GpuIndicatorSnapshot) Collect(ctx context.Context) (common.Info, error) {
+	if series := c.collectSamples(); series != nil {
+		return series, nil
+	}
 	var curDeviceIndicatorValues *DeviceIndicatorValues
 	if !c.cfg.UserConfig.NVSMI {
 		curDeviceIndicatorValues = c.getInfobyLatestInfo(ctx)
@@ -100,5 +113,35 @@ func (c *GpuIndicatorSnapshot) Collect(ctx context.Context) (common.Info, error)
 	if curDeviceIndicatorValues == nil {
 		return nil, fmt.Errorf("failed to get device indicator states")
 	}
+	c.lastSample = curDeviceIndicatorValues.LastUpdate
 	return curDeviceIndicatorValues, nil
 }
+
+// collectSamples returns the samples of the field sampler taken since the
+// previous collection. It returns nil before the first sample, the nvidia
+// info is used instead. A sampler which cannot start is dropped for good.
+func (c *GpuIndicatorSnapshot) collectSamples() *DeviceIndicatorSeries {
+	if c.sampler == nil {
+		return nil
+	}
+	if err := c.sampler.Start(); err != nil {
+		logrus.WithField("collector", "gpuevents").WithError(err).Warn("failed to start the field sampler, evaluate the rules on the nvidia info")
+		c.sampler = nil
+		return nil
+	}
+	samples := c.sampler.Since(c.lastSample)
+	if len(samples) == 0 {
+		return nil
+	}
+	series := &DeviceIndicatorSeries{Samples: samples}
+	c.lastSample = series.Last().LastUpdate
+	return series
+}
+
+// Close stops the field sampler.
+func (c *GpuIndicatorSnapshot) Close() error {
+	if c.sampler != nil {
+		c.sampler.Stop()
+	}
+	return nil
+}
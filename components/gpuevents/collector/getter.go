@@ -60,7 +60,9 @@ func getInfobyNvidiaSmi(ctx context.Context) *DeviceIndicatorValues {
 	// Convert each row of data into a map with headers as keys
 	for _, row := range dataRows {
 		gpuIndex := row[0]
+		index, _ := strconv.Atoi(gpuIndex)
 		devIndicatorValues.Indicators[gpuIndex] = &IndicatorValues{
+			Index:      index,
 			Indicators: make(map[string]int64),
 			LastUpdate: time.Now(),
 		}
@@ -118,6 +120,7 @@ func (c *GpuIndicatorSnapshot) getInfobyLatestInfo(ctx context.Context) *DeviceI
 		uuid := deviceInfo.UUID
 		// gpuIndexInt := deviceInfo.Index
 		devIndicatorValues.Indicators[uuid] = &IndicatorValues{
+			Index:      deviceInfo.Index,
 			Indicators: make(map[string]int64),
 			LastUpdate: info.Time,
 		}
@@ -26,6 +26,7 @@ import (
 	"github.com/scitix/sichek/components/nvidia/collector"
 	"github.com/scitix/sichek/components/nvidia/config"
 	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
 )
 
 type component struct {
@@ -212,7 +213,7 @@ func (c *component) Start() <-chan *common.Result {
 				result, err := c.HealthCheck(c.ctx)
 				c.serviceMtx.Unlock()
 				if err != nil {
-					fmt.Printf("%s analyze failed: %v\n", c.name, err)
+					logrus.WithField("component", c.name).Errorf("analyze failed: %v", err)
 					continue
 				}
 				if result.Level == consts.LevelCritical || result.Level == consts.LevelWarning {
@@ -0,0 +1,333 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultSampleInterval   = time.Second
+	DefaultSampleBufferSize = 300
+)
+
+// gpuIdleReason is the GPU idle bit of the clocks event reasons
+const gpuIdleReason uint64 = 0x0000000000000001
+
+// DeviceIndicatorSeries is the indicator values of the GPUs sampled by the
+// field sampler since the previous collection, oldest first.
+type DeviceIndicatorSeries struct {
+	Samples []*DeviceIndicatorValues
+}
+
+func (s *DeviceIndicatorSeries) JSON() (string, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Last returns the latest sample, nil when the series is empty.
+func (s *DeviceIndicatorSeries) Last() *DeviceIndicatorValues {
+	if len(s.Samples) == 0 {
+		return nil
+	}
+	return s.Samples[len(s.Samples)-1]
+}
+
+// sampleSource reads the indicators of every GPU once.
+type sampleSource interface {
+	Read() (map[string]*IndicatorValues, error)
+	Close()
+}
+
+// FieldSampler reads the hang indicators of the GPUs every interval into a
+// ring buffer, so that the gpuevents rules are evaluated at the resolution of
+// the sampler rather than at the query interval of the component.
+type FieldSampler struct {
+	interval time.Duration
+	size     int
+	open     func() (sampleSource, error)
+
+	mu      sync.Mutex
+	samples []*DeviceIndicatorValues // ring buffer, next is the oldest slot once full
+	next    int
+	full    bool
+
+	startOnce sync.Once
+	startErr  error
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// NewFieldSampler returns a sampler reading the GPUs through NVML. It starts
+// sampling on the first call of Start.
+func NewFieldSampler(interval time.Duration, size int) *FieldSampler {
+	return newFieldSampler(interval, size, func() (sampleSource, error) {
+		source, err := newNVMLSampleSource()
+		if err != nil {
+			return nil, err
+		}
+		return source, nil
+	})
+}
+
+func newFieldSampler(interval time.Duration, size int, open func() (sampleSource, error)) *FieldSampler {
+	if interval <= 0 {
+		interval = DefaultSampleInterval
+	}
+	if size <= 0 {
+		size = DefaultSampleBufferSize
+	}
+	return &FieldSampler{
+		interval: interval,
+		size:     size,
+		open:     open,
+		samples:  make([]*DeviceIndicatorValues, size),
+	}
+}
+
+// Start opens the source and samples it until Stop. It only takes effect
+// once, the following calls return the error of the first one.
+func (s *FieldSampler) Start() error {
+	s.startOnce.Do(func() {
+		source, err := s.open()
+		if err != nil {
+			s.startErr = err
+			return
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		s.cancel = cancel
+		s.done = make(chan struct{})
+		go s.run(ctx, source)
+		logrus.WithField("collector", "gpuevents").Infof("field sampler started; interval=%s buffer=%d", s.interval, s.size)
+	})
+	return s.startErr
+}
+
+func (s *FieldSampler) run(ctx context.Context, source sampleSource) {
+	defer close(s.done)
+	defer source.Close()
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			indicators, err := source.Read()
+			if err != nil {
+				logrus.WithField("collector", "gpuevents").WithError(err).Warn("field sampler failed to read the GPUs")
+				continue
+			}
+			s.add(now, indicators)
+		}
+	}
+}
+
+func (s *FieldSampler) add(now time.Time, indicators map[string]*IndicatorValues) {
+	for _, values := range indicators {
+		values.LastUpdate = now
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples[s.next] = &DeviceIndicatorValues{Indicators: indicators, LastUpdate: now}
+	s.next = (s.next + 1) % s.size
+	if s.next == 0 {
+		s.full = true
+	}
+}
+
+// Since returns the buffered samples taken after t, oldest first.
+func (s *FieldSampler) Since(t time.Time) []*DeviceIndicatorValues {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ordered []*DeviceIndicatorValues
+	if s.full {
+		ordered = append(ordered, s.samples[s.next:]...)
+	}
+	ordered = append(ordered, s.samples[:s.next]...)
+	var samples []*DeviceIndicatorValues
+	for _, sample := range ordered {
+		if sample != nil && sample.LastUpdate.After(t) {
+			samples = append(samples, sample)
+		}
+	}
+	return samples
+}
+
+// Stop stops sampling and waits for the sampling goroutine to exit.
+func (s *FieldSampler) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+}
+
+// nvmlSampleSource reads the power and power violation time of a GPU in one
+// nvmlDeviceGetFieldValues call. Utilization, clocks, PCIe throughput and
+// performance state have no field ID and are read with their own cheap calls.
+type nvmlSampleSource struct {
+	nvmlInst nvml.Interface
+	devices  map[string]nvml.Device
+	index    map[string]int
+}
+
+func newNVMLSampleSource() (*nvmlSampleSource, error) {
+	nvmlInst := nvml.New()
+	if ret := nvmlInst.Init(); ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("failed to initialize NVML: %v", nvml.ErrorString(ret))
+	}
+	count, ret := nvmlInst.DeviceGetCount()
+	if ret != nvml.SUCCESS {
+		nvmlInst.Shutdown()
+		return nil, fmt.Errorf("failed to get device count: %v", nvml.ErrorString(ret))
+	}
+	source := &nvmlSampleSource{
+		nvmlInst: nvmlInst,
+		devices:  make(map[string]nvml.Device, count),
+		index:    make(map[string]int, count),
+	}
+	for i := 0; i < count; i++ {
+		device, ret := nvmlInst.DeviceGetHandleByIndex(i)
+		if ret != nvml.SUCCESS {
+			logrus.WithField("collector", "gpuevents").Warnf("failed to get GPU %d: %v", i, nvml.ErrorString(ret))
+			continue
+		}
+		uuid, ret := device.GetUUID()
+		if ret != nvml.SUCCESS {
+			logrus.WithField("collector", "gpuevents").Warnf("failed to get the UUID of GPU %d: %v", i, nvml.ErrorString(ret))
+			continue
+		}
+		source.devices[uuid] = device
+		source.index[uuid] = i
+	}
+	if len(source.devices) == 0 {
+		nvmlInst.Shutdown()
+		return nil, fmt.Errorf("find no gpus")
+	}
+	return source, nil
+}
+
+// Read reads the GPUs in parallel, the PCIe throughput counters of NVML take
+// 20ms each to sample.
+func (s *nvmlSampleSource) Read() (map[string]*IndicatorValues, error) {
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs []error
+	)
+	indicators := make(map[string]*IndicatorValues, len(s.devices))
+	for uuid, device := range s.devices {
+		wg.Add(1)
+		go func(uuid string, device nvml.Device) {
+			defer wg.Done()
+			values, err := readDeviceIndicators(device)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("GPU %s: %w", uuid, err))
+				return
+			}
+			indicators[uuid] = &IndicatorValues{Index: s.index[uuid], Indicators: values}
+		}(uuid, device)
+	}
+	wg.Wait()
+	if len(indicators) == 0 && len(errs) > 0 {
+		return nil, errs[0]
+	}
+	for _, err := range errs {
+		logrus.WithField("collector", "gpuevents").Warn(err)
+	}
+	return indicators, nil
+}
+
+func (s *nvmlSampleSource) Close() {
+	s.nvmlInst.Shutdown()
+}
+
+// readDeviceIndicators reads the indicators of getInfobyLatestInfo, in the
+// same units.
+func readDeviceIndicators(device nvml.Device) (map[string]int64, error) {
+	values := make(map[string]int64)
+	fields := []nvml.FieldValue{
+		{FieldId: nvml.FI_DEV_POWER_INSTANT},     // mW
+		{FieldId: nvml.FI_DEV_PERF_POLICY_POWER}, // ns
+	}
+	if ret := device.GetFieldValues(fields); ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("failed to get field values: %v", nvml.ErrorString(ret))
+	}
+	if fields[0].NvmlReturn == uint32(nvml.SUCCESS) {
+		values["pwr"] = fieldValueInt64(fields[0]) / 1000
+	}
+	if fields[1].NvmlReturn == uint32(nvml.SUCCESS) {
+		values["pviol"] = fieldValueInt64(fields[1])
+	}
+
+	utilization, ret := device.GetUtilizationRates()
+	if ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("failed to get utilization: %v", nvml.ErrorString(ret))
+	}
+	values["sm"] = int64(utilization.Gpu)
+	values["mem"] = int64(utilization.Memory)
+	if smClk, ret := device.GetClockInfo(nvml.CLOCK_SM); ret == nvml.SUCCESS {
+		values["smclk"] = int64(smClk)
+	}
+	if gClk, ret := device.GetClockInfo(nvml.CLOCK_GRAPHICS); ret == nvml.SUCCESS {
+		values["gclk"] = int64(gClk)
+	}
+	if rx, ret := device.GetPcieThroughput(nvml.PCIE_UTIL_RX_BYTES); ret == nvml.SUCCESS {
+		values["rxpci"] = int64(rx / 1024)
+	}
+	if tx, ret := device.GetPcieThroughput(nvml.PCIE_UTIL_TX_BYTES); ret == nvml.SUCCESS {
+		values["txpci"] = int64(tx / 1024)
+	}
+	reasons, ret := device.GetCurrentClocksEventReasons()
+	switch {
+	case ret != nvml.SUCCESS:
+		values["gpuidle"] = -1
+	case reasons&gpuIdleReason != 0:
+		values["gpuidle"] = 1
+	default:
+		values["gpuidle"] = 0
+	}
+	if pstate, ret := device.GetPerformanceState(); ret == nvml.SUCCESS {
+		values["gpustate"] = int64(pstate)
+	}
+	return values, nil
+}
+
+// fieldValueInt64 decodes the value of a field by its value type.
+func fieldValueInt64(field nvml.FieldValue) int64 {
+	switch nvml.ValueType(field.ValueType) {
+	case nvml.VALUE_TYPE_UNSIGNED_INT:
+		return int64(binary.NativeEndian.Uint32(field.Value[:4]))
+	case nvml.VALUE_TYPE_SIGNED_INT:
+		return int64(int32(binary.NativeEndian.Uint32(field.Value[:4])))
+	default:
+		return int64(binary.NativeEndian.Uint64(field.Value[:]))
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeSampleSource struct {
+	mu     sync.Mutex
+	reads  int
+	closed bool
+}
+
+func (f *fakeSampleSource) Read() (map[string]*IndicatorValues, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reads++
+	return map[string]*IndicatorValues{
+		"GPU-0": {Index: 0, Indicators: map[string]int64{"smclk": int64(f.reads)}},
+	}, nil
+}
+
+func (f *fakeSampleSource) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+}
+
+func TestFieldSamplerRing(t *testing.T) {
+	s := newFieldSampler(time.Second, 3, nil)
+	start := time.Now()
+	for i := 1; i <= 5; i++ {
+		s.add(start.Add(time.Duration(i)*time.Second), map[string]*IndicatorValues{
+			"GPU-0": {Indicators: map[string]int64{"smclk": int64(i)}},
+		})
+	}
+	samples := s.Since(time.Time{})
+	if len(samples) != 3 {
+		t.Fatalf("expected the last 3 samples kept, got %d", len(samples))
+	}
+	for i, sample := range samples {
+		if got := sample.Indicators["GPU-0"].Indicators["smclk"]; got != int64(i+3) {
+			t.Errorf("sample %d: expected smclk %d, got %d", i, i+3, got)
+		}
+		if !sample.Indicators["GPU-0"].LastUpdate.Equal(sample.LastUpdate) {
+			t.Errorf("sample %d: device time %s differs from sample time %s", i, sample.Indicators["GPU-0"].LastUpdate, sample.LastUpdate)
+		}
+	}
+	samples = s.Since(start.Add(4 * time.Second))
+	if len(samples) != 1 || samples[0].Indicators["GPU-0"].Indicators["smclk"] != 5 {
+		t.Errorf("expected only the sample after the 4th second, got %v", samples)
+	}
+}
+
+func TestFieldSamplerStart(t *testing.T) {
+	opens := 0
+	failing := newFieldSampler(time.Millisecond, 10, func() (sampleSource, error) {
+		opens++
+		return nil, fmt.Errorf("no NVML")
+	})
+	if err := failing.Start(); err == nil {
+		t.Fatal("expected the error of the source")
+	}
+	if err := failing.Start(); err == nil || opens != 1 {
+		t.Errorf("expected the first error returned again without reopening, got %v after %d opens", err, opens)
+	}
+	failing.Stop()
+
+	source := &fakeSampleSource{}
+	s := newFieldSampler(5*time.Millisecond, 100, func() (sampleSource, error) { return source, nil })
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for len(s.Since(time.Time{})) < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	s.Stop()
+	samples := s.Since(time.Time{})
+	if len(samples) < 3 {
+		t.Fatalf("expected at least 3 samples, got %d", len(samples))
+	}
+	for i := 1; i < len(samples); i++ {
+		if !samples[i].LastUpdate.After(samples[i-1].LastUpdate) {
+			t.Errorf("samples are not in time order: %s then %s", samples[i-1].LastUpdate, samples[i].LastUpdate)
+		}
+	}
+	if !source.closed {
+		t.Error("expected the source closed on Stop")
+	}
+}
@@ -33,6 +33,14 @@ type UserConfig struct {
 	Mock            bool            `json:"mock" yaml:"mock"`
 	IgnoreNamespace []string        `json:"ignore_namespaces" yaml:"ignore_namespaces"`
 	IgnoredCheckers []string        `json:"ignored_checkers,omitempty" yaml:"ignored_checkers,omitempty"`
+	// RulesFile holds user event rules overriding the built-in rules, reloaded on change
+	RulesFile string `json:"rules_file,omitempty" yaml:"rules_file,omitempty"`
+	// SampleInterval is the period the field sampler reads the GPUs at, the
+	// rules are evaluated on every sample. 0 evaluates them on the nvidia info
+	// of each query interval instead.
+	SampleInterval common.Duration `json:"sample_interval,omitempty" yaml:"sample_interval,omitempty"`
+	// SampleBufferSize is the number of samples kept between two checks
+	SampleBufferSize int `json:"sample_buffer_size,omitempty" yaml:"sample_buffer_size,omitempty"`
 
 	ProcessedIgnoreNamespace map[string]struct{}
 }
@@ -16,10 +16,13 @@ limitations under the License.
 package config
 
 import (
+	"fmt"
+
 	"github.com/scitix/sichek/components/common"
 	nvutils "github.com/scitix/sichek/components/nvidia/utils"
 	"github.com/scitix/sichek/consts"
 	"github.com/scitix/sichek/pkg/utils"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 const (
@@ -48,6 +51,41 @@ type GpuEventRule struct {
 	IndicatorsByModel          []*IndicatorModelOverride `json:"check_items_by_model,omitempty" yaml:"check_items_by_model,omitempty"`
 	AbnormalDetectedTimes      uint32                    `json:"abnormal_detected_times,omitempty" yaml:"abnormal_detected_times,omitempty"`
 	QueryIntervalAfterAbnormal common.Duration           `json:"query_interval_after_abnormal,omitempty" yaml:"query_interval_after_abnormal,omitempty"`
+	// PodCorrelation only reports the GPUs assigned to a pod when all the
+	// GPUs of the pod hang at the same time, e.g. a stuck NCCL collective.
+	// The GPUs not assigned to a pod are reported on their own.
+	PodCorrelation bool `json:"pod_correlation,omitempty" yaml:"pod_correlation,omitempty"`
+	// EvidenceSamples is the number of last values of each indicator kept for
+	// the detail of a hang, DefaultEvidenceSamples when unset
+	EvidenceSamples int `json:"evidence_samples,omitempty" yaml:"evidence_samples,omitempty"`
+	// NCCLLogLines is the number of last NCCL lines of the logs of the pods of
+	// the hung GPUs added to the detail, DefaultNCCLLogLines when unset
+	NCCLLogLines int `json:"nccl_log_lines,omitempty" yaml:"nccl_log_lines,omitempty"`
+	// PodSelector scopes the rule to the GPUs of the pods whose labels match
+	// the label selector, e.g. "job-type=training". The rule applies to every
+	// GPU when unset, and to no GPU outside a pod when set.
+	PodSelector string `json:"pod_selector,omitempty" yaml:"pod_selector,omitempty"`
+}
+
+const (
+	DefaultEvidenceSamples = 5
+	DefaultNCCLLogLines    = 20
+)
+
+// EvidenceSampleCount returns the number of indicator values kept as evidence.
+func (r *GpuEventRule) EvidenceSampleCount() int {
+	if r.EvidenceSamples > 0 {
+		return r.EvidenceSamples
+	}
+	return DefaultEvidenceSamples
+}
+
+// NCCLLogLineCount returns the number of NCCL log lines added as evidence.
+func (r *GpuEventRule) NCCLLogLineCount() int {
+	if r.NCCLLogLines > 0 {
+		return r.NCCLLogLines
+	}
+	return DefaultNCCLLogLines
 }
 
 type HangIndicator struct {
@@ -70,17 +108,7 @@ func LoadDefaultEventRules() (map[string]*GpuEventRule, error) {
 	if err != nil {
 		return nil, err
 	}
-	for _, eventRule := range eventRules.Rules {
-		for _, m := range eventRule.IndicatorsByModel {
-			if m.Model == deviceID {
-				for k, override := range m.Override {
-					clone := *override
-					eventRule.Indicators[k] = &clone
-				}
-				break
-			}
-		}
-	}
+	ApplyModelOverrides(eventRules.Rules, deviceID)
 	return eventRules.Rules, nil
 }
 
@@ -94,7 +122,14 @@ func LoadEventRules(file string) (map[string]*GpuEventRule, error) {
 	if err != nil {
 		return nil, err
 	}
-	for _, eventRule := range eventRules.Rules {
+	ApplyModelOverrides(eventRules.Rules, deviceID)
+	return eventRules.Rules, nil
+}
+
+// ApplyModelOverrides replaces the indicators of the rules by the overrides
+// of the GPU model deviceID.
+func ApplyModelOverrides(rules map[string]*GpuEventRule, deviceID string) {
+	for _, eventRule := range rules {
 		for _, m := range eventRule.IndicatorsByModel {
 			if m.Model == deviceID {
 				for k, override := range m.Override {
@@ -105,5 +140,34 @@ func LoadEventRules(file string) (map[string]*GpuEventRule, error) {
 			}
 		}
 	}
-	return eventRules.Rules, nil
+}
+
+// Validate checks the rule can be evaluated by its checker.
+func (r *GpuEventRule) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("rule name is empty")
+	}
+	if r.DurationThreshold.Duration <= 0 {
+		return fmt.Errorf("rule %s: duration_threshold must be positive", r.Name)
+	}
+	if len(r.Indicators) == 0 {
+		return fmt.Errorf("rule %s: no check_items", r.Name)
+	}
+	for name, indicator := range r.Indicators {
+		if indicator == nil {
+			return fmt.Errorf("rule %s: check item %s is empty", r.Name, name)
+		}
+		switch CompareType(indicator.CompareType) {
+		case CompareLow, CompareHigh, CompareEqual:
+		default:
+			return fmt.Errorf("rule %s: check item %s: invalid compare %q", r.Name, name, indicator.CompareType)
+		}
+	}
+	if _, ok := consts.LevelPriority[r.Level]; !ok {
+		return fmt.Errorf("rule %s: invalid level %q", r.Name, r.Level)
+	}
+	if _, err := labels.Parse(r.PodSelector); err != nil {
+		return fmt.Errorf("rule %s: invalid pod_selector %q: %v", r.Name, r.PodSelector, err)
+	}
+	return nil
 }
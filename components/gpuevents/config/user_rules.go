@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultUserRulesFile holds the event rules overriding the built-in rules, e.g.
+//
+//	gpuevents:
+//	  SmClkStuckLow:
+//	    name: "SmClkStuckLow"
+//	    description: "SM clock too low for long time on training pods"
+//	    duration_threshold: 15m
+//	    level: fatal
+//	    pod_selector: "job-type=training"
+//	    check_items:
+//	      smclk:
+//	        threshold: 800
+//	        compare: low
+//
+// A user rule replaces the built-in rule of the same name as a whole.
+var DefaultUserRulesFile = filepath.Join(consts.DefaultProductionCfgPath, consts.ComponentNameGpuEvents, "rules.yaml")
+
+// UserRulesLoader loads the user rules file and reloads it when the file is
+// added, removed or modified.
+type UserRulesLoader struct {
+	file        string
+	deviceID    string
+	fingerprint string
+	loaded      bool
+	rules       map[string]*GpuEventRule
+}
+
+// NewUserRulesLoader returns a loader of file, whose model overrides are
+// applied for the GPU model deviceID.
+func NewUserRulesLoader(file string, deviceID string) *UserRulesLoader {
+	return &UserRulesLoader{file: file, deviceID: deviceID}
+}
+
+// Load returns the user rules and whether they changed since the previous
+// call. A missing file has no rules, while a file failing to parse keeps
+// the previous rules so a typo does not drop the rules in use. Invalid
+// rules are skipped and logged.
+func (l *UserRulesLoader) Load() (map[string]*GpuEventRule, bool) {
+	fingerprint := l.stat()
+	if l.loaded && fingerprint == l.fingerprint {
+		return l.rules, false
+	}
+	l.fingerprint, l.loaded = fingerprint, true
+	if fingerprint == "" {
+		changed := len(l.rules) > 0
+		l.rules = nil
+		return nil, changed
+	}
+	eventRules := &GpuEventRules{}
+	if err := utils.LoadFromYaml(l.file, eventRules); err != nil {
+		logrus.WithField("component", "gpuevents").Errorf("failed to load user rules from %s, keep the previous rules: %v", l.file, err)
+		return l.rules, false
+	}
+	ApplyModelOverrides(eventRules.Rules, l.deviceID)
+	rules := make(map[string]*GpuEventRule, len(eventRules.Rules))
+	for name, rule := range eventRules.Rules {
+		if rule == nil {
+			continue
+		}
+		if rule.Name == "" {
+			rule.Name = name
+		}
+		if err := rule.Validate(); err != nil {
+			logrus.WithField("component", "gpuevents").Errorf("skip user rule in %s: %v", l.file, err)
+			continue
+		}
+		rules[name] = rule
+	}
+	l.rules = rules
+	logrus.WithField("component", "gpuevents").Infof("loaded %d user rules from %s", len(rules), l.file)
+	return rules, true
+}
+
+// stat fingerprints the size and mtime of the file, empty if it is missing.
+func (l *UserRulesLoader) stat() string {
+	info, err := os.Stat(l.file)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d:%d", info.Size(), info.ModTime().UnixNano())
+}
+
+// MergeEventRules returns the built-in rules overridden and extended by the user rules.
+func MergeEventRules(builtin, user map[string]*GpuEventRule) map[string]*GpuEventRule {
+	merged := make(map[string]*GpuEventRule, len(builtin)+len(user))
+	for name, rule := range builtin {
+		merged[name] = rule
+	}
+	for name, rule := range user {
+		merged[name] = rule
+	}
+	return merged
+}
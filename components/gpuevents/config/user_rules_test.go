@@ -0,0 +1,107 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUserRulesLoader(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "rules.yaml")
+	loader := NewUserRulesLoader(file, "0x233010de")
+	if rules, changed := loader.Load(); changed || len(rules) != 0 {
+		t.Fatalf("expected no user rules without the file, got %d rules changed=%v", len(rules), changed)
+	}
+
+	content := `gpuevents:
+  SmClkStuckLow:
+    name: "SmClkStuckLow"
+    duration_threshold: 15m
+    level: fatal
+    pod_selector: "job-type in (training, finetune)"
+    check_items:
+      smclk:
+        threshold: 800
+        compare: low
+    check_items_by_model:
+      - model: "0x233010de"
+        override:
+          smclk:
+            threshold: 1000
+            compare: low
+  Broken:
+    name: "Broken"
+    duration_threshold: 1m
+    level: fatal
+    pod_selector: "job-type in training"
+    check_items:
+      smclk:
+        threshold: 800
+        compare: low
+`
+	writeRules(t, file, content)
+	rules, changed := loader.Load()
+	if !changed || len(rules) != 1 {
+		t.Fatalf("expected only the valid rule loaded, got %d rules changed=%v", len(rules), changed)
+	}
+	rule := rules[SmClkStuckLowCheckerName]
+	if rule.PodSelector != "job-type in (training, finetune)" || rule.Indicators["smclk"].Threshold != 1000 {
+		t.Errorf("unexpected rule: %+v", rule)
+	}
+	if _, changed = loader.Load(); changed {
+		t.Errorf("unchanged file should not reload the rules")
+	}
+
+	// a file failing to parse keeps the previous rules
+	writeRules(t, file, "gpuevents: [")
+	if rules, changed = loader.Load(); changed || rules[SmClkStuckLowCheckerName] != rule {
+		t.Errorf("expected the previous rules kept, got %d rules changed=%v", len(rules), changed)
+	}
+
+	if err := os.Remove(file); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if rules, changed = loader.Load(); !changed || len(rules) != 0 {
+		t.Errorf("expected the user rules dropped with the file, got %d rules changed=%v", len(rules), changed)
+	}
+}
+
+func TestMergeEventRules(t *testing.T) {
+	builtin := map[string]*GpuEventRule{
+		GPUHangCheckerName:       {Name: GPUHangCheckerName},
+		SmClkStuckLowCheckerName: {Name: SmClkStuckLowCheckerName},
+	}
+	user := map[string]*GpuEventRule{
+		SmClkStuckLowCheckerName: {Name: SmClkStuckLowCheckerName, PodSelector: "job-type=training"},
+	}
+	merged := MergeEventRules(builtin, user)
+	if len(merged) != 2 || merged[GPUHangCheckerName] != builtin[GPUHangCheckerName] || merged[SmClkStuckLowCheckerName] != user[SmClkStuckLowCheckerName] {
+		t.Errorf("expected the user rule to override the built-in one, got %+v", merged)
+	}
+}
+
+func writeRules(t *testing.T, file string, content string) {
+	t.Helper()
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	// the fingerprint changes even if the file is rewritten within the mtime resolution
+	future := time.Now().Add(time.Duration(len(content)) * time.Second)
+	_ = os.Chtimes(file, future, future)
+}
@@ -18,6 +18,7 @@ package gpuevents
 import (
 	"context"
 	"fmt"
+	"io"
 	"sync"
 	"time"
 
@@ -25,7 +26,10 @@ import (
 	"github.com/scitix/sichek/components/gpuevents/checker"
 	"github.com/scitix/sichek/components/gpuevents/collector"
 	"github.com/scitix/sichek/components/gpuevents/config"
+	gpueventsmetrics "github.com/scitix/sichek/components/gpuevents/metrics"
+	nvutils "github.com/scitix/sichek/components/nvidia/utils"
 	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
 	"github.com/scitix/sichek/pkg/utils"
 
 	"github.com/sirupsen/logrus"
@@ -40,6 +44,13 @@ type component struct {
 	componentName string
 	collector     common.Collector
 	checkers      []common.Checker
+	metrics       *gpueventsmetrics.GpuEventsMetrics
+
+	// userRules hot-reloads the user rules file, rules are the built-in
+	// rules merged with the user rules the checkers are built from
+	builtinRules map[string]*config.GpuEventRule
+	userRules    *config.UserRulesLoader
+	rules        map[string]*config.GpuEventRule
 
 	cacheMtx          sync.RWMutex
 	cacheInfoBuffer   []common.Info
@@ -87,21 +98,30 @@ func newComponent(cfgFile string, specFile string) (comp common.Component, err e
 		logrus.WithField("component", "gpuevents").Errorf("NewComponent load spec config failed: %v", err)
 		return nil, err
 	}
+	deviceID, err := nvutils.GetDeviceID()
+	if err != nil {
+		logrus.WithField("component", "gpuevents").WithError(err).Error("failed to get the GPU device ID")
+		return nil, err
+	}
+	rulesFile := userCfg.UserConfig.RulesFile
+	if rulesFile == "" {
+		rulesFile = config.DefaultUserRulesFile
+	}
+	userRules := config.NewUserRulesLoader(rulesFile, deviceID)
+	loadedUserRules, _ := userRules.Load()
+	rules := config.MergeEventRules(eventRules, loadedUserRules)
 	GpuIndicatorSnapshot, err := collector.NewGpuIndicatorSnapshot(userCfg)
 	if err != nil {
 		logrus.WithField("component", "gpuevents").WithError(err).Error("failed to create GpuIndicatorSnapshot")
 		return nil, err
 	}
 
-	hangChecker, err := checker.NewCheckers(userCfg, eventRules)
+	hangChecker, err := checker.NewCheckers(userCfg, rules)
 	if err != nil {
 		logrus.WithField("component", "gpuevents").WithError(err).Error("failed to create HangChecker")
 		return nil, err
 	}
 
-	freqController := common.GetFreqController()
-	freqController.RegisterModule(consts.ComponentNameGpuEvents, userCfg)
-
 	component := &component{
 		ctx:           ctx,
 		cancel:        cancel,
@@ -111,11 +131,18 @@ func newComponent(cfgFile string, specFile string) (comp common.Component, err e
 		collector: GpuIndicatorSnapshot,
 		checkers:  hangChecker,
 
+		builtinRules: eventRules,
+		userRules:    userRules,
+		rules:        rules,
+
 		cacheResultBuffer: make([]*common.Result, userCfg.UserConfig.CacheSize),
 		cacheInfoBuffer:   make([]common.Info, userCfg.UserConfig.CacheSize),
 		currIndex:         0,
 		cacheSize:         userCfg.UserConfig.CacheSize,
 	}
+	if userCfg.UserConfig.EnableMetrics {
+		component.metrics = gpueventsmetrics.NewGpuEventsMetrics()
+	}
 	component.service = common.NewCommonService(ctx, userCfg, component.componentName, component.GetTimeout(), component.HealthCheck)
 	return component, nil
 }
@@ -125,12 +152,20 @@ func (c *component) Name() string {
 }
 
 func (c *component) HealthCheck(ctx context.Context) (*common.Result, error) {
-	info, err := c.collector.Collect(ctx)
+	info, err := common.Collect(ctx, c.componentName, c.collector.Collect)
 	if err != nil || info == nil {
 		logrus.WithField("component", "gpuevents").Error("failed to Collect")
 		return &common.Result{}, err
 	}
-	result := common.Check(ctx, c.componentName, info, c.checkers)
+	if c.metrics != nil {
+		switch indicators := info.(type) {
+		case *collector.DeviceIndicatorValues:
+			c.metrics.ExportMetrics(indicators)
+		case *collector.DeviceIndicatorSeries:
+			c.metrics.ExportMetrics(indicators.Last())
+		}
+	}
+	result := common.Check(ctx, c.componentName, info, c.loadCheckers())
 	c.cacheMtx.Lock()
 	c.cacheResultBuffer[c.currIndex%c.cacheSize] = result
 	c.cacheInfoBuffer[c.currIndex%c.cacheSize] = info
@@ -145,6 +180,33 @@ func (c *component) HealthCheck(ctx context.Context) (*common.Result, error) {
 	return result, nil
 }
 
+// loadCheckers returns the checkers of the built-in rules merged with the user
+// rules, which are reloaded if the rules file changed since the previous check.
+// Only the checkers whose rule changed are rebuilt, the others keep their state.
+func (c *component) loadCheckers() []common.Checker {
+	userRules, changed := c.userRules.Load()
+	if !changed {
+		return c.checkers
+	}
+	rules := config.MergeEventRules(c.builtinRules, userRules)
+	checkers, err := checker.NewCheckers(c.cfg, rules)
+	if err != nil {
+		logrus.WithField("component", "gpuevents").WithError(err).Error("failed to rebuild the checkers, keep the previous ones")
+		return c.checkers
+	}
+	previous := make(map[string]common.Checker, len(c.checkers))
+	for _, chk := range c.checkers {
+		previous[chk.Name()] = chk
+	}
+	for i, chk := range checkers {
+		if old, found := previous[chk.Name()]; found && rules[chk.Name()] == c.rules[chk.Name()] {
+			checkers[i] = old
+		}
+	}
+	c.rules, c.checkers = rules, checkers
+	return checkers
+}
+
 func (c *component) CacheResults() ([]*common.Result, error) {
 	c.cacheMtx.Lock()
 	defer c.cacheMtx.Unlock()
@@ -182,6 +244,11 @@ func (c *component) Start() <-chan *common.Result {
 }
 
 func (c *component) Stop() error {
+	if closer, ok := c.collector.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			logrus.WithField("component", "gpuevents").WithError(err).Warn("failed to close the collector")
+		}
+	}
 	return c.service.Stop()
 }
 
@@ -211,11 +278,11 @@ func (c *component) PrintInfo(info common.Info, result *common.Result, summaryPr
 	for _, result := range checkerResults {
 		if result.Status == consts.StatusAbnormal {
 			checkAllPassed = false
-			fmt.Printf("\t%s%s%s\n", consts.LevelColor(result.Level), result.Detail, consts.Reset)
+			printer.Printf("\t%s%s%s\n", consts.LevelColor(result.Level), result.Detail, consts.Reset)
 		}
 	}
 	if checkAllPassed {
-		fmt.Printf("%sNo Hang event detected%s\n", consts.Green, consts.Reset)
+		printer.Printf("%sNo Hang event detected%s\n", consts.Green, consts.Reset)
 	}
 	return checkAllPassed
 }
@@ -0,0 +1,51 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package metrics
+
+import (
+	"github.com/scitix/sichek/components/gpuevents/collector"
+	metrics "github.com/scitix/sichek/metrics"
+)
+
+const (
+	MetricPrefix = "sichek_gpuevents"
+)
+
+type GpuEventsMetrics struct {
+	IndicatorGauge *metrics.GaugeVecMetricExporter
+}
+
+func NewGpuEventsMetrics() *GpuEventsMetrics {
+	return &GpuEventsMetrics{
+		IndicatorGauge: metrics.NewGaugeVecMetricExporter(MetricPrefix, []string{"device", "indicator"}),
+	}
+}
+
+// ExportMetrics exports sichek_gpuevents_indicator, the value of every hang
+// indicator (e.g. SM activity, power, PCIe throughput) per GPU.
+func (m *GpuEventsMetrics) ExportMetrics(info *collector.DeviceIndicatorValues) {
+	if info == nil {
+		return
+	}
+	for device, values := range info.Indicators {
+		if values == nil {
+			continue
+		}
+		for indicator, value := range values.Indicators {
+			m.IndicatorGauge.SetMetric("indicator", []string{device, indicator}, float64(value))
+		}
+	}
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"strings"
+
+	"github.com/scitix/sichek/components/infiniband/collector"
+)
+
+// NewSpecsFromInfo builds one HCASpec per board ID from the hardware info of
+// the local IB ports. Per device values (names, GUIDs, BDFs, NUMA) are
+// dropped so the spec applies to every card of the same board ID.
+func NewSpecsFromInfo(hwInfos map[string]collector.IBHardWareInfo) map[string]*HCASpec {
+	specs := make(map[string]*HCASpec)
+	for _, hwInfo := range hwInfos {
+		if hwInfo.BoardID == "" || strings.Contains(hwInfo.IBDev, "mezz") {
+			continue
+		}
+		if _, ok := specs[hwInfo.BoardID]; ok {
+			continue
+		}
+		hardware := collector.IBHardWareInfo{
+			HCAType:          hwInfo.HCAType,
+			BoardID:          hwInfo.BoardID,
+			VPD:              hwInfo.VPD,
+			PhyState:         hwInfo.PhyState,
+			PortState:        hwInfo.PortState,
+			LinkLayer:        hwInfo.LinkLayer,
+			NetOperstate:     hwInfo.NetOperstate,
+			PortSpeed:        hwInfo.PortSpeed,
+			PCIESpeed:        hwInfo.PCIESpeed,
+			PCIEWidth:        hwInfo.PCIEWidth,
+			PCIETreeSpeedMin: hwInfo.PCIETreeSpeedMin,
+			PCIETreeWidthMin: hwInfo.PCIETreeWidthMin,
+			PCIEMRR:          hwInfo.PCIEMRR,
+		}
+		if hwInfo.FWVer != "" {
+			hardware.FWVer = ">=" + hwInfo.FWVer
+		}
+		specs[hwInfo.BoardID] = &HCASpec{Hardware: hardware}
+	}
+	return specs
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/scitix/sichek/components/infiniband/collector"
 	"github.com/scitix/sichek/consts"
 	"github.com/scitix/sichek/pkg/httpclient"
+	"github.com/scitix/sichek/pkg/hostfs"
 	"github.com/scitix/sichek/pkg/utils"
 	"github.com/sirupsen/logrus"
 	"sigs.k8s.io/yaml"
@@ -27,6 +28,9 @@ type HCASpecs struct {
 type HCASpec struct {
 	Hardware collector.IBHardWareInfo `json:"hardware" yaml:"hardware"`
 	Perf     HCAPerf                  `json:"perf" yaml:"perf"`
+	// FWMatrix maps an OFED major version, e.g. "23.10", to the firmware
+	// versions of the board supported by it, e.g. ">=28.39.1002"
+	FWMatrix map[string]string `json:"fw_matrix,omitempty" yaml:"fw_matrix,omitempty"`
 }
 
 type HCAPerf struct {
@@ -294,21 +298,23 @@ func (s *HCASpecs) tryLoadFromDevConfig() error {
 // FilterSpecsForLocalHost filters `allSpecs` to include only the board IDs
 // present on the local host. If `file` is non-empty, overwrites it with the
 // filtered subset (the applied baseline) using common.WriteSpec (.bak backup + tracing).
+// A board ID without its own spec is served the spec of its HCA type, if any.
 // This is a pure lookup; no network calls. If IDs are missing, call EnsureSpec first.
 func FilterSpecsForLocalHost(file string, allSpecs *HCASpecs) (*HCASpecs, error) {
 	if allSpecs == nil || allSpecs.GetMap() == nil {
 		return nil, fmt.Errorf("HCA spec is not initialized")
 	}
-	_, ibDevs, err := GetIBPFBoardIDs()
+	devBoardIDs, ibDevs, err := GetIBPFBoardIDs()
 	if err != nil {
 		return nil, err
 	}
+	hcaTypes := BoardHCATypes(devBoardIDs)
 
 	result := &HCASpecs{Specs: map[string]*HCASpec{}}
 	var missing []string
 
 	for _, boardID := range ibDevs {
-		if spec, ok := allSpecs.GetMap()[boardID]; ok {
+		if spec, ok := allSpecs.Lookup(boardID, hcaTypes[boardID]); ok {
 			result.Specs[boardID] = spec
 		} else {
 			logrus.WithField("component", "hca").Warnf(
@@ -326,8 +332,60 @@ func FilterSpecsForLocalHost(file string, allSpecs *HCASpecs) (*HCASpecs, error)
 	// the source of truth maintained by humans.
 	return result, nil
 }
+
+// ibSysfsDir is where the IB devices are enumerated, joined with hostfs.Root,
+// a var for tests.
+var ibSysfsDir = "/sys/class/infiniband"
+
+// Lookup returns the spec of boardID, falling back to the spec keyed by the
+// HCA type, e.g. "MT4129" for the ConnectX-7, so that a board without its own
+// entry is still validated against the spec of its device.
+func (s *HCASpecs) Lookup(boardID, hcaType string) (*HCASpec, bool) {
+	m := s.GetMap()
+	if spec, ok := m[boardID]; ok {
+		return spec, true
+	}
+	if hcaType != "" {
+		if spec, ok := m[hcaType]; ok {
+			return spec, true
+		}
+	}
+	return nil, false
+}
+
+// GetIBPFHCATypes maps the IB devices to their HCA type, the PCI device ID
+// of the board as the driver reports it, e.g. "MT4129" for the ConnectX-7
+// and "MT41692" for the BlueField-3.
+func GetIBPFHCATypes(ibDevs []string) map[string]string {
+	types := make(map[string]string, len(ibDevs))
+	for _, dev := range ibDevs {
+		content, err := os.ReadFile(hostfs.Path(ibSysfsDir, dev, "hca_type"))
+		if err != nil {
+			continue
+		}
+		if hcaType := strings.TrimSpace(string(content)); hcaType != "" {
+			types[dev] = hcaType
+		}
+	}
+	return types
+}
+
+// BoardHCATypes maps the board IDs of devBoardIDs to the HCA type of one of
+// their devices.
+func BoardHCATypes(devBoardIDs map[string]string) map[string]string {
+	devs := make([]string, 0, len(devBoardIDs))
+	for dev := range devBoardIDs {
+		devs = append(devs, dev)
+	}
+	types := make(map[string]string)
+	for dev, hcaType := range GetIBPFHCATypes(devs) {
+		types[devBoardIDs[dev]] = hcaType
+	}
+	return types
+}
+
 func GetIBPFBoardIDs() (map[string]string, []string, error) {
-	baseDir := "/sys/class/infiniband"
+	baseDir := hostfs.Path(ibSysfsDir)
 	devices, err := os.ReadDir(baseDir)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to read %s: %v", baseDir, err)
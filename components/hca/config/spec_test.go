@@ -19,10 +19,12 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/scitix/sichek/components/common"
 	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/hostfs/hostfstest"
 	"github.com/scitix/sichek/pkg/httpclient"
 )
 
@@ -151,6 +153,7 @@ func TestLoadSpecFromRemoteURL(t *testing.T) {
 }
 
 func TestGetBoardIDs(t *testing.T) {
+	hostfstest.Load(t, "h100-cx7")
 	_, boardIDs, err := GetIBPFBoardIDs()
 	if err != nil {
 		t.Fatalf("getBoardIDs() returned an error: %v", err)
@@ -209,3 +212,42 @@ func TestLoadProductionSpec(t *testing.T) {
 		t.Skip("Production spec files not found, skipping TestLoadProductionSpec")
 	}
 }
+
+func TestLookupFallsBackToHCAType(t *testing.T) {
+	specs := &HCASpecs{Specs: map[string]*HCASpec{
+		"MT_0000000970": {FWMatrix: map[string]string{"23.10": "board"}},
+		"MT41692":       {FWMatrix: map[string]string{"23.10": "type"}},
+	}}
+	if spec, ok := specs.Lookup("MT_0000000970", "MT41692"); !ok || spec.FWMatrix["23.10"] != "board" {
+		t.Errorf("expected the board spec to win, got %v", spec)
+	}
+	if spec, ok := specs.Lookup("MT_0000001093", "MT41692"); !ok || spec.FWMatrix["23.10"] != "type" {
+		t.Errorf("expected the HCA type spec, got %v", spec)
+	}
+	if _, ok := specs.Lookup("MT_0000001093", ""); ok {
+		t.Errorf("expected no spec without a matching board ID or HCA type")
+	}
+}
+
+func TestGetIBPFHCATypes(t *testing.T) {
+	dir := t.TempDir()
+	saved := ibSysfsDir
+	ibSysfsDir = dir
+	defer func() { ibSysfsDir = saved }()
+	for dev, hcaType := range map[string]string{"mlx5_0": "MT4129\n", "mlx5_1": "MT41692\n"} {
+		if err := os.MkdirAll(filepath.Join(dir, dev), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, dev, "hca_type"), []byte(hcaType), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	types := GetIBPFHCATypes([]string{"mlx5_0", "mlx5_1", "mlx5_2"})
+	if len(types) != 2 || types["mlx5_0"] != "MT4129" || types["mlx5_1"] != "MT41692" {
+		t.Errorf("unexpected HCA types %v", types)
+	}
+	boards := BoardHCATypes(map[string]string{"mlx5_0": "MT_0000000970", "mlx5_1": "MT_0000001093"})
+	if boards["MT_0000000970"] != "MT4129" || boards["MT_0000001093"] != "MT41692" {
+		t.Errorf("unexpected board HCA types %v", boards)
+	}
+}
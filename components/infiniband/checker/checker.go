@@ -19,14 +19,20 @@ import (
 	"github.com/scitix/sichek/components/common"
 	"github.com/scitix/sichek/components/infiniband/collector"
 	"github.com/scitix/sichek/components/infiniband/config"
+	"github.com/scitix/sichek/pkg/capability"
 	"github.com/sirupsen/logrus"
 )
 
-func NewCheckers(cfg *config.InfinibandUserConfig, spec *config.InfinibandSpec, info *collector.InfinibandInfo) ([]common.Checker, error) {
+// NewCheckers creates the infiniband checkers, lastInfo returns the previous
+// sample from the component cache for the subnet manager failover checker,
+// flapHistory keeps the link flaps of the ports across health checks and
+// baseline the last-seen counters for the counter rate checkers.
+func NewCheckers(cfg *config.InfinibandUserConfig, spec *config.InfinibandSpec, info *collector.InfinibandInfo, lastInfo func() (common.Info, error), flapHistory *LinkFlapHistory, baseline *CounterBaseline) ([]common.Checker, error) {
 
 	checkerConstructors := map[string]func(*config.InfinibandSpec) (common.Checker, error){
 		config.CheckIBOFED:      NewIBOFEDChecker,
 		config.CheckIBFW:        NewFirmwareChecker,
+		config.CheckIBFWMatrix:  NewIBFWMatrixChecker,
 		config.CheckIBState:     NewIBStateChecker,
 		config.CheckIBPhyState:  NewIBPhyStateChecker,
 		config.CheckIBPortSpeed: NewIBPortSpeedChecker,
@@ -39,6 +45,31 @@ func NewCheckers(cfg *config.InfinibandUserConfig, spec *config.InfinibandSpec,
 		config.CheckIBLost:      NewIBLostChecker,
 		config.CheckPCIETreeSpeed: NewIBPCIETreeSpeedChecker,
 		config.CheckPCIETreeWidth: NewIBPCIETreeWidthChecker,
+		config.CheckIBCounterRate: func(spec *config.InfinibandSpec) (common.Checker, error) {
+			return NewIBCounterRateChecker(spec, baseline)
+		},
+		config.CheckIBCongestion: func(spec *config.InfinibandSpec) (common.Checker, error) {
+			return NewIBCongestionChecker(spec, baseline)
+		},
+		config.CheckIBProbe:       NewIBProbeChecker,
+		config.CheckIBLinkFlap: func(spec *config.InfinibandSpec) (common.Checker, error) {
+			return NewIBLinkFlapChecker(spec, flapHistory)
+		},
+		config.CheckIBVFNum:       NewIBVFNumChecker,
+		config.CheckIBVFGUID:      NewIBVFGUIDChecker,
+		config.CheckIBVFLinkState: NewIBVFLinkStateChecker,
+		config.CheckIBVFError:     NewIBVFErrorChecker,
+		config.CheckIBVFCounters: func(spec *config.InfinibandSpec) (common.Checker, error) {
+			return NewIBVFCounterChecker(spec, baseline)
+		},
+		config.CheckIBSubnetManager: NewIBSubnetManagerChecker,
+		config.CheckIBSMFailover: func(spec *config.InfinibandSpec) (common.Checker, error) {
+			return NewIBSMFailoverChecker(spec, lastInfo)
+		},
+		config.CheckIBPortMTU:     NewIBPortMTUChecker,
+		config.CheckRoCEGID:       NewRoCEGIDChecker,
+		config.CheckIBPhyDiag:     NewIBPhyDiagChecker,
+		config.CheckIBTemperature: NewIBTemperatureChecker,
 		// config.CheckIBNUM:         dependence.NewIOMMUChecker,
 		// config.CheckNetOperstate:  NewNetOperstateChecker,
 		// config.CheckPCIEACS:       NewPCIEACSChecker,
@@ -53,6 +84,11 @@ func NewCheckers(cfg *config.InfinibandUserConfig, spec *config.InfinibandSpec,
 	}
 	info.RUnlock()
 
+	// privilegedCheckers are reported as skipped when sichek runs without the capabilities they need
+	privilegedCheckers := map[string][]capability.Capability{
+		config.CheckPCIEMRR: {capability.PCIConfig},
+	}
+
 	ignoredSet := make(map[string]struct{})
 	for _, checker := range cfg.Infiniband.IgnoredCheckers {
 		ignoredSet[checker] = struct{}{}
@@ -70,6 +106,9 @@ func NewCheckers(cfg *config.InfinibandUserConfig, spec *config.InfinibandSpec,
 				logrus.WithError(err).WithField("checker", checkerName).Error("Failed to create checker")
 				continue
 			}
+			if caps, ok := privilegedCheckers[checkerName]; ok {
+				checker = common.RequireCapabilities(checker, caps...)
+			}
 			usedCheckers = append(usedCheckers, checker)
 			usedCheckersName = append(usedCheckersName, checkerName)
 		}
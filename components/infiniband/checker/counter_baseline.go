@@ -0,0 +1,241 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scitix/sichek/components/infiniband/collector"
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	counterBaselineFile = "ib_counter_baseline.json"
+	// DefaultCounterBaselineMaxAge drops a baseline left by a daemon stopped
+	// for longer, the rate over such a window would hide a burst of errors.
+	DefaultCounterBaselineMaxAge = time.Hour
+)
+
+// PortBaseline is the last-seen sample of the counters of an IB port.
+type PortBaseline struct {
+	Time     time.Time            `json:"time"`
+	Counters collector.IBCounters `json:"counters"`
+}
+
+// CounterReset is a counter found lower than in the baseline, after a driver
+// reload, a port reset or a counter wrap.
+type CounterReset struct {
+	Counter string `json:"counter"`
+	Prev    uint64 `json:"prev"`
+	Curr    uint64 `json:"curr"`
+}
+
+func (r CounterReset) String() string {
+	return fmt.Sprintf("%s %d -> %d", r.Counter, r.Prev, r.Curr)
+}
+
+// CounterBaselineState is the persisted baseline of the ports, BootID tells
+// apart the baselines recorded before a reboot, when the counters restart.
+type CounterBaselineState struct {
+	BootID string                   `json:"boot_id"`
+	Ports  map[string]*PortBaseline `json:"ports"`
+}
+
+// CounterBaseline keeps the last-seen counters of the IB ports and persists
+// them under the state directory, so that the counter rate checkers compare
+// the first sample after a daemon restart with the one before instead of
+// waiting for a second sample. The checkers read the baseline, the component
+// records the sample once all of them ran. A counter lower than its baseline
+// was reset and restarts from the new value rather than producing a huge
+// delta.
+type CounterBaseline struct {
+	mu     sync.RWMutex
+	path   string
+	maxAge time.Duration
+	bootID string
+	ports  map[string]*PortBaseline
+
+	saveFailed bool
+}
+
+// NewCounterBaseline loads the baseline persisted in dir, the baseline of
+// another boot or older than DefaultCounterBaselineMaxAge is dropped.
+func NewCounterBaseline(dir string) *CounterBaseline {
+	b := &CounterBaseline{
+		path:   filepath.Join(dir, counterBaselineFile),
+		maxAge: DefaultCounterBaselineMaxAge,
+		bootID: readBootID(),
+		ports:  make(map[string]*PortBaseline),
+	}
+	if err := b.load(time.Now()); err != nil {
+		logrus.WithField("component", "infiniband").Warnf("load IB counter baseline failed, starting over: %v", err)
+	}
+	return b
+}
+
+func (b *CounterBaseline) load(now time.Time) error {
+	data, err := os.ReadFile(b.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	state := &CounterBaselineState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return fmt.Errorf("invalid IB counter baseline %s: %w", b.path, err)
+	}
+	if state.BootID != b.bootID {
+		logrus.WithField("component", "infiniband").Infof("IB counter baseline was recorded before a reboot, dropped")
+		return nil
+	}
+	for key, port := range state.Ports {
+		if port == nil || now.Sub(port.Time) > b.maxAge || port.Time.After(now) {
+			continue
+		}
+		b.ports[key] = port
+	}
+	return nil
+}
+
+// Get returns the baseline of a port taken before at, nil if there is none.
+func (b *CounterBaseline) Get(key string, at time.Time) *PortBaseline {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	port, ok := b.ports[key]
+	if !ok || !port.Time.Before(at) || at.Sub(port.Time) > b.maxAge {
+		return nil
+	}
+	return port
+}
+
+// Record makes the counters of info the baseline of their ports, and of the
+// VFs given to the pods, logs the counters found reset and persists the
+// baseline. The ports gone from info are forgotten.
+func (b *CounterBaseline) Record(info *collector.InfinibandInfo) {
+	if b == nil || info == nil {
+		return
+	}
+	info.RLock()
+	ports := make(map[string]*PortBaseline, len(info.IBCounters))
+	for key, counters := range info.IBCounters {
+		copied := make(collector.IBCounters, len(counters))
+		for name, value := range counters {
+			copied[name] = value
+		}
+		ports[key] = &PortBaseline{Time: info.Time, Counters: copied}
+	}
+	for _, hw := range info.IBHardWareInfo {
+		for _, vf := range hw.VFs {
+			if vf.Pod == nil || len(vf.Counters) == 0 {
+				continue
+			}
+			copied := make(collector.IBCounters, len(vf.Counters))
+			for name, value := range vf.Counters {
+				copied[name] = value
+			}
+			ports[VFBaselineKey(vf)] = &PortBaseline{Time: info.Time, Counters: copied}
+		}
+	}
+	info.RUnlock()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for key, port := range ports {
+		prev, ok := b.ports[key]
+		if !ok || !prev.Time.Before(port.Time) {
+			continue
+		}
+		if resets := counterResets(prev.Counters, port.Counters); len(resets) > 0 {
+			logrus.WithField("component", "infiniband").Warnf("IB counters of %s were reset (driver reload or wrap), baseline restarted: %s", key, formatResets(resets))
+		}
+	}
+	b.ports = ports
+
+	if err := b.save(&CounterBaselineState{BootID: b.bootID, Ports: ports}); err != nil {
+		// e.g. a CLI run without root, the rates still work within the process
+		if !b.saveFailed {
+			logrus.WithField("component", "infiniband").Warnf("persist IB counter baseline to %s failed: %v", b.path, err)
+		}
+		b.saveFailed = true
+		return
+	}
+	b.saveFailed = false
+}
+
+// VFBaselineKey is the baseline key of the counters of a VF given to a pod,
+// a VF given to another pod starts over.
+func VFBaselineKey(vf collector.VFInfo) string {
+	return "vf/" + vf.BDF + "/" + vf.Pod.UID
+}
+
+// save atomically replaces the persisted baseline.
+func (b *CounterBaseline) save(state *CounterBaselineState) error {
+	if err := os.MkdirAll(filepath.Dir(b.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	tmp := b.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, b.path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// counterResets returns the counters lower in curr than in prev, by name.
+func counterResets(prev, curr collector.IBCounters) []CounterReset {
+	var resets []CounterReset
+	for name, currVal := range curr {
+		if prevVal, ok := prev[name]; ok && currVal < prevVal {
+			resets = append(resets, CounterReset{Counter: name, Prev: prevVal, Curr: currVal})
+		}
+	}
+	sort.Slice(resets, func(i, j int) bool { return resets[i].Counter < resets[j].Counter })
+	return resets
+}
+
+func formatResets(resets []CounterReset) string {
+	parts := make([]string, 0, len(resets))
+	for _, reset := range resets {
+		parts = append(parts, reset.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// readBootID returns the id the kernel draws at each boot, empty if unknown.
+func readBootID() string {
+	data, err := os.ReadFile(hostfs.Path("/proc/sys/kernel/random/boot_id"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/infiniband/collector"
+)
+
+func baselineSample(at time.Time, symbolErrors uint64) *collector.InfinibandInfo {
+	return &collector.InfinibandInfo{
+		Time:       at,
+		IBCounters: map[string]collector.IBCounters{"mlx5_0/p1": {"symbol_error": symbolErrors}},
+	}
+}
+
+func TestCounterBaselineSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	NewCounterBaseline(dir).Record(baselineSample(now.Add(-2*time.Minute), 100))
+
+	// a new daemon finds the baseline of the previous one
+	baseline := NewCounterBaseline(dir)
+	prev := baseline.Get("mlx5_0/p1", now)
+	if prev == nil || prev.Counters["symbol_error"] != 100 {
+		t.Fatalf("expected the persisted baseline, got %+v", prev)
+	}
+	// the baseline of a sample is never the sample itself
+	if baseline.Get("mlx5_0/p1", now.Add(-2*time.Minute)) != nil {
+		t.Errorf("baseline returned for a sample not newer than it")
+	}
+	if baseline.Get("mlx5_1/p1", now) != nil {
+		t.Errorf("baseline returned for an unknown port")
+	}
+}
+
+func TestCounterBaselineDropsStaleState(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	NewCounterBaseline(dir).Record(baselineSample(now.Add(-2*DefaultCounterBaselineMaxAge), 100))
+	if prev := NewCounterBaseline(dir).Get("mlx5_0/p1", now); prev != nil {
+		t.Errorf("expected a baseline older than the max age to be dropped, got %+v", prev)
+	}
+
+	// a baseline recorded before a reboot is dropped too
+	NewCounterBaseline(dir).Record(baselineSample(now.Add(-time.Minute), 100))
+	path := filepath.Join(dir, counterBaselineFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read baseline: %v", err)
+	}
+	state := &CounterBaselineState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		t.Fatalf("decode baseline: %v", err)
+	}
+	state.BootID = "another-boot"
+	data, _ = json.Marshal(state)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("write baseline: %v", err)
+	}
+	if prev := NewCounterBaseline(dir).Get("mlx5_0/p1", now); prev != nil {
+		t.Errorf("expected the baseline of another boot to be dropped, got %+v", prev)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatalf("write baseline: %v", err)
+	}
+	if prev := NewCounterBaseline(dir).Get("mlx5_0/p1", now); prev != nil {
+		t.Errorf("expected a corrupt baseline to be ignored, got %+v", prev)
+	}
+}
+
+func TestCounterBaselineReset(t *testing.T) {
+	now := time.Now()
+	baseline := NewCounterBaseline(t.TempDir())
+	baseline.Record(baselineSample(now.Add(-2*time.Minute), 1000000))
+
+	// the driver reload reset the counter: no rate, the baseline restarts
+	curr := baselineSample(now.Add(-time.Minute), 10)
+	prev := baseline.Get("mlx5_0/p1", curr.Time)
+	resets := counterResets(prev.Counters, curr.IBCounters["mlx5_0/p1"])
+	if len(resets) != 1 || resets[0].String() != "symbol_error 1000000 -> 10" {
+		t.Fatalf("unexpected resets %v", resets)
+	}
+	if rates := counterRates(prev.Counters, curr.IBCounters["mlx5_0/p1"], map[string]float64{"symbol_error": 1}, 1); len(rates) != 0 {
+		t.Errorf("reset counter rated: %v", rates)
+	}
+	baseline.Record(curr)
+	if prev := baseline.Get("mlx5_0/p1", now); prev == nil || prev.Counters["symbol_error"] != 10 {
+		t.Errorf("expected the baseline to restart from the reset value, got %+v", prev)
+	}
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/infiniband/collector"
+	"github.com/scitix/sichek/components/infiniband/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+// IBFWMatrixChecker reports the HCAs needing a firmware update: the ones
+// running another firmware than the other HCAs of the same type, and the ones
+// whose firmware is not supported by the installed OFED per the fw_matrix of
+// their board in the spec.
+type IBFWMatrixChecker struct {
+	name string
+	spec *config.InfinibandSpec
+}
+
+func NewIBFWMatrixChecker(specCfg *config.InfinibandSpec) (common.Checker, error) {
+	return &IBFWMatrixChecker{
+		name: config.CheckIBFWMatrix,
+		spec: specCfg,
+	}, nil
+}
+
+func (c *IBFWMatrixChecker) Name() string {
+	return c.name
+}
+
+func (c *IBFWMatrixChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	infinibandInfo, ok := data.(*collector.InfinibandInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid InfinibandInfo type")
+	}
+
+	result := config.InfinibandCheckItems[c.name]
+	result.Status = consts.StatusNormal
+
+	infinibandInfo.RLock()
+	hws := uniqueByDev(infinibandInfo.IBHardWareInfo)
+	ofedVer := infinibandInfo.IBSoftWareInfo.OFEDVer
+	infinibandInfo.RUnlock()
+	if len(hws) == 0 {
+		result.Status = consts.StatusAbnormal
+		result.Detail = config.NOIBFOUND
+		return &result, fmt.Errorf("fail to get the IB device")
+	}
+
+	devs := make([]string, 0, len(hws))
+	for dev := range hws {
+		devs = append(devs, dev)
+	}
+	sort.Strings(devs)
+
+	// the firmware run by most HCAs of a type is the one the others should run
+	byType := make(map[string]map[string]int)
+	for _, dev := range devs {
+		hw := hws[dev]
+		if byType[hw.HCAType] == nil {
+			byType[hw.HCAType] = make(map[string]int)
+		}
+		byType[hw.HCAType][hw.FWVer]++
+	}
+	ofedMajor, _, ofedErr := parseOFEDVersion(ofedVer)
+	if ofedErr != nil {
+		logrus.WithField("checker", c.name).Debugf("skip the OFED firmware matrix: %v", ofedErr)
+	}
+
+	var (
+		detail      []string
+		failedHcas  []string
+		currVersion []string
+	)
+	for _, dev := range devs {
+		hw := hws[dev]
+		currVersion = append(currVersion, hw.FWVer)
+		var reasons []string
+		if expected := majorityFW(byType[hw.HCAType]); hw.FWVer != expected {
+			reasons = append(reasons, fmt.Sprintf("expected:%s (the other %s HCAs)", expected, hw.HCAType))
+		}
+		if ofedErr == nil {
+			if hcaSpec, ok := c.spec.HCAs[hw.BoardID]; ok {
+				if supported, ok := hcaSpec.FWMatrix[ofedMajor]; ok && !common.CompareVersion(supported, hw.FWVer) {
+					reasons = append(reasons, fmt.Sprintf("expected:%s (OFED %s)", supported, ofedMajor))
+				}
+			}
+		}
+		if len(reasons) == 0 {
+			continue
+		}
+		logrus.WithFields(logrus.Fields{
+			"checker": c.Name(),
+			"hca":     hw.IBDev,
+			"psid":    hw.BoardID,
+			"curr":    hw.FWVer,
+		}).Warnf("Infiniband firmware needs an update: %s", strings.Join(reasons, ", "))
+		failedHcas = append(failedHcas, hw.IBDev)
+		detail = append(detail, fmt.Sprintf("hca:%s psid:%s bdf:%s curr:%s %s", hw.IBDev, hw.BoardID, hw.PCIEBDF, hw.FWVer, strings.Join(reasons, " ")))
+	}
+
+	result.Curr = strings.Join(currVersion, ",")
+	if len(failedHcas) > 0 {
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(failedHcas, ",")
+		result.Detail = strings.Join(detail, "\n")
+	}
+	return &result, nil
+}
+
+// majorityFW returns the firmware run by most HCAs, the highest version on a tie.
+func majorityFW(counts map[string]int) string {
+	var majority string
+	for fw, count := range counts {
+		if count > counts[majority] || (count == counts[majority] && common.CompareVersion(">"+majority, fw)) {
+			majority = fw
+		}
+	}
+	return majority
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	hcaConfig "github.com/scitix/sichek/components/hca/config"
+	"github.com/scitix/sichek/components/infiniband/collector"
+	"github.com/scitix/sichek/components/infiniband/config"
+	"github.com/scitix/sichek/consts"
+)
+
+func TestIBFWMatrixChecker(t *testing.T) {
+	spec := &config.InfinibandSpec{HCAs: map[string]*hcaConfig.HCASpec{
+		"MT_0000000970": {FWMatrix: map[string]string{"23.10": ">=28.39.2048"}},
+	}}
+	chk, err := NewIBFWMatrixChecker(spec)
+	if err != nil {
+		t.Fatalf("NewIBFWMatrixChecker: %v", err)
+	}
+	hca := func(dev, fw string) collector.IBHardWareInfo {
+		return collector.IBHardWareInfo{IBDev: dev, HCAType: "MT4129", BoardID: "MT_0000000970", FWVer: fw}
+	}
+
+	cases := map[string]struct {
+		ofed       string
+		fws        []string
+		wantStatus string
+		wantDevice string
+	}{
+		"consistent and supported": {"MLNX_OFED_LINUX-23.10-1.1.9.0", []string{"28.39.2048", "28.39.2048", "28.39.2048"}, consts.StatusNormal, ""},
+		"one hca behind":           {"MLNX_OFED_LINUX-23.10-1.1.9.0", []string{"28.39.2048", "28.39.1002", "28.39.2048"}, consts.StatusAbnormal, "mlx5_1"},
+		"all below the matrix":     {"MLNX_OFED_LINUX-23.10-1.1.9.0", []string{"28.39.1002", "28.39.1002", "28.39.1002"}, consts.StatusAbnormal, "mlx5_0,mlx5_1,mlx5_2"},
+		"ofed not in the matrix":   {"MLNX_OFED_LINUX-24.04-0.6.6.0", []string{"28.39.1002", "28.39.1002", "28.39.1002"}, consts.StatusNormal, ""},
+		"inbox rdma-core":          {"50.0", []string{"28.39.1002", "28.39.1002", "28.39.1002"}, consts.StatusNormal, ""},
+	}
+	for name, tc := range cases {
+		info := &collector.InfinibandInfo{
+			IBSoftWareInfo: collector.IBSoftWareInfo{OFEDVer: tc.ofed},
+			IBHardWareInfo: map[string]collector.IBHardWareInfo{},
+		}
+		for i, fw := range tc.fws {
+			dev := "mlx5_" + string(rune('0'+i))
+			info.IBHardWareInfo[dev+"/p1"] = hca(dev, fw)
+		}
+		result, err := chk.Check(context.Background(), info)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if result.Status != tc.wantStatus || result.Device != tc.wantDevice {
+			t.Errorf("%s: got status=%s device=%q, want %s %q (%s)", name, result.Status, result.Device, tc.wantStatus, tc.wantDevice, result.Detail)
+		}
+		if result.Status == consts.StatusAbnormal && !strings.Contains(result.Detail, "psid:MT_0000000970") {
+			t.Errorf("%s: expected the PSID in the detail, got %s", name, result.Detail)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/infiniband/collector"
+	"github.com/scitix/sichek/components/infiniband/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+// IBCongestionChecker compares the CNP, pause frame and packet sequence
+// counters of the IB ports with their baseline. Rising CNPs and pause
+// frames mean the fabric is congested, while out of sequence packets mean
+// packets are lost, which on a lossless fabric points to link errors. Loss is
+// reported over congestion as it needs a hardware fix.
+type IBCongestionChecker struct {
+	name     string
+	spec     *config.InfinibandSpec
+	baseline *CounterBaseline
+}
+
+func NewIBCongestionChecker(specCfg *config.InfinibandSpec, baseline *CounterBaseline) (common.Checker, error) {
+	if baseline == nil {
+		return nil, fmt.Errorf("counter baseline is required by %s", config.CheckIBCongestion)
+	}
+	return &IBCongestionChecker{
+		name:     config.CheckIBCongestion,
+		spec:     specCfg,
+		baseline: baseline,
+	}, nil
+}
+
+func (c *IBCongestionChecker) Name() string {
+	return c.name
+}
+
+func (c *IBCongestionChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	infinibandInfo, ok := data.(*collector.InfinibandInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid InfinibandInfo type")
+	}
+
+	result := config.InfinibandCheckItems[c.name]
+	result.Status = consts.StatusNormal
+
+	infinibandInfo.RLock()
+	defer infinibandInfo.RUnlock()
+
+	keys := make([]string, 0, len(infinibandInfo.IBCounters))
+	for key := range infinibandInfo.IBCounters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var (
+		congestedPorts []string
+		lossPorts      []string
+		detail         string
+		compared       int
+	)
+	for _, key := range keys {
+		prev := c.baseline.Get(key, infinibandInfo.Time)
+		if prev == nil {
+			continue
+		}
+		compared++
+		prevCounters := prev.Counters
+		minutes := infinibandInfo.Time.Sub(prev.Time).Minutes()
+		currCounters := infinibandInfo.IBCounters[key]
+		thresholds := c.spec.ForDevice(infinibandInfo.IBHardWareInfo[key].IBDev).CongestionRateThresholds()
+		lossRates := counterRates(prevCounters, currCounters, thresholds.Loss, minutes)
+		for _, counter := range sortedKeys(lossRates) {
+			detail += fmt.Sprintf("%s packet loss: %s increased %.2f/min, threshold is %.2f/min\n", key, counter, lossRates[counter], thresholds.Loss[counter])
+		}
+		congestionRates := counterRates(prevCounters, currCounters, thresholds.Congestion, minutes)
+		for _, counter := range sortedKeys(congestionRates) {
+			detail += fmt.Sprintf("%s congestion: %s increased %.2f/min, threshold is %.2f/min\n", key, counter, congestionRates[counter], thresholds.Congestion[counter])
+		}
+		if len(lossRates) > 0 {
+			lossPorts = append(lossPorts, key)
+		} else if len(congestionRates) > 0 {
+			congestedPorts = append(congestedPorts, key)
+		}
+	}
+
+	if compared == 0 {
+		result.Curr = "no previous sample"
+		return &result, nil
+	}
+
+	switch {
+	case len(lossPorts) > 0:
+		result.Status = consts.StatusAbnormal
+		result.ErrorName = config.IBPacketLossErrorName
+		result.Suggestion = config.IBPacketLossSuggestion
+		result.Device = strings.Join(append(lossPorts, congestedPorts...), ",")
+		result.Curr = fmt.Sprintf("%d ports losing packets, %d ports congested", len(lossPorts), len(congestedPorts))
+		result.Detail = detail
+		logrus.WithField("component", "infiniband").Errorf("IB packet loss detected: %s", detail)
+	case len(congestedPorts) > 0:
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(congestedPorts, ",")
+		result.Curr = fmt.Sprintf("%d ports congested", len(congestedPorts))
+		result.Detail = detail
+		logrus.WithField("component", "infiniband").Warnf("IB fabric congestion detected: %s", detail)
+	default:
+		result.Curr = "OK"
+	}
+	return &result, nil
+}
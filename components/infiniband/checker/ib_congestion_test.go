@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/infiniband/collector"
+	"github.com/scitix/sichek/components/infiniband/config"
+	"github.com/scitix/sichek/consts"
+)
+
+func TestIBCongestionChecker(t *testing.T) {
+	now := time.Now()
+	prev := &collector.InfinibandInfo{
+		Time: now.Add(-time.Minute),
+		IBCounters: map[string]collector.IBCounters{
+			"mlx5_0/p1": {"np_cnp_sent": 1000, "rx_pause_ctrl_phy": 10, "out_of_sequence": 5},
+			"mlx5_1/p1": {"np_cnp_sent": 1000, "rx_pause_ctrl_phy": 10, "out_of_sequence": 5},
+			"mlx5_2/p1": {"np_cnp_sent": 1000, "rx_pause_ctrl_phy": 10, "out_of_sequence": 5, "packet_seq_err": 0},
+		},
+	}
+	curr := &collector.InfinibandInfo{
+		Time: now,
+		IBCounters: map[string]collector.IBCounters{
+			"mlx5_0/p1": {"np_cnp_sent": 2000, "rx_pause_ctrl_phy": 20, "out_of_sequence": 6},
+			"mlx5_1/p1": {"np_cnp_sent": 1000, "rx_pause_ctrl_phy": 100010, "out_of_sequence": 5},
+			"mlx5_2/p1": {"np_cnp_sent": 1000, "rx_pause_ctrl_phy": 10, "out_of_sequence": 5, "packet_seq_err": 50},
+		},
+	}
+
+	baseline := NewCounterBaseline(t.TempDir())
+	chk, err := NewIBCongestionChecker(&config.InfinibandSpec{}, baseline)
+	if err != nil {
+		t.Fatalf("NewIBCongestionChecker: %v", err)
+	}
+
+	result, err := chk.Check(context.Background(), prev)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if result.Status != consts.StatusNormal {
+		t.Errorf("first sample: expected normal, got %+v", result)
+	}
+
+	baseline.Record(prev)
+	result, err = chk.Check(context.Background(), curr)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if result.Status != consts.StatusAbnormal || result.ErrorName != config.IBPacketLossErrorName {
+		t.Fatalf("expected packet loss, got %+v", result)
+	}
+	if result.Device != "mlx5_2/p1,mlx5_1/p1" {
+		t.Errorf("unexpected devices %q", result.Device)
+	}
+
+	// without the lossy port only the congestion is reported
+	delete(curr.IBCounters, "mlx5_2/p1")
+	result, err = chk.Check(context.Background(), curr)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if result.Status != consts.StatusAbnormal || result.ErrorName != config.IBFabricCongestionErrorName || result.Device != "mlx5_1/p1" {
+		t.Fatalf("expected mlx5_1/p1 congested, got %+v", result)
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/infiniband/collector"
+	"github.com/scitix/sichek/components/infiniband/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+// IBCounterRateChecker compares the IB port counters with their baseline,
+// the previous sample persisted across daemon restarts, since the absolute
+// values only grow, and flags the ports whose error counters increase faster
+// than the spec thresholds (per minute). The counters found reset since the
+// baseline are reported in the detail and not rated.
+type IBCounterRateChecker struct {
+	name     string
+	spec     *config.InfinibandSpec
+	baseline *CounterBaseline
+}
+
+func NewIBCounterRateChecker(specCfg *config.InfinibandSpec, baseline *CounterBaseline) (common.Checker, error) {
+	if baseline == nil {
+		return nil, fmt.Errorf("counter baseline is required by %s", config.CheckIBCounterRate)
+	}
+	return &IBCounterRateChecker{
+		name:     config.CheckIBCounterRate,
+		spec:     specCfg,
+		baseline: baseline,
+	}, nil
+}
+
+func (c *IBCounterRateChecker) Name() string {
+	return c.name
+}
+
+func (c *IBCounterRateChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	infinibandInfo, ok := data.(*collector.InfinibandInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid InfinibandInfo type")
+	}
+
+	result := config.InfinibandCheckItems[c.name]
+	result.Status = consts.StatusNormal
+
+	infinibandInfo.RLock()
+	defer infinibandInfo.RUnlock()
+
+	keys := make([]string, 0, len(infinibandInfo.IBCounters))
+	for key := range infinibandInfo.IBCounters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var (
+		abnormalDevices []string
+		resetPorts      []string
+		detail          string
+		compared        int
+	)
+	for _, key := range keys {
+		prev := c.baseline.Get(key, infinibandInfo.Time)
+		if prev == nil {
+			continue
+		}
+		compared++
+		currCounters := infinibandInfo.IBCounters[key]
+		if resets := counterResets(prev.Counters, currCounters); len(resets) > 0 {
+			resetPorts = append(resetPorts, fmt.Sprintf("%s (%s)", key, formatResets(resets)))
+		}
+		minutes := infinibandInfo.Time.Sub(prev.Time).Minutes()
+		thresholds := c.spec.ForDevice(infinibandInfo.IBHardWareInfo[key].IBDev).RateThresholds()
+		rates := counterRates(prev.Counters, currCounters, thresholds, minutes)
+		if len(rates) == 0 {
+			continue
+		}
+		abnormalDevices = append(abnormalDevices, key)
+		for _, counter := range sortedKeys(rates) {
+			detail += fmt.Sprintf("%s %s increased %.2f/min, threshold is %.2f/min\n", key, counter, rates[counter], thresholds[counter])
+		}
+	}
+	if compared == 0 {
+		// first sample, nothing to compare with yet
+		result.Curr = "no previous sample"
+		return &result, nil
+	}
+	if len(resetPorts) > 0 {
+		detail += fmt.Sprintf("counters reset since the baseline, not rated: %s\n", strings.Join(resetPorts, "; "))
+	}
+
+	if len(abnormalDevices) > 0 {
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(abnormalDevices, ",")
+		result.Curr = fmt.Sprintf("%d ports abnormal", len(abnormalDevices))
+		result.Detail = detail
+		logrus.WithField("component", "infiniband").Errorf("IB counter rate exceeded: %s", detail)
+	} else {
+		result.Curr = "OK"
+		if len(resetPorts) > 0 {
+			result.Curr = fmt.Sprintf("OK, counters reset on %d ports", len(resetPorts))
+			result.Detail = detail
+		}
+	}
+	return &result, nil
+}
+
+// counterRates returns the per-minute rates of the counters that exceed their threshold.
+// A counter lower than in the previous sample was reset (e.g. driver reload) and is skipped.
+func counterRates(prev, curr collector.IBCounters, thresholds map[string]float64, minutes float64) map[string]float64 {
+	exceeded := make(map[string]float64)
+	for counter, threshold := range thresholds {
+		currVal, ok := curr[counter]
+		if !ok {
+			continue
+		}
+		prevVal, ok := prev[counter]
+		if !ok || currVal <= prevVal {
+			continue
+		}
+		rate := float64(currVal-prevVal) / minutes
+		if rate > threshold {
+			exceeded[counter] = rate
+		}
+	}
+	return exceeded
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/infiniband/collector"
+	"github.com/scitix/sichek/components/infiniband/config"
+	"github.com/scitix/sichek/consts"
+)
+
+func TestIBCounterRateChecker(t *testing.T) {
+	now := time.Now()
+	prev := &collector.InfinibandInfo{
+		Time: now.Add(-2 * time.Minute),
+		IBCounters: map[string]collector.IBCounters{
+			"mlx5_0/p1": {"symbol_error": 1000, "link_downed": 3, "port_rcv_errors": 5},
+			"mlx5_1/p1": {"symbol_error": 1000, "link_downed": 3, "port_rcv_errors": 5},
+		},
+	}
+	curr := &collector.InfinibandInfo{
+		Time: now,
+		IBCounters: map[string]collector.IBCounters{
+			// 10 symbol errors in 2 minutes stays below 10/min
+			"mlx5_0/p1": {"symbol_error": 1010, "link_downed": 3, "port_rcv_errors": 5},
+			"mlx5_1/p1": {"symbol_error": 1100, "link_downed": 4, "port_rcv_errors": 0},
+		},
+	}
+
+	baseline := NewCounterBaseline(t.TempDir())
+	chk, err := NewIBCounterRateChecker(&config.InfinibandSpec{}, baseline)
+	if err != nil {
+		t.Fatalf("NewIBCounterRateChecker: %v", err)
+	}
+
+	// first sample has nothing to compare with
+	result, err := chk.Check(context.Background(), prev)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if result.Status != consts.StatusNormal {
+		t.Errorf("first sample: expected normal, got %+v", result)
+	}
+
+	baseline.Record(prev)
+	result, err = chk.Check(context.Background(), curr)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if result.Status != consts.StatusAbnormal || result.Device != "mlx5_1/p1" {
+		t.Fatalf("expected mlx5_1/p1 abnormal, got %+v", result)
+	}
+	if !strings.Contains(result.Detail, "mlx5_1/p1 (port_rcv_errors 5 -> 0)") {
+		t.Errorf("expected the reset counter in the detail, got %q", result.Detail)
+	}
+	// the reset port_rcv_errors counter must not be reported
+	rates := counterRates(prev.IBCounters["mlx5_1/p1"], curr.IBCounters["mlx5_1/p1"], config.DefaultCounterRateThresholds, 2)
+	if len(rates) != 2 || rates["symbol_error"] != 50 || rates["link_downed"] != 0.5 {
+		t.Errorf("unexpected rates: %v", rates)
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/scitix/sichek/components/infiniband/collector"
 	"github.com/scitix/sichek/components/infiniband/config"
 	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/hostfs"
 	"github.com/sirupsen/logrus"
 )
 
@@ -56,7 +57,7 @@ func (c *IBDriverChecker) GetSpec() common.CheckerSpec {
 }
 
 func (c *IBDriverChecker) FindPCIDevicesByID(targetVendorID string, targetDeviceIDs []string) ([]string, error) {
-	pciDevicesPath := "/sys/bus/pci/devices"
+	pciDevicesPath := hostfs.Path("/sys/bus/pci/devices")
 	bdfList := make([]string, 0)
 
 	entries, err := os.ReadDir(pciDevicesPath)
@@ -0,0 +1,187 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/infiniband/collector"
+	"github.com/scitix/sichek/components/infiniband/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+const linkDownedCounter = "link_downed"
+
+// LinkFlapHistory keeps the link flaps seen on each IB port across health
+// checks. It is owned by the component so that the history survives the
+// checkers being rebuilt on a spec reload.
+type LinkFlapHistory struct {
+	mu    sync.Mutex
+	ports map[string]*portFlapState
+}
+
+type portFlapState struct {
+	linkDowned    uint64
+	hasLinkDowned bool
+	active        bool
+	flaps         []time.Time
+}
+
+func NewLinkFlapHistory() *LinkFlapHistory {
+	return &LinkFlapHistory{ports: make(map[string]*portFlapState)}
+}
+
+// Observe records the flaps of a port since its previous sample and returns
+// the flaps within window before now. A link that goes down bumps link_downed,
+// and an ACTIVE to down transition is counted as well in case the counter is
+// not exposed or was reset.
+func (h *LinkFlapHistory) Observe(port string, counters collector.IBCounters, portState string, now time.Time, window time.Duration) []time.Time {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	linkDowned, hasLinkDowned := counters[linkDownedCounter]
+	active := strings.Contains(portState, "ACTIVE")
+	state, seen := h.ports[port]
+	if !seen {
+		state = &portFlapState{}
+		h.ports[port] = state
+	} else {
+		flaps := 0
+		if hasLinkDowned && state.hasLinkDowned && linkDowned > state.linkDowned {
+			flaps = int(linkDowned - state.linkDowned)
+		}
+		if flaps == 0 && state.active && !active {
+			flaps = 1
+		}
+		for i := 0; i < flaps; i++ {
+			state.flaps = append(state.flaps, now)
+		}
+	}
+	state.linkDowned, state.hasLinkDowned, state.active = linkDowned, hasLinkDowned, active
+
+	kept := state.flaps[:0]
+	for _, t := range state.flaps {
+		if now.Sub(t) < window {
+			kept = append(kept, t)
+		}
+	}
+	state.flaps = kept
+	return append([]time.Time(nil), kept...)
+}
+
+// Prune forgets the ports not in present, e.g. an HCA that was removed or
+// renamed, so that the history does not grow with ports that no longer exist.
+func (h *LinkFlapHistory) Prune(present []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	keep := make(map[string]bool, len(present))
+	for _, port := range present {
+		keep[port] = true
+	}
+	for port := range h.ports {
+		if !keep[port] {
+			delete(h.ports, port)
+		}
+	}
+}
+
+// IBLinkFlapChecker reports the IB ports that went down more than the spec
+// allows within the flap window, which a single snapshot of the port state
+// cannot see for an intermittent link.
+type IBLinkFlapChecker struct {
+	name    string
+	spec    *config.InfinibandSpec
+	history *LinkFlapHistory
+}
+
+func NewIBLinkFlapChecker(specCfg *config.InfinibandSpec, history *LinkFlapHistory) (common.Checker, error) {
+	if history == nil {
+		return nil, fmt.Errorf("link flap history is required by %s", config.CheckIBLinkFlap)
+	}
+	return &IBLinkFlapChecker{
+		name:    config.CheckIBLinkFlap,
+		spec:    specCfg,
+		history: history,
+	}, nil
+}
+
+func (c *IBLinkFlapChecker) Name() string {
+	return c.name
+}
+
+func (c *IBLinkFlapChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	infinibandInfo, ok := data.(*collector.InfinibandInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid InfinibandInfo type")
+	}
+
+	result := config.InfinibandCheckItems[c.name]
+	result.Status = consts.StatusNormal
+
+	limit := c.spec.LinkFlapLimit()
+
+	infinibandInfo.RLock()
+	defer infinibandInfo.RUnlock()
+
+	keys := make([]string, 0, len(infinibandInfo.IBHardWareInfo))
+	for key := range infinibandInfo.IBHardWareInfo {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	c.history.Prune(keys)
+
+	var (
+		flappingPorts []string
+		detail        string
+		maxFlaps      int
+	)
+	for _, key := range keys {
+		hw := infinibandInfo.IBHardWareInfo[key]
+		devLimit := c.spec.ForDevice(hw.IBDev).LinkFlapLimit()
+		window := time.Duration(devLimit.WindowMinutes) * time.Minute
+		flaps := c.history.Observe(key, infinibandInfo.IBCounters[key], hw.PortState, infinibandInfo.Time, window)
+		if len(flaps) > maxFlaps {
+			maxFlaps = len(flaps)
+		}
+		if len(flaps) < devLimit.MaxFlaps {
+			continue
+		}
+		flappingPorts = append(flappingPorts, key)
+		times := make([]string, 0, len(flaps))
+		for _, t := range flaps {
+			times = append(times, t.Format(time.RFC3339))
+		}
+		detail += fmt.Sprintf("%s flapped %d times in the last %dm: %s\n", key, len(flaps), devLimit.WindowMinutes, strings.Join(times, ", "))
+	}
+
+	result.Spec = fmt.Sprintf("<%d flaps/%dm", limit.MaxFlaps, limit.WindowMinutes)
+	result.Curr = fmt.Sprintf("%d", maxFlaps)
+	if len(flappingPorts) > 0 {
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(flappingPorts, ",")
+		result.Detail = detail
+		logrus.WithField("component", "infiniband").Errorf("IB link flapping: %s", detail)
+	}
+	return &result, nil
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/infiniband/collector"
+	"github.com/scitix/sichek/components/infiniband/config"
+	"github.com/scitix/sichek/consts"
+)
+
+func TestIBLinkFlapChecker(t *testing.T) {
+	spec := &config.InfinibandSpec{LinkFlap: &config.LinkFlapSpec{MaxFlaps: 3, WindowMinutes: 10}}
+	chk, err := NewIBLinkFlapChecker(spec, NewLinkFlapHistory())
+	if err != nil {
+		t.Fatalf("NewIBLinkFlapChecker: %v", err)
+	}
+
+	start := time.Now()
+	samples := []struct {
+		minute     int
+		linkDowned uint64
+		state      string
+		wantStatus string
+	}{
+		{0, 5, "4: ACTIVE", consts.StatusNormal},
+		{1, 6, "4: ACTIVE", consts.StatusNormal},   // went down and came back between samples
+		{2, 6, "1: DOWN", consts.StatusNormal},     // down without the counter moving
+		{3, 7, "4: ACTIVE", consts.StatusAbnormal}, // third flap within 10 minutes
+		{14, 7, "4: ACTIVE", consts.StatusNormal},  // flaps aged out of the window
+	}
+	for _, sample := range samples {
+		info := &collector.InfinibandInfo{
+			Time: start.Add(time.Duration(sample.minute) * time.Minute),
+			IBHardWareInfo: map[string]collector.IBHardWareInfo{
+				"mlx5_0/p1": {IBDev: "mlx5_0", PortState: sample.state},
+				"mlx5_1/p1": {IBDev: "mlx5_1", PortState: "4: ACTIVE"},
+			},
+			IBCounters: map[string]collector.IBCounters{
+				"mlx5_0/p1": {"link_downed": sample.linkDowned},
+				"mlx5_1/p1": {"link_downed": 2},
+			},
+		}
+		result, err := chk.Check(context.Background(), info)
+		if err != nil {
+			t.Fatalf("minute %d: Check: %v", sample.minute, err)
+		}
+		if result.Status != sample.wantStatus {
+			t.Fatalf("minute %d: expected %s, got %+v", sample.minute, sample.wantStatus, result)
+		}
+		if result.Status == consts.StatusAbnormal && result.Device != "mlx5_0/p1" {
+			t.Errorf("minute %d: expected mlx5_0/p1 flapping, got %s", sample.minute, result.Device)
+		}
+	}
+}
+
+func TestLinkFlapHistoryPrune(t *testing.T) {
+	history := NewLinkFlapHistory()
+	now := time.Now()
+	history.Observe("mlx5_0/p1", collector.IBCounters{"link_downed": 1}, "4: ACTIVE", now, time.Hour)
+	history.Observe("mlx5_1/p1", collector.IBCounters{"link_downed": 1}, "4: ACTIVE", now, time.Hour)
+
+	history.Prune([]string{"mlx5_0/p1"})
+	if _, ok := history.ports["mlx5_1/p1"]; ok || len(history.ports) != 1 {
+		t.Errorf("expected only the present port kept, got %v", history.ports)
+	}
+}
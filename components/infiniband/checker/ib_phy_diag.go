@@ -0,0 +1,189 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/infiniband/collector"
+	"github.com/scitix/sichek/components/infiniband/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+// IBPhyDiagChecker reads the BER, the FEC corrected errors, the eye grade
+// and the module health of every active port with mlxlink, which the sysfs
+// counters do not expose, and reports the ports degrading before their link
+// flaps. mlxlink takes seconds per port, so it runs at most once per
+// interval of the spec and the result is reused in between.
+type IBPhyDiagChecker struct {
+	name string
+	spec *config.InfinibandSpec
+	// lookTool and query run mlxlink, replaced in tests
+	lookTool func(ctx context.Context) (string, error)
+	query    func(ctx context.Context, tool string, IBDev string, port int, eye bool) (*collector.PhyDiag, error)
+
+	mu      sync.Mutex
+	lastRun time.Time
+	last    *common.CheckerResult
+}
+
+func NewIBPhyDiagChecker(specCfg *config.InfinibandSpec) (common.Checker, error) {
+	return &IBPhyDiagChecker{
+		name:     config.CheckIBPhyDiag,
+		spec:     specCfg,
+		lookTool: collector.PhyDiagTool,
+		query:    collector.QueryPhyDiag,
+	}, nil
+}
+
+func (c *IBPhyDiagChecker) Name() string {
+	return c.name
+}
+
+func (c *IBPhyDiagChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	infinibandInfo, ok := data.(*collector.InfinibandInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid InfinibandInfo type")
+	}
+
+	result := config.InfinibandCheckItems[c.name]
+	result.Status = consts.StatusNormal
+	limit := c.spec.PhyDiagLimit()
+	if !limit.Enable {
+		result.Level = consts.LevelInfo
+		result.Curr = "N/A"
+		result.Detail = "mlxlink diagnosis is not enabled by phy_diag in the spec"
+		result.Suggestion = ""
+		return &result, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.last != nil && time.Since(c.lastRun) < time.Duration(limit.IntervalMinutes)*time.Minute {
+		last := *c.last
+		return &last, nil
+	}
+
+	tool, err := c.lookTool(ctx)
+	if err != nil {
+		result.Level = consts.LevelInfo
+		result.Curr = "N/A"
+		result.Detail = err.Error()
+		result.Suggestion = "Install MFT or mstflint to read the BER of the ports"
+		return &result, nil
+	}
+
+	infinibandInfo.RLock()
+	keys := make([]string, 0, len(infinibandInfo.IBHardWareInfo))
+	ports := make(map[string]collector.IBHardWareInfo, len(infinibandInfo.IBHardWareInfo))
+	for key, hw := range infinibandInfo.IBHardWareInfo {
+		if !strings.Contains(hw.PhyState, "LinkUp") {
+			continue
+		}
+		keys = append(keys, key)
+		ports[key] = hw
+	}
+	infinibandInfo.RUnlock()
+	sort.Strings(keys)
+	if len(keys) == 0 {
+		result.Curr = "N/A"
+		result.Detail = "No port with a physical link"
+		return &result, nil
+	}
+
+	var (
+		failedPorts []string
+		details     []string
+		queried     int
+	)
+	for _, key := range keys {
+		hw := ports[key]
+		devLimit := c.spec.ForDevice(hw.IBDev).PhyDiagLimit()
+		diag, err := c.query(ctx, tool, hw.IBDev, hw.Port, devLimit.MinGrade > 0)
+		if err != nil {
+			logrus.WithField("component", "infiniband").Warnf("%s of %s failed: %v", tool, key, err)
+			details = append(details, fmt.Sprintf("%s: %s failed: %v", key, tool, err))
+			continue
+		}
+		queried++
+		reasons := phyDiagReasons(diag, devLimit)
+		if len(reasons) == 0 {
+			details = append(details, fmt.Sprintf("%s: effective BER %.1e, raw BER %.1e", key, diag.EffectiveBER, diag.RawBER))
+			continue
+		}
+		detail := fmt.Sprintf("%s: %s", key, strings.Join(reasons, ", "))
+		if diag.Recommendation != "" && !strings.HasPrefix(diag.Recommendation, "No issue") {
+			detail += fmt.Sprintf(" (%s: %s)", tool, diag.Recommendation)
+		}
+		failedPorts = append(failedPorts, key)
+		details = append(details, detail)
+	}
+
+	result.Spec = fmt.Sprintf("effective BER <= %.0e, raw BER <= %.0e", limit.MaxEffectiveBER, limit.MaxRawBER)
+	result.Curr = fmt.Sprintf("%d/%d ports degraded", len(failedPorts), queried)
+	result.Detail = strings.Join(details, "\n")
+	if len(failedPorts) > 0 {
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(failedPorts, ",")
+		logrus.WithField("component", "infiniband").Warnf("IB ports with a degraded physical layer: %s", result.Detail)
+	} else if queried == 0 {
+		result.Level = consts.LevelInfo
+		result.Curr = "N/A"
+		result.Suggestion = ""
+	}
+
+	c.lastRun = time.Now()
+	last := result
+	c.last = &last
+	return &result, nil
+}
+
+// phyDiagReasons tells why the physical layer of a port fails the spec.
+func phyDiagReasons(diag *collector.PhyDiag, limit *config.PhyDiagSpec) []string {
+	var reasons []string
+	if diag.EffectiveBER > limit.MaxEffectiveBER {
+		reasons = append(reasons, fmt.Sprintf("effective BER %.1e > %.0e (%d uncorrected errors)", diag.EffectiveBER, limit.MaxEffectiveBER, diag.EffectiveErrors))
+	}
+	if diag.RawBER > limit.MaxRawBER {
+		reasons = append(reasons, fmt.Sprintf("raw BER %.1e > %.0e (%d errors corrected by the FEC)", diag.RawBER, limit.MaxRawBER, diag.RawErrors))
+	}
+	if limit.MinGrade > 0 && len(diag.Grades) > 0 {
+		if grade := slices.Min(diag.Grades); grade < limit.MinGrade {
+			reasons = append(reasons, fmt.Sprintf("eye grade %g < %g", grade, limit.MinGrade))
+		}
+	}
+	maxTemp := limit.MaxModuleTempC
+	if maxTemp <= 0 {
+		maxTemp = diag.TempHighAlarm
+	}
+	if maxTemp > 0 && diag.ModuleTemp > maxTemp {
+		reasons = append(reasons, fmt.Sprintf("module temperature %gC > %gC", diag.ModuleTemp, maxTemp))
+	}
+	if diag.RxPowerLowAlarm != 0 && len(diag.RxPower) > 0 {
+		if rx := slices.Min(diag.RxPower); rx < diag.RxPowerLowAlarm {
+			reasons = append(reasons, fmt.Sprintf("rx power %gdBm < %gdBm low alarm", rx, diag.RxPowerLowAlarm))
+		}
+	}
+	return reasons
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/scitix/sichek/components/infiniband/collector"
+	"github.com/scitix/sichek/components/infiniband/config"
+	"github.com/scitix/sichek/consts"
+)
+
+func TestIBPhyDiagChecker(t *testing.T) {
+	info := &collector.InfinibandInfo{
+		IBHardWareInfo: map[string]collector.IBHardWareInfo{
+			"mlx5_0/p1": ibPort("mlx5_0", "4: ACTIVE", "0x1a", "0x1"),
+			"mlx5_1/p1": ibPort("mlx5_1", "4: ACTIVE", "0x1b", "0x1"),
+			"mlx5_2/p1": ibPort("mlx5_2", "4: ACTIVE", "0x1c", "0x1"),
+			"mlx5_3/p1": {IBDev: "mlx5_3", Port: 1, PhyState: "3: Disabled"},
+		},
+	}
+
+	chk, _ := NewIBPhyDiagChecker(&config.InfinibandSpec{})
+	result, err := chk.Check(context.Background(), info)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if result.Status != consts.StatusNormal || result.Curr != "N/A" {
+		t.Fatalf("expected the checker to be disabled by default, got %+v", result)
+	}
+
+	spec := &config.InfinibandSpec{PhyDiag: &config.PhyDiagSpec{Enable: true, MinGrade: 5000}}
+	chk, _ = NewIBPhyDiagChecker(spec)
+	c := chk.(*IBPhyDiagChecker)
+	c.lookTool = func(context.Context) (string, error) { return "mlxlink", nil }
+	queries := 0
+	c.query = func(ctx context.Context, tool, IBDev string, port int, eye bool) (*collector.PhyDiag, error) {
+		queries++
+		if !eye {
+			t.Errorf("expected the eye grade to be queried with min_grade set")
+		}
+		switch IBDev {
+		case "mlx5_0":
+			return &collector.PhyDiag{EffectiveBER: 1e-255, RawBER: 1e-11, Grades: []float64{13000, 12800}, ModuleTemp: 50, TempHighAlarm: 75}, nil
+		case "mlx5_1":
+			return &collector.PhyDiag{EffectiveBER: 3e-10, EffectiveErrors: 42, RawBER: 2e-4, RawErrors: 90000, Grades: []float64{13000, 3000},
+				ModuleTemp: 80, TempHighAlarm: 75, RxPower: []float64{1.2, -12.5}, RxPowerLowAlarm: -10.4, Recommendation: "Bad signal integrity"}, nil
+		}
+		return nil, errors.New("device busy")
+	}
+	result, err = chk.Check(context.Background(), info)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if result.Status != consts.StatusAbnormal || result.Device != "mlx5_1/p1" || result.Curr != "1/2 ports degraded" {
+		t.Fatalf("expected mlx5_1/p1 degraded, got %+v", result)
+	}
+	for _, want := range []string{"effective BER 3.0e-10 > 1e-12", "raw BER 2.0e-04 > 1e-05", "eye grade 3000 < 5000", "module temperature 80C > 75C",
+		"rx power -12.5dBm < -10.4dBm low alarm", "(mlxlink: Bad signal integrity)", "mlx5_2/p1: mlxlink failed: device busy"} {
+		if !strings.Contains(result.Detail, want) {
+			t.Errorf("detail %q does not contain %q", result.Detail, want)
+		}
+	}
+
+	// the result is reused within the interval
+	if _, err := chk.Check(context.Background(), info); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if queries != 3 {
+		t.Errorf("expected mlxlink to run once per port within the interval, ran %d times", queries)
+	}
+}
+
+func TestIBPhyDiagCheckerWithoutTool(t *testing.T) {
+	chk, _ := NewIBPhyDiagChecker(&config.InfinibandSpec{PhyDiag: &config.PhyDiagSpec{Enable: true}})
+	c := chk.(*IBPhyDiagChecker)
+	c.lookTool = func(context.Context) (string, error) {
+		return "", errors.New("neither mlxlink nor mstlink is installed")
+	}
+	result, err := chk.Check(context.Background(), &collector.InfinibandInfo{})
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if result.Status != consts.StatusNormal || result.Level != consts.LevelInfo || result.Curr != "N/A" {
+		t.Fatalf("expected N/A without mlxlink, got %+v", result)
+	}
+}
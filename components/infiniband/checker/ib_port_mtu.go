@@ -0,0 +1,128 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/infiniband/collector"
+	"github.com/scitix/sichek/components/infiniband/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+// IBPortMTUChecker reports the active ports whose MTU is below the one of
+// the fabric: the active MTU of an InfiniBand port, the MTU of the netdev of
+// a RoCE port. The traffic still flows, only the NCCL bandwidth drops.
+type IBPortMTUChecker struct {
+	name string
+	spec *config.InfinibandSpec
+}
+
+func NewIBPortMTUChecker(specCfg *config.InfinibandSpec) (common.Checker, error) {
+	return &IBPortMTUChecker{
+		name: config.CheckIBPortMTU,
+		spec: specCfg,
+	}, nil
+}
+
+func (c *IBPortMTUChecker) Name() string {
+	return c.name
+}
+
+func (c *IBPortMTUChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	infinibandInfo, ok := data.(*collector.InfinibandInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid InfinibandInfo type")
+	}
+
+	result := config.InfinibandCheckItems[c.name]
+	result.Status = consts.StatusNormal
+
+	infinibandInfo.RLock()
+	keys := make([]string, 0, len(infinibandInfo.IBHardWareInfo))
+	ports := make(map[string]collector.IBHardWareInfo, len(infinibandInfo.IBHardWareInfo))
+	for key, hw := range infinibandInfo.IBHardWareInfo {
+		// the ports without a link are reported by the state checkers
+		if !strings.Contains(hw.PortState, "ACTIVE") {
+			continue
+		}
+		keys = append(keys, key)
+		ports[key] = hw
+	}
+	infinibandInfo.RUnlock()
+	sort.Strings(keys)
+	if len(keys) == 0 {
+		result.Curr = "N/A"
+		result.Detail = "No active port"
+		return &result, nil
+	}
+
+	var failedPorts, details, specs []string
+	seenSpecs := make(map[string]bool)
+	for _, key := range keys {
+		hw := ports[key]
+		mtu := c.spec.ForDevice(hw.IBDev).ExpectedMTU()
+		reason, spec := portMTUReason(hw, mtu)
+		if spec != "" && !seenSpecs[spec] {
+			seenSpecs[spec] = true
+			specs = append(specs, spec)
+		}
+		if reason == "" {
+			continue
+		}
+		failedPorts = append(failedPorts, key)
+		details = append(details, fmt.Sprintf("%s: %s", key, reason))
+	}
+
+	sort.Strings(specs)
+	result.Spec = strings.Join(specs, ", ")
+	if len(failedPorts) > 0 {
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(failedPorts, ",")
+		result.Curr = fmt.Sprintf("%d/%d ports with a wrong MTU", len(failedPorts), len(keys))
+		result.Detail = strings.Join(details, "\n")
+		logrus.WithField("component", "infiniband").Warnf("ports with a wrong MTU: %s", result.Detail)
+		return &result, nil
+	}
+	result.Curr = fmt.Sprintf("%d/%d ports", len(keys), len(keys))
+	return &result, nil
+}
+
+// portMTUReason compares the MTU of a port with the spec of its link layer,
+// an unknown MTU is not reported.
+func portMTUReason(hw collector.IBHardWareInfo, mtu *config.MTUSpec) (reason, spec string) {
+	switch hw.LinkLayer {
+	case "InfiniBand":
+		spec = fmt.Sprintf("IB active MTU %d", mtu.IB)
+		if hw.ActiveMTU > 0 && hw.ActiveMTU != mtu.IB {
+			reason = fmt.Sprintf("active MTU is %d, expected %d", hw.ActiveMTU, mtu.IB)
+		}
+	case "Ethernet":
+		spec = fmt.Sprintf("RoCE netdev MTU %d", mtu.RoCE)
+		if hw.NetMTU > 0 && hw.NetMTU != mtu.RoCE {
+			reason = fmt.Sprintf("MTU of %s is %d, expected %d", hw.NetDev, hw.NetMTU, mtu.RoCE)
+			if hw.ActiveMTU > 0 {
+				reason += fmt.Sprintf(", RDMA active MTU %d", hw.ActiveMTU)
+			}
+		}
+	}
+	return reason, spec
+}
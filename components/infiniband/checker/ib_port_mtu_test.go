@@ -0,0 +1,60 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/scitix/sichek/components/infiniband/collector"
+	"github.com/scitix/sichek/components/infiniband/config"
+	"github.com/scitix/sichek/consts"
+)
+
+func TestIBPortMTUChecker(t *testing.T) {
+	info := &collector.InfinibandInfo{
+		IBHardWareInfo: map[string]collector.IBHardWareInfo{
+			"mlx5_0/p1": {IBDev: "mlx5_0", Port: 1, LinkLayer: "InfiniBand", PortState: "4: ACTIVE", ActiveMTU: 4096},
+			"mlx5_1/p1": {IBDev: "mlx5_1", Port: 1, LinkLayer: "InfiniBand", PortState: "4: ACTIVE", ActiveMTU: 2048},
+			"mlx5_2/p1": {IBDev: "mlx5_2", Port: 1, LinkLayer: "Ethernet", PortState: "4: ACTIVE", NetDev: "eth2", NetMTU: 1500, ActiveMTU: 1024},
+			"mlx5_3/p1": {IBDev: "mlx5_3", Port: 1, LinkLayer: "Ethernet", PortState: "4: ACTIVE", NetDev: "eth3", NetMTU: 9000, ActiveMTU: 4096},
+			"mlx5_4/p1": {IBDev: "mlx5_4", Port: 1, LinkLayer: "InfiniBand", PortState: "1: DOWN", ActiveMTU: 256},
+		},
+	}
+	chk, err := NewIBPortMTUChecker(&config.InfinibandSpec{})
+	if err != nil {
+		t.Fatalf("NewIBPortMTUChecker: %v", err)
+	}
+	result, err := chk.Check(context.Background(), info)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if result.Status != consts.StatusAbnormal || result.Device != "mlx5_1/p1,mlx5_2/p1" {
+		t.Fatalf("expected mlx5_1/p1 and mlx5_2/p1 abnormal, got %+v", result)
+	}
+	if !strings.Contains(result.Detail, "MTU of eth2 is 1500, expected 9000") {
+		t.Errorf("unexpected detail %q", result.Detail)
+	}
+
+	// a RoCE fabric without jumbo frames
+	spec := &config.InfinibandSpec{MTU: &config.MTUSpec{RoCE: 1500}}
+	chk, _ = NewIBPortMTUChecker(spec)
+	result, _ = chk.Check(context.Background(), info)
+	if result.Device != "mlx5_1/p1,mlx5_3/p1" {
+		t.Errorf("expected mlx5_1/p1 and mlx5_3/p1 abnormal, got %q", result.Device)
+	}
+}
@@ -0,0 +1,391 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/infiniband/collector"
+	"github.com/scitix/sichek/components/infiniband/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+// ibpingLossRegexp and ibpingRTTRegexp match the summary of ibping, e.g.
+// "5 packets transmitted, 5 received, 0% packet loss, time 4 ms" and
+// "rtt min/avg/max = 0.103/0.148/0.215 ms".
+var (
+	ibpingLossRegexp = regexp.MustCompile(`(\d+) packets transmitted, (\d+) received`)
+	ibpingRTTRegexp  = regexp.MustCompile(`rtt min/avg/max = [\d.]+/([\d.]+)/[\d.]+ ms`)
+
+	icmpProbeID atomic.Uint32
+)
+
+// probeStats is the outcome of the probes of a target, Err is set when the
+// probes could not be sent at all.
+type probeStats struct {
+	Sent     int
+	Received int
+	AvgRTT   time.Duration
+	Err      error
+}
+
+func (s probeStats) LossPercent() float64 {
+	if s.Sent == 0 {
+		return 100
+	}
+	return float64(s.Sent-s.Received) * 100 / float64(s.Sent)
+}
+
+// probeTarget is a target probed from an RDMA interface.
+type probeTarget struct {
+	IBDev  string
+	Port   int
+	NetDev string
+	// Kind is gateway, rping or ibping
+	Kind    string
+	Address string
+}
+
+func (t probeTarget) String() string {
+	dev := t.IBDev
+	if t.NetDev != "" {
+		dev = fmt.Sprintf("%s(%s)", t.IBDev, t.NetDev)
+	}
+	return fmt.Sprintf("%s %s %s", dev, t.Kind, t.Address)
+}
+
+// IBProbeChecker actively probes the gateway of each RoCE interface, and the
+// rping/ibping peers of the spec, measuring the packet loss and RTT that the
+// collected gateway alone cannot tell.
+type IBProbeChecker struct {
+	name string
+	spec *config.InfinibandSpec
+	// probe sends the probes of a target, replaced in tests
+	probe func(ctx context.Context, target probeTarget, limit *config.ProbeSpec) probeStats
+}
+
+func NewIBProbeChecker(specCfg *config.InfinibandSpec) (common.Checker, error) {
+	return &IBProbeChecker{
+		name:  config.CheckIBProbe,
+		spec:  specCfg,
+		probe: runProbe,
+	}, nil
+}
+
+func (c *IBProbeChecker) Name() string {
+	return c.name
+}
+
+func (c *IBProbeChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	infinibandInfo, ok := data.(*collector.InfinibandInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid InfinibandInfo type")
+	}
+
+	result := config.InfinibandCheckItems[c.name]
+	result.Status = consts.StatusNormal
+
+	limit := c.spec.ProbeLimit()
+	infinibandInfo.RLock()
+	targets := probeTargets(infinibandInfo.IBHardWareInfo, limit)
+	infinibandInfo.RUnlock()
+	if len(targets) == 0 {
+		result.Detail = "No gateway or peer to probe"
+		return &result, nil
+	}
+
+	stats := make([]probeStats, len(targets))
+	var wg sync.WaitGroup
+	for i := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stats[i] = c.probe(ctx, targets[i], limit)
+		}()
+	}
+	wg.Wait()
+
+	var (
+		detail    []string
+		failedDev []string
+		failed    = make(map[string]bool)
+	)
+	for i, target := range targets {
+		reason := probeReason(stats[i], limit)
+		if reason == "" {
+			detail = append(detail, fmt.Sprintf("%s: %.0f%% loss, avg rtt %s", target, stats[i].LossPercent(), stats[i].AvgRTT))
+			continue
+		}
+		logrus.WithFields(logrus.Fields{
+			"checker": c.Name(),
+			"target":  target.String(),
+		}).Warnf("RDMA probe failed: %s", reason)
+		detail = append(detail, fmt.Sprintf("%s: %s", target, reason))
+		if !failed[target.IBDev] {
+			failed[target.IBDev] = true
+			failedDev = append(failedDev, target.IBDev)
+		}
+	}
+	result.Detail = strings.Join(detail, "\n")
+	result.Curr = fmt.Sprintf("%d/%d targets unreachable", len(targets)-countReachable(stats, limit), len(targets))
+	if len(failedDev) > 0 {
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(failedDev, ",")
+	}
+	return &result, nil
+}
+
+// probeTargets lists the gateway of every RoCE interface with an IPv4 gateway,
+// plus the peers of the spec, sorted for a stable detail.
+func probeTargets(hws map[string]collector.IBHardWareInfo, limit *config.ProbeSpec) []probeTarget {
+	var targets []probeTarget
+	for _, hw := range hws {
+		switch hw.LinkLayer {
+		case "Ethernet":
+			if net.ParseIP(hw.PFGW).To4() != nil {
+				targets = append(targets, probeTarget{IBDev: hw.IBDev, Port: hw.Port, NetDev: hw.NetDev, Kind: "gateway", Address: hw.PFGW})
+			}
+			if limit.RPingPeer != "" {
+				targets = append(targets, probeTarget{IBDev: hw.IBDev, Port: hw.Port, NetDev: hw.NetDev, Kind: "rping", Address: limit.RPingPeer})
+			}
+		case "InfiniBand":
+			if limit.IBPingPeer != "" {
+				targets = append(targets, probeTarget{IBDev: hw.IBDev, Port: hw.Port, Kind: "ibping", Address: limit.IBPingPeer})
+			}
+		}
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].IBDev != targets[j].IBDev {
+			return targets[i].IBDev < targets[j].IBDev
+		}
+		if targets[i].Port != targets[j].Port {
+			return targets[i].Port < targets[j].Port
+		}
+		return targets[i].Kind < targets[j].Kind
+	})
+	return targets
+}
+
+// probeReason returns why the probes of a target fail the spec, or "" if they pass.
+func probeReason(stats probeStats, limit *config.ProbeSpec) string {
+	switch {
+	case stats.Err != nil:
+		return fmt.Sprintf("probe failed: %v", stats.Err)
+	case stats.Received == 0:
+		return fmt.Sprintf("unreachable, %d/%d probes lost", stats.Sent, stats.Sent)
+	case stats.LossPercent() > limit.MaxLossPercent:
+		return fmt.Sprintf("%.0f%% loss > %g%%", stats.LossPercent(), limit.MaxLossPercent)
+	case limit.MaxRTTMs > 0 && float64(stats.AvgRTT)/float64(time.Millisecond) > limit.MaxRTTMs:
+		return fmt.Sprintf("avg rtt %s > %gms", stats.AvgRTT, limit.MaxRTTMs)
+	}
+	return ""
+}
+
+func countReachable(stats []probeStats, limit *config.ProbeSpec) int {
+	reachable := 0
+	for _, s := range stats {
+		if probeReason(s, limit) == "" {
+			reachable++
+		}
+	}
+	return reachable
+}
+
+func runProbe(ctx context.Context, target probeTarget, limit *config.ProbeSpec) probeStats {
+	timeout := time.Duration(limit.TimeoutMs) * time.Millisecond
+	switch target.Kind {
+	case "gateway":
+		src, err := interfaceIPv4(target.NetDev)
+		if err != nil {
+			return probeStats{Err: err}
+		}
+		return icmpProbe(src, target.Address, limit.Count, timeout)
+	case "rping":
+		src, err := interfaceIPv4(target.NetDev)
+		if err != nil {
+			return probeStats{Err: err}
+		}
+		return rpingProbe(ctx, src, target.Address, limit.Count, timeout)
+	case "ibping":
+		return ibpingProbe(ctx, target.IBDev, target.Port, target.Address, limit.Count, timeout)
+	}
+	return probeStats{Err: fmt.Errorf("unknown probe %s", target.Kind)}
+}
+
+// interfaceIPv4 returns the IPv4 address of netDev, the probes are sent from
+// it so that the policy routing of the interface applies.
+func interfaceIPv4(netDev string) (string, error) {
+	iface, err := net.InterfaceByName(netDev)
+	if err != nil {
+		return "", err
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return "", err
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+			return ipNet.IP.String(), nil
+		}
+	}
+	return "", fmt.Errorf("interface %s has no IPv4 address", netDev)
+}
+
+// icmpProbe sends count ICMP echo requests from src to dst one after the
+// other, falling back to unprivileged ICMP sockets when not root.
+func icmpProbe(src, dst string, count int, timeout time.Duration) probeStats {
+	network, sendTo := "ip4:icmp", net.Addr(&net.IPAddr{IP: net.ParseIP(dst)})
+	conn, err := icmp.ListenPacket(network, src)
+	if err != nil {
+		network, sendTo = "udp4", &net.UDPAddr{IP: net.ParseIP(dst)}
+		if conn, err = icmp.ListenPacket(network, src); err != nil {
+			return probeStats{Err: fmt.Errorf("icmp listen on %s failed: %w", src, err)}
+		}
+	}
+	defer conn.Close()
+
+	// the id tells apart the probes sent to the same gateway from several interfaces
+	id := int(icmpProbeID.Add(1)) & 0xffff
+	stats := probeStats{}
+	var total time.Duration
+	reply := make([]byte, 1500)
+	for seq := 1; seq <= count; seq++ {
+		msg := icmp.Message{
+			Type: ipv4.ICMPTypeEcho,
+			Body: &icmp.Echo{ID: id, Seq: seq, Data: []byte("sichek-probe")},
+		}
+		msgBytes, err := msg.Marshal(nil)
+		if err != nil {
+			return probeStats{Err: err}
+		}
+		start := time.Now()
+		if _, err := conn.WriteTo(msgBytes, sendTo); err != nil {
+			return probeStats{Err: fmt.Errorf("icmp write to %s failed: %w", dst, err)}
+		}
+		stats.Sent++
+		deadline := start.Add(timeout)
+		for {
+			if err := conn.SetReadDeadline(deadline); err != nil {
+				return probeStats{Err: err}
+			}
+			n, peer, err := conn.ReadFrom(reply)
+			if err != nil {
+				break // timed out, the probe is lost
+			}
+			// the raw socket sees the replies to the probes of the other interfaces too
+			if !addrIs(peer, dst) {
+				continue
+			}
+			parsed, err := icmp.ParseMessage(1, reply[:n])
+			if err != nil || parsed.Type != ipv4.ICMPTypeEchoReply {
+				continue
+			}
+			// the kernel sets the id of unprivileged sockets, which only get their own replies
+			if echo, ok := parsed.Body.(*icmp.Echo); ok && echo.Seq == seq && (echo.ID == id || network == "udp4") {
+				stats.Received++
+				total += time.Since(start)
+				break
+			}
+		}
+	}
+	if stats.Received > 0 {
+		stats.AvgRTT = total / time.Duration(stats.Received)
+	}
+	return stats
+}
+
+func addrIs(addr net.Addr, ip string) bool {
+	switch a := addr.(type) {
+	case *net.IPAddr:
+		return a.IP.String() == ip
+	case *net.UDPAddr:
+		return a.IP.String() == ip
+	}
+	return false
+}
+
+// rpingProbe runs rping clients against peer, each connecting over rdma-cm
+// from src, since rping has no loss statistics of its own.
+func rpingProbe(ctx context.Context, src, peer string, count int, timeout time.Duration) probeStats {
+	stats := probeStats{}
+	var total time.Duration
+	for i := 0; i < count; i++ {
+		probeCtx, cancel := context.WithTimeout(ctx, timeout)
+		start := time.Now()
+		_, err := utils.ExecCommand(probeCtx, "rping", "-c", "-a", peer, "-I", src, "-C", "1")
+		cancel()
+		stats.Sent++
+		if err != nil {
+			logrus.WithField("component", "infiniband").Debugf("rping %s from %s failed: %v", peer, src, err)
+			continue
+		}
+		stats.Received++
+		total += time.Since(start)
+	}
+	if stats.Received > 0 {
+		stats.AvgRTT = total / time.Duration(stats.Received)
+	}
+	return stats
+}
+
+func ibpingProbe(ctx context.Context, ibDev string, port int, lid string, count int, timeout time.Duration) probeStats {
+	if port == 0 {
+		port = 1
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, time.Duration(count+1)*timeout)
+	defer cancel()
+	output, err := utils.ExecCommand(probeCtx, "ibping", "-C", ibDev, "-P", strconv.Itoa(port),
+		"-c", strconv.Itoa(count), "-t", strconv.Itoa(int(timeout/time.Millisecond)), lid)
+	stats, parseErr := parseIBPing(string(output))
+	if parseErr != nil {
+		if err != nil {
+			return probeStats{Err: fmt.Errorf("ibping failed: %w", err)}
+		}
+		return probeStats{Err: parseErr}
+	}
+	return stats
+}
+
+// parseIBPing parses the summary printed by ibping.
+func parseIBPing(output string) (probeStats, error) {
+	match := ibpingLossRegexp.FindStringSubmatch(output)
+	if match == nil {
+		return probeStats{}, fmt.Errorf("no ibping statistics in output")
+	}
+	stats := probeStats{}
+	stats.Sent, _ = strconv.Atoi(match[1])
+	stats.Received, _ = strconv.Atoi(match[2])
+	if rtt := ibpingRTTRegexp.FindStringSubmatch(output); rtt != nil {
+		if ms, err := strconv.ParseFloat(rtt[1], 64); err == nil {
+			stats.AvgRTT = time.Duration(ms * float64(time.Millisecond))
+		}
+	}
+	return stats, nil
+}
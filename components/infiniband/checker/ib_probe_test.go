@@ -0,0 +1,121 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/infiniband/collector"
+	"github.com/scitix/sichek/components/infiniband/config"
+	"github.com/scitix/sichek/consts"
+)
+
+func TestIBProbeChecker(t *testing.T) {
+	spec := &config.InfinibandSpec{Probe: &config.ProbeSpec{MaxRTTMs: 2, RPingPeer: "10.0.9.9"}}
+	chk, err := NewIBProbeChecker(spec)
+	if err != nil {
+		t.Fatalf("NewIBProbeChecker: %v", err)
+	}
+	probed := make(chan probeTarget, 10)
+	chk.(*IBProbeChecker).probe = func(ctx context.Context, target probeTarget, limit *config.ProbeSpec) probeStats {
+		probed <- target
+		switch {
+		case target.IBDev == "mlx5_1" && target.Kind == "gateway":
+			return probeStats{Sent: limit.Count, Received: 0}
+		case target.IBDev == "mlx5_2":
+			return probeStats{Sent: limit.Count, Received: limit.Count, AvgRTT: 5 * time.Millisecond}
+		}
+		return probeStats{Sent: limit.Count, Received: limit.Count, AvgRTT: 100 * time.Microsecond}
+	}
+
+	info := &collector.InfinibandInfo{
+		IBHardWareInfo: map[string]collector.IBHardWareInfo{
+			"mlx5_0/p1": {IBDev: "mlx5_0", NetDev: "eth0", LinkLayer: "Ethernet", PFGW: "10.0.0.1"},
+			"mlx5_1/p1": {IBDev: "mlx5_1", NetDev: "eth1", LinkLayer: "Ethernet", PFGW: "10.0.1.1"},
+			"mlx5_2/p1": {IBDev: "mlx5_2", NetDev: "eth2", LinkLayer: "Ethernet", PFGW: "IPV6"},
+			"mlx5_3/p1": {IBDev: "mlx5_3", LinkLayer: "InfiniBand"},
+		},
+	}
+	result, err := chk.Check(context.Background(), info)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	close(probed)
+	// a gateway and the rping peer for mlx5_0 and mlx5_1, only the peer for the IPv6 mlx5_2
+	if len(probed) != 5 {
+		t.Errorf("expected 5 probes, got %d", len(probed))
+	}
+	if result.Status != consts.StatusAbnormal || result.Device != "mlx5_1,mlx5_2" {
+		t.Fatalf("expected mlx5_1 and mlx5_2 abnormal, got %s on %q: %s", result.Status, result.Device, result.Detail)
+	}
+	for _, want := range []string{"mlx5_1(eth1) gateway 10.0.1.1: unreachable, 5/5 probes lost", "mlx5_2(eth2) rping 10.0.9.9: avg rtt 5ms > 2ms"} {
+		if !strings.Contains(result.Detail, want) {
+			t.Errorf("expected %q in detail, got %s", want, result.Detail)
+		}
+	}
+}
+
+func TestProbeReason(t *testing.T) {
+	limit := config.DefaultProbe
+	cases := []struct {
+		stats probeStats
+		want  string
+	}{
+		{probeStats{Sent: 5, Received: 5}, ""},
+		{probeStats{Sent: 5, Received: 4}, ""},
+		{probeStats{Sent: 5, Received: 3}, "40% loss > 20%"},
+		{probeStats{Sent: 5}, "unreachable"},
+	}
+	for _, tc := range cases {
+		got := probeReason(tc.stats, limit)
+		if (tc.want == "") != (got == "") || !strings.Contains(got, tc.want) {
+			t.Errorf("%+v: expected %q, got %q", tc.stats, tc.want, got)
+		}
+	}
+}
+
+func TestParseIBPing(t *testing.T) {
+	output := `Pong from node-2.(none) (Lid 12): time 0.146 ms
+Pong from node-2.(none) (Lid 12): time 0.120 ms
+
+--- node-2.(none) (Lid 12) ibping statistics ---
+3 packets transmitted, 2 received, 33% packet loss, time 3000 ms
+rtt min/avg/max = 0.120/0.133/0.146 ms
+`
+	stats, err := parseIBPing(output)
+	if err != nil {
+		t.Fatalf("parseIBPing: %v", err)
+	}
+	if stats.Sent != 3 || stats.Received != 2 || stats.AvgRTT != 133*time.Microsecond {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if _, err := parseIBPing("ibwarn: [1234] mad_rpc_open_port: can't open UMAD port"); err == nil {
+		t.Error("expected an error without statistics")
+	}
+}
+
+func TestICMPProbeLoopback(t *testing.T) {
+	stats := icmpProbe("127.0.0.1", "127.0.0.1", 2, time.Second)
+	if stats.Err != nil {
+		t.Skipf("icmp sockets not permitted: %v", stats.Err)
+	}
+	if stats.Received != 2 {
+		t.Errorf("expected the loopback to answer, got %+v", stats)
+	}
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/infiniband/collector"
+	"github.com/scitix/sichek/components/infiniband/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+const smMasterState = "SMINFO_MASTER"
+
+// IBSubnetManagerChecker reports the InfiniBand ports with a link but not
+// configured by a subnet manager: a port stuck in INIT, or active without a
+// LID or SM LID. With query_sminfo it also asks the master SM of each port
+// over the fabric, which the local port state alone cannot tell is alive.
+type IBSubnetManagerChecker struct {
+	name string
+	spec *config.InfinibandSpec
+	// querySM queries the master SM of a port, replaced in tests
+	querySM func(ctx context.Context, IBDev string, port int) (*collector.SMInfo, error)
+}
+
+func NewIBSubnetManagerChecker(specCfg *config.InfinibandSpec) (common.Checker, error) {
+	return &IBSubnetManagerChecker{
+		name:    config.CheckIBSubnetManager,
+		spec:    specCfg,
+		querySM: collector.QuerySMInfo,
+	}, nil
+}
+
+func (c *IBSubnetManagerChecker) Name() string {
+	return c.name
+}
+
+func (c *IBSubnetManagerChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	infinibandInfo, ok := data.(*collector.InfinibandInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid InfinibandInfo type")
+	}
+
+	result := config.InfinibandCheckItems[c.name]
+	result.Status = consts.StatusNormal
+
+	var smSpec config.SubnetManagerSpec
+	if c.spec != nil && c.spec.SubnetManager != nil {
+		smSpec = *c.spec.SubnetManager
+	}
+
+	infinibandInfo.RLock()
+	keys := make([]string, 0, len(infinibandInfo.IBHardWareInfo))
+	ports := make(map[string]collector.IBHardWareInfo, len(infinibandInfo.IBHardWareInfo))
+	for key, hw := range infinibandInfo.IBHardWareInfo {
+		if hw.LinkLayer != "InfiniBand" {
+			continue
+		}
+		keys = append(keys, key)
+		ports[key] = hw
+	}
+	infinibandInfo.RUnlock()
+	sort.Strings(keys)
+	if len(keys) == 0 {
+		result.Curr = "N/A"
+		result.Detail = "No InfiniBand port"
+		return &result, nil
+	}
+
+	var (
+		failedPorts []string
+		details     []string
+		masters     = make(map[string]bool)
+	)
+	for _, key := range keys {
+		hw := ports[key]
+		reason := portSMReason(hw)
+		if reason == "" && smSpec.QuerySMInfo && strings.Contains(hw.PortState, "ACTIVE") {
+			sm, err := c.querySM(ctx, hw.IBDev, hw.Port)
+			if err == nil {
+				masters[sm.GUID] = true
+			}
+			reason = smInfoReason(sm, err, smSpec.SMGUIDs)
+		}
+		if reason == "" {
+			continue
+		}
+		failedPorts = append(failedPorts, key)
+		details = append(details, fmt.Sprintf("%s: %s", key, reason))
+	}
+
+	result.Spec = "registered with a master SM"
+	if len(smSpec.SMGUIDs) > 0 {
+		result.Spec = fmt.Sprintf("registered with master SM %s", strings.Join(smSpec.SMGUIDs, " or "))
+	}
+	if len(failedPorts) > 0 {
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(failedPorts, ",")
+		result.Curr = fmt.Sprintf("%d/%d ports without SM", len(failedPorts), len(keys))
+		result.Detail = strings.Join(details, "\n")
+		logrus.WithField("component", "infiniband").Errorf("IB ports without subnet manager: %s", result.Detail)
+		return &result, nil
+	}
+	result.Curr = fmt.Sprintf("%d/%d ports registered", len(keys), len(keys))
+	if len(masters) > 0 {
+		guids := make([]string, 0, len(masters))
+		for guid := range masters {
+			guids = append(guids, guid)
+		}
+		sort.Strings(guids)
+		result.Detail = fmt.Sprintf("All %d InfiniBand ports are registered with master SM %s", len(keys), strings.Join(guids, ","))
+	}
+	return &result, nil
+}
+
+// portSMReason tells why a port with a physical link is not configured by a
+// SM, the ports without a link are reported by the state checkers.
+func portSMReason(hw collector.IBHardWareInfo) string {
+	if !strings.Contains(hw.PhyState, "LinkUp") {
+		return ""
+	}
+	if strings.Contains(hw.PortState, "INIT") || strings.Contains(hw.PortState, "ARMED") {
+		return fmt.Sprintf("link is up but the port is %s, no subnet manager configured it", hw.PortState)
+	}
+	if !strings.Contains(hw.PortState, "ACTIVE") {
+		return ""
+	}
+	if lid, ok := collector.ParseLID(hw.LID); ok && lid == 0 {
+		return "port is ACTIVE without a LID"
+	}
+	if smLID, ok := collector.ParseLID(hw.SMLID); ok && smLID == 0 {
+		return "port is ACTIVE without a subnet manager LID"
+	}
+	return ""
+}
+
+func smInfoReason(sm *collector.SMInfo, err error, expectedGUIDs []string) string {
+	if err != nil {
+		return fmt.Sprintf("subnet manager unreachable: %v", err)
+	}
+	if sm.State != smMasterState {
+		return fmt.Sprintf("SM %s (LID %s) is %s, not master", sm.GUID, sm.LID, sm.State)
+	}
+	if len(expectedGUIDs) > 0 && !slices.ContainsFunc(expectedGUIDs, func(guid string) bool {
+		return strings.EqualFold(guid, sm.GUID)
+	}) {
+		return fmt.Sprintf("master SM %s (LID %s) is not one of the expected SMs %s", sm.GUID, sm.LID, strings.Join(expectedGUIDs, ","))
+	}
+	return ""
+}
+
+// IBSMFailoverChecker reports the InfiniBand ports whose SM LID changed since
+// the previous sample, i.e. the master SM failed over or a second SM took
+// over the fabric.
+type IBSMFailoverChecker struct {
+	name     string
+	lastInfo func() (common.Info, error)
+}
+
+func NewIBSMFailoverChecker(specCfg *config.InfinibandSpec, lastInfo func() (common.Info, error)) (common.Checker, error) {
+	if lastInfo == nil {
+		return nil, fmt.Errorf("lastInfo is required by %s", config.CheckIBSMFailover)
+	}
+	return &IBSMFailoverChecker{
+		name:     config.CheckIBSMFailover,
+		lastInfo: lastInfo,
+	}, nil
+}
+
+func (c *IBSMFailoverChecker) Name() string {
+	return c.name
+}
+
+func (c *IBSMFailoverChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	infinibandInfo, ok := data.(*collector.InfinibandInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid InfinibandInfo type")
+	}
+
+	result := config.InfinibandCheckItems[c.name]
+	result.Status = consts.StatusNormal
+
+	info, err := c.lastInfo()
+	prevInfo, ok := info.(*collector.InfinibandInfo)
+	if err != nil || !ok || prevInfo == nil || prevInfo == infinibandInfo {
+		// first sample, nothing to compare with yet
+		result.Curr = "no previous sample"
+		return &result, nil
+	}
+
+	prevInfo.RLock()
+	defer prevInfo.RUnlock()
+	infinibandInfo.RLock()
+	defer infinibandInfo.RUnlock()
+
+	keys := make([]string, 0, len(infinibandInfo.IBHardWareInfo))
+	for key := range infinibandInfo.IBHardWareInfo {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var movedPorts, details []string
+	for _, key := range keys {
+		prev, ok := prevInfo.IBHardWareInfo[key]
+		if !ok {
+			continue
+		}
+		prevLID, prevOK := collector.ParseLID(prev.SMLID)
+		currLID, currOK := collector.ParseLID(infinibandInfo.IBHardWareInfo[key].SMLID)
+		// a port losing its SM is reported by the subnet manager checker
+		if !prevOK || !currOK || prevLID == 0 || currLID == 0 || prevLID == currLID {
+			continue
+		}
+		movedPorts = append(movedPorts, key)
+		details = append(details, fmt.Sprintf("%s: SM LID changed from %d to %d", key, prevLID, currLID))
+	}
+	if len(movedPorts) > 0 {
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(movedPorts, ",")
+		result.Curr = fmt.Sprintf("%d ports", len(movedPorts))
+		result.Detail = strings.Join(details, "\n")
+		logrus.WithField("component", "infiniband").Warnf("IB subnet manager failover: %s", result.Detail)
+		return &result, nil
+	}
+	result.Curr = "no failover"
+	return &result, nil
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/infiniband/collector"
+	"github.com/scitix/sichek/components/infiniband/config"
+	"github.com/scitix/sichek/consts"
+)
+
+func ibPort(dev, state, lid, smLID string) collector.IBHardWareInfo {
+	return collector.IBHardWareInfo{
+		IBDev:     dev,
+		Port:      1,
+		LinkLayer: "InfiniBand",
+		PhyState:  "5: LinkUp",
+		PortState: state,
+		LID:       lid,
+		SMLID:     smLID,
+	}
+}
+
+func TestIBSubnetManagerChecker(t *testing.T) {
+	info := &collector.InfinibandInfo{
+		IBHardWareInfo: map[string]collector.IBHardWareInfo{
+			"mlx5_0/p1": ibPort("mlx5_0", "4: ACTIVE", "0x1a", "0x1"),
+			"mlx5_1/p1": ibPort("mlx5_1", "2: INIT", "0x0", "0x0"),
+			"mlx5_2/p1": ibPort("mlx5_2", "4: ACTIVE", "0x1b", "0x0"),
+			"mlx5_3/p1": {IBDev: "mlx5_3", Port: 1, LinkLayer: "InfiniBand", PhyState: "3: Disabled", PortState: "1: DOWN", LID: "0x0", SMLID: "0x0"},
+			"mlx5_4/p1": {IBDev: "mlx5_4", Port: 1, LinkLayer: "Ethernet", PhyState: "5: LinkUp", PortState: "4: ACTIVE"},
+		},
+	}
+	chk, err := NewIBSubnetManagerChecker(&config.InfinibandSpec{})
+	if err != nil {
+		t.Fatalf("NewIBSubnetManagerChecker: %v", err)
+	}
+	result, err := chk.Check(context.Background(), info)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if result.Status != consts.StatusAbnormal || result.Device != "mlx5_1/p1,mlx5_2/p1" {
+		t.Fatalf("expected mlx5_1/p1 and mlx5_2/p1 abnormal, got %+v", result)
+	}
+	if result.Curr != "2/4 ports without SM" {
+		t.Errorf("unexpected curr %q", result.Curr)
+	}
+}
+
+func TestIBSubnetManagerCheckerSMInfo(t *testing.T) {
+	info := &collector.InfinibandInfo{
+		IBHardWareInfo: map[string]collector.IBHardWareInfo{
+			"mlx5_0/p1": ibPort("mlx5_0", "4: ACTIVE", "0x1a", "0x1"),
+			"mlx5_1/p1": ibPort("mlx5_1", "4: ACTIVE", "0x1b", "0x1"),
+			"mlx5_2/p1": ibPort("mlx5_2", "4: ACTIVE", "0x1c", "0x2"),
+		},
+	}
+	spec := &config.InfinibandSpec{SubnetManager: &config.SubnetManagerSpec{
+		QuerySMInfo: true,
+		SMGUIDs:     []string{"0x248A070300F0D2C0"},
+	}}
+	c, _ := NewIBSubnetManagerChecker(spec)
+	chk := c.(*IBSubnetManagerChecker)
+	chk.querySM = func(ctx context.Context, IBDev string, port int) (*collector.SMInfo, error) {
+		switch IBDev {
+		case "mlx5_0":
+			return &collector.SMInfo{LID: "1", GUID: "0x248a070300f0d2c0", State: "SMINFO_MASTER"}, nil
+		case "mlx5_1":
+			return nil, errors.New("sminfo: iberror: failed: query")
+		default:
+			return &collector.SMInfo{LID: "2", GUID: "0x0002c90300a1b2c3", State: "SMINFO_MASTER"}, nil
+		}
+	}
+	result, err := chk.Check(context.Background(), info)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if result.Status != consts.StatusAbnormal || result.Device != "mlx5_1/p1,mlx5_2/p1" {
+		t.Fatalf("expected mlx5_1/p1 and mlx5_2/p1 abnormal, got %+v", result)
+	}
+	if !strings.Contains(result.Detail, "unreachable") || !strings.Contains(result.Detail, "not one of the expected SMs") {
+		t.Errorf("unexpected detail %q", result.Detail)
+	}
+}
+
+func TestIBSMFailoverChecker(t *testing.T) {
+	prev := &collector.InfinibandInfo{
+		IBHardWareInfo: map[string]collector.IBHardWareInfo{
+			"mlx5_0/p1": ibPort("mlx5_0", "4: ACTIVE", "0x1a", "0x1"),
+			"mlx5_1/p1": ibPort("mlx5_1", "4: ACTIVE", "0x1b", "0x1"),
+		},
+	}
+	curr := &collector.InfinibandInfo{
+		IBHardWareInfo: map[string]collector.IBHardWareInfo{
+			"mlx5_0/p1": ibPort("mlx5_0", "4: ACTIVE", "0x1a", "0x5"),
+			"mlx5_1/p1": ibPort("mlx5_1", "2: INIT", "0x0", "0x0"),
+		},
+	}
+	var last common.Info
+	chk, err := NewIBSMFailoverChecker(&config.InfinibandSpec{}, func() (common.Info, error) { return last, nil })
+	if err != nil {
+		t.Fatalf("NewIBSMFailoverChecker: %v", err)
+	}
+	result, _ := chk.Check(context.Background(), prev)
+	if result.Status != consts.StatusNormal {
+		t.Errorf("first sample: expected normal, got %+v", result)
+	}
+	last = prev
+	result, _ = chk.Check(context.Background(), curr)
+	if result.Status != consts.StatusAbnormal || result.Device != "mlx5_0/p1" {
+		t.Fatalf("expected mlx5_0/p1 abnormal, got %+v", result)
+	}
+	if result.Detail != "mlx5_0/p1: SM LID changed from 1 to 5" {
+		t.Errorf("unexpected detail %q", result.Detail)
+	}
+}
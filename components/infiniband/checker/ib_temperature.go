@@ -0,0 +1,118 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/infiniband/collector"
+	"github.com/scitix/sichek/components/infiniband/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+// IBTemperatureChecker reports the HCAs whose ASIC, and the ports whose
+// cable module, exceed the max temperature of the spec. The ASIC also fails
+// above the critical temperature its firmware reports in hwmon.
+type IBTemperatureChecker struct {
+	name string
+	spec *config.InfinibandSpec
+}
+
+func NewIBTemperatureChecker(specCfg *config.InfinibandSpec) (common.Checker, error) {
+	return &IBTemperatureChecker{
+		name: config.CheckIBTemperature,
+		spec: specCfg,
+	}, nil
+}
+
+func (c *IBTemperatureChecker) Name() string {
+	return c.name
+}
+
+func (c *IBTemperatureChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	infinibandInfo, ok := data.(*collector.InfinibandInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid InfinibandInfo type")
+	}
+
+	result := config.InfinibandCheckItems[c.name]
+	result.Status = consts.StatusNormal
+	limit := c.spec.TemperatureLimit()
+	result.Spec = fmt.Sprintf("ASIC <= %gC, cable <= %gC", limit.MaxASICTempC, limit.MaxCableTempC)
+
+	infinibandInfo.RLock()
+	keys := make([]string, 0, len(infinibandInfo.IBHardWareInfo))
+	ports := make(map[string]collector.IBHardWareInfo, len(infinibandInfo.IBHardWareInfo))
+	for key, hw := range infinibandInfo.IBHardWareInfo {
+		if hw.ASICTemp <= 0 && hw.CableTemp <= 0 {
+			continue
+		}
+		keys = append(keys, key)
+		ports[key] = hw
+	}
+	infinibandInfo.RUnlock()
+	sort.Strings(keys)
+	if len(keys) == 0 {
+		result.Level = consts.LevelInfo
+		result.Curr = "N/A"
+		result.Detail = "No HCA exposes its temperature in hwmon"
+		result.Suggestion = ""
+		return &result, nil
+	}
+
+	var failedPorts, details []string
+	hottest := 0.0
+	for _, key := range keys {
+		hw := ports[key]
+		reasons := temperatureReasons(hw, c.spec.ForDevice(hw.IBDev).TemperatureLimit())
+		hottest = max(hottest, hw.ASICTemp, hw.CableTemp)
+		if len(reasons) == 0 {
+			continue
+		}
+		failedPorts = append(failedPorts, key)
+		details = append(details, fmt.Sprintf("%s: %s", key, strings.Join(reasons, ", ")))
+	}
+
+	result.Curr = fmt.Sprintf("%gC", hottest)
+	if len(failedPorts) > 0 {
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(failedPorts, ",")
+		result.Detail = strings.Join(details, "\n")
+		logrus.WithField("component", "infiniband").Warnf("overheating HCAs or cables: %s", result.Detail)
+	}
+	return &result, nil
+}
+
+// temperatureReasons tells why the HCA or the cable of a port is too hot.
+func temperatureReasons(hw collector.IBHardWareInfo, limit *config.TemperatureSpec) []string {
+	var reasons []string
+	maxASIC := limit.MaxASICTempC
+	if hw.ASICTempCrit > 0 && hw.ASICTempCrit < maxASIC {
+		maxASIC = hw.ASICTempCrit
+	}
+	if hw.ASICTemp > maxASIC {
+		reasons = append(reasons, fmt.Sprintf("ASIC temperature %gC > %gC", hw.ASICTemp, maxASIC))
+	}
+	if hw.CableTemp > limit.MaxCableTempC {
+		reasons = append(reasons, fmt.Sprintf("cable temperature %gC > %gC", hw.CableTemp, limit.MaxCableTempC))
+	}
+	return reasons
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/scitix/sichek/components/infiniband/collector"
+	"github.com/scitix/sichek/components/infiniband/config"
+	"github.com/scitix/sichek/consts"
+)
+
+func TestIBTemperatureChecker(t *testing.T) {
+	info := &collector.InfinibandInfo{
+		IBHardWareInfo: map[string]collector.IBHardWareInfo{
+			"mlx5_0/p1": {IBDev: "mlx5_0", Port: 1, ASICTemp: 72, CableTemp: 48},
+			"mlx5_1/p1": {IBDev: "mlx5_1", Port: 1, ASICTemp: 108, CableTemp: 51},
+			"mlx5_2/p1": {IBDev: "mlx5_2", Port: 1, ASICTemp: 96, ASICTempCrit: 95},
+			"mlx5_3/p1": {IBDev: "mlx5_3", Port: 1, ASICTemp: 70, CableTemp: 78},
+			"mlx5_4/p1": {IBDev: "mlx5_4", Port: 1},
+		},
+	}
+	chk, err := NewIBTemperatureChecker(&config.InfinibandSpec{})
+	if err != nil {
+		t.Fatalf("NewIBTemperatureChecker: %v", err)
+	}
+	result, err := chk.Check(context.Background(), info)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if result.Status != consts.StatusAbnormal || result.Device != "mlx5_1/p1,mlx5_2/p1,mlx5_3/p1" || result.Curr != "108C" {
+		t.Fatalf("expected mlx5_1/p1, mlx5_2/p1 and mlx5_3/p1 abnormal, got %+v", result)
+	}
+	for _, want := range []string{"mlx5_1/p1: ASIC temperature 108C > 105C", "mlx5_2/p1: ASIC temperature 96C > 95C", "mlx5_3/p1: cable temperature 78C > 75C"} {
+		if !strings.Contains(result.Detail, want) {
+			t.Errorf("expected %q in the detail %q", want, result.Detail)
+		}
+	}
+
+	// a board with hotter rated cables
+	spec := &config.InfinibandSpec{Boards: map[string]*config.InfinibandSpec{
+		"MT_0000000970": {Temperature: &config.TemperatureSpec{MaxCableTempC: 85}},
+	}}
+	spec.SelectBoardSpecs(map[string]string{"mlx5_3": "MT_0000000970"}, nil)
+	chk, _ = NewIBTemperatureChecker(spec)
+	result, _ = chk.Check(context.Background(), info)
+	if result.Device != "mlx5_1/p1,mlx5_2/p1" {
+		t.Errorf("expected mlx5_1/p1 and mlx5_2/p1 abnormal, got %q", result.Device)
+	}
+
+	// without hwmon the temperature is not checked
+	result, _ = chk.Check(context.Background(), &collector.InfinibandInfo{
+		IBHardWareInfo: map[string]collector.IBHardWareInfo{"mlx5_0/p1": {IBDev: "mlx5_0", Port: 1}},
+	})
+	if result.Status != consts.StatusNormal || result.Curr != "N/A" {
+		t.Errorf("expected N/A without hwmon, got %+v", result)
+	}
+}
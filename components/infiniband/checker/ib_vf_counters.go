@@ -0,0 +1,141 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/infiniband/collector"
+	"github.com/scitix/sichek/components/infiniband/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+// IBVFCounterChecker rates the error counters of the VFs given to the pods,
+// read in the network namespace of each pod, against their baseline, and
+// reports the pod of each VF over the spec thresholds so that an anomaly of
+// a single workload is told apart from a fault of the PF.
+type IBVFCounterChecker struct {
+	name     string
+	spec     *config.InfinibandSpec
+	baseline *CounterBaseline
+}
+
+func NewIBVFCounterChecker(spec *config.InfinibandSpec, baseline *CounterBaseline) (common.Checker, error) {
+	if baseline == nil {
+		return nil, fmt.Errorf("counter baseline is required by %s", config.CheckIBVFCounters)
+	}
+	return &IBVFCounterChecker{
+		name:     config.CheckIBVFCounters,
+		spec:     spec,
+		baseline: baseline,
+	}, nil
+}
+
+func (c *IBVFCounterChecker) Name() string {
+	return c.name
+}
+
+func (c *IBVFCounterChecker) Description() string {
+	return config.InfinibandCheckItems[c.name].Description
+}
+
+func (c *IBVFCounterChecker) GetSpec() common.CheckerSpec {
+	return nil
+}
+
+func (c *IBVFCounterChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	infinibandInfo, ok := data.(*collector.InfinibandInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid InfinibandInfo type")
+	}
+
+	result := config.InfinibandCheckItems[c.name]
+	result.Status = consts.StatusNormal
+
+	infinibandInfo.RLock()
+	defer infinibandInfo.RUnlock()
+
+	if infinibandInfo.IBNicRole != sriovNodeRole {
+		result.Curr = "N/A"
+		result.Detail = fmt.Sprintf("Not an SR-IOV node (role %q), no VF to check", infinibandInfo.IBNicRole)
+		result.Suggestion = ""
+		return &result, nil
+	}
+
+	pfs := uniqueByDev(infinibandInfo.IBHardWareInfo)
+	devs := make([]string, 0, len(pfs))
+	for dev := range pfs {
+		devs = append(devs, dev)
+	}
+	sort.Strings(devs)
+
+	var (
+		abnormal []string
+		pods     []string
+		details  []string
+		compared int
+	)
+	for _, dev := range devs {
+		pf := pfs[dev]
+		thresholds := c.spec.ForDevice(pf.IBDev).VFCounterRateThresholds()
+		for _, vf := range pf.VFs {
+			if vf.Pod == nil || len(vf.Counters) == 0 {
+				continue
+			}
+			prev := c.baseline.Get(VFBaselineKey(vf), infinibandInfo.Time)
+			if prev == nil {
+				continue
+			}
+			compared++
+			minutes := infinibandInfo.Time.Sub(prev.Time).Minutes()
+			rates := counterRates(prev.Counters, vf.Counters, thresholds, minutes)
+			if len(rates) == 0 {
+				continue
+			}
+			abnormal = append(abnormal, pf.IBDev+"/"+vf.BDF)
+			pods = append(pods, vf.Pod.String())
+			for _, counter := range sortedKeys(rates) {
+				details = append(details, fmt.Sprintf("%s: %s %s increased %.2f/min, threshold is %.2f/min\n",
+					pf.IBDev, vfName(vf), counter, rates[counter], thresholds[counter]))
+			}
+		}
+	}
+	if compared == 0 {
+		result.Curr = "no previous sample"
+		return &result, nil
+	}
+
+	if len(abnormal) > 0 {
+		logrus.WithFields(logrus.Fields{
+			"checker": c.Name(),
+			"vfs":     abnormal,
+			"pods":    pods,
+		}).Errorf("VF counter rate exceeded")
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(abnormal, ",")
+		result.Curr = fmt.Sprintf("%d VFs abnormal", len(abnormal))
+		result.Detail = strings.Join(details, "")
+	} else {
+		result.Curr = fmt.Sprintf("OK, %d VFs in pods", compared)
+		result.Suggestion = ""
+	}
+	return &result, nil
+}
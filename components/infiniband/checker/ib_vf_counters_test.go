@@ -0,0 +1,91 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/infiniband/collector"
+	"github.com/scitix/sichek/components/infiniband/config"
+	"github.com/scitix/sichek/consts"
+)
+
+func newVFCounterInfo(at time.Time, pod *collector.VFPod, counters collector.IBCounters) *collector.InfinibandInfo {
+	return &collector.InfinibandInfo{
+		IBNicRole: sriovNodeRole,
+		Time:      at,
+		IBHardWareInfo: map[string]collector.IBHardWareInfo{
+			"mlx5_0/p1": {IBDev: "mlx5_0", Port: 1, VFs: []collector.VFInfo{
+				{Index: 0, BDF: "0000:3b:00.2", Pod: pod, Counters: counters},
+				// a free VF has no counters
+				{Index: 1, BDF: "0000:3b:00.3"},
+			}},
+		},
+	}
+}
+
+func TestIBVFCounterChecker(t *testing.T) {
+	now := time.Now()
+	pod := &collector.VFPod{UID: "uid-0", Namespace: "train", Name: "llama-0"}
+	prev := newVFCounterInfo(now.Add(-2*time.Minute), pod, collector.IBCounters{"out_of_sequence": 100, "req_cqe_error": 0})
+	curr := newVFCounterInfo(now, pod, collector.IBCounters{"out_of_sequence": 200, "req_cqe_error": 3})
+
+	baseline := NewCounterBaseline(t.TempDir())
+	chk, err := NewIBVFCounterChecker(&config.InfinibandSpec{}, baseline)
+	if err != nil {
+		t.Fatalf("NewIBVFCounterChecker: %v", err)
+	}
+	result, err := chk.Check(context.Background(), prev)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if result.Status != consts.StatusNormal || result.Curr != "no previous sample" {
+		t.Errorf("first sample: expected normal, got %+v", result)
+	}
+
+	baseline.Record(prev)
+	result, err = chk.Check(context.Background(), curr)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if result.Status != consts.StatusAbnormal || result.Device != "mlx5_0/0000:3b:00.2" {
+		t.Fatalf("expected the VF abnormal, got %+v", result)
+	}
+	// 50 out of sequence packets per minute stay below the default threshold
+	if !strings.Contains(result.Detail, "VF 0 (0000:3b:00.2, pod train/llama-0) req_cqe_error increased 1.50/min") ||
+		strings.Contains(result.Detail, "out_of_sequence") {
+		t.Errorf("expected the completion errors attributed to the pod, got %q", result.Detail)
+	}
+
+	// the VF given to another pod starts over
+	moved := newVFCounterInfo(now.Add(time.Minute), &collector.VFPod{UID: "uid-1"}, collector.IBCounters{"req_cqe_error": 10})
+	result, err = chk.Check(context.Background(), moved)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if result.Status != consts.StatusNormal {
+		t.Errorf("expected no baseline for the new pod, got %+v", result)
+	}
+
+	other := newVFCounterInfo(now, pod, collector.IBCounters{"req_cqe_error": 3})
+	other.IBNicRole = "computeNode"
+	if result, _ := chk.Check(context.Background(), other); result.Status != consts.StatusNormal || result.Curr != "N/A" {
+		t.Errorf("expected N/A on a non SR-IOV node, got %+v", result)
+	}
+}
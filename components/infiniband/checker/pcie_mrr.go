@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/common/remediator"
 	"github.com/scitix/sichek/components/infiniband/collector"
 	"github.com/scitix/sichek/components/infiniband/config"
 	"github.com/scitix/sichek/consts"
@@ -104,9 +105,11 @@ func (c *PCIEMRRChecker) Check(ctx context.Context, data any) (*common.CheckerRe
 			failedHcas = append(failedHcas, hwInfo.IBDev)
 			faiedHcasSpec = append(faiedHcasSpec, hcaSpec.Hardware.PCIEMRR)
 			faiedHcasCurr = append(faiedHcasCurr, hwInfo.PCIEMRR)
-			// auto fix if the curr not match the spec
-			if err := collector.ModifyPCIeMaxReadRequest(hwInfo.PCIEBDF, "68", 5); err != nil {
-				logrus.WithField("component", "infiniband").Errorf("Failed to modify PCIe Max Read Request for %s: %v", hwInfo.PCIEBDF, err)
+			action, err := remediator.NewSetPCIeMRRAction(hwInfo.PCIEBDF, hcaSpec.Hardware.PCIEMRR)
+			if err != nil {
+				logrus.WithField("component", "infiniband").Warnf("no remediation for the PCIe MaxReadReq of %s: %v", hwInfo.PCIEBDF, err)
+			} else {
+				result.Remediations = append(result.Remediations, action)
 			}
 		}
 	}
@@ -0,0 +1,148 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/infiniband/collector"
+	"github.com/scitix/sichek/components/infiniband/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+// RoCEGIDChecker reports the active RoCE ports missing a GID of the expected
+// type, and at the expected index when the spec sets one, for an address of
+// their netdev. NCCL picks the GID by NCCL_IB_GID_INDEX, so a RoCE v1 GID or
+// a GID that moved to another index silently breaks the traffic.
+type RoCEGIDChecker struct {
+	name string
+	spec *config.InfinibandSpec
+}
+
+func NewRoCEGIDChecker(specCfg *config.InfinibandSpec) (common.Checker, error) {
+	return &RoCEGIDChecker{
+		name: config.CheckRoCEGID,
+		spec: specCfg,
+	}, nil
+}
+
+func (c *RoCEGIDChecker) Name() string {
+	return c.name
+}
+
+func (c *RoCEGIDChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	infinibandInfo, ok := data.(*collector.InfinibandInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid InfinibandInfo type")
+	}
+
+	result := config.InfinibandCheckItems[c.name]
+	result.Status = consts.StatusNormal
+
+	infinibandInfo.RLock()
+	keys := make([]string, 0, len(infinibandInfo.IBHardWareInfo))
+	ports := make(map[string]collector.IBHardWareInfo, len(infinibandInfo.IBHardWareInfo))
+	for key, hw := range infinibandInfo.IBHardWareInfo {
+		if hw.LinkLayer != "Ethernet" || !strings.Contains(hw.PortState, "ACTIVE") {
+			continue
+		}
+		keys = append(keys, key)
+		ports[key] = hw
+	}
+	infinibandInfo.RUnlock()
+	sort.Strings(keys)
+	if len(keys) == 0 {
+		result.Curr = "N/A"
+		result.Detail = "No active RoCE port"
+		return &result, nil
+	}
+
+	var failedPorts, details []string
+	for _, key := range keys {
+		hw := ports[key]
+		reason := roceGIDReason(hw, c.spec.ForDevice(hw.IBDev).ExpectedRoCEGID())
+		if reason == "" {
+			continue
+		}
+		failedPorts = append(failedPorts, key)
+		details = append(details, fmt.Sprintf("%s: %s", key, reason))
+	}
+
+	expected := c.spec.ExpectedRoCEGID()
+	result.Spec = fmt.Sprintf("%s GID for each address", expected.Type)
+	if expected.Index != nil {
+		result.Spec += fmt.Sprintf(" at index %d", *expected.Index)
+	}
+	if len(failedPorts) > 0 {
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(failedPorts, ",")
+		result.Curr = fmt.Sprintf("%d/%d ports without the expected GID", len(failedPorts), len(keys))
+		result.Detail = strings.Join(details, "\n")
+		logrus.WithField("component", "infiniband").Errorf("RoCE ports without the expected GID: %s", result.Detail)
+		return &result, nil
+	}
+	result.Curr = fmt.Sprintf("%d/%d ports", len(keys), len(keys))
+	return &result, nil
+}
+
+// roceGIDReason tells which address of the netdev of a RoCE port has no GID
+// matching the spec.
+func roceGIDReason(hw collector.IBHardWareInfo, spec *config.RoCEGIDSpec) string {
+	if len(hw.NetIPs) == 0 {
+		return fmt.Sprintf("netdev %q has no IP address to derive a GID from", hw.NetDev)
+	}
+	var reasons []string
+	for _, addr := range hw.NetIPs {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			continue
+		}
+		var found []collector.IBGID
+		for _, gid := range hw.GIDs {
+			if ip.Equal(gid.IP()) {
+				found = append(found, gid)
+			}
+		}
+		if len(found) == 0 {
+			reasons = append(reasons, fmt.Sprintf("no GID for %s", addr))
+			continue
+		}
+		ok := false
+		var seen []string
+		for _, gid := range found {
+			if gid.Type == spec.Type && (spec.Index == nil || gid.Index == *spec.Index) {
+				ok = true
+				break
+			}
+			seen = append(seen, fmt.Sprintf("%d (%s)", gid.Index, gid.Type))
+		}
+		if ok {
+			continue
+		}
+		want := spec.Type + " GID"
+		if spec.Index != nil {
+			want += fmt.Sprintf(" at index %d", *spec.Index)
+		}
+		reasons = append(reasons, fmt.Sprintf("no %s for %s, found %s", want, addr, strings.Join(seen, ", ")))
+	}
+	return strings.Join(reasons, "; ")
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/scitix/sichek/components/infiniband/collector"
+	"github.com/scitix/sichek/components/infiniband/config"
+	"github.com/scitix/sichek/consts"
+)
+
+func rocePort(dev string, ips []string, gids ...collector.IBGID) collector.IBHardWareInfo {
+	return collector.IBHardWareInfo{
+		IBDev:     dev,
+		Port:      1,
+		NetDev:    "eth_" + dev,
+		LinkLayer: "Ethernet",
+		PortState: "4: ACTIVE",
+		NetIPs:    ips,
+		GIDs:      gids,
+	}
+}
+
+func TestRoCEGIDChecker(t *testing.T) {
+	linkLocal := collector.IBGID{Index: 0, GID: "fe80:0000:0000:0000:0ac0:ebff:fe12:3456", Type: collector.GIDTypeRoCEv1}
+	v1 := collector.IBGID{Index: 2, GID: "0000:0000:0000:0000:0000:ffff:0a00:0105", Type: collector.GIDTypeRoCEv1}
+	v2 := collector.IBGID{Index: 3, GID: "0000:0000:0000:0000:0000:ffff:0a00:0105", Type: collector.GIDTypeRoCEv2}
+	info := &collector.InfinibandInfo{
+		IBHardWareInfo: map[string]collector.IBHardWareInfo{
+			"mlx5_0/p1": rocePort("mlx5_0", []string{"10.0.1.5"}, linkLocal, v1, v2),
+			"mlx5_1/p1": rocePort("mlx5_1", []string{"10.0.1.5"}, linkLocal, v1),
+			"mlx5_2/p1": rocePort("mlx5_2", nil, linkLocal),
+			"mlx5_3/p1": rocePort("mlx5_3", []string{"10.0.1.6"}, linkLocal, v1, v2),
+			"mlx5_4/p1": {IBDev: "mlx5_4", Port: 1, LinkLayer: "InfiniBand", PortState: "4: ACTIVE"},
+		},
+	}
+	chk, err := NewRoCEGIDChecker(&config.InfinibandSpec{})
+	if err != nil {
+		t.Fatalf("NewRoCEGIDChecker: %v", err)
+	}
+	result, err := chk.Check(context.Background(), info)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if result.Status != consts.StatusAbnormal || result.Device != "mlx5_1/p1,mlx5_2/p1,mlx5_3/p1" {
+		t.Fatalf("expected mlx5_1/p1, mlx5_2/p1 and mlx5_3/p1 abnormal, got %+v", result)
+	}
+	for _, want := range []string{
+		"mlx5_1/p1: no RoCE v2 GID for 10.0.1.5, found 2 (IB/RoCE v1)",
+		"mlx5_2/p1: netdev \"eth_mlx5_2\" has no IP address",
+		"mlx5_3/p1: no GID for 10.0.1.6",
+	} {
+		if !strings.Contains(result.Detail, want) {
+			t.Errorf("expected %q in detail %q", want, result.Detail)
+		}
+	}
+
+	// NCCL_IB_GID_INDEX=2 needs the RoCE v2 GID at index 2
+	index := 2
+	chk, _ = NewRoCEGIDChecker(&config.InfinibandSpec{RoCEGID: &config.RoCEGIDSpec{Index: &index}})
+	info = &collector.InfinibandInfo{
+		IBHardWareInfo: map[string]collector.IBHardWareInfo{
+			"mlx5_0/p1": rocePort("mlx5_0", []string{"10.0.1.5"}, linkLocal, v1, v2),
+		},
+	}
+	result, _ = chk.Check(context.Background(), info)
+	if result.Status != consts.StatusAbnormal || !strings.Contains(result.Detail, "no RoCE v2 GID at index 2 for 10.0.1.5") {
+		t.Errorf("expected the GID index mismatch, got %+v", result)
+	}
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/infiniband/collector"
+	"github.com/scitix/sichek/components/infiniband/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+// sriovNodeRole is the IBNicRole of the nodes giving VFs to the pods, the
+// rdma subsystem runs in exclusive mode there.
+const sriovNodeRole = "sriovNode"
+
+// vfCheckFunc returns the problems of the VFs of a PF, empty when healthy.
+type vfCheckFunc func(spec *config.InfinibandSpec, pf collector.IBHardWareInfo) []string
+
+// SRIOVVFChecker runs one of the VF checks on every SR-IOV PF of a sriovNode
+// node, the other nodes have no VFs to check.
+type SRIOVVFChecker struct {
+	name  string
+	spec  *config.InfinibandSpec
+	check vfCheckFunc
+}
+
+// NewIBVFNumChecker checks that each PF exposes the VFs of the spec.
+func NewIBVFNumChecker(spec *config.InfinibandSpec) (common.Checker, error) {
+	return &SRIOVVFChecker{name: config.CheckIBVFNum, spec: spec, check: checkVFNum}, nil
+}
+
+// NewIBVFGUIDChecker checks that each VF has a unique GUID, or MAC on RoCE.
+func NewIBVFGUIDChecker(spec *config.InfinibandSpec) (common.Checker, error) {
+	return &SRIOVVFChecker{name: config.CheckIBVFGUID, spec: spec, check: checkVFGUIDs}, nil
+}
+
+// NewIBVFLinkStateChecker checks that the link of each VF follows its PF.
+func NewIBVFLinkStateChecker(spec *config.InfinibandSpec) (common.Checker, error) {
+	return &SRIOVVFChecker{name: config.CheckIBVFLinkState, spec: spec, check: checkVFLinkState}, nil
+}
+
+// NewIBVFErrorChecker checks that no VF is stuck in an error state.
+func NewIBVFErrorChecker(spec *config.InfinibandSpec) (common.Checker, error) {
+	return &SRIOVVFChecker{name: config.CheckIBVFError, spec: spec, check: checkVFErrors}, nil
+}
+
+func (c *SRIOVVFChecker) Name() string {
+	return c.name
+}
+
+func (c *SRIOVVFChecker) Description() string {
+	return config.InfinibandCheckItems[c.name].Description
+}
+
+func (c *SRIOVVFChecker) GetSpec() common.CheckerSpec {
+	return nil
+}
+
+func (c *SRIOVVFChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	infinibandInfo, ok := data.(*collector.InfinibandInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid InfinibandInfo type")
+	}
+
+	result := config.InfinibandCheckItems[c.name]
+	result.Status = consts.StatusNormal
+
+	infinibandInfo.RLock()
+	role := infinibandInfo.IBNicRole
+	pfs := make([]collector.IBHardWareInfo, 0, len(infinibandInfo.IBHardWareInfo))
+	for _, hw := range uniqueByDev(infinibandInfo.IBHardWareInfo) {
+		if strings.Contains(hw.IBDev, "mlx_bond") || strings.HasSuffix(hw.PCIEBDF, ".1") {
+			// VFs are created on the first function of the HCA only
+			continue
+		}
+		pfs = append(pfs, hw)
+	}
+	infinibandInfo.RUnlock()
+
+	if role != sriovNodeRole {
+		result.Curr = "N/A"
+		result.Detail = fmt.Sprintf("Not an SR-IOV node (role %q), no VF to check", role)
+		result.Suggestion = ""
+		return &result, nil
+	}
+	sort.Slice(pfs, func(i, j int) bool { return pfs[i].IBDev < pfs[j].IBDev })
+
+	var failedPFs, details []string
+	vfCount := 0
+	for _, pf := range pfs {
+		vfCount += len(pf.VFs)
+		problems := c.check(c.spec.ForDevice(pf.IBDev), pf)
+		if len(problems) == 0 {
+			continue
+		}
+		failedPFs = append(failedPFs, pf.IBDev)
+		for _, problem := range problems {
+			details = append(details, fmt.Sprintf("%s: %s\n", pf.IBDev, problem))
+		}
+	}
+
+	if len(failedPFs) > 0 {
+		logrus.WithFields(logrus.Fields{
+			"checker": c.Name(),
+			"devices": failedPFs,
+		}).Errorf("SR-IOV VF check failed")
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(failedPFs, ",")
+		result.Curr = fmt.Sprintf("%d PFs with faulty VFs", len(failedPFs))
+		result.Detail = strings.Join(details, "")
+	} else {
+		result.Curr = fmt.Sprintf("%d VFs on %d PFs", vfCount, len(pfs))
+		result.Suggestion = ""
+	}
+	return &result, nil
+}
+
+func checkVFNum(spec *config.InfinibandSpec, pf collector.IBHardWareInfo) []string {
+	var problems []string
+	expected := spec.ExpectedVFs()
+	if expected > 0 && pf.VFConfigured != expected {
+		problems = append(problems, fmt.Sprintf("%d VFs configured, spec %d", pf.VFConfigured, expected))
+	}
+	if len(pf.VFs) != pf.VFConfigured {
+		problems = append(problems, fmt.Sprintf("only %d of the %d configured VFs are enumerated", len(pf.VFs), pf.VFConfigured))
+	}
+	return problems
+}
+
+// vfName names a VF in the problems, along with the pod it is allocated to so
+// that the owner of the workload can be told.
+func vfName(vf collector.VFInfo) string {
+	if vf.Pod != nil {
+		return fmt.Sprintf("VF %d (%s, %s)", vf.Index, vf.BDF, vf.Pod)
+	}
+	return fmt.Sprintf("VF %d (%s)", vf.Index, vf.BDF)
+}
+
+// zeroAddress reports whether a MAC or a GUID is unset, e.g. 00:00:00:00:00:00.
+func zeroAddress(addr string) bool {
+	return strings.Trim(addr, "0:") == ""
+}
+
+func checkVFGUIDs(_ *config.InfinibandSpec, pf collector.IBHardWareInfo) []string {
+	var problems []string
+	seen := make(map[string]int)
+	for _, vf := range pf.VFs {
+		if vf.LinkState == "" && vf.MAC == "" && vf.NodeGUID == "" {
+			// not listed by ip link, nothing to check
+			continue
+		}
+		id := vf.PortGUID
+		if pf.LinkLayer == "Ethernet" {
+			// RoCE VFs are addressed by their MAC, the GID derives from it
+			id = vf.MAC
+			if zeroAddress(vf.MAC) {
+				problems = append(problems, fmt.Sprintf("%s has no MAC", vfName(vf)))
+				continue
+			}
+		} else if zeroAddress(vf.NodeGUID) || zeroAddress(vf.PortGUID) {
+			problems = append(problems, fmt.Sprintf("%s has no GUID, node_guid %q port_guid %q", vfName(vf), vf.NodeGUID, vf.PortGUID))
+			continue
+		}
+		if other, ok := seen[id]; ok {
+			problems = append(problems, fmt.Sprintf("VF %d and VF %d share %s", other, vf.Index, id))
+			continue
+		}
+		seen[id] = vf.Index
+	}
+	return problems
+}
+
+func checkVFLinkState(spec *config.InfinibandSpec, pf collector.IBHardWareInfo) []string {
+	var problems []string
+	expected := spec.VFLinkState()
+	pfActive := strings.Contains(pf.PortState, "ACTIVE")
+	for _, vf := range pf.VFs {
+		if vf.LinkState != "" && vf.LinkState != expected {
+			problems = append(problems, fmt.Sprintf("%s link-state is %s, expected %s", vfName(vf), vf.LinkState, expected))
+			continue
+		}
+		if vf.PortState == "" {
+			continue
+		}
+		vfActive := strings.Contains(vf.PortState, "ACTIVE")
+		if pfActive && !vfActive && strings.Contains(vf.PortState, "DOWN") {
+			problems = append(problems, fmt.Sprintf("%s port is %s while the PF is %s", vfName(vf), vf.PortState, pf.PortState))
+		} else if !pfActive && vfActive {
+			problems = append(problems, fmt.Sprintf("%s port is %s while the PF is %s", vfName(vf), vf.PortState, pf.PortState))
+		}
+	}
+	return problems
+}
+
+func checkVFErrors(_ *config.InfinibandSpec, pf collector.IBHardWareInfo) []string {
+	var problems []string
+	pfActive := strings.Contains(pf.PortState, "ACTIVE")
+	for _, vf := range pf.VFs {
+		switch {
+		case vf.FatalErrors > 0:
+			problems = append(problems, fmt.Sprintf("%s reported %d fatal PCIe errors", vfName(vf), vf.FatalErrors))
+		case strings.Contains(vf.PhyState, "LinkErrorRecovery"):
+			problems = append(problems, fmt.Sprintf("%s is stuck in %s", vfName(vf), vf.PhyState))
+		case pfActive && (strings.Contains(vf.PortState, "INIT") || strings.Contains(vf.PortState, "ARMED")):
+			// the subnet manager never activated the VF, e.g. its GUID is unknown to it
+			problems = append(problems, fmt.Sprintf("%s port is stuck in %s while the PF is %s", vfName(vf), vf.PortState, pf.PortState))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/infiniband/collector"
+	"github.com/scitix/sichek/components/infiniband/config"
+	"github.com/scitix/sichek/consts"
+)
+
+func sriovInfo(role string, pf collector.IBHardWareInfo) *collector.InfinibandInfo {
+	return &collector.InfinibandInfo{
+		IBNicRole:      role,
+		IBHardWareInfo: map[string]collector.IBHardWareInfo{collector.HWInfoKey(pf.IBDev, 1): pf},
+	}
+}
+
+func TestSRIOVVFCheckers(t *testing.T) {
+	spec := &config.InfinibandSpec{SRIOV: &config.SRIOVSpec{NumVFs: 2}}
+	healthy := collector.IBHardWareInfo{
+		IBDev: "mlx5_0", Port: 1, PCIEBDF: "0000:3b:00.0", LinkLayer: "InfiniBand", PortState: "4: ACTIVE",
+		VFConfigured: 2,
+		VFs: []collector.VFInfo{
+			{Index: 0, BDF: "0000:3b:00.2", NodeGUID: "11:22:33:44:55:66:77:01", PortGUID: "11:22:33:44:55:66:77:01", LinkState: "auto", PortState: "4: ACTIVE"},
+			{Index: 1, BDF: "0000:3b:00.3", NodeGUID: "11:22:33:44:55:66:77:02", PortGUID: "11:22:33:44:55:66:77:02", LinkState: "auto"},
+		},
+	}
+	faulty := healthy
+	faulty.VFConfigured = 3
+	faulty.VFs = []collector.VFInfo{
+		{Index: 0, BDF: "0000:3b:00.2", NodeGUID: "11:22:33:44:55:66:77:01", PortGUID: "11:22:33:44:55:66:77:01", LinkState: "enable"},
+		{Index: 1, BDF: "0000:3b:00.3", NodeGUID: "00:00:00:00:00:00:00:00", PortGUID: "00:00:00:00:00:00:00:00", LinkState: "auto", PortState: "2: INIT"},
+	}
+
+	constructors := map[string]func(*config.InfinibandSpec) (common.Checker, error){
+		config.CheckIBVFNum:       NewIBVFNumChecker,
+		config.CheckIBVFGUID:      NewIBVFGUIDChecker,
+		config.CheckIBVFLinkState: NewIBVFLinkStateChecker,
+		config.CheckIBVFError:     NewIBVFErrorChecker,
+	}
+	for name, constructor := range constructors {
+		chk, err := constructor(spec)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		result, err := chk.Check(context.Background(), sriovInfo("sriovNode", healthy))
+		if err != nil || result.Status != consts.StatusNormal {
+			t.Errorf("%s: expected a healthy PF to pass, got %+v, err %v", name, result, err)
+		}
+		result, err = chk.Check(context.Background(), sriovInfo("sriovNode", faulty))
+		if err != nil || result.Status != consts.StatusAbnormal || result.Device != "mlx5_0" {
+			t.Errorf("%s: expected the faulty PF to fail, got %+v, err %v", name, result, err)
+		}
+		result, err = chk.Check(context.Background(), sriovInfo("macvlanNode", faulty))
+		if err != nil || result.Status != consts.StatusNormal {
+			t.Errorf("%s: expected a non SR-IOV node to be skipped, got %+v, err %v", name, result, err)
+		}
+	}
+}
+
+func TestCheckVFGUIDsRoCE(t *testing.T) {
+	pf := collector.IBHardWareInfo{
+		IBDev: "mlx5_0", LinkLayer: "Ethernet",
+		VFs: []collector.VFInfo{
+			{Index: 0, MAC: "0a:00:00:00:00:01", LinkState: "auto"},
+			{Index: 1, MAC: "0a:00:00:00:00:01", LinkState: "auto"},
+			{Index: 2, MAC: "00:00:00:00:00:00", LinkState: "auto"},
+		},
+	}
+	problems := checkVFGUIDs(nil, pf)
+	if len(problems) != 2 || !strings.Contains(problems[0], "share") || !strings.Contains(problems[1], "no MAC") {
+		t.Errorf("unexpected problems %v", problems)
+	}
+}
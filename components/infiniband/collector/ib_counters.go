@@ -16,13 +16,18 @@ limitations under the License.
 package collector
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
 
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/scitix/sichek/pkg/utils"
 	"github.com/sirupsen/logrus"
 )
 
@@ -65,7 +70,7 @@ func (cnt *IBCounters) Collect(IBDev string, port int) {
 // GetIBCounter gets IB counter for a specific counter type
 func (cnt *IBCounters) GetIBCounter(IBDev string, port int, counterType string) (map[string]uint64, error) {
 	Counters := make(map[string]uint64, 0)
-	counterPath := path.Join(IBSYSPathPre, IBDev, "ports", fmt.Sprintf("%d", port), counterType)
+	counterPath := hostfs.Path(IBSYSPathPre, IBDev, "ports", fmt.Sprintf("%d", port), counterType)
 	ibCounterName, err := ListDir(counterPath)
 	if err != nil {
 		logrus.WithField("component", "infiniband").Errorf("Fail to get the counter from path :%s", counterPath)
@@ -89,3 +94,39 @@ func (cnt *IBCounters) GetIBCounter(IBDev string, port int, counterType string)
 
 	return Counters, nil
 }
+
+// pauseCounterRegex matches the global and per-priority (PFC) pause frame
+// counters of mlx5 netdevs in `ethtool -S`, e.g. rx_pause_ctrl_phy and tx_prio3_pause.
+var pauseCounterRegex = regexp.MustCompile(`^(rx|tx)_(pause_ctrl_phy|prio[0-7]_pause)$`)
+
+// CollectPauseCounters adds the Ethernet pause frame counters of the RoCE
+// netdev, which are not exposed under hw_counters.
+func (cnt *IBCounters) CollectPauseCounters(ctx context.Context, netDev string) {
+	cmdCtx, cancel := context.WithTimeout(ctx, consts.CmdTimeout)
+	defer cancel()
+	output, err := utils.ExecCommand(cmdCtx, "ethtool", "-S", netDev)
+	if err != nil {
+		logrus.WithField("component", "infiniband").Debugf("ethtool -S %s failed: %v", netDev, err)
+		return
+	}
+	for k, v := range ParsePauseCounters(string(output)) {
+		(*cnt)[k] = v
+	}
+}
+
+// ParsePauseCounters parses the pause frame counters from the `ethtool -S` output.
+func ParsePauseCounters(output string) IBCounters {
+	counters := make(IBCounters)
+	for _, line := range strings.Split(output, "\n") {
+		key, val, found := strings.Cut(strings.TrimSpace(line), ":")
+		if !found || !pauseCounterRegex.MatchString(key) {
+			continue
+		}
+		value, err := strconv.ParseUint(strings.TrimSpace(val), 10, 64)
+		if err != nil {
+			continue
+		}
+		counters[key] = value
+	}
+	return counters
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePauseCounters(t *testing.T) {
+	output := `NIC statistics:
+     rx_packets: 1234
+     rx_pause_ctrl_phy: 42
+     tx_pause_ctrl_phy: 7
+     rx_prio3_pause: 40
+     rx_prio3_pause_duration: 9000
+     tx_prio3_pause_transition: 3
+     tx_prio3_pause: 6
+     rx_discards_phy: 1
+`
+	counters := ParsePauseCounters(output)
+	assert.Equal(t, IBCounters{
+		"rx_pause_ctrl_phy": 42,
+		"tx_pause_ctrl_phy": 7,
+		"rx_prio3_pause":    40,
+		"tx_prio3_pause":    6,
+	}, counters)
+}
@@ -21,10 +21,12 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/scitix/sichek/pkg/hostfs"
 	"github.com/sirupsen/logrus"
 	"github.com/vishvananda/netlink"
 )
@@ -77,13 +79,15 @@ func (gw *IBGateway) GetPFGW(IBDev string) string {
 		}
 		logrus.WithField("component", "infiniband").Infof("Interface %s has IPv4 address, continuing gateway lookup for IB device: %s", ifaceName, IBDev)
 
-		iface, err := net.InterfaceByName(ifaceName)
+		// read the flags from the sysfs of the host, the interface may not be
+		// in the network namespace of sichek
+		flags, err := strconv.ParseUint(strings.TrimPrefix(readSysfsLine(hostfs.Path(NetClassPath, ifaceName, "flags")), "0x"), 16, 32)
 		if err != nil {
 			logrus.WithField("component", "infiniband").Errorf("Failed to find interface %s[%s]: %v", IBDev, ifaceName, err)
 			return ""
 		}
 
-		if iface.Flags&net.FlagUp == 0 {
+		if net.Flags(flags)&net.FlagUp == 0 {
 			logrus.WithField("component", "infiniband").Warnf("Interface %s is down, cannot find gateway", ifaceName)
 			return ""
 		}
@@ -104,7 +108,7 @@ func (gw *IBGateway) GetPFGW(IBDev string) string {
 
 func (gw *IBGateway) GetIBDevLinklayer(IBDev string) string {
 	var linkLayer string
-	netPath := filepath.Join(IBSYSPathPre, IBDev, "device", "net")
+	netPath := hostfs.Path(IBSYSPathPre, IBDev, "device", "net")
 	dirs, err := os.ReadDir(netPath)
 	if err != nil {
 		logrus.WithField("component", "infiniband").Errorf("failed to read net directory %s: %v", netPath, err)
@@ -169,14 +173,22 @@ func (gw *IBGateway) FindGateway(ifaceName string) (string, error) {
 
 // Prioritize querying policy routing, if not found then query interface routing
 func (gw *IBGateway) _findGatewayWithNetlink(ifaceName string) (string, error) {
+	// the handle is in the network namespace of the host when sichek runs
+	// in a pod of its own one
+	handle, err := hostfs.NetlinkHandle()
+	if err != nil {
+		return "", fmt.Errorf("netlink: %w", err)
+	}
+	defer handle.Close()
+
 	// Get interface Link object by interface name
-	link, err := netlink.LinkByName(ifaceName)
+	link, err := handle.LinkByName(ifaceName)
 	if err != nil {
 		return "", fmt.Errorf("netlink: failed to find interface '%s': %w", ifaceName, err)
 	}
 
 	// --- 1. Prioritize querying policy routing ---
-	gateway, err := gw._findGatewayFromPolicyRouting(link)
+	gateway, err := gw._findGatewayFromPolicyRouting(handle, link)
 	if err == nil && gateway != "" {
 		logrus.WithFields(logrus.Fields{
 			"component": "infiniband",
@@ -191,13 +203,13 @@ func (gw *IBGateway) _findGatewayWithNetlink(ifaceName string) (string, error) {
 	}).Debug("Policy routing lookup failed, falling back to interface routing")
 
 	// --- 2. Fall back to interface routing query ---
-	return gw._findGatewayFromInterfaceRouting(link, ifaceName)
+	return gw._findGatewayFromInterfaceRouting(handle, link, ifaceName)
 }
 
 // _findGatewayFromPolicyRouting finds gateway through policy routing
-func (gw *IBGateway) _findGatewayFromPolicyRouting(link netlink.Link) (string, error) {
+func (gw *IBGateway) _findGatewayFromPolicyRouting(handle *netlink.Handle, link netlink.Link) (string, error) {
 	// Get interface IPv4 addresses as source addresses
-	sourceIPs, err := gw._getInterfaceIPv4Addresses(link)
+	sourceIPs, err := gw._getInterfaceIPv4Addresses(handle, link)
 	if err != nil {
 		return "", fmt.Errorf("failed to get interface addresses: %w", err)
 	}
@@ -207,7 +219,7 @@ func (gw *IBGateway) _findGatewayFromPolicyRouting(link netlink.Link) (string, e
 	}
 
 	// Get routing rules
-	rules, err := netlink.RuleList(netlink.FAMILY_V4)
+	rules, err := handle.RuleList(netlink.FAMILY_V4)
 	if err != nil {
 		return "", fmt.Errorf("failed to list routing rules: %w", err)
 	}
@@ -227,7 +239,7 @@ func (gw *IBGateway) _findGatewayFromPolicyRouting(link netlink.Link) (string, e
 		// Check matching routing rules - modified here, pass struct instead of pointer
 		for _, rule := range rules {
 			if gw._isRuleMatchingSource(rule, sourceIP) {
-				gateway, err := gw._findGatewayInTable(rule.Table /*sourceIP*/)
+				gateway, err := gw._findGatewayInTable(handle, rule.Table /*sourceIP*/)
 				if err == nil && gateway != "" {
 					logrus.WithField("component", "infiniband").Infof("Found gateway in policy table - source_ip: %s, table: %d, priority: %d, gateway: %s",
 						sourceIP.String(), rule.Table, rule.Priority, gateway)
@@ -266,9 +278,9 @@ func (gw *IBGateway) _isRuleMatchingSource(rule netlink.Rule, sourceIP net.IP) b
 }
 
 // _findGatewayInTable finds gateway in specified routing table
-func (gw *IBGateway) _findGatewayInTable(tableID int /*sourceIP net.IP*/) (string, error) {
+func (gw *IBGateway) _findGatewayInTable(handle *netlink.Handle, tableID int /*sourceIP net.IP*/) (string, error) {
 	// Get routes in specified table
-	routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{
+	routes, err := handle.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{
 		Table: tableID,
 	}, netlink.RT_FILTER_TABLE)
 
@@ -317,8 +329,8 @@ func (gw *IBGateway) _findGatewayInTable(tableID int /*sourceIP net.IP*/) (strin
 }
 
 // _getInterfaceIPv4Addresses gets interface IPv4 addresses
-func (gw *IBGateway) _getInterfaceIPv4Addresses(link netlink.Link) ([]net.IP, error) {
-	addrs, err := netlink.AddrList(link, netlink.FAMILY_V4)
+func (gw *IBGateway) _getInterfaceIPv4Addresses(handle *netlink.Handle, link netlink.Link) ([]net.IP, error) {
+	addrs, err := handle.AddrList(link, netlink.FAMILY_V4)
 	if err != nil {
 		return nil, err
 	}
@@ -334,9 +346,9 @@ func (gw *IBGateway) _getInterfaceIPv4Addresses(link netlink.Link) ([]net.IP, er
 }
 
 // _findGatewayFromInterfaceRouting finds gateway through interface routing (original logic)
-func (gw *IBGateway) _findGatewayFromInterfaceRouting(link netlink.Link, ifaceName string) (string, error) {
+func (gw *IBGateway) _findGatewayFromInterfaceRouting(handle *netlink.Handle, link netlink.Link, ifaceName string) (string, error) {
 	// Only find IPv4 routes associated with this interface
-	routes, err := netlink.RouteList(link, netlink.FAMILY_V4)
+	routes, err := handle.RouteList(link, netlink.FAMILY_V4)
 	if err != nil {
 		return "", fmt.Errorf("netlink: failed to list routes for '%s': %w", ifaceName, err)
 	}
@@ -383,20 +395,20 @@ func (gw *IBGateway) _findGatewayFromInterfaceRouting(link netlink.Link, ifaceNa
 }
 
 func (gw *IBGateway) CheckIPVersionViaSysfs(interfaceName string) (hasIPv4, hasIPv6 bool, err error) {
-	basePath := filepath.Join("/sys/class/net", interfaceName)
+	basePath := hostfs.Path(NetClassPath, interfaceName)
 
 	if _, err := os.Stat(basePath); os.IsNotExist(err) {
 		return false, false, fmt.Errorf("interface %s not found", interfaceName)
 	}
 
-	ipv4Path := "/proc/net/fib_trie"
+	ipv4Path := hostfs.Path("/proc/net/fib_trie")
 	if data, err := os.ReadFile(ipv4Path); err == nil {
 		if strings.Contains(string(data), interfaceName) {
 			hasIPv4 = true
 		}
 	}
 
-	ipv6Path := "/proc/net/if_inet6"
+	ipv6Path := hostfs.Path("/proc/net/if_inet6")
 	if data, err := os.ReadFile(ipv6Path); err == nil {
 		lines := strings.Split(string(data), "\n")
 		for _, line := range lines {
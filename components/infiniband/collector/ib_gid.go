@@ -0,0 +1,154 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/scitix/sichek/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// GID types as exposed in ports/<port>/gid_attrs/types
+const (
+	GIDTypeRoCEv1 = "IB/RoCE v1"
+	GIDTypeRoCEv2 = "RoCE v2"
+)
+
+const zeroGID = "0000:0000:0000:0000:0000:0000:0000:0000"
+
+// IBGID is a populated entry of the GID table of a port.
+type IBGID struct {
+	Index int    `json:"index" yaml:"index"`
+	GID   string `json:"gid" yaml:"gid"`
+	// Type is IB/RoCE v1 or RoCE v2
+	Type   string `json:"type,omitempty" yaml:"type,omitempty"`
+	NetDev string `json:"net_dev,omitempty" yaml:"net_dev,omitempty"`
+}
+
+// IP returns the address a RoCE GID is derived from, an IPv4 address is
+// mapped to ::ffff:a.b.c.d.
+func (g IBGID) IP() net.IP {
+	return net.ParseIP(g.GID)
+}
+
+// GetGIDs reads the populated entries of the GID table of a port.
+func (c *IBHardWareInfo) GetGIDs(IBDev string, port int) []IBGID {
+	portDir := hostfs.Path(IBSYSPathPre, IBDev, "ports", strconv.Itoa(port))
+	entries, err := os.ReadDir(filepath.Join(portDir, "gids"))
+	if err != nil {
+		logrus.WithField("component", "infiniband").Debugf("Failed to read the GID table of %s/p%d: %v", IBDev, port, err)
+		return nil
+	}
+	var gids []IBGID
+	for _, entry := range entries {
+		index, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		gid := readSysfsLine(filepath.Join(portDir, "gids", entry.Name()))
+		if gid == "" || gid == zeroGID {
+			continue
+		}
+		// the attributes of an unpopulated GID fail with EINVAL
+		gids = append(gids, IBGID{
+			Index:  index,
+			GID:    gid,
+			Type:   readSysfsLine(filepath.Join(portDir, "gid_attrs", "types", entry.Name())),
+			NetDev: readSysfsLine(filepath.Join(portDir, "gid_attrs", "ndevs", entry.Name())),
+		})
+	}
+	sort.Slice(gids, func(i, j int) bool { return gids[i].Index < gids[j].Index })
+	return gids
+}
+
+// GetNetMTU reads the MTU of the netdev of a port.
+func (c *IBHardWareInfo) GetNetMTU(netDev string) int {
+	if netDev == "" {
+		return 0
+	}
+	mtu, err := strconv.Atoi(readSysfsLine(hostfs.Path(NetClassPath, netDev, "mtu")))
+	if err != nil {
+		return 0
+	}
+	return mtu
+}
+
+// GetNetIPs returns the global unicast addresses of the netdev of a port,
+// the source addresses RoCE v2 GIDs are derived from.
+func (c *IBHardWareInfo) GetNetIPs(netDev string) []string {
+	if netDev == "" {
+		return nil
+	}
+	iface, err := net.InterfaceByName(netDev)
+	if err != nil {
+		logrus.WithField("component", "infiniband").Debugf("Failed to get interface %s: %v", netDev, err)
+		return nil
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		logrus.WithField("component", "infiniband").Debugf("Failed to get the addresses of %s: %v", netDev, err)
+		return nil
+	}
+	var ips []string
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || !ipNet.IP.IsGlobalUnicast() {
+			continue
+		}
+		ips = append(ips, ipNet.IP.String())
+	}
+	return ips
+}
+
+// activeMTURegexp matches the active MTU of ibv_devinfo, e.g.
+// "active_mtu:		4096 (5)".
+var activeMTURegexp = regexp.MustCompile(`active_mtu:\s+(\d+)`)
+
+// QueryActiveMTU returns the MTU negotiated on the port, which sysfs does not
+// expose. Replaced in tests.
+var QueryActiveMTU = func(ctx context.Context, IBDev string, port int) (int, error) {
+	output, err := utils.ExecCommand(ctx, "ibv_devinfo", "-d", IBDev, "-i", strconv.Itoa(port))
+	if err != nil {
+		return 0, fmt.Errorf("ibv_devinfo failed: %v, %s", err, strings.TrimSpace(string(output)))
+	}
+	return ParseActiveMTU(string(output))
+}
+
+func ParseActiveMTU(output string) (int, error) {
+	m := activeMTURegexp.FindStringSubmatch(output)
+	if m == nil {
+		return 0, fmt.Errorf("no active_mtu in ibv_devinfo output")
+	}
+	return strconv.Atoi(m[1])
+}
+
+func readSysfsLine(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
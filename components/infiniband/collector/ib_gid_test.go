@@ -0,0 +1,50 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"testing"
+)
+
+func TestParseActiveMTU(t *testing.T) {
+	output := `hca_id:	mlx5_0
+	transport:			InfiniBand (0)
+	fw_ver:				28.39.1002
+		port:	1
+			state:			PORT_ACTIVE (4)
+			max_mtu:		4096 (5)
+			active_mtu:		1024 (3)
+			sm_lid:			1
+`
+	mtu, err := ParseActiveMTU(output)
+	if err != nil || mtu != 1024 {
+		t.Fatalf("expected active MTU 1024, got %d, %v", mtu, err)
+	}
+	if _, err := ParseActiveMTU("hca_id:	mlx5_0\n"); err == nil {
+		t.Error("expected an error without active_mtu")
+	}
+}
+
+func TestIBGIDIP(t *testing.T) {
+	gid := IBGID{Index: 3, GID: "0000:0000:0000:0000:0000:ffff:0a00:0105", Type: GIDTypeRoCEv2}
+	if ip := gid.IP(); ip == nil || ip.String() != "10.0.1.5" {
+		t.Errorf("expected 10.0.1.5, got %v", ip)
+	}
+	gid = IBGID{Index: 0, GID: "fe80:0000:0000:0000:a088:c203:0051:7e2c"}
+	if ip := gid.IP(); ip == nil || !ip.IsLinkLocalUnicast() {
+		t.Errorf("expected a link local address, got %v", ip)
+	}
+}
@@ -22,13 +22,14 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"path"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/scitix/sichek/pkg/hostfs"
 	"github.com/sirupsen/logrus"
 )
 
@@ -42,12 +43,21 @@ type IBHardWareInfo struct {
 	PFGW                string         `json:"pf_gw" yaml:"pf_gw"`
 	VFSpec              string         `json:"vf_spec" yaml:"vf_spec"`
 	VFNum               string         `json:"vf_num" yaml:"vf_num"`
+	VFConfigured        int            `json:"vf_configured,omitempty" yaml:"vf_configured,omitempty"`
+	VFs                 []VFInfo       `json:"vfs,omitempty" yaml:"vfs,omitempty"`
 	PhyState            string         `json:"phy_state" yaml:"phy_state"`
 	PortState           string         `json:"port_state" yaml:"port_state"`
 	LinkLayer           string         `json:"link_layer" yaml:"link_layer"`
 	NetOperstate        string         `json:"net_operstate" yaml:"net_operstate"`
 	PortSpeed           string         `json:"port_speed" yaml:"port_speed"`
 	PortSpeedState      string         `json:"port_speed_state" yaml:"port_speed_state"`
+	LID                 string         `json:"lid,omitempty" yaml:"lid,omitempty"`
+	SMLID               string         `json:"sm_lid,omitempty" yaml:"sm_lid,omitempty"`
+	SMSL                string         `json:"sm_sl,omitempty" yaml:"sm_sl,omitempty"`
+	ActiveMTU           int            `json:"active_mtu,omitempty" yaml:"active_mtu,omitempty"`
+	NetMTU              int            `json:"net_mtu,omitempty" yaml:"net_mtu,omitempty"`
+	NetIPs              []string       `json:"net_ips,omitempty" yaml:"net_ips,omitempty"`
+	GIDs                []IBGID        `json:"gids,omitempty" yaml:"gids,omitempty"`
 	BoardID             string         `json:"board_id" yaml:"board_id"`
 	DeviceID            string         `json:"device_id" yaml:"device_id"`
 	PCIEBDF             string         `json:"pcie_bdf" yaml:"pcie_bdf"`
@@ -61,6 +71,10 @@ type IBHardWareInfo struct {
 	PCIETreeWidthMinBDF string         `json:"pcie_tree_width_bdf" yaml:"pcie_tree_width_bdf"`
 	PCIETreeLinks       []PCIETreeLink `json:"pcie_tree_links" yaml:"pcie_tree_links"`
 	PCIEMRR             string         `json:"pcie_mrr" yaml:"pcie_mrr"`
+	// ASICTemp, ASICTempCrit and CableTemp are in Celsius, 0 when the driver does not expose them
+	ASICTemp     float64 `json:"asic_temp_c,omitempty" yaml:"asic_temp_c,omitempty"`
+	ASICTempCrit float64 `json:"asic_temp_crit_c,omitempty" yaml:"asic_temp_crit_c,omitempty"`
+	CableTemp    float64 `json:"cable_temp_c,omitempty" yaml:"cable_temp_c,omitempty"`
 	// Slot             string `json:"slot" yaml:"slot"`
 	NumaNode string `json:"numa_node" yaml:"numa_node"`
 	CPULists string `json:"cpu_lists" yaml:"cpu_lists"`
@@ -69,29 +83,108 @@ type IBHardWareInfo struct {
 	OFEDVer  string `json:"ofed_ver" yaml:"ofed_ver"` // compatible with IB Spec Requirement
 }
 
+// staticAttrTTL bounds how long the attributes that only change with a
+// firmware update or a hardware replacement are served from the cache.
+const staticAttrTTL = 10 * time.Minute
+
+// ibStaticAttrs are the attributes of an IB device that do not change while
+// it runs. They are read on the first collection and reused by the following
+// ones, VPD in particular is slow to read from the adapter.
+type ibStaticAttrs struct {
+	HCAType    string
+	BoardID    string
+	DeviceID   string
+	FWVer      string
+	VPD        string
+	SystemGUID string
+	NodeGUID   string
+	PCIEBDF    string
+	NumaNode   string
+	CPULists   string
+	readAt     time.Time
+}
+
+var (
+	staticAttrsMu sync.Mutex
+	staticAttrs   = make(map[string]ibStaticAttrs)
+)
+
+// getStaticAttrs returns the static attributes of IBDev, from the cache when
+// they were read within staticAttrTTL.
+func (hw *IBHardWareInfo) getStaticAttrs(IBDev string) ibStaticAttrs {
+	staticAttrsMu.Lock()
+	attrs, ok := staticAttrs[IBDev]
+	staticAttrsMu.Unlock()
+	if ok && time.Since(attrs.readAt) < staticAttrTTL {
+		return attrs
+	}
+
+	attrs = ibStaticAttrs{
+		HCAType:    hw.GetHCAType(IBDev),
+		BoardID:    hw.GetBoardID(IBDev),
+		DeviceID:   hw.GetDeviceID(IBDev),
+		FWVer:      hw.GetFWVer(IBDev),
+		VPD:        hw.GetVPD(IBDev),
+		SystemGUID: hw.GetSystemGUID(IBDev),
+		NodeGUID:   hw.GetNodeGUID(IBDev),
+		readAt:     time.Now(),
+	}
+	if bdf := GetIBDevBDF(IBDev); len(bdf) >= 1 {
+		attrs.PCIEBDF = bdf[0]
+	}
+	if numa := GetNumaNode(IBDev); len(numa) >= 1 {
+		attrs.NumaNode = numa[0]
+	}
+	if cpus := GetCPUList(IBDev); len(cpus) >= 1 {
+		attrs.CPULists = cpus[0]
+	}
+	staticAttrsMu.Lock()
+	staticAttrs[IBDev] = attrs
+	staticAttrsMu.Unlock()
+	return attrs
+}
+
+// pruneStaticAttrs forgets the cached attributes of the devices not in
+// present, so that a replaced HCA is read again.
+func pruneStaticAttrs(present map[string]string) {
+	staticAttrsMu.Lock()
+	defer staticAttrsMu.Unlock()
+	for IBDev := range staticAttrs {
+		if _, ok := present[IBDev]; !ok {
+			delete(staticAttrs, IBDev)
+		}
+	}
+}
+
 // Collect collects all hardware information for a given IB device and fills the struct.
 // port selects which entry under /sys/class/infiniband/<dev>/ports/ is sampled
 // (multi-plane HCAs expose more than one). Pass 1 for legacy single-port cards.
+// The static attributes come from the cache, only the state of the port, the
+// link and the PCIe path is read on every collection.
 func (hw *IBHardWareInfo) Collect(ctx context.Context, IBDev string, port int, ibNicRole string) {
 	hw.IBDev = IBDev
 	hw.Port = port
 
 	// Basic device information
-	hw.HCAType = hw.GetHCAType(IBDev)
-	hw.BoardID = hw.GetBoardID(IBDev)
-	hw.DeviceID = hw.GetDeviceID(IBDev)
-	hw.FWVer = hw.GetFWVer(IBDev)
-	hw.VPD = hw.GetVPD(IBDev)
+	attrs := hw.getStaticAttrs(IBDev)
+	hw.HCAType = attrs.HCAType
+	hw.BoardID = attrs.BoardID
+	hw.DeviceID = attrs.DeviceID
+	hw.FWVer = attrs.FWVer
+	hw.VPD = attrs.VPD
 
 	// GUID information
-	hw.SystemGUID = hw.GetSystemGUID(IBDev)
-	hw.NodeGUID = hw.GetNodeGUID(IBDev)
+	hw.SystemGUID = attrs.SystemGUID
+	hw.NodeGUID = attrs.NodeGUID
 
 	// Port state information
 	hw.PhyState = hw.GetPhyStat(IBDev, port)
 	hw.PortState = hw.GetIBStat(IBDev, port)
 	hw.LinkLayer = hw.GetLinkLayer(IBDev, port)
 	hw.PortSpeed = hw.GetPortSpeed(IBDev, port)
+	if hw.LinkLayer == "InfiniBand" {
+		hw.LID, hw.SMLID, hw.SMSL = hw.GetSMAttrs(IBDev, port)
+	}
 
 	// Network device information.  For multi-plane PFs the per-port netdev
 	// lives under ports/<port>/gid_attrs/ndevs/0; fall back to the legacy
@@ -102,6 +195,18 @@ func (hw *IBHardWareInfo) Collect(ctx context.Context, IBDev string, port int, i
 	}
 	hw.NetOperstate = hw.GetNetOperstate(IBDev, hw.NetDev)
 
+	// MTU and GID table, the RoCE v2 GIDs are derived from the addresses of the netdev
+	if mtu, err := QueryActiveMTU(ctx, IBDev, port); err == nil {
+		hw.ActiveMTU = mtu
+	} else {
+		logrus.WithField("component", "infiniband").Debugf("Failed to query the active MTU of %s/p%d: %v", IBDev, port, err)
+	}
+	hw.NetMTU = hw.GetNetMTU(hw.NetDev)
+	hw.GIDs = hw.GetGIDs(IBDev, port)
+	if hw.LinkLayer == "Ethernet" {
+		hw.NetIPs = hw.GetNetIPs(hw.NetDev)
+	}
+
 	// Gateway information
 	hw.PFGW = GetIBGateway().GetPFGW(IBDev)
 
@@ -109,26 +214,25 @@ func (hw *IBHardWareInfo) Collect(ctx context.Context, IBDev string, port int, i
 	if ibNicRole == "sriovNode" {
 		hw.VFNum = hw.GetVFNum(IBDev)
 		hw.VFSpec = hw.GetVFSpec(IBDev)
+		hw.VFConfigured, hw.VFs = GetVFInfos(ctx, IBDev, hw.NetDev)
 	}
 
 	// PCIe information
-	if len(GetIBDevBDF(IBDev)) >= 1 {
-		hw.PCIEBDF = GetIBDevBDF(IBDev)[0]
-	}
+	hw.PCIEBDF = attrs.PCIEBDF
 	hw.PCIESpeed = GetPCIECLinkSpeed(IBDev)
 	hw.PCIEWidth = GetPCIECLinkWidth(IBDev)
-	if len(GetPCIEMRR(ctx, IBDev)) >= 1 {
-		hw.PCIEMRR = GetPCIEMRR(ctx, IBDev)[0]
+	if mrr := GetPCIEMRR(ctx, IBDev); len(mrr) >= 1 {
+		hw.PCIEMRR = mrr[0]
 	}
 	hw.PCIETreeLinks = GetPCIETreeLinks(IBDev)
 	hw.PCIETreeSpeedMin, hw.PCIETreeSpeedMinBDF = minLinkCurSpeed(hw.PCIETreeLinks)
 	hw.PCIETreeWidthMin, hw.PCIETreeWidthMinBDF = minLinkCurWidth(hw.PCIETreeLinks)
-	if len(GetNumaNode(IBDev)) >= 1 {
-		hw.NumaNode = GetNumaNode(IBDev)[0]
-	}
-	if len(GetCPUList(IBDev)) >= 1 {
-		hw.CPULists = GetCPUList(IBDev)[0]
-	}
+	hw.NumaNode = attrs.NumaNode
+	hw.CPULists = attrs.CPULists
+
+	// Temperature information
+	temp := GetHCATemp(IBDev, port)
+	hw.ASICTemp, hw.ASICTempCrit, hw.CableTemp = temp.ASIC, temp.ASICCrit, temp.Cable
 }
 
 // GetHCAType gets HCA type
@@ -145,7 +249,7 @@ func (c *IBHardWareInfo) GetHCAType(IBDev string) string {
 
 // GetVPD gets VPD information
 func (c *IBHardWareInfo) GetVPD(IBDev string) string {
-	vpdPath := filepath.Join(IBSYSPathPre, IBDev, "device", "vpd")
+	vpdPath := hostfs.Path(IBSYSPathPre, IBDev, "device", "vpd")
 	data, err := os.ReadFile(vpdPath)
 	if err != nil {
 		logrus.WithField("component", "infiniband").Errorf("failed to read vpd file %s, err: %v", vpdPath, err)
@@ -225,6 +329,21 @@ func (c *IBHardWareInfo) GetPortSpeed(IBDev string, port int) string {
 	return v
 }
 
+// GetSMAttrs gets the LID the subnet manager assigned to an InfiniBand port,
+// and the LID and SL of the SM the port is registered with, all 0x0 until an
+// SM configured the port.
+func (c *IBHardWareInfo) GetSMAttrs(IBDev string, port int) (lid, smLID, smSL string) {
+	var err error
+	if lid, err = readPortAttr(IBDev, port, "lid"); err != nil {
+		logrus.WithField("component", "infiniband").Errorf("Failed to read LID for %s/p%d: %v", IBDev, port, err)
+	}
+	if smLID, err = readPortAttr(IBDev, port, "sm_lid"); err != nil {
+		logrus.WithField("component", "infiniband").Errorf("Failed to read SM LID for %s/p%d: %v", IBDev, port, err)
+	}
+	smSL, _ = readPortAttr(IBDev, port, "sm_sl")
+	return lid, smLID, smSL
+}
+
 // GetLinkLayer gets link layer
 func (c *IBHardWareInfo) GetLinkLayer(IBDev string, port int) string {
 	v, err := readPortAttr(IBDev, port, "link_layer")
@@ -355,12 +474,12 @@ func (c *IBHardWareInfo) GetPortNetDev(IBDev string, port int) string {
 // to the first netdev under the IB device when empty.
 func (c *IBHardWareInfo) GetNetOperstate(IBDev string, netDev string) string {
 	if netDev != "" {
-		operstatePath := filepath.Join("/sys/class/net", netDev, "operstate")
+		operstatePath := hostfs.Path(NetClassPath, netDev, "operstate")
 		if data, err := os.ReadFile(operstatePath); err == nil {
 			return strings.TrimSpace(string(data))
 		}
 	}
-	netPath := filepath.Join(IBSYSPathPre, IBDev, "device", "net")
+	netPath := hostfs.Path(IBSYSPathPre, IBDev, "device", "net")
 	dirs, err := os.ReadDir(netPath)
 	if err != nil {
 		logrus.WithField("component", "infiniband").Errorf("failed to read net directory %s: %v", netPath, err)
@@ -382,7 +501,7 @@ func (c *IBHardWareInfo) GetNetOperstate(IBDev string, netDev string) string {
 
 // GetVFSpec gets VF specification
 func (c *IBHardWareInfo) GetVFSpec(IBDev string) string {
-	netPath := filepath.Join(IBSYSPathPre, IBDev, "device", "sriov_totalvfs")
+	netPath := hostfs.Path(IBSYSPathPre, IBDev, "device", "sriov_totalvfs")
 	data, err := os.ReadFile(netPath)
 	if err != nil {
 		logrus.WithField("component", "infiniband").Errorf("failed to read net directory %s: %v", netPath, err)
@@ -439,7 +558,7 @@ func GetNumaNode(IBDev string) []string {
 	if len(BDF) == 0 {
 		return nil
 	}
-	DesPath := path.Join(PCIPath, BDF[0], "numa_node")
+	DesPath := hostfs.Path(PCIPath, BDF[0], "numa_node")
 	numaNode, err := GetFileCnt(DesPath)
 	if err != nil {
 		logrus.WithField("component", "infiniband").Errorf("Failed to read NUMA node for %s: %v", IBDev, err)
@@ -454,7 +573,7 @@ func GetCPUList(IBDev string) []string {
 	if len(BDF) == 0 {
 		return nil
 	}
-	DesPath := path.Join(PCIPath, BDF[0], "local_cpulist")
+	DesPath := hostfs.Path(PCIPath, BDF[0], "local_cpulist")
 	CPUList, err := GetFileCnt(DesPath)
 	if err != nil {
 		logrus.WithField("component", "infiniband").Errorf("Failed to read CPU list for %s: %v", IBDev, err)
@@ -0,0 +1,183 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/utils"
+)
+
+// PhyDiag is the physical layer state of a port as read with mlxlink: the
+// BER before and after the FEC, the eye grade of the lanes and the health of
+// the module, none of which the sysfs counters expose.
+type PhyDiag struct {
+	EffectiveErrors uint64  `json:"effective_errors" yaml:"effective_errors"`
+	EffectiveBER    float64 `json:"effective_ber" yaml:"effective_ber"`
+	// RawErrors are the bit errors the FEC corrected, summed over the lanes.
+	RawErrors uint64  `json:"raw_errors" yaml:"raw_errors"`
+	RawBER    float64 `json:"raw_ber" yaml:"raw_ber"`
+	// Grades are the eye grades of the lanes, empty unless queried.
+	Grades         []float64 `json:"grades,omitempty" yaml:"grades,omitempty"`
+	Recommendation string    `json:"recommendation,omitempty" yaml:"recommendation,omitempty"`
+
+	ModuleTemp      float64   `json:"module_temp_c" yaml:"module_temp_c"`
+	TempHighAlarm   float64   `json:"temp_high_alarm_c" yaml:"temp_high_alarm_c"`
+	RxPower         []float64 `json:"rx_power_dbm,omitempty" yaml:"rx_power_dbm,omitempty"`
+	RxPowerLowAlarm float64   `json:"rx_power_low_alarm_dbm" yaml:"rx_power_low_alarm_dbm"`
+	TxPower         []float64 `json:"tx_power_dbm,omitempty" yaml:"tx_power_dbm,omitempty"`
+	TxPowerLowAlarm float64   `json:"tx_power_low_alarm_dbm" yaml:"tx_power_low_alarm_dbm"`
+}
+
+// PhyDiagTool returns mlxlink, or mstlink of mstflint when MFT is not
+// installed.
+func PhyDiagTool(ctx context.Context) (string, error) {
+	for _, tool := range []string{"mlxlink", "mstlink"} {
+		if _, err := utils.ExecCommand(ctx, "which", tool); err == nil {
+			return tool, nil
+		}
+	}
+	return "", fmt.Errorf("neither mlxlink nor mstlink is installed")
+}
+
+// QueryPhyDiag reads the BER counters and the module of the port with tool,
+// and the eye grades of its lanes when eye is set.
+func QueryPhyDiag(ctx context.Context, tool string, IBDev string, port int, eye bool) (*PhyDiag, error) {
+	cmdCtx, cancel := context.WithTimeout(ctx, consts.CmdTimeout)
+	defer cancel()
+	args := []string{"-d", IBDev, "-p", strconv.Itoa(port), "-c", "-m"}
+	output, err := utils.ExecCommand(cmdCtx, tool, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %v, %s", tool, err, strings.TrimSpace(string(output)))
+	}
+	diag, err := ParsePhyDiag(string(output))
+	if err != nil || !eye {
+		return diag, err
+	}
+	// the eye is read apart, the tools refuse it on the speeds without eye
+	// measurement and would fail the BER query with it
+	eyeCtx, eyeCancel := context.WithTimeout(ctx, consts.CmdTimeout)
+	defer eyeCancel()
+	output, err = utils.ExecCommand(eyeCtx, tool, "-d", IBDev, "-p", strconv.Itoa(port), "--show_eye")
+	if err != nil {
+		return diag, nil
+	}
+	if eyeDiag, err := ParsePhyDiag(string(output)); err == nil {
+		diag.Grades = eyeDiag.Grades
+	}
+	return diag, nil
+}
+
+// ParsePhyDiag parses the "key : value" lines of mlxlink, e.g.
+//
+//	Recommendation                  : No issue was observed
+//	Effective Physical Errors       : 0
+//	Effective Physical BER          : 15E-255
+//	Raw Physical Errors Per Lane    : 12,0,3,0
+//	Raw Physical BER                : 3E-11
+//	Physical Grade                  : 13516,13298,13407,13621
+//	Temperature [C]                 : 54 [-5..75]
+//	Rx Power Current [dBm]          : 1.886,1.989,2.281,1.976 [-10.41..6]
+//	Tx Power Current [dBm]          : 1.562,1.427,1.559,1.761 [-8.508..5]
+//
+// It fails when the output has neither BER counters nor eye grades.
+func ParsePhyDiag(output string) (*PhyDiag, error) {
+	diag := &PhyDiag{}
+	found := false
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		switch {
+		case key == "Recommendation":
+			diag.Recommendation = value
+		case key == "Effective Physical Errors":
+			diag.EffectiveErrors, _ = strconv.ParseUint(value, 10, 64)
+			found = true
+		case key == "Effective Physical BER":
+			diag.EffectiveBER = parseBER(value)
+			found = true
+		case key == "Raw Physical Errors Per Lane":
+			for _, lane := range parseLaneValues(value) {
+				diag.RawErrors += uint64(lane)
+			}
+			found = true
+		case key == "Raw Physical BER":
+			diag.RawBER = parseBER(value)
+			found = true
+		case key == "Physical Grade":
+			diag.Grades = parseLaneValues(value)
+			found = found || len(diag.Grades) > 0
+		case strings.HasPrefix(key, "Temperature"):
+			values, _, high := parseValueRange(value)
+			if len(values) > 0 {
+				diag.ModuleTemp = values[0]
+			}
+			diag.TempHighAlarm = high
+		case strings.HasPrefix(key, "Rx Power Current"):
+			diag.RxPower, diag.RxPowerLowAlarm, _ = parseValueRange(value)
+		case strings.HasPrefix(key, "Tx Power Current"):
+			diag.TxPower, diag.TxPowerLowAlarm, _ = parseValueRange(value)
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("no BER counters in the output")
+	}
+	return diag, nil
+}
+
+// parseBER parses a BER such as "15E-255", a BER that cannot be parsed, e.g.
+// "N/A", is 0.
+func parseBER(value string) float64 {
+	ber, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0
+	}
+	return ber
+}
+
+// parseValueRange parses "1.886,1.989 [-10.41..6]" into the lane values and
+// the alarm range.
+func parseValueRange(value string) (values []float64, low, high float64) {
+	valuePart, rangePart, _ := strings.Cut(value, "[")
+	values = parseLaneValues(valuePart)
+	lowStr, highStr, ok := strings.Cut(strings.TrimSuffix(strings.TrimSpace(rangePart), "]"), "..")
+	if ok {
+		low, _ = strconv.ParseFloat(strings.TrimSpace(lowStr), 64)
+		high, _ = strconv.ParseFloat(strings.TrimSpace(highStr), 64)
+	}
+	return values, low, high
+}
+
+// parseLaneValues parses comma separated lane values, skipping "N/A".
+func parseLaneValues(value string) []float64 {
+	var values []float64
+	for _, field := range strings.Split(value, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			continue
+		}
+		values = append(values, v)
+	}
+	return values
+}
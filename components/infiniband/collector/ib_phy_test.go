@@ -0,0 +1,71 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParsePhyDiag(t *testing.T) {
+	output := `
+Operational Info
+----------------
+State                              : Active
+Physical state                     : LinkUp
+Speed                              : IB-NDR
+FEC                                : Standard_RS-FEC - (544,514)
+
+Troubleshooting Info
+--------------------
+Status Opcode                      : 0
+Recommendation                     : No issue was observed
+
+Physical Counters and BER Info
+------------------------------
+Time Since Last Clear [Min]        : 7241.4
+Effective Physical Errors          : 0
+Effective Physical BER             : 15E-255
+Raw Physical Errors Per Lane       : 12,0,3,N/A
+Raw Physical BER                   : 3E-11
+Link Down Counter                  : 1
+
+Module Info
+-----------
+Temperature [C]                    : 54 [-5..75]
+Rx Power Current [dBm]             : 1.886,1.989,2.281,1.976 [-10.41..6]
+Tx Power Current [dBm]             : 1.562,1.427,1.559,1.761 [-8.508..5]
+`
+	diag, err := ParsePhyDiag(output)
+	if err != nil {
+		t.Fatalf("ParsePhyDiag: %v", err)
+	}
+	if diag.EffectiveBER != 15e-255 || diag.RawBER != 3e-11 || diag.RawErrors != 15 || diag.Recommendation != "No issue was observed" {
+		t.Errorf("unexpected BER %+v", diag)
+	}
+	if diag.ModuleTemp != 54 || diag.TempHighAlarm != 75 || diag.RxPowerLowAlarm != -10.41 || !slices.Equal(diag.TxPower, []float64{1.562, 1.427, 1.559, 1.761}) {
+		t.Errorf("unexpected module %+v", diag)
+	}
+
+	eye, err := ParsePhyDiag("Physical Grade                     : 13516,13298,13407,13621\n")
+	if err != nil || !slices.Equal(eye.Grades, []float64{13516, 13298, 13407, 13621}) {
+		t.Errorf("unexpected eye grades %+v, %v", eye, err)
+	}
+
+	if _, err := ParsePhyDiag("-E- Failed to open device\n"); err == nil {
+		t.Error("expected an error without BER counters")
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/sirupsen/logrus"
+)
+
+// GetIBDevPorts returns the port numbers under /sys/class/infiniband/<IBDev>/ports/, sorted.
+func GetIBDevPorts(IBDev string) []int {
+	portsDir := hostfs.Path(IBSYSPathPre, IBDev, "ports")
+	entries, err := os.ReadDir(portsDir)
+	if err != nil {
+		logrus.WithField("component", "infiniband").Errorf("failed to read ports directory %s: %v", portsDir, err)
+		return nil
+	}
+	var ports []int
+	for _, entry := range entries {
+		port, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+	return ports
+}
+
+// isPortActive reports whether the port is ACTIVE or its physical link is up.
+func isPortActive(IBDev string, port int) bool {
+	if state, err := readPortAttr(IBDev, port, "state"); err == nil && strings.Contains(state, "ACTIVE") {
+		return true
+	}
+	phyState, err := readPortAttr(IBDev, port, "phys_state")
+	return err == nil && strings.Contains(phyState, "LinkUp")
+}
+
+// portDiscovery finds the ports to sample of the IB devices whose ports are
+// not set in the spec, e.g. the second port of a dual-port CX-7 or BF-3. The
+// lowest port is always sampled, as the legacy port 1 was, and the other ones
+// once they were seen active, so that an unused port is not reported down
+// while a port that goes down afterwards keeps being checked.
+type portDiscovery struct {
+	mu   sync.Mutex
+	seen map[string]map[int]bool
+}
+
+func newPortDiscovery() *portDiscovery {
+	return &portDiscovery{seen: make(map[string]map[int]bool)}
+}
+
+// Ports returns the ports to sample of IBDev, port 1 when it has none.
+func (d *portDiscovery) Ports(IBDev string) []int {
+	all := GetIBDevPorts(IBDev)
+	if len(all) == 0 {
+		return []int{1}
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	seen, ok := d.seen[IBDev]
+	if !ok {
+		seen = make(map[int]bool)
+		d.seen[IBDev] = seen
+	}
+	ports := []int{all[0]}
+	for _, port := range all[1:] {
+		if !seen[port] && isPortActive(IBDev, port) {
+			logrus.WithField("component", "infiniband").Infof("discovered active port %s", HWInfoKey(IBDev, port))
+			seen[port] = true
+		}
+		if seen[port] {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+// prune forgets the ports of the devices not in present, so that a replaced
+// HCA is discovered again.
+func (d *portDiscovery) prune(present map[string]string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for IBDev := range d.seen {
+		if _, ok := present[IBDev]; !ok {
+			delete(d.seen, IBDev)
+		}
+	}
+}
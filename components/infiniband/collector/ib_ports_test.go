@@ -0,0 +1,91 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/scitix/sichek/pkg/hostfs/hostfstest"
+)
+
+func TestPortDiscovery(t *testing.T) {
+	hostfstest.Build(t, `
+# dual-port BF-3 with its second port not cabled yet
+-- sys/class/infiniband/mlx5_0/ports/1/state --
+4: ACTIVE
+-- sys/class/infiniband/mlx5_0/ports/1/phys_state --
+5: LinkUp
+-- sys/class/infiniband/mlx5_0/ports/2/state --
+1: DOWN
+-- sys/class/infiniband/mlx5_0/ports/2/phys_state --
+2: Polling
+-- sys/class/infiniband/mlx5_1/ports/1/state --
+1: DOWN
+-- sys/class/infiniband/mlx5_1/ports/1/phys_state --
+3: Disabled
+`)
+	if ports := GetIBDevPorts("mlx5_0"); !reflect.DeepEqual(ports, []int{1, 2}) {
+		t.Fatalf("expected ports 1 and 2 of mlx5_0, got %v", ports)
+	}
+
+	d := newPortDiscovery()
+	if ports := d.Ports("mlx5_0"); !reflect.DeepEqual(ports, []int{1}) {
+		t.Errorf("expected the unused port 2 to be left out, got %v", ports)
+	}
+	if ports := d.Ports("mlx5_1"); !reflect.DeepEqual(ports, []int{1}) {
+		t.Errorf("expected the lowest port to be sampled even when down, got %v", ports)
+	}
+	if ports := d.Ports("mlx5_2"); !reflect.DeepEqual(ports, []int{1}) {
+		t.Errorf("expected port 1 for a device without ports, got %v", ports)
+	}
+
+	hostfstest.WriteFile(t, "/sys/class/infiniband/mlx5_0/ports/2/phys_state", "5: LinkUp\n")
+	if ports := d.Ports("mlx5_0"); !reflect.DeepEqual(ports, []int{1, 2}) {
+		t.Errorf("expected port 2 once its link is up, got %v", ports)
+	}
+	hostfstest.WriteFile(t, "/sys/class/infiniband/mlx5_0/ports/2/phys_state", "3: Disabled\n")
+	if ports := d.Ports("mlx5_0"); !reflect.DeepEqual(ports, []int{1, 2}) {
+		t.Errorf("expected port 2 to keep being sampled once down, got %v", ports)
+	}
+
+	d.prune(map[string]string{"mlx5_1": ""})
+	if ports := d.Ports("mlx5_0"); !reflect.DeepEqual(ports, []int{1}) {
+		t.Errorf("expected the ports of a replaced device to be discovered again, got %v", ports)
+	}
+}
+
+func TestResolvePorts(t *testing.T) {
+	hostfstest.Build(t, `
+-- sys/class/infiniband/mlx5_0/ports/1/state --
+4: ACTIVE
+-- sys/class/infiniband/mlx5_0/ports/2/state --
+4: ACTIVE
+`)
+	i := &InfinibandInfo{discovery: newPortDiscovery()}
+	if ports := i.resolvePorts("mlx5_0"); !reflect.DeepEqual(ports, []int{1, 2}) {
+		t.Errorf("expected the discovered ports, got %v", ports)
+	}
+	i.SetPortResolver(func(IBDev string) []int {
+		if IBDev == "mlx5_0" {
+			return []int{2}
+		}
+		return nil
+	})
+	if ports := i.resolvePorts("mlx5_0"); !reflect.DeepEqual(ports, []int{2}) {
+		t.Errorf("expected the ports of the spec, got %v", ports)
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/scitix/sichek/pkg/utils"
+)
+
+// SMInfo is the master subnet manager of the fabric of a port as queried
+// with sminfo.
+type SMInfo struct {
+	LID           string `json:"lid" yaml:"lid"`
+	GUID          string `json:"guid" yaml:"guid"`
+	ActivityCount uint64 `json:"activity_count" yaml:"activity_count"`
+	Priority      int    `json:"priority" yaml:"priority"`
+	// State is the SM state, e.g. SMINFO_MASTER or SMINFO_STANDBY.
+	State string `json:"state" yaml:"state"`
+}
+
+// sminfoRegexp matches the output of sminfo, e.g. "sminfo: sm lid 1 sm guid
+// 0x248a070300f0d2c0, activity count 236755 priority 15 state 3 SMINFO_MASTER".
+var sminfoRegexp = regexp.MustCompile(`sm lid (\d+) sm guid (0x[0-9a-fA-F]+), activity count (\d+) priority (\d+) state \d+ (\S+)`)
+
+// QuerySMInfo asks the master SM of the fabric of the port for its SMInfo.
+func QuerySMInfo(ctx context.Context, IBDev string, port int) (*SMInfo, error) {
+	output, err := utils.ExecCommand(ctx, "sminfo", "-C", IBDev, "-P", strconv.Itoa(port))
+	if err != nil {
+		return nil, fmt.Errorf("sminfo failed: %v, %s", err, strings.TrimSpace(string(output)))
+	}
+	return ParseSMInfo(string(output))
+}
+
+func ParseSMInfo(output string) (*SMInfo, error) {
+	m := sminfoRegexp.FindStringSubmatch(output)
+	if m == nil {
+		return nil, fmt.Errorf("unexpected sminfo output: %s", strings.TrimSpace(output))
+	}
+	activity, _ := strconv.ParseUint(m[3], 10, 64)
+	priority, _ := strconv.Atoi(m[4])
+	return &SMInfo{
+		LID:           m[1],
+		GUID:          strings.ToLower(m[2]),
+		ActivityCount: activity,
+		Priority:      priority,
+		State:         m[5],
+	}, nil
+}
+
+// ParseLID parses a LID as exposed in sysfs, e.g. "0x1a", or as printed by
+// the diag tools, e.g. "26".
+func ParseLID(lid string) (uint64, bool) {
+	lid = strings.TrimSpace(lid)
+	if lid == "" {
+		return 0, false
+	}
+	value, err := strconv.ParseUint(lid, 0, 16)
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
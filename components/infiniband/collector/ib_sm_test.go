@@ -0,0 +1,45 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import "testing"
+
+func TestParseSMInfo(t *testing.T) {
+	sm, err := ParseSMInfo("sminfo: sm lid 1 sm guid 0x248A070300F0D2C0, activity count 236755 priority 15 state 3 SMINFO_MASTER\n")
+	if err != nil {
+		t.Fatalf("ParseSMInfo: %v", err)
+	}
+	want := SMInfo{LID: "1", GUID: "0x248a070300f0d2c0", ActivityCount: 236755, Priority: 15, State: "SMINFO_MASTER"}
+	if *sm != want {
+		t.Errorf("ParseSMInfo = %+v, want %+v", *sm, want)
+	}
+	if _, err := ParseSMInfo("ibwarn: [1234] mad_rpc_open_port: can't open UMAD port"); err == nil {
+		t.Error("expected an error for an unexpected output")
+	}
+}
+
+func TestParseLID(t *testing.T) {
+	for lid, want := range map[string]uint64{"0x1a": 26, "0x0": 0, "26": 26, " 0x1\n": 1} {
+		if got, ok := ParseLID(lid); !ok || got != want {
+			t.Errorf("ParseLID(%q) = %d, %t, want %d", lid, got, ok, want)
+		}
+	}
+	for _, lid := range []string{"", "lid", "0x10000"} {
+		if _, ok := ParseLID(lid); ok {
+			t.Errorf("ParseLID(%q) should fail", lid)
+		}
+	}
+}
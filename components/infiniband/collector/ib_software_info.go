@@ -23,6 +23,7 @@ import (
 	"os/exec"
 	"strings"
 
+	"github.com/scitix/sichek/pkg/hostfs"
 	"github.com/scitix/sichek/pkg/utils"
 
 	"github.com/sirupsen/logrus"
@@ -50,7 +51,7 @@ func (sw *IBSoftWareInfo) GetOFEDInfo(ctx context.Context) string {
 		}
 	}
 
-	if data, err := os.ReadFile("/sys/module/mlx5_core/version"); err == nil {
+	if data, err := os.ReadFile(hostfs.Path("/sys/module/mlx5_core/version")); err == nil {
 		if ver := strings.TrimSpace(string(data)); ver != "" {
 			return fmt.Sprintf("rdma_core:%s", ver)
 		}
@@ -89,15 +90,15 @@ func (sw *IBSoftWareInfo) GetKernelModule() []string {
 }
 
 func IsModuleLoaded(moduleName string) bool {
-	file, err := os.Open("/proc/modules")
+	file, err := os.Open(hostfs.Path("/proc/modules"))
 	if err != nil {
-		fmt.Printf("Unable to open the /proc/modules file: %v\n", err)
+		logrus.WithField("component", "infiniband").Errorf("Unable to open the /proc/modules file: %v", err)
 		return false
 	}
 
 	defer func() {
 		if closeErr := file.Close(); closeErr != nil {
-			fmt.Printf("Error closing file: %v\n", closeErr)
+			logrus.WithField("component", "infiniband").Errorf("Error closing file: %v", closeErr)
 		}
 	}()
 
@@ -115,7 +116,7 @@ func checkModuleInFile(moduleName string, file *os.File) bool {
 	}
 
 	if err := scanner.Err(); err != nil {
-		fmt.Printf("An error occurred while reading the file: %v\n", err)
+		logrus.WithField("component", "infiniband").Errorf("An error occurred while reading the file: %v", err)
 	}
 
 	return false
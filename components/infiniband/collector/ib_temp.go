@@ -0,0 +1,110 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/scitix/sichek/pkg/hostfs"
+)
+
+// HCATemp is the temperature of the ASIC of an HCA and of the cable module
+// of a port, read from the hwmon of its PCI device, which mlx5 registers
+// since Linux 6.5. A temperature the driver does not expose is 0.
+type HCATemp struct {
+	ASIC float64
+	// ASICCrit is the critical temperature of the ASIC the firmware reports
+	ASICCrit float64
+	Cable    float64
+}
+
+// hwmonSensor is a temp<N>_* sensor of a hwmon device.
+type hwmonSensor struct {
+	index int
+	label string
+	input float64
+	crit  float64
+}
+
+// GetHCATemp reads the temperatures of IBDev and of the cable of its port.
+// The ASIC sensor is labelled "asic", or is temp1 without labels, and the
+// module sensors are labelled "module<N>", the module of port p being N=p-1
+// unless the PCI function has a single module.
+func GetHCATemp(IBDev string, port int) HCATemp {
+	var temp HCATemp
+	sensors := readHwmonSensors(hostfs.Path(IBSYSPathPre, IBDev, "device", "hwmon"))
+	modules := make(map[int]float64)
+	for _, sensor := range sensors {
+		switch {
+		case sensor.label == "asic" || (sensor.label == "" && sensor.index == 1):
+			temp.ASIC, temp.ASICCrit = sensor.input, sensor.crit
+		case strings.HasPrefix(sensor.label, "module"):
+			if module, err := strconv.Atoi(strings.TrimPrefix(sensor.label, "module")); err == nil {
+				modules[module] = sensor.input
+			}
+		}
+	}
+	if len(modules) == 1 {
+		for _, cable := range modules {
+			temp.Cable = cable
+		}
+	} else if cable, ok := modules[port-1]; ok {
+		temp.Cable = cable
+	}
+	return temp
+}
+
+// readHwmonSensors reads the temperature sensors of the hwmon devices under
+// dir, in millidegrees Celsius in sysfs.
+func readHwmonSensors(dir string) []hwmonSensor {
+	inputs, _ := filepath.Glob(filepath.Join(dir, "hwmon*", "temp*_input"))
+	sort.Strings(inputs)
+	sensors := make([]hwmonSensor, 0, len(inputs))
+	for _, input := range inputs {
+		prefix := strings.TrimSuffix(input, "_input")
+		index, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(prefix), "temp"))
+		if err != nil {
+			continue
+		}
+		value, ok := readMilliCelsius(input)
+		if !ok {
+			continue
+		}
+		sensor := hwmonSensor{index: index, input: value}
+		if label, err := os.ReadFile(prefix + "_label"); err == nil {
+			sensor.label = strings.ToLower(strings.TrimSpace(string(label)))
+		}
+		sensor.crit, _ = readMilliCelsius(prefix + "_crit")
+		sensors = append(sensors, sensor)
+	}
+	return sensors
+}
+
+func readMilliCelsius(path string) (float64, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	value, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return float64(value) / 1000, true
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"testing"
+
+	"github.com/scitix/sichek/pkg/hostfs/hostfstest"
+)
+
+func TestGetHCATemp(t *testing.T) {
+	hostfstest.Build(t, `
+-- sys/class/infiniband/mlx5_0/device/hwmon/hwmon3/temp1_input --
+71000
+-- sys/class/infiniband/mlx5_0/device/hwmon/hwmon3/temp1_label --
+asic
+-- sys/class/infiniband/mlx5_0/device/hwmon/hwmon3/temp1_crit --
+105000
+-- sys/class/infiniband/mlx5_0/device/hwmon/hwmon3/temp2_input --
+52500
+-- sys/class/infiniband/mlx5_0/device/hwmon/hwmon3/temp2_label --
+module0
+-- sys/class/infiniband/mlx5_1/device/hwmon/hwmon4/temp1_input --
+64000
+-- sys/class/infiniband/mlx5_1/device/hwmon/hwmon4/temp2_input --
+40000
+-- sys/class/infiniband/mlx5_1/device/hwmon/hwmon4/temp2_label --
+module0
+-- sys/class/infiniband/mlx5_1/device/hwmon/hwmon4/temp3_input --
+45000
+-- sys/class/infiniband/mlx5_1/device/hwmon/hwmon4/temp3_label --
+module1
+-- sys/class/infiniband/mlx5_2/device/uevent --
+PCI_SLOT_NAME=0000:29:00.0
+`)
+	if temp := GetHCATemp("mlx5_0", 1); temp != (HCATemp{ASIC: 71, ASICCrit: 105, Cable: 52.5}) {
+		t.Errorf("unexpected mlx5_0 temperature %+v", temp)
+	}
+	// the ASIC without label is temp1, the module of port 2 is module1
+	if temp := GetHCATemp("mlx5_1", 2); temp != (HCATemp{ASIC: 64, Cable: 45}) {
+		t.Errorf("unexpected mlx5_1 temperature %+v", temp)
+	}
+	if temp := GetHCATemp("mlx5_2", 1); temp != (HCATemp{}) {
+		t.Errorf("expected no temperature without hwmon, got %+v", temp)
+	}
+}
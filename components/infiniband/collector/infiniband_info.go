@@ -21,12 +21,12 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"path"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/pkg/hostfs"
 	"github.com/scitix/sichek/pkg/utils"
 
 	"github.com/sirupsen/logrus"
@@ -39,9 +39,17 @@ func HWInfoKey(IBDev string, port int) string {
 	return fmt.Sprintf("%s/p%d", IBDev, port)
 }
 
+// maxCollectWorkers bounds the ports collected at the same time, each of
+// them reads the PCIe config space and execs ethtool.
+const maxCollectWorkers = 8
+
+// collectPortTimeout bounds the collection of a port, a var for tests.
+var collectPortTimeout = 10 * time.Second
+
 // PortResolver returns the list of port numbers to sample under
-// /sys/class/infiniband/<IBDev>/ports/.  Wiring the spec.PortsFor as a
-// resolver lets the collector stay free of a config-package import.
+// /sys/class/infiniband/<IBDev>/ports/, or nil to let the collector discover
+// them.  Wiring the spec.PortsFor as a resolver lets the collector stay free
+// of a config-package import.
 type PortResolver func(IBDev string) []int
 
 type InfinibandInfo struct {
@@ -56,6 +64,8 @@ type InfinibandInfo struct {
 	IBNicRole    string                `json:"ib_nic_role" yaml:"ib_nic_role"`
 	Time         time.Time             `json:"time" yaml:"time"`
 	portResolver PortResolver
+	discovery    *portDiscovery
+	vfPods       *vfPodResolver
 	mu           sync.RWMutex
 }
 
@@ -66,6 +76,8 @@ func NewIBCollector(ctx context.Context) (*InfinibandInfo, error) {
 		// PCIETreeInfo:   make(map[string]PCIETreeInfo),
 		IBPFDevs:   make(map[string]string),
 		IBCounters: make(map[string]IBCounters),
+		discovery:  newPortDiscovery(),
+		vfPods:     newVFPodResolver(),
 		mu:         sync.RWMutex{},
 	}
 	i.IBNicRole = i.GetNICRole()
@@ -81,8 +93,8 @@ func NewIBCollector(ctx context.Context) (*InfinibandInfo, error) {
 }
 
 // SetPortResolver installs a port resolver so Collect samples the configured
-// ports for each device.  Pass nil (or never call this) to fall back to
-// reading port 1 only — preserving legacy single-port behaviour.
+// ports for each device.  Pass nil (or never call this) to discover the ports
+// of every device, see portDiscovery.
 func (i *InfinibandInfo) SetPortResolver(r PortResolver) {
 	i.mu.Lock()
 	defer i.mu.Unlock()
@@ -95,6 +107,9 @@ func (i *InfinibandInfo) resolvePorts(IBDev string) []int {
 			return ports
 		}
 	}
+	if i.discovery != nil {
+		return i.discovery.Ports(IBDev)
+	}
 	return []int{1}
 }
 
@@ -142,6 +157,8 @@ func (i *InfinibandInfo) Collect(ctx context.Context) (common.Info, error) {
 		IBPCIDevs:       i.IBPCIDevs,
 		IBCapablePCINum: i.IBCapablePCINum,
 		portResolver:    i.portResolver,
+		discovery:       i.discovery,
+		vfPods:          i.vfPods,
 	}
 
 	newInfo.IBPFDevs = i.GetIBPFdevs()
@@ -170,6 +187,7 @@ func (i *InfinibandInfo) Collect(ctx context.Context) (common.Info, error) {
 	newInfo.IBSoftWareInfo.Collect(ctx)
 
 	// // IBPFDevs is the list of IB PF devices, ignoring cx4 and virtual functions and bond devices
+	var jobs []portJob
 	for IBDev := range newInfo.IBPFDevs {
 		// skip mezzanine card
 		if strings.Contains(IBDev, "mezz") {
@@ -181,7 +199,7 @@ func (i *InfinibandInfo) Collect(ctx context.Context) (common.Info, error) {
 		if len(bdfList) > 0 {
 			bdf := bdfList[0]
 			if len(bdf) > 0 && strings.HasSuffix(bdf, ".1") {
-				netDir := fmt.Sprintf("/sys/bus/pci/devices/%s/net", bdf)
+				netDir := hostfs.Path(PCIPath, bdf, "net")
 				files, err := os.ReadDir(netDir)
 				if err != nil {
 					logrus.WithField("component", "infiniband").Errorf("Error reading net dir (driver loaded?): %v", err)
@@ -192,7 +210,7 @@ func (i *InfinibandInfo) Collect(ctx context.Context) (common.Info, error) {
 					logrus.WithField("component", "infiniband").Errorf("No network interface found for this BDF: %s", bdf)
 					continue
 				}
-				masterPath := fmt.Sprintf("/sys/class/net/%s/master", files[0].Name())
+				masterPath := hostfs.Path(NetClassPath, files[0].Name(), "master")
 				_, err = os.Lstat(masterPath)
 				if os.IsNotExist(err) {
 					continue
@@ -201,21 +219,85 @@ func (i *InfinibandInfo) Collect(ctx context.Context) (common.Info, error) {
 		}
 
 		for _, port := range newInfo.resolvePorts(IBDev) {
-			var hwInfo IBHardWareInfo
-			hwInfo.Collect(ctx, IBDev, port, newInfo.IBNicRole)
-			key := HWInfoKey(IBDev, port)
-			newInfo.IBHardWareInfo[key] = hwInfo
-
-			counters := make(IBCounters)
-			counters.Collect(IBDev, port)
-			newInfo.IBCounters[key] = counters
+			jobs = append(jobs, portJob{IBDev: IBDev, Port: port})
+		}
+	}
+	pruneStaticAttrs(newInfo.IBPFDevs)
+	if newInfo.discovery != nil {
+		newInfo.discovery.prune(newInfo.IBPFDevs)
+	}
+
+	samples := collectPorts(ctx, jobs, func(ctx context.Context, job portJob) portSample {
+		var hwInfo IBHardWareInfo
+		hwInfo.Collect(ctx, job.IBDev, job.Port, newInfo.IBNicRole)
+		counters := make(IBCounters)
+		counters.Collect(job.IBDev, job.Port)
+		if hwInfo.LinkLayer == "Ethernet" && hwInfo.NetDev != "" {
+			counters.CollectPauseCounters(ctx, hwInfo.NetDev)
 		}
+		return portSample{HWInfo: hwInfo, Counters: counters}
+	})
+	for key, sample := range samples {
+		newInfo.IBHardWareInfo[key] = sample.HWInfo
+		newInfo.IBCounters[key] = sample.Counters
+	}
+	if newInfo.IBNicRole == "sriovNode" && newInfo.vfPods != nil {
+		// attribute the VFs to the pods consuming them
+		newInfo.vfPods.Resolve(ctx, newInfo.IBHardWareInfo)
 	}
 
 	newInfo.Time = time.Now()
 	return newInfo, nil
 }
 
+// portJob is a port of an IB device to collect.
+type portJob struct {
+	IBDev string
+	Port  int
+}
+
+// portSample is the state of a port collected by a worker.
+type portSample struct {
+	HWInfo   IBHardWareInfo
+	Counters IBCounters
+}
+
+// collectPorts collects the ports with at most maxCollectWorkers at a time,
+// keyed by HWInfoKey. A port that takes longer than collectPortTimeout, e.g.
+// a config space read hung on a broken PCIe link, is left out of this collection instead
+// of delaying the others.
+func collectPorts(ctx context.Context, jobs []portJob, collect func(context.Context, portJob) portSample) map[string]portSample {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		samples = make(map[string]portSample, len(jobs))
+		workers = make(chan struct{}, maxCollectWorkers)
+	)
+	for _, job := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			workers <- struct{}{}
+			defer func() { <-workers }()
+
+			jobCtx, cancel := context.WithTimeout(ctx, collectPortTimeout)
+			defer cancel()
+			done := make(chan portSample, 1)
+			go func() { done <- collect(jobCtx, job) }()
+			select {
+			case sample := <-done:
+				mu.Lock()
+				samples[HWInfoKey(job.IBDev, job.Port)] = sample
+				mu.Unlock()
+			case <-jobCtx.Done():
+				logrus.WithField("component", "infiniband").Warnf("collect %s timed out: %v", HWInfoKey(job.IBDev, job.Port), jobCtx.Err())
+			}
+		}()
+	}
+	wg.Wait()
+	return samples
+}
+
 // countHCAPCINum counts the number of HCA PCI devices
 // Note: When RDMA bonding (mlx5 bonding) is enabled, the IB core registers a single logical IB device.
 // Therefore, only one PCI function contains the infiniband/ directory,
@@ -237,7 +319,7 @@ func countHCAPCINum(ibPFDevs map[string]string) int {
 }
 
 func ignoreVirtualFunction(ibDev string) bool {
-	vfPath := path.Join(IBSYSPathPre, ibDev, "device", "physfn")
+	vfPath := hostfs.Path(IBSYSPathPre, ibDev, "device", "physfn")
 	if _, err := os.Stat(vfPath); err == nil {
 		logrus.WithField("component", "infiniband").
 			Infof("ignoring virtual function IBDev: %s", ibDev)
@@ -269,7 +351,7 @@ func (i *InfinibandInfo) GetPFDevs(IBDevs []string) []string {
 
 // GetIBPFdevs Get IB PF devices igoring virtual functions and bond devices
 func (i *InfinibandInfo) GetIBPFdevs() map[string]string {
-	allIBDevs, err := GetFileCnt(IBSYSPathPre)
+	allIBDevs, err := GetFileCnt(hostfs.Path(IBSYSPathPre))
 	if err != nil {
 		logrus.WithField("component", "infiniband").Errorf("Failed to read IB devices directory: %v", err)
 		return make(map[string]string)
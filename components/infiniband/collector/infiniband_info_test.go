@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/pkg/hostfs/hostfstest"
+)
+
+func TestCollectPorts(t *testing.T) {
+	oldTimeout := collectPortTimeout
+	collectPortTimeout = 200 * time.Millisecond
+	defer func() { collectPortTimeout = oldTimeout }()
+
+	var jobs []portJob
+	for i := 0; i < 20; i++ {
+		jobs = append(jobs, portJob{IBDev: "mlx5_" + string(rune('a'+i)), Port: 1})
+	}
+	jobs = append(jobs, portJob{IBDev: "mlx5_hung", Port: 1})
+
+	var running, maxRunning atomic.Int32
+	samples := collectPorts(context.Background(), jobs, func(ctx context.Context, job portJob) portSample {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			old := maxRunning.Load()
+			if n <= old || maxRunning.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		if job.IBDev == "mlx5_hung" {
+			<-time.After(time.Second)
+		} else {
+			time.Sleep(10 * time.Millisecond)
+		}
+		return portSample{HWInfo: IBHardWareInfo{IBDev: job.IBDev, Port: job.Port}}
+	})
+
+	if len(samples) != 20 {
+		t.Errorf("expected the 20 ports that did not hang, got %d", len(samples))
+	}
+	if _, ok := samples[HWInfoKey("mlx5_hung", 1)]; ok {
+		t.Error("expected the hung port to be left out")
+	}
+	if sample := samples[HWInfoKey("mlx5_a", 1)]; sample.HWInfo.IBDev != "mlx5_a" {
+		t.Errorf("unexpected sample %+v", sample.HWInfo)
+	}
+	if maxRunning.Load() > maxCollectWorkers {
+		t.Errorf("expected at most %d ports collected at a time, got %d", maxCollectWorkers, maxRunning.Load())
+	}
+}
+
+func TestCollectFixture(t *testing.T) {
+	hostfstest.Load(t, "h100-cx7")
+	// the static attributes cached by other tests are not of the fixture
+	pruneStaticAttrs(nil)
+	t.Cleanup(func() { pruneStaticAttrs(nil) })
+	// lose the link of mlx5_1 to check that the state is read on every collection
+	hostfstest.WriteFile(t, "/sys/class/infiniband/mlx5_1/ports/1/state", "1: DOWN\n")
+	savedQueryActiveMTU := QueryActiveMTU
+	QueryActiveMTU = func(ctx context.Context, IBDev string, port int) (int, error) { return 4096, nil }
+	t.Cleanup(func() { QueryActiveMTU = savedQueryActiveMTU })
+
+	ibCollector, err := NewIBCollector(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ibCollector.IBCapablePCINum != 2 {
+		t.Errorf("expected 2 RDMA capable PCI devices, got %d: %v", ibCollector.IBCapablePCINum, ibCollector.IBPCIDevs)
+	}
+	info, err := ibCollector.Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ibInfo := info.(*InfinibandInfo)
+	if len(ibInfo.IBPFDevs) != 2 || ibInfo.IBPFDevs["mlx5_0"] != "ibp24s0" {
+		t.Fatalf("expected mlx5_0 and mlx5_1, got %v", ibInfo.IBPFDevs)
+	}
+	if ibInfo.IBSoftWareInfo.OFEDVer == "" || len(ibInfo.IBSoftWareInfo.KernelModule) == 0 {
+		t.Errorf("expected the OFED version and kernel modules, got %+v", ibInfo.IBSoftWareInfo)
+	}
+
+	hw := ibInfo.IBHardWareInfo[HWInfoKey("mlx5_0", 1)]
+	for name, got := range map[string][2]string{
+		"fw":         {hw.FWVer, "28.39.1002"},
+		"board":      {hw.BoardID, "MT_0000000838"},
+		"hca":        {hw.HCAType, "MT4129"},
+		"state":      {hw.PortState, "4: ACTIVE"},
+		"link layer": {hw.LinkLayer, "InfiniBand"},
+		"lid":        {hw.LID, "0x12"},
+		"sm lid":     {hw.SMLID, "0x1"},
+		"bdf":        {hw.PCIEBDF, "0000:18:00.0"},
+		"pcie speed": {hw.PCIESpeed, "32.0 GT/s PCIe"},
+		"numa":       {hw.NumaNode, "0"},
+		"operstate":  {hw.NetOperstate, "up"},
+	} {
+		if got[0] != got[1] {
+			t.Errorf("mlx5_0 %s: expected %q, got %q", name, got[1], got[0])
+		}
+	}
+	if hw.ActiveMTU != 4096 || hw.NetMTU != 4092 {
+		t.Errorf("expected active MTU 4096 and IPoIB MTU 4092, got %d and %d", hw.ActiveMTU, hw.NetMTU)
+	}
+	if len(hw.GIDs) != 1 || hw.GIDs[0].Index != 0 || hw.GIDs[0].Type != GIDTypeRoCEv1 {
+		t.Errorf("expected the populated GID 0 only, got %+v", hw.GIDs)
+	}
+	if len(hw.PCIETreeLinks) != 3 || hw.PCIETreeLinks[0].ParentBDF != "0000:15:01.0" {
+		t.Errorf("expected the 3 links from the root port, got %+v", hw.PCIETreeLinks)
+	}
+	if got := ibInfo.IBHardWareInfo[HWInfoKey("mlx5_1", 1)].PortState; got != "1: DOWN" {
+		t.Errorf("expected mlx5_1 down, got %q", got)
+	}
+	counters := ibInfo.IBCounters[HWInfoKey("mlx5_0", 1)]
+	if _, ok := counters["symbol_error"]; !ok {
+		t.Errorf("expected the port counters, got %v", counters)
+	}
+	if _, ok := counters["out_of_sequence"]; !ok {
+		t.Errorf("expected the hw counters, got %v", counters)
+	}
+}
@@ -16,25 +16,27 @@ limitations under the License.
 package collector
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"slices"
 	"strconv"
 	"strings"
 
-	"github.com/scitix/sichek/pkg/utils"
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/scitix/sichek/pkg/pci"
 	"github.com/sirupsen/logrus"
 )
 
-// PCIPath is the root of the PCI sysfs tree. It is a var (not a const) so
-// tests can redirect it to a t.TempDir() before exercising the collector.
+// PCIPath is the root of the PCI sysfs tree, joined with hostfs.Root. It is a
+// var (not a const) so tests can redirect it to a t.TempDir() before
+// exercising the collector.
 var PCIPath = "/sys/bus/pci/devices"
 
+var bdfRegex = regexp.MustCompile(`\b[0-9a-fA-F]{4}:[0-9a-fA-F]{2}:[0-9a-fA-F]{2}\.[0-7]\b`)
+
 var (
 	targetVendorID = []string{
 		"0x15b3", // Mellanox Technologies
@@ -43,11 +45,11 @@ var (
 
 // FindIBPCIDevices finds RDMA-capable PCI devices by checking infiniband sysfs, and ignore virtual functions
 func GetRDMACapablePCIeDevices() (map[string]string, error) {
-	if _, err := os.Stat(PCIPath); os.IsNotExist(err) {
+	if _, err := os.Stat(hostfs.Path(PCIPath)); os.IsNotExist(err) {
 		return nil, fmt.Errorf("pci devices directory not found at %s: %w", PCIPath, err)
 	}
 
-	entries, err := os.ReadDir(PCIPath)
+	entries, err := os.ReadDir(hostfs.Path(PCIPath))
 	if err != nil {
 		return nil, fmt.Errorf("failed to read pci devices directory %s: %w", PCIPath, err)
 	}
@@ -56,7 +58,7 @@ func GetRDMACapablePCIeDevices() (map[string]string, error) {
 
 	for _, entry := range entries {
 		pciAddr := entry.Name()
-		deviceDir := filepath.Join(PCIPath, pciAddr)
+		deviceDir := hostfs.Path(PCIPath, pciAddr)
 		isVirtualFunction, err := IsVirtualFunctionByBDF(pciAddr)
 		if isVirtualFunction {
 			continue
@@ -100,7 +102,7 @@ func GetRDMACapablePCIeDevices() (map[string]string, error) {
 }
 
 func IsVirtualFunctionByBDF(bdf string) (bool, error) {
-	p := filepath.Join(PCIPath, bdf, "physfn")
+	p := hostfs.Path(PCIPath, bdf, "physfn")
 
 	_, err := os.Lstat(p)
 	if err == nil {
@@ -155,28 +157,22 @@ func (c *PCIETreeInfo) GetPCIETreeWidth(IBDev string) []PCIETreeWidthInfo {
 	if len(bdf) == 0 {
 		return nil
 	}
-	devicePath := filepath.Join(PCIPath, bdf[0])
-	cmd := exec.Command("readlink", devicePath)
-	output, err := cmd.Output()
-	if err != nil {
+	bdfs := upstreamBDFs(bdf[0])
+	if len(bdfs) == 0 {
 		return nil
 	}
-
-	bdfRegexPattern := `\b[0-9a-fA-F]{4}:[0-9a-fA-F]{2}:[0-9a-fA-F]{2}\.[0-7]\b`
-	re := regexp.MustCompile(bdfRegexPattern)
-	bdfs := re.FindAllString(string(output), -1)
 	allTreeWidth := make([]PCIETreeWidthInfo, 0, len(bdfs))
 
 	for _, bdf := range bdfs {
 		var perTreeWidth PCIETreeWidthInfo
-		width, err := GetFileCnt(filepath.Join(PCIPath, bdf, "current_link_width"))
-		if err != nil || len(width) == 0 {
-			logrus.WithField("component", "infiniband").Warnf("Failed to read PCIe width for BDF %s: %v", bdf, err)
+		width := readLinkAttr(bdf, "current_link_width")
+		if width == "" {
+			logrus.WithField("component", "infiniband").Warnf("Failed to read PCIe width for BDF %s", bdf)
 			continue
 		}
-		logrus.WithField("component", "infiniband").Infof("get the pcie tree width, ib:%s bdf:%s width:%s", IBDev, bdf, width[0])
+		logrus.WithField("component", "infiniband").Infof("get the pcie tree width, ib:%s bdf:%s width:%s", IBDev, bdf, width)
 		perTreeWidth.BDF = bdf
-		perTreeWidth.Width = width[0]
+		perTreeWidth.Width = width
 		allTreeWidth = append(allTreeWidth, perTreeWidth)
 	}
 	return allTreeWidth
@@ -206,44 +202,64 @@ func GetPCIECLinkWidth(IBDev string) string {
 	return result[0]
 }
 
-// GetPCIEMRR gets PCIe Max Read Request
+// GetPCIEMRR gets PCIe Max Read Request from the Device Control register in
+// the config space of the device, e.g. ["4096", "bytes"] as lspci prints it.
 func GetPCIEMRR(ctx context.Context, IBDev string) []string {
 	bdf := GetIBDevBDF(IBDev)
 	if len(bdf) == 0 {
 		return nil
 	}
-
-	// lspciCmd := exec.Command("lspci", "-s", bdf[0], "-vvv")
-	lspciOutput, err := utils.ExecCommand(ctx, "lspci", "-s", bdf[0], "-vvv")
+	mrr, err := readPCIEMRR(bdf[0])
 	if err != nil {
+		logrus.WithField("component", "infiniband").Warnf("Failed to read PCIe MaxReadReq of %s: %v", IBDev, err)
 		return nil
 	}
+	return []string{strconv.Itoa(mrr), "bytes"}
+}
 
-	grepCmd := exec.Command("grep", "MaxReadReq")
-	grepCmd.Stdin = bytes.NewBuffer(lspciOutput)
-	grepOutput, err := grepCmd.Output()
+func readPCIEMRR(bdf string) (int, error) {
+	cfg, err := pci.ReadConfig(bdf)
 	if err != nil {
-		return nil
+		return 0, err
 	}
+	return cfg.MaxReadRequest()
+}
 
-	parts := strings.Split(string(grepOutput), "MaxReadReq ")
-	var mrr []string
-	if len(parts) > 1 {
-		mrr = strings.Fields(parts[1])
-		// autofix
-		if strings.Compare(mrr[0], "4096") != 0 {
-			// get BDF
-			bdf := GetIBDevBDF(IBDev)
-			if len(bdf) > 0 {
-				// autofix
-				if err := ModifyPCIeMaxReadRequest(bdf[0], "68", 5); err != nil {
-					logrus.WithField("component", "infiniband").Errorf("Failed to modify PCIe Max Read Request for %s: %v", bdf[0], err)
-				}
-			}
-		}
+// upstreamBDFs returns the BDFs on the sysfs path of the device, from the
+// root port down to the device itself.
+func upstreamBDFs(bdf string) []string {
+	linkPath, err := os.Readlink(hostfs.Path(PCIPath, bdf))
+	if err != nil {
+		logrus.WithField("component", "infiniband").Errorf("Failed to resolve symlink for %s: %v", bdf, err)
+		return nil
 	}
+	return bdfRegex.FindAllString(linkPath, -1)
+}
 
-	return mrr
+// readLinkAttr reads a *_link_{speed,width} attribute of the device, falling
+// back to the Link Status register of its config space on kernels that do
+// not export the attribute.
+func readLinkAttr(bdf, name string) string {
+	if value := readSysfsString(hostfs.Path(PCIPath, bdf, name)); value != "" {
+		return value
+	}
+	cfg, err := pci.ReadConfig(bdf)
+	if err != nil {
+		return ""
+	}
+	var link pci.Link
+	if strings.HasPrefix(name, "max_") {
+		link, err = cfg.LinkCapabilities()
+	} else {
+		link, err = cfg.LinkStatus()
+	}
+	if err != nil {
+		return ""
+	}
+	if strings.HasSuffix(name, "_width") {
+		return strconv.Itoa(link.Width)
+	}
+	return link.Speed
 }
 
 // GetPCIETreeLinks enumerates every PCIe link on the upstream path of an IB
@@ -268,16 +284,7 @@ func GetPCIETreeLinks(IBDev string) []PCIETreeLink {
 // on individual files leave the corresponding fields blank rather than
 // dropping the entire link, so the checker can still emit a useful message.
 func getPCIETreeLinksByBDF(nicBDF string) []PCIETreeLink {
-	devicePath := filepath.Join(PCIPath, nicBDF)
-	linkPath, err := os.Readlink(devicePath)
-	if err != nil {
-		logrus.WithField("component", "infiniband").Errorf("Failed to resolve symlink for %s: %v", devicePath, err)
-		return nil
-	}
-
-	bdfRegexPattern := `\b[0-9a-fA-F]{4}:[0-9a-fA-F]{2}:[0-9a-fA-F]{2}\.[0-7]\b`
-	re := regexp.MustCompile(bdfRegexPattern)
-	allBdfs := re.FindAllString(linkPath, -1)
+	allBdfs := upstreamBDFs(nicBDF)
 	if len(allBdfs) < 2 {
 		logrus.WithField("component", "infiniband").Infof("No upstream PCIe link for %s (path has <2 BDFs), skipping.", nicBDF)
 		return nil
@@ -288,19 +295,19 @@ func getPCIETreeLinksByBDF(nicBDF string) []PCIETreeLink {
 		parent := allBdfs[i-1]
 		child := allBdfs[i]
 		link := PCIETreeLink{ParentBDF: parent, ChildBDF: child}
-		link.CurSpeed = readSysfsString(filepath.Join(PCIPath, child, "current_link_speed"))
+		link.CurSpeed = readLinkAttr(child, "current_link_speed")
 		if link.CurSpeed == "" {
 			// Fall back to parent's report; the two endpoints share the link.
-			link.CurSpeed = readSysfsString(filepath.Join(PCIPath, parent, "current_link_speed"))
+			link.CurSpeed = readLinkAttr(parent, "current_link_speed")
 		}
-		link.CurWidth = readSysfsString(filepath.Join(PCIPath, child, "current_link_width"))
+		link.CurWidth = readLinkAttr(child, "current_link_width")
 		if link.CurWidth == "" {
-			link.CurWidth = readSysfsString(filepath.Join(PCIPath, parent, "current_link_width"))
+			link.CurWidth = readLinkAttr(parent, "current_link_width")
 		}
-		link.ParentMaxSpeed = readSysfsString(filepath.Join(PCIPath, parent, "max_link_speed"))
-		link.ChildMaxSpeed = readSysfsString(filepath.Join(PCIPath, child, "max_link_speed"))
-		link.ParentMaxWidth = readSysfsString(filepath.Join(PCIPath, parent, "max_link_width"))
-		link.ChildMaxWidth = readSysfsString(filepath.Join(PCIPath, child, "max_link_width"))
+		link.ParentMaxSpeed = readLinkAttr(parent, "max_link_speed")
+		link.ChildMaxSpeed = readLinkAttr(child, "max_link_speed")
+		link.ParentMaxWidth = readLinkAttr(parent, "max_link_width")
+		link.ChildMaxWidth = readLinkAttr(child, "max_link_width")
 		links = append(links, link)
 	}
 	return links
@@ -323,91 +330,24 @@ func GetPCIETreeSpeed(IBDev string) []PCIETreeSpeedInfo {
 	if len(bdf) == 0 {
 		return nil
 	}
-	devicePath := filepath.Join(PCIPath, bdf[0])
-	cmd := exec.Command("readlink", devicePath)
-	output, err := cmd.Output()
-	if err != nil {
+	bdfs := upstreamBDFs(bdf[0])
+	if len(bdfs) == 0 {
 		return nil
 	}
-
-	bdfRegexPattern := `\b[0-9a-fA-F]{4}:[0-9a-fA-F]{2}:[0-9a-fA-F]{2}\.[0-7]\b`
-	re := regexp.MustCompile(bdfRegexPattern)
-	bdfs := re.FindAllString(string(output), -1)
 	allTreeSpeed := make([]PCIETreeSpeedInfo, 0, len(bdfs))
 	logrus.WithField("component", "infiniband").Infof("get the pcie tree speed, ib:%s bdfs:%v", IBDev, bdfs)
 
 	for _, bdf := range bdfs {
 		var perTreeSpeed PCIETreeSpeedInfo
-		speed, err := GetFileCnt(filepath.Join(PCIPath, bdf, "current_link_speed"))
-		if err != nil || len(speed) == 0 {
-			logrus.WithField("component", "infiniband").Warnf("Failed to read PCIe speed for BDF %s: %v", bdf, err)
+		speed := readLinkAttr(bdf, "current_link_speed")
+		if speed == "" {
+			logrus.WithField("component", "infiniband").Warnf("Failed to read PCIe speed for BDF %s", bdf)
 			continue
 		}
-		logrus.WithField("component", "infiniband").Infof("get the pcie tree speed, ib:%s bdf:%s speed:%s", IBDev, bdf, speed[0])
+		logrus.WithField("component", "infiniband").Infof("get the pcie tree speed, ib:%s bdf:%s speed:%s", IBDev, bdf, speed)
 		perTreeSpeed.BDF = bdf
-		perTreeSpeed.Speed = speed[0]
+		perTreeSpeed.Speed = speed
 		allTreeSpeed = append(allTreeSpeed, perTreeSpeed)
 	}
 	return allTreeSpeed
 }
-
-// ModifyPCIeMaxReadRequest modifies the Max Read Request Size of a PCIe device
-// deviceAddr: PCI device address, e.g., "80:00.0"
-// offset: Register offset address, e.g., "68"
-// newHighNibble: New high nibble value (0-F)
-func ModifyPCIeMaxReadRequest(deviceAddr string, offset string, newHighNibble int) error {
-	// Validate input parameters
-	if newHighNibble < 0 || newHighNibble > 0xF {
-		return fmt.Errorf("new high nibble value must be between 0-F")
-	}
-
-	// Read current value
-	readCmd := exec.Command("setpci", "-s", deviceAddr, offset+".w")
-	output, err := readCmd.Output()
-	if err != nil {
-		return fmt.Errorf("failed to read PCI register: %v", err)
-	}
-
-	// Parse the returned hexadecimal value
-	currentValueStr := strings.TrimSpace(string(output))
-	currentValue, err := strconv.ParseUint(currentValueStr, 16, 16)
-	if err != nil {
-		return fmt.Errorf("failed to parse hex value: %v", err)
-	}
-
-	// Modify the high nibble
-	// Clear the top 4 bits (0x0FFF mask)
-	newValue := currentValue & 0x0FFF
-	// Set the new high nibble
-	newValue |= uint64(newHighNibble) << 12
-
-	logrus.WithField("component", "infiniband").Infof("Modifying PCIe Max Read Request for device %s at offset %s: current value 0x%04X, new value 0x%04X", deviceAddr, offset, currentValue, newValue)
-
-	// Write back the new value
-	writeValueStr := fmt.Sprintf("%04x", newValue)
-	writeCmd := exec.Command("setpci", "-s", deviceAddr, offset+".w="+writeValueStr)
-	err = writeCmd.Run()
-	if err != nil {
-		return fmt.Errorf("failed to write PCI register: %v", err)
-	}
-
-	// Verify the write was successful
-	verifyCmd := exec.Command("setpci", "-s", deviceAddr, offset+".w")
-	verifyOutput, err := verifyCmd.Output()
-	if err != nil {
-		return fmt.Errorf("failed to verify write result: %v", err)
-	}
-
-	verifiedValueStr := strings.TrimSpace(string(verifyOutput))
-	verifiedValue, err := strconv.ParseUint(verifiedValueStr, 16, 16)
-	if err != nil {
-		return fmt.Errorf("failed to parse verification value: %v", err)
-	}
-
-	if verifiedValue != newValue {
-		return fmt.Errorf("write verification failed: expected 0x%04X, got 0x%04X", newValue, verifiedValue)
-	}
-
-	fmt.Printf("Successfully modified! Verified value: 0x%04X\n", verifiedValue)
-	return nil
-}
@@ -0,0 +1,169 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	pciecollector "github.com/scitix/sichek/components/pcie/collector"
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/scitix/sichek/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// VFInfo is the state of a virtual function of an SR-IOV enabled HCA.
+type VFInfo struct {
+	Index int    `json:"index" yaml:"index"`
+	BDF   string `json:"bdf" yaml:"bdf"`
+	// Driver is the driver the VF is bound to, e.g. mlx5_core or vfio-pci.
+	Driver string `json:"driver,omitempty" yaml:"driver,omitempty"`
+	// MAC, NodeGUID, PortGUID and LinkState are the VF settings of the PF as
+	// shown by `ip link show`, the GUIDs only exist on InfiniBand VFs.
+	MAC       string `json:"mac,omitempty" yaml:"mac,omitempty"`
+	NodeGUID  string `json:"node_guid,omitempty" yaml:"node_guid,omitempty"`
+	PortGUID  string `json:"port_guid,omitempty" yaml:"port_guid,omitempty"`
+	LinkState string `json:"link_state,omitempty" yaml:"link_state,omitempty"`
+	// IBDev, PortState and PhyState are only set while the RDMA device of
+	// the VF is in the network namespace of sichek, not yet given to a pod.
+	// PortState is read in the network namespace of the pod once given.
+	IBDev     string `json:"ib_dev,omitempty" yaml:"ib_dev,omitempty"`
+	PortState string `json:"port_state,omitempty" yaml:"port_state,omitempty"`
+	PhyState  string `json:"phy_state,omitempty" yaml:"phy_state,omitempty"`
+	// FatalErrors is the TOTAL_ERR_FATAL AER counter of the VF.
+	FatalErrors uint64 `json:"fatal_errors,omitempty" yaml:"fatal_errors,omitempty"`
+	// Pod is the pod the VF is allocated to, nil while the VF is free.
+	Pod *VFPod `json:"pod,omitempty" yaml:"pod,omitempty"`
+	// Counters are the counters of the RDMA device of the VF, read in the
+	// network namespace of its pod.
+	Counters IBCounters `json:"counters,omitempty" yaml:"counters,omitempty"`
+}
+
+var (
+	ipLinkVFRegexp    = regexp.MustCompile(`^\s*vf (\d+)\s+(.*)$`)
+	ipLinkMACRegexp   = regexp.MustCompile(`(?:link/ether|MAC) ([0-9a-fA-F:]+)`)
+	ipLinkNodeGUIDReg = regexp.MustCompile(`NODE_GUID ([0-9a-fA-F:]+)`)
+	ipLinkPortGUIDReg = regexp.MustCompile(`PORT_GUID ([0-9a-fA-F:]+)`)
+	ipLinkStateRegexp = regexp.MustCompile(`link-state (\w+)`)
+)
+
+// GetVFInfos returns the number of VFs configured in sriov_numvfs and the
+// state of each VF enumerated under the PF.
+func GetVFInfos(ctx context.Context, IBDev, netDev string) (int, []VFInfo) {
+	deviceDir := hostfs.Path(IBSYSPathPre, IBDev, "device")
+	configured := 0
+	if data, err := os.ReadFile(filepath.Join(deviceDir, "sriov_numvfs")); err == nil {
+		configured, _ = strconv.Atoi(strings.TrimSpace(string(data)))
+	}
+
+	vfs := readVFs(deviceDir)
+	if netDev != "" && len(vfs) > 0 {
+		out, err := utils.ExecCommand(ctx, "ip", "link", "show", "dev", netDev)
+		if err != nil {
+			logrus.WithField("component", "infiniband").Warnf("failed to read the VF settings of %s: %v", netDev, err)
+		} else {
+			settings := ParseIPLinkVFs(string(out))
+			for i := range vfs {
+				if vf, ok := settings[vfs[i].Index]; ok {
+					vfs[i].MAC = vf.MAC
+					vfs[i].NodeGUID = vf.NodeGUID
+					vfs[i].PortGUID = vf.PortGUID
+					vfs[i].LinkState = vf.LinkState
+				}
+			}
+		}
+	}
+	return configured, vfs
+}
+
+// readVFs reads the VFs from the virtfn<N> links of the PF device directory.
+func readVFs(deviceDir string) []VFInfo {
+	links, err := filepath.Glob(filepath.Join(deviceDir, "virtfn*"))
+	if err != nil || len(links) == 0 {
+		return nil
+	}
+	vfs := make([]VFInfo, 0, len(links))
+	for _, link := range links {
+		index, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(link), "virtfn"))
+		if err != nil {
+			continue
+		}
+		vf := VFInfo{Index: index}
+		if target, err := os.Readlink(link); err == nil {
+			vf.BDF = filepath.Base(target)
+		}
+		if driver, err := os.Readlink(filepath.Join(link, "driver")); err == nil {
+			vf.Driver = filepath.Base(driver)
+		}
+		if data, err := os.ReadFile(filepath.Join(link, "aer_dev_fatal")); err == nil {
+			vf.FatalErrors = pciecollector.ParseAERCounters(string(data))["TOTAL_ERR_FATAL"]
+		}
+		if devs, err := os.ReadDir(filepath.Join(link, "infiniband")); err == nil && len(devs) > 0 {
+			vf.IBDev = devs[0].Name()
+			portDir := filepath.Join(link, "infiniband", vf.IBDev, "ports", "1")
+			vf.PortState = readTrimmed(filepath.Join(portDir, "state"))
+			vf.PhyState = readTrimmed(filepath.Join(portDir, "phys_state"))
+		}
+		vfs = append(vfs, vf)
+	}
+	sort.Slice(vfs, func(i, j int) bool { return vfs[i].Index < vfs[j].Index })
+	return vfs
+}
+
+func readTrimmed(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// ParseIPLinkVFs parses the "vf <N> ..." lines of `ip link show` of a PF, e.g.
+// "vf 0 link/infiniband ... NODE_GUID 00:..., PORT_GUID 00:..., link-state auto"
+// or "vf 0 link/ether 00:... brd ..., spoof checking off, link-state auto".
+func ParseIPLinkVFs(output string) map[int]VFInfo {
+	vfs := make(map[int]VFInfo)
+	for _, line := range strings.Split(output, "\n") {
+		m := ipLinkVFRegexp.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		index, err := strconv.Atoi(m[1])
+		if err != nil {
+			continue
+		}
+		vf := VFInfo{Index: index}
+		if mac := ipLinkMACRegexp.FindStringSubmatch(m[2]); mac != nil {
+			vf.MAC = strings.ToLower(mac[1])
+		}
+		if guid := ipLinkNodeGUIDReg.FindStringSubmatch(m[2]); guid != nil {
+			vf.NodeGUID = strings.ToLower(guid[1])
+		}
+		if guid := ipLinkPortGUIDReg.FindStringSubmatch(m[2]); guid != nil {
+			vf.PortGUID = strings.ToLower(guid[1])
+		}
+		if state := ipLinkStateRegexp.FindStringSubmatch(m[2]); state != nil {
+			vf.LinkState = state[1]
+		}
+		vfs[index] = vf
+	}
+	return vfs
+}
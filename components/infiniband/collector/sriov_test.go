@@ -0,0 +1,68 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIPLinkVFs(t *testing.T) {
+	output := `4: ib0: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 4092 qdisc mq state UP mode DEFAULT group default qlen 256
+    link/infiniband 00:00:10:29:fe:80:00:00:00:00:00:00:b8:3f:d2:03:00:a1:b2:c3 brd 00:ff:ff:ff:ff:12:40:1b:ff:ff:00:00:00:00:00:00:ff:ff:ff:ff
+    vf 0     link/infiniband 00:00:10:29:fe:80:00:00:00:00:00:00:b8:3f:d2:03:00:a1:b2:c3 brd 00:ff:ff:ff:ff:12:40:1b:ff:ff:00:00:00:00:00:00:ff:ff:ff:ff, spoof checking off, NODE_GUID 11:22:33:44:55:66:77:01, PORT_GUID 11:22:33:44:55:66:77:01, link-state auto, trust off, query_rss off
+    vf 1     link/infiniband 00:00:10:29:fe:80:00:00:00:00:00:00:b8:3f:d2:03:00:a1:b2:c3 brd 00:ff:ff:ff:ff:12:40:1b:ff:ff:00:00:00:00:00:00:ff:ff:ff:ff, spoof checking off, NODE_GUID 00:00:00:00:00:00:00:00, PORT_GUID 00:00:00:00:00:00:00:00, link-state enable, trust off, query_rss off
+    vf 2     link/ether 0A:00:00:00:00:02 brd ff:ff:ff:ff:ff:ff, spoof checking off, link-state auto, trust off, query_rss off`
+
+	vfs := ParseIPLinkVFs(output)
+	require.Len(t, vfs, 3)
+	assert.Equal(t, "11:22:33:44:55:66:77:01", vfs[0].NodeGUID)
+	assert.Equal(t, "11:22:33:44:55:66:77:01", vfs[0].PortGUID)
+	assert.Equal(t, "auto", vfs[0].LinkState)
+	assert.Equal(t, "enable", vfs[1].LinkState)
+	assert.Equal(t, "0a:00:00:00:00:02", vfs[2].MAC)
+	assert.Empty(t, vfs[2].NodeGUID)
+}
+
+func TestReadVFs(t *testing.T) {
+	root := t.TempDir()
+	deviceDir := filepath.Join(root, "pf")
+	for _, vf := range []struct {
+		link, bdf string
+	}{{"virtfn1", "0000:3b:00.3"}, {"virtfn0", "0000:3b:00.2"}} {
+		target := filepath.Join(root, vf.bdf)
+		require.NoError(t, os.MkdirAll(filepath.Join(target, "infiniband", "mlx5_"+vf.link[6:], "ports", "1"), 0755))
+		require.NoError(t, os.MkdirAll(deviceDir, 0755))
+		require.NoError(t, os.Symlink(target, filepath.Join(deviceDir, vf.link)))
+	}
+	ports := filepath.Join(root, "0000:3b:00.3", "infiniband", "mlx5_1", "ports", "1")
+	require.NoError(t, os.WriteFile(filepath.Join(ports, "state"), []byte("2: INIT\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(ports, "phys_state"), []byte("5: LinkUp\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "0000:3b:00.2", "aer_dev_fatal"), []byte("Undefined 0\nTOTAL_ERR_FATAL 2\n"), 0644))
+
+	vfs := readVFs(deviceDir)
+	require.Len(t, vfs, 2)
+	assert.Equal(t, 0, vfs[0].Index)
+	assert.Equal(t, "0000:3b:00.2", vfs[0].BDF)
+	assert.Equal(t, uint64(2), vfs[0].FatalErrors)
+	assert.Equal(t, "mlx5_1", vfs[1].IBDev)
+	assert.Equal(t, "2: INIT", vfs[1].PortState)
+	assert.Equal(t, "5: LinkUp", vfs[1].PhyState)
+}
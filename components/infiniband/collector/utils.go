@@ -17,22 +17,25 @@ package collector
 
 import (
 	"bufio"
-	"fmt"
 	"os"
-	"path"
 	"path/filepath"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/scitix/sichek/pkg/hostfs"
 	"github.com/sirupsen/logrus"
 )
 
-const (
-	IBSYSPathPre    = "/sys/class/infiniband/"
-	gatewayCacheTTL = 5 * time.Minute
+// IBSYSPathPre and NetClassPath are the sysfs trees of the RDMA and network
+// devices, joined with hostfs.Root.
+var (
+	IBSYSPathPre = "/sys/class/infiniband/"
+	NetClassPath = "/sys/class/net"
 )
 
+const gatewayCacheTTL = 5 * time.Minute
+
 func ListDir(dir string) ([]string, error) {
 	files, err := os.ReadDir(dir)
 	if err != nil {
@@ -56,7 +59,7 @@ func ReadFileLines(filePath string) ([]string, error) {
 
 	defer func() {
 		if closeErr := file.Close(); closeErr != nil {
-			fmt.Printf("Error closing file: %v\n", closeErr)
+			logrus.WithField("component", "infiniband").Errorf("Error closing file: %v", closeErr)
 		}
 	}()
 
@@ -92,7 +95,7 @@ func GetFileCnt(path string) ([]string, error) {
 
 // ReadIBDevSysfileLines gets system content from specified path
 func ReadIBDevSysfileLines(IBDev string, DstPath string) ([]string, error) {
-	fullPath := path.Join(IBSYSPathPre, IBDev, DstPath)
+	fullPath := hostfs.Path(IBSYSPathPre, IBDev, DstPath)
 	return GetFileCnt(fullPath)
 }
 
@@ -114,7 +117,7 @@ func GetIBDevBDF(IBDev string) []string {
 
 // getBondInterface gets bond interface for a slave interface
 func getBondInterface(slaveInterface string) (string, bool) {
-	bondPattern := "/sys/class/net/bond*"
+	bondPattern := hostfs.Path(NetClassPath, "bond*")
 	bondDirs, err := filepath.Glob(bondPattern)
 	if err != nil {
 		return "", false
@@ -148,7 +151,7 @@ func getBondInterface(slaveInterface string) (string, bool) {
 // A device is treated as a VF when /sys/class/infiniband/<dev>/device/physfn
 // exists. Names are returned in lexicographic order.
 func ListActiveRoceVFs() []string {
-	return listActiveRoceVFs(IBSYSPathPre)
+	return listActiveRoceVFs(hostfs.Path(IBSYSPathPre))
 }
 
 func listActiveRoceVFs(root string) []string {
@@ -198,7 +201,7 @@ func hasActiveRocePort(portsDir string) bool {
 // - PF only (VF should already be filtered outside)
 // - Bond-aware
 func GetIBdev2NetDev(ibDev string) (string, bool) {
-	netPath := filepath.Join("/sys/class/infiniband", ibDev, "device/net")
+	netPath := hostfs.Path(IBSYSPathPre, ibDev, "device/net")
 	physDevs, err := os.ReadDir(netPath)
 	if err != nil {
 		logrus.WithField("component", "infiniband").Errorf("failed to GetIBdev2NetDev for %s: %v", ibDev, err)
@@ -0,0 +1,426 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/scitix/sichek/pkg/k8s"
+	"github.com/scitix/sichek/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// VFPod is the pod a VF is allocated to by the SR-IOV network device plugin,
+// as recorded in the device manager checkpoint of the kubelet.
+type VFPod struct {
+	UID string `json:"uid" yaml:"uid"`
+	// Namespace and Name come from the pod-resources API of the kubelet, they
+	// are empty when it is unreachable.
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Name      string `json:"name,omitempty" yaml:"name,omitempty"`
+	Container string `json:"container,omitempty" yaml:"container,omitempty"`
+	Resource  string `json:"resource,omitempty" yaml:"resource,omitempty"`
+	// PID is a process of the pod whose network namespace the VF was looked
+	// up in, 0 when no process of the pod was found.
+	PID int `json:"pid,omitempty" yaml:"pid,omitempty"`
+	// NetDev and IBDev are the names of the VF in the network namespace of
+	// the pod, e.g. net1 and mlx5_5.
+	NetDev string `json:"net_dev,omitempty" yaml:"net_dev,omitempty"`
+	IBDev  string `json:"ib_dev,omitempty" yaml:"ib_dev,omitempty"`
+}
+
+// String returns the namespace/name of the pod, or its UID without the
+// pod-resources API.
+func (p *VFPod) String() string {
+	if p == nil {
+		return ""
+	}
+	if p.Name == "" {
+		return "pod " + p.UID
+	}
+	return "pod " + p.Namespace + "/" + p.Name
+}
+
+// ReadVFAllocations maps the device IDs allocated in the device plugin
+// checkpoint at path to their pod, the SR-IOV network device plugin uses the
+// PCI address of the VFs as device ID.
+func ReadVFAllocations(path string) (map[string]*VFPod, error) {
+	checkpoint, err := k8s.ReadDevicePluginCheckpoint(path)
+	if err != nil {
+		return nil, err
+	}
+	allocations := make(map[string]*VFPod)
+	for _, entry := range checkpoint.Data.PodDeviceEntries {
+		for _, id := range entry.Devices() {
+			allocations[id] = &VFPod{UID: entry.PodUID, Container: entry.ContainerName, Resource: entry.ResourceName}
+		}
+	}
+	return allocations, nil
+}
+
+// RDMALink is a port of an RDMA device as shown by `rdma -j link show`.
+type RDMALink struct {
+	IBDev     string `json:"ifname"`
+	Port      int    `json:"port"`
+	State     string `json:"state"`
+	PhysState string `json:"physical_state"`
+	NetDev    string `json:"netdev"`
+}
+
+// PodNetNS is the netdevs and RDMA devices in the network namespace of a pod,
+// the RDMA subsystem of a sriovNode node runs in exclusive mode so that the
+// RDMA device of a VF moves along with its netdev.
+type PodNetNS struct {
+	// NetDevs maps the PCI address of the parent device and the MAC of each
+	// netdev to its name.
+	NetDevs map[string]string
+	Links   []RDMALink
+	// NodeGUIDs maps the node GUID of each RDMA device to its name, for the
+	// InfiniBand VFs without an IPoIB netdev.
+	NodeGUIDs map[string]string
+	// Counters are the counters of the RDMA devices, keyed by HWInfoKey.
+	Counters map[string]IBCounters
+}
+
+// ParseIPLinkParents parses `ip -d -j link show` into a map from the parent
+// PCI address and the MAC of each netdev to its name.
+func ParseIPLinkParents(output []byte) (map[string]string, error) {
+	var links []struct {
+		IfName    string `json:"ifname"`
+		Address   string `json:"address"`
+		ParentBus string `json:"parentbus"`
+		ParentDev string `json:"parentdev"`
+	}
+	if err := json.Unmarshal(output, &links); err != nil {
+		return nil, fmt.Errorf("invalid ip link output: %w", err)
+	}
+	netDevs := make(map[string]string)
+	for _, link := range links {
+		if link.ParentBus == "pci" && link.ParentDev != "" {
+			netDevs[link.ParentDev] = link.IfName
+		}
+		if link.Address != "" && !zeroAddr(link.Address) {
+			netDevs[strings.ToLower(link.Address)] = link.IfName
+		}
+	}
+	return netDevs, nil
+}
+
+// ParseRDMALinks parses `rdma -j link show`.
+func ParseRDMALinks(output []byte) ([]RDMALink, error) {
+	var links []RDMALink
+	if err := json.Unmarshal(output, &links); err != nil {
+		return nil, fmt.Errorf("invalid rdma link output: %w", err)
+	}
+	return links, nil
+}
+
+// ParseRDMADevGUIDs parses `rdma -j dev show` into a map from the node GUID of
+// each RDMA device, normalized by normalizeGUID, to its name.
+func ParseRDMADevGUIDs(output []byte) (map[string]string, error) {
+	var devs []struct {
+		IfName   string `json:"ifname"`
+		NodeGUID string `json:"node_guid"`
+	}
+	if err := json.Unmarshal(output, &devs); err != nil {
+		return nil, fmt.Errorf("invalid rdma dev output: %w", err)
+	}
+	guids := make(map[string]string)
+	for _, dev := range devs {
+		if guid := normalizeGUID(dev.NodeGUID); guid != "" {
+			guids[guid] = dev.IfName
+		}
+	}
+	return guids, nil
+}
+
+// ParseRDMAStatistics parses `rdma -j statistic show` into the counters of
+// each port, keyed by HWInfoKey. The counters are listed next to the name and
+// the port of the link, or nested in an object by the newer iproute2.
+func ParseRDMAStatistics(output []byte) (map[string]IBCounters, error) {
+	var stats []map[string]any
+	if err := json.Unmarshal(output, &stats); err != nil {
+		return nil, fmt.Errorf("invalid rdma statistic output: %w", err)
+	}
+	counters := make(map[string]IBCounters)
+	for _, stat := range stats {
+		ibDev, _ := stat["ifname"].(string)
+		port, _ := stat["port"].(float64)
+		if ibDev == "" || port == 0 {
+			continue
+		}
+		cnt := make(IBCounters)
+		for name, value := range stat {
+			switch v := value.(type) {
+			case float64:
+				if name != "ifindex" && name != "port" && v >= 0 {
+					cnt[name] = uint64(v)
+				}
+			case map[string]any:
+				for nested, value := range v {
+					if n, ok := value.(float64); ok && n >= 0 {
+						cnt[nested] = uint64(n)
+					}
+				}
+			}
+		}
+		counters[HWInfoKey(ibDev, int(port))] = cnt
+	}
+	return counters, nil
+}
+
+// normalizeGUID strips the separators of a GUID, `ip link` prints
+// 11:22:33:44:55:66:77:88 and `rdma dev` 1122:3344:5566:7788.
+func normalizeGUID(guid string) string {
+	guid = strings.ToLower(strings.ReplaceAll(guid, ":", ""))
+	if strings.Trim(guid, "0") == "" {
+		return ""
+	}
+	return guid
+}
+
+// zeroAddr reports whether a MAC is unset, e.g. 00:00:00:00:00:00.
+func zeroAddr(addr string) bool {
+	return strings.Trim(addr, "0:") == ""
+}
+
+// Match returns the names of a VF in the network namespace, by the parent of
+// its netdev, else its MAC, else the node GUID of its RDMA device.
+func (ns *PodNetNS) Match(vf VFInfo) (netDev string, link *RDMALink) {
+	netDev = ns.NetDevs[vf.BDF]
+	if netDev == "" && vf.MAC != "" && !zeroAddr(vf.MAC) {
+		netDev = ns.NetDevs[strings.ToLower(vf.MAC)]
+	}
+	ibDev := ""
+	if guid := normalizeGUID(vf.NodeGUID); netDev == "" && guid != "" {
+		ibDev = ns.NodeGUIDs[guid]
+	}
+	for i := range ns.Links {
+		if (netDev != "" && ns.Links[i].NetDev == netDev) || (ibDev != "" && ns.Links[i].IBDev == ibDev) {
+			return netDev, &ns.Links[i]
+		}
+	}
+	return netDev, nil
+}
+
+// vfRef is a VF of the IBHardWareInfo at key.
+type vfRef struct {
+	key   string
+	index int
+}
+
+// vfPodResolver attributes the VFs of a sriovNode node to the pods they are
+// allocated to and reads their counters in the network namespace of the pod.
+// The process found for each pod is kept across collections, so that /proc is
+// only scanned for the new pods.
+type vfPodResolver struct {
+	mu         sync.Mutex
+	pids       map[string]int
+	checkpoint string
+	// podNames and inspect are vars for tests.
+	podNames func() map[string]*k8s.PodInfo
+	inspect  func(ctx context.Context, pid int) (*PodNetNS, error)
+}
+
+func newVFPodResolver() *vfPodResolver {
+	return &vfPodResolver{
+		pids:       make(map[string]int),
+		checkpoint: k8s.DevicePluginCheckpointPath,
+		podNames:   podResourceNames,
+		inspect:    inspectPodNetNS,
+	}
+}
+
+// Resolve sets the pod, and the names and counters in the pod, of the VFs of
+// hws allocated to a pod.
+func (r *vfPodResolver) Resolve(ctx context.Context, hws map[string]IBHardWareInfo) {
+	allocations, err := ReadVFAllocations(r.checkpoint)
+	if err != nil {
+		logrus.WithField("component", "infiniband").Debugf("no device plugin checkpoint, VFs not attributed to pods: %v", err)
+		return
+	}
+
+	byPod := make(map[string][]vfRef)
+	for key, hw := range hws {
+		for i := range hw.VFs {
+			pod, ok := allocations[hw.VFs[i].BDF]
+			if !ok {
+				continue
+			}
+			copied := *pod
+			hw.VFs[i].Pod = &copied
+			byPod[pod.UID] = append(byPod[pod.UID], vfRef{key: key, index: i})
+		}
+	}
+	if len(byPod) == 0 {
+		r.prune(byPod)
+		return
+	}
+
+	names := r.podNames()
+	for uid, refs := range byPod {
+		for _, ref := range refs {
+			vf := &hws[ref.key].VFs[ref.index]
+			if info, ok := names[vf.BDF]; ok && info != nil {
+				vf.Pod.Namespace, vf.Pod.Name = info.Namespace, info.PodName
+			}
+		}
+
+		pid := r.podPID(uid)
+		if pid == 0 {
+			logrus.WithField("component", "infiniband").Debugf("no process of pod %s found, its VFs are not inspected", uid)
+			continue
+		}
+		ns, err := r.inspect(ctx, pid)
+		if err != nil {
+			logrus.WithField("component", "infiniband").Warnf("failed to inspect the network namespace of pod %s: %v", uid, err)
+			continue
+		}
+		for _, ref := range refs {
+			vf := &hws[ref.key].VFs[ref.index]
+			vf.Pod.PID = pid
+			netDev, link := ns.Match(*vf)
+			vf.Pod.NetDev = netDev
+			if link == nil {
+				continue
+			}
+			vf.Pod.IBDev = link.IBDev
+			if vf.PortState == "" {
+				vf.PortState = link.State
+			}
+			vf.Counters = ns.Counters[HWInfoKey(link.IBDev, link.Port)]
+		}
+	}
+	r.prune(byPod)
+}
+
+// prune forgets the processes of the pods holding no VF anymore.
+func (r *vfPodResolver) prune(pods map[string][]vfRef) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for uid := range r.pids {
+		if _, ok := pods[uid]; !ok {
+			delete(r.pids, uid)
+		}
+	}
+}
+
+// podPID returns a process of the pod, 0 if none is found.
+func (r *vfPodResolver) podPID(uid string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if pid, ok := r.pids[uid]; ok && pidInPod(pid, uid) {
+		return pid
+	}
+	delete(r.pids, uid)
+	pid := findPodPID(uid)
+	if pid != 0 {
+		r.pids[uid] = pid
+	}
+	return pid
+}
+
+// findPodPID scans /proc of the host for a process whose cgroup is the one
+// of the pod, the pause container shares the network namespace as well.
+func findPodPID(uid string) int {
+	entries, err := os.ReadDir(hostfs.Path("/proc"))
+	if err != nil {
+		return 0
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid <= 1 {
+			continue
+		}
+		if pidInPod(pid, uid) {
+			return pid
+		}
+	}
+	return 0
+}
+
+// pidInPod reports whether the cgroup of the process belongs to the pod, the
+// systemd cgroup driver writes the UID of the pod with underscores.
+func pidInPod(pid int, uid string) bool {
+	data, err := os.ReadFile(hostfs.Path("/proc", strconv.Itoa(pid), "cgroup"))
+	if err != nil {
+		return false
+	}
+	cgroup := string(data)
+	return strings.Contains(cgroup, "pod"+uid) || strings.Contains(cgroup, "pod"+strings.ReplaceAll(uid, "-", "_"))
+}
+
+// podResourceNames maps the device IDs allocated to the pods to their name
+// with the pod-resources API of the kubelet.
+func podResourceNames() map[string]*k8s.PodInfo {
+	mapper := k8s.NewPodResourceMapper()
+	if mapper == nil {
+		return nil
+	}
+	names, err := mapper.GetResourceDeviceToPodMap(nil)
+	if err != nil {
+		logrus.WithField("component", "infiniband").Debugf("failed to list the pod resources: %v", err)
+		return nil
+	}
+	return names
+}
+
+// inspectPodNetNS lists the netdevs, the RDMA devices and their counters in
+// the network namespace of the process pid.
+func inspectPodNetNS(ctx context.Context, pid int) (*PodNetNS, error) {
+	run := func(command string, args ...string) ([]byte, error) {
+		cmdCtx, cancel := context.WithTimeout(ctx, consts.CmdTimeout)
+		defer cancel()
+		nsArgs := append([]string{"-t", strconv.Itoa(pid), "-n", "--", command}, args...)
+		return utils.ExecCommand(cmdCtx, "nsenter", nsArgs...)
+	}
+
+	out, err := run("ip", "-d", "-j", "link", "show")
+	if err != nil {
+		return nil, err
+	}
+	ns := &PodNetNS{}
+	if ns.NetDevs, err = ParseIPLinkParents(out); err != nil {
+		return nil, err
+	}
+	if out, err = run("rdma", "-j", "link", "show"); err == nil {
+		ns.Links, err = ParseRDMALinks(out)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if out, err = run("rdma", "-j", "dev", "show"); err == nil {
+		ns.NodeGUIDs, err = ParseRDMADevGUIDs(out)
+	}
+	if err != nil {
+		logrus.WithField("component", "infiniband").Debugf("failed to read the RDMA devices of pid %d: %v", pid, err)
+	}
+	if out, err = run("rdma", "-j", "statistic", "show"); err == nil {
+		ns.Counters, err = ParseRDMAStatistics(out)
+	}
+	if err != nil {
+		logrus.WithField("component", "infiniband").Debugf("failed to read the RDMA counters of pid %d: %v", pid, err)
+	}
+	return ns, nil
+}
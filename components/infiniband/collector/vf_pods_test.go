@@ -0,0 +1,135 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/scitix/sichek/pkg/hostfs/hostfstest"
+	"github.com/scitix/sichek/pkg/k8s"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePodNetNS(t *testing.T) {
+	netDevs, err := ParseIPLinkParents([]byte(`[
+{"ifindex":1,"ifname":"lo","address":"00:00:00:00:00:00"},
+{"ifindex":3,"ifname":"eth0","address":"1a:2b:3c:4d:5e:6f","linkinfo":{"info_kind":"veth"}},
+{"ifindex":7,"ifname":"net1","address":"0A:00:00:00:00:02","parentbus":"pci","parentdev":"0000:3b:00.2"}]`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"1a:2b:3c:4d:5e:6f": "eth0", "0a:00:00:00:00:02": "net1", "0000:3b:00.2": "net1"}, netDevs)
+
+	links, err := ParseRDMALinks([]byte(`[{"ifindex":5,"ifname":"mlx5_5","port":1,"state":"ACTIVE","physical_state":"LINK_UP","netdev":"net1","netdev_index":7}]`))
+	require.NoError(t, err)
+	assert.Equal(t, []RDMALink{{IBDev: "mlx5_5", Port: 1, State: "ACTIVE", PhysState: "LINK_UP", NetDev: "net1"}}, links)
+
+	guids, err := ParseRDMADevGUIDs([]byte(`[{"ifindex":5,"ifname":"mlx5_5","node_type":"ca","node_guid":"1122:3344:5566:7701"},{"ifindex":6,"ifname":"mlx5_6","node_guid":"0000:0000:0000:0000"}]`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"1122334455667701": "mlx5_5"}, guids)
+
+	counters, err := ParseRDMAStatistics([]byte(`[
+{"ifindex":5,"ifname":"mlx5_5","port":1,"out_of_sequence":12,"req_cqe_error":0},
+{"ifindex":6,"ifname":"mlx5_6","port":1,"counters":{"rnr_nak_retry_err":4}}]`))
+	require.NoError(t, err)
+	assert.Equal(t, IBCounters{"out_of_sequence": 12, "req_cqe_error": 0}, counters["mlx5_5/p1"])
+	assert.Equal(t, IBCounters{"rnr_nak_retry_err": 4}, counters["mlx5_6/p1"])
+
+	_, err = ParseRDMALinks([]byte("rdma: unknown option"))
+	assert.Error(t, err)
+}
+
+func TestPodNetNSMatch(t *testing.T) {
+	ns := &PodNetNS{
+		NetDevs:   map[string]string{"0000:3b:00.2": "net1", "0a:00:00:00:00:03": "net2"},
+		Links:     []RDMALink{{IBDev: "mlx5_5", Port: 1, NetDev: "net1"}, {IBDev: "mlx5_6", Port: 1, NetDev: "net2"}, {IBDev: "mlx5_7", Port: 1}},
+		NodeGUIDs: map[string]string{"1122334455667704": "mlx5_7"},
+	}
+	netDev, link := ns.Match(VFInfo{BDF: "0000:3b:00.2"})
+	require.NotNil(t, link)
+	assert.Equal(t, "net1", netDev)
+	assert.Equal(t, "mlx5_5", link.IBDev)
+
+	// by MAC when the netdev shows no parent
+	netDev, link = ns.Match(VFInfo{BDF: "0000:3b:00.3", MAC: "0A:00:00:00:00:03"})
+	require.NotNil(t, link)
+	assert.Equal(t, "net2", netDev)
+	assert.Equal(t, "mlx5_6", link.IBDev)
+
+	// an InfiniBand VF without IPoIB by its node GUID
+	netDev, link = ns.Match(VFInfo{BDF: "0000:3b:00.4", NodeGUID: "11:22:33:44:55:66:77:04"})
+	require.NotNil(t, link)
+	assert.Empty(t, netDev)
+	assert.Equal(t, "mlx5_7", link.IBDev)
+
+	_, link = ns.Match(VFInfo{BDF: "0000:3b:00.5", NodeGUID: "00:00:00:00:00:00:00:00"})
+	assert.Nil(t, link)
+}
+
+func TestVFPodResolver(t *testing.T) {
+	hostfstest.Build(t, `
+-- var/lib/kubelet/device-plugins/kubelet_internal_checkpoint --
+{"Data":{"PodDeviceEntries":[
+{"PodUID":"0c4f2e6a-1b2c-4d5e-8f90-123456789abc","ContainerName":"trainer","ResourceName":"rdma/sriov_ib","DeviceIDs":{"0":["0000:3b:00.2"]}},
+{"PodUID":"9d8c7b6a-5f4e-4d3c-2b1a-0f9e8d7c6b5a","ContainerName":"gone","ResourceName":"rdma/sriov_ib","DeviceIDs":{"0":["0000:3b:00.3"]}},
+{"PodUID":"uid-gpu","ContainerName":"trainer","ResourceName":"nvidia.com/gpu","DeviceIDs":{"0":["GPU-0"]}}],
+"RegisteredDevices":{}},"Checksum":1}
+-- proc/1/cgroup --
+0::/init.scope
+-- proc/4242/cgroup --
+0::/kubepods.slice/kubepods-pod0c4f2e6a_1b2c_4d5e_8f90_123456789abc.slice/cri-containerd-abc.scope
+`)
+	var inspected []int
+	r := newVFPodResolver()
+	r.podNames = func() map[string]*k8s.PodInfo {
+		return map[string]*k8s.PodInfo{"0000:3b:00.2": {Namespace: "train", PodName: "llama-0"}}
+	}
+	r.inspect = func(_ context.Context, pid int) (*PodNetNS, error) {
+		inspected = append(inspected, pid)
+		return &PodNetNS{
+			NetDevs:  map[string]string{"0000:3b:00.2": "net1"},
+			Links:    []RDMALink{{IBDev: "mlx5_5", Port: 1, State: "ACTIVE", NetDev: "net1"}},
+			Counters: map[string]IBCounters{"mlx5_5/p1": {"out_of_sequence": 12}},
+		}, nil
+	}
+
+	hws := map[string]IBHardWareInfo{
+		"mlx5_0/p1": {IBDev: "mlx5_0", Port: 1, VFs: []VFInfo{
+			{Index: 0, BDF: "0000:3b:00.2"},
+			{Index: 1, BDF: "0000:3b:00.3"},
+			{Index: 2, BDF: "0000:3b:00.4"},
+		}},
+	}
+	r.Resolve(context.Background(), hws)
+
+	vfs := hws["mlx5_0/p1"].VFs
+	require.NotNil(t, vfs[0].Pod)
+	assert.Equal(t, VFPod{
+		UID: "0c4f2e6a-1b2c-4d5e-8f90-123456789abc", Namespace: "train", Name: "llama-0",
+		Container: "trainer", Resource: "rdma/sriov_ib", PID: 4242, NetDev: "net1", IBDev: "mlx5_5",
+	}, *vfs[0].Pod)
+	assert.Equal(t, "pod train/llama-0", vfs[0].Pod.String())
+	assert.Equal(t, "ACTIVE", vfs[0].PortState)
+	assert.Equal(t, IBCounters{"out_of_sequence": 12}, vfs[0].Counters)
+
+	// allocated to a pod without any process left, attributed but not inspected
+	require.NotNil(t, vfs[1].Pod)
+	assert.Equal(t, "pod 9d8c7b6a-5f4e-4d3c-2b1a-0f9e8d7c6b5a", vfs[1].Pod.String())
+	assert.Zero(t, vfs[1].Pod.PID)
+	assert.Nil(t, vfs[2].Pod)
+	assert.Equal(t, []int{4242}, inspected)
+	assert.Equal(t, map[string]int{"0c4f2e6a-1b2c-4d5e-8f90-123456789abc": 4242}, r.pids)
+}
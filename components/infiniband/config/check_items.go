@@ -34,13 +34,37 @@ const (
 	CheckRoCE         = "check_roce"
 	CheckIBDriver     = "check_ib_driver"
 
-	CheckPCIEACS       = "check_pcie_acs"
-	CheckPCIEMRR       = "check_pcie_mrr"
-	CheckPCIESpeed     = "check_pcie_speed"
-	CheckPCIEWidth     = "check_pcie_width"
-	CheckPCIETreeSpeed = "check_pcie_tree_speed"
-	CheckPCIETreeWidth = "check_pcie_tree_width"
-	CheckIBLost        = "check_ib_lost"
+	CheckPCIEACS         = "check_pcie_acs"
+	CheckPCIEMRR         = "check_pcie_mrr"
+	CheckPCIESpeed       = "check_pcie_speed"
+	CheckPCIEWidth       = "check_pcie_width"
+	CheckPCIETreeSpeed   = "check_pcie_tree_speed"
+	CheckPCIETreeWidth   = "check_pcie_tree_width"
+	CheckIBLost          = "check_ib_lost"
+	CheckIBCounterRate   = "check_ib_counter_rate"
+	CheckIBCongestion    = "check_ib_congestion"
+	CheckIBLinkFlap      = "check_ib_link_flap"
+	CheckIBFWMatrix      = "check_ib_fw_matrix"
+	CheckIBProbe         = "check_ib_probe"
+	CheckIBVFNum         = "check_ib_vf_num"
+	CheckIBVFGUID        = "check_ib_vf_guid"
+	CheckIBVFLinkState   = "check_ib_vf_link_state"
+	CheckIBVFError       = "check_ib_vf_error"
+	CheckIBVFCounters    = "check_ib_vf_counters"
+	CheckIBSubnetManager = "check_ib_subnet_manager"
+	CheckIBSMFailover    = "check_ib_sm_failover"
+	CheckIBPortMTU       = "check_ib_port_mtu"
+	CheckRoCEGID         = "check_roce_gid"
+	CheckIBPhyDiag       = "check_ib_phy_diag"
+	CheckIBTemperature   = "check_ib_temperature"
+)
+
+// Error names of the congestion checker, which tells fabric congestion apart
+// from packet loss caused by link errors.
+const (
+	IBFabricCongestionErrorName = "IBFabricCongestion"
+	IBPacketLossErrorName       = "IBPacketLoss"
+	IBPacketLossSuggestion      = "Check the link errors, cable and transceiver of the port, and the PFC/ECN configuration of the switch if the fabric is RoCE"
 )
 
 var InfinibandCheckItems = map[string]common.CheckerResult{
@@ -180,4 +204,132 @@ var InfinibandCheckItems = map[string]common.CheckerResult{
 		ErrorName:   "IBLost",
 		Suggestion:  "Check IB device status",
 	},
+	CheckIBCounterRate: {
+		Name:        CheckIBCounterRate,
+		Description: "Check if the IB port error counters increase faster than the spec thresholds",
+		Level:       consts.LevelWarning,
+		Detail:      "IB port error counter rates are within the thresholds",
+		ErrorName:   "IBCounterRateExceeded",
+		Suggestion:  "Check the cable and transceiver of the port, and reseat or replace them if the errors keep increasing",
+	},
+	CheckIBCongestion: {
+		Name:        CheckIBCongestion,
+		Description: "Check if the CNP, pause frame or packet loss counters of the IB ports increase faster than the spec thresholds",
+		Level:       consts.LevelWarning,
+		Detail:      "IB port congestion and packet loss counter rates are within the thresholds",
+		ErrorName:   IBFabricCongestionErrorName,
+		Suggestion:  "Check the traffic pattern of the jobs and the congestion control (ECN/DCQCN) configuration of the fabric",
+	},
+	CheckIBLinkFlap: {
+		Name:        CheckIBLinkFlap,
+		Description: "Check if the IB ports went down more often than the spec allows within the flap window",
+		Level:       consts.LevelCritical,
+		Detail:      "No IB port flaps more often than the spec allows",
+		ErrorName:   "IBLinkFlapping",
+		Suggestion:  "Check the cable, transceiver and switch port of the flapping link, and replace the cable or transceiver if it keeps flapping",
+	},
+	CheckIBFWMatrix: {
+		Name:        CheckIBFWMatrix,
+		Description: "Check if the HCAs of the same type run the same firmware, supported by the installed OFED according to the spec",
+		Level:       consts.LevelWarning,
+		Detail:      "All HCAs run a consistent firmware supported by the installed OFED",
+		ErrorName:   "IBFirmwareInconsistent",
+		Suggestion:  "Update the firmware of the listed HCAs with `mlxfwmanager -u -d <pcie_bdf>`, then reset them with `mlxfwreset -d <pcie_bdf> reset` or reboot the node",
+	},
+	CheckIBProbe: {
+		Name:        CheckIBProbe,
+		Description: "Check if the gateway of each RoCE interface and the configured RDMA peers answer the probes without loss",
+		Level:       consts.LevelCritical,
+		Detail:      "The gateways and peers of all RDMA interfaces are reachable",
+		ErrorName:   "RDMAPeerUnreachable",
+		Suggestion:  "Check the routes, the policy routing rules and the switch port of the interface, and the cable if the probes are only partially lost",
+	},
+	CheckIBPhyDiag: {
+		Name:        CheckIBPhyDiag,
+		Description: "Check if the BER, the FEC corrected errors, the eye grade and the module of each active port read with mlxlink are within the spec",
+		Level:       consts.LevelWarning,
+		Detail:      "The physical layer of all active ports is healthy",
+		ErrorName:   "IBPhyDegraded",
+		Suggestion:  "Clean or reseat the cable and the transceiver of the port, replace them if the BER stays high, and check `mlxlink -d <dev> -p <port> -c -m` for the recommendation of the firmware",
+	},
+	CheckIBTemperature: {
+		Name:        CheckIBTemperature,
+		Description: "Check if the ASIC of each HCA and the cable of each port are below the max temperature of the spec",
+		Level:       consts.LevelWarning,
+		Detail:      "All HCAs and cables are below the max temperature",
+		ErrorName:   "IBTemperatureHigh",
+		Suggestion:  "Check the airflow and the fans around the HCA, an overheating HCA throttles and flaps its links",
+	},
+	CheckIBVFNum: {
+		Name:        CheckIBVFNum,
+		Description: "Check if each PF of an SR-IOV node exposes the number of VFs of the spec",
+		Level:       consts.LevelCritical,
+		Detail:      "All PFs expose the expected number of VFs",
+		ErrorName:   "IBVFNumMismatch",
+		Suggestion:  "Recreate the VFs with `echo <num> > /sys/class/infiniband/<dev>/device/sriov_numvfs`, and check the SR-IOV device plugin config and NUM_OF_VFS in `mlxconfig -d <pcie_bdf> q`",
+	},
+	CheckIBVFGUID: {
+		Name:        CheckIBVFGUID,
+		Description: "Check if each VF of an SR-IOV node has a unique non-zero GUID, or MAC on RoCE",
+		Level:       consts.LevelCritical,
+		Detail:      "All VFs have unique GUIDs assigned",
+		ErrorName:   "IBVFGUIDMissing",
+		Suggestion:  "Assign the GUIDs with `ip link set <pf_netdev> vf <n> node_guid <guid> port_guid <guid>`, or the MAC with `ip link set <pf_netdev> vf <n> mac <mac>` on RoCE, or restart the SR-IOV config daemon",
+	},
+	CheckIBVFLinkState: {
+		Name:        CheckIBVFLinkState,
+		Description: "Check if the link of each VF of an SR-IOV node follows its PF",
+		Level:       consts.LevelCritical,
+		Detail:      "The links of all VFs follow their PF",
+		ErrorName:   "IBVFLinkStateMismatch",
+		Suggestion:  "Set `ip link set <pf_netdev> vf <n> state auto` so that the VFs follow the link of the PF",
+	},
+	CheckIBVFError: {
+		Name:        CheckIBVFError,
+		Description: "Check if any VF of an SR-IOV node is stuck in an error state",
+		Level:       consts.LevelCritical,
+		Detail:      "No VF is in an error state",
+		ErrorName:   "IBVFError",
+		Suggestion:  "Drain the pods using the VF, then recreate the VFs of the PF or reset the HCA",
+	},
+	CheckIBVFCounters: {
+		Name:        CheckIBVFCounters,
+		Description: "Check if the error counters of the VFs given to the pods increase faster than the spec",
+		Level:       consts.LevelWarning,
+		Detail:      "No VF error counter increases faster than the spec",
+		ErrorName:   "IBVFCounterRateExceeded",
+		Suggestion:  "Check the workload of the pod and the link of the PF, completion errors and transport retries of a single VF point to the pod rather than the fabric",
+	},
+	CheckIBSubnetManager: {
+		Name:        CheckIBSubnetManager,
+		Description: "Check if the active InfiniBand ports are registered with a reachable master subnet manager",
+		Level:       consts.LevelCritical,
+		Detail:      "All InfiniBand ports are registered with the subnet manager",
+		ErrorName:   "IBSubnetManagerMissing",
+		Suggestion:  "Check that the subnet manager (opensm or UFM) of the fabric runs and can reach the switch of the port, a port stuck in INIT carries no traffic",
+	},
+	CheckIBSMFailover: {
+		Name:        CheckIBSMFailover,
+		Description: "Check if the subnet manager of the InfiniBand ports changed since the last check",
+		Level:       consts.LevelWarning,
+		Detail:      "The subnet manager of the InfiniBand ports did not change",
+		ErrorName:   "IBSubnetManagerFailover",
+		Suggestion:  "Check why the master subnet manager failed over, e.g. in the logs of the previous master, a flapping SM resweeps the fabric and stalls the traffic",
+	},
+	CheckIBPortMTU: {
+		Name:        CheckIBPortMTU,
+		Description: "Check if the active MTU of the InfiniBand ports and the MTU of the RoCE netdevs match the spec",
+		Level:       consts.LevelWarning,
+		Detail:      "The MTU of all active ports matches the spec",
+		ErrorName:   "IBPortMTUMismatch",
+		Suggestion:  "Set the MTU of the RoCE netdev with `ip link set <netdev> mtu 9000` and persist it in the network config, or check the MTU of the IB partition in the subnet manager",
+	},
+	CheckRoCEGID: {
+		Name:        CheckRoCEGID,
+		Description: "Check if the RoCE ports expose a GID of the expected type for the addresses of their netdev",
+		Level:       consts.LevelCritical,
+		Detail:      "All RoCE ports expose the expected GIDs",
+		ErrorName:   "RoCEGIDMissing",
+		Suggestion:  "Check the IP address of the RoCE netdev and the default GID type with `cma_roce_mode -d <ibdev> -p <port>`, and that NCCL_IB_GID_INDEX points at the RoCE v2 GID",
+	},
 }
@@ -17,6 +17,7 @@ package config
 
 import (
 	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
 )
 
 type InfinibandUserConfig struct {
@@ -29,6 +30,17 @@ type InfinibandConfig struct {
 	CacheSize       int64           `json:"cache_size" yaml:"cache_size"`
 	EnableMetrics   bool            `json:"enable_metrics" yaml:"enable_metrics"`
 	IgnoredCheckers []string        `json:"ignored_checkers" yaml:"ignored_checkers"`
+	// StateDir keeps the counter baseline of the ports across restarts.
+	StateDir string `json:"state_dir,omitempty" yaml:"state_dir,omitempty"`
+}
+
+// StatePath returns the directory of the counter baseline,
+// consts.DefaultStatePath unless set.
+func (c *InfinibandConfig) StatePath() string {
+	if c == nil || c.StateDir == "" {
+		return consts.DefaultStatePath
+	}
+	return c.StateDir
 }
 
 func (c *InfinibandUserConfig) GetQueryInterval() common.Duration {
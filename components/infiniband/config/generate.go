@@ -0,0 +1,68 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/scitix/sichek/components/infiniband/collector"
+)
+
+// NewSpecFromInfo builds an InfinibandSpec from the devices, ports and
+// software observed on the local node. Versions are relaxed to ">=" so the
+// generated spec keeps passing after upgrades.
+func NewSpecFromInfo(info *collector.InfinibandInfo) *InfinibandSpec {
+	info.RLock()
+	defer info.RUnlock()
+
+	spec := &InfinibandSpec{
+		IBPFDevs: make(map[string]string, len(info.IBPFDevs)),
+		IBSoftWareInfo: &collector.IBSoftWareInfo{
+			KernelModule: append([]string(nil), info.IBSoftWareInfo.KernelModule...),
+		},
+		PCIeACS: "disable",
+	}
+	if info.IBSoftWareInfo.OFEDVer != "" {
+		spec.IBSoftWareInfo.OFEDVer = ">=" + info.IBSoftWareInfo.OFEDVer
+	}
+	for ibDev, netDev := range info.IBPFDevs {
+		if strings.Contains(ibDev, "mezz") {
+			continue
+		}
+		spec.IBPFDevs[ibDev] = netDev
+	}
+	spec.HCANum = len(spec.IBPFDevs)
+
+	// only record the ports of multi-port HCAs, the single port 1 ones are discovered
+	ports := make(map[string][]int)
+	for _, hwInfo := range info.IBHardWareInfo {
+		if _, ok := spec.IBPFDevs[hwInfo.IBDev]; ok {
+			ports[hwInfo.IBDev] = append(ports[hwInfo.IBDev], hwInfo.Port)
+		}
+	}
+	for ibDev, devPorts := range ports {
+		sort.Ints(devPorts)
+		if len(devPorts) == 1 && devPorts[0] == 1 {
+			continue
+		}
+		if spec.DevicePorts == nil {
+			spec.DevicePorts = make(map[string][]int)
+		}
+		spec.DevicePorts[ibDev] = devPorts
+	}
+	return spec
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/scitix/sichek/components/infiniband/collector"
+)
+
+func TestNewSpecFromInfo(t *testing.T) {
+	info := &collector.InfinibandInfo{
+		IBPFDevs: map[string]string{"mlx5_0": "ib0", "roce_r0": "eth_r0_p0", "mezz_0": "eth0"},
+		IBHardWareInfo: map[string]collector.IBHardWareInfo{
+			"mlx5_0/p1":  {IBDev: "mlx5_0", Port: 1},
+			"roce_r0/p6": {IBDev: "roce_r0", Port: 6},
+			"roce_r0/p3": {IBDev: "roce_r0", Port: 3},
+			"mezz_0/p1":  {IBDev: "mezz_0", Port: 1},
+		},
+		IBSoftWareInfo: collector.IBSoftWareInfo{
+			OFEDVer:      "MLNX_OFED_LINUX-24.10-2.1.8.0",
+			KernelModule: []string{"mlx5_core", "ib_core"},
+		},
+	}
+	spec := NewSpecFromInfo(info)
+	if spec.HCANum != 2 || spec.IBPFDevs["mezz_0"] != "" {
+		t.Errorf("unexpected ib devs: %v", spec.IBPFDevs)
+	}
+	if spec.IBSoftWareInfo.OFEDVer != ">=MLNX_OFED_LINUX-24.10-2.1.8.0" {
+		t.Errorf("unexpected ofed version: %s", spec.IBSoftWareInfo.OFEDVer)
+	}
+	if !reflect.DeepEqual(spec.DevicePorts, map[string][]int{"roce_r0": {3, 6}}) {
+		t.Errorf("unexpected device ports: %v", spec.DevicePorts)
+	}
+	if ports := spec.PortsFor("mlx5_0"); ports != nil {
+		t.Errorf("unexpected ports for mlx5_0: %v", ports)
+	}
+}
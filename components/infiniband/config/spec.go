@@ -42,9 +42,50 @@ type InfinibandSpec struct {
 	// RoCE) where the data path lives on ports other than 1.
 	DevicePorts map[string][]int `json:"device_ports,omitempty" yaml:"device_ports,omitempty"`
 	// DefaultPorts is the fallback port list applied to any IBPFDevs entry
-	// not present in DevicePorts. When both are empty, the collector keeps
-	// legacy behavior and reads only port 1.
+	// not present in DevicePorts. When both are empty, the collector reads
+	// the lowest port of the device and the other ones once seen active.
 	DefaultPorts []int `json:"default_ports,omitempty" yaml:"default_ports,omitempty"`
+	// CounterRateThresholds maps an IB port counter name (e.g. symbol_error)
+	// to the max increase per minute tolerated between two samples. When
+	// empty, DefaultCounterRateThresholds is used.
+	CounterRateThresholds map[string]float64 `json:"counter_rate_thresholds,omitempty" yaml:"counter_rate_thresholds,omitempty"`
+	// CongestionThresholds are the max increases per minute of the congestion
+	// (CNP, pause frames) and packet loss counters. When empty,
+	// DefaultCongestionThresholds is used.
+	CongestionThresholds *CongestionThresholds `json:"congestion_thresholds,omitempty" yaml:"congestion_thresholds,omitempty"`
+	// LinkFlap is the number of link flaps tolerated per port within a time
+	// window. When empty, DefaultLinkFlap is used.
+	LinkFlap *LinkFlapSpec `json:"link_flap,omitempty" yaml:"link_flap,omitempty"`
+	// Probe configures the active reachability probe of the gateway of the
+	// RoCE interfaces and of the optional peers. When empty, DefaultProbe is used.
+	Probe *ProbeSpec `json:"probe,omitempty" yaml:"probe,omitempty"`
+	// SRIOV is the expected SR-IOV setup of the HCAs of the sriovNode nodes.
+	// When empty, the VFs are checked against their own sriov_numvfs.
+	SRIOV *SRIOVSpec `json:"sriov,omitempty" yaml:"sriov,omitempty"`
+	// SubnetManager configures the checks of the subnet manager the
+	// InfiniBand ports are registered with. It applies to the whole fabric,
+	// the Boards do not override it.
+	SubnetManager *SubnetManagerSpec `json:"subnet_manager,omitempty" yaml:"subnet_manager,omitempty"`
+	// MTU is the MTU expected on the active ports. When empty, DefaultMTU
+	// is used.
+	MTU *MTUSpec `json:"mtu,omitempty" yaml:"mtu,omitempty"`
+	// RoCEGID is the GID the RoCE ports are expected to expose for the
+	// addresses of their netdev. When empty, DefaultRoCEGID is used.
+	RoCEGID *RoCEGIDSpec `json:"roce_gid,omitempty" yaml:"roce_gid,omitempty"`
+	// PhyDiag enables reading the BER, FEC and module health of the active
+	// ports with mlxlink. When the thresholds are empty, DefaultPhyDiag is used.
+	PhyDiag *PhyDiagSpec `json:"phy_diag,omitempty" yaml:"phy_diag,omitempty"`
+	// Temperature is the max temperature of the ASIC of the HCAs and of the
+	// cables of their ports. When empty, DefaultTemperature is used.
+	Temperature *TemperatureSpec `json:"temperature,omitempty" yaml:"temperature,omitempty"`
+	// Boards overrides the settings above for the HCAs of a board ID (PSID),
+	// e.g. MT_0000000970, or of an HCA type, e.g. MT41692 for the BlueField-3,
+	// so that the HCAs of a heterogeneous node are each checked against their
+	// own spec. The board ID entry wins over the HCA type one.
+	Boards map[string]*InfinibandSpec `json:"boards,omitempty" yaml:"boards,omitempty"`
+
+	// devSpecs is the spec selected from Boards for each IB device
+	devSpecs map[string]*InfinibandSpec
 
 	// HCAs is for in-memory use only (full specs)
 	HCAs map[string]*hcaConfig.HCASpec `json:"-" yaml:"-"`
@@ -53,19 +94,435 @@ type InfinibandSpec struct {
 }
 
 // PortsFor returns the port numbers that should be sampled for the given IB
-// device. Resolution order: DevicePorts entry → DefaultPorts → []int{1}.
-// The returned slice is always non-empty so callers can range over it
-// unconditionally.
+// device. Resolution order: DevicePorts entry → DefaultPorts → nil, for the
+// collector to discover the active ports of the device.
 func (s *InfinibandSpec) PortsFor(ibDev string) []int {
 	if s != nil {
 		if ports, ok := s.DevicePorts[ibDev]; ok && len(ports) > 0 {
 			return ports
 		}
-		if len(s.DefaultPorts) > 0 {
-			return s.DefaultPorts
+		if dev := s.ForDevice(ibDev); len(dev.DefaultPorts) > 0 {
+			return dev.DefaultPorts
+		}
+	}
+	return nil
+}
+
+// ForDevice returns the spec selected for the board of ibDev by
+// SelectBoardSpecs, or the spec itself when no entry of Boards matches.
+func (s *InfinibandSpec) ForDevice(ibDev string) *InfinibandSpec {
+	if s != nil {
+		if dev, ok := s.devSpecs[ibDev]; ok {
+			return dev
+		}
+	}
+	return s
+}
+
+// SelectBoardSpecs picks the entry of Boards matching each IB device, by its
+// board ID in devBoardIDs, else by its HCA type in devHCATypes.
+func (s *InfinibandSpec) SelectBoardSpecs(devBoardIDs, devHCATypes map[string]string) {
+	s.devSpecs = make(map[string]*InfinibandSpec)
+	if len(s.Boards) == 0 {
+		return
+	}
+	for dev, boardID := range devBoardIDs {
+		key := boardID
+		board, ok := s.Boards[key]
+		if !ok {
+			key = devHCATypes[dev]
+			board, ok = s.Boards[key]
 		}
+		if !ok || board == nil {
+			continue
+		}
+		logrus.WithField("component", "infiniband").Infof("using the %s board spec for %s", key, dev)
+		s.devSpecs[dev] = s.withBoard(board)
+	}
+}
+
+// withBoard returns a copy of the spec with the settings board sets.
+func (s *InfinibandSpec) withBoard(board *InfinibandSpec) *InfinibandSpec {
+	merged := *s
+	merged.Boards = nil
+	merged.devSpecs = nil
+	if board.PCIeACS != "" {
+		merged.PCIeACS = board.PCIeACS
+	}
+	if len(board.DefaultPorts) > 0 {
+		merged.DefaultPorts = board.DefaultPorts
+	}
+	if len(board.CounterRateThresholds) > 0 {
+		merged.CounterRateThresholds = board.CounterRateThresholds
+	}
+	if board.CongestionThresholds != nil {
+		merged.CongestionThresholds = board.CongestionThresholds
+	}
+	if board.LinkFlap != nil {
+		merged.LinkFlap = board.LinkFlap
+	}
+	if board.Probe != nil {
+		merged.Probe = board.Probe
+	}
+	if board.SRIOV != nil {
+		merged.SRIOV = board.SRIOV
+	}
+	if board.MTU != nil {
+		merged.MTU = board.MTU
+	}
+	if board.RoCEGID != nil {
+		merged.RoCEGID = board.RoCEGID
+	}
+	if board.PhyDiag != nil {
+		merged.PhyDiag = board.PhyDiag
+	}
+	if board.Temperature != nil {
+		merged.Temperature = board.Temperature
+	}
+	return &merged
+}
+
+// DefaultCounterRateThresholds are the max increases per minute of the IB
+// port error counters, a link that goes down is never expected.
+var DefaultCounterRateThresholds = map[string]float64{
+	"symbol_error":                    10,
+	"port_rcv_errors":                 10,
+	"link_downed":                     0,
+	"link_error_recovery":             0,
+	"local_link_integrity_errors":     0,
+	"excessive_buffer_overrun_errors": 0,
+}
+
+// RateThresholds returns the counter rate thresholds of the spec, falling
+// back to DefaultCounterRateThresholds.
+func (s *InfinibandSpec) RateThresholds() map[string]float64 {
+	if s != nil && len(s.CounterRateThresholds) > 0 {
+		return s.CounterRateThresholds
+	}
+	return DefaultCounterRateThresholds
+}
+
+// CongestionThresholds splits the counters watched by the congestion checker
+// into congestion signals and packet loss signals, each mapping a counter
+// name to the max increase per minute.
+type CongestionThresholds struct {
+	Congestion map[string]float64 `json:"congestion,omitempty" yaml:"congestion,omitempty"`
+	Loss       map[string]float64 `json:"loss,omitempty" yaml:"loss,omitempty"`
+}
+
+// DefaultCongestionThresholds tolerate the CNPs and pause frames of a busy
+// fabric, while a lossless fabric should barely see out of sequence packets.
+var DefaultCongestionThresholds = &CongestionThresholds{
+	Congestion: map[string]float64{
+		"np_cnp_sent":       600000,
+		"rp_cnp_handled":    600000,
+		"rx_pause_ctrl_phy": 60000,
+		"tx_pause_ctrl_phy": 60000,
+	},
+	Loss: map[string]float64{
+		"out_of_sequence": 100,
+		"packet_seq_err":  10,
+	},
+}
+
+// CongestionRateThresholds returns the congestion thresholds of the spec,
+// falling back to DefaultCongestionThresholds for the empty groups.
+func (s *InfinibandSpec) CongestionRateThresholds() *CongestionThresholds {
+	thresholds := &CongestionThresholds{
+		Congestion: DefaultCongestionThresholds.Congestion,
+		Loss:       DefaultCongestionThresholds.Loss,
+	}
+	if s == nil || s.CongestionThresholds == nil {
+		return thresholds
+	}
+	if len(s.CongestionThresholds.Congestion) > 0 {
+		thresholds.Congestion = s.CongestionThresholds.Congestion
+	}
+	if len(s.CongestionThresholds.Loss) > 0 {
+		thresholds.Loss = s.CongestionThresholds.Loss
+	}
+	return thresholds
+}
+
+// LinkFlapSpec reports a port once it went down MaxFlaps times within the
+// last WindowMinutes.
+type LinkFlapSpec struct {
+	MaxFlaps      int `json:"max_flaps" yaml:"max_flaps"`
+	WindowMinutes int `json:"window_minutes" yaml:"window_minutes"`
+}
+
+// DefaultLinkFlap tolerates a couple of link downs an hour, e.g. a cable
+// reseat or a switch reboot, but not a port bouncing every few minutes.
+var DefaultLinkFlap = &LinkFlapSpec{
+	MaxFlaps:      3,
+	WindowMinutes: 60,
+}
+
+// LinkFlapLimit returns the link flap limit of the spec, falling back to
+// DefaultLinkFlap for the unset fields.
+func (s *InfinibandSpec) LinkFlapLimit() *LinkFlapSpec {
+	limit := *DefaultLinkFlap
+	if s == nil || s.LinkFlap == nil {
+		return &limit
+	}
+	if s.LinkFlap.MaxFlaps > 0 {
+		limit.MaxFlaps = s.LinkFlap.MaxFlaps
+	}
+	if s.LinkFlap.WindowMinutes > 0 {
+		limit.WindowMinutes = s.LinkFlap.WindowMinutes
+	}
+	return &limit
+}
+
+// ProbeSpec sends Count probes to the gateway of each RoCE interface, waiting
+// TimeoutMs for each reply, and reports an interface once more than
+// MaxLossPercent of them are lost or the average RTT exceeds MaxRTTMs.
+type ProbeSpec struct {
+	Count          int     `json:"count" yaml:"count"`
+	TimeoutMs      int     `json:"timeout_ms" yaml:"timeout_ms"`
+	MaxLossPercent float64 `json:"max_loss_percent" yaml:"max_loss_percent"`
+	MaxRTTMs       float64 `json:"max_rtt_ms,omitempty" yaml:"max_rtt_ms,omitempty"`
+	// RPingPeer is the address of a node running `rping -s`, probed over
+	// rdma-cm from every RoCE interface when set.
+	RPingPeer string `json:"rping_peer,omitempty" yaml:"rping_peer,omitempty"`
+	// IBPingPeer is the LID of a node running `ibping -S`, probed from every
+	// InfiniBand port when set.
+	IBPingPeer string `json:"ibping_peer,omitempty" yaml:"ibping_peer,omitempty"`
+}
+
+// SRIOVSpec is the number of VFs each PF of a sriovNode node should expose,
+// and the link state of the VFs, "auto" to follow the link of the PF.
+type SRIOVSpec struct {
+	NumVFs    int    `json:"num_vfs,omitempty" yaml:"num_vfs,omitempty"`
+	LinkState string `json:"link_state,omitempty" yaml:"link_state,omitempty"`
+	// CounterRateThresholds are the max increases per minute of the error
+	// counters of the VFs given to the pods. When empty,
+	// DefaultVFCounterRateThresholds is used.
+	CounterRateThresholds map[string]float64 `json:"counter_rate_thresholds,omitempty" yaml:"counter_rate_thresholds,omitempty"`
+}
+
+// SubnetManagerSpec enables querying the master SM of each InfiniBand port
+// with sminfo, which reaches the SM over the fabric instead of trusting the
+// local port state, and restricts the SMs allowed to be master.
+type SubnetManagerSpec struct {
+	QuerySMInfo bool `json:"query_sminfo,omitempty" yaml:"query_sminfo,omitempty"`
+	// SMGUIDs are the port GUIDs of the SMs expected to be master, e.g. the
+	// ones of the UFM appliances, any SM is accepted when empty. Requires
+	// QuerySMInfo.
+	SMGUIDs []string `json:"sm_guids,omitempty" yaml:"sm_guids,omitempty"`
+}
+
+// MTUSpec is the MTU of the active ports. A port below the MTU of the
+// fabric still carries traffic, only the NCCL bandwidth silently drops.
+type MTUSpec struct {
+	// IB is the active MTU of the InfiniBand ports.
+	IB int `json:"ib,omitempty" yaml:"ib,omitempty"`
+	// RoCE is the MTU of the netdev of the RoCE ports.
+	RoCE int `json:"roce,omitempty" yaml:"roce,omitempty"`
+}
+
+// DefaultMTU is the largest IB MTU, and jumbo frames on the RoCE netdevs so
+// that the RDMA MTU reaches 4096 as well.
+var DefaultMTU = &MTUSpec{
+	IB:   4096,
+	RoCE: 9000,
+}
+
+// ExpectedMTU returns the MTU spec, falling back to DefaultMTU for the
+// unset fields.
+func (s *InfinibandSpec) ExpectedMTU() *MTUSpec {
+	mtu := *DefaultMTU
+	if s == nil || s.MTU == nil {
+		return &mtu
+	}
+	if s.MTU.IB > 0 {
+		mtu.IB = s.MTU.IB
+	}
+	if s.MTU.RoCE > 0 {
+		mtu.RoCE = s.MTU.RoCE
+	}
+	return &mtu
+}
+
+// RoCEGIDSpec is the GID expected for each address of the netdev of a RoCE
+// port. NCCL_IB_GID_INDEX selects the GID by its index, a GID of the wrong
+// type or at another index makes NCCL fall back to a slow path or hang.
+type RoCEGIDSpec struct {
+	// Type is the GID type, RoCE v2 or IB/RoCE v1.
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+	// Index, when set, is the index the GID must be at, e.g. 3.
+	Index *int `json:"index,omitempty" yaml:"index,omitempty"`
+}
+
+// DefaultRoCEGID requires a RoCE v2 GID at any index.
+var DefaultRoCEGID = &RoCEGIDSpec{
+	Type: collector.GIDTypeRoCEv2,
+}
+
+// ExpectedRoCEGID returns the RoCE GID spec, falling back to DefaultRoCEGID
+// for the unset fields.
+func (s *InfinibandSpec) ExpectedRoCEGID() *RoCEGIDSpec {
+	gid := *DefaultRoCEGID
+	if s == nil || s.RoCEGID == nil {
+		return &gid
+	}
+	if s.RoCEGID.Type != "" {
+		gid.Type = s.RoCEGID.Type
+	}
+	gid.Index = s.RoCEGID.Index
+	return &gid
+}
+
+// DefaultVFLinkState makes the VFs follow the link of their PF, so that a
+// pod sees its VF go down with the port instead of a stale link up.
+const DefaultVFLinkState = "auto"
+
+// VFLinkState returns the expected link state of the VFs.
+func (s *InfinibandSpec) VFLinkState() string {
+	if s != nil && s.SRIOV != nil && s.SRIOV.LinkState != "" {
+		return s.SRIOV.LinkState
+	}
+	return DefaultVFLinkState
+}
+
+// DefaultVFCounterRateThresholds are the max increases per minute of the
+// transport error counters of a VF. Retries and out of sequence packets show
+// up on a busy fabric, a completion error breaks a QP of the workload.
+var DefaultVFCounterRateThresholds = map[string]float64{
+	"out_of_sequence":         100,
+	"packet_seq_err":          10,
+	"implied_nak_seq_err":     10,
+	"local_ack_timeout_err":   1,
+	"rnr_nak_retry_err":       1,
+	"req_cqe_error":           0,
+	"resp_cqe_error":          0,
+	"resp_local_length_error": 0,
+}
+
+// VFCounterRateThresholds returns the VF counter rate thresholds of the spec,
+// falling back to DefaultVFCounterRateThresholds.
+func (s *InfinibandSpec) VFCounterRateThresholds() map[string]float64 {
+	if s != nil && s.SRIOV != nil && len(s.SRIOV.CounterRateThresholds) > 0 {
+		return s.SRIOV.CounterRateThresholds
+	}
+	return DefaultVFCounterRateThresholds
+}
+
+// ExpectedVFs returns the number of VFs per PF of the spec, 0 if unset.
+func (s *InfinibandSpec) ExpectedVFs() int {
+	if s != nil && s.SRIOV != nil {
+		return s.SRIOV.NumVFs
 	}
-	return []int{1}
+	return 0
+}
+
+// DefaultProbe tolerates a lost probe out of five, a gateway answering
+// none of them is unreachable.
+var DefaultProbe = &ProbeSpec{
+	Count:          5,
+	TimeoutMs:      1000,
+	MaxLossPercent: 20,
+}
+
+// ProbeLimit returns the probe spec, falling back to DefaultProbe for the
+// unset fields.
+func (s *InfinibandSpec) ProbeLimit() *ProbeSpec {
+	limit := *DefaultProbe
+	if s == nil || s.Probe == nil {
+		return &limit
+	}
+	if s.Probe.Count > 0 {
+		limit.Count = s.Probe.Count
+	}
+	if s.Probe.TimeoutMs > 0 {
+		limit.TimeoutMs = s.Probe.TimeoutMs
+	}
+	if s.Probe.MaxLossPercent > 0 {
+		limit.MaxLossPercent = s.Probe.MaxLossPercent
+	}
+	limit.MaxRTTMs = s.Probe.MaxRTTMs
+	limit.RPingPeer = s.Probe.RPingPeer
+	limit.IBPingPeer = s.Probe.IBPingPeer
+	return &limit
+}
+
+// PhyDiagSpec runs mlxlink on every active port at most once per
+// IntervalMinutes, as it queries the firmware of the HCA and the module and
+// takes seconds per port. A port fails when its effective BER, after the FEC,
+// exceeds MaxEffectiveBER, its raw BER, the errors the FEC corrected, exceeds
+// MaxRawBER, the grade of a lane is below MinGrade, or its module is hotter
+// than MaxModuleTempC or receives less than its low rx power alarm. MinGrade
+// 0 skips the eye grade, which not every speed supports, and MaxModuleTempC
+// 0 uses the high temperature alarm of the module.
+type PhyDiagSpec struct {
+	Enable          bool    `json:"enable" yaml:"enable"`
+	IntervalMinutes int     `json:"interval_minutes,omitempty" yaml:"interval_minutes,omitempty"`
+	MaxEffectiveBER float64 `json:"max_effective_ber,omitempty" yaml:"max_effective_ber,omitempty"`
+	MaxRawBER       float64 `json:"max_raw_ber,omitempty" yaml:"max_raw_ber,omitempty"`
+	MinGrade        float64 `json:"min_grade,omitempty" yaml:"min_grade,omitempty"`
+	MaxModuleTempC  float64 `json:"max_module_temp_c,omitempty" yaml:"max_module_temp_c,omitempty"`
+}
+
+// DefaultPhyDiag tolerates the raw BER the FEC of a PAM4 link corrects
+// routinely, but no more than one uncorrected error in 1e12 bits.
+var DefaultPhyDiag = &PhyDiagSpec{
+	IntervalMinutes: 60,
+	MaxEffectiveBER: 1e-12,
+	MaxRawBER:       1e-5,
+}
+
+// PhyDiagLimit returns the mlxlink spec, falling back to DefaultPhyDiag for
+// the unset thresholds. It is disabled unless the spec enables it.
+func (s *InfinibandSpec) PhyDiagLimit() *PhyDiagSpec {
+	limit := *DefaultPhyDiag
+	if s == nil || s.PhyDiag == nil {
+		return &limit
+	}
+	limit.Enable = s.PhyDiag.Enable
+	if s.PhyDiag.IntervalMinutes > 0 {
+		limit.IntervalMinutes = s.PhyDiag.IntervalMinutes
+	}
+	if s.PhyDiag.MaxEffectiveBER > 0 {
+		limit.MaxEffectiveBER = s.PhyDiag.MaxEffectiveBER
+	}
+	if s.PhyDiag.MaxRawBER > 0 {
+		limit.MaxRawBER = s.PhyDiag.MaxRawBER
+	}
+	limit.MinGrade = s.PhyDiag.MinGrade
+	limit.MaxModuleTempC = s.PhyDiag.MaxModuleTempC
+	return &limit
+}
+
+// TemperatureSpec is the max temperature in Celsius of the ASIC of an HCA,
+// read from its hwmon, and of the cable modules of its ports. An overheating
+// HCA throttles and its links flap long before the firmware shuts it down.
+type TemperatureSpec struct {
+	MaxASICTempC  float64 `json:"max_asic_temp_c,omitempty" yaml:"max_asic_temp_c,omitempty"`
+	MaxCableTempC float64 `json:"max_cable_temp_c,omitempty" yaml:"max_cable_temp_c,omitempty"`
+}
+
+// DefaultTemperature leaves a margin below the 120C shutdown of the
+// ConnectX ASICs and the 75C max operating temperature of the optical
+// modules.
+var DefaultTemperature = &TemperatureSpec{
+	MaxASICTempC:  105,
+	MaxCableTempC: 75,
+}
+
+// TemperatureLimit returns the temperature spec, falling back to
+// DefaultTemperature for the unset thresholds.
+func (s *InfinibandSpec) TemperatureLimit() *TemperatureSpec {
+	limit := *DefaultTemperature
+	if s == nil || s.Temperature == nil {
+		return &limit
+	}
+	if s.Temperature.MaxASICTempC > 0 {
+		limit.MaxASICTempC = s.Temperature.MaxASICTempC
+	}
+	if s.Temperature.MaxCableTempC > 0 {
+		limit.MaxCableTempC = s.Temperature.MaxCableTempC
+	}
+	return &limit
 }
 
 // LoadSpec loads infiniband spec from the given file path using the common YAML loader.
@@ -123,6 +580,12 @@ func FilterSpec(specs *InfinibandSpecs, file string) (*InfinibandSpec, error) {
 				Warnf("IB devices in the spec [%v] are not consistent with the current hardware[%v], trimming the spec to match the current hardware", specKeys, currKeys)
 		}
 		ibSpec.HCANum = len(ibSpec.IBPFDevs)
+		hcaTypes := hcaConfig.GetIBPFHCATypes(currKeys)
+		ibSpec.SelectBoardSpecs(devBoardIDMap, hcaTypes)
+		boardHCATypes := make(map[string]string, len(hcaTypes))
+		for dev, hcaType := range hcaTypes {
+			boardHCATypes[devBoardIDMap[dev]] = hcaType
+		}
 
 		// Load HCA specs from provided file and merge with default specs
 		// This will load from the provided file, merge with built-in specs (provided file has higher priority),
@@ -139,7 +602,7 @@ func FilterSpec(specs *InfinibandSpecs, file string) (*InfinibandSpec, error) {
 		// Check each board ID and fill in missing specs from hcaSpecs
 		var missingBoardIDs []string
 		for _, boardID := range ibDevs {
-			if hcaSpec, ok := hcaSpecs.Specs[boardID]; ok {
+			if hcaSpec, ok := hcaSpecs.Lookup(boardID, boardHCATypes[boardID]); ok {
 				ibSpec.HCAs[boardID] = hcaSpec
 				logrus.WithField("component", "infiniband").
 					Infof("loaded HCA spec for hardware board ID %s", boardID)
@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	hcaConfig "github.com/scitix/sichek/components/hca/config"
+	"github.com/scitix/sichek/pkg/hostfs/hostfstest"
 	"github.com/scitix/sichek/pkg/utils"
 )
 
@@ -167,6 +168,7 @@ hca:
 }
 
 func TestGetClusterInfinibandSpec(t *testing.T) {
+	hostfstest.Load(t, "h100-cx7")
 	// LoadSpec requires a non-empty file path; use a temp spec file with infiniband + top-level hca
 	specFile, err := os.CreateTemp("", "spec_*.yaml")
 	if err != nil {
@@ -205,6 +207,7 @@ hca:
 // TestLoadSpecFillsHCAsFromTopLevelHca verifies that LoadSpec (FilterSpec) fills InfinibandSpec.HCAs
 // from the top-level "hca" section for each board ID on the host.
 func TestLoadSpecFillsHCAsFromTopLevelHca(t *testing.T) {
+	hostfstest.Load(t, "h100-cx7")
 	specData := `
 nvidia: {}
 infiniband:
@@ -305,3 +308,44 @@ hca:
 		break
 	}
 }
+
+func TestSelectBoardSpecs(t *testing.T) {
+	spec := &InfinibandSpec{
+		PCIeACS:  "disable",
+		LinkFlap: &LinkFlapSpec{MaxFlaps: 3, WindowMinutes: 60},
+		Boards: map[string]*InfinibandSpec{
+			// BlueField-3 storage HCAs, by HCA type
+			"MT41692": {
+				DefaultPorts: []int{1, 2},
+				LinkFlap:     &LinkFlapSpec{MaxFlaps: 10},
+			},
+			"MT_0000000970": {
+				CounterRateThresholds: map[string]float64{"symbol_error": 100},
+			},
+		},
+	}
+	spec.SelectBoardSpecs(
+		map[string]string{"mlx5_0": "MT_0000000970", "mlx5_1": "MT_0000000971", "mlx5_2": "MT_0000001093"},
+		map[string]string{"mlx5_0": "MT4129", "mlx5_1": "MT4129", "mlx5_2": "MT41692"},
+	)
+
+	if got := spec.ForDevice("mlx5_0").RateThresholds()["symbol_error"]; got != 100 {
+		t.Errorf("expected the board ID spec for mlx5_0, got symbol_error %v", got)
+	}
+	if got := spec.ForDevice("mlx5_1"); got != spec {
+		t.Errorf("expected the node spec for mlx5_1 without a board entry")
+	}
+	bf3 := spec.ForDevice("mlx5_2")
+	if got := bf3.LinkFlapLimit(); got.MaxFlaps != 10 || got.WindowMinutes != 60 {
+		t.Errorf("expected the HCA type spec for mlx5_2, got %+v", got)
+	}
+	if bf3.PCIeACS != "disable" {
+		t.Errorf("expected mlx5_2 to inherit pcie_acs, got %q", bf3.PCIeACS)
+	}
+	if ports := spec.PortsFor("mlx5_2"); len(ports) != 2 {
+		t.Errorf("expected the ports of the HCA type spec, got %v", ports)
+	}
+	if ports := spec.PortsFor("mlx5_0"); ports != nil {
+		t.Errorf("expected the ports of mlx5_0 to be discovered, got %v", ports)
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/scitix/sichek/pkg/utils"
 
 	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
 	"github.com/sirupsen/logrus"
 )
 
@@ -49,6 +50,9 @@ type component struct {
 	cfgMutex      sync.RWMutex
 	collector     common.Collector
 	checkers      []common.Checker
+	flapHistory   *checker.LinkFlapHistory
+	baseline      *checker.CounterBaseline
+	specMtx       sync.RWMutex
 	cacheMtx      sync.RWMutex
 	cacheBuffer   []*common.Result
 	cacheInfo     []common.Info
@@ -80,6 +84,7 @@ func newInfinibandComponent(cfgFile string, specFile string, ignoredCheckers []s
 		ctx:           ctx,
 		cancel:        cancel,
 		componentName: consts.ComponentNameInfiniband,
+		flapHistory:   checker.NewLinkFlapHistory(),
 	}
 
 	// load user config first (needed for service creation even if spec fails)
@@ -110,6 +115,7 @@ func newInfinibandComponent(cfgFile string, specFile string, ignoredCheckers []s
 		cfg.Infiniband.IgnoredCheckers = ignoredCheckers
 	}
 	component.cfg = cfg
+	component.baseline = checker.NewCounterBaseline(cfg.Infiniband.StatePath())
 
 	cacheSize := cfg.Infiniband.CacheSize
 	if cacheSize <= 0 {
@@ -121,20 +127,20 @@ func newInfinibandComponent(cfgFile string, specFile string, ignoredCheckers []s
 	component.cacheSize = cacheSize
 
 	// load spec file
-	ibSpec, err := config.LoadSpec(specFile)
-	if err != nil {
-		logrus.WithField("component", "infiniband").Errorf("load spec config failed: %v", err)
-		component.initError = fmt.Errorf("spec loading failed: %v", err)
-		component.service = common.NewCommonService(ctx, cfg, component.componentName, component.GetTimeout(), component.HealthCheck)
-		return component, nil
-	}
-	component.spec = ibSpec
-
-	specJSON, jsonErr := json.MarshalIndent(ibSpec, "", "  ")
-	if jsonErr != nil {
-		logrus.WithField("component", "infiniband").Errorf("Failed to marshal spec to JSON: %v", jsonErr)
+	// a missing or invalid spec only disables the spec based checkers, the
+	// collector still runs so the infos keep being reported
+	ibSpec, specErr := config.LoadSpec(specFile)
+	if specErr != nil {
+		logrus.WithField("component", "infiniband").Warnf("load spec config failed, degrade to SpecMissing: %v", specErr)
+		ibSpec = nil
 	} else {
-		logrus.WithField("component", "infiniband").Infof("Infiniband Spec loaded (JSON):\n%s", string(specJSON))
+		component.spec = ibSpec
+		specJSON, jsonErr := json.MarshalIndent(ibSpec, "", "  ")
+		if jsonErr != nil {
+			logrus.WithField("component", "infiniband").Errorf("Failed to marshal spec to JSON: %v", jsonErr)
+		} else {
+			logrus.WithField("component", "infiniband").Infof("Infiniband Spec loaded (JSON):\n%s", string(specJSON))
+		}
 	}
 
 	// initialize metrics if enabled
@@ -152,11 +158,19 @@ func newInfinibandComponent(cfgFile string, specFile string, ignoredCheckers []s
 	}
 	// Wire spec port resolution into the collector so multi-plane HCAs are
 	// sampled per port instead of the legacy port-1 hard-coding.
-	ibCollector.SetPortResolver(ibSpec.PortsFor)
+	if ibSpec != nil {
+		ibCollector.SetPortResolver(ibSpec.PortsFor)
+	}
 	component.collector = ibCollector
 
+	if ibSpec == nil {
+		component.checkers = []common.Checker{common.NewSpecMissingChecker(consts.ComponentNameInfiniband, specErr)}
+		component.service = common.NewCommonService(ctx, cfg, component.componentName, component.GetTimeout(), component.HealthCheck)
+		return component, nil
+	}
+
 	// create checkers
-	checkers, err := checker.NewCheckers(cfg, ibSpec, ibCollector)
+	checkers, err := checker.NewCheckers(cfg, ibSpec, ibCollector, component.LastInfo, component.flapHistory, component.baseline)
 	if err != nil {
 		logrus.WithField("component", "infiniband").Errorf("NewCheckers failed: %v", err)
 		component.initError = fmt.Errorf("failed to create infiniband checkers: %w", err)
@@ -180,7 +194,7 @@ func (c *component) HealthCheck(ctx context.Context) (*common.Result, error) {
 		return c.reportInitErrorResult(), nil
 	}
 
-	info, err := c.collector.Collect(ctx)
+	info, err := common.Collect(ctx, c.componentName, c.collector.Collect)
 	if err != nil {
 		logrus.WithField("component", "Infiniband").Errorf("failed to collect Infiniband info: %v", err)
 		return nil, err
@@ -195,13 +209,18 @@ func (c *component) HealthCheck(ctx context.Context) (*common.Result, error) {
 		c.metrics.ExportMetrics(InfinibandInfo)
 	}
 
-	result := common.Check(ctx, c.componentName, InfinibandInfo, c.checkers)
+	c.specMtx.RLock()
+	spec, checkers := c.spec, c.checkers
+	c.specMtx.RUnlock()
+	result := common.Check(ctx, c.componentName, InfinibandInfo, checkers)
+	// the counters become the baseline of the next check once all checkers compared them
+	c.baseline.Record(InfinibandInfo)
 	// WARNING:
 	// When there is no intersection between `ibSpec.IBPFDevs` and `devBoardIDMap` discovered,
 	// the trimming operation in spec.gomay result in an empty `ibSpec.IBPFDevs`.
 	// This is considered an abnormal state and should trigger an alert,
 	// as it likely indicates a serious inconsistency in device discovery or spec synchronization.
-	if len(c.spec.IBPFDevs) == 0 {
+	if spec != nil && len(spec.IBPFDevs) == 0 {
 		result.Status = consts.StatusAbnormal
 		result.Checkers = append(result.Checkers, c.buildSpecEmptyErrorResult())
 	}
@@ -280,6 +299,36 @@ func (c *component) Start() <-chan *common.Result {
 	return c.service.Start()
 }
 
+// UpdateSpec reloads the infiniband spec, rebuilds the checkers and points the
+// collector to the ports of the new spec.
+func (c *component) UpdateSpec(specFile string) error {
+	ibCollector, ok := c.collector.(*collector.InfinibandInfo)
+	if !ok {
+		return fmt.Errorf("infiniband collector is not initialized")
+	}
+	spec, err := config.LoadSpec(specFile)
+	if err != nil {
+		return fmt.Errorf("load infiniband spec %s: %w", specFile, err)
+	}
+	if spec == nil {
+		return fmt.Errorf("infiniband spec is nil after loading from %s", specFile)
+	}
+	c.cfgMutex.RLock()
+	cfg := c.cfg
+	c.cfgMutex.RUnlock()
+	checkers, err := checker.NewCheckers(cfg, spec, ibCollector, c.LastInfo, c.flapHistory, c.baseline)
+	if err != nil {
+		return err
+	}
+	c.specMtx.Lock()
+	ibCollector.SetPortResolver(spec.PortsFor)
+	c.spec = spec
+	c.checkers = checkers
+	c.specMtx.Unlock()
+	logrus.WithField("component", "infiniband").Infof("reloaded spec from %s", specFile)
+	return nil
+}
+
 // Return the running status of the component
 func (c *component) Status() bool {
 	return c.service.Status()
@@ -338,16 +387,16 @@ func (c *component) PrintInfo(info common.Info, result *common.Result, summaryPr
 	// HealthCheck-time result captured so the operator sees the actual
 	// reason instead of an opaque "invalid data type" line.
 	if info == nil {
-		fmt.Println("Errors Events:")
+		printer.Println("Errors Events:")
 		if result == nil || len(result.Checkers) == 0 {
-			fmt.Printf("\t%sInfiniband component initialization failed (no detail)%s\n", consts.Red, consts.Reset)
+			printer.Printf("\t%sInfiniband component initialization failed (no detail)%s\n", consts.Red, consts.Reset)
 		} else {
 			for _, cr := range result.Checkers {
 				detail := cr.Detail
 				if detail == "" {
 					detail = cr.Curr
 				}
-				fmt.Printf("\t%s[%s] %s%s\n", consts.Red, cr.ErrorName, detail, consts.Reset)
+				printer.Printf("\t%s[%s] %s%s\n", consts.Red, cr.ErrorName, detail, consts.Reset)
 			}
 		}
 		return false
@@ -455,20 +504,20 @@ func (c *component) PrintInfo(info common.Info, result *common.Result, summaryPr
 		if printInterval < len(ofedVersionPrint) {
 			printInterval = len(ofedVersionPrint) + 2
 		}
-		fmt.Printf("%-*s\n", printInterval, ibControllersPrint)
-		fmt.Printf("%-*s%-*s%-*s\n", printInterval, ibKmodPrint, printInterval, phyStatPrint, printInterval, "")          //, PerformancePrint)
-		fmt.Printf("%-*s%-*s\t%-*s\n", printInterval, ofedVersionPrint, printInterval, ibStatePrint, printInterval, "")   //, "Throughput: TBD")
-		fmt.Printf("%-*s%-*s\t%-*s\n", printInterval, fwVersionPrint, printInterval, ibPortSpeedPrint, printInterval, "") //, "Latency: TBD")
-		fmt.Printf("%-*s%-*s\n", printInterval, consts.Green+""+consts.Reset, printInterval, pcieLinkPrint)
+		printer.Printf("%-*s\n", printInterval, ibControllersPrint)
+		printer.Printf("%-*s%-*s%-*s\n", printInterval, ibKmodPrint, printInterval, phyStatPrint, printInterval, "")          //, PerformancePrint)
+		printer.Printf("%-*s%-*s\t%-*s\n", printInterval, ofedVersionPrint, printInterval, ibStatePrint, printInterval, "")   //, "Throughput: TBD")
+		printer.Printf("%-*s%-*s\t%-*s\n", printInterval, fwVersionPrint, printInterval, ibPortSpeedPrint, printInterval, "") //, "Latency: TBD")
+		printer.Printf("%-*s%-*s\n", printInterval, consts.Green+""+consts.Reset, printInterval, pcieLinkPrint)
 	}
 
-	fmt.Println("Errors Events:")
+	printer.Println("Errors Events:")
 
 	if len(infinibandEvents) == 0 {
-		fmt.Printf("\t%sNo Infiniband Events Detected%s\n", consts.Green, consts.Reset)
+		printer.Printf("\t%sNo Infiniband Events Detected%s\n", consts.Green, consts.Reset)
 	} else {
 		for _, event := range infinibandEvents {
-			fmt.Printf("\t%s\n", event)
+			printer.Printf("\t%s\n", event)
 		}
 	}
 	logrus.Infof("ibInfo.IBCapablePCINum: %d, ibInfo.HCAPCINum: %d", ibInfo.IBCapablePCINum, ibInfo.HCAPCINum)
@@ -22,6 +22,15 @@ type devPortKey struct {
 	port string
 }
 
+// vfCounterKey is the label set of a counter of a VF given to a pod.
+type vfCounterKey struct {
+	dev, vf, bdf, namespace, pod, counter string
+}
+
+func (k vfCounterKey) labels() []string {
+	return []string{k.dev, k.vf, k.bdf, k.namespace, k.pod, k.counter}
+}
+
 type IBMetrics struct {
 	IBHardWareInfoGauge    *common.GaugeVecMetricExporter
 	IBNumGauge             *common.GaugeVecMetricExporter
@@ -29,9 +38,13 @@ type IBMetrics struct {
 	IBHardWareInfoStrGauge *common.GaugeVecMetricExporter
 	IBCounterGauge         *common.GaugeVecMetricExporter
 	IBSoftWareInfoGauge    *common.GaugeVecMetricExporter
+	// IBVFCounterGauge is the counters of the VFs given to the pods,
+	// labelled with the pod so that an anomaly points at the workload.
+	IBVFCounterGauge *common.GaugeVecMetricExporter
 	// previous label sets: used by deleting series that disappeared
 	prevIBDevs       map[devPortKey]struct{}
 	prevCounterPairs map[devPortKey]map[string]struct{} // (ibDev, port) -> set of counter names
+	prevVFCounters   map[vfCounterKey]struct{}
 	prevMu           sync.Mutex
 }
 
@@ -43,8 +56,10 @@ func NewInfinibandMetrics() *IBMetrics {
 		IBHardWareInfoStrGauge: common.NewGaugeVecMetricExporter(MetricPrefix, []string{"ib_dev", "port", "metric_name"}),
 		IBCounterGauge:         common.NewGaugeVecMetricExporter(MetricPrefix, []string{"ib_dev", "port", "counter_name"}),
 		IBSoftWareInfoGauge:    common.NewGaugeVecMetricExporter(MetricPrefix, []string{"metric_name"}),
+		IBVFCounterGauge:       common.NewGaugeVecMetricExporter(MetricPrefix, []string{"ib_dev", "vf", "bdf", "namespace", "pod", "counter_name"}),
 		prevIBDevs:             make(map[devPortKey]struct{}),
 		prevCounterPairs:       make(map[devPortKey]map[string]struct{}),
+		prevVFCounters:         make(map[vfCounterKey]struct{}),
 	}
 }
 
@@ -82,6 +97,24 @@ func (m *IBMetrics) ExportMetrics(infinibandInfo *collector.InfinibandInfo) {
 			curCounterPairs[k][c] = struct{}{}
 		}
 	}
+	curVFCounters := make(map[vfCounterKey]uint64)
+	for _, hw := range infinibandInfo.IBHardWareInfo {
+		for _, vf := range hw.VFs {
+			if vf.Pod == nil {
+				continue
+			}
+			for c, value := range vf.Counters {
+				curVFCounters[vfCounterKey{
+					dev:       hw.IBDev,
+					vf:        strconv.Itoa(vf.Index),
+					bdf:       vf.BDF,
+					namespace: vf.Pod.Namespace,
+					pod:       podLabel(vf.Pod),
+					counter:   c,
+				}] = value
+			}
+		}
+	}
 	infinibandInfo.RUnlock()
 
 	m.prevMu.Lock()
@@ -93,6 +126,9 @@ func (m *IBMetrics) ExportMetrics(infinibandInfo *collector.InfinibandInfo) {
 		m.IBHardWareInfoGauge.DeleteLabelValues("phy_state", []string{prev.dev, prev.port})
 		m.IBHardWareInfoGauge.DeleteLabelValues("port_state", []string{prev.dev, prev.port})
 		m.IBHardWareInfoGauge.DeleteLabelValues("port_speed_state", []string{prev.dev, prev.port})
+		for _, name := range temperatureMetrics {
+			m.IBHardWareInfoGauge.DeleteLabelValues(name, []string{prev.dev, prev.port})
+		}
 	}
 	for prev, prevCounters := range m.prevCounterPairs {
 		if _, stillPresent := curIBDevs[prev]; stillPresent {
@@ -102,10 +138,24 @@ func (m *IBMetrics) ExportMetrics(infinibandInfo *collector.InfinibandInfo) {
 			m.IBCounterGauge.DeleteLabelValues("counter", []string{prev.dev, prev.port, prevCounter})
 		}
 	}
+	for prev := range m.prevVFCounters {
+		if _, stillPresent := curVFCounters[prev]; !stillPresent {
+			// the VF was released or given to another pod
+			m.IBVFCounterGauge.DeleteLabelValues("vf_counter", prev.labels())
+		}
+	}
 	m.prevIBDevs = curIBDevs
 	m.prevCounterPairs = curCounterPairs
+	m.prevVFCounters = make(map[vfCounterKey]struct{}, len(curVFCounters))
+	for k := range curVFCounters {
+		m.prevVFCounters[k] = struct{}{}
+	}
 	m.prevMu.Unlock()
 
+	for k, value := range curVFCounters {
+		m.IBVFCounterGauge.SetMetric("vf_counter", k.labels(), float64(value))
+	}
+
 	// ib_hardware_info
 	infinibandInfo.RLock()
 	m.IBNumGauge.SetMetric("hca_num", nil, float64(infinibandInfo.HCAPCINum))
@@ -115,6 +165,8 @@ func (m *IBMetrics) ExportMetrics(infinibandInfo *collector.InfinibandInfo) {
 		m.IBHardWareInfoGauge.SetMetric("phy_state", []string{hardWareInfo.IBDev, port}, convertState(hardWareInfo.PhyState))
 		m.IBHardWareInfoGauge.SetMetric("port_state", []string{hardWareInfo.IBDev, port}, convertState(hardWareInfo.PortState))
 		m.IBHardWareInfoGauge.SetMetric("port_speed_state", []string{hardWareInfo.IBDev, port}, convertSpeed(hardWareInfo.PortSpeedState))
+		m.setTemperature("asic_temp_celsius", []string{hardWareInfo.IBDev, port}, hardWareInfo.ASICTemp)
+		m.setTemperature("cable_temp_celsius", []string{hardWareInfo.IBDev, port}, hardWareInfo.CableTemp)
 	}
 	// ib_counters keyed by the same per-port hwInfo map key (<ibdev>/p<port>).
 	for mapKey, ibCounter := range infinibandInfo.IBCounters {
@@ -132,6 +184,27 @@ func (m *IBMetrics) ExportMetrics(infinibandInfo *collector.InfinibandInfo) {
 	m.IBSoftWareInfoGauge.ExportStructWithStrField(infinibandInfo.IBSoftWareInfo, []string{}, TagPrefix)
 }
 
+// podLabel is the name of the pod of a VF, its UID without the pod-resources
+// API of the kubelet.
+func podLabel(pod *collector.VFPod) string {
+	if pod.Name != "" {
+		return pod.Name
+	}
+	return pod.UID
+}
+
+// temperatureMetrics are the temperatures of the ASIC of the HCA and of the
+// cable of each port, exported only when hwmon exposes them.
+var temperatureMetrics = []string{"asic_temp_celsius", "cable_temp_celsius"}
+
+func (m *IBMetrics) setTemperature(name string, labelVals []string, value float64) {
+	if value <= 0 {
+		m.IBHardWareInfoGauge.DeleteLabelValues(name, labelVals)
+		return
+	}
+	m.IBHardWareInfoGauge.SetMetric(name, labelVals, value)
+}
+
 func extractFirstNumber(s string) float64 {
 	var firstPart string
 
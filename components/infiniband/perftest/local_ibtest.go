@@ -26,6 +26,7 @@ import (
 	"github.com/scitix/sichek/components/common"
 	"github.com/scitix/sichek/components/infiniband/collector"
 	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
 )
 
 func RunLocalIBTest(ibBwPerfType string, ibDevice1 string, ibDevice2 string, serverIP string, msgSize int, testDuring, gid, qpNum int, useGDR, rdmaCM, verbose bool) (string, error) {
@@ -42,19 +43,19 @@ func RunLocalIBTest(ibBwPerfType string, ibDevice1 string, ibDevice2 string, ser
 	// Start server process
 	runCmd := fmt.Sprintf("%s %s > /dev/null", ibBwPerfType, ibBwArgs)
 	if verbose {
-		fmt.Printf("Executing: %s\n", runCmd)
+		printer.Printf("Executing: %s\n", runCmd)
 	}
 	serverCmd := exec.Command("sh", "-c", runCmd)
 	if err := serverCmd.Start(); err != nil {
 		if verbose {
-			fmt.Printf("Error starting server: %v\n", err)
+			printer.Printf("Error starting server: %v\n", err)
 		}
 		return "", fmt.Errorf("error starting server: %v", err)
 	}
 
 	// Sleep to allow server to start
 	if verbose {
-		fmt.Println("Sleeping for 2 seconds to allow server to initialize")
+		printer.Println("Sleeping for 2 seconds to allow server to initialize")
 	}
 	time.Sleep(2 * time.Second)
 
@@ -68,7 +69,7 @@ func RunLocalIBTest(ibBwPerfType string, ibDevice1 string, ibDevice2 string, ser
 	}
 	runCmd = fmt.Sprintf("%s %s %s", ibBwPerfType, ibBwArgs, serverIP)
 	if verbose {
-		fmt.Printf("Executing: %s\n", runCmd)
+		printer.Printf("Executing: %s\n", runCmd)
 	}
 	clientCmd := exec.Command("sh", "-c", runCmd)
 
@@ -78,8 +79,8 @@ func RunLocalIBTest(ibBwPerfType string, ibDevice1 string, ibDevice2 string, ser
 
 	if err := clientCmd.Run(); err != nil {
 		if verbose {
-			fmt.Printf("Error executing client: %v\n", err)
-			fmt.Printf("Stdout: %s\nStderr: %s\n", stdout.String(), stderr.String())
+			printer.Printf("Error executing client: %v\n", err)
+			printer.Printf("Stdout: %s\nStderr: %s\n", stdout.String(), stderr.String())
 		}
 		return "", fmt.Errorf("error executing client: %v", err)
 	}
@@ -112,7 +113,7 @@ func RunLocalNumaAwareIBTest(
 	}
 	serverCmdStr := fmt.Sprintf("numactl --membind=%d --cpunodebind=%d %s %s > /dev/null", numaNode, numaNode, ibBwPerfType, serverArgs)
 	if verbose {
-		fmt.Printf("Executing server: %s\n", serverCmdStr)
+		printer.Printf("Executing server: %s\n", serverCmdStr)
 	}
 
 	serverCmd := exec.Command("sh", "-c", serverCmdStr)
@@ -121,7 +122,7 @@ func RunLocalNumaAwareIBTest(
 	}
 
 	if verbose {
-		fmt.Println("Sleeping for 2 seconds to allow server to initialize")
+		printer.Println("Sleeping for 2 seconds to allow server to initialize")
 	}
 	time.Sleep(2 * time.Second)
 
@@ -135,7 +136,7 @@ func RunLocalNumaAwareIBTest(
 	}
 	clientCmdStr := fmt.Sprintf("numactl --membind=%d --cpunodebind=%d %s %s %s", numaNode, numaNode, ibBwPerfType, clientArgs, serverIP)
 	if verbose {
-		fmt.Printf("Executing client: %s\n", clientCmdStr)
+		printer.Printf("Executing client: %s\n", clientCmdStr)
 	}
 
 	clientCmd := exec.Command("sh", "-c", clientCmdStr)
@@ -145,8 +146,8 @@ func RunLocalNumaAwareIBTest(
 
 	if err := clientCmd.Run(); err != nil {
 		if verbose {
-			fmt.Printf("Error executing client: %v\n", err)
-			fmt.Printf("Stdout: %s\nStderr: %s\n", stdout.String(), stderr.String())
+			printer.Printf("Error executing client: %v\n", err)
+			printer.Printf("Stdout: %s\nStderr: %s\n", stdout.String(), stderr.String())
 		}
 		return "", fmt.Errorf("error executing client: %v", err)
 	}
@@ -175,6 +176,34 @@ func parseLatency(out string, msgSize int, srcDev, dstDev string) (float64, erro
 	return 0, fmt.Errorf("failed to parse latency for %s -> %s msgSize=%d", srcDev, dstDev, msgSize)
 }
 
+// PerfThreshold is the expected performance of an HCA type, keyed by its board
+// ID in the thresholds passed to CheckNodeIBPerfHealth.
+type PerfThreshold struct {
+	BandwidthGbps float64
+	LatencyUs     float64
+}
+
+// pairThreshold returns the threshold of a src -> dst test. A pair is bounded
+// by its slower HCA, so the lower bandwidth and the higher latency is used.
+// Devices whose board ID has no threshold fall back to the given default.
+func pairThreshold(thresholds map[string]PerfThreshold, def PerfThreshold, src, dst collector.IBHardWareInfo) PerfThreshold {
+	srcTh, srcOk := thresholds[src.BoardID]
+	dstTh, dstOk := thresholds[dst.BoardID]
+	switch {
+	case srcOk && dstOk:
+		return PerfThreshold{
+			BandwidthGbps: min(srcTh.BandwidthGbps, dstTh.BandwidthGbps),
+			LatencyUs:     max(srcTh.LatencyUs, dstTh.LatencyUs),
+		}
+	case srcOk:
+		return srcTh
+	case dstOk:
+		return dstTh
+	default:
+		return def
+	}
+}
+
 func getActiveIBPFDevices() ([]collector.IBHardWareInfo, []collector.IBHardWareInfo, error) {
 	var info collector.InfinibandInfo
 	ibDevs := info.GetIBPFdevs()
@@ -184,6 +213,7 @@ func getActiveIBPFDevices() ([]collector.IBHardWareInfo, []collector.IBHardWareI
 	for mlxDev := range ibDevs {
 		var hwInfo collector.IBHardWareInfo
 		hwInfo.IBDev = mlxDev
+		hwInfo.BoardID = hwInfo.GetBoardID(mlxDev)
 		// perftest historically samples port 1; the multi-plane refactor is
 		// scoped to the regular health-check pipeline.
 		hwInfo.PhyState = hwInfo.GetPhyStat(mlxDev, 1)
@@ -202,10 +232,10 @@ func getActiveIBPFDevices() ([]collector.IBHardWareInfo, []collector.IBHardWareI
 
 		if strings.Contains(hwInfo.PhyState, "LinkUp") && strings.Contains(hwInfo.PortState, "ACTIVE") {
 			activeDevices = append(activeDevices, hwInfo)
-			fmt.Println("Active IB device found:", hwInfo)
+			printer.Println("Active IB device found:", hwInfo)
 		} else {
 			deactiveDevices = append(deactiveDevices, hwInfo)
-			fmt.Println("Deactive IB device found:", hwInfo)
+			printer.Println("Deactive IB device found:", hwInfo)
 		}
 	}
 
@@ -216,9 +246,13 @@ func getActiveIBPFDevices() ([]collector.IBHardWareInfo, []collector.IBHardWareI
 	return activeDevices, deactiveDevices, nil
 }
 
+// CheckNodeIBPerfHealth runs the perftest between every pair of the active
+// HCAs. Each pair is checked against the thresholds of its HCA board IDs, or
+// against expectedBandwidthGbps/expectedLatencyUs if the board is not listed.
 func CheckNodeIBPerfHealth(
 	ibBwPerfType string,
 	expectedBandwidthGbps, expectedLatencyUs float64,
+	thresholds map[string]PerfThreshold,
 	ibDevice string,
 	msgSize int,
 	testDuring int,
@@ -242,7 +276,7 @@ func CheckNodeIBPerfHealth(
 	var usedDeviceStrings []string
 	for _, dev := range activeDeviceInfos {
 		if strings.Contains(dev.IBDev, "mezz") {
-			fmt.Printf("Skip mezzanine card %s in performance test\n", dev.IBDev)
+			printer.Printf("Skip mezzanine card %s in performance test\n", dev.IBDev)
 			continue
 		}
 		if ibDevice == "" {
@@ -259,17 +293,19 @@ func CheckNodeIBPerfHealth(
 	status := consts.StatusNormal
 	checkRes := make([]*common.CheckerResult, 0)
 
-	fmt.Printf("Using IB devices for performance test: %v\n", usedDeviceStrings)
+	printer.Printf("Using IB devices for performance test: %v\n", usedDeviceStrings)
 
 	for _, srcDev := range usedDeviceInfos {
 		for _, dstDev := range usedDeviceInfos {
 			resTemplate := PerfCheckItems[IBPerfTestName]
 			resItem := &common.CheckerResult{
 				Name:   resTemplate.Name,
+				Device: fmt.Sprintf("%s->%s", srcDev.IBDev, dstDev.IBDev),
 				Status: consts.StatusNormal,
 			}
 
 			resItem.Detail = fmt.Sprintf("Testing %s -> %s", srcDev.IBDev, dstDev.IBDev)
+			threshold := pairThreshold(thresholds, PerfThreshold{BandwidthGbps: expectedBandwidthGbps, LatencyUs: expectedLatencyUs}, srcDev, dstDev)
 
 			var out string
 			var metrics float64
@@ -282,32 +318,37 @@ func CheckNodeIBPerfHealth(
 			if err == nil {
 				if strings.Contains(ibBwPerfType, "lat") {
 					metrics, err = parseLatency(out, msgSize, srcDev.IBDev, dstDev.IBDev)
-					if err != nil || metrics > expectedLatencyUs {
+					resItem.Spec = fmt.Sprintf("<= %.2f us", threshold.LatencyUs)
+					resItem.Curr = fmt.Sprintf("%.2f us", metrics)
+					if err != nil || metrics > threshold.LatencyUs {
 						resItem.Status = consts.StatusAbnormal
-						resItem.Detail += fmt.Sprintf(" ❌ %.2f us > expected %.2f us", metrics, expectedLatencyUs)
+						resItem.Detail += fmt.Sprintf(" ❌ %.2f us > expected %.2f us", metrics, threshold.LatencyUs)
 						status = consts.StatusAbnormal
 					} else {
 						resItem.Status = consts.StatusNormal
-						resItem.Detail += fmt.Sprintf(" ✅ %.2f <= expected %.2f us", metrics, expectedLatencyUs)
+						resItem.Detail += fmt.Sprintf(" ✅ %.2f <= expected %.2f us", metrics, threshold.LatencyUs)
 					}
 				} else {
 					metrics, err = parseBandwidth(out, msgSize, srcDev.IBDev, dstDev.IBDev)
-					if err != nil || metrics < expectedBandwidthGbps {
+					resItem.Spec = fmt.Sprintf(">= %.2f Gbps", threshold.BandwidthGbps)
+					resItem.Curr = fmt.Sprintf("%.2f Gbps", metrics)
+					if err != nil || metrics < threshold.BandwidthGbps {
 						resItem.Status = consts.StatusAbnormal
-						resItem.Detail += fmt.Sprintf(" ❌ %.2f Gbps < expected %.2f Gbps", metrics, expectedBandwidthGbps)
+						resItem.Detail += fmt.Sprintf(" ❌ %.2f Gbps < expected %.2f Gbps", metrics, threshold.BandwidthGbps)
 						status = consts.StatusAbnormal
 					} else {
 						resItem.Status = consts.StatusNormal
-						resItem.Detail += fmt.Sprintf(" ✅ %.2f Gbps >= expected %.2f Gbps", metrics, expectedBandwidthGbps)
+						resItem.Detail += fmt.Sprintf(" ✅ %.2f Gbps >= expected %.2f Gbps", metrics, threshold.BandwidthGbps)
 					}
 				}
 			} else {
 				resItem.Status = consts.StatusAbnormal
+				resItem.Curr = "failed"
 				resItem.Detail += fmt.Sprintf(" ❌ Test failed: %v", err)
 				status = consts.StatusAbnormal
 			}
 
-			fmt.Println(resItem.Detail)
+			printer.Println(resItem.Detail)
 			checkRes = append(checkRes, resItem)
 		}
 	}
@@ -348,22 +389,45 @@ func CheckNodeIBPerfHealth(
 
 func PrintInfo(result *common.Result, verbos bool) bool {
 	if result == nil {
-		fmt.Println("No IB performance test results found.")
+		printer.Println("No IB performance test results found.")
 		return false
 	}
 	checkerResults := result.Checkers
+	PrintPerfTable(result)
 	if result.Status == consts.StatusNormal {
-		fmt.Println("✅ Node IB Health Check PASSED: All IB devices meet the spec.")
+		printer.Println("✅ Node IB Health Check PASSED: All IB devices meet the spec.")
 		return true
 	}
 	for _, result := range checkerResults {
 		if result.Status == consts.StatusAbnormal {
-			fmt.Printf("%s\n", result.Detail)
+			printer.Printf("%s\n", result.Detail)
 		} else {
 			if verbos {
-				fmt.Printf("%s\n", result.Detail)
+				printer.Printf("%s\n", result.Detail)
 			}
 		}
 	}
 	return false
 }
+
+// PrintPerfTable prints the pass/fail of every tested device pair.
+func PrintPerfTable(result *common.Result) {
+	header := false
+	for _, checkerResult := range result.Checkers {
+		if checkerResult.Device == "" {
+			continue
+		}
+		if !header {
+			printer.Printf("\n%-32s %-20s %-16s %-6s\n", "Device", "Expected", "Measured", "Result")
+			header = true
+		}
+		verdict := consts.Green + "PASS" + consts.Reset
+		if checkerResult.Status == consts.StatusAbnormal {
+			verdict = consts.Red + "FAIL" + consts.Reset
+		}
+		printer.Printf("%-32s %-20s %-16s %s\n", checkerResult.Device, checkerResult.Spec, checkerResult.Curr, verdict)
+	}
+	if header {
+		printer.Println()
+	}
+}
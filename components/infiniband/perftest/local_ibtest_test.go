@@ -17,11 +17,37 @@ package perftest
 
 import (
 	"testing"
+
+	"github.com/scitix/sichek/components/infiniband/collector"
 )
 
 func TestCheckNodeIBPerfHealth(t *testing.T) {
-	_, err := CheckNodeIBPerfHealth("ib_read_bw", 150.0, 0.0, "", 65536, 2, 3, 0, false, false, true, false)
+	_, err := CheckNodeIBPerfHealth("ib_read_bw", 150.0, 0.0, nil, "", 65536, 2, 3, 0, false, false, true, false)
 	if err != nil {
 		t.Errorf("CheckNodeIBPerfHealth FAILED: %v", err)
 	}
 }
+
+func TestPairThreshold(t *testing.T) {
+	thresholds := map[string]PerfThreshold{
+		"MT_0000000838": {BandwidthGbps: 380, LatencyUs: 3}, // ConnectX-7 400G
+		"MT_0000000970": {BandwidthGbps: 190, LatencyUs: 4}, // ConnectX-7 200G
+	}
+	def := PerfThreshold{BandwidthGbps: 100, LatencyUs: 10}
+	cx7 := collector.IBHardWareInfo{IBDev: "mlx5_0", BoardID: "MT_0000000838"}
+	cx7200 := collector.IBHardWareInfo{IBDev: "mlx5_1", BoardID: "MT_0000000970"}
+	unknown := collector.IBHardWareInfo{IBDev: "mlx5_2", BoardID: "MT_unknown"}
+
+	if got := pairThreshold(thresholds, def, cx7, cx7); got != thresholds["MT_0000000838"] {
+		t.Errorf("same board: got %+v", got)
+	}
+	if got := pairThreshold(thresholds, def, cx7, cx7200); got != (PerfThreshold{BandwidthGbps: 190, LatencyUs: 4}) {
+		t.Errorf("mixed boards should be bounded by the slower HCA, got %+v", got)
+	}
+	if got := pairThreshold(thresholds, def, unknown, cx7); got != thresholds["MT_0000000838"] {
+		t.Errorf("one known board: got %+v", got)
+	}
+	if got := pairThreshold(thresholds, def, unknown, unknown); got != def {
+		t.Errorf("unknown board should use default, got %+v", got)
+	}
+}
@@ -25,6 +25,7 @@ import (
 
 	"github.com/scitix/sichek/components/common"
 	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
 	"github.com/sirupsen/logrus"
 )
 
@@ -56,15 +57,15 @@ func CheckRoCEPerfHealth(
 		allSpecifiedDevFound, usedDeviceInfos = resolveActiveIBDeviceByNet(netDevice, verbose)
 		if !allSpecifiedDevFound {
 			specifiedDevs = netDevice
-			fmt.Printf("Not all specificd RoCE v2 devices are found with active status, spec: %v, actual: %v\n", netDevice, usedDeviceInfos)
+			printer.Printf("Not all specificd RoCE v2 devices are found with active status, spec: %v, actual: %v\n", netDevice, usedDeviceInfos)
 		}
 	} else {
 		allSpecifiedDevFound, usedDeviceInfos = resolveActiveIBDevice(ibDevice, verbose)
 		if !allSpecifiedDevFound && ibDevice != "" {
 			specifiedDevs = ibDevice
-			fmt.Printf("Not all specificd RoCE v2 devices are found with active status, spec: %v, actual: %v\n", ibDevice, usedDeviceInfos)
+			printer.Printf("Not all specificd RoCE v2 devices are found with active status, spec: %v, actual: %v\n", ibDevice, usedDeviceInfos)
 		} else if !allSpecifiedDevFound {
-			fmt.Println("No active RoCE v2 devices found")
+			printer.Println("No active RoCE v2 devices found")
 		}
 	}
 
@@ -72,7 +73,7 @@ func CheckRoCEPerfHealth(
 	for _, dev := range usedDeviceInfos {
 		usedDevs = append(usedDevs, dev.Dev)
 	}
-	fmt.Printf("Using RoCE devices for performance test: %v\n", usedDevs)
+	printer.Printf("Using RoCE devices for performance test: %v\n", usedDevs)
 	for _, srcDev := range usedDeviceInfos {
 		for _, dstDev := range usedDeviceInfos {
 			resTemplate := PerfCheckItems[IBPerfTestName]
@@ -113,7 +114,7 @@ func CheckRoCEPerfHealth(
 				status = consts.StatusAbnormal
 			}
 
-			fmt.Println(resItem.Detail)
+			printer.Println(resItem.Detail)
 			checkRes = append(checkRes, resItem)
 		}
 	}
@@ -177,7 +178,7 @@ func resolveActiveIBDevice(ibDev string, verbose bool) (bool, []*RoCEV2DeviceInf
 			}
 		}
 		if len(activeDevs) == 0 {
-			fmt.Println("No active RoCE v2 devices found")
+			printer.Println("No active RoCE v2 devices found")
 			return false, nil
 		}
 		return len(activeDevs) > 0, activeDevs
@@ -210,7 +211,7 @@ func resolveActiveIBDevice(ibDev string, verbose bool) (bool, []*RoCEV2DeviceInf
 			}
 		}
 		if !found {
-			fmt.Printf("Specified dev device %s not found in active RoCE devices\n", dev)
+			printer.Printf("Specified dev device %s not found in active RoCE devices\n", dev)
 			return false, nil
 		}
 	}
@@ -240,7 +241,7 @@ func resolveActiveIBDeviceByNet(netDev string, verbose bool) (bool, []*RoCEV2Dev
 			}
 		}
 		if len(activeDevs) == 0 {
-			fmt.Println("No valid RoCE v2 devices found")
+			printer.Println("No valid RoCE v2 devices found")
 			return false, nil
 		}
 		return true, activeDevs
@@ -254,7 +255,7 @@ func resolveActiveIBDeviceByNet(netDev string, verbose bool) (bool, []*RoCEV2Dev
 	for _, info := range infos {
 		if _, ok := netFilter[info.Iface]; ok {
 			if info.Status != "up" {
-				fmt.Printf("Net device %s is not up\n", info.Iface)
+				printer.Printf("Net device %s is not up\n", info.Iface)
 			} else {
 				activeDevs = append(activeDevs, info)
 				if verbose {
@@ -274,7 +275,7 @@ func resolveActiveIBDeviceByNet(netDev string, verbose bool) (bool, []*RoCEV2Dev
 			}
 		}
 		if !found {
-			fmt.Printf("Specified net device %s not found in RoCE devices\n", iface)
+			printer.Printf("Specified net device %s not found in RoCE devices\n", iface)
 			allDevFound = false
 		}
 	}
@@ -0,0 +1,322 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/inventory/collector"
+	"github.com/scitix/sichek/components/inventory/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DeviceKindGPU = "gpu"
+	DeviceKindHCA = "hca"
+
+	identityStateFile = "device_identity.json"
+	// zeroGUID is the node GUID of an HCA whose firmware did not assign one
+	zeroGUID = "0000:0000:0000:0000"
+)
+
+// DeviceIdentity ties a GPU or an HCA to the PCI slot it was found in. ID is
+// what identifies the device across reboots: the UUID of a GPU, the node GUID
+// of an HCA or its VPD serial number when the GUID is not set.
+type DeviceIdentity struct {
+	Kind   string `json:"kind"`
+	ID     string `json:"id"`
+	Serial string `json:"serial,omitempty"`
+	Name   string `json:"name,omitempty"`
+	BDF    string `json:"bdf,omitempty"`
+	// Index is the index of a GPU, it is not part of the identity.
+	Index int `json:"index,omitempty"`
+}
+
+func (d DeviceIdentity) String() string {
+	id := d.ID
+	if d.Serial != "" && d.Serial != d.ID {
+		id = fmt.Sprintf("%s (serial %s)", d.ID, d.Serial)
+	}
+	return fmt.Sprintf("%s %s %s", d.Kind, d.Name, id)
+}
+
+// IdentityState is the set of devices recorded on the node.
+type IdentityState struct {
+	Time    time.Time        `json:"time"`
+	Devices []DeviceIdentity `json:"devices"`
+}
+
+// IdentityStore persists the recorded device identities, so that a device
+// swapped while the node was down is told apart after the reboot.
+type IdentityStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewIdentityStore(dir string) *IdentityStore {
+	return &IdentityStore{path: filepath.Join(dir, identityStateFile)}
+}
+
+// Load returns the recorded state, nil if none was recorded yet.
+func (s *IdentityStore) Load() (*IdentityState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	state := &IdentityState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("invalid device identity state %s: %w", s.path, err)
+	}
+	return state, nil
+}
+
+// Save atomically replaces the recorded state.
+func (s *IdentityStore) Save(state *IdentityState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// Reset forgets the recorded state, the devices found by the next check are
+// recorded as the expected ones.
+func (s *IdentityStore) Reset() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// DeviceIdentities returns the identities of the GPUs and HCAs of info.
+func DeviceIdentities(info *collector.InventoryInfo) []DeviceIdentity {
+	var devices []DeviceIdentity
+	for _, gpu := range info.GPUs {
+		devices = append(devices, DeviceIdentity{
+			Kind:   DeviceKindGPU,
+			ID:     gpu.UUID,
+			Serial: gpu.SerialNumber,
+			Name:   gpu.Name,
+			BDF:    strings.ToLower(gpu.BusID),
+			Index:  gpu.Index,
+		})
+	}
+	for _, hca := range info.HCAs {
+		id := hca.NodeGUID
+		if id == "" || id == zeroGUID {
+			id = hca.SerialNumber
+		}
+		if id == "" {
+			// no identity to track, e.g. an HCA without VPD under a zero GUID
+			continue
+		}
+		devices = append(devices, DeviceIdentity{
+			Kind:   DeviceKindHCA,
+			ID:     id,
+			Serial: hca.SerialNumber,
+			Name:   hca.Name,
+			BDF:    strings.ToLower(hca.BDF),
+		})
+	}
+	return devices
+}
+
+const (
+	IdentityMissing = "missing"
+	IdentityMoved   = "moved"
+	IdentityNew     = "new"
+)
+
+// IdentityChange is a device that disappeared, moved to another PCI slot or
+// appeared since the identities were recorded.
+type IdentityChange struct {
+	Change   string          `json:"change"`
+	Recorded *DeviceIdentity `json:"recorded,omitempty"`
+	Current  *DeviceIdentity `json:"current,omitempty"`
+}
+
+func (c IdentityChange) String() string {
+	switch c.Change {
+	case IdentityMissing:
+		return fmt.Sprintf("%s is missing from %s", c.Recorded, c.Recorded.BDF)
+	case IdentityMoved:
+		return fmt.Sprintf("%s moved from %s to %s", c.Current, c.Recorded.BDF, c.Current.BDF)
+	default:
+		return fmt.Sprintf("%s is new at %s", c.Current, c.Current.BDF)
+	}
+}
+
+// CompareIdentities returns the changes from recorded to current, ordered by
+// device kind and PCI slot. The devices of skipKinds are not compared, e.g.
+// the GPUs when nvidia-smi failed and the GPUs are missing from current.
+func CompareIdentities(recorded, current []DeviceIdentity, skipKinds map[string]bool) []IdentityChange {
+	key := func(d DeviceIdentity) string { return d.Kind + "/" + d.ID }
+	currentByKey := make(map[string]*DeviceIdentity, len(current))
+	for i := range current {
+		currentByKey[key(current[i])] = &current[i]
+	}
+	recordedKeys := make(map[string]bool, len(recorded))
+
+	var changes []IdentityChange
+	for i := range recorded {
+		r := &recorded[i]
+		if skipKinds[r.Kind] {
+			continue
+		}
+		recordedKeys[key(*r)] = true
+		c, ok := currentByKey[key(*r)]
+		switch {
+		case !ok:
+			changes = append(changes, IdentityChange{Change: IdentityMissing, Recorded: r})
+		case c.BDF != r.BDF:
+			changes = append(changes, IdentityChange{Change: IdentityMoved, Recorded: r, Current: c})
+		}
+	}
+	for i := range current {
+		c := &current[i]
+		if skipKinds[c.Kind] || recordedKeys[key(*c)] {
+			continue
+		}
+		changes = append(changes, IdentityChange{Change: IdentityNew, Current: c})
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		a, b := changes[i].device(), changes[j].device()
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.BDF < b.BDF
+	})
+	return changes
+}
+
+func (c IdentityChange) device() *DeviceIdentity {
+	if c.Recorded != nil {
+		return c.Recorded
+	}
+	return c.Current
+}
+
+// DeviceIdentityChecker alerts when a GPU or HCA disappears, changes slot or
+// a new one appears, a silent hardware swap that breaks the GPU/NIC affinity
+// the workloads were placed with. The devices found on the first check are
+// recorded as the expected ones, and the alert holds until the changes are
+// accepted by resetting the store.
+type DeviceIdentityChecker struct {
+	name  string
+	store *IdentityStore
+}
+
+func NewDeviceIdentityChecker(cfg *config.InventoryConfig) (common.Checker, error) {
+	return &DeviceIdentityChecker{
+		name:  config.DeviceIdentityCheckerName,
+		store: NewIdentityStore(cfg.StatePath()),
+	}, nil
+}
+
+func (c *DeviceIdentityChecker) Name() string {
+	return c.name
+}
+
+func (c *DeviceIdentityChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	info, ok := data.(*collector.InventoryInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid data type, expected InventoryInfo")
+	}
+
+	result := config.InventoryCheckItems[config.DeviceIdentityCheckerName]
+	current := DeviceIdentities(info)
+	skipKinds := incompleteKinds(info)
+	result.Curr = fmt.Sprintf("%d devices", len(current))
+
+	recorded, err := c.store.Load()
+	if err != nil {
+		return nil, err
+	}
+	if recorded == nil {
+		if len(skipKinds) > 0 {
+			result.Detail = "Device identities not recorded yet, the inventory is incomplete"
+			return &result, nil
+		}
+		if err := c.store.Save(&IdentityState{Time: info.Time, Devices: current}); err != nil {
+			return nil, fmt.Errorf("failed to record device identities: %w", err)
+		}
+		logrus.WithField("checker", c.name).Infof("recorded the identities of %d devices", len(current))
+		result.Detail = fmt.Sprintf("Recorded the identities of %d devices", len(current))
+		return &result, nil
+	}
+	result.Spec = fmt.Sprintf("%d devices recorded at %s", len(recorded.Devices), recorded.Time.Format(time.RFC3339))
+
+	changes := CompareIdentities(recorded.Devices, current, skipKinds)
+	if len(changes) == 0 {
+		return &result, nil
+	}
+	details := make([]string, 0, len(changes))
+	var gpus []string
+	for _, change := range changes {
+		details = append(details, change.String())
+		if change.Current != nil && change.Current.Kind == DeviceKindGPU {
+			gpus = append(gpus, fmt.Sprintf("%d", change.Current.Index))
+			result.Devices = append(result.Devices, &common.DeviceResult{Index: change.Current.Index, UUID: change.Current.ID, BDF: change.Current.BDF})
+		}
+	}
+	result.Status = consts.StatusAbnormal
+	result.Device = strings.Join(gpus, ",")
+	result.Curr = fmt.Sprintf("%d devices changed", len(changes))
+	result.Detail = strings.Join(details, "\n")
+	return &result, nil
+}
+
+// incompleteKinds returns the device kinds the collector failed to list.
+func incompleteKinds(info *collector.InventoryInfo) map[string]bool {
+	kinds := make(map[string]bool)
+	for _, e := range info.Errors {
+		if strings.HasPrefix(e, "gpus:") {
+			kinds[DeviceKindGPU] = true
+		}
+	}
+	return kinds
+}
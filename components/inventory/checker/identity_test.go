@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/inventory/collector"
+	"github.com/scitix/sichek/components/inventory/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testInventory() *collector.InventoryInfo {
+	return &collector.InventoryInfo{
+		Time: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		GPUs: []collector.GPU{
+			{Index: 0, Name: "NVIDIA H100 80GB HBM3", UUID: "GPU-aaaa", SerialNumber: "1650123000001", BusID: "00000000:18:00.0"},
+			{Index: 1, Name: "NVIDIA H100 80GB HBM3", UUID: "GPU-bbbb", SerialNumber: "1650123000002", BusID: "00000000:2A:00.0"},
+		},
+		HCAs: []collector.HCA{
+			{Name: "mlx5_0", NodeGUID: "a088:c203:0001:0001", SerialNumber: "MT2330X00001", BDF: "0000:1a:00.0"},
+			{Name: "mlx5_1", NodeGUID: "0000:0000:0000:0000", SerialNumber: "MT2330X00002", BDF: "0000:2c:00.0"},
+			{Name: "mlx5_2", NodeGUID: "0000:0000:0000:0000", BDF: "0000:3c:00.0"},
+		},
+	}
+}
+
+func TestDeviceIdentities(t *testing.T) {
+	devices := DeviceIdentities(testInventory())
+	require.Len(t, devices, 4)
+	assert.Equal(t, DeviceIdentity{Kind: DeviceKindGPU, ID: "GPU-bbbb", Serial: "1650123000002", Name: "NVIDIA H100 80GB HBM3", BDF: "00000000:2a:00.0", Index: 1}, devices[1])
+	assert.Equal(t, "a088:c203:0001:0001", devices[2].ID)
+	// without a node GUID the HCA is tracked by its VPD serial, and skipped without one
+	assert.Equal(t, "MT2330X00002", devices[3].ID)
+}
+
+func TestCompareIdentities(t *testing.T) {
+	recorded := DeviceIdentities(testInventory())
+
+	info := testInventory()
+	// GPU 0 reseated in another slot, GPU 1 swapped and mlx5_1 gone
+	info.GPUs[0].BusID = "00000000:9A:00.0"
+	info.GPUs[1] = collector.GPU{Index: 1, Name: "NVIDIA H100 80GB HBM3", UUID: "GPU-cccc", BusID: "00000000:2A:00.0"}
+	info.HCAs = info.HCAs[:1]
+	current := DeviceIdentities(info)
+
+	changes := CompareIdentities(recorded, current, nil)
+	var got []string
+	for _, c := range changes {
+		got = append(got, c.String())
+	}
+	assert.Equal(t, []string{
+		"gpu NVIDIA H100 80GB HBM3 GPU-aaaa (serial 1650123000001) moved from 00000000:18:00.0 to 00000000:9a:00.0",
+		"gpu NVIDIA H100 80GB HBM3 GPU-bbbb (serial 1650123000002) is missing from 00000000:2a:00.0",
+		"gpu NVIDIA H100 80GB HBM3 GPU-cccc is new at 00000000:2a:00.0",
+		"hca mlx5_1 MT2330X00002 is missing from 0000:2c:00.0",
+	}, got)
+
+	changes = CompareIdentities(recorded, current, map[string]bool{DeviceKindGPU: true})
+	require.Len(t, changes, 1)
+	assert.Equal(t, IdentityMissing, changes[0].Change)
+
+	assert.Empty(t, CompareIdentities(recorded, recorded, nil))
+}
+
+func TestDeviceIdentityChecker(t *testing.T) {
+	cfg := &config.InventoryConfig{StateDir: t.TempDir()}
+	c, err := NewDeviceIdentityChecker(cfg)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// an incomplete inventory is not recorded
+	incomplete := testInventory()
+	incomplete.GPUs = nil
+	incomplete.Errors = []string{"gpus: nvidia-smi failed"}
+	result, err := c.Check(ctx, incomplete)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusNormal, result.Status)
+	state, err := NewIdentityStore(cfg.StatePath()).Load()
+	require.NoError(t, err)
+	assert.Nil(t, state)
+
+	// the first complete check records the devices
+	result, err = c.Check(ctx, testInventory())
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusNormal, result.Status)
+	assert.Contains(t, result.Detail, "Recorded the identities of 4 devices")
+
+	result, err = c.Check(ctx, testInventory())
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusNormal, result.Status)
+
+	// GPUs missing because nvidia-smi failed are not reported as removed
+	result, err = c.Check(ctx, incomplete)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusNormal, result.Status)
+
+	swapped := testInventory()
+	swapped.GPUs[1].UUID = "GPU-cccc"
+	result, err = c.Check(ctx, swapped)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusAbnormal, result.Status)
+	assert.Equal(t, "1", result.Device)
+	assert.Equal(t, 2, len(strings.Split(result.Detail, "\n")))
+	require.Len(t, result.Devices, 1)
+	assert.Equal(t, "GPU-cccc", result.Devices[0].UUID)
+
+	// the alert holds until the changes are accepted
+	result, err = c.Check(ctx, swapped)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusAbnormal, result.Status)
+	require.NoError(t, NewIdentityStore(cfg.StatePath()).Reset())
+	result, err = c.Check(ctx, swapped)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusNormal, result.Status)
+	result, err = c.Check(ctx, swapped)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusNormal, result.Status)
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/scitix/sichek/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// The sources of the inventory, vars so that tests can point them at a fake tree.
+var (
+	dmiPath        = "/sys/class/dmi/id"
+	machineIDPath  = "/etc/machine-id"
+	osReleasePath  = "/etc/os-release"
+	osReleaseHost  = "/proc/sys/kernel/osrelease"
+	infinibandPath = "/sys/class/infiniband"
+)
+
+// InventoryInfo is the identity and the field replaceable units of the node,
+// what asset tracking and RMA workflows need to know about it.
+type InventoryInfo struct {
+	Time      time.Time `json:"time"`
+	Hostname  string    `json:"hostname"`
+	MachineID string    `json:"machine_id,omitempty"`
+	Kernel    string    `json:"kernel,omitempty"`
+	OSImage   string    `json:"os_image,omitempty"`
+	System    DMIInfo   `json:"system"`
+	GPUs      []GPU     `json:"gpus,omitempty"`
+	HCAs      []HCA     `json:"hcas,omitempty"`
+	DIMMs     []DIMM    `json:"dimms,omitempty"`
+	// Errors records the sources that could not be read, an inventory with
+	// holes is still worth reporting.
+	Errors []string `json:"errors,omitempty"`
+}
+
+func (i *InventoryInfo) JSON() (string, error) {
+	b, err := common.JSON(i)
+	return string(b), err
+}
+
+// DMIInfo is the system, board and BIOS identity from the SMBIOS tables.
+type DMIInfo struct {
+	SysVendor     string `json:"sys_vendor,omitempty"`
+	ProductName   string `json:"product_name,omitempty"`
+	ProductSerial string `json:"product_serial,omitempty"`
+	ProductUUID   string `json:"product_uuid,omitempty"`
+	BoardVendor   string `json:"board_vendor,omitempty"`
+	BoardName     string `json:"board_name,omitempty"`
+	BoardSerial   string `json:"board_serial,omitempty"`
+	ChassisSerial string `json:"chassis_serial,omitempty"`
+	BIOSVendor    string `json:"bios_vendor,omitempty"`
+	BIOSVersion   string `json:"bios_version,omitempty"`
+	BIOSDate      string `json:"bios_date,omitempty"`
+}
+
+// HCA is an RDMA adapter with the identity read from its PCI VPD.
+type HCA struct {
+	Name         string `json:"name"`
+	BDF          string `json:"bdf,omitempty"`
+	BoardID      string `json:"board_id,omitempty"`
+	FWVersion    string `json:"fw_version,omitempty"`
+	NodeGUID     string `json:"node_guid,omitempty"`
+	ProductName  string `json:"product_name,omitempty"`
+	PartNumber   string `json:"part_number,omitempty"`
+	SerialNumber string `json:"serial_number,omitempty"`
+}
+
+// Collector implements common.Collector for the inventory component.
+type Collector struct {
+	name string
+}
+
+func NewCollector() *Collector {
+	return &Collector{name: "InventoryCollector"}
+}
+
+func (c *Collector) Name() string { return c.name }
+
+func (c *Collector) Collect(ctx context.Context) (common.Info, error) {
+	info := &InventoryInfo{
+		Time:      time.Now(),
+		MachineID: readTrimmed(hostfs.Path(machineIDPath)),
+		Kernel:    readTrimmed(hostfs.Path(osReleaseHost)),
+		OSImage:   readOSImage(hostfs.Path(osReleasePath)),
+		System:    readDMI(hostfs.Path(dmiPath)),
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		info.Errors = append(info.Errors, fmt.Sprintf("hostname: %v", err))
+	}
+	info.Hostname = hostname
+
+	if utils.IsNvidiaGPUExist() {
+		if info.GPUs, err = collectGPUs(ctx); err != nil {
+			info.Errors = append(info.Errors, fmt.Sprintf("gpus: %v", err))
+		}
+	}
+	info.HCAs = collectHCAs(hostfs.Path(infinibandPath))
+	if info.DIMMs, err = CollectDIMMs(ctx); err != nil {
+		info.Errors = append(info.Errors, fmt.Sprintf("dimms: %v", err))
+	}
+	for _, e := range info.Errors {
+		logrus.WithField("component", "inventory").Warnf("incomplete inventory, %s", e)
+	}
+	return info, nil
+}
+
+// readTrimmed returns the content of a single value file, "" if unreadable.
+func readTrimmed(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func readOSImage(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if value, ok := strings.CutPrefix(line, "PRETTY_NAME="); ok {
+			return strings.Trim(value, `"'`)
+		}
+	}
+	return ""
+}
+
+// readDMI reads the SMBIOS identity exported by the kernel. The serials and
+// the UUID are only readable by root and left empty otherwise.
+func readDMI(root string) DMIInfo {
+	read := func(name string) string { return readTrimmed(filepath.Join(root, name)) }
+	return DMIInfo{
+		SysVendor:     read("sys_vendor"),
+		ProductName:   read("product_name"),
+		ProductSerial: read("product_serial"),
+		ProductUUID:   read("product_uuid"),
+		BoardVendor:   read("board_vendor"),
+		BoardName:     read("board_name"),
+		BoardSerial:   read("board_serial"),
+		ChassisSerial: read("chassis_serial"),
+		BIOSVendor:    read("bios_vendor"),
+		BIOSVersion:   read("bios_version"),
+		BIOSDate:      read("bios_date"),
+	}
+}
+
+func collectHCAs(root string) []HCA {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil
+	}
+	hcas := make([]HCA, 0, len(entries))
+	for _, entry := range entries {
+		dir := filepath.Join(root, entry.Name())
+		hca := HCA{
+			Name:      entry.Name(),
+			BoardID:   readTrimmed(filepath.Join(dir, "board_id")),
+			FWVersion: readTrimmed(filepath.Join(dir, "fw_ver")),
+			NodeGUID:  readTrimmed(filepath.Join(dir, "node_guid")),
+		}
+		if device, err := filepath.EvalSymlinks(filepath.Join(dir, "device")); err == nil {
+			hca.BDF = filepath.Base(device)
+		}
+		if data, err := os.ReadFile(filepath.Join(dir, "device", "vpd")); err == nil {
+			vpd := ParseVPD(data)
+			hca.ProductName = vpd.ProductName
+			hca.PartNumber = vpd.Fields["PN"]
+			hca.SerialNumber = vpd.Fields["SN"]
+		}
+		hcas = append(hcas, hca)
+	}
+	sort.Slice(hcas, func(i, j int) bool { return hcas[i].Name < hcas[j].Name })
+	return hcas
+}
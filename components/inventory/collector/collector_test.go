@@ -0,0 +1,193 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// buildVPD assembles a VPD image with an identifier string and a VPD-R section.
+func buildVPD(name string, fields [][2]string) []byte {
+	var ro []byte
+	for _, f := range fields {
+		ro = append(ro, f[0][0], f[0][1], byte(len(f[1])))
+		ro = append(ro, f[1]...)
+	}
+	// checksum keyword, its value is not decoded
+	ro = append(ro, 'R', 'V', 1, 0)
+	vpd := []byte{0x82, byte(len(name)), byte(len(name) >> 8)}
+	vpd = append(vpd, name...)
+	vpd = append(vpd, 0x90, byte(len(ro)), byte(len(ro)>>8))
+	vpd = append(vpd, ro...)
+	return append(vpd, 0x78)
+}
+
+func TestParseVPD(t *testing.T) {
+	data := buildVPD("ConnectX-7 HHHL adapter card; 400GbE / NDR IB ", [][2]string{
+		{"PN", "MCX75310AAS-NEAT"},
+		{"EC", "A6"},
+		{"SN", "MT2312X00001"},
+		{"V2", "MCX75310AAS-NEAT\x00\x00"},
+	})
+	vpd := ParseVPD(data)
+	if vpd.ProductName != "ConnectX-7 HHHL adapter card; 400GbE / NDR IB" {
+		t.Errorf("ProductName = %q", vpd.ProductName)
+	}
+	want := map[string]string{"PN": "MCX75310AAS-NEAT", "EC": "A6", "SN": "MT2312X00001", "V2": "MCX75310AAS-NEAT"}
+	if !reflect.DeepEqual(vpd.Fields, want) {
+		t.Errorf("Fields = %v, want %v", vpd.Fields, want)
+	}
+
+	// a truncated image keeps what was decoded so far
+	truncated := ParseVPD(data[:len(data)-20])
+	if truncated.ProductName == "" || truncated.Fields["PN"] != "MCX75310AAS-NEAT" {
+		t.Errorf("truncated VPD = %+v", truncated)
+	}
+	if empty := ParseVPD(nil); empty.ProductName != "" || len(empty.Fields) != 0 {
+		t.Errorf("empty VPD = %+v", empty)
+	}
+}
+
+func TestParseDMIDecodeMemory(t *testing.T) {
+	out := []byte(`# dmidecode 3.3
+Getting SMBIOS data from sysfs.
+SMBIOS 3.5.0 present.
+
+Handle 0x0040, DMI type 17, 92 bytes
+Memory Device
+	Array Handle: 0x003F
+	Total Width: 80 bits
+	Size: 64 GB
+	Form Factor: DIMM
+	Locator: CPU0_DIMM_A1
+	Bank Locator: P0_Node0_Channel0_Dimm0
+	Type: DDR5
+	Speed: 4800 MT/s
+	Manufacturer: Samsung
+	Serial Number: 80CE0123
+	Part Number: M321R8GA0BB0-CQKZJ
+	Rank: 2
+	Configured Memory Speed: 4400 MT/s
+	Memory Operating Mode Capability:
+		Volatile memory
+
+Handle 0x0042, DMI type 17, 92 bytes
+Memory Device
+	Size: No Module Installed
+	Locator: CPU0_DIMM_A2
+	Bank Locator: P0_Node0_Channel0_Dimm1
+	Type: Unknown
+	Speed: Unknown
+	Manufacturer: NO DIMM
+	Serial Number: NO DIMM
+`)
+	want := []DIMM{
+		{
+			Locator:         "CPU0_DIMM_A1",
+			BankLocator:     "P0_Node0_Channel0_Dimm0",
+			Populated:       true,
+			Size:            "64 GB",
+			Type:            "DDR5",
+			Speed:           "4800 MT/s",
+			ConfiguredSpeed: "4400 MT/s",
+			Rank:            "2",
+			Manufacturer:    "Samsung",
+			SerialNumber:    "80CE0123",
+			PartNumber:      "M321R8GA0BB0-CQKZJ",
+		},
+		{
+			Locator:     "CPU0_DIMM_A2",
+			BankLocator: "P0_Node0_Channel0_Dimm1",
+		},
+	}
+	if got := parseDMIDecodeMemory(out); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDMIDecodeMemory() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseGPUQuery(t *testing.T) {
+	out := []byte("0, NVIDIA H100 80GB HBM3, 1652922012345, GPU-4b2f3c1e-0000-1111-2222-333344445555, 00000000:18:00.0, 96.00.74.00.0D\n" +
+		"1, NVIDIA H100 80GB HBM3, [N/A], GPU-9a8b7c6d-0000-1111-2222-333344445555, 00000000:2A:00.0, 96.00.74.00.0D\n")
+	gpus, err := parseGPUQuery(out)
+	if err != nil {
+		t.Fatalf("parseGPUQuery: %v", err)
+	}
+	want := []GPU{
+		{Index: 0, Name: "NVIDIA H100 80GB HBM3", SerialNumber: "1652922012345", UUID: "GPU-4b2f3c1e-0000-1111-2222-333344445555", BusID: "00000000:18:00.0", VBIOSVersion: "96.00.74.00.0D"},
+		{Index: 1, Name: "NVIDIA H100 80GB HBM3", UUID: "GPU-9a8b7c6d-0000-1111-2222-333344445555", BusID: "00000000:2a:00.0", VBIOSVersion: "96.00.74.00.0D"},
+	}
+	if !reflect.DeepEqual(gpus, want) {
+		t.Errorf("parseGPUQuery() = %+v, want %+v", gpus, want)
+	}
+
+	if _, err := parseGPUQuery([]byte("0, NVIDIA H100\n")); err == nil {
+		t.Error("expected an error on a short record")
+	}
+}
+
+func TestCollectHCAs(t *testing.T) {
+	root := t.TempDir()
+	pciDev := filepath.Join(root, "pci", "0000:18:00.0")
+	ibDev := filepath.Join(root, "infiniband", "mlx5_0")
+	for _, dir := range []string{pciDev, ibDev} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(pciDev, filepath.Join(ibDev, "device")); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		filepath.Join(ibDev, "board_id"):  "MT_0000000838\n",
+		filepath.Join(ibDev, "fw_ver"):    "28.39.1002\n",
+		filepath.Join(ibDev, "node_guid"): "a088:c203:0012:3456\n",
+		filepath.Join(pciDev, "vpd"):      string(buildVPD("ConnectX-7", [][2]string{{"PN", "MCX75310AAS-NEAT"}, {"SN", "MT2312X00001"}})),
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hcas := collectHCAs(filepath.Join(root, "infiniband"))
+	want := []HCA{{
+		Name:         "mlx5_0",
+		BDF:          "0000:18:00.0",
+		BoardID:      "MT_0000000838",
+		FWVersion:    "28.39.1002",
+		NodeGUID:     "a088:c203:0012:3456",
+		ProductName:  "ConnectX-7",
+		PartNumber:   "MCX75310AAS-NEAT",
+		SerialNumber: "MT2312X00001",
+	}}
+	if !reflect.DeepEqual(hcas, want) {
+		t.Errorf("collectHCAs() = %+v, want %+v", hcas, want)
+	}
+}
+
+func TestReadOSImage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "os-release")
+	content := "NAME=\"Ubuntu\"\nVERSION_ID=\"22.04\"\nPRETTY_NAME=\"Ubuntu 22.04.4 LTS\"\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := readOSImage(path); got != "Ubuntu 22.04.4 LTS" {
+		t.Errorf("readOSImage() = %q", got)
+	}
+}
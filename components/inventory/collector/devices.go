@@ -0,0 +1,165 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/scitix/sichek/pkg/utils"
+)
+
+// GPU is the identity of a GPU as reported by nvidia-smi.
+type GPU struct {
+	Index        int    `json:"index"`
+	Name         string `json:"name"`
+	SerialNumber string `json:"serial_number,omitempty"`
+	UUID         string `json:"uuid"`
+	BusID        string `json:"bus_id,omitempty"`
+	VBIOSVersion string `json:"vbios_version,omitempty"`
+}
+
+var gpuQueryFields = []string{"index", "name", "serial", "uuid", "pci.bus_id", "vbios_version"}
+
+func collectGPUs(ctx context.Context) ([]GPU, error) {
+	out, err := utils.ExecCommand(ctx, "nvidia-smi", "--query-gpu="+strings.Join(gpuQueryFields, ","), "--format=csv,noheader")
+	if err != nil {
+		return nil, fmt.Errorf("nvidia-smi failed: %w", err)
+	}
+	return parseGPUQuery(out)
+}
+
+// parseGPUQuery parses the csv output of nvidia-smi --query-gpu with the
+// columns of gpuQueryFields.
+func parseGPUQuery(out []byte) ([]GPU, error) {
+	reader := csv.NewReader(bytes.NewReader(out))
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = len(gpuQueryFields)
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse nvidia-smi output: %w", err)
+	}
+	gpus := make([]GPU, 0, len(records))
+	for _, record := range records {
+		index, err := strconv.Atoi(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid GPU index %q", record[0])
+		}
+		gpus = append(gpus, GPU{
+			Index:        index,
+			Name:         record[1],
+			SerialNumber: notAvailable(record[2]),
+			UUID:         record[3],
+			BusID:        strings.ToLower(record[4]),
+			VBIOSVersion: notAvailable(record[5]),
+		})
+	}
+	return gpus, nil
+}
+
+func notAvailable(value string) string {
+	if value == "[N/A]" || value == "N/A" {
+		return ""
+	}
+	return value
+}
+
+// DIMM is a memory slot of the SMBIOS memory device table, populated or not,
+// so that the layout shows which slots are empty.
+type DIMM struct {
+	Locator         string `json:"locator"`
+	BankLocator     string `json:"bank_locator,omitempty"`
+	Populated       bool   `json:"populated"`
+	Size            string `json:"size,omitempty"`
+	Type            string `json:"type,omitempty"`
+	Speed           string `json:"speed,omitempty"`
+	ConfiguredSpeed string `json:"configured_speed,omitempty"`
+	Rank            string `json:"rank,omitempty"`
+	Manufacturer    string `json:"manufacturer,omitempty"`
+	SerialNumber    string `json:"serial_number,omitempty"`
+	PartNumber      string `json:"part_number,omitempty"`
+}
+
+// CollectDIMMs returns the memory slots of the node, it needs root to run dmidecode.
+func CollectDIMMs(ctx context.Context) ([]DIMM, error) {
+	out, err := utils.ExecCommand(ctx, "dmidecode", "-t", "17")
+	if err != nil {
+		return nil, fmt.Errorf("dmidecode failed: %w", err)
+	}
+	return parseDMIDecodeMemory(out), nil
+}
+
+// parseDMIDecodeMemory parses the Memory Device records of `dmidecode -t 17`.
+func parseDMIDecodeMemory(out []byte) []DIMM {
+	var dimms []DIMM
+	var curr *DIMM
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "Memory Device" {
+			dimms = append(dimms, DIMM{})
+			curr = &dimms[len(dimms)-1]
+			continue
+		}
+		if !strings.HasPrefix(line, "\t") {
+			// a blank line or the handle line of the next record
+			curr = nil
+			continue
+		}
+		if curr == nil {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if value == "Unknown" || value == "Not Specified" || value == "NO DIMM" {
+			value = ""
+		}
+		switch key {
+		case "Locator":
+			curr.Locator = value
+		case "Bank Locator":
+			curr.BankLocator = value
+		case "Size":
+			if value != "" && value != "No Module Installed" {
+				curr.Size = value
+				curr.Populated = true
+			}
+		case "Type":
+			curr.Type = value
+		case "Speed":
+			curr.Speed = value
+		case "Configured Memory Speed":
+			curr.ConfiguredSpeed = value
+		case "Rank":
+			curr.Rank = value
+		case "Manufacturer":
+			curr.Manufacturer = value
+		case "Serial Number":
+			curr.SerialNumber = value
+		case "Part Number":
+			curr.PartNumber = value
+		}
+	}
+	return dimms
+}
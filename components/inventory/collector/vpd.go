@@ -0,0 +1,91 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"encoding/binary"
+	"strings"
+)
+
+// PCI VPD resource tags
+const (
+	vpdTagIdentifier = 0x02
+	vpdTagReadOnly   = 0x10
+	vpdTagReadWrite  = 0x11
+	vpdTagEnd        = 0x0f
+)
+
+// VPD is the Vital Product Data of a PCI device: the identifier string and
+// the keywords of its read-only and read-write sections, e.g. PN (part
+// number), SN (serial number) and EC (engineering change level).
+type VPD struct {
+	ProductName string
+	Fields      map[string]string
+}
+
+// ParseVPD decodes the resource list of a PCI VPD image as read from
+// /sys/bus/pci/devices/*/vpd. A truncated image yields what was decoded
+// before the truncation.
+func ParseVPD(data []byte) VPD {
+	vpd := VPD{Fields: make(map[string]string)}
+	for off := 0; off < len(data); {
+		tag := data[off]
+		if tag&0x80 == 0 {
+			// small resource, only the end tag is of interest
+			if (tag>>3)&0x0f == vpdTagEnd {
+				break
+			}
+			off += 1 + int(tag&0x07)
+			continue
+		}
+		if off+3 > len(data) {
+			break
+		}
+		size := int(binary.LittleEndian.Uint16(data[off+1:]))
+		start := off + 3
+		end := min(start+size, len(data))
+		body := data[start:end]
+		switch tag & 0x7f {
+		case vpdTagIdentifier:
+			vpd.ProductName = vpdString(body)
+		case vpdTagReadOnly, vpdTagReadWrite:
+			parseVPDKeywords(body, vpd.Fields)
+		}
+		off = start + size
+	}
+	return vpd
+}
+
+// parseVPDKeywords decodes the keyword list of a VPD-R or VPD-W section. The
+// RV checksum and RW free space keywords carry no product data.
+func parseVPDKeywords(body []byte, fields map[string]string) {
+	for off := 0; off+3 <= len(body); {
+		keyword := string(body[off : off+2])
+		size := int(body[off+2])
+		start := off + 3
+		end := min(start+size, len(body))
+		if keyword != "RV" && keyword != "RW" {
+			if value := vpdString(body[start:end]); value != "" {
+				fields[keyword] = value
+			}
+		}
+		off = start + size
+	}
+}
+
+func vpdString(b []byte) string {
+	return strings.TrimSpace(strings.Trim(string(b), "\x00"))
+}
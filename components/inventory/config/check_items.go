@@ -0,0 +1,37 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+)
+
+const (
+	DeviceIdentityCheckerName = "device-identity"
+)
+
+var InventoryCheckItems = map[string]common.CheckerResult{
+	DeviceIdentityCheckerName: {
+		Name:        DeviceIdentityCheckerName,
+		Description: "Check if the GPUs and HCAs are the ones recorded on the node, in the same PCI slots",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelWarning,
+		Detail:      "The GPUs and HCAs match the recorded device identities",
+		ErrorName:   "DeviceIdentityChanged",
+		Suggestion:  "Check the hardware maintenance records of the node; once the swap is expected, run `sichek inventory --accept-changes` to record the new devices",
+	},
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+)
+
+type InventoryUserConfig struct {
+	Inventory *InventoryConfig `yaml:"inventory"`
+}
+
+type InventoryConfig struct {
+	QueryInterval   common.Duration `json:"query_interval" yaml:"query_interval"`
+	CacheSize       int64           `json:"cache_size"     yaml:"cache_size"`
+	IgnoredCheckers []string        `json:"ignored_checkers,omitempty" yaml:"ignored_checkers,omitempty"`
+	// StateDir keeps the device identities recorded across reboots.
+	StateDir string `json:"state_dir,omitempty" yaml:"state_dir,omitempty"`
+}
+
+// StatePath returns the directory of the device identity state,
+// consts.DefaultStatePath unless set.
+func (c *InventoryConfig) StatePath() string {
+	if c == nil || c.StateDir == "" {
+		return consts.DefaultStatePath
+	}
+	return c.StateDir
+}
+
+// The inventory only changes on a hardware or software maintenance, there is
+// no point in collecting it often.
+func (c *InventoryUserConfig) GetQueryInterval() common.Duration {
+	if c.Inventory == nil || c.Inventory.QueryInterval.Duration == 0 {
+		return common.Duration{Duration: time.Hour}
+	}
+	return c.Inventory.QueryInterval
+}
+
+func (c *InventoryUserConfig) SetQueryInterval(newInterval common.Duration) {
+	if c.Inventory == nil {
+		c.Inventory = &InventoryConfig{}
+	}
+	c.Inventory.QueryInterval = newInterval
+}
@@ -0,0 +1,266 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/inventory/checker"
+	"github.com/scitix/sichek/components/inventory/collector"
+	"github.com/scitix/sichek/components/inventory/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
+
+	"github.com/sirupsen/logrus"
+)
+
+type component struct {
+	ctx           context.Context
+	cancel        context.CancelFunc
+	componentName string
+
+	cfg      *config.InventoryUserConfig
+	cfgMutex sync.Mutex
+
+	collector common.Collector
+	checkers  []common.Checker
+
+	cacheMtx    sync.RWMutex
+	cacheBuffer []*common.Result
+	cacheInfo   []common.Info
+	currIndex   int64
+	cacheSize   int64
+
+	service *common.CommonService
+}
+
+var (
+	inventoryComponent     *component
+	inventoryComponentOnce sync.Once
+)
+
+// NewComponent constructs (or returns the previously-constructed) inventory
+// component. specFile is ignored — the inventory is not checked against a spec.
+func NewComponent(cfgFile string, specFile string) (common.Component, error) {
+	var err error
+	inventoryComponentOnce.Do(func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic occurred when create component inventory: %v", r)
+			}
+		}()
+		inventoryComponent, err = newComponent(cfgFile)
+	})
+	return inventoryComponent, err
+}
+
+func newComponent(cfgFile string) (comp *component, err error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		if err != nil {
+			cancel()
+		}
+	}()
+
+	cfg := &config.InventoryUserConfig{}
+	if loadErr := common.LoadUserConfig(cfgFile, cfg); loadErr != nil {
+		logrus.WithField("component", "inventory").Warnf("load user config failed, using defaults: %v", loadErr)
+	}
+	if cfg.Inventory == nil {
+		cfg.Inventory = &config.InventoryConfig{}
+	}
+	if cfg.Inventory.CacheSize <= 0 {
+		cfg.Inventory.CacheSize = 5
+	}
+	checkers, err := checker.NewCheckers(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	comp = &component{
+		ctx:           ctx,
+		cancel:        cancel,
+		componentName: consts.ComponentNameInventory,
+		collector:     collector.NewCollector(),
+		checkers:      checkers,
+		cfg:           cfg,
+		cacheBuffer:   make([]*common.Result, cfg.Inventory.CacheSize),
+		cacheInfo:     make([]common.Info, cfg.Inventory.CacheSize),
+		cacheSize:     cfg.Inventory.CacheSize,
+	}
+	comp.service = common.NewCommonService(ctx, cfg, comp.componentName, comp.GetTimeout(), comp.HealthCheck)
+	return
+}
+
+func (c *component) Name() string { return c.componentName }
+
+func (c *component) HealthCheck(ctx context.Context) (*common.Result, error) {
+	info, err := common.Collect(ctx, c.componentName, c.collector.Collect)
+	if err != nil {
+		logrus.WithField("component", "inventory").Errorf("collect failed: %v", err)
+		return nil, err
+	}
+
+	// Besides the device identities the inventory is informational,
+	// consumers pull it through LastInfo or `sichek export`.
+	result := common.Check(ctx, c.componentName, info, c.checkers)
+
+	c.cacheMtx.Lock()
+	c.cacheInfo[c.currIndex] = info
+	c.cacheBuffer[c.currIndex] = result
+	c.currIndex = (c.currIndex + 1) % c.cacheSize
+	c.cacheMtx.Unlock()
+
+	if result.Status == consts.StatusAbnormal {
+		logrus.WithField("component", "inventory").Errorf("Health Check Failed")
+	} else {
+		logrus.WithField("component", "inventory").Infof("Health Check PASSED")
+	}
+	return result, nil
+}
+
+// AcceptDeviceChanges forgets the device identities recorded on the node, so
+// that the devices found by the next check, e.g. after a planned GPU or HCA
+// swap, are recorded as the expected ones.
+func AcceptDeviceChanges(cfgFile string) error {
+	cfg := &config.InventoryUserConfig{}
+	if err := common.LoadUserConfig(cfgFile, cfg); err != nil {
+		logrus.WithField("component", "inventory").Warnf("load user config failed, using defaults: %v", err)
+	}
+	return checker.NewIdentityStore(cfg.Inventory.StatePath()).Reset()
+}
+
+func (c *component) CacheResults() ([]*common.Result, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	return c.cacheBuffer, nil
+}
+
+func (c *component) LastResult() (*common.Result, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	result := c.cacheBuffer[c.currIndex]
+	if c.currIndex == 0 {
+		result = c.cacheBuffer[c.cacheSize-1]
+	}
+	return result, nil
+}
+
+func (c *component) CacheInfos() ([]common.Info, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	return c.cacheInfo, nil
+}
+
+func (c *component) LastInfo() (common.Info, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	var info common.Info
+	if c.currIndex == 0 {
+		info = c.cacheInfo[c.cacheSize-1]
+	} else {
+		info = c.cacheInfo[c.currIndex-1]
+	}
+	return info, nil
+}
+
+func (c *component) Metrics(ctx context.Context, since time.Time) (interface{}, error) {
+	return nil, nil
+}
+
+func (c *component) Start() <-chan *common.Result { return c.service.Start() }
+
+func (c *component) Stop() error { return c.service.Stop() }
+
+func (c *component) Update(cfg common.ComponentUserConfig) error {
+	c.cfgMutex.Lock()
+	configPointer, ok := cfg.(*config.InventoryUserConfig)
+	if !ok {
+		c.cfgMutex.Unlock()
+		return fmt.Errorf("update wrong config type for inventory")
+	}
+	c.cfg = configPointer
+	c.cfgMutex.Unlock()
+	return c.service.Update(cfg)
+}
+
+func (c *component) Status() bool { return c.service.Status() }
+
+func (c *component) GetTimeout() time.Duration {
+	return c.cfg.GetQueryInterval().Duration
+}
+
+func (c *component) PrintInfo(info common.Info, result *common.Result, summaryPrint bool) bool {
+	inv, ok := info.(*collector.InventoryInfo)
+	if !ok {
+		logrus.WithField("component", "inventory").Errorf("invalid data type, expected *InventoryInfo")
+		return false
+	}
+
+	sys := inv.System
+	printer.Printf("\n%sInventory%s of %s\n", consts.Green, consts.Reset, inv.Hostname)
+	printer.Printf("  Machine ID : %s\n", inv.MachineID)
+	printer.Printf("  System     : %s %s (serial %s)\n", sys.SysVendor, sys.ProductName, sys.ProductSerial)
+	printer.Printf("  BIOS       : %s %s (%s)\n", sys.BIOSVendor, sys.BIOSVersion, sys.BIOSDate)
+	printer.Printf("  OS         : %s, kernel %s\n", inv.OSImage, inv.Kernel)
+	for _, gpu := range inv.GPUs {
+		printer.Printf("  GPU %-7d: %s serial %s vbios %s [%s]\n", gpu.Index, gpu.Name, gpu.SerialNumber, gpu.VBIOSVersion, gpu.BusID)
+	}
+	for _, hca := range inv.HCAs {
+		printer.Printf("  %-11s: %s PN %s serial %s fw %s [%s]\n", hca.Name, hca.ProductName, hca.PartNumber, hca.SerialNumber, hca.FWVersion, hca.BDF)
+	}
+	populated := 0
+	for _, dimm := range inv.DIMMs {
+		if dimm.Populated {
+			populated++
+		}
+	}
+	if len(inv.DIMMs) > 0 {
+		printer.Printf("  DIMMs      : %d of %d slots populated\n", populated, len(inv.DIMMs))
+		if !summaryPrint {
+			for _, dimm := range inv.DIMMs {
+				if dimm.Populated {
+					printer.Printf("    %-16s %s %s %s %s serial %s\n", dimm.Locator, dimm.Size, dimm.Type, dimm.Speed, dimm.PartNumber, dimm.SerialNumber)
+				} else {
+					printer.Printf("    %-16s empty\n", dimm.Locator)
+				}
+			}
+		}
+	}
+	for _, e := range inv.Errors {
+		printer.Printf("  %sincomplete%s: %s\n", consts.Yellow, consts.Reset, e)
+	}
+
+	checkAllPassed := true
+	if result != nil {
+		for _, res := range result.Checkers {
+			if res.Status != consts.StatusAbnormal {
+				continue
+			}
+			checkAllPassed = false
+			for _, line := range strings.Split(res.Detail, "\n") {
+				printer.Printf("  %s%s%s: %s\n", consts.LevelColor(res.Level), res.ErrorName, consts.Reset, line)
+			}
+		}
+	}
+	printer.Println()
+	return checkAllPassed
+}
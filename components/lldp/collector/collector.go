@@ -25,6 +25,7 @@ import (
 
 	"github.com/scitix/sichek/components/common"
 
+	"github.com/scitix/sichek/pkg/hostfs"
 	"github.com/vishvananda/netlink"
 )
 
@@ -148,7 +149,12 @@ func lldpctlErrDetail(err error) string {
 // and missing fields stay as zero values in the snapshot.
 func collectLocalIface(name string) LocalIface {
 	li := LocalIface{Name: name}
-	link, err := netlink.LinkByName(name)
+	handle, err := hostfs.NetlinkHandle()
+	if err != nil {
+		return li
+	}
+	defer handle.Close()
+	link, err := handle.LinkByName(name)
 	if err != nil {
 		return li
 	}
@@ -162,7 +168,7 @@ func collectLocalIface(name string) LocalIface {
 	li.MTU = attrs.MTU
 	li.OperState = attrs.OperState.String()
 	if attrs.MasterIndex > 0 {
-		if master, err := netlink.LinkByIndex(attrs.MasterIndex); err == nil && master != nil && master.Attrs() != nil {
+		if master, err := handle.LinkByIndex(attrs.MasterIndex); err == nil && master != nil && master.Attrs() != nil {
 			li.Master = master.Attrs().Name
 		}
 	}
@@ -170,7 +176,7 @@ func collectLocalIface(name string) LocalIface {
 		li.VlanID = vlan.VlanId
 	}
 
-	if addrs, err := netlink.AddrList(link, netlink.FAMILY_V4); err == nil {
+	if addrs, err := handle.AddrList(link, netlink.FAMILY_V4); err == nil {
 		for _, a := range addrs {
 			if a.IPNet != nil {
 				li.IPv4 = append(li.IPv4, a.IPNet.String())
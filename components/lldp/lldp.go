@@ -25,6 +25,7 @@ import (
 	"github.com/scitix/sichek/components/lldp/collector"
 	"github.com/scitix/sichek/components/lldp/config"
 	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
 
 	"github.com/sirupsen/logrus"
 )
@@ -106,7 +107,7 @@ func newComponent(cfgFile string) (comp *component, err error) {
 func (c *component) Name() string { return c.componentName }
 
 func (c *component) HealthCheck(ctx context.Context) (*common.Result, error) {
-	info, err := c.collector.Collect(ctx)
+	info, err := common.Collect(ctx, c.componentName, c.collector.Collect)
 	if err != nil {
 		logrus.WithField("component", "lldp").Errorf("collect failed: %v", err)
 		return nil, err
@@ -199,15 +200,15 @@ func (c *component) PrintInfo(info common.Info, result *common.Result, summaryPr
 	}
 
 	if !lldpInfo.LldpdAvailable {
-		fmt.Printf("\n%sLLDP%s: lldpd not available: %s\n\n", consts.Yellow, consts.Reset, lldpInfo.Reason)
+		printer.Printf("\n%sLLDP%s: lldpd not available: %s\n\n", consts.Yellow, consts.Reset, lldpInfo.Reason)
 		return true
 	}
 	if len(lldpInfo.Interfaces) == 0 {
-		fmt.Printf("\n%sLLDP%s: no neighbors detected\n\n", consts.Yellow, consts.Reset)
+		printer.Printf("\n%sLLDP%s: no neighbors detected\n\n", consts.Yellow, consts.Reset)
 		return true
 	}
 
-	fmt.Printf("\n%sLLDP neighbors (%d)%s\n", consts.Green, len(lldpInfo.Interfaces), consts.Reset)
+	printer.Printf("\n%sLLDP neighbors (%d)%s\n", consts.Green, len(lldpInfo.Interfaces), consts.Reset)
 	for _, iface := range lldpInfo.Interfaces {
 		mgmt := ""
 		if len(iface.Neighbor.Chassis.MgmtIP) > 0 {
@@ -217,11 +218,11 @@ func (c *component) PrintInfo(info common.Info, result *common.Result, summaryPr
 		if len(iface.Local.IPv4) > 0 {
 			ip = iface.Local.IPv4[0]
 		}
-		fmt.Printf("  %-18s [%s %s] -> %s/%s @ %s\n",
+		printer.Printf("  %-18s [%s %s] -> %s/%s @ %s\n",
 			iface.Local.Name,
 			iface.Local.OperState, ip,
 			iface.Neighbor.Chassis.Name, iface.Neighbor.Port.ID, mgmt)
 	}
-	fmt.Println()
+	printer.Println()
 	return true
 }
@@ -19,11 +19,12 @@ import (
 	"fmt"
 
 	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/memory/config"
 )
 
 // NewCheckers creates all memory checkers.
 // expectedCapacityGB of 0 means the capacity check will be skipped at runtime.
-func NewCheckers(expectedCapacityGB float64) ([]common.Checker, error) {
+func NewCheckers(expectedCapacityGB float64, dimmCERate config.DIMMCERateConfig) ([]common.Checker, error) {
 	checkers := make([]common.Checker, 0)
 
 	eccUncorrected, err := NewMemoryECCUncorrectedChecker()
@@ -38,6 +39,12 @@ func NewCheckers(expectedCapacityGB float64) ([]common.Checker, error) {
 	}
 	checkers = append(checkers, eccCorrected)
 
+	dimmRate, err := NewMemoryDIMMCERateChecker(dimmCERate)
+	if err != nil {
+		return nil, fmt.Errorf("create memory dimm ce rate checker failed: %v", err)
+	}
+	checkers = append(checkers, dimmRate)
+
 	capacity, err := NewMemoryCapacityChecker(expectedCapacityGB, 5.0)
 	if err != nil {
 		return nil, fmt.Errorf("create memory capacity checker failed: %v", err)
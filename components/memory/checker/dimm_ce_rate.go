@@ -0,0 +1,221 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/memory/collector"
+	"github.com/scitix/sichek/components/memory/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+const MemoryDIMMCERateCheckerName = "memory-dimm-ce-rate"
+
+// dimmCESample is the correctable error count of a DIMM at a point in time.
+type dimmCESample struct {
+	Time    time.Time
+	CECount int64
+}
+
+// DIMMCEHistory keeps the correctable error counts of each DIMM across
+// health checks.
+type DIMMCEHistory struct {
+	mu    sync.Mutex
+	dimms map[string][]dimmCESample
+}
+
+func NewDIMMCEHistory() *DIMMCEHistory {
+	return &DIMMCEHistory{dimms: make(map[string][]dimmCESample)}
+}
+
+// Observe records the sample of a DIMM, keeping the samples within keep
+// before it, and returns them. The history of a DIMM restarts when its
+// counter goes down, e.g. after the EDAC counters were reset.
+func (h *DIMMCEHistory) Observe(id string, sample dimmCESample, keep time.Duration) []dimmCESample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	samples := h.dimms[id]
+	if n := len(samples); n > 0 && sample.CECount < samples[n-1].CECount {
+		samples = nil
+	}
+	start := 0
+	for start < len(samples) && sample.Time.Sub(samples[start].Time) > keep {
+		start++
+	}
+	samples = append(samples[start:], sample)
+	h.dimms[id] = samples
+	return append([]dimmCESample(nil), samples...)
+}
+
+// Prune forgets the DIMMs not in present.
+func (h *DIMMCEHistory) Prune(present map[string]bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for id := range h.dimms {
+		if !present[id] {
+			delete(h.dimms, id)
+		}
+	}
+}
+
+// MemoryDIMMCERateChecker reports the DIMMs whose correctable errors
+// accelerate, a DIMM about to fail well before the node wide count of the
+// memory-ecc-corrected checker crosses its threshold.
+type MemoryDIMMCERateChecker struct {
+	name    string
+	limit   config.DIMMCERateConfig
+	history *DIMMCEHistory
+}
+
+func NewMemoryDIMMCERateChecker(limit config.DIMMCERateConfig) (common.Checker, error) {
+	if limit.Window.Duration <= 0 {
+		return nil, fmt.Errorf("invalid DIMM correctable error window %s", limit.Window.Duration)
+	}
+	return &MemoryDIMMCERateChecker{
+		name:    MemoryDIMMCERateCheckerName,
+		limit:   limit,
+		history: NewDIMMCEHistory(),
+	}, nil
+}
+
+func (c *MemoryDIMMCERateChecker) Name() string {
+	return c.name
+}
+
+func (c *MemoryDIMMCERateChecker) GetSpec() common.CheckerSpec {
+	return nil
+}
+
+func (c *MemoryDIMMCERateChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	output, ok := data.(*collector.Output)
+	if !ok {
+		return nil, fmt.Errorf("invalid data type, expected *collector.Output")
+	}
+
+	result := config.MemoryCheckItems[MemoryDIMMCERateCheckerName]
+	dimms := output.EDAC.DIMMs()
+	if !output.EDAC.Available || len(dimms) == 0 {
+		result.Status = consts.StatusNormal
+		result.Level = consts.LevelInfo
+		result.Curr = "N/A"
+		result.Detail = "EDAC does not report the errors per DIMM"
+		result.Suggestion = ""
+		return &result, nil
+	}
+	window := c.limit.Window.Duration
+	result.Spec = fmt.Sprintf("<%g/h, or <%g/h and less than %gx the previous %s", c.limit.MaxPerHour, c.limit.MinPerHour, c.limit.Acceleration, window)
+
+	now := output.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	rasDaemon := make(map[string]collector.DIMMInfo, len(output.EDAC.RasDaemon))
+	for _, dimm := range output.EDAC.RasDaemon {
+		rasDaemon[dimm.Label] = dimm
+	}
+	present := make(map[string]bool, len(dimms))
+	var failedDIMMs, details []string
+	for _, dimm := range dimms {
+		present[dimm.ID] = true
+		// keep a bit more than two windows, so that a sample at least a window
+		// before the base of the last window survives the check intervals
+		samples := c.history.Observe(dimm.ID, dimmCESample{Time: now, CECount: dimm.CECount}, 3*window)
+		reason := dimmCERateReason(samples, window, c.limit)
+		if reason == "" {
+			continue
+		}
+		detail := fmt.Sprintf("DIMM %s (%s): %s", dimm.Name(), dimm.ID, reason)
+		if history, ok := rasDaemon[dimm.Label]; ok && dimm.Label != "" {
+			detail += fmt.Sprintf(", rasdaemon recorded %d correctable errors", history.CECount)
+		}
+		failedDIMMs = append(failedDIMMs, dimm.Name())
+		details = append(details, detail+"\n")
+	}
+	c.history.Prune(present)
+
+	if len(failedDIMMs) > 0 {
+		logrus.WithFields(logrus.Fields{
+			"checker": c.Name(),
+			"dimms":   failedDIMMs,
+		}).Warnf("DIMM correctable errors accelerate")
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(failedDIMMs, ",")
+		result.Curr = fmt.Sprintf("%d DIMMs accelerating", len(failedDIMMs))
+		result.Detail = strings.Join(details, "")
+	} else {
+		result.Status = consts.StatusNormal
+		result.Curr = "Healthy"
+		result.Detail = fmt.Sprintf("The correctable errors of all %d DIMMs are steady", len(dimms))
+		result.Suggestion = ""
+	}
+	return &result, nil
+}
+
+// dimmCERateReason compares the correctable error rate of the last window
+// with the one of the window before it. samples are sorted by time and the
+// last one is the current count.
+func dimmCERateReason(samples []dimmCESample, window time.Duration, limit config.DIMMCERateConfig) string {
+	if len(samples) < 2 {
+		return ""
+	}
+	curr := samples[len(samples)-1]
+	// base is the latest sample at least a window old, or the oldest one
+	// while the history is shorter than a window
+	baseIdx := 0
+	for i, s := range samples[:len(samples)-1] {
+		if curr.Time.Sub(s.Time) >= window {
+			baseIdx = i
+		}
+	}
+	base := samples[baseIdx]
+	span := curr.Time.Sub(base.Time)
+	if span < window/4 {
+		return ""
+	}
+	recent := float64(curr.CECount-base.CECount) / span.Hours()
+	if limit.MaxPerHour > 0 && recent >= limit.MaxPerHour {
+		return fmt.Sprintf("correctable errors grew from %d to %d in %s (%.1f/h >= %g/h)",
+			base.CECount, curr.CECount, span.Round(time.Minute), recent, limit.MaxPerHour)
+	}
+	if recent < limit.MinPerHour {
+		return ""
+	}
+	// the previous rate needs a sample a window before base
+	prevIdx := -1
+	for i, s := range samples[:baseIdx] {
+		if base.Time.Sub(s.Time) >= window {
+			prevIdx = i
+		}
+	}
+	if prevIdx < 0 {
+		return ""
+	}
+	prev := samples[prevIdx]
+	previous := float64(base.CECount-prev.CECount) / base.Time.Sub(prev.Time).Hours()
+	if recent <= limit.Acceleration*previous {
+		return ""
+	}
+	return fmt.Sprintf("correctable errors accelerate from %.1f/h to %.1f/h (%d to %d in %s)",
+		previous, recent, base.CECount, curr.CECount, span.Round(time.Minute))
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/memory/collector"
+	"github.com/scitix/sichek/components/memory/config"
+	"github.com/scitix/sichek/consts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dimmOutput(at time.Time, ce ...int64) *collector.Output {
+	mc := collector.MCInfo{ID: "mc0"}
+	for i, count := range ce {
+		mc.DIMMs = append(mc.DIMMs, collector.DIMMInfo{
+			ID:      "mc0/dimm" + string(rune('0'+i)),
+			Label:   "DIMM_A" + string(rune('1'+i)),
+			CECount: count,
+		})
+	}
+	return &collector.Output{
+		EDAC: collector.EDACInfo{Available: true, Controllers: []collector.MCInfo{mc}},
+		Time: at,
+	}
+}
+
+func TestMemoryDIMMCERateChecker(t *testing.T) {
+	chk, err := NewMemoryDIMMCERateChecker(config.DefaultDIMMCERate)
+	require.NoError(t, err)
+
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	// DIMM_A1 logs 5/h then 40/h, DIMM_A2 logs a steady 20/h
+	steps := []struct {
+		at     time.Duration
+		a1, a2 int64
+		status string
+	}{
+		{0, 0, 0, consts.StatusNormal},
+		{30 * time.Minute, 2, 10, consts.StatusNormal},
+		{time.Hour, 5, 20, consts.StatusNormal},
+		{90 * time.Minute, 25, 30, consts.StatusNormal},
+		{2 * time.Hour, 45, 40, consts.StatusAbnormal},
+	}
+	for _, step := range steps {
+		result, err := chk.Check(context.Background(), dimmOutput(start.Add(step.at), step.a1, step.a2))
+		require.NoError(t, err)
+		assert.Equal(t, step.status, result.Status, "at %s", step.at)
+		if result.Status == consts.StatusAbnormal {
+			assert.Equal(t, "DIMM_A1", result.Device)
+			assert.Contains(t, result.Detail, "accelerate from 5.0/h to 40.0/h")
+		}
+	}
+}
+
+func TestMemoryDIMMCERateCheckerMaxRate(t *testing.T) {
+	chk, err := NewMemoryDIMMCERateChecker(config.DefaultDIMMCERate)
+	require.NoError(t, err)
+
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	_, err = chk.Check(context.Background(), dimmOutput(start, 0))
+	require.NoError(t, err)
+	result, err := chk.Check(context.Background(), dimmOutput(start.Add(30*time.Minute), 600))
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusAbnormal, result.Status)
+
+	// a reset counter restarts the history
+	result, err = chk.Check(context.Background(), dimmOutput(start.Add(time.Hour), 0))
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusNormal, result.Status)
+}
+
+func TestMemoryDIMMCERateCheckerNoDIMMs(t *testing.T) {
+	chk, err := NewMemoryDIMMCERateChecker(config.DefaultDIMMCERate)
+	require.NoError(t, err)
+	result, err := chk.Check(context.Background(), &collector.Output{EDAC: collector.EDACInfo{Available: true}})
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusNormal, result.Status)
+	assert.Equal(t, "N/A", result.Curr)
+}
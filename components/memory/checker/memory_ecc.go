@@ -18,6 +18,7 @@ package checker
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/scitix/sichek/components/common"
 	"github.com/scitix/sichek/components/memory/collector"
@@ -67,6 +68,7 @@ func (c *MemoryECCUncorrectedChecker) Check(ctx context.Context, data any) (*com
 		result.Status = consts.StatusAbnormal
 		result.Curr = fmt.Sprintf("%d", output.EDAC.TotalUCE)
 		result.Detail = fmt.Sprintf("Uncorrectable memory ECC errors detected: count=%d", output.EDAC.TotalUCE)
+		result.Device, result.Detail = withDIMMs(result.Detail, output.EDAC.DIMMs(), func(d collector.DIMMInfo) int64 { return d.UCECount })
 	} else {
 		result.Status = consts.StatusNormal
 		result.Curr = "0"
@@ -121,6 +123,7 @@ func (c *MemoryECCCorrectedChecker) Check(ctx context.Context, data any) (*commo
 		result.Status = consts.StatusAbnormal
 		result.Curr = fmt.Sprintf("%d", output.EDAC.TotalCE)
 		result.Detail = fmt.Sprintf("Correctable memory ECC count %d exceeds threshold %d", output.EDAC.TotalCE, c.threshold)
+		result.Device, result.Detail = withDIMMs(result.Detail, output.EDAC.DIMMs(), func(d collector.DIMMInfo) int64 { return d.CECount })
 	} else {
 		result.Status = consts.StatusNormal
 		result.Curr = fmt.Sprintf("%d", output.EDAC.TotalCE)
@@ -130,3 +133,19 @@ func (c *MemoryECCCorrectedChecker) Check(ctx context.Context, data any) (*commo
 
 	return &result, nil
 }
+
+// withDIMMs appends the DIMMs with errors, as counted by count, to detail and
+// returns them as the devices of the result.
+func withDIMMs(detail string, dimms []collector.DIMMInfo, count func(collector.DIMMInfo) int64) (string, string) {
+	var names, counts []string
+	for _, dimm := range dimms {
+		if n := count(dimm); n > 0 {
+			names = append(names, dimm.Name())
+			counts = append(counts, fmt.Sprintf("%s=%d", dimm.Name(), n))
+		}
+	}
+	if len(names) == 0 {
+		return "", detail
+	}
+	return strings.Join(names, ","), detail + ", DIMMs: " + strings.Join(counts, ", ")
+}
@@ -61,6 +61,7 @@ func (c *MemoryCollector) Collect(ctx context.Context) (common.Info, error) {
 
 	edac := &EDACInfo{}
 	edac.Get()
+	edac.GetRasDaemon(ctx)
 
 	capacity := MemoryCapacityFromMemInfo(info)
 
@@ -16,20 +16,37 @@ limitations under the License.
 package collector
 
 import (
+	"context"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/scitix/sichek/pkg/utils"
+	"github.com/sirupsen/logrus"
 )
 
 const edacSysfsPath = "/sys/devices/system/edac/mc"
 
+// rasDaemonDBPath is the event database of rasdaemon, a var for tests.
+var rasDaemonDBPath = "/var/lib/rasdaemon/ras-mc_event.db"
+
+// Corrected on DIMM Label(s): 'CPU_SrcID#0_MC#0_Chan#0_DIMM#0' location: 0:0:0:-1 errors: 12
+var rasMCSummaryRe = regexp.MustCompile(`^\s*(\S+) on DIMM Label\(s\): '([^']*)' location: (\S+) errors: (\d+)`)
+
 // EDACInfo holds aggregated EDAC memory controller error data.
 type EDACInfo struct {
 	Available   bool     `json:"available"`
 	Controllers []MCInfo `json:"controllers"`
 	TotalCE     int64    `json:"total_ce"`
 	TotalUCE    int64    `json:"total_uce"`
+	// RasDaemon is the errors per DIMM rasdaemon recorded in its database,
+	// which survive the reboots that reset the EDAC counters.
+	RasDaemon []DIMMInfo `json:"rasdaemon,omitempty"`
 }
 
 // MCInfo represents a single memory controller's error counts.
@@ -38,6 +55,35 @@ type MCInfo struct {
 	CECount  int64       `json:"ce_count"`
 	UCECount int64       `json:"uce_count"`
 	CSRows   []CSRowInfo `json:"csrows"`
+	DIMMs    []DIMMInfo  `json:"dimms,omitempty"`
+}
+
+// DIMMInfo is the error counts of a DIMM, labeled with its slot on the board,
+// e.g. CPU_SrcID#0_MC#0_Chan#0_DIMM#0, when the EDAC driver knows it.
+type DIMMInfo struct {
+	// ID is the EDAC path of the DIMM, e.g. mc0/dimm3 or mc0/csrow0/ch1
+	ID       string `json:"id"`
+	Label    string `json:"label"`
+	Location string `json:"location,omitempty"`
+	CECount  int64  `json:"ce_count"`
+	UCECount int64  `json:"uce_count"`
+}
+
+// Name is the label of the DIMM, or its EDAC path without one.
+func (d DIMMInfo) Name() string {
+	if d.Label != "" {
+		return d.Label
+	}
+	return d.ID
+}
+
+// DIMMs returns the DIMMs of all the memory controllers.
+func (e *EDACInfo) DIMMs() []DIMMInfo {
+	var dimms []DIMMInfo
+	for _, mc := range e.Controllers {
+		dimms = append(dimms, mc.DIMMs...)
+	}
+	return dimms
 }
 
 // CSRowInfo represents a chip-select row's error counts.
@@ -49,7 +95,7 @@ type CSRowInfo struct {
 
 // Get reads EDAC info from the default sysfs path.
 func (e *EDACInfo) Get() {
-	e.getFromDir(filepath.Dir(edacSysfsPath))
+	e.getFromDir(hostfs.Path(filepath.Dir(edacSysfsPath)))
 }
 
 // getFromDir reads EDAC info from the given base directory (parent of mc/).
@@ -95,12 +141,117 @@ func (e *EDACInfo) getFromDir(baseDir string) {
 			}
 		}
 
+		mc.DIMMs = readDIMMs(mcPath, mc.ID)
+
 		e.Controllers = append(e.Controllers, mc)
 		e.TotalCE += mc.CECount
 		e.TotalUCE += mc.UCECount
 	}
 }
 
+// readDIMMs reads the dimm*/rank* directories of the memory controllers that
+// expose them, or else the per channel counters of the csrows of the legacy
+// layout.
+func readDIMMs(mcPath, mcID string) []DIMMInfo {
+	entries, err := os.ReadDir(mcPath)
+	if err != nil {
+		return nil
+	}
+	var dimms []DIMMInfo
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || !(strings.HasPrefix(name, "dimm") || strings.HasPrefix(name, "rank")) {
+			continue
+		}
+		path := filepath.Join(mcPath, name)
+		dimms = append(dimms, DIMMInfo{
+			ID:       mcID + "/" + name,
+			Label:    readEdacStringFile(filepath.Join(path, "dimm_label")),
+			Location: readEdacStringFile(filepath.Join(path, "dimm_location")),
+			CECount:  readEdacIntFile(filepath.Join(path, "dimm_ce_count")),
+			UCECount: readEdacIntFile(filepath.Join(path, "dimm_ue_count")),
+		})
+	}
+	if len(dimms) > 0 {
+		return dimms
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), "csrow") {
+			continue
+		}
+		csPath := filepath.Join(mcPath, entry.Name())
+		counters, _ := filepath.Glob(filepath.Join(csPath, "ch*_ce_count"))
+		sort.Strings(counters)
+		for _, counter := range counters {
+			channel := strings.TrimSuffix(filepath.Base(counter), "_ce_count")
+			dimms = append(dimms, DIMMInfo{
+				ID:      mcID + "/" + entry.Name() + "/" + channel,
+				Label:   readEdacStringFile(filepath.Join(csPath, channel+"_dimm_label")),
+				CECount: readEdacIntFile(counter),
+			})
+		}
+	}
+	return dimms
+}
+
+// GetRasDaemon reads the errors per DIMM recorded by rasdaemon, if it runs
+// with its database.
+func (e *EDACInfo) GetRasDaemon(ctx context.Context) {
+	if _, err := os.Stat(rasDaemonDBPath); err != nil {
+		return
+	}
+	if _, err := exec.LookPath("ras-mc-ctl"); err != nil {
+		return
+	}
+	output, err := utils.ExecCommand(ctx, "ras-mc-ctl", "--summary")
+	if err != nil {
+		logrus.WithField("collector", "memory").Warnf("ras-mc-ctl --summary failed: %v", err)
+		return
+	}
+	e.RasDaemon = ParseRasMCSummary(string(output))
+}
+
+// ParseRasMCSummary parses the memory controller events of
+// `ras-mc-ctl --summary` into the errors per DIMM label.
+func ParseRasMCSummary(output string) []DIMMInfo {
+	byLabel := make(map[string]*DIMMInfo)
+	var labels []string
+	for _, line := range strings.Split(output, "\n") {
+		m := rasMCSummaryRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		count, err := strconv.ParseInt(m[4], 10, 64)
+		if err != nil {
+			continue
+		}
+		dimm, ok := byLabel[m[2]]
+		if !ok {
+			dimm = &DIMMInfo{ID: m[3], Label: m[2], Location: m[3]}
+			byLabel[m[2]] = dimm
+			labels = append(labels, m[2])
+		}
+		if strings.EqualFold(m[1], "Corrected") {
+			dimm.CECount += count
+		} else {
+			dimm.UCECount += count
+		}
+	}
+	dimms := make([]DIMMInfo, 0, len(labels))
+	for _, label := range labels {
+		dimms = append(dimms, *byLabel[label])
+	}
+	return dimms
+}
+
+func readEdacStringFile(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
 // readEdacIntFile reads a single integer value from a sysfs file.
 // Returns 0 if the file cannot be read or parsed.
 func readEdacIntFile(path string) int64 {
@@ -82,3 +82,58 @@ func TestEDACInfoGetFromDir_NotAvailable(t *testing.T) {
 	assert.Equal(t, int64(0), edac.TotalCE)
 	assert.Equal(t, int64(0), edac.TotalUCE)
 }
+
+func TestEDACInfoDIMMs(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(path, value string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(value+"\n"), 0644))
+	}
+	// mc0 exposes its DIMMs, mc1 only the legacy csrow channels
+	mc0 := filepath.Join(tmpDir, "mc", "mc0")
+	write(filepath.Join(mc0, "ce_count"), "12")
+	write(filepath.Join(mc0, "dimm0", "dimm_label"), "CPU_SrcID#0_MC#0_Chan#0_DIMM#0")
+	write(filepath.Join(mc0, "dimm0", "dimm_location"), "channel 0 slot 0")
+	write(filepath.Join(mc0, "dimm0", "dimm_ce_count"), "12")
+	write(filepath.Join(mc0, "dimm0", "dimm_ue_count"), "0")
+	write(filepath.Join(mc0, "dimm1", "dimm_ce_count"), "0")
+	write(filepath.Join(mc0, "dimm1", "dimm_ue_count"), "1")
+	mc1 := filepath.Join(tmpDir, "mc", "mc1")
+	write(filepath.Join(mc1, "csrow0", "ce_count"), "3")
+	write(filepath.Join(mc1, "csrow0", "ch0_ce_count"), "1")
+	write(filepath.Join(mc1, "csrow0", "ch0_dimm_label"), "DIMM_B1")
+	write(filepath.Join(mc1, "csrow0", "ch1_ce_count"), "2")
+
+	edac := &EDACInfo{}
+	edac.getFromDir(tmpDir)
+
+	dimms := edac.DIMMs()
+	require.Len(t, dimms, 4)
+	assert.Equal(t, "mc0/dimm0", dimms[0].ID)
+	assert.Equal(t, "CPU_SrcID#0_MC#0_Chan#0_DIMM#0", dimms[0].Name())
+	assert.Equal(t, "channel 0 slot 0", dimms[0].Location)
+	assert.Equal(t, int64(12), dimms[0].CECount)
+	assert.Equal(t, "mc0/dimm1", dimms[1].Name())
+	assert.Equal(t, int64(1), dimms[1].UCECount)
+	assert.Equal(t, "DIMM_B1", dimms[2].Name())
+	assert.Equal(t, int64(1), dimms[2].CECount)
+	assert.Equal(t, "mc1/csrow0/ch1", dimms[3].Name())
+	assert.Equal(t, int64(2), dimms[3].CECount)
+}
+
+func TestParseRasMCSummary(t *testing.T) {
+	output := `Memory controller events summary:
+	Corrected on DIMM Label(s): 'CPU_SrcID#0_MC#0_Chan#0_DIMM#0' location: 0:0:0:-1 errors: 12
+	Uncorrected on DIMM Label(s): 'CPU_SrcID#0_MC#0_Chan#0_DIMM#0' location: 0:0:0:-1 errors: 1
+	Corrected on DIMM Label(s): 'CPU_SrcID#1_MC#0_Chan#1_DIMM#0' location: 1:0:1:-1 errors: 3
+
+No PCIe AER errors.
+`
+	dimms := ParseRasMCSummary(output)
+	require.Len(t, dimms, 2)
+	assert.Equal(t, "CPU_SrcID#0_MC#0_Chan#0_DIMM#0", dimms[0].Label)
+	assert.Equal(t, int64(12), dimms[0].CECount)
+	assert.Equal(t, int64(1), dimms[0].UCECount)
+	assert.Equal(t, "1:0:1:-1", dimms[1].Location)
+	assert.Equal(t, int64(3), dimms[1].CECount)
+}
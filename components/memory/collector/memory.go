@@ -22,6 +22,7 @@ import (
 	"strings"
 
 	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/pkg/hostfs"
 )
 
 type MemoryInfo struct {
@@ -53,7 +54,7 @@ func (memInfo *MemoryInfo) ToString() string {
 }
 
 func (memInfo *MemoryInfo) Get() error {
-	return memInfo.get("/proc/meminfo")
+	return memInfo.get(hostfs.Path("/proc/meminfo"))
 }
 
 func (memInfo *MemoryInfo) get(filename string) error {
@@ -37,6 +37,14 @@ var MemoryCheckItems = map[string]common.CheckerResult{
 		ErrorName:   "MemoryECCCorrectedHigh",
 		Suggestion:  "Correctable memory errors increasing. Monitor DIMM health and plan replacement",
 	},
+	"memory-dimm-ce-rate": {
+		Name:        "memory-dimm-ce-rate",
+		Description: "Check the correctable ECC errors of each DIMM do not accelerate",
+		Spec:        "not accelerating",
+		Level:       consts.LevelWarning,
+		ErrorName:   "MemoryDIMMCEAccelerating",
+		Suggestion:  "The correctable errors of the DIMM accelerate, it is likely to fail. Plan its replacement",
+	},
 	"memory-capacity": {
 		Name:        "memory-capacity",
 		Description: "Check total memory matches expected specification",
@@ -16,6 +16,8 @@ limitations under the License.
 package config
 
 import (
+	"time"
+
 	"github.com/scitix/sichek/components/common"
 )
 
@@ -27,6 +29,50 @@ type MemoryConfig struct {
 	QueryInterval common.Duration `json:"query_interval" yaml:"query_interval"`
 	CacheSize     int64           `json:"cache_size" yaml:"cache_size"`
 	EnableMetrics bool            `json:"enable_metrics" yaml:"enable_metrics"`
+	// DIMMCERate flags the DIMMs whose correctable errors accelerate. When
+	// empty, DefaultDIMMCERate is used.
+	DIMMCERate *DIMMCERateConfig `json:"dimm_ce_rate,omitempty" yaml:"dimm_ce_rate,omitempty"`
+}
+
+// DIMMCERateConfig reports a DIMM whose correctable errors over the last
+// Window grow by MinPerHour or more and Acceleration times faster than over
+// the window before, or by MaxPerHour or more at any pace.
+type DIMMCERateConfig struct {
+	Window       common.Duration `json:"window" yaml:"window"`
+	MinPerHour   float64         `json:"min_per_hour" yaml:"min_per_hour"`
+	MaxPerHour   float64         `json:"max_per_hour" yaml:"max_per_hour"`
+	Acceleration float64         `json:"acceleration" yaml:"acceleration"`
+}
+
+// DefaultDIMMCERate ignores the odd correctable error a healthy DIMM logs,
+// while a DIMM degrading typically doubles its error rate hour over hour.
+var DefaultDIMMCERate = DIMMCERateConfig{
+	Window:       common.Duration{Duration: time.Hour},
+	MinPerHour:   10,
+	MaxPerHour:   1000,
+	Acceleration: 2,
+}
+
+// DIMMCERateLimit returns the DIMM correctable error rate config, falling back
+// to DefaultDIMMCERate for the unset fields.
+func (c *MemoryConfig) DIMMCERateLimit() DIMMCERateConfig {
+	limit := DefaultDIMMCERate
+	if c == nil || c.DIMMCERate == nil {
+		return limit
+	}
+	if c.DIMMCERate.Window.Duration > 0 {
+		limit.Window = c.DIMMCERate.Window
+	}
+	if c.DIMMCERate.MinPerHour > 0 {
+		limit.MinPerHour = c.DIMMCERate.MinPerHour
+	}
+	if c.DIMMCERate.MaxPerHour > 0 {
+		limit.MaxPerHour = c.DIMMCERate.MaxPerHour
+	}
+	if c.DIMMCERate.Acceleration > 0 {
+		limit.Acceleration = c.DIMMCERate.Acceleration
+	}
+	return limit
 }
 
 func (c *MemoryUserConfig) GetQueryInterval() common.Duration {
@@ -0,0 +1,79 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+)
+
+const DefaultSpecID = "default"
+
+type MemorySpecs struct {
+	Specs map[string]*MemorySpec `json:"memory" yaml:"memory"`
+}
+
+// MemorySpec is the memory expected with a CPU model, it is checked by
+// `sichek memtest`.
+type MemorySpec struct {
+	// MinTriadBandwidthPerSocket is the STREAM triad bandwidth in GB/s a socket
+	// achieves at least, the NUMA nodes of a socket share it. 0 only compares
+	// the NUMA nodes with each other.
+	MinTriadBandwidthPerSocket float64 `json:"min_triad_bandwidth_per_socket" yaml:"min_triad_bandwidth_per_socket"`
+	// DIMMs is the number of populated DIMMs of the node, 0 skips the check.
+	DIMMs int `json:"dimms" yaml:"dimms"`
+	// MinSpeed is the configured speed of the DIMMs in MT/s, 0 skips the check.
+	MinSpeed int `json:"min_speed_mts" yaml:"min_speed_mts"`
+}
+
+// MatchSpecID returns the key of specs matching cpuModel, the longest key
+// contained in the model name, e.g. "Platinum 8480+" for
+// "Intel(R) Xeon(R) Platinum 8480+", else DefaultSpecID.
+func MatchSpecID(specs map[string]*MemorySpec, cpuModel string) string {
+	id := ""
+	for key := range specs {
+		if key == DefaultSpecID || !strings.Contains(cpuModel, key) {
+			continue
+		}
+		if len(key) > len(id) || (len(key) == len(id) && key < id) {
+			id = key
+		}
+	}
+	if id == "" {
+		return DefaultSpecID
+	}
+	return id
+}
+
+// LoadSpec reads the memory multi-spec YAML at `file` and returns the entry
+// of cpuModel, see MatchSpecID.
+func LoadSpec(file, cpuModel string) (*MemorySpec, error) {
+	if file == "" {
+		return nil, fmt.Errorf("memory spec file path is empty")
+	}
+	var s MemorySpecs
+	if err := common.LoadSpec(file, &s); err != nil {
+		return nil, err
+	}
+	return common.FilterSpec(file, "memory", MatchSpecID(s.Specs, cpuModel),
+		func(c *MemorySpecs, id string) (*MemorySpec, bool) {
+			spec, ok := c.Specs[id]
+			return spec, ok
+		},
+	)
+}
@@ -96,7 +96,7 @@ func newMemoryComponent(cfgFile string, specFile string) (comp *component, err e
 		return nil, err
 	}
 
-	checkers, err := checker.NewCheckers(0)
+	checkers, err := checker.NewCheckers(0, memoryCfg.Memory.DIMMCERateLimit())
 	if err != nil {
 		logrus.WithField("component", "memory").Errorf("NewMemoryComponent create checkers failed: %v", err)
 		return nil, err
@@ -134,7 +134,7 @@ func (c *component) Name() string {
 }
 
 func (c *component) HealthCheck(ctx context.Context) (*common.Result, error) {
-	info, err := c.collector.Collect(ctx)
+	info, err := common.Collect(ctx, c.componentName, c.collector.Collect)
 	if err != nil {
 		logrus.WithField("component", "memory").Errorf("failed to collect memory info: %v", err)
 		return nil, err
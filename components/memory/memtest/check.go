@@ -0,0 +1,220 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package memtest
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	invcollector "github.com/scitix/sichek/components/inventory/collector"
+	"github.com/scitix/sichek/consts"
+)
+
+const MemTestName = "MemTest"
+
+// CheckOptions are the limits the benchmark and the DIMMs are checked with.
+type CheckOptions struct {
+	// ExpectedTriad is the triad bandwidth in GB/s of each NUMA node, 0 skips
+	// the check.
+	ExpectedTriad float64
+	// ImbalancePercent flags the NUMA nodes whose triad bandwidth or memory is
+	// that much below the best node.
+	ImbalancePercent float64
+	// DIMMs is the number of populated DIMMs, 0 skips the check.
+	DIMMs int
+	// MinSpeed is the configured speed of the DIMMs in MT/s, 0 skips the check.
+	MinSpeed int
+}
+
+// ExpectedTriadPerNode splits the triad bandwidth of a socket between the
+// NUMA nodes of the sockets, e.g. with sub-NUMA clustering.
+func ExpectedTriadPerNode(perSocket float64, sockets, nodes int) float64 {
+	if perSocket <= 0 || sockets <= 0 || nodes <= 0 {
+		return 0
+	}
+	return perSocket * float64(sockets) / float64(nodes)
+}
+
+// Check turns the benchmark results of the NUMA nodes and the DIMMs into a
+// result. dimms is nil when dmidecode is not available, the DIMM checks are
+// then skipped.
+func Check(results []*StreamResult, nodes []*NumaNode, dimms []invcollector.DIMM, opts CheckOptions) *common.Result {
+	res := &common.Result{
+		Item:   MemTestName,
+		Status: consts.StatusNormal,
+		Level:  consts.LevelInfo,
+		Time:   time.Now(),
+	}
+	fail := func(name, device, spec, curr, errorName, detail, suggestion string) {
+		res.Status = consts.StatusAbnormal
+		res.Level = consts.LevelCritical
+		res.Checkers = append(res.Checkers, &common.CheckerResult{
+			Name:        name,
+			Description: "Memory bandwidth and DIMM population test",
+			Device:      device,
+			Spec:        spec,
+			Curr:        curr,
+			Status:      consts.StatusAbnormal,
+			Level:       consts.LevelCritical,
+			Detail:      detail,
+			ErrorName:   errorName,
+			Suggestion:  suggestion,
+		})
+	}
+	floor := 1 - opts.ImbalancePercent/100
+
+	var bestTriad float64
+	for _, r := range results {
+		bestTriad = max(bestTriad, r.Triad)
+	}
+	for _, r := range results {
+		node := fmt.Sprintf("node%d", r.Node)
+		if opts.ExpectedTriad > 0 && r.Triad < opts.ExpectedTriad {
+			fail("MemTestBandwidth", node, fmt.Sprintf("%.1f GB/s", opts.ExpectedTriad), fmt.Sprintf("%.1f GB/s", r.Triad), "MemBandwidthLow",
+				fmt.Sprintf("NUMA node %d triad bandwidth is %.1f GB/s, but expected >= %.1f GB/s.", r.Node, r.Triad, opts.ExpectedTriad),
+				"Check the DIMM population and speed of the node with dmidecode -t 17 and the memory settings of the BIOS")
+		}
+		if opts.ImbalancePercent > 0 && r.Triad < bestTriad*floor {
+			fail("MemTestBalance", node, fmt.Sprintf("%.1f GB/s", bestTriad*floor), fmt.Sprintf("%.1f GB/s", r.Triad), "MemBandwidthImbalance",
+				fmt.Sprintf("NUMA node %d triad bandwidth is %.1f GB/s, %.0f%% below the best node at %.1f GB/s.", r.Node, r.Triad, 100*(1-r.Triad/bestTriad), bestTriad),
+				"Check the DIMMs of the memory channels of the NUMA node")
+		}
+	}
+
+	// the memory only nodes, e.g. CXL memory, are not expected to match
+	var bestMem uint64
+	for _, node := range nodes {
+		if len(node.CPUs) > 0 {
+			bestMem = max(bestMem, node.MemTotal)
+		}
+	}
+	for _, node := range nodes {
+		if len(node.CPUs) == 0 || opts.ImbalancePercent <= 0 || float64(node.MemTotal) >= float64(bestMem)*floor {
+			continue
+		}
+		fail("MemTestDIMMPopulation", fmt.Sprintf("node%d", node.ID), formatKB(bestMem), formatKB(node.MemTotal), "MemDIMMMisPopulated",
+			fmt.Sprintf("NUMA node %d has %s of memory, the largest node has %s.", node.ID, formatKB(node.MemTotal), formatKB(bestMem)),
+			"Check for missing or failed DIMMs on the NUMA node")
+	}
+
+	if dimms != nil {
+		checkDIMMs(dimms, opts, fail)
+	}
+
+	if res.Status == consts.StatusNormal {
+		var minTriad float64
+		for _, r := range results {
+			if minTriad == 0 || r.Triad < minTriad {
+				minTriad = r.Triad
+			}
+		}
+		res.Checkers = append(res.Checkers, &common.CheckerResult{
+			Name:        "MemTestBandwidth",
+			Description: "Memory bandwidth and DIMM population test",
+			Spec:        fmt.Sprintf("%.1f GB/s", opts.ExpectedTriad),
+			Curr:        fmt.Sprintf("%.1f GB/s", minTriad),
+			Status:      consts.StatusNormal,
+			Level:       consts.LevelInfo,
+			Detail:      fmt.Sprintf("Memory test passed on %d NUMA nodes, min triad bandwidth = %.1f GB/s.", len(results), minTriad),
+			ErrorName:   "MemBandwidthLow",
+		})
+	}
+	return res
+}
+
+func checkDIMMs(dimms []invcollector.DIMM, opts CheckOptions, fail func(name, device, spec, curr, errorName, detail, suggestion string)) {
+	var populated []invcollector.DIMM
+	for _, dimm := range dimms {
+		if dimm.Populated {
+			populated = append(populated, dimm)
+		}
+	}
+	if opts.DIMMs > 0 && len(populated) != opts.DIMMs {
+		fail("MemTestDIMMPopulation", "", strconv.Itoa(opts.DIMMs), strconv.Itoa(len(populated)), "MemDIMMMisPopulated",
+			fmt.Sprintf("%d DIMMs are populated, but expected %d.", len(populated), opts.DIMMs),
+			"Check for missing or failed DIMMs with dmidecode -t 17")
+	}
+
+	// DIMMs of mixed sizes break the channel interleaving
+	sizes := make(map[string][]string)
+	for _, dimm := range populated {
+		size := normalizeSize(dimm.Size)
+		sizes[size] = append(sizes[size], dimm.Locator)
+	}
+	if len(sizes) > 1 {
+		var parts []string
+		for size, locators := range sizes {
+			parts = append(parts, fmt.Sprintf("%s: %s", size, strings.Join(locators, ",")))
+		}
+		sort.Strings(parts)
+		fail("MemTestDIMMPopulation", "", "same size", strings.Join(parts, "; "), "MemDIMMMisPopulated",
+			fmt.Sprintf("The DIMMs have mixed sizes: %s.", strings.Join(parts, "; ")),
+			"Populate the memory channels with DIMMs of the same size")
+	}
+
+	if opts.MinSpeed <= 0 {
+		return
+	}
+	for _, dimm := range populated {
+		speed := parseSpeed(dimm.ConfiguredSpeed)
+		if speed == 0 || speed >= opts.MinSpeed {
+			continue
+		}
+		fail("MemTestDIMMSpeed", dimm.Locator, fmt.Sprintf("%d MT/s", opts.MinSpeed), dimm.ConfiguredSpeed, "MemDIMMSpeedLow",
+			fmt.Sprintf("DIMM %s runs at %s, but expected >= %d MT/s (rated %s).", dimm.Locator, dimm.ConfiguredSpeed, opts.MinSpeed, dimm.Speed),
+			"Check the memory frequency in the BIOS and replace the DIMMs slower than the others")
+	}
+}
+
+// parseSpeed returns the speed of a dmidecode value such as "4800 MT/s" or,
+// with older versions, "4800 MHz".
+func parseSpeed(value string) int {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return 0
+	}
+	speed, _ := strconv.Atoi(fields[0])
+	return speed
+}
+
+// normalizeSize returns a dmidecode size such as "65536 MB" as "64 GB".
+func normalizeSize(value string) string {
+	fields := strings.Fields(value)
+	if len(fields) != 2 {
+		return value
+	}
+	size, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return value
+	}
+	switch fields[1] {
+	case "MB":
+		if size%1024 == 0 {
+			return fmt.Sprintf("%d GB", size/1024)
+		}
+	case "TB":
+		return fmt.Sprintf("%d GB", size*1024)
+	}
+	return value
+}
+
+func formatKB(kb uint64) string {
+	return fmt.Sprintf("%.1f GB", float64(kb)/(1024*1024))
+}
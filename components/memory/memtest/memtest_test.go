@@ -0,0 +1,141 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package memtest
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	invcollector "github.com/scitix/sichek/components/inventory/collector"
+	"github.com/scitix/sichek/consts"
+	"golang.org/x/sys/unix"
+)
+
+func TestParseNumaNode(t *testing.T) {
+	cpus, err := parseCPUList("0-3,8,10-11\n")
+	if err != nil || !reflect.DeepEqual(cpus, []int{0, 1, 2, 3, 8, 10, 11}) {
+		t.Errorf("parseCPUList() = %v, %v", cpus, err)
+	}
+	if _, err := parseCPUList("3-1"); err == nil {
+		t.Errorf("expected invalid range to be rejected")
+	}
+	if cpus, err := parseCPUList("\n"); err != nil || len(cpus) != 0 {
+		t.Errorf("expected no CPU for a memory only node, got %v, %v", cpus, err)
+	}
+
+	total, free := parseNodeMeminfo(strings.NewReader(`Node 1 MemTotal:       527939876 kB
+Node 1 MemFree:        498237604 kB
+Node 1 MemUsed:         29702272 kB
+`))
+	if total != 527939876 || free != 498237604 {
+		t.Errorf("parseNodeMeminfo() = %d, %d", total, free)
+	}
+}
+
+func TestRunStream(t *testing.T) {
+	var set unix.CPUSet
+	if err := unix.SchedGetaffinity(0, &set); err != nil {
+		t.Skipf("no CPU affinity: %v", err)
+	}
+	var cpus []int
+	for cpu := 0; cpu < 1024 && len(cpus) < 2; cpu++ {
+		if set.IsSet(cpu) {
+			cpus = append(cpus, cpu)
+		}
+	}
+	node := &NumaNode{ID: 0, CPUs: cpus}
+	res, err := RunStream(context.Background(), node, StreamOptions{ArrayBytes: 4 << 20, NTimes: 3})
+	if err != nil {
+		t.Fatalf("RunStream: %v", err)
+	}
+	if res.Threads != len(cpus) || res.Copy <= 0 || res.Scale <= 0 || res.Add <= 0 || res.Triad <= 0 {
+		t.Errorf("unexpected result %s", res)
+	}
+
+	if _, err := RunStream(context.Background(), &NumaNode{ID: 1}, StreamOptions{ArrayBytes: 4 << 20, NTimes: 3}); err == nil {
+		t.Errorf("expected a node without CPU to be rejected")
+	}
+	if _, err := RunStream(context.Background(), &NumaNode{ID: 0, CPUs: cpus, MemFree: 1024}, StreamOptions{ArrayBytes: 4 << 20, NTimes: 3}); err == nil {
+		t.Errorf("expected a node without enough free memory to be rejected")
+	}
+}
+
+func newTestDIMMs(n int, size, speed string) []invcollector.DIMM {
+	var dimms []invcollector.DIMM
+	for i := 0; i < n; i++ {
+		dimms = append(dimms, invcollector.DIMM{Locator: "DIMM" + string(rune('A'+i)), Populated: true, Size: size, Speed: "4800 MT/s", ConfiguredSpeed: speed})
+	}
+	return append(dimms, invcollector.DIMM{Locator: "EMPTY"})
+}
+
+func TestCheck(t *testing.T) {
+	nodes := []*NumaNode{
+		{ID: 0, CPUs: []int{0, 1}, MemTotal: 512 << 20},
+		{ID: 1, CPUs: []int{2, 3}, MemTotal: 512 << 20},
+		{ID: 2, MemTotal: 128 << 20}, // CXL memory
+	}
+	results := []*StreamResult{{Node: 0, Triad: 210}, {Node: 1, Triad: 205}}
+	opts := CheckOptions{ExpectedTriad: 200, ImbalancePercent: 15, DIMMs: 4, MinSpeed: 4800}
+
+	res := Check(results, nodes, newTestDIMMs(4, "64 GB", "4800 MT/s"), opts)
+	if res.Status != consts.StatusNormal || len(res.Checkers) != 1 {
+		t.Fatalf("expected normal result, got %+v", res.Checkers)
+	}
+
+	// node 1 lost a DIMM: less memory and bandwidth, the speed dropped
+	nodes[1].MemTotal = 384 << 20
+	results[1].Triad = 150
+	dimms := newTestDIMMs(3, "64 GB", "4400 MT/s")
+	dimms[0].Size = "32768 MB"
+	res = Check(results, nodes, dimms, opts)
+	if res.Status != consts.StatusAbnormal {
+		t.Fatalf("expected abnormal result, got %s", res.Status)
+	}
+	got := make(map[string]int)
+	for _, checker := range res.Checkers {
+		got[checker.ErrorName+" "+checker.Device]++
+	}
+	want := map[string]int{
+		"MemBandwidthLow node1":       1,
+		"MemBandwidthImbalance node1": 1,
+		"MemDIMMMisPopulated node1":   1,
+		"MemDIMMMisPopulated ":        2, // count and mixed sizes
+		"MemDIMMSpeedLow DIMMA":       1,
+		"MemDIMMSpeedLow DIMMB":       1,
+		"MemDIMMSpeedLow DIMMC":       1,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Check() = %v, want %v", got, want)
+	}
+
+	// without dmidecode only the bandwidth and the NUMA memory are checked
+	res = Check(results, nodes, nil, CheckOptions{ImbalancePercent: 15, DIMMs: 4})
+	if len(res.Checkers) != 2 {
+		t.Errorf("expected the bandwidth and memory imbalance of node 1 only, got %+v", res.Checkers)
+	}
+}
+
+func TestExpectedTriadPerNode(t *testing.T) {
+	// 2 sockets with sub-NUMA clustering into 2 nodes each
+	if got := ExpectedTriadPerNode(220, 2, 4); got != 110 {
+		t.Errorf("ExpectedTriadPerNode() = %v, want 110", got)
+	}
+	if got := ExpectedTriadPerNode(0, 2, 2); got != 0 {
+		t.Errorf("ExpectedTriadPerNode() = %v, want 0", got)
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package memtest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/scitix/sichek/pkg/hostfs"
+)
+
+const nodeSysfsPath = "/sys/devices/system/node"
+
+// NumaNode is a NUMA node with its CPUs and memory.
+type NumaNode struct {
+	ID   int   `json:"id"`
+	CPUs []int `json:"cpus"`
+	// MemTotal and MemFree are in kB.
+	MemTotal uint64 `json:"mem_total_kb"`
+	MemFree  uint64 `json:"mem_free_kb"`
+}
+
+// GetNumaNodes returns the NUMA nodes of the host sorted by ID.
+func GetNumaNodes() ([]*NumaNode, error) {
+	root := hostfs.Path(nodeSysfsPath)
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	var nodes []*NumaNode
+	for _, entry := range entries {
+		name := entry.Name()
+		id, err := strconv.Atoi(strings.TrimPrefix(name, "node"))
+		if !strings.HasPrefix(name, "node") || err != nil {
+			continue
+		}
+		node := &NumaNode{ID: id}
+		data, err := os.ReadFile(filepath.Join(root, name, "cpulist"))
+		if err != nil {
+			return nil, err
+		}
+		if node.CPUs, err = parseCPUList(string(data)); err != nil {
+			return nil, err
+		}
+		f, err := os.Open(filepath.Join(root, name, "meminfo"))
+		if err != nil {
+			return nil, err
+		}
+		node.MemTotal, node.MemFree = parseNodeMeminfo(f)
+		f.Close()
+		nodes = append(nodes, node)
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no NUMA node in %s", root)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes, nil
+}
+
+// parseNodeMeminfo returns MemTotal and MemFree in kB of a node meminfo, e.g.
+//
+//	Node 0 MemTotal:       527939876 kB
+//	Node 0 MemFree:        498237604 kB
+func parseNodeMeminfo(r io.Reader) (total, free uint64) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[3], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[2] {
+		case "MemTotal:":
+			total = value
+		case "MemFree:":
+			free = value
+		}
+	}
+	return total, free
+}
+
+// parseCPUList parses a kernel CPU list such as "0-23,48-71".
+func parseCPUList(list string) ([]int, error) {
+	var cpus []int
+	for _, part := range strings.Split(strings.TrimSpace(list), ",") {
+		if part == "" {
+			continue
+		}
+		lo, hi, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(lo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CPU list %q", list)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(hi); err != nil || end < start {
+				return nil, fmt.Errorf("invalid CPU list %q", list)
+			}
+		}
+		for cpu := start; cpu <= end; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus, nil
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package memtest measures the memory bandwidth of each NUMA node with a
+// STREAM like benchmark and checks it, together with the DIMM population,
+// against the memory spec of the CPU model. Mis-populated DIMMs and DIMMs
+// running at a lower speed silently slow down the data loading of the jobs.
+package memtest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// The kernels of STREAM, run in this order on the arrays a, b and c.
+const (
+	KernelCopy = iota
+	KernelScale
+	KernelAdd
+	KernelTriad
+	numKernels
+)
+
+// kernelArrays is the number of arrays a kernel reads or writes, STREAM
+// counts the bytes it moves from them.
+var kernelArrays = [numKernels]int{2, 2, 3, 3}
+
+const scalar = 3.0
+
+// StreamOptions sizes the benchmark of a NUMA node.
+type StreamOptions struct {
+	// ArrayBytes is the size of each of the three arrays, STREAM requires
+	// them to be at least 4 times the size of the last level caches.
+	ArrayBytes int64
+	// NTimes is the number of runs of each kernel, the best run counts and
+	// the first one, warming up, is ignored.
+	NTimes int
+	// Threads is the number of threads, one per CPU of the node when 0.
+	Threads int
+}
+
+// StreamResult is the best bandwidth of each kernel in GB/s.
+type StreamResult struct {
+	Node    int     `json:"node"`
+	Threads int     `json:"threads"`
+	Copy    float64 `json:"copy_gbps"`
+	Scale   float64 `json:"scale_gbps"`
+	Add     float64 `json:"add_gbps"`
+	Triad   float64 `json:"triad_gbps"`
+}
+
+func (r *StreamResult) String() string {
+	return fmt.Sprintf("node %d (%d threads): copy %.1f GB/s, scale %.1f GB/s, add %.1f GB/s, triad %.1f GB/s",
+		r.Node, r.Threads, r.Copy, r.Scale, r.Add, r.Triad)
+}
+
+// streamWorker runs the kernels on its chunk of the arrays from a thread
+// pinned to its CPU. It maps and first touches the chunk from that thread,
+// so that the pages are allocated on the NUMA node of the CPU.
+type streamWorker struct {
+	cpu     int
+	n       int
+	mem     []byte
+	a, b, c []float64
+	run     chan int
+}
+
+func (w *streamWorker) start(ready chan<- error, done *sync.WaitGroup, exited *sync.WaitGroup) {
+	go func() {
+		defer exited.Done()
+		// the thread is not returned to the runtime with its pinned affinity
+		runtime.LockOSThread()
+		var set unix.CPUSet
+		set.Set(w.cpu)
+		if err := unix.SchedSetaffinity(0, &set); err != nil {
+			ready <- fmt.Errorf("pin thread to CPU %d: %w", w.cpu, err)
+			return
+		}
+		mem, err := unix.Mmap(-1, 0, 3*w.n*8, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_ANON|unix.MAP_PRIVATE)
+		if err != nil {
+			ready <- fmt.Errorf("map %d bytes on CPU %d: %w", 3*w.n*8, w.cpu, err)
+			return
+		}
+		w.mem = mem
+		all := unsafe.Slice((*float64)(unsafe.Pointer(&mem[0])), 3*w.n)
+		w.a, w.b, w.c = all[:w.n:w.n], all[w.n:2*w.n:2*w.n], all[2*w.n:]
+		for i := range w.a {
+			w.a[i], w.b[i], w.c[i] = 1, 2, 0
+		}
+		ready <- nil
+		for kernel := range w.run {
+			w.kernel(kernel)
+			done.Done()
+		}
+		_ = unix.Munmap(w.mem)
+	}()
+}
+
+func (w *streamWorker) kernel(kernel int) {
+	a, b, c := w.a, w.b, w.c
+	switch kernel {
+	case KernelCopy:
+		copy(c, a)
+	case KernelScale:
+		b = b[:len(c)]
+		for i, v := range c {
+			b[i] = scalar * v
+		}
+	case KernelAdd:
+		a, b = a[:len(c)], b[:len(c)]
+		for i := range c {
+			c[i] = a[i] + b[i]
+		}
+	case KernelTriad:
+		b, c = b[:len(a)], c[:len(a)]
+		for i := range a {
+			a[i] = b[i] + scalar*c[i]
+		}
+	}
+}
+
+// RunStream runs the STREAM kernels with the memory and threads of node.
+func RunStream(ctx context.Context, node *NumaNode, opts StreamOptions) (*StreamResult, error) {
+	if len(node.CPUs) == 0 {
+		return nil, fmt.Errorf("NUMA node %d has no CPU", node.ID)
+	}
+	if opts.NTimes < 2 {
+		return nil, fmt.Errorf("ntimes must be at least 2, got %d", opts.NTimes)
+	}
+	threads := opts.Threads
+	if threads <= 0 || threads > len(node.CPUs) {
+		threads = len(node.CPUs)
+	}
+	elements := int(opts.ArrayBytes / 8)
+	if elements < threads {
+		return nil, fmt.Errorf("array size %d bytes is too small for %d threads", opts.ArrayBytes, threads)
+	}
+	if need := uint64(3*opts.ArrayBytes) / 1024; node.MemFree > 0 && need > node.MemFree/2 {
+		return nil, fmt.Errorf("NUMA node %d has %d kB free, the arrays need %d kB", node.ID, node.MemFree, need)
+	}
+
+	chunk := (elements + threads - 1) / threads
+	workers := make([]*streamWorker, 0, threads)
+	ready := make(chan error, threads)
+	var done, exited sync.WaitGroup
+	for i := 0; i < threads && i*chunk < elements; i++ {
+		w := &streamWorker{cpu: node.CPUs[i], n: min(chunk, elements-i*chunk), run: make(chan int)}
+		exited.Add(1)
+		w.start(ready, &done, &exited)
+		workers = append(workers, w)
+	}
+	var startErr error
+	for range workers {
+		if err := <-ready; err != nil && startErr == nil {
+			startErr = err
+		}
+	}
+	defer func() {
+		for _, w := range workers {
+			close(w.run)
+		}
+		exited.Wait()
+	}()
+	if startErr != nil {
+		return nil, startErr
+	}
+
+	var best [numKernels]time.Duration
+	for i := range best {
+		best[i] = time.Duration(math.MaxInt64)
+	}
+	for iter := 0; iter < opts.NTimes; iter++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for kernel := 0; kernel < numKernels; kernel++ {
+			done.Add(len(workers))
+			start := time.Now()
+			for _, w := range workers {
+				w.run <- kernel
+			}
+			done.Wait()
+			if elapsed := time.Since(start); iter > 0 && elapsed < best[kernel] {
+				best[kernel] = elapsed
+			}
+		}
+	}
+
+	res := &StreamResult{Node: node.ID, Threads: len(workers)}
+	for kernel, dst := range []*float64{&res.Copy, &res.Scale, &res.Add, &res.Triad} {
+		bytes := float64(kernelArrays[kernel] * 8 * elements)
+		*dst = bytes / best[kernel].Seconds() / 1e9
+	}
+	return res, nil
+}
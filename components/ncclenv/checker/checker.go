@@ -0,0 +1,50 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/ncclenv/config"
+)
+
+// NewCheckers creates all NCCL environment checkers, filtering out any in the ignored list.
+func NewCheckers(cfg *config.NCCLEnvUserConfig) ([]common.Checker, error) {
+	checkerConstructors := map[string]func(*config.NCCLEnvConfig) (common.Checker, error){
+		config.GPUDirectRDMACheckerName: NewGPUDirectRDMAChecker,
+		config.IBHCACheckerName:         NewIBHCAChecker,
+		config.RoCEGIDCheckerName:       NewRoCEGIDChecker,
+		config.RDMAMTUCheckerName:       NewRDMAMTUChecker,
+		config.RoCEPFCCheckerName:       NewRoCEPFCChecker,
+	}
+
+	ignoredSet := make(map[string]struct{})
+	for _, v := range cfg.NCCLEnv.IgnoredCheckers {
+		ignoredSet[v] = struct{}{}
+	}
+
+	checkers := make([]common.Checker, 0, len(checkerConstructors))
+	for checkerName, constructor := range checkerConstructors {
+		if _, found := ignoredSet[checkerName]; found {
+			continue
+		}
+		checker, err := constructor(cfg.NCCLEnv)
+		if err != nil {
+			return nil, err
+		}
+		checkers = append(checkers, checker)
+	}
+	return checkers, nil
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/ncclenv/collector"
+	"github.com/scitix/sichek/components/ncclenv/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCheckers(t *testing.T) map[string]common.Checker {
+	t.Helper()
+	checkers, err := NewCheckers(&config.NCCLEnvUserConfig{NCCLEnv: &config.NCCLEnvConfig{}})
+	require.NoError(t, err)
+	byName := make(map[string]common.Checker)
+	for _, c := range checkers {
+		byName[c.Name()] = c
+	}
+	require.Len(t, byName, 5)
+	return byName
+}
+
+func rocePort(dev string, mtu int, pfc3 bool) collector.RDMAPort {
+	return collector.RDMAPort{
+		IBDev: dev, Port: 1, State: "4: ACTIVE", LinkLayer: "Ethernet", NetDev: "eth_" + dev, MTU: mtu,
+		GIDs: []collector.GID{
+			{Index: 0, GID: "fe80:0000:0000:0000:0e42:a1ff:fe00:0001", Type: "IB/RoCE v1"},
+			{Index: 1, GID: "fe80:0000:0000:0000:0e42:a1ff:fe00:0001", Type: "RoCE v2"},
+			{Index: 3, GID: "0000:0000:0000:0000:0000:ffff:0a00:0001", Type: "RoCE v2"},
+		},
+		PFC: map[int]bool{0: false, 3: pfc3},
+	}
+}
+
+func TestParseIBHCA(t *testing.T) {
+	ports := []collector.RDMAPort{{IBDev: "mlx5_0", Port: 1}, {IBDev: "mlx5_1", Port: 1}, {IBDev: "mlx5_10", Port: 1}}
+	selected := func(value string) []string {
+		filter := parseIBHCA(value)
+		var names []string
+		for i := range ports {
+			if filter.selects(&ports[i]) {
+				names = append(names, ports[i].IBDev)
+			}
+		}
+		return names
+	}
+	assert.Equal(t, []string{"mlx5_0", "mlx5_1", "mlx5_10"}, selected(""))
+	assert.Equal(t, []string{"mlx5_1", "mlx5_10"}, selected("mlx5_1"))
+	assert.Equal(t, []string{"mlx5_1"}, selected("=mlx5_1:1"))
+	assert.Equal(t, []string(nil), selected("=mlx5_1:2"))
+	assert.Equal(t, []string{"mlx5_0", "mlx5_10"}, selected("^=mlx5_1"))
+	assert.Equal(t, []string{"mlx5_0"}, selected("^mlx5_1"))
+}
+
+func TestIBHCAChecker(t *testing.T) {
+	checker := newCheckers(t)[config.IBHCACheckerName]
+	ctx := context.Background()
+	down := rocePort("mlx5_2", 9000, true)
+	down.State = "1: DOWN"
+	info := &collector.NCCLEnvInfo{Ports: []collector.RDMAPort{rocePort("mlx5_0", 9000, true), rocePort("mlx5_1", 9000, true), down}}
+
+	res, err := checker.Check(ctx, info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusNormal, res.Status)
+	assert.Equal(t, "2 active ports", res.Curr)
+
+	info.Env = map[string]string{"NCCL_IB_HCA": "=mlx5_0,mlx5_1"}
+	res, err = checker.Check(ctx, info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusNormal, res.Status)
+
+	info.Env = map[string]string{"NCCL_IB_HCA": "=mlx5_0,mlx5_2,mlx5_7"}
+	res, err = checker.Check(ctx, info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusAbnormal, res.Status)
+	assert.Equal(t, consts.LevelCritical, res.Level)
+	assert.Contains(t, res.Detail, "mlx5_2 matches no active port (mlx5_2:1 1: DOWN)")
+	assert.Contains(t, res.Detail, "mlx5_7 matches no HCA of the node")
+
+	info.Env = map[string]string{"NCCL_IB_HCA": "^mlx5"}
+	res, err = checker.Check(ctx, info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusAbnormal, res.Status)
+	assert.Contains(t, res.Detail, "selects no active port")
+
+	info.Env = map[string]string{"NCCL_IB_HCA": "mlx5_7", "NCCL_IB_DISABLE": "1"}
+	res, err = checker.Check(ctx, info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusNormal, res.Status)
+}
+
+func TestGPUDirectRDMAChecker(t *testing.T) {
+	checker := newCheckers(t)[config.GPUDirectRDMACheckerName]
+	ctx := context.Background()
+	info := &collector.NCCLEnvInfo{Ports: []collector.RDMAPort{rocePort("mlx5_0", 9000, true)}}
+
+	res, err := checker.Check(ctx, info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusNormal, res.Status, "no GPU")
+
+	info.NvidiaGPU = true
+	info.DMABufReason = "the proprietary NVIDIA kernel module does not export dma-buf"
+	res, err = checker.Check(ctx, info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusAbnormal, res.Status)
+	assert.Contains(t, res.Detail, "proprietary")
+	require.Len(t, res.Remediations, 1)
+
+	info.PeerMemLoaded = true
+	res, err = checker.Check(ctx, info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusNormal, res.Status)
+	assert.Equal(t, "nvidia_peermem", res.Curr)
+
+	info.PeerMemLoaded, info.DMABuf = false, true
+	res, err = checker.Check(ctx, info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusNormal, res.Status)
+	assert.Equal(t, "dma-buf", res.Curr)
+}
+
+func TestRoCEPortCheckers(t *testing.T) {
+	checkers := newCheckers(t)
+	ctx := context.Background()
+	ib := collector.RDMAPort{IBDev: "mlx5_9", Port: 1, State: "4: ACTIVE", LinkLayer: "InfiniBand", MTU: 2044}
+	info := &collector.NCCLEnvInfo{Ports: []collector.RDMAPort{rocePort("mlx5_0", 9000, true), rocePort("mlx5_1", 1500, false), ib}}
+
+	res, err := checkers[config.RDMAMTUCheckerName].Check(ctx, info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusAbnormal, res.Status)
+	assert.Equal(t, "mlx5_1:1", res.Device)
+	assert.Equal(t, "1 of 2 ports", res.Curr)
+
+	res, err = checkers[config.RoCEPFCCheckerName].Check(ctx, info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusAbnormal, res.Status)
+	assert.Equal(t, "mlx5_1:1", res.Device)
+
+	// the misconfigured port is not used by NCCL
+	info.Env = map[string]string{"NCCL_IB_HCA": "^=mlx5_1"}
+	res, err = checkers[config.RDMAMTUCheckerName].Check(ctx, info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusNormal, res.Status)
+
+	res, err = checkers[config.RoCEGIDCheckerName].Check(ctx, info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusNormal, res.Status)
+
+	info.Env["NCCL_IB_GID_INDEX"] = "0"
+	res, err = checkers[config.RoCEGIDCheckerName].Check(ctx, info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusAbnormal, res.Status)
+	assert.Contains(t, res.Detail, "GID 0 fe80:0000:0000:0000:0e42:a1ff:fe00:0001 is IB/RoCE v1, expected RoCE v2")
+
+	info.Env["NCCL_IB_GID_INDEX"] = "5"
+	res, err = checkers[config.RoCEGIDCheckerName].Check(ctx, info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusAbnormal, res.Status)
+	assert.Contains(t, res.Detail, "GID 5 is not populated, the RoCE v2 IPv4 GID is 3")
+
+	info.Env["NCCL_IB_GID_INDEX"] = "3"
+	res, err = checkers[config.RoCEGIDCheckerName].Check(ctx, info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusNormal, res.Status)
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/common/remediator"
+	"github.com/scitix/sichek/components/ncclenv/collector"
+	"github.com/scitix/sichek/components/ncclenv/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+// GPUDirectRDMAChecker flags the GPU nodes whose HCAs cannot access the GPU
+// memory directly, NCCL then bounces every transfer through host memory.
+type GPUDirectRDMAChecker struct {
+	name string
+}
+
+func NewGPUDirectRDMAChecker(cfg *config.NCCLEnvConfig) (common.Checker, error) {
+	return &GPUDirectRDMAChecker{name: config.GPUDirectRDMACheckerName}, nil
+}
+
+func (c *GPUDirectRDMAChecker) Name() string {
+	return c.name
+}
+
+func (c *GPUDirectRDMAChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	info, ok := data.(*collector.NCCLEnvInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid NCCLEnvInfo type")
+	}
+	result := config.NCCLEnvCheckItems[c.name]
+	result.Spec = "nvidia_peermem or dma-buf"
+	switch {
+	case !info.NvidiaGPU:
+		result.Curr = "N/A"
+		result.Detail = "No NVIDIA GPU on the node"
+	case len(ncclPorts(info)) == 0:
+		result.Curr = "N/A"
+		result.Detail = "NCCL uses no active RDMA port"
+	case info.PeerMemLoaded:
+		result.Curr = "nvidia_peermem"
+		result.Detail = "GPUDirect RDMA is available through nvidia_peermem"
+	case info.DMABuf:
+		result.Curr = "dma-buf"
+		result.Detail = "GPUDirect RDMA is available through dma-buf"
+	default:
+		logrus.WithField("checker", c.name).Warnf("GPUDirect RDMA is not available: nvidia_peermem is not loaded and %s", info.DMABufReason)
+		result.Status = consts.StatusAbnormal
+		result.Curr = "Unavailable"
+		result.Detail = fmt.Sprintf("nvidia_peermem is not loaded and dma-buf is not supported: %s", info.DMABufReason)
+		result.Remediations = append(result.Remediations, remediator.NewModprobeAction("nvidia_peermem"))
+		return &result, nil
+	}
+	result.Suggestion = ""
+	return &result, nil
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/ncclenv/collector"
+	"github.com/scitix/sichek/components/ncclenv/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+// hcaFilter is a parsed NCCL_IB_HCA: a list of device[:port] entries that
+// are prefixes of the device names unless exact, and select the ports they
+// match, or all the other ones if exclude.
+type hcaFilter struct {
+	exclude bool
+	exact   bool
+	entries []hcaEntry
+}
+
+type hcaEntry struct {
+	dev  string
+	port int // 0 matches all the ports of the device
+}
+
+func (e hcaEntry) String() string {
+	if e.port == 0 {
+		return e.dev
+	}
+	return fmt.Sprintf("%s:%d", e.dev, e.port)
+}
+
+// parseIBHCA parses the NCCL_IB_HCA syntax, e.g. "mlx5", "=mlx5_0:1,mlx5_1:1"
+// or "^=mlx5_4". An empty value selects all the ports.
+func parseIBHCA(value string) hcaFilter {
+	var filter hcaFilter
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "^") {
+		filter.exclude = true
+		value = value[1:]
+	}
+	if strings.HasPrefix(value, "=") {
+		filter.exact = true
+		value = value[1:]
+	}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		dev, portStr, _ := strings.Cut(item, ":")
+		port, _ := strconv.Atoi(portStr)
+		filter.entries = append(filter.entries, hcaEntry{dev: dev, port: port})
+	}
+	return filter
+}
+
+func (f hcaFilter) matches(e hcaEntry, port *collector.RDMAPort) bool {
+	if e.port != 0 && e.port != port.Port {
+		return false
+	}
+	if f.exact {
+		return port.IBDev == e.dev
+	}
+	return strings.HasPrefix(port.IBDev, e.dev)
+}
+
+// selects reports whether NCCL uses the port under the filter.
+func (f hcaFilter) selects(port *collector.RDMAPort) bool {
+	if len(f.entries) == 0 {
+		return true
+	}
+	for _, e := range f.entries {
+		if f.matches(e, port) {
+			return !f.exclude
+		}
+	}
+	return f.exclude
+}
+
+// ibDisabled reports whether NCCL is told not to use the RDMA devices at all.
+func ibDisabled(env map[string]string) bool {
+	return env["NCCL_IB_DISABLE"] == "1"
+}
+
+// ncclPorts returns the active ports NCCL uses under NCCL_IB_HCA.
+func ncclPorts(info *collector.NCCLEnvInfo) []*collector.RDMAPort {
+	if ibDisabled(info.Env) {
+		return nil
+	}
+	filter := parseIBHCA(info.Env["NCCL_IB_HCA"])
+	var ports []*collector.RDMAPort
+	for i := range info.Ports {
+		port := &info.Ports[i]
+		if port.Active() && filter.selects(port) {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+// IBHCAChecker flags an NCCL_IB_HCA that lists HCAs the node does not have
+// or that are down, e.g. copied from a node with a different NIC layout.
+type IBHCAChecker struct {
+	name string
+}
+
+func NewIBHCAChecker(cfg *config.NCCLEnvConfig) (common.Checker, error) {
+	return &IBHCAChecker{name: config.IBHCACheckerName}, nil
+}
+
+func (c *IBHCAChecker) Name() string {
+	return c.name
+}
+
+func (c *IBHCAChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	info, ok := data.(*collector.NCCLEnvInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid NCCLEnvInfo type")
+	}
+	result := config.NCCLEnvCheckItems[c.name]
+	value, set := info.Env["NCCL_IB_HCA"]
+	result.Spec = "NCCL_IB_HCA=" + value
+	if ibDisabled(info.Env) {
+		result.Curr = "NCCL_IB_DISABLE=1"
+		result.Detail = "NCCL_IB_DISABLE=1, NCCL does not use the RDMA devices"
+		return &result, nil
+	}
+	active := ncclPorts(info)
+	if !set {
+		result.Curr = fmt.Sprintf("%d active ports", len(active))
+		result.Detail = fmt.Sprintf("NCCL_IB_HCA is not set, NCCL uses the %d active ports", len(active))
+		return &result, nil
+	}
+
+	filter := parseIBHCA(value)
+	var reasons []string
+	if !filter.exclude {
+		for _, e := range filter.entries {
+			var states []string
+			matchedActive := false
+			for i := range info.Ports {
+				port := &info.Ports[i]
+				if !filter.matches(e, port) {
+					continue
+				}
+				if port.Active() {
+					matchedActive = true
+					break
+				}
+				states = append(states, fmt.Sprintf("%s:%d %s", port.IBDev, port.Port, port.State))
+			}
+			switch {
+			case matchedActive:
+			case len(states) == 0:
+				reasons = append(reasons, fmt.Sprintf("%s matches no HCA of the node", e))
+			default:
+				reasons = append(reasons, fmt.Sprintf("%s matches no active port (%s)", e, strings.Join(states, ", ")))
+			}
+		}
+	}
+	if len(active) == 0 {
+		reasons = append(reasons, fmt.Sprintf("NCCL_IB_HCA=%s selects no active port", value))
+	}
+
+	result.Curr = fmt.Sprintf("%d active ports", len(active))
+	if len(reasons) > 0 {
+		logrus.WithField("checker", c.name).Warnf("NCCL_IB_HCA=%s does not match the HCAs of the node: %s", value, strings.Join(reasons, "; "))
+		result.Status = consts.StatusAbnormal
+		result.Detail = strings.Join(reasons, "\n")
+		return &result, nil
+	}
+	result.Suggestion = ""
+	result.Detail = fmt.Sprintf("NCCL_IB_HCA=%s selects %d active ports", value, len(active))
+	return &result, nil
+}
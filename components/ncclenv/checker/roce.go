@@ -0,0 +1,165 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/ncclenv/collector"
+	"github.com/scitix/sichek/components/ncclenv/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+// RoCEPortChecker checks the active RoCE ports NCCL uses one by one.
+type RoCEPortChecker struct {
+	name string
+	spec string
+	// abnormal reports why the port is misconfigured, "" if it is not.
+	abnormal func(env map[string]string, port *collector.RDMAPort) string
+}
+
+func NewRoCEGIDChecker(cfg *config.NCCLEnvConfig) (common.Checker, error) {
+	mode := cfg.Mode()
+	return &RoCEPortChecker{
+		name: config.RoCEGIDCheckerName,
+		spec: mode,
+		abnormal: func(env map[string]string, port *collector.RDMAPort) string {
+			return gidReason(env["NCCL_IB_GID_INDEX"], mode, port)
+		},
+	}, nil
+}
+
+func NewRDMAMTUChecker(cfg *config.NCCLEnvConfig) (common.Checker, error) {
+	minMTU := cfg.MTULimit()
+	return &RoCEPortChecker{
+		name: config.RDMAMTUCheckerName,
+		spec: fmt.Sprintf("mtu >= %d", minMTU),
+		abnormal: func(env map[string]string, port *collector.RDMAPort) string {
+			if port.MTU > 0 && port.MTU < minMTU {
+				return fmt.Sprintf("%s mtu %d < %d", port.NetDev, port.MTU, minMTU)
+			}
+			return ""
+		},
+	}, nil
+}
+
+func NewRoCEPFCChecker(cfg *config.NCCLEnvConfig) (common.Checker, error) {
+	priority := cfg.PFCLimit()
+	return &RoCEPortChecker{
+		name: config.RoCEPFCCheckerName,
+		spec: fmt.Sprintf("pfc on priority %d", priority),
+		abnormal: func(env map[string]string, port *collector.RDMAPort) string {
+			// the PFC config is unknown without dcb
+			if priority < 0 || port.PFC == nil {
+				return ""
+			}
+			if !port.PFC[priority] {
+				return fmt.Sprintf("%s has PFC off on priority %d", port.NetDev, priority)
+			}
+			return ""
+		},
+	}, nil
+}
+
+// gidReason checks the GID NCCL picks on a port: the one at NCCL_IB_GID_INDEX
+// if set, otherwise NCCL selects a GID of the RoCE mode by itself.
+func gidReason(gidIndex, mode string, port *collector.RDMAPort) string {
+	index, err := strconv.Atoi(gidIndex)
+	if gidIndex == "" || (err == nil && index < 0) {
+		for _, gid := range port.GIDs {
+			if gid.Type == mode {
+				return ""
+			}
+		}
+		return fmt.Sprintf("no %s GID", mode)
+	}
+	if err != nil {
+		return fmt.Sprintf("invalid NCCL_IB_GID_INDEX %q", gidIndex)
+	}
+	for _, gid := range port.GIDs {
+		if gid.Index != index {
+			continue
+		}
+		if gid.Type != mode {
+			return fmt.Sprintf("GID %d %s is %s, expected %s", index, gid.GID, gid.Type, mode)
+		}
+		return ""
+	}
+	return fmt.Sprintf("GID %d is not populated%s", index, gidHint(mode, port))
+}
+
+// gidHint suggests the first IPv4 GID of the RoCE mode, the one to set
+// NCCL_IB_GID_INDEX to.
+func gidHint(mode string, port *collector.RDMAPort) string {
+	for _, gid := range port.GIDs {
+		if gid.Type == mode && gid.IPv4() {
+			return fmt.Sprintf(", the %s IPv4 GID is %d", mode, gid.Index)
+		}
+	}
+	return ""
+}
+
+func (c *RoCEPortChecker) Name() string {
+	return c.name
+}
+
+func (c *RoCEPortChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	info, ok := data.(*collector.NCCLEnvInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid NCCLEnvInfo type")
+	}
+	result := config.NCCLEnvCheckItems[c.name]
+	result.Spec = c.spec
+
+	var checked int
+	var abnormalPorts, reasons []string
+	for _, port := range ncclPorts(info) {
+		if !port.RoCE() {
+			continue
+		}
+		checked++
+		reason := c.abnormal(info.Env, port)
+		if reason == "" {
+			continue
+		}
+		name := fmt.Sprintf("%s:%d", port.IBDev, port.Port)
+		abnormalPorts = append(abnormalPorts, name)
+		reasons = append(reasons, fmt.Sprintf("%s: %s", name, reason))
+	}
+
+	if len(abnormalPorts) > 0 {
+		logrus.WithField("checker", c.name).Warnf("misconfigured RoCE ports: %s", strings.Join(reasons, "; "))
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(abnormalPorts, ",")
+		result.Curr = fmt.Sprintf("%d of %d ports", len(abnormalPorts), checked)
+		result.Detail = strings.Join(reasons, "\n")
+		return &result, nil
+	}
+	result.Suggestion = ""
+	if checked == 0 {
+		result.Curr = "N/A"
+		result.Detail = "NCCL uses no active RoCE port"
+	} else {
+		result.Curr = fmt.Sprintf("%d ports", checked)
+		result.Detail = fmt.Sprintf("All %d RoCE ports NCCL uses match %s", checked, c.spec)
+	}
+	return &result, nil
+}
@@ -0,0 +1,327 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	ethcollector "github.com/scitix/sichek/components/ethernet/collector"
+	"github.com/scitix/sichek/components/ncclenv/config"
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/scitix/sichek/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// The sources of the collector, vars so that tests can point them at a fake tree.
+var (
+	IBSysfsPath       = "/sys/class/infiniband"
+	moduleSysfsPath   = "/sys/module"
+	nvidiaVersionPath = "/proc/driver/nvidia/version"
+	kernelReleasePath = "/proc/sys/kernel/osrelease"
+	kernelConfigPaths = []string{"/boot/config-%s", "/proc/config.gz"}
+)
+
+const zeroGID = "0000:0000:0000:0000:0000:0000:0000:0000"
+
+// NCCLEnvInfo is the part of the node environment NCCL depends on to move
+// data between the GPUs of different nodes.
+type NCCLEnvInfo struct {
+	Time          time.Time `json:"time"`
+	NvidiaGPU     bool      `json:"nvidia_gpu"`
+	PeerMemLoaded bool      `json:"peermem_loaded"`
+	DMABuf        bool      `json:"dmabuf"`
+	DMABufReason  string    `json:"dmabuf_reason,omitempty"`
+	// Env holds the NCCL_* variables of the conf files and the environment.
+	Env    map[string]string `json:"env,omitempty"`
+	Ports  []RDMAPort        `json:"ports,omitempty"`
+	Errors []string          `json:"errors,omitempty"`
+}
+
+func (i *NCCLEnvInfo) JSON() (string, error) {
+	b, err := common.JSON(i)
+	return string(b), err
+}
+
+// RDMAPort is a port of an RDMA device as NCCL sees it.
+type RDMAPort struct {
+	IBDev     string       `json:"ib_dev"`
+	Port      int          `json:"port"`
+	State     string       `json:"state"`
+	LinkLayer string       `json:"link_layer"`
+	NetDev    string       `json:"netdev,omitempty"`
+	MTU       int          `json:"mtu,omitempty"`
+	GIDs      []GID        `json:"gids,omitempty"`
+	PFC       map[int]bool `json:"pfc,omitempty"`
+}
+
+// Active reports whether the port is ACTIVE, the only state NCCL uses.
+func (p *RDMAPort) Active() bool {
+	return strings.HasSuffix(p.State, "ACTIVE")
+}
+
+// RoCE reports whether the port runs over Ethernet.
+func (p *RDMAPort) RoCE() bool {
+	return p.LinkLayer == "Ethernet"
+}
+
+// GID is a populated entry of the GID table of a port.
+type GID struct {
+	Index  int    `json:"index"`
+	GID    string `json:"gid"`
+	Type   string `json:"type,omitempty"`
+	NetDev string `json:"netdev,omitempty"`
+}
+
+// IPv4 reports whether the GID is an IPv4-mapped address, which NCCL
+// prefers as it routes across subnets.
+func (g GID) IPv4() bool {
+	return strings.HasPrefix(g.GID, "0000:0000:0000:0000:0000:ffff:")
+}
+
+type NCCLEnvCollector struct {
+	name string
+	cfg  *config.NCCLEnvConfig
+}
+
+func NewNCCLEnvCollector(cfg *config.NCCLEnvConfig) *NCCLEnvCollector {
+	return &NCCLEnvCollector{name: "NCCLEnvCollector", cfg: cfg}
+}
+
+func (c *NCCLEnvCollector) Name() string { return c.name }
+
+func (c *NCCLEnvCollector) Collect(ctx context.Context) (common.Info, error) {
+	info := &NCCLEnvInfo{
+		Time:      time.Now(),
+		NvidiaGPU: utils.IsNvidiaGPUExist(),
+		Env:       NCCLEnv(c.cfg.ConfFiles(), c.cfg.Env, os.Environ()),
+	}
+	if info.NvidiaGPU {
+		_, err := os.Stat(hostfs.Path(moduleSysfsPath, "nvidia_peermem"))
+		info.PeerMemLoaded = err == nil
+		info.DMABuf, info.DMABufReason = dmaBufSupport()
+	}
+	ports, err := collectPorts(hostfs.Path(IBSysfsPath))
+	if err != nil {
+		info.Errors = append(info.Errors, err.Error())
+	}
+	for i := range ports {
+		if !ports[i].RoCE() || ports[i].NetDev == "" {
+			continue
+		}
+		out, err := utils.ExecCommand(ctx, "dcb", "pfc", "show", "dev", ports[i].NetDev)
+		if err != nil {
+			logrus.WithField("component", "nccl_env").Warnf("failed to get pfc config of %s: %v", ports[i].NetDev, err)
+			continue
+		}
+		ports[i].PFC = ethcollector.ParseDcbPfc(string(out))
+	}
+	info.Ports = ports
+	return info, nil
+}
+
+// NCCLEnv merges the NCCL_* variables of the conf files, the configured job
+// environment and environ, the later sources overriding the former ones.
+func NCCLEnv(confFiles []string, jobEnv map[string]string, environ []string) map[string]string {
+	env := make(map[string]string)
+	for _, path := range confFiles {
+		for key, value := range readNCCLConf(path) {
+			env[key] = value
+		}
+	}
+	for key, value := range jobEnv {
+		if strings.HasPrefix(key, "NCCL_") {
+			env[key] = value
+		}
+	}
+	for _, kv := range environ {
+		key, value, ok := strings.Cut(kv, "=")
+		if ok && strings.HasPrefix(key, "NCCL_") {
+			env[key] = value
+		}
+	}
+	return env
+}
+
+// readNCCLConf parses a nccl.conf, a KEY=VALUE per line with # comments.
+func readNCCLConf(path string) map[string]string {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	env := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		env[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+	return env
+}
+
+// dmaBufSupport reports whether GPU memory can be registered with the HCAs
+// through dma-buf, which needs the open NVIDIA kernel module and a kernel
+// built with CONFIG_DMABUF_MOVE_NOTIFY.
+func dmaBufSupport() (bool, string) {
+	version, err := os.ReadFile(hostfs.Path(nvidiaVersionPath))
+	if err != nil {
+		return false, fmt.Sprintf("NVIDIA driver version not readable: %v", err)
+	}
+	if !strings.Contains(string(version), "Open Kernel Module") {
+		return false, "the proprietary NVIDIA kernel module does not export dma-buf"
+	}
+	release := readSysfs(hostfs.Path(kernelReleasePath))
+	for _, pattern := range kernelConfigPaths {
+		path := pattern
+		if strings.Contains(pattern, "%s") {
+			path = fmt.Sprintf(pattern, release)
+		}
+		path = hostfs.Path(path)
+		enabled, err := kernelConfigEnabled(path, "CONFIG_DMABUF_MOVE_NOTIFY")
+		if err != nil {
+			continue
+		}
+		if !enabled {
+			return false, fmt.Sprintf("kernel %s is built without CONFIG_DMABUF_MOVE_NOTIFY", release)
+		}
+		return true, ""
+	}
+	return false, fmt.Sprintf("the config of kernel %s is not readable", release)
+}
+
+func kernelConfigEnabled(path, option string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	var reader io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return false, err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		if scanner.Text() == option+"=y" {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// collectPorts reads the state, link layer, netdev and GID table of every
+// port of the RDMA devices under root.
+func collectPorts(root string) ([]RDMAPort, error) {
+	devices, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list RDMA devices: %w", err)
+	}
+	var ports []RDMAPort
+	for _, device := range devices {
+		portDirs, err := os.ReadDir(filepath.Join(root, device.Name(), "ports"))
+		if err != nil {
+			continue
+		}
+		for _, portDir := range portDirs {
+			num, err := strconv.Atoi(portDir.Name())
+			if err != nil {
+				continue
+			}
+			ports = append(ports, collectPort(filepath.Join(root, device.Name(), "ports", portDir.Name()), device.Name(), num))
+		}
+	}
+	sort.Slice(ports, func(i, j int) bool {
+		if ports[i].IBDev != ports[j].IBDev {
+			return ports[i].IBDev < ports[j].IBDev
+		}
+		return ports[i].Port < ports[j].Port
+	})
+	return ports, nil
+}
+
+func collectPort(dir, ibDev string, num int) RDMAPort {
+	port := RDMAPort{
+		IBDev:     ibDev,
+		Port:      num,
+		State:     readSysfs(filepath.Join(dir, "state")),
+		LinkLayer: readSysfs(filepath.Join(dir, "link_layer")),
+	}
+	entries, _ := os.ReadDir(filepath.Join(dir, "gids"))
+	for _, entry := range entries {
+		index, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		gid := readSysfs(filepath.Join(dir, "gids", entry.Name()))
+		if gid == "" || gid == zeroGID {
+			continue
+		}
+		// the attributes of an unpopulated GID fail with EINVAL
+		port.GIDs = append(port.GIDs, GID{
+			Index:  index,
+			GID:    gid,
+			Type:   readSysfs(filepath.Join(dir, "gid_attrs", "types", entry.Name())),
+			NetDev: readSysfs(filepath.Join(dir, "gid_attrs", "ndevs", entry.Name())),
+		})
+	}
+	sort.Slice(port.GIDs, func(i, j int) bool { return port.GIDs[i].Index < port.GIDs[j].Index })
+	if port.RoCE() {
+		for _, gid := range port.GIDs {
+			if gid.NetDev != "" {
+				port.NetDev = gid.NetDev
+				break
+			}
+		}
+		if port.NetDev != "" {
+			port.MTU, _ = strconv.Atoi(readSysfs(hostfs.Path(ethcollector.NetSysfsPath, port.NetDev, "mtu")))
+		}
+	}
+	return port
+}
+
+func readSysfs(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
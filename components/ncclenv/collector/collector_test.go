@@ -0,0 +1,130 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	ethcollector "github.com/scitix/sichek/components/ethernet/collector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func TestCollectPorts(t *testing.T) {
+	root := t.TempDir()
+	ib := filepath.Join(root, "infiniband")
+	roce := filepath.Join(ib, "mlx5_0", "ports", "1")
+	writeFiles(t, map[string]string{
+		filepath.Join(roce, "state"):                            "4: ACTIVE\n",
+		filepath.Join(roce, "link_layer"):                       "Ethernet\n",
+		filepath.Join(roce, "gids", "0"):                        "fe80:0000:0000:0000:0e42:a1ff:fe00:0001\n",
+		filepath.Join(roce, "gids", "1"):                        "fe80:0000:0000:0000:0e42:a1ff:fe00:0001\n",
+		filepath.Join(roce, "gids", "3"):                        "0000:0000:0000:0000:0000:ffff:0a00:0001\n",
+		filepath.Join(roce, "gids", "4"):                        zeroGID + "\n",
+		filepath.Join(roce, "gid_attrs", "types", "0"):          "IB/RoCE v1\n",
+		filepath.Join(roce, "gid_attrs", "types", "1"):          "RoCE v2\n",
+		filepath.Join(roce, "gid_attrs", "types", "3"):          "RoCE v2\n",
+		filepath.Join(roce, "gid_attrs", "ndevs", "0"):          "eth0\n",
+		filepath.Join(roce, "gid_attrs", "ndevs", "1"):          "eth0\n",
+		filepath.Join(roce, "gid_attrs", "ndevs", "3"):          "eth0\n",
+		filepath.Join(ib, "mlx5_1", "ports", "1", "state"):      "1: DOWN\n",
+		filepath.Join(ib, "mlx5_1", "ports", "1", "link_layer"): "InfiniBand\n",
+		filepath.Join(root, "net", "eth0", "mtu"):               "9000\n",
+	})
+	origNet := ethcollector.NetSysfsPath
+	ethcollector.NetSysfsPath = filepath.Join(root, "net")
+	defer func() { ethcollector.NetSysfsPath = origNet }()
+
+	ports, err := collectPorts(ib)
+	require.NoError(t, err)
+	require.Len(t, ports, 2)
+
+	assert.Equal(t, "mlx5_0", ports[0].IBDev)
+	assert.True(t, ports[0].Active())
+	assert.True(t, ports[0].RoCE())
+	assert.Equal(t, "eth0", ports[0].NetDev)
+	assert.Equal(t, 9000, ports[0].MTU)
+	require.Len(t, ports[0].GIDs, 3, "the zero GID is not populated")
+	assert.Equal(t, GID{Index: 3, GID: "0000:0000:0000:0000:0000:ffff:0a00:0001", Type: "RoCE v2", NetDev: "eth0"}, ports[0].GIDs[2])
+	assert.True(t, ports[0].GIDs[2].IPv4())
+
+	assert.Equal(t, "mlx5_1", ports[1].IBDev)
+	assert.False(t, ports[1].Active())
+	assert.Empty(t, ports[1].NetDev)
+
+	ports, err = collectPorts(filepath.Join(root, "missing"))
+	assert.NoError(t, err)
+	assert.Empty(t, ports)
+}
+
+func TestNCCLEnv(t *testing.T) {
+	conf := filepath.Join(t.TempDir(), "nccl.conf")
+	writeFiles(t, map[string]string{conf: "# cluster defaults\nNCCL_IB_HCA=mlx5\nNCCL_IB_GID_INDEX = 3\nNCCL_SOCKET_IFNAME=\"bond0\"\n"})
+
+	env := NCCLEnv([]string{conf, "/nonexistent/nccl.conf"},
+		map[string]string{"NCCL_IB_GID_INDEX": "1", "CUDA_VISIBLE_DEVICES": "0"},
+		[]string{"NCCL_IB_HCA==mlx5_0,mlx5_1", "PATH=/usr/bin"})
+	assert.Equal(t, map[string]string{
+		"NCCL_IB_HCA":        "=mlx5_0,mlx5_1",
+		"NCCL_IB_GID_INDEX":  "1",
+		"NCCL_SOCKET_IFNAME": "bond0",
+	}, env)
+}
+
+func TestDMABufSupport(t *testing.T) {
+	root := t.TempDir()
+	origVersion, origRelease, origConfigs := nvidiaVersionPath, kernelReleasePath, kernelConfigPaths
+	defer func() {
+		nvidiaVersionPath, kernelReleasePath, kernelConfigPaths = origVersion, origRelease, origConfigs
+	}()
+	nvidiaVersionPath = filepath.Join(root, "version")
+	kernelReleasePath = filepath.Join(root, "osrelease")
+	kernelConfigPaths = []string{filepath.Join(root, "config-%s")}
+
+	writeFiles(t, map[string]string{
+		nvidiaVersionPath: "NVRM version: NVIDIA UNIX x86_64 Kernel Module  535.154.05\n",
+		kernelReleasePath: "5.15.0-97-generic\n",
+	})
+	ok, reason := dmaBufSupport()
+	assert.False(t, ok)
+	assert.Contains(t, reason, "proprietary")
+
+	writeFiles(t, map[string]string{nvidiaVersionPath: "NVRM version: NVIDIA UNIX Open Kernel Module for x86_64  535.154.05\n"})
+	ok, reason = dmaBufSupport()
+	assert.False(t, ok)
+	assert.Contains(t, reason, "not readable")
+
+	configPath := filepath.Join(root, "config-5.15.0-97-generic")
+	writeFiles(t, map[string]string{configPath: "CONFIG_DMA_SHARED_BUFFER=y\n# CONFIG_DMABUF_MOVE_NOTIFY is not set\n"})
+	ok, reason = dmaBufSupport()
+	assert.False(t, ok)
+	assert.Contains(t, reason, "without CONFIG_DMABUF_MOVE_NOTIFY")
+
+	writeFiles(t, map[string]string{configPath: "CONFIG_DMA_SHARED_BUFFER=y\nCONFIG_DMABUF_MOVE_NOTIFY=y\n"})
+	ok, reason = dmaBufSupport()
+	assert.True(t, ok)
+	assert.Empty(t, reason)
+}
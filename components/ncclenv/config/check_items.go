@@ -0,0 +1,79 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+)
+
+const (
+	GPUDirectRDMACheckerName = "nccl-env-gpudirect-rdma"
+	IBHCACheckerName         = "nccl-env-ib-hca"
+	RoCEGIDCheckerName       = "nccl-env-roce-gid"
+	RDMAMTUCheckerName       = "nccl-env-rdma-mtu"
+	RoCEPFCCheckerName       = "nccl-env-roce-pfc"
+)
+
+// NCCLEnvCheckItems is a map of check items for the node environment NCCL
+// relies on, the misconfigurations behind most NET/IB completion errors.
+var NCCLEnvCheckItems = map[string]common.CheckerResult{
+	GPUDirectRDMACheckerName: {
+		Name:        GPUDirectRDMACheckerName,
+		Description: "Check if GPUDirect RDMA is available through nvidia_peermem or dma-buf",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelWarning,
+		Detail:      "GPUDirect RDMA is available",
+		ErrorName:   "GPUDirectRDMAUnavailable",
+		Suggestion:  "Load nvidia_peermem, or use the open NVIDIA kernel module on a kernel with CONFIG_DMABUF_MOVE_NOTIFY, otherwise NCCL stages the traffic through host memory",
+	},
+	IBHCACheckerName: {
+		Name:        IBHCACheckerName,
+		Description: "Check if the HCAs selected by NCCL_IB_HCA exist and are active",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelCritical,
+		Detail:      "NCCL_IB_HCA selects active HCAs",
+		ErrorName:   "NCCLIBHCAMismatch",
+		Suggestion:  "Fix NCCL_IB_HCA to list the HCAs of the node (see ibv_devices), a missing or down HCA fails the NCCL init or the transfers with NET/IB errors",
+	},
+	RoCEGIDCheckerName: {
+		Name:        RoCEGIDCheckerName,
+		Description: "Check if the GID NCCL uses on the RoCE ports is of the expected RoCE mode",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelCritical,
+		Detail:      "The RoCE ports have a GID of the expected RoCE mode",
+		ErrorName:   "RoCEGIDMisconfigured",
+		Suggestion:  "Set NCCL_IB_GID_INDEX to a RoCE v2 GID with an IP address (see show_gids), a wrong GID makes the QPs of the peers fail with completion errors",
+	},
+	RDMAMTUCheckerName: {
+		Name:        RDMAMTUCheckerName,
+		Description: "Check if the MTU of the netdevs of the RoCE ports allows a 4096 RoCE MTU",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelWarning,
+		Detail:      "The netdevs of the RoCE ports have a large enough MTU",
+		ErrorName:   "RDMAMTUTooSmall",
+		Suggestion:  "Raise the MTU of the netdev and the switch ports, e.g. to 9000, a smaller RoCE MTU cuts the bandwidth and mismatched MTUs drop packets",
+	},
+	RoCEPFCCheckerName: {
+		Name:        RoCEPFCCheckerName,
+		Description: "Check if PFC is enabled on the priority of the NCCL traffic of the RoCE ports",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelWarning,
+		Detail:      "PFC is enabled on the NCCL priority of the RoCE ports",
+		ErrorName:   "RoCEPFCDisabled",
+		Suggestion:  "Enable PFC on the priority with `mlnx_qos -i <netdev> --pfc`, a lossy RoCE fabric retransmits under congestion and times out the NCCL transfers",
+	},
+}
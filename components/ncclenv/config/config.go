@@ -0,0 +1,103 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+)
+
+const (
+	DefaultMinRoCEMTU  = 4200
+	DefaultPFCPriority = 3
+	DefaultRoCEMode    = "RoCE v2"
+)
+
+// DefaultNCCLConfFiles are the files NCCL reads its variables from when they
+// are not set in the environment of the job.
+var DefaultNCCLConfFiles = []string{"/etc/nccl.conf"}
+
+type NCCLEnvUserConfig struct {
+	NCCLEnv *NCCLEnvConfig `json:"nccl_env" yaml:"nccl_env"`
+}
+
+type NCCLEnvConfig struct {
+	QueryInterval   common.Duration `json:"query_interval" yaml:"query_interval"`
+	CacheSize       int64           `json:"cache_size" yaml:"cache_size"`
+	IgnoredCheckers []string        `json:"ignored_checkers,omitempty" yaml:"ignored_checkers,omitempty"`
+	// NCCLConfFiles are read in order, the later files override the former.
+	NCCLConfFiles []string `json:"nccl_conf_files,omitempty" yaml:"nccl_conf_files,omitempty"`
+	// Env is the NCCL environment the jobs of the cluster are started with,
+	// e.g. injected by the training operator. It overrides the conf files and
+	// is overridden by the environment of sichek itself.
+	Env map[string]string `json:"env,omitempty" yaml:"env,omitempty"`
+	// MinRoCEMTU is the minimum MTU of the netdev of a RoCE port, a 4096
+	// RoCE MTU needs room for the RoCE headers on top of it.
+	MinRoCEMTU int `json:"min_roce_mtu,omitempty" yaml:"min_roce_mtu,omitempty"`
+	// PFCPriority is the priority NCCL traffic is sent with, which needs PFC
+	// on a lossless fabric. A negative priority skips the check on fabrics
+	// relying on ECN only.
+	PFCPriority *int `json:"pfc_priority,omitempty" yaml:"pfc_priority,omitempty"`
+	// RoCEMode is the type of the GIDs NCCL is expected to use.
+	RoCEMode string `json:"roce_mode,omitempty" yaml:"roce_mode,omitempty"`
+}
+
+func (c *NCCLEnvUserConfig) GetQueryInterval() common.Duration {
+	if c.NCCLEnv == nil || c.NCCLEnv.QueryInterval.Duration == 0 {
+		return common.Duration{Duration: 5 * time.Minute}
+	}
+	return c.NCCLEnv.QueryInterval
+}
+
+func (c *NCCLEnvUserConfig) SetQueryInterval(newInterval common.Duration) {
+	if c.NCCLEnv == nil {
+		c.NCCLEnv = &NCCLEnvConfig{}
+	}
+	c.NCCLEnv.QueryInterval = newInterval
+}
+
+// ConfFiles returns NCCLConfFiles, or DefaultNCCLConfFiles if unset.
+func (c *NCCLEnvConfig) ConfFiles() []string {
+	if len(c.NCCLConfFiles) == 0 {
+		return DefaultNCCLConfFiles
+	}
+	return c.NCCLConfFiles
+}
+
+// MTULimit returns MinRoCEMTU, or DefaultMinRoCEMTU if unset.
+func (c *NCCLEnvConfig) MTULimit() int {
+	if c.MinRoCEMTU <= 0 {
+		return DefaultMinRoCEMTU
+	}
+	return c.MinRoCEMTU
+}
+
+// PFCLimit returns PFCPriority, or DefaultPFCPriority if unset.
+func (c *NCCLEnvConfig) PFCLimit() int {
+	if c.PFCPriority == nil {
+		return DefaultPFCPriority
+	}
+	return *c.PFCPriority
+}
+
+// Mode returns RoCEMode, or DefaultRoCEMode if unset.
+func (c *NCCLEnvConfig) Mode() string {
+	if c.RoCEMode == "" {
+		return DefaultRoCEMode
+	}
+	return c.RoCEMode
+}
@@ -0,0 +1,262 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package ncclenv
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/ncclenv/checker"
+	"github.com/scitix/sichek/components/ncclenv/collector"
+	"github.com/scitix/sichek/components/ncclenv/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/scitix/sichek/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+type component struct {
+	ctx           context.Context
+	cancel        context.CancelFunc
+	componentName string
+	cfg           *config.NCCLEnvUserConfig
+	cfgMutex      sync.Mutex
+	collector     *collector.NCCLEnvCollector
+	checkers      []common.Checker
+
+	cacheMtx    sync.RWMutex
+	cacheBuffer []*common.Result
+	cacheInfo   []common.Info
+	currIndex   int64
+	cacheSize   int64
+
+	service *common.CommonService
+}
+
+var (
+	ncclEnvComponent     *component
+	ncclEnvComponentOnce sync.Once
+)
+
+// NewComponent constructs (or returns the previously-constructed) nccl_env
+// component. specFile is ignored — the expectations live in the user config.
+func NewComponent(cfgFile string, specFile string, ignoredCheckers []string) (common.Component, error) {
+	var err error
+	ncclEnvComponentOnce.Do(func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic occurred when create component nccl_env: %v", r)
+			}
+		}()
+		ncclEnvComponent, err = newComponent(cfgFile, ignoredCheckers)
+	})
+	return ncclEnvComponent, err
+}
+
+func newComponent(cfgFile string, ignoredCheckers []string) (comp *component, err error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		if err != nil {
+			cancel()
+		}
+	}()
+
+	cfg := &config.NCCLEnvUserConfig{}
+	if loadErr := common.LoadUserConfig(cfgFile, cfg); loadErr != nil || cfg.NCCLEnv == nil {
+		logrus.WithField("component", "nccl_env").Warnf("get user config failed or nccl_env config is nil, using default config")
+		cfg.NCCLEnv = &config.NCCLEnvConfig{}
+	}
+	if len(ignoredCheckers) > 0 {
+		cfg.NCCLEnv.IgnoredCheckers = ignoredCheckers
+	}
+	if cfg.NCCLEnv.CacheSize <= 0 {
+		cfg.NCCLEnv.CacheSize = 5
+	}
+
+	checkers, err := checker.NewCheckers(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	comp = &component{
+		ctx:           ctx,
+		cancel:        cancel,
+		componentName: consts.ComponentNameNCCLEnv,
+		cfg:           cfg,
+		collector:     collector.NewNCCLEnvCollector(cfg.NCCLEnv),
+		checkers:      checkers,
+		cacheBuffer:   make([]*common.Result, cfg.NCCLEnv.CacheSize),
+		cacheInfo:     make([]common.Info, cfg.NCCLEnv.CacheSize),
+		cacheSize:     cfg.NCCLEnv.CacheSize,
+	}
+	comp.service = common.NewCommonService(ctx, cfg, comp.componentName, comp.GetTimeout(), comp.HealthCheck)
+	return comp, nil
+}
+
+func (c *component) Name() string {
+	return c.componentName
+}
+
+func (c *component) HealthCheck(ctx context.Context) (*common.Result, error) {
+	info, err := common.Collect(ctx, c.componentName, c.collector.Collect)
+	if err != nil {
+		logrus.WithField("component", "nccl_env").Errorf("failed to collect nccl env info: %v", err)
+		return nil, err
+	}
+	result := common.Check(ctx, c.componentName, info, c.checkers)
+
+	c.cacheMtx.Lock()
+	c.cacheBuffer[c.currIndex] = result
+	c.cacheInfo[c.currIndex] = info
+	c.currIndex = (c.currIndex + 1) % c.cacheSize
+	c.cacheMtx.Unlock()
+
+	if result.Status == consts.StatusAbnormal && consts.LevelPriority[result.Level] > consts.LevelPriority[consts.LevelInfo] {
+		logrus.WithField("component", "nccl_env").Errorf("Health Check Failed")
+	} else {
+		logrus.WithField("component", "nccl_env").Infof("Health Check PASSED")
+	}
+	return result, nil
+}
+
+func (c *component) CacheResults() ([]*common.Result, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	return c.cacheBuffer, nil
+}
+
+func (c *component) LastResult() (*common.Result, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	result := c.cacheBuffer[c.currIndex]
+	if c.currIndex == 0 {
+		result = c.cacheBuffer[c.cacheSize-1]
+	}
+	return result, nil
+}
+
+func (c *component) CacheInfos() ([]common.Info, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	return c.cacheInfo, nil
+}
+
+func (c *component) LastInfo() (common.Info, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	var info common.Info
+	if c.currIndex == 0 {
+		info = c.cacheInfo[c.cacheSize-1]
+	} else {
+		info = c.cacheInfo[c.currIndex-1]
+	}
+	return info, nil
+}
+
+func (c *component) Metrics(ctx context.Context, since time.Time) (interface{}, error) {
+	return nil, nil
+}
+
+func (c *component) Start() <-chan *common.Result {
+	return c.service.Start()
+}
+
+func (c *component) Stop() error {
+	return c.service.Stop()
+}
+
+func (c *component) Update(cfg common.ComponentUserConfig) error {
+	c.cfgMutex.Lock()
+	configPointer, ok := cfg.(*config.NCCLEnvUserConfig)
+	if !ok {
+		c.cfgMutex.Unlock()
+		return fmt.Errorf("update wrong config type for nccl_env")
+	}
+	c.cfg = configPointer
+	c.cfgMutex.Unlock()
+	return c.service.Update(cfg)
+}
+
+func (c *component) Status() bool {
+	return c.service.Status()
+}
+
+func (c *component) GetTimeout() time.Duration {
+	return c.cfg.GetQueryInterval().Duration
+}
+
+func (c *component) PrintInfo(info common.Info, result *common.Result, summaryPrint bool) bool {
+	checkAllPassed := true
+	if result.Status == consts.StatusAbnormal && consts.LevelPriority[result.Level] > consts.LevelPriority[consts.LevelInfo] {
+		checkAllPassed = false
+	}
+
+	utils.PrintTitle("NCCL Environment", "-")
+
+	envInfo, ok := info.(*collector.NCCLEnvInfo)
+	if !ok || envInfo == nil {
+		printer.Println("No NCCL environment info available")
+		return checkAllPassed
+	}
+
+	if envInfo.NvidiaGPU {
+		printer.Printf("nvidia_peermem: %t, dma-buf: %t %s\n", envInfo.PeerMemLoaded, envInfo.DMABuf, envInfo.DMABufReason)
+	}
+	keys := make([]string, 0, len(envInfo.Env))
+	for key := range envInfo.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		printer.Printf("%s=%s\n", key, envInfo.Env[key])
+	}
+	printer.Println()
+	if len(envInfo.Ports) == 0 {
+		printer.Println("No RDMA port found")
+	} else {
+		printer.Printf("%-12s %-5s %-10s %-10s %-12s %-6s %-6s\n", "HCA", "Port", "State", "LinkLayer", "NetDev", "MTU", "GIDs")
+	}
+	for _, port := range envInfo.Ports {
+		printer.Printf("%-12s %-5d %-10s %-10s %-12s %-6d %-6d\n", port.IBDev, port.Port, port.State, port.LinkLayer, port.NetDev, port.MTU, len(port.GIDs))
+	}
+	for _, e := range envInfo.Errors {
+		printer.Printf("%s%s%s\n", consts.Yellow, e, consts.Reset)
+	}
+
+	hasErrors := false
+	if result != nil {
+		for _, res := range result.Checkers {
+			if res.Status != consts.StatusNormal && res.Level != consts.LevelInfo {
+				if !hasErrors {
+					printer.Printf("\nErrors Events:\n")
+					hasErrors = true
+				}
+				printer.Printf("\tEvent: %s%s%s -> %s\n", consts.LevelColor(res.Level), res.ErrorName, consts.Reset, res.Detail)
+			}
+		}
+	}
+	if !hasErrors {
+		printer.Printf("\nErrors Events:\n\tNo NCCL Environment Events Detected\n")
+	}
+
+	printer.Println()
+	return checkAllPassed
+}
@@ -73,6 +73,7 @@ func (c *AppClocksChecker) Check(ctx context.Context, data any) (*common.Checker
 			)
 			devicePodName := fmt.Sprintf("%d", device.Index)
 			failedGpuidPodnames = append(failedGpuidPodnames, devicePodName)
+			result.Devices = append(result.Devices, device.DeviceResult())
 		}
 	}
 	if len(gpusAppClocksStatus) > 0 {
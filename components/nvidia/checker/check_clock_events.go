@@ -18,7 +18,9 @@ package checker
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/scitix/sichek/components/common"
 	"github.com/scitix/sichek/components/nvidia/collector"
@@ -55,7 +57,9 @@ func (c *ClockEventsChecker) Check(ctx context.Context, data any) (*common.Check
 	// Check if any critical clock event is engaged in any Nvidia GPU
 	var devClockEvents map[int]string
 	var failedGpuidPodnames []string
-	for _, device := range nvidiaInfo.DevicesInfo {
+	var failedReason []string
+	for i := range nvidiaInfo.DevicesInfo {
+		device := &nvidiaInfo.DevicesInfo[i]
 		if !device.ClockEvents.IsSupported {
 			logrus.WithField("component", "Nvidia-Clock-Events-Checker").Warnf("device is not supported for clock events")
 			return nil, nil
@@ -67,15 +71,19 @@ func (c *ClockEventsChecker) Check(ctx context.Context, data any) (*common.Check
 			devClockEvents[device.Index] = device.ClockEvents.ToString()
 			devicePodName := fmt.Sprintf("%d", device.Index)
 			failedGpuidPodnames = append(failedGpuidPodnames, devicePodName)
+			failedReason = append(failedReason, clockEventsReason(nvidiaInfo, device))
+			deviceResult := device.DeviceResult()
+			deviceResult.Pods = devicePods(nvidiaInfo, device)
+			result.Devices = append(result.Devices, deviceResult)
 		}
 	}
 	if len(devClockEvents) > 0 {
 		logrus.WithFields(logrus.Fields{
-			"checker": c.Name(),
+			"checker":         c.Name(),
 			"critical_events": devClockEvents,
 		}).Errorf("Critical clock events engaged")
 		result.Status = consts.StatusAbnormal
-		result.Detail = fmt.Sprintf("Critical clock events engaged: \n%v", devClockEvents)
+		result.Detail = fmt.Sprintf("Critical clock events engaged: \n%s", strings.Join(failedReason, ""))
 		result.Device = strings.Join(failedGpuidPodnames, ",")
 	} else {
 		result.Status = consts.StatusNormal
@@ -83,3 +91,46 @@ func (c *ClockEventsChecker) Check(ctx context.Context, data any) (*common.Check
 	}
 	return &result, nil
 }
+
+// clockEventsReason describes the critical clock events of a GPU with how long
+// the clocks were held down and the workloads running on it at that moment.
+func clockEventsReason(nvidiaInfo *collector.NvidiaInfo, device *collector.DeviceInfo) string {
+	events := make([]string, 0, len(device.ClockEvents.CriticalClockEvents))
+	for _, event := range device.ClockEvents.CriticalClockEvents {
+		events = append(events, event.Name)
+	}
+	sort.Strings(events)
+	reason := fmt.Sprintf("GPU %d: %s engaged, throttled by thermal for %s and by power for %s since the driver loaded",
+		device.Index, strings.Join(events, ", "),
+		time.Duration(device.ClockEvents.ThermalViolationNs).Round(time.Millisecond),
+		time.Duration(device.ClockEvents.PowerViolationNs).Round(time.Millisecond))
+	if pods := devicePods(nvidiaInfo, device); len(pods) > 0 {
+		reason += fmt.Sprintf(", pods: %s", strings.Join(pods, ","))
+	}
+	if len(device.Processes) > 0 {
+		processes := make([]string, 0, len(device.Processes))
+		for _, process := range device.Processes {
+			processes = append(processes, fmt.Sprintf("%d(%s)", process.PID, process.Name))
+		}
+		reason += fmt.Sprintf(", processes: %s", strings.Join(processes, ","))
+	}
+	return reason + "\n"
+}
+
+// devicePods returns the namespace/name of the pods the GPU is allocated to,
+// or the uid of the pods its processes belong to when no pod mapping exists.
+func devicePods(nvidiaInfo *collector.NvidiaInfo, device *collector.DeviceInfo) []string {
+	if pod, found := nvidiaInfo.DeviceToPodMap[device.UUID]; found && pod != nil {
+		return []string{pod.Namespace + "/" + pod.PodName}
+	}
+	var pods []string
+	seen := make(map[string]bool)
+	for _, process := range device.Processes {
+		if process.PodUID == "" || seen[process.PodUID] {
+			continue
+		}
+		seen[process.PodUID] = true
+		pods = append(pods, process.PodUID)
+	}
+	return pods
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/scitix/sichek/components/nvidia/collector"
+	"github.com/scitix/sichek/components/nvidia/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/k8s"
+)
+
+func TestClockEventsCheckerAttribution(t *testing.T) {
+	chk, err := NewClockEventsChecker(&config.NvidiaSpec{})
+	if err != nil {
+		t.Fatalf("failed to create ClockEventsChecker: %v", err)
+	}
+	powerBrake := collector.CriticalClockEvents[0x80]
+	info := &collector.NvidiaInfo{
+		DeviceToPodMap: map[string]*k8s.PodInfo{"GPU-0": {Namespace: "train", PodName: "job-0"}},
+		DevicesInfo: []collector.DeviceInfo{
+			{
+				Index: 0,
+				UUID:  "GPU-0",
+				ClockEvents: collector.ClockEvents{
+					IsSupported:         true,
+					CriticalClockEvents: []collector.ClockEvent{powerBrake},
+					PowerViolationNs:    12_500_000_000,
+				},
+				Processes: []collector.GPUProcess{{PID: 42, Name: "python"}},
+			},
+			{
+				Index:       1,
+				UUID:        "GPU-1",
+				ClockEvents: collector.ClockEvents{IsSupported: true},
+			},
+		},
+	}
+
+	result, err := chk.Check(context.Background(), info)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != consts.StatusAbnormal || result.Device != "0" {
+		t.Fatalf("expected GPU 0 abnormal, got %s on %q", result.Status, result.Device)
+	}
+	if len(result.Devices) != 1 || len(result.Devices[0].Pods) != 1 || result.Devices[0].Pods[0] != "train/job-0" {
+		t.Errorf("expected GPU 0 attributed to train/job-0, got %+v", result.Devices)
+	}
+	for _, want := range []string{"HW Power Brake Slowdown", "by power for 12.5s", "pods: train/job-0", "42(python)"} {
+		if !strings.Contains(result.Detail, want) {
+			t.Errorf("expected %q in detail, got %s", want, result.Detail)
+		}
+	}
+}
+
+func TestDevicePodsFromProcesses(t *testing.T) {
+	device := &collector.DeviceInfo{
+		UUID: "GPU-0",
+		Processes: []collector.GPUProcess{
+			{PID: 1, PodUID: "pod-a"},
+			{PID: 2, PodUID: "pod-a"},
+			{PID: 3},
+		},
+	}
+	pods := devicePods(&collector.NvidiaInfo{}, device)
+	if len(pods) != 1 || pods[0] != "pod-a" {
+		t.Errorf("expected the pod of the processes, got %v", pods)
+	}
+}
@@ -17,10 +17,15 @@ package checker
 
 import (
 	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/common/remediator"
 	"github.com/scitix/sichek/components/nvidia/config"
 	"github.com/scitix/sichek/consts"
 
@@ -114,7 +119,7 @@ func TestNvPeerMemChecker_Check(t *testing.T) {
 
 	// Run the Check method
 	// Create a new NvPeerMemChecker
-	t.Logf("======test: `do NvPeerMemChecker and expect to load nvidia_peermem`=====")
+	t.Logf("======test: `do NvPeerMemChecker and expect to load nvidia_peermem by remediation`=====")
 	cfg := &config.NvidiaSpec{}
 	checker, err := NewNvPeerMemChecker(cfg)
 	if err != nil {
@@ -125,12 +130,27 @@ func TestNvPeerMemChecker_Check(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	if result.Status != consts.StatusAbnormal || len(result.Remediations) == 0 {
+		t.Fatalf("expected status 'abnormal' with remediations, got %v", result.Status)
+	}
+	result = remediate(t, ctx, result)
 	if result.Status != consts.StatusNormal {
-		t.Errorf("expected status 'normal', got %v", result.Status)
+		t.Errorf("expected status 'normal' after remediation, got %v", result.Status)
 	}
 	t.Logf("result: %+v", result.ToString())
 }
 
+func remediate(t *testing.T, ctx context.Context, result *common.CheckerResult) *common.CheckerResult {
+	r := remediator.New(remediator.Config{Enable: true, AuditLog: filepath.Join(t.TempDir(), "audit.log")})
+	remediated, records := r.Remediate(ctx, &common.Result{Item: "nvidia", Checkers: []*common.CheckerResult{result}})
+	for _, record := range records {
+		if record.Status != remediator.AuditStatusApplied {
+			t.Fatalf("remediation %s of %s failed: %s", record.Action, record.Target, record.Error)
+		}
+	}
+	return remediated.Checkers[0]
+}
+
 func TestPCIeACSChecker_Check(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -167,7 +187,7 @@ func TestPCIeACSChecker_Check(t *testing.T) {
 
 		// Run the Check method
 		// Create a new NvPeerMemChecker
-		t.Logf("======test: `do PCIeACSChecker and expect to disable ACS online`=====")
+		t.Logf("======test: `do PCIeACSChecker and expect to disable ACS by remediation`=====")
 		cfg := &config.NvidiaSpec{}
 		checker, err := NewPCIeACSChecker(cfg)
 		if err != nil {
@@ -178,6 +198,10 @@ func TestPCIeACSChecker_Check(t *testing.T) {
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
+		if len(result.Remediations) != 2 {
+			t.Fatalf("expected 2 remediations, got %d", len(result.Remediations))
+		}
+		result = remediate(t, ctx, result)
 		if result.Status != consts.StatusNormal {
 			t.Errorf("expected status 'normal', got %v", result.Status)
 		}
@@ -199,3 +223,166 @@ func TestPCIeACSChecker_Check(t *testing.T) {
 		t.Fatalf("expected status 'normal', got %v", result.Status)
 	}
 }
+
+// fakeDriver lays out the sysfs, procfs and library dirs of a node whose
+// loaded module is kmod and whose libraries are libs, by dir name.
+func fakeDriver(t *testing.T, kmod string, installed string, libs map[string]string) {
+	t.Helper()
+	root := t.TempDir()
+	oldModuleDir, oldProcVersion, oldLibDirs, oldInstalled, oldDKMS := sysModuleDir, procDriverVersionPath, nvmlLibDirs, installedModuleVersion, dkmsStatus
+	t.Cleanup(func() {
+		sysModuleDir, procDriverVersionPath, nvmlLibDirs, installedModuleVersion, dkmsStatus = oldModuleDir, oldProcVersion, oldLibDirs, oldInstalled, oldDKMS
+	})
+	sysModuleDir = filepath.Join(root, "sys", "module")
+	procDriverVersionPath = filepath.Join(root, "proc", "version")
+	if kmod != "" {
+		writeFile(t, filepath.Join(sysModuleDir, "nvidia", "version"), kmod+"\n")
+	}
+	nvmlLibDirs = nil
+	for dir, version := range libs {
+		libDir := filepath.Join(root, dir)
+		writeFile(t, filepath.Join(libDir, "libnvidia-ml.so."+version), "")
+		if err := os.Symlink("libnvidia-ml.so."+version, filepath.Join(libDir, "libnvidia-ml.so.1")); err != nil {
+			t.Fatal(err)
+		}
+		nvmlLibDirs = append(nvmlLibDirs, libDir)
+	}
+	installedModuleVersion = func(ctx context.Context) (string, error) {
+		if installed == "" {
+			return "", errors.New("modinfo: ERROR: Module nvidia not found.")
+		}
+		return installed, nil
+	}
+	dkmsStatus = func(ctx context.Context) (string, error) {
+		return "nvidia/550.54.15: added", nil
+	}
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNvidiaDriverMismatchChecker_Check(t *testing.T) {
+	tests := []struct {
+		name       string
+		kmod       string
+		installed  string
+		libs       map[string]string
+		wantStatus string
+		wantDetail string
+	}{
+		{
+			name:       "consistent",
+			kmod:       "550.54.15",
+			installed:  "550.54.15",
+			libs:       map[string]string{"lib64": "550.54.15"},
+			wantStatus: consts.StatusNormal,
+		},
+		{
+			name:       "upgraded without reboot",
+			kmod:       "535.129.03",
+			installed:  "550.54.15",
+			libs:       map[string]string{"lib64": "550.54.15"},
+			wantStatus: consts.StatusAbnormal,
+			wantDetail: "driver/library version mismatch",
+		},
+		{
+			name:       "dkms failed after a kernel upgrade",
+			installed:  "",
+			libs:       map[string]string{"lib64": "550.54.15"},
+			wantStatus: consts.StatusAbnormal,
+			wantDetail: "dkms status: nvidia/550.54.15: added",
+		},
+		{
+			name:       "several userspace versions",
+			kmod:       "550.54.15",
+			installed:  "550.54.15",
+			libs:       map[string]string{"lib64": "550.54.15", "lib": "535.129.03"},
+			wantStatus: consts.StatusAbnormal,
+			wantDetail: "several userspace driver versions",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeDriver(t, tt.kmod, tt.installed, tt.libs)
+			checker, err := NewNvidiaDriverMismatchChecker(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := checker.Check(context.Background(), nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Status != tt.wantStatus {
+				t.Fatalf("expected status %s, got %s: %s", tt.wantStatus, result.Status, result.Detail)
+			}
+			if !strings.Contains(result.Detail, tt.wantDetail) {
+				t.Errorf("expected detail to contain %q, got %q", tt.wantDetail, result.Detail)
+			}
+		})
+	}
+}
+
+func TestLoadedModuleVersionFromProc(t *testing.T) {
+	fakeDriver(t, "", "", nil)
+	writeFile(t, procDriverVersionPath, "NVRM version: NVIDIA UNIX x86_64 Kernel Module  550.54.15  Tue Mar  5 22:23:56 UTC 2024\n")
+	if got := loadedModuleVersion(); got != "550.54.15" {
+		t.Errorf("expected 550.54.15, got %q", got)
+	}
+}
+
+func TestKernelTaintChecker_Check(t *testing.T) {
+	tests := []struct {
+		name        string
+		tainted     string
+		moduleTaint string
+		wantStatus  string
+		wantLevel   string
+	}{
+		{name: "proprietary out-of-tree unsigned", tainted: "12289", moduleTaint: "POE", wantStatus: consts.StatusNormal},
+		{name: "warning", tainted: "12801", wantStatus: consts.StatusAbnormal, wantLevel: consts.LevelWarning},
+		{name: "oops", tainted: "12417", wantStatus: consts.StatusAbnormal, wantLevel: consts.LevelCritical},
+		{name: "forced nvidia module", tainted: "12291", moduleTaint: "POEF", wantStatus: consts.StatusAbnormal, wantLevel: consts.LevelWarning},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeDriver(t, "550.54.15", "550.54.15", nil)
+			oldTainted := procTaintedPath
+			t.Cleanup(func() { procTaintedPath = oldTainted })
+			procTaintedPath = filepath.Join(t.TempDir(), "tainted")
+			writeFile(t, procTaintedPath, tt.tainted+"\n")
+			if tt.moduleTaint != "" {
+				writeFile(t, filepath.Join(sysModuleDir, "nvidia", "taint"), tt.moduleTaint+"\n")
+			}
+			checker, err := NewKernelTaintChecker(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := checker.Check(context.Background(), nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Status != tt.wantStatus {
+				t.Fatalf("expected status %s, got %s: %s", tt.wantStatus, result.Status, result.Detail)
+			}
+			if tt.wantLevel != "" && result.Level != tt.wantLevel {
+				t.Errorf("expected level %s, got %s", tt.wantLevel, result.Level)
+			}
+		})
+	}
+}
+
+func TestTaintFlags(t *testing.T) {
+	if got := taintFlags(12289); got != "POE" {
+		t.Errorf("expected POE, got %s", got)
+	}
+	if got := taintFlags(0); got != "none" {
+		t.Errorf("expected none, got %s", got)
+	}
+}
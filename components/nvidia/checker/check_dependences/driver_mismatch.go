@@ -0,0 +1,220 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/nvidia/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/scitix/sichek/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// sysModuleDir and procDriverVersionPath expose the version of the
+	// loaded nvidia kernel module, replaced in tests.
+	sysModuleDir          = "/sys/module"
+	procDriverVersionPath = "/proc/driver/nvidia/version"
+	// nvmlLibDirs are searched for the libnvidia-ml.so.1 of the userspace driver.
+	nvmlLibDirs = []string{"/usr/lib/x86_64-linux-gnu", "/usr/lib/aarch64-linux-gnu", "/usr/lib64", "/usr/lib"}
+	// installedModuleVersion returns the version of the nvidia module
+	// installed for the running kernel, replaced in tests.
+	installedModuleVersion = modinfoVersion
+	// dkmsStatus returns the output of `dkms status nvidia`, replaced in tests.
+	dkmsStatus = func(ctx context.Context) (string, error) {
+		out, err := utils.ExecCommand(ctx, "dkms", "status", "nvidia")
+		return string(out), err
+	}
+
+	// procVersionRegexp matches "NVRM version: NVIDIA UNIX x86_64 Kernel Module  550.54.15  Tue Mar  5 ..."
+	procVersionRegexp = regexp.MustCompile(`Kernel Module\s+(?:for \S+\s+)?(\d+(?:\.\d+)+)`)
+)
+
+// DriverVersions are the versions of the parts of the NVIDIA driver, which
+// must all be the same for NVML to initialize.
+type DriverVersions struct {
+	// KernelModule is the version of the loaded nvidia kernel module.
+	KernelModule string
+	// Installed is the version of the nvidia module installed for the running
+	// kernel, InstalledErr is set when none is, e.g. after a DKMS failure.
+	Installed    string
+	InstalledErr error
+	// Userspace are the versions of libnvidia-ml.so.1 by path.
+	Userspace map[string]string
+}
+
+// NvidiaDriverMismatchChecker reports a node whose loaded nvidia kernel
+// module, module installed for the running kernel and userspace driver
+// library disagree, e.g. a driver upgraded without a reboot, or a kernel
+// upgraded without the DKMS rebuild of the module. NVML fails to initialize
+// with "Driver/library version mismatch" on such a node.
+type NvidiaDriverMismatchChecker struct {
+	name string
+	cfg  *config.NvidiaSpec
+}
+
+func NewNvidiaDriverMismatchChecker(cfg *config.NvidiaSpec) (common.Checker, error) {
+	return &NvidiaDriverMismatchChecker{
+		name: config.DriverMismatchCheckerName,
+		cfg:  cfg,
+	}, nil
+}
+
+func (c *NvidiaDriverMismatchChecker) Name() string {
+	return c.name
+}
+
+func (c *NvidiaDriverMismatchChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	versions := GetDriverVersions(ctx)
+	result := config.GPUCheckItems[config.DriverMismatchCheckerName]
+	result.Curr = fmt.Sprintf("kmod=%s, installed=%s, userspace=%s",
+		orNone(versions.KernelModule), orNone(versions.Installed), orNone(strings.Join(uniqueVersions(versions.Userspace), ",")))
+	reasons := driverMismatchReasons(versions)
+	if len(reasons) > 0 && versions.InstalledErr != nil && versions.KernelModule == "" {
+		// nothing is loaded and nothing is installed for this kernel
+		if out, err := dkmsStatus(ctx); err == nil && strings.TrimSpace(out) != "" {
+			reasons = append(reasons, "dkms status: "+strings.Join(strings.Fields(strings.TrimSpace(out)), " "))
+		}
+	}
+	if len(reasons) == 0 {
+		result.Status = consts.StatusNormal
+		result.Detail = fmt.Sprintf("The nvidia kernel module and userspace driver are both %s", versions.KernelModule)
+		result.Suggestion = ""
+		return &result, nil
+	}
+	logrus.WithField("checker", c.Name()).Errorf("nvidia driver mismatch: %s", strings.Join(reasons, "; "))
+	result.Status = consts.StatusAbnormal
+	result.Detail = strings.Join(reasons, "\n")
+	return &result, nil
+}
+
+// driverMismatchReasons lists what disagrees between the driver versions.
+func driverMismatchReasons(v DriverVersions) []string {
+	var reasons []string
+	userspace := uniqueVersions(v.Userspace)
+	if v.KernelModule == "" {
+		reasons = append(reasons, "the nvidia kernel module is not loaded")
+	}
+	if v.InstalledErr != nil {
+		reasons = append(reasons, fmt.Sprintf("no nvidia kernel module is installed for the running kernel, the DKMS build may have failed: %v", v.InstalledErr))
+	} else if v.KernelModule != "" && v.Installed != v.KernelModule {
+		reasons = append(reasons, fmt.Sprintf("the loaded kernel module %s differs from the installed %s, the driver was upgraded without a reboot", v.KernelModule, v.Installed))
+	}
+	if len(userspace) > 1 {
+		var libs []string
+		for path, version := range v.Userspace {
+			libs = append(libs, fmt.Sprintf("%s -> %s", path, version))
+		}
+		sort.Strings(libs)
+		reasons = append(reasons, fmt.Sprintf("several userspace driver versions are installed: %s", strings.Join(libs, ", ")))
+	}
+	if v.KernelModule != "" && len(userspace) > 0 && !contains(userspace, v.KernelModule) {
+		reasons = append(reasons, fmt.Sprintf("the loaded kernel module %s differs from the userspace driver %s, NVML fails with a driver/library version mismatch", v.KernelModule, strings.Join(userspace, ",")))
+	}
+	return reasons
+}
+
+// GetDriverVersions reads the versions of the loaded kernel module, of the
+// module installed for the running kernel and of the userspace library.
+func GetDriverVersions(ctx context.Context) DriverVersions {
+	versions := DriverVersions{
+		KernelModule: loadedModuleVersion(),
+		Userspace:    userspaceVersions(),
+	}
+	versions.Installed, versions.InstalledErr = installedModuleVersion(ctx)
+	return versions
+}
+
+func loadedModuleVersion() string {
+	if data, err := os.ReadFile(hostfs.Path(sysModuleDir, "nvidia", "version")); err == nil {
+		return strings.TrimSpace(string(data))
+	}
+	if data, err := os.ReadFile(hostfs.Path(procDriverVersionPath)); err == nil {
+		if m := procVersionRegexp.FindStringSubmatch(string(data)); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// userspaceVersions resolves libnvidia-ml.so.1 of each library dir to its
+// versioned file, e.g. libnvidia-ml.so.550.54.15.
+func userspaceVersions() map[string]string {
+	versions := make(map[string]string)
+	seen := make(map[string]bool)
+	for _, dir := range nvmlLibDirs {
+		link := filepath.Join(dir, "libnvidia-ml.so.1")
+		target, err := filepath.EvalSymlinks(link)
+		if err != nil || seen[target] {
+			continue
+		}
+		seen[target] = true
+		version := strings.TrimPrefix(filepath.Base(target), "libnvidia-ml.so.")
+		if version == "1" || version == filepath.Base(target) {
+			continue
+		}
+		versions[link] = version
+	}
+	return versions
+}
+
+func modinfoVersion(ctx context.Context) (string, error) {
+	out, err := utils.ExecCommand(ctx, "modinfo", "-F", "version", "nvidia")
+	if err != nil {
+		return "", fmt.Errorf("modinfo nvidia: %v, %s", err, strings.TrimSpace(string(out)))
+	}
+	version := strings.TrimSpace(string(out))
+	if version == "" {
+		return "", fmt.Errorf("modinfo nvidia has no version")
+	}
+	return version, nil
+}
+
+func uniqueVersions(versions map[string]string) []string {
+	var unique []string
+	for _, version := range versions {
+		if !contains(unique, version) {
+			unique = append(unique, version)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
@@ -19,6 +19,7 @@ import (
 	"context"
 
 	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/common/remediator"
 	"github.com/scitix/sichek/components/nvidia/config"
 	"github.com/scitix/sichek/consts"
 	"github.com/scitix/sichek/pkg/systemd"
@@ -61,6 +62,7 @@ func (c *NVFabricManagerChecker) Check(ctx context.Context, data any) (*common.C
 		result.Detail = "Nvidia FabricManager is not active, please check to restart Nvidia FabricManager"
 		result.Curr = "NotActive"
 		result.Spec = "Active"
+		result.Remediations = append(result.Remediations, remediator.NewRestartServiceAction("nvidia-fabricmanager"))
 	} else {
 		result.Status = consts.StatusNormal
 		result.Curr = "Active"
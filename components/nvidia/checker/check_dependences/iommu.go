@@ -23,6 +23,7 @@ import (
 	"github.com/scitix/sichek/components/common"
 	"github.com/scitix/sichek/components/nvidia/config"
 	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/hostfs"
 	"github.com/sirupsen/logrus"
 )
 
@@ -45,7 +46,7 @@ func (c *IOMMUChecker) Name() string {
 // Check Checks if IOMMU is closed
 func (c *IOMMUChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
 	// checks if IOMMU groups are present in /sys/kernel/iommu_groups
-	const iommuPath = "/sys/kernel/iommu_groups"
+	iommuPath := hostfs.Path("/sys/kernel/iommu_groups")
 
 	// Check if the path exists
 	_, err := os.Stat(iommuPath)
@@ -0,0 +1,145 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/nvidia/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/sirupsen/logrus"
+)
+
+// procTaintedPath is the taint mask of the kernel, replaced in tests.
+var procTaintedPath = "/proc/sys/kernel/tainted"
+
+// kernelTaint is a bit of /proc/sys/kernel/tainted, see
+// https://docs.kernel.org/admin-guide/tainted-kernels.html
+type kernelTaint struct {
+	bit  uint
+	flag byte
+	desc string
+	// critical taints mean the kernel state is no longer trustworthy
+	critical bool
+}
+
+// reportedTaints are the taints caused by an error. The proprietary (P),
+// out-of-tree (O) and unsigned (E) taints of the nvidia module are expected
+// on every GPU node and ignored.
+var reportedTaints = []kernelTaint{
+	{bit: 1, flag: 'F', desc: "a module was force loaded"},
+	{bit: 3, flag: 'R', desc: "a module was force unloaded"},
+	{bit: 4, flag: 'M', desc: "the processor reported a machine check exception", critical: true},
+	{bit: 5, flag: 'B', desc: "a bad page was referenced or some unexpected page flags seen", critical: true},
+	{bit: 7, flag: 'D', desc: "the kernel died recently, i.e. there was an OOPS or BUG", critical: true},
+	{bit: 9, flag: 'W', desc: "the kernel issued a warning"},
+	{bit: 14, flag: 'L', desc: "a soft lockup occurred", critical: true},
+}
+
+// KernelTaintChecker reports the kernel taints caused by an error, and an
+// nvidia module that was force loaded, e.g. over a mismatching kernel.
+type KernelTaintChecker struct {
+	name string
+	cfg  *config.NvidiaSpec
+}
+
+func NewKernelTaintChecker(cfg *config.NvidiaSpec) (common.Checker, error) {
+	return &KernelTaintChecker{
+		name: config.KernelTaintCheckerName,
+		cfg:  cfg,
+	}, nil
+}
+
+func (c *KernelTaintChecker) Name() string {
+	return c.name
+}
+
+func (c *KernelTaintChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	mask, err := readKernelTaint()
+	if err != nil {
+		return nil, err
+	}
+	moduleTaint := readModuleTaint("nvidia")
+
+	result := config.GPUCheckItems[config.KernelTaintCheckerName]
+	result.Curr = fmt.Sprintf("%d (%s)", mask, taintFlags(mask))
+	var reasons []string
+	for _, taint := range reportedTaints {
+		if mask&(1<<taint.bit) == 0 {
+			continue
+		}
+		reasons = append(reasons, fmt.Sprintf("%c: %s", taint.flag, taint.desc))
+		if taint.critical {
+			result.Level = consts.LevelCritical
+		}
+	}
+	if strings.ContainsAny(moduleTaint, "FR") {
+		reasons = append(reasons, fmt.Sprintf("the nvidia module is tainted %s, it was forced over a mismatching kernel", moduleTaint))
+	}
+	if len(reasons) == 0 {
+		result.Status = consts.StatusNormal
+		result.Suggestion = ""
+		return &result, nil
+	}
+	logrus.WithField("checker", c.Name()).Warnf("kernel is tainted: %s", strings.Join(reasons, "; "))
+	result.Status = consts.StatusAbnormal
+	result.Detail = strings.Join(reasons, "\n")
+	return &result, nil
+}
+
+func readKernelTaint() (uint64, error) {
+	data, err := os.ReadFile(hostfs.Path(procTaintedPath))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %v", hostfs.Path(procTaintedPath), err)
+	}
+	mask, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid kernel taint %q: %v", strings.TrimSpace(string(data)), err)
+	}
+	return mask, nil
+}
+
+// readModuleTaint returns the taint flags of a loaded module, e.g. "POE".
+func readModuleTaint(module string) string {
+	data, err := os.ReadFile(filepath.Join(sysModuleDir, module, "taint"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// taintFlags formats the taint mask the way the kernel prints it in an oops,
+// with the flag of each set bit.
+func taintFlags(mask uint64) string {
+	const flags = "PFSRMBUDAWCIOELKXTN"
+	var sb strings.Builder
+	for bit := 0; bit < len(flags); bit++ {
+		if mask&(1<<uint(bit)) != 0 {
+			sb.WriteByte(flags[bit])
+		}
+	}
+	if sb.Len() == 0 {
+		return "none"
+	}
+	return sb.String()
+}
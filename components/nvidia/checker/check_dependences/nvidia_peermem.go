@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/common/remediator"
 	"github.com/scitix/sichek/components/nvidia/config"
 	"github.com/scitix/sichek/consts"
 	"github.com/scitix/sichek/pkg/utils"
@@ -59,21 +60,11 @@ func (c *NvPeerMemChecker) Check(ctx context.Context, data any) (*common.Checker
 	result := config.GPUCheckItems[config.NvPeerMemCheckerName]
 
 	if !usingPeermem {
-		_, err := utils.ExecCommand(ctx, "modprobe", "nvidia_peermem")
-		if err == nil {
-			result.Status = consts.StatusNormal
-			result.Curr = "LoadedOnline"
-			result.Detail = "nvidia_peermem is not loaded. It has been loaded online successfully"
-			result.Suggestion = ""
-		} else {
-			logrus.WithFields(logrus.Fields{
-				"checker": c.Name(),
-				"error": err,
-			}).Errorf("nvidia_peermem is not loaded correctly. Failed to load online")
-			result.Status = consts.StatusAbnormal
-			result.Curr = "NotLoaded"
-			result.Detail = fmt.Sprintf("nvidia_peermem is not loaded correctly. Failed to load nvidia_peermem online: %v", err)
-		}
+		logrus.WithField("checker", c.Name()).Errorf("nvidia_peermem is not loaded correctly")
+		result.Status = consts.StatusAbnormal
+		result.Curr = "NotLoaded"
+		result.Detail = "nvidia_peermem is not loaded correctly"
+		result.Remediations = append(result.Remediations, remediator.NewModprobeAction("nvidia_peermem"))
 	} else {
 		result.Status = consts.StatusNormal
 		result.Curr = "Loaded"
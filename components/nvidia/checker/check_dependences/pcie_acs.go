@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/common/remediator"
 	"github.com/scitix/sichek/components/nvidia/config"
 	"github.com/scitix/sichek/consts"
 	"github.com/scitix/sichek/pkg/utils"
@@ -53,25 +54,19 @@ func (c *PCIeACSChecker) Check(ctx context.Context, data any) (*common.CheckerRe
 	result := config.GPUCheckItems[config.PCIeACSCheckerName]
 
 	if len(enabledACS) > 0 {
-		failedDevices, err := utils.BatchDisableACS(ctx, enabledACS)
-		if err == nil && len(failedDevices) == 0 {
-			result.Status = consts.StatusNormal
-			result.Curr = "DisabledOnline"
-			result.Detail = "Detect Not All PCIe ACS are disabled. They have been disabled online successfully"
-		} else {
-			var failedBDFs []string
-			for _, failedDevice := range failedDevices {
-				failedBDFs = append(failedBDFs, failedDevice.BDF)
-			}
-			result.Device = strings.Join(failedBDFs, ",")
-			logrus.WithFields(logrus.Fields{
-				"checker": c.Name(),
-				"failed_bdfs": failedBDFs,
-			}).Errorf("Not All PCIe ACS are disabled. Failed to disable online")
-			result.Status = consts.StatusAbnormal
-			result.Curr = "NotAllDisabled"
-			result.Detail = fmt.Sprintf("Not All PCIe ACS are disabled. Failed to disable online: %v", failedBDFs)
+		var enabledBDFs []string
+		for _, device := range enabledACS {
+			enabledBDFs = append(enabledBDFs, device.BDF)
+			result.Remediations = append(result.Remediations, remediator.NewDisableACSAction(device.BDF))
 		}
+		result.Device = strings.Join(enabledBDFs, ",")
+		logrus.WithFields(logrus.Fields{
+			"checker":      c.Name(),
+			"enabled_bdfs": enabledBDFs,
+		}).Errorf("Not All PCIe ACS are disabled")
+		result.Status = consts.StatusAbnormal
+		result.Curr = "NotAllDisabled"
+		result.Detail = fmt.Sprintf("Not All PCIe ACS are disabled: %v", enabledBDFs)
 	} else {
 		result.Status = consts.StatusNormal
 		result.Curr = "Disabled"
@@ -0,0 +1,203 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/nvidia/collector"
+	"github.com/scitix/sichek/components/nvidia/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/k8s"
+	"github.com/sirupsen/logrus"
+)
+
+// DevicePluginChecker flags the GPUs the NVIDIA device plugin advertises to
+// Kubernetes but NVML can not access, e.g. a GPU fallen off the bus, which get
+// pods scheduled onto them, and the accessible GPUs it does not advertise.
+type DevicePluginChecker struct {
+	name string
+}
+
+func NewDevicePluginChecker(cfg *config.NvidiaSpec) (common.Checker, error) {
+	return &DevicePluginChecker{
+		name: config.DevicePluginCheckerName,
+	}, nil
+}
+
+func (c *DevicePluginChecker) Name() string {
+	return c.name
+}
+
+func (c *DevicePluginChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	nvidiaInfo, ok := data.(*collector.NvidiaInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid data type, expected NvidiaInfo")
+	}
+
+	result := config.GPUCheckItems[config.DevicePluginCheckerName]
+	dp := nvidiaInfo.DevicePlugin
+	if dp == nil {
+		result.Status = consts.StatusNormal
+		result.Level = consts.LevelInfo
+		result.Curr = NOTSUPPORT
+		result.Detail = fmt.Sprintf("Neither the node %s nor the kubelet device checkpoint is available", k8s.NvidiaGPUResource)
+		result.Suggestion = ""
+		return &result, nil
+	}
+
+	reasons, failedDevices, expected := devicePluginMismatches(nvidiaInfo)
+	result.Spec = fmt.Sprintf("%d %s", expected, k8s.NvidiaGPUResource)
+	result.Curr = "unknown"
+	if dp.Allocatable >= 0 {
+		result.Curr = fmt.Sprintf("%d %s", dp.Allocatable, k8s.NvidiaGPUResource)
+	}
+
+	if len(reasons) > 0 {
+		logrus.WithFields(logrus.Fields{
+			"checker": c.Name(),
+			"devices": failedDevices,
+		}).Errorf("device plugin advertises GPUs inconsistent with NVML")
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(failedDevices, ",")
+		result.Detail = strings.Join(reasons, "")
+	} else {
+		result.Status = consts.StatusNormal
+		result.Suggestion = ""
+		result.Detail = fmt.Sprintf("The device plugin advertises the %d accessible GPUs", expected)
+	}
+	return &result, nil
+}
+
+// devicePluginMismatches compares the GPUs accessible via NVML with the ones
+// registered in the kubelet checkpoint and the allocatable of the node. It
+// returns the reasons, the flagged GPUs and the allocatable expected of the
+// accessible GPUs. A GPU shared by time slicing is registered once per
+// replica as <uuid>::<n>. With the MIG devices advertised instead of the GPUs,
+// the counts are not comparable and only the registered GPUs are checked.
+func devicePluginMismatches(info *collector.NvidiaInfo) ([]string, []string, int) {
+	dp := info.DevicePlugin
+	accessible := make(map[string]int)
+	for _, device := range info.DevicesInfo {
+		if info.GPUAvailability[device.Index] && device.UUID != "" {
+			accessible[device.UUID] = device.Index
+		}
+	}
+	lost := make(map[string]int)
+	for index, uuid := range info.DeviceUUIDs {
+		if _, ok := accessible[uuid]; !ok && uuid != "" {
+			lost[uuid] = index
+		}
+	}
+
+	registered := make(map[string]int)
+	mig := false
+	gpuIDs := 0
+	for _, id := range dp.Registered {
+		if strings.HasPrefix(id, "MIG-") {
+			mig = true
+			continue
+		}
+		uuid, _, _ := strings.Cut(id, "::")
+		registered[uuid]++
+		gpuIDs++
+	}
+	replicas := 1
+	if len(registered) > 0 {
+		replicas = max(1, gpuIDs/len(registered))
+	}
+	expected := len(accessible) * replicas
+
+	var reasons []string
+	failed := make(map[string]bool)
+	if dp.Registered != nil {
+		uuids := make([]string, 0, len(registered))
+		for uuid := range registered {
+			uuids = append(uuids, uuid)
+		}
+		sort.Strings(uuids)
+		for _, uuid := range uuids {
+			if _, ok := accessible[uuid]; ok {
+				continue
+			}
+			gpu := uuid
+			reason := "is not visible via NVML"
+			if index, ok := lost[uuid]; ok {
+				gpu = fmt.Sprintf("%d (%s)", index, uuid)
+				failed[strconv.Itoa(index)] = true
+				reason = "is not accessible via NVML"
+				if err := info.LostGPUErrors[index]; err != "" {
+					reason += ": " + err
+				}
+			} else {
+				failed[uuid] = true
+			}
+			reasons = append(reasons, fmt.Sprintf("GPU %s is advertised by the device plugin but %s%s\n", gpu, reason, allocatedTo(dp, uuid)))
+		}
+		if !mig {
+			indexes := make([]int, 0, len(accessible))
+			for uuid, index := range accessible {
+				if registered[uuid] == 0 {
+					indexes = append(indexes, index)
+				}
+			}
+			sort.Ints(indexes)
+			for _, index := range indexes {
+				reasons = append(reasons, fmt.Sprintf("GPU %d (%s) is accessible via NVML but not registered by the device plugin\n", index, info.DeviceUUIDs[index]))
+				failed[strconv.Itoa(index)] = true
+			}
+		}
+	}
+	if dp.Allocatable >= 0 && !mig {
+		if dp.Allocatable > int64(expected) {
+			reasons = append(reasons, fmt.Sprintf("node allocatable %s is %d, more than the %d of the %d accessible GPUs, pods may be scheduled onto broken GPUs\n",
+				k8s.NvidiaGPUResource, dp.Allocatable, expected, len(accessible)))
+			for _, index := range lost {
+				failed[strconv.Itoa(index)] = true
+			}
+		} else if dp.Allocatable < int64(expected) {
+			reasons = append(reasons, fmt.Sprintf("node allocatable %s is %d, less than the %d of the %d accessible GPUs, the device plugin marks GPUs unhealthy or is not running\n",
+				k8s.NvidiaGPUResource, dp.Allocatable, expected, len(accessible)))
+		}
+	}
+
+	failedDevices := make([]string, 0, len(failed))
+	for device := range failed {
+		failedDevices = append(failedDevices, device)
+	}
+	sort.Strings(failedDevices)
+	return reasons, failedDevices, expected
+}
+
+// allocatedTo describes the containers the replicas of a GPU are allocated to.
+func allocatedTo(dp *collector.DevicePluginInfo, uuid string) string {
+	var containers []string
+	for id, container := range dp.Allocated {
+		if id == uuid || strings.HasPrefix(id, uuid+"::") {
+			containers = append(containers, container)
+		}
+	}
+	if len(containers) == 0 {
+		return ""
+	}
+	sort.Strings(containers)
+	return ", allocated to pod " + strings.Join(containers, ", ")
+}
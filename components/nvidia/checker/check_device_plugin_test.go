@@ -0,0 +1,76 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/scitix/sichek/components/nvidia/collector"
+	"github.com/scitix/sichek/components/nvidia/config"
+	"github.com/scitix/sichek/consts"
+)
+
+func TestDevicePluginChecker(t *testing.T) {
+	chk, err := NewDevicePluginChecker(&config.NvidiaSpec{})
+	if err != nil {
+		t.Fatalf("failed to create DevicePluginChecker: %v", err)
+	}
+	newInfo := func(dp *collector.DevicePluginInfo) *collector.NvidiaInfo {
+		return &collector.NvidiaInfo{
+			DeviceUUIDs:     map[int]string{0: "GPU-0", 1: "GPU-1", 2: "GPU-2"},
+			GPUAvailability: map[int]bool{0: true, 1: true, 2: false},
+			LostGPUErrors:   map[int]string{2: "GPU is lost"},
+			DevicesInfo:     []collector.DeviceInfo{{Index: 0, UUID: "GPU-0"}, {Index: 1, UUID: "GPU-1"}},
+			DevicePlugin:    dp,
+		}
+	}
+
+	cases := map[string]struct {
+		dp         *collector.DevicePluginInfo
+		wantStatus string
+		wantDevice string
+		wantDetail string
+	}{
+		"not kubernetes": {nil, consts.StatusNormal, "", ""},
+		"consistent": {&collector.DevicePluginInfo{Allocatable: 2, Registered: []string{"GPU-0", "GPU-1"}},
+			consts.StatusNormal, "", ""},
+		"time slicing": {&collector.DevicePluginInfo{Allocatable: 4, Registered: []string{"GPU-0::0", "GPU-0::1", "GPU-1::0", "GPU-1::1"}},
+			consts.StatusNormal, "", ""},
+		"lost GPU still advertised": {&collector.DevicePluginInfo{Allocatable: 3, Registered: []string{"GPU-0", "GPU-1", "GPU-2"},
+			Allocated: map[string]string{"GPU-2": "uid-0/trainer"}},
+			consts.StatusAbnormal, "2", "GPU 2 (GPU-2) is advertised by the device plugin but is not accessible via NVML: GPU is lost, allocated to pod uid-0/trainer"},
+		"lost GPU in allocatable only": {&collector.DevicePluginInfo{Allocatable: 3, Capacity: 3},
+			consts.StatusAbnormal, "2", "node allocatable nvidia.com/gpu is 3, more than the 2 of the 2 accessible GPUs"},
+		"GPU not registered": {&collector.DevicePluginInfo{Allocatable: 1, Registered: []string{"GPU-0"}},
+			consts.StatusAbnormal, "1", "GPU 1 (GPU-1) is accessible via NVML but not registered by the device plugin"},
+		"MIG devices": {&collector.DevicePluginInfo{Allocatable: 7, Registered: []string{"MIG-a", "MIG-b"}},
+			consts.StatusNormal, "", ""},
+	}
+	for name, tc := range cases {
+		result, err := chk.Check(context.Background(), newInfo(tc.dp))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if result.Status != tc.wantStatus || result.Device != tc.wantDevice {
+			t.Errorf("%s: expected status %s on %q, got %s on %q (%s)", name, tc.wantStatus, tc.wantDevice, result.Status, result.Device, result.Detail)
+		}
+		if !strings.Contains(result.Detail, tc.wantDetail) {
+			t.Errorf("%s: expected %q in the detail, got %q", name, tc.wantDetail, result.Detail)
+		}
+	}
+}
@@ -67,6 +67,7 @@ func (c *SRAMAggUncorrectableChecker) Check(ctx context.Context, data any) (*com
 				c.cfg.MemoryErrorThreshold.SRAMAggregateUncorrectableErrors)
 			devicePodName := fmt.Sprintf("%d", device.Index)
 			failedGpuidPodnames = append(failedGpuidPodnames, devicePodName)
+			result.Devices = append(result.Devices, device.DeviceResult())
 		}
 	}
 	if len(failedGpuidPodnames) > 0 {
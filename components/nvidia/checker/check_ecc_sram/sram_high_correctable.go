@@ -62,6 +62,7 @@ func (c *SRAMHighcorrectableChecker) Check(ctx context.Context, data any) (*comm
 			}
 			devicePodName := fmt.Sprintf("%d", device.Index)
 			failedGpuidPodnames = append(failedGpuidPodnames, devicePodName)
+			result.Devices = append(result.Devices, device.DeviceResult())
 		}
 
 		if device.MemoryErrors.AggregateECC.SRAM.Corrected > c.cfg.MemoryErrorThreshold.SRAMAggregateCorrectableErrors {
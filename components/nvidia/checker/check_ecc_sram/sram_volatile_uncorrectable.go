@@ -62,6 +62,7 @@ func (c *SRAMVolatileUncorrectableChecker) Check(ctx context.Context, data any)
 			}
 			devicePodName := fmt.Sprintf("%d", device.Index)
 			failedGpuidPodnames = append(failedGpuidPodnames, devicePodName)
+			result.Devices = append(result.Devices, device.DeviceResult())
 			memoryErrorEvents[device.Index] = fmt.Sprintf(
 				"GPU %d:%s SRAM Volatile Uncorrectable Detected\n",
 				device.Index, device.UUID)
@@ -0,0 +1,205 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/nvidia/collector"
+	"github.com/scitix/sichek/components/nvidia/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+// minECCTrendSpan is the history needed before a growth rate is computed, so
+// that a couple of errors right after startup are not extrapolated to a rate.
+const minECCTrendSpan = time.Hour
+
+// ECCTrendHistory keeps the memory error counters of each GPU across health
+// checks. It is owned by the component so that the history survives the
+// checkers being rebuilt on a spec reload.
+type ECCTrendHistory struct {
+	mu   sync.Mutex
+	gpus map[string][]ECCSample
+}
+
+// ECCSample is the memory error counters of a GPU at a point in time.
+type ECCSample struct {
+	Time            time.Time
+	RemappedRows    int
+	SRAMCorrectable uint64
+}
+
+func NewECCTrendHistory() *ECCTrendHistory {
+	return &ECCTrendHistory{gpus: make(map[string][]ECCSample)}
+}
+
+// Observe records the sample of a GPU and returns the oldest sample within
+// window before it. The history of a GPU restarts when its counters go down,
+// e.g. after the aggregate counters were cleared with `nvidia-smi -p 1`.
+func (h *ECCTrendHistory) Observe(uuid string, sample ECCSample, window time.Duration) ECCSample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	samples := h.gpus[uuid]
+	if n := len(samples); n > 0 && (sample.RemappedRows < samples[n-1].RemappedRows || sample.SRAMCorrectable < samples[n-1].SRAMCorrectable) {
+		samples = nil
+	}
+	start := 0
+	for start < len(samples) && sample.Time.Sub(samples[start].Time) > window {
+		start++
+	}
+	samples = append(samples[start:], sample)
+	h.gpus[uuid] = samples
+	return samples[0]
+}
+
+// Prune forgets the GPUs not in present, e.g. a GPU that was replaced, so
+// that the history does not grow with GPUs that no longer exist.
+func (h *ECCTrendHistory) Prune(present []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	keep := make(map[string]bool, len(present))
+	for _, uuid := range present {
+		keep[uuid] = true
+	}
+	for uuid := range h.gpus {
+		if !keep[uuid] {
+			delete(h.gpus, uuid)
+		}
+	}
+}
+
+// ECCTrendChecker predicts GPU failures from the growth rate of the remapped
+// rows and SRAM correctable errors, which the instantaneous thresholds only
+// catch once the GPU is already out of spare rows.
+type ECCTrendChecker struct {
+	name    string
+	cfg     *config.NvidiaSpec
+	history *ECCTrendHistory
+}
+
+func NewECCTrendChecker(cfg *config.NvidiaSpec, history *ECCTrendHistory) (common.Checker, error) {
+	if history == nil {
+		return nil, fmt.Errorf("ECC trend history is required by %s", config.ECCTrendCheckerName)
+	}
+	return &ECCTrendChecker{
+		name:    config.ECCTrendCheckerName,
+		cfg:     cfg,
+		history: history,
+	}, nil
+}
+
+func (c *ECCTrendChecker) Name() string {
+	return c.name
+}
+
+func (c *ECCTrendChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	nvidiaInfo, ok := data.(*collector.NvidiaInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid data type, expected NvidiaInfo")
+	}
+
+	result := config.GPUCheckItems[config.ECCTrendCheckerName]
+	spec := c.cfg.ECCTrend
+	if spec == nil {
+		result.Status = consts.StatusNormal
+		result.Curr = NOTSUPPORT
+		result.Detail = "No ECC trend expected"
+		result.Suggestion = ""
+		return &result, nil
+	}
+	window := spec.Window()
+	result.Spec = fmt.Sprintf("remapped rows <= %g/h, SRAM correctable <= %g/h over %s",
+		spec.MaxRemappedRowsPerHour, spec.MaxSRAMCorrectablePerHour, window)
+
+	now := nvidiaInfo.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	present := make([]string, 0, len(nvidiaInfo.DevicesInfo))
+	var failedReason []string
+	var failedDevices []string
+	for i := range nvidiaInfo.DevicesInfo {
+		device := &nvidiaInfo.DevicesInfo[i]
+		present = append(present, device.UUID)
+		curr := ECCSample{
+			Time:            now,
+			RemappedRows:    device.MemoryErrors.RemappedRows.RemappedDueToCorrectable + device.MemoryErrors.RemappedRows.RemappedDueToUncorrectable,
+			SRAMCorrectable: device.MemoryErrors.AggregateECC.SRAM.Corrected,
+		}
+		oldest := c.history.Observe(device.UUID, curr, window)
+		reasons := eccTrendReasons(oldest, curr, spec)
+		if len(reasons) == 0 {
+			continue
+		}
+		for _, reason := range reasons {
+			failedReason = append(failedReason, fmt.Sprintf("GPU %d: %s\n", device.Index, reason))
+		}
+		failedDevices = append(failedDevices, fmt.Sprintf("%d", device.Index))
+		result.Devices = append(result.Devices, device.DeviceResult())
+	}
+	c.history.Prune(present)
+
+	if len(failedReason) > 0 {
+		logrus.WithFields(logrus.Fields{
+			"checker": c.Name(),
+			"devices": failedDevices,
+		}).Warnf("GPU memory errors grow faster than the spec")
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(failedDevices, ",")
+		result.Curr = fmt.Sprintf("%d GPUs likely to fail", len(failedDevices))
+		result.Detail = strings.Join(failedReason, "")
+	} else {
+		result.Status = consts.StatusNormal
+		result.Curr = "Healthy"
+		result.Suggestion = ""
+		result.Detail = fmt.Sprintf("The memory errors of all %d GPUs grow within %s", len(nvidiaInfo.DevicesInfo), result.Spec)
+	}
+	return &result, nil
+}
+
+// eccTrendReasons compares the growth rates between the oldest sample in the
+// window and the current one against the slopes of the spec.
+func eccTrendReasons(oldest, curr ECCSample, spec *config.ECCTrendSpec) []string {
+	span := curr.Time.Sub(oldest.Time)
+	if span < minECCTrendSpan {
+		return nil
+	}
+	hours := span.Hours()
+	var reasons []string
+	if spec.MaxRemappedRowsPerHour > 0 {
+		rate := float64(curr.RemappedRows-oldest.RemappedRows) / hours
+		if rate > spec.MaxRemappedRowsPerHour {
+			reasons = append(reasons, fmt.Sprintf("remapped rows grew from %d to %d in %s (%.2f/h > %g/h)",
+				oldest.RemappedRows, curr.RemappedRows, span.Round(time.Minute), rate, spec.MaxRemappedRowsPerHour))
+		}
+	}
+	if spec.MaxSRAMCorrectablePerHour > 0 {
+		rate := float64(curr.SRAMCorrectable-oldest.SRAMCorrectable) / hours
+		if rate > spec.MaxSRAMCorrectablePerHour {
+			reasons = append(reasons, fmt.Sprintf("SRAM correctable errors grew from %d to %d in %s (%.2f/h > %g/h)",
+				oldest.SRAMCorrectable, curr.SRAMCorrectable, span.Round(time.Minute), rate, spec.MaxSRAMCorrectablePerHour))
+		}
+	}
+	return reasons
+}
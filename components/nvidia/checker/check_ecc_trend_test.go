@@ -0,0 +1,88 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/nvidia/collector"
+	"github.com/scitix/sichek/components/nvidia/config"
+	"github.com/scitix/sichek/consts"
+)
+
+func TestECCTrendChecker(t *testing.T) {
+	spec := &config.NvidiaSpec{ECCTrend: &config.ECCTrendSpec{WindowHours: 6, MaxRemappedRowsPerHour: 1, MaxSRAMCorrectablePerHour: 10}}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sample := func(at time.Duration, remapped int, sram uint64) *collector.NvidiaInfo {
+		device := collector.DeviceInfo{Index: 0, UUID: "GPU-0"}
+		device.MemoryErrors.RemappedRows.RemappedDueToCorrectable = remapped
+		device.MemoryErrors.AggregateECC.SRAM.Corrected = sram
+		return &collector.NvidiaInfo{Time: start.Add(at), DevicesInfo: []collector.DeviceInfo{device}}
+	}
+
+	cases := map[string]struct {
+		samples    []*collector.NvidiaInfo
+		wantStatus string
+	}{
+		"steady":                   {[]*collector.NvidiaInfo{sample(0, 2, 100), sample(3*time.Hour, 3, 110)}, consts.StatusNormal},
+		"too early to tell":        {[]*collector.NvidiaInfo{sample(0, 0, 0), sample(30*time.Minute, 8, 0)}, consts.StatusNormal},
+		"remapped rows growing":    {[]*collector.NvidiaInfo{sample(0, 0, 0), sample(2*time.Hour, 8, 0)}, consts.StatusAbnormal},
+		"sram correctable growing": {[]*collector.NvidiaInfo{sample(0, 0, 0), sample(2*time.Hour, 0, 100)}, consts.StatusAbnormal},
+		// The burst at the start fell out of the window.
+		"old burst": {[]*collector.NvidiaInfo{sample(0, 0, 0), sample(time.Hour, 20, 0), sample(8*time.Hour, 21, 0)}, consts.StatusNormal},
+		// Cleared counters restart the history instead of producing a negative rate.
+		"counters cleared": {[]*collector.NvidiaInfo{sample(0, 0, 500), sample(2*time.Hour, 0, 0)}, consts.StatusNormal},
+	}
+	for name, tc := range cases {
+		chk, err := NewECCTrendChecker(spec, NewECCTrendHistory())
+		if err != nil {
+			t.Fatalf("failed to create ECCTrendChecker: %v", err)
+		}
+		var status, detail string
+		for _, info := range tc.samples {
+			result, err := chk.Check(context.Background(), info)
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", name, err)
+			}
+			status, detail = result.Status, result.Detail
+		}
+		if status != tc.wantStatus {
+			t.Errorf("%s: expected status %s, got %s (%s)", name, tc.wantStatus, status, detail)
+		}
+	}
+
+	// Without an ECC trend spec the growth is not analyzed.
+	chk, _ := NewECCTrendChecker(&config.NvidiaSpec{}, NewECCTrendHistory())
+	if result, _ := chk.Check(context.Background(), sample(0, 0, 0)); result.Status != consts.StatusNormal || result.Curr != NOTSUPPORT {
+		t.Errorf("expected ECC trend check skipped without spec, got %+v", result)
+	}
+}
+
+func TestECCTrendHistoryPrune(t *testing.T) {
+	history := NewECCTrendHistory()
+	now := time.Now()
+	history.Observe("GPU-0", ECCSample{Time: now}, time.Hour)
+	history.Observe("GPU-1", ECCSample{Time: now}, time.Hour)
+	history.Prune([]string{"GPU-1"})
+	if _, ok := history.gpus["GPU-0"]; ok {
+		t.Errorf("expected GPU-0 pruned from the history")
+	}
+	if _, ok := history.gpus["GPU-1"]; !ok {
+		t.Errorf("expected GPU-1 kept in the history")
+	}
+}
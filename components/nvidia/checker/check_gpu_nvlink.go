@@ -76,6 +76,7 @@ func (c *NvlinkChecker) Check(ctx context.Context, data any) (*common.CheckerRes
 			failedReason = append(failedReason, fmt.Sprintf("GPU %d: NVlinkSupported is `%t`, while expected `%t`\n",
 				device.Index, device.NVLinkStates.NVlinkSupported, c.cfg.Nvlink.NVlinkSupported))
 			failedGpuidPodnames = append(failedGpuidPodnames, devicePodName)
+			result.Devices = append(result.Devices, device.DeviceResult())
 			continue
 		}
 		if device.NVLinkStates.NvlinkNum != c.cfg.Nvlink.NvlinkNum {
@@ -88,6 +89,7 @@ func (c *NvlinkChecker) Check(ctx context.Context, data any) (*common.CheckerRes
 			failedReason = append(failedReason, fmt.Sprintf("GPU %d: NVlinkNum is `%d`, while expected `%d`\n",
 				device.Index, device.NVLinkStates.NvlinkNum, c.cfg.Nvlink.NvlinkNum))
 			failedGpuidPodnames = append(failedGpuidPodnames, devicePodName)
+			result.Devices = append(result.Devices, device.DeviceResult())
 			continue
 		}
 		if !device.NVLinkStates.AllFeatureEnabled {
@@ -104,6 +106,7 @@ func (c *NvlinkChecker) Check(ctx context.Context, data any) (*common.CheckerRes
 			}).Errorf("Not All NVlink Features Are Enabled")
 			failedReason = append(failedReason, fmt.Sprintf("GPU %d: Not All NVlink Features Are Enabled. Disabled links: %v\n", device.Index, disabledLinks))
 			failedGpuidPodnames = append(failedGpuidPodnames, devicePodName)
+			result.Devices = append(result.Devices, device.DeviceResult())
 			continue
 		}
 
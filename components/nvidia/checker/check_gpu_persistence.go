@@ -22,10 +22,10 @@ import (
 	"strings"
 
 	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/common/remediator"
 	"github.com/scitix/sichek/components/nvidia/collector"
 	"github.com/scitix/sichek/components/nvidia/config"
 	"github.com/scitix/sichek/consts"
-	"github.com/scitix/sichek/pkg/utils"
 )
 
 type GpuPersistenceChecker struct {
@@ -61,36 +61,23 @@ func (c *GpuPersistenceChecker) Check(ctx context.Context, data any) (*common.Ch
 
 	// Check if all the Nvidia GPUs have persistence mode enabled
 	var disableGpus []string
-	var failedGpuidPodnames []string
 	for _, device := range nvidiaInfo.DevicesInfo {
 		if device.States.GpuPersistenceM != c.cfg.State.GpuPersistenceM {
-			devicePodName := fmt.Sprintf("%d", device.Index)
-			disableGpus = append(disableGpus, fmt.Sprintf("GPU %d", device.Index))
-			_, err := utils.ExecCommand(ctx, "nvidia-persistenced")
-			if err != nil {
-				result.Detail += fmt.Sprintf("GPU %d:  Failed to enable persistence mode: %s\n", device.Index, err.Error())
-				failedGpuidPodnames = append(failedGpuidPodnames, devicePodName)
-			} else {
-				result.Detail += fmt.Sprintf("GPU %d:  Persistence mode has been enabled\n", device.Index)
-			}
+			disableGpus = append(disableGpus, fmt.Sprintf("%d", device.Index))
+			result.Detail += fmt.Sprintf("GPU %d:  Persistence mode is %s\n", device.Index, device.States.GpuPersistenceM)
+			result.Devices = append(result.Devices, device.DeviceResult())
 		}
 	}
-	result.Status = consts.StatusNormal
 	if len(disableGpus) == 0 {
 		result.Status = consts.StatusNormal
 		result.Detail = "All Nvidia GPUs have persistence mode enabled"
 		result.Curr = "Enabled"
 		result.Suggestion = ""
 	} else {
-		if len(failedGpuidPodnames) == 0 {
-			result.Status = consts.StatusNormal
-			result.Curr = "EnabledOnline"
-			result.Suggestion = ""
-		} else {
-			result.Status = consts.StatusAbnormal
-			result.Curr = "Disabled"
-			result.Device = fmt.Sprintf("%v", strings.Join(failedGpuidPodnames, ","))
-		}
+		result.Status = consts.StatusAbnormal
+		result.Curr = "Disabled"
+		result.Device = strings.Join(disableGpus, ",")
+		result.Remediations = append(result.Remediations, remediator.NewGPUPersistenceAction())
 	}
 	return &result, nil
 }
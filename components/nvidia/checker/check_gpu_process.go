@@ -0,0 +1,124 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/nvidia/collector"
+	"github.com/scitix/sichek/components/nvidia/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+// hostGPUProcesses are the node agents expected to hold a GPU context outside of any pod.
+var hostGPUProcesses = map[string]bool{
+	"nv-hostengine":       true,
+	"dcgm-exporter":       true,
+	"nvidia-persistenced": true,
+	"sichek":              true,
+}
+
+// GPUProcessChecker flags GPUs occupied by processes that belong to no
+// scheduled pod: host processes, processes of deleted pods and processes of
+// pods running on a GPU that is not allocated to any pod. They make the
+// device plugin hand out a busy GPU.
+type GPUProcessChecker struct {
+	name string
+	cfg  *config.NvidiaSpec
+}
+
+func NewGPUProcessChecker(cfg *config.NvidiaSpec) (common.Checker, error) {
+	return &GPUProcessChecker{
+		name: config.GPUProcessCheckerName,
+		cfg:  cfg,
+	}, nil
+}
+
+func (c *GPUProcessChecker) Name() string {
+	return c.name
+}
+
+func (c *GPUProcessChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	nvidiaInfo, ok := data.(*collector.NvidiaInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid data type, expected NvidiaInfo")
+	}
+
+	result := config.GPUCheckItems[config.GPUProcessCheckerName]
+	if nvidiaInfo.DeviceToPodMap == nil {
+		// without the kubelet pod resources the processes cannot be attributed
+		result.Status = consts.StatusNormal
+		result.Curr = NOTSUPPORT
+		result.Detail = "GPU allocation of the pods is not available"
+		result.Suggestion = ""
+		return &result, nil
+	}
+
+	var failedReason []string
+	var failedDevices []string
+	for i := range nvidiaInfo.DevicesInfo {
+		device := &nvidiaInfo.DevicesInfo[i]
+		_, allocated := nvidiaInfo.DeviceToPodMap[device.UUID]
+		var rogue []string
+		for _, process := range device.Processes {
+			if reason := rogueReason(&process, allocated); reason != "" {
+				rogue = append(rogue, fmt.Sprintf("pid %d (%s, %dMiB) %s", process.PID, process.Name, process.UsedMemoryMiB, reason))
+			}
+		}
+		if len(rogue) == 0 {
+			continue
+		}
+		failedReason = append(failedReason, fmt.Sprintf("GPU %d: %s\n", device.Index, strings.Join(rogue, "; ")))
+		failedDevices = append(failedDevices, fmt.Sprintf("%d", device.Index))
+		result.Devices = append(result.Devices, device.DeviceResult())
+	}
+
+	if len(failedReason) > 0 {
+		logrus.WithFields(logrus.Fields{
+			"checker": c.Name(),
+			"devices": failedDevices,
+		}).Warnf("GPUs occupied by rogue processes")
+		result.Status = consts.StatusAbnormal
+		result.Curr = fmt.Sprintf("%d", len(failedDevices))
+		result.Device = strings.Join(failedDevices, ",")
+		result.Detail = strings.Join(failedReason, "")
+	} else {
+		result.Status = consts.StatusNormal
+		result.Curr = "0"
+		result.Suggestion = ""
+	}
+	return &result, nil
+}
+
+// rogueReason returns why the process does not belong to a scheduled pod, or "" if it does.
+func rogueReason(process *collector.GPUProcess, allocated bool) string {
+	if hostGPUProcesses[process.Name] {
+		return ""
+	}
+	switch {
+	case process.PodUID == "":
+		return "runs outside of any pod"
+	case !process.PodKnown:
+		return fmt.Sprintf("belongs to the deleted pod %s", process.PodUID)
+	case !allocated:
+		return fmt.Sprintf("of pod %s uses a GPU not allocated to any pod", process.PodUID)
+	}
+	return ""
+}
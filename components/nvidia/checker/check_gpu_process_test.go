@@ -0,0 +1,73 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/scitix/sichek/components/nvidia/collector"
+	"github.com/scitix/sichek/components/nvidia/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/k8s"
+)
+
+func TestGPUProcessChecker(t *testing.T) {
+	chk, err := NewGPUProcessChecker(&config.NvidiaSpec{})
+	if err != nil {
+		t.Fatalf("failed to create GPUProcessChecker: %v", err)
+	}
+	podUID := "0a1b2c3d-1111-2222-3333-444455556666"
+	newInfo := func(process collector.GPUProcess) *collector.NvidiaInfo {
+		return &collector.NvidiaInfo{
+			DeviceToPodMap: map[string]*k8s.PodInfo{"GPU-0": {Namespace: "train", PodName: "job-0"}},
+			DevicesInfo: []collector.DeviceInfo{
+				{Index: 0, UUID: "GPU-0"},
+				{Index: 1, UUID: "GPU-1", Processes: []collector.GPUProcess{process}},
+			},
+		}
+	}
+
+	cases := map[string]struct {
+		process    collector.GPUProcess
+		wantStatus string
+	}{
+		"host process":       {collector.GPUProcess{PID: 10, Name: "python"}, consts.StatusAbnormal},
+		"host agent":         {collector.GPUProcess{PID: 11, Name: "nv-hostengine"}, consts.StatusNormal},
+		"deleted pod":        {collector.GPUProcess{PID: 12, Name: "python", PodUID: podUID}, consts.StatusAbnormal},
+		"unallocated device": {collector.GPUProcess{PID: 13, Name: "python", PodUID: podUID, PodKnown: true}, consts.StatusAbnormal},
+	}
+	for name, tc := range cases {
+		result, err := chk.Check(context.Background(), newInfo(tc.process))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if result.Status != tc.wantStatus {
+			t.Errorf("%s: expected status %s, got %s (%s)", name, tc.wantStatus, result.Status, result.Detail)
+		}
+		if result.Status == consts.StatusAbnormal && result.Device != "1" {
+			t.Errorf("%s: expected GPU 1 flagged, got %s", name, result.Device)
+		}
+	}
+
+	// A pod process on its allocated GPU is fine.
+	info := newInfo(collector.GPUProcess{})
+	info.DevicesInfo[1].Processes = nil
+	info.DevicesInfo[0].Processes = []collector.GPUProcess{{PID: 14, Name: "python", PodUID: podUID, PodKnown: true}}
+	if result, _ := chk.Check(context.Background(), info); result.Status != consts.StatusNormal {
+		t.Errorf("expected pod process on allocated GPU to pass, got %s", result.Detail)
+	}
+}
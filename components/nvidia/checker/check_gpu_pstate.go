@@ -62,6 +62,7 @@ func (c *GpuPStateChecker) Check(ctx context.Context, data any) (*common.Checker
 			info += fmt.Sprintf("GPU %d: unexpeced pstate P%d, expected pstate P%d\n", device.Index, device.States.GpuPstate, c.cfg.State.GpuPstate)
 			devicePodName := fmt.Sprintf("%d", device.Index)
 			failedGpuidPodnames = append(failedGpuidPodnames, devicePodName)
+			result.Devices = append(result.Devices, device.DeviceResult())
 		}
 	}
 	if len(failedGpuidPodnames) > 0 {
@@ -53,7 +53,7 @@ func (c *HardwareChecker) Check(ctx context.Context, data any) (*common.CheckerR
 	result := config.GPUCheckItems[config.HardwareCheckerName]
 
 	// Check if any Nvidia GPU is lost
-	lostGPUs, lostReasons := c.checkGPUbyIndex(nvidiaInfo)
+	lostGPUs, lostReasons, lostDevices := c.checkGPUbyIndex(nvidiaInfo)
 	lostGPUNums := len(lostGPUs)
 	curGPUNums := c.spec.GpuNums - lostGPUNums
 	if lostGPUNums != 0 {
@@ -61,6 +61,7 @@ func (c *HardwareChecker) Check(ctx context.Context, data any) (*common.CheckerR
 		result.Detail = fmt.Sprintf("Expected GPU number: %d, Current GPU number: %d, Lost GPU: %v\t\t\n%v",
 			c.spec.GpuNums, curGPUNums, lostGPUNums, strings.Join(lostReasons, "\n"))
 		result.Device = strings.Join(lostGPUs, ",")
+		result.Devices = lostDevices
 	} else {
 		result.Status = consts.StatusNormal
 
@@ -69,9 +70,10 @@ func (c *HardwareChecker) Check(ctx context.Context, data any) (*common.CheckerR
 }
 
 // ref. https://docs.nvidia.com/deploy/nvml-api/group__nvmlDeviceQueries.html#group__nvmlDeviceQueries_1g4cc7ff5253d53cc97b1afb606d614888
-func (c *HardwareChecker) checkGPUbyIndex(nvidiaInfo *collector.NvidiaInfo) ([]string, []string) {
+func (c *HardwareChecker) checkGPUbyIndex(nvidiaInfo *collector.NvidiaInfo) ([]string, []string, []*common.DeviceResult) {
 	var lostDeviceIDs []string
 	var lostDeviceIDErrs []string
+	var lostDevices []*common.DeviceResult
 	for index, available := range nvidiaInfo.GPUAvailability {
 		if !available {
 			errMsg := nvidiaInfo.LostGPUErrors[index]
@@ -93,11 +95,16 @@ func (c *HardwareChecker) checkGPUbyIndex(nvidiaInfo *collector.NvidiaInfo) ([]s
 				devicePodName = fmt.Sprintf("%d", index)
 			}
 			lostDeviceIDs = append(lostDeviceIDs, devicePodName)
+			lostDevice := &common.DeviceResult{Index: index}
+			if nvidiaInfo.ValiddeviceUUIDFlag {
+				lostDevice.UUID = nvidiaInfo.DeviceUUIDs[index]
+			}
+			lostDevices = append(lostDevices, lostDevice)
 			logrus.WithFields(logrus.Fields{
 				"checker": c.Name(),
 				"gpu_index": index,
 			}).Errorf("GPU is lost/inaccessible: %s", errMsg)
 		}
 	}
-	return lostDeviceIDs, lostDeviceIDErrs
+	return lostDeviceIDs, lostDeviceIDErrs, lostDevices
 }
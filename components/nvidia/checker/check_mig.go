@@ -0,0 +1,154 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/nvidia/collector"
+	"github.com/scitix/sichek/components/nvidia/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+// MIGChecker flags GPUs whose MIG mode or instance geometry differs from the
+// spec, e.g. a GPU left with a pending mode change or a lost instance, and
+// MIG instances with uncorrectable ECC errors.
+type MIGChecker struct {
+	name string
+	cfg  *config.NvidiaSpec
+}
+
+func NewMIGChecker(cfg *config.NvidiaSpec) (common.Checker, error) {
+	return &MIGChecker{
+		name: config.MIGCheckerName,
+		cfg:  cfg,
+	}, nil
+}
+
+func (c *MIGChecker) Name() string {
+	return c.name
+}
+
+func (c *MIGChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	nvidiaInfo, ok := data.(*collector.NvidiaInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid data type, expected NvidiaInfo")
+	}
+
+	result := config.GPUCheckItems[config.MIGCheckerName]
+	spec := c.cfg.MIG
+	if spec == nil {
+		result.Status = consts.StatusNormal
+		result.Curr = NOTSUPPORT
+		result.Detail = "No MIG layout expected"
+		result.Suggestion = ""
+		return &result, nil
+	}
+	expectedMode := collector.MIGModeDisabled
+	if spec.Enabled {
+		expectedMode = collector.MIGModeEnabled
+	}
+	result.Spec = migLayoutString(expectedMode, spec.Profiles)
+
+	var failedReason []string
+	var failedDevices []string
+	for i := range nvidiaInfo.DevicesInfo {
+		device := &nvidiaInfo.DevicesInfo[i]
+		reasons := checkMIGDevice(&device.MIG, expectedMode, spec)
+		if len(reasons) == 0 {
+			continue
+		}
+		for _, reason := range reasons {
+			failedReason = append(failedReason, fmt.Sprintf("GPU %d: %s\n", device.Index, reason))
+		}
+		failedDevices = append(failedDevices, fmt.Sprintf("%d", device.Index))
+		result.Devices = append(result.Devices, device.DeviceResult())
+	}
+
+	if len(failedReason) > 0 {
+		logrus.WithFields(logrus.Fields{
+			"checker": c.Name(),
+			"devices": failedDevices,
+		}).Errorf("MIG layout mismatch")
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(failedDevices, ",")
+		result.Curr = fmt.Sprintf("%d GPUs mismatch", len(failedDevices))
+		result.Detail = strings.Join(failedReason, "")
+	} else {
+		result.Status = consts.StatusNormal
+		result.Curr = result.Spec
+		result.Suggestion = ""
+		result.Detail = fmt.Sprintf("All %d GPUs match the MIG layout %s", len(nvidiaInfo.DevicesInfo), result.Spec)
+	}
+	return &result, nil
+}
+
+func checkMIGDevice(mig *collector.MIGInfo, expectedMode string, spec *config.MIGSpec) []string {
+	if mig.CurrentMode == collector.MIGModeNotSupported {
+		if spec.Enabled {
+			return []string{"MIG is not supported"}
+		}
+		return nil
+	}
+	var reasons []string
+	if mig.CurrentMode != expectedMode {
+		reasons = append(reasons, fmt.Sprintf("MIG mode is %s, expected %s", mig.CurrentMode, expectedMode))
+	}
+	if mig.PendingMode != "" && mig.PendingMode != mig.CurrentMode {
+		reasons = append(reasons, fmt.Sprintf("MIG mode change to %s is pending a GPU reset", mig.PendingMode))
+	}
+	if !spec.Enabled || !mig.Enabled() {
+		return reasons
+	}
+	if len(spec.Profiles) > 0 {
+		counts := mig.ProfileCounts()
+		if curr := migLayoutString(mig.CurrentMode, counts); curr != migLayoutString(expectedMode, spec.Profiles) {
+			reasons = append(reasons, fmt.Sprintf("MIG instances are %s, expected %s", curr, migLayoutString(expectedMode, spec.Profiles)))
+		}
+	}
+	for _, instance := range mig.Instances {
+		if instance.ECCUncorrectable > 0 {
+			reasons = append(reasons, fmt.Sprintf("MIG instance %s (GI %d/CI %d) has %d volatile uncorrectable ECC errors",
+				instance.Profile, instance.GPUInstanceID, instance.ComputeInstanceID, instance.ECCUncorrectable))
+		}
+	}
+	return reasons
+}
+
+// migLayoutString renders the mode and profile counts in a stable order,
+// e.g. "Enabled[1g.10gb:3,3g.40gb:1]".
+func migLayoutString(mode string, profiles map[string]int) string {
+	if len(profiles) == 0 || mode != collector.MIGModeEnabled {
+		return mode
+	}
+	names := make([]string, 0, len(profiles))
+	for name, count := range profiles {
+		if count > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s:%d", name, profiles[name]))
+	}
+	return fmt.Sprintf("%s[%s]", mode, strings.Join(parts, ","))
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/scitix/sichek/components/nvidia/collector"
+	"github.com/scitix/sichek/components/nvidia/config"
+	"github.com/scitix/sichek/consts"
+)
+
+func TestMIGChecker(t *testing.T) {
+	spec := &config.NvidiaSpec{MIG: &config.MIGSpec{Enabled: true, Profiles: map[string]int{"3g.40gb": 2}}}
+	chk, err := NewMIGChecker(spec)
+	if err != nil {
+		t.Fatalf("failed to create MIGChecker: %v", err)
+	}
+	healthy := func() *collector.NvidiaInfo {
+		return &collector.NvidiaInfo{DevicesInfo: []collector.DeviceInfo{{
+			Index: 0,
+			MIG: collector.MIGInfo{
+				CurrentMode: collector.MIGModeEnabled,
+				PendingMode: collector.MIGModeEnabled,
+				Instances: []collector.MIGInstance{
+					{GPUInstanceID: 1, Profile: "3g.40gb"},
+					{GPUInstanceID: 2, Profile: "3g.40gb"},
+				},
+			},
+		}}}
+	}
+
+	cases := map[string]struct {
+		mutate     func(*collector.MIGInfo)
+		wantStatus string
+	}{
+		"healthy": {func(*collector.MIGInfo) {}, consts.StatusNormal},
+		"mig disabled": {func(m *collector.MIGInfo) {
+			m.CurrentMode, m.PendingMode, m.Instances = collector.MIGModeDisabled, collector.MIGModeDisabled, nil
+		}, consts.StatusAbnormal},
+		"pending reset":    {func(m *collector.MIGInfo) { m.PendingMode = collector.MIGModeDisabled }, consts.StatusAbnormal},
+		"instance lost":    {func(m *collector.MIGInfo) { m.Instances = m.Instances[:1] }, consts.StatusAbnormal},
+		"wrong profile":    {func(m *collector.MIGInfo) { m.Instances[1].Profile = "4g.40gb" }, consts.StatusAbnormal},
+		"uncorrectable":    {func(m *collector.MIGInfo) { m.Instances[0].ECCUncorrectable = 1 }, consts.StatusAbnormal},
+		"mig not possible": {func(m *collector.MIGInfo) { m.CurrentMode, m.Instances = collector.MIGModeNotSupported, nil }, consts.StatusAbnormal},
+	}
+	for name, tc := range cases {
+		info := healthy()
+		tc.mutate(&info.DevicesInfo[0].MIG)
+		result, err := chk.Check(context.Background(), info)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if result.Status != tc.wantStatus {
+			t.Errorf("%s: expected status %s, got %s (%s)", name, tc.wantStatus, result.Status, result.Detail)
+		}
+	}
+
+	// Without a MIG spec the geometry is not checked.
+	chk, _ = NewMIGChecker(&config.NvidiaSpec{})
+	info := healthy()
+	info.DevicesInfo[0].MIG.Instances = nil
+	if result, _ := chk.Check(context.Background(), info); result.Status != consts.StatusNormal || result.Curr != NOTSUPPORT {
+		t.Errorf("expected MIG check skipped without spec, got %+v", result)
+	}
+}
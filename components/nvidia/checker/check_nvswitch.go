@@ -0,0 +1,129 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/nvidia/collector"
+	"github.com/scitix/sichek/components/nvidia/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+// NVSwitchChecker catches a degraded NVLink fabric on HGX systems that the
+// per GPU nvlink checker misses: a lost NVSwitch, a switch with all its GPU
+// links down, GPUs not registered with the fabric and fabricmanager errors.
+type NVSwitchChecker struct {
+	name string
+	cfg  *config.NvidiaSpec
+}
+
+func NewNVSwitchChecker(cfg *config.NvidiaSpec) (common.Checker, error) {
+	return &NVSwitchChecker{
+		name: config.NVSwitchCheckerName,
+		cfg:  cfg,
+	}, nil
+}
+
+func (c *NVSwitchChecker) Name() string {
+	return c.name
+}
+
+func (c *NVSwitchChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	nvidiaInfo, ok := data.(*collector.NvidiaInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid data type, expected NvidiaInfo")
+	}
+
+	result := config.GPUCheckItems[config.NVSwitchCheckerName]
+	spec := c.cfg.NVSwitch
+	if spec == nil || spec.SwitchNum == 0 {
+		result.Status = consts.StatusNormal
+		result.Curr = NOTSUPPORT
+		result.Detail = "No NVSwitch expected"
+		result.Suggestion = ""
+		return &result, nil
+	}
+	result.Spec = fmt.Sprintf("%d", spec.SwitchNum)
+	info := nvidiaInfo.NVSwitchInfo
+	if info == nil {
+		info = &collector.NVSwitchInfo{}
+	}
+
+	var failedReason []string
+	var failedDevices []string
+	if info.PCISwitchCount < spec.SwitchNum {
+		failedReason = append(failedReason, fmt.Sprintf("found %d NVSwitches on PCI bus, while expected %d\n", info.PCISwitchCount, spec.SwitchNum))
+	}
+	if info.PCISwitchCount > 0 && len(info.Switches) < spec.SwitchNum {
+		failedReason = append(failedReason, fmt.Sprintf("only %d NVSwitches are reachable through GPU NVLinks, while expected %d\n", len(info.Switches), spec.SwitchNum))
+	}
+	for _, sw := range info.Switches {
+		if sw.InactiveLinks > 0 {
+			failedReason = append(failedReason, fmt.Sprintf("NVSwitch %s: %d GPU links inactive\n", sw.BusID, sw.InactiveLinks))
+			failedDevices = append(failedDevices, sw.BusID)
+			continue
+		}
+		if sw.ReplayErrors > spec.LinkErrorThreshold || sw.RecoveryErrors > spec.LinkErrorThreshold || sw.CRCErrors > spec.LinkErrorThreshold {
+			failedReason = append(failedReason, fmt.Sprintf("NVSwitch %s: link errors replay=%d recovery=%d crc=%d exceed threshold %d\n",
+				sw.BusID, sw.ReplayErrors, sw.RecoveryErrors, sw.CRCErrors, spec.LinkErrorThreshold))
+			failedDevices = append(failedDevices, sw.BusID)
+		}
+	}
+	for _, gpu := range info.GPUFabric {
+		if gpu.State == "Not Supported" {
+			continue
+		}
+		if gpu.State != "Completed" || gpu.Status != "SUCCESS" {
+			failedReason = append(failedReason, fmt.Sprintf("GPU %d: fabric state `%s`, status `%s`\n", gpu.Index, gpu.State, gpu.Status))
+			failedDevices = append(failedDevices, fmt.Sprintf("%d", gpu.Index))
+			result.Devices = append(result.Devices, gpuDeviceResult(nvidiaInfo, gpu.Index))
+		}
+	}
+	if len(info.FabricManagerErrors) > 0 {
+		failedReason = append(failedReason, fmt.Sprintf("fabricmanager logged %d errors since start, last: %s\n",
+			len(info.FabricManagerErrors), info.FabricManagerErrors[len(info.FabricManagerErrors)-1]))
+	}
+
+	result.Curr = fmt.Sprintf("%d", len(info.Switches))
+	if len(failedReason) > 0 {
+		logrus.WithFields(logrus.Fields{
+			"checker": c.Name(),
+			"reasons": len(failedReason),
+		}).Errorf("NVSwitch fabric degraded")
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(failedDevices, ",")
+		result.Detail = strings.Join(failedReason, "")
+	} else {
+		result.Status = consts.StatusNormal
+		result.Suggestion = ""
+		result.Detail = fmt.Sprintf("All %d NVSwitches are healthy", len(info.Switches))
+	}
+	return &result, nil
+}
+
+func gpuDeviceResult(nvidiaInfo *collector.NvidiaInfo, index int) *common.DeviceResult {
+	for i := range nvidiaInfo.DevicesInfo {
+		if nvidiaInfo.DevicesInfo[i].Index == index {
+			return nvidiaInfo.DevicesInfo[i].DeviceResult()
+		}
+	}
+	return &common.DeviceResult{Index: index}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/scitix/sichek/components/nvidia/collector"
+	"github.com/scitix/sichek/components/nvidia/config"
+	"github.com/scitix/sichek/consts"
+)
+
+func TestNVSwitchChecker_Degraded(t *testing.T) {
+	spec := &config.NvidiaSpec{NVSwitch: &config.NVSwitchSpec{SwitchNum: 4}}
+	chk, err := NewNVSwitchChecker(spec)
+	if err != nil {
+		t.Fatalf("failed to create NVSwitchChecker: %v", err)
+	}
+	healthy := func() *collector.NvidiaInfo {
+		info := &collector.NvidiaInfo{NVSwitchInfo: &collector.NVSwitchInfo{PCISwitchCount: 4}}
+		for _, busID := range []string{"00000000:05:00.0", "00000000:06:00.0", "00000000:07:00.0", "00000000:08:00.0"} {
+			info.NVSwitchInfo.Switches = append(info.NVSwitchInfo.Switches, collector.NVSwitchState{BusID: busID, ActiveLinks: 36})
+		}
+		info.NVSwitchInfo.GPUFabric = []collector.GPUFabricState{{Index: 0, State: "Completed", Status: "SUCCESS"}}
+		return info
+	}
+
+	cases := map[string]struct {
+		mutate     func(*collector.NVSwitchInfo)
+		wantStatus string
+	}{
+		"healthy":           {func(*collector.NVSwitchInfo) {}, consts.StatusNormal},
+		"switch lost":       {func(i *collector.NVSwitchInfo) { i.PCISwitchCount = 3 }, consts.StatusAbnormal},
+		"switch unreached":  {func(i *collector.NVSwitchInfo) { i.Switches = i.Switches[:3] }, consts.StatusAbnormal},
+		"inactive links":    {func(i *collector.NVSwitchInfo) { i.Switches[1].InactiveLinks = 2 }, consts.StatusAbnormal},
+		"recovery errors":   {func(i *collector.NVSwitchInfo) { i.Switches[2].RecoveryErrors = 1 }, consts.StatusAbnormal},
+		"fabric not done":   {func(i *collector.NVSwitchInfo) { i.GPUFabric[0].State = "In Progress" }, consts.StatusAbnormal},
+		"fabricmanager err": {func(i *collector.NVSwitchInfo) { i.FabricManagerErrors = []string{"[ERROR] trunk link down"} }, consts.StatusAbnormal},
+	}
+	for name, tc := range cases {
+		info := healthy()
+		tc.mutate(info.NVSwitchInfo)
+		result, err := chk.Check(context.Background(), info)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if result.Status != tc.wantStatus {
+			t.Errorf("%s: expected status %s, got %s (%s)", name, tc.wantStatus, result.Status, result.Detail)
+		}
+	}
+}
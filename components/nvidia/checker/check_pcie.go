@@ -86,6 +86,7 @@ func (c *PCIeChecker) Check(ctx context.Context, data any) (*common.CheckerResul
 		if device.PCIeInfo.PCILinkGen != device.PCIeInfo.PCILinkGenMAX || device.PCIeInfo.PCILinkWidth != device.PCIeInfo.PCILinkWidthMAX {
 			devicePodName := fmt.Sprintf("%d", device.Index)
 			failedGpuidPodnames = append(failedGpuidPodnames, devicePodName)
+			result.Devices = append(result.Devices, device.DeviceResult())
 		}
 	}
 	if result.Status == consts.StatusAbnormal {
@@ -58,6 +58,7 @@ func (c *RemmapedRowsFailureChecker) Check(ctx context.Context, data any) (*comm
 		if device.MemoryErrors.RemappedRows.RemappingFailureOccurred {
 			devicePodName := fmt.Sprintf("%d", device.Index)
 			failedGpuidPodnames = append(failedGpuidPodnames, devicePodName)
+			result.Devices = append(result.Devices, device.DeviceResult())
 			failedGpus = append(failedGpus, fmt.Sprintf("%d:%s", device.Index, device.UUID))
 		}
 	}
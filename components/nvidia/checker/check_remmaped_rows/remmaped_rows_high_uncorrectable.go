@@ -66,6 +66,7 @@ func (c *RemmapedRowsUncorrectableChecker) Check(ctx context.Context, data any)
 				c.cfg.MemoryErrorThreshold.RemappedUncorrectableErrors)
 			devicePodName := fmt.Sprintf("%d", device.Index)
 			failedGpuidPodnames = append(failedGpuidPodnames, devicePodName)
+			result.Devices = append(result.Devices, device.DeviceResult())
 		}
 	}
 	if len(failedGpuidPodnames) > 0 {
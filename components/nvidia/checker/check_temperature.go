@@ -72,6 +72,7 @@ func (c *GpuTemperatureChecker) Check(ctx context.Context, data any) (*common.Ch
 			)
 			devicePodName := fmt.Sprintf("%d", device.Index)
 			failedGpuidPodnames = append(failedGpuidPodnames, devicePodName)
+			result.Devices = append(result.Devices, device.DeviceResult())
 		}
 	}
 	if len(unexpectedGpusTemp) > 0 {
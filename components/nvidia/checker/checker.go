@@ -22,18 +22,25 @@ import (
 	remap "github.com/scitix/sichek/components/nvidia/checker/check_remmaped_rows"
 	"github.com/scitix/sichek/components/nvidia/config"
 	nvutils "github.com/scitix/sichek/components/nvidia/utils" // Added import
+	"github.com/scitix/sichek/pkg/capability"
 
 	"github.com/sirupsen/logrus"
 )
 
-func NewCheckers(nvidiaCfg *config.NvidiaUserConfig, nvidiaSpecCfg *config.NvidiaSpec) ([]common.Checker, error) {
+func NewCheckers(nvidiaCfg *config.NvidiaUserConfig, nvidiaSpecCfg *config.NvidiaSpec, eccHistory *ECCTrendHistory) ([]common.Checker, error) {
 	checkerConstructors := map[string]func(*config.NvidiaSpec) (common.Checker, error){
 		config.PCIeACSCheckerName:                   dependence.NewPCIeACSChecker,
 		config.IOMMUCheckerName:                     dependence.NewIOMMUChecker,
 		config.NVFabricManagerCheckerName:           dependence.NewNVFabricManagerChecker,
 		config.NvPeerMemCheckerName:                 dependence.NewNvPeerMemChecker,
+		config.DriverMismatchCheckerName:            dependence.NewNvidiaDriverMismatchChecker,
+		config.KernelTaintCheckerName:               dependence.NewKernelTaintChecker,
 		config.IBGDACheckerName:                     NewIBGDAChecker,
 		config.P2PCheckerName:                       NewP2PChecker,
+		config.NVSwitchCheckerName:                  NewNVSwitchChecker,
+		config.MIGCheckerName:                       NewMIGChecker,
+		config.DevicePluginCheckerName:              NewDevicePluginChecker,
+		config.GPUProcessCheckerName:                NewGPUProcessChecker,
 		config.PCIeCheckerName:                      NewPCIeChecker,
 		config.HardwareCheckerName:                  NewHardwareChecker,
 		config.SoftwareCheckerName:                  NewSoftwareChecker,
@@ -48,6 +55,14 @@ func NewCheckers(nvidiaCfg *config.NvidiaUserConfig, nvidiaSpecCfg *config.Nvidi
 		config.RemmapedRowsFailureCheckerName:       remap.NewRemmapedRowsFailureChecker,
 		config.RemmapedRowsUncorrectableCheckerName: remap.NewRemmapedRowsUncorrectableChecker,
 		config.RemmapedRowsPendingCheckerName:       remap.NewRemmapedRowsPendingChecker,
+		config.ECCTrendCheckerName: func(spec *config.NvidiaSpec) (common.Checker, error) {
+			return NewECCTrendChecker(spec, eccHistory)
+		},
+	}
+
+	// privilegedCheckers are reported as skipped when sichek runs without the capabilities they need
+	privilegedCheckers := map[string][]capability.Capability{
+		config.PCIeACSCheckerName: {capability.PCIConfig},
 	}
 
 	ignoredSet := make(map[string]struct{})
@@ -86,6 +101,9 @@ func NewCheckers(nvidiaCfg *config.NvidiaUserConfig, nvidiaSpecCfg *config.Nvidi
 				logrus.WithError(err).WithField("checker", checkerName).Error("Failed to create checker")
 				continue
 			}
+			if caps, ok := privilegedCheckers[checkerName]; ok {
+				checker = common.RequireCapabilities(checker, caps...)
+			}
 			usedCheckers = append(usedCheckers, checker)
 			usedCheckersName = append(usedCheckersName, checkerName)
 		}
@@ -364,7 +364,7 @@ func TestSoftwareChecker_Check(t *testing.T) {
 
 func TestChecker_Check(t *testing.T) {
 	// Create a new SoftwareChecker
-	checkers, err := NewCheckers(&nvidiaUserCfg, nvidiaSpecCfg)
+	checkers, err := NewCheckers(&nvidiaUserCfg, nvidiaSpecCfg, NewECCTrendHistory())
 	if err != nil {
 		t.Fatalf("failed to create Checkers: %v", err)
 	}
@@ -84,6 +84,10 @@ type ClockEvents struct {
 	GpuIdle             bool         `json:"gpu_idle" yaml:"gpu_idle"`
 	CriticalClockEvents []ClockEvent `json:"critical_clock_events" yaml:"critical_clock_events"`
 	WarningClockEvents  []ClockEvent `json:"warning_clock_events" yaml:"warning_clock_events"`
+	// ThermalViolationNs and PowerViolationNs are the accumulated time the clocks
+	// were held down by the thermal and power policies since the driver loaded.
+	ThermalViolationNs uint64 `json:"thermal_violation_ns" yaml:"thermal_violation_ns"`
+	PowerViolationNs   uint64 `json:"power_violation_ns" yaml:"power_violation_ns"`
 }
 
 func (clk *ClockEvents) JSON() ([]byte, error) {
@@ -123,5 +127,12 @@ func (clk *ClockEvents) Get(device nvml.Device, uuid string) error {
 		}
 	}
 
+	if violation, ret := device.GetViolationStatus(nvml.PERF_POLICY_THERMAL); errors.Is(ret, nvml.SUCCESS) {
+		clk.ThermalViolationNs = violation.ViolationTime
+	}
+	if violation, ret := device.GetViolationStatus(nvml.PERF_POLICY_POWER); errors.Is(ret, nvml.SUCCESS) {
+		clk.PowerViolationNs = violation.ViolationTime
+	}
+
 	return nil
 }
@@ -27,6 +27,7 @@ import (
 
 	"github.com/scitix/sichek/components/common"
 	"github.com/scitix/sichek/components/nvidia/utils"
+	"github.com/scitix/sichek/pkg/hostfs"
 	"github.com/scitix/sichek/pkg/k8s"
 
 	"github.com/NVIDIA/go-nvml/pkg/nvml"
@@ -43,6 +44,8 @@ type NvidiaCollector struct {
 	DeviceUUIDs       map[int]string
 	nvmlInst          *nvml.Interface // Shared pointer to NVML instance
 	podResourceMapper *k8s.PodResourceMapper
+	// energy attributes the GPU energy consumption to pods across collections
+	energy *EnergyAccountant
 }
 
 func NewNvidiaCollector(ctx context.Context, nvmlInstPtr *nvml.Interface, expectedDeviceCount int, expectedDeviceName string) (*NvidiaCollector, error) {
@@ -52,7 +55,7 @@ func NewNvidiaCollector(ctx context.Context, nvmlInstPtr *nvml.Interface, expect
 		logrus.WithField("component", "NVIDIA-Collector").Errorf("%v", err)
 		return nil, err
 	}
-	collector := &NvidiaCollector{nvmlInst: nvmlInstPtr, podResourceMapper: podResourceMapper}
+	collector := &NvidiaCollector{nvmlInst: nvmlInstPtr, podResourceMapper: podResourceMapper, energy: NewEnergyAccountant()}
 	var err error
 	for i := 0; i < expectedDeviceCount; i++ {
 		err = collector.softwareInfo.Get(ctx, i)
@@ -147,6 +150,7 @@ func (collector *NvidiaCollector) Collect(ctx context.Context) (*NvidiaInfo, err
 		IbgdaEnable:         collector.getDriverParams(),
 		IbgdaConfigCount:    collector.getIBGDAConfigCount(),
 		P2PStatusMatrix:     collector.getP2PStatusMatrix(),
+		NVSwitchInfo:        collector.getNVSwitchInfo(),
 	}
 
 	// Get the number of devices
@@ -200,12 +204,14 @@ func (collector *NvidiaCollector) Collect(ctx context.Context) (*NvidiaInfo, err
 		logrus.WithField("component", "NVIDIA-Collector").Errorf("failed to get device to pod map: %v", err2)
 	}
 	nvidia.DeviceToPodMap = deviceToPodMap
+	nvidia.Energy = collector.energy.Account(nvidia.Time, nvidia.DevicesInfo, deviceToPodMap)
+	nvidia.DevicePlugin = getDevicePluginInfo(ctx)
 	return nvidia, nil
 }
 
 func (collector *NvidiaCollector) getDriverParams() map[string]string {
 	params := make(map[string]string)
-	path := "/proc/driver/nvidia/params"
+	path := hostfs.Path("/proc/driver/nvidia/params")
 
 	file, err := os.Open(path)
 	if err != nil {
@@ -42,7 +42,9 @@ type DeviceInfo struct {
 	Utilization   UtilizationInfo `json:"utilization_info" yaml:"utilization_info"`
 	NVLinkStates  NVLinkStates    `json:"nvlink_state" yaml:"nvlink_state"`
 	MemoryErrors  MemoryErrors    `json:"ecc_event" yaml:"ecc_event"`
+	MIG           MIGInfo         `json:"mig_info" yaml:"mig_info"`
 	NProcess      int             `json:"nprocess" yaml:"nprocess"`
+	Processes     []GPUProcess    `json:"processes,omitempty" yaml:"processes,omitempty"`
 	PartialErrors []string        `json:"partial_errors,omitempty" yaml:"partial_errors,omitempty"`
 }
 
@@ -55,6 +57,15 @@ func (deviceInfo *DeviceInfo) ToString() string {
 	return common.ToString(deviceInfo)
 }
 
+// DeviceResult identifies the device in the results of the checkers.
+func (deviceInfo *DeviceInfo) DeviceResult() *common.DeviceResult {
+	return &common.DeviceResult{
+		Index: deviceInfo.Index,
+		UUID:  deviceInfo.UUID,
+		BDF:   deviceInfo.PCIeInfo.BDFID,
+	}
+}
+
 func (deviceInfo *DeviceInfo) Get(device nvml.Device, index int, driverVersion string) error {
 	deviceInfo.PartialErrors = make([]string, 0)
 
@@ -145,10 +156,18 @@ func (deviceInfo *DeviceInfo) Get(device nvml.Device, index int, driverVersion s
 		}
 	}
 
-	// Get Utilization info
-	err2 = deviceInfo.Utilization.Get(device, uuid)
+	// Get MIG info
+	err2 = deviceInfo.MIG.Get(device, uuid)
 	if err2 != nil {
-		deviceInfo.PartialErrors = append(deviceInfo.PartialErrors, fmt.Sprintf("failed to get utilization info: %v", err2))
+		deviceInfo.PartialErrors = append(deviceInfo.PartialErrors, fmt.Sprintf("failed to get MIG info: %v", err2))
+	}
+
+	// Get Utilization info, not reported by the parent GPU in MIG mode
+	if !deviceInfo.MIG.Enabled() {
+		err2 = deviceInfo.Utilization.Get(device, uuid)
+		if err2 != nil {
+			deviceInfo.PartialErrors = append(deviceInfo.PartialErrors, fmt.Sprintf("failed to get utilization info: %v", err2))
+		}
 	}
 
 	// Get MemoryErrors info
@@ -165,13 +184,20 @@ func (deviceInfo *DeviceInfo) Get(device nvml.Device, index int, driverVersion s
 		deviceInfo.PartialErrors = append(deviceInfo.PartialErrors, fmt.Sprintf("failed to get nvlink states: %v", err2))
 	}
 
-	// Get the number of processes using the GPU
-	processInfo, err := device.GetComputeRunningProcesses()
-	if !errors.Is(err, nvml.SUCCESS) {
+	// Get the number of processes using the GPU, in MIG mode they run on the instances
+	if deviceInfo.MIG.Enabled() {
+		deviceInfo.NProcess = 0
+		deviceInfo.Processes = nil
+		for _, instance := range deviceInfo.MIG.Instances {
+			deviceInfo.NProcess += instance.NProcess
+			deviceInfo.Processes = append(deviceInfo.Processes, instance.Processes...)
+		}
+	} else if processInfo, err := device.GetComputeRunningProcesses(); !errors.Is(err, nvml.SUCCESS) {
 		deviceInfo.PartialErrors = append(deviceInfo.PartialErrors, fmt.Sprintf("failed to get processes: %v", nvml.ErrorString(err)))
 		deviceInfo.NProcess = 0
 	} else {
 		deviceInfo.NProcess = len(processInfo)
+		deviceInfo.Processes = getGPUProcesses(processInfo)
 	}
 
 	if len(deviceInfo.PartialErrors) > 0 {
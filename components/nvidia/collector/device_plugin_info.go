@@ -0,0 +1,73 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"context"
+	"os"
+
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/k8s"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DevicePluginInfo is what the kubelet advertises and records of the GPUs the
+// NVIDIA device plugin registered.
+type DevicePluginInfo struct {
+	// Allocatable and Capacity are the nvidia.com/gpu in the node status, -1
+	// when the node can not be read
+	Allocatable int64 `json:"allocatable"`
+	Capacity    int64 `json:"capacity"`
+	// Registered are the device IDs in the device manager checkpoint of the
+	// kubelet, nil without a checkpoint
+	Registered []string `json:"registered,omitempty"`
+	// Allocated maps an allocated device ID to its pod UID and container
+	Allocated map[string]string `json:"allocated,omitempty"`
+}
+
+// getDevicePluginInfo reads the nvidia.com/gpu of the node and the device
+// manager checkpoint of the kubelet, it returns nil on a node with neither,
+// e.g. outside Kubernetes.
+func getDevicePluginInfo(ctx context.Context) *DevicePluginInfo {
+	info := &DevicePluginInfo{Allocatable: -1, Capacity: -1}
+	found := false
+	if client, err := k8s.NewClient(); err == nil && client != nil {
+		ctx, cancel := context.WithTimeout(ctx, consts.CmdTimeout)
+		defer cancel()
+		allocatable, capacity, ok, err := client.GetNodeResource(ctx, k8s.NvidiaGPUResource)
+		if err != nil {
+			logrus.WithField("component", "NVIDIA-Collector").Debugf("failed to get the %s of the node: %v", k8s.NvidiaGPUResource, err)
+		} else if ok {
+			info.Allocatable, info.Capacity = allocatable, capacity
+			found = true
+		}
+	}
+	checkpoint, err := k8s.ReadDevicePluginCheckpoint(k8s.DevicePluginCheckpointPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logrus.WithField("component", "NVIDIA-Collector").Warnf("failed to read the device plugin checkpoint: %v", err)
+		}
+	} else if registered := checkpoint.Registered(k8s.NvidiaGPUResource); registered != nil {
+		info.Registered = registered
+		info.Allocated = checkpoint.Allocated(k8s.NvidiaGPUResource)
+		found = true
+	}
+	if !found {
+		return nil
+	}
+	return info
+}
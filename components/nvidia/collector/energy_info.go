@@ -0,0 +1,140 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/scitix/sichek/pkg/k8s"
+)
+
+// podEnergyRetention is how long the energy of a pod is still reported after
+// it released its last GPU, so that the final value gets scraped.
+const podEnergyRetention = time.Hour
+
+// DeviceEnergy is the energy a GPU consumed since the previous collection.
+type DeviceEnergy struct {
+	Index        int     `json:"index" yaml:"index"`
+	UUID         string  `json:"uuid" yaml:"uuid"`
+	IntervalS    float64 `json:"interval_s" yaml:"interval_s"`
+	EnergyJ      float64 `json:"energy_J" yaml:"energy_J"`
+	AvgPowerW    float64 `json:"avg_power_W" yaml:"avg_power_W"`
+	PodName      string  `json:"pod_name,omitempty" yaml:"pod_name,omitempty"`
+	PodNamespace string  `json:"pod_namespace,omitempty" yaml:"pod_namespace,omitempty"`
+}
+
+// PodEnergy is the GPU energy accumulated by a pod since sichek started.
+type PodEnergy struct {
+	Namespace string    `json:"namespace" yaml:"namespace"`
+	PodName   string    `json:"pod_name" yaml:"pod_name"`
+	EnergyJ   float64   `json:"energy_J" yaml:"energy_J"`
+	Devices   []int     `json:"devices,omitempty" yaml:"devices,omitempty"`
+	LastSeen  time.Time `json:"last_seen" yaml:"last_seen"`
+}
+
+// EnergyInfo attributes the energy consumed by the GPUs to the pods they
+// are allocated to.
+type EnergyInfo struct {
+	Devices []DeviceEnergy `json:"devices" yaml:"devices"`
+	Pods    []PodEnergy    `json:"pods" yaml:"pods"`
+}
+
+type energySample struct {
+	time   time.Time
+	energy uint64
+}
+
+// EnergyAccountant keeps the energy counter of each GPU between collections
+// and the energy accumulated by each pod.
+type EnergyAccountant struct {
+	mu      sync.Mutex
+	samples map[string]energySample
+	pods    map[string]*PodEnergy
+}
+
+func NewEnergyAccountant() *EnergyAccountant {
+	return &EnergyAccountant{
+		samples: make(map[string]energySample),
+		pods:    make(map[string]*PodEnergy),
+	}
+}
+
+// Account charges the energy each GPU consumed since the previous call to the
+// pod it is allocated to now. The first sample of a GPU, and the one after
+// its counter went down, e.g. after a driver reload, only set the baseline.
+func (a *EnergyAccountant) Account(now time.Time, devices []DeviceInfo, deviceToPodMap map[string]*k8s.PodInfo) *EnergyInfo {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	info := &EnergyInfo{Devices: make([]DeviceEnergy, 0, len(devices))}
+	for _, device := range devices {
+		if device.UUID == "" || device.Power.TotalEnergy == 0 {
+			continue
+		}
+		pod := deviceToPodMap[device.UUID]
+		if pod != nil {
+			key := pod.Namespace + "/" + pod.PodName
+			usage, ok := a.pods[key]
+			if !ok {
+				usage = &PodEnergy{Namespace: pod.Namespace, PodName: pod.PodName}
+				a.pods[key] = usage
+			}
+			usage.LastSeen = now
+			if !slices.Contains(usage.Devices, device.Index) {
+				usage.Devices = append(usage.Devices, device.Index)
+				sort.Ints(usage.Devices)
+			}
+		}
+
+		last, ok := a.samples[device.UUID]
+		a.samples[device.UUID] = energySample{time: now, energy: device.Power.TotalEnergy}
+		if !ok || device.Power.TotalEnergy < last.energy || !now.After(last.time) {
+			continue
+		}
+		interval := now.Sub(last.time).Seconds()
+		energy := DeviceEnergy{
+			Index:     device.Index,
+			UUID:      device.UUID,
+			IntervalS: interval,
+			EnergyJ:   float64(device.Power.TotalEnergy-last.energy) / 1000,
+		}
+		energy.AvgPowerW = energy.EnergyJ / interval
+		if pod != nil {
+			energy.PodName, energy.PodNamespace = pod.PodName, pod.Namespace
+			a.pods[pod.Namespace+"/"+pod.PodName].EnergyJ += energy.EnergyJ
+		}
+		info.Devices = append(info.Devices, energy)
+	}
+
+	info.Pods = make([]PodEnergy, 0, len(a.pods))
+	for key, usage := range a.pods {
+		if now.Sub(usage.LastSeen) > podEnergyRetention {
+			delete(a.pods, key)
+			continue
+		}
+		info.Pods = append(info.Pods, *usage)
+	}
+	sort.Slice(info.Pods, func(i, j int) bool {
+		if info.Pods[i].Namespace != info.Pods[j].Namespace {
+			return info.Pods[i].Namespace < info.Pods[j].Namespace
+		}
+		return info.Pods[i].PodName < info.Pods[j].PodName
+	})
+	return info
+}
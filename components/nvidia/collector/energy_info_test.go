@@ -0,0 +1,68 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/pkg/k8s"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func energyDevice(index int, uuid string, energyMJ uint64) DeviceInfo {
+	return DeviceInfo{Index: index, UUID: uuid, Power: PowerInfo{TotalEnergy: energyMJ}}
+}
+
+func TestEnergyAccountant(t *testing.T) {
+	a := NewEnergyAccountant()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	pods := map[string]*k8s.PodInfo{
+		"GPU-0": {Namespace: "train", PodName: "llm-0"},
+		"GPU-1": {Namespace: "train", PodName: "llm-0"},
+	}
+
+	// the first sample only sets the baseline
+	info := a.Account(start, []DeviceInfo{energyDevice(0, "GPU-0", 1000000), energyDevice(1, "GPU-1", 2000000), energyDevice(2, "GPU-2", 500)}, pods)
+	assert.Empty(t, info.Devices)
+	require.Len(t, info.Pods, 1)
+	assert.Equal(t, []int{0, 1}, info.Pods[0].Devices)
+	assert.Zero(t, info.Pods[0].EnergyJ)
+
+	// 10s later, GPU-0 drew 700W and GPU-1 350W, GPU-2 is not allocated
+	info = a.Account(start.Add(10*time.Second), []DeviceInfo{energyDevice(0, "GPU-0", 8000000), energyDevice(1, "GPU-1", 5500000), energyDevice(2, "GPU-2", 1000500)}, pods)
+	require.Len(t, info.Devices, 3)
+	assert.InDelta(t, 700, info.Devices[0].AvgPowerW, 0.001)
+	assert.Equal(t, "llm-0", info.Devices[0].PodName)
+	assert.InDelta(t, 100, info.Devices[2].AvgPowerW, 0.001)
+	assert.Empty(t, info.Devices[2].PodName)
+	require.Len(t, info.Pods, 1)
+	assert.InDelta(t, 10500, info.Pods[0].EnergyJ, 0.001)
+
+	// GPU-0 moves to another pod and the counter of GPU-1 was reset
+	pods = map[string]*k8s.PodInfo{"GPU-0": {Namespace: "infer", PodName: "svc-0"}}
+	info = a.Account(start.Add(20*time.Second), []DeviceInfo{energyDevice(0, "GPU-0", 9000000), energyDevice(1, "GPU-1", 100)}, pods)
+	require.Len(t, info.Devices, 1)
+	require.Len(t, info.Pods, 2)
+	assert.Equal(t, "infer", info.Pods[0].Namespace)
+	assert.InDelta(t, 1000, info.Pods[0].EnergyJ, 0.001)
+	assert.InDelta(t, 10500, info.Pods[1].EnergyJ, 0.001)
+
+	// the pods that released their GPUs are forgotten after the retention
+	info = a.Account(start.Add(20*time.Second+podEnergyRetention+time.Second), []DeviceInfo{energyDevice(0, "GPU-0", 9500000)}, nil)
+	assert.Empty(t, info.Pods)
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+	"github.com/scitix/sichek/components/common"
+)
+
+const (
+	MIGModeEnabled      = "Enabled"
+	MIGModeDisabled     = "Disabled"
+	MIGModeNotSupported = "Not Supported"
+)
+
+// MIGInfo is the MIG geometry of a GPU. The parent GPU of MIG instances does
+// not report utilization and its running processes, they are collected per
+// instance instead.
+type MIGInfo struct {
+	// CurrentMode is the active MIG mode, PendingMode differs from it until the GPU is reset
+	CurrentMode string        `json:"current_mode" yaml:"current_mode"`
+	PendingMode string        `json:"pending_mode,omitempty" yaml:"pending_mode,omitempty"`
+	Instances   []MIGInstance `json:"instances,omitempty" yaml:"instances,omitempty"`
+}
+
+// MIGInstance is one GPU instance/compute instance pair exposed as a MIG device.
+type MIGInstance struct {
+	GPUInstanceID     int `json:"gpu_instance_id" yaml:"gpu_instance_id"`
+	ComputeInstanceID int `json:"compute_instance_id" yaml:"compute_instance_id"`
+	// Profile is the MIG profile name, e.g. 1g.10gb
+	Profile            string       `json:"profile" yaml:"profile"`
+	UUID               string       `json:"uuid" yaml:"uuid"`
+	MemoryTotalMiB     uint64       `json:"memory_total_mib" yaml:"memory_total_mib"`
+	MemoryUsedMiB      uint64       `json:"memory_used_mib" yaml:"memory_used_mib"`
+	NProcess           int          `json:"nprocess" yaml:"nprocess"`
+	Processes          []GPUProcess `json:"processes,omitempty" yaml:"processes,omitempty"`
+	ECCUncorrectable   uint64       `json:"ecc_volatile_uncorrectable" yaml:"ecc_volatile_uncorrectable"`
+	ECCCorrectable     uint64       `json:"ecc_volatile_correctable" yaml:"ecc_volatile_correctable"`
+	ECCCountersPresent bool         `json:"ecc_counters_present" yaml:"ecc_counters_present"`
+}
+
+func (mig *MIGInfo) JSON() ([]byte, error) {
+	return common.JSON(mig)
+}
+
+// ToString Convert struct to JSON (pretty-printed)
+func (mig *MIGInfo) ToString() string {
+	return common.ToString(mig)
+}
+
+// Enabled reports whether the GPU runs in MIG mode.
+func (mig *MIGInfo) Enabled() bool {
+	return mig.CurrentMode == MIGModeEnabled
+}
+
+// ProfileCounts returns the number of instances of each MIG profile.
+func (mig *MIGInfo) ProfileCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, instance := range mig.Instances {
+		counts[instance.Profile]++
+	}
+	return counts
+}
+
+// Get collects the MIG mode of the GPU and, if enabled, its MIG instances.
+func (mig *MIGInfo) Get(device nvml.Device, uuid string) error {
+	mig.Instances = nil
+	current, pending, err := device.GetMigMode()
+	if errors.Is(err, nvml.ERROR_NOT_SUPPORTED) {
+		mig.CurrentMode, mig.PendingMode = MIGModeNotSupported, ""
+		return nil
+	}
+	if !errors.Is(err, nvml.SUCCESS) {
+		return fmt.Errorf("failed to get MIG mode for GPU %v: %v", uuid, nvml.ErrorString(err))
+	}
+	mig.CurrentMode, mig.PendingMode = migModeString(current), migModeString(pending)
+	if !mig.Enabled() {
+		return nil
+	}
+
+	maxCount, err := device.GetMaxMigDeviceCount()
+	if !errors.Is(err, nvml.SUCCESS) {
+		return fmt.Errorf("failed to get max MIG device count for GPU %v: %v", uuid, nvml.ErrorString(err))
+	}
+	var partialErrs []string
+	for i := 0; i < maxCount; i++ {
+		migDevice, err := device.GetMigDeviceHandleByIndex(i)
+		if errors.Is(err, nvml.ERROR_NOT_FOUND) {
+			// the slot is not populated
+			continue
+		}
+		if !errors.Is(err, nvml.SUCCESS) {
+			partialErrs = append(partialErrs, fmt.Sprintf("MIG device %d: %v", i, nvml.ErrorString(err)))
+			continue
+		}
+		instance, err2 := getMIGInstance(migDevice)
+		if err2 != nil {
+			partialErrs = append(partialErrs, fmt.Sprintf("MIG device %d: %v", i, err2))
+		}
+		mig.Instances = append(mig.Instances, instance)
+	}
+	sort.Slice(mig.Instances, func(i, j int) bool {
+		if mig.Instances[i].GPUInstanceID != mig.Instances[j].GPUInstanceID {
+			return mig.Instances[i].GPUInstanceID < mig.Instances[j].GPUInstanceID
+		}
+		return mig.Instances[i].ComputeInstanceID < mig.Instances[j].ComputeInstanceID
+	})
+	if len(partialErrs) > 0 {
+		return fmt.Errorf("failed to get MIG instances for GPU %v: %s", uuid, strings.Join(partialErrs, "; "))
+	}
+	return nil
+}
+
+func getMIGInstance(migDevice nvml.Device) (MIGInstance, error) {
+	var instance MIGInstance
+	var errs []string
+	if id, err := migDevice.GetGpuInstanceId(); errors.Is(err, nvml.SUCCESS) {
+		instance.GPUInstanceID = id
+	} else {
+		errs = append(errs, fmt.Sprintf("gpu instance id: %v", nvml.ErrorString(err)))
+	}
+	if id, err := migDevice.GetComputeInstanceId(); errors.Is(err, nvml.SUCCESS) {
+		instance.ComputeInstanceID = id
+	} else {
+		errs = append(errs, fmt.Sprintf("compute instance id: %v", nvml.ErrorString(err)))
+	}
+	if uuid, err := migDevice.GetUUID(); errors.Is(err, nvml.SUCCESS) {
+		instance.UUID = uuid
+	} else {
+		errs = append(errs, fmt.Sprintf("uuid: %v", nvml.ErrorString(err)))
+	}
+	if name, err := migDevice.GetName(); errors.Is(err, nvml.SUCCESS) {
+		instance.Profile = migProfileFromName(name)
+	} else {
+		errs = append(errs, fmt.Sprintf("name: %v", nvml.ErrorString(err)))
+	}
+	if memory, err := migDevice.GetMemoryInfo(); errors.Is(err, nvml.SUCCESS) {
+		instance.MemoryTotalMiB = memory.Total / 1024 / 1024
+		instance.MemoryUsedMiB = memory.Used / 1024 / 1024
+	} else {
+		errs = append(errs, fmt.Sprintf("memory info: %v", nvml.ErrorString(err)))
+	}
+	if processes, err := migDevice.GetComputeRunningProcesses(); errors.Is(err, nvml.SUCCESS) {
+		instance.NProcess = len(processes)
+		instance.Processes = getGPUProcesses(processes)
+	}
+	// ECC counters of an instance are only exposed on some GPUs, so a missing counter is not an error
+	uncorrectable, err := migDevice.GetTotalEccErrors(nvml.MEMORY_ERROR_TYPE_UNCORRECTED, nvml.VOLATILE_ECC)
+	if errors.Is(err, nvml.SUCCESS) {
+		instance.ECCUncorrectable = uncorrectable
+		instance.ECCCountersPresent = true
+		if correctable, err := migDevice.GetTotalEccErrors(nvml.MEMORY_ERROR_TYPE_CORRECTED, nvml.VOLATILE_ECC); errors.Is(err, nvml.SUCCESS) {
+			instance.ECCCorrectable = correctable
+		}
+	}
+	if len(errs) > 0 {
+		return instance, fmt.Errorf("%s", strings.Join(errs, ", "))
+	}
+	return instance, nil
+}
+
+func migModeString(mode int) string {
+	switch mode {
+	case nvml.DEVICE_MIG_ENABLE:
+		return MIGModeEnabled
+	case nvml.DEVICE_MIG_DISABLE:
+		return MIGModeDisabled
+	default:
+		return fmt.Sprintf("Unknown(%d)", mode)
+	}
+}
+
+// migProfileFromName extracts the profile from the name of a MIG device,
+// e.g. "NVIDIA A100-SXM4-80GB MIG 1g.10gb" -> "1g.10gb".
+func migProfileFromName(name string) string {
+	if idx := strings.LastIndex(name, "MIG "); idx >= 0 {
+		return strings.TrimSpace(name[idx+len("MIG "):])
+	}
+	return name
+}
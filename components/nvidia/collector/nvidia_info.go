@@ -37,6 +37,9 @@ type NvidiaInfo struct {
 	IbgdaEnable         map[string]string       `json:"ibgda_enable"`
 	IbgdaConfigCount    int                     `json:"ibgda_config_count"` // Added field for config count
 	P2PStatusMatrix     map[string]bool         `json:"p2p_status_matrix"`  // New field for P2P status
+	NVSwitchInfo        *NVSwitchInfo           `json:"nvswitch_info,omitempty"`
+	Energy              *EnergyInfo             `json:"energy,omitempty"`
+	DevicePlugin        *DevicePluginInfo       `json:"device_plugin,omitempty"`
 }
 
 func (nvidia *NvidiaInfo) JSON() (string, error) {
@@ -0,0 +1,241 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/sirupsen/logrus"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+)
+
+const (
+	nvidiaPCIVendor = "0x10de"
+	// NVSwitches enumerate as PCI "other bridge" devices
+	nvswitchPCIClass = "0x068000"
+	// maxFabricManagerErrors bounds the fabricmanager log lines kept per collection
+	maxFabricManagerErrors = 20
+)
+
+var (
+	pciDevicesDir        = "/sys/bus/pci/devices"
+	fabricManagerLogPath = "/var/log/fabricmanager.log"
+)
+
+// NVSwitchInfo is the node level view of the NVLink fabric on HGX systems:
+// the NVSwitches found on the PCI bus, the GPU links landing on each of them,
+// the fabric registration state of each GPU and the fabricmanager errors
+// logged since its last start.
+type NVSwitchInfo struct {
+	PCISwitchCount      int              `json:"pci_switch_count"`
+	Switches            []NVSwitchState  `json:"switches,omitempty"`
+	GPUFabric           []GPUFabricState `json:"gpu_fabric,omitempty"`
+	FabricManagerErrors []string         `json:"fabricmanager_errors,omitempty"`
+}
+
+// NVSwitchState aggregates the GPU side NVLinks that terminate on one NVSwitch.
+type NVSwitchState struct {
+	BusID          string `json:"bus_id"`
+	ConnectedGPUs  []int  `json:"connected_gpus"`
+	ActiveLinks    int    `json:"active_links"`
+	InactiveLinks  int    `json:"inactive_links"`
+	ReplayErrors   uint64 `json:"replay_errors"`
+	RecoveryErrors uint64 `json:"recovery_errors"`
+	CRCErrors      uint64 `json:"crc_errors"`
+}
+
+// GPUFabricState is the registration of a GPU with the NVLink fabric as
+// reported by nvmlDeviceGetGpuFabricInfo.
+type GPUFabricState struct {
+	Index  int    `json:"index"`
+	State  string `json:"state"`
+	Status string `json:"status"`
+}
+
+func (info *NVSwitchInfo) JSON() ([]byte, error) {
+	return common.JSON(info)
+}
+
+// ToString Convert struct to JSON (pretty-printed)
+func (info *NVSwitchInfo) ToString() string {
+	return common.ToString(info)
+}
+
+// Switch returns the state of the NVSwitch at busID, or nil if no GPU link reaches it.
+func (info *NVSwitchInfo) Switch(busID string) *NVSwitchState {
+	for i := range info.Switches {
+		if info.Switches[i].BusID == busID {
+			return &info.Switches[i]
+		}
+	}
+	return nil
+}
+
+func (collector *NvidiaCollector) getNVSwitchInfo() *NVSwitchInfo {
+	info := &NVSwitchInfo{PCISwitchCount: countPCINVSwitches()}
+	if info.PCISwitchCount == 0 {
+		// PCIe or NVLink bridge systems, nothing to collect
+		return info
+	}
+	switches := make(map[string]*NVSwitchState)
+	for i := 0; i < collector.ExpectedDeviceCount; i++ {
+		device, ret := (*collector.nvmlInst).DeviceGetHandleByIndex(i)
+		if !errors.Is(ret, nvml.SUCCESS) {
+			continue
+		}
+		info.GPUFabric = append(info.GPUFabric, getGPUFabricState(device, i))
+		collectSwitchLinks(device, i, switches)
+	}
+	for _, sw := range switches {
+		info.Switches = append(info.Switches, *sw)
+	}
+	sort.Slice(info.Switches, func(i, j int) bool { return info.Switches[i].BusID < info.Switches[j].BusID })
+	info.FabricManagerErrors = parseFabricManagerLog(fabricManagerLogPath)
+	return info
+}
+
+func getGPUFabricState(device nvml.Device, index int) GPUFabricState {
+	state := GPUFabricState{Index: index}
+	fabric, ret := device.GetGpuFabricInfo()
+	if !errors.Is(ret, nvml.SUCCESS) {
+		state.State = "Not Supported"
+		return state
+	}
+	switch fabric.State {
+	case nvml.GPU_FABRIC_STATE_NOT_SUPPORTED:
+		state.State = "Not Supported"
+	case nvml.GPU_FABRIC_STATE_NOT_STARTED:
+		state.State = "Not Started"
+	case nvml.GPU_FABRIC_STATE_IN_PROGRESS:
+		state.State = "In Progress"
+	case nvml.GPU_FABRIC_STATE_COMPLETED:
+		state.State = "Completed"
+	default:
+		state.State = fmt.Sprintf("Unknown(%d)", fabric.State)
+	}
+	state.Status = nvml.Return(fabric.Status).String()
+	return state
+}
+
+// collectSwitchLinks walks the NVLinks of a GPU and accounts the ones whose
+// remote end is an NVSwitch to that switch, keyed by its PCI bus id.
+func collectSwitchLinks(device nvml.Device, index int, switches map[string]*NVSwitchState) {
+	for link := 0; link < int(nvml.NVLINK_MAX_LINKS); link++ {
+		remoteType, ret := device.GetNvLinkRemoteDeviceType(link)
+		if errors.Is(ret, nvml.ERROR_INVALID_ARGUMENT) || errors.Is(ret, nvml.ERROR_NOT_SUPPORTED) {
+			break
+		}
+		if !errors.Is(ret, nvml.SUCCESS) || remoteType != nvml.NVLINK_DEVICE_TYPE_SWITCH {
+			continue
+		}
+		pciInfo, ret := device.GetNvLinkRemotePciInfo(link)
+		if !errors.Is(ret, nvml.SUCCESS) {
+			logrus.WithField("component", "NVIDIA-Collector").Warnf("GPU %d link %d: failed to get remote pci info: %v", index, link, ret)
+			continue
+		}
+		busID := busIDToString(pciInfo.BusId[:])
+		sw, ok := switches[busID]
+		if !ok {
+			sw = &NVSwitchState{BusID: busID}
+			switches[busID] = sw
+		}
+		if len(sw.ConnectedGPUs) == 0 || sw.ConnectedGPUs[len(sw.ConnectedGPUs)-1] != index {
+			sw.ConnectedGPUs = append(sw.ConnectedGPUs, index)
+		}
+		if state, ret := device.GetNvLinkState(link); errors.Is(ret, nvml.SUCCESS) && state == nvml.FEATURE_ENABLED {
+			sw.ActiveLinks++
+		} else {
+			sw.InactiveLinks++
+		}
+		if v, ret := device.GetNvLinkErrorCounter(link, nvml.NVLINK_ERROR_DL_REPLAY); errors.Is(ret, nvml.SUCCESS) {
+			sw.ReplayErrors += v
+		}
+		if v, ret := device.GetNvLinkErrorCounter(link, nvml.NVLINK_ERROR_DL_RECOVERY); errors.Is(ret, nvml.SUCCESS) {
+			sw.RecoveryErrors += v
+		}
+		if v, ret := device.GetNvLinkErrorCounter(link, nvml.NVLINK_ERROR_DL_CRC_FLIT); errors.Is(ret, nvml.SUCCESS) {
+			sw.CRCErrors += v
+		}
+	}
+}
+
+func busIDToString(busID []int8) string {
+	var b strings.Builder
+	for _, c := range busID {
+		if c == 0 {
+			break
+		}
+		b.WriteByte(byte(c))
+	}
+	return strings.ToLower(b.String())
+}
+
+func countPCINVSwitches() int {
+	entries, err := os.ReadDir(hostfs.Path(pciDevicesDir))
+	if err != nil {
+		return 0
+	}
+	count := 0
+	for _, entry := range entries {
+		dir := hostfs.Path(pciDevicesDir, entry.Name())
+		vendor, err := os.ReadFile(filepath.Join(dir, "vendor"))
+		if err != nil || strings.TrimSpace(string(vendor)) != nvidiaPCIVendor {
+			continue
+		}
+		class, err := os.ReadFile(filepath.Join(dir, "class"))
+		if err != nil || strings.TrimSpace(string(class)) != nvswitchPCIClass {
+			continue
+		}
+		count++
+	}
+	return count
+}
+
+// parseFabricManagerLog returns the ERROR lines logged by nvidia-fabricmanager
+// since it last started, so errors of a previous run do not stick forever.
+func parseFabricManagerLog(path string) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var errs []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.Contains(line, "Fabric Manager version") && strings.Contains(line, "is running") {
+			errs = errs[:0]
+			continue
+		}
+		if strings.Contains(line, "[ERROR]") {
+			errs = append(errs, line)
+			if len(errs) > maxFabricManagerErrors {
+				errs = errs[1:]
+			}
+		}
+	}
+	return errs
+}
@@ -33,6 +33,9 @@ type PowerInfo struct {
 	MaxPowerLimit      float32 `json:"max_power_limit_W" yaml:"max_power_limit_W"`
 	PowerViolations    uint64  `json:"power_violations" yaml:"power_violations"`
 	ThermalViolations  uint64  `json:"thermal_violations" yaml:"thermal_violations"`
+	// TotalEnergy is the energy consumed since the driver was last reloaded,
+	// 0 on GPUs older than Volta which do not report it
+	TotalEnergy uint64 `json:"total_energy_mJ" yaml:"total_energy_mJ"`
 }
 
 func (info *PowerInfo) JSON() ([]byte, error) {
@@ -53,6 +56,14 @@ func (info *PowerInfo) Get(device nvml.Device, uuid string) error {
 	}
 	info.PowerUsage = powerUsage
 
+	// ref. https://docs.nvidia.com/deploy/nvml-api/group__nvmlDeviceQueries.html#group__nvmlDeviceQueries_1g732ab899b5bd18ac4bfb93c02de4900a
+	totalEnergy, ret := device.GetTotalEnergyConsumption()
+	if errors.Is(ret, nvml.SUCCESS) {
+		info.TotalEnergy = totalEnergy
+	} else if !errors.Is(ret, nvml.ERROR_NOT_SUPPORTED) {
+		return fmt.Errorf("failed to get device total energy consumption: %v", nvml.ErrorString(ret))
+	}
+
 	// ref. https://docs.nvidia.com/deploy/nvml-api/group__nvmlDeviceQueries.html#group__nvmlDeviceQueries_1gf754f109beca3a4a8c8c1cd650d7d66c
 	curPowerLimit, ret := device.GetPowerManagementLimit()
 	if !errors.Is(ret, nvml.SUCCESS) {
@@ -0,0 +1,99 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+	"github.com/scitix/sichek/pkg/hostfs"
+)
+
+var (
+	procDir        = "/proc"
+	kubeletPodsDir = "/var/lib/kubelet/pods"
+
+	// pod uid in the cgroup path, e.g. kubepods/burstable/pod<uid>/<container> with
+	// cgroupfs or kubepods-burstable-pod<uid>.slice/cri-containerd-<container>.scope
+	// with the systemd driver, where the dashes of the uid are underscores
+	cgroupPodRegexp       = regexp.MustCompile(`pod([0-9a-fA-F]{8}[-_][0-9a-fA-F]{4}[-_][0-9a-fA-F]{4}[-_][0-9a-fA-F]{4}[-_][0-9a-fA-F]{12})`)
+	cgroupContainerRegexp = regexp.MustCompile(`([0-9a-f]{64})`)
+)
+
+// GPUProcess is a process holding a GPU context, attributed to its pod
+// through the cgroup of the process.
+type GPUProcess struct {
+	PID           uint32 `json:"pid" yaml:"pid"`
+	Name          string `json:"name" yaml:"name"`
+	UsedMemoryMiB uint64 `json:"used_memory_mib" yaml:"used_memory_mib"`
+	// PodUID is empty for processes running outside of any pod
+	PodUID      string `json:"pod_uid,omitempty" yaml:"pod_uid,omitempty"`
+	ContainerID string `json:"container_id,omitempty" yaml:"container_id,omitempty"`
+	// PodKnown reports whether the kubelet still manages the pod of the process
+	PodKnown bool `json:"pod_known" yaml:"pod_known"`
+}
+
+// getGPUProcesses resolves the processes reported by NVML to their command
+// and pod. A process that exited meanwhile keeps an empty name.
+func getGPUProcesses(infos []nvml.ProcessInfo) []GPUProcess {
+	processes := make([]GPUProcess, 0, len(infos))
+	for _, info := range infos {
+		process := GPUProcess{
+			PID:           info.Pid,
+			UsedMemoryMiB: info.UsedGpuMemory / 1024 / 1024,
+		}
+		if comm, err := os.ReadFile(hostfs.Path(procDir, fmt.Sprint(info.Pid), "comm")); err == nil {
+			process.Name = strings.TrimSpace(string(comm))
+		}
+		process.PodUID, process.ContainerID = podFromCgroup(hostfs.Path(procDir, fmt.Sprint(info.Pid), "cgroup"))
+		if process.PodUID != "" {
+			_, err := os.Stat(filepath.Join(kubeletPodsDir, process.PodUID))
+			process.PodKnown = err == nil
+		}
+		processes = append(processes, process)
+	}
+	return processes
+}
+
+// podFromCgroup returns the pod uid and container id found in the cgroup file of a process.
+func podFromCgroup(path string) (string, string) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		match := cgroupPodRegexp.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		podUID := strings.ReplaceAll(match[1], "_", "-")
+		containerID := ""
+		if container := cgroupContainerRegexp.FindStringSubmatch(line); container != nil {
+			containerID = container[1]
+		}
+		return podUID, containerID
+	}
+	return "", ""
+}
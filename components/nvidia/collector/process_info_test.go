@@ -0,0 +1,48 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPodFromCgroup(t *testing.T) {
+	containerID := "4f2d0c3b9a8e7f6d5c4b3a2918273645f1e2d3c4b5a697887766554433221100"
+	cases := map[string]string{
+		"cgroupfs": "12:devices:/kubepods/burstable/pod0a1b2c3d-1111-2222-3333-444455556666/" + containerID + "\n",
+		"systemd":  "0::/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod0a1b2c3d_1111_2222_3333_444455556666.slice/cri-containerd-" + containerID + ".scope\n",
+	}
+	for name, content := range cases {
+		path := filepath.Join(t.TempDir(), "cgroup")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		podUID, container := podFromCgroup(path)
+		if podUID != "0a1b2c3d-1111-2222-3333-444455556666" || container != containerID {
+			t.Errorf("%s: got pod %q container %q", name, podUID, container)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "cgroup")
+	if err := os.WriteFile(path, []byte("0::/user.slice/user-0.slice/session-1.scope\n"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if podUID, _ := podFromCgroup(path); podUID != "" {
+		t.Errorf("expected no pod for a host process, got %q", podUID)
+	}
+}
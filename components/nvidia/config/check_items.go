@@ -43,6 +43,13 @@ const (
 	NvlsErrorCheckerName                 = "NVLSError"
 	IBGDACheckerName                     = "ibgda"
 	P2PCheckerName                       = "p2p_topo"
+	NVSwitchCheckerName                  = "nvswitch"
+	MIGCheckerName                       = "mig"
+	GPUProcessCheckerName                = "gpu-process"
+	ECCTrendCheckerName                  = "ecc-trend"
+	DriverMismatchCheckerName            = "nvidia-driver-mismatch"
+	KernelTaintCheckerName               = "kernel-taint"
+	DevicePluginCheckerName              = "device-plugin"
 )
 
 // GPUCheckItems is a map of check items for GPU
@@ -54,7 +61,7 @@ var GPUCheckItems = map[string]common.CheckerResult{
 		Level:       consts.LevelCritical,
 		Detail:      "PCIe ACS is closed",
 		ErrorName:   "PCIeACSNotClosed",
-		Suggestion:  "run `for i in $(lspci | cut -f 1 -d \" \");do setpci -v -s $i ecap_acs+6.w=0;done` to close the ACS. Ideally this will be done automatically online",
+		Suggestion:  "run `for i in $(lspci | cut -f 1 -d \" \");do setpci -v -s $i ecap_acs+6.w=0;done` to close the ACS. Run with --auto-fix to apply it online",
 	},
 	IOMMUCheckerName: {
 		Name:        IOMMUCheckerName,
@@ -72,7 +79,7 @@ var GPUCheckItems = map[string]common.CheckerResult{
 		Level:       consts.LevelCritical,
 		Detail:      "nvidia_peermem is loaded",
 		ErrorName:   "NvidiaPeerMemNotLoaded",
-		Suggestion:  "run `modprobe nvidia_peermem` to load the nvidia_peermem. Ideally this will be done automatically online",
+		Suggestion:  "run `modprobe nvidia_peermem` to load the nvidia_peermem. Run with --auto-fix to apply it online",
 	},
 	NVFabricManagerCheckerName: {
 		Name:        NVFabricManagerCheckerName,
@@ -81,7 +88,7 @@ var GPUCheckItems = map[string]common.CheckerResult{
 		Level:       consts.LevelCritical,
 		Detail:      "nvidia-fabricmanager is active",
 		ErrorName:   "NvidiaFabricManagerNotActive",
-		Suggestion:  "run `systemctl restart nvidia-fabricmanager` to load the nvidia-fabricmanager. Ideally this will be done automatically online",
+		Suggestion:  "run `systemctl restart nvidia-fabricmanager` to load the nvidia-fabricmanager. Run with --auto-fix to apply it online",
 	},
 	PCIeCheckerName: {
 		Name:        PCIeCheckerName,
@@ -117,7 +124,7 @@ var GPUCheckItems = map[string]common.CheckerResult{
 		Level:       consts.LevelWarning,
 		Detail:      "NVIDIA Persistenced Mode is enabled",
 		ErrorName:   "GPUPersistencedModeNotEnabled",
-		Suggestion:  "run `nvidia-persistenced` to auto enable the persistence mode. Ideally this will be done automatically online",
+		Suggestion:  "run `nvidia-persistenced` to auto enable the persistence mode. Run with --auto-fix to apply it online",
 	},
 	GpuPStateCheckerName: {
 		Name:        GpuPStateCheckerName,
@@ -236,6 +243,69 @@ var GPUCheckItems = map[string]common.CheckerResult{
 		ErrorName:   "P2PNotSupported",
 		Suggestion:  "Check NVLink connections or PCIe topology settings (ACS)",
 	},
+	NVSwitchCheckerName: {
+		Name:        NVSwitchCheckerName,
+		Description: "Check if the NVSwitch fabric is healthy: all switches present, GPU links up and fabric registration completed",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelCritical,
+		Detail:      "NVSwitch fabric is healthy",
+		ErrorName:   "NVSwitchFabricDegraded",
+		Suggestion:  "Check `nvidia-smi -q | grep -A4 Fabric` and /var/log/fabricmanager.log, restart nvidia-fabricmanager or coldreset the system",
+	},
+	MIGCheckerName: {
+		Name:        MIGCheckerName,
+		Description: "Check if the MIG mode and instance geometry of the GPUs match the spec",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelCritical,
+		Detail:      "MIG layout matches the spec",
+		ErrorName:   "MIGGeometryMismatch",
+		Suggestion:  "Reconfigure MIG with `nvidia-smi mig -cgi <profiles> -C` or the MIG manager, reset the GPU if the MIG mode change is pending",
+	},
+	GPUProcessCheckerName: {
+		Name:        GPUProcessCheckerName,
+		Description: "Check if the GPUs are only used by the processes of the pods they are allocated to",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelWarning,
+		Detail:      "All GPU processes belong to scheduled pods",
+		ErrorName:   "GPURogueProcess",
+		Suggestion:  "Kill the leaked or zombie processes listed in the detail, they keep the GPU busy for the pods scheduled on it",
+	},
+	ECCTrendCheckerName: {
+		Name:        ECCTrendCheckerName,
+		Description: "Check if the remapped rows and SRAM correctable errors of the GPUs grow faster than the spec allows",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelWarning,
+		Detail:      "The memory errors of all GPUs grow within the spec",
+		ErrorName:   "GPULikelyToFail",
+		Suggestion:  "Drain the node and run `dcgmi diag -r 3` on the GPU, plan its RMA before the errors become uncorrectable",
+	},
+	DriverMismatchCheckerName: {
+		Name:        DriverMismatchCheckerName,
+		Description: "Check if the loaded nvidia kernel module, the module installed for the running kernel and the userspace driver have the same version",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelCritical,
+		Detail:      "The nvidia kernel module and userspace driver have the same version",
+		ErrorName:   "NvidiaDriverVersionMismatch",
+		Suggestion:  "Drain the node and reboot it to load the installed driver, reinstall the driver or rebuild its DKMS module with `dkms autoinstall` if the module is missing for the running kernel",
+	},
+	KernelTaintCheckerName: {
+		Name:        KernelTaintCheckerName,
+		Description: "Check if the kernel or the nvidia module is tainted by an oops, a machine check or a forced module load",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelWarning,
+		Detail:      "The kernel is not tainted by an error",
+		ErrorName:   "KernelTainted",
+		Suggestion:  "Check dmesg for the oops, machine check or forced module load that tainted the kernel and reboot the node",
+	},
+	DevicePluginCheckerName: {
+		Name:        DevicePluginCheckerName,
+		Description: "Check if the GPUs the device plugin advertises to Kubernetes are the ones accessible via NVML",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelCritical,
+		Detail:      "The device plugin advertises the accessible GPUs",
+		ErrorName:   "GPUDevicePluginMismatch",
+		Suggestion:  "Drain the node if a GPU fell off the bus, otherwise restart the NVIDIA device plugin pod so that it re-registers the GPUs with the kubelet",
+	},
 }
 
 var CriticalXidEvent = map[uint64]common.CheckerResult{
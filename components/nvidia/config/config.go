@@ -24,11 +24,34 @@ type NvidiaUserConfig struct {
 }
 
 type NvidiaConfig struct {
-	QueryInterval   common.Duration `json:"query_interval" yaml:"query_interval"`
-	CacheSize       int64           `json:"cache_size" yaml:"cache_size"`
-	EnableMetrics   bool            `json:"enable_metrics" yaml:"enable_metrics"`
-	EnableXidPoller bool            `json:"enable_xid_poller" yaml:"enable_xid_poller"`
-	IgnoredCheckers []string        `json:"ignored_checkers,omitempty" yaml:"ignored_checkers,omitempty"`
+	QueryInterval   common.Duration  `json:"query_interval" yaml:"query_interval"`
+	CacheSize       int64            `json:"cache_size" yaml:"cache_size"`
+	EnableMetrics   bool             `json:"enable_metrics" yaml:"enable_metrics"`
+	EnableXidPoller bool             `json:"enable_xid_poller" yaml:"enable_xid_poller"`
+	IgnoredCheckers []string         `json:"ignored_checkers,omitempty" yaml:"ignored_checkers,omitempty"`
+	XidPolicy       *XidPolicyConfig `json:"xid_policy,omitempty" yaml:"xid_policy,omitempty"`
+	// LifecycleRules override DefaultLifecycleRules per checker.
+	LifecycleRules []common.LifecycleRule `json:"lifecycle_rules,omitempty" yaml:"lifecycle_rules,omitempty"`
+	// EnableXidBackfill reads the critical xids logged since boot at startup,
+	// the XidEventPoller only sees the xids raised after it started.
+	EnableXidBackfill bool `json:"enable_xid_backfill" yaml:"enable_xid_backfill"`
+}
+
+// XidPolicyConfig controls how the events of the XidEventPoller are deduplicated
+// and escalated before they are reported.
+type XidPolicyConfig struct {
+	// DedupWindow is the window in which the same xid on the same GPU is reported once.
+	DedupWindow common.Duration `json:"dedup_window" yaml:"dedup_window"`
+	Policies    []XidPolicy     `json:"policies,omitempty" yaml:"policies,omitempty"`
+}
+
+// XidPolicy overrides the dedup window of an xid, and escalates its level to
+// EscalateLevel once it occurs EscalateCount times within the window.
+type XidPolicy struct {
+	Xid           uint64          `json:"xid" yaml:"xid"`
+	DedupWindow   common.Duration `json:"dedup_window,omitempty" yaml:"dedup_window,omitempty"`
+	EscalateCount int             `json:"escalate_count,omitempty" yaml:"escalate_count,omitempty"`
+	EscalateLevel string          `json:"escalate_level,omitempty" yaml:"escalate_level,omitempty"`
 }
 
 func (c *NvidiaConfig) IsXidPollerEnabled() bool {
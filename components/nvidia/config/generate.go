@@ -0,0 +1,122 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+	"github.com/scitix/sichek/components/nvidia/collector"
+	"github.com/sirupsen/logrus"
+)
+
+// GenerateSpec introspects the local GPUs through NVML and nvidia-smi and
+// returns the device ID used as spec key with a NvidiaSpec filled from the
+// observed values. Thresholds and dependences are not observable and use the
+// production defaults, perf baselines are left empty.
+func GenerateSpec(ctx context.Context) (string, *NvidiaSpec, error) {
+	nvmlInst := nvml.New()
+	if ret := nvmlInst.Init(); !errors.Is(ret, nvml.SUCCESS) {
+		return "", nil, fmt.Errorf("failed to initialize NVML: %v", nvml.ErrorString(ret))
+	}
+	defer nvmlInst.Shutdown()
+
+	deviceCount, ret := nvmlInst.DeviceGetCount()
+	if !errors.Is(ret, nvml.SUCCESS) {
+		return "", nil, fmt.Errorf("failed to get device count: %s", nvml.ErrorString(ret))
+	}
+	if deviceCount == 0 {
+		return "", nil, fmt.Errorf("no nvidia GPU found")
+	}
+
+	var softwareInfo collector.SoftwareInfo
+	if err := softwareInfo.Get(ctx, 0); err != nil {
+		return "", nil, err
+	}
+	devices := make([]collector.DeviceInfo, 0, deviceCount)
+	var memoryGB int
+	for i := 0; i < deviceCount; i++ {
+		device, ret := nvmlInst.DeviceGetHandleByIndex(i)
+		if !errors.Is(ret, nvml.SUCCESS) {
+			return "", nil, fmt.Errorf("failed to get Nvidia GPU %d: %s", i, nvml.ErrorString(ret))
+		}
+		var deviceInfo collector.DeviceInfo
+		if err := deviceInfo.Get(device, i, softwareInfo.DriverVersion); err != nil {
+			logrus.WithField("component", "nvidia/spec").Warnf("GPU %d partially collected: %v", i, err)
+		}
+		if memoryGB == 0 {
+			if memory, ret := device.GetMemoryInfo(); errors.Is(ret, nvml.SUCCESS) {
+				memoryGB = int(memory.Total >> 30)
+			}
+		}
+		devices = append(devices, deviceInfo)
+	}
+	deviceID, spec := newSpecFromDevices(softwareInfo, devices, memoryGB)
+	return deviceID, spec, nil
+}
+
+func newSpecFromDevices(softwareInfo collector.SoftwareInfo, devices []collector.DeviceInfo, memoryGB int) (string, *NvidiaSpec) {
+	first := devices[0]
+	spec := &NvidiaSpec{
+		Name:      first.Name,
+		GpuNums:   len(devices),
+		GpuMemory: memoryGB,
+		Dependence: Dependence{
+			PcieAcs:        "disable",
+			Iommu:          "off",
+			NvidiaPeermem:  "enable",
+			FabricManager:  "active",
+			CpuPerformance: "enable",
+		},
+		Software: collector.SoftwareInfo{
+			DriverVersion: ">=" + softwareInfo.DriverVersion,
+		},
+		Nvlink: collector.NVLinkStates{
+			NVlinkSupported: first.NVLinkStates.NVlinkSupported,
+			NvlinkNum:       first.NVLinkStates.NvlinkNum,
+		},
+		State: collector.StatesInfo{
+			GpuPersistenceM: "enable",
+			GpuPstate:       0,
+		},
+		MemoryErrorThreshold: MemoryErrorThreshold{
+			RemappedUncorrectableErrors:      512,
+			SRAMVolatileUncorrectableErrors:  0,
+			SRAMAggregateUncorrectableErrors: 4,
+			SRAMVolatileCorrectableErrors:    10000000,
+			SRAMAggregateCorrectableErrors:   10000000,
+		},
+		TemperatureThreshold: TemperatureThreshold{
+			Gpu:    75,
+			Memory: 95,
+		},
+	}
+	if softwareInfo.CUDAVersion != "" {
+		spec.Software.CUDAVersion = ">=" + softwareInfo.CUDAVersion
+	}
+	for _, device := range devices {
+		// a GPU with fewer active links is a failure to report, not the baseline
+		if device.NVLinkStates.NvlinkNum > spec.Nvlink.NvlinkNum {
+			spec.Nvlink.NvlinkNum = device.NVLinkStates.NvlinkNum
+		}
+	}
+	if !spec.Nvlink.NVlinkSupported {
+		spec.Dependence.FabricManager = "Not Required"
+	}
+	return fmt.Sprintf("0x%x", first.PCIeInfo.DEVID), spec
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import "github.com/scitix/sichek/components/common"
+
+// DefaultLifecycleRules map the GPU memory and xid checkers to the action
+// that recovers the GPU, following the NVIDIA GPU memory error management
+// guide: a pending row remap or a contained error is cleared by a GPU reset,
+// an uncontained error or a GPU that fell off the bus needs the node
+// rebooted, and exhausted remap rows or an uncorrectable SRAM error rate
+// call for an RMA. Rules of the user config override them per checker.
+var DefaultLifecycleRules = []common.LifecycleRule{
+	{Checker: RemmapedRowsPendingCheckerName, Action: common.LifecycleActionResetGPU},
+	{Checker: SRAMVolatileUncorrectableCheckerName, Action: common.LifecycleActionResetGPU},
+	{Checker: RemmapedRowsFailureCheckerName, Action: common.LifecycleActionRMA},
+	{Checker: RemmapedRowsUncorrectableCheckerName, Action: common.LifecycleActionRMA},
+	{Checker: SRAMAggUncorrectableCheckerName, Action: common.LifecycleActionRMA},
+	{Checker: ECCTrendCheckerName, Action: common.LifecycleActionRMA},
+	{Checker: "xid-48", Action: common.LifecycleActionResetGPU},
+	{Checker: "xid-63", Action: common.LifecycleActionResetGPU},
+	{Checker: "xid-74", Action: common.LifecycleActionResetGPU},
+	{Checker: "xid-94", Action: common.LifecycleActionResetGPU},
+	{Checker: "xid-79", Action: common.LifecycleActionDrainReboot},
+	{Checker: "xid-95", Action: common.LifecycleActionDrainReboot},
+	{Checker: "xid-64", Action: common.LifecycleActionRMA},
+	{Checker: "xid-92", Action: common.LifecycleActionRMA},
+}
@@ -20,6 +20,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/scitix/sichek/components/common"
 	"github.com/scitix/sichek/components/nvidia/collector"
@@ -45,6 +46,9 @@ type NvidiaSpec struct {
 	TemperatureThreshold TemperatureThreshold   `json:"temperature_threshold" yaml:"temperature_threshold"`
 	CriticalXidEvents    map[int]string         `json:"critical_xid_events,omitempty" yaml:"critical_xid_events,omitempty"`
 	Perf                 PerfMetrics            `json:"perf,omitempty" yaml:"perf,omitempty"`
+	NVSwitch             *NVSwitchSpec          `json:"nvswitch,omitempty" yaml:"nvswitch,omitempty"`
+	MIG                  *MIGSpec               `json:"mig,omitempty" yaml:"mig,omitempty"`
+	ECCTrend             *ECCTrendSpec          `json:"ecc_trend,omitempty" yaml:"ecc_trend,omitempty"`
 }
 
 type NvidiaSpecs struct {
@@ -52,10 +56,11 @@ type NvidiaSpecs struct {
 }
 
 type Dependence struct {
-	PcieAcs        string `json:"pcie_acs" yaml:"pcie_acs"`
-	Iommu          string `json:"iommu" yaml:"iommu"`
-	NvidiaPeermem  string `json:"nv_peermem" yaml:"nv_peermem"`
-	FabricManager  string `json:"nv_fabricmanager" yaml:"nv_fabricmanager"`
+	PcieAcs       string `json:"pcie_acs" yaml:"pcie_acs"`
+	Iommu         string `json:"iommu" yaml:"iommu"`
+	NvidiaPeermem string `json:"nv_peermem" yaml:"nv_peermem"`
+	FabricManager string `json:"nv_fabricmanager" yaml:"nv_fabricmanager"`
+	// CpuPerformance is enforced by the cpu-performance checker of the cpu component
 	CpuPerformance string `json:"cpu_performance" yaml:"cpu_performance"`
 }
 
@@ -74,6 +79,52 @@ type TemperatureThreshold struct {
 
 type PerfMetrics struct {
 	NcclAllReduceBw float64 `json:"nccl-all-reduce-bw" yaml:"nccl-all-reduce-bw"`
+	// NcclAllReduceBwMultiNode is the busbw in GB/s expected from `sichek nccltest --hosts`, bounded by the HCAs
+	NcclAllReduceBwMultiNode float64 `json:"nccl-all-reduce-bw-multi-node,omitempty" yaml:"nccl-all-reduce-bw-multi-node,omitempty"`
+	// NvlinkP2PBw is the minimal p2p bandwidth in GB/s expected between any two GPUs
+	NvlinkP2PBw float64 `json:"nvlink-p2p-bw,omitempty" yaml:"nvlink-p2p-bw,omitempty"`
+}
+
+// NVSwitchSpec describes the NVLink fabric of HGX systems. A nil spec means
+// the GPUs are not connected through NVSwitches and the fabric is not checked.
+type NVSwitchSpec struct {
+	SwitchNum int `json:"switch_num" yaml:"switch_num"`
+	// LinkErrorThreshold is the max replay/recovery/CRC errors tolerated on
+	// the GPU links of one NVSwitch
+	LinkErrorThreshold uint64 `json:"link_error_threshold" yaml:"link_error_threshold"`
+}
+
+// MIGSpec declares the expected MIG layout of every GPU of the node. A nil
+// spec means the MIG geometry is not checked.
+type MIGSpec struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// Profiles is the expected number of instances of each MIG profile per GPU, e.g. {"1g.10gb": 7}
+	Profiles map[string]int `json:"profiles,omitempty" yaml:"profiles,omitempty"`
+}
+
+// ECCTrendSpec flags a GPU as likely to fail when its memory error counters
+// grow faster than the given slopes over the trend window. A nil spec means
+// the ECC trend is not analyzed, a zero slope that the counter is not checked.
+type ECCTrendSpec struct {
+	// WindowHours is the span of history the growth rates are computed over, DefaultECCTrendWindowHours when unset
+	WindowHours int `json:"window_hours,omitempty" yaml:"window_hours,omitempty"`
+	// MaxRemappedRowsPerHour is the max growth of the rows remapped due to correctable and uncorrectable errors
+	MaxRemappedRowsPerHour float64 `json:"max_remapped_rows_per_hour,omitempty" yaml:"max_remapped_rows_per_hour,omitempty"`
+	// MaxSRAMCorrectablePerHour is the max growth of the aggregate SRAM correctable errors
+	MaxSRAMCorrectablePerHour float64 `json:"max_sram_correctable_per_hour,omitempty" yaml:"max_sram_correctable_per_hour,omitempty"`
+}
+
+// DefaultECCTrendWindowHours smooths out a burst of errors, e.g. a single
+// faulty row remapped a few times, while still catching a steady decline.
+const DefaultECCTrendWindowHours = 24
+
+// Window returns the trend window of the spec.
+func (s *ECCTrendSpec) Window() time.Duration {
+	hours := s.WindowHours
+	if hours <= 0 {
+		hours = DefaultECCTrendWindowHours
+	}
+	return time.Duration(hours) * time.Hour
 }
 
 // ─── EnsureSpec ──────────────────────────────────────────────────────────────
@@ -23,6 +23,11 @@ type NvidiaMetrics struct {
 	NvidiaDeviceClkEventGauge *common.GaugeVecMetricExporter
 	NvidiaIBGDAStatusGauge    *common.GaugeVecMetricExporter
 	NvidiaP2PStatusGauge      *common.GaugeVecMetricExporter
+	NvidiaXidEventGauge       *common.GaugeVecMetricExporter
+	NvidiaPodEnergyGauge      *common.GaugeVecMetricExporter
+	// podEnergyLabels are the pods exported by the last ExportMetrics, to
+	// delete the series of the pods the collector forgot
+	podEnergyLabels map[string][]string
 }
 
 func NewNvidiaMetrics() *NvidiaMetrics {
@@ -33,6 +38,8 @@ func NewNvidiaMetrics() *NvidiaMetrics {
 	NvidiaDeviceClkEventGauge := common.NewGaugeVecMetricExporter(MetricPrefix, []string{"index", "clock_event_reason_id"})
 	NvidiaIBGDAStatusGauge := common.NewGaugeVecMetricExporter(MetricPrefix, []string{"status"})
 	NvidiaP2PStatusGauge := common.NewGaugeVecMetricExporter(MetricPrefix, []string{"status"})
+	NvidiaXidEventGauge := common.NewGaugeVecMetricExporter(MetricPrefix, []string{"index", "xid"})
+	NvidiaPodEnergyGauge := common.NewGaugeVecMetricExporter(MetricPrefix, []string{"namespace", "pod"})
 	return &NvidiaMetrics{
 		NvidiaDevCntGauge:         NvidiaDevCntGauge,
 		NvidiaSoftwareInfoGauge:   NvidiaSoftwareInfoGauge,
@@ -41,6 +48,9 @@ func NewNvidiaMetrics() *NvidiaMetrics {
 		NvidiaDeviceClkEventGauge: NvidiaDeviceClkEventGauge,
 		NvidiaIBGDAStatusGauge:    NvidiaIBGDAStatusGauge,
 		NvidiaP2PStatusGauge:      NvidiaP2PStatusGauge,
+		NvidiaXidEventGauge:       NvidiaXidEventGauge,
+		NvidiaPodEnergyGauge:      NvidiaPodEnergyGauge,
+		podEnergyLabels:           make(map[string][]string),
 	}
 }
 
@@ -106,4 +116,33 @@ func (m *NvidiaMetrics) ExportMetrics(metrics *collector.NvidiaInfo) {
 		m.NvidiaDeviceGauge.ExportStruct(device.MemoryErrors.VolatileECC, []string{deviceIdx}, TagPrefix)
 		m.NvidiaDeviceGauge.ExportStruct(device.MemoryErrors.AggregateECC, []string{deviceIdx}, TagPrefix)
 	}
+	m.exportEnergy(metrics.Energy)
+}
+
+// exportEnergy exports the average power of each GPU over the last interval
+// and the GPU energy accumulated by each pod, for chargeback dashboards.
+func (m *NvidiaMetrics) exportEnergy(energy *collector.EnergyInfo) {
+	if energy == nil {
+		return
+	}
+	for _, device := range energy.Devices {
+		m.NvidiaDeviceGauge.SetMetric("avg_power_W", []string{fmt.Sprintf("%d", device.Index)}, device.AvgPowerW)
+	}
+	exported := make(map[string][]string, len(energy.Pods))
+	for _, pod := range energy.Pods {
+		labels := []string{pod.Namespace, pod.PodName}
+		m.NvidiaPodEnergyGauge.SetMetric("pod_gpu_energy_joules", labels, pod.EnergyJ)
+		exported[pod.Namespace+"/"+pod.PodName] = labels
+	}
+	for key, labels := range m.podEnergyLabels {
+		if _, ok := exported[key]; !ok {
+			m.NvidiaPodEnergyGauge.DeleteLabelValues("pod_gpu_energy_joules", labels)
+		}
+	}
+	m.podEnergyLabels = exported
+}
+
+// ExportXidCount exports the accumulated occurrences of an xid on a GPU.
+func (m *NvidiaMetrics) ExportXidCount(index int, xid uint64, count uint64) {
+	m.NvidiaXidEventGauge.SetMetric("xid_event_count", []string{fmt.Sprintf("%d", index), fmt.Sprintf("%d", xid)}, float64(count))
 }
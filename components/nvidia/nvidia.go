@@ -27,11 +27,13 @@ import (
 	"github.com/NVIDIA/go-nvml/pkg/nvml"
 	"github.com/scitix/sichek/components/common"
 	"github.com/scitix/sichek/components/nvidia/checker"
+	dependence "github.com/scitix/sichek/components/nvidia/checker/check_dependences"
 	"github.com/scitix/sichek/components/nvidia/collector"
 	"github.com/scitix/sichek/components/nvidia/config"
 	"github.com/scitix/sichek/components/nvidia/metrics"
 	nvidiautils "github.com/scitix/sichek/components/nvidia/utils"
 	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
 	"github.com/scitix/sichek/pkg/utils"
 
 	"github.com/sirupsen/logrus"
@@ -48,6 +50,7 @@ type component struct {
 	nvmlInstPtr *nvml.Interface // Shared pointer to NVML instance for collector and checkers
 	collector   *collector.NvidiaCollector
 	checkers    []common.Checker
+	specMtx     sync.RWMutex
 
 	cacheMtx    sync.RWMutex
 	cacheBuffer []*common.Result
@@ -56,12 +59,18 @@ type component struct {
 	cacheSize   int64
 
 	xidPoller *XidEventPoller
+	xidPolicy *XidPolicyEngine
+	// xidHistory holds the critical xids logged since boot before sichek started
+	xidHistory *XidHistory
+	lifecycle  *common.LifecycleAdvisor
+	// eccHistory outlives the checkers rebuilt on a spec reload
+	eccHistory *checker.ECCTrendHistory
 
 	healthCheckMtx sync.Mutex
 	serviceMtx     sync.RWMutex
 	nvmlMtx        sync.RWMutex
 	running        bool
-	resultChannel  chan *common.Result
+	results        *common.ResultQueue
 
 	metrics *metrics.NvidiaMetrics
 
@@ -165,7 +174,7 @@ func ReNewNvml(c *component) error {
 		c.serviceMtx.RUnlock()
 		var newPoller *XidEventPoller
 		if isRunning && c.cfg != nil && c.cfg.Nvidia != nil && c.cfg.Nvidia.IsXidPollerEnabled() {
-			poller, err := NewXidEventPoller(c.ctx, c.cfg, nvmlInst, &c.nvmlMtx, c.resultChannel)
+			poller, err := NewXidEventPoller(c.ctx, c.cfg, nvmlInst, &c.nvmlMtx, c.results, c.xidPolicy, c.lifecycle)
 			if err != nil {
 				logrus.WithField("component", "nvidia").Errorf("failed to recreate xid poller after NVML reinit: %v", err)
 			} else {
@@ -173,7 +182,7 @@ func ReNewNvml(c *component) error {
 				go func() {
 					defer func() {
 						if err := recover(); err != nil {
-							fmt.Printf("[xidPoller] panic err is %s\n", err)
+							printer.Printf("[xidPoller] panic err is %s\n", err)
 						}
 					}()
 					err := poller.Start()
@@ -227,7 +236,8 @@ func newNvidia(cfgFile string, specFile string, ignoredCheckers []string) (comp
 		serviceMtx:     sync.RWMutex{},
 		nvmlMtx:        sync.RWMutex{},
 		running:        false,
-		resultChannel:  make(chan *common.Result),
+		results:        common.NewResultQueue(consts.ComponentNameNvidia, common.DefaultResultQueueSize),
+		eccHistory:     checker.NewECCTrendHistory(),
 	}
 
 	nvidiaCfg := &config.NvidiaUserConfig{}
@@ -270,16 +280,24 @@ func newNvidia(cfgFile string, specFile string, ignoredCheckers []string) (comp
 	component.nvmlInst = nvmlInst
 	component.nvmlInstPtr = &nvmlInst
 
-	nvidiaSpecCfg, err := config.LoadSpec(specFile)
-	if err != nil {
-		logrus.WithField("component", "nvidia").Errorf("LoadSpec failed: %v", err)
-		component.initError = fmt.Errorf("spec loading failed: %w", err)
-		return component, nil
-	}
-	if nvidiaSpecCfg == nil {
-		logrus.WithField("component", "nvidia").Errorf("LoadSpec returned nil spec")
-		component.initError = fmt.Errorf("NVIDIA spec is nil after loading from %s", specFile)
-		return component, nil
+	// A missing or invalid spec degrades the component to a single SpecMissing
+	// checker; the collector is then sized by the GPUs NVML actually sees.
+	nvidiaSpecCfg, specErr := config.LoadSpec(specFile)
+	if specErr == nil && nvidiaSpecCfg == nil {
+		specErr = fmt.Errorf("NVIDIA spec is nil after loading from %s", specFile)
+	}
+	expectedGpuNums, expectedGpuName := 0, ""
+	if specErr != nil {
+		logrus.WithField("component", "nvidia").Warnf("LoadSpec failed, degrade to SpecMissing: %v", specErr)
+		nvidiaSpecCfg = nil
+		deviceCount, ret := nvmlInst.DeviceGetCount()
+		if !errors.Is(ret, nvml.SUCCESS) {
+			component.initError = fmt.Errorf("spec loading failed: %w, and get device count failed: %v", specErr, nvml.ErrorString(ret))
+			return component, nil
+		}
+		expectedGpuNums = deviceCount
+	} else {
+		expectedGpuNums, expectedGpuName = nvidiaSpecCfg.GpuNums, nvidiaSpecCfg.Name
 	}
 
 	// Use a timeout for collector init so nvidia-smi (SoftwareInfo.Get) cannot hang forever
@@ -288,7 +306,7 @@ func newNvidia(cfgFile string, specFile string, ignoredCheckers []string) (comp
 	// Pass the shared pointer to collector
 	// Note: NVML calls in collector are protected by locks in nvidia.go where collector methods are called
 	component.nvmlMtx.Lock()
-	collectorPointer, err := collector.NewNvidiaCollector(collectorCtx, component.nvmlInstPtr, nvidiaSpecCfg.GpuNums, nvidiaSpecCfg.Name)
+	collectorPointer, err := collector.NewNvidiaCollector(collectorCtx, component.nvmlInstPtr, expectedGpuNums, expectedGpuName)
 	component.nvmlMtx.Unlock()
 	if err != nil {
 		logrus.WithField("component", "nvidia").Errorf("NewNvidiaCollector failed: %v", err)
@@ -296,16 +314,33 @@ func newNvidia(cfgFile string, specFile string, ignoredCheckers []string) (comp
 		return component, nil
 	}
 
-	checkers, err := checker.NewCheckers(nvidiaCfg, nvidiaSpecCfg)
+	var checkers []common.Checker
+	if nvidiaSpecCfg == nil {
+		checkers = []common.Checker{common.NewSpecMissingChecker(consts.ComponentNameNvidia, specErr)}
+	} else {
+		checkers, err = checker.NewCheckers(nvidiaCfg, nvidiaSpecCfg, component.eccHistory)
+		if err != nil {
+			logrus.WithField("component", "nvidia").Errorf("NewCheckers failed: %v", err)
+			component.initError = fmt.Errorf("failed to create nvidia checkers: %w", err)
+			return component, nil
+		}
+	}
+
+	lifecycle, err := common.NewLifecycleAdvisor(config.DefaultLifecycleRules, nvidiaCfg.Nvidia.LifecycleRules)
 	if err != nil {
-		logrus.WithField("component", "nvidia").Errorf("NewCheckers failed: %v", err)
-		component.initError = fmt.Errorf("failed to create nvidia checkers: %w", err)
-		return component, nil
+		logrus.WithField("component", "nvidia").Warnf("invalid lifecycle_rules, using the default rules: %v", err)
+		lifecycle, _ = common.NewLifecycleAdvisor(config.DefaultLifecycleRules, nil)
 	}
 
+	// The policy outlives the pollers recreated by ReNewNvml, so the xid counts are kept.
+	xidPolicy := NewXidPolicyEngine(nvidiaCfg.Nvidia.XidPolicy)
+	var xidHistory *XidHistory
+	if nvidiaCfg.Nvidia.EnableXidBackfill {
+		xidHistory = BackfillXidHistory(ctx, xidPolicy)
+	}
 	var xidPoller *XidEventPoller
 	if nvidiaCfg.Nvidia.IsXidPollerEnabled() {
-		xidPoller, err = NewXidEventPoller(ctx, nvidiaCfg, nvmlInst, &component.nvmlMtx, component.resultChannel)
+		xidPoller, err = NewXidEventPoller(ctx, nvidiaCfg, nvmlInst, &component.nvmlMtx, component.results, xidPolicy, lifecycle)
 		if err != nil {
 			logrus.WithField("component", "nvidia").Errorf("NewXidEventPoller failed: %v", err)
 			component.initError = fmt.Errorf("failed to create XID event poller: %w", err)
@@ -327,6 +362,9 @@ func newNvidia(cfgFile string, specFile string, ignoredCheckers []string) (comp
 	component.collector = collectorPointer
 	component.checkers = checkers
 	component.xidPoller = xidPoller
+	component.xidPolicy = xidPolicy
+	component.xidHistory = xidHistory
+	component.lifecycle = lifecycle
 	component.metrics = nvidiaMetrics
 
 	return component, nil
@@ -355,7 +393,7 @@ func (c *component) checkInitError() (*common.Result, bool) {
 	result := &common.Result{
 		Item:     consts.ComponentNameNvidia,
 		Status:   consts.StatusAbnormal,
-		Checkers: []*common.CheckerResult{checkerResult},
+		Checkers: append([]*common.CheckerResult{checkerResult}, c.diagnoseDriver(c.ctx)...),
 		Time:     time.Now(),
 	}
 	return result, true
@@ -374,12 +412,37 @@ func (c *component) reportInitNVMLError(err error) *common.Result {
 	result := &common.Result{
 		Item:     consts.ComponentNameNvidia,
 		Status:   consts.StatusAbnormal,
-		Checkers: []*common.CheckerResult{checkerResult},
+		Checkers: append([]*common.CheckerResult{checkerResult}, c.diagnoseDriver(c.ctx)...),
 		Time:     time.Now(),
 	}
 	return result
 }
 
+// diagnoseDriver runs the checkers that do not need NVML, returning the
+// abnormal ones to explain why NVML fails to initialize, e.g. a driver
+// upgraded without a reboot, instead of only retrying.
+func (c *component) diagnoseDriver(ctx context.Context) []*common.CheckerResult {
+	var results []*common.CheckerResult
+	for _, newChecker := range []func(*config.NvidiaSpec) (common.Checker, error){
+		dependence.NewNvidiaDriverMismatchChecker,
+		dependence.NewKernelTaintChecker,
+	} {
+		chk, err := newChecker(nil)
+		if err != nil {
+			continue
+		}
+		result, err := chk.Check(ctx, nil)
+		if err != nil {
+			logrus.WithField("component", "nvidia").Warnf("failed to run %s: %v", chk.Name(), err)
+			continue
+		}
+		if result.Status == consts.StatusAbnormal {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
 func (c *component) HealthCheck(ctx context.Context) (*common.Result, error) {
 	c.healthCheckMtx.Lock()
 	defer c.healthCheckMtx.Unlock()
@@ -401,7 +464,7 @@ func (c *component) HealthCheck(ctx context.Context) (*common.Result, error) {
 	timer := common.NewTimer(fmt.Sprintf("%s-HealthCheck-Cost", c.componentName))
 	// Protect all NVML calls in collector with RLock
 	c.nvmlMtx.RLock()
-	nvidiaInfo, err := c.collector.Collect(ctx)
+	nvidiaInfo, err := common.Collect(ctx, c.componentName, c.collector.Collect)
 	c.nvmlMtx.RUnlock()
 	timer.Mark("Collect")
 
@@ -441,8 +504,18 @@ func (c *component) HealthCheck(ctx context.Context) (*common.Result, error) {
 	// Successfully collected info, continue with normal flow
 	if c.cfg.Nvidia.EnableMetrics {
 		c.metrics.ExportMetrics(nvidiaInfo)
+		if c.xidPolicy != nil {
+			for _, count := range c.xidPolicy.Counts() {
+				c.metrics.ExportXidCount(count.Device, count.Xid, count.Count)
+			}
+		}
 	}
-	result := common.Check(ctx, c.componentName, nvidiaInfo, c.checkers)
+	c.specMtx.RLock()
+	checkers := c.checkers
+	c.specMtx.RUnlock()
+	result := common.Check(ctx, c.componentName, nvidiaInfo, checkers)
+	c.xidHistory.Apply(result)
+	c.lifecycle.Advise(result)
 	timer.Mark("check")
 	c.cacheMtx.Lock()
 	c.cacheBuffer[c.currIndex] = result
@@ -493,15 +566,35 @@ func (c *component) LastInfo() (common.Info, error) {
 	return info, nil
 }
 
+// UnhealthyDevices returns the GPUs the abnormal checkers of the last health check
+// reported with one of the given levels, so that they can be drained one by one.
+func (c *component) UnhealthyDevices(levels ...string) ([]*common.DeviceResult, error) {
+	result, err := c.LastResult()
+	if err != nil {
+		return nil, err
+	}
+	return common.UnhealthyDevices(result, levels...), nil
+}
+
+// Metrics returns the accumulated xid counts per GPU.
 func (c *component) Metrics(ctx context.Context, since time.Time) (interface{}, error) {
-	return nil, nil
+	if c.xidPolicy == nil {
+		return nil, nil
+	}
+	var counts []XidCount
+	for _, count := range c.xidPolicy.Counts() {
+		if !count.Last.Before(since) {
+			counts = append(counts, count)
+		}
+	}
+	return counts, nil
 }
 
 func (c *component) Start() <-chan *common.Result {
 	c.serviceMtx.Lock()
 	if c.running {
 		c.serviceMtx.Unlock()
-		return c.resultChannel
+		return c.results.C()
 	}
 	c.running = true
 	c.serviceMtx.Unlock()
@@ -509,7 +602,7 @@ func (c *component) Start() <-chan *common.Result {
 	go func() {
 		defer func() {
 			if err := recover(); err != nil {
-				fmt.Printf("[NvidiaStart] panic err is %s\n", err)
+				printer.Printf("[NvidiaStart] panic err is %s\n", err)
 			}
 		}()
 		c.cfgMutex.RLock()
@@ -538,7 +631,7 @@ func (c *component) Start() <-chan *common.Result {
 				}
 				result, err := common.RunHealthCheckWithTimeout(c.ctx, c.GetTimeout(), c.componentName, c.HealthCheck)
 				if err != nil {
-					fmt.Printf("%s HealthCheck failed: %v\n", c.componentName, err)
+					printer.Printf("%s HealthCheck failed: %v\n", c.componentName, err)
 					continue
 				}
 				// Check if the error message contains "Timeout"
@@ -554,9 +647,7 @@ func (c *component) Start() <-chan *common.Result {
 					}
 				}
 				c.checkXidPollerResult(result)
-				c.serviceMtx.Lock()
-				c.resultChannel <- result
-				c.serviceMtx.Unlock()
+				c.results.Send(result)
 			}
 		}
 	}()
@@ -564,7 +655,7 @@ func (c *component) Start() <-chan *common.Result {
 	go func() {
 		defer func() {
 			if err := recover(); err != nil {
-				fmt.Printf("[xidPoller] panic err is %s\n", err)
+				printer.Printf("[xidPoller] panic err is %s\n", err)
 			}
 		}()
 		if c.xidPoller == nil {
@@ -579,13 +670,13 @@ func (c *component) Start() <-chan *common.Result {
 	c.serviceMtx.Lock()
 	c.running = true
 	c.serviceMtx.Unlock()
-	return c.resultChannel
+	return c.results.C()
 }
 
 func (c *component) Stop() error {
 	c.cancel()
 	c.serviceMtx.Lock()
-	close(c.resultChannel)
+	c.results.Close()
 	c.running = false
 	c.serviceMtx.Unlock()
 
@@ -607,6 +698,34 @@ func (c *component) Update(cfg common.ComponentUserConfig) error {
 	}
 	c.cfg = configPointer
 	c.cfgMutex.Unlock()
+	common.GetFreqController().RegisterModule(consts.ComponentNameNvidia, configPointer)
+	return nil
+}
+
+// UpdateSpec reloads the nvidia spec and rebuilds the checkers from it. The
+// collector keeps the expected GPU count of the spec it was created with.
+func (c *component) UpdateSpec(specFile string) error {
+	if c.initError != nil {
+		return fmt.Errorf("nvidia component is not initialized: %w", c.initError)
+	}
+	spec, err := config.LoadSpec(specFile)
+	if err != nil {
+		return fmt.Errorf("load nvidia spec %s: %w", specFile, err)
+	}
+	if spec == nil {
+		return fmt.Errorf("NVIDIA spec is nil after loading from %s", specFile)
+	}
+	c.cfgMutex.RLock()
+	cfg := c.cfg
+	c.cfgMutex.RUnlock()
+	checkers, err := checker.NewCheckers(cfg, spec, c.eccHistory)
+	if err != nil {
+		return err
+	}
+	c.specMtx.Lock()
+	c.checkers = checkers
+	c.specMtx.Unlock()
+	logrus.WithField("component", "nvidia").Infof("reloaded spec from %s", specFile)
 	return nil
 }
 
@@ -853,40 +972,40 @@ func (c *component) PrintInfo(info common.Info, result *common.Result, summaryPr
 
 		gpuNumPrint := "GPU NUMs:"
 		if len(nvidiaInfo.DevicesInfo) > 0 {
-			fmt.Printf("%s\n", nvidiaInfo.DevicesInfo[0].Name)
+			printer.Printf("%s\n", nvidiaInfo.DevicesInfo[0].Name)
 		} else {
-			fmt.Printf("No GPU devices available\n")
+			printer.Printf("No GPU devices available\n")
 		}
-		fmt.Printf("%-*s%-*s%-*s\n", printInterval, driverPrint, printInterval, iommuPrint, printInterval, persistencePrint)
-		fmt.Printf("%-*s%-*s%-*s\n", printInterval, cudaVersionPrint, printInterval, acsPrint, printInterval, pstatePrint)
-		fmt.Printf("%-*s%-*s\n", printInterval, p2pPrint, printInterval, ibgdaPrint)
-		fmt.Printf("%-*s%-*s%-*s\n", printInterval-consts.PadLen, gpuNumPrint, printInterval, peermemPrint, printInterval, nvlinkPrint)
-		fmt.Printf("%-*s%-*s%-*s\n", printInterval+consts.PadLen, gpuStatusPrint, printInterval, fabricmanagerPrint, printInterval, pcieLinkPrint)
-		fmt.Println()
+		printer.Printf("%-*s%-*s%-*s\n", printInterval, driverPrint, printInterval, iommuPrint, printInterval, persistencePrint)
+		printer.Printf("%-*s%-*s%-*s\n", printInterval, cudaVersionPrint, printInterval, acsPrint, printInterval, pstatePrint)
+		printer.Printf("%-*s%-*s\n", printInterval, p2pPrint, printInterval, ibgdaPrint)
+		printer.Printf("%-*s%-*s%-*s\n", printInterval-consts.PadLen, gpuNumPrint, printInterval, peermemPrint, printInterval, nvlinkPrint)
+		printer.Printf("%-*s%-*s%-*s\n", printInterval+consts.PadLen, gpuStatusPrint, printInterval, fabricmanagerPrint, printInterval, pcieLinkPrint)
+		printer.Println()
 	}
 	if len(systemEvent) > 0 {
-		fmt.Println("System Settings and Status:")
+		printer.Println("System Settings and Status:")
 		for _, v := range systemEvent {
-			fmt.Printf("\t%s\n", v)
+			printer.Printf("\t%s\n", v)
 		}
 	}
 	if len(gpuStatus) > 0 {
-		fmt.Println("NVIDIA GPU:")
+		printer.Println("NVIDIA GPU:")
 		for _, v := range gpuStatus {
-			fmt.Printf("\t%s\n", v)
+			printer.Printf("\t%s\n", v)
 		}
 	}
-	fmt.Println("Clock Events:")
+	printer.Println("Clock Events:")
 	for _, v := range clockEvents {
-		fmt.Printf("\t%s\n", v)
+		printer.Printf("\t%s\n", v)
 	}
-	fmt.Println("Memory ECC:")
+	printer.Println("Memory ECC:")
 	for _, v := range eccEvents {
-		fmt.Printf("\t%s\n", v)
+		printer.Printf("\t%s\n", v)
 	}
-	fmt.Println("Remapped Rows:")
+	printer.Println("Remapped Rows:")
 	for _, v := range remmapedRowsEvents {
-		fmt.Printf("\t%s\n", v)
+		printer.Printf("\t%s\n", v)
 	}
 	return checkAllPassed
 }
@@ -0,0 +1,289 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package nvidia
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/nvidia/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// backfillSkippedXids are the critical xids a job commonly causes, e.g. an
+// illegal memory access, they say nothing about the GPU once the job is gone.
+var backfillSkippedXids = map[uint64]bool{31: true}
+
+var (
+	// NVRM: Xid (PCI:0000:18:00): 79, pid=1234, name=python, GPU has fallen off the bus.
+	nvrmXidRe = regexp.MustCompile(`NVRM: Xid \(PCI:([0-9a-fA-F]+:[0-9a-fA-F]+:[0-9a-fA-F]+)(?:\.[0-7])?\): (\d+),\s*(.*)$`)
+	// GPU 00000000:18:00.0
+	smiGPUHeaderRe = regexp.MustCompile(`^GPU ([0-9a-fA-F]+:[0-9a-fA-F]+:[0-9a-fA-F]+\.[0-7])\s*$`)
+	smiFieldRe     = regexp.MustCompile(`^\s*(GPU UUID|Minor Number|Module ID)\s*:\s*(\S+)`)
+)
+
+// XidRecord is an xid the NVIDIA driver logged to the kernel log.
+type XidRecord struct {
+	// Time is zero when the log line carries no parsable timestamp.
+	Time  time.Time `json:"time"`
+	BusID string    `json:"bus_id"`
+	// Device is the module id of the GPU, as reported by the XidEventPoller, -1 if unknown.
+	Device  int    `json:"device"`
+	UUID    string `json:"uuid,omitempty"`
+	Xid     uint64 `json:"xid"`
+	Message string `json:"message"`
+}
+
+// xidGPU identifies the GPU at a PCI address in the nvidia-smi -q output.
+type xidGPU struct {
+	device int
+	uuid   string
+}
+
+// XidHistory holds the critical xids the driver logged since the last boot,
+// before sichek started, so that a GPU broken before a restart of sichek is
+// not reported healthy because the XidEventPoller only sees new events.
+type XidHistory struct {
+	Records []XidRecord
+}
+
+// BackfillXidHistory reads the xids logged since boot from the kernel log and
+// maps them to the GPUs with nvidia-smi -q. The xids are recorded in policy so
+// that the xid counts include them, a nil policy is allowed.
+func BackfillXidHistory(ctx context.Context, policy *XidPolicyEngine) *XidHistory {
+	ctx, cancel := context.WithTimeout(ctx, consts.CmdTimeout)
+	defer cancel()
+
+	// journalctl keeps the whole boot, the dmesg ring buffer may have wrapped
+	out, err := utils.ExecCommand(ctx, "journalctl", "-k", "-b", "--no-pager", "-o", "short-iso")
+	if err != nil {
+		logrus.WithField("component", "nvidia").Debugf("journalctl -k failed, fall back to dmesg: %v", err)
+		out, err = utils.ExecCommand(ctx, "dmesg", "-T")
+		if err != nil {
+			logrus.WithField("component", "nvidia").Warnf("failed to read the kernel log for the xid history: %v", err)
+			return &XidHistory{}
+		}
+	}
+	records := ParseNVRMXids(string(out))
+	if len(records) == 0 {
+		return &XidHistory{}
+	}
+
+	var gpus map[string]xidGPU
+	if smi, err := utils.ExecCommand(ctx, "nvidia-smi", "-q"); err != nil {
+		logrus.WithField("component", "nvidia").Warnf("failed to map the xid history to the GPUs: %v", err)
+	} else {
+		gpus = parseNvidiaSmiBusIDs(string(smi))
+	}
+	history := newXidHistory(records, gpus)
+	for _, record := range history.Records {
+		if policy != nil {
+			when := record.Time
+			if when.IsZero() {
+				when = time.Now()
+			}
+			policy.Evaluate(record.Device, record.Xid, config.CriticalXidEvent[record.Xid], when)
+		}
+		logrus.WithField("component", "nvidia").Warnf("GPU device %d logged critical xid %d since boot: %s", record.Device, record.Xid, record.Message)
+	}
+	return history
+}
+
+// newXidHistory keeps the critical xids of records, with their GPU resolved
+// from gpus keyed by the bus id of the kernel log.
+func newXidHistory(records []XidRecord, gpus map[string]xidGPU) *XidHistory {
+	history := &XidHistory{}
+	for _, record := range records {
+		if !config.IsCriticalXidEvent(record.Xid) || backfillSkippedXids[record.Xid] {
+			continue
+		}
+		record.Device = -1
+		if gpu, ok := gpus[record.BusID]; ok {
+			record.Device = gpu.device
+			record.UUID = gpu.uuid
+		}
+		history.Records = append(history.Records, record)
+	}
+	return history
+}
+
+// ParseNVRMXids parses the xids of the kernel log, as printed by
+// `journalctl -k -o short-iso` or `dmesg -T`.
+func ParseNVRMXids(output string) []XidRecord {
+	var records []XidRecord
+	for _, line := range strings.Split(output, "\n") {
+		m := nvrmXidRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		xid, err := strconv.ParseUint(m[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		records = append(records, XidRecord{
+			Time:    parseKernelLogTime(line),
+			BusID:   normalizeXidBusID(m[1]),
+			Xid:     xid,
+			Message: strings.TrimSpace(m[3]),
+		})
+	}
+	return records
+}
+
+// parseKernelLogTime parses the leading "2024-05-01T12:00:00+0800" of
+// journalctl or "[Wed May  1 12:00:00 2024]" of dmesg -T.
+func parseKernelLogTime(line string) time.Time {
+	if strings.HasPrefix(line, "[") {
+		if end := strings.Index(line, "]"); end > 0 {
+			if t, err := time.ParseInLocation(time.ANSIC, strings.TrimSpace(line[1:end]), time.Local); err == nil {
+				return t
+			}
+		}
+		return time.Time{}
+	}
+	field, _, _ := strings.Cut(line, " ")
+	for _, layout := range []string{"2006-01-02T15:04:05-0700", time.RFC3339} {
+		if t, err := time.Parse(layout, field); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// normalizeXidBusID turns the 8 digit domain of nvidia-smi and the 4 digit
+// one of the kernel log into domain:bus:device, without the function the
+// kernel log omits.
+func normalizeXidBusID(busID string) string {
+	busID = strings.ToLower(busID)
+	if i := strings.LastIndex(busID, "."); i > 0 {
+		busID = busID[:i]
+	}
+	parts := strings.Split(busID, ":")
+	if len(parts) == 3 && len(parts[0]) > 4 {
+		parts[0] = parts[0][len(parts[0])-4:]
+	}
+	return strings.Join(parts, ":")
+}
+
+// parseNvidiaSmiBusIDs maps the bus id of every GPU of `nvidia-smi -q` to its
+// module id, or its minor number on the GPUs without one, and its UUID.
+func parseNvidiaSmiBusIDs(output string) map[string]xidGPU {
+	gpus := make(map[string]xidGPU)
+	var busID string
+	minor := -1
+	var gpu xidGPU
+	flush := func() {
+		if busID == "" {
+			return
+		}
+		if gpu.device < 0 {
+			gpu.device = minor
+		}
+		gpus[busID] = gpu
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if m := smiGPUHeaderRe.FindStringSubmatch(strings.TrimRight(line, "\r")); m != nil {
+			flush()
+			busID = normalizeXidBusID(m[1])
+			minor = -1
+			gpu = xidGPU{device: -1}
+			continue
+		}
+		m := smiFieldRe.FindStringSubmatch(line)
+		if m == nil || busID == "" {
+			continue
+		}
+		switch m[1] {
+		case "GPU UUID":
+			gpu.uuid = m[2]
+		case "Minor Number":
+			if v, err := strconv.Atoi(m[2]); err == nil {
+				minor = v
+			}
+		case "Module ID":
+			if v, err := strconv.Atoi(m[2]); err == nil {
+				gpu.device = v
+			}
+		}
+	}
+	flush()
+	return gpus
+}
+
+// CheckerResults returns an abnormal result per xid, listing the GPUs that
+// logged it since boot.
+func (h *XidHistory) CheckerResults() []*common.CheckerResult {
+	if h == nil || len(h.Records) == 0 {
+		return nil
+	}
+	byXid := make(map[uint64][]XidRecord)
+	var xids []uint64
+	for _, record := range h.Records {
+		if _, ok := byXid[record.Xid]; !ok {
+			xids = append(xids, record.Xid)
+		}
+		byXid[record.Xid] = append(byXid[record.Xid], record)
+	}
+	sort.Slice(xids, func(i, j int) bool { return xids[i] < xids[j] })
+
+	results := make([]*common.CheckerResult, 0, len(xids))
+	for _, xid := range xids {
+		event := config.CriticalXidEvent[xid]
+		event.Status = consts.StatusAbnormal
+		var details, devices []string
+		seen := make(map[int]bool)
+		for _, record := range byXid[xid] {
+			when := "before sichek started"
+			if !record.Time.IsZero() {
+				when = "at " + record.Time.Format(time.RFC3339)
+			}
+			details = append(details, fmt.Sprintf("GPU device %d (%s) logged critical xid %d %s: %s\n", record.Device, record.BusID, xid, when, record.Message))
+			if record.Device >= 0 && !seen[record.Device] {
+				seen[record.Device] = true
+				devices = append(devices, strconv.Itoa(record.Device))
+				event.Devices = append(event.Devices, &common.DeviceResult{Index: record.Device, UUID: record.UUID})
+			}
+		}
+		event.Device = strings.Join(devices, ",")
+		event.Curr = fmt.Sprintf("%d occurrences since boot", len(byXid[xid]))
+		event.Detail = strings.Join(details, "")
+		results = append(results, &event)
+	}
+	return results
+}
+
+// Apply adds the xids logged since boot to result, so that the GPUs stay
+// abnormal until the node reboots.
+func (h *XidHistory) Apply(result *common.Result) {
+	if result == nil {
+		return
+	}
+	for _, checker := range h.CheckerResults() {
+		result.Checkers = append(result.Checkers, checker)
+		result.Status = consts.StatusAbnormal
+		if consts.LevelPriority[checker.Level] > consts.LevelPriority[result.Level] {
+			result.Level = checker.Level
+		}
+	}
+}
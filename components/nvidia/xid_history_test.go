@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package nvidia
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+)
+
+const testJournalKernelLog = `2024-05-01T11:59:58+0800 gpu-node-1 kernel: NVRM: loading NVIDIA UNIX x86_64 Kernel Module  535.154.05
+2024-05-01T12:00:00+0800 gpu-node-1 kernel: NVRM: Xid (PCI:0000:18:00): 31, pid=4242, name=python, Ch 00000008, intr 00000000. MMU Fault: ENGINE GRAPHICS GPCCLIENT_T1_0 faulted @ 0x7f1c_00000000.
+2024-05-01T12:00:01+0800 gpu-node-1 kernel: NVRM: Xid (PCI:0000:18:00): 79, pid='<unknown>', name=<unknown>, GPU has fallen off the bus.
+2024-05-01T12:00:02+0800 gpu-node-1 kernel: NVRM: Xid (PCI:0000:9a:00): 48, pid=4243, name=python, An uncorrectable double bit error (DBE) has been detected on GPU in the framebuffer at partition 0, subpartition 0.
+2024-05-01T12:00:03+0800 gpu-node-1 kernel: NVRM: Xid (PCI:0000:9a:00): 13, pid=4243, name=python, Graphics SM Warp Exception on (GPC 0, TPC 0, SM 0).
+`
+
+const testNvidiaSmiQuery = `==============NVSMI LOG==============
+
+Driver Version                            : 535.154.05
+Attached GPUs                             : 2
+GPU 00000000:18:00.0
+    Product Name                          : NVIDIA H100 80GB HBM3
+    Minor Number                          : 0
+    GPU UUID                              : GPU-aaaaaaaa-0000-0000-0000-000000000000
+    Module ID                             : 2
+GPU 00000000:9A:00.0
+    Product Name                          : NVIDIA H100 80GB HBM3
+    Minor Number                          : 5
+    GPU UUID                              : GPU-bbbbbbbb-0000-0000-0000-000000000000
+    Module ID                             : N/A
+`
+
+func TestParseNVRMXids(t *testing.T) {
+	records := ParseNVRMXids(testJournalKernelLog)
+	if len(records) != 4 {
+		t.Fatalf("expected 4 xids, got %d: %+v", len(records), records)
+	}
+	if records[1].Xid != 79 || records[1].BusID != "0000:18:00" || records[1].Message != "pid='<unknown>', name=<unknown>, GPU has fallen off the bus." {
+		t.Errorf("unexpected record %+v", records[1])
+	}
+	want := time.Date(2024, 5, 1, 4, 0, 1, 0, time.UTC)
+	if !records[1].Time.Equal(want) {
+		t.Errorf("expected time %v, got %v", want, records[1].Time)
+	}
+
+	dmesg := ParseNVRMXids("[Wed May  1 12:00:01 2024] NVRM: Xid (PCI:0000:18:00.0): 94, pid=1, Contained: SM (0x1).")
+	if len(dmesg) != 1 || dmesg[0].Xid != 94 || dmesg[0].BusID != "0000:18:00" || dmesg[0].Time.IsZero() {
+		t.Errorf("unexpected dmesg -T record %+v", dmesg)
+	}
+}
+
+func TestParseNvidiaSmiBusIDs(t *testing.T) {
+	gpus := parseNvidiaSmiBusIDs(testNvidiaSmiQuery)
+	if gpu := gpus["0000:18:00"]; gpu.device != 2 || gpu.uuid != "GPU-aaaaaaaa-0000-0000-0000-000000000000" {
+		t.Errorf("unexpected GPU at 18:00 %+v", gpu)
+	}
+	// without a module id the minor number identifies the GPU
+	if gpu := gpus["0000:9a:00"]; gpu.device != 5 {
+		t.Errorf("unexpected GPU at 9a:00 %+v", gpu)
+	}
+}
+
+func TestXidHistoryApply(t *testing.T) {
+	history := newXidHistory(ParseNVRMXids(testJournalKernelLog), parseNvidiaSmiBusIDs(testNvidiaSmiQuery))
+	// xid 31 is caused by jobs and xid 13 is not critical
+	if len(history.Records) != 2 {
+		t.Fatalf("expected the critical xids 79 and 48, got %+v", history.Records)
+	}
+
+	result := &common.Result{Item: consts.ComponentNameNvidia, Status: consts.StatusNormal, Level: consts.LevelInfo}
+	history.Apply(result)
+	if result.Status != consts.StatusAbnormal || result.Level != consts.LevelFatal {
+		t.Errorf("expected the fatal xid 79 to fail the result, got %s/%s", result.Status, result.Level)
+	}
+	if len(result.Checkers) != 2 || result.Checkers[0].Name != "xid-48" || result.Checkers[1].Name != "xid-79" {
+		t.Fatalf("unexpected checkers %+v", result.Checkers)
+	}
+	lost := result.Checkers[1]
+	if lost.Device != "2" || len(lost.Devices) != 1 || lost.Devices[0].UUID != "GPU-aaaaaaaa-0000-0000-0000-000000000000" {
+		t.Errorf("expected xid 79 on the GPU with module id 2, got %+v", lost)
+	}
+	if !strings.Contains(lost.Detail, "GPU has fallen off the bus") {
+		t.Errorf("unexpected detail %q", lost.Detail)
+	}
+
+	var empty *XidHistory
+	empty.Apply(result)
+	if len(result.Checkers) != 2 {
+		t.Errorf("expected a nil history to leave the result untouched")
+	}
+}
+
+func TestXidHistoryPopulatesPolicyCounts(t *testing.T) {
+	policy := NewXidPolicyEngine(nil)
+	history := newXidHistory(ParseNVRMXids(testJournalKernelLog), parseNvidiaSmiBusIDs(testNvidiaSmiQuery))
+	for _, record := range history.Records {
+		policy.Evaluate(record.Device, record.Xid, common.CheckerResult{}, record.Time)
+	}
+	counts := policy.Counts()
+	if len(counts) != 2 || counts[0].Device != 2 || counts[0].Xid != 79 || counts[1].Device != 5 || counts[1].Xid != 48 {
+		t.Errorf("unexpected counts %+v", counts)
+	}
+}
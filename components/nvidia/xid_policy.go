@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package nvidia
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/nvidia/config"
+)
+
+const defaultXidDedupWindow = 5 * time.Minute
+
+// XidCount is the number of occurrences of an xid on a GPU since sichek started.
+type XidCount struct {
+	Device int       `json:"device"`
+	Xid    uint64    `json:"xid"`
+	Count  uint64    `json:"count"`
+	Last   time.Time `json:"last"`
+}
+
+type xidKey struct {
+	device int
+	xid    uint64
+}
+
+type xidState struct {
+	windowStart time.Time
+	windowCount int
+	escalated   bool
+	total       uint64
+	last        time.Time
+}
+
+// XidPolicyEngine deduplicates the xid events per GPU within a window and
+// escalates their level when they repeat, so that a noisy xid is reported once
+// per window instead of flooding the result channel.
+type XidPolicyEngine struct {
+	mu       sync.Mutex
+	window   time.Duration
+	policies map[uint64]config.XidPolicy
+	states   map[xidKey]*xidState
+}
+
+func NewXidPolicyEngine(cfg *config.XidPolicyConfig) *XidPolicyEngine {
+	e := &XidPolicyEngine{
+		window:   defaultXidDedupWindow,
+		policies: make(map[uint64]config.XidPolicy),
+		states:   make(map[xidKey]*xidState),
+	}
+	if cfg == nil {
+		return e
+	}
+	if cfg.DedupWindow.Duration > 0 {
+		e.window = cfg.DedupWindow.Duration
+	}
+	for _, policy := range cfg.Policies {
+		e.policies[policy.Xid] = policy
+	}
+	return e
+}
+
+// Evaluate records an occurrence of xid on the device at now. It returns the
+// event to report, with its level escalated if the policy of the xid says so,
+// or nil if the event is a duplicate within the dedup window.
+func (e *XidPolicyEngine) Evaluate(device int, xid uint64, event common.CheckerResult, now time.Time) *common.CheckerResult {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	policy := e.policies[xid]
+	window := e.window
+	if policy.DedupWindow.Duration > 0 {
+		window = policy.DedupWindow.Duration
+	}
+
+	key := xidKey{device: device, xid: xid}
+	state, ok := e.states[key]
+	if !ok {
+		state = &xidState{}
+		e.states[key] = state
+	}
+	if state.windowCount == 0 || now.Sub(state.windowStart) > window {
+		state.windowStart = now
+		state.windowCount = 0
+		state.escalated = false
+	}
+	state.windowCount++
+	state.total++
+	state.last = now
+
+	escalate := policy.EscalateCount > 0 && policy.EscalateLevel != "" && state.windowCount >= policy.EscalateCount
+	// Report the first event of a window, and once more when the xid crosses its escalation threshold.
+	report := state.windowCount == 1 || (escalate && !state.escalated)
+	if escalate {
+		state.escalated = true
+	}
+	if !report {
+		return nil
+	}
+	if escalate {
+		event.Level = policy.EscalateLevel
+		event.Detail = fmt.Sprintf("%s, occurred %d times within %s", event.Detail, state.windowCount, window)
+	}
+	return &event
+}
+
+// Counts returns the accumulated occurrences of every xid per GPU.
+func (e *XidPolicyEngine) Counts() []XidCount {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	counts := make([]XidCount, 0, len(e.states))
+	for key, state := range e.states {
+		counts = append(counts, XidCount{Device: key.device, Xid: key.xid, Count: state.total, Last: state.last})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Device != counts[j].Device {
+			return counts[i].Device < counts[j].Device
+		}
+		return counts[i].Xid < counts[j].Xid
+	})
+	return counts
+}
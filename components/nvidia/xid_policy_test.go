@@ -0,0 +1,77 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package nvidia
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/nvidia/config"
+	"github.com/scitix/sichek/consts"
+)
+
+func TestXidPolicyEngine_Dedup(t *testing.T) {
+	engine := NewXidPolicyEngine(&config.XidPolicyConfig{DedupWindow: common.Duration{Duration: time.Minute}})
+	event := config.CriticalXidEvent[48]
+	now := time.Now()
+
+	if got := engine.Evaluate(0, 48, event, now); got == nil {
+		t.Fatal("first event should be reported")
+	}
+	if got := engine.Evaluate(0, 48, event, now.Add(10*time.Second)); got != nil {
+		t.Errorf("duplicated event within the window should be suppressed, got %+v", got)
+	}
+	if got := engine.Evaluate(1, 48, event, now.Add(10*time.Second)); got == nil {
+		t.Error("same xid on another GPU should be reported")
+	}
+	if got := engine.Evaluate(0, 48, event, now.Add(2*time.Minute)); got == nil {
+		t.Error("event after the window should be reported")
+	}
+
+	counts := engine.Counts()
+	if len(counts) != 2 || counts[0].Device != 0 || counts[0].Count != 3 || counts[1].Count != 1 {
+		t.Errorf("unexpected counts: %+v", counts)
+	}
+}
+
+func TestXidPolicyEngine_Escalate(t *testing.T) {
+	engine := NewXidPolicyEngine(&config.XidPolicyConfig{
+		DedupWindow: common.Duration{Duration: time.Hour},
+		Policies:    []config.XidPolicy{{Xid: 31, EscalateCount: 3, EscalateLevel: consts.LevelFatal}},
+	})
+	event := config.CriticalXidEvent[31]
+	now := time.Now()
+
+	var reported []*common.CheckerResult
+	for i := 0; i < 5; i++ {
+		if got := engine.Evaluate(2, 31, event, now.Add(time.Duration(i)*time.Second)); got != nil {
+			reported = append(reported, got)
+		}
+	}
+	if len(reported) != 2 {
+		t.Fatalf("expected the first and the escalated events, got %d", len(reported))
+	}
+	if reported[0].Level != consts.LevelCritical {
+		t.Errorf("first event level = %s, want %s", reported[0].Level, consts.LevelCritical)
+	}
+	if reported[1].Level != consts.LevelFatal {
+		t.Errorf("escalated event level = %s, want %s", reported[1].Level, consts.LevelFatal)
+	}
+	if config.CriticalXidEvent[31].Level != consts.LevelCritical {
+		t.Error("escalation must not modify the xid event template")
+	}
+}
@@ -34,8 +34,13 @@ type XidEventPoller struct {
 	NvmlInst       nvml.Interface
 	NvmlMtx        *sync.RWMutex
 
-	Cfg       *config.NvidiaUserConfig
-	EventChan chan *common.Result
+	Cfg *config.NvidiaUserConfig
+	// Events queues the xid results, dropping the oldest for a stalled consumer.
+	Events *common.ResultQueue
+	// Policy deduplicates and escalates the events, nil reports every event.
+	Policy *XidPolicyEngine
+	// Lifecycle recommends the action of the reported events, nil leaves it unset.
+	Lifecycle *common.LifecycleAdvisor
 
 	Ctx    context.Context
 	Cancel context.CancelFunc
@@ -44,7 +49,7 @@ type XidEventPoller struct {
 	wg          sync.WaitGroup // Wait for Start() to exit
 }
 
-func NewXidEventPoller(ctx context.Context, cfg *config.NvidiaUserConfig, nvmlInst nvml.Interface, nvmlMtx *sync.RWMutex, eventChan chan *common.Result) (*XidEventPoller, error) {
+func NewXidEventPoller(ctx context.Context, cfg *config.NvidiaUserConfig, nvmlInst nvml.Interface, nvmlMtx *sync.RWMutex, events *common.ResultQueue, policy *XidPolicyEngine, lifecycle *common.LifecycleAdvisor) (*XidEventPoller, error) {
 	xidEventSet, ret := nvmlInst.EventSetCreate()
 	if ret != nvml.SUCCESS {
 		logrus.WithField("component", "nvidia").Errorf("failed to create event set: %v", nvml.ErrorString(ret))
@@ -57,7 +62,9 @@ func NewXidEventPoller(ctx context.Context, cfg *config.NvidiaUserConfig, nvmlIn
 		NvmlInst:       nvmlInst,
 		NvmlMtx:        nvmlMtx,
 		Cfg:            cfg,
-		EventChan:      eventChan,
+		Events:         events,
+		Policy:         policy,
+		Lifecycle:      lifecycle,
 		Ctx:            xctx,
 		Cancel:         xcancel,
 		XidEventSet:    xidEventSet,
@@ -187,20 +194,37 @@ func (x *XidEventPoller) handleEvent(e nvml.EventData) {
 	event := config.CriticalXidEvent[xid]
 	event.Detail = fmt.Sprintf("GPU device %d detect critical xid event %d", deviceID, xid)
 	event.Status = consts.StatusAbnormal
+	if deviceID >= 0 {
+		x.NvmlMtx.RLock()
+		uuid, _ := e.Device.GetUUID()
+		x.NvmlMtx.RUnlock()
+		event.Device = fmt.Sprintf("%d", deviceID)
+		event.Devices = []*common.DeviceResult{{Index: deviceID, UUID: uuid}}
+	}
 	logrus.WithField("component", "nvidia").Errorf("%v\n", event.Detail)
 
+	reported := &event
+	if x.Policy != nil {
+		reported = x.Policy.Evaluate(deviceID, xid, event, time.Now())
+		if reported == nil {
+			logrus.WithField("component", "nvidia").Infof("suppress duplicated xid event %d for GPU device %d", xid, deviceID)
+			return
+		}
+	}
+
 	resResult := &common.Result{
 		Item:     consts.ComponentNameNvidia,
-		Status:   event.Status,
-		Checkers: []*common.CheckerResult{&event},
+		Status:   reported.Status,
+		Level:    reported.Level,
+		Checkers: []*common.CheckerResult{reported},
 		Time:     time.Now(),
 	}
+	x.Lifecycle.Advise(resResult)
 
-	select {
-	case x.EventChan <- resResult:
+	if x.Events.Send(resResult) {
 		logrus.WithField("component", "nvidia").Infof("Notified xid event %d for GPU device %d", xid, deviceID)
-	default:
-		logrus.WithField("component", "nvidia").Warningf("xid event channel is full, skipping event")
+	} else {
+		logrus.WithField("component", "nvidia").Warningf("xid event queue is closed, skipping event")
 	}
 }
 
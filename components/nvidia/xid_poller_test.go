@@ -24,6 +24,7 @@ import (
 
 	"github.com/scitix/sichek/components/common"
 	"github.com/scitix/sichek/components/nvidia/config"
+	"github.com/scitix/sichek/consts"
 
 	"github.com/NVIDIA/go-nvml/pkg/nvml"
 )
@@ -39,10 +40,10 @@ func TestNewXidEventPoller(t *testing.T) {
 
 	ctx := context.Background()
 	cfg := &config.NvidiaUserConfig{}
-	eventChan := make(chan *common.Result, 1)
+	events := common.NewResultQueue(consts.ComponentNameNvidia, 1)
 	var nvmlMtx sync.RWMutex
 
-	poller, err := NewXidEventPoller(ctx, cfg, nvmlInst, &nvmlMtx, eventChan)
+	poller, err := NewXidEventPoller(ctx, cfg, nvmlInst, &nvmlMtx, events, nil, nil)
 	if err != nil {
 		t.Errorf("failed to create XidEventPoller: %v", err)
 	}
@@ -62,10 +63,10 @@ func TestXidEventPoller_Start(t *testing.T) {
 
 	ctx, cancel := context.WithCancel(context.Background())
 	cfg := &config.NvidiaUserConfig{}
-	eventChan := make(chan *common.Result, 1)
+	events := common.NewResultQueue(consts.ComponentNameNvidia, 1)
 	var nvmlMtx sync.RWMutex
 
-	poller, err := NewXidEventPoller(ctx, cfg, nvmlInst, &nvmlMtx, eventChan)
+	poller, err := NewXidEventPoller(ctx, cfg, nvmlInst, &nvmlMtx, events, nil, nil)
 	if err != nil {
 		t.Errorf("failed to create XidEventPoller: %v", err)
 	}
@@ -0,0 +1,151 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/pcie/collector"
+	"github.com/scitix/sichek/components/pcie/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+// AERChecker flags the devices whose AER errors of one severity increase
+// faster than the spec threshold (per minute). The counters are compared
+// with the previous sample of the component; without one, e.g. for a one-shot
+// CLI check, the totals since boot are averaged over the uptime.
+type AERChecker struct {
+	name      string
+	threshold float64
+	counter   func(collector.AERCounters) uint64
+	lastInfo  func() (common.Info, error)
+}
+
+func newAERChecker(name string, threshold float64, counter func(collector.AERCounters) uint64, lastInfo func() (common.Info, error)) (common.Checker, error) {
+	if lastInfo == nil {
+		return nil, fmt.Errorf("lastInfo is required by %s", name)
+	}
+	return &AERChecker{
+		name:      name,
+		threshold: threshold,
+		counter:   counter,
+		lastInfo:  lastInfo,
+	}, nil
+}
+
+func NewAERFatalChecker(spec *config.PcieSpec, lastInfo func() (common.Info, error)) (common.Checker, error) {
+	return newAERChecker(config.AERFatalCheckerName, spec.AER.Fatal,
+		func(c collector.AERCounters) uint64 { return c.Fatal }, lastInfo)
+}
+
+func NewAERNonFatalChecker(spec *config.PcieSpec, lastInfo func() (common.Info, error)) (common.Checker, error) {
+	return newAERChecker(config.AERNonFatalCheckerName, spec.AER.NonFatal,
+		func(c collector.AERCounters) uint64 { return c.NonFatal }, lastInfo)
+}
+
+func NewAERCorrectableChecker(spec *config.PcieSpec, lastInfo func() (common.Info, error)) (common.Checker, error) {
+	return newAERChecker(config.AERCorrectableCheckerName, spec.AER.Correctable,
+		func(c collector.AERCounters) uint64 { return c.Correctable }, lastInfo)
+}
+
+func (c *AERChecker) Name() string {
+	return c.name
+}
+
+func (c *AERChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	info, ok := data.(*collector.PCIeInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid PCIeInfo type")
+	}
+
+	result := config.PcieAERCheckItems[c.name]
+	result.Status = consts.StatusNormal
+
+	prevCounters := make(map[string]collector.AERCounters)
+	since := info.BootTime
+	last, err := c.lastInfo()
+	if prevInfo, ok := last.(*collector.PCIeInfo); err == nil && ok && prevInfo != nil && prevInfo != info {
+		since = prevInfo.Time
+		for _, device := range prevInfo.Devices {
+			prevCounters[device.BDF] = device.AER
+		}
+	}
+	minutes := info.Time.Sub(since).Minutes()
+	if since.IsZero() || minutes <= 0 {
+		result.Curr = "no previous sample"
+		return &result, nil
+	}
+
+	var (
+		abnormalDevices []string
+		detail          string
+	)
+	for _, device := range info.Devices {
+		curr := c.counter(device.AER)
+		prev := c.counter(prevCounters[device.BDF])
+		// a lower value means the counters were reset, e.g. by a device reset
+		if curr <= prev {
+			continue
+		}
+		rate := float64(curr-prev) / minutes
+		if rate <= c.threshold {
+			continue
+		}
+		abnormalDevices = append(abnormalDevices, device.BDF)
+		detail += fmt.Sprintf("%s %s %s errors increased %.2f/min (%d -> %d, from %s), threshold is %.2f/min%s\n",
+			device.Type, device.BDF, severityOf(c.name), rate, prev, curr, device.Source, c.threshold, errorTypes(device.Errors))
+		for _, event := range device.DmesgEvents {
+			detail += "\t" + event + "\n"
+		}
+	}
+
+	if len(abnormalDevices) > 0 {
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(abnormalDevices, ",")
+		result.Curr = fmt.Sprintf("%d devices abnormal", len(abnormalDevices))
+		result.Detail = detail
+		logrus.WithField("component", "pcie").Errorf("PCIe AER rate exceeded: %s", detail)
+	} else {
+		result.Curr = "OK"
+	}
+	return &result, nil
+}
+
+func severityOf(checkerName string) string {
+	return strings.TrimPrefix(checkerName, "aer-")
+}
+
+// errorTypes formats the per error type counters, e.g. " [BadTLP:3 RxErr:12]".
+func errorTypes(errors map[string]uint64) string {
+	if len(errors) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(errors))
+	for name := range errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s:%d", name, errors[name]))
+	}
+	return " [" + strings.Join(parts, " ") + "]"
+}
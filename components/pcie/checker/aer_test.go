@@ -0,0 +1,90 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/pcie/collector"
+	"github.com/scitix/sichek/components/pcie/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPCIeInfo(now time.Time, correctable, fatal uint64) *collector.PCIeInfo {
+	return &collector.PCIeInfo{
+		Time:     now,
+		BootTime: now.Add(-100 * time.Minute),
+		Devices: []*collector.PCIeDevice{
+			{BDF: "0000:3b:00.0", Type: collector.DeviceTypeGPU, Source: collector.SourceSysfs,
+				AER: collector.AERCounters{Correctable: correctable, Fatal: fatal}},
+		},
+	}
+}
+
+func TestAERCheckers(t *testing.T) {
+	spec := &config.PcieSpec{AER: config.AERThresholds{Correctable: 10}}
+	var last common.Info
+	lastInfo := func() (common.Info, error) { return last, nil }
+
+	checkers, err := NewCheckers(&config.PcieUserConfig{
+		Pcie: &config.PcieConfig{IgnoredCheckers: []string{config.AERNonFatalCheckerName}},
+	}, spec, lastInfo)
+	require.NoError(t, err)
+	require.Len(t, checkers, 2)
+	byName := make(map[string]common.Checker)
+	for _, c := range checkers {
+		byName[c.Name()] = c
+	}
+	correctable, fatal := byName[config.AERCorrectableCheckerName], byName[config.AERFatalCheckerName]
+	require.NotNil(t, correctable)
+	require.NotNil(t, fatal)
+
+	ctx := context.Background()
+	now := time.Now()
+
+	// no previous sample: 500 correctable errors over 100 minutes of uptime is 5/min
+	first := newPCIeInfo(now, 500, 0)
+	res, err := correctable.Check(ctx, first)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusNormal, res.Status, res.Detail)
+
+	// 100 more errors within 5 minutes is 20/min
+	last = first
+	second := newPCIeInfo(now.Add(5*time.Minute), 600, 1)
+	res, err = correctable.Check(ctx, second)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusAbnormal, res.Status)
+	assert.Equal(t, consts.LevelWarning, res.Level)
+	assert.Equal(t, "0000:3b:00.0", res.Device)
+	assert.Contains(t, res.Detail, "correctable errors increased 20.00/min")
+
+	res, err = fatal.Check(ctx, second)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusAbnormal, res.Status)
+	assert.Equal(t, consts.LevelCritical, res.Level)
+	assert.Equal(t, "PCIeAERFatal", res.ErrorName)
+
+	// counters reset by a device reset are not an increase
+	last = second
+	res, err = fatal.Check(ctx, newPCIeInfo(now.Add(10*time.Minute), 0, 0))
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusNormal, res.Status)
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/pcie/config"
+)
+
+// NewCheckers creates all PCIe AER checkers, filtering out any in the ignored list.
+func NewCheckers(cfg *config.PcieUserConfig, spec *config.PcieSpec, lastInfo func() (common.Info, error)) ([]common.Checker, error) {
+	checkerConstructors := map[string]func(*config.PcieSpec, func() (common.Info, error)) (common.Checker, error){
+		config.AERFatalCheckerName:       NewAERFatalChecker,
+		config.AERNonFatalCheckerName:    NewAERNonFatalChecker,
+		config.AERCorrectableCheckerName: NewAERCorrectableChecker,
+	}
+
+	ignoredSet := make(map[string]struct{})
+	if cfg != nil && cfg.Pcie != nil {
+		for _, v := range cfg.Pcie.IgnoredCheckers {
+			ignoredSet[v] = struct{}{}
+		}
+	}
+
+	checkers := make([]common.Checker, 0, len(checkerConstructors))
+	for checkerName, constructor := range checkerConstructors {
+		if _, found := ignoredSet[checkerName]; found {
+			continue
+		}
+		checker, err := constructor(spec, lastInfo)
+		if err != nil {
+			return nil, err
+		}
+		checkers = append(checkers, checker)
+	}
+	return checkers, nil
+}
@@ -0,0 +1,273 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/scitix/sichek/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// PCIPath and UptimePath are joined with hostfs.Root, they are vars so that
+// tests can point them to files under t.TempDir().
+var (
+	PCIPath    = "/sys/bus/pci/devices"
+	UptimePath = "/proc/uptime"
+)
+
+const (
+	DeviceTypeGPU = "gpu"
+	DeviceTypeHCA = "hca"
+
+	SourceSysfs = "sysfs"
+	SourceDmesg = "dmesg"
+
+	// maxDmesgEvents bounds the kernel messages kept per device.
+	maxDmesgEvents = 5
+)
+
+// AERCounters are the totals of the AER errors reported by a device since boot.
+type AERCounters struct {
+	Correctable uint64 `json:"correctable"`
+	NonFatal    uint64 `json:"nonfatal"`
+	Fatal       uint64 `json:"fatal"`
+}
+
+type PCIeDevice struct {
+	BDF    string `json:"bdf"`
+	Type   string `json:"type"`
+	Vendor string `json:"vendor"`
+	Device string `json:"device"`
+	// Source is sysfs when the kernel exposes the aer_dev_* counters, and
+	// dmesg when the counters are rebuilt from the kernel AER messages.
+	Source string      `json:"source"`
+	AER    AERCounters `json:"aer"`
+	// Errors holds the non-zero per error type counters, e.g. BadTLP.
+	Errors      map[string]uint64 `json:"errors,omitempty" metric:"-"`
+	DmesgEvents []string          `json:"dmesg_events,omitempty" metric:"-"`
+}
+
+type PCIeInfo struct {
+	Time     time.Time     `json:"time"`
+	BootTime time.Time     `json:"boot_time"`
+	Devices  []*PCIeDevice `json:"devices"`
+}
+
+func (i *PCIeInfo) JSON() (string, error) {
+	data, err := json.Marshal(i)
+	return string(data), err
+}
+
+// PCIeCollector collects the AER counters of the GPUs and HCAs.
+type PCIeCollector struct {
+	name string
+}
+
+func NewPCIeCollector() (*PCIeCollector, error) {
+	return &PCIeCollector{name: "PCIeCollector"}, nil
+}
+
+func (c *PCIeCollector) Name() string {
+	return c.name
+}
+
+func (c *PCIeCollector) Collect(ctx context.Context) (*PCIeInfo, error) {
+	devices, err := FindDevices()
+	if err != nil {
+		return nil, err
+	}
+	info := &PCIeInfo{Time: time.Now(), BootTime: bootTime(), Devices: devices}
+
+	out, err := utils.ExecCommand(ctx, "dmesg")
+	if err != nil {
+		logrus.WithField("component", "pcie").Warnf("failed to read dmesg for AER events: %v", err)
+	}
+	dmesgCounters, dmesgEvents := ParseDmesgAER(string(out))
+
+	for _, device := range devices {
+		device.DmesgEvents = dmesgEvents[device.BDF]
+		if readAER(device) {
+			device.Source = SourceSysfs
+		} else {
+			// kernels before 4.17 do not expose the aer_dev_* counters
+			device.Source = SourceDmesg
+			device.AER = dmesgCounters[device.BDF]
+		}
+	}
+	return info, nil
+}
+
+// FindDevices lists the GPUs (NVIDIA/AMD display or 3D controllers) and the
+// HCAs (InfiniBand controllers and Mellanox Ethernet NICs), skipping the
+// virtual functions.
+func FindDevices() ([]*PCIeDevice, error) {
+	entries, err := os.ReadDir(hostfs.Path(PCIPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", PCIPath, err)
+	}
+	var devices []*PCIeDevice
+	for _, entry := range entries {
+		bdf := entry.Name()
+		dir := hostfs.Path(PCIPath, bdf)
+		if _, err := os.Stat(filepath.Join(dir, "physfn")); err == nil {
+			continue
+		}
+		class := readSysfs(filepath.Join(dir, "class"))
+		vendor := readSysfs(filepath.Join(dir, "vendor"))
+		deviceType := deviceTypeOf(class, vendor)
+		if deviceType == "" {
+			continue
+		}
+		devices = append(devices, &PCIeDevice{
+			BDF:    bdf,
+			Type:   deviceType,
+			Vendor: vendor,
+			Device: readSysfs(filepath.Join(dir, "device")),
+		})
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].BDF < devices[j].BDF })
+	return devices, nil
+}
+
+func deviceTypeOf(class, vendor string) string {
+	switch {
+	case (strings.HasPrefix(class, "0x0300") || strings.HasPrefix(class, "0x0302")) && (vendor == "0x10de" || vendor == "0x1002"):
+		return DeviceTypeGPU
+	case strings.HasPrefix(class, "0x0207"), strings.HasPrefix(class, "0x0200") && vendor == "0x15b3":
+		return DeviceTypeHCA
+	}
+	return ""
+}
+
+// readAER fills the AER counters of the device from sysfs and reports
+// whether the counters are exposed.
+func readAER(device *PCIeDevice) bool {
+	dir := hostfs.Path(PCIPath, device.BDF)
+	supported := false
+	for _, f := range []struct {
+		file  string
+		total string
+		value *uint64
+	}{
+		{"aer_dev_correctable", "TOTAL_ERR_COR", &device.AER.Correctable},
+		{"aer_dev_nonfatal", "TOTAL_ERR_NONFATAL", &device.AER.NonFatal},
+		{"aer_dev_fatal", "TOTAL_ERR_FATAL", &device.AER.Fatal},
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, f.file))
+		if err != nil {
+			continue
+		}
+		supported = true
+		counters := ParseAERCounters(string(data))
+		*f.value = counters[f.total]
+		for name, value := range counters {
+			if name == f.total || value == 0 {
+				continue
+			}
+			if device.Errors == nil {
+				device.Errors = make(map[string]uint64)
+			}
+			device.Errors[name] += value
+		}
+	}
+	return supported
+}
+
+// ParseAERCounters parses an aer_dev_* file, one "<error> <count>" per line.
+func ParseAERCounters(data string) map[string]uint64 {
+	counters := make(map[string]uint64)
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		counters[fields[0]] = value
+	}
+	return counters
+}
+
+// dmesgAERRegex matches the AER report of a device, e.g.
+// "nvidia 0000:3b:00.0: PCIe Bus Error: severity=Corrected, type=Physical Layer, (Receiver ID)".
+var dmesgAERRegex = regexp.MustCompile(`([0-9a-fA-F]{4}:[0-9a-fA-F]{2}:[0-9a-fA-F]{2}\.[0-7]): (?:AER: )?PCIe Bus Error: severity=([^,]+)`)
+
+// ParseDmesgAER counts the AER reports of each device in the dmesg output and
+// keeps the last reports as events.
+func ParseDmesgAER(out string) (map[string]AERCounters, map[string][]string) {
+	counters := make(map[string]AERCounters)
+	events := make(map[string][]string)
+	for _, line := range strings.Split(out, "\n") {
+		match := dmesgAERRegex.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		bdf := strings.ToLower(match[1])
+		c := counters[bdf]
+		severity := strings.TrimSpace(match[2])
+		switch {
+		case strings.HasPrefix(severity, "Corrected"):
+			c.Correctable++
+		case strings.Contains(severity, "Non-Fatal"):
+			c.NonFatal++
+		case strings.Contains(severity, "Fatal"):
+			c.Fatal++
+		default:
+			continue
+		}
+		counters[bdf] = c
+		events[bdf] = append(events[bdf], strings.TrimSpace(line))
+		if len(events[bdf]) > maxDmesgEvents {
+			events[bdf] = events[bdf][1:]
+		}
+	}
+	return counters, events
+}
+
+func bootTime() time.Time {
+	fields := strings.Fields(readSysfs(hostfs.Path(UptimePath)))
+	if len(fields) == 0 {
+		return time.Time{}
+	}
+	uptime, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Now().Add(-time.Duration(uptime * float64(time.Second)))
+}
+
+func readSysfs(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
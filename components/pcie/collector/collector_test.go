@@ -0,0 +1,106 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/pkg/hostfs/hostfstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestFindDevicesAndReadAER(t *testing.T) {
+	old := PCIPath
+	PCIPath = t.TempDir()
+	defer func() { PCIPath = old }()
+
+	// GPU with AER counters
+	gpu := filepath.Join(PCIPath, "0000:3b:00.0")
+	writeFile(t, filepath.Join(gpu, "class"), "0x030200\n")
+	writeFile(t, filepath.Join(gpu, "vendor"), "0x10de\n")
+	writeFile(t, filepath.Join(gpu, "device"), "0x2330\n")
+	writeFile(t, filepath.Join(gpu, "aer_dev_correctable"), "RxErr 12\nBadTLP 3\nBadDLLP 0\nTOTAL_ERR_COR 15\n")
+	writeFile(t, filepath.Join(gpu, "aer_dev_nonfatal"), "Undefined 0\nCmpltTO 1\nTOTAL_ERR_NONFATAL 1\n")
+	writeFile(t, filepath.Join(gpu, "aer_dev_fatal"), "Undefined 0\nTOTAL_ERR_FATAL 0\n")
+	// IB HCA without AER counters
+	hca := filepath.Join(PCIPath, "0000:1a:00.0")
+	writeFile(t, filepath.Join(hca, "class"), "0x020700\n")
+	writeFile(t, filepath.Join(hca, "vendor"), "0x15b3\n")
+	// HCA virtual function
+	vf := filepath.Join(PCIPath, "0000:1a:00.2")
+	writeFile(t, filepath.Join(vf, "class"), "0x020700\n")
+	writeFile(t, filepath.Join(vf, "vendor"), "0x15b3\n")
+	writeFile(t, filepath.Join(vf, "physfn", "class"), "0x020700\n")
+	// NVMe disk
+	nvme := filepath.Join(PCIPath, "0000:5e:00.0")
+	writeFile(t, filepath.Join(nvme, "class"), "0x010802\n")
+	writeFile(t, filepath.Join(nvme, "vendor"), "0x144d\n")
+
+	devices, err := FindDevices()
+	require.NoError(t, err)
+	require.Len(t, devices, 2)
+	assert.Equal(t, "0000:1a:00.0", devices[0].BDF)
+	assert.Equal(t, DeviceTypeHCA, devices[0].Type)
+	assert.Equal(t, "0000:3b:00.0", devices[1].BDF)
+	assert.Equal(t, DeviceTypeGPU, devices[1].Type)
+
+	assert.False(t, readAER(devices[0]))
+	assert.True(t, readAER(devices[1]))
+	assert.Equal(t, AERCounters{Correctable: 15, NonFatal: 1, Fatal: 0}, devices[1].AER)
+	assert.Equal(t, map[string]uint64{"RxErr": 12, "BadTLP": 3, "CmpltTO": 1}, devices[1].Errors)
+}
+
+func TestFindDevicesFixture(t *testing.T) {
+	hostfstest.Load(t, "h100-cx7")
+	hostfstest.WriteFile(t, "/sys/bus/pci/devices/0000:38:00.0/aer_dev_correctable", "RxErr 7\nTOTAL_ERR_COR 7\n")
+
+	devices, err := FindDevices()
+	require.NoError(t, err)
+	require.Len(t, devices, 3)
+	assert.Equal(t, []string{"0000:18:00.0", "0000:19:00.0", "0000:38:00.0"}, []string{devices[0].BDF, devices[1].BDF, devices[2].BDF})
+	assert.Equal(t, DeviceTypeGPU, devices[1].Type)
+	for _, device := range devices {
+		assert.True(t, readAER(device), device.BDF)
+	}
+	assert.Equal(t, uint64(7), devices[2].AER.Correctable)
+	assert.Equal(t, map[string]uint64{"RxErr": 7}, devices[2].Errors)
+	assert.WithinDuration(t, time.Now().Add(-864000*time.Second), bootTime(), time.Minute)
+}
+
+func TestParseDmesgAER(t *testing.T) {
+	out := `[  100.1] pcieport 0000:17:01.0: AER: Corrected error received: 0000:1a:00.0
+[  100.2] mlx5_core 0000:1a:00.0: PCIe Bus Error: severity=Corrected, type=Physical Layer, (Receiver ID)
+[  100.3] mlx5_core 0000:1a:00.0:   device [15b3:101b] error status/mask=00000001/00002000
+[  200.1] mlx5_core 0000:1a:00.0: AER: PCIe Bus Error: severity=Corrected, type=Data Link Layer, (Transmitter ID)
+[  300.1] nvidia 0000:3B:00.0: PCIe Bus Error: severity=Uncorrected (Non-Fatal), type=Transaction Layer, (Requester ID)
+[  400.1] nvidia 0000:3b:00.0: PCIe Bus Error: severity=Uncorrected (Fatal), type=Transaction Layer, (Receiver ID)
+`
+	counters, events := ParseDmesgAER(out)
+	assert.Equal(t, AERCounters{Correctable: 2}, counters["0000:1a:00.0"])
+	assert.Equal(t, AERCounters{NonFatal: 1, Fatal: 1}, counters["0000:3b:00.0"])
+	assert.Len(t, events["0000:1a:00.0"], 2)
+	assert.Contains(t, events["0000:3b:00.0"][1], "severity=Uncorrected (Fatal)")
+}
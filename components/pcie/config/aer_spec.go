@@ -0,0 +1,121 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/httpclient"
+	"github.com/sirupsen/logrus"
+)
+
+// PcieSpecs is the `pcie` section of the spec, separate from `pcie_topo`.
+type PcieSpecs struct {
+	Specs map[string]*PcieSpec `json:"pcie" yaml:"pcie"`
+}
+
+type PcieSpec struct {
+	AER AERThresholds `json:"aer" yaml:"aer"`
+}
+
+// AERThresholds are the max AER errors per minute tolerated per device.
+type AERThresholds struct {
+	Correctable float64 `json:"correctable" yaml:"correctable"`
+	NonFatal    float64 `json:"nonfatal" yaml:"nonfatal"`
+	Fatal       float64 `json:"fatal" yaml:"fatal"`
+}
+
+// ─── EnsureAERSpec ───────────────────────────────────────────────────────────
+
+// EnsureAERSpec ensures that `file` contains the "default" pcie spec entry,
+// potentially downloading it from OSS. The AER thresholds do not depend on
+// the device, so unlike pcie_topo the spec is not keyed by GPU device ID.
+func EnsureAERSpec(file string) (string, error) {
+	const comp = "pcie/spec"
+	const deviceID = "default"
+
+	var s PcieSpecs
+	if err := common.LoadSpec(file, &s); err == nil {
+		if s.Specs != nil {
+			if _, ok := s.Specs[deviceID]; ok {
+				logrus.WithField("component", comp).Infof("spec for pcie %s already in %s, skipping download", deviceID, file)
+				return file, nil
+			}
+		}
+	} else {
+		logrus.WithField("component", comp).Debugf("LoadSpec failed during EnsureAERSpec (may be new file): %v", err)
+	}
+
+	// Download {SICHEK_SPEC_URL}/pcie/default.yaml
+	ossBase := httpclient.GetSichekSpecURL()
+	if ossBase == "" {
+		return file, fmt.Errorf("EnsureAERSpec: pcie %s not in spec and SICHEK_SPEC_URL not set", deviceID)
+	}
+
+	tmpFile := filepath.Join(os.TempDir(), fmt.Sprintf("pcie_%s.yaml", deviceID))
+	perDevURL := fmt.Sprintf("%s/%s/%s.yaml",
+		strings.TrimRight(ossBase, "/"), consts.ComponentNamePCIE, deviceID)
+
+	logrus.WithField("component", comp).Infof("downloading pcie spec from %s", perDevURL)
+	if err := common.DownloadSpecFile(perDevURL, tmpFile, comp); err != nil {
+		return file, fmt.Errorf("EnsureAERSpec: download failed: %w", err)
+	}
+
+	var perDevice PcieSpecs
+	if err := common.LoadSpec(tmpFile, &perDevice); err != nil {
+		return file, fmt.Errorf("EnsureAERSpec: parse per-device spec: %w", err)
+	}
+
+	if err := common.MergeAndWriteSpec(
+		file,
+		"pcie",
+		perDevice.Specs,
+		func(c *PcieSpecs) map[string]*PcieSpec { return c.Specs },
+		func(c *PcieSpecs, m map[string]*PcieSpec) { c.Specs = m },
+	); err != nil {
+		return file, fmt.Errorf("EnsureAERSpec: merge failed: %w", err)
+	}
+
+	logrus.WithField("component", comp).Infof("merged pcie %s spec into %s", deviceID, file)
+	return file, nil
+}
+
+// ─── LoadAERSpec ─────────────────────────────────────────────────────────────
+
+// LoadAERSpec reads the pcie multi-spec YAML at `file`, ensures the "default"
+// entry is present and returns it.
+func LoadAERSpec(file string) (*PcieSpec, error) {
+	if file == "" {
+		return nil, fmt.Errorf("pcie spec file path is empty")
+	}
+
+	if _, err := EnsureAERSpec(file); err != nil {
+		logrus.WithField("component", "pcie/spec").Warnf("EnsureAERSpec failed: %v", err)
+	}
+
+	logrus.WithField("component", "pcie").Infof("filtering spec for pcie default in %s", file)
+	return common.FilterSpec(file, "pcie", "default",
+		func(c *PcieSpecs, id string) (*PcieSpec, bool) {
+			spec, ok := c.Specs[id]
+			return spec, ok
+		},
+	)
+}
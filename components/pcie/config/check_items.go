@@ -21,15 +21,17 @@ import (
 )
 
 const (
-	PciTopoNumaCheckerName   = "PciTopoNumaCheckerName"
-	PciTopoSwitchCheckerName = "PciTopoSwitchCheckerName"
+	PciTopoNumaCheckerName        = "PciTopoNumaCheckerName"
+	PciTopoSwitchCheckerName      = "PciTopoSwitchCheckerName"
+	PciTopoLocalityCheckerName    = "PciTopoLocalityCheckerName"
+	PciTopoIRQAffinityCheckerName = "PciTopoIRQAffinityCheckerName"
 )
 
 // PciTopoCheckItems is a map of check items for Topo
 var PciTopoCheckItems = map[string]common.CheckerResult{
 	PciTopoNumaCheckerName: {
 		Name:        PciTopoNumaCheckerName,
-		Description: "",
+		Description: "Check if the GPU and IB counts of each NUMA node match the spec",
 		Status:      consts.StatusNormal,
 		Level:       consts.LevelCritical,
 		Detail:      "",
@@ -38,11 +40,66 @@ var PciTopoCheckItems = map[string]common.CheckerResult{
 	},
 	PciTopoSwitchCheckerName: {
 		Name:        PciTopoSwitchCheckerName,
-		Description: "",
+		Description: "Check if the GPU and IB pairings under the PCIe switches match the spec",
 		Status:      consts.StatusNormal,
 		Level:       consts.LevelCritical,
 		Detail:      "",
 		ErrorName:   "SwitchDeviceRelationError",
-		Suggestion:  "Check Device Topo",
+		Suggestion:  "Check if the listed GPUs and HCAs are seated in the right slots and the PCIe cables of their risers are connected as designed",
+	},
+	PciTopoLocalityCheckerName: {
+		Name:        PciTopoLocalityCheckerName,
+		Description: "Check if each GPU has an HCA under the same PCIe switch and on the same NUMA node as the spec pairs them",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelWarning,
+		Detail:      "",
+		ErrorName:   "GPUHCANotLocal",
+		Suggestion:  "Check the slots of the listed GPUs and HCAs, NCCL traffic of a GPU without a local HCA crosses the CPU root complex or the inter-socket link",
+	},
+	PciTopoIRQAffinityCheckerName: {
+		Name:        PciTopoIRQAffinityCheckerName,
+		Description: "Check if the interrupts of the mlx5 HCAs are delivered to the CPUs of their NUMA node",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelWarning,
+		Detail:      "",
+		ErrorName:   "HCAIRQAffinityRemote",
+		Suggestion:  "Pin the mlx5 interrupts to the local CPUs of the HCA, e.g. with set_irq_affinity_bynode.sh of the mlnx tools, and check that irqbalance does not move them off the NUMA node",
+	},
+}
+
+const (
+	AERFatalCheckerName       = "aer-fatal"
+	AERNonFatalCheckerName    = "aer-nonfatal"
+	AERCorrectableCheckerName = "aer-correctable"
+)
+
+// PcieAERCheckItems is a map of check items for the PCIe AER counters
+var PcieAERCheckItems = map[string]common.CheckerResult{
+	AERFatalCheckerName: {
+		Name:        AERFatalCheckerName,
+		Description: "Check if the fatal PCIe AER errors of the GPUs and HCAs increase faster than the threshold",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelCritical,
+		Detail:      "No fatal PCIe AER error",
+		ErrorName:   "PCIeAERFatal",
+		Suggestion:  "Drain the node, reseat the device or its riser and check the PCIe slot, the device may fall off the bus (xid 79)",
+	},
+	AERNonFatalCheckerName: {
+		Name:        AERNonFatalCheckerName,
+		Description: "Check if the non-fatal uncorrectable PCIe AER errors of the GPUs and HCAs increase faster than the threshold",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelCritical,
+		Detail:      "No non-fatal PCIe AER error",
+		ErrorName:   "PCIeAERNonFatal",
+		Suggestion:  "Drain the node and check the PCIe link of the device, reseat the device if the errors keep increasing",
+	},
+	AERCorrectableCheckerName: {
+		Name:        AERCorrectableCheckerName,
+		Description: "Check if the correctable PCIe AER errors of the GPUs and HCAs increase faster than the threshold",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelWarning,
+		Detail:      "Correctable PCIe AER errors are below the threshold",
+		ErrorName:   "PCIeAERCorrectable",
+		Suggestion:  "Check the PCIe link speed and width of the device (lspci -vv), a growing rate often precedes a GPU falling off the bus",
 	},
 }
@@ -0,0 +1,46 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package metrics
+
+import (
+	"github.com/scitix/sichek/components/pcie/collector"
+	common "github.com/scitix/sichek/metrics"
+)
+
+const (
+	MetricPrefix = "sichek_pcie"
+	TagPrefix    = "json"
+)
+
+type PcieMetrics struct {
+	AERGauge *common.GaugeVecMetricExporter
+}
+
+func NewPcieMetrics() *PcieMetrics {
+	return &PcieMetrics{
+		AERGauge: common.NewGaugeVecMetricExporter(MetricPrefix+"_aer", []string{"bdf", "type"}),
+	}
+}
+
+// ExportMetrics exports the AER totals, e.g. sichek_pcie_aer_correctable.
+func (m *PcieMetrics) ExportMetrics(info *collector.PCIeInfo) {
+	if info == nil {
+		return
+	}
+	for _, device := range info.Devices {
+		m.AERGauge.ExportStruct(device.AER, []string{device.BDF, device.Type}, TagPrefix)
+	}
+}
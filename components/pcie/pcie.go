@@ -0,0 +1,306 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pcie
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/pcie/checker"
+	"github.com/scitix/sichek/components/pcie/collector"
+	"github.com/scitix/sichek/components/pcie/config"
+	pciemetrics "github.com/scitix/sichek/components/pcie/metrics"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/scitix/sichek/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+type component struct {
+	ctx           context.Context
+	cancel        context.CancelFunc
+	componentName string
+	cfg           *config.PcieUserConfig
+	cfgMutex      sync.Mutex
+	spec          *config.PcieSpec
+	collector     *collector.PCIeCollector
+	checkers      []common.Checker
+	specMtx       sync.RWMutex
+	metrics       *pciemetrics.PcieMetrics
+
+	cacheMtx    sync.RWMutex
+	cacheBuffer []*common.Result
+	cacheInfo   []common.Info
+	currIndex   int64
+	cacheSize   int64
+
+	service *common.CommonService
+}
+
+var (
+	pcieComponent     *component
+	pcieComponentOnce sync.Once
+)
+
+func NewComponent(cfgFile string, specFile string, ignoredCheckers []string) (common.Component, error) {
+	var err error
+	pcieComponentOnce.Do(func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic occurred when create component pcie: %v", r)
+			}
+		}()
+		pcieComponent, err = newComponent(cfgFile, specFile, ignoredCheckers)
+	})
+	return pcieComponent, err
+}
+
+func newComponent(cfgFile string, specFile string, ignoredCheckers []string) (comp *component, err error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		if err != nil {
+			cancel()
+		}
+	}()
+
+	cfg := &config.PcieUserConfig{}
+	err = common.LoadUserConfig(cfgFile, cfg)
+	if err != nil || cfg.Pcie == nil {
+		logrus.WithField("component", "pcie").Warnf("get user config failed or pcie config is nil, using default config")
+		cfg.Pcie = &config.PcieConfig{
+			QueryInterval: common.Duration{Duration: 60 * time.Second},
+			CacheSize:     5,
+		}
+	}
+	if len(ignoredCheckers) > 0 {
+		cfg.Pcie.IgnoredCheckers = ignoredCheckers
+	}
+
+	spec, specErr := config.LoadAERSpec(specFile)
+	if specErr != nil {
+		logrus.WithField("component", "pcie").Warnf("failed to load spec %s: %v", specFile, specErr)
+	}
+
+	collectorInst, err := collector.NewPCIeCollector()
+	if err != nil {
+		logrus.WithField("component", "pcie").Errorf("create pcie collector failed: %v", err)
+		return nil, err
+	}
+
+	cacheSize := cfg.Pcie.CacheSize
+	if cacheSize == 0 {
+		cacheSize = 5
+	}
+
+	comp = &component{
+		ctx:           ctx,
+		cancel:        cancel,
+		componentName: consts.ComponentNamePCIE,
+		cfg:           cfg,
+		spec:          spec,
+		collector:     collectorInst,
+		cacheBuffer:   make([]*common.Result, cacheSize),
+		cacheInfo:     make([]common.Info, cacheSize),
+		cacheSize:     cacheSize,
+	}
+
+	comp.checkers, err = common.CheckersOrSpecMissing(consts.ComponentNamePCIE, spec, specErr, func(spec *config.PcieSpec) ([]common.Checker, error) {
+		return checker.NewCheckers(cfg, spec, comp.LastInfo)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Pcie.EnableMetrics {
+		comp.metrics = pciemetrics.NewPcieMetrics()
+	}
+	service := common.NewCommonService(ctx, cfg, comp.componentName, comp.GetTimeout(), comp.HealthCheck)
+	comp.service = service
+
+	return comp, nil
+}
+
+func (c *component) Name() string {
+	return c.componentName
+}
+
+func (c *component) HealthCheck(ctx context.Context) (*common.Result, error) {
+	timer := common.NewTimer(fmt.Sprintf("%s-HealthCheck-Cost", c.componentName))
+	pcieInfo, err := common.Collect(ctx, c.componentName, c.collector.Collect)
+	if err != nil {
+		logrus.WithField("component", "pcie").Errorf("failed to collect pcie info: %v", err)
+		return nil, err
+	}
+	timer.Mark("pcie-collect")
+
+	if c.metrics != nil {
+		c.metrics.ExportMetrics(pcieInfo)
+	}
+
+	c.specMtx.RLock()
+	checkers := c.checkers
+	c.specMtx.RUnlock()
+	result := common.Check(ctx, c.componentName, pcieInfo, checkers)
+	timer.Mark("pcie-check")
+
+	c.cacheMtx.Lock()
+	c.cacheBuffer[c.currIndex] = result
+	c.cacheInfo[c.currIndex] = pcieInfo
+	c.currIndex = (c.currIndex + 1) % c.cacheSize
+	c.cacheMtx.Unlock()
+
+	if result.Status == consts.StatusAbnormal && consts.LevelPriority[result.Level] > consts.LevelPriority[consts.LevelInfo] {
+		logrus.WithField("component", "pcie").Errorf("Health Check Failed")
+	} else {
+		logrus.WithField("component", "pcie").Infof("Health Check PASSED")
+	}
+
+	return result, nil
+}
+
+func (c *component) CacheResults() ([]*common.Result, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	return c.cacheBuffer, nil
+}
+
+func (c *component) LastResult() (*common.Result, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	result := c.cacheBuffer[c.currIndex]
+	if c.currIndex == 0 {
+		result = c.cacheBuffer[c.cacheSize-1]
+	}
+	return result, nil
+}
+
+func (c *component) CacheInfos() ([]common.Info, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	return c.cacheInfo, nil
+}
+
+func (c *component) LastInfo() (common.Info, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	var info common.Info
+	if c.currIndex == 0 {
+		info = c.cacheInfo[c.cacheSize-1]
+	} else {
+		info = c.cacheInfo[c.currIndex-1]
+	}
+	return info, nil
+}
+
+func (c *component) Start() <-chan *common.Result {
+	return c.service.Start()
+}
+
+func (c *component) Stop() error {
+	return c.service.Stop()
+}
+
+func (c *component) Update(cfg common.ComponentUserConfig) error {
+	c.cfgMutex.Lock()
+	configPointer, ok := cfg.(*config.PcieUserConfig)
+	if !ok {
+		c.cfgMutex.Unlock()
+		return fmt.Errorf("update wrong config type for pcie")
+	}
+	c.cfg = configPointer
+	c.cfgMutex.Unlock()
+	return c.service.Update(cfg)
+}
+
+// UpdateSpec reloads the AER thresholds and rebuilds the checkers from them.
+func (c *component) UpdateSpec(specFile string) error {
+	spec, err := config.LoadAERSpec(specFile)
+	if err != nil {
+		return fmt.Errorf("load pcie spec %s: %w", specFile, err)
+	}
+	if spec == nil {
+		return fmt.Errorf("pcie spec is nil after loading from %s", specFile)
+	}
+	c.cfgMutex.Lock()
+	cfg := c.cfg
+	c.cfgMutex.Unlock()
+	checkers, err := checker.NewCheckers(cfg, spec, c.LastInfo)
+	if err != nil {
+		return err
+	}
+	c.specMtx.Lock()
+	c.spec = spec
+	c.checkers = checkers
+	c.specMtx.Unlock()
+	logrus.WithField("component", "pcie").Infof("reloaded spec from %s", specFile)
+	return nil
+}
+
+func (c *component) Status() bool {
+	return c.service.Status()
+}
+
+func (c *component) GetTimeout() time.Duration {
+	return c.cfg.GetQueryInterval().Duration
+}
+
+func (c *component) PrintInfo(info common.Info, result *common.Result, summaryPrint bool) bool {
+	checkAllPassed := true
+	if result.Status == consts.StatusAbnormal && consts.LevelPriority[result.Level] > consts.LevelPriority[consts.LevelInfo] {
+		checkAllPassed = false
+	}
+
+	utils.PrintTitle("PCIe AER", "-")
+
+	pcieInfo, ok := info.(*collector.PCIeInfo)
+	if !ok || pcieInfo == nil {
+		printer.Println("No PCIe info available")
+		return checkAllPassed
+	}
+
+	if len(pcieInfo.Devices) > 0 {
+		printer.Printf("%-14s %-6s %-8s %-14s %-10s %-8s\n", "BDF", "Type", "Source", "Correctable", "NonFatal", "Fatal")
+		for _, device := range pcieInfo.Devices {
+			printer.Printf("%-14s %-6s %-8s %-14d %-10d %-8d\n",
+				device.BDF, device.Type, device.Source, device.AER.Correctable, device.AER.NonFatal, device.AER.Fatal)
+		}
+	} else {
+		printer.Println("No GPU or HCA found")
+	}
+
+	hasErrors := false
+	if result != nil {
+		for _, res := range result.Checkers {
+			if res.Status != consts.StatusNormal && res.Level != consts.LevelInfo {
+				if !hasErrors {
+					printer.Printf("\nErrors Events:\n")
+					hasErrors = true
+				}
+				printer.Printf("\tEvent: %s%s%s -> %s\n", consts.LevelColor(res.Level), res.ErrorName, consts.Reset, res.Detail)
+			}
+		}
+	}
+	if !hasErrors {
+		printer.Printf("\nErrors Events:\n\tNo PCIe AER Events Detected\n")
+	}
+
+	printer.Println()
+	return checkAllPassed
+}
@@ -0,0 +1,144 @@
+package topotest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/sirupsen/logrus"
+)
+
+// IRQAffinity is the CPUs an MSI interrupt of an HCA is delivered to.
+type IRQAffinity struct {
+	IRQ  int   `json:"irq"`
+	CPUs []int `json:"cpus"`
+}
+
+// HCAIRQInfo is the local CPUs of an HCA and the affinity of its interrupts.
+type HCAIRQInfo struct {
+	Name      string        `json:"name"`
+	BDF       string        `json:"bdf"`
+	NumaID    uint64        `json:"numa_id"`
+	LocalCPUs []int         `json:"local_cpus"`
+	IRQs      []IRQAffinity `json:"irqs"`
+}
+
+// RemoteIRQs returns the interrupts none of whose CPUs is local to the HCA.
+func (h *HCAIRQInfo) RemoteIRQs() []IRQAffinity {
+	var remote []IRQAffinity
+	for _, irq := range h.IRQs {
+		if !slices.ContainsFunc(irq.CPUs, func(cpu int) bool { return slices.Contains(h.LocalCPUs, cpu) }) {
+			remote = append(remote, irq)
+		}
+	}
+	return remote
+}
+
+// CollectIRQAffinity reads the local CPUs of the mlx5 HCAs and the CPUs their
+// MSI interrupts are delivered to. The effective affinity is preferred over
+// the requested one, as irqbalance or the kernel may deliver an interrupt to
+// a single CPU of its mask. The HCAs whose interrupts can not be read are
+// skipped.
+func CollectIRQAffinity(ibs map[string]*DeviceInfo) map[string]*HCAIRQInfo {
+	hcas := make(map[string]*HCAIRQInfo)
+	for _, ib := range ibs {
+		if !strings.HasPrefix(ib.Name, "mlx5_") {
+			continue
+		}
+		devPath := hostfs.Path("/sys/bus/pci/devices", ib.BDF)
+		localStr, err := readFile(filepath.Join(devPath, "local_cpulist"))
+		if err != nil {
+			logrus.WithField("component", "pcie_topo").Debugf("failed to read the local CPUs of %s: %v", ib.Name, err)
+			continue
+		}
+		localCPUs, err := parseCPUList(localStr)
+		if err != nil {
+			logrus.WithField("component", "pcie_topo").Warnf("invalid local CPUs of %s: %v", ib.Name, err)
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(devPath, "msi_irqs"))
+		if err != nil {
+			logrus.WithField("component", "pcie_topo").Debugf("failed to read the interrupts of %s: %v", ib.Name, err)
+			continue
+		}
+		hca := &HCAIRQInfo{Name: ib.Name, BDF: ib.BDF, NumaID: ib.NumaID, LocalCPUs: localCPUs}
+		for _, entry := range entries {
+			irq, err := strconv.Atoi(entry.Name())
+			if err != nil {
+				continue
+			}
+			cpus, err := readIRQAffinity(irq)
+			if err != nil {
+				logrus.WithField("component", "pcie_topo").Debugf("failed to read the affinity of irq %d of %s: %v", irq, ib.Name, err)
+				continue
+			}
+			hca.IRQs = append(hca.IRQs, IRQAffinity{IRQ: irq, CPUs: cpus})
+		}
+		sort.Slice(hca.IRQs, func(i, j int) bool { return hca.IRQs[i].IRQ < hca.IRQs[j].IRQ })
+		hcas[ib.Name] = hca
+	}
+	return hcas
+}
+
+func readIRQAffinity(irq int) ([]int, error) {
+	dir := hostfs.Path("/proc/irq", strconv.Itoa(irq))
+	for _, name := range []string{"effective_affinity_list", "smp_affinity_list"} {
+		list, err := readFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		if cpus, err := parseCPUList(list); err == nil && len(cpus) > 0 {
+			return cpus, nil
+		}
+	}
+	return nil, fmt.Errorf("no affinity in %s", dir)
+}
+
+// parseCPUList parses a kernel CPU list such as "0-23,48-71".
+func parseCPUList(list string) ([]int, error) {
+	var cpus []int
+	for _, part := range strings.Split(strings.TrimSpace(list), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		lo, hi, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(lo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CPU list %q", list)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(hi); err != nil || end < start {
+				return nil, fmt.Errorf("invalid CPU list %q", list)
+			}
+		}
+		for cpu := start; cpu <= end; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus, nil
+}
+
+// formatCPUList formats sorted CPUs as a kernel CPU list.
+func formatCPUList(cpus []int) string {
+	var parts []string
+	for i := 0; i < len(cpus); {
+		j := i
+		for j+1 < len(cpus) && cpus[j+1] == cpus[j]+1 {
+			j++
+		}
+		if i == j {
+			parts = append(parts, strconv.Itoa(cpus[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", cpus[i], cpus[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
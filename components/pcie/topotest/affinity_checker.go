@@ -0,0 +1,155 @@
+package topotest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/pcie/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+// checkGPUHCALocality reports the GPUs without an HCA on their NUMA node,
+// whose NCCL traffic crosses the inter-socket link, and the GPUs without an
+// HCA under their PCIe switch when the spec pairs more GPUs with HCAs, whose
+// GPUDirect RDMA traffic goes through the CPU root complex. A NUMA node the
+// spec gives no HCA is not expected to have a local one.
+func checkGPUHCALocality(devices map[string]*DeviceInfo, switches map[string]*EndpointInfoByPCIeSW, spec *config.PcieTopoSpec) *common.CheckerResult {
+	res := config.PciTopoCheckItems[config.PciTopoLocalityCheckerName]
+
+	numaWithIB := make(map[uint64]bool)
+	var gpus []*DeviceInfo
+	for _, device := range devices {
+		switch device.Type {
+		case "GPU":
+			gpus = append(gpus, device)
+		case "IB":
+			numaWithIB[device.NumaID] = true
+		}
+	}
+	sort.Slice(gpus, func(i, j int) bool { return gpus[i].BDF < gpus[j].BDF })
+
+	switchLocal := make(map[string]bool)
+	for _, sw := range switches {
+		hasIB := false
+		for _, dev := range sw.DeviceList {
+			hasIB = hasIB || dev.Type == "IB"
+		}
+		if !hasIB {
+			continue
+		}
+		for _, dev := range sw.DeviceList {
+			if dev.Type == "GPU" {
+				switchLocal[dev.BDF] = true
+			}
+		}
+	}
+
+	numaExpectsIB := make(map[uint64]bool)
+	for _, cfg := range spec.NumaConfig {
+		numaExpectsIB[cfg.NodeID] = cfg.IBCount > 0
+	}
+	expectedPaired := 0
+	for _, sw := range spec.PciSwitchesConfig {
+		if sw.IB > 0 {
+			expectedPaired += sw.GPU * sw.Count
+		}
+	}
+
+	var builder strings.Builder
+	var failed []string
+	var unpaired []*DeviceInfo
+	for _, gpu := range gpus {
+		if !numaWithIB[gpu.NumaID] && numaExpectsIB[gpu.NumaID] {
+			builder.WriteString(fmt.Sprintf("GPU %s (%s) has no HCA on its NUMA node %d, its NCCL traffic crosses the inter-socket link\n",
+				gpu.Name, gpu.BDF, gpu.NumaID))
+			failed = append(failed, gpu.BDF)
+			continue
+		}
+		if !switchLocal[gpu.BDF] {
+			unpaired = append(unpaired, gpu)
+		}
+	}
+	if paired := len(gpus) - len(unpaired); len(unpaired) > 0 && paired < expectedPaired {
+		for _, gpu := range unpaired {
+			builder.WriteString(fmt.Sprintf("GPU %s (%s) shares no PCIe switch with an HCA, its GPUDirect RDMA traffic goes through the CPU root complex\n",
+				gpu.Name, gpu.BDF))
+			failed = append(failed, gpu.BDF)
+		}
+	}
+	res.Spec = fmt.Sprintf("%d GPUs under the PCIe switch of an HCA", expectedPaired)
+	res.Curr = fmt.Sprintf("%d GPUs under the PCIe switch of an HCA", len(gpus)-len(unpaired))
+
+	if len(failed) > 0 {
+		logrus.WithFields(logrus.Fields{
+			"checker": config.PciTopoLocalityCheckerName,
+			"gpus":    failed,
+		}).Warnf("GPUs without a local HCA")
+		res.Status = consts.StatusAbnormal
+		sort.Strings(failed)
+		res.Device = strings.Join(failed, ",")
+		res.Detail = builder.String()
+	} else {
+		res.Detail = "Check Pass"
+	}
+	return &res
+}
+
+// checkHCAIRQAffinity reports the mlx5 HCAs with interrupts delivered only to
+// CPUs outside their NUMA node, whose completions then bounce across the
+// sockets.
+func checkHCAIRQAffinity(hcas map[string]*HCAIRQInfo) *common.CheckerResult {
+	res := config.PciTopoCheckItems[config.PciTopoIRQAffinityCheckerName]
+	names := make([]string, 0, len(hcas))
+	for name, hca := range hcas {
+		if len(hca.IRQs) > 0 && len(hca.LocalCPUs) > 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		res.Status = consts.StatusNormal
+		res.Level = consts.LevelInfo
+		res.Curr = "N/A"
+		res.Detail = "No interrupt affinity of an mlx5 HCA is available"
+		res.Suggestion = ""
+		return &res
+	}
+	sort.Strings(names)
+
+	var builder strings.Builder
+	var failed []string
+	total, remote := 0, 0
+	for _, name := range names {
+		hca := hcas[name]
+		remoteIRQs := hca.RemoteIRQs()
+		total += len(hca.IRQs)
+		remote += len(remoteIRQs)
+		if len(remoteIRQs) == 0 {
+			continue
+		}
+		irqs := make([]string, 0, len(remoteIRQs))
+		for _, irq := range remoteIRQs {
+			irqs = append(irqs, fmt.Sprintf("%d->%s", irq.IRQ, formatCPUList(irq.CPUs)))
+		}
+		builder.WriteString(fmt.Sprintf("%s (%s): %d of %d interrupts are delivered outside its local CPUs %s: %s\n",
+			name, hca.BDF, len(remoteIRQs), len(hca.IRQs), formatCPUList(hca.LocalCPUs), strings.Join(irqs, " ")))
+		failed = append(failed, name)
+	}
+	res.Spec = "0 remote interrupts"
+	res.Curr = fmt.Sprintf("%d of %d interrupts remote", remote, total)
+
+	if len(failed) > 0 {
+		logrus.WithFields(logrus.Fields{
+			"checker": config.PciTopoIRQAffinityCheckerName,
+			"hcas":    failed,
+		}).Warnf("mlx5 interrupts delivered to remote NUMA CPUs")
+		res.Status = consts.StatusAbnormal
+		res.Device = strings.Join(failed, ",")
+		res.Detail = builder.String()
+	} else {
+		res.Detail = "Check Pass"
+	}
+	return &res
+}
@@ -0,0 +1,123 @@
+package topotest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/scitix/sichek/components/pcie/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/hostfs/hostfstest"
+)
+
+func TestCheckGPUHCALocality(t *testing.T) {
+	gpu := func(name, bdf string, numa uint64) *DeviceInfo {
+		return &DeviceInfo{Type: "GPU", Name: name, BDF: bdf, NumaID: numa}
+	}
+	ib := func(name, bdf string, numa uint64) *DeviceInfo {
+		return &DeviceInfo{Type: "IB", Name: name, BDF: bdf, NumaID: numa}
+	}
+	spec := &config.PcieTopoSpec{
+		NumaConfig:        []*config.NumaConfig{{NodeID: 0, GPUCount: 1, IBCount: 1}, {NodeID: 1, GPUCount: 1, IBCount: 1}},
+		PciSwitchesConfig: []*config.PciSwitch{{GPU: 1, IB: 1, Count: 2}},
+	}
+	gpu0, ib0 := gpu("0", "0000:18:00.0", 0), ib("mlx5_0", "0000:19:00.0", 0)
+	gpu1, ib1 := gpu("1", "0000:98:00.0", 1), ib("mlx5_1", "0000:99:00.0", 1)
+	devices := map[string]*DeviceInfo{gpu0.BDF: gpu0, ib0.BDF: ib0, gpu1.BDF: gpu1, ib1.BDF: ib1}
+	switches := map[string]*EndpointInfoByPCIeSW{
+		"0000:10:00.0": {SwitchBDF: "0000:10:00.0", DeviceList: []*DeviceInfo{gpu0, ib0}},
+		"0000:90:00.0": {SwitchBDF: "0000:90:00.0", DeviceList: []*DeviceInfo{gpu1, ib1}},
+	}
+	if res := checkGPUHCALocality(devices, switches, spec); res.Status != consts.StatusNormal {
+		t.Fatalf("expected normal, got %+v", res)
+	}
+
+	// GPU 1 lost its switch local HCA, but the HCA is still on its NUMA node
+	switches["0000:90:00.0"].DeviceList = []*DeviceInfo{gpu1}
+	switches["0000:91:00.0"] = &EndpointInfoByPCIeSW{SwitchBDF: "0000:91:00.0", DeviceList: []*DeviceInfo{ib1}}
+	res := checkGPUHCALocality(devices, switches, spec)
+	if res.Status != consts.StatusAbnormal || res.Device != gpu1.BDF || !strings.Contains(res.Detail, "shares no PCIe switch") {
+		t.Fatalf("expected GPU 1 without a switch local HCA, got %+v", res)
+	}
+
+	// the HCA of NUMA node 1 moved to NUMA node 0
+	ib1.NumaID = 0
+	res = checkGPUHCALocality(devices, switches, spec)
+	if res.Status != consts.StatusAbnormal || res.Device != gpu1.BDF || !strings.Contains(res.Detail, "no HCA on its NUMA node 1") {
+		t.Fatalf("expected GPU 1 without a NUMA local HCA, got %+v", res)
+	}
+
+	// a spec without HCAs on NUMA node 1 and without GPU and HCA pairs expects neither
+	spec = &config.PcieTopoSpec{
+		NumaConfig:        []*config.NumaConfig{{NodeID: 0, GPUCount: 1, IBCount: 2}, {NodeID: 1, GPUCount: 1}},
+		PciSwitchesConfig: []*config.PciSwitch{{GPU: 1, Count: 2}, {IB: 2, Count: 1}},
+	}
+	if res := checkGPUHCALocality(devices, switches, spec); res.Status != consts.StatusNormal {
+		t.Fatalf("expected normal, got %+v", res)
+	}
+}
+
+func TestCollectIRQAffinity(t *testing.T) {
+	hostfstest.Build(t, `
+-- sys/bus/pci/devices/0000:19:00.0/local_cpulist --
+0-3
+-- sys/bus/pci/devices/0000:19:00.0/msi_irqs/40 --
+msix
+-- sys/bus/pci/devices/0000:19:00.0/msi_irqs/41 --
+msix
+-- sys/bus/pci/devices/0000:19:00.0/msi_irqs/42 --
+msix
+-- proc/irq/40/effective_affinity_list --
+2
+-- proc/irq/40/smp_affinity_list --
+0-3
+-- proc/irq/41/effective_affinity_list --
+5
+-- proc/irq/42/smp_affinity_list --
+3-5
+-- sys/bus/pci/devices/0000:29:00.0/local_cpulist --
+4-7
+`)
+	ibs := map[string]*DeviceInfo{
+		"0000:19:00.0": {Type: "IB", Name: "mlx5_0", BDF: "0000:19:00.0"},
+		"0000:29:00.0": {Type: "IB", Name: "mlx5_1", BDF: "0000:29:00.0"},
+		"0000:39:00.0": {Type: "IB", Name: "irdma0", BDF: "0000:39:00.0"},
+	}
+	hcas := CollectIRQAffinity(ibs)
+	if len(hcas) != 1 || hcas["mlx5_0"] == nil {
+		t.Fatalf("expected the interrupts of mlx5_0 only, got %+v", hcas)
+	}
+	hca := hcas["mlx5_0"]
+	if got := formatCPUList(hca.LocalCPUs); got != "0-3" {
+		t.Errorf("unexpected local CPUs %s", got)
+	}
+	if len(hca.IRQs) != 3 || formatCPUList(hca.IRQs[0].CPUs) != "2" || formatCPUList(hca.IRQs[2].CPUs) != "3-5" {
+		t.Fatalf("unexpected interrupts %+v", hca.IRQs)
+	}
+
+	res := checkHCAIRQAffinity(hcas)
+	if res.Status != consts.StatusAbnormal || res.Device != "mlx5_0" {
+		t.Fatalf("expected mlx5_0 with a remote interrupt, got %+v", res)
+	}
+	if !strings.Contains(res.Detail, "1 of 3 interrupts are delivered outside its local CPUs 0-3: 41->5") {
+		t.Errorf("unexpected detail %q", res.Detail)
+	}
+
+	if res := checkHCAIRQAffinity(nil); res.Status != consts.StatusNormal || res.Curr != "N/A" {
+		t.Errorf("expected N/A without interrupts, got %+v", res)
+	}
+}
+
+func TestParseCPUList(t *testing.T) {
+	cpus, err := parseCPUList("0-2,8,10-11\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := formatCPUList(cpus); got != "0-2,8,10-11" {
+		t.Errorf("unexpected CPUs %s", got)
+	}
+	for _, list := range []string{"a", "3-1", "1-x"} {
+		if _, err := parseCPUList(list); err == nil {
+			t.Errorf("expected an error for %q", list)
+		}
+	}
+}
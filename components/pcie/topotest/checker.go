@@ -0,0 +1,128 @@
+package topotest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/pcie/config"
+)
+
+// NumaChecker validates the GPU and IB counts of each NUMA node.
+type NumaChecker struct {
+	name string
+	spec *config.PcieTopoSpec
+}
+
+func NewNumaChecker(spec *config.PcieTopoSpec) (common.Checker, error) {
+	return &NumaChecker{name: config.PciTopoNumaCheckerName, spec: spec}, nil
+}
+
+func (c *NumaChecker) Name() string {
+	return c.name
+}
+
+func (c *NumaChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	info, ok := data.(*TopoInfo)
+	if !ok || info == nil {
+		return nil, fmt.Errorf("invalid data type for %s: expected *topotest.TopoInfo", c.name)
+	}
+	return checkNuma(info.Devices, c.spec.NumaConfig), nil
+}
+
+// SwitchChecker validates the GPU and IB pairing under the PCIe switches.
+type SwitchChecker struct {
+	name string
+	spec *config.PcieTopoSpec
+}
+
+func NewSwitchChecker(spec *config.PcieTopoSpec) (common.Checker, error) {
+	return &SwitchChecker{name: config.PciTopoSwitchCheckerName, spec: spec}, nil
+}
+
+func (c *SwitchChecker) Name() string {
+	return c.name
+}
+
+func (c *SwitchChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	info, ok := data.(*TopoInfo)
+	if !ok || info == nil {
+		return nil, fmt.Errorf("invalid data type for %s: expected *topotest.TopoInfo", c.name)
+	}
+	return checkPciSwitches(info.Switches, c.spec.PciSwitchesConfig), nil
+}
+
+// LocalityChecker validates that each GPU has an HCA under its PCIe switch
+// and on its NUMA node as the spec pairs them.
+type LocalityChecker struct {
+	name string
+	spec *config.PcieTopoSpec
+}
+
+func NewLocalityChecker(spec *config.PcieTopoSpec) (common.Checker, error) {
+	return &LocalityChecker{name: config.PciTopoLocalityCheckerName, spec: spec}, nil
+}
+
+func (c *LocalityChecker) Name() string {
+	return c.name
+}
+
+func (c *LocalityChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	info, ok := data.(*TopoInfo)
+	if !ok || info == nil {
+		return nil, fmt.Errorf("invalid data type for %s: expected *topotest.TopoInfo", c.name)
+	}
+	return checkGPUHCALocality(info.Devices, info.Switches, c.spec), nil
+}
+
+// IRQAffinityChecker validates that the mlx5 interrupts are delivered to the
+// CPUs of the NUMA node of their HCA.
+type IRQAffinityChecker struct {
+	name string
+}
+
+func NewIRQAffinityChecker(spec *config.PcieTopoSpec) (common.Checker, error) {
+	return &IRQAffinityChecker{name: config.PciTopoIRQAffinityCheckerName}, nil
+}
+
+func (c *IRQAffinityChecker) Name() string {
+	return c.name
+}
+
+func (c *IRQAffinityChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	info, ok := data.(*TopoInfo)
+	if !ok || info == nil {
+		return nil, fmt.Errorf("invalid data type for %s: expected *topotest.TopoInfo", c.name)
+	}
+	return checkHCAIRQAffinity(info.HCAIRQs), nil
+}
+
+// NewCheckers creates the PCIe topology checkers, filtering out any in the ignored list.
+func NewCheckers(cfg *config.PcieTopoUserConfig, spec *config.PcieTopoSpec) ([]common.Checker, error) {
+	checkerConstructors := map[string]func(*config.PcieTopoSpec) (common.Checker, error){
+		config.PciTopoNumaCheckerName:        NewNumaChecker,
+		config.PciTopoSwitchCheckerName:      NewSwitchChecker,
+		config.PciTopoLocalityCheckerName:    NewLocalityChecker,
+		config.PciTopoIRQAffinityCheckerName: NewIRQAffinityChecker,
+	}
+
+	ignoredSet := make(map[string]struct{})
+	if cfg != nil && cfg.PcieTopo != nil {
+		for _, v := range cfg.PcieTopo.IgnoredCheckers {
+			ignoredSet[v] = struct{}{}
+		}
+	}
+
+	checkers := make([]common.Checker, 0, len(checkerConstructors))
+	for checkerName, constructor := range checkerConstructors {
+		if _, found := ignoredSet[checkerName]; found {
+			continue
+		}
+		checker, err := constructor(spec)
+		if err != nil {
+			return nil, err
+		}
+		checkers = append(checkers, checker)
+	}
+	return checkers, nil
+}
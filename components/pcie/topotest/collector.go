@@ -0,0 +1,54 @@
+package topotest
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// TopoInfo is the NUMA and lowest common PCIe switch placement of the GPUs
+// and IB devices of the node, and the interrupt affinity of the mlx5 HCAs.
+type TopoInfo struct {
+	Time     time.Time                        `json:"time"`
+	Devices  map[string]*DeviceInfo           `json:"devices"`
+	Switches map[string]*EndpointInfoByPCIeSW `json:"switches"`
+	HCAIRQs  map[string]*HCAIRQInfo           `json:"hca_irqs,omitempty"`
+}
+
+func (t *TopoInfo) JSON() (string, error) {
+	data, err := json.Marshal(t)
+	return string(data), err
+}
+
+// CollectTopology builds the PCIe trees and groups the GPUs and IB devices by
+// their NUMA node and lowest common PCIe switch.
+func CollectTopology() (*TopoInfo, error) {
+	nodes, pciTrees, err := BuildPciTrees()
+	if err != nil {
+		return nil, fmt.Errorf("error building PCIe trees: %v", err)
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("find no pci nodes")
+	}
+	gpus, err := GetGPUList()
+	if err != nil {
+		return nil, err
+	}
+	if len(gpus) == 0 {
+		return nil, fmt.Errorf("find no gpus")
+	}
+	// Find all GPUS by numa node
+	FillNvGPUsWithNumaNode(nodes, gpus)
+
+	ibs, err := GetIBList()
+	if err != nil {
+		return nil, err
+	}
+	devices := mergeDeviceMaps(ibs, gpus)
+	return &TopoInfo{
+		Time:     time.Now(),
+		Devices:  devices,
+		Switches: ParseEndpointsbyCommonSwitch(pciTrees, nodes, devices),
+		HCAIRQs:  CollectIRQAffinity(ibs),
+	}, nil
+}
@@ -0,0 +1,309 @@
+package topotest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+	"github.com/sirupsen/logrus"
+)
+
+// Node and link types of the exported topology graph
+const (
+	TopoNodeNUMA       = "numa"
+	TopoNodeRootPort   = "root_port"
+	TopoNodePCIeSwitch = "pcie_switch"
+	TopoNodePCI        = "pci"
+	TopoNodeGPU        = "gpu"
+	TopoNodeHCA        = "hca"
+	TopoNodeNVSwitch   = "nvswitch"
+
+	TopoLinkNUMA   = "numa"
+	TopoLinkPCIe   = "pcie"
+	TopoLinkNVLink = "nvlink"
+)
+
+// TopoNode is a NUMA node, PCIe bridge, GPU, HCA or NVSwitch of the graph.
+type TopoNode struct {
+	ID      string  `json:"id"`
+	Type    string  `json:"type"`
+	Name    string  `json:"name"`
+	BDF     string  `json:"bdf,omitempty"`
+	NumaID  *uint64 `json:"numa_id,omitempty"`
+	UUID    string  `json:"uuid,omitempty"`
+	BoardID string  `json:"board_id,omitempty"`
+}
+
+// TopoLink connects two nodes. Lanes is the number of NVLinks between them.
+type TopoLink struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Type  string `json:"type"`
+	Lanes int    `json:"lanes,omitempty"`
+}
+
+// TopoGraph is the node topology exported by `sichek topo export`.
+type TopoGraph struct {
+	Time  time.Time   `json:"time"`
+	Nodes []*TopoNode `json:"nodes"`
+	Links []*TopoLink `json:"links"`
+}
+
+// NVLinkConnection is the number of active NVLinks from a GPU to a peer GPU
+// or NVSwitch, identified by their BDF.
+type NVLinkConnection struct {
+	LocalBDF   string `json:"local_bdf"`
+	RemoteBDF  string `json:"remote_bdf"`
+	RemoteType string `json:"remote_type"`
+	Lanes      int    `json:"lanes"`
+}
+
+func numaNodeID(numaID uint64) string {
+	return fmt.Sprintf("numa%d", numaID)
+}
+
+// BuildTopoGraph keeps the PCIe bridges on the path from each GPU and HCA to
+// its root port, hangs the root ports under their NUMA node and adds the
+// NVLink connections between the GPUs and NVSwitches.
+func BuildTopoGraph(nodes map[string]*PciNode, devices map[string]*DeviceInfo, nvlinks []NVLinkConnection) *TopoGraph {
+	graph := &TopoGraph{Time: time.Now()}
+	graphNodes := make(map[string]*TopoNode)
+	links := make(map[[2]string]*TopoLink)
+	addLink := func(from, to, linkType string, lanes int) {
+		key := [2]string{from, to}
+		if link, ok := links[key]; ok {
+			link.Lanes += lanes
+			return
+		}
+		links[key] = &TopoLink{From: from, To: to, Type: linkType, Lanes: lanes}
+	}
+
+	for bdf, device := range devices {
+		pciNode, ok := nodes[bdf]
+		if !ok {
+			logrus.WithField("component", "topo").Warnf("%s %s (%s) is not found in the PCIe trees", device.Type, device.Name, bdf)
+			continue
+		}
+		numaID := device.NumaID
+		node := &TopoNode{ID: bdf, BDF: bdf, NumaID: &numaID, UUID: device.UUID, BoardID: device.BoardID}
+		switch device.Type {
+		case "GPU":
+			node.Type = TopoNodeGPU
+			node.Name = "GPU " + device.Name
+		case "IB":
+			node.Type = TopoNodeHCA
+			node.Name = device.Name
+		default:
+			node.Type = TopoNodePCI
+			node.Name = device.Name
+		}
+		graphNodes[bdf] = node
+
+		// walk up to the root port, adding the bridges that are not yet known
+		child := pciNode
+		for parent := pciNode.Parent; parent != nil; child, parent = parent, parent.Parent {
+			addLink(parent.BDF, child.BDF, TopoLinkPCIe, 0)
+			if _, ok := graphNodes[parent.BDF]; ok {
+				break
+			}
+			graphNodes[parent.BDF] = newBridgeNode(parent)
+		}
+		if child.Parent == nil {
+			numa := numaNodeID(child.NumaID)
+			if _, ok := graphNodes[numa]; !ok {
+				numaID := child.NumaID
+				graphNodes[numa] = &TopoNode{ID: numa, Type: TopoNodeNUMA, Name: fmt.Sprintf("NUMA %d", numaID), NumaID: &numaID}
+			}
+			addLink(numa, child.BDF, TopoLinkNUMA, 0)
+		}
+	}
+
+	for _, conn := range nvlinks {
+		if _, ok := graphNodes[conn.LocalBDF]; !ok {
+			continue
+		}
+		remote := conn.RemoteBDF
+		switch conn.RemoteType {
+		case TopoNodeGPU:
+			if _, ok := graphNodes[remote]; !ok {
+				continue
+			}
+		default:
+			remote = conn.RemoteType + ":" + conn.RemoteBDF
+			if _, ok := graphNodes[remote]; !ok {
+				graphNodes[remote] = &TopoNode{ID: remote, Type: conn.RemoteType, Name: "NVSwitch " + conn.RemoteBDF, BDF: conn.RemoteBDF}
+			}
+		}
+		// GPU to GPU links are reported by both ends, keep one direction
+		from, to := conn.LocalBDF, remote
+		if conn.RemoteType == TopoNodeGPU && from > to {
+			continue
+		}
+		addLink(from, to, TopoLinkNVLink, conn.Lanes)
+	}
+
+	for _, node := range graphNodes {
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool { return graph.Nodes[i].ID < graph.Nodes[j].ID })
+	for _, link := range links {
+		graph.Links = append(graph.Links, link)
+	}
+	sort.Slice(graph.Links, func(i, j int) bool {
+		if graph.Links[i].Type != graph.Links[j].Type {
+			return graph.Links[i].Type > graph.Links[j].Type
+		}
+		if graph.Links[i].From != graph.Links[j].From {
+			return graph.Links[i].From < graph.Links[j].From
+		}
+		return graph.Links[i].To < graph.Links[j].To
+	})
+	return graph
+}
+
+func newBridgeNode(pciNode *PciNode) *TopoNode {
+	numaID := pciNode.NumaID
+	node := &TopoNode{ID: pciNode.BDF, BDF: pciNode.BDF, NumaID: &numaID}
+	switch {
+	case pciNode.Parent == nil:
+		node.Type = TopoNodeRootPort
+		node.Name = "Root Port " + pciNode.BDF
+	case pciNode.IsSwitch:
+		node.Type = TopoNodePCIeSwitch
+		node.Name = "PCIe Switch " + pciNode.BDF
+	default:
+		node.Type = TopoNodePCI
+		node.Name = pciNode.Name
+	}
+	return node
+}
+
+func (g *TopoGraph) JSON() ([]byte, error) {
+	return json.MarshalIndent(g, "", "  ")
+}
+
+// dotNodeAttrs is the Graphviz style of every node type
+var dotNodeAttrs = map[string]string{
+	TopoNodeNUMA:       `shape=ellipse, style=filled, fillcolor="#f4d03f"`,
+	TopoNodeRootPort:   `shape=box, style=filled, fillcolor="#d5d8dc"`,
+	TopoNodePCIeSwitch: `shape=box, style=filled, fillcolor="#aeb6bf"`,
+	TopoNodePCI:        `shape=box`,
+	TopoNodeGPU:        `shape=box3d, style=filled, fillcolor="#82e0aa"`,
+	TopoNodeHCA:        `shape=component, style=filled, fillcolor="#85c1e9"`,
+	TopoNodeNVSwitch:   `shape=hexagon, style=filled, fillcolor="#58d68d"`,
+}
+
+// DOT renders the graph in the Graphviz DOT language, e.g. for
+// `sichek topo export --format dot | dot -Tsvg -o topo.svg`.
+func (g *TopoGraph) DOT() string {
+	var b strings.Builder
+	b.WriteString("graph topology {\n")
+	b.WriteString("  rankdir=TB;\n")
+	b.WriteString("  node [fontname=\"Helvetica\", fontsize=10];\n")
+	for _, node := range g.Nodes {
+		label := node.Name
+		if node.BDF != "" && !strings.HasSuffix(node.Name, node.BDF) {
+			label += "\\n" + node.BDF
+		}
+		fmt.Fprintf(&b, "  %q [label=%s, %s];\n", node.ID, dotQuote(label), dotNodeAttrs[node.Type])
+	}
+	for _, link := range g.Links {
+		switch link.Type {
+		case TopoLinkNVLink:
+			fmt.Fprintf(&b, "  %q -- %q [label=\"NV%d\", color=\"#28b463\", penwidth=2, constraint=false];\n", link.From, link.To, link.Lanes)
+		case TopoLinkNUMA:
+			fmt.Fprintf(&b, "  %q -- %q [style=dashed];\n", link.From, link.To)
+		default:
+			fmt.Fprintf(&b, "  %q -- %q;\n", link.From, link.To)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotQuote quotes a DOT label, keeping the \n line breaks of Graphviz
+func dotQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// CollectNVLinks reads the remote end of every active NVLink of the GPUs.
+func CollectNVLinks(gpus map[string]*DeviceInfo) ([]NVLinkConnection, error) {
+	nvmlInst := nvml.New()
+	if ret := nvmlInst.Init(); ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("failed to initialize NVML: %v", nvml.ErrorString(ret))
+	}
+	defer nvmlInst.Shutdown()
+
+	lanes := make(map[[2]string]*NVLinkConnection)
+	for bdf, gpu := range gpus {
+		device, ret := nvmlInst.DeviceGetHandleByUUID(gpu.UUID)
+		if ret != nvml.SUCCESS {
+			logrus.WithField("component", "topo").Warnf("failed to get GPU %s: %s", gpu.Name, nvml.ErrorString(ret))
+			continue
+		}
+		for link := 0; link < int(nvml.NVLINK_MAX_LINKS); link++ {
+			state, ret := device.GetNvLinkState(link)
+			if ret == nvml.ERROR_INVALID_ARGUMENT || ret == nvml.ERROR_NOT_SUPPORTED {
+				break
+			}
+			if ret != nvml.SUCCESS || state != nvml.FEATURE_ENABLED {
+				continue
+			}
+			pciInfo, ret := device.GetNvLinkRemotePciInfo(link)
+			if ret != nvml.SUCCESS {
+				continue
+			}
+			remoteType := TopoNodeGPU
+			if devType, ret := device.GetNvLinkRemoteDeviceType(link); ret == nvml.SUCCESS && devType != nvml.NVLINK_DEVICE_TYPE_GPU {
+				remoteType = TopoNodeNVSwitch
+			}
+			remote := fmt.Sprintf("%04x:%02x:%02x.0", pciInfo.Domain, pciInfo.Bus, pciInfo.Device)
+			key := [2]string{bdf, remote}
+			if conn, ok := lanes[key]; ok {
+				conn.Lanes++
+				continue
+			}
+			lanes[key] = &NVLinkConnection{LocalBDF: bdf, RemoteBDF: remote, RemoteType: remoteType, Lanes: 1}
+		}
+	}
+	conns := make([]NVLinkConnection, 0, len(lanes))
+	for _, conn := range lanes {
+		conns = append(conns, *conn)
+	}
+	return conns, nil
+}
+
+// CollectTopoGraph builds the topology graph of the local node. A node without
+// NVIDIA GPUs still gets the graph of its HCAs.
+func CollectTopoGraph() (*TopoGraph, error) {
+	nodes, _, err := BuildPciTrees()
+	if err != nil {
+		return nil, fmt.Errorf("error building PCIe trees: %v", err)
+	}
+	gpus, err := GetGPUList()
+	if err != nil {
+		logrus.WithField("component", "topo").Warnf("skip GPUs: %v", err)
+		gpus = map[string]*DeviceInfo{}
+	}
+	FillNvGPUsWithNumaNode(nodes, gpus)
+	ibs, err := GetIBList()
+	if err != nil {
+		logrus.WithField("component", "topo").Warnf("skip IB devices: %v", err)
+		ibs = map[string]*DeviceInfo{}
+	}
+	devices := mergeDeviceMaps(ibs, gpus)
+	if len(devices) == 0 {
+		return nil, fmt.Errorf("find no gpus or ib devices")
+	}
+	var nvlinks []NVLinkConnection
+	if len(gpus) > 0 {
+		nvlinks, err = CollectNVLinks(gpus)
+		if err != nil {
+			logrus.WithField("component", "topo").Warnf("skip NVLinks: %v", err)
+		}
+	}
+	return BuildTopoGraph(nodes, devices, nvlinks), nil
+}
@@ -0,0 +1,147 @@
+package topotest
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// newTestPciNodes builds root port 0000:00:01.0 on NUMA 0 with a PCIe switch
+// carrying GPU 0 and mlx5_0, root port 0000:80:01.0 on NUMA 1 with GPU 1,
+// and an unrelated NIC that must not show up in the graph.
+func newTestPciNodes() map[string]*PciNode {
+	nodes := make(map[string]*PciNode)
+	add := func(bdf string, numa uint64, isSwitch bool, parent string) {
+		node := &PciNode{BDF: bdf, NumaID: numa, IsSwitch: isSwitch, Name: "Vendor-0x15b3-Device-0x1021"}
+		if p, ok := nodes[parent]; ok {
+			node.Parent = p
+			p.Children = append(p.Children, node)
+		}
+		nodes[bdf] = node
+	}
+	add("0000:00:01.0", 0, true, "")
+	add("0000:10:00.0", 0, true, "0000:00:01.0")
+	add("0000:11:00.0", 0, true, "0000:10:00.0")
+	add("0000:12:00.0", 0, true, "0000:10:00.0")
+	add("0000:18:00.0", 0, false, "0000:11:00.0")
+	add("0000:19:00.0", 0, false, "0000:12:00.0")
+	add("0000:80:01.0", 1, true, "")
+	add("0000:88:00.0", 1, false, "0000:80:01.0")
+	add("0000:00:02.0", 0, true, "")
+	add("0000:05:00.0", 0, false, "0000:00:02.0")
+	return nodes
+}
+
+func TestBuildTopoGraph(t *testing.T) {
+	devices := map[string]*DeviceInfo{
+		"0000:18:00.0": {Type: "GPU", Name: "0", BDF: "0000:18:00.0", UUID: "GPU-0"},
+		"0000:19:00.0": {Type: "IB", Name: "mlx5_0", BDF: "0000:19:00.0", BoardID: "MT_0000000838"},
+		"0000:88:00.0": {Type: "GPU", Name: "1", BDF: "0000:88:00.0", UUID: "GPU-1", NumaID: 1},
+	}
+	nvlinks := []NVLinkConnection{
+		{LocalBDF: "0000:18:00.0", RemoteBDF: "0000:88:00.0", RemoteType: TopoNodeGPU, Lanes: 4},
+		{LocalBDF: "0000:88:00.0", RemoteBDF: "0000:18:00.0", RemoteType: TopoNodeGPU, Lanes: 4},
+		{LocalBDF: "0000:18:00.0", RemoteBDF: "0000:c0:00.0", RemoteType: TopoNodeNVSwitch, Lanes: 2},
+		{LocalBDF: "0000:88:00.0", RemoteBDF: "0000:c0:00.0", RemoteType: TopoNodeNVSwitch, Lanes: 2},
+	}
+	graph := BuildTopoGraph(newTestPciNodes(), devices, nvlinks)
+
+	types := make(map[string]string)
+	for _, node := range graph.Nodes {
+		types[node.ID] = node.Type
+	}
+	want := map[string]string{
+		"numa0":                 TopoNodeNUMA,
+		"numa1":                 TopoNodeNUMA,
+		"0000:00:01.0":          TopoNodeRootPort,
+		"0000:10:00.0":          TopoNodePCIeSwitch,
+		"0000:11:00.0":          TopoNodePCIeSwitch,
+		"0000:12:00.0":          TopoNodePCIeSwitch,
+		"0000:18:00.0":          TopoNodeGPU,
+		"0000:19:00.0":          TopoNodeHCA,
+		"0000:80:01.0":          TopoNodeRootPort,
+		"0000:88:00.0":          TopoNodeGPU,
+		"nvswitch:0000:c0:00.0": TopoNodeNVSwitch,
+	}
+	if len(types) != len(want) {
+		t.Errorf("expected %d nodes, got %v", len(want), types)
+	}
+	for id, typ := range want {
+		if types[id] != typ {
+			t.Errorf("node %s: expected type %q, got %q", id, typ, types[id])
+		}
+	}
+
+	links := make(map[string]int)
+	for _, link := range graph.Links {
+		links[link.Type+" "+link.From+" "+link.To] = link.Lanes
+	}
+	for _, l := range []string{
+		"numa numa0 0000:00:01.0",
+		"numa numa1 0000:80:01.0",
+		"pcie 0000:00:01.0 0000:10:00.0",
+		"pcie 0000:10:00.0 0000:11:00.0",
+		"pcie 0000:10:00.0 0000:12:00.0",
+		"pcie 0000:11:00.0 0000:18:00.0",
+		"pcie 0000:12:00.0 0000:19:00.0",
+		"pcie 0000:80:01.0 0000:88:00.0",
+	} {
+		if _, ok := links[l]; !ok {
+			t.Errorf("missing link %q in %v", l, links)
+		}
+	}
+	if lanes := links["nvlink 0000:18:00.0 0000:88:00.0"]; lanes != 4 {
+		t.Errorf("expected one GPU to GPU NVLink with 4 lanes, got %v", links)
+	}
+	if _, ok := links["nvlink 0000:88:00.0 0000:18:00.0"]; ok {
+		t.Errorf("GPU to GPU NVLink is duplicated: %v", links)
+	}
+	if lanes := links["nvlink 0000:88:00.0 nvswitch:0000:c0:00.0"]; lanes != 2 {
+		t.Errorf("expected NVSwitch link with 2 lanes, got %v", links)
+	}
+	if len(links) != 11 {
+		t.Errorf("expected 11 links, got %d: %v", len(links), links)
+	}
+}
+
+func TestTopoGraphRender(t *testing.T) {
+	devices := map[string]*DeviceInfo{
+		"0000:18:00.0": {Type: "GPU", Name: "0", BDF: "0000:18:00.0"},
+		"0000:88:00.0": {Type: "GPU", Name: "1", BDF: "0000:88:00.0", NumaID: 1},
+	}
+	nvlinks := []NVLinkConnection{{LocalBDF: "0000:18:00.0", RemoteBDF: "0000:88:00.0", RemoteType: TopoNodeGPU, Lanes: 18}}
+	graph := BuildTopoGraph(newTestPciNodes(), devices, nvlinks)
+
+	dot := graph.DOT()
+	for _, want := range []string{
+		"graph topology {",
+		`"0000:18:00.0" [label="GPU 0\n0000:18:00.0", shape=box3d`,
+		`"numa0" -- "0000:00:01.0" [style=dashed];`,
+		`"0000:11:00.0" -- "0000:18:00.0";`,
+		`"0000:18:00.0" -- "0000:88:00.0" [label="NV18"`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("dot output does not contain %q:\n%s", want, dot)
+		}
+	}
+	if !strings.HasSuffix(dot, "}\n") {
+		t.Errorf("dot output is not terminated:\n%s", dot)
+	}
+
+	data, err := graph.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded TopoGraph
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Nodes) != len(graph.Nodes) || len(decoded.Links) != len(graph.Links) {
+		t.Errorf("json round trip lost nodes or links: %s", data)
+	}
+	for _, node := range decoded.Nodes {
+		if node.ID == "0000:88:00.0" && (node.NumaID == nil || *node.NumaID != 1) {
+			t.Errorf("expected GPU 1 on NUMA 1, got %+v", node)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package topotest
+
+import (
+	"sort"
+
+	nvutils "github.com/scitix/sichek/components/nvidia/utils"
+	"github.com/scitix/sichek/components/pcie/config"
+)
+
+// GenerateSpec inspects the PCIe trees of the local node and returns the GPU
+// device ID used as spec key with the observed NUMA and PCIe switch layout.
+func GenerateSpec() (string, *config.PcieTopoSpec, error) {
+	deviceID, err := nvutils.GetDeviceID()
+	if err != nil {
+		return "", nil, err
+	}
+	info, err := CollectTopology()
+	if err != nil {
+		return "", nil, err
+	}
+	return deviceID, newSpecFromDevices(info.Devices, info.Switches), nil
+}
+
+// newSpecFromDevices is the reverse of checkNuma and checkPciSwitches.
+func newSpecFromDevices(devices map[string]*DeviceInfo, switches map[string]*EndpointInfoByPCIeSW) *config.PcieTopoSpec {
+	numaCount := make(map[uint64]*config.NumaConfig)
+	for _, device := range devices {
+		numa, ok := numaCount[device.NumaID]
+		if !ok {
+			numa = &config.NumaConfig{NodeID: device.NumaID}
+			numaCount[device.NumaID] = numa
+		}
+		switch device.Type {
+		case "GPU":
+			numa.GPUCount++
+		case "IB":
+			numa.IBCount++
+		}
+	}
+	spec := &config.PcieTopoSpec{}
+	for _, numa := range numaCount {
+		spec.NumaConfig = append(spec.NumaConfig, numa)
+	}
+	sort.Slice(spec.NumaConfig, func(i, j int) bool {
+		return spec.NumaConfig[i].NodeID < spec.NumaConfig[j].NodeID
+	})
+
+	switchCount := make(map[[2]int]int)
+	for _, sw := range switches {
+		var gpu, ib int
+		for _, dev := range sw.DeviceList {
+			switch dev.Type {
+			case "GPU":
+				gpu++
+			case "IB":
+				ib++
+			}
+		}
+		switchCount[[2]int{gpu, ib}]++
+	}
+	for key, count := range switchCount {
+		spec.PciSwitchesConfig = append(spec.PciSwitchesConfig, &config.PciSwitch{GPU: key[0], IB: key[1], Count: count})
+	}
+	sort.Slice(spec.PciSwitchesConfig, func(i, j int) bool {
+		a, b := spec.PciSwitchesConfig[i], spec.PciSwitchesConfig[j]
+		if a.GPU != b.GPU {
+			return a.GPU > b.GPU
+		}
+		return a.IB > b.IB
+	})
+	return spec
+}
@@ -9,6 +9,8 @@ import (
 	"strconv"
 
 	"github.com/NVIDIA/go-nvml/pkg/nvml"
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/scitix/sichek/pkg/printer"
 	"github.com/sirupsen/logrus"
 
 	"strings"
@@ -83,10 +85,10 @@ func readFile(path string) (string, error) {
 }
 
 func GetNUMANodes() []string {
-	const path = "/sys/devices/system/node/"
+	path := hostfs.Path("/sys/devices/system/node")
 	files, err := os.ReadDir(path)
 	if err != nil {
-		fmt.Println("Error reading NUMA node info:", err)
+		printer.Println("Error reading NUMA node info:", err)
 		return nil
 	}
 	var nodes []string
@@ -99,9 +101,9 @@ func GetNUMANodes() []string {
 }
 
 func GetCPUVendorID() string {
-	data, err := os.ReadFile("/proc/cpuinfo")
+	data, err := os.ReadFile(hostfs.Path("/proc/cpuinfo"))
 	if err != nil {
-		fmt.Println("Error reading /proc/cpuinfo:", err)
+		printer.Println("Error reading /proc/cpuinfo:", err)
 		return "Unknown"
 	}
 	lines := strings.Split(string(data), "\n")
@@ -121,7 +123,7 @@ func BuildPciTrees() (map[string]*PciNode, []PciTree, error) {
 	// Map to store all nodes by their BDF
 	nodes := make(map[string]*PciNode)
 	// Iterate over all devices in /sys/bus/pci/ddevices
-	deviceDir := "/sys/bus/pci/devices"
+	deviceDir := hostfs.Path("/sys/bus/pci/devices")
 	entries, err := os.ReadDir(deviceDir)
 	if err != nil {
 		return nil, nil, err
@@ -138,13 +140,13 @@ func BuildPciTrees() (map[string]*PciNode, []PciTree, error) {
 		if err == nil {
 			classCode, err = strconv.ParseUint(classStr, 0, 32)
 			if err != nil {
-				fmt.Printf("Error parse class code for BDF %s: %v\n", bdf, err)
+				printer.Printf("Error parse class code for BDF %s: %v\n", bdf, err)
 				continue
 			}
 			node.IsSwitch = (classCode == 0x060400) // 0x060400:PCI-to-PCI bridge
 
 		} else {
-			fmt.Printf("Error reading class for BDF %s: %v\n", bdf, err)
+			printer.Printf("Error reading class for BDF %s: %v\n", bdf, err)
 			continue
 		}
 		vendorPath := filepath.Join(deviceDir, bdf, "vendor")
@@ -153,11 +155,11 @@ func BuildPciTrees() (map[string]*PciNode, []PciTree, error) {
 		if err == nil {
 			vendorCode, err = strconv.ParseUint(vendorStr, 0, 32)
 			if err != nil {
-				fmt.Printf("Error parse vendor code for BDF %s: %v\n", bdf, err)
+				printer.Printf("Error parse vendor code for BDF %s: %v\n", bdf, err)
 				continue
 			}
 		} else {
-			fmt.Printf("Error reading vendor for BDF %s: %v\n", bdf, err)
+			printer.Printf("Error reading vendor for BDF %s: %v\n", bdf, err)
 			continue
 		}
 		devicePath := filepath.Join(deviceDir, bdf, "device")
@@ -166,11 +168,11 @@ func BuildPciTrees() (map[string]*PciNode, []PciTree, error) {
 		if err == nil {
 			deviceCode, err = strconv.ParseUint(deviceStr, 0, 32)
 			if err != nil {
-				fmt.Printf("Error parse device code for BDF %ss: %v\n", bdf, err)
+				printer.Printf("Error parse device code for BDF %ss: %v\n", bdf, err)
 				continue
 			}
 		} else {
-			fmt.Printf("Error reading device for BDF %s: %v\n", bdf, err)
+			printer.Printf("Error reading device for BDF %s: %v\n", bdf, err)
 			continue
 		}
 
@@ -183,7 +185,7 @@ func BuildPciTrees() (map[string]*PciNode, []PciTree, error) {
 				continue
 			}
 		} else {
-			fmt.Printf("Error reading numaNode for BDF %s:%v\n", bdf, err)
+			printer.Printf("Error reading numaNode for BDF %s:%v\n", bdf, err)
 			continue
 		}
 		node.Name = fmt.Sprintf("Vendor-%s-Device-%s", vendorStr, deviceStr)
@@ -198,7 +200,7 @@ func BuildPciTrees() (map[string]*PciNode, []PciTree, error) {
 		parentPath := deviceDir + "/" + bdf + "/.."
 		parentRealPath, err := filepath.EvalSymlinks(parentPath)
 		if err != nil {
-			fmt.Printf("Warning: failed to evaluate symlink ffor %s: %v\n", bdf, err)
+			printer.Printf("Warning: failed to evaluate symlink ffor %s: %v\n", bdf, err)
 			continue
 		}
 		parentBDF := filepath.Base(parentRealPath)
@@ -250,25 +252,25 @@ func GetGPUList() (map[string]*DeviceInfo, error) {
 		device, err := nvmlInst.DeviceGetHandleByIndex(i)
 		gpu := &DeviceInfo{}
 		if err != nvml.SUCCESS {
-			fmt.Printf("failed to get Nvidia GPU %d: %s", i, nvml.ErrorString(err))
+			printer.Printf("failed to get Nvidia GPU %d: %s", i, nvml.ErrorString(err))
 			continue
 		}
 		minorNumber, err := device.GetMinorNumber()
 		if err != nvml.SUCCESS {
-			fmt.Printf("failed to get index for GPU %d: %v", i, nvml.ErrorString(err))
+			printer.Printf("failed to get index for GPU %d: %v", i, nvml.ErrorString(err))
 			continue
 		}
 		gpu.Name = strconv.Itoa(minorNumber)
 		// Get GPU UUID
 		uuid, err := device.GetUUID()
 		if err != nvml.SUCCESS {
-			fmt.Printf("failed to get UUID for GPU %d: %v", i, nvml.ErrorString(err))
+			printer.Printf("failed to get UUID for GPU %d: %v", i, nvml.ErrorString(err))
 			continue
 		}
 		gpu.UUID = uuid
 		pciInfo, err := device.GetPciInfo()
 		if err != nvml.SUCCESS {
-			fmt.Printf("failed to get PCIe Info for NVIDIA GPU %d: %s", i, nvml.ErrorString(err))
+			printer.Printf("failed to get PCIe Info for NVIDIA GPU %d: %s", i, nvml.ErrorString(err))
 			continue
 		}
 		gpu.Type = "GPU"
@@ -281,7 +283,7 @@ func GetGPUList() (map[string]*DeviceInfo, error) {
 }
 
 func GetIBList() (map[string]*DeviceInfo, error) {
-	basePath := "/sys/class/infiniband"
+	basePath := hostfs.Path("/sys/class/infiniband")
 	entries, err := os.ReadDir(basePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read infiniband dir: %v", err)
@@ -299,7 +301,7 @@ func GetIBList() (map[string]*DeviceInfo, error) {
 		// read PCI BDF
 		realPath, err := filepath.EvalSymlinks(devPath)
 		if err != nil {
-			fmt.Printf("Error evaluating symlink for %s: %v\n", devPath, err)
+			printer.Printf("Error evaluating symlink for %s: %v\n", devPath, err)
 			continue
 		}
 		bdf := filepath.Base(realPath)
@@ -310,7 +312,7 @@ func GetIBList() (map[string]*DeviceInfo, error) {
 		if err == nil {
 			boardIDStr = strings.TrimSpace(boardIDStr)
 		} else {
-			fmt.Printf("Error reading board ID for BDF %s: %v\n", bdf, err)
+			printer.Printf("Error reading board ID for BDF %s: %v\n", bdf, err)
 			continue
 		}
 
@@ -329,11 +331,11 @@ func GetIBList() (map[string]*DeviceInfo, error) {
 		if err == nil {
 			numaNode, err = strconv.ParseUint(numaNodeStr, 0, 32)
 			if err != nil {
-				fmt.Printf("Error parse IB's numaNode code for BDF %s: %v\n", bdf, err)
+				printer.Printf("Error parse IB's numaNode code for BDF %s: %v\n", bdf, err)
 				continue
 			}
 		} else {
-			fmt.Printf("Error reading numaNode for BDF %s:%v\n", bdf, err)
+			printer.Printf("Error reading numaNode for BDF %s:%v\n", bdf, err)
 			continue
 		}
 		ibInfo := &DeviceInfo{
@@ -358,7 +360,7 @@ func FillNvGPUsWithNumaNode(nodes map[string]*PciNode, gpus map[string]*DeviceIn
 			domain := strings.Split(node.BDF, ":")[0] // Extract domainfrom BDF
 			gpu, exist := gpus[node.BDF]
 			if !exist {
-				fmt.Printf("not find gpus for BDF %s\n", node.BDF)
+				printer.Printf("not find gpus for BDF %s\n", node.BDF)
 				continue
 			}
 			gpu.NumaID = numaNode
@@ -493,7 +495,7 @@ func mergeDeviceMaps(map1, map2 map[string]*DeviceInfo) map[string]*DeviceInfo {
 
 	for key, value := range map2 {
 		if existingValue, exists := merged[key]; exists {
-			fmt.Printf("Key %s exists, keeping original value: %+v\n", key, existingValue)
+			printer.Printf("Key %s exists, keeping original value: %+v\n", key, existingValue)
 		} else {
 			merged[key] = value
 		}
@@ -503,48 +505,48 @@ func mergeDeviceMaps(map1, map2 map[string]*DeviceInfo) map[string]*DeviceInfo {
 
 func PrintGPUTopology() {
 	cpuVendorId := GetCPUVendorID()
-	fmt.Printf("CPU vendor id: %s\n", cpuVendorId)
+	printer.Printf("CPU vendor id: %s\n", cpuVendorId)
 	numaNodes := GetNUMANodes()
-	fmt.Printf("Number of NUMA nodes: %d\n", len(numaNodes))
+	printer.Printf("Number of NUMA nodes: %d\n", len(numaNodes))
 	if cpuVendorId == "AuthenticAMD" {
-		fmt.Printf("Get AuthenticAMD with %d NUMA nodes\n", len(numaNodes))
+		printer.Printf("Get AuthenticAMD with %d NUMA nodes\n", len(numaNodes))
 	}
 	// Build PCIe trees
 	nodes, pciTrees, err := BuildPciTrees()
 	if err != nil {
 		// t.Errorf("Error building PCIe trees: %v\n", err)
-		fmt.Printf("Error building PCIe trees: %v\n", err)
+		printer.Printf("Error building PCIe trees: %v\n", err)
 		os.Exit(1)
 	}
 
 	ibs, err := GetIBList()
 	if err != nil {
-		fmt.Printf("Error GetIBList: %v\n", err)
+		printer.Printf("Error GetIBList: %v\n", err)
 		return
 	}
 	for _, ib := range ibs {
-		fmt.Printf("IB %s: boardID=%s, BDF=%v, numa_node=%v, domain=%v\n", ib.Name, ib.BoardID, ib.BDF, ib.NumaID, ib.DomainID)
+		printer.Printf("IB %s: boardID=%s, BDF=%v, numa_node=%v, domain=%v\n", ib.Name, ib.BoardID, ib.BDF, ib.NumaID, ib.DomainID)
 	}
 	gpus, err := GetGPUList()
 	if err != nil {
-		fmt.Printf("Error GetGPUList: %v\n", err)
+		printer.Printf("Error GetGPUList: %v\n", err)
 		return
 	}
 	// Find all GPUS by numa node
 	FillNvGPUsWithNumaNode(nodes, gpus)
 	for _, gpu := range gpus {
-		fmt.Printf("GPU %s: uuid=%v, BDF=%v, numa_node=%v, domain=%v\n", gpu.Name, gpu.UUID, gpu.BDF, gpu.NumaID, gpu.DomainID)
+		printer.Printf("GPU %s: uuid=%v, BDF=%v, numa_node=%v, domain=%v\n", gpu.Name, gpu.UUID, gpu.BDF, gpu.NumaID, gpu.DomainID)
 	}
 	devices := mergeDeviceMaps(ibs, gpus)
 	// Find all GPUS and IBs by common PCIe switch
 	endpointListbyCommonPcieSWs := ParseEndpointsbyCommonSwitch(pciTrees, nodes, devices)
-	fmt.Printf("Find GPUS and IBs by common PCIe switch: \n")
+	printer.Printf("Find GPUS and IBs by common PCIe switch: \n")
 	for _, sw := range endpointListbyCommonPcieSWs {
-		fmt.Printf(" - PCIe Switch: %s, with GPUS and IBs: \n", sw.SwitchBDF)
+		printer.Printf(" - PCIe Switch: %s, with GPUS and IBs: \n", sw.SwitchBDF)
 		for _, device := range sw.DeviceList {
-			fmt.Printf("%s %s: uuid=%v, BDF=%v, numa_node=%v, domain=%v\n", device.Type, device.Name, device.UUID, device.BDF, device.NumaID, device.DomainID)
+			printer.Printf("%s %s: uuid=%v, BDF=%v, numa_node=%v, domain=%v\n", device.Type, device.Name, device.UUID, device.BDF, device.NumaID, device.DomainID)
 		}
-		fmt.Println()
+		printer.Println()
 	}
 
 }
@@ -1,13 +1,16 @@
 package topotest
 
 import (
+	"context"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/scitix/sichek/components/common"
 	"github.com/scitix/sichek/components/pcie/config"
 	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
 	"github.com/sirupsen/logrus"
 )
 
@@ -105,31 +108,38 @@ func summarizeSwitchConfig(config []*config.PciSwitch) map[string]int {
 	return counts
 }
 
+// switchPairing returns the GPU and IB pairing of the switch in the key format of summarizeSwitchConfig.
+func switchPairing(sw *EndpointInfoByPCIeSW) string {
+	gpu, ib := 0, 0
+	for _, dev := range sw.DeviceList {
+		switch dev.Type {
+		case "GPU":
+			gpu++
+		case "IB":
+			ib++
+		}
+	}
+	return fmt.Sprintf("gpu_%d&&ib_%d", gpu, ib)
+}
+
 func summarizeActualSwitch(pciSwitch map[string]*EndpointInfoByPCIeSW) map[string]int {
 	counts := make(map[string]int)
 	for _, sw := range pciSwitch {
-		gpu, ib := 0, 0
-		for _, dev := range sw.DeviceList {
-			switch dev.Type {
-			case "GPU":
-				gpu++
-			case "IB":
-				ib++
-			}
-		}
-		key := fmt.Sprintf("gpu_%d&&ib_%d", gpu, ib)
-		counts[key]++
+		counts[switchPairing(sw)]++
 	}
 	return counts
 }
 
-func checkPciSwitches(pciTrees []PciTree, nodes map[string]*PciNode, devices map[string]*DeviceInfo, PciSwitchesConfig []*config.PciSwitch) *common.CheckerResult {
-	endpointListbyCommonPcieSWs := ParseEndpointsbyCommonSwitch(pciTrees, nodes, devices)
+// checkPciSwitches compares the GPU and IB pairings under the lowest common
+// PCIe switches with the spec. The switches whose pairing exceeds the
+// expected count hold the miscabled or misseated devices and are reported
+// with their devices.
+func checkPciSwitches(switches map[string]*EndpointInfoByPCIeSW, PciSwitchesConfig []*config.PciSwitch) *common.CheckerResult {
 	res := config.PciTopoCheckItems[config.PciTopoSwitchCheckerName]
 	var builder strings.Builder
 
 	expected := summarizeSwitchConfig(PciSwitchesConfig)
-	actual := summarizeActualSwitch(endpointListbyCommonPcieSWs)
+	actual := summarizeActualSwitch(switches)
 
 	if !reflect.DeepEqual(expected, actual) {
 		logrus.WithFields(logrus.Fields{
@@ -140,6 +150,35 @@ func checkPciSwitches(pciTrees []PciTree, nodes map[string]*PciNode, devices map
 		res.Status = consts.StatusAbnormal
 		builder.WriteString(fmt.Sprintf("switch configuration mismatch.\nExpected: %v\nActual: %v\n", expected, actual))
 
+		remaining := make(map[string]int, len(expected))
+		for key, count := range expected {
+			remaining[key] = count
+		}
+		switchBDFs := make([]string, 0, len(switches))
+		for bdf := range switches {
+			switchBDFs = append(switchBDFs, bdf)
+		}
+		sort.Strings(switchBDFs)
+		var devices []string
+		for _, bdf := range switchBDFs {
+			sw := switches[bdf]
+			key := switchPairing(sw)
+			if remaining[key] > 0 {
+				remaining[key]--
+				continue
+			}
+			builder.WriteString(fmt.Sprintf("PCIe switch %s has unexpected pairing %s:%s\n", bdf, key, sw.String()))
+			for _, dev := range sw.DeviceList {
+				devices = append(devices, dev.BDF)
+			}
+		}
+		for _, key := range sortedKeys(remaining) {
+			if remaining[key] > 0 {
+				builder.WriteString(fmt.Sprintf("%d PCIe switches with pairing %s are missing\n", remaining[key], key))
+			}
+		}
+		sort.Strings(devices)
+		res.Device = strings.Join(devices, ",")
 	}
 
 	res.Detail = builder.String()
@@ -149,50 +188,56 @@ func checkPciSwitches(pciTrees []PciTree, nodes map[string]*PciNode, devices map
 	return &res
 }
 
-func CheckGPUTopology(file string) (*common.Result, error) {
-	spec, err := config.LoadSpec(file)
-	if err != nil {
-		return nil, fmt.Errorf("load GPUTopology Config Err: %v", err)
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
 	}
+	sort.Strings(keys)
+	return keys
+}
 
-	// Build PCIe trees
-	nodes, pciTrees, err := BuildPciTrees()
-	if err != nil {
-		return nil, fmt.Errorf("error building PCIe trees: %v", err)
-	}
-	if len(nodes) == 0 {
-		return nil, fmt.Errorf("find no pci nodes")
-	}
-	gpus, err := GetGPUList()
+func CheckGPUTopology(file string) (*common.Result, error) {
+	spec, err := config.LoadSpec(file)
 	if err != nil {
-		return nil, err
-	}
-	if len(gpus) == 0 {
-		return nil, fmt.Errorf("find no gpus")
+		// like the pcie_topo component, degrade to a SpecMissing warning
+		// instead of failing the whole check
+		logrus.WithField("component", consts.ComponentNamePcieTopo).Warnf("load GPUTopology spec failed: %v", err)
+		specMissing, _ := common.NewSpecMissingChecker(consts.ComponentNamePcieTopo, err).Check(context.Background(), nil)
+		return &common.Result{
+			Item:     consts.ComponentNamePcieTopo,
+			Status:   specMissing.Status,
+			Level:    specMissing.Level,
+			Checkers: []*common.CheckerResult{specMissing},
+		}, nil
 	}
-	// Find all GPUS by numa node
-	FillNvGPUsWithNumaNode(nodes, gpus)
 
-	ibs, err := GetIBList()
+	info, err := CollectTopology()
 	if err != nil {
 		return nil, err
 	}
-	devices := mergeDeviceMaps(ibs, gpus)
 	var checkRes []*common.CheckerResult
-	numaCheckRes := checkNuma(devices, spec.NumaConfig)
+	numaCheckRes := checkNuma(info.Devices, spec.NumaConfig)
 	checkRes = append(checkRes, numaCheckRes)
 
-	switchCheckRes := checkPciSwitches(pciTrees, nodes, devices, spec.PciSwitchesConfig)
+	switchCheckRes := checkPciSwitches(info.Switches, spec.PciSwitchesConfig)
 	checkRes = append(checkRes, switchCheckRes)
+	checkRes = append(checkRes, checkGPUHCALocality(info.Devices, info.Switches, spec))
+	checkRes = append(checkRes, checkHCAIRQAffinity(info.HCAIRQs))
 	status := consts.StatusNormal
+	level := consts.LevelInfo
 	for _, item := range checkRes {
 		if item.Status == consts.StatusAbnormal {
 			status = consts.StatusAbnormal
+			if consts.LevelPriority[item.Level] > consts.LevelPriority[level] {
+				level = item.Level
+			}
 		}
 	}
 	res := &common.Result{
-		Item:     "pcie_topo",
+		Item:     consts.ComponentNamePcieTopo,
 		Status:   status,
+		Level:    level,
 		Checkers: checkRes,
 	}
 	return res, err
@@ -209,8 +254,8 @@ func PrintInfo(result *common.Result, verbos bool) bool {
 	}
 	for _, result := range checkerResults {
 		if result.Status == consts.StatusAbnormal {
-			fmt.Printf("%s%s%s\n", consts.LevelColor(result.Level), result.ErrorName, consts.Reset)
-			fmt.Printf("%s\n", result.Detail)
+			printer.Printf("%s%s%s\n", consts.LevelColor(result.Level), result.ErrorName, consts.Reset)
+			printer.Printf("%s\n", result.Detail)
 		}
 	}
 	return false
@@ -0,0 +1,41 @@
+package topotest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/scitix/sichek/components/pcie/config"
+	"github.com/scitix/sichek/consts"
+)
+
+func TestCheckPciSwitches(t *testing.T) {
+	gpu := func(name, bdf string) *DeviceInfo { return &DeviceInfo{Type: "GPU", Name: name, BDF: bdf} }
+	ib := func(name, bdf string) *DeviceInfo { return &DeviceInfo{Type: "IB", Name: name, BDF: bdf} }
+	spec := []*config.PciSwitch{{GPU: 1, IB: 1, Count: 2}}
+
+	switches := map[string]*EndpointInfoByPCIeSW{
+		"0000:10:00.0": {SwitchBDF: "0000:10:00.0", DeviceList: []*DeviceInfo{gpu("0", "0000:18:00.0"), ib("mlx5_0", "0000:19:00.0")}},
+		"0000:20:00.0": {SwitchBDF: "0000:20:00.0", DeviceList: []*DeviceInfo{gpu("1", "0000:28:00.0"), ib("mlx5_1", "0000:29:00.0")}},
+	}
+	if res := checkPciSwitches(switches, spec); res.Status != consts.StatusNormal {
+		t.Fatalf("expected normal, got %+v", res)
+	}
+
+	// mlx5_1 is seated under the switch of GPU 0
+	switches = map[string]*EndpointInfoByPCIeSW{
+		"0000:10:00.0": {SwitchBDF: "0000:10:00.0", DeviceList: []*DeviceInfo{gpu("0", "0000:18:00.0"), ib("mlx5_0", "0000:19:00.0"), ib("mlx5_1", "0000:29:00.0")}},
+		"0000:20:00.0": {SwitchBDF: "0000:20:00.0", DeviceList: []*DeviceInfo{gpu("1", "0000:28:00.0")}},
+	}
+	res := checkPciSwitches(switches, spec)
+	if res.Status != consts.StatusAbnormal {
+		t.Fatalf("expected abnormal, got %+v", res)
+	}
+	if res.Device != "0000:18:00.0,0000:19:00.0,0000:28:00.0,0000:29:00.0" {
+		t.Errorf("unexpected devices %q", res.Device)
+	}
+	for _, want := range []string{"PCIe switch 0000:10:00.0 has unexpected pairing gpu_1&&ib_2", "PCIe switch 0000:20:00.0 has unexpected pairing gpu_1&&ib_0", "2 PCIe switches with pairing gpu_1&&ib_1 are missing"} {
+		if !strings.Contains(res.Detail, want) {
+			t.Errorf("detail %q does not contain %q", res.Detail, want)
+		}
+	}
+}
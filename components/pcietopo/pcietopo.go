@@ -0,0 +1,292 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pcietopo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/pcie/config"
+	"github.com/scitix/sichek/components/pcie/topotest"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/scitix/sichek/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// component runs the GPU and IB placement checks of topotest periodically,
+// so that a miscabled riser or a device seated in the wrong slot is caught
+// by the daemon instead of only by a manual `sichek topo`.
+type component struct {
+	ctx           context.Context
+	cancel        context.CancelFunc
+	componentName string
+	cfg           *config.PcieTopoUserConfig
+	cfgMutex      sync.Mutex
+	spec          *config.PcieTopoSpec
+	checkers      []common.Checker
+	specMtx       sync.RWMutex
+
+	cacheMtx    sync.RWMutex
+	cacheBuffer []*common.Result
+	cacheInfo   []common.Info
+	currIndex   int64
+	cacheSize   int64
+
+	service *common.CommonService
+}
+
+var (
+	pcieTopoComponent     *component
+	pcieTopoComponentOnce sync.Once
+)
+
+func NewComponent(cfgFile string, specFile string, ignoredCheckers []string) (common.Component, error) {
+	var err error
+	pcieTopoComponentOnce.Do(func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic occurred when create component pcie_topo: %v", r)
+			}
+		}()
+		pcieTopoComponent, err = newComponent(cfgFile, specFile, ignoredCheckers)
+	})
+	return pcieTopoComponent, err
+}
+
+func newComponent(cfgFile string, specFile string, ignoredCheckers []string) (comp *component, err error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		if err != nil {
+			cancel()
+		}
+	}()
+
+	cfg := &config.PcieTopoUserConfig{}
+	err = common.LoadUserConfig(cfgFile, cfg)
+	if err != nil || cfg.PcieTopo == nil {
+		logrus.WithField("component", "pcie_topo").Warnf("get user config failed or pcie_topo config is nil, using default config")
+		cfg.PcieTopo = &config.PcieTopoConfig{
+			QueryInterval: common.Duration{Duration: 10 * time.Minute},
+			CacheSize:     5,
+		}
+	}
+	if len(ignoredCheckers) > 0 {
+		cfg.PcieTopo.IgnoredCheckers = ignoredCheckers
+	}
+
+	spec, specErr := config.LoadSpec(specFile)
+	if specErr != nil {
+		logrus.WithField("component", "pcie_topo").Warnf("failed to load spec %s: %v", specFile, specErr)
+	}
+
+	cacheSize := cfg.PcieTopo.CacheSize
+	if cacheSize == 0 {
+		cacheSize = 5
+	}
+
+	comp = &component{
+		ctx:           ctx,
+		cancel:        cancel,
+		componentName: consts.ComponentNamePcieTopo,
+		cfg:           cfg,
+		spec:          spec,
+		cacheBuffer:   make([]*common.Result, cacheSize),
+		cacheInfo:     make([]common.Info, cacheSize),
+		cacheSize:     cacheSize,
+	}
+
+	comp.checkers, err = common.CheckersOrSpecMissing(consts.ComponentNamePcieTopo, spec, specErr, func(spec *config.PcieTopoSpec) ([]common.Checker, error) {
+		return topotest.NewCheckers(cfg, spec)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	service := common.NewCommonService(ctx, cfg, comp.componentName, comp.GetTimeout(), comp.HealthCheck)
+	comp.service = service
+
+	return comp, nil
+}
+
+func (c *component) Name() string {
+	return c.componentName
+}
+
+func (c *component) HealthCheck(ctx context.Context) (*common.Result, error) {
+	timer := common.NewTimer(fmt.Sprintf("%s-HealthCheck-Cost", c.componentName))
+	topoInfo, err := topotest.CollectTopology()
+	if err != nil {
+		logrus.WithField("component", "pcie_topo").Errorf("failed to collect pcie topology: %v", err)
+		return nil, err
+	}
+	timer.Mark("pcie_topo-collect")
+
+	c.specMtx.RLock()
+	checkers := c.checkers
+	c.specMtx.RUnlock()
+	result := common.Check(ctx, c.componentName, topoInfo, checkers)
+	timer.Mark("pcie_topo-check")
+
+	c.cacheMtx.Lock()
+	c.cacheBuffer[c.currIndex] = result
+	c.cacheInfo[c.currIndex] = topoInfo
+	c.currIndex = (c.currIndex + 1) % c.cacheSize
+	c.cacheMtx.Unlock()
+
+	if result.Status == consts.StatusAbnormal && consts.LevelPriority[result.Level] > consts.LevelPriority[consts.LevelInfo] {
+		logrus.WithField("component", "pcie_topo").Errorf("Health Check Failed")
+	} else {
+		logrus.WithField("component", "pcie_topo").Infof("Health Check PASSED")
+	}
+
+	return result, nil
+}
+
+func (c *component) CacheResults() ([]*common.Result, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	return c.cacheBuffer, nil
+}
+
+func (c *component) LastResult() (*common.Result, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	result := c.cacheBuffer[c.currIndex]
+	if c.currIndex == 0 {
+		result = c.cacheBuffer[c.cacheSize-1]
+	}
+	return result, nil
+}
+
+func (c *component) CacheInfos() ([]common.Info, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	return c.cacheInfo, nil
+}
+
+func (c *component) LastInfo() (common.Info, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	var info common.Info
+	if c.currIndex == 0 {
+		info = c.cacheInfo[c.cacheSize-1]
+	} else {
+		info = c.cacheInfo[c.currIndex-1]
+	}
+	return info, nil
+}
+
+func (c *component) Start() <-chan *common.Result {
+	return c.service.Start()
+}
+
+func (c *component) Stop() error {
+	return c.service.Stop()
+}
+
+func (c *component) Update(cfg common.ComponentUserConfig) error {
+	c.cfgMutex.Lock()
+	configPointer, ok := cfg.(*config.PcieTopoUserConfig)
+	if !ok {
+		c.cfgMutex.Unlock()
+		return fmt.Errorf("update wrong config type for pcie_topo")
+	}
+	c.cfg = configPointer
+	c.cfgMutex.Unlock()
+	return c.service.Update(cfg)
+}
+
+// UpdateSpec reloads the expected NUMA and PCIe switch layout and rebuilds the checkers from it.
+func (c *component) UpdateSpec(specFile string) error {
+	spec, err := config.LoadSpec(specFile)
+	if err != nil {
+		return fmt.Errorf("load pcie_topo spec %s: %w", specFile, err)
+	}
+	if spec == nil {
+		return fmt.Errorf("pcie_topo spec is nil after loading from %s", specFile)
+	}
+	c.cfgMutex.Lock()
+	cfg := c.cfg
+	c.cfgMutex.Unlock()
+	checkers, err := topotest.NewCheckers(cfg, spec)
+	if err != nil {
+		return err
+	}
+	c.specMtx.Lock()
+	c.spec = spec
+	c.checkers = checkers
+	c.specMtx.Unlock()
+	logrus.WithField("component", "pcie_topo").Infof("reloaded spec from %s", specFile)
+	return nil
+}
+
+func (c *component) Status() bool {
+	return c.service.Status()
+}
+
+func (c *component) GetTimeout() time.Duration {
+	return c.cfg.GetQueryInterval().Duration
+}
+
+func (c *component) PrintInfo(info common.Info, result *common.Result, summaryPrint bool) bool {
+	checkAllPassed := true
+	if result.Status == consts.StatusAbnormal && consts.LevelPriority[result.Level] > consts.LevelPriority[consts.LevelInfo] {
+		checkAllPassed = false
+	}
+
+	utils.PrintTitle("PCIe Topology", "-")
+
+	topoInfo, ok := info.(*topotest.TopoInfo)
+	if !ok || topoInfo == nil {
+		printer.Println("No PCIe topology info available")
+		return checkAllPassed
+	}
+
+	switchBDFs := make([]string, 0, len(topoInfo.Switches))
+	for bdf := range topoInfo.Switches {
+		switchBDFs = append(switchBDFs, bdf)
+	}
+	sort.Strings(switchBDFs)
+	printer.Printf("%-16s %s\n", "PCIe Switch", "Devices")
+	for _, bdf := range switchBDFs {
+		printer.Printf("%-16s%s\n", bdf, topoInfo.Switches[bdf].String())
+	}
+
+	hasErrors := false
+	if result != nil {
+		for _, res := range result.Checkers {
+			if res.Status != consts.StatusNormal && res.Level != consts.LevelInfo {
+				if !hasErrors {
+					printer.Printf("\nErrors Events:\n")
+					hasErrors = true
+				}
+				printer.Printf("\tEvent: %s%s%s -> %s\n", consts.LevelColor(res.Level), res.ErrorName, consts.Reset, res.Detail)
+			}
+		}
+	}
+	if !hasErrors {
+		printer.Printf("\nErrors Events:\n\tNo PCIe Topology Events Detected\n")
+	}
+
+	printer.Println()
+	return checkAllPassed
+}
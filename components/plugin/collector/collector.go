@@ -0,0 +1,208 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/plugin/config"
+	"github.com/scitix/sichek/consts"
+)
+
+// maxStderrLen caps the stderr of a plugin kept in the output.
+const maxStderrLen = 1024
+
+var errorNameRe = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// Output is the result of a run of a plugin. Err is set when the plugin could
+// not be run or its stdout is not a checker result set, Checkers is then empty.
+type Output struct {
+	Time     time.Time               `json:"time"`
+	Plugin   string                  `json:"plugin"`
+	Command  string                  `json:"command"`
+	ExitCode int                     `json:"exit_code"`
+	Duration string                  `json:"duration"`
+	Stderr   string                  `json:"stderr,omitempty"`
+	Err      string                  `json:"error,omitempty"`
+	Checkers []*common.CheckerResult `json:"checkers"`
+}
+
+func (o *Output) JSON() (string, error) {
+	data, err := json.Marshal(o)
+	return string(data), err
+}
+
+type PluginCollector struct {
+	name string
+	cfg  *config.PluginConfig
+}
+
+func NewCollector(cfg *config.PluginConfig) *PluginCollector {
+	return &PluginCollector{
+		name: "PluginCollector",
+		cfg:  cfg,
+	}
+}
+
+func (c *PluginCollector) Name() string {
+	return c.name
+}
+
+// Collect runs the plugin with its timeout. A plugin exiting non-zero is not
+// an error as long as it prints its results, it may exit 1 on abnormal
+// results like the nagios plugins do.
+func (c *PluginCollector) Collect(ctx context.Context) (common.Info, error) {
+	output := &Output{
+		Time:    time.Now(),
+		Plugin:  c.cfg.Name,
+		Command: strings.Join(append([]string{c.cfg.Command}, c.cfg.Args...), " "),
+	}
+	runCtx, cancel := context.WithTimeout(ctx, c.cfg.Timeout.Duration)
+	defer cancel()
+	cmd := exec.CommandContext(runCtx, c.cfg.Command, c.cfg.Args...)
+	// kill the whole process group on timeout, a child left behind keeps
+	// stdout open and the plugin hanging
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = time.Second
+	cmd.Env = append(os.Environ(), "SICHEK_PLUGIN_NAME="+c.cfg.Name)
+	for key, value := range c.cfg.Env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	output.Duration = time.Since(output.Time).Round(time.Millisecond).String()
+	output.Stderr = truncate(strings.TrimSpace(stderr.String()), maxStderrLen)
+	if cmd.ProcessState != nil {
+		output.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	if runCtx.Err() == context.DeadlineExceeded {
+		output.Err = fmt.Sprintf("plugin timed out after %s", c.cfg.Timeout.Duration)
+		return output, nil
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		output.Err = fmt.Sprintf("failed to run plugin: %v", err)
+		return output, nil
+	}
+	checkers, parseErr := ParseOutput(stdout.Bytes())
+	if parseErr != nil {
+		if err != nil {
+			output.Err = fmt.Sprintf("plugin exited with %d: %v", output.ExitCode, parseErr)
+		} else {
+			output.Err = parseErr.Error()
+		}
+		return output, nil
+	}
+	output.Checkers = checkers
+	return output, nil
+}
+
+// ParseOutput parses the stdout of a plugin, either {"checkers": [...]} or a
+// bare list of checker results, and fills in the defaults of the results.
+func ParseOutput(data []byte) ([]*common.CheckerResult, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("plugin printed no checker results")
+	}
+	var checkers []*common.CheckerResult
+	if data[0] == '[' {
+		if err := json.Unmarshal(data, &checkers); err != nil {
+			return nil, fmt.Errorf("invalid checker results: %w", err)
+		}
+	} else {
+		var set struct {
+			Checkers []*common.CheckerResult `json:"checkers"`
+		}
+		if err := json.Unmarshal(data, &set); err != nil {
+			return nil, fmt.Errorf("invalid checker results: %w", err)
+		}
+		checkers = set.Checkers
+	}
+	if len(checkers) == 0 {
+		return nil, fmt.Errorf("plugin printed no checker results")
+	}
+	seen := make(map[string]bool, len(checkers))
+	for idx, checker := range checkers {
+		if checker == nil || checker.Name == "" {
+			return nil, fmt.Errorf("checker result #%d has no name", idx)
+		}
+		if seen[checker.Name] {
+			return nil, fmt.Errorf("checker %q is reported twice", checker.Name)
+		}
+		seen[checker.Name] = true
+		if err := normalize(checker); err != nil {
+			return nil, err
+		}
+	}
+	return checkers, nil
+}
+
+// normalize defaults the status to normal and the level to info, or warning
+// for an abnormal result, and makes the error name usable in a metric name.
+func normalize(checker *common.CheckerResult) error {
+	switch checker.Status {
+	case "":
+		checker.Status = consts.StatusNormal
+	case consts.StatusNormal, consts.StatusAbnormal:
+	default:
+		return fmt.Errorf("checker %q has invalid status %q, expected %s or %s", checker.Name, checker.Status, consts.StatusNormal, consts.StatusAbnormal)
+	}
+	if checker.Level == "" {
+		checker.Level = consts.LevelInfo
+		if checker.Status == consts.StatusAbnormal {
+			checker.Level = consts.LevelWarning
+		}
+	} else if _, ok := consts.LevelPriority[checker.Level]; !ok {
+		return fmt.Errorf("checker %q has invalid level %q", checker.Name, checker.Level)
+	}
+	if checker.ErrorName == "" {
+		checker.ErrorName = checker.Name
+	}
+	checker.ErrorName = strings.Trim(errorNameRe.ReplaceAllString(checker.ErrorName, "_"), "_")
+	switch checker.Action {
+	case "", common.LifecycleActionNone, common.LifecycleActionResetGPU, common.LifecycleActionDrainReboot, common.LifecycleActionRMA:
+	default:
+		return fmt.Errorf("checker %q has invalid action %q", checker.Name, checker.Action)
+	}
+	// the silences are applied by sichek, and a remediation needs the
+	// Apply func a plugin cannot provide
+	checker.SilencedBy = ""
+	checker.Remediations = nil
+	return nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
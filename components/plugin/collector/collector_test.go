@@ -0,0 +1,152 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/plugin/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOutput(t *testing.T) {
+	checkers, err := ParseOutput([]byte(`{"checkers": [
+		{"name": "license-server", "status": "abnormal", "level": "critical", "curr": "unreachable", "error_name": "license server down"},
+		{"name": "fabric-route", "curr": "ok"}
+	]}`))
+	require.NoError(t, err)
+	require.Len(t, checkers, 2)
+	assert.Equal(t, consts.LevelCritical, checkers[0].Level)
+	assert.Equal(t, "license_server_down", checkers[0].ErrorName)
+	assert.Equal(t, consts.StatusNormal, checkers[1].Status)
+	assert.Equal(t, consts.LevelInfo, checkers[1].Level)
+	assert.Equal(t, "fabric_route", checkers[1].ErrorName)
+
+	checkers, err = ParseOutput([]byte(`[{"name": "quota", "status": "abnormal"}]`))
+	require.NoError(t, err)
+	require.Len(t, checkers, 1)
+	assert.Equal(t, consts.LevelWarning, checkers[0].Level)
+
+	for _, invalid := range []string{
+		``,
+		`not json`,
+		`{"checkers": []}`,
+		`[{"status": "normal"}]`,
+		`[{"name": "a"}, {"name": "a"}]`,
+		`[{"name": "a", "status": "broken"}]`,
+		`[{"name": "a", "level": "severe"}]`,
+		`[{"name": "a", "action": "reinstall"}]`,
+	} {
+		_, err := ParseOutput([]byte(invalid))
+		assert.Errorf(t, err, "output %q", invalid)
+	}
+}
+
+func TestParseOutputDropsRemediations(t *testing.T) {
+	checkers, err := ParseOutput([]byte(`[{"name": "a", "status": "abnormal", "silenced_by": "x",
+		"remediations": [{"name": "modprobe", "target": "x"}], "action": "drain-reboot"}]`))
+	require.NoError(t, err)
+	assert.Empty(t, checkers[0].SilencedBy)
+	assert.Nil(t, checkers[0].Remediations)
+	assert.Equal(t, common.LifecycleActionDrainReboot, checkers[0].Action)
+}
+
+func writePlugin(t *testing.T, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "plugin.sh")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755))
+	return path
+}
+
+func TestCollect(t *testing.T) {
+	cases := []struct {
+		name     string
+		script   string
+		timeout  time.Duration
+		checkers int
+		exitCode int
+		err      bool
+	}{
+		{
+			name:     "results",
+			script:   `echo '{"checkers": [{"name": "'$SICHEK_PLUGIN_NAME'", "curr": "'$SITE'"}]}'`,
+			checkers: 1,
+		},
+		{
+			name:     "non-zero exit with results",
+			script:   `echo '[{"name": "quota", "status": "abnormal"}]'; exit 1`,
+			checkers: 1,
+			exitCode: 1,
+		},
+		{
+			name:     "failure without results",
+			script:   `echo "no route to license server" >&2; exit 2`,
+			exitCode: 2,
+			err:      true,
+		},
+		{
+			name:    "timeout",
+			script:  `sleep 5`,
+			timeout: 100 * time.Millisecond,
+			err:     true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			timeout := tc.timeout
+			if timeout == 0 {
+				timeout = 5 * time.Second
+			}
+			c := NewCollector(&config.PluginConfig{
+				Name:    "site_check",
+				Command: writePlugin(t, tc.script),
+				Env:     map[string]string{"SITE": "dc1"},
+				Timeout: common.Duration{Duration: timeout},
+			})
+			info, err := c.Collect(context.Background())
+			require.NoError(t, err)
+			output := info.(*Output)
+			assert.Len(t, output.Checkers, tc.checkers)
+			assert.Equal(t, tc.err, output.Err != "", output.Err)
+			if tc.exitCode != 0 {
+				assert.Equal(t, tc.exitCode, output.ExitCode)
+			}
+			if tc.name == "results" {
+				assert.Equal(t, "site_check", output.Checkers[0].Name)
+				assert.Equal(t, "dc1", output.Checkers[0].Curr)
+			}
+			if tc.name == "failure without results" {
+				assert.Equal(t, "no route to license server", output.Stderr)
+			}
+		})
+	}
+
+	c := NewCollector(&config.PluginConfig{
+		Name:    "missing",
+		Command: filepath.Join(t.TempDir(), "missing"),
+		Timeout: common.Duration{Duration: time.Second},
+	})
+	info, err := c.Collect(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, info.(*Output).Err, "failed to run plugin")
+}
@@ -0,0 +1,35 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+)
+
+const PluginExecCheckerName = "plugin-exec"
+
+var PluginCheckItems = map[string]common.CheckerResult{
+	PluginExecCheckerName: {
+		Name:        PluginExecCheckerName,
+		Description: "Check if the plugin runs and prints its checker results as JSON",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelWarning,
+		Detail:      "The plugin printed its checker results",
+		ErrorName:   "PluginExecFailed",
+		Suggestion:  "Run the plugin command by hand, it must exit within its timeout and print {\"checkers\": [...]} on stdout",
+	},
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+)
+
+const (
+	DefaultQueryInterval = time.Minute
+	DefaultTimeout       = 30 * time.Second
+	DefaultCacheSize     = 5
+)
+
+// nameRe restricts the plugin names to what can be used as a component name,
+// a metric name prefix and a log file name.
+var nameRe = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+// builtinComponents are the names a plugin cannot take.
+var builtinComponents = append(slices.Clone(consts.DefaultComponents), consts.ComponentNameMemory, consts.ComponentNameHCA)
+
+// PluginUserConfig is the plugins list of the user config, each plugin is an
+// external binary run as a component of its own.
+type PluginUserConfig struct {
+	Plugins []*PluginConfig `json:"plugins" yaml:"plugins"`
+}
+
+type PluginConfig struct {
+	// Name is the component name of the plugin, it must not collide with a built-in component.
+	Name string `json:"name" yaml:"name"`
+	// Command is the plugin binary, it prints its checker results as JSON on stdout.
+	Command       string            `json:"command" yaml:"command"`
+	Args          []string          `json:"args,omitempty" yaml:"args,omitempty"`
+	Env           map[string]string `json:"env,omitempty" yaml:"env,omitempty"`
+	QueryInterval common.Duration   `json:"query_interval" yaml:"query_interval"`
+	// Timeout caps each run of the plugin. Default 30s.
+	Timeout   common.Duration `json:"timeout" yaml:"timeout"`
+	CacheSize int64           `json:"cache_size" yaml:"cache_size"`
+}
+
+// LoadConfig loads the plugins of the user config, the plugins missing a
+// setting get its default.
+func LoadConfig(cfgFile string) (*PluginUserConfig, error) {
+	cfg := &PluginUserConfig{}
+	if err := common.LoadUserConfig(cfgFile, cfg); err != nil {
+		return nil, err
+	}
+	for _, plugin := range cfg.Plugins {
+		if plugin == nil {
+			continue
+		}
+		if plugin.QueryInterval.Duration <= 0 {
+			plugin.QueryInterval.Duration = DefaultQueryInterval
+		}
+		if plugin.Timeout.Duration <= 0 {
+			plugin.Timeout.Duration = DefaultTimeout
+		}
+		if plugin.CacheSize <= 0 {
+			plugin.CacheSize = DefaultCacheSize
+		}
+	}
+	return cfg, nil
+}
+
+// Validate checks the plugins have a command and a unique name that is not
+// the one of a built-in component.
+func (c *PluginUserConfig) Validate() error {
+	seen := make(map[string]bool, len(c.Plugins))
+	for idx, plugin := range c.Plugins {
+		if plugin == nil {
+			return fmt.Errorf("plugin #%d is empty", idx)
+		}
+		if err := plugin.Validate(); err != nil {
+			return err
+		}
+		if seen[plugin.Name] {
+			return fmt.Errorf("plugin %q is configured twice", plugin.Name)
+		}
+		seen[plugin.Name] = true
+	}
+	return nil
+}
+
+func (p *PluginConfig) Validate() error {
+	if !nameRe.MatchString(p.Name) {
+		return fmt.Errorf("invalid plugin name %q, expected %s", p.Name, nameRe.String())
+	}
+	if slices.Contains(builtinComponents, p.Name) {
+		return fmt.Errorf("plugin %q collides with the built-in component of the same name", p.Name)
+	}
+	if p.Command == "" {
+		return fmt.Errorf("plugin %q has no command", p.Name)
+	}
+	return nil
+}
+
+// Get returns the config of the plugin named name.
+func (c *PluginUserConfig) Get(name string) (*PluginConfig, bool) {
+	for _, plugin := range c.Plugins {
+		if plugin != nil && plugin.Name == name {
+			return plugin, true
+		}
+	}
+	return nil, false
+}
+
+// Names returns the names of the valid plugins of the user config, it is
+// empty when the config has no plugins or fails to load.
+func Names(cfgFile string) []string {
+	cfg, err := LoadConfig(cfgFile)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, plugin := range cfg.Plugins {
+		if plugin != nil && plugin.Validate() == nil && !slices.Contains(names, plugin.Name) {
+			names = append(names, plugin.Name)
+		}
+	}
+	return names
+}
+
+func (p *PluginConfig) GetQueryInterval() common.Duration {
+	return p.QueryInterval
+}
+
+func (p *PluginConfig) SetQueryInterval(newInterval common.Duration) {
+	p.QueryInterval = newInterval
+}
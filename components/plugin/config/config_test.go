@@ -0,0 +1,62 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	cfgFile := filepath.Join(t.TempDir(), "user_config.yaml")
+	require.NoError(t, os.WriteFile(cfgFile, []byte(`
+cpu:
+  query_interval: 30s
+plugins:
+  - name: license_server
+    command: /opt/site/check-license
+    args: ["--server", "lic01"]
+    query_interval: 5m
+  - name: nvidia
+    command: /opt/site/check-gpu
+  - name: Bad-Name
+    command: /opt/site/check
+  - name: no_command
+`), 0o644))
+
+	cfg, err := LoadConfig(cfgFile)
+	require.NoError(t, err)
+	require.Len(t, cfg.Plugins, 4)
+	plugin, ok := cfg.Get("license_server")
+	require.True(t, ok)
+	assert.Equal(t, 5*time.Minute, plugin.QueryInterval.Duration)
+	assert.Equal(t, DefaultTimeout, plugin.Timeout.Duration)
+	assert.Equal(t, int64(DefaultCacheSize), plugin.CacheSize)
+	assert.NoError(t, plugin.Validate())
+	assert.Error(t, cfg.Validate())
+
+	for _, name := range []string{"nvidia", "Bad-Name", "no_command"} {
+		plugin, ok := cfg.Get(name)
+		require.True(t, ok)
+		assert.Errorf(t, plugin.Validate(), "plugin %s", name)
+	}
+	assert.Equal(t, []string{"license_server"}, Names(cfgFile))
+}
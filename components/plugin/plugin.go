@@ -0,0 +1,293 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/plugin/collector"
+	"github.com/scitix/sichek/components/plugin/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/scitix/sichek/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// component runs an external plugin binary, its checker results are handled
+// like the ones of a built-in component: printed in the summary, exported as
+// metrics and reported in the snapshot.
+type component struct {
+	ctx           context.Context
+	cancel        context.CancelFunc
+	componentName string
+	cfg           *config.PluginConfig
+	cfgMutex      sync.Mutex
+	collector     *collector.PluginCollector
+
+	cacheMtx    sync.RWMutex
+	cacheBuffer []*common.Result
+	cacheInfo   []common.Info
+	currIndex   int64
+	cacheSize   int64
+
+	service *common.CommonService
+}
+
+var (
+	pluginComponents   = make(map[string]*component)
+	pluginComponentsMu sync.Mutex
+)
+
+// IsPlugin reports whether name is a plugin of the user config.
+func IsPlugin(cfgFile string, name string) bool {
+	for _, plugin := range config.Names(cfgFile) {
+		if plugin == name {
+			return true
+		}
+	}
+	return false
+}
+
+// NewComponent constructs (or returns the previously-constructed) component
+// of the plugin named name in the user config. specFile is ignored.
+func NewComponent(name string, cfgFile string, specFile string) (common.Component, error) {
+	pluginComponentsMu.Lock()
+	defer pluginComponentsMu.Unlock()
+	if comp, ok := pluginComponents[name]; ok {
+		return comp, nil
+	}
+	comp, err := newComponent(name, cfgFile)
+	if err != nil {
+		return nil, err
+	}
+	pluginComponents[name] = comp
+	return comp, nil
+}
+
+func newComponent(name string, cfgFile string) (comp *component, err error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		if err != nil {
+			cancel()
+		}
+	}()
+
+	pluginCfg, err := config.LoadConfig(cfgFile)
+	if err != nil {
+		logrus.WithField("component", name).Errorf("NewComponent get config failed: %v", err)
+		return nil, fmt.Errorf("NewPluginComponent get user config failed: %w", err)
+	}
+	cfg, ok := pluginCfg.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("plugin %q is not configured", name)
+	}
+	if err = cfg.Validate(); err != nil {
+		return nil, err
+	}
+	component := &component{
+		ctx:           ctx,
+		cancel:        cancel,
+		componentName: name,
+		cfg:           cfg,
+		collector:     collector.NewCollector(cfg),
+		cacheBuffer:   make([]*common.Result, cfg.CacheSize),
+		cacheInfo:     make([]common.Info, cfg.CacheSize),
+		cacheSize:     cfg.CacheSize,
+	}
+	component.service = common.NewCommonService(ctx, cfg, component.componentName, component.GetTimeout(), component.HealthCheck)
+	return component, nil
+}
+
+func (c *component) Name() string {
+	return c.componentName
+}
+
+func (c *component) HealthCheck(ctx context.Context) (*common.Result, error) {
+	info, err := common.Collect(ctx, c.componentName, c.collector.Collect)
+	if err != nil {
+		logrus.WithField("component", c.componentName).Errorf("failed to run plugin: %v", err)
+		return nil, err
+	}
+	output, ok := info.(*collector.Output)
+	if !ok {
+		return nil, fmt.Errorf("wrong plugin collector info type")
+	}
+	result := Check(c.componentName, output)
+
+	c.cacheMtx.Lock()
+	c.cacheInfo[c.currIndex] = info
+	c.cacheBuffer[c.currIndex] = result
+	c.currIndex = (c.currIndex + 1) % c.cacheSize
+	c.cacheMtx.Unlock()
+	if result.Status == consts.StatusAbnormal {
+		var failedCheckers []string
+		for _, checker := range result.Checkers {
+			if checker.Status == consts.StatusAbnormal {
+				failedCheckers = append(failedCheckers, checker.Name)
+			}
+		}
+		logrus.WithFields(logrus.Fields{
+			"component":       c.componentName,
+			"failed_checkers": failedCheckers,
+		}).Errorf("Health Check Failed")
+	} else {
+		logrus.WithField("component", c.componentName).Infof("Health Check PASSED")
+	}
+	return result, nil
+}
+
+// Check turns the output of a plugin into the result of its component. A
+// plugin that fails to run or to print its results reports the plugin-exec
+// checker abnormal.
+func Check(componentName string, output *collector.Output) *common.Result {
+	result := &common.Result{
+		Item:   componentName,
+		Status: consts.StatusNormal,
+		Level:  consts.LevelInfo,
+		Time:   time.Now(),
+	}
+	checkers := output.Checkers
+	if output.Err != "" {
+		execResult := config.PluginCheckItems[config.PluginExecCheckerName]
+		execResult.Status = consts.StatusAbnormal
+		execResult.Curr = fmt.Sprintf("exit code %d", output.ExitCode)
+		execResult.Detail = fmt.Sprintf("%s: %s", output.Command, output.Err)
+		if output.Stderr != "" {
+			execResult.Detail += fmt.Sprintf(", stderr: %s", output.Stderr)
+		}
+		checkers = []*common.CheckerResult{&execResult}
+	}
+	for _, checker := range checkers {
+		result.Checkers = append(result.Checkers, checker)
+		if checker.Status != consts.StatusAbnormal {
+			continue
+		}
+		result.Status = consts.StatusAbnormal
+		if consts.LevelPriority[result.Level] < consts.LevelPriority[checker.Level] {
+			result.Level = checker.Level
+		}
+	}
+	return result
+}
+
+func (c *component) CacheResults() ([]*common.Result, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	return c.cacheBuffer, nil
+}
+
+func (c *component) LastResult() (*common.Result, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	var result *common.Result
+	if c.currIndex == 0 {
+		result = c.cacheBuffer[c.cacheSize-1]
+	} else {
+		result = c.cacheBuffer[c.currIndex-1]
+	}
+	return result, nil
+}
+
+func (c *component) CacheInfos() ([]common.Info, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	return c.cacheInfo, nil
+}
+
+func (c *component) LastInfo() (common.Info, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	var info common.Info
+	if c.currIndex == 0 {
+		info = c.cacheInfo[c.cacheSize-1]
+	} else {
+		info = c.cacheInfo[c.currIndex-1]
+	}
+	return info, nil
+}
+
+func (c *component) Metrics(ctx context.Context, since time.Time) (interface{}, error) {
+	return nil, nil
+}
+
+func (c *component) Start() <-chan *common.Result {
+	return c.service.Start()
+}
+
+func (c *component) Stop() error {
+	return c.service.Stop()
+}
+
+func (c *component) Update(cfg common.ComponentUserConfig) error {
+	c.cfgMutex.Lock()
+	configPointer, ok := cfg.(*config.PluginConfig)
+	if !ok {
+		c.cfgMutex.Unlock()
+		return fmt.Errorf("update wrong config type for plugin %s", c.componentName)
+	}
+	c.cfg = configPointer
+	c.collector = collector.NewCollector(configPointer)
+	c.cfgMutex.Unlock()
+	return c.service.Update(cfg)
+}
+
+func (c *component) Status() bool {
+	return c.service.Status()
+}
+
+func (c *component) GetTimeout() time.Duration {
+	return c.cfg.GetQueryInterval().Duration
+}
+
+func (c *component) PrintInfo(info common.Info, result *common.Result, summaryPrint bool) bool {
+	checkAllPassed := true
+	if result.Status == consts.StatusAbnormal && consts.LevelPriority[result.Level] > consts.LevelPriority[consts.LevelInfo] {
+		checkAllPassed = false
+	}
+
+	utils.PrintTitle("Plugin "+c.componentName, "-")
+	if output, ok := info.(*collector.Output); ok && output != nil {
+		printer.Printf("Command: %s (exit code %d, took %s)\n", output.Command, output.ExitCode, output.Duration)
+	}
+	for _, res := range result.Checkers {
+		status := consts.Green + "PASS" + consts.Reset
+		if res.Status == consts.StatusAbnormal {
+			status = consts.LevelColor(res.Level) + "FAIL" + consts.Reset
+		}
+		printer.Printf("%s %s: %s\n", status, res.Name, res.Curr)
+	}
+
+	hasErrors := false
+	for _, res := range result.Checkers {
+		if res.Status != consts.StatusNormal && res.Level != consts.LevelInfo {
+			if !hasErrors {
+				printer.Printf("\nErrors Events:\n")
+				hasErrors = true
+			}
+			printer.Printf("\tEvent: %s%s%s -> %s\n", consts.LevelColor(res.Level), res.ErrorName, consts.Reset, res.Detail)
+		}
+	}
+	if !hasErrors {
+		printer.Printf("\nErrors Events:\n\tNo %s Events Detected\n", c.componentName)
+	}
+	printer.Println()
+	return checkAllPassed
+}
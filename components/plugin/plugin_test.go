@@ -0,0 +1,52 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package plugin
+
+import (
+	"testing"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/plugin/collector"
+	"github.com/scitix/sichek/components/plugin/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	result := Check("site_check", &collector.Output{Checkers: []*common.CheckerResult{
+		{Name: "a", Status: consts.StatusNormal, Level: consts.LevelInfo},
+		{Name: "b", Status: consts.StatusAbnormal, Level: consts.LevelWarning},
+		{Name: "c", Status: consts.StatusAbnormal, Level: consts.LevelCritical},
+	}})
+	assert.Equal(t, "site_check", result.Item)
+	assert.Equal(t, consts.StatusAbnormal, result.Status)
+	assert.Equal(t, consts.LevelCritical, result.Level)
+	assert.Len(t, result.Checkers, 3)
+
+	result = Check("site_check", &collector.Output{Checkers: []*common.CheckerResult{
+		{Name: "a", Status: consts.StatusNormal, Level: consts.LevelInfo},
+	}})
+	assert.Equal(t, consts.StatusNormal, result.Status)
+	assert.Equal(t, consts.LevelInfo, result.Level)
+
+	result = Check("site_check", &collector.Output{Command: "/bin/false", ExitCode: 1, Err: "plugin printed no checker results", Stderr: "boom"})
+	require.Len(t, result.Checkers, 1)
+	assert.Equal(t, config.PluginExecCheckerName, result.Checkers[0].Name)
+	assert.Equal(t, consts.StatusAbnormal, result.Status)
+	assert.Equal(t, consts.LevelWarning, result.Level)
+	assert.Contains(t, result.Checkers[0].Detail, "boom")
+}
@@ -28,6 +28,9 @@ type PodLogConfig struct {
 	CacheSize        int64           `json:"cache_size" yaml:"cache_size"`
 	IgnoreNamespaces []string        `json:"ignore_namespaces" yaml:"ignore_namespaces"`
 	SkipPercent      int64           `json:"skip_percent" yaml:"skip_percent"`
+	EnableMetrics    bool            `json:"enable_metrics" yaml:"enable_metrics"`
+	// RulesDir holds user rule files added to the built-in rules, reloaded on change
+	RulesDir string `json:"rules_dir,omitempty" yaml:"rules_dir,omitempty"`
 }
 
 func (c *PodlogUserConfig) GetQueryInterval() common.Duration {
@@ -0,0 +1,167 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultUserRulesDir holds the rule files added by SREs on top of the built-in rules.
+var DefaultUserRulesDir = filepath.Join(consts.DefaultProductionCfgPath, consts.ComponentNamePodlog, "rules.d")
+
+// UserRuleFile is a user rule file, e.g.
+//
+//	rules:
+//	  - name: NCCLNetIBCompletionError
+//	    regexp: 'NET/IB: Got completion from peer (?P<peer>\S+) with status=(?P<status>\d+)'
+//	    classification: network
+//	    severity: critical
+//	    suggestion: check the IB link to the peer
+//
+// Named groups of the regexp are extracted into the detail of the result.
+type UserRuleFile struct {
+	Rules []UserRule `json:"rules" yaml:"rules"`
+}
+
+type UserRule struct {
+	Name        string `json:"name" yaml:"name"`
+	Regexp      string `json:"regexp" yaml:"regexp"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Classification groups the rule by the faulty layer, e.g. network, gpu, storage or application
+	Classification string `json:"classification,omitempty" yaml:"classification,omitempty"`
+	// Severity is one of info, warning, critical or fatal, warning by default
+	Severity   string `json:"severity,omitempty" yaml:"severity,omitempty"`
+	Suggestion string `json:"suggestion,omitempty" yaml:"suggestion,omitempty"`
+}
+
+// Validate checks the rule can be turned into an event checker.
+func (r *UserRule) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("rule name is empty")
+	}
+	if _, err := regexp.Compile(r.Regexp); err != nil || r.Regexp == "" {
+		return fmt.Errorf("rule %s: invalid regexp %q: %v", r.Name, r.Regexp, err)
+	}
+	if r.Severity != "" {
+		if _, ok := consts.LevelPriority[r.Severity]; !ok {
+			return fmt.Errorf("rule %s: invalid severity %q", r.Name, r.Severity)
+		}
+	}
+	return nil
+}
+
+// EventRule converts the rule to the event checker run on the pod logs.
+func (r *UserRule) EventRule() *common.EventRuleConfig {
+	level := r.Severity
+	if level == "" {
+		level = consts.LevelWarning
+	}
+	description := r.Description
+	if r.Classification != "" {
+		description = fmt.Sprintf("[%s] %s", r.Classification, description)
+	}
+	return &common.EventRuleConfig{
+		Name:        r.Name,
+		Regexp:      r.Regexp,
+		Description: strings.TrimSpace(description),
+		Level:       level,
+		Suggestion:  r.Suggestion,
+	}
+}
+
+// UserRulesLoader loads the *.yaml rule files of a directory and reloads
+// them when a file is added, removed or modified.
+type UserRulesLoader struct {
+	dir         string
+	fingerprint string
+	rules       map[string]*common.EventRuleConfig
+}
+
+func NewUserRulesLoader(dir string) *UserRulesLoader {
+	return &UserRulesLoader{dir: dir}
+}
+
+// Load returns the user rules and whether they changed since the previous
+// call. Invalid rules are skipped and logged, so a typo in one file does
+// not disable the other rules.
+func (l *UserRulesLoader) Load() (map[string]*common.EventRuleConfig, bool) {
+	files, fingerprint := l.scan()
+	if l.rules != nil && fingerprint == l.fingerprint {
+		return l.rules, false
+	}
+	rules := make(map[string]*common.EventRuleConfig)
+	for _, file := range files {
+		var ruleFile UserRuleFile
+		if err := utils.LoadFromYaml(file, &ruleFile); err != nil {
+			logrus.WithField("component", "podlog").Errorf("failed to load user rules from %s: %v", file, err)
+			continue
+		}
+		for i := range ruleFile.Rules {
+			rule := &ruleFile.Rules[i]
+			if err := rule.Validate(); err != nil {
+				logrus.WithField("component", "podlog").Errorf("skip user rule in %s: %v", file, err)
+				continue
+			}
+			rules[rule.Name] = rule.EventRule()
+		}
+	}
+	l.fingerprint, l.rules = fingerprint, rules
+	logrus.WithField("component", "podlog").Infof("loaded %d user rules from %d files in %s", len(rules), len(files), l.dir)
+	return rules, true
+}
+
+// scan lists the rule files and fingerprints their name, size and mtime.
+func (l *UserRulesLoader) scan() ([]string, string) {
+	var files []string
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		matches, _ := filepath.Glob(filepath.Join(l.dir, pattern))
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+	var fingerprint strings.Builder
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(&fingerprint, "%s:%d:%d;", file, info.Size(), info.ModTime().UnixNano())
+	}
+	return files, fingerprint.String()
+}
+
+// MergeEventRules returns the built-in rules overridden and extended by the user rules.
+func MergeEventRules(builtin, user map[string]*common.EventRuleConfig) common.EventRuleGroup {
+	merged := make(common.EventRuleGroup, len(builtin)+len(user))
+	for name, rule := range builtin {
+		ruleCopy := *rule
+		merged[name] = &ruleCopy
+	}
+	for name, rule := range user {
+		ruleCopy := *rule
+		merged[name] = &ruleCopy
+	}
+	return merged
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+)
+
+func TestUserRulesLoader(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "nccl.yaml")
+	content := `rules:
+  - name: NCCLNetIBCompletionError
+    regexp: 'NET/IB: Got completion from peer (?P<peer>\S+) with status=(?P<status>\d+)'
+    classification: network
+    severity: critical
+  - name: BrokenRule
+    regexp: '(unclosed'
+`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	loader := NewUserRulesLoader(dir)
+	rules, changed := loader.Load()
+	if !changed || len(rules) != 1 {
+		t.Fatalf("expected only the valid rule loaded, got %d rules changed=%v", len(rules), changed)
+	}
+	rule := rules["NCCLNetIBCompletionError"]
+	if rule.Level != consts.LevelCritical || rule.Description != "[network]" {
+		t.Errorf("unexpected rule: %+v", rule)
+	}
+	if _, changed = loader.Load(); changed {
+		t.Errorf("unchanged files should not reload the rules")
+	}
+
+	content += `  - name: CUDALaunchFailure
+    regexp: 'unspecified launch failure'
+`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	future := time.Now().Add(time.Minute)
+	_ = os.Chtimes(file, future, future)
+	rules, changed = loader.Load()
+	if !changed || len(rules) != 2 || rules["CUDALaunchFailure"].Level != consts.LevelWarning {
+		t.Errorf("expected the added rule reloaded with the default severity, got %d rules changed=%v", len(rules), changed)
+	}
+}
+
+func TestMergeEventRules(t *testing.T) {
+	builtin := common.EventRuleGroup{
+		"ECCError":       {Name: "ECCError", Regexp: "ECC", Level: "error"},
+		"NCCLNetIBError": {Name: "NCCLNetIBError", Regexp: "NET/IB", Level: "error"},
+	}
+	user := map[string]*common.EventRuleConfig{
+		"NCCLNetIBError": {Name: "NCCLNetIBError", Regexp: "NET/IB: Got completion", Level: consts.LevelCritical},
+	}
+	merged := MergeEventRules(builtin, user)
+	if len(merged) != 2 || merged["NCCLNetIBError"].Level != consts.LevelCritical {
+		t.Errorf("expected the user rule to override the built-in one, got %+v", merged["NCCLNetIBError"])
+	}
+	merged["ECCError"].LogFile = "/var/log/pods/x.log"
+	if builtin["ECCError"].LogFile != "" {
+		t.Errorf("merging should not share the built-in rules")
+	}
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package metrics
+
+import (
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	metrics "github.com/scitix/sichek/metrics"
+)
+
+const (
+	MetricPrefix = "sichek_podlog"
+)
+
+type PodlogMetrics struct {
+	AnomalyGauge *metrics.GaugeVecMetricExporter
+}
+
+func NewPodlogMetrics() *PodlogMetrics {
+	return &PodlogMetrics{
+		AnomalyGauge: metrics.NewGaugeVecMetricExporter(MetricPrefix, []string{"event"}),
+	}
+}
+
+// ExportMetrics exports sichek_podlog_anomaly_count, the pod log lines matched
+// by each event rule in the last check, and sichek_podlog_anomaly_pods, the
+// number of pods they were found in.
+func (m *PodlogMetrics) ExportMetrics(result *common.Result) {
+	if result == nil {
+		return
+	}
+	m.AnomalyGauge.ExportEventCounts("anomaly_count", result)
+	for _, checker := range result.Checkers {
+		pods := 0
+		if checker.Status == consts.StatusAbnormal && checker.Device != "" {
+			pods = len(strings.Split(checker.Device, ","))
+		}
+		m.AnomalyGauge.SetMetric("anomaly_pods", []string{checker.Name}, float64(pods))
+	}
+}
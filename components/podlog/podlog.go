@@ -27,8 +27,10 @@ import (
 	"github.com/scitix/sichek/components/common"
 	filter "github.com/scitix/sichek/components/common/eventfilter"
 	"github.com/scitix/sichek/components/podlog/config"
+	podlogmetrics "github.com/scitix/sichek/components/podlog/metrics"
 	"github.com/scitix/sichek/consts"
 	"github.com/scitix/sichek/pkg/k8s"
+	"github.com/scitix/sichek/pkg/printer"
 	"github.com/scitix/sichek/pkg/utils"
 
 	"github.com/sirupsen/logrus"
@@ -42,7 +44,13 @@ type component struct {
 	eventRule     *config.PodlogEventRule
 	cfgMutex      sync.Mutex
 
+	// userRules hot-reloads the user rule files, eventCheckers are the
+	// built-in rules merged with them
+	userRules     *config.UserRulesLoader
+	eventCheckers common.EventRuleGroup
+
 	podResourceMapper *k8s.PodResourceMapper
+	metrics           *podlogmetrics.PodlogMetrics
 	onlyRunningPods   bool  // true: only check running pods; false: check all pods in log_dir
 	skipPercent       int64 // skip percent for file reading
 
@@ -118,8 +126,14 @@ func newComponent(cfgFile string, specFile string, onlyRunningPods bool, skipPer
 		}
 	}
 
+	rulesDir := cfg.Podlog.RulesDir
+	if rulesDir == "" {
+		rulesDir = config.DefaultUserRulesDir
+	}
+
 	podResourceMapper := k8s.NewPodResourceMapper()
 	component.eventRule = eventRules
+	component.userRules = config.NewUserRulesLoader(rulesDir)
 	component.podResourceMapper = podResourceMapper
 	component.onlyRunningPods = onlyRunningPods
 	component.skipPercent = skipPercent
@@ -127,6 +141,9 @@ func newComponent(cfgFile string, specFile string, onlyRunningPods bool, skipPer
 	component.cacheInfoBuffer = make([]common.Info, cfg.Podlog.CacheSize)
 	component.currIndex = 0
 	component.cacheSize = cfg.Podlog.CacheSize
+	if cfg.Podlog.EnableMetrics {
+		component.metrics = podlogmetrics.NewPodlogMetrics()
+	}
 
 	component.service = common.NewCommonService(ctx, cfg, component.componentName, component.GetTimeout(), component.HealthCheck)
 	return component, nil
@@ -182,12 +199,13 @@ func (c *component) HealthCheck(ctx context.Context) (*common.Result, error) {
 		}, nil
 	}
 	joinedLogFiles := strings.Join(allFiles, ",")
-	for _, eventChecker := range c.eventRule.EventCheckers {
+	eventCheckers := c.loadEventCheckers()
+	for _, eventChecker := range eventCheckers {
 		if eventChecker != nil {
 			eventChecker.LogFile = joinedLogFiles
 		}
 	}
-	filterPointer, err := filter.NewEventFilter(consts.ComponentNamePodlog, c.eventRule.EventCheckers, c.skipPercent)
+	filterPointer, err := filter.NewEventFilter(consts.ComponentNamePodlog, eventCheckers, c.skipPercent)
 	if err != nil {
 		logrus.WithError(err).Error("failed to create filter in podlog component")
 		return nil, err
@@ -216,6 +234,9 @@ func (c *component) HealthCheck(ctx context.Context) (*common.Result, error) {
 			checkerResult.Device = strings.Join(podNameList, ",")
 		}
 	}
+	if c.metrics != nil {
+		c.metrics.ExportMetrics(result)
+	}
 	c.cacheMtx.Lock()
 	c.cacheResultBuffer[c.currIndex%c.cacheSize] = result
 	// c.cacheInfoBuffer[c.currIndex%c.cacheSize] = nil
@@ -239,6 +260,16 @@ func (c *component) HealthCheck(ctx context.Context) (*common.Result, error) {
 	return result, nil
 }
 
+// loadEventCheckers returns the built-in rules merged with the user rules,
+// which are reloaded if a rule file changed since the previous check.
+func (c *component) loadEventCheckers() common.EventRuleGroup {
+	userRules, changed := c.userRules.Load()
+	if changed || c.eventCheckers == nil {
+		c.eventCheckers = config.MergeEventRules(c.eventRule.EventCheckers, userRules)
+	}
+	return c.eventCheckers
+}
+
 // walkPodLogFiles walks through the directory and collects pod log file paths.
 // filterFunc is called for each valid log file with (absPath, podName, podNameErr).
 // If filterFunc returns true, the file is included in the result.
@@ -385,12 +416,12 @@ func (c *component) PrintInfo(info common.Info, result *common.Result, summaryPr
 		for _, checkerResult := range checkerResults {
 			if checkerResult.Status == consts.StatusAbnormal {
 				checkAllPassed = false
-				fmt.Printf("\t%sDetected %s error for %s times in %s%s\n", consts.LevelColor(checkerResult.Level), checkerResult.ErrorName, checkerResult.Curr, checkerResult.Device, consts.Reset)
+				printer.Printf("\t%sDetected %s error for %s times in %s%s\n", consts.LevelColor(checkerResult.Level), checkerResult.ErrorName, checkerResult.Curr, checkerResult.Device, consts.Reset)
 			}
 		}
 	}
 	if checkAllPassed {
-		fmt.Printf("%sNo PodLog Error detected%s\n", consts.Green, consts.Reset)
+		printer.Printf("%sNo PodLog Error detected%s\n", consts.Green, consts.Reset)
 	}
 	return checkAllPassed
 }
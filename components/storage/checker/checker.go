@@ -0,0 +1,63 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/storage/config"
+	"github.com/scitix/sichek/pkg/capability"
+)
+
+// NewCheckers creates all storage checkers, filtering out any in the ignored list.
+func NewCheckers(cfg *config.StorageUserConfig, spec *config.StorageSpec) ([]common.Checker, error) {
+	checkerConstructors := map[string]func(*config.StorageSpec) (common.Checker, error){
+		config.NvmeHealthCheckerName:      NewNvmeHealthChecker,
+		config.NvmeWearCheckerName:        NewNvmeWearChecker,
+		config.NvmeTemperatureCheckerName: NewNvmeTemperatureChecker,
+		config.FSUsageCheckerName:         NewFSUsageChecker,
+		config.FSReadOnlyCheckerName:      NewFSReadOnlyChecker,
+	}
+
+	// the NVMe SMART log is read with an admin command, which needs CAP_SYS_ADMIN
+	privilegedCheckers := map[string][]capability.Capability{
+		config.NvmeHealthCheckerName:      {capability.SysAdmin},
+		config.NvmeWearCheckerName:        {capability.SysAdmin},
+		config.NvmeTemperatureCheckerName: {capability.SysAdmin},
+	}
+
+	ignoredSet := make(map[string]struct{})
+	if cfg != nil && cfg.Storage != nil {
+		for _, v := range cfg.Storage.IgnoredCheckers {
+			ignoredSet[v] = struct{}{}
+		}
+	}
+
+	checkers := make([]common.Checker, 0, len(checkerConstructors))
+	for checkerName, constructor := range checkerConstructors {
+		if _, found := ignoredSet[checkerName]; found {
+			continue
+		}
+		checker, err := constructor(spec)
+		if err != nil {
+			return nil, err
+		}
+		if caps, ok := privilegedCheckers[checkerName]; ok {
+			checker = common.RequireCapabilities(checker, caps...)
+		}
+		checkers = append(checkers, checker)
+	}
+	return checkers, nil
+}
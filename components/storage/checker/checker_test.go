@@ -0,0 +1,105 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/storage/collector"
+	"github.com/scitix/sichek/components/storage/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCheckers(t *testing.T) map[string]common.Checker {
+	t.Helper()
+	spec := &config.StorageSpec{
+		Nvme: config.NvmeSpec{MaxMediaErrors: 0, MaxPercentageUsed: 90, MaxTemperatureCelsius: 70},
+		Filesystem: config.FilesystemSpec{
+			MaxUsedPercent:     90,
+			IgnoredMountPoints: []string{"/boot"},
+		},
+	}
+	checkers, err := NewCheckers(&config.StorageUserConfig{Storage: &config.StorageConfig{}}, spec)
+	require.NoError(t, err)
+	byName := make(map[string]common.Checker)
+	for _, c := range checkers {
+		byName[c.Name()] = c
+	}
+	require.Len(t, byName, 5)
+	return byName
+}
+
+func TestNvmeCheckers(t *testing.T) {
+	checkers := newCheckers(t)
+	ctx := context.Background()
+	info := &collector.StorageInfo{NvmeDevices: []*collector.NvmeDevice{
+		{Name: "nvme0", Model: "SAMSUNG MZQL27T6HBLA", SMART: collector.SmartLog{TemperatureCelsius: 40, PercentageUsed: 3}},
+		{Name: "nvme1", Model: "SAMSUNG MZQL27T6HBLA", SMART: collector.SmartLog{CriticalWarning: 0x4, MediaErrors: 12, TemperatureCelsius: 75, PercentageUsed: 95}},
+	}}
+
+	res, err := checkers[config.NvmeHealthCheckerName].Check(ctx, info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusAbnormal, res.Status)
+	assert.Equal(t, consts.LevelCritical, res.Level)
+	assert.Equal(t, "nvme1", res.Device)
+	assert.Contains(t, res.Detail, "critical warning 0x4 (reliability degraded)")
+	assert.Contains(t, res.Detail, "12 media errors")
+
+	res, err = checkers[config.NvmeWearCheckerName].Check(ctx, info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusAbnormal, res.Status)
+	assert.Equal(t, consts.LevelWarning, res.Level)
+	assert.Equal(t, "nvme1", res.Device)
+
+	res, err = checkers[config.NvmeTemperatureCheckerName].Check(ctx, info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusAbnormal, res.Status)
+	assert.Equal(t, "nvme1", res.Device)
+
+	info.NvmeDevices = info.NvmeDevices[:1]
+	for _, name := range []string{config.NvmeHealthCheckerName, config.NvmeWearCheckerName, config.NvmeTemperatureCheckerName} {
+		res, err = checkers[name].Check(ctx, info)
+		require.NoError(t, err)
+		assert.Equal(t, consts.StatusNormal, res.Status, name)
+	}
+}
+
+func TestFilesystemCheckers(t *testing.T) {
+	checkers := newCheckers(t)
+	ctx := context.Background()
+	info := &collector.StorageInfo{Filesystems: []*collector.Filesystem{
+		{Device: "/dev/nvme0n1p2", MountPoint: "/", FSType: "ext4", UsedPercent: 42},
+		{Device: "/dev/md0", MountPoint: "/data", FSType: "xfs", UsedPercent: 97.5},
+		{Device: "/dev/nvme1n1", MountPoint: "/scratch", FSType: "ext4", ReadOnly: true},
+		{Device: "/dev/sda1", MountPoint: "/boot", FSType: "ext4", UsedPercent: 99, ReadOnly: true},
+	}}
+
+	res, err := checkers[config.FSUsageCheckerName].Check(ctx, info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusAbnormal, res.Status)
+	assert.Equal(t, "/data", res.Device)
+	assert.Contains(t, res.Detail, "97.5% used")
+
+	res, err = checkers[config.FSReadOnlyCheckerName].Check(ctx, info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusAbnormal, res.Status)
+	assert.Equal(t, consts.LevelCritical, res.Level)
+	assert.Equal(t, "/scratch", res.Device)
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/storage/collector"
+	"github.com/scitix/sichek/components/storage/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+// FilesystemChecker flags the local filesystems that are almost full or
+// read-only, the ignored mount points of the spec are skipped.
+type FilesystemChecker struct {
+	name    string
+	ignored map[string]bool
+	// abnormal reports why the filesystem is abnormal, "" if it is not.
+	abnormal func(*collector.Filesystem) string
+}
+
+func newFilesystemChecker(name string, spec *config.StorageSpec, abnormal func(*collector.Filesystem) string) *FilesystemChecker {
+	ignored := make(map[string]bool)
+	for _, mountPoint := range spec.Filesystem.IgnoredMountPoints {
+		ignored[mountPoint] = true
+	}
+	return &FilesystemChecker{name: name, ignored: ignored, abnormal: abnormal}
+}
+
+func NewFSUsageChecker(spec *config.StorageSpec) (common.Checker, error) {
+	maxUsedPercent := spec.Filesystem.MaxUsedPercent
+	return newFilesystemChecker(config.FSUsageCheckerName, spec, func(fs *collector.Filesystem) string {
+		if maxUsedPercent > 0 && fs.UsedPercent > maxUsedPercent {
+			return fmt.Sprintf("%.1f%% used, threshold is %.0f%%", fs.UsedPercent, maxUsedPercent)
+		}
+		return ""
+	}), nil
+}
+
+func NewFSReadOnlyChecker(spec *config.StorageSpec) (common.Checker, error) {
+	return newFilesystemChecker(config.FSReadOnlyCheckerName, spec, func(fs *collector.Filesystem) string {
+		if fs.ReadOnly {
+			return "mounted read-only"
+		}
+		return ""
+	}), nil
+}
+
+func (c *FilesystemChecker) Name() string {
+	return c.name
+}
+
+func (c *FilesystemChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	info, ok := data.(*collector.StorageInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid StorageInfo type")
+	}
+
+	result := config.StorageCheckItems[c.name]
+	result.Status = consts.StatusNormal
+
+	var abnormalMounts []string
+	var detail string
+	for _, fs := range info.Filesystems {
+		if c.ignored[fs.MountPoint] {
+			continue
+		}
+		reason := c.abnormal(fs)
+		if reason == "" {
+			continue
+		}
+		abnormalMounts = append(abnormalMounts, fs.MountPoint)
+		detail += fmt.Sprintf("%s (%s %s): %s\n", fs.MountPoint, fs.Device, fs.FSType, reason)
+	}
+
+	if len(abnormalMounts) > 0 {
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(abnormalMounts, ",")
+		result.Curr = fmt.Sprintf("%d filesystems abnormal", len(abnormalMounts))
+		result.Detail = detail
+		logrus.WithField("component", "storage").Errorf("%s failed: %s", c.name, detail)
+	} else {
+		result.Curr = "OK"
+	}
+	return &result, nil
+}
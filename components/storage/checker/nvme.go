@@ -0,0 +1,133 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package checker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/storage/collector"
+	"github.com/scitix/sichek/components/storage/config"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+)
+
+// NvmeChecker flags the NVMe disks whose SMART log breaks one limit of the spec.
+type NvmeChecker struct {
+	name string
+	// abnormal reports why the SMART log breaks the limit, "" if it does not.
+	abnormal func(*collector.SmartLog) string
+}
+
+func NewNvmeHealthChecker(spec *config.StorageSpec) (common.Checker, error) {
+	maxMediaErrors := spec.Nvme.MaxMediaErrors
+	return &NvmeChecker{
+		name: config.NvmeHealthCheckerName,
+		abnormal: func(s *collector.SmartLog) string {
+			var reasons []string
+			if s.CriticalWarning != 0 {
+				reasons = append(reasons, fmt.Sprintf("critical warning 0x%x (%s)", s.CriticalWarning, criticalWarnings(s.CriticalWarning)))
+			}
+			if s.MediaErrors > maxMediaErrors {
+				reasons = append(reasons, fmt.Sprintf("%d media errors, threshold is %d", s.MediaErrors, maxMediaErrors))
+			}
+			return strings.Join(reasons, ", ")
+		},
+	}, nil
+}
+
+func NewNvmeWearChecker(spec *config.StorageSpec) (common.Checker, error) {
+	maxPercentageUsed := spec.Nvme.MaxPercentageUsed
+	return &NvmeChecker{
+		name: config.NvmeWearCheckerName,
+		abnormal: func(s *collector.SmartLog) string {
+			if maxPercentageUsed > 0 && s.PercentageUsed >= maxPercentageUsed {
+				return fmt.Sprintf("%d%% of the endurance used, threshold is %d%%", s.PercentageUsed, maxPercentageUsed)
+			}
+			return ""
+		},
+	}, nil
+}
+
+func NewNvmeTemperatureChecker(spec *config.StorageSpec) (common.Checker, error) {
+	maxCelsius := spec.Nvme.MaxTemperatureCelsius
+	return &NvmeChecker{
+		name: config.NvmeTemperatureCheckerName,
+		abnormal: func(s *collector.SmartLog) string {
+			if maxCelsius > 0 && s.TemperatureCelsius > maxCelsius {
+				return fmt.Sprintf("%.0f C, threshold is %.0f C", s.TemperatureCelsius, maxCelsius)
+			}
+			return ""
+		},
+	}, nil
+}
+
+func (c *NvmeChecker) Name() string {
+	return c.name
+}
+
+func (c *NvmeChecker) Check(ctx context.Context, data any) (*common.CheckerResult, error) {
+	info, ok := data.(*collector.StorageInfo)
+	if !ok {
+		return nil, fmt.Errorf("invalid StorageInfo type")
+	}
+
+	result := config.StorageCheckItems[c.name]
+	result.Status = consts.StatusNormal
+
+	var abnormalDevices []string
+	var detail string
+	for _, device := range info.NvmeDevices {
+		reason := c.abnormal(&device.SMART)
+		if reason == "" {
+			continue
+		}
+		abnormalDevices = append(abnormalDevices, device.Name)
+		detail += fmt.Sprintf("%s (%s %s): %s\n", device.Name, device.Model, device.Serial, reason)
+	}
+
+	if len(abnormalDevices) > 0 {
+		result.Status = consts.StatusAbnormal
+		result.Device = strings.Join(abnormalDevices, ",")
+		result.Curr = fmt.Sprintf("%d disks abnormal", len(abnormalDevices))
+		result.Detail = detail
+		logrus.WithField("component", "storage").Errorf("%s failed: %s", c.name, detail)
+	} else {
+		result.Curr = "OK"
+	}
+	return &result, nil
+}
+
+// criticalWarnings names the bits of the Critical Warning field of the SMART log.
+func criticalWarnings(warning uint64) string {
+	names := []string{
+		"available spare below threshold",
+		"temperature threshold exceeded",
+		"reliability degraded",
+		"read-only",
+		"volatile memory backup failed",
+		"persistent memory read-only",
+	}
+	var set []string
+	for bit, name := range names {
+		if warning&(1<<bit) != 0 {
+			set = append(set, name)
+		}
+	}
+	return strings.Join(set, ", ")
+}
@@ -0,0 +1,323 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/scitix/sichek/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// NvmeSysPath lists the NVMe controllers, e.g. /sys/class/nvme/nvme0.
+	NvmeSysPath = "/sys/class/nvme"
+	// MountsPath and HostRoot point to the mount namespace of the host, so
+	// that the daemon pod sees the same filesystems as sichek on the host.
+	MountsPath = "/proc/1/mounts"
+	HostRoot   = "/proc/1/root"
+)
+
+// localFSTypes are the filesystems of local disks, network and virtual
+// filesystems are left to their own components (e.g. gpfs).
+var localFSTypes = map[string]bool{
+	"ext2":  true,
+	"ext3":  true,
+	"ext4":  true,
+	"xfs":   true,
+	"btrfs": true,
+	"f2fs":  true,
+}
+
+const (
+	SourceNvmeCli  = "nvme-cli"
+	SourceSmartctl = "smartctl"
+)
+
+// SmartLog is the SMART / Health Information log page of an NVMe controller.
+type SmartLog struct {
+	CriticalWarning         uint64  `json:"critical_warning"`
+	TemperatureCelsius      float64 `json:"temperature_celsius"`
+	AvailableSpare          uint64  `json:"available_spare"`
+	AvailableSpareThreshold uint64  `json:"available_spare_threshold"`
+	PercentageUsed          uint64  `json:"percentage_used"`
+	MediaErrors             uint64  `json:"media_errors"`
+	NumErrLogEntries        uint64  `json:"num_err_log_entries"`
+}
+
+type NvmeDevice struct {
+	Name   string   `json:"name"`
+	Model  string   `json:"model" metric:"-"`
+	Serial string   `json:"serial" metric:"-"`
+	Source string   `json:"source" metric:"-"`
+	SMART  SmartLog `json:"smart"`
+}
+
+type Filesystem struct {
+	Device      string  `json:"device" metric:"-"`
+	MountPoint  string  `json:"mount_point" metric:"-"`
+	FSType      string  `json:"fs_type" metric:"-"`
+	ReadOnly    bool    `json:"read_only"`
+	SizeBytes   uint64  `json:"size_bytes"`
+	UsedBytes   uint64  `json:"used_bytes"`
+	UsedPercent float64 `json:"used_percent"`
+}
+
+type StorageInfo struct {
+	Time        time.Time     `json:"time"`
+	NvmeDevices []*NvmeDevice `json:"nvme_devices"`
+	Filesystems []*Filesystem `json:"filesystems"`
+	// Errors are the devices or filesystems that could not be read.
+	Errors []string `json:"errors,omitempty"`
+}
+
+func (i *StorageInfo) JSON() (string, error) {
+	data, err := json.Marshal(i)
+	return string(data), err
+}
+
+// StorageCollector collects the SMART logs of the NVMe disks and the usage of
+// the local filesystems.
+type StorageCollector struct {
+	name string
+}
+
+func NewStorageCollector() (*StorageCollector, error) {
+	return &StorageCollector{name: "StorageCollector"}, nil
+}
+
+func (c *StorageCollector) Name() string {
+	return c.name
+}
+
+func (c *StorageCollector) Collect(ctx context.Context) (*StorageInfo, error) {
+	info := &StorageInfo{Time: time.Now()}
+
+	controllers, err := filepath.Glob(hostfs.Path(NvmeSysPath, "nvme[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(controllers)
+	for _, path := range controllers {
+		device := &NvmeDevice{
+			Name:   filepath.Base(path),
+			Model:  readSysfs(filepath.Join(path, "model")),
+			Serial: readSysfs(filepath.Join(path, "serial")),
+		}
+		if err := readSmartLog(ctx, device); err != nil {
+			logrus.WithField("component", "storage").Warnf("failed to read the SMART log of %s: %v", device.Name, err)
+			info.Errors = append(info.Errors, fmt.Sprintf("%s: %v", device.Name, err))
+			continue
+		}
+		info.NvmeDevices = append(info.NvmeDevices, device)
+	}
+
+	mounts, err := os.ReadFile(hostfs.Path(MountsPath))
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", hostfs.Path(MountsPath), err)
+	}
+	for _, fs := range ParseMounts(string(mounts)) {
+		if err := statFilesystem(fs); err != nil {
+			info.Errors = append(info.Errors, fmt.Sprintf("%s: %v", fs.MountPoint, err))
+			continue
+		}
+		info.Filesystems = append(info.Filesystems, fs)
+	}
+	return info, nil
+}
+
+// readSmartLog reads the SMART log with nvme-cli and falls back to smartctl
+// on hosts without nvme-cli.
+func readSmartLog(ctx context.Context, device *NvmeDevice) error {
+	dev := "/dev/" + device.Name
+	out, nvmeErr := utils.ExecCommand(ctx, "nvme", "smart-log", dev, "-o", "json")
+	if nvmeErr == nil {
+		smart, err := ParseNvmeSmartLog(out)
+		if err == nil {
+			device.SMART = *smart
+			device.Source = SourceNvmeCli
+			return nil
+		}
+		nvmeErr = err
+	}
+	// smartctl exits non-zero when the disk reports a problem, the JSON is still valid
+	out, _ = utils.ExecCommand(ctx, "smartctl", "-j", "-a", dev)
+	smart, err := ParseSmartctl(out)
+	if err != nil {
+		return fmt.Errorf("nvme-cli: %v, smartctl: %v", nvmeErr, err)
+	}
+	device.SMART = *smart
+	device.Source = SourceSmartctl
+	return nil
+}
+
+// ParseNvmeSmartLog parses `nvme smart-log <dev> -o json`, the temperature is
+// reported in Kelvin.
+func ParseNvmeSmartLog(out []byte) (*SmartLog, error) {
+	var log struct {
+		CriticalWarning  jsonUint `json:"critical_warning"`
+		Temperature      jsonUint `json:"temperature"`
+		AvailSpare       jsonUint `json:"avail_spare"`
+		SpareThresh      jsonUint `json:"spare_thresh"`
+		PercentUsed      jsonUint `json:"percent_used"`
+		MediaErrors      jsonUint `json:"media_errors"`
+		NumErrLogEntries jsonUint `json:"num_err_log_entries"`
+	}
+	if err := json.Unmarshal(jsonObject(out), &log); err != nil {
+		return nil, fmt.Errorf("parse nvme smart-log: %w", err)
+	}
+	smart := &SmartLog{
+		CriticalWarning:         uint64(log.CriticalWarning),
+		AvailableSpare:          uint64(log.AvailSpare),
+		AvailableSpareThreshold: uint64(log.SpareThresh),
+		PercentageUsed:          uint64(log.PercentUsed),
+		MediaErrors:             uint64(log.MediaErrors),
+		NumErrLogEntries:        uint64(log.NumErrLogEntries),
+	}
+	if log.Temperature > 0 {
+		smart.TemperatureCelsius = float64(log.Temperature) - 273.15
+	}
+	return smart, nil
+}
+
+// ParseSmartctl parses the NVMe health log of `smartctl -j -a <dev>`.
+func ParseSmartctl(out []byte) (*SmartLog, error) {
+	var report struct {
+		Log *struct {
+			CriticalWarning         uint64  `json:"critical_warning"`
+			Temperature             float64 `json:"temperature"`
+			AvailableSpare          uint64  `json:"available_spare"`
+			AvailableSpareThreshold uint64  `json:"available_spare_threshold"`
+			PercentageUsed          uint64  `json:"percentage_used"`
+			MediaErrors             uint64  `json:"media_errors"`
+			NumErrLogEntries        uint64  `json:"num_err_log_entries"`
+		} `json:"nvme_smart_health_information_log"`
+	}
+	if err := json.Unmarshal(jsonObject(out), &report); err != nil {
+		return nil, fmt.Errorf("parse smartctl: %w", err)
+	}
+	if report.Log == nil {
+		return nil, fmt.Errorf("smartctl reported no NVMe health log")
+	}
+	return &SmartLog{
+		CriticalWarning:         report.Log.CriticalWarning,
+		TemperatureCelsius:      report.Log.Temperature,
+		AvailableSpare:          report.Log.AvailableSpare,
+		AvailableSpareThreshold: report.Log.AvailableSpareThreshold,
+		PercentageUsed:          report.Log.PercentageUsed,
+		MediaErrors:             report.Log.MediaErrors,
+		NumErrLogEntries:        report.Log.NumErrLogEntries,
+	}, nil
+}
+
+// ParseMounts returns the local disk filesystems of a mounts file. A device
+// mounted several times, e.g. by bind mounts of kubelet, is only kept at its
+// first mount point: a bind mount may be read-only on purpose while a
+// filesystem remounted read-only on errors is read-only everywhere.
+func ParseMounts(content string) []*Filesystem {
+	var filesystems []*Filesystem
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		device, mountPoint, fsType, options := fields[0], unescapeMountPath(fields[1]), fields[2], fields[3]
+		if !localFSTypes[fsType] || !strings.HasPrefix(device, "/dev/") || seen[device] {
+			continue
+		}
+		seen[device] = true
+		fs := &Filesystem{Device: device, MountPoint: mountPoint, FSType: fsType}
+		for _, option := range strings.Split(options, ",") {
+			if option == "ro" {
+				fs.ReadOnly = true
+			}
+		}
+		filesystems = append(filesystems, fs)
+	}
+	return filesystems
+}
+
+func statFilesystem(fs *Filesystem) error {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(hostfs.Path(HostRoot, fs.MountPoint), &stat); err != nil {
+		return err
+	}
+	fs.SizeBytes = stat.Blocks * uint64(stat.Bsize)
+	// like df, the blocks reserved for root count as neither used nor available
+	fs.UsedBytes = (stat.Blocks - stat.Bfree) * uint64(stat.Bsize)
+	if usable := fs.UsedBytes + stat.Bavail*uint64(stat.Bsize); usable > 0 {
+		fs.UsedPercent = float64(fs.UsedBytes) * 100 / float64(usable)
+	}
+	return nil
+}
+
+// unescapeMountPath decodes the octal escapes of spaces and tabs in /proc/mounts.
+func unescapeMountPath(path string) string {
+	if !strings.Contains(path, `\`) {
+		return path
+	}
+	if unquoted, err := strconv.Unquote(`"` + path + `"`); err == nil {
+		return unquoted
+	}
+	return path
+}
+
+func readSysfs(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// jsonObject skips the warnings the tools print before the JSON document.
+func jsonObject(out []byte) []byte {
+	if i := bytes.IndexByte(out, '{'); i > 0 {
+		return out[i:]
+	}
+	return out
+}
+
+// jsonUint accepts the counters of nvme-cli as numbers or strings, newer
+// versions print the 128-bit counters as strings.
+type jsonUint uint64
+
+func (u *jsonUint) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		return nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	*u = jsonUint(v)
+	return nil
+}
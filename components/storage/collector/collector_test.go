@@ -0,0 +1,91 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNvmeSmartLog(t *testing.T) {
+	out := `{
+  "critical_warning" : 4,
+  "temperature" : 318,
+  "avail_spare" : 100,
+  "spare_thresh" : 10,
+  "percent_used" : 3,
+  "data_units_read" : "123456789",
+  "media_errors" : "2",
+  "num_err_log_entries" : 17
+}`
+	smart, err := ParseNvmeSmartLog([]byte(out))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), smart.CriticalWarning)
+	assert.InDelta(t, 44.85, smart.TemperatureCelsius, 0.01)
+	assert.Equal(t, uint64(10), smart.AvailableSpareThreshold)
+	assert.Equal(t, uint64(3), smart.PercentageUsed)
+	assert.Equal(t, uint64(2), smart.MediaErrors)
+	assert.Equal(t, uint64(17), smart.NumErrLogEntries)
+
+	_, err = ParseNvmeSmartLog([]byte("open /dev/nvme0: No such file or directory"))
+	assert.Error(t, err)
+}
+
+func TestParseSmartctl(t *testing.T) {
+	out := `{
+  "device": {"name": "/dev/nvme0", "type": "nvme"},
+  "smart_status": {"passed": true},
+  "nvme_smart_health_information_log": {
+    "critical_warning": 0,
+    "temperature": 36,
+    "available_spare": 100,
+    "available_spare_threshold": 10,
+    "percentage_used": 91,
+    "media_errors": 0,
+    "num_err_log_entries": 0
+  }
+}`
+	smart, err := ParseSmartctl([]byte(out))
+	require.NoError(t, err)
+	assert.Equal(t, &SmartLog{
+		TemperatureCelsius:      36,
+		AvailableSpare:          100,
+		AvailableSpareThreshold: 10,
+		PercentageUsed:          91,
+	}, smart)
+
+	_, err = ParseSmartctl([]byte(`{"device": {"name": "/dev/sda", "type": "sat"}}`))
+	assert.Error(t, err)
+}
+
+func TestParseMounts(t *testing.T) {
+	mounts := `sysfs /sys sysfs rw,nosuid,nodev,noexec,relatime 0 0
+/dev/nvme0n1p2 / ext4 rw,relatime,errors=remount-ro 0 0
+/dev/md0 /data xfs ro,relatime,attr2,inode64 0 0
+/dev/nvme0n1p2 /var/lib/kubelet/pods/abc/volumes/host ext4 ro,relatime 0 0
+/dev/nvme1n1 /mnt/local\040scratch ext4 rw,relatime 0 0
+tmpfs /run tmpfs rw,nosuid,nodev 0 0
+10.0.0.1:/share /mnt/nfs nfs4 rw,relatime 0 0
+`
+	filesystems := ParseMounts(mounts)
+	require.Len(t, filesystems, 3)
+	assert.Equal(t, &Filesystem{Device: "/dev/nvme0n1p2", MountPoint: "/", FSType: "ext4"}, filesystems[0])
+	assert.True(t, filesystems[1].ReadOnly)
+	assert.Equal(t, "/data", filesystems[1].MountPoint)
+	assert.Equal(t, "/mnt/local scratch", filesystems[2].MountPoint)
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+)
+
+const (
+	NvmeHealthCheckerName      = "storage-nvme-health"
+	NvmeWearCheckerName        = "storage-nvme-wear"
+	NvmeTemperatureCheckerName = "storage-nvme-temperature"
+	FSUsageCheckerName         = "storage-fs-usage"
+	FSReadOnlyCheckerName      = "storage-fs-readonly"
+)
+
+// StorageCheckItems is a map of check items for the local NVMe disks and filesystems
+var StorageCheckItems = map[string]common.CheckerResult{
+	NvmeHealthCheckerName: {
+		Name:        NvmeHealthCheckerName,
+		Description: "Check if an NVMe disk raises a SMART critical warning or has more media errors than the threshold",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelCritical,
+		Detail:      "All NVMe disks are healthy",
+		ErrorName:   "NVMeSmartCritical",
+		Suggestion:  "Back up the scratch data and replace the NVMe disk, check `nvme smart-log` and `nvme error-log`",
+	},
+	NvmeWearCheckerName: {
+		Name:        NvmeWearCheckerName,
+		Description: "Check if the endurance used by an NVMe disk is below the threshold",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelWarning,
+		Detail:      "All NVMe disks are within their endurance",
+		ErrorName:   "NVMeWearOut",
+		Suggestion:  "Plan the replacement of the NVMe disk before it wears out",
+	},
+	NvmeTemperatureCheckerName: {
+		Name:        NvmeTemperatureCheckerName,
+		Description: "Check if the temperature of an NVMe disk is below the threshold",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelWarning,
+		Detail:      "All NVMe disks are below the temperature threshold",
+		ErrorName:   "NVMeOverTemperature",
+		Suggestion:  "Check the airflow of the disk bay, the disk throttles its I/O when it overheats",
+	},
+	FSUsageCheckerName: {
+		Name:        FSUsageCheckerName,
+		Description: "Check if the usage of the local filesystems is below the threshold",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelWarning,
+		Detail:      "All local filesystems have free space",
+		ErrorName:   "FilesystemAlmostFull",
+		Suggestion:  "Clean up the checkpoints, logs and container images on the filesystem",
+	},
+	FSReadOnlyCheckerName: {
+		Name:        FSReadOnlyCheckerName,
+		Description: "Check if a local filesystem has been remounted read-only",
+		Status:      consts.StatusNormal,
+		Level:       consts.LevelCritical,
+		Detail:      "No local filesystem is read-only",
+		ErrorName:   "FilesystemReadOnly",
+		Suggestion:  "Drain the node, check dmesg for I/O errors of the disk and run fsck before remounting it read-write",
+	},
+}
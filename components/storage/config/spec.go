@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/httpclient"
+	"github.com/sirupsen/logrus"
+)
+
+type StorageSpecs struct {
+	Specs map[string]*StorageSpec `json:"storage" yaml:"storage"`
+}
+
+type StorageSpec struct {
+	Nvme       NvmeSpec       `json:"nvme" yaml:"nvme"`
+	Filesystem FilesystemSpec `json:"filesystem" yaml:"filesystem"`
+}
+
+type NvmeSpec struct {
+	MaxMediaErrors        uint64  `json:"max_media_errors" yaml:"max_media_errors"`
+	MaxPercentageUsed     uint64  `json:"max_percentage_used" yaml:"max_percentage_used"`
+	MaxTemperatureCelsius float64 `json:"max_temperature_celsius" yaml:"max_temperature_celsius"`
+}
+
+type FilesystemSpec struct {
+	MaxUsedPercent float64 `json:"max_used_percent" yaml:"max_used_percent"`
+	// IgnoredMountPoints are skipped by the usage and read-only checks, e.g. a
+	// filesystem mounted read-only on purpose.
+	IgnoredMountPoints []string `json:"ignored_mount_points,omitempty" yaml:"ignored_mount_points,omitempty"`
+}
+
+// EnsureSpec ensures that `file` contains the "default" storage spec entry,
+// potentially downloading it from OSS.
+func EnsureSpec(file string) (string, error) {
+	const comp = "storage/spec"
+	const specID = "default"
+
+	var s StorageSpecs
+	if err := common.LoadSpec(file, &s); err == nil {
+		if s.Specs != nil {
+			if _, ok := s.Specs[specID]; ok {
+				logrus.WithField("component", comp).Infof("spec for storage %s already in %s, skipping download", specID, file)
+				return file, nil
+			}
+		}
+	} else {
+		logrus.WithField("component", comp).Debugf("LoadSpec failed during EnsureSpec (may be new file): %v", err)
+	}
+
+	// Download {SICHEK_SPEC_URL}/storage/default.yaml
+	ossBase := httpclient.GetSichekSpecURL()
+	if ossBase == "" {
+		return file, fmt.Errorf("EnsureSpec: storage %s not in spec and SICHEK_SPEC_URL not set", specID)
+	}
+
+	tmpFile := filepath.Join(os.TempDir(), fmt.Sprintf("storage_%s.yaml", specID))
+	url := fmt.Sprintf("%s/%s/%s.yaml", strings.TrimRight(ossBase, "/"), consts.ComponentNameStorage, specID)
+
+	logrus.WithField("component", comp).Infof("downloading storage spec from %s", url)
+	if err := common.DownloadSpecFile(url, tmpFile, comp); err != nil {
+		return file, fmt.Errorf("EnsureSpec: download failed: %w", err)
+	}
+
+	var downloaded StorageSpecs
+	if err := common.LoadSpec(tmpFile, &downloaded); err != nil {
+		return file, fmt.Errorf("EnsureSpec: parse downloaded spec: %w", err)
+	}
+
+	if err := common.MergeAndWriteSpec(
+		file,
+		"storage",
+		downloaded.Specs,
+		func(c *StorageSpecs) map[string]*StorageSpec { return c.Specs },
+		func(c *StorageSpecs, m map[string]*StorageSpec) { c.Specs = m },
+	); err != nil {
+		return file, fmt.Errorf("EnsureSpec: merge failed: %w", err)
+	}
+
+	logrus.WithField("component", comp).Infof("merged storage %s spec into %s", specID, file)
+	return file, nil
+}
+
+// LoadSpec reads the storage multi-spec YAML at `file`, ensures the "default"
+// entry is present and returns it.
+func LoadSpec(file string) (*StorageSpec, error) {
+	if file == "" {
+		return nil, fmt.Errorf("storage spec file path is empty")
+	}
+
+	if _, err := EnsureSpec(file); err != nil {
+		logrus.WithField("component", "storage/spec").Warnf("EnsureSpec failed: %v", err)
+	}
+
+	return common.FilterSpec(file, "storage", "default",
+		func(c *StorageSpecs, id string) (*StorageSpec, bool) {
+			spec, ok := c.Specs[id]
+			return spec, ok
+		},
+	)
+}
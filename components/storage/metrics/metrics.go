@@ -0,0 +1,52 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package metrics
+
+import (
+	"github.com/scitix/sichek/components/storage/collector"
+	common "github.com/scitix/sichek/metrics"
+)
+
+const (
+	MetricPrefix = "sichek_storage"
+	TagPrefix    = "json"
+)
+
+type StorageMetrics struct {
+	NvmeGauge       *common.GaugeVecMetricExporter
+	FilesystemGauge *common.GaugeVecMetricExporter
+}
+
+func NewStorageMetrics() *StorageMetrics {
+	return &StorageMetrics{
+		NvmeGauge:       common.NewGaugeVecMetricExporter(MetricPrefix+"_nvme", []string{"device", "model"}),
+		FilesystemGauge: common.NewGaugeVecMetricExporter(MetricPrefix+"_fs", []string{"mount_point", "device"}),
+	}
+}
+
+// ExportMetrics exports the SMART log, e.g. sichek_storage_nvme_media_errors,
+// and the filesystem usage, e.g. sichek_storage_fs_used_percent.
+func (m *StorageMetrics) ExportMetrics(info *collector.StorageInfo) {
+	if info == nil {
+		return
+	}
+	for _, device := range info.NvmeDevices {
+		m.NvmeGauge.ExportStruct(device.SMART, []string{device.Name, device.Model}, TagPrefix)
+	}
+	for _, fs := range info.Filesystems {
+		m.FilesystemGauge.ExportStruct(*fs, []string{fs.MountPoint, fs.Device}, TagPrefix)
+	}
+}
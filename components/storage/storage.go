@@ -0,0 +1,315 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/storage/checker"
+	"github.com/scitix/sichek/components/storage/collector"
+	"github.com/scitix/sichek/components/storage/config"
+	storagemetrics "github.com/scitix/sichek/components/storage/metrics"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/scitix/sichek/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+type component struct {
+	ctx           context.Context
+	cancel        context.CancelFunc
+	componentName string
+	cfg           *config.StorageUserConfig
+	cfgMutex      sync.Mutex
+	spec          *config.StorageSpec
+	collector     *collector.StorageCollector
+	checkers      []common.Checker
+	specMtx       sync.RWMutex
+	metrics       *storagemetrics.StorageMetrics
+
+	cacheMtx    sync.RWMutex
+	cacheBuffer []*common.Result
+	cacheInfo   []common.Info
+	currIndex   int64
+	cacheSize   int64
+
+	service *common.CommonService
+}
+
+var (
+	storageComponent     *component
+	storageComponentOnce sync.Once
+)
+
+func NewComponent(cfgFile string, specFile string, ignoredCheckers []string) (common.Component, error) {
+	var err error
+	storageComponentOnce.Do(func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic occurred when create component storage: %v", r)
+			}
+		}()
+		storageComponent, err = newComponent(cfgFile, specFile, ignoredCheckers)
+	})
+	return storageComponent, err
+}
+
+func newComponent(cfgFile string, specFile string, ignoredCheckers []string) (comp *component, err error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		if err != nil {
+			cancel()
+		}
+	}()
+
+	cfg := &config.StorageUserConfig{}
+	err = common.LoadUserConfig(cfgFile, cfg)
+	if err != nil || cfg.Storage == nil {
+		logrus.WithField("component", "storage").Warnf("get user config failed or storage config is nil, using default config")
+		cfg.Storage = &config.StorageConfig{
+			QueryInterval: common.Duration{Duration: 60 * time.Second},
+			CacheSize:     5,
+		}
+	}
+	if len(ignoredCheckers) > 0 {
+		cfg.Storage.IgnoredCheckers = ignoredCheckers
+	}
+
+	spec, specErr := config.LoadSpec(specFile)
+	if specErr != nil {
+		logrus.WithField("component", "storage").Warnf("failed to load spec %s: %v", specFile, specErr)
+	}
+
+	collectorInst, err := collector.NewStorageCollector()
+	if err != nil {
+		logrus.WithField("component", "storage").Errorf("create storage collector failed: %v", err)
+		return nil, err
+	}
+
+	cacheSize := cfg.Storage.CacheSize
+	if cacheSize == 0 {
+		cacheSize = 5
+	}
+
+	comp = &component{
+		ctx:           ctx,
+		cancel:        cancel,
+		componentName: consts.ComponentNameStorage,
+		cfg:           cfg,
+		spec:          spec,
+		collector:     collectorInst,
+		cacheBuffer:   make([]*common.Result, cacheSize),
+		cacheInfo:     make([]common.Info, cacheSize),
+		cacheSize:     cacheSize,
+	}
+
+	comp.checkers, err = common.CheckersOrSpecMissing(consts.ComponentNameStorage, spec, specErr, func(spec *config.StorageSpec) ([]common.Checker, error) {
+		return checker.NewCheckers(cfg, spec)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Storage.EnableMetrics {
+		comp.metrics = storagemetrics.NewStorageMetrics()
+	}
+	service := common.NewCommonService(ctx, cfg, comp.componentName, comp.GetTimeout(), comp.HealthCheck)
+	comp.service = service
+
+	return comp, nil
+}
+
+func (c *component) Name() string {
+	return c.componentName
+}
+
+func (c *component) HealthCheck(ctx context.Context) (*common.Result, error) {
+	timer := common.NewTimer(fmt.Sprintf("%s-HealthCheck-Cost", c.componentName))
+	storageInfo, err := common.Collect(ctx, c.componentName, c.collector.Collect)
+	if err != nil {
+		logrus.WithField("component", "storage").Errorf("failed to collect storage info: %v", err)
+		return nil, err
+	}
+	timer.Mark("storage-collect")
+
+	if c.metrics != nil {
+		c.metrics.ExportMetrics(storageInfo)
+	}
+
+	c.specMtx.RLock()
+	checkers := c.checkers
+	c.specMtx.RUnlock()
+	result := common.Check(ctx, c.componentName, storageInfo, checkers)
+	timer.Mark("storage-check")
+
+	c.cacheMtx.Lock()
+	c.cacheBuffer[c.currIndex] = result
+	c.cacheInfo[c.currIndex] = storageInfo
+	c.currIndex = (c.currIndex + 1) % c.cacheSize
+	c.cacheMtx.Unlock()
+
+	if result.Status == consts.StatusAbnormal && consts.LevelPriority[result.Level] > consts.LevelPriority[consts.LevelInfo] {
+		logrus.WithField("component", "storage").Errorf("Health Check Failed")
+	} else {
+		logrus.WithField("component", "storage").Infof("Health Check PASSED")
+	}
+
+	return result, nil
+}
+
+func (c *component) CacheResults() ([]*common.Result, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	return c.cacheBuffer, nil
+}
+
+func (c *component) LastResult() (*common.Result, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	result := c.cacheBuffer[c.currIndex]
+	if c.currIndex == 0 {
+		result = c.cacheBuffer[c.cacheSize-1]
+	}
+	return result, nil
+}
+
+func (c *component) CacheInfos() ([]common.Info, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	return c.cacheInfo, nil
+}
+
+func (c *component) LastInfo() (common.Info, error) {
+	c.cacheMtx.RLock()
+	defer c.cacheMtx.RUnlock()
+	var info common.Info
+	if c.currIndex == 0 {
+		info = c.cacheInfo[c.cacheSize-1]
+	} else {
+		info = c.cacheInfo[c.currIndex-1]
+	}
+	return info, nil
+}
+
+func (c *component) Start() <-chan *common.Result {
+	return c.service.Start()
+}
+
+func (c *component) Stop() error {
+	return c.service.Stop()
+}
+
+func (c *component) Update(cfg common.ComponentUserConfig) error {
+	c.cfgMutex.Lock()
+	configPointer, ok := cfg.(*config.StorageUserConfig)
+	if !ok {
+		c.cfgMutex.Unlock()
+		return fmt.Errorf("update wrong config type for storage")
+	}
+	c.cfg = configPointer
+	c.cfgMutex.Unlock()
+	return c.service.Update(cfg)
+}
+
+// UpdateSpec reloads the disk and filesystem thresholds and rebuilds the checkers from them.
+func (c *component) UpdateSpec(specFile string) error {
+	spec, err := config.LoadSpec(specFile)
+	if err != nil {
+		return fmt.Errorf("load storage spec %s: %w", specFile, err)
+	}
+	if spec == nil {
+		return fmt.Errorf("storage spec is nil after loading from %s", specFile)
+	}
+	c.cfgMutex.Lock()
+	cfg := c.cfg
+	c.cfgMutex.Unlock()
+	checkers, err := checker.NewCheckers(cfg, spec)
+	if err != nil {
+		return err
+	}
+	c.specMtx.Lock()
+	c.spec = spec
+	c.checkers = checkers
+	c.specMtx.Unlock()
+	logrus.WithField("component", "storage").Infof("reloaded spec from %s", specFile)
+	return nil
+}
+
+func (c *component) Status() bool {
+	return c.service.Status()
+}
+
+func (c *component) GetTimeout() time.Duration {
+	return c.cfg.GetQueryInterval().Duration
+}
+
+func (c *component) PrintInfo(info common.Info, result *common.Result, summaryPrint bool) bool {
+	checkAllPassed := true
+	if result.Status == consts.StatusAbnormal && consts.LevelPriority[result.Level] > consts.LevelPriority[consts.LevelInfo] {
+		checkAllPassed = false
+	}
+
+	utils.PrintTitle("Storage", "-")
+
+	storageInfo, ok := info.(*collector.StorageInfo)
+	if !ok || storageInfo == nil {
+		printer.Println("No storage info available")
+		return checkAllPassed
+	}
+
+	if len(storageInfo.NvmeDevices) > 0 {
+		printer.Printf("%-10s %-24s %-10s %-8s %-8s %-12s %-10s\n", "NVMe", "Model", "Source", "Warning", "Used", "MediaErrors", "Temp")
+		for _, device := range storageInfo.NvmeDevices {
+			printer.Printf("%-10s %-24s %-10s 0x%-6x %-8s %-12d %-10s\n", device.Name, device.Model, device.Source,
+				device.SMART.CriticalWarning, fmt.Sprintf("%d%%", device.SMART.PercentageUsed), device.SMART.MediaErrors,
+				fmt.Sprintf("%.0f C", device.SMART.TemperatureCelsius))
+		}
+	} else {
+		printer.Println("No NVMe disk found")
+	}
+	printer.Println()
+	printer.Printf("%-32s %-16s %-6s %-10s %-4s\n", "Mount Point", "Device", "Type", "Used", "RO")
+	for _, fs := range storageInfo.Filesystems {
+		printer.Printf("%-32s %-16s %-6s %-10s %-4t\n", fs.MountPoint, fs.Device, fs.FSType, fmt.Sprintf("%.1f%%", fs.UsedPercent), fs.ReadOnly)
+	}
+	for _, e := range storageInfo.Errors {
+		printer.Printf("%s%s%s\n", consts.Yellow, e, consts.Reset)
+	}
+
+	hasErrors := false
+	if result != nil {
+		for _, res := range result.Checkers {
+			if res.Status != consts.StatusNormal && res.Level != consts.LevelInfo {
+				if !hasErrors {
+					printer.Printf("\nErrors Events:\n")
+					hasErrors = true
+				}
+				printer.Printf("\tEvent: %s%s%s -> %s\n", consts.LevelColor(res.Level), res.ErrorName, consts.Reset, res.Detail)
+			}
+		}
+	}
+	if !hasErrors {
+		printer.Printf("\nErrors Events:\n\tNo Storage Events Detected\n")
+	}
+
+	printer.Println()
+	return checkAllPassed
+}
@@ -27,6 +27,7 @@ import (
 	filter "github.com/scitix/sichek/components/common/eventfilter"
 	"github.com/scitix/sichek/components/syslog/config"
 	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
 
 	"github.com/sirupsen/logrus"
 )
@@ -267,10 +268,10 @@ func (c *component) PrintInfo(info common.Info /*ignored*/, result *common.Resul
 		}
 	}
 	if checkAllPassed {
-		fmt.Printf("%sNo System Log Error detected%s\n", consts.Green, consts.Reset)
+		printer.Printf("%sNo System Log Error detected%s\n", consts.Green, consts.Reset)
 	} else {
 		for _, v := range syslogEvents {
-			fmt.Printf("\t%s\n", v)
+			printer.Printf("\t%s\n", v)
 		}
 	}
 	return checkAllPassed
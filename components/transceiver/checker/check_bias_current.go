@@ -71,14 +71,14 @@ func (c *BiasCurrentChecker) Check(ctx context.Context, data any) (*common.Check
 				}
 				result.ErrorName = tmpl.ErrorName
 				result.Detail += fmt.Sprintf(
-					"Interface %s lane %d bias current %.3f mA is <= 0 (laser may be off or faulty).\n",
-					module.Interface, lane, bias,
+					"Port %s lane %d bias current %.3f mA is <= 0 (laser may be off or faulty).\n",
+					module.Location(), lane, bias,
 				)
 				moduleAbnormal = true
 			}
 		}
 		if moduleAbnormal {
-			abnormalDevices = append(abnormalDevices, module.Interface)
+			abnormalDevices = append(abnormalDevices, module.Name())
 		}
 	}
 
@@ -67,7 +67,7 @@ func (c *LinkErrorsChecker) Check(ctx context.Context, data any) (*common.Checke
 			continue
 		}
 
-		iface := module.Interface
+		iface := module.Name()
 		curr := module.LinkErrors
 
 		prev, hasPrev := c.prevErrors[iface]
@@ -93,8 +93,8 @@ func (c *LinkErrorsChecker) Check(ctx context.Context, data any) (*common.Checke
 				}
 				result.ErrorName = tmpl.ErrorName
 				result.Detail += fmt.Sprintf(
-					"Interface %s link error %q increased by %d (prev=%d, curr=%d).\n",
-					iface, errType, delta, prevVal, currVal,
+					"Port %s link error %q increased by %d (prev=%d, curr=%d).\n",
+					module.Location(), errType, delta, prevVal, currVal,
 				)
 				moduleAbnormal = true
 			}
@@ -62,10 +62,10 @@ func (c *PresenceChecker) Check(ctx context.Context, data any) (*common.CheckerR
 		}
 		result.ErrorName = tmpl.ErrorName
 		result.Detail += fmt.Sprintf(
-			"Interface %s transceiver module is not present.\n",
-			module.Interface,
+			"Port %s transceiver module is not present.\n",
+			module.Location(),
 		)
-		abnormalDevices = append(abnormalDevices, module.Interface)
+		abnormalDevices = append(abnormalDevices, module.Name())
 	}
 
 	if result.Status != consts.StatusNormal {
@@ -27,7 +27,8 @@ import (
 )
 
 // RxPowerChecker checks Rx optical power per lane against module built-in alarm
-// thresholds plus a configurable margin from the spec.
+// thresholds plus a configurable margin from the spec, or against the absolute
+// range of the spec for modules without alarm thresholds.
 type RxPowerChecker struct {
 	spec *config.TransceiverSpec
 }
@@ -62,9 +63,17 @@ func (c *RxPowerChecker) Check(ctx context.Context, data any) (*common.CheckerRe
 			margin = netSpec.Thresholds.RxPowerMarginDB
 		}
 
-		// Skip if no valid alarm thresholds from module
+		// Prefer the module built-in alarm thresholds; fall back to the spec
+		// range for modules that do not report them
+		low := module.RxPowerLowAlarm + margin
+		high := module.RxPowerHighAlarm - margin
+		source := "alarm±margin"
 		if module.RxPowerLowAlarm == 0 && module.RxPowerHighAlarm == 0 {
-			continue
+			if netSpec == nil || (netSpec.Thresholds.RxPowerMinDBM == 0 && netSpec.Thresholds.RxPowerMaxDBM == 0) {
+				continue
+			}
+			low, high = netSpec.Thresholds.RxPowerMinDBM, netSpec.Thresholds.RxPowerMaxDBM
+			source = "spec"
 		}
 
 		moduleAbnormal := false
@@ -74,8 +83,6 @@ func (c *RxPowerChecker) Check(ctx context.Context, data any) (*common.CheckerRe
 			if rxPow <= -30 {
 				continue
 			}
-			low := module.RxPowerLowAlarm + margin
-			high := module.RxPowerHighAlarm - margin
 
 			if rxPow < low || rxPow > high {
 				result.Status = consts.StatusAbnormal
@@ -85,14 +92,14 @@ func (c *RxPowerChecker) Check(ctx context.Context, data any) (*common.CheckerRe
 				}
 				result.ErrorName = tmpl.ErrorName
 				result.Detail += fmt.Sprintf(
-					"Interface %s lane %d Rx power %.2f dBm out of range [%.2f, %.2f] dBm (alarm±margin).\n",
-					module.Interface, lane, rxPow, low, high,
+					"Port %s lane %d Rx power %.2f dBm out of range [%.2f, %.2f] dBm (%s), module %s.\n",
+					module.Location(), lane, rxPow, low, high, source, module.Identity(),
 				)
 				moduleAbnormal = true
 			}
 		}
 		if moduleAbnormal {
-			abnormalDevices = append(abnormalDevices, module.Interface)
+			abnormalDevices = append(abnormalDevices, module.Name())
 		}
 	}
 
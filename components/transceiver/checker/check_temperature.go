@@ -27,7 +27,8 @@ import (
 )
 
 // TemperatureChecker checks module temperature against warning and critical thresholds
-// from the spec.
+// from the spec, and against the high alarm threshold of the module itself when it
+// is lower than the critical threshold.
 type TemperatureChecker struct {
 	spec *config.TransceiverSpec
 }
@@ -64,6 +65,9 @@ func (c *TemperatureChecker) Check(ctx context.Context, data any) (*common.Check
 		temp := module.Temperature
 		warnThresh := netSpec.Thresholds.TemperatureWarningC
 		critThresh := netSpec.Thresholds.TemperatureCriticalC
+		if module.TempHighAlarm > 0 && module.TempHighAlarm < critThresh {
+			critThresh = module.TempHighAlarm
+		}
 
 		if temp >= critThresh {
 			result.Status = consts.StatusAbnormal
@@ -74,10 +78,10 @@ func (c *TemperatureChecker) Check(ctx context.Context, data any) (*common.Check
 			}
 			result.ErrorName = tmpl.ErrorName
 			result.Detail += fmt.Sprintf(
-				"Interface %s temperature %.1f°C exceeds critical threshold %.1f°C.\n",
-				module.Interface, temp, critThresh,
+				"Port %s temperature %.1f°C exceeds critical threshold %.1f°C, module %s.\n",
+				module.Location(), temp, critThresh, module.Identity(),
 			)
-			abnormalDevices = append(abnormalDevices, module.Interface)
+			abnormalDevices = append(abnormalDevices, module.Name())
 		} else if temp >= warnThresh {
 			result.Status = consts.StatusAbnormal
 			// At warning threshold, always use warning level regardless of network type
@@ -86,10 +90,10 @@ func (c *TemperatureChecker) Check(ctx context.Context, data any) (*common.Check
 			}
 			result.ErrorName = tmpl.ErrorName
 			result.Detail += fmt.Sprintf(
-				"Interface %s temperature %.1f°C exceeds warning threshold %.1f°C.\n",
-				module.Interface, temp, warnThresh,
+				"Port %s temperature %.1f°C exceeds warning threshold %.1f°C, module %s.\n",
+				module.Location(), temp, warnThresh, module.Identity(),
 			)
-			abnormalDevices = append(abnormalDevices, module.Interface)
+			abnormalDevices = append(abnormalDevices, module.Name())
 		}
 	}
 
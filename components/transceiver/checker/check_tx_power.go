@@ -93,14 +93,14 @@ func (c *TxPowerChecker) Check(ctx context.Context, data any) (*common.CheckerRe
 				}
 				result.ErrorName = tmpl.ErrorName
 				result.Detail += fmt.Sprintf(
-					"Interface %s lane %d Tx power %.2f dBm out of range [%.2f, %.2f] dBm (alarm±margin).\n",
-					module.Interface, lane, txPow, low, high,
+					"Port %s lane %d Tx power %.2f dBm out of range [%.2f, %.2f] dBm (alarm±margin).\n",
+					module.Location(), lane, txPow, low, high,
 				)
 				moduleAbnormal = true
 			}
 		}
 		if moduleAbnormal {
-			abnormalDevices = append(abnormalDevices, module.Interface)
+			abnormalDevices = append(abnormalDevices, module.Name())
 		}
 	}
 
@@ -78,10 +78,10 @@ func (c *VendorChecker) Check(ctx context.Context, data any) (*common.CheckerRes
 			}
 			result.ErrorName = tmpl.ErrorName
 			result.Detail += fmt.Sprintf(
-				"Interface %s vendor %q is not in the approved vendors list %v.\n",
-				module.Interface, vendor, netSpec.ApprovedVendors,
+				"Port %s vendor %q is not in the approved vendors list %v.\n",
+				module.Location(), vendor, netSpec.ApprovedVendors,
 			)
-			abnormalDevices = append(abnormalDevices, module.Interface)
+			abnormalDevices = append(abnormalDevices, module.Name())
 		}
 	}
 
@@ -72,10 +72,10 @@ func (c *VoltageChecker) Check(ctx context.Context, data any) (*common.CheckerRe
 			}
 			result.ErrorName = tmpl.ErrorName
 			result.Detail += fmt.Sprintf(
-				"Interface %s voltage %.3f V out of range [%.3f, %.3f] V.\n",
-				module.Interface, volt, low, high,
+				"Port %s voltage %.3f V out of range [%.3f, %.3f] V.\n",
+				module.Location(), volt, low, high,
 			)
-			abnormalDevices = append(abnormalDevices, module.Interface)
+			abnormalDevices = append(abnormalDevices, module.Name())
 		}
 	}
 
@@ -117,6 +117,24 @@ func TestTemperatureChecker_AbsentModuleSkipped(t *testing.T) {
 	assert.Equal(t, consts.StatusNormal, result.Status)
 }
 
+func TestTemperatureChecker_ModuleAlarmBelowCritical(t *testing.T) {
+	// 72°C < crit(75) but >= module high alarm(70) → critical, reported with the cage
+	chk := &TemperatureChecker{spec: testSpec()}
+	info := &collector.TransceiverInfo{
+		Modules: []collector.ModuleInfo{
+			{Interface: "ib0", IBDev: "mlx5_0", PcieBDF: "0000:19:00.0", NetworkType: "business", Present: true,
+				Temperature: 72, TempHighAlarm: 70, Vendor: "NVIDIA", PartNumber: "MMA4Z00-NS", SerialNumber: "MT2301FT0001"},
+		},
+	}
+
+	result, err := chk.Check(context.Background(), info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusAbnormal, result.Status)
+	assert.Equal(t, consts.LevelCritical, result.Level)
+	assert.Equal(t, "ib0", result.Device)
+	assert.Contains(t, result.Detail, "Port ib0 (mlx5_0, 0000:19:00.0) temperature 72.0°C exceeds critical threshold 70.0°C, module NVIDIA MMA4Z00-NS SN MT2301FT0001")
+}
+
 // ─── Presence checker ─────────────────────────────────────────────────────────
 
 func TestPresenceChecker_AllPresent(t *testing.T) {
@@ -237,6 +255,50 @@ func TestVendorChecker_CaseInsensitiveMatch(t *testing.T) {
 	assert.Equal(t, consts.StatusNormal, result.Status)
 }
 
+// ─── Rx power checker ─────────────────────────────────────────────────────────
+
+func TestRxPowerChecker_AlarmThresholds(t *testing.T) {
+	// business margin 1 dB: alarm range [-10, 5] → [-9, 4]; the dark lane is skipped
+	chk := &RxPowerChecker{spec: testSpec()}
+	info := &collector.TransceiverInfo{
+		Modules: []collector.ModuleInfo{
+			{Interface: "ib0", IBDev: "mlx5_0", NetworkType: "business", Present: true,
+				RxPower: []float64{1.5, -40, 2.0}, RxPowerLowAlarm: -10, RxPowerHighAlarm: 5},
+			{IBDev: "mlx5_1", NetworkType: "business", Present: true,
+				RxPower: []float64{1.5, -9.5}, RxPowerLowAlarm: -10, RxPowerHighAlarm: 5},
+		},
+	}
+
+	result, err := chk.Check(context.Background(), info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusAbnormal, result.Status)
+	assert.Equal(t, "mlx5_1", result.Device)
+	assert.Contains(t, result.Detail, "Port mlx5_1 lane 2 Rx power -9.50 dBm out of range [-9.00, 4.00] dBm (alarm±margin)")
+}
+
+func TestRxPowerChecker_SpecRangeWithoutAlarms(t *testing.T) {
+	spec := testSpec()
+	info := &collector.TransceiverInfo{
+		Modules: []collector.ModuleInfo{
+			{Interface: "eth2", NetworkType: "business", Present: true, RxPower: []float64{-11}},
+		},
+	}
+
+	// without a spec range a module without alarm thresholds is not checked
+	chk := &RxPowerChecker{spec: spec}
+	result, err := chk.Check(context.Background(), info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusNormal, result.Status)
+
+	spec.Networks["business"].Thresholds.RxPowerMinDBM = -10
+	spec.Networks["business"].Thresholds.RxPowerMaxDBM = 5
+	result, err = chk.Check(context.Background(), info)
+	require.NoError(t, err)
+	assert.Equal(t, consts.StatusAbnormal, result.Status)
+	assert.Equal(t, "eth2", result.Device)
+	assert.Contains(t, result.Detail, "out of range [-10.00, 5.00] dBm (spec)")
+}
+
 // ─── Error handling ───────────────────────────────────────────────────────────
 
 func TestCheckers_InvalidDataType(t *testing.T) {
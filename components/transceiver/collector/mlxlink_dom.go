@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/hostfs"
 	"github.com/scitix/sichek/pkg/utils"
 )
 
@@ -159,7 +160,7 @@ func parseMLXLinkMultiLane(s string) []float64 {
 
 func (m *ModuleInfo) parseIBCounters(ibDev string) {
 	m.LinkErrors = make(map[string]uint64)
-	counterDir := "/sys/class/infiniband/" + ibDev + "/ports/1/counters"
+	counterDir := hostfs.Path("/sys/class/infiniband", ibDev, "ports/1/counters")
 	errorKeys := []string{"symbol_error_counter", "VL15_dropped", "link_error_recovery_counter", "link_downed_counter"}
 
 	for _, key := range errorKeys {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/sirupsen/logrus"
@@ -21,6 +22,7 @@ func (t *TransceiverInfo) JSON() (string, error) {
 type ModuleInfo struct {
 	Interface   string `json:"interface"`
 	IBDev       string `json:"ib_dev"`
+	PcieBDF     string `json:"pcie_bdf,omitempty"`
 	NetworkType string `json:"network_type"`
 	CollectTool string `json:"collect_tool"`
 
@@ -49,6 +51,45 @@ type ModuleInfo struct {
 	LinkErrors map[string]uint64 `json:"link_errors"`
 }
 
+// Name identifies the port of the module, the netdev or the IB device of an
+// IB port without one.
+func (m *ModuleInfo) Name() string {
+	if m.Interface != "" {
+		return m.Interface
+	}
+	return m.IBDev
+}
+
+// Location describes the cage of the module, e.g. "ib0 (mlx5_0, 0000:19:00.0)",
+// so that the right optic is replaced on a node with dozens of ports.
+func (m *ModuleInfo) Location() string {
+	var parts []string
+	if m.IBDev != "" && m.IBDev != m.Name() {
+		parts = append(parts, m.IBDev)
+	}
+	if m.PcieBDF != "" {
+		parts = append(parts, m.PcieBDF)
+	}
+	if len(parts) == 0 {
+		return m.Name()
+	}
+	return fmt.Sprintf("%s (%s)", m.Name(), strings.Join(parts, ", "))
+}
+
+// Identity describes the module itself, e.g. "NVIDIA MMA4Z00-NS SN MT2301FT0001".
+func (m *ModuleInfo) Identity() string {
+	var parts []string
+	for _, part := range []string{m.Vendor, m.PartNumber} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if m.SerialNumber != "" {
+		parts = append(parts, "SN "+m.SerialNumber)
+	}
+	return strings.Join(parts, " ")
+}
+
 type collectTask struct {
 	iface      InterfaceEntry
 	useMLXLink bool
@@ -154,6 +195,7 @@ func (c *TransceiverCollector) Collect(ctx context.Context) (*TransceiverInfo, e
 			}
 
 			module.Interface = task.iface.Name
+			module.PcieBDF = task.iface.PcieBDF
 			if task.iface.IsIB {
 				module.IBDev = task.iface.IBDev
 			}
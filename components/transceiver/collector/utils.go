@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/scitix/sichek/pkg/hostfs"
 	"github.com/sirupsen/logrus"
 )
 
@@ -20,7 +21,7 @@ type InterfaceEntry struct {
 func EnumerateTransceiverInterfaces() ([]InterfaceEntry, error) {
 	var entries []InterfaceEntry
 
-	netDir := "/sys/class/net"
+	netDir := hostfs.Path("/sys/class/net")
 	netEntries, err := os.ReadDir(netDir)
 	if err != nil {
 		return nil, err
@@ -70,7 +71,7 @@ func EnumerateTransceiverInterfaces() ([]InterfaceEntry, error) {
 		entries = append(entries, entry)
 	}
 
-	ibDir := "/sys/class/infiniband"
+	ibDir := hostfs.Path("/sys/class/infiniband")
 	ibEntries, err := os.ReadDir(ibDir)
 	if err != nil {
 		logrus.WithField("component", "transceiver").Debugf("no infiniband devices: %v", err)
@@ -143,7 +144,7 @@ func GetLinkSpeed(ifaceName string) (string, int) {
 	if ifaceName == "" {
 		return "", 0
 	}
-	speedPath := filepath.Join("/sys/class/net", ifaceName, "speed")
+	speedPath := hostfs.Path("/sys/class/net", ifaceName, "speed")
 	data, err := os.ReadFile(speedPath)
 	if err != nil {
 		return "", 0
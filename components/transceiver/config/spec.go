@@ -51,6 +51,10 @@ type ThresholdSpec struct {
 	RxPowerMarginDB      float64 `json:"rx_power_margin_db" yaml:"rx_power_margin_db"`
 	TemperatureWarningC  float64 `json:"temperature_warning_c" yaml:"temperature_warning_c"`
 	TemperatureCriticalC float64 `json:"temperature_critical_c" yaml:"temperature_critical_c"`
+	// RxPowerMinDBM and RxPowerMaxDBM bound the Rx power of the modules that
+	// report no alarm thresholds, e.g. some AOCs; both zero disables the check.
+	RxPowerMinDBM float64 `json:"rx_power_min_dbm" yaml:"rx_power_min_dbm"`
+	RxPowerMaxDBM float64 `json:"rx_power_max_dbm" yaml:"rx_power_max_dbm"`
 }
 
 // ─── EnsureSpec ──────────────────────────────────────────────────────────────
@@ -182,6 +186,7 @@ func defaultSpec() *TransceiverSpec {
 			"business": {
 				Thresholds: ThresholdSpec{
 					TxPowerMarginDB: 1.0, RxPowerMarginDB: 1.0,
+					RxPowerMinDBM: -10, RxPowerMaxDBM: 5,
 					TemperatureWarningC: 65, TemperatureCriticalC: 75,
 				},
 				CheckVendor: true, CheckLinkErrors: true,
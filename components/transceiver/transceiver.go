@@ -28,6 +28,7 @@ import (
 	"github.com/scitix/sichek/components/transceiver/config"
 	trmetrics "github.com/scitix/sichek/components/transceiver/metrics"
 	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/printer"
 	"github.com/scitix/sichek/pkg/utils"
 
 	"github.com/sirupsen/logrus"
@@ -41,6 +42,7 @@ type component struct {
 	cfgMutex      sync.Mutex
 	collector     *collector.TransceiverCollector
 	checkers      []common.Checker
+	specMtx       sync.RWMutex
 	metrics       *trmetrics.TransceiverMetrics
 
 	cacheMtx    sync.RWMutex
@@ -91,27 +93,16 @@ func newComponent(cfgFile string, specFile string, ignoredCheckers []string) (co
 		cfg.Transceiver.IgnoredCheckers = ignoredCheckers
 	}
 
-	spec, err := config.LoadSpec(specFile)
-	if err != nil {
-		logrus.WithField("component", "transceiver").Warnf("failed to load spec %s: %v", specFile, err)
-	}
-
-	// Build classifier from spec: speed-based + pattern-based
-	patterns := make(map[string][]string)
-	managementMaxMbps := 0
-	if spec != nil {
-		for netName, netSpec := range spec.Networks {
-			patterns[netName] = netSpec.InterfacePatterns
-			if netName == "management" && netSpec.MaxSpeedMbps > 0 {
-				managementMaxMbps = netSpec.MaxSpeedMbps
-			}
-		}
+	spec, specErr := config.LoadSpec(specFile)
+	if specErr != nil {
+		logrus.WithField("component", "transceiver").Warnf("failed to load spec %s: %v", specFile, specErr)
 	}
-	classifier := collector.NewNetworkClassifier(patterns, managementMaxMbps)
 
-	collectorInst := collector.NewTransceiverCollector(classifier)
+	collectorInst := newCollector(spec)
 
-	checkers, err := checker.NewCheckers(cfg, spec)
+	checkers, err := common.CheckersOrSpecMissing(consts.ComponentNameTransceiver, spec, specErr, func(spec *config.TransceiverSpec) ([]common.Checker, error) {
+		return checker.NewCheckers(cfg, spec)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -145,7 +136,10 @@ func (c *component) Name() string {
 
 func (c *component) HealthCheck(ctx context.Context) (*common.Result, error) {
 	timer := common.NewTimer(fmt.Sprintf("%s-HealthCheck-Cost", c.componentName))
-	trInfo, err := c.collector.Collect(ctx)
+	c.specMtx.RLock()
+	collectorInst, checkers := c.collector, c.checkers
+	c.specMtx.RUnlock()
+	trInfo, err := common.Collect(ctx, c.componentName, collectorInst.Collect)
 	if err != nil {
 		logrus.WithField("component", "transceiver").Errorf("failed to collect transceiver info: %v", err)
 		return nil, err
@@ -156,7 +150,7 @@ func (c *component) HealthCheck(ctx context.Context) (*common.Result, error) {
 		c.metrics.ExportMetrics(trInfo)
 	}
 
-	result := common.Check(ctx, c.componentName, trInfo, c.checkers)
+	result := common.Check(ctx, c.componentName, trInfo, checkers)
 	timer.Mark("transceiver-check")
 
 	c.cacheMtx.Lock()
@@ -227,6 +221,49 @@ func (c *component) Update(cfg common.ComponentUserConfig) error {
 	return c.service.Update(cfg)
 }
 
+// newCollector builds the collector with the network classifier of spec:
+// speed-based + pattern-based.
+func newCollector(spec *config.TransceiverSpec) *collector.TransceiverCollector {
+	patterns := make(map[string][]string)
+	managementMaxMbps := 0
+	if spec != nil {
+		for netName, netSpec := range spec.Networks {
+			patterns[netName] = netSpec.InterfacePatterns
+			if netName == "management" && netSpec.MaxSpeedMbps > 0 {
+				managementMaxMbps = netSpec.MaxSpeedMbps
+			}
+		}
+	}
+	classifier := collector.NewNetworkClassifier(patterns, managementMaxMbps)
+	return collector.NewTransceiverCollector(classifier)
+}
+
+// UpdateSpec reloads the transceiver spec and rebuilds the collector, whose
+// network classifier comes from the spec, and the checkers.
+func (c *component) UpdateSpec(specFile string) error {
+	spec, err := config.LoadSpec(specFile)
+	if err != nil {
+		return fmt.Errorf("load transceiver spec %s: %w", specFile, err)
+	}
+	if spec == nil {
+		return fmt.Errorf("transceiver spec is nil after loading from %s", specFile)
+	}
+	c.cfgMutex.Lock()
+	cfg := c.cfg
+	c.cfgMutex.Unlock()
+	checkers, err := checker.NewCheckers(cfg, spec)
+	if err != nil {
+		return err
+	}
+	collectorInst := newCollector(spec)
+	c.specMtx.Lock()
+	c.collector = collectorInst
+	c.checkers = checkers
+	c.specMtx.Unlock()
+	logrus.WithField("component", "transceiver").Infof("reloaded spec from %s", specFile)
+	return nil
+}
+
 func (c *component) Status() bool {
 	return c.service.Status()
 }
@@ -245,18 +282,18 @@ func (c *component) PrintInfo(info common.Info, result *common.Result, summaryPr
 
 	trInfo, ok := info.(*collector.TransceiverInfo)
 	if !ok || trInfo == nil {
-		fmt.Println("No transceiver info available")
+		printer.Println("No transceiver info available")
 		return checkAllPassed
 	}
 
 	if len(trInfo.Modules) == 0 {
-		fmt.Println("No transceiver modules found")
+		printer.Println("No transceiver modules found")
 		return checkAllPassed
 	}
 
-	fmt.Printf("%-16s %-12s %-12s %-20s %-12s %-8s %-10s %-24s %-24s\n",
+	printer.Printf("%-16s %-12s %-12s %-20s %-12s %-8s %-10s %-24s %-24s\n",
 		"Interface", "NetworkType", "Vendor", "PartNumber", "LinkSpeed", "Temp(C)", "Volt(V)", "TxPower(dBm)", "RxPower(dBm)")
-	fmt.Printf("%-16s %-12s %-12s %-20s %-12s %-8s %-10s %-24s %-24s\n",
+	printer.Printf("%-16s %-12s %-12s %-20s %-12s %-8s %-10s %-24s %-24s\n",
 		"----------------", "------------", "------------", "--------------------", "------------", "--------", "----------", "------------------------", "------------------------")
 	for _, mod := range trInfo.Modules {
 		speed := mod.LinkSpeed
@@ -269,7 +306,7 @@ func (c *component) PrintInfo(info common.Info, result *common.Result, summaryPr
 		if mod.Voltage > 0 {
 			voltStr = fmt.Sprintf("%.3f", mod.Voltage)
 		}
-		fmt.Printf("%-16s %-12s %-12s %-20s %-12s %-8.1f %-10s %-24s %-24s\n",
+		printer.Printf("%-16s %-12s %-12s %-20s %-12s %-8.1f %-10s %-24s %-24s\n",
 			mod.Interface, mod.NetworkType, mod.Vendor, mod.PartNumber, speed, mod.Temperature, voltStr, txStr, rxStr)
 	}
 
@@ -278,20 +315,20 @@ func (c *component) PrintInfo(info common.Info, result *common.Result, summaryPr
 		for _, res := range result.Checkers {
 			if res.Status != consts.StatusNormal && res.Level != consts.LevelInfo {
 				if !hasErrors {
-					fmt.Printf("\nErrors Events:\n")
+					printer.Printf("\nErrors Events:\n")
 					hasErrors = true
 				}
-				fmt.Printf("\tEvent: %s%s%s -> %s\n", consts.LevelColor(res.Level), res.ErrorName, consts.Reset, res.Detail)
+				printer.Printf("\tEvent: %s%s%s -> %s\n", consts.LevelColor(res.Level), res.ErrorName, consts.Reset, res.Detail)
 			}
 		}
 		if !hasErrors {
-			fmt.Printf("\nErrors Events:\n\tNo Transceiver Events Detected\n")
+			printer.Printf("\nErrors Events:\n\tNo Transceiver Events Detected\n")
 		}
 	} else {
-		fmt.Printf("\nErrors Events:\n\tNo Transceiver Events Detected\n")
+		printer.Printf("\nErrors Events:\n\tNo Transceiver Events Detected\n")
 	}
 
-	fmt.Println()
+	printer.Println()
 	return checkAllPassed
 }
 
@@ -47,6 +47,20 @@ const (
 	ComponentNameTransceiver  = "transceiver"
 	ComponentIDLLDP           = "17"
 	ComponentNameLLDP         = "lldp"
+	ComponentIDAmd            = "18"
+	ComponentNameAmd          = "amd"
+	ComponentIDBMC            = "19"
+	ComponentNameBMC          = "bmc"
+	ComponentIDStorage        = "20"
+	ComponentNameStorage      = "storage"
+	ComponentIDPcieTopo       = "21"
+	ComponentNamePcieTopo     = "pcie_topo"
+	ComponentIDInventory      = "22"
+	ComponentNameInventory    = "inventory"
+	ComponentIDNCCLEnv        = "23"
+	ComponentNameNCCLEnv      = "nccl_env"
+	ComponentIDContainerRuntime   = "24"
+	ComponentNameContainerRuntime = "container_runtime"
 
 	/*----------------------checker id------------------------*/
 	CheckerIDInfinibandFW            = "4001"
@@ -98,6 +112,8 @@ var (
 	DefaultComponents = []string{
 		ComponentNameCPU, ComponentNameNvidia, ComponentNameInfiniband, ComponentNameEthernet, ComponentNameGpfs, ComponentNameDmesg,
 		ComponentNamePodlog, ComponentNameGpuEvents, ComponentNameSyslog, ComponentNameTransceiver, ComponentNameLLDP,
+		ComponentNameAmd, ComponentNamePCIE, ComponentNameBMC, ComponentNameStorage, ComponentNamePcieTopo,
+		ComponentNameInventory, ComponentNameNCCLEnv, ComponentNameContainerRuntime,
 	}
 )
 
@@ -111,6 +127,10 @@ const (
 	/*----------------------component status----------------------*/
 	StatusNormal   = "normal"
 	StatusAbnormal = "abnormal"
+	// StatusSilenced is an abnormal checker matched by a silence, it does not count as a failure
+	StatusSilenced = "silenced"
+	// StatusSkipped is a checker not run because sichek lacks the privileges it needs, e.g. when running rootless
+	StatusSkipped = "skipped"
 )
 
 // priority map
@@ -130,6 +150,11 @@ const (
 	DefaultProductionPath    = "/var/sichek"
 	DefaultProductionCfgPath = "/var/sichek/config"
 	DefaultSnapshotPath      = "/var/sichek/data/snapshot.json"
+	DefaultHistoryPath       = "/var/sichek/history"
+	DefaultSilencePath       = "/var/sichek/data/silences.json"
+	DefaultStatePath         = "/var/sichek/state"
+	DefaultPerfHistoryPath   = "/var/sichek/data/perf-history.jsonl"
+	DefaultLogDir            = "/var/log/sichek"
 
 	// OSS Spec URLs
 	DomesticSpecURL = "https://oss-cn-shanghai-2.siflow.cn/hisys:hisys-sichek-sh/specs"
@@ -162,5 +187,9 @@ const PadLen = len(Green) + len(Reset)
 const CmdTimeout = 30 * time.Second
 const IbPerfTestTimeout = 600 * time.Second
 const AllCmdTimeout = 60 * time.Second
+const DaemonStopTimeout = 30 * time.Second        // Graceful shutdown bound of the daemon components
+const ComponentInitTimeout = 60 * time.Second     // Creation bound of each component, e.g. of a hung NVML init
+const ComponentInitParallel = 4                   // Components created at the same time
+const AllCmdParallel = 8                          // Components created and checked at the same time by sichek all
 const DefaultCacheLine int64 = 10000              // Default cache line number for event filter
 const DefaultFileLoaderInterval = 5 * time.Second // Default interval for file loader scheduler
@@ -0,0 +1,833 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package errdef
+
+import "github.com/scitix/sichek/consts"
+
+// catalog lists the error names of the checkers. The codes are stable: never
+// renumber or reuse one, append new entries at the end of their category and
+// keep the entry of a removed error name.
+var catalog = []ErrorDef{
+	// GPU, nvidia
+	def("GPU-0001", "GPULost", consts.ComponentNameNvidia, consts.LevelFatal,
+		"A GPU fell off the bus or does not answer NVML",
+		"Cold reset the node, replace the GPU if it is lost again"),
+	def("GPU-0002", "xid79-GPULost", consts.ComponentNameNvidia, consts.LevelFatal,
+		"Xid 79, the GPU fell off the PCIe bus",
+		"Cold reset the node, replace the GPU if it is lost again"),
+	def("GPU-0003", "NvlinkNotActive", consts.ComponentNameNvidia, consts.LevelFatal,
+		"NVLinks of a GPU are inactive",
+		"Reboot the node, check the NVLink bridges or baseboard if the links stay down"),
+	def("GPU-0004", "ClockThrottleEvent", consts.ComponentNameNvidia, consts.LevelCritical,
+		"A GPU throttles its clocks for a hardware reason, e.g. HW slowdown or power brake",
+		"Diagnose the GPU with dcgmi diag and check its cooling and power"),
+	def("GPU-0005", "RemmapedRowsPending", consts.ComponentNameNvidia, consts.LevelCritical,
+		"GPU memory rows wait for a reset to be remapped",
+		"Reset the GPU to apply the pending row remappings"),
+	def("GPU-0006", "RemmapedRowsFailure", consts.ComponentNameNvidia, consts.LevelCritical,
+		"Remapping a row of the GPU memory failed",
+		"Replace the GPU"),
+	def("GPU-0007", "HighSRAMAggregateUncorrectableErrors", consts.ComponentNameNvidia, consts.LevelCritical,
+		"The aggregate count of uncorrectable SRAM errors of a GPU is high",
+		"Replace the GPU"),
+	def("GPU-0008", "SRAMVolatileUncorrectableErrors", consts.ComponentNameNvidia, consts.LevelWarning,
+		"A GPU had uncorrectable SRAM errors since its last reset",
+		"Reset the GPU"),
+	def("GPU-0009", "HighSRAMCorrectableErrors", consts.ComponentNameNvidia, consts.LevelWarning,
+		"The correctable SRAM errors of a GPU are high",
+		"Diagnose the GPU with dcgmi diag and plan its replacement"),
+	def("GPU-0010", "HighRemmapedRowsUncorrectableErrors", consts.ComponentNameNvidia, consts.LevelWarning,
+		"A GPU remapped many rows for uncorrectable errors",
+		"Diagnose the GPU with dcgmi diag and plan its replacement"),
+	def("GPU-0011", "xid31-GPUMemoryPageFault", consts.ComponentNameNvidia, consts.LevelCritical,
+		"Xid 31, a GPU memory page fault",
+		"Reset the GPU and check the application for illegal memory accesses"),
+	def("GPU-0012", "xid48-GPUMemoryDBE", consts.ComponentNameNvidia, consts.LevelCritical,
+		"Xid 48, a double bit ECC error in the GPU memory",
+		"Reset the GPU"),
+	def("GPU-0013", "xid63-ECCRowremapperPending", consts.ComponentNameNvidia, consts.LevelCritical,
+		"Xid 63, a row remapping is pending",
+		"Reset the GPU to apply the row remapping"),
+	def("GPU-0014", "xid64-ECCRowremapperFailure", consts.ComponentNameNvidia, consts.LevelCritical,
+		"Xid 64, the row remapper failed",
+		"Reset the GPU, replace it if the failure repeats"),
+	def("GPU-0015", "xid74-NVLinkError", consts.ComponentNameNvidia, consts.LevelCritical,
+		"Xid 74, an NVLink error",
+		"Reset the GPU and check the NVLink connections"),
+	def("GPU-0016", "xid92-HighSingleBitECCErrorRate", consts.ComponentNameNvidia, consts.LevelCritical,
+		"Xid 92, a high single bit ECC error rate",
+		"Replace the GPU"),
+	def("GPU-0017", "xid94-ContainedECCError", consts.ComponentNameNvidia, consts.LevelCritical,
+		"Xid 94, a contained ECC error, the application using the memory was stopped",
+		"Reset the GPU"),
+	def("GPU-0018", "xid95-UncontainedECCError", consts.ComponentNameNvidia, consts.LevelCritical,
+		"Xid 95, an uncontained ECC error, every application on the GPU is affected",
+		"Reset the GPU, replace it if the error repeats"),
+	def("GPU-0019", "NvidiaDriverVersionMismatch", consts.ComponentNameNvidia, consts.LevelCritical,
+		"The loaded NVIDIA kernel module, the module installed for the running kernel and the userspace driver differ",
+		"Reboot the node to load the installed driver, or rebuild its DKMS module"),
+	def("GPU-0020", "NvidiaFabricManagerNotActive", consts.ComponentNameNvidia, consts.LevelCritical,
+		"nvidia-fabricmanager is not running on an NVSwitch system",
+		"Restart nvidia-fabricmanager, or run with --auto-fix"),
+	def("GPU-0021", "NvidiaPeerMemNotLoaded", consts.ComponentNameNvidia, consts.LevelCritical,
+		"The nvidia_peermem module is not loaded, GPUDirect RDMA is unavailable",
+		"Load it with modprobe nvidia_peermem, or run with --auto-fix"),
+	def("GPU-0022", "IBGDANotEnabled", consts.ComponentNameNvidia, consts.LevelCritical,
+		"The NVIDIA driver options for IBGDA are not set",
+		"Set NVreg_RegistryDwords=\"EnableStreamMemOPs=1;PeerMappingOverride=1\" in /etc/modprobe.d/nvidia.conf and reload the driver"),
+	def("GPU-0023", "NVSwitchFabricDegraded", consts.ComponentNameNvidia, consts.LevelCritical,
+		"The NVSwitch fabric of a GPU is not registered or degraded",
+		"Check the fabric state with nvidia-smi -q and the fabricmanager log, restart nvidia-fabricmanager or cold reset the node"),
+	def("GPU-0024", "MIGGeometryMismatch", consts.ComponentNameNvidia, consts.LevelCritical,
+		"The MIG mode or instances of a GPU differ from the spec",
+		"Reconfigure MIG with nvidia-smi mig or the MIG manager"),
+	def("GPU-0025", "NVMLInitFailed", consts.ComponentNameNvidia, consts.LevelCritical,
+		"NVML failed to initialize, the GPUs are not checked",
+		"Check the NVIDIA driver with nvidia-smi and the dmesg"),
+	def("GPU-0026", "P2PNotSupported", consts.ComponentNameNvidia, consts.LevelWarning,
+		"Peer to peer access between GPUs is not supported",
+		"Check the NVLink connections and the PCIe ACS settings"),
+	def("GPU-0027", "HighTemperature", consts.ComponentNameNvidia, consts.LevelWarning,
+		"A GPU runs above its temperature threshold",
+		"Check the cooling of the node and the performance of the application"),
+	def("GPU-0028", "GPUStateNotMaxPerformance", consts.ComponentNameNvidia, consts.LevelWarning,
+		"A GPU is not in the P0 performance state under load",
+		"Reset the GPU"),
+	def("GPU-0029", "GPUPersistencedModeNotEnabled", consts.ComponentNameNvidia, consts.LevelWarning,
+		"The persistence mode of a GPU is disabled",
+		"Run nvidia-persistenced, or run with --auto-fix"),
+	def("GPU-0030", "AppClocksNotMax", consts.ComponentNameNvidia, consts.LevelWarning,
+		"The application clocks of a GPU are not set to the maximum",
+		"Reset them with nvidia-smi -rac"),
+	def("GPU-0031", "SoftwareVersionIncorrect", consts.ComponentNameNvidia, consts.LevelWarning,
+		"The NVIDIA driver or CUDA version differs from the spec",
+		"Update the software to the version in the spec"),
+	def("GPU-0032", "GPULikelyToFail", consts.ComponentNameNvidia, consts.LevelWarning,
+		"The error counters of a GPU predict a failure",
+		"Drain the node, run dcgmi diag -r 3 and plan the RMA of the GPU"),
+	def("GPU-0033", "GPURogueProcess", consts.ComponentNameNvidia, consts.LevelWarning,
+		"Processes outside any pod, leaked or zombie, hold a GPU",
+		"Kill the processes listed in the detail"),
+
+	// GPU, amd
+	def("GPU-0034", "XGMILinkDown", consts.ComponentNameAmd, consts.LevelCritical,
+		"An XGMI link between AMD GPUs is down",
+		"Check the links with rocm-smi --showtopotype, reboot the node or replace the baseboard"),
+	def("GPU-0035", "GPUECCUncorrectable", consts.ComponentNameAmd, consts.LevelCritical,
+		"An AMD GPU had uncorrectable ECC errors",
+		"Drain the node and reset the GPU, replace it if the errors persist"),
+	def("GPU-0036", "GPUOverheat", consts.ComponentNameAmd, consts.LevelWarning,
+		"An AMD GPU runs above its temperature threshold",
+		"Check the cooling and airflow of the node"),
+	def("GPU-0037", "GPUECCHighCorrectable", consts.ComponentNameAmd, consts.LevelWarning,
+		"The correctable ECC errors of an AMD GPU are high",
+		"Monitor the GPU memory and plan the GPU replacement"),
+	def("GPU-0038", "AmdDriverVersionMismatch", consts.ComponentNameAmd, consts.LevelWarning,
+		"The amdgpu driver version differs from the spec",
+		"Upgrade the amdgpu driver to the version in the spec"),
+
+	// GPU, gpuevents
+	def("GPU-0039", "GPUHang", consts.ComponentNameGpuEvents, consts.LevelFatal,
+		"A GPU is busy but makes no progress, its power and memory activity stay low",
+		"Stop the job and resubmit it, reset the GPU if the hang repeats"),
+	def("GPU-0040", "SmClkStuckLow", consts.ComponentNameGpuEvents, consts.LevelFatal,
+		"The SM clock of a busy GPU is stuck low",
+		"Stop the job and reset the GPU"),
+
+	// GPU, dmesg
+	def("GPU-0041", "NvErrObjectNotFound", consts.ComponentNameDmesg, consts.LevelCritical,
+		"The NVIDIA driver logged an object not found error",
+		"Reset the GPU and check the dmesg for xids"),
+	def("GPU-0042", "NvErrResetRequired", consts.ComponentNameDmesg, consts.LevelCritical,
+		"The NVIDIA driver requires a GPU reset",
+		"Reset the GPU"),
+	def("GPU-0043", "NVSXID", consts.ComponentNameDmesg, consts.LevelInfo,
+		"The kernel log has an NVSwitch SXid",
+		"Check the SXid in the NVSwitch documentation and the fabricmanager log"),
+
+	// GPU, syslog
+	def("GPU-0044", "NVLSError", consts.ComponentNameSyslog, consts.LevelCritical,
+		"The fabricmanager log reports an NVLink SHARP error",
+		"Check the NVLS state in the fabricmanager log and restart nvidia-fabricmanager"),
+
+	// GPU, nvidia
+	def("GPU-0045", "GPUDevicePluginMismatch", consts.ComponentNameNvidia, consts.LevelCritical,
+		"The GPUs the device plugin advertises to Kubernetes differ from the ones accessible via NVML",
+		"Drain the node if a GPU fell off the bus, otherwise restart the NVIDIA device plugin"),
+
+	// network, infiniband
+	def("NET-0001", "IBLost", consts.ComponentNameInfiniband, consts.LevelCritical,
+		"An InfiniBand device disappeared",
+		"Check the HCA with ibstat and lspci, reset or reseat it"),
+	def("NET-0002", "IBDeviceCountMismatch", consts.ComponentNameInfiniband, consts.LevelCritical,
+		"The number of InfiniBand devices differs from the spec",
+		"Check the PCIe status and the connectivity of the HCAs"),
+	def("NET-0003", "IBStateNotActive", consts.ComponentNameInfiniband, consts.LevelCritical,
+		"An InfiniBand port is not ACTIVE",
+		"Check the subnet manager and the connection of the port"),
+	def("NET-0004", "IBPhyStateNotLinkUp", consts.ComponentNameInfiniband, consts.LevelCritical,
+		"The physical state of an InfiniBand port is not LinkUp",
+		"Check the cable and the switch port"),
+	def("NET-0005", "IBPortSpeedNotMax", consts.ComponentNameInfiniband, consts.LevelCritical,
+		"An InfiniBand port runs below its maximum speed",
+		"Check the cable and the speed settings of the firmware and the switch"),
+	def("NET-0006", "IBNetOperStateNotUP", consts.ComponentNameInfiniband, consts.LevelCritical,
+		"The netdev of an InfiniBand port is not up",
+		"Check the network interface and the driver"),
+	def("NET-0007", "IBKernelModulesNotAllInstalled", consts.ComponentNameInfiniband, consts.LevelCritical,
+		"InfiniBand kernel modules are missing",
+		"Install or reload the missing kernel modules"),
+	def("NET-0008", "IBLinkFlapping", consts.ComponentNameInfiniband, consts.LevelCritical,
+		"An InfiniBand link went down and up repeatedly",
+		"Check the cable, transceiver and switch port, replace the cable or transceiver if it keeps flapping"),
+	def("NET-0009", "IBSubnetManagerMissing", consts.ComponentNameInfiniband, consts.LevelCritical,
+		"No subnet manager answers on an InfiniBand port",
+		"Check that opensm or UFM runs and reaches the switch of the port"),
+	def("NET-0010", "IBVFError", consts.ComponentNameInfiniband, consts.LevelCritical,
+		"A virtual function of an HCA is in error",
+		"Drain the pods using the VF, recreate the VFs or reset the HCA"),
+	def("NET-0011", "IBVFNumMismatch", consts.ComponentNameInfiniband, consts.LevelCritical,
+		"The number of virtual functions of an HCA differs from the spec",
+		"Recreate the VFs with sriov_numvfs and check the SR-IOV device plugin"),
+	def("NET-0012", "IBVFGUIDMissing", consts.ComponentNameInfiniband, consts.LevelCritical,
+		"A virtual function has no node or port GUID",
+		"Assign the GUIDs with ip link set <pf> vf <n> node_guid <guid> port_guid <guid>"),
+	def("NET-0013", "IBVFLinkStateMismatch", consts.ComponentNameInfiniband, consts.LevelCritical,
+		"The link state of a virtual function does not follow its PF",
+		"Set ip link set <pf> vf <n> state auto"),
+	def("NET-0014", "RoCEGIDMissing", consts.ComponentNameInfiniband, consts.LevelCritical,
+		"A RoCE port has no RoCE v2 GID with an IP address",
+		"Check the IP address of the netdev and the default GID type with cma_roce_mode"),
+	def("NET-0015", "RDMAPeerUnreachable", consts.ComponentNameInfiniband, consts.LevelCritical,
+		"The RDMA probes to the peers or the gateway fail",
+		"Check the routes, the policy routing rules and the switch port of the interface"),
+	def("NET-0016", "IBCounterRateExceeded", consts.ComponentNameInfiniband, consts.LevelWarning,
+		"The error counters of an InfiniBand port grow faster than the threshold",
+		"Check the cable and transceiver of the port, reseat or replace them"),
+	def("NET-0017", "IBFabricCongestion", consts.ComponentNameInfiniband, consts.LevelWarning,
+		"The xmit wait of InfiniBand ports shows congestion",
+		"Check the traffic pattern of the jobs and the congestion control of the fabric"),
+	def("NET-0018", "IBPacketLoss", consts.ComponentNameInfiniband, consts.LevelWarning,
+		"InfiniBand ports discard packets",
+		"Check the congestion control and the switch ports of the fabric"),
+	def("NET-0019", "IBSubnetManagerFailover", consts.ComponentNameInfiniband, consts.LevelWarning,
+		"The master subnet manager of the fabric changed",
+		"Check why the previous master failed over in its logs"),
+	def("NET-0020", "RoCENotEnabled", consts.ComponentNameInfiniband, consts.LevelWarning,
+		"RoCE is disabled on an Ethernet HCA",
+		"Enable RoCE in the device configuration"),
+	def("NET-0021", "IBPortMTUMismatch", consts.ComponentNameInfiniband, consts.LevelWarning,
+		"The MTU of a port differs from the spec",
+		"Set the MTU of the netdev and persist it in the network config"),
+	def("NET-0022", "IBFirmwareVersionMismatch", consts.ComponentNameInfiniband, consts.LevelWarning,
+		"The firmware of an HCA differs from the spec",
+		"Update the firmware to the version in the spec"),
+	def("NET-0023", "IBFirmwareInconsistent", consts.ComponentNameInfiniband, consts.LevelWarning,
+		"The HCAs of the node run different firmware versions",
+		"Update the firmware with mlxfwmanager and reset the HCAs with mlxfwreset"),
+	def("NET-0024", "OFEDVersionMismatch", consts.ComponentNameInfiniband, consts.LevelWarning,
+		"The OFED version differs from the spec",
+		"Upgrade or reinstall OFED to the version in the spec"),
+	def("NET-0025", "IBDeviceNameMismatch", consts.ComponentNameInfiniband, consts.LevelWarning,
+		"The InfiniBand device names differ from the spec",
+		"Check the udev naming rules"),
+
+	// network, dmesg
+	def("NET-0026", "IBHCAError", consts.ComponentNameDmesg, consts.LevelCritical,
+		"The kernel log has a fatal HCA error",
+		"Reset the HCA and check its firmware"),
+
+	// network, ethernet
+	def("NET-0027", "NoBondInterface", consts.ComponentNameEthernet, consts.LevelCritical,
+		"No bond interface is configured",
+		"Configure the bond in /etc/netplan or /etc/sysconfig/network-scripts"),
+	def("NET-0028", "LinkDown", consts.ComponentNameEthernet, consts.LevelCritical,
+		"A bond slave has no link",
+		"Check the cable, the switch port and the driver of the slave"),
+	def("NET-0029", "TxTimeout", consts.ComponentNameEthernet, consts.LevelCritical,
+		"The transmit queue of a NIC timed out",
+		"Check the dmesg for the NIC errors and the driver version"),
+	def("NET-0030", "SpeedMismatch", consts.ComponentNameEthernet, consts.LevelWarning,
+		"A bond slave runs below the speed of the spec",
+		"Check the cable and the switch port configuration"),
+	def("NET-0031", "CRCErrorsGrowing", consts.ComponentNameEthernet, consts.LevelWarning,
+		"The CRC errors of a NIC grow",
+		"Check the cable and the transceiver of the NIC"),
+	def("NET-0032", "CarrierErrorsGrowing", consts.ComponentNameEthernet, consts.LevelWarning,
+		"The carrier errors of a NIC grow",
+		"Check the cable and the switch port of the NIC"),
+	def("NET-0033", "DropsGrowing", consts.ComponentNameEthernet, consts.LevelWarning,
+		"The dropped packets of a NIC grow",
+		"Check the ring buffers of the NIC and the load of the node"),
+	def("NET-0034", "BondingMissing", consts.ComponentNameEthernet, consts.LevelCritical,
+		"The bonding module or the bond of the spec is missing",
+		"Load the bonding module and configure the bond"),
+	def("NET-0035", "BondDown", consts.ComponentNameEthernet, consts.LevelCritical,
+		"A bond interface is down",
+		"Check the slaves of the bond and their switch ports"),
+	def("NET-0036", "MTUMismatch", consts.ComponentNameEthernet, consts.LevelWarning,
+		"The MTU of a bond differs from the spec",
+		"Set the MTU of the bond in the network config"),
+	def("NET-0037", "XmitHashPolicyMismatch", consts.ComponentNameEthernet, consts.LevelWarning,
+		"The transmit hash policy of a bond differs from the spec",
+		"Set xmit_hash_policy in the bond options"),
+	def("NET-0038", "SlaveCountMismatch", consts.ComponentNameEthernet, consts.LevelWarning,
+		"A bond has fewer slaves than the spec",
+		"Check the missing slaves and the bond config"),
+	def("NET-0039", "MiimonDisabled", consts.ComponentNameEthernet, consts.LevelCritical,
+		"MII link monitoring of a bond is disabled",
+		"Set miimon in the bond options"),
+	def("NET-0040", "MiimonMismatch", consts.ComponentNameEthernet, consts.LevelWarning,
+		"The miimon interval of a bond differs from the spec",
+		"Set miimon in the bond options"),
+	def("NET-0041", "DowndelayTooSmall", consts.ComponentNameEthernet, consts.LevelWarning,
+		"The downdelay of a bond is smaller than its miimon",
+		"Set downdelay to a multiple of miimon"),
+	def("NET-0042", "DowndelayMismatch", consts.ComponentNameEthernet, consts.LevelWarning,
+		"The downdelay of a bond differs from the spec",
+		"Set downdelay in the bond options"),
+	def("NET-0043", "UpdelayZero", consts.ComponentNameEthernet, consts.LevelWarning,
+		"The updelay of a bond is zero, a flapping slave joins at once",
+		"Set updelay in the bond options"),
+	def("NET-0044", "UpdelayMismatch", consts.ComponentNameEthernet, consts.LevelWarning,
+		"The updelay of a bond differs from the spec",
+		"Set updelay in the bond options"),
+	def("NET-0045", "ActiveSlaveFlapping", consts.ComponentNameEthernet, consts.LevelWarning,
+		"The active slave of a bond changes repeatedly",
+		"Check the links of the slaves and their switch ports"),
+	def("NET-0046", "LinkFailureGrowing", consts.ComponentNameEthernet, consts.LevelWarning,
+		"The link failures of a bond slave grow",
+		"Check the cable and the switch port of the slave"),
+	def("NET-0047", "ActiveAggregatorMissing", consts.ComponentNameEthernet, consts.LevelCritical,
+		"An LACP bond has no active aggregator",
+		"Check the LACP configuration of the switch ports"),
+	def("NET-0048", "PartnerMacInvalid", consts.ComponentNameEthernet, consts.LevelCritical,
+		"The LACP partner of a bond has no valid MAC",
+		"Check that LACP is enabled on the switch ports"),
+	def("NET-0049", "AggregatorMismatch", consts.ComponentNameEthernet, consts.LevelCritical,
+		"The slaves of an LACP bond are in different aggregators",
+		"Check that the switch ports are in the same port channel"),
+	def("NET-0050", "ActorKeyMismatch", consts.ComponentNameEthernet, consts.LevelWarning,
+		"The slaves of an LACP bond have different actor keys",
+		"Check that the slaves have the same speed and duplex"),
+	def("NET-0051", "PartnerKeyMismatch", consts.ComponentNameEthernet, consts.LevelWarning,
+		"The slaves of an LACP bond have different partner keys",
+		"Check that the switch ports are in the same port channel"),
+	def("NET-0052", "LACPRateMismatch", consts.ComponentNameEthernet, consts.LevelWarning,
+		"The LACP rate of a bond differs from the spec",
+		"Set lacp_rate in the bond options"),
+	def("NET-0053", "ARPFailed", consts.ComponentNameEthernet, consts.LevelWarning,
+		"The gateway does not answer ARP",
+		"Check the VLAN and the switch configuration of the bond"),
+	def("NET-0054", "GatewayUnreachable", consts.ComponentNameEthernet, consts.LevelCritical,
+		"The gateway does not answer ping",
+		"Check the routes and the switch configuration of the bond"),
+	def("NET-0055", "DirectRouteMismatch", consts.ComponentNameEthernet, consts.LevelWarning,
+		"The direct routes differ from the addresses of the interfaces",
+		"Check the routes of the network config"),
+	def("NET-0056", "RPFilterEnabled", consts.ComponentNameEthernet, consts.LevelWarning,
+		"Reverse path filtering is enabled, asymmetric routes drop packets",
+		"Set net.ipv4.conf.*.rp_filter to 0 or 2"),
+	def("NET-0057", "NoRoCEInterface", consts.ComponentNameEthernet, consts.LevelCritical,
+		"No NIC has an Ethernet link layer for RoCE",
+		"Check the HCAs and their link type"),
+	def("NET-0058", "RoCELinkDown", consts.ComponentNameEthernet, consts.LevelCritical,
+		"A RoCE NIC has no link",
+		"Check the cable and the switch port of the NIC"),
+	def("NET-0059", "RoCESpeedMismatch", consts.ComponentNameEthernet, consts.LevelWarning,
+		"A RoCE NIC runs below the speed of the spec",
+		"Check the cable and the switch port configuration"),
+	def("NET-0060", "RoCEMTUMismatch", consts.ComponentNameEthernet, consts.LevelWarning,
+		"The MTU of a RoCE NIC differs from the spec",
+		"Set the MTU of the netdev in the network config"),
+	def("NET-0061", "PFCMisconfigured", consts.ComponentNameEthernet, consts.LevelCritical,
+		"PFC is not enabled on the RoCE priority",
+		"Enable PFC on the priority with mlnx_qos"),
+	def("NET-0062", "ECNDisabled", consts.ComponentNameEthernet, consts.LevelWarning,
+		"ECN is disabled on a RoCE NIC",
+		"Enable ECN on the RoCE priority"),
+	def("NET-0063", "DCQCNDisabled", consts.ComponentNameEthernet, consts.LevelWarning,
+		"DCQCN congestion control is disabled on a RoCE NIC",
+		"Enable DCQCN in the NIC configuration"),
+	def("NET-0064", "RoCEDriverMismatch", consts.ComponentNameEthernet, consts.LevelWarning,
+		"The driver of a RoCE NIC differs from the spec",
+		"Install the driver of the spec"),
+	def("NET-0065", "RoCEDriverVersionMismatch", consts.ComponentNameEthernet, consts.LevelWarning,
+		"The driver version of a RoCE NIC differs from the spec",
+		"Update the driver to the version in the spec"),
+	def("NET-0066", "RoCEFirmwareMismatch", consts.ComponentNameEthernet, consts.LevelWarning,
+		"The firmware of a RoCE NIC differs from the spec",
+		"Update the firmware to the version in the spec"),
+
+	// network, transceiver
+	def("NET-0067", "TransceiverMissing", consts.ComponentNameTransceiver, consts.LevelWarning,
+		"A port has no transceiver",
+		"Reseat or replace the transceiver"),
+	def("NET-0068", "TransceiverOverheat", consts.ComponentNameTransceiver, consts.LevelWarning,
+		"A transceiver runs above its temperature threshold",
+		"Check the airflow and replace the transceiver if it keeps overheating"),
+	def("NET-0069", "RxPowerOutOfRange", consts.ComponentNameTransceiver, consts.LevelWarning,
+		"The receive power of a transceiver is out of range",
+		"Clean the fiber connectors and check the transceiver at the remote end"),
+	def("NET-0070", "TxPowerOutOfRange", consts.ComponentNameTransceiver, consts.LevelWarning,
+		"The transmit power of a transceiver is out of range",
+		"Clean the fiber connectors or replace the transceiver"),
+	def("NET-0071", "BiasCurrentAbnormal", consts.ComponentNameTransceiver, consts.LevelWarning,
+		"The laser bias current of a transceiver is out of range, the laser may be failing",
+		"Replace the transceiver"),
+	def("NET-0072", "VoltageOutOfRange", consts.ComponentNameTransceiver, consts.LevelWarning,
+		"The supply voltage of a transceiver is out of range",
+		"Reseat the transceiver, replace it if the issue persists"),
+	def("NET-0073", "LinkErrorsIncreased", consts.ComponentNameTransceiver, consts.LevelWarning,
+		"The link errors of a port grow",
+		"Clean the connectors, replace the transceiver or cable"),
+	def("NET-0074", "VendorNotApproved", consts.ComponentNameTransceiver, consts.LevelWarning,
+		"A transceiver is not from an approved vendor",
+		"Replace it with a transceiver of an approved vendor"),
+
+	// network, ncclenv
+	def("NET-0075", "NCCLIBHCAMismatch", consts.ComponentNameNCCLEnv, consts.LevelCritical,
+		"NCCL_IB_HCA lists HCAs missing or down on the node",
+		"Set NCCL_IB_HCA to the HCAs listed by ibv_devices"),
+	def("NET-0076", "RoCEGIDMisconfigured", consts.ComponentNameNCCLEnv, consts.LevelCritical,
+		"NCCL_IB_GID_INDEX does not point to a RoCE v2 GID with an IP address",
+		"Set NCCL_IB_GID_INDEX to a RoCE v2 GID listed by show_gids"),
+	def("NET-0077", "RoCEPFCDisabled", consts.ComponentNameNCCLEnv, consts.LevelWarning,
+		"PFC is disabled on the RoCE priority used by NCCL",
+		"Enable PFC on the priority with mlnx_qos"),
+	def("NET-0078", "GPUDirectRDMAUnavailable", consts.ComponentNameNCCLEnv, consts.LevelWarning,
+		"GPUDirect RDMA is unavailable, NCCL copies through the host memory",
+		"Load nvidia_peermem or use dma-buf with the open NVIDIA kernel module"),
+	def("NET-0079", "RDMAMTUTooSmall", consts.ComponentNameNCCLEnv, consts.LevelWarning,
+		"The RoCE MTU is smaller than 4096",
+		"Raise the MTU of the netdev and the switch ports"),
+	def("NET-0080", "IBPhyDegraded", consts.ComponentNameInfiniband, consts.LevelWarning,
+		"The BER, eye grade or module of a port read with mlxlink exceeds the spec",
+		"Clean or reseat the cable and transceiver of the port, replace them if the BER stays high"),
+	def("NET-0081", "IBTemperatureHigh", consts.ComponentNameInfiniband, consts.LevelWarning,
+		"The ASIC of an HCA or the cable of a port exceeds the max temperature",
+		"Check the airflow and fans around the HCA, an overheating HCA throttles and flaps its links"),
+	def("NET-0082", "IBVFCounterRateExceeded", consts.ComponentNameInfiniband, consts.LevelWarning,
+		"The error counters of a VF given to a pod increase faster than the spec",
+		"Check the workload of the pod and the link of the PF, errors on a single VF point to the pod rather than the fabric"),
+
+	// storage, gpfs
+	def("STO-0001", "GPFSNotInstalled", consts.ComponentNameGpfs, consts.LevelCritical,
+		"GPFS is not installed",
+		"Install GPFS"),
+	def("STO-0002", "GPFSNotInCluster", consts.ComponentNameGpfs, consts.LevelCritical,
+		"The node is not in a GPFS cluster",
+		"Add the node to the GPFS cluster"),
+	def("STO-0003", "GPFSNotStarted", consts.ComponentNameGpfs, consts.LevelCritical,
+		"The GPFS daemon is not started",
+		"Start GPFS with mmstartup"),
+	def("STO-0004", "GPFSNotMounted", consts.ComponentNameGpfs, consts.LevelCritical,
+		"A GPFS filesystem is not mounted",
+		"Mount the filesystem with mmmount"),
+	def("STO-0005", "GPFSNodeNotHealthy", consts.ComponentNameGpfs, consts.LevelCritical,
+		"xstor-health reports the GPFS node unhealthy",
+		"Check mmhealth and the GPFS log"),
+	def("STO-0006", "GPFSRDMAError", consts.ComponentNameGpfs, consts.LevelCritical,
+		"GPFS does not use RDMA",
+		"Check the RDMA network of the node and the GPFS log"),
+	def("STO-0007", "GPFSMountHung", consts.ComponentNameGpfs, consts.LevelCritical,
+		"The probe I/O on a mount does not return in time",
+		"Check the filesystem client state and the processes in D state on the mount"),
+	def("STO-0008", "GPFSProbeIOError", consts.ComponentNameGpfs, consts.LevelCritical,
+		"The probe I/O on a mount fails",
+		"Check that the mount is present and writable and the filesystem client log"),
+	def("STO-0009", "GPFSProbeSlow", consts.ComponentNameGpfs, consts.LevelWarning,
+		"The p99 latency of the probe I/O exceeds the threshold",
+		"Check the load of the filesystem servers and the storage network"),
+	def("STO-0010", "GPFSLongWaiters", consts.ComponentNameGpfs, consts.LevelCritical,
+		"A thread of the GPFS daemon waits longer than the threshold",
+		"Check the waiter reasons with mmdiag --waiters"),
+	def("STO-0011", "GPFSFilesystemUnmounted", consts.ComponentNameGpfs, consts.LevelCritical,
+		"mmhealth reports a filesystem failed, unmounted or stale",
+		"Check mmhealth node show FILESYSTEM and remount the filesystem"),
+	def("STO-0012", "GPFSQuorumLost", consts.ComponentNameGpfs, consts.LevelCritical,
+		"mmhealth reports a quorum issue",
+		"Check the quorum nodes with mmgetstate -a and the network to them"),
+	def("STO-0013", "GPFSComponentDegraded", consts.ComponentNameGpfs, consts.LevelWarning,
+		"mmhealth reports a component degraded or failed",
+		"Check the events of the component with mmhealth node show"),
+	def("STO-0014", "QuorumConnectionDown", consts.ComponentNameGpfs, consts.LevelWarning,
+		"The GPFS log reports the quorum nodes unreachable",
+		"Check the GPFS daemon network"),
+	def("STO-0015", "GPFSUnmount", consts.ComponentNameGpfs, consts.LevelWarning,
+		"The GPFS log reports a filesystem unmounted",
+		"Check the GPFS state and remount the filesystem"),
+	def("STO-0016", "ExpelledFromGPFSCluster", consts.ComponentNameGpfs, consts.LevelWarning,
+		"The GPFS log reports the node expelled from the cluster",
+		"Check the GPFS daemon network and state"),
+	def("STO-0017", "GPFSUnauthorized", consts.ComponentNameGpfs, consts.LevelWarning,
+		"The GPFS log reports the remote cluster not authorized",
+		"Check the GPFS authorization of the remote cluster"),
+	def("STO-0018", "Bond0Lost", consts.ComponentNameGpfs, consts.LevelWarning,
+		"The GPFS log reports bond0 lost, the GPFS control network fails",
+		"Check the Ethernet network of the node"),
+	def("STO-0019", "RDMA_start_failed", consts.ComponentNameGpfs, consts.LevelWarning,
+		"The GPFS log reports RDMA failed to start",
+		"Check the RDMA network and devices"),
+	def("STO-0020", "RDMAStatusError", consts.ComponentNameGpfs, consts.LevelInfo,
+		"The GPFS log reports RDMA disabled or failing",
+		"Check the RDMA network and devices"),
+	def("STO-0021", "BadTcpState", consts.ComponentNameGpfs, consts.LevelInfo,
+		"The GPFS log reports a bad TCP state",
+		"Check the GPFS daemon network"),
+	def("STO-0022", "TimeClockError", consts.ComponentNameGpfs, consts.LevelInfo,
+		"The GPFS log reports the time of day jumped back",
+		"Synchronize the clock with NTP"),
+	def("STO-0023", "OSLockup", consts.ComponentNameGpfs, consts.LevelInfo,
+		"The log reports a soft or hard lockup, GPFS heartbeats may fail",
+		"Check the kernel and driver bugs behind the lockup"),
+	def("STO-0024", "incompatible_GPFS_version", consts.ComponentNameGpfs, consts.LevelInfo,
+		"The GPFS log reports an incompatible version",
+		"Update GPFS to a compatible version"),
+	def("STO-0025", "autoload_failed", consts.ComponentNameGpfs, consts.LevelInfo,
+		"The GPFS log reports the autoload failed",
+		"Check the GPFS daemon and start it with mmstartup"),
+
+	// storage, storage
+	def("STO-0026", "NVMeSmartCritical", consts.ComponentNameStorage, consts.LevelCritical,
+		"The SMART critical warning of an NVMe disk is set",
+		"Back up the data and replace the NVMe disk"),
+	def("STO-0027", "FilesystemReadOnly", consts.ComponentNameStorage, consts.LevelCritical,
+		"A local filesystem was remounted read-only",
+		"Drain the node, check the dmesg for I/O errors and run fsck"),
+	def("STO-0028", "NVMeOverTemperature", consts.ComponentNameStorage, consts.LevelWarning,
+		"An NVMe disk runs above its temperature threshold",
+		"Check the airflow of the disk bay"),
+	def("STO-0029", "NVMeWearOut", consts.ComponentNameStorage, consts.LevelWarning,
+		"An NVMe disk is close to its rated endurance",
+		"Plan the replacement of the disk"),
+	def("STO-0030", "FilesystemAlmostFull", consts.ComponentNameStorage, consts.LevelWarning,
+		"A local filesystem is almost full",
+		"Clean up the checkpoints, logs and images on the filesystem"),
+
+	// memory, memory
+	def("MEM-0001", "MemoryECCUncorrected", consts.ComponentNameMemory, consts.LevelCritical,
+		"EDAC reports uncorrectable memory errors",
+		"Identify the faulty DIMM and replace it"),
+	def("MEM-0002", "MemoryCapacityMismatch", consts.ComponentNameMemory, consts.LevelCritical,
+		"The memory capacity differs from the spec",
+		"Check for failed or missing DIMMs"),
+	def("MEM-0003", "MemoryECCCorrectedHigh", consts.ComponentNameMemory, consts.LevelWarning,
+		"The correctable memory errors exceed the threshold",
+		"Monitor the DIMMs and plan their replacement"),
+	def("MEM-0004", "MemoryDIMMCEAccelerating", consts.ComponentNameMemory, consts.LevelWarning,
+		"The correctable errors of a DIMM accelerate",
+		"Plan the replacement of the DIMM"),
+	def("MEM-0005", "OutOfMemory", consts.ComponentNameMemory, consts.LevelCritical,
+		"The kernel log reports an out of memory kill",
+		"Check the memory use of the jobs"),
+	def("MEM-0006", "UncorrectableECC", consts.ComponentNameMemory, consts.LevelCritical,
+		"The kernel log reports an uncorrectable ECC error",
+		"Identify the faulty DIMM and replace it"),
+	def("MEM-0007", "CorrectableECC", consts.ComponentNameMemory, consts.LevelWarning,
+		"The kernel log reports correctable ECC errors",
+		"Monitor the DIMMs and plan their replacement"),
+
+	// memory, dmesg
+	def("MEM-0008", "SysOOM", consts.ComponentNameDmesg, consts.LevelInfo,
+		"The kernel log reports a system out of memory kill",
+		"Check the memory use of the node"),
+
+	// CPU, cpu
+	def("CPU-0001", "CPUMCEUncorrected", consts.ComponentNameCPU, consts.LevelCritical,
+		"An uncorrected machine check exception",
+		"Schedule the maintenance of the node"),
+	def("CPU-0002", "CPUMCECorrectedHigh", consts.ComponentNameCPU, consts.LevelWarning,
+		"The corrected machine check exceptions exceed the threshold",
+		"Monitor the errors and schedule a preventive maintenance"),
+	def("CPU-0003", "CPUPerfModeNotEnabled", consts.ComponentNameCPU, consts.LevelWarning,
+		"The CPU frequency governor is not performance",
+		"Set scaling_governor of all CPUs to performance"),
+	def("CPU-0004", "CPUFrequencyRestricted", consts.ComponentNameCPU, consts.LevelWarning,
+		"The CPU frequency is capped below its maximum",
+		"Restore scaling_max_freq, enable turbo and check thermald"),
+	def("CPU-0005", "ClockSyncServiceNotRunning", consts.ComponentNameCPU, consts.LevelWarning,
+		"No clock synchronization service runs",
+		"Start ptp4l, chronyd or ntpd"),
+	def("CPU-0006", "ClockSyncOffsetHigh", consts.ComponentNameCPU, consts.LevelWarning,
+		"The clock offset exceeds the threshold",
+		"Check the PTP or NTP configuration"),
+	def("CPU-0007", "mce_error", consts.ComponentNameCPU, consts.LevelCritical,
+		"The kernel log reports a machine check exception",
+		"Check the CPU and memory health with mcelog or rasdaemon"),
+	def("CPU-0008", "cpu_lockup", consts.ComponentNameCPU, consts.LevelWarning,
+		"The kernel log reports a CPU lockup",
+		"Check the kernel and driver bugs behind the lockup"),
+	def("CPU-0009", "cpu_overheating", consts.ComponentNameCPU, consts.LevelWarning,
+		"The kernel log reports a CPU overheating",
+		"Check the cooling of the node"),
+
+	// CPU, dmesg
+	def("CPU-0010", "MCEHardwareError", consts.ComponentNameDmesg, consts.LevelWarning,
+		"The kernel log reports a machine check hardware error",
+		"Check the CPU and memory health with mcelog or rasdaemon"),
+	def("CPU-0011", "MCECorrected", consts.ComponentNameDmesg, consts.LevelWarning,
+		"The kernel log reports corrected machine checks",
+		"Monitor the errors of the reported CPU or DIMM and schedule a preventive maintenance"),
+	def("CPU-0012", "MCEUncorrected", consts.ComponentNameDmesg, consts.LevelCritical,
+		"The kernel log reports an uncorrected machine check",
+		"Drain the node and check the CPU or DIMM at the reported location"),
+
+	// PCIe, pcie
+	def("PCI-0001", "PCIeAERFatal", consts.ComponentNamePCIE, consts.LevelCritical,
+		"A PCIe device reported fatal AER errors",
+		"Drain the node, reseat the device or its riser and check the slot"),
+	def("PCI-0002", "PCIeAERNonFatal", consts.ComponentNamePCIE, consts.LevelCritical,
+		"A PCIe device reported non fatal AER errors",
+		"Drain the node and check the PCIe link of the device"),
+	def("PCI-0003", "PCIeAERCorrectable", consts.ComponentNamePCIE, consts.LevelWarning,
+		"The correctable AER errors of a PCIe device grow",
+		"Check the link speed and width of the device with lspci -vv"),
+	def("PCI-0004", "NumaDeviceRelationError", consts.ComponentNamePCIE, consts.LevelCritical,
+		"The devices of a NUMA node differ from the spec",
+		"Check the PCIe topology of the node"),
+	def("PCI-0005", "SwitchDeviceRelationError", consts.ComponentNamePCIE, consts.LevelCritical,
+		"The devices under a PCIe switch differ from the spec",
+		"Check that the GPUs and HCAs are seated in the right slots and their riser cables"),
+
+	// PCIe, infiniband
+	def("PCI-0006", "PCIEACSNotDisabled", consts.ComponentNameInfiniband, consts.LevelCritical,
+		"PCIe ACS is enabled on the path of an HCA",
+		"Disable ACS in the BIOS or with setpci"),
+	def("PCI-0007", "PCIEMRRIncorrect", consts.ComponentNameInfiniband, consts.LevelInfo,
+		"The PCIe max read request of an HCA is not 4096",
+		"Set the MRR to 4096"),
+	def("PCI-0008", "PCIELinkSpeedDownDegraded", consts.ComponentNameInfiniband, consts.LevelCritical,
+		"The PCIe link of an HCA runs below its speed",
+		"Check the slot and the firmware of the HCA"),
+	def("PCI-0009", "PCIELinkWidthIncorrect", consts.ComponentNameInfiniband, consts.LevelCritical,
+		"The PCIe link of an HCA is narrower than the spec",
+		"Check the lane configuration in the BIOS"),
+	def("PCI-0010", "PCIETreeSpeedDownDegraded", consts.ComponentNameInfiniband, consts.LevelCritical,
+		"A PCIe link on the path of an HCA to its root port runs below its speed",
+		"Check the speed of the upstream devices"),
+	def("PCI-0011", "PCIETreeWidthIncorrect", consts.ComponentNameInfiniband, consts.LevelCritical,
+		"A PCIe link on the path of an HCA to its root port is narrower than the spec",
+		"Check the PCIe switch and topology"),
+
+	// PCIe, nvidia
+	def("PCI-0012", "PCIeACSNotClosed", consts.ComponentNameNvidia, consts.LevelCritical,
+		"PCIe ACS is enabled, peer to peer traffic goes through the root complex",
+		"Disable ACS with setpci, or run with --auto-fix"),
+	def("PCI-0013", "PCIeLinkDegraded", consts.ComponentNameNvidia, consts.LevelWarning,
+		"The PCIe link of a GPU runs below its speed or width",
+		"Reboot the node, reseat the GPU if the link stays degraded"),
+
+	// PCIe, pcie_topo
+	def("PCI-0014", "GPUHCANotLocal", consts.ComponentNamePcieTopo, consts.LevelWarning,
+		"A GPU has no HCA under its PCIe switch or on its NUMA node as the spec pairs them",
+		"Check the slots of the GPU and the HCAs, its NCCL traffic crosses the root complex or the sockets"),
+	def("PCI-0015", "HCAIRQAffinityRemote", consts.ComponentNamePcieTopo, consts.LevelWarning,
+		"Interrupts of an mlx5 HCA are delivered to CPUs outside its NUMA node",
+		"Pin the mlx5 interrupts to the local CPUs of the HCA and check irqbalance"),
+
+	// hardware, bmc
+	def("HW-0001", "BMCFanFailure", consts.ComponentNameBMC, consts.LevelCritical,
+		"A fan is below its minimum speed or failed",
+		"Replace the fan module reported by ipmitool sensor"),
+	def("HW-0002", "BMCPSUFailure", consts.ComponentNameBMC, consts.LevelCritical,
+		"A power supply is missing or failed",
+		"Check the power cord and the power supply"),
+	def("HW-0003", "BMCOverTemperature", consts.ComponentNameBMC, consts.LevelCritical,
+		"A chassis temperature exceeds its maximum",
+		"Check the fans, the air inlet and the cooling of the rack"),
+	def("HW-0004", "BMCSELCriticalEvent", consts.ComponentNameBMC, consts.LevelCritical,
+		"The BMC event log has critical events",
+		"Check ipmitool sel elist and clear the log after the hardware is fixed"),
+
+	// hardware, inventory
+	def("HW-0005", "DeviceIdentityChanged", consts.ComponentNameInventory, consts.LevelWarning,
+		"The serial number or firmware of a device changed since the accepted inventory",
+		"Check the maintenance records, accept the inventory once the swap is expected"),
+
+	// system, nvidia
+	def("SYS-0001", "IOMMUNotClosed", consts.ComponentNameNvidia, consts.LevelCritical,
+		"The IOMMU is enabled",
+		"Add iommu=off to the kernel command line and reboot"),
+	def("SYS-0002", "KernelTainted", consts.ComponentNameNvidia, consts.LevelWarning,
+		"The kernel is tainted",
+		"Check the dmesg for the oops, machine check or forced module load and reboot"),
+
+	// system, cpu
+	def("SYS-0003", "kernel_panic", consts.ComponentNameCPU, consts.LevelCritical,
+		"The kernel log reports a kernel panic",
+		"Restart the node"),
+
+	// system, dmesg
+	def("SYS-0004", "DmesgError", consts.ComponentNameDmesg, consts.LevelCritical,
+		"The kernel log has an error",
+		"Check the dmesg"),
+
+	// system, container_runtime
+	def("SYS-0005", "ContainerRuntimeUnresponsive", consts.ComponentNameContainerRuntime, consts.LevelCritical,
+		"containerd or docker does not answer on its socket in time",
+		"Check `systemctl status containerd` and the containerd log, restart containerd if it hangs"),
+	def("SYS-0006", "NvidiaContainerToolkitMissing", consts.ComponentNameContainerRuntime, consts.LevelCritical,
+		"The nvidia-container-toolkit hook is not installed on a GPU node",
+		"Install nvidia-container-toolkit or check the toolkit daemonset of the GPU operator"),
+	def("SYS-0007", "NvidiaContainerToolkitVersionMismatch", consts.ComponentNameContainerRuntime, consts.LevelWarning,
+		"The nvidia-container-toolkit version does not match the spec",
+		"Upgrade nvidia-container-toolkit to the version of the spec"),
+	def("SYS-0008", "DefaultRuntimeNotGPU", consts.ComponentNameContainerRuntime, consts.LevelCritical,
+		"The default runtime of containerd or docker does not inject the GPU devices",
+		"Run `nvidia-ctk runtime configure --runtime=containerd --set-as-default` and restart containerd"),
+	def("SYS-0009", "KubeletPLEGSlow", consts.ComponentNameContainerRuntime, consts.LevelWarning,
+		"The kubelet PLEG relists the containers slowly or stalled",
+		"Check the load of containerd and the number of containers on the node"),
+
+	// workload, podlog
+	def("WKL-0001", "CUDAOutOfMemory", consts.ComponentNamePodlog, consts.LevelCritical,
+		"A job ran out of GPU memory",
+		"Check the job log, stop and restart the job"),
+	def("WKL-0002", "ECCError", consts.ComponentNamePodlog, consts.LevelCritical,
+		"A job failed on a GPU ECC error",
+		"Check the job log, stop and restart the job"),
+	def("WKL-0003", "ValueError:", consts.ComponentNamePodlog, consts.LevelCritical,
+		"A job failed on a ValueError",
+		"Check the job log, stop and restart the job"),
+	def("WKL-0004", "DiskQuotaExceeded", consts.ComponentNamePodlog, consts.LevelCritical,
+		"A job exceeded its disk quota",
+		"Check the job log, stop and restart the job"),
+	def("WKL-0005", "NCCLNetIBError", consts.ComponentNamePodlog, consts.LevelCritical,
+		"NCCL of a job failed on the InfiniBand network",
+		"Check the job log, stop and restart the job"),
+
+	// workload, nvidia
+	def("WKL-0006", "NCCLTimeout", consts.ComponentNameNvidia, consts.LevelFatal,
+		"NCCL of a job timed out",
+		"Stop the job and resubmit it"),
+
+	// workload, dmesg
+	def("WKL-0007", "CgroupOOM", consts.ComponentNameDmesg, consts.LevelInfo,
+		"The kernel log reports a cgroup out of memory kill",
+		"Check the memory limit of the pod"),
+	def("WKL-0008", "LibcSegFault", consts.ComponentNameDmesg, consts.LevelInfo,
+		"The kernel log reports a segfault in libc",
+		"Check the application"),
+	def("WKL-0009", "NCCLSegFault", consts.ComponentNameDmesg, consts.LevelInfo,
+		"The kernel log reports a segfault in NCCL",
+		"Check the NCCL version of the application"),
+
+	// diagnostics, gpuburn
+	def("DIAG-0001", "GpuBurnFailed", "gpuburn", consts.LevelCritical,
+		"gpu_burn failed to run",
+		"Check the gpu_burn output with -v"),
+	def("DIAG-0002", "GpuBurnComputeError", "gpuburn", consts.LevelCritical,
+		"A GPU computed wrong results under load",
+		"Replace the GPU"),
+	def("DIAG-0003", "GpuBurnEccError", "gpuburn", consts.LevelCritical,
+		"A GPU raised uncorrectable ECC errors under load",
+		"Reset the GPU and check its remapped rows"),
+	def("DIAG-0004", "GpuBurnThrottling", "gpuburn", consts.LevelCritical,
+		"A GPU throttled under load",
+		"Check the cooling and power supply of the node"),
+	def("DIAG-0005", "GpuBurnOverheat", "gpuburn", consts.LevelCritical,
+		"A GPU reached its slowdown temperature under load",
+		"Check the cooling of the node"),
+	def("DIAG-0006", "GpuBurnClockDrop", "gpuburn", consts.LevelCritical,
+		"The SM clock of a GPU dropped under load",
+		"Check the clock events and power limit with nvidia-smi -q -d PERFORMANCE"),
+	def("DIAG-0007", "GpuBurnXidError", "gpuburn", consts.LevelCritical,
+		"A GPU raised an xid under load",
+		"Check the xid with nvidia-bug-report.sh"),
+
+	// diagnostics, cudatest
+	def("DIAG-0008", "CudaTestFailed", "cudatest", consts.LevelCritical,
+		"The CUDA sanity test failed on a GPU",
+		"Check the GPU with nvidia-smi -q and the dmesg for xids"),
+	def("DIAG-0009", "CudaTestTimeout", "cudatest", consts.LevelCritical,
+		"The CUDA sanity test hung on a GPU",
+		"Reset the GPU, replace it if it hangs again"),
+	def("DIAG-0010", "CudaTestWrongResult", "cudatest", consts.LevelCritical,
+		"A GPU computed a wrong result in the CUDA sanity test",
+		"Replace the GPU"),
+	def("DIAG-0011", "CudaTestLaunchError", "cudatest", consts.LevelCritical,
+		"A kernel failed to launch in the CUDA sanity test",
+		"Reset the GPU and check the dmesg for xids"),
+
+	// diagnostics, gpudiag
+	def("DIAG-0012", "GpuDiagTestError", "gpudiag", consts.LevelCritical,
+		"A dcgmi diag test failed",
+		"Check the failed test in the dcgmi diag output"),
+
+	// diagnostics, nvlink_perftest
+	def("DIAG-0013", "NvlinkPerfTestError", "nvlink_perftest", consts.LevelCritical,
+		"The NVLink bandwidth is below the spec",
+		"Check the NVLink state with nvidia-smi nvlink -s"),
+
+	// diagnostics, nccl_perftest
+	def("DIAG-0014", "NcclPerfTestError", "nccl_perftest", consts.LevelCritical,
+		"The NCCL bandwidth is below the spec",
+		"Check the NVLinks and the HCAs used by NCCL"),
+
+	// diagnostics, ib_perftest
+	def("DIAG-0015", "IbPerfTestError", "ib_perftest", consts.LevelCritical,
+		"The InfiniBand bandwidth is below the spec",
+		"Check the links and the PCIe of the HCAs"),
+	def("DIAG-0016", "DeactiveIBDevicesFound", "ib_perftest", consts.LevelCritical,
+		"HCAs in the test are not active",
+		"Check the connections and configuration of the HCAs"),
+
+	// diagnostics, memtest
+	def("DIAG-0017", "MemBandwidthLow", "memtest", consts.LevelCritical,
+		"The memory bandwidth of a NUMA node is below the spec of the CPU model",
+		"Check the DIMM population and speed with dmidecode -t 17 and the memory settings of the BIOS"),
+	def("DIAG-0018", "MemBandwidthImbalance", "memtest", consts.LevelCritical,
+		"The memory bandwidth of a NUMA node is below the other nodes",
+		"Check the DIMMs of the memory channels of the NUMA node"),
+	def("DIAG-0019", "MemDIMMMisPopulated", "memtest", consts.LevelCritical,
+		"DIMMs are missing or of mixed sizes",
+		"Check for missing or failed DIMMs with dmidecode -t 17"),
+	def("DIAG-0020", "MemDIMMSpeedLow", "memtest", consts.LevelCritical,
+		"DIMMs run below the memory speed of the spec",
+		"Check the memory frequency in the BIOS and replace the slower DIMMs"),
+
+	// sichek itself
+	def("SCK-0001", "InitError", "", consts.LevelCritical,
+		"A component failed to initialize",
+		"Check the sichek logs and the dependencies of the component"),
+	def("SCK-0002", "*HealthCheckTimeout", "", consts.LevelCritical,
+		"A health check of a component did not finish in time",
+		"Check the sichek logs for the collector that hangs"),
+	def("SCK-0003", "*HealthCheckPanic", "", consts.LevelCritical,
+		"A health check of a component panicked",
+		"Report the panic in the sichek logs"),
+	def("SCK-0004", "SpecMissing", "", consts.LevelWarning,
+		"The spec of a component is missing or invalid, the spec based checks are skipped",
+		"Provide a valid spec with --spec"),
+	def("SCK-0005", "SpecEmptyError", "", consts.LevelCritical,
+		"The spec of the infiniband component is empty",
+		"Check the spec of the node"),
+	def("SCK-0006", "CollectFailed", "", consts.LevelCritical,
+		"A component failed to collect its data",
+		"Check the sichek logs and the state of the component"),
+	def("SCK-0007", "PluginExecFailed", "", consts.LevelWarning,
+		"A plugin command failed or timed out",
+		"Run the plugin command by hand"),
+	def("SCK-0009", "CheckerDurationBudgetExceeded", "", consts.LevelWarning,
+		"A checker or collector ran longer than its duration budget in several consecutive runs",
+		"Check the load of the node and the commands the checker runs with the sichek_checker_duration_seconds histogram"),
+
+	// sichek itself, dmesg
+	def("SCK-0008", "KmsgRecordsMissed", consts.ComponentNameDmesg, consts.LevelInfo,
+		"Kernel messages were overwritten before sichek read them",
+		"Check the burst of kernel messages with dmesg"),
+}
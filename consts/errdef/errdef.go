@@ -0,0 +1,182 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package errdef is the catalog of the error names reported by the checkers.
+// Each error name has a stable code, a category and a default severity, so
+// that automation can key off the code instead of the free-form name.
+package errdef
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/scitix/sichek/consts"
+)
+
+// Category groups the errors by the subsystem at fault.
+type Category string
+
+const (
+	CategoryGPU        Category = "gpu"
+	CategoryNetwork    Category = "network"
+	CategoryStorage    Category = "storage"
+	CategoryMemory     Category = "memory"
+	CategoryCPU        Category = "cpu"
+	CategoryPCIe       Category = "pcie"
+	CategoryHardware   Category = "hardware"
+	CategorySystem     Category = "system"
+	CategoryWorkload   Category = "workload"
+	CategoryDiagnostic Category = "diagnostic"
+	CategorySichek     Category = "sichek"
+)
+
+// categoryPrefix is the prefix of the codes of each category, e.g. GPU-0001.
+var categoryPrefix = map[string]Category{
+	"GPU":  CategoryGPU,
+	"NET":  CategoryNetwork,
+	"STO":  CategoryStorage,
+	"MEM":  CategoryMemory,
+	"CPU":  CategoryCPU,
+	"PCI":  CategoryPCIe,
+	"HW":   CategoryHardware,
+	"SYS":  CategorySystem,
+	"WKL":  CategoryWorkload,
+	"DIAG": CategoryDiagnostic,
+	"SCK":  CategorySichek,
+}
+
+const docBaseURL = "https://github.com/scitix/sichek/blob/main/docs/"
+
+// componentDocs are the documents of the components, the others link to the
+// error categorization.
+var componentDocs = map[string]string{
+	consts.ComponentNameNvidia:     "nvidia.md",
+	consts.ComponentNameInfiniband: "infiniband.md",
+	consts.ComponentNameEthernet:   "ethernet.md",
+	consts.ComponentNameGpfs:       "gpfs.md",
+	consts.ComponentNameGpuEvents:  "hang.md",
+	consts.ComponentNameNCCLEnv:    "nccl.md",
+	consts.ComponentNameCPU:        "system.md",
+	consts.ComponentNameMemory:     "system.md",
+}
+
+// ErrorDef is an entry of the catalog. Severity is the default level of the
+// checker, a spec or a checker may still raise or lower it for a result.
+type ErrorDef struct {
+	Code        string   `json:"code" yaml:"code"`
+	Name        string   `json:"name" yaml:"name"`
+	Component   string   `json:"component" yaml:"component"`
+	Category    Category `json:"category" yaml:"category"`
+	Severity    string   `json:"severity" yaml:"severity"`
+	Description string   `json:"description" yaml:"description"`
+	Remediation string   `json:"remediation" yaml:"remediation"`
+	DocURL      string   `json:"doc_url,omitempty" yaml:"doc_url,omitempty"`
+}
+
+func def(code, name, component, severity, description, remediation string) ErrorDef {
+	prefix, _, _ := strings.Cut(code, "-")
+	doc, ok := componentDocs[component]
+	if !ok {
+		doc = "errors-categorization.md"
+	}
+	return ErrorDef{
+		Code:        code,
+		Name:        name,
+		Component:   component,
+		Category:    categoryPrefix[prefix],
+		Severity:    severity,
+		Description: description,
+		Remediation: remediation,
+		DocURL:      docBaseURL + doc,
+	}
+}
+
+// suffixPrefix marks the names registered by suffix, reported with the
+// component name in front, e.g. nvidiaHealthCheckTimeout.
+const suffixPrefix = "*"
+
+var byName = func() map[string]ErrorDef {
+	defs := make(map[string]ErrorDef, len(catalog))
+	for _, def := range catalog {
+		defs[def.Name] = def
+	}
+	return defs
+}()
+
+// Lookup returns the entry of an error name.
+func Lookup(name string) (ErrorDef, bool) {
+	if def, ok := byName[name]; ok {
+		return def, true
+	}
+	for _, def := range catalog {
+		if suffix, ok := strings.CutPrefix(def.Name, suffixPrefix); ok && strings.HasSuffix(name, suffix) {
+			return def, true
+		}
+	}
+	return ErrorDef{}, false
+}
+
+// Code returns the code of an error name, or "" when it is not registered,
+// e.g. the error names of the plugins.
+func Code(name string) string {
+	def, _ := Lookup(name)
+	return def.Code
+}
+
+// All returns the catalog sorted by code.
+func All() []ErrorDef {
+	defs := append([]ErrorDef(nil), catalog...)
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Code < defs[j].Code })
+	return defs
+}
+
+// Categories returns the categories sorted by name.
+func Categories() []Category {
+	categories := make([]Category, 0, len(categoryPrefix))
+	for _, category := range categoryPrefix {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i] < categories[j] })
+	return categories
+}
+
+// Validate checks that the codes and names are unique, that the codes carry
+// the prefix of a category and that the entries are complete.
+func Validate() error {
+	codes := make(map[string]string, len(catalog))
+	names := make(map[string]bool, len(catalog))
+	for _, def := range catalog {
+		prefix, seq, _ := strings.Cut(def.Code, "-")
+		if _, ok := categoryPrefix[prefix]; !ok || len(seq) != 4 || strings.Trim(seq, "0123456789") != "" {
+			return fmt.Errorf("%s: code %q does not match <CATEGORY>-NNNN", def.Name, def.Code)
+		}
+		if other, ok := codes[def.Code]; ok {
+			return fmt.Errorf("code %s is used by both %s and %s", def.Code, other, def.Name)
+		}
+		codes[def.Code] = def.Name
+		if names[def.Name] {
+			return fmt.Errorf("%s is registered twice", def.Name)
+		}
+		names[def.Name] = true
+		if _, ok := consts.LevelPriority[def.Severity]; !ok {
+			return fmt.Errorf("%s: unknown severity %q", def.Name, def.Severity)
+		}
+		if def.Description == "" || def.Remediation == "" {
+			return fmt.Errorf("%s: description and remediation are required", def.Name)
+		}
+	}
+	return nil
+}
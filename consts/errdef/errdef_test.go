@@ -0,0 +1,253 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package errdef
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const repoRoot = "../.."
+
+// sourceErrorNames collects the error names written as literals in the
+// sources: the ErrorName fields of the check items, the assignments to
+// ErrorName or to an errorName variable, the arguments of the local closures
+// taking an errorName and the constants named ErrorName* or *ErrorName.
+func sourceErrorNames(t *testing.T) map[string]string {
+	var files []*ast.File
+	fset := token.NewFileSet()
+	err := filepath.WalkDir(repoRoot, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name := d.Name(); path != repoRoot && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		files = append(files, file)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the string constants by name, the ones declared twice are ambiguous
+	consts := make(map[string]string)
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				value := spec.(*ast.ValueSpec)
+				for i, ident := range value.Names {
+					if i >= len(value.Values) {
+						continue
+					}
+					if s, ok := stringLit(value.Values[i]); ok {
+						if _, dup := consts[ident.Name]; dup {
+							s = ""
+						}
+						consts[ident.Name] = s
+					}
+				}
+			}
+		}
+	}
+	resolve := func(expr ast.Expr) string {
+		if s, ok := stringLit(expr); ok {
+			return s
+		}
+		switch e := expr.(type) {
+		case *ast.Ident:
+			return consts[e.Name]
+		case *ast.SelectorExpr:
+			return consts[e.Sel.Name]
+		}
+		return ""
+	}
+
+	names := make(map[string]string)
+	for _, file := range files {
+		pos := func(node ast.Node) string {
+			p := fset.Position(node.Pos())
+			rel, _ := filepath.Rel(repoRoot, p.Filename)
+			return rel + ":" + strconv.Itoa(p.Line)
+		}
+		add := func(expr ast.Expr) {
+			if name := resolve(expr); name != "" {
+				names[name] = pos(expr)
+			}
+		}
+		// closures with an errorName parameter, by variable name
+		closures := make(map[string]int)
+		ast.Inspect(file, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.KeyValueExpr:
+				if key, ok := n.Key.(*ast.Ident); ok && key.Name == "ErrorName" {
+					add(n.Value)
+				}
+			case *ast.ValueSpec:
+				for i, ident := range n.Names {
+					isErrorName := strings.HasPrefix(ident.Name, "ErrorName") || strings.HasSuffix(ident.Name, "ErrorName")
+					if isErrorName && i < len(n.Values) {
+						add(n.Values[i])
+					}
+				}
+			case *ast.AssignStmt:
+				for i, lhs := range n.Lhs {
+					if len(n.Rhs) != len(n.Lhs) {
+						break
+					}
+					switch l := lhs.(type) {
+					case *ast.SelectorExpr:
+						if l.Sel.Name == "ErrorName" {
+							add(n.Rhs[i])
+						}
+					case *ast.Ident:
+						if l.Name == "errorName" {
+							add(n.Rhs[i])
+						}
+						if fn, ok := n.Rhs[i].(*ast.FuncLit); ok {
+							idx := 0
+							for _, field := range fn.Type.Params.List {
+								for _, param := range field.Names {
+									if param.Name == "errorName" {
+										closures[l.Name] = idx
+									}
+									idx++
+								}
+							}
+						}
+					}
+				}
+			case *ast.CallExpr:
+				if fn, ok := n.Fun.(*ast.Ident); ok {
+					if idx, ok := closures[fn.Name]; ok && idx < len(n.Args) {
+						add(n.Args[idx])
+					}
+				}
+			}
+			return true
+		})
+	}
+	return names
+}
+
+func stringLit(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
+
+// eventRuleErrorNames collects the names of the default event rules, the
+// error names of the log and gpuevents checkers.
+func eventRuleErrorNames(t *testing.T) map[string]string {
+	paths, err := filepath.Glob(filepath.Join(repoRoot, "components/*/config/default_event_rules.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[string]string)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rules any
+		if err := yaml.Unmarshal(data, &rules); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		rel, _ := filepath.Rel(repoRoot, path)
+		collectRuleNames(rules, rel, names)
+	}
+	return names
+}
+
+// collectRuleNames walks the rules, a rule is a mapping with a name and a
+// level, e.g. the podlog rules are nested under event_checkers.
+func collectRuleNames(node any, path string, names map[string]string) {
+	rule, ok := node.(map[string]any)
+	if !ok {
+		return
+	}
+	name, hasName := rule["name"].(string)
+	_, hasLevel := rule["level"]
+	if hasName && hasLevel {
+		names[name] = path
+		return
+	}
+	for _, child := range rule {
+		collectRuleNames(child, path, names)
+	}
+}
+
+func TestCatalogValid(t *testing.T) {
+	if err := Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestErrorNamesRegistered(t *testing.T) {
+	names := sourceErrorNames(t)
+	if len(names) < 100 {
+		t.Fatalf("found only %d error names, the scan is broken", len(names))
+	}
+	for name, at := range eventRuleErrorNames(t) {
+		names[name] = at
+	}
+	var missing []string
+	for name, at := range names {
+		if _, ok := Lookup(name); !ok {
+			missing = append(missing, name+" ("+at+")")
+		}
+	}
+	sort.Strings(missing)
+	if len(missing) > 0 {
+		t.Errorf("%d error names are not registered in consts/errdef, add them to the catalog:\n%s", len(missing), strings.Join(missing, "\n"))
+	}
+}
+
+func TestLookup(t *testing.T) {
+	def, ok := Lookup("nvidiaHealthCheckTimeout")
+	if !ok || def.Category != CategorySichek {
+		t.Errorf("expected the timeout of a component to match by suffix, got %+v", def)
+	}
+	if Code("NoSuchError") != "" {
+		t.Errorf("expected no code for an unknown name")
+	}
+}
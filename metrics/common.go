@@ -20,10 +20,12 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/scitix/sichek/components/common"
 	"github.com/scitix/sichek/pkg/utils"
 	"github.com/sirupsen/logrus"
 )
@@ -136,6 +138,21 @@ func (e *GaugeVecMetricExporter) DeleteLabelValues(name string, labelVals []stri
 	return gaugeVec.DeleteLabelValues(labelVals...)
 }
 
+// ExportEventCounts sets the metric name, labeled by the event checker, to the
+// number of matched lines (Curr) of every checker of an event filter result.
+func (e *GaugeVecMetricExporter) ExportEventCounts(name string, result *common.Result) {
+	if result == nil {
+		return
+	}
+	for _, checker := range result.Checkers {
+		count, err := strconv.ParseFloat(checker.Curr, 64)
+		if err != nil {
+			continue
+		}
+		e.SetMetric(name, []string{checker.Name}, count)
+	}
+}
+
 // StructToMetricsMap This recursively flattens the struct into a map where each field is represented by a string path and its corresponding value.
 func StructToMetricsMap(v reflect.Value, path, tagPrefix string, metrics map[string]*StructMetrics) {
 	// Dereference pointers
@@ -252,3 +252,22 @@ func TestCheckerResultReflect(t *testing.T) {
 	}
 	t.Logf("labelValues=%v", labelValues)
 }
+
+func TestGaugeVecMetricExporter_ExportEventCounts(t *testing.T) {
+	exporter := NewGaugeVecMetricExporter("test_event", []string{"event"})
+	exporter.ExportEventCounts("count", &common.Result{Checkers: []*common.CheckerResult{
+		{Name: "NCCLTimeout", Curr: "3"},
+		{Name: "NotACount", Curr: "abnormal"},
+	}})
+
+	gaugeVec, exists := exporter.MetricsMap["test_event_count"]
+	if !exists {
+		t.Fatalf("expected metric test_event_count to exist")
+	}
+	if !gaugeVec.DeleteLabelValues("NCCLTimeout", exporter.nodeName) {
+		t.Errorf("expected a series for NCCLTimeout")
+	}
+	if gaugeVec.DeleteLabelValues("NotACount", exporter.nodeName) {
+		t.Errorf("expected no series for a non-numeric curr")
+	}
+}
@@ -15,6 +15,8 @@ limitations under the License.
 */
 package metrics
 
+import "github.com/scitix/sichek/components/common"
+
 type MetricsUserConfig struct {
 	Metrics *MetricsConfig `json:"metrics" yaml:"metrics"`
 }
@@ -22,4 +24,8 @@ type MetricsUserConfig struct {
 type MetricsConfig struct {
 	Port   int    `json:"port" yaml:"port"`
 	Socket string `json:"socket" yaml:"socket"`
+	// TextfileDir is the --collector.textfile.directory of node-exporter, the
+	// metrics are written to it as sichek.prom when set.
+	TextfileDir      string          `json:"textfile_dir,omitempty" yaml:"textfile_dir,omitempty"`
+	TextfileInterval common.Duration `json:"textfile_interval,omitempty" yaml:"textfile_interval,omitempty"`
 }
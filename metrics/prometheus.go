@@ -23,6 +23,7 @@ const (
 type HealthCheckResMetrics struct {
 	HealthCheckResGauge *GaugeVecMetricExporter
 	AnnotationResGauge  *GaugeVecMetricExporter
+	DroppedResultGauge  *GaugeVecMetricExporter
 }
 
 func getHealthCheckResMetricLables() []string {
@@ -42,10 +43,12 @@ func newHealthCheckResMetrics() *HealthCheckResMetrics {
 	healthCheckResMetricLables := getHealthCheckResMetricLables()
 	HealthCheckResGauge := NewGaugeVecMetricExporter(MetricPrefix, healthCheckResMetricLables)
 	AnnotationResGauge := NewGaugeVecMetricExporter(MetricPrefix, []string{"annotaion"})
+	DroppedResultGauge := NewGaugeVecMetricExporter(MetricPrefix, []string{"component"})
 
 	return &HealthCheckResMetrics{
 		HealthCheckResGauge: HealthCheckResGauge,
 		AnnotationResGauge:  AnnotationResGauge,
+		DroppedResultGauge:  DroppedResultGauge,
 	}
 }
 
@@ -69,7 +72,8 @@ func (m *HealthCheckResMetrics) ExportMetrics(metrics *common.Result) {
 		// Always reset first to clear previous state
 		m.HealthCheckResGauge.ResetMetric(metricName)
 
-		if checker.Status == consts.StatusAbnormal {
+		// silenced checkers are exported with status="silenced" so that alerts can drop them
+		if checker.Status == consts.StatusAbnormal || checker.Status == consts.StatusSilenced {
 			devices := []string{checker.Device}
 			if checker.Device != "" && strings.Contains(checker.Device, ",") {
 				parts := strings.Split(checker.Device, ",")
@@ -108,6 +112,14 @@ func (m *HealthCheckResMetrics) ExportMetrics(metrics *common.Result) {
 	}
 }
 
+// ExportDroppedResults exports the number of results each component dropped
+// because the daemon did not keep up, as sichek_dropped_results{component}.
+func (m *HealthCheckResMetrics) ExportDroppedResults(dropped map[string]uint64) {
+	for component, n := range dropped {
+		m.DroppedResultGauge.SetMetric("dropped_results", []string{component}, float64(n))
+	}
+}
+
 func (m *HealthCheckResMetrics) ExportAnnotationMetrics(annoStr string) {
 	m.AnnotationResGauge.ResetMetric("node_annotaion")
 	m.AnnotationResGauge.SetMetric("node_annotaion", []string{annoStr}, 1.0)
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/scitix/sichek/components/common"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// TextfileName is the file written to the textfile directory, node-exporter
+	// exposes every *.prom file of the directory.
+	TextfileName            = "sichek.prom"
+	DefaultTextfileInterval = 60 * time.Second
+)
+
+// WriteTextfile writes the metrics of g to dir/sichek.prom in the Prometheus
+// text format. The file is replaced atomically so that node-exporter never
+// reads a partial file.
+func WriteTextfile(dir string, g prometheus.Gatherer) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create textfile dir %s: %w", dir, err)
+	}
+	return prometheus.WriteToTextfile(filepath.Join(dir, TextfileName), g)
+}
+
+// StartTextfileExporter rewrites the textfile every textfile_interval until
+// ctx is done, for the clusters where Prometheus cannot scrape the nodes.
+// It returns immediately if textfile_dir is not set in the user config.
+func StartTextfileExporter(ctx context.Context, cfgFile string) {
+	cfg := &MetricsUserConfig{}
+	if err := common.LoadUserConfig(cfgFile, cfg); err != nil || cfg.Metrics == nil || cfg.Metrics.TextfileDir == "" {
+		return
+	}
+	dir := cfg.Metrics.TextfileDir
+	interval := cfg.Metrics.TextfileInterval.Duration
+	if interval <= 0 {
+		interval = DefaultTextfileInterval
+	}
+	logrus.WithField("component", "metrics").Infof("writing metrics to %s every %s", filepath.Join(dir, TextfileName), interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := WriteTextfile(dir, prometheus.DefaultGatherer); err != nil {
+				logrus.WithField("component", "metrics").Errorf("failed to write the metrics textfile: %v", err)
+			}
+		}
+	}
+}
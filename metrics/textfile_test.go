@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestWriteTextfile(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "sichek_test_temperature"}, []string{"index"})
+	registry.MustRegister(gauge)
+	gauge.WithLabelValues("0").Set(42)
+
+	dir := filepath.Join(t.TempDir(), "textfile")
+	if err := WriteTextfile(dir, registry); err != nil {
+		t.Fatalf("WriteTextfile: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, TextfileName))
+	if err != nil {
+		t.Fatalf("read textfile: %v", err)
+	}
+	if !strings.Contains(string(data), `sichek_test_temperature{index="0"} 42`) {
+		t.Errorf("unexpected textfile content:\n%s", data)
+	}
+}
@@ -0,0 +1,260 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package alert sends the critical results of the daemon to the webhooks of
+// the alerting integrations, e.g. Slack, Feishu and PagerDuty. An alert
+// fires once when a checker turns abnormal, repeats while it stays abnormal
+// and resolves when the checker returns to normal.
+package alert
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/httpclient"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the `alert` section of the user config.
+type Config struct {
+	Alert struct {
+		Enable bool `json:"enable" yaml:"enable"`
+		// MinLevel is the lowest level of the abnormal checkers that fire.
+		MinLevel string `json:"min_level" yaml:"min_level"`
+		// SendResolved notifies the webhooks when a firing checker returns to normal.
+		SendResolved bool `json:"send_resolved" yaml:"send_resolved"`
+		// RepeatInterval resends a firing alert that did not change, 0 never does.
+		RepeatInterval time.Duration `json:"repeat_interval" yaml:"repeat_interval"`
+		Timeout        time.Duration `json:"timeout" yaml:"timeout"`
+		RetryMax       int           `json:"retry_max" yaml:"retry_max"`
+		Webhooks       []Webhook     `json:"webhooks" yaml:"webhooks"`
+	} `json:"alert" yaml:"alert"`
+}
+
+// LoadConfig loads the alert config from cfgFile, missing fields keep their
+// defaults.
+func LoadConfig(cfgFile string) *Config {
+	config := &Config{}
+	config.Alert.MinLevel = consts.LevelCritical
+	config.Alert.SendResolved = true
+	config.Alert.RepeatInterval = 4 * time.Hour
+	config.Alert.Timeout = 10 * time.Second
+	config.Alert.RetryMax = 3
+
+	if cfgFile != "" {
+		data, err := os.ReadFile(cfgFile)
+		if err == nil {
+			err = yaml.Unmarshal(data, config)
+		}
+		if err != nil {
+			logrus.WithField("alert", "config").Warnf("Failed to load alert config from %s, using defaults: %v", cfgFile, err)
+		}
+	}
+	if _, ok := consts.LevelPriority[config.Alert.MinLevel]; !ok {
+		logrus.WithField("alert", "config").Warnf("unknown alert min_level %q, using %s", config.Alert.MinLevel, consts.LevelCritical)
+		config.Alert.MinLevel = consts.LevelCritical
+	}
+	if config.Alert.Timeout <= 0 {
+		config.Alert.Timeout = 10 * time.Second
+	}
+	return config
+}
+
+// Alert is a firing or resolved abnormal checker of a component.
+type Alert struct {
+	Node       string    `json:"node"`
+	Component  string    `json:"component"`
+	Checker    string    `json:"checker"`
+	Level      string    `json:"level"`
+	ErrorName  string    `json:"error_name"`
+	ErrorCode  string    `json:"error_code,omitempty"`
+	Device     string    `json:"device,omitempty"`
+	Detail     string    `json:"detail"`
+	Suggestion string    `json:"suggestion"`
+	Resolved   bool      `json:"resolved"`
+	StartsAt   time.Time `json:"starts_at"`
+	Time       time.Time `json:"time"`
+}
+
+// Key identifies the alerts of a checker of the node, the webhooks with
+// their own dedup, e.g. PagerDuty, resolve the incident with it.
+func (a *Alert) Key() string {
+	return a.Node + "/" + a.Component + "/" + a.Checker
+}
+
+// firing is the last sent alert of an abnormal checker.
+type firing struct {
+	alert    Alert
+	lastSent time.Time
+}
+
+// Notifier turns the results into alerts and sends them to the webhooks from
+// a single goroutine, so the health check pipeline never blocks on the
+// network. Alerts that cannot be delivered after the retries are dropped,
+// a checker that stays abnormal fires again after the repeat interval.
+type Notifier struct {
+	cfg      *Config
+	node     string
+	client   *http.Client
+	queue    chan *Alert
+	mu       sync.Mutex
+	firing   map[string]*firing
+	minLevel int
+
+	// backoff and now allow tests to inject zero-sleep and a fake clock.
+	backoff func(attempt int) time.Duration
+	now     func() time.Time
+}
+
+// New constructs a Notifier, it returns nil if the alerts are disabled or no
+// webhook is configured.
+func New(cfg *Config, node string) *Notifier {
+	if cfg == nil || !cfg.Alert.Enable {
+		return nil
+	}
+	if len(cfg.Alert.Webhooks) == 0 {
+		logrus.WithField("alert", "config").Warnf("alerts are enabled without webhooks")
+		return nil
+	}
+	return &Notifier{
+		cfg:      cfg,
+		node:     node,
+		client:   &http.Client{Timeout: cfg.Alert.Timeout},
+		queue:    make(chan *Alert, 100),
+		firing:   make(map[string]*firing),
+		minLevel: consts.LevelPriority[cfg.Alert.MinLevel],
+		backoff:  httpclient.ExponentialBackoff,
+		now:      time.Now,
+	}
+}
+
+// Notify queues the alerts the result fires or resolves. A checker fires
+// when it turns abnormal at MinLevel or above, when its level rises or its
+// devices change, and again after the repeat interval. Silenced checkers
+// neither fire nor resolve. It never blocks, the alerts are dropped when
+// the queue is full.
+func (n *Notifier) Notify(result *common.Result) {
+	if n == nil || result == nil {
+		return
+	}
+	now := n.now()
+	var alerts []*Alert
+	n.mu.Lock()
+	for _, checker := range result.Checkers {
+		if checker == nil || checker.Status == consts.StatusSilenced {
+			continue
+		}
+		alert := Alert{
+			Node:       n.node,
+			Component:  result.Item,
+			Checker:    checker.Name,
+			Level:      checker.Level,
+			ErrorName:  checker.ErrorName,
+			ErrorCode:  checker.ErrorCode,
+			Device:     checker.Device,
+			Detail:     strings.TrimSpace(checker.Detail),
+			Suggestion: checker.Suggestion,
+			StartsAt:   now,
+			Time:       now,
+		}
+		key := alert.Key()
+		prev, wasFiring := n.firing[key]
+		if checker.Status == consts.StatusAbnormal && consts.LevelPriority[checker.Level] >= n.minLevel {
+			if wasFiring {
+				alert.StartsAt = prev.alert.StartsAt
+				changed := consts.LevelPriority[checker.Level] > consts.LevelPriority[prev.alert.Level] || checker.Device != prev.alert.Device
+				repeat := n.cfg.Alert.RepeatInterval > 0 && now.Sub(prev.lastSent) >= n.cfg.Alert.RepeatInterval
+				if !changed && !repeat {
+					continue
+				}
+			}
+			n.firing[key] = &firing{alert: alert, lastSent: now}
+			alerts = append(alerts, &alert)
+			continue
+		}
+		if !wasFiring {
+			continue
+		}
+		delete(n.firing, key)
+		if n.cfg.Alert.SendResolved {
+			resolved := prev.alert
+			resolved.Resolved = true
+			resolved.Time = now
+			resolved.Detail = strings.TrimSpace(checker.Detail)
+			alerts = append(alerts, &resolved)
+		}
+	}
+	n.mu.Unlock()
+
+	for _, alert := range alerts {
+		select {
+		case n.queue <- alert:
+		default:
+			n.logEntry().Warnf("alert queue is full, drop the alert of %s", alert.Key())
+		}
+	}
+}
+
+// Run sends the queued alerts to the webhooks until ctx is canceled.
+func (n *Notifier) Run(ctx context.Context) {
+	if n == nil {
+		return
+	}
+	n.logEntry().Infof("alert notifier started; webhooks=%d min_level=%s", len(n.cfg.Alert.Webhooks), n.cfg.Alert.MinLevel)
+	for {
+		select {
+		case <-ctx.Done():
+			n.logEntry().Info("alert notifier stopped (context canceled)")
+			return
+		case alert := <-n.queue:
+			n.send(ctx, alert)
+		}
+	}
+}
+
+// send posts the alert to every webhook, a failing webhook does not keep the
+// alert from the others.
+func (n *Notifier) send(ctx context.Context, alert *Alert) {
+	defer func() {
+		if p := recover(); p != nil {
+			n.logEntry().Errorf("alert notifier panic: %v", p)
+		}
+	}()
+	for i := range n.cfg.Alert.Webhooks {
+		webhook := &n.cfg.Alert.Webhooks[i]
+		body, header, err := webhook.Payload(alert, n.now())
+		if err != nil {
+			n.logEntry().Errorf("format the alert of %s for webhook %s failed: %v", alert.Key(), webhook.Name, err)
+			continue
+		}
+		if err := httpclient.PostWithRetry(ctx, n.client, webhook.Endpoint(), header, body, n.cfg.Alert.RetryMax, n.backoff); err != nil {
+			n.logEntry().Warnf("send the alert of %s to webhook %s failed: %v", alert.Key(), webhook.Name, err)
+		}
+	}
+}
+
+func (n *Notifier) logEntry() *logrus.Entry {
+	return logrus.WithFields(logrus.Fields{
+		"service": "alert",
+		"node":    n.node,
+	})
+}
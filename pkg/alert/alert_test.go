@@ -0,0 +1,198 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+)
+
+func newTestNotifier(t *testing.T, webhooks ...Webhook) (*Notifier, *time.Time) {
+	t.Helper()
+	cfg := LoadConfig("")
+	cfg.Alert.Enable = true
+	cfg.Alert.RepeatInterval = time.Hour
+	cfg.Alert.Webhooks = webhooks
+	n := New(cfg, "node-a")
+	n.backoff = func(int) time.Duration { return 0 }
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	n.now = func() time.Time { return now }
+	return n, &now
+}
+
+func result(status, level, device string) *common.Result {
+	return &common.Result{Item: consts.ComponentNameNvidia, Status: status, Level: level, Checkers: []*common.CheckerResult{{
+		Name: "GPULost", Status: status, Level: level, ErrorName: "GPULost", Device: device,
+		Detail: "GPU lost\n", Suggestion: "Reboot the node",
+	}}}
+}
+
+func drain(n *Notifier) []*Alert {
+	var alerts []*Alert
+	for {
+		select {
+		case alert := <-n.queue:
+			alerts = append(alerts, alert)
+		default:
+			return alerts
+		}
+	}
+}
+
+func TestNew_Disabled(t *testing.T) {
+	if n := New(LoadConfig(""), "node-a"); n != nil {
+		t.Fatalf("New with the default config = %v, want nil", n)
+	}
+	cfg := LoadConfig("")
+	cfg.Alert.Enable = true
+	if n := New(cfg, "node-a"); n != nil {
+		t.Fatalf("New without webhooks = %v, want nil", n)
+	}
+	// A nil notifier must be safe to use.
+	var n *Notifier
+	n.Notify(result(consts.StatusAbnormal, consts.LevelCritical, "0"))
+	n.Run(context.Background())
+}
+
+func TestNotify_DedupRepeatAndResolve(t *testing.T) {
+	n, now := newTestNotifier(t, Webhook{Name: "test", URL: "http://127.0.0.1:0"})
+
+	n.Notify(result(consts.StatusAbnormal, consts.LevelCritical, "0"))
+	alerts := drain(n)
+	if len(alerts) != 1 || alerts[0].Resolved || alerts[0].Detail != "GPU lost" {
+		t.Fatalf("expected one firing alert, got %+v", alerts)
+	}
+
+	// the same failure is not sent again before the repeat interval
+	*now = now.Add(10 * time.Minute)
+	n.Notify(result(consts.StatusAbnormal, consts.LevelCritical, "0"))
+	if alerts := drain(n); len(alerts) != 0 {
+		t.Fatalf("expected the duplicate to be dropped, got %+v", alerts)
+	}
+
+	// a new device fires at once and keeps the start of the alert
+	n.Notify(result(consts.StatusAbnormal, consts.LevelCritical, "0,1"))
+	alerts = drain(n)
+	if len(alerts) != 1 || alerts[0].Device != "0,1" || !alerts[0].StartsAt.Equal(now.Add(-10*time.Minute)) {
+		t.Fatalf("expected the changed devices to fire, got %+v", alerts)
+	}
+
+	*now = now.Add(time.Hour)
+	n.Notify(result(consts.StatusAbnormal, consts.LevelCritical, "0,1"))
+	if alerts := drain(n); len(alerts) != 1 {
+		t.Fatalf("expected the alert to repeat, got %+v", alerts)
+	}
+
+	// a silenced checker neither fires nor resolves
+	n.Notify(result(consts.StatusSilenced, consts.LevelCritical, "0,1"))
+	if alerts := drain(n); len(alerts) != 0 {
+		t.Fatalf("expected no alert for a silenced checker, got %+v", alerts)
+	}
+
+	n.Notify(result(consts.StatusNormal, consts.LevelCritical, ""))
+	alerts = drain(n)
+	if len(alerts) != 1 || !alerts[0].Resolved || alerts[0].Device != "0,1" {
+		t.Fatalf("expected a resolved alert, got %+v", alerts)
+	}
+	n.Notify(result(consts.StatusNormal, consts.LevelCritical, ""))
+	if alerts := drain(n); len(alerts) != 0 {
+		t.Fatalf("expected a single resolved alert, got %+v", alerts)
+	}
+
+	// warnings are below the default min level
+	n.Notify(result(consts.StatusAbnormal, consts.LevelWarning, "0"))
+	if alerts := drain(n); len(alerts) != 0 {
+		t.Fatalf("expected no alert for a warning, got %+v", alerts)
+	}
+}
+
+func TestSend_Webhooks(t *testing.T) {
+	bodies := make(chan map[string]any, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("invalid body %s: %v", data, err)
+		}
+		body["path"] = r.URL.Path
+		body["token"] = r.Header.Get("Authorization")
+		bodies <- body
+	}))
+	defer server.Close()
+
+	n, _ := newTestNotifier(t,
+		Webhook{Name: "slack", Type: TypeSlack, URL: server.URL + "/slack"},
+		Webhook{Name: "feishu", Type: TypeFeishu, URL: server.URL + "/feishu", Secret: "s3cret"},
+		Webhook{Name: "pagerduty", Type: TypePagerDuty, URL: server.URL + "/pagerduty", RoutingKey: "key"},
+		Webhook{Name: "generic", URL: server.URL + "/generic", Headers: map[string]string{"Authorization": "Bearer t"}},
+	)
+	n.Notify(result(consts.StatusAbnormal, consts.LevelFatal, "0"))
+	n.send(context.Background(), drain(n)[0])
+	close(bodies)
+
+	got := make(map[string]map[string]any)
+	for body := range bodies {
+		got[body["path"].(string)] = body
+	}
+	if text, _ := got["/slack"]["text"].(string); !strings.HasPrefix(text, "[FIRING] fatal nvidia/GPULost on node-a") || !strings.Contains(text, "*Suggestion*: Reboot the node") {
+		t.Errorf("unexpected slack text %q", text)
+	}
+	if got["/feishu"]["msg_type"] != "text" || got["/feishu"]["sign"] == nil {
+		t.Errorf("unexpected feishu message %v", got["/feishu"])
+	}
+	pd := got["/pagerduty"]
+	if pd["event_action"] != "trigger" || pd["dedup_key"] != "node-a/nvidia/GPULost" || pd["payload"].(map[string]any)["severity"] != "critical" {
+		t.Errorf("unexpected pagerduty event %v", pd)
+	}
+	if got["/generic"]["checker"] != "GPULost" || got["/generic"]["token"] != "Bearer t" {
+		t.Errorf("unexpected generic alert %v", got["/generic"])
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	cfgFile := filepath.Join(t.TempDir(), "user_config.yaml")
+	data := `
+alert:
+  enable: true
+  min_level: warning
+  repeat_interval: 30m
+  webhooks:
+    - name: ops
+      type: pagerduty
+      routing_key: key
+`
+	if err := os.WriteFile(cfgFile, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := LoadConfig(cfgFile)
+	if !cfg.Alert.Enable || cfg.Alert.MinLevel != consts.LevelWarning || cfg.Alert.RepeatInterval != 30*time.Minute || !cfg.Alert.SendResolved {
+		t.Fatalf("unexpected config %+v", cfg.Alert)
+	}
+	if len(cfg.Alert.Webhooks) != 1 || cfg.Alert.Webhooks[0].Endpoint() != pagerDutyEventsURL {
+		t.Fatalf("unexpected webhooks %+v", cfg.Alert.Webhooks)
+	}
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package alert
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scitix/sichek/consts"
+)
+
+// the webhook types
+const (
+	TypeSlack     = "slack"
+	TypeFeishu    = "feishu"
+	TypePagerDuty = "pagerduty"
+	TypeGeneric   = "generic"
+)
+
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// Webhook is an endpoint the alerts are posted to.
+type Webhook struct {
+	Name string `json:"name" yaml:"name"`
+	// Type is the payload format: slack, feishu, pagerduty or generic, the
+	// generic one posts the Alert as JSON.
+	Type string `json:"type" yaml:"type"`
+	// URL is the incoming webhook URL, PagerDuty defaults to its events API.
+	URL string `json:"url" yaml:"url"`
+	// Secret signs the Feishu messages when the signature check of the bot is on.
+	Secret string `json:"secret,omitempty" yaml:"secret,omitempty"`
+	// RoutingKey is the integration key of the PagerDuty service.
+	RoutingKey string `json:"routing_key,omitempty" yaml:"routing_key,omitempty"`
+	// Headers are added to the requests, e.g. an authorization token.
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+}
+
+// Endpoint returns the URL the alerts are posted to.
+func (w *Webhook) Endpoint() string {
+	if w.URL == "" && w.Type == TypePagerDuty {
+		return pagerDutyEventsURL
+	}
+	return w.URL
+}
+
+// Payload formats the alert for the webhook.
+func (w *Webhook) Payload(alert *Alert, now time.Time) ([]byte, http.Header, error) {
+	var payload any
+	switch w.Type {
+	case TypeSlack:
+		payload = map[string]string{"text": alertText(alert, "*", "*")}
+	case TypeFeishu:
+		msg := map[string]any{
+			"msg_type": "text",
+			"content":  map[string]string{"text": alertText(alert, "", "")},
+		}
+		if w.Secret != "" {
+			timestamp := strconv.FormatInt(now.Unix(), 10)
+			msg["timestamp"] = timestamp
+			msg["sign"] = feishuSign(timestamp, w.Secret)
+		}
+		payload = msg
+	case TypePagerDuty:
+		if w.RoutingKey == "" {
+			return nil, nil, fmt.Errorf("pagerduty webhook without a routing_key")
+		}
+		action := "trigger"
+		if alert.Resolved {
+			action = "resolve"
+		}
+		payload = map[string]any{
+			"routing_key":  w.RoutingKey,
+			"event_action": action,
+			"dedup_key":    alert.Key(),
+			"payload": map[string]any{
+				"summary":   alertTitle(alert),
+				"source":    alert.Node,
+				"severity":  pagerDutySeverity(alert.Level),
+				"component": alert.Component,
+				"class":     alert.ErrorName,
+				"timestamp": alert.Time.Format(time.RFC3339),
+				"custom_details": map[string]string{
+					"checker":    alert.Checker,
+					"error_code": alert.ErrorCode,
+					"device":     alert.Device,
+					"detail":     alert.Detail,
+					"suggestion": alert.Suggestion,
+				},
+			},
+		}
+	case TypeGeneric, "":
+		payload = alert
+	default:
+		return nil, nil, fmt.Errorf("unknown webhook type %q", w.Type)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	for key, value := range w.Headers {
+		header.Set(key, value)
+	}
+	return body, header, nil
+}
+
+func alertTitle(alert *Alert) string {
+	state := "FIRING"
+	if alert.Resolved {
+		state = "RESOLVED"
+	}
+	name := alert.ErrorName
+	if alert.ErrorCode != "" {
+		name = fmt.Sprintf("%s (%s)", name, alert.ErrorCode)
+	}
+	return fmt.Sprintf("[%s] %s %s/%s on %s", state, alert.Level, alert.Component, name, alert.Node)
+}
+
+// alertText formats the alert as the lines of a chat message, the field
+// names are wrapped in open and end, e.g. "*" for bold in Slack.
+func alertText(alert *Alert, open, end string) string {
+	var b strings.Builder
+	b.WriteString(alertTitle(alert))
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "\n%s%s%s: %s", open, name, end, value)
+		}
+	}
+	field("Node", alert.Node)
+	field("Component", alert.Component)
+	field("Checker", alert.Checker)
+	field("Device", alert.Device)
+	field("Detail", alert.Detail)
+	if !alert.Resolved {
+		field("Suggestion", alert.Suggestion)
+	}
+	field("Since", alert.StartsAt.Format(time.RFC3339))
+	return b.String()
+}
+
+func pagerDutySeverity(level string) string {
+	switch level {
+	case consts.LevelFatal, consts.LevelCritical:
+		return "critical"
+	case consts.LevelWarning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// feishuSign is the signature of a Feishu bot message, the HMAC-SHA256 of
+// an empty message keyed with the timestamp and the secret.
+func feishuSign(timestamp, secret string) string {
+	mac := hmac.New(sha256.New, []byte(timestamp+"\n"+secret))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package bundle writes the support bundles of sichek, a tar.gz gathering the
+// reports, histories, logs, specs and topology of a node to attach to the
+// support tickets of the hardware vendors.
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"time"
+)
+
+// ManifestName is the name of the manifest written at the root of the bundle.
+const ManifestName = "manifest.json"
+
+// Manifest describes the content of a bundle, written last as manifest.json.
+type Manifest struct {
+	Node     string          `json:"node"`
+	Version  string          `json:"version,omitempty"`
+	Time     time.Time       `json:"time"`
+	Redacted []string        `json:"redacted,omitempty"`
+	Files    []ManifestEntry `json:"files"`
+	// Errors are the items that could not be gathered, keyed by item name,
+	// e.g. the fabricmanager log on a node without NVSwitch.
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// ManifestEntry is a file of the bundle.
+type ManifestEntry struct {
+	Name   string `json:"name"`
+	Size   int    `json:"size"`
+	Source string `json:"source,omitempty"`
+}
+
+// Writer writes the files of a bundle into a gzip compressed tar, redacting
+// their content with its Redactor.
+type Writer struct {
+	gz       *gzip.Writer
+	tw       *tar.Writer
+	dir      string
+	redactor *Redactor
+	modTime  time.Time
+	manifest Manifest
+	names    map[string]bool
+}
+
+// NewWriter creates a Writer writing to w, the files are stored under dir in
+// the archive. The redactor may be nil to keep the content as is.
+func NewWriter(w io.Writer, dir string, redactor *Redactor, manifest Manifest) *Writer {
+	if manifest.Time.IsZero() {
+		manifest.Time = time.Now()
+	}
+	if redactor != nil {
+		manifest.Node = redactor.RedactString(manifest.Node)
+		manifest.Redacted = redactor.Categories()
+	}
+	manifest.Files = nil
+	manifest.Errors = make(map[string]string)
+	gz := gzip.NewWriter(w)
+	return &Writer{
+		gz:       gz,
+		tw:       tar.NewWriter(gz),
+		dir:      dir,
+		redactor: redactor,
+		modTime:  manifest.Time,
+		manifest: manifest,
+		names:    make(map[string]bool),
+	}
+}
+
+// AddFile redacts data and writes it as name, source records where it comes
+// from in the manifest, e.g. the log file or the command.
+func (w *Writer) AddFile(name, source string, data []byte) error {
+	if w.names[name] {
+		return fmt.Errorf("duplicated bundle file %s", name)
+	}
+	if w.redactor != nil {
+		data = w.redactor.Redact(data)
+		source = w.redactor.RedactString(source)
+	}
+	if err := w.write(name, data); err != nil {
+		return err
+	}
+	w.names[name] = true
+	w.manifest.Files = append(w.manifest.Files, ManifestEntry{Name: name, Size: len(data), Source: source})
+	return nil
+}
+
+// AddJSON marshals v with indentation and writes it as name.
+func (w *Writer) AddJSON(name, source string, v any) error {
+	data, err := marshalJSON(v)
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", name, err)
+	}
+	return w.AddFile(name, source, data)
+}
+
+// AddError records in the manifest that item could not be gathered.
+func (w *Writer) AddError(item string, err error) {
+	msg := err.Error()
+	if w.redactor != nil {
+		msg = w.redactor.RedactString(msg)
+	}
+	w.manifest.Errors[item] = msg
+}
+
+// Manifest returns the manifest of the files written so far.
+func (w *Writer) Manifest() Manifest {
+	return w.manifest
+}
+
+// Close writes the manifest and flushes the archive, it does not close the
+// underlying writer.
+func (w *Writer) Close() error {
+	sort.Slice(w.manifest.Files, func(i, j int) bool { return w.manifest.Files[i].Name < w.manifest.Files[j].Name })
+	data, err := marshalJSON(w.manifest)
+	if err != nil {
+		return fmt.Errorf("marshal manifest: %w", err)
+	}
+	if err := w.write(ManifestName, data); err != nil {
+		return err
+	}
+	if err := w.tw.Close(); err != nil {
+		return err
+	}
+	return w.gz.Close()
+}
+
+// marshalJSON indents v and keeps the <> of the pseudonyms unescaped.
+func marshalJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (w *Writer) write(name string, data []byte) error {
+	header := &tar.Header{
+		Name:    path.Join(w.dir, name),
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: w.modTime,
+	}
+	if err := w.tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("write %s header: %w", name, err)
+	}
+	if _, err := w.tw.Write(data); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+// ReadTail returns the last maxBytes of the file at path, from the start of a
+// line, the whole file when maxBytes <= 0.
+func ReadTail(path string, maxBytes int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if maxBytes <= 0 || info.Size() <= maxBytes {
+		return io.ReadAll(f)
+	}
+	if _, err := f.Seek(info.Size()-maxBytes, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	// drop the partial first line
+	for i, b := range data {
+		if b == '\n' {
+			return data[i+1:], nil
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func readBundle(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	files := make(map[string]string)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[header.Name] = string(content)
+	}
+	return files
+}
+
+func TestWriter(t *testing.T) {
+	redactor, err := NewRedactor([]string{RedactHostname, RedactIP}, []string{"gpu-node-01.example.com"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	w := NewWriter(&buf, "sichek-bundle", redactor, Manifest{Node: "gpu-node-01.example.com", Version: "v1.0.0", Time: time.Unix(0, 0)})
+	if err := w.AddFile("logs/syslog.txt", "/var/log/syslog", []byte("gpu-node-01 kernel: mlx5_core 10.0.0.1 link down\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.AddJSON("export.json", "sichek export", map[string]string{"node": "gpu-node-01.example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.AddFile("export.json", "", nil); err == nil {
+		t.Error("expected an error on a duplicated file")
+	}
+	w.AddError("fabricmanager", errors.New("open /var/log/fabricmanager.log on gpu-node-01: no such file"))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files := readBundle(t, buf.Bytes())
+	if got := files["sichek-bundle/logs/syslog.txt"]; got != "<host-2> kernel: mlx5_core <ip-1> link down\n" {
+		t.Errorf("unexpected redacted syslog %q", got)
+	}
+	if got := files["sichek-bundle/export.json"]; !bytes.Contains([]byte(got), []byte(`"node": "<host-1>"`)) {
+		t.Errorf("unexpected redacted export %q", got)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal([]byte(files["sichek-bundle/"+ManifestName]), &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Node != "<host-1>" || manifest.Version != "v1.0.0" {
+		t.Errorf("unexpected manifest node or version: %+v", manifest)
+	}
+	if len(manifest.Files) != 2 || manifest.Files[0].Name != "export.json" || manifest.Files[1].Source != "/var/log/syslog" {
+		t.Errorf("unexpected manifest files: %+v", manifest.Files)
+	}
+	if msg := manifest.Errors["fabricmanager"]; msg != "open /var/log/fabricmanager.log on <host-2>: no such file" {
+		t.Errorf("unexpected manifest error %q", msg)
+	}
+	if len(manifest.Redacted) != 2 {
+		t.Errorf("expected hostname and ip redacted, got %v", manifest.Redacted)
+	}
+}
+
+func TestReadTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fabricmanager.log")
+	if err := os.WriteFile(path, []byte("line 1\nline 2\nline 3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ReadTail(path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "line 3\n" {
+		t.Errorf("expected the last whole line, got %q", data)
+	}
+	data, err = ReadTail(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 21 {
+		t.Errorf("expected the whole file, got %q", data)
+	}
+}
+
+func TestFilterLines(t *testing.T) {
+	data := []byte(`[Mon Oct 12 10:00:00 2026] NVRM: Xid (PCI:0000:18:00): 79, GPU has fallen off the bus
+[Mon Oct 12 10:00:01 2026] usb 1-1: new high-speed USB device
+[Mon Oct 12 10:00:02 2026] mlx5_core 0000:19:00.0: mlx5_port_module_event: Cable unplugged
+[Mon Oct 12 10:00:03 2026] pcieport 0000:00:01.0: AER: Corrected error received
+`)
+	got := string(FilterLines(data, DmesgPatterns, 0))
+	want := `[Mon Oct 12 10:00:00 2026] NVRM: Xid (PCI:0000:18:00): 79, GPU has fallen off the bus
+[Mon Oct 12 10:00:02 2026] mlx5_core 0000:19:00.0: mlx5_port_module_event: Cable unplugged
+[Mon Oct 12 10:00:03 2026] pcieport 0000:00:01.0: AER: Corrected error received
+`
+	if got != want {
+		t.Errorf("unexpected filtered lines:\n%s", got)
+	}
+	if got := string(FilterLines(data, DmesgPatterns, 1)); got != "[Mon Oct 12 10:00:03 2026] pcieport 0000:00:01.0: AER: Corrected error received\n" {
+		t.Errorf("expected the last matching line, got %q", got)
+	}
+	if got := FilterLines(data, []*regexp.Regexp{regexp.MustCompile(`nvswitch`)}, 0); got != nil {
+		t.Errorf("expected no line, got %q", got)
+	}
+
+	if got := string(TailLines([]byte("a\nb\nc\n"), 2)); got != "b\nc\n" {
+		t.Errorf("expected the last 2 lines, got %q", got)
+	}
+	if got := string(TailLines([]byte("a\nb"), 5)); got != "a\nb" {
+		t.Errorf("expected all the lines, got %q", got)
+	}
+}
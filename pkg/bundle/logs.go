@@ -0,0 +1,87 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package bundle
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+)
+
+// DmesgPatterns match the kernel lines of the GPU and HCA drivers gathered
+// from dmesg.
+var DmesgPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`NVRM`),
+	regexp.MustCompile(`mlx5`),
+	regexp.MustCompile(`(?i)nvswitch|nvlink`),
+	regexp.MustCompile(`\bAER\b|pcieport`),
+}
+
+// SyslogPatterns match the syslog lines of the drivers, the fabricmanager and
+// the hardware errors.
+var SyslogPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`NVRM|Xid|SXid`),
+	regexp.MustCompile(`mlx5|ib_core|rdma`),
+	regexp.MustCompile(`(?i)fabricmanager|nvidia-persistenced|dcgm`),
+	regexp.MustCompile(`(?i)\bmce\b|edac|hardware error`),
+	regexp.MustCompile(`\bAER\b`),
+	regexp.MustCompile(`(?i)sichek`),
+}
+
+// FilterLines returns the last maxLines lines of data matching any of
+// patterns, all the matching lines when maxLines <= 0.
+func FilterLines(data []byte, patterns []*regexp.Regexp, maxLines int) []byte {
+	var lines [][]byte
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		for _, re := range patterns {
+			if re.Match(line) {
+				lines = append(lines, append([]byte(nil), line...))
+				break
+			}
+		}
+	}
+	if maxLines > 0 && len(lines) > maxLines {
+		lines = lines[len(lines)-maxLines:]
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return append(bytes.Join(lines, []byte("\n")), '\n')
+}
+
+// TailLines returns the last maxLines lines of data, all of them when maxLines <= 0.
+func TailLines(data []byte, maxLines int) []byte {
+	if maxLines <= 0 {
+		return data
+	}
+	end := len(data)
+	if end > 0 && data[end-1] == '\n' {
+		end--
+	}
+	for i := end - 1; i >= 0; i-- {
+		if data[i] != '\n' {
+			continue
+		}
+		maxLines--
+		if maxLines == 0 {
+			return data[i+1:]
+		}
+	}
+	return data
+}
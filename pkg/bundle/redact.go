@@ -0,0 +1,202 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package bundle
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// The categories of the data a Redactor may redact.
+const (
+	RedactHostname = "hostname"
+	RedactIP       = "ip"
+	RedactMAC      = "mac"
+	RedactSerial   = "serial"
+	RedactAll      = "all"
+)
+
+// RedactCategories lists the categories accepted by NewRedactor, but RedactAll.
+var RedactCategories = []string{RedactHostname, RedactIP, RedactMAC, RedactSerial}
+
+var (
+	macRegexp  = regexp.MustCompile(`\b[0-9a-fA-F]{2}(?::[0-9a-fA-F]{2}){5}\b`)
+	ipv4Regexp = regexp.MustCompile(`\b(?:25[0-5]|2[0-4][0-9]|1?[0-9]?[0-9])(?:\.(?:25[0-5]|2[0-4][0-9]|1?[0-9]?[0-9])){3}\b`)
+	// ipv6Regexp matches the full and the "::" compressed forms, not the
+	// clock times or the MAC addresses.
+	ipv6Regexp = regexp.MustCompile(`\b(?:[0-9a-fA-F]{1,4}:){7}[0-9a-fA-F]{1,4}\b|\b(?:[0-9a-fA-F]{1,4}:){1,6}:(?:[0-9a-fA-F]{1,4}(?::[0-9a-fA-F]{1,4})*)?`)
+	// serialRegexps match the GPU UUIDs, the IB GUIDs and the values of the
+	// serial fields, e.g. "serial_number": "1654321012345".
+	gpuUUIDRegexp     = regexp.MustCompile(`\bGPU-[0-9a-fA-F]{8}(?:-[0-9a-fA-F]{4}){3}-[0-9a-fA-F]{12}\b`)
+	guidRegexp        = regexp.MustCompile(`\b(?:0x[0-9a-fA-F]{16}|[0-9a-fA-F]{4}(?::[0-9a-fA-F]{4}){3})\b`)
+	serialFieldRegexp = regexp.MustCompile(`(?i)("?[a-z_]*serial[a-z_]*"?\s*[:=]\s*"?)([^"\s,}]+)`)
+)
+
+// Redactor replaces the hostnames, IP and MAC addresses and serial numbers of
+// the bundle files with pseudonyms, e.g. <ip-1>. The same value gets the same
+// pseudonym in all the files, so that the vendor can still follow a device
+// across the logs and the reports.
+type Redactor struct {
+	categories map[string]bool
+	hostnames  []string
+	patterns   []*regexp.Regexp
+	pseudonyms map[string]map[string]string
+}
+
+// NewRedactor creates a Redactor of the categories, hostnames are the names
+// of the node redacted with RedactHostname, patterns are extra regexps whose
+// matches are always redacted.
+func NewRedactor(categories []string, hostnames []string, patterns []string) (*Redactor, error) {
+	r := &Redactor{
+		categories: make(map[string]bool),
+		pseudonyms: make(map[string]map[string]string),
+	}
+	for _, category := range categories {
+		category = strings.ToLower(strings.TrimSpace(category))
+		switch category {
+		case "":
+		case RedactAll:
+			for _, c := range RedactCategories {
+				r.categories[c] = true
+			}
+		case RedactHostname, RedactIP, RedactMAC, RedactSerial:
+			r.categories[category] = true
+		default:
+			return nil, fmt.Errorf("unknown redaction %q, expected one of %s or %s", category, strings.Join(RedactCategories, ", "), RedactAll)
+		}
+	}
+	for _, hostname := range hostnames {
+		if hostname == "" {
+			continue
+		}
+		r.hostnames = append(r.hostnames, hostname)
+		// the short name is logged by syslog
+		if short, _, ok := strings.Cut(hostname, "."); ok && short != "" {
+			r.hostnames = append(r.hostnames, short)
+		}
+	}
+	// replace the longest names first, not to leave the domain of an FQDN
+	sort.Slice(r.hostnames, func(i, j int) bool { return len(r.hostnames[i]) > len(r.hostnames[j]) })
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Enabled reports whether the Redactor redacts anything.
+func (r *Redactor) Enabled() bool {
+	return len(r.categories) > 0 || len(r.patterns) > 0
+}
+
+// Categories returns the redacted categories, "pattern" when there are extra patterns.
+func (r *Redactor) Categories() []string {
+	var categories []string
+	for _, c := range RedactCategories {
+		if r.categories[c] {
+			categories = append(categories, c)
+		}
+	}
+	if len(r.patterns) > 0 {
+		categories = append(categories, "pattern")
+	}
+	return categories
+}
+
+// Redact returns data with the values of the categories replaced.
+func (r *Redactor) Redact(data []byte) []byte {
+	if !r.Enabled() {
+		return data
+	}
+	return []byte(r.RedactString(string(data)))
+}
+
+// RedactString returns s with the values of the categories replaced.
+func (r *Redactor) RedactString(s string) string {
+	if !r.Enabled() {
+		return s
+	}
+	for _, re := range r.patterns {
+		s = re.ReplaceAllStringFunc(s, func(m string) string { return r.pseudonym("redacted", m) })
+	}
+	if r.categories[RedactHostname] {
+		for _, hostname := range r.hostnames {
+			s = strings.ReplaceAll(s, hostname, r.pseudonym("host", hostname))
+		}
+	}
+	if r.categories[RedactMAC] {
+		s = macRegexp.ReplaceAllStringFunc(s, func(m string) string { return r.pseudonym("mac", strings.ToLower(m)) })
+	}
+	if r.categories[RedactIP] {
+		s = replaceIPv4(s, func(m string) string { return r.pseudonym("ip", m) })
+		s = ipv6Regexp.ReplaceAllStringFunc(s, func(m string) string { return r.pseudonym("ip", strings.ToLower(m)) })
+	}
+	// after the IPv6 addresses, whose last groups look like GUIDs
+	if r.categories[RedactSerial] {
+		s = gpuUUIDRegexp.ReplaceAllStringFunc(s, func(m string) string { return r.pseudonym("gpu-uuid", m) })
+		s = guidRegexp.ReplaceAllStringFunc(s, func(m string) string { return r.pseudonym("guid", m) })
+		s = serialFieldRegexp.ReplaceAllStringFunc(s, func(m string) string {
+			sub := serialFieldRegexp.FindStringSubmatch(m)
+			return sub[1] + r.pseudonym("serial", sub[2])
+		})
+	}
+	return s
+}
+
+// replaceIPv4 replaces the IPv4 addresses of s, but the loopback ones and the
+// dotted versions, e.g. the 0.3.3.1 of the OFED release 24.01-0.3.3.1.
+func replaceIPv4(s string, repl func(string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range ipv4Regexp.FindAllStringIndex(s, -1) {
+		start, end := loc[0], loc[1]
+		m := s[start:end]
+		if strings.HasPrefix(m, "0.") || strings.HasPrefix(m, "127.") ||
+			(start > 0 && strings.ContainsRune(".-v", rune(s[start-1]))) ||
+			(end < len(s) && s[end] == '.' && end+1 < len(s) && s[end+1] >= '0' && s[end+1] <= '9') {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(repl(m))
+		last = end
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// pseudonym returns the pseudonym of value in category, <category-N> with N
+// the order the values were first seen.
+func (r *Redactor) pseudonym(category, value string) string {
+	values, ok := r.pseudonyms[category]
+	if !ok {
+		values = make(map[string]string)
+		r.pseudonyms[category] = values
+	}
+	if p, ok := values[value]; ok {
+		return p
+	}
+	p := fmt.Sprintf("<%s-%d>", category, len(values)+1)
+	values[value] = p
+	return p
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package bundle
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactor(t *testing.T) {
+	redactor, err := NewRedactor([]string{RedactAll}, []string{"gpu-node-01.example.com"}, []string{`rack-[0-9]+`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name, in, want string
+	}{
+		{"fqdn", "node gpu-node-01.example.com", "node <host-1>"},
+		{"short hostname", "Oct 12 gpu-node-01 kernel:", "Oct 12 <host-2> kernel:"},
+		{"ipv4", "peer 10.0.0.1 and 10.0.0.2, again 10.0.0.1 on 127.0.0.1", "peer <ip-1> and <ip-2>, again <ip-1> on 127.0.0.1"},
+		{"versions", "kernel 5.15.0-91-generic ofed 24.01-0.3.3.1 driver 535.104.05 fw 28.39.1002", "kernel 5.15.0-91-generic ofed 24.01-0.3.3.1 driver 535.104.05 fw 28.39.1002"},
+		{"ipv6", "gid fe80::ba59:9fff:fe12:3456 on port 1", "gid <ip-3> on port 1"},
+		{"time", "at 10:00:03 2026", "at 10:00:03 2026"},
+		{"mac", "mac B8:59:9F:12:34:56", "mac <mac-1>"},
+		{"gpu uuid", "GPU-6a4f1c3e-1b2d-4c5e-8f90-123456789abc failed", "<gpu-uuid-1> failed"},
+		{"guid", "node_guid 0xb8599f0300123456 port_guid b859:9f03:0012:3456", "node_guid <guid-1> port_guid <guid-2>"},
+		{"serial field", `"serial_number": "1654321012345", "board_id": "MT_0000000838"`, `"serial_number": "<serial-1>", "board_id": "MT_0000000838"`},
+		{"pattern", "located in rack-42", "located in <redacted-1>"},
+	}
+	for _, c := range cases {
+		if got := redactor.RedactString(c.in); got != c.want {
+			t.Errorf("%s: RedactString(%q) = %q, want %q", c.name, c.in, got, c.want)
+		}
+	}
+	if got := redactor.Categories(); len(got) != 5 {
+		t.Errorf("expected all the categories and pattern, got %v", got)
+	}
+}
+
+func TestRedactorDisabled(t *testing.T) {
+	redactor, err := NewRedactor(nil, []string{"gpu-node-01"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := "gpu-node-01 10.0.0.1"
+	if redactor.Enabled() || redactor.RedactString(in) != in {
+		t.Errorf("expected nothing redacted, got %q", redactor.RedactString(in))
+	}
+	if _, err := NewRedactor([]string{"password"}, nil, nil); err == nil {
+		t.Error("expected an error on an unknown category")
+	}
+	if _, err := NewRedactor(nil, nil, []string{"("}); err == nil {
+		t.Error("expected an error on an invalid pattern")
+	}
+}
+
+func TestMaskSecrets(t *testing.T) {
+	cfg := `reporter:
+  endpoint: "http://collector:38080/api/v1/snapshots"
+  auth_token: abc123
+alert:
+  enable: true
+  webhooks:
+    - name: ops-slack
+      type: slack  # the team channel
+      url: "https://hooks.slack.com/services/T000/B000/XXXX"
+    - name: ops-pagerduty
+      type: pagerduty
+      url: ""
+      routing_key: R0UT1NGK3Y
+      secret: s3cr3t
+      headers:
+        Authorization: Bearer xyz
+`
+	masked, err := MaskSecrets([]byte(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(masked)
+	for _, secret := range []string{"abc123", "XXXX", "R0UT1NGK3Y", "s3cr3t", "Bearer xyz"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("secret %q left in the config:\n%s", secret, got)
+		}
+	}
+	for _, kept := range []string{"ops-slack", "type: pagerduty", "Authorization: \"<masked>\"", "# the team channel", "url: \"\"", "http://collector:38080"} {
+		if !strings.Contains(got, kept) {
+			t.Errorf("expected %q kept in the config:\n%s", kept, got)
+		}
+	}
+
+	if _, err := MaskSecrets([]byte("alert: [unterminated")); err == nil {
+		t.Error("expected an error on an invalid config")
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package bundle
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MaskedValue replaces the secrets of the user config in the bundle.
+const MaskedValue = "<masked>"
+
+// secretKeys are the keys of the user config whose values are secrets, e.g.
+// the webhooks of alert.webhooks, the URL of a Slack or Feishu webhook
+// embeds its token.
+var secretKeys = map[string]bool{
+	"url":         true,
+	"secret":      true,
+	"routing_key": true,
+	"headers":     true,
+}
+
+// secretKeyParts mark the other keys holding credentials, e.g. a report token.
+var secretKeyParts = []string{"token", "password", "secret", "api_key"}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	if secretKeys[key] {
+		return true
+	}
+	for _, part := range secretKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaskSecrets replaces the values of the secret keys of a YAML user config
+// with MaskedValue, whatever the redaction of the bundle, so that the webhook
+// URLs and tokens never leave the node. A config that cannot be parsed is an
+// error rather than added as is.
+func MaskSecrets(data []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse the config to mask its secrets: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return data, nil
+	}
+	maskNode(&doc)
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func maskNode(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			maskNode(child)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if isSecretKey(node.Content[i].Value) {
+				maskValue(node.Content[i+1])
+				continue
+			}
+			maskNode(node.Content[i+1])
+		}
+	}
+}
+
+// maskValue masks a scalar, or every scalar of a mapping or a sequence, e.g.
+// the headers of a webhook. The empty values are kept to show they are unset.
+func maskValue(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode {
+		if node.Value != "" && node.Tag != "!!null" {
+			node.Value = MaskedValue
+			node.Tag = "!!str"
+			node.Style = yaml.DoubleQuotedStyle
+		}
+		return
+	}
+	if node.Kind == yaml.MappingNode {
+		// keep the keys of the mapping
+		for i := 1; i < len(node.Content); i += 2 {
+			maskValue(node.Content[i])
+		}
+		return
+	}
+	for _, child := range node.Content {
+		maskValue(child)
+	}
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package capability probes the privileges sichek runs with, so that the
+// checkers needing a privilege it lacks, e.g. reading the PCI extended config
+// space without CAP_SYS_ADMIN, are reported as skipped instead of failing
+// with misleading errors when sichek runs rootless.
+package capability
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Capability is a privilege or an access some checkers depend on.
+type Capability string
+
+const (
+	Root     Capability = "root"
+	SysAdmin Capability = "CAP_SYS_ADMIN"
+	NetAdmin Capability = "CAP_NET_ADMIN"
+	SysRawIO Capability = "CAP_SYS_RAWIO"
+	Syslog   Capability = "CAP_SYSLOG"
+	// Kmsg is the read access to /dev/kmsg, restricted by dmesg_restrict.
+	Kmsg Capability = "kmsg"
+	// PCIConfig is the read access to the config space past the first 64
+	// bytes, where the PCIe capabilities are, e.g. the MRR or the ACS control.
+	PCIConfig Capability = "pci-config"
+	// IPMI is the access to the IPMI device ipmitool talks to the BMC through.
+	IPMI Capability = "ipmi"
+)
+
+// bits of the linux capabilities in the CapEff mask of /proc/self/status
+var capBits = map[Capability]uint{
+	NetAdmin: 12,
+	SysRawIO: 17,
+	SysAdmin: 21,
+	Syslog:   34,
+}
+
+// requirements are what the user is told to grant when a capability is missing.
+var requirements = map[Capability]string{
+	Root:      "root",
+	SysAdmin:  "root/CAP_SYS_ADMIN",
+	NetAdmin:  "root/CAP_NET_ADMIN",
+	SysRawIO:  "root/CAP_SYS_RAWIO",
+	Syslog:    "root/CAP_SYSLOG",
+	Kmsg:      "root/CAP_SYSLOG to read /dev/kmsg",
+	PCIConfig: "root/CAP_SYS_ADMIN to read the PCI config space",
+	IPMI:      "root to open the IPMI device",
+}
+
+// the probed paths are vars so that tests can point them at fake files
+var (
+	procStatusPath  = "/proc/self/status"
+	kmsgPath        = "/dev/kmsg"
+	pciDevicesPath  = "/sys/bus/pci/devices"
+	ipmiDevicePaths = []string{"/dev/ipmi0", "/dev/ipmi/0", "/dev/ipmidev/0"}
+	geteuid         = os.Geteuid
+)
+
+// pciHeaderSize is the part of the config space unprivileged readers get.
+const pciHeaderSize = 64
+
+// Set is the capabilities sichek holds.
+type Set struct {
+	has map[Capability]bool
+}
+
+// NewSet returns a set holding caps.
+func NewSet(caps ...Capability) *Set {
+	s := &Set{has: make(map[Capability]bool, len(caps))}
+	for _, c := range caps {
+		s.has[c] = true
+	}
+	return s
+}
+
+// Has reports whether the set holds c.
+func (s *Set) Has(c Capability) bool {
+	return s != nil && s.has[c]
+}
+
+// Missing returns the caps not in the set, in the order given.
+func (s *Set) Missing(caps ...Capability) []Capability {
+	var missing []Capability
+	for _, c := range caps {
+		if !s.Has(c) {
+			missing = append(missing, c)
+		}
+	}
+	return missing
+}
+
+// List returns the capabilities of the set, sorted.
+func (s *Set) List() []Capability {
+	var caps []Capability
+	if s != nil {
+		for c, ok := range s.has {
+			if ok {
+				caps = append(caps, c)
+			}
+		}
+	}
+	sort.Slice(caps, func(i, j int) bool { return caps[i] < caps[j] })
+	return caps
+}
+
+// Requirement describes what grants the missing caps, e.g. "root/CAP_SYS_ADMIN".
+func Requirement(missing []Capability) string {
+	var parts []string
+	seen := make(map[string]bool)
+	for _, c := range missing {
+		req, ok := requirements[c]
+		if !ok {
+			req = string(c)
+		}
+		if !seen[req] {
+			seen[req] = true
+			parts = append(parts, req)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+var (
+	detectOnce sync.Once
+	detected   *Set
+)
+
+// Detect probes the capabilities once and returns the cached result, the
+// privileges of a process do not change while it runs.
+func Detect() *Set {
+	detectOnce.Do(func() {
+		detected = Probe()
+	})
+	return detected
+}
+
+// Probe probes the capabilities of the process.
+func Probe() *Set {
+	s := NewSet()
+	root := geteuid() == 0
+	s.has[Root] = root
+	capEff, err := readCapEff(procStatusPath)
+	if err != nil {
+		// without /proc assume root holds every capability, as it does by default
+		capEff = 0
+		if root {
+			capEff = ^uint64(0)
+		}
+	}
+	for c, bit := range capBits {
+		s.has[c] = capEff&(1<<bit) != 0
+	}
+	s.has[Kmsg] = canOpen(kmsgPath, os.O_RDONLY)
+	s.has[PCIConfig] = canReadPCIConfig(pciDevicesPath)
+	s.has[IPMI] = canOpenIPMI()
+	return s
+}
+
+// readCapEff parses the effective capability mask of /proc/<pid>/status.
+func readCapEff(path string) (uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "CapEff:"); ok {
+			return strconv.ParseUint(strings.TrimSpace(value), 16, 64)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, errors.New("no CapEff in " + path)
+}
+
+// canOpen reports whether path opens with flag. A missing path is not a
+// privilege issue: it counts as accessible so that the checker still runs
+// and reports the missing device.
+func canOpen(path string, flag int) bool {
+	f, err := os.OpenFile(path, flag, 0)
+	if err != nil {
+		return !errors.Is(err, fs.ErrPermission)
+	}
+	f.Close()
+	return true
+}
+
+func canOpenIPMI() bool {
+	for _, path := range ipmiDevicePaths {
+		if _, err := os.Stat(path); err == nil {
+			return canOpen(path, os.O_RDWR)
+		}
+	}
+	return true
+}
+
+// canReadPCIConfig reads the config space of the first PCI device: the kernel
+// truncates it to the standard header for the readers without CAP_SYS_ADMIN.
+func canReadPCIConfig(devicesPath string) bool {
+	entries, err := os.ReadDir(devicesPath)
+	if err != nil || len(entries) == 0 {
+		return true
+	}
+	data, err := os.ReadFile(filepath.Join(devicesPath, entries[0].Name(), "config"))
+	if err != nil {
+		return !errors.Is(err, fs.ErrPermission)
+	}
+	return len(data) > pciHeaderSize
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package capability
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// useFakeHost points the probed paths at a temp dir holding a status file
+// with capEff and a PCI device whose config space is configSize bytes.
+func useFakeHost(t *testing.T, euid int, capEff string, configSize int) {
+	t.Helper()
+	dir := t.TempDir()
+	origStatus, origKmsg, origPCI, origIPMI, origEuid := procStatusPath, kmsgPath, pciDevicesPath, ipmiDevicePaths, geteuid
+	t.Cleanup(func() {
+		procStatusPath, kmsgPath, pciDevicesPath, ipmiDevicePaths, geteuid = origStatus, origKmsg, origPCI, origIPMI, origEuid
+	})
+
+	procStatusPath = filepath.Join(dir, "status")
+	status := "Name:\tsichek\nCapInh:\t0000000000000000\nCapEff:\t" + capEff + "\nCapBnd:\t000001ffffffffff\n"
+	if err := os.WriteFile(procStatusPath, []byte(status), 0644); err != nil {
+		t.Fatal(err)
+	}
+	kmsgPath = filepath.Join(dir, "kmsg")
+	if err := os.WriteFile(kmsgPath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	pciDevicesPath = filepath.Join(dir, "devices")
+	device := filepath.Join(pciDevicesPath, "0000:00:00.0")
+	if err := os.MkdirAll(device, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(device, "config"), make([]byte, configSize), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ipmiDevicePaths = []string{filepath.Join(dir, "ipmi0")}
+	geteuid = func() int { return euid }
+}
+
+func TestProbeRoot(t *testing.T) {
+	useFakeHost(t, 0, "000001ffffffffff", 4096)
+	s := Probe()
+	for _, c := range []Capability{Root, SysAdmin, NetAdmin, SysRawIO, Syslog, Kmsg, PCIConfig, IPMI} {
+		if !s.Has(c) {
+			t.Errorf("expected root to hold %s", c)
+		}
+	}
+}
+
+func TestProbeRootless(t *testing.T) {
+	// only CAP_NET_ADMIN, as granted to a container with --cap-add NET_ADMIN
+	useFakeHost(t, 1000, "0000000000001000", pciHeaderSize)
+	s := Probe()
+	if s.Has(Root) || s.Has(SysAdmin) || s.Has(Syslog) {
+		t.Errorf("unexpected privileges in %v", s.List())
+	}
+	if !s.Has(NetAdmin) {
+		t.Errorf("expected CAP_NET_ADMIN in %v", s.List())
+	}
+	if s.Has(PCIConfig) {
+		t.Errorf("expected a truncated config space to deny %s", PCIConfig)
+	}
+	// a missing device is reported by the checker itself, not as a privilege issue
+	if !s.Has(IPMI) {
+		t.Errorf("expected a missing IPMI device not to be a missing privilege")
+	}
+}
+
+func TestMissingAndRequirement(t *testing.T) {
+	s := NewSet(Root, NetAdmin)
+	missing := s.Missing(SysAdmin, NetAdmin, PCIConfig, SysAdmin)
+	if len(missing) != 3 || missing[0] != SysAdmin || missing[1] != PCIConfig {
+		t.Fatalf("unexpected missing capabilities %v", missing)
+	}
+	want := "root/CAP_SYS_ADMIN, root/CAP_SYS_ADMIN to read the PCI config space"
+	if got := Requirement(missing); got != want {
+		t.Errorf("Requirement() = %q, want %q", got, want)
+	}
+	var nilSet *Set
+	if nilSet.Has(Root) || len(nilSet.Missing(Root)) != 1 {
+		t.Errorf("expected a nil set to hold nothing")
+	}
+}
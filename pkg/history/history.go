@@ -0,0 +1,121 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package history persists the health check results of the daemon on local
+// storage, so that past failures can be reviewed after a node reboot.
+package history
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the `history` section of the user config.
+type Config struct {
+	History struct {
+		Enable    bool          `json:"enable" yaml:"enable"`
+		Path      string        `json:"path" yaml:"path"`
+		Retention time.Duration `json:"retention" yaml:"retention"`
+	} `json:"history" yaml:"history"`
+}
+
+// LoadConfig loads the history config from cfgFile, missing fields keep their
+// defaults. yaml.v3 is used so that retention accepts duration strings like 168h.
+func LoadConfig(cfgFile string) *Config {
+	config := &Config{}
+	config.History.Enable = true
+	config.History.Path = consts.DefaultHistoryPath
+	config.History.Retention = 7 * 24 * time.Hour
+
+	if cfgFile != "" {
+		data, err := os.ReadFile(cfgFile)
+		if err == nil {
+			err = yaml.Unmarshal(data, config)
+		}
+		if err != nil {
+			logrus.WithField("history", "config").Warnf("Failed to load history config from %s, using defaults: %v", cfgFile, err)
+		}
+	}
+	if config.History.Path == "" {
+		config.History.Path = consts.DefaultHistoryPath
+	}
+	return config
+}
+
+// Record is one health check result of a component. Info holds the collected
+// info of the component and is only kept for abnormal results to bound the
+// size of the history.
+type Record struct {
+	Time      time.Time       `json:"time"`
+	Node      string          `json:"node,omitempty"`
+	Component string          `json:"component"`
+	Result    *common.Result  `json:"result"`
+	Info      json.RawMessage `json:"info,omitempty"`
+}
+
+// Query selects the records returned by Store.Query.
+type Query struct {
+	// Component matches all components if empty.
+	Component string
+	Since     time.Time
+	// AbnormalOnly drops the records whose result is normal.
+	AbnormalOnly bool
+}
+
+// Match reports whether the record is selected by the query.
+func (q *Query) Match(r *Record) bool {
+	if r.Time.Before(q.Since) {
+		return false
+	}
+	if q.Component != "" && r.Component != q.Component {
+		return false
+	}
+	if q.AbnormalOnly && (r.Result == nil || r.Result.Status != consts.StatusAbnormal) {
+		return false
+	}
+	return true
+}
+
+// Store is the persistence layer of the history.
+type Store interface {
+	Append(record *Record) error
+	// Query returns the matched records ordered by time.
+	Query(query Query) ([]*Record, error)
+	// Prune removes the records older than the retention.
+	Prune(now time.Time) error
+	Close() error
+}
+
+// NewRecord builds the record of a result, info is attached to abnormal results only.
+func NewRecord(component string, result *common.Result, info common.Info) *Record {
+	record := &Record{
+		Time:      time.Now(),
+		Node:      result.Node,
+		Component: component,
+		Result:    result,
+	}
+	if result.Status == consts.StatusAbnormal && info != nil {
+		if data, err := json.Marshal(info); err == nil {
+			record.Info = data
+		}
+	}
+	return record
+}
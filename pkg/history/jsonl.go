@@ -0,0 +1,199 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	filePrefix = "history-"
+	fileSuffix = ".jsonl"
+	dayLayout  = "2006-01-02"
+	// maxLineSize bounds a record, the info of a large node can take a few MBs.
+	maxLineSize = 16 * 1024 * 1024
+)
+
+// JSONLStore stores the records as JSON lines in one file per day under dir,
+// retention is applied on whole files.
+type JSONLStore struct {
+	mu        sync.Mutex
+	dir       string
+	retention time.Duration
+	day       string
+	file      *os.File
+}
+
+// NewJSONLStore creates the store under dir and prunes the expired files.
+func NewJSONLStore(dir string, retention time.Duration) (*JSONLStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create history dir %s failed: %w", dir, err)
+	}
+	s := &JSONLStore{dir: dir, retention: retention}
+	if err := s.Prune(time.Now()); err != nil {
+		logrus.WithField("history", "jsonl").Warnf("prune history failed: %v", err)
+	}
+	return s, nil
+}
+
+func (s *JSONLStore) Append(record *Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshal history record failed: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	day := record.Time.Format(dayLayout)
+	if s.file == nil || s.day != day {
+		if s.file != nil {
+			_ = s.file.Close()
+		}
+		file, err := os.OpenFile(s.path(day), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			s.file = nil
+			return fmt.Errorf("open history file failed: %w", err)
+		}
+		s.file, s.day = file, day
+		// A new day is a good time to drop the expired files.
+		if err := s.prune(record.Time); err != nil {
+			logrus.WithField("history", "jsonl").Warnf("prune history failed: %v", err)
+		}
+	}
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write history record failed: %w", err)
+	}
+	return nil
+}
+
+func (s *JSONLStore) Query(query Query) ([]*Record, error) {
+	files, err := s.files()
+	if err != nil {
+		return nil, err
+	}
+	sinceDay := query.Since.Format(dayLayout)
+	var records []*Record
+	for _, day := range files {
+		if !query.Since.IsZero() && day < sinceDay {
+			continue
+		}
+		dayRecords, err := readRecords(s.path(day), &query)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, dayRecords...)
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Time.Before(records[j].Time) })
+	return records, nil
+}
+
+func (s *JSONLStore) Prune(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.prune(now)
+}
+
+func (s *JSONLStore) prune(now time.Time) error {
+	if s.retention <= 0 {
+		return nil
+	}
+	files, err := s.files()
+	if err != nil {
+		return err
+	}
+	// A file is expired once its last record, at the end of its day, is out of the retention.
+	expireDay := now.Add(-s.retention).Format(dayLayout)
+	for _, day := range files {
+		if day >= expireDay || day == s.day {
+			continue
+		}
+		if err := os.Remove(s.path(day)); err != nil {
+			return fmt.Errorf("remove expired history file failed: %w", err)
+		}
+		logrus.WithField("history", "jsonl").Infof("removed expired history of %s", day)
+	}
+	return nil
+}
+
+func (s *JSONLStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+func (s *JSONLStore) path(day string) string {
+	return filepath.Join(s.dir, filePrefix+day+fileSuffix)
+}
+
+// files returns the days of the history files in ascending order.
+func (s *JSONLStore) files() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("read history dir %s failed: %w", s.dir, err)
+	}
+	var days []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		days = append(days, strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix))
+	}
+	sort.Strings(days)
+	return days, nil
+}
+
+func readRecords(path string, query *Query) ([]*Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open history file failed: %w", err)
+	}
+	defer file.Close()
+
+	var records []*Record
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			// A torn line left by a crash must not hide the rest of the history.
+			logrus.WithField("history", "jsonl").Warnf("skip corrupted record in %s: %v", path, err)
+			continue
+		}
+		if query.Match(&record) {
+			records = append(records, &record)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read history file %s failed: %w", path, err)
+	}
+	return records, nil
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+)
+
+func newRecord(component, status string, ts time.Time) *Record {
+	return &Record{
+		Time:      ts,
+		Component: component,
+		Result:    &common.Result{Item: component, Status: status},
+	}
+}
+
+func TestJSONLStore_AppendQuery(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewJSONLStore(dir, 0)
+	if err != nil {
+		t.Fatalf("NewJSONLStore: %v", err)
+	}
+	now := time.Now()
+	records := []*Record{
+		newRecord(consts.ComponentNameNvidia, consts.StatusAbnormal, now.Add(-48*time.Hour)),
+		newRecord(consts.ComponentNameNvidia, consts.StatusNormal, now.Add(-2*time.Hour)),
+		newRecord(consts.ComponentNameInfiniband, consts.StatusAbnormal, now.Add(-time.Hour)),
+		newRecord(consts.ComponentNameNvidia, consts.StatusAbnormal, now),
+	}
+	for _, record := range records {
+		if err := store.Append(record); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	got, err := store.Query(Query{})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(got) != len(records) {
+		t.Fatalf("got %d records, want %d", len(got), len(records))
+	}
+
+	got, err = store.Query(Query{Component: consts.ComponentNameNvidia, Since: now.Add(-24 * time.Hour), AbnormalOnly: true})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(got) != 1 || !got[0].Time.Equal(records[3].Time) {
+		t.Errorf("unexpected records: %+v", got)
+	}
+}
+
+func TestJSONLStore_Prune(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewJSONLStore(dir, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("NewJSONLStore: %v", err)
+	}
+	now := time.Now()
+	if err := store.Append(newRecord(consts.ComponentNameNvidia, consts.StatusAbnormal, now.Add(-72*time.Hour))); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	// Switching to a new day closes the old file and prunes it.
+	if err := store.Append(newRecord(consts.ComponentNameNvidia, consts.StatusAbnormal, now)); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	defer store.Close()
+
+	days, err := store.files()
+	if err != nil {
+		t.Fatalf("files: %v", err)
+	}
+	if len(days) != 1 || days[0] != now.Format(dayLayout) {
+		t.Errorf("expected only today's history, got %v", days)
+	}
+}
+
+func TestJSONLStore_SkipCorruptedLine(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewJSONLStore(dir, 0)
+	if err != nil {
+		t.Fatalf("NewJSONLStore: %v", err)
+	}
+	now := time.Now()
+	if err := store.Append(newRecord(consts.ComponentNameNvidia, consts.StatusAbnormal, now)); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	store.Close()
+
+	path := filepath.Join(dir, filePrefix+now.Format(dayLayout)+fileSuffix)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	_, _ = f.WriteString(`{"time": "2024-`)
+	f.Close()
+
+	got, err := store.Query(Query{})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(got) != 1 {
+		t.Errorf("got %d records, want 1", len(got))
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "cfg.yaml")
+	if err := os.WriteFile(p, []byte("history:\n  enable: false\n  retention: 48h\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	cfg := LoadConfig(p)
+	if cfg.History.Enable || cfg.History.Retention != 48*time.Hour || cfg.History.Path != consts.DefaultHistoryPath {
+		t.Errorf("unexpected config: %+v", cfg.History)
+	}
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package hostfs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// the own view of the process, not joined with Root, a var so that tests can
+// point it at a fake tree
+var selfRoot = "/"
+
+// cgroupMarkers are found in the cgroup path of a containerized process.
+var cgroupMarkers = []string{"kubepods", "docker", "containerd", "crio", "libpod"}
+
+// Environment is how sichek sees the host it checks when it runs in a
+// container, e.g. in a pod of the sichek DaemonSet.
+type Environment struct {
+	HostRoot    string `json:"host_root"`
+	InContainer bool   `json:"in_container"`
+	// HostPID is set when the processes of the host are visible, i.e. the pod
+	// runs with hostPID or /proc of the host is mounted under HostRoot.
+	HostPID bool `json:"host_pid"`
+	// HostNetwork is set when sichek shares the network namespace of the host.
+	HostNetwork bool `json:"host_network"`
+	// HostNetNS is the network namespace of the host when sichek runs in its
+	// own and can reach the one of the host, e.g. /host/proc/1/ns/net.
+	HostNetNS string   `json:"host_netns,omitempty"`
+	Warnings  []string `json:"warnings,omitempty"`
+}
+
+// SetRoot sets Root to root, e.g. /host where the DaemonSet mounts / of the
+// host, after checking the sysfs and procfs of the host are under it.
+func SetRoot(root string) error {
+	if root == "" {
+		root = "/"
+	}
+	for _, dir := range []string{"sys", "proc"} {
+		if info, err := os.Stat(filepath.Join(root, dir)); err != nil || !info.IsDir() {
+			return fmt.Errorf("host root %s has no %s directory", root, dir)
+		}
+	}
+	Root = filepath.Clean(root)
+	return nil
+}
+
+// DetectEnvironment probes whether sichek runs in a container and which
+// namespaces of the host it shares, and warns about what the container hides
+// from the checks.
+func DetectEnvironment() *Environment {
+	env := &Environment{HostRoot: Root, InContainer: inContainer()}
+	hostInit := readTrimmed(Path("/proc/1/comm"))
+	env.HostPID = !env.InContainer || hostInit == "systemd" || hostInit == "init"
+
+	selfNet, selfErr := os.Readlink(filepath.Join(selfRoot, "proc/self/ns/net"))
+	hostNet, hostErr := os.Readlink(Path("/proc/1/ns/net"))
+	switch {
+	case !env.InContainer:
+		env.HostNetwork = true
+	case selfErr == nil && hostErr == nil && env.HostPID:
+		env.HostNetwork = selfNet == hostNet
+	default:
+		// without the namespace of the host, guess from the interfaces: the
+		// network namespace of a pod only holds virtual ones, e.g. a veth
+		env.HostNetwork = hasPhysicalNetdev()
+	}
+	if !env.HostNetwork && env.HostPID && hostErr == nil {
+		env.HostNetNS = Path("/proc/1/ns/net")
+	}
+
+	if !env.InContainer && Root == "/" {
+		return env
+	}
+	if !env.HostPID {
+		env.warn("the processes of the host are not visible, run with hostPID or mount /proc of the host under --host-root")
+	}
+	if !env.HostNetwork {
+		if env.HostNetNS != "" {
+			env.warn("running in a network namespace of its own, the routes are read from the one of the host at %s", env.HostNetNS)
+		} else {
+			env.warn("running in a network namespace of its own, the routes and gateways reflect the pod network, run with hostNetwork")
+		}
+	}
+	if hasNvidiaGPU() && !exists(filepath.Join(selfRoot, "dev/nvidiactl")) {
+		env.warn("NVIDIA GPUs are on the host but /dev/nvidiactl is hidden, run privileged or with the NVIDIA container runtime")
+	}
+	if entries, _ := os.ReadDir(Path("/sys/class/infiniband")); len(entries) > 0 && !exists(filepath.Join(selfRoot, "dev/infiniband")) {
+		env.warn("RDMA devices are on the host but /dev/infiniband is hidden, run privileged or mount /dev/infiniband")
+	}
+	if !exists(filepath.Join(selfRoot, "dev/kmsg")) {
+		env.warn("/dev/kmsg is hidden, the kernel messages of the host are not checked")
+	}
+	return env
+}
+
+func (e *Environment) warn(format string, args ...any) {
+	e.Warnings = append(e.Warnings, fmt.Sprintf(format, args...))
+}
+
+func inContainer() bool {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		return true
+	}
+	for _, marker := range []string{".dockerenv", "run/.containerenv"} {
+		if exists(filepath.Join(selfRoot, marker)) {
+			return true
+		}
+	}
+	cgroup := readTrimmed(filepath.Join(selfRoot, "proc/self/cgroup"))
+	for _, marker := range cgroupMarkers {
+		if strings.Contains(cgroup, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPhysicalNetdev reports whether the network namespace of sichek holds an
+// interface backed by a device, /sys/class/net follows the namespace of the
+// process that mounted sysfs.
+func hasPhysicalNetdev() bool {
+	netdevs, _ := filepath.Glob(filepath.Join(selfRoot, "sys/class/net/*/device"))
+	return len(netdevs) > 0
+}
+
+// hasNvidiaGPU reports whether the host has an NVIDIA display or 3D controller.
+func hasNvidiaGPU() bool {
+	devices, _ := filepath.Glob(Path("/sys/bus/pci/devices/*"))
+	for _, device := range devices {
+		if readTrimmed(filepath.Join(device, "vendor")) == "0x10de" && strings.HasPrefix(readTrimmed(filepath.Join(device, "class")), "0x03") {
+			return true
+		}
+	}
+	return false
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func readTrimmed(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package hostfs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// tree creates the files, a value starting with "->" is a symlink target and
+// an empty one a directory.
+func tree(t *testing.T, files map[string]string) string {
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		dir := path
+		if content != "" {
+			dir = filepath.Dir(path)
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		var err error
+		switch {
+		case content == "":
+		case strings.HasPrefix(content, "->"):
+			err = os.Symlink(strings.TrimPrefix(content, "->"), path)
+		default:
+			err = os.WriteFile(path, []byte(content+"\n"), 0644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func withRoots(t *testing.T, self, host string) {
+	origSelf, origRoot := selfRoot, Root
+	t.Cleanup(func() { selfRoot, Root = origSelf, origRoot })
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	selfRoot = self
+	if err := SetRoot(host); err != nil {
+		t.Fatal(err)
+	}
+}
+
+var hostTree = map[string]string{
+	"proc/1/comm":   "systemd",
+	"proc/1/ns/net": "->net:[4026531840]",
+	"sys/bus/pci/devices/0000:18:00.0/vendor": "0x10de",
+	"sys/bus/pci/devices/0000:18:00.0/class":  "0x030200",
+	"sys/class/infiniband/mlx5_0":             "",
+}
+
+func TestDetectEnvironmentHostNamespaces(t *testing.T) {
+	withRoots(t, tree(t, map[string]string{
+		".dockerenv":       "",
+		"proc/self/ns/net": "->net:[4026531840]",
+		"dev/nvidiactl":    "x",
+		"dev/infiniband":   "",
+		"dev/kmsg":         "x",
+	}), tree(t, hostTree))
+
+	env := DetectEnvironment()
+	if !env.InContainer || !env.HostPID || !env.HostNetwork || env.HostNetNS != "" {
+		t.Errorf("unexpected environment %+v", env)
+	}
+	if len(env.Warnings) != 0 {
+		t.Errorf("unexpected warnings %v", env.Warnings)
+	}
+}
+
+func TestDetectEnvironmentOwnNetns(t *testing.T) {
+	host := tree(t, hostTree)
+	withRoots(t, tree(t, map[string]string{
+		"proc/self/cgroup": "0::/kubepods.slice/kubepods-pod1.slice/cri-containerd-abc.scope",
+		"proc/self/ns/net": "->net:[4026532001]",
+	}), host)
+
+	env := DetectEnvironment()
+	if !env.InContainer || !env.HostPID || env.HostNetwork || env.HostNetNS != filepath.Join(host, "proc/1/ns/net") {
+		t.Errorf("unexpected environment %+v", env)
+	}
+	want := []string{"network namespace of its own", "/dev/nvidiactl is hidden", "/dev/infiniband is hidden", "/dev/kmsg is hidden"}
+	if len(env.Warnings) != len(want) {
+		t.Fatalf("expected %d warnings, got %v", len(want), env.Warnings)
+	}
+	for i, w := range want {
+		if !strings.Contains(env.Warnings[i], w) {
+			t.Errorf("warning %d: expected %q in %q", i, w, env.Warnings[i])
+		}
+	}
+}
+
+func TestDetectEnvironmentNoHostPID(t *testing.T) {
+	withRoots(t, tree(t, map[string]string{
+		".dockerenv":                 "",
+		"proc/self/ns/net":           "->net:[4026532001]",
+		"sys/class/net/eth0/address": "0a:58:0a:00:00:05",
+		"dev/kmsg":                   "x",
+	}), tree(t, map[string]string{"proc/1/comm": "sichek", "sys": ""}))
+
+	env := DetectEnvironment()
+	if env.HostPID || env.HostNetwork || env.HostNetNS != "" {
+		t.Errorf("unexpected environment %+v", env)
+	}
+	if len(env.Warnings) != 2 || !strings.Contains(env.Warnings[0], "hostPID") || !strings.Contains(env.Warnings[1], "run with hostNetwork") {
+		t.Errorf("unexpected warnings %v", env.Warnings)
+	}
+}
+
+func TestSetRoot(t *testing.T) {
+	origRoot := Root
+	t.Cleanup(func() { Root = origRoot })
+	if err := SetRoot(tree(t, map[string]string{"sys": ""})); err == nil || !strings.Contains(err.Error(), "no proc directory") {
+		t.Errorf("expected the missing proc to fail, got %v", err)
+	}
+	host := tree(t, map[string]string{"sys": "", "proc": ""})
+	if err := SetRoot(host + "/"); err != nil {
+		t.Fatalf("SetRoot: %v", err)
+	}
+	if got := Path("/sys/class/net"); got != filepath.Join(host, "sys/class/net") {
+		t.Errorf("unexpected path %s", got)
+	}
+}
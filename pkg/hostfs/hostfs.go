@@ -0,0 +1,29 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package hostfs locates the sysfs and procfs files the collectors read on
+// the host. The collectors join their absolute paths with Root, so that the
+// tests can point them at a fake tree built from a fixture, see hostfstest.
+package hostfs
+
+import "path/filepath"
+
+// Root is the directory the host paths are relative to, "/" on a node.
+var Root = "/"
+
+// Path joins the absolute host path elem with Root.
+func Path(elem ...string) string {
+	return filepath.Join(append([]string{Root}, elem...)...)
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package hostfstest builds fake host trees for the collector tests, so that
+// the infiniband, pcie and cpu collectors can be tested in CI containers
+// without the hardware.
+//
+// A fixture is a text archive of the files under the host root:
+//
+//	# comment
+//	-- sys/class/infiniband/mlx5_0/fw_ver --
+//	28.39.1002
+//	-- sys/class/infiniband/mlx5_0/device -> ../../../devices/pci0000:15/0000:15:01.0/0000:16:00.0 --
+//	-- sys/class/infiniband/mlx5_0/ports/1/counters/ --
+//
+// A "-- path --" line starts a file holding the lines up to the next header,
+// a "-- path -> target --" line creates a symlink and a "-- path/ --" line an
+// empty directory. The lines before the first header are comments. The
+// symlink targets are relative, as in sysfs, so that they resolve in the tree.
+package hostfstest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/scitix/sichek/pkg/hostfs"
+)
+
+// Entry is a file, symlink or directory of a fixture.
+type Entry struct {
+	Path    string
+	Content string
+	// Target is the target of a symlink.
+	Target string
+	Dir    bool
+}
+
+// Parse parses a fixture.
+func Parse(archive string) ([]Entry, error) {
+	var entries []Entry
+	var current *Entry
+	for n, line := range strings.SplitAfter(archive, "\n") {
+		trimmed := strings.TrimRight(line, "\n")
+		if strings.HasPrefix(trimmed, "-- ") && strings.HasSuffix(trimmed, " --") && len(trimmed) > 6 {
+			header := strings.TrimSpace(trimmed[3 : len(trimmed)-3])
+			entry := Entry{Path: header}
+			if path, target, ok := strings.Cut(header, " -> "); ok {
+				entry.Path, entry.Target = strings.TrimSpace(path), strings.TrimSpace(target)
+			} else if strings.HasSuffix(header, "/") {
+				entry.Path, entry.Dir = strings.TrimSuffix(header, "/"), true
+			}
+			entry.Path = strings.TrimPrefix(entry.Path, "/")
+			if entry.Path == "" || strings.HasPrefix(filepath.Clean(entry.Path), "..") {
+				return nil, fmt.Errorf("line %d: invalid path %q", n+1, header)
+			}
+			entries = append(entries, entry)
+			current = &entries[len(entries)-1]
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if current.Target != "" || current.Dir {
+			if strings.TrimSpace(trimmed) != "" {
+				return nil, fmt.Errorf("line %d: content after the symlink or directory %s", n+1, current.Path)
+			}
+			continue
+		}
+		current.Content += line
+	}
+	return entries, nil
+}
+
+// Build writes the fixture under a temporary directory and points hostfs.Root
+// and the HOST_* variables of gopsutil at it for the duration of the test.
+// It returns the root of the tree.
+func Build(t testing.TB, archive string) string {
+	t.Helper()
+	entries, err := Parse(archive)
+	if err != nil {
+		t.Fatalf("invalid fixture: %v", err)
+	}
+	root := t.TempDir()
+	for _, entry := range entries {
+		path := filepath.Join(root, entry.Path)
+		if entry.Dir {
+			mkdir(t, path)
+			continue
+		}
+		mkdir(t, filepath.Dir(path))
+		if entry.Target != "" {
+			if err := os.Symlink(entry.Target, path); err != nil {
+				t.Fatalf("failed to create the symlink %s: %v", entry.Path, err)
+			}
+			continue
+		}
+		if err := os.WriteFile(path, []byte(entry.Content), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", entry.Path, err)
+		}
+	}
+
+	oldRoot := hostfs.Root
+	hostfs.Root = root
+	t.Cleanup(func() { hostfs.Root = oldRoot })
+	if tt, ok := t.(*testing.T); ok {
+		tt.Setenv("HOST_PROC", filepath.Join(root, "proc"))
+		tt.Setenv("HOST_SYS", filepath.Join(root, "sys"))
+		tt.Setenv("HOST_ETC", filepath.Join(root, "etc"))
+	}
+	return root
+}
+
+// Load builds the tree of the fixture name from the testdata of this package,
+// e.g. "h100-cx7".
+func Load(t testing.TB, name string) string {
+	t.Helper()
+	_, file, _, ok := runtime.Caller(0)
+	if !ok {
+		t.Fatal("failed to locate the fixtures")
+	}
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(file), "testdata", name+".txtar"))
+	if err != nil {
+		t.Fatalf("failed to read the fixture %s: %v", name, err)
+	}
+	return Build(t, string(data))
+}
+
+// WriteFile writes the host file path of the tree, e.g. to take a port of the
+// fixture down.
+func WriteFile(t testing.TB, path string, content string) {
+	t.Helper()
+	full := hostfs.Path(path)
+	mkdir(t, filepath.Dir(full))
+	if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+// Remove removes the host file or directory path of the tree, e.g. to lose a
+// device of the fixture.
+func Remove(t testing.TB, path string) {
+	t.Helper()
+	if err := os.RemoveAll(hostfs.Path(path)); err != nil {
+		t.Fatalf("failed to remove %s: %v", path, err)
+	}
+}
+
+func mkdir(t testing.TB, dir string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("failed to create %s: %v", dir, err)
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package hostfstest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scitix/sichek/pkg/hostfs"
+)
+
+func TestParse(t *testing.T) {
+	entries, err := Parse(`# comment
+-- sys/module/mlx5_core/version --
+24.10-1.1.4
+-- sys/class/infiniband/mlx5_0 -> ../../devices/mlx5_0 --
+-- sys/devices/mlx5_0/ports/1/counters/ --
+-- proc/loadavg --
+1.00 1.00 1.00 1/100 42
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Entry{
+		{Path: "sys/module/mlx5_core/version", Content: "24.10-1.1.4\n"},
+		{Path: "sys/class/infiniband/mlx5_0", Target: "../../devices/mlx5_0"},
+		{Path: "sys/devices/mlx5_0/ports/1/counters", Dir: true},
+		{Path: "proc/loadavg", Content: "1.00 1.00 1.00 1/100 42\n"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("expected %d entries, got %d: %+v", len(want), len(entries), entries)
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Errorf("entry %d: expected %+v, got %+v", i, want[i], entries[i])
+		}
+	}
+
+	if _, err := Parse("-- ../etc/passwd --\n"); err == nil {
+		t.Error("expected a path escaping the root to fail")
+	}
+	if _, err := Parse("-- sys/a -> b --\ncontent\n"); err == nil {
+		t.Error("expected content after a symlink to fail")
+	}
+}
+
+func TestBuild(t *testing.T) {
+	oldRoot := hostfs.Root
+	t.Run("build", func(t *testing.T) {
+		root := Build(t, `-- sys/devices/mlx5_0/fw_ver --
+28.39.1002
+-- sys/class/infiniband/mlx5_0 -> ../../devices/mlx5_0 --
+`)
+		if hostfs.Root != root {
+			t.Fatalf("expected hostfs.Root %s, got %s", root, hostfs.Root)
+		}
+		data, err := os.ReadFile(hostfs.Path("/sys/class/infiniband/mlx5_0/fw_ver"))
+		if err != nil || string(data) != "28.39.1002\n" {
+			t.Errorf("expected the firmware through the symlink, got %q, %v", data, err)
+		}
+		if got := os.Getenv("HOST_PROC"); got != filepath.Join(root, "proc") {
+			t.Errorf("expected HOST_PROC under the root, got %s", got)
+		}
+
+		WriteFile(t, "/sys/devices/mlx5_0/fw_ver", "28.40.1000\n")
+		data, _ = os.ReadFile(hostfs.Path("/sys/devices/mlx5_0/fw_ver"))
+		if string(data) != "28.40.1000\n" {
+			t.Errorf("expected the rewritten firmware, got %q", data)
+		}
+		Remove(t, "/sys/class/infiniband/mlx5_0")
+		if _, err := os.Lstat(hostfs.Path("/sys/class/infiniband/mlx5_0")); !os.IsNotExist(err) {
+			t.Errorf("expected the symlink to be removed, got %v", err)
+		}
+	})
+	if hostfs.Root != oldRoot {
+		t.Errorf("expected hostfs.Root to be restored to %s, got %s", oldRoot, hostfs.Root)
+	}
+}
+
+func TestLoadFixture(t *testing.T) {
+	Load(t, "h100-cx7")
+	for _, path := range []string{
+		"/sys/class/infiniband/mlx5_0/device/uevent",
+		"/sys/class/infiniband/mlx5_1/ports/1/counters/symbol_error",
+		"/sys/bus/pci/devices/0000:19:00.0/vendor",
+		"/proc/uptime",
+	} {
+		if _, err := os.Stat(hostfs.Path(path)); err != nil {
+			t.Errorf("expected %s in the fixture: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package hostfs
+
+import (
+	"fmt"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+)
+
+// NetNSPath is the network namespace the routes and links are read from,
+// empty for the one of sichek. It is set to Environment.HostNetNS when sichek
+// runs in a pod without hostNetwork.
+var NetNSPath = ""
+
+// NetlinkHandle returns a netlink handle in the network namespace NetNSPath,
+// the caller closes it.
+func NetlinkHandle() (*netlink.Handle, error) {
+	if NetNSPath == "" {
+		return netlink.NewHandle()
+	}
+	ns, err := netns.GetFromPath(NetNSPath)
+	if err != nil {
+		return nil, fmt.Errorf("open network namespace %s: %w", NetNSPath, err)
+	}
+	defer ns.Close()
+	handle, err := netlink.NewHandleAt(ns)
+	if err != nil {
+		return nil, fmt.Errorf("netlink handle in %s: %w", NetNSPath, err)
+	}
+	return handle, nil
+}
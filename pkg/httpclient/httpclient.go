@@ -30,6 +30,7 @@ import (
 	"sigs.k8s.io/yaml"
 
 	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/specsign"
 )
 
 var (
@@ -210,6 +211,10 @@ func Download(fileURL, targetPath string) error {
 		return fmt.Errorf("failed to read file content: %v", err)
 	}
 
+	if err := specsign.Verify(fileURL, body); err != nil {
+		return err
+	}
+
 	var tmp interface{}
 	if err := yaml.Unmarshal(body, &tmp); err != nil {
 		return fmt.Errorf("file is not valid YAML: %v", err)
@@ -223,7 +228,8 @@ func Download(fileURL, targetPath string) error {
 	return nil
 }
 
-// LoadSpecFromURL loads a spec from a given URL into the provided structure
+// LoadSpecFromURL loads a spec from a given URL into the provided structure,
+// merging in the base specs it inherits from (see SpecBaseKey).
 func LoadSpecFromURL(url string, spec interface{}) error {
 	if url == "" {
 		return fmt.Errorf("URL is empty")
@@ -254,6 +260,15 @@ func LoadSpecFromURL(url string, spec interface{}) error {
 		return fmt.Errorf("failed to read body from %s: %v", url, err)
 	}
 
+	if err := specsign.Verify(url, data); err != nil {
+		return err
+	}
+
+	data, err = ResolveSpecOverlay(data, url)
+	if err != nil {
+		return err
+	}
+
 	// Try to unmarshal as YAML
 	if err := yaml.Unmarshal(data, spec); err != nil {
 		return fmt.Errorf("failed to unmarshal YAML from %s: %v", url, err)
@@ -0,0 +1,228 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package httpclient
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+
+	"github.com/scitix/sichek/pkg/specsign"
+)
+
+// SpecBaseKey is the top-level key through which a spec inherits from one or
+// more base specs. A cluster spec that only tweaks a threshold of the shared
+// H100 or CX-7 spec can then be written as an overlay:
+//
+//	base: h100_base_spec.yaml
+//	nvidia:
+//	  "0x233010de":
+//	    temperature_threshold: 80
+//
+// The bases are deep-merged in order and the overlay is merged last: maps
+// merge key by key, lists and scalars are replaced and an explicit null
+// removes the key inherited from the base.
+const SpecBaseKey = "base"
+
+// maxSpecOverlayDepth bounds the chain of bases a spec may inherit through.
+const maxSpecOverlayDepth = 8
+
+// ResolveSpecOverlay returns data with its bases merged in, or data unchanged
+// when it does not declare any. origin is the path or URL data was read from;
+// relative base references are resolved against it, and a local base that
+// does not exist is fetched from SICHEK_SPEC_URL.
+func ResolveSpecOverlay(data []byte, origin string) ([]byte, error) {
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal YAML from %s: %v", origin, err)
+	}
+	if _, ok := doc[SpecBaseKey]; !ok {
+		return data, nil
+	}
+	merged, err := resolveSpecDoc(doc, origin, []string{origin})
+	if err != nil {
+		return nil, err
+	}
+	out, err := yaml.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal merged spec of %s: %v", origin, err)
+	}
+	return out, nil
+}
+
+// MergeSpecMaps deep-merges overlay into base and returns base. Nested maps
+// are merged recursively, any other overlay value replaces the base one and a
+// nil overlay value deletes the key.
+func MergeSpecMaps(base, overlay map[string]interface{}) map[string]interface{} {
+	if base == nil {
+		base = make(map[string]interface{}, len(overlay))
+	}
+	for k, v := range overlay {
+		if v == nil {
+			delete(base, k)
+			continue
+		}
+		if vm, ok := v.(map[string]interface{}); ok {
+			if bm, ok := base[k].(map[string]interface{}); ok {
+				base[k] = MergeSpecMaps(bm, vm)
+				continue
+			}
+			base[k] = MergeSpecMaps(nil, vm)
+			continue
+		}
+		base[k] = v
+	}
+	return base
+}
+
+func resolveSpecDoc(doc map[string]interface{}, origin string, chain []string) (map[string]interface{}, error) {
+	refs, err := specBaseRefs(doc[SpecBaseKey])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", origin, err)
+	}
+	delete(doc, SpecBaseKey)
+	if len(refs) == 0 {
+		return doc, nil
+	}
+	if len(chain) > maxSpecOverlayDepth {
+		return nil, fmt.Errorf("spec inheritance deeper than %d levels: %s", maxSpecOverlayDepth, strings.Join(chain, " -> "))
+	}
+
+	merged := make(map[string]interface{})
+	for _, ref := range refs {
+		location := resolveSpecRef(origin, ref)
+		for _, seen := range chain {
+			if seen == location {
+				return nil, fmt.Errorf("spec inheritance cycle: %s -> %s", strings.Join(chain, " -> "), location)
+			}
+		}
+		data, location, err := readSpecRef(location)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load base spec %q of %s: %v", ref, origin, err)
+		}
+		var baseDoc map[string]interface{}
+		if err := yaml.Unmarshal(data, &baseDoc); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal YAML from %s: %v", location, err)
+		}
+		baseDoc, err = resolveSpecDoc(baseDoc, location, append(chain, location))
+		if err != nil {
+			return nil, err
+		}
+		merged = MergeSpecMaps(merged, baseDoc)
+		logrus.WithField("component", "httpclient").Debugf("merged base spec %s into %s", location, origin)
+	}
+	return MergeSpecMaps(merged, doc), nil
+}
+
+// specBaseRefs accepts the base key either as a single reference or a list.
+func specBaseRefs(v interface{}) ([]string, error) {
+	switch base := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if base == "" {
+			return nil, nil
+		}
+		return []string{base}, nil
+	case []interface{}:
+		refs := make([]string, 0, len(base))
+		for _, item := range base {
+			ref, ok := item.(string)
+			if !ok || ref == "" {
+				return nil, fmt.Errorf("%s must list spec file names or URLs, got %v", SpecBaseKey, item)
+			}
+			refs = append(refs, ref)
+		}
+		return refs, nil
+	default:
+		return nil, fmt.Errorf("%s must be a spec file name, URL or a list of them, got %v", SpecBaseKey, v)
+	}
+}
+
+// resolveSpecRef resolves ref against the directory or URL of origin.
+func resolveSpecRef(origin, ref string) string {
+	if isSpecURL(ref) || filepath.IsAbs(ref) {
+		return ref
+	}
+	if isSpecURL(origin) {
+		u, err := url.Parse(origin)
+		if err != nil {
+			return ref
+		}
+		u.Path = path.Join(path.Dir(u.Path), ref)
+		u.RawQuery = ""
+		return u.String()
+	}
+	if origin == "" {
+		return ref
+	}
+	return filepath.Join(filepath.Dir(origin), ref)
+}
+
+// readSpecRef reads a local or remote base spec and returns the location it
+// was eventually read from.
+func readSpecRef(location string) ([]byte, string, error) {
+	if isSpecURL(location) {
+		data, err := fetchSpec(location)
+		return data, location, err
+	}
+	data, err := os.ReadFile(location)
+	if err == nil || !os.IsNotExist(err) {
+		return data, location, err
+	}
+	ossBase := GetSichekSpecURL()
+	if ossBase == "" {
+		return nil, location, err
+	}
+	remote := strings.TrimRight(ossBase, "/") + "/" + filepath.Base(location)
+	data, fetchErr := fetchSpec(remote)
+	if fetchErr != nil {
+		return nil, location, fmt.Errorf("%v; %v", err, fetchErr)
+	}
+	return data, remote, nil
+}
+
+func fetchSpec(fileURL string) ([]byte, error) {
+	resp, err := getDefaultClient().Get(fileURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch spec from %s: %v", fileURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected HTTP status %d while fetching %s", resp.StatusCode, fileURL)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body from %s: %v", fileURL, err)
+	}
+	// a base spec is as trusted as the specs inheriting from it
+	if err := specsign.Verify(fileURL, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func isSpecURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ExponentialBackoff returns the wait before retry attempt+1: 1s, 2s, 4s, 8s capped at 30s.
+func ExponentialBackoff(attempt int) time.Duration {
+	return min(time.Second<<attempt, 30*time.Second)
+}
+
+// PostWithRetry POSTs body to url with retries on 5xx or transport errors up
+// to retryMax attempts, waiting backoff(i) before retry i+1. 4xx responses
+// abort immediately, configuration or auth errors won't fix themselves.
+func PostWithRetry(ctx context.Context, client *http.Client, url string, header http.Header, body []byte, retryMax int, backoff func(attempt int) time.Duration) error {
+	var lastErr error
+	attempts := max(retryMax, 1)
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff(i - 1)):
+			}
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("new request: %w", err)
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+			return fmt.Errorf("endpoint returned %d", resp.StatusCode)
+		}
+		lastErr = fmt.Errorf("endpoint returned %d", resp.StatusCode)
+	}
+	return lastErr
+}
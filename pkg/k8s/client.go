@@ -105,6 +105,30 @@ func (kc *K8sClient) GetCurrNode(ctx context.Context) (*v1.Node, error) {
 	return node, nil
 }
 
+// GetKubeletMetrics reads the Prometheus metrics of the kubelet of the current
+// node through the node proxy of the API server.
+func (kc *K8sClient) GetKubeletMetrics(ctx context.Context) ([]byte, error) {
+	nodeName, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hostname: %v", err)
+	}
+
+	data, err := kc.client.CoreV1().RESTClient().Get().AbsPath("/api/v1/nodes", nodeName, "proxy", "metrics").DoRaw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get kubelet metrics of node %s failed: %v", nodeName, err)
+	}
+	return data, nil
+}
+
+// GetPodLabels returns the labels of the pod namespace/name.
+func (kc *K8sClient) GetPodLabels(ctx context.Context, namespace, name string) (map[string]string, error) {
+	pod, err := kc.client.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("get k8s pod %s/%s failed: %v", namespace, name, err)
+	}
+	return pod.Labels, nil
+}
+
 func (kc *K8sClient) UpdateNodeAnnotation(ctx context.Context, anno map[string]string) error {
 	node, err := kc.GetCurrNode(ctx)
 	if err != nil {
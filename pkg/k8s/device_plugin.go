@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/scitix/sichek/pkg/hostfs"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// NvidiaGPUResource is the extended resource the NVIDIA device plugin advertises.
+	NvidiaGPUResource = "nvidia.com/gpu"
+	// DevicePluginCheckpointPath is where the kubelet device manager records the
+	// devices registered by the device plugins and allocated to the containers.
+	DevicePluginCheckpointPath = "/var/lib/kubelet/device-plugins/kubelet_internal_checkpoint"
+)
+
+// DevicePluginCheckpoint is the device manager checkpoint of the kubelet.
+type DevicePluginCheckpoint struct {
+	Data struct {
+		PodDeviceEntries  []PodDeviceEntry    `json:"PodDeviceEntries"`
+		RegisteredDevices map[string][]string `json:"RegisteredDevices"`
+	} `json:"Data"`
+}
+
+// PodDeviceEntry is the devices of a resource allocated to a container.
+type PodDeviceEntry struct {
+	PodUID        string          `json:"PodUID"`
+	ContainerName string          `json:"ContainerName"`
+	ResourceName  string          `json:"ResourceName"`
+	DeviceIDs     json.RawMessage `json:"DeviceIDs"`
+}
+
+// Devices returns the allocated device IDs. The kubelet records them per
+// NUMA node since 1.20 and as a plain list before.
+func (e *PodDeviceEntry) Devices() []string {
+	var perNUMA map[string][]string
+	if err := json.Unmarshal(e.DeviceIDs, &perNUMA); err == nil {
+		var ids []string
+		for _, numaIDs := range perNUMA {
+			ids = append(ids, numaIDs...)
+		}
+		return ids
+	}
+	var ids []string
+	_ = json.Unmarshal(e.DeviceIDs, &ids)
+	return ids
+}
+
+// ReadDevicePluginCheckpoint reads the device manager checkpoint of the kubelet
+// at path, under the host root.
+func ReadDevicePluginCheckpoint(path string) (*DevicePluginCheckpoint, error) {
+	data, err := os.ReadFile(hostfs.Path(path))
+	if err != nil {
+		return nil, err
+	}
+	var checkpoint DevicePluginCheckpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("invalid device plugin checkpoint %s: %w", path, err)
+	}
+	return &checkpoint, nil
+}
+
+// Registered returns the healthy device IDs of resource the device plugin
+// registered with the kubelet.
+func (c *DevicePluginCheckpoint) Registered(resource string) []string {
+	return c.Data.RegisteredDevices[resource]
+}
+
+// Allocated maps the allocated device IDs of resource to the pod UID and the
+// container they are allocated to.
+func (c *DevicePluginCheckpoint) Allocated(resource string) map[string]string {
+	allocated := make(map[string]string)
+	for _, entry := range c.Data.PodDeviceEntries {
+		if entry.ResourceName != resource {
+			continue
+		}
+		for _, id := range entry.Devices() {
+			allocated[id] = entry.PodUID + "/" + entry.ContainerName
+		}
+	}
+	return allocated
+}
+
+// GetNodeResource returns the allocatable and the capacity of resource in the
+// status of the current node, found is false when the node does not have it.
+func (kc *K8sClient) GetNodeResource(ctx context.Context, resource string) (allocatable int64, capacity int64, found bool, err error) {
+	node, err := kc.GetCurrNode(ctx)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	name := v1.ResourceName(resource)
+	if quantity, ok := node.Status.Capacity[name]; ok {
+		capacity = quantity.Value()
+		found = true
+	}
+	if quantity, ok := node.Status.Allocatable[name]; ok {
+		allocatable = quantity.Value()
+		found = true
+	}
+	return allocatable, capacity, found, nil
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package k8s
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/scitix/sichek/pkg/hostfs/hostfstest"
+)
+
+func TestReadDevicePluginCheckpoint(t *testing.T) {
+	hostfstest.Build(t, `
+-- var/lib/kubelet/device-plugins/kubelet_internal_checkpoint --
+{"Data":{"PodDeviceEntries":[
+{"PodUID":"uid-0","ContainerName":"trainer","ResourceName":"nvidia.com/gpu","DeviceIDs":{"0":["GPU-0"],"1":["GPU-1"]},"AllocResp":"CgA="},
+{"PodUID":"uid-1","ContainerName":"old","ResourceName":"nvidia.com/gpu","DeviceIDs":["GPU-2"]},
+{"PodUID":"uid-2","ContainerName":"nic","ResourceName":"rdma/hca","DeviceIDs":{"0":["mlx5_0"]}}],
+"RegisteredDevices":{"nvidia.com/gpu":["GPU-0","GPU-1","GPU-2","GPU-3"],"rdma/hca":["mlx5_0"]}},"Checksum":1}
+`)
+	checkpoint, err := ReadDevicePluginCheckpoint(DevicePluginCheckpointPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := checkpoint.Registered(NvidiaGPUResource); !slices.Equal(got, []string{"GPU-0", "GPU-1", "GPU-2", "GPU-3"}) {
+		t.Errorf("unexpected registered GPUs %v", got)
+	}
+	allocated := checkpoint.Allocated(NvidiaGPUResource)
+	want := map[string]string{"GPU-0": "uid-0/trainer", "GPU-1": "uid-0/trainer", "GPU-2": "uid-1/old"}
+	if len(allocated) != len(want) {
+		t.Fatalf("unexpected allocated GPUs %v", allocated)
+	}
+	for id, container := range want {
+		if allocated[id] != container {
+			t.Errorf("GPU %s allocated to %q, want %q", id, allocated[id], container)
+		}
+	}
+
+	if _, err := ReadDevicePluginCheckpoint("/no/such/checkpoint"); err == nil {
+		t.Errorf("expected an error without a checkpoint")
+	}
+}
@@ -0,0 +1,401 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	NodeConditionPrefix = "Sichek"
+	NodeConditionSuffix = "Healthy"
+	DefaultTaintKey     = "scitix.ai/sichek-unhealthy"
+	// UnhealthyDevicesAnnotation lists the unhealthy devices per component as
+	// json, e.g. {"nvidia":[{"index":3,"uuid":"GPU-..."}]}, for draining single GPUs.
+	UnhealthyDevicesAnnotation = "scitix.ai/sichek-unhealthy-devices"
+
+	conditionReasonHealthy = "SichekCheckPassed"
+	// maxConditionMessageLen keeps the node status small when many checkers fail.
+	maxConditionMessageLen = 1024
+)
+
+// NodeHealthConfig is the `node_health` section of the user config.
+type NodeHealthConfig struct {
+	NodeHealth struct {
+		Enable bool `json:"enable" yaml:"enable"`
+		// Levels of an abnormal result that mark the component unhealthy.
+		Levels []string `json:"levels" yaml:"levels"`
+		Taint  struct {
+			Enable bool   `json:"enable" yaml:"enable"`
+			Key    string `json:"key" yaml:"key"`
+			Value  string `json:"value" yaml:"value"`
+			Effect string `json:"effect" yaml:"effect"`
+		} `json:"taint" yaml:"taint"`
+		// Status publishes a compact status per component as node annotations,
+		// and optionally labels, see NodeStatusPublisher.
+		Status struct {
+			Enable bool   `json:"enable" yaml:"enable"`
+			Prefix string `json:"prefix" yaml:"prefix"`
+			Labels bool   `json:"labels" yaml:"labels"`
+			// MinInterval is the min time between two patches of the node, the
+			// changes in between are published together.
+			MinInterval time.Duration `json:"min_interval" yaml:"min_interval"`
+		} `json:"status" yaml:"status"`
+	} `json:"node_health" yaml:"node_health"`
+}
+
+// LoadNodeHealthConfig loads the node health config from cfgFile, missing fields keep their defaults.
+func LoadNodeHealthConfig(cfgFile string) *NodeHealthConfig {
+	config := &NodeHealthConfig{}
+	if cfgFile != "" {
+		data, err := os.ReadFile(cfgFile)
+		if err == nil {
+			err = yaml.Unmarshal(data, config)
+		}
+		if err != nil {
+			logrus.WithField("k8s", "node-health").Warnf("Failed to load node_health config from %s, using defaults: %v", cfgFile, err)
+		}
+	}
+	cfg := &config.NodeHealth
+	if len(cfg.Levels) == 0 {
+		cfg.Levels = []string{consts.LevelCritical, consts.LevelFatal}
+	}
+	if cfg.Taint.Key == "" {
+		cfg.Taint.Key = DefaultTaintKey
+	}
+	if cfg.Taint.Value == "" {
+		cfg.Taint.Value = "true"
+	}
+	if cfg.Taint.Effect == "" {
+		cfg.Taint.Effect = string(v1.TaintEffectNoSchedule)
+	}
+	if cfg.Status.Prefix == "" {
+		cfg.Status.Prefix = DefaultStatusPrefix
+	}
+	if cfg.Status.MinInterval <= 0 {
+		cfg.Status.MinInterval = DefaultStatusMinInterval
+	}
+	return config
+}
+
+// NodeHealthController reflects the component results on the Node object: one
+// condition per component, an annotation with the unhealthy devices, and
+// optionally a taint while any component is unhealthy.
+type NodeHealthController struct {
+	cfg       *NodeHealthConfig
+	client    *K8sClient
+	mu        sync.Mutex
+	unhealthy map[string]bool
+	devices   map[string][]*common.DeviceResult
+}
+
+func NewNodeHealthController(cfg *NodeHealthConfig) (*NodeHealthController, error) {
+	client, err := NewClient()
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, fmt.Errorf("k8s client is not available")
+	}
+	return &NodeHealthController{
+		cfg:       cfg,
+		client:    client,
+		unhealthy: make(map[string]bool),
+		devices:   make(map[string][]*common.DeviceResult),
+	}, nil
+}
+
+// Update patches the condition of the component, the unhealthy devices annotation
+// and the taint of the node if they changed. Only the changed fields are sent,
+// so concurrent writers of the node, e.g. the kubelet, are not overwritten.
+func (c *NodeHealthController) Update(ctx context.Context, component string, result *common.Result) error {
+	cfg := &c.cfg.NodeHealth
+	cond := NodeCondition(component, result, cfg.Levels)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.unhealthy[component] = cond.Status == v1.ConditionFalse
+	if cond.Status == v1.ConditionFalse {
+		c.devices[component] = common.UnhealthyDevices(result, cfg.Levels...)
+	} else {
+		delete(c.devices, component)
+	}
+
+	node, err := c.client.GetCurrNode(ctx)
+	if err != nil {
+		return err
+	}
+	nodes := c.client.client.CoreV1().Nodes()
+	if conditions, changed := SetNodeCondition(node.Status.Conditions, cond); changed {
+		patch, err := conditionPatch(conditions, cond.Type)
+		if err != nil {
+			return err
+		}
+		if _, err := nodes.PatchStatus(ctx, node.Name, patch); err != nil {
+			return fmt.Errorf("patch node condition %s failed: %w", cond.Type, err)
+		}
+		logrus.WithField("k8s", "node-health").Infof("set node condition %s=%s: %s", cond.Type, cond.Status, cond.Reason)
+	}
+
+	annotations, annotationChanged, err := SetUnhealthyDevicesAnnotation(copyAnnotations(node.Annotations), c.devices)
+	if err != nil {
+		return err
+	}
+	if annotationChanged {
+		patch, err := annotationPatch(annotations, UnhealthyDevicesAnnotation)
+		if err != nil {
+			return err
+		}
+		if _, err := nodes.Patch(ctx, node.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return fmt.Errorf("patch node annotation %s failed: %w", UnhealthyDevicesAnnotation, err)
+		}
+		logrus.WithField("k8s", "node-health").Infof("set node annotation %s=%s", UnhealthyDevicesAnnotation, annotations[UnhealthyDevicesAnnotation])
+	}
+
+	if !cfg.Taint.Enable {
+		return nil
+	}
+	unhealthy := false
+	for _, u := range c.unhealthy {
+		unhealthy = unhealthy || u
+	}
+	taint := v1.Taint{Key: cfg.Taint.Key, Value: cfg.Taint.Value, Effect: v1.TaintEffect(cfg.Taint.Effect)}
+	oldTaints := append([]v1.Taint(nil), node.Spec.Taints...)
+	taints, taintChanged := SetNodeTaint(node.Spec.Taints, taint, unhealthy)
+	if !taintChanged {
+		return nil
+	}
+	patch, err := taintsPatch(oldTaints, taints)
+	if err != nil {
+		return err
+	}
+	if _, err := nodes.Patch(ctx, node.Name, types.JSONPatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("patch node taint %s failed: %w", taint.Key, err)
+	}
+	logrus.WithField("k8s", "node-health").Infof("node taint %s present=%v", taint.Key, unhealthy)
+	return nil
+}
+
+// conditionPatch returns a strategic merge patch of the node status carrying
+// only the condition of condType; conditions are merged by type.
+func conditionPatch(conditions []v1.NodeCondition, condType v1.NodeConditionType) ([]byte, error) {
+	for _, cond := range conditions {
+		if cond.Type != condType {
+			continue
+		}
+		patch := map[string]any{"status": map[string]any{"conditions": []v1.NodeCondition{cond}}}
+		data, err := json.Marshal(patch)
+		if err != nil {
+			return nil, fmt.Errorf("marshal node condition patch failed: %w", err)
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("node condition %s not found", condType)
+}
+
+// annotationPatch returns a json merge patch setting the annotation key to its
+// value in annotations, or removing it if it is absent.
+func annotationPatch(annotations map[string]string, key string) ([]byte, error) {
+	var value any
+	if v, ok := annotations[key]; ok {
+		value = v
+	}
+	patch := map[string]any{"metadata": map[string]any{"annotations": map[string]any{key: value}}}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return nil, fmt.Errorf("marshal node annotation patch failed: %w", err)
+	}
+	return data, nil
+}
+
+// taintsPatch returns a json patch replacing the taints of the node. Taints are
+// not merged by key, so the patch first tests that the taints read are still
+// current and fails instead of dropping a taint added meanwhile.
+func taintsPatch(oldTaints, newTaints []v1.Taint) ([]byte, error) {
+	if newTaints == nil {
+		newTaints = []v1.Taint{}
+	}
+	var ops []map[string]any
+	if len(oldTaints) > 0 {
+		ops = append(ops, map[string]any{"op": "test", "path": "/spec/taints", "value": oldTaints})
+	}
+	ops = append(ops, map[string]any{"op": "add", "path": "/spec/taints", "value": newTaints})
+	data, err := json.Marshal(ops)
+	if err != nil {
+		return nil, fmt.Errorf("marshal node taints patch failed: %w", err)
+	}
+	return data, nil
+}
+
+func copyAnnotations(annotations map[string]string) map[string]string {
+	if annotations == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(annotations))
+	for k, v := range annotations {
+		copied[k] = v
+	}
+	return copied
+}
+
+// SetUnhealthyDevicesAnnotation sets the unhealthy devices of the components in
+// annotations and reports whether they changed. The annotation is removed when
+// no component has unhealthy devices.
+func SetUnhealthyDevicesAnnotation(annotations map[string]string, devices map[string][]*common.DeviceResult) (map[string]string, bool, error) {
+	present := make(map[string][]*common.DeviceResult, len(devices))
+	for component, componentDevices := range devices {
+		if len(componentDevices) > 0 {
+			present[component] = componentDevices
+		}
+	}
+	existing, exists := annotations[UnhealthyDevicesAnnotation]
+	if len(present) == 0 {
+		if !exists {
+			return annotations, false, nil
+		}
+		delete(annotations, UnhealthyDevicesAnnotation)
+		return annotations, true, nil
+	}
+	// json sorts the map keys, so the value is stable across updates
+	data, err := json.Marshal(present)
+	if err != nil {
+		return annotations, false, fmt.Errorf("marshal unhealthy devices failed: %w", err)
+	}
+	if exists && existing == string(data) {
+		return annotations, false, nil
+	}
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[UnhealthyDevicesAnnotation] = string(data)
+	return annotations, true, nil
+}
+
+// NodeConditionType returns the condition type of a component, e.g. SichekNvidiaHealthy.
+func NodeConditionType(component string) v1.NodeConditionType {
+	var name strings.Builder
+	for _, part := range strings.FieldsFunc(component, func(r rune) bool { return r == '_' || r == '-' }) {
+		name.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return v1.NodeConditionType(NodeConditionPrefix + name.String() + NodeConditionSuffix)
+}
+
+// NodeCondition builds the condition of a component from its result. The
+// component is unhealthy if an abnormal checker has one of the given levels,
+// the reason is the error name of the checker and the message its detail.
+func NodeCondition(component string, result *common.Result, levels []string) v1.NodeCondition {
+	cond := v1.NodeCondition{
+		Type:    NodeConditionType(component),
+		Status:  v1.ConditionTrue,
+		Reason:  conditionReasonHealthy,
+		Message: fmt.Sprintf("sichek %s health check passed", component),
+	}
+	if result == nil || result.Status != consts.StatusAbnormal {
+		return cond
+	}
+	unhealthyLevels := make(map[string]bool, len(levels))
+	for _, level := range levels {
+		unhealthyLevels[level] = true
+	}
+	var reasons, details []string
+	for _, checker := range result.Checkers {
+		if checker.Status != consts.StatusAbnormal || !unhealthyLevels[checker.Level] {
+			continue
+		}
+		reasons = append(reasons, checker.ErrorName)
+		details = append(details, checker.Detail)
+	}
+	if len(reasons) == 0 {
+		return cond
+	}
+	cond.Status = v1.ConditionFalse
+	sort.Strings(reasons)
+	cond.Reason = reasons[0]
+	cond.Message = strings.Join(details, "; ")
+	cond.Message = truncateMessage(cond.Message, maxConditionMessageLen)
+	return cond
+}
+
+// truncateMessage cuts msg to at most maxLen bytes without splitting a multi-byte rune.
+func truncateMessage(msg string, maxLen int) string {
+	if len(msg) <= maxLen {
+		return msg
+	}
+	end := maxLen
+	for end > 0 && !utf8.RuneStart(msg[end]) {
+		end--
+	}
+	return msg[:end]
+}
+
+// SetNodeCondition sets cond in conditions and reports whether they changed.
+// The transition time is kept if the status did not change, so that updating
+// the message alone does not look like a new failure.
+func SetNodeCondition(conditions []v1.NodeCondition, cond v1.NodeCondition) ([]v1.NodeCondition, bool) {
+	now := metav1.Now()
+	for i := range conditions {
+		existing := &conditions[i]
+		if existing.Type != cond.Type {
+			continue
+		}
+		if existing.Status == cond.Status && existing.Reason == cond.Reason && existing.Message == cond.Message {
+			return conditions, false
+		}
+		if existing.Status != cond.Status {
+			existing.LastTransitionTime = now
+		}
+		existing.Status, existing.Reason, existing.Message = cond.Status, cond.Reason, cond.Message
+		existing.LastHeartbeatTime = now
+		return conditions, true
+	}
+	cond.LastHeartbeatTime = now
+	cond.LastTransitionTime = now
+	return append(conditions, cond), true
+}
+
+// SetNodeTaint adds or removes the taint with the key of taint and reports
+// whether the taints changed. Only the taint owned by sichek is touched.
+func SetNodeTaint(taints []v1.Taint, taint v1.Taint, present bool) ([]v1.Taint, bool) {
+	for i := range taints {
+		if taints[i].Key != taint.Key || taints[i].Effect != taint.Effect {
+			continue
+		}
+		if present {
+			return taints, false
+		}
+		return append(taints[:i:i], taints[i+1:]...), true
+	}
+	if !present {
+		return taints, false
+	}
+	return append(taints, taint), true
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package k8s
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestNodeCondition(t *testing.T) {
+	if got := NodeConditionType("nvidia"); got != "SichekNvidiaHealthy" {
+		t.Errorf("NodeConditionType(nvidia)=%s", got)
+	}
+	if got := NodeConditionType("pcie_topo"); got != "SichekPcieTopoHealthy" {
+		t.Errorf("NodeConditionType(pcie_topo)=%s", got)
+	}
+
+	levels := []string{consts.LevelCritical, consts.LevelFatal}
+	result := &common.Result{
+		Item:   consts.ComponentNameNvidia,
+		Status: consts.StatusAbnormal,
+		Checkers: []*common.CheckerResult{
+			{Status: consts.StatusAbnormal, Level: consts.LevelWarning, ErrorName: "GPUClockSlow", Detail: "clock slow"},
+			{Status: consts.StatusAbnormal, Level: consts.LevelCritical, ErrorName: "GPULost", Detail: "GPU 3 is lost"},
+		},
+	}
+	cond := NodeCondition(consts.ComponentNameNvidia, result, levels)
+	if cond.Status != v1.ConditionFalse || cond.Reason != "GPULost" || cond.Message != "GPU 3 is lost" {
+		t.Errorf("unexpected condition: %+v", cond)
+	}
+
+	// A warning alone does not make the node unhealthy.
+	result.Checkers = result.Checkers[:1]
+	if cond := NodeCondition(consts.ComponentNameNvidia, result, levels); cond.Status != v1.ConditionTrue {
+		t.Errorf("expected healthy condition on warning, got %+v", cond)
+	}
+}
+
+func TestSetNodeCondition(t *testing.T) {
+	cond := v1.NodeCondition{Type: "SichekNvidiaHealthy", Status: v1.ConditionFalse, Reason: "GPULost"}
+	conditions, changed := SetNodeCondition([]v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}}, cond)
+	if !changed || len(conditions) != 2 {
+		t.Fatalf("expected condition added, got %+v", conditions)
+	}
+	transition := conditions[1].LastTransitionTime
+	if _, changed = SetNodeCondition(conditions, cond); changed {
+		t.Errorf("same condition should not be updated")
+	}
+	cond.Message = "GPU 3 is lost"
+	if conditions, changed = SetNodeCondition(conditions, cond); !changed || !conditions[1].LastTransitionTime.Equal(&transition) {
+		t.Errorf("message update should keep the transition time, got %+v", conditions[1])
+	}
+}
+
+func TestSetNodeTaint(t *testing.T) {
+	taint := v1.Taint{Key: DefaultTaintKey, Value: "true", Effect: v1.TaintEffectNoSchedule}
+	other := v1.Taint{Key: "other", Effect: v1.TaintEffectNoSchedule}
+
+	taints, changed := SetNodeTaint([]v1.Taint{other}, taint, true)
+	if !changed || len(taints) != 2 {
+		t.Fatalf("expected taint added, got %+v", taints)
+	}
+	if _, changed = SetNodeTaint(taints, taint, true); changed {
+		t.Errorf("taint should not be added twice")
+	}
+	taints, changed = SetNodeTaint(taints, taint, false)
+	if !changed || len(taints) != 1 || taints[0].Key != "other" {
+		t.Errorf("expected only sichek taint removed, got %+v", taints)
+	}
+	if _, changed = SetNodeTaint(taints, taint, false); changed {
+		t.Errorf("removing a missing taint should not change the taints")
+	}
+}
+
+func TestLoadNodeHealthConfig(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "cfg.yaml")
+	if err := os.WriteFile(p, []byte("node_health:\n  enable: true\n  taint:\n    enable: true\n    effect: NoExecute\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	cfg := LoadNodeHealthConfig(p).NodeHealth
+	if !cfg.Enable || !cfg.Taint.Enable || cfg.Taint.Effect != "NoExecute" || cfg.Taint.Key != DefaultTaintKey {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if len(cfg.Levels) != 2 {
+		t.Errorf("expected default levels, got %v", cfg.Levels)
+	}
+	if cfg.Status.Enable || cfg.Status.Prefix != DefaultStatusPrefix || cfg.Status.MinInterval != DefaultStatusMinInterval {
+		t.Errorf("expected the default status publishing, got %+v", cfg.Status)
+	}
+
+	if err := os.WriteFile(p, []byte("node_health:\n  status:\n    enable: true\n    labels: true\n    min_interval: 5m\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	status := LoadNodeHealthConfig(p).NodeHealth.Status
+	if !status.Enable || !status.Labels || status.MinInterval != 5*time.Minute {
+		t.Errorf("unexpected status config: %+v", status)
+	}
+}
+
+func TestSetUnhealthyDevicesAnnotation(t *testing.T) {
+	devices := map[string][]*common.DeviceResult{
+		consts.ComponentNameNvidia: {{Index: 3, UUID: "GPU-3", BDF: "0000:18:00.0"}},
+		consts.ComponentNameAmd:    nil,
+	}
+	annotations, changed, err := SetUnhealthyDevicesAnnotation(nil, devices)
+	if err != nil || !changed {
+		t.Fatalf("expected annotation added, changed=%v err=%v", changed, err)
+	}
+	want := `{"nvidia":[{"index":3,"uuid":"GPU-3","bdf":"0000:18:00.0"}]}`
+	if got := annotations[UnhealthyDevicesAnnotation]; got != want {
+		t.Errorf("annotation=%s, want %s", got, want)
+	}
+	if _, changed, _ = SetUnhealthyDevicesAnnotation(annotations, devices); changed {
+		t.Errorf("same devices should not change the annotation")
+	}
+	annotations["other"] = "kept"
+	annotations, changed, _ = SetUnhealthyDevicesAnnotation(annotations, map[string][]*common.DeviceResult{})
+	if _, exists := annotations[UnhealthyDevicesAnnotation]; !changed || exists || annotations["other"] != "kept" {
+		t.Errorf("expected only the devices annotation removed, got %v", annotations)
+	}
+}
+
+func TestNodeConditionTruncatesOnRuneBoundary(t *testing.T) {
+	msg := strings.Repeat("a", maxConditionMessageLen-1) + "设备丢失"
+	got := truncateMessage(msg, maxConditionMessageLen)
+	if len(got) != maxConditionMessageLen-1 || !utf8.ValidString(got) {
+		t.Errorf("expected truncation before the multi-byte rune, got len %d valid %v", len(got), utf8.ValidString(got))
+	}
+	if got := truncateMessage("short", maxConditionMessageLen); got != "short" {
+		t.Errorf("short message should be kept, got %q", got)
+	}
+}
+
+func TestNodeHealthPatches(t *testing.T) {
+	conditions := []v1.NodeCondition{
+		{Type: v1.NodeReady, Status: v1.ConditionTrue},
+		{Type: "SichekNvidiaHealthy", Status: v1.ConditionFalse, Reason: "GPULost"},
+	}
+	patch, err := conditionPatch(conditions, "SichekNvidiaHealthy")
+	if err != nil || !strings.Contains(string(patch), `"type":"SichekNvidiaHealthy"`) || strings.Contains(string(patch), `"type":"Ready"`) {
+		t.Errorf("expected only the sichek condition in the patch, got %s err=%v", patch, err)
+	}
+
+	patch, _ = annotationPatch(map[string]string{}, UnhealthyDevicesAnnotation)
+	if want := `{"metadata":{"annotations":{"scitix.ai/sichek-unhealthy-devices":null}}}`; string(patch) != want {
+		t.Errorf("annotation removal patch=%s, want %s", patch, want)
+	}
+
+	taint := v1.Taint{Key: DefaultTaintKey, Value: "true", Effect: v1.TaintEffectNoSchedule}
+	patch, _ = taintsPatch(nil, []v1.Taint{taint})
+	if strings.Contains(string(patch), `"op":"test"`) {
+		t.Errorf("no test op expected without existing taints, got %s", patch)
+	}
+	patch, _ = taintsPatch([]v1.Taint{taint}, nil)
+	if !strings.Contains(string(patch), `"op":"test"`) || !strings.Contains(string(patch), `"value":[]`) {
+		t.Errorf("expected test and empty replace ops, got %s", patch)
+	}
+}
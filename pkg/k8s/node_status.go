@@ -0,0 +1,193 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// DefaultStatusPrefix is the prefix of the status annotations and labels,
+	// e.g. sichek.scitix.io/nvidia=fail:xid-79.
+	DefaultStatusPrefix      = "sichek.scitix.io"
+	DefaultStatusMinInterval = time.Minute
+
+	StatusPass = "pass"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+
+	// maxStatusReasons bounds the checkers listed in a status annotation.
+	maxStatusReasons = 5
+)
+
+// NodeStatusPublisher publishes the compact status of each component as a
+// node annotation, e.g. sichek.scitix.io/nvidia=fail:xid-79 or
+// sichek.scitix.io/infiniband=pass, and optionally as a label holding the
+// status alone, e.g. sichek.scitix.io/nvidia=fail, for node selectors. The
+// node is patched at most once per MinInterval.
+type NodeStatusPublisher struct {
+	cfg       *NodeHealthConfig
+	client    *K8sClient
+	mu        sync.Mutex
+	statuses  map[string]string
+	published map[string]string
+	lastPatch time.Time
+}
+
+func NewNodeStatusPublisher(cfg *NodeHealthConfig) (*NodeStatusPublisher, error) {
+	client, err := NewClient()
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, fmt.Errorf("k8s client is not available")
+	}
+	return &NodeStatusPublisher{
+		cfg:       cfg,
+		client:    client,
+		statuses:  make(map[string]string),
+		published: make(map[string]string),
+	}, nil
+}
+
+// Update records the status of the component and patches the annotations and
+// labels that changed since the last patch, unless the node was patched less
+// than MinInterval ago. The changes held back are sent with a later update.
+func (p *NodeStatusPublisher) Update(ctx context.Context, component string, result *common.Result) error {
+	cfg := &p.cfg.NodeHealth
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.statuses[component] = CompactStatus(result, cfg.Levels)
+
+	patch, changed, err := statusPatch(cfg.Status.Prefix, cfg.Status.Labels, p.published, p.statuses)
+	if err != nil || !changed {
+		return err
+	}
+	if since := time.Since(p.lastPatch); since < cfg.Status.MinInterval {
+		logrus.WithField("k8s", "node-status").Debugf("hold back the status of %s for %s", component, cfg.Status.MinInterval-since)
+		return nil
+	}
+	node, err := p.client.GetCurrNode(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := p.client.client.CoreV1().Nodes().Patch(ctx, node.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("patch node status annotations failed: %w", err)
+	}
+	p.lastPatch = time.Now()
+	for name, status := range p.statuses {
+		if p.published[name] != status {
+			logrus.WithField("k8s", "node-status").Infof("set node annotation %s=%s", StatusKey(cfg.Status.Prefix, name), status)
+		}
+		p.published[name] = status
+	}
+	return nil
+}
+
+// StatusKey returns the annotation and label key of a component.
+func StatusKey(prefix, component string) string {
+	return prefix + "/" + component
+}
+
+// CompactStatus summarizes a result as pass, warn:<checkers> or
+// fail:<checkers>. The component fails when an abnormal checker has one of
+// the given levels and warns on the other abnormal checkers, which are listed
+// sorted by name.
+func CompactStatus(result *common.Result, levels []string) string {
+	if result == nil || result.Status != consts.StatusAbnormal {
+		return StatusPass
+	}
+	failLevels := make(map[string]bool, len(levels))
+	for _, level := range levels {
+		failLevels[level] = true
+	}
+	var failed, warned []string
+	for _, checker := range result.Checkers {
+		if checker.Status != consts.StatusAbnormal {
+			continue
+		}
+		switch {
+		case failLevels[checker.Level]:
+			failed = append(failed, checker.Name)
+		case checker.Level != consts.LevelInfo:
+			warned = append(warned, checker.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return StatusFail + ":" + joinReasons(failed)
+	}
+	if len(warned) > 0 {
+		return StatusWarn + ":" + joinReasons(warned)
+	}
+	return StatusPass
+}
+
+func joinReasons(names []string) string {
+	sort.Strings(names)
+	if len(names) > maxStatusReasons {
+		return fmt.Sprintf("%s,+%d", strings.Join(names[:maxStatusReasons], ","), len(names)-maxStatusReasons)
+	}
+	return strings.Join(names, ",")
+}
+
+// StatusLabelValue returns the label value of a compact status, its status
+// without the checkers, as label values can not hold a ':'.
+func StatusLabelValue(status string) string {
+	value, _, _ := strings.Cut(status, ":")
+	return value
+}
+
+// statusPatch returns a json merge patch of the annotations, and the labels
+// if set, of the components whose status differs from the published one.
+func statusPatch(prefix string, labels bool, published, statuses map[string]string) ([]byte, bool, error) {
+	annotations := make(map[string]string)
+	labelValues := make(map[string]string)
+	for component, status := range statuses {
+		old, ok := published[component]
+		if ok && old == status {
+			continue
+		}
+		key := StatusKey(prefix, component)
+		annotations[key] = status
+		if labels && (!ok || StatusLabelValue(old) != StatusLabelValue(status)) {
+			labelValues[key] = StatusLabelValue(status)
+		}
+	}
+	if len(annotations) == 0 {
+		return nil, false, nil
+	}
+	metadata := map[string]any{"annotations": annotations}
+	if len(labelValues) > 0 {
+		metadata["labels"] = labelValues
+	}
+	data, err := json.Marshal(map[string]any{"metadata": metadata})
+	if err != nil {
+		return nil, false, fmt.Errorf("marshal node status patch failed: %w", err)
+	}
+	return data, true, nil
+}
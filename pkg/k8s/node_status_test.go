@@ -0,0 +1,99 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+)
+
+func TestCompactStatus(t *testing.T) {
+	levels := []string{consts.LevelCritical, consts.LevelFatal}
+	if got := CompactStatus(nil, levels); got != StatusPass {
+		t.Errorf("expected pass without result, got %s", got)
+	}
+	result := &common.Result{
+		Status: consts.StatusAbnormal,
+		Checkers: []*common.CheckerResult{
+			{Name: "gpu-clock", Status: consts.StatusAbnormal, Level: consts.LevelWarning},
+			{Name: "xid-79", Status: consts.StatusAbnormal, Level: consts.LevelFatal},
+			{Name: "xid-48", Status: consts.StatusAbnormal, Level: consts.LevelCritical},
+			{Name: "pcie", Status: consts.StatusNormal, Level: consts.LevelCritical},
+		},
+	}
+	if got := CompactStatus(result, levels); got != "fail:xid-48,xid-79" {
+		t.Errorf("unexpected status %s", got)
+	}
+	if got := StatusLabelValue("fail:xid-48,xid-79"); got != StatusFail {
+		t.Errorf("unexpected label value %s", got)
+	}
+
+	result.Checkers = result.Checkers[:1]
+	if got := CompactStatus(result, levels); got != "warn:gpu-clock" {
+		t.Errorf("unexpected status %s", got)
+	}
+
+	result.Checkers = nil
+	for i := 0; i < 7; i++ {
+		result.Checkers = append(result.Checkers, &common.CheckerResult{Name: fmt.Sprintf("c%d", i), Status: consts.StatusAbnormal, Level: consts.LevelCritical})
+	}
+	if got := CompactStatus(result, levels); got != "fail:c0,c1,c2,c3,c4,+2" {
+		t.Errorf("unexpected status %s", got)
+	}
+}
+
+func TestStatusPatch(t *testing.T) {
+	published := map[string]string{"nvidia": "fail:xid-79", "infiniband": "pass"}
+	statuses := map[string]string{"nvidia": "fail:xid-48", "infiniband": "pass", "cpu": "warn:cpu-performance"}
+	data, changed, err := statusPatch(DefaultStatusPrefix, true, published, statuses)
+	if err != nil || !changed {
+		t.Fatalf("expected a patch, got changed=%v err=%v", changed, err)
+	}
+	var patch struct {
+		Metadata struct {
+			Annotations map[string]string `json:"annotations"`
+			Labels      map[string]string `json:"labels"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &patch); err != nil {
+		t.Fatal(err)
+	}
+	annotations := patch.Metadata.Annotations
+	if len(annotations) != 2 || annotations["sichek.scitix.io/nvidia"] != "fail:xid-48" || annotations["sichek.scitix.io/cpu"] != "warn:cpu-performance" {
+		t.Errorf("unexpected annotations %v", annotations)
+	}
+	// the nvidia label stays fail, only the new cpu one is sent
+	if labels := patch.Metadata.Labels; len(labels) != 1 || labels["sichek.scitix.io/cpu"] != StatusWarn {
+		t.Errorf("unexpected labels %v", labels)
+	}
+
+	if _, changed, _ := statusPatch(DefaultStatusPrefix, true, statuses, statuses); changed {
+		t.Errorf("expected no patch when nothing changed")
+	}
+	data, _, _ = statusPatch(DefaultStatusPrefix, false, published, statuses)
+	var noLabels struct {
+		Metadata struct {
+			Labels map[string]string `json:"labels"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &noLabels); err != nil || noLabels.Metadata.Labels != nil {
+		t.Errorf("expected no labels when disabled, got %v", noLabels.Metadata.Labels)
+	}
+}
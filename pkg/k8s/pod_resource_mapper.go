@@ -85,6 +85,15 @@ func newPodResourceMapper() (*PodResourceMapper, error) {
 }
 
 func (p *PodResourceMapper) GetDeviceToPodMap() (map[string]*PodInfo, error) {
+	return p.GetResourceDeviceToPodMap(func(resourceName string) bool {
+		return resourceName == NvidiaGPUResource
+	})
+}
+
+// GetResourceDeviceToPodMap maps the device IDs of the resources matched by
+// match, all of them when nil, to the pod they are allocated to, e.g. the PCI
+// addresses of the VFs given by the SR-IOV network device plugin.
+func (p *PodResourceMapper) GetResourceDeviceToPodMap(match func(resourceName string) bool) (map[string]*PodInfo, error) {
 	if p.PodResourcesKubeletSocketPath == "" {
 		logrus.Warn("PodResourcesKubeletSocketPath is not set, returning empty map")
 		return nil, nil
@@ -126,7 +135,7 @@ func (p *PodResourceMapper) GetDeviceToPodMap() (map[string]*PodInfo, error) {
 	for _, pod := range resp.PodResources {
 		for _, container := range pod.Containers {
 			for _, device := range container.Devices {
-				if device.ResourceName == "nvidia.com/gpu" {
+				if match == nil || match(device.ResourceName) {
 					for _, deviceID := range device.DeviceIds {
 						deviceToPodMap[deviceID] = &PodInfo{
 							Namespace: pod.Namespace,
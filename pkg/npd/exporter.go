@@ -0,0 +1,255 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package npd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/sirupsen/logrus"
+)
+
+// Exporter converts the results into NPD statuses and writes them to the
+// configured sinks. A sink that fails is logged and retried with the next
+// result, it never fails the health check pipeline.
+type Exporter struct {
+	cfg       *Config
+	converter *Converter
+	socket    *SocketSink
+	journald  *JournaldSink
+
+	mu        sync.Mutex
+	lastState map[string]ConditionStatus
+}
+
+// New constructs an Exporter, it returns nil if the export is disabled or no
+// sink is configured.
+func New(cfg *Config) *Exporter {
+	if cfg == nil || !cfg.NPD.Enable {
+		return nil
+	}
+	e := &Exporter{
+		cfg:       cfg,
+		converter: NewConverter(cfg.NPD.Source, cfg.NPD.Levels),
+		lastState: make(map[string]ConditionStatus),
+	}
+	if cfg.NPD.Socket != "" {
+		e.socket = NewSocketSink(cfg.NPD.Socket, cfg.NPD.Timeout)
+	}
+	if cfg.NPD.Journald {
+		e.journald = NewJournaldSink(cfg.NPD.JournaldSocket, cfg.NPD.Source)
+	}
+	if e.socket == nil && e.journald == nil {
+		logrus.WithField("npd", "config").Warnf("npd export is enabled without a socket or journald")
+		return nil
+	}
+	return e
+}
+
+// Export writes the status of the result to the sinks. The socket receives
+// the full status every time, journald the new events and the conditions
+// that changed status.
+func (e *Exporter) Export(result *common.Result) {
+	if e == nil || result == nil {
+		return
+	}
+	status := e.converter.Convert(result)
+	if status == nil {
+		return
+	}
+	if e.socket != nil {
+		if err := e.socket.Write(status); err != nil {
+			logrus.WithField("npd", "socket").Warnf("write the npd status of %s failed: %v", result.Item, err)
+		}
+	}
+	if e.journald == nil {
+		return
+	}
+	for _, event := range status.Events {
+		if err := e.journald.WriteEvent(result.Item, event); err != nil {
+			logrus.WithField("npd", "journald").Warnf("write the npd event %s of %s failed: %v", event.Reason, result.Item, err)
+		}
+	}
+	e.mu.Lock()
+	var changed []Condition
+	for _, cond := range status.Conditions {
+		if prev, ok := e.lastState[cond.Type]; ok && prev == cond.Status {
+			continue
+		}
+		e.lastState[cond.Type] = cond.Status
+		changed = append(changed, cond)
+	}
+	e.mu.Unlock()
+	for _, cond := range changed {
+		if err := e.journald.WriteCondition(result.Item, cond); err != nil {
+			logrus.WithField("npd", "journald").Warnf("write the npd condition %s failed: %v", cond.Type, err)
+		}
+	}
+}
+
+// Close closes the connection of the socket sink.
+func (e *Exporter) Close() {
+	if e == nil || e.socket == nil {
+		return
+	}
+	e.socket.Close()
+}
+
+// SocketSink writes the statuses as line-delimited JSON to a unix stream
+// socket, e.g. the one of an NPD custom plugin bridge. The connection is
+// dialed lazily and again after a failed write.
+type SocketSink struct {
+	path    string
+	timeout time.Duration
+	mu      sync.Mutex
+	conn    net.Conn
+}
+
+// NewSocketSink constructs a SocketSink writing to the unix socket at path.
+func NewSocketSink(path string, timeout time.Duration) *SocketSink {
+	return &SocketSink{path: path, timeout: timeout}
+}
+
+// Write sends the status as a single JSON line.
+func (s *SocketSink) Write(status *Status) error {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		conn, err := net.DialTimeout("unix", s.path, s.timeout)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	_ = s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+	if _, err := s.conn.Write(data); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
+	return nil
+}
+
+// Close closes the connection, the next Write dials again.
+func (s *SocketSink) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
+
+// JournaldSink writes entries with the native journal protocol, so that the
+// NPD system log monitor with the journald source reads them by the syslog
+// identifier. The message is "<reason>: <message>", the patterns of the
+// monitor config match it.
+type JournaldSink struct {
+	path       string
+	identifier string
+}
+
+// NewJournaldSink constructs a JournaldSink writing to the journal socket at
+// path with the given syslog identifier.
+func NewJournaldSink(path, identifier string) *JournaldSink {
+	return &JournaldSink{path: path, identifier: identifier}
+}
+
+// WriteEvent writes a problem event of a component.
+func (j *JournaldSink) WriteEvent(component string, event Event) error {
+	priority := "6"
+	if event.Severity == SeverityWarn {
+		priority = "4"
+	}
+	return j.send(map[string]string{
+		"MESSAGE":          event.Reason + ": " + event.Message,
+		"PRIORITY":         priority,
+		"SICHEK_COMPONENT": component,
+		"SICHEK_REASON":    event.Reason,
+	})
+}
+
+// WriteCondition writes the change of the problem condition of a component,
+// e.g. "SichekNvidiaProblem True: XidError: ...".
+func (j *JournaldSink) WriteCondition(component string, cond Condition) error {
+	priority := "6"
+	if cond.Status == ConditionTrue {
+		priority = "3"
+	}
+	return j.send(map[string]string{
+		"MESSAGE":          fmt.Sprintf("%s %s: %s: %s", cond.Type, cond.Status, cond.Reason, cond.Message),
+		"PRIORITY":         priority,
+		"SICHEK_COMPONENT": component,
+		"SICHEK_CONDITION": cond.Type,
+		"SICHEK_REASON":    cond.Reason,
+	})
+}
+
+func (j *JournaldSink) send(fields map[string]string) error {
+	fields["SYSLOG_IDENTIFIER"] = j.identifier
+	conn, err := net.Dial("unixgram", j.path)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write(EncodeJournalEntry(fields))
+	return err
+}
+
+// EncodeJournalEntry encodes the fields with the native journal protocol:
+// "KEY=value\n", or the key, a newline, the little-endian 64-bit length and
+// the value for the values spanning several lines.
+func EncodeJournalEntry(fields map[string]string) []byte {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	// MESSAGE first, the rest sorted, keeps the entries stable
+	sort.Slice(keys, func(i, k int) bool { return keyLess(keys[i], keys[k]) })
+	var buf bytes.Buffer
+	for _, key := range keys {
+		value := fields[key]
+		if !strings.Contains(value, "\n") {
+			buf.WriteString(key + "=" + value + "\n")
+			continue
+		}
+		buf.WriteString(key + "\n")
+		_ = binary.Write(&buf, binary.LittleEndian, uint64(len(value)))
+		buf.WriteString(value + "\n")
+	}
+	return buf.Bytes()
+}
+
+func keyLess(a, b string) bool {
+	if a == "MESSAGE" || b == "MESSAGE" {
+		return a == "MESSAGE" && b != "MESSAGE"
+	}
+	return a < b
+}
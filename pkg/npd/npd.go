@@ -0,0 +1,254 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package npd exports the results of the daemon in the format of the
+// Kubernetes Node Problem Detector (NPD), so that clusters already running
+// NPD pipelines pick up the problems sichek finds. Each component maps to a
+// permanent problem condition, e.g. SichekNvidiaProblem, and each checker
+// that turns abnormal to a temporary problem event. The status is written
+// as line-delimited JSON to a unix socket and/or to journald, where the NPD
+// system log monitor watches the sichek syslog identifier.
+package npd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DefaultSource         = "sichek"
+	DefaultJournaldSocket = "/run/systemd/journal/socket"
+
+	ConditionPrefix = "Sichek"
+	ConditionSuffix = "Problem"
+
+	// maxMessageLen keeps the messages within what NPD and the API server accept.
+	maxMessageLen = 1024
+)
+
+// Severity of an NPD event, see k8s.io/node-problem-detector/pkg/types.
+type Severity string
+
+const (
+	SeverityInfo Severity = "info"
+	SeverityWarn Severity = "warn"
+)
+
+// ConditionStatus of an NPD condition, True means the problem is present.
+type ConditionStatus string
+
+const (
+	ConditionTrue    ConditionStatus = "True"
+	ConditionFalse   ConditionStatus = "False"
+	ConditionUnknown ConditionStatus = "Unknown"
+)
+
+// Event is a temporary problem, NPD turns it into a Kubernetes event.
+type Event struct {
+	Severity  Severity  `json:"severity"`
+	Timestamp time.Time `json:"timestamp"`
+	Reason    string    `json:"reason"`
+	Message   string    `json:"message"`
+}
+
+// Condition is a permanent problem, NPD turns it into a node condition.
+type Condition struct {
+	Type       string          `json:"type"`
+	Status     ConditionStatus `json:"status"`
+	Transition time.Time       `json:"transition"`
+	Reason     string          `json:"reason"`
+	Message    string          `json:"message"`
+}
+
+// Status is what a problem daemon reports to NPD, it mirrors types.Status of
+// the node problem detector.
+type Status struct {
+	Source     string      `json:"source"`
+	Events     []Event     `json:"events"`
+	Conditions []Condition `json:"conditions"`
+}
+
+// Config is the `npd` section of the user config.
+type Config struct {
+	NPD struct {
+		Enable bool `json:"enable" yaml:"enable"`
+		// Source names the problem daemon in the events and the syslog identifier in journald.
+		Source string `json:"source" yaml:"source"`
+		// Levels of an abnormal checker that are reported as problems.
+		Levels []string `json:"levels" yaml:"levels"`
+		// Socket is the unix socket the status is written to as line-delimited JSON, empty disables it.
+		Socket string `json:"socket" yaml:"socket"`
+		// Journald writes the events and condition changes to journald.
+		Journald       bool          `json:"journald" yaml:"journald"`
+		JournaldSocket string        `json:"journald_socket" yaml:"journald_socket"`
+		Timeout        time.Duration `json:"timeout" yaml:"timeout"`
+	} `json:"npd" yaml:"npd"`
+}
+
+// LoadConfig loads the npd config from cfgFile, missing fields keep their
+// defaults.
+func LoadConfig(cfgFile string) *Config {
+	config := &Config{}
+	if cfgFile != "" {
+		data, err := os.ReadFile(cfgFile)
+		if err == nil {
+			err = yaml.Unmarshal(data, config)
+		}
+		if err != nil {
+			logrus.WithField("npd", "config").Warnf("Failed to load npd config from %s, using defaults: %v", cfgFile, err)
+		}
+	}
+	cfg := &config.NPD
+	if cfg.Source == "" {
+		cfg.Source = DefaultSource
+	}
+	if len(cfg.Levels) == 0 {
+		cfg.Levels = []string{consts.LevelCritical, consts.LevelFatal}
+	}
+	if cfg.JournaldSocket == "" {
+		cfg.JournaldSocket = DefaultJournaldSocket
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 2 * time.Second
+	}
+	return config
+}
+
+// ConditionType returns the condition type of a component, e.g. SichekNvidiaProblem.
+func ConditionType(component string) string {
+	var name strings.Builder
+	for _, part := range strings.FieldsFunc(component, func(r rune) bool { return r == '_' || r == '-' }) {
+		name.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return ConditionPrefix + name.String() + ConditionSuffix
+}
+
+// Converter turns the results of the components into NPD statuses. It keeps
+// the abnormal checkers and the conditions between results, so that an
+// event is only emitted when a checker turns abnormal and a condition keeps
+// its transition time while its status does not change.
+type Converter struct {
+	source     string
+	levels     map[string]bool
+	mu         sync.Mutex
+	abnormal   map[string]bool
+	conditions map[string]Condition
+
+	now func() time.Time
+}
+
+// NewConverter constructs a Converter reporting the abnormal checkers at the
+// given levels as problems of source.
+func NewConverter(source string, levels []string) *Converter {
+	c := &Converter{
+		source:     source,
+		levels:     make(map[string]bool, len(levels)),
+		abnormal:   make(map[string]bool),
+		conditions: make(map[string]Condition),
+		now:        time.Now,
+	}
+	for _, level := range levels {
+		c.levels[level] = true
+	}
+	return c
+}
+
+// Convert returns the status of the result: the condition of its component
+// and an event per checker that turned abnormal since the previous result.
+// Silenced checkers are not problems.
+func (c *Converter) Convert(result *common.Result) *Status {
+	if result == nil {
+		return nil
+	}
+	now := c.now()
+	status := &Status{Source: c.source, Events: []Event{}}
+	var reasons, details []string
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, checker := range result.Checkers {
+		if checker == nil {
+			continue
+		}
+		key := result.Item + "/" + checker.Name
+		if checker.Status != consts.StatusAbnormal || !c.levels[checker.Level] {
+			delete(c.abnormal, key)
+			continue
+		}
+		detail := oneLine(checker.Detail)
+		reasons = append(reasons, checker.ErrorName)
+		details = append(details, detail)
+		if c.abnormal[key] {
+			continue
+		}
+		c.abnormal[key] = true
+		message := fmt.Sprintf("[%s] %s", key, detail)
+		if checker.Device != "" {
+			message = fmt.Sprintf("[%s] device=%s %s", key, checker.Device, detail)
+		}
+		status.Events = append(status.Events, Event{
+			Severity:  SeverityWarn,
+			Timestamp: now,
+			Reason:    checker.ErrorName,
+			Message:   truncate(message, maxMessageLen),
+		})
+	}
+
+	cond := Condition{
+		Type:    ConditionType(result.Item),
+		Status:  ConditionFalse,
+		Reason:  "NoProblem",
+		Message: fmt.Sprintf("sichek %s health check passed", result.Item),
+	}
+	if len(reasons) > 0 {
+		sort.Strings(reasons)
+		cond.Status = ConditionTrue
+		cond.Reason = reasons[0]
+		cond.Message = truncate(strings.Join(details, "; "), maxMessageLen)
+	}
+	cond.Transition = now
+	if prev, ok := c.conditions[cond.Type]; ok && prev.Status == cond.Status {
+		cond.Transition = prev.Transition
+	}
+	c.conditions[cond.Type] = cond
+
+	for _, existing := range c.conditions {
+		status.Conditions = append(status.Conditions, existing)
+	}
+	sort.Slice(status.Conditions, func(i, j int) bool { return status.Conditions[i].Type < status.Conditions[j].Type })
+	return status
+}
+
+// oneLine joins the lines of a detail, the NPD log monitor matches a single line.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(strings.TrimSpace(s), "\n", "; ")), " ")
+}
+
+// truncate cuts s to at most maxLen bytes without splitting a multi-byte rune.
+func truncate(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return strings.ToValidUTF8(s[:maxLen], "")
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package npd
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+)
+
+func abnormalResult(level string) *common.Result {
+	return &common.Result{
+		Item:   "nvidia",
+		Status: consts.StatusAbnormal,
+		Checkers: []*common.CheckerResult{
+			{Name: "xid", Status: consts.StatusAbnormal, Level: level, ErrorName: "XidError", Device: "GPU-1", Detail: "xid 79\nfallen off the bus"},
+			{Name: "pcie", Status: consts.StatusNormal, Level: consts.LevelInfo},
+		},
+	}
+}
+
+func normalResult() *common.Result {
+	return &common.Result{
+		Item:     "nvidia",
+		Status:   consts.StatusNormal,
+		Checkers: []*common.CheckerResult{{Name: "xid", Status: consts.StatusNormal, Level: consts.LevelInfo}},
+	}
+}
+
+func TestConditionType(t *testing.T) {
+	for component, want := range map[string]string{
+		"nvidia":      "SichekNvidiaProblem",
+		"infiniband":  "SichekInfinibandProblem",
+		"gpu_events":  "SichekGpuEventsProblem",
+		"hang-detect": "SichekHangDetectProblem",
+	} {
+		if got := ConditionType(component); got != want {
+			t.Errorf("ConditionType(%q) = %q, want %q", component, got, want)
+		}
+	}
+}
+
+func TestConverter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewConverter("sichek", []string{consts.LevelCritical, consts.LevelFatal})
+	c.now = func() time.Time { return now }
+
+	status := c.Convert(abnormalResult(consts.LevelCritical))
+	if len(status.Events) != 1 {
+		t.Fatalf("expected 1 event, got %+v", status.Events)
+	}
+	event := status.Events[0]
+	if event.Reason != "XidError" || event.Severity != SeverityWarn || event.Message != "[nvidia/xid] device=GPU-1 xid 79; fallen off the bus" {
+		t.Errorf("unexpected event %+v", event)
+	}
+	if len(status.Conditions) != 1 || status.Conditions[0].Status != ConditionTrue || status.Conditions[0].Reason != "XidError" {
+		t.Fatalf("unexpected conditions %+v", status.Conditions)
+	}
+	firstTransition := status.Conditions[0].Transition
+
+	// the checker stays abnormal: no new event, the transition is kept
+	now = now.Add(time.Minute)
+	status = c.Convert(abnormalResult(consts.LevelCritical))
+	if len(status.Events) != 0 {
+		t.Errorf("expected no event while the checker stays abnormal, got %+v", status.Events)
+	}
+	if !status.Conditions[0].Transition.Equal(firstTransition) {
+		t.Errorf("transition changed while the condition kept its status")
+	}
+
+	now = now.Add(time.Minute)
+	status = c.Convert(normalResult())
+	if status.Conditions[0].Status != ConditionFalse || !status.Conditions[0].Transition.Equal(now) {
+		t.Errorf("expected the condition to recover, got %+v", status.Conditions[0])
+	}
+
+	// turning abnormal again emits a new event
+	status = c.Convert(abnormalResult(consts.LevelFatal))
+	if len(status.Events) != 1 {
+		t.Errorf("expected a new event, got %+v", status.Events)
+	}
+}
+
+func TestConverterIgnoresLevelsAndSilenced(t *testing.T) {
+	c := NewConverter("sichek", []string{consts.LevelCritical})
+	status := c.Convert(abnormalResult(consts.LevelWarning))
+	if len(status.Events) != 0 || status.Conditions[0].Status != ConditionFalse {
+		t.Errorf("warning checker reported as a problem: %+v", status)
+	}
+	result := abnormalResult(consts.LevelCritical)
+	result.Checkers[0].Status = consts.StatusSilenced
+	status = c.Convert(result)
+	if len(status.Events) != 0 || status.Conditions[0].Status != ConditionFalse {
+		t.Errorf("silenced checker reported as a problem: %+v", status)
+	}
+}
+
+func TestExporterSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "npd.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	lines := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	cfg := LoadConfig("")
+	cfg.NPD.Enable = true
+	cfg.NPD.Socket = path
+	e := New(cfg)
+	defer e.Close()
+	e.Export(abnormalResult(consts.LevelCritical))
+	e.Export(normalResult())
+
+	for i, want := range []ConditionStatus{ConditionTrue, ConditionFalse} {
+		select {
+		case line := <-lines:
+			var status Status
+			if err := json.Unmarshal([]byte(line), &status); err != nil {
+				t.Fatalf("decode status %d: %v", i, err)
+			}
+			if status.Source != DefaultSource || status.Conditions[0].Status != want {
+				t.Errorf("status %d = %+v, want condition %s", i, status, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("status %d not received", i)
+		}
+	}
+}
+
+func TestExporterJournald(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer conn.Close()
+
+	cfg := LoadConfig("")
+	cfg.NPD.Enable = true
+	cfg.NPD.Journald = true
+	cfg.NPD.JournaldSocket = path
+	New(cfg).Export(abnormalResult(consts.LevelCritical))
+
+	// the event, then the condition turning True
+	buf := make([]byte, 4096)
+	for _, want := range []string{"MESSAGE=XidError: [nvidia/xid]", "MESSAGE=SichekNvidiaProblem True: XidError:"} {
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatalf("read entry: %v", err)
+		}
+		entry := string(buf[:n])
+		if !strings.HasPrefix(entry, want) || !strings.Contains(entry, "SYSLOG_IDENTIFIER=sichek\n") {
+			t.Errorf("unexpected entry %q, want prefix %q", entry, want)
+		}
+	}
+}
+
+func TestEncodeJournalEntry(t *testing.T) {
+	data := EncodeJournalEntry(map[string]string{"PRIORITY": "4", "MESSAGE": "a\nb"})
+	want := []byte("MESSAGE\n")
+	want = binary.LittleEndian.AppendUint64(want, 3)
+	want = append(want, "a\nb\nPRIORITY=4\n"...)
+	if string(data) != string(want) {
+		t.Errorf("EncodeJournalEntry = %q, want %q", data, want)
+	}
+}
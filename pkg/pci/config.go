@@ -0,0 +1,278 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package pci reads the PCIe capability registers of a device from its config
+// space in sysfs, so that sichek does not depend on lspci/setpci being
+// installed in the container it runs in.
+package pci
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/scitix/sichek/pkg/hostfs"
+)
+
+// SysfsPath is the root of the PCI sysfs tree, joined with hostfs.Root. It is
+// a var so that tests can point it at a fake tree holding synthetic config
+// files.
+var SysfsPath = "/sys/bus/pci/devices"
+
+const (
+	capPointer    = 0x34
+	extCapStart   = 0x100
+	statusCapList = 0x10
+
+	// CapIDExpress is the id of the PCI Express capability.
+	CapIDExpress = 0x10
+	// ExtCapIDACS is the id of the Access Control Services extended capability.
+	ExtCapIDACS = 0x000d
+
+	// registers of the PCI Express capability
+	expDevCtl     = 0x08
+	expLinkCap    = 0x0c
+	expLinkStatus = 0x12
+
+	// registers of the ACS extended capability
+	acsCap = 0x04
+	acsCtl = 0x06
+)
+
+// linkSpeeds maps the link speed encoding to the format of the
+// current_link_speed attribute of the kernel.
+var linkSpeeds = map[uint32]string{
+	1: "2.5 GT/s PCIe",
+	2: "5.0 GT/s PCIe",
+	3: "8.0 GT/s PCIe",
+	4: "16.0 GT/s PCIe",
+	5: "32.0 GT/s PCIe",
+	6: "64.0 GT/s PCIe",
+}
+
+// Config is a snapshot of the config space of a PCI device.
+type Config struct {
+	BDF  string
+	data []byte
+}
+
+// Link is the speed and width of a PCIe link, in the format of the
+// *_link_speed and *_link_width sysfs attributes.
+type Link struct {
+	Speed string
+	Width int
+}
+
+// NormalizeBDF prepends the default domain to a bus:device.function address,
+// e.g. "80:00.0", as accepted by setpci.
+func NormalizeBDF(bdf string) string {
+	if strings.Count(bdf, ":") == 1 {
+		return "0000:" + bdf
+	}
+	return bdf
+}
+
+func configPath(bdf string) string {
+	return hostfs.Path(SysfsPath, NormalizeBDF(bdf), "config")
+}
+
+// ReadConfig reads the config space of the device at bdf. Unprivileged
+// readers only get the first 64 bytes, which hold no capability.
+func ReadConfig(bdf string) (*Config, error) {
+	data, err := os.ReadFile(configPath(bdf))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the config space of %s: %w", bdf, err)
+	}
+	return &Config{BDF: NormalizeBDF(bdf), data: data}, nil
+}
+
+// Word returns the 16-bit register at off.
+func (c *Config) Word(off int) (uint16, error) {
+	if off < 0 || off+2 > len(c.data) {
+		return 0, fmt.Errorf("offset 0x%x is beyond the %d bytes of config space of %s", off, len(c.data), c.BDF)
+	}
+	return binary.LittleEndian.Uint16(c.data[off:]), nil
+}
+
+// Dword returns the 32-bit register at off.
+func (c *Config) Dword(off int) (uint32, error) {
+	if off < 0 || off+4 > len(c.data) {
+		return 0, fmt.Errorf("offset 0x%x is beyond the %d bytes of config space of %s", off, len(c.data), c.BDF)
+	}
+	return binary.LittleEndian.Uint32(c.data[off:]), nil
+}
+
+// Capability returns the offset of the capability with id, or 0 if the
+// device does not have it.
+func (c *Config) Capability(id uint8) int {
+	status, err := c.Word(0x06)
+	if err != nil || status&statusCapList == 0 || len(c.data) <= capPointer {
+		return 0
+	}
+	off := int(c.data[capPointer] &^ 0x3)
+	// at most 48 capabilities fit in the 192 bytes after the header, the
+	// bound guards against a looping list
+	for i := 0; i < 48 && off >= 0x40 && off+2 <= len(c.data); i++ {
+		if c.data[off] == id {
+			return off
+		}
+		off = int(c.data[off+1] &^ 0x3)
+	}
+	return 0
+}
+
+// ExtCapability returns the offset of the extended capability with id, or 0
+// if the device does not have it or the extended config space is not readable.
+func (c *Config) ExtCapability(id uint16) int {
+	off := extCapStart
+	for i := 0; i < (4096-extCapStart)/4 && off >= extCapStart; i++ {
+		header, err := c.Dword(off)
+		if err != nil || header == 0 || header == 0xffffffff {
+			return 0
+		}
+		if uint16(header&0xffff) == id {
+			return off
+		}
+		off = int(header>>20) &^ 0x3
+	}
+	return 0
+}
+
+func (c *Config) express() (int, error) {
+	off := c.Capability(CapIDExpress)
+	if off == 0 {
+		return 0, fmt.Errorf("%s has no readable PCI Express capability", c.BDF)
+	}
+	return off, nil
+}
+
+// MaxReadRequest returns the Max Read Request size in bytes from the Device
+// Control register.
+func (c *Config) MaxReadRequest() (int, error) {
+	off, err := c.express()
+	if err != nil {
+		return 0, err
+	}
+	ctl, err := c.Word(off + expDevCtl)
+	if err != nil {
+		return 0, err
+	}
+	return 128 << ((ctl >> 12) & 0x7), nil
+}
+
+// DevCtlOffset returns the offset of the Device Control register.
+func (c *Config) DevCtlOffset() (int, error) {
+	off, err := c.express()
+	if err != nil {
+		return 0, err
+	}
+	return off + expDevCtl, nil
+}
+
+// LinkCapabilities returns the maximum speed and width of the link.
+func (c *Config) LinkCapabilities() (Link, error) {
+	off, err := c.express()
+	if err != nil {
+		return Link{}, err
+	}
+	lnkCap, err := c.Dword(off + expLinkCap)
+	if err != nil {
+		return Link{}, err
+	}
+	return newLink(lnkCap&0xf, (lnkCap>>4)&0x3f), nil
+}
+
+// LinkStatus returns the negotiated speed and width of the link.
+func (c *Config) LinkStatus() (Link, error) {
+	off, err := c.express()
+	if err != nil {
+		return Link{}, err
+	}
+	status, err := c.Word(off + expLinkStatus)
+	if err != nil {
+		return Link{}, err
+	}
+	return newLink(uint32(status&0xf), uint32(status>>4)&0x3f), nil
+}
+
+func newLink(speed, width uint32) Link {
+	link := Link{Speed: linkSpeeds[speed], Width: int(width)}
+	if link.Speed == "" {
+		link.Speed = "Unknown"
+	}
+	return link
+}
+
+// ACS returns the offset of the ACS extended capability, or an error if the
+// device does not have it.
+func (c *Config) ACS() (int, error) {
+	off := c.ExtCapability(ExtCapIDACS)
+	if off == 0 {
+		return 0, fmt.Errorf("%s has no readable ACS capability", c.BDF)
+	}
+	return off, nil
+}
+
+// ACSCapability returns the ACS Capability register.
+func (c *Config) ACSCapability() (uint16, error) {
+	off, err := c.ACS()
+	if err != nil {
+		return 0, err
+	}
+	return c.Word(off + acsCap)
+}
+
+// ACSControl returns the ACS Control register.
+func (c *Config) ACSControl() (uint16, error) {
+	off, err := c.ACS()
+	if err != nil {
+		return 0, err
+	}
+	return c.Word(off + acsCtl)
+}
+
+// ACSControlOffset returns the offset of the ACS Control register.
+func (c *Config) ACSControlOffset() (int, error) {
+	off, err := c.ACS()
+	if err != nil {
+		return 0, err
+	}
+	return off + acsCtl, nil
+}
+
+// WriteWord writes the 16-bit register at off of the device at bdf.
+func WriteWord(bdf string, off int, value uint16) error {
+	f, err := os.OpenFile(configPath(bdf), os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open the config space of %s: %w", bdf, err)
+	}
+	defer f.Close()
+	buf := make([]byte, 2)
+	binary.LittleEndian.PutUint16(buf, value)
+	if _, err := f.WriteAt(buf, int64(off)); err != nil {
+		return fmt.Errorf("failed to write offset 0x%x of %s: %w", off, bdf, err)
+	}
+	return nil
+}
+
+// ReadWord reads the 16-bit register at off of the device at bdf.
+func ReadWord(bdf string, off int) (uint16, error) {
+	cfg, err := ReadConfig(bdf)
+	if err != nil {
+		return 0, err
+	}
+	return cfg.Word(off)
+}
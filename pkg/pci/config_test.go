@@ -0,0 +1,144 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pci
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFakeDevice writes the config space of a PCIe device with a power
+// management and a PCI Express capability, and the AER and ACS extended
+// capabilities.
+func writeFakeDevice(t *testing.T, bdf string, size int) {
+	t.Helper()
+	data := make([]byte, 4096)
+	put16 := func(off int, v uint16) { binary.LittleEndian.PutUint16(data[off:], v) }
+	put32 := func(off int, v uint32) { binary.LittleEndian.PutUint32(data[off:], v) }
+	put16(0x00, 0x15b3)
+	put16(0x06, statusCapList)
+	data[capPointer] = 0x40
+	// power management, next at 0x60
+	data[0x40], data[0x41] = 0x01, 0x60
+	// PCI Express, end of list
+	data[0x60], data[0x61] = CapIDExpress, 0x00
+	put16(0x60+expDevCtl, 5<<12|0x2810&0x0fff)
+	put32(0x60+expLinkCap, 16<<4|5)
+	put16(0x60+expLinkStatus, 8<<4|4)
+	// AER, next at 0x148
+	put32(0x100, 0x148<<20|1<<16|0x0001)
+	// ACS, end of list
+	put32(0x148, 1<<16|ExtCapIDACS)
+	put16(0x148+acsCap, 0x005f)
+	put16(0x148+acsCtl, 0x001d)
+
+	data = data[:size]
+
+	dir := filepath.Join(SysfsPath, bdf)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func useFakeSysfs(t *testing.T) {
+	t.Helper()
+	orig := SysfsPath
+	SysfsPath = t.TempDir()
+	t.Cleanup(func() { SysfsPath = orig })
+}
+
+func TestConfigCapabilities(t *testing.T) {
+	useFakeSysfs(t)
+	writeFakeDevice(t, "0000:80:00.0", 4096)
+
+	// setpci style addresses default to domain 0
+	cfg, err := ReadConfig("80:00.0")
+	if err != nil {
+		t.Fatalf("ReadConfig: %v", err)
+	}
+	if off := cfg.Capability(CapIDExpress); off != 0x60 {
+		t.Errorf("PCI Express capability at 0x%x, want 0x60", off)
+	}
+	if off := cfg.Capability(0x11); off != 0 {
+		t.Errorf("MSI-X capability at 0x%x, want none", off)
+	}
+	if off := cfg.ExtCapability(ExtCapIDACS); off != 0x148 {
+		t.Errorf("ACS capability at 0x%x, want 0x148", off)
+	}
+
+	mrr, err := cfg.MaxReadRequest()
+	if err != nil || mrr != 4096 {
+		t.Errorf("MaxReadRequest = %d, %v; want 4096", mrr, err)
+	}
+	linkCap, err := cfg.LinkCapabilities()
+	if err != nil || linkCap != (Link{Speed: "32.0 GT/s PCIe", Width: 16}) {
+		t.Errorf("LinkCapabilities = %+v, %v", linkCap, err)
+	}
+	linkStatus, err := cfg.LinkStatus()
+	if err != nil || linkStatus != (Link{Speed: "16.0 GT/s PCIe", Width: 8}) {
+		t.Errorf("LinkStatus = %+v, %v", linkStatus, err)
+	}
+	acsCap, err := cfg.ACSCapability()
+	if err != nil || acsCap != 0x5f {
+		t.Errorf("ACSCapability = 0x%x, %v; want 0x5f", acsCap, err)
+	}
+	acsCtl, err := cfg.ACSControl()
+	if err != nil || acsCtl != 0x1d {
+		t.Errorf("ACSControl = 0x%x, %v; want 0x1d", acsCtl, err)
+	}
+}
+
+func TestConfigUnprivileged(t *testing.T) {
+	useFakeSysfs(t)
+	// unprivileged readers only see the header
+	writeFakeDevice(t, "0000:80:00.0", 64)
+
+	cfg, err := ReadConfig("0000:80:00.0")
+	if err != nil {
+		t.Fatalf("ReadConfig: %v", err)
+	}
+	if _, err := cfg.MaxReadRequest(); err == nil {
+		t.Error("MaxReadRequest succeeded without the capabilities")
+	}
+	if _, err := cfg.ACSControl(); err == nil {
+		t.Error("ACSControl succeeded without the extended config space")
+	}
+}
+
+func TestWriteWord(t *testing.T) {
+	useFakeSysfs(t)
+	writeFakeDevice(t, "0000:80:00.0", 4096)
+
+	if err := WriteWord("80:00.0", 0x148+acsCtl, 0); err != nil {
+		t.Fatalf("WriteWord: %v", err)
+	}
+	acsCtl, err := ReadWord("0000:80:00.0", 0x148+acsCtl)
+	if err != nil || acsCtl != 0 {
+		t.Errorf("ACS control after write = 0x%x, %v; want 0", acsCtl, err)
+	}
+	cfg, err := ReadConfig("0000:80:00.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acsCap, _ := cfg.ACSCapability(); acsCap != 0x5f {
+		t.Errorf("write clobbered the ACS capability: 0x%x", acsCap)
+	}
+}
@@ -0,0 +1,224 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package perfhistory persists the results of the performance tests of a
+// node, e.g. the bus bandwidth of nccltest, so that a run can be compared
+// against the own history of the node instead of a static threshold only.
+package perfhistory
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultMaxRuns bounds the runs kept per test and key.
+	DefaultMaxRuns = 50
+	// DefaultMinRuns is the number of previous runs a baseline needs.
+	DefaultMinRuns = 3
+)
+
+// Run is the result of a performance test.
+type Run struct {
+	Time time.Time `json:"time"`
+	Node string    `json:"node,omitempty"`
+	Test string    `json:"test"`
+	// Key identifies the runs of Test that are comparable, e.g. the GPU count
+	// and message sizes of nccltest.
+	Key    string            `json:"key"`
+	Params map[string]string `json:"params,omitempty"`
+	Value  float64           `json:"value"`
+	Unit   string            `json:"unit,omitempty"`
+}
+
+// Key builds a stable key from params, sorted by name.
+func Key(params map[string]string) string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, name+"="+params[name])
+	}
+	return strings.Join(parts, ",")
+}
+
+// Store keeps the runs as JSON lines in a single file.
+type Store struct {
+	mu   sync.Mutex
+	path string
+	// MaxRuns bounds the runs kept per test and key, the oldest are dropped.
+	MaxRuns int
+}
+
+func NewStore(path string) *Store {
+	return &Store{path: path, MaxRuns: DefaultMaxRuns}
+}
+
+// Append adds run to the history, dropping the oldest runs of its test and
+// key beyond MaxRuns.
+func (s *Store) Append(run Run) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs, err := s.read()
+	if err != nil {
+		return err
+	}
+	runs = append(runs, run)
+	if s.MaxRuns > 0 {
+		same := 0
+		for i := len(runs) - 1; i >= 0; i-- {
+			if runs[i].Test != run.Test || runs[i].Key != run.Key {
+				continue
+			}
+			same++
+			if same > s.MaxRuns {
+				runs = append(runs[:i], runs[i+1:]...)
+			}
+		}
+	}
+	return s.write(runs)
+}
+
+// Runs returns the runs of test with key, oldest first.
+func (s *Store) Runs(test, key string) ([]Run, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	var matched []Run
+	for _, run := range runs {
+		if run.Test == test && run.Key == key {
+			matched = append(matched, run)
+		}
+	}
+	return matched, nil
+}
+
+func (s *Store) read() ([]Run, error) {
+	file, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open perf history %s failed: %w", s.path, err)
+	}
+	defer file.Close()
+
+	var runs []Run
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var run Run
+		if err := json.Unmarshal(scanner.Bytes(), &run); err != nil {
+			// A torn line left by a crash must not hide the rest of the history.
+			logrus.WithField("perfhistory", s.path).Warnf("skip corrupted run: %v", err)
+			continue
+		}
+		runs = append(runs, run)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read perf history %s failed: %w", s.path, err)
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].Time.Before(runs[j].Time) })
+	return runs, nil
+}
+
+// write replaces the file atomically, so that a crash keeps the previous history.
+func (s *Store) write(runs []Run) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("create perf history dir failed: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("create perf history failed: %w", err)
+	}
+	writer := bufio.NewWriter(file)
+	for _, run := range runs {
+		data, err := json.Marshal(run)
+		if err != nil {
+			file.Close()
+			return fmt.Errorf("marshal perf run failed: %w", err)
+		}
+		writer.Write(append(data, '\n'))
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("write perf history failed: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("write perf history failed: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Median returns the median of the values of runs.
+func Median(runs []Run) float64 {
+	if len(runs) == 0 {
+		return 0
+	}
+	values := make([]float64, len(runs))
+	for i, run := range runs {
+		values[i] = run.Value
+	}
+	sort.Float64s(values)
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+	return values[mid]
+}
+
+// Comparison is a value compared against the median of the previous runs.
+type Comparison struct {
+	Value  float64
+	Median float64
+	Runs   int
+	// DropPercent is how much Value is below Median, negative when above.
+	DropPercent float64
+	// Regressed is set when DropPercent exceeds the allowed drop.
+	Regressed bool
+}
+
+// Compare compares value, where higher is better, against the median of
+// history. It returns nil when history has fewer than minRuns runs, a
+// baseline of one or two runs is too noisy to fail a node on.
+func Compare(history []Run, value, maxDropPercent float64, minRuns int) *Comparison {
+	if len(history) == 0 || len(history) < minRuns {
+		return nil
+	}
+	median := Median(history)
+	c := &Comparison{Value: value, Median: median, Runs: len(history)}
+	if median > 0 {
+		c.DropPercent = (median - value) / median * 100
+	}
+	c.Regressed = c.DropPercent > maxDropPercent
+	return c
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package perfhistory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStoreAppendAndRuns(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "data", "perf-history.jsonl"))
+	store.MaxRuns = 3
+	key := Key(map[string]string{"gpus": "8", "begin": "1G", "end": "8G"})
+	if key != "begin=1G,end=8G,gpus=8" {
+		t.Fatalf("unexpected key %q", key)
+	}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		if err := store.Append(Run{Time: start.Add(time.Duration(i) * time.Hour), Test: "nccltest", Key: key, Value: float64(100 + i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Append(Run{Time: start, Test: "nccltest", Key: "gpus=2", Value: 50}); err != nil {
+		t.Fatal(err)
+	}
+
+	runs, err := store.Runs("nccltest", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 3 || runs[0].Value != 102 || runs[2].Value != 104 {
+		t.Errorf("expected the last 3 runs, got %+v", runs)
+	}
+	if runs, _ := store.Runs("nccltest", "gpus=2"); len(runs) != 1 {
+		t.Errorf("expected the runs of another key to be kept, got %+v", runs)
+	}
+}
+
+func TestStoreSkipsCorruptedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "perf-history.jsonl")
+	data := `{"time":"2026-01-01T00:00:00Z","test":"nccltest","key":"k","value":100}
+{"time":"2026-01-01T01:00:00Z","test":"ncc
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runs, err := NewStore(path).Runs("nccltest", "k")
+	if err != nil || len(runs) != 1 {
+		t.Errorf("expected the valid run only, got %+v, %v", runs, err)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	history := []Run{{Value: 180}, {Value: 100}, {Value: 182}, {Value: 178}}
+	if got := Median(history); got != 179 {
+		t.Errorf("Median() = %v, want 179", got)
+	}
+	if c := Compare(history[:2], 90, 10, DefaultMinRuns); c != nil {
+		t.Errorf("expected no baseline below %d runs, got %+v", DefaultMinRuns, c)
+	}
+	c := Compare(history, 150, 10, DefaultMinRuns)
+	if c == nil || !c.Regressed || c.Runs != 4 {
+		t.Fatalf("expected a regression, got %+v", c)
+	}
+	if c := Compare(history, 170, 10, DefaultMinRuns); c == nil || c.Regressed {
+		t.Errorf("expected a drop of 5%% to be within 10%%, got %+v", c)
+	}
+}
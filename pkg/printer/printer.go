@@ -0,0 +1,258 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package printer is the single way the CLI writes its report. The report
+// goes to stdout in one of three modes: plain text, text colored with ANSI
+// escapes, or a JSON document written once at the end. Warnings and the
+// verbose progress go to stderr, so that a redirected report stays parsable.
+// With the quiet verbosity nothing is printed, the exit code alone tells
+// the outcome.
+package printer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+type Mode string
+
+const (
+	ModePlain Mode = "plain"
+	ModeColor Mode = "color"
+	ModeJSON  Mode = "json"
+)
+
+type Verbosity int
+
+const (
+	VerbosityQuiet Verbosity = iota
+	VerbosityNormal
+	VerbosityVerbose
+)
+
+// ColorAuto colors the report when stdout is a terminal and NO_COLOR is unset.
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+// defaultWidth is the width of the titles when stdout is not a terminal.
+const defaultWidth = 80
+
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// Printer writes the report of the CLI, it is safe for concurrent use.
+type Printer struct {
+	mode      Mode
+	verbosity Verbosity
+	mu        sync.Mutex
+	out       io.Writer
+	err       io.Writer
+}
+
+// New constructs a Printer writing the report to out and the warnings and
+// progress to errOut.
+func New(mode Mode, verbosity Verbosity, out, errOut io.Writer) *Printer {
+	return &Printer{mode: mode, verbosity: verbosity, out: out, err: errOut}
+}
+
+var (
+	defaultMu      sync.RWMutex
+	defaultPrinter = New(ModeFromColor(ColorAuto, os.Stdout), VerbosityNormal, os.Stdout, os.Stderr)
+)
+
+// Default returns the printer of the CLI.
+func Default() *Printer {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultPrinter
+}
+
+// SetDefault replaces the printer of the CLI.
+func SetDefault(p *Printer) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultPrinter = p
+}
+
+// ParseMode returns the mode of the --json and --color flags.
+func ParseMode(jsonOut bool, color string) (Mode, error) {
+	if jsonOut {
+		return ModeJSON, nil
+	}
+	switch color {
+	case ColorAuto, "":
+		return ModeFromColor(ColorAuto, os.Stdout), nil
+	case ColorAlways, ColorNever:
+		return ModeFromColor(color, os.Stdout), nil
+	default:
+		return "", fmt.Errorf("invalid --color %q, expected %s, %s or %s", color, ColorAuto, ColorAlways, ColorNever)
+	}
+}
+
+// ModeFromColor returns the text mode of a --color value for out.
+func ModeFromColor(color string, out *os.File) Mode {
+	switch color {
+	case ColorAlways:
+		return ModeColor
+	case ColorNever:
+		return ModePlain
+	}
+	if os.Getenv("NO_COLOR") != "" || out == nil || !term.IsTerminal(int(out.Fd())) {
+		return ModePlain
+	}
+	return ModeColor
+}
+
+func (p *Printer) Mode() Mode { return p.mode }
+
+func (p *Printer) Verbosity() Verbosity { return p.verbosity }
+
+// IsJSON reports whether the report is a JSON document.
+func (p *Printer) IsJSON() bool { return p.mode == ModeJSON }
+
+// textEnabled reports whether the text of the report is printed.
+func (p *Printer) textEnabled() bool {
+	return p.mode != ModeJSON && p.verbosity > VerbosityQuiet
+}
+
+func (p *Printer) write(w io.Writer, s string) {
+	if p.mode != ModeColor {
+		s = ansiEscape.ReplaceAllString(s, "")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, _ = io.WriteString(w, s)
+}
+
+// Printf prints a line of the text report.
+func (p *Printer) Printf(format string, args ...any) {
+	if p.textEnabled() {
+		p.write(p.out, fmt.Sprintf(format, args...))
+	}
+}
+
+// Println prints a line of the text report.
+func (p *Printer) Println(args ...any) {
+	if p.textEnabled() {
+		p.write(p.out, fmt.Sprintln(args...))
+	}
+}
+
+// Print prints text of the report.
+func (p *Printer) Print(args ...any) {
+	if p.textEnabled() {
+		p.write(p.out, fmt.Sprint(args...))
+	}
+}
+
+// Write prints p as text of the report, so that the printer can be passed
+// to the functions writing to an io.Writer.
+func (p *Printer) Write(b []byte) (int, error) {
+	if p.textEnabled() {
+		p.write(p.out, string(b))
+	}
+	return len(b), nil
+}
+
+// WithOutput returns a printer of the same mode and verbosity writing the
+// report to out.
+func (p *Printer) WithOutput(out io.Writer) *Printer {
+	return New(p.mode, p.verbosity, out, p.err)
+}
+
+// Title prints text centered in a line of paddingChar as wide as 80% of the
+// terminal, or of defaultWidth when stdout is not a terminal.
+func (p *Printer) Title(text, paddingChar string) {
+	if !p.textEnabled() {
+		return
+	}
+	width := defaultWidth
+	if f, ok := p.out.(*os.File); ok {
+		if w, _, err := term.GetSize(int(f.Fd())); err == nil {
+			width = int(float64(w) * 0.8)
+		}
+	}
+	p.write(p.out, padTitle(text, paddingChar, width)+"\n")
+}
+
+func padTitle(text, paddingChar string, width int) string {
+	paddingLength := width - len(text)
+	charLen := len(paddingChar)
+	if paddingLength <= 0 || charLen == 0 {
+		return text
+	}
+	leftPadding := paddingLength / 2
+	rightPadding := paddingLength - leftPadding
+	left := strings.Repeat(paddingChar, leftPadding/charLen) + paddingChar[:leftPadding%charLen]
+	right := strings.Repeat(paddingChar, rightPadding/charLen) + paddingChar[:rightPadding%charLen]
+	return left + text + right
+}
+
+// Warnf prints a warning to stderr, unless quiet.
+func (p *Printer) Warnf(format string, args ...any) {
+	if p.verbosity > VerbosityQuiet {
+		p.write(p.err, fmt.Sprintf(format, args...))
+	}
+}
+
+// Progressf prints the progress of the checks to stderr, only when verbose.
+func (p *Printer) Progressf(format string, args ...any) {
+	if p.verbosity >= VerbosityVerbose {
+		p.write(p.err, fmt.Sprintf(format, args...))
+	}
+}
+
+// JSON writes v as the JSON document of the report, only in the JSON mode.
+func (p *Printer) JSON(v any) error {
+	if p.mode != ModeJSON || p.verbosity == VerbosityQuiet {
+		return nil
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	p.write(p.out, string(data)+"\n")
+	return nil
+}
+
+// Printf prints a line of the text report with the default printer.
+func Printf(format string, args ...any) { Default().Printf(format, args...) }
+
+// Println prints a line of the text report with the default printer.
+func Println(args ...any) { Default().Println(args...) }
+
+// Print prints text of the report with the default printer.
+func Print(args ...any) { Default().Print(args...) }
+
+// JSON writes v as the JSON document of the report with the default printer.
+func JSON(v any) error { return Default().JSON(v) }
+
+// Title prints a title line with the default printer.
+func Title(text, paddingChar string) { Default().Title(text, paddingChar) }
+
+// Warnf prints a warning with the default printer.
+func Warnf(format string, args ...any) { Default().Warnf(format, args...) }
+
+// Progressf prints the progress of the checks with the default printer.
+func Progressf(format string, args ...any) { Default().Progressf(format, args...) }
@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package printer
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const red, reset = "\033[31m", "\033[0m"
+
+func TestPrinterModes(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    Mode
+		wantOut string
+	}{
+		{name: "color keeps the escapes", mode: ModeColor, wantOut: red + "FAIL" + reset + "\n"},
+		{name: "plain strips the escapes", mode: ModePlain, wantOut: "FAIL\n"},
+		{name: "json drops the text", mode: ModeJSON, wantOut: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			p := New(tt.mode, VerbosityNormal, &out, &errOut)
+			p.Printf("%sFAIL%s\n", red, reset)
+			if out.String() != tt.wantOut {
+				t.Errorf("stdout = %q, want %q", out.String(), tt.wantOut)
+			}
+			p.Warnf("unknown components x\n")
+			if errOut.String() != "unknown components x\n" {
+				t.Errorf("warnings must go to stderr in every mode, got %q", errOut.String())
+			}
+		})
+	}
+}
+
+func TestPrinterVerbosity(t *testing.T) {
+	var out, errOut bytes.Buffer
+	p := New(ModePlain, VerbosityQuiet, &out, &errOut)
+	p.Printf("report\n")
+	p.Title("Summary", "-")
+	p.Warnf("warning\n")
+	p.Progressf("progress\n")
+	if out.Len() != 0 || errOut.Len() != 0 {
+		t.Errorf("quiet printed stdout=%q stderr=%q", out.String(), errOut.String())
+	}
+
+	p = New(ModePlain, VerbosityNormal, &out, &errOut)
+	p.Progressf("progress\n")
+	if errOut.Len() != 0 {
+		t.Errorf("progress printed without verbose: %q", errOut.String())
+	}
+	p = New(ModePlain, VerbosityVerbose, &out, &errOut)
+	p.Progressf("[cpu] checking\n")
+	if errOut.String() != "[cpu] checking\n" || out.Len() != 0 {
+		t.Errorf("verbose progress: stdout=%q stderr=%q", out.String(), errOut.String())
+	}
+}
+
+func TestPrinterJSON(t *testing.T) {
+	var out, errOut bytes.Buffer
+	p := New(ModeJSON, VerbosityNormal, &out, &errOut)
+	p.Printf("Hostname: node-a\n")
+	p.Title("Summary", "-")
+	if err := p.JSON(map[string]string{"overall": "PASS"}); err != nil {
+		t.Fatalf("JSON: %v", err)
+	}
+	var doc map[string]string
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatalf("stdout is not a JSON document: %v\n%s", err, out.String())
+	}
+	if doc["overall"] != "PASS" {
+		t.Errorf("unexpected document %v", doc)
+	}
+
+	out.Reset()
+	if err := New(ModePlain, VerbosityNormal, &out, &errOut).JSON(doc); err != nil || out.Len() != 0 {
+		t.Errorf("JSON printed in the plain mode: %q, %v", out.String(), err)
+	}
+}
+
+func TestPrinterTitleWithoutTerminal(t *testing.T) {
+	var out bytes.Buffer
+	New(ModePlain, VerbosityNormal, &out, &out).Title("Summary", "-")
+	line := strings.TrimSuffix(out.String(), "\n")
+	if len(line) != defaultWidth || !strings.Contains(line, "Summary") {
+		t.Errorf("title = %q, want %d wide", line, defaultWidth)
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	if mode, _ := ParseMode(true, ColorAlways); mode != ModeJSON {
+		t.Errorf("--json must win over --color, got %s", mode)
+	}
+	if mode, _ := ParseMode(false, ColorAlways); mode != ModeColor {
+		t.Errorf("--color always = %s", mode)
+	}
+	if mode, _ := ParseMode(false, ColorNever); mode != ModePlain {
+		t.Errorf("--color never = %s", mode)
+	}
+	if _, err := ParseMode(false, "rainbow"); err == nil {
+		t.Errorf("expected an error for an invalid --color")
+	}
+}
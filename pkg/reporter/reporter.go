@@ -0,0 +1,285 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package reporter pushes the status changes of the health check results and
+// periodic heartbeats of the node to a central aggregation endpoint. Unlike
+// the snapshot reporter of the daemon, which POSTs the whole state of the node
+// every interval, it only sends a result when the status of a component or of
+// one of its checkers changed.
+package reporter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/httpclient"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	EnvReportURL      = "SICHEK_REPORT_URL"
+	EnvReportSpoolDir = "SICHEK_REPORT_SPOOL_DIR"
+
+	EventTypeResult    = "result"
+	EventTypeHeartbeat = "heartbeat"
+
+	defaultSpoolDir = "/var/sichek/data/report-spool"
+)
+
+// Config controls the result reporter.
+type Config struct {
+	URL               string
+	Timeout           time.Duration
+	RetryMax          int
+	HeartbeatInterval time.Duration
+	SpoolDir          string
+	// SpoolMaxFiles caps the spooled events, the oldest ones are dropped first.
+	SpoolMaxFiles int
+	QueueSize     int
+}
+
+// ConfigFromEnv returns the reporter config, URL is empty when
+// SICHEK_REPORT_URL is not set which disables the reporter.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		URL:               strings.TrimSpace(os.Getenv(EnvReportURL)),
+		Timeout:           10 * time.Second,
+		RetryMax:          3,
+		HeartbeatInterval: 5 * time.Minute,
+		SpoolDir:          defaultSpoolDir,
+		SpoolMaxFiles:     1000,
+		QueueSize:         100,
+	}
+	if dir := os.Getenv(EnvReportSpoolDir); dir != "" {
+		cfg.SpoolDir = dir
+	}
+	return cfg
+}
+
+// Event is the JSON document POSTed to the aggregation endpoint.
+type Event struct {
+	Type   string         `json:"type"`
+	Node   string         `json:"node"`
+	Time   time.Time      `json:"time"`
+	Result *common.Result `json:"result,omitempty"`
+}
+
+// Reporter sends the events queued by Report from a single goroutine, so the
+// health check pipeline never blocks on the network. Events that cannot be
+// delivered after the retries are spooled to disk and resent once the
+// endpoint is reachable again.
+type Reporter struct {
+	cfg    Config
+	node   string
+	client *http.Client
+	queue  chan *Event
+
+	mu sync.Mutex
+	// reported is the state of the last result reported per component.
+	reported map[string]string
+
+	// backoff allows tests to inject zero-sleep. Defaults to exponential.
+	backoff func(attempt int) time.Duration
+}
+
+// New constructs a Reporter, it returns nil if cfg.URL is empty.
+func New(cfg Config, node string) *Reporter {
+	if cfg.URL == "" {
+		return nil
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 100
+	}
+	return &Reporter{
+		cfg:      cfg,
+		node:     node,
+		client:   &http.Client{Timeout: cfg.Timeout},
+		queue:    make(chan *Event, cfg.QueueSize),
+		reported: make(map[string]string),
+		backoff:  httpclient.ExponentialBackoff,
+	}
+}
+
+// Report queues the result when the status of its component or of one of its
+// checkers changed since the last reported result, i.e. when the component
+// turns abnormal, its abnormal checkers change, or it recovers. It never
+// blocks, the event is spooled when the queue is full.
+func (r *Reporter) Report(result *common.Result) {
+	if r == nil || result == nil || !r.changed(result) {
+		return
+	}
+	event := &Event{Type: EventTypeResult, Node: r.node, Time: time.Now(), Result: result}
+	select {
+	case r.queue <- event:
+	default:
+		r.logEntry().Warnf("report queue is full, spool result of %s", result.Item)
+		r.spool(event)
+	}
+}
+
+// changed records the state of result and reports whether it differs from the
+// state last reported for its component. A normal component is never reported
+// until it was reported abnormal.
+func (r *Reporter) changed(result *common.Result) bool {
+	state := resultState(result)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	last, ok := r.reported[result.Item]
+	if !ok && result.Status != consts.StatusAbnormal {
+		return false
+	}
+	if ok && last == state {
+		return false
+	}
+	r.reported[result.Item] = state
+	return true
+}
+
+// resultState is the status of result and of its abnormal checkers.
+func resultState(result *common.Result) string {
+	parts := []string{result.Status}
+	for _, checker := range result.Checkers {
+		if checker != nil && checker.Status == consts.StatusAbnormal {
+			parts = append(parts, checker.Name+"="+checker.Level)
+		}
+	}
+	sort.Strings(parts[1:])
+	return strings.Join(parts, ",")
+}
+
+// Run delivers the queued events and the heartbeats until ctx is canceled.
+func (r *Reporter) Run(ctx context.Context) {
+	if r == nil {
+		return
+	}
+	r.logEntry().Infof("result reporter started; url=%s heartbeat=%v", r.cfg.URL, r.cfg.HeartbeatInterval)
+	heartbeat := time.NewTicker(r.cfg.HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	r.deliver(ctx, &Event{Type: EventTypeHeartbeat, Node: r.node, Time: time.Now()})
+	for {
+		select {
+		case <-ctx.Done():
+			r.logEntry().Info("result reporter stopped (context canceled)")
+			return
+		case event := <-r.queue:
+			r.deliver(ctx, event)
+		case <-heartbeat.C:
+			r.deliver(ctx, &Event{Type: EventTypeHeartbeat, Node: r.node, Time: time.Now()})
+		}
+	}
+}
+
+// deliver sends the event, spools it on failure, and flushes the spool once
+// the endpoint accepted an event. Heartbeats are never spooled, a late
+// heartbeat carries no information.
+func (r *Reporter) deliver(ctx context.Context, event *Event) {
+	defer func() {
+		if p := recover(); p != nil {
+			r.logEntry().Errorf("result reporter panic: %v", p)
+		}
+	}()
+	data, err := json.Marshal(event)
+	if err != nil {
+		r.logEntry().Errorf("marshal %s event failed: %v", event.Type, err)
+		return
+	}
+	if err := r.post(ctx, data); err != nil {
+		r.logEntry().Warnf("post %s event failed: %v", event.Type, err)
+		if event.Type == EventTypeResult {
+			r.spoolData(data)
+		}
+		return
+	}
+	r.flushSpool(ctx)
+}
+
+// post POSTs the event with the retries of cfg.RetryMax.
+func (r *Reporter) post(ctx context.Context, data []byte) error {
+	header := http.Header{}
+	header.Set("X-Sichek-Node", r.node)
+	header.Set("Content-Type", "application/json")
+	return httpclient.PostWithRetry(ctx, r.client, r.cfg.URL, header, data, r.cfg.RetryMax, r.backoff)
+}
+
+func (r *Reporter) spool(event *Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		r.logEntry().Errorf("marshal %s event failed: %v", event.Type, err)
+		return
+	}
+	r.spoolData(data)
+}
+
+func (r *Reporter) spoolData(data []byte) {
+	if err := os.MkdirAll(r.cfg.SpoolDir, 0755); err != nil {
+		r.logEntry().Errorf("create spool dir %s failed: %v", r.cfg.SpoolDir, err)
+		return
+	}
+	name := filepath.Join(r.cfg.SpoolDir, fmt.Sprintf("%d.json", time.Now().UnixNano()))
+	if err := os.WriteFile(name, data, 0644); err != nil {
+		r.logEntry().Errorf("spool event to %s failed: %v", name, err)
+		return
+	}
+	files := r.spooledFiles()
+	for len(files) > r.cfg.SpoolMaxFiles && r.cfg.SpoolMaxFiles > 0 {
+		_ = os.Remove(files[0])
+		files = files[1:]
+	}
+}
+
+// flushSpool resends the spooled events in order and stops at the first failure.
+func (r *Reporter) flushSpool(ctx context.Context) {
+	for _, name := range r.spooledFiles() {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			r.logEntry().Errorf("read spooled event %s failed: %v", name, err)
+			_ = os.Remove(name)
+			continue
+		}
+		if err := r.post(ctx, data); err != nil {
+			r.logEntry().Warnf("resend spooled event %s failed: %v", name, err)
+			return
+		}
+		_ = os.Remove(name)
+	}
+}
+
+// spooledFiles returns the spooled events, oldest first.
+func (r *Reporter) spooledFiles() []string {
+	files, err := filepath.Glob(filepath.Join(r.cfg.SpoolDir, "*.json"))
+	if err != nil {
+		return nil
+	}
+	sort.Strings(files)
+	return files
+}
+
+func (r *Reporter) logEntry() *logrus.Entry {
+	return logrus.WithFields(logrus.Fields{
+		"service": "result-reporter",
+		"node":    r.node,
+	})
+}
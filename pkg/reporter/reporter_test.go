@@ -0,0 +1,183 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package reporter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+)
+
+func newTestReporter(t *testing.T, url string) *Reporter {
+	t.Helper()
+	r := New(Config{
+		URL:               url,
+		Timeout:           time.Second,
+		RetryMax:          2,
+		HeartbeatInterval: time.Hour,
+		SpoolDir:          t.TempDir(),
+		SpoolMaxFiles:     10,
+	}, "node-a")
+	r.backoff = func(int) time.Duration { return 0 }
+	return r
+}
+
+func abnormalResult(item string) *common.Result {
+	return &common.Result{Item: item, Status: consts.StatusAbnormal, Level: consts.LevelCritical}
+}
+
+func TestNew_DisabledWithoutURL(t *testing.T) {
+	if r := New(Config{}, "node-a"); r != nil {
+		t.Fatalf("New with empty URL = %v, want nil", r)
+	}
+	// A nil reporter must be safe to use.
+	var r *Reporter
+	r.Report(abnormalResult("nvidia"))
+	r.Run(context.Background())
+}
+
+func TestReport_OnlyAbnormal(t *testing.T) {
+	r := newTestReporter(t, "http://127.0.0.1:0")
+	r.Report(&common.Result{Item: "cpu", Status: consts.StatusNormal})
+	r.Report(abnormalResult("nvidia"))
+	if got := len(r.queue); got != 1 {
+		t.Fatalf("queued %d events, want 1", got)
+	}
+}
+
+func TestReport_OnlyStatusChanges(t *testing.T) {
+	r := newTestReporter(t, "http://127.0.0.1:0")
+	withChecker := func(name, level string) *common.Result {
+		result := abnormalResult("nvidia")
+		result.Checkers = []*common.CheckerResult{{Name: name, Status: consts.StatusAbnormal, Level: level}}
+		return result
+	}
+	r.Report(withChecker("xid", consts.LevelCritical))
+	r.Report(withChecker("xid", consts.LevelCritical))
+	if got := len(r.queue); got != 1 {
+		t.Fatalf("queued %d events for an unchanged result, want 1", got)
+	}
+	r.Report(withChecker("ecc", consts.LevelCritical))
+	r.Report(withChecker("ecc", consts.LevelWarning))
+	if got := len(r.queue); got != 3 {
+		t.Fatalf("queued %d events after the checkers changed, want 3", got)
+	}
+	r.Report(&common.Result{Item: "nvidia", Status: consts.StatusNormal})
+	r.Report(&common.Result{Item: "nvidia", Status: consts.StatusNormal})
+	if got := len(r.queue); got != 4 {
+		t.Fatalf("queued %d events after the recovery, want 4", got)
+	}
+}
+
+func TestDeliver_PostsEvent(t *testing.T) {
+	var mu sync.Mutex
+	var got []Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("X-Sichek-Node") != "node-a" {
+			t.Errorf("X-Sichek-Node=%q", req.Header.Get("X-Sichek-Node"))
+		}
+		body, _ := io.ReadAll(req.Body)
+		var e Event
+		if err := json.Unmarshal(body, &e); err != nil {
+			t.Errorf("unmarshal: %v", err)
+		}
+		mu.Lock()
+		got = append(got, e)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	r := newTestReporter(t, srv.URL)
+	r.deliver(context.Background(), &Event{Type: EventTypeResult, Node: "node-a", Result: abnormalResult("nvidia")})
+
+	if len(got) != 1 || got[0].Type != EventTypeResult || got[0].Result == nil || got[0].Result.Item != "nvidia" {
+		t.Fatalf("unexpected events: %+v", got)
+	}
+}
+
+func TestDeliver_SpoolAndFlush(t *testing.T) {
+	var down atomic.Bool
+	var hits atomic.Int32
+	down.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		hits.Add(1)
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	r := newTestReporter(t, srv.URL)
+	ctx := context.Background()
+	r.deliver(ctx, &Event{Type: EventTypeResult, Node: "node-a", Result: abnormalResult("nvidia")})
+	r.deliver(ctx, &Event{Type: EventTypeHeartbeat, Node: "node-a"})
+	if n := hits.Load(); n != 4 {
+		t.Errorf("hits=%d, want 4 (2 events x RetryMax)", n)
+	}
+	if n := len(r.spooledFiles()); n != 1 {
+		t.Fatalf("spooled %d events, want 1 (heartbeats are not spooled)", n)
+	}
+
+	down.Store(false)
+	hits.Store(0)
+	r.deliver(ctx, &Event{Type: EventTypeHeartbeat, Node: "node-a"})
+	if n := hits.Load(); n != 2 {
+		t.Errorf("hits=%d, want 2 (heartbeat + spooled event)", n)
+	}
+	if n := len(r.spooledFiles()); n != 0 {
+		t.Errorf("spool not flushed, %d events left", n)
+	}
+}
+
+func TestDeliver_4xxNoRetry(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	r := newTestReporter(t, srv.URL)
+	if err := r.post(context.Background(), []byte("{}")); err == nil {
+		t.Fatal("post succeeded on 400")
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("hits=%d, want 1", n)
+	}
+}
+
+func TestSpool_MaxFiles(t *testing.T) {
+	r := newTestReporter(t, "http://127.0.0.1:0")
+	r.cfg.SpoolMaxFiles = 3
+	for i := 0; i < 5; i++ {
+		r.spool(&Event{Type: EventTypeResult, Node: "node-a", Result: abnormalResult("nvidia")})
+	}
+	if n := len(r.spooledFiles()); n != 3 {
+		t.Errorf("spooled %d events, want 3", n)
+	}
+}
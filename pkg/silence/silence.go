@@ -0,0 +1,274 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package silence persists the maintenance windows of known issues, e.g. a
+// GPU waiting for its RMA, so that their checkers stop failing the node for a
+// while without being disabled in the config.
+package silence
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/sirupsen/logrus"
+)
+
+// Silence mutes the abnormal results of a checker of a component until EndsAt.
+type Silence struct {
+	ID        string `json:"id"`
+	Component string `json:"component"`
+	// Checker matches the name or the error name of a checker, empty matches all the checkers of the component.
+	Checker  string    `json:"checker,omitempty"`
+	Reason   string    `json:"reason"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+// Active reports whether the silence applies at now.
+func (s *Silence) Active(now time.Time) bool {
+	return !now.Before(s.StartsAt) && now.Before(s.EndsAt)
+}
+
+// Matches reports whether the silence applies to the checker of component.
+func (s *Silence) Matches(component string, checker *common.CheckerResult) bool {
+	if s.Component != component {
+		return false
+	}
+	return s.Checker == "" || s.Checker == checker.Name || s.Checker == checker.ErrorName
+}
+
+type configFile struct {
+	Silence struct {
+		Path string `json:"path" yaml:"path"`
+	} `json:"silence" yaml:"silence"`
+}
+
+// LoadPath returns the silences file set in silence.path of the user config.
+// It defaults to DefaultSilencePath under the host root, so that sichek run in
+// a container with --host-root shares the silences of the daemon of the host.
+func LoadPath(cfgFile string) string {
+	var f configFile
+	if err := common.LoadUserConfig(cfgFile, &f); err == nil && f.Silence.Path != "" {
+		return f.Silence.Path
+	}
+	return hostfs.Path(consts.DefaultSilencePath)
+}
+
+// Store keeps the silences in a JSON file. The daemon reads it on every
+// result, so a silence added from the command line applies without a restart.
+type Store struct {
+	path string
+}
+
+func NewStore(path string) *Store {
+	if path == "" {
+		path = consts.DefaultSilencePath
+	}
+	return &Store{path: path}
+}
+
+func (s *Store) Path() string {
+	return s.path
+}
+
+// List returns all the silences of the store, including the expired ones
+// not cleaned up yet, ordered by end time.
+func (s *Store) List() ([]*Silence, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read silences %s: %w", s.path, err)
+	}
+	var silences []*Silence
+	if err := json.Unmarshal(data, &silences); err != nil {
+		return nil, fmt.Errorf("parse silences %s: %w", s.path, err)
+	}
+	sort.SliceStable(silences, func(i, j int) bool { return silences[i].EndsAt.Before(silences[j].EndsAt) })
+	return silences, nil
+}
+
+// Active returns the silences that apply at now.
+func (s *Store) Active(now time.Time) ([]*Silence, error) {
+	silences, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	active := silences[:0]
+	for _, silence := range silences {
+		if silence.Active(now) {
+			active = append(active, silence)
+		}
+	}
+	return active, nil
+}
+
+// Add stores a new silence and drops the expired ones.
+func (s *Store) Add(silence *Silence) (*Silence, error) {
+	if silence.Component == "" {
+		return nil, fmt.Errorf("a silence requires a component")
+	}
+	if !silence.EndsAt.After(silence.StartsAt) {
+		return nil, fmt.Errorf("a silence must end after it starts")
+	}
+	if silence.ID == "" {
+		id := make([]byte, 4)
+		if _, err := rand.Read(id); err != nil {
+			return nil, fmt.Errorf("generate silence id: %w", err)
+		}
+		silence.ID = hex.EncodeToString(id)
+	}
+	now := time.Now()
+	silences, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	kept := make([]*Silence, 0, len(silences)+1)
+	for _, existing := range silences {
+		if now.Before(existing.EndsAt) {
+			kept = append(kept, existing)
+		}
+	}
+	if err := s.write(append(kept, silence)); err != nil {
+		return nil, err
+	}
+	return silence, nil
+}
+
+// Remove expires the silence with the given id.
+func (s *Store) Remove(id string) error {
+	silences, err := s.List()
+	if err != nil {
+		return err
+	}
+	kept := make([]*Silence, 0, len(silences))
+	for _, silence := range silences {
+		if silence.ID != id {
+			kept = append(kept, silence)
+		}
+	}
+	if len(kept) == len(silences) {
+		return fmt.Errorf("silence %s not found", id)
+	}
+	return s.write(kept)
+}
+
+// write replaces the file atomically so that the daemon never reads a partial file.
+func (s *Store) write(silences []*Silence) error {
+	data, err := json.MarshalIndent(silences, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("create silences dir: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("write silences %s: %w", tmp, err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Apply silences the abnormal checkers of result matched by an active silence
+// of the store. A store that cannot be read silences nothing.
+func (s *Store) Apply(result *common.Result) *common.Result {
+	silences, err := s.Active(time.Now())
+	if err != nil {
+		logrus.WithField("silence", "apply").Warnf("failed to load silences: %v", err)
+		return result
+	}
+	return Apply(result, silences)
+}
+
+// Apply returns a copy of result where the abnormal checkers matched by one
+// of silences are marked silenced, with the status, level and lifecycle
+// action of the result recomputed from the remaining abnormal checkers. The
+// result is returned as is when no silence matches.
+func Apply(result *common.Result, silences []*Silence) *common.Result {
+	if result == nil || len(silences) == 0 {
+		return result
+	}
+	var silenced *common.Result
+	for i, checker := range result.Checkers {
+		if checker == nil || checker.Status != consts.StatusAbnormal {
+			continue
+		}
+		for _, silence := range silences {
+			if !silence.Matches(result.Item, checker) {
+				continue
+			}
+			if silenced == nil {
+				silenced = copyResult(result)
+			}
+			silenced.Checkers[i].Status = consts.StatusSilenced
+			silenced.Checkers[i].SilencedBy = silence.ID
+			break
+		}
+	}
+	if silenced == nil {
+		return result
+	}
+	status := consts.StatusNormal
+	level := consts.LevelInfo
+	for _, checker := range silenced.Checkers {
+		if checker == nil || checker.Status != consts.StatusAbnormal {
+			continue
+		}
+		status = consts.StatusAbnormal
+		if consts.LevelPriority[level] < consts.LevelPriority[checker.Level] {
+			level = checker.Level
+		}
+	}
+	silenced.Status = status
+	silenced.Level = level
+	silenced.Action = common.AggregateLifecycle(silenced)
+	return silenced
+}
+
+// IsSilenced reports whether result only passes because of silences.
+func IsSilenced(result *common.Result) bool {
+	if result == nil || result.Status == consts.StatusAbnormal {
+		return false
+	}
+	for _, checker := range result.Checkers {
+		if checker != nil && checker.Status == consts.StatusSilenced {
+			return true
+		}
+	}
+	return false
+}
+
+func copyResult(result *common.Result) *common.Result {
+	copied := *result
+	copied.Checkers = make([]*common.CheckerResult, len(result.Checkers))
+	for i, checker := range result.Checkers {
+		if checker == nil {
+			continue
+		}
+		checkerCopy := *checker
+		copied.Checkers[i] = &checkerCopy
+	}
+	return &copied
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package silence
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/scitix/sichek/pkg/hostfs/hostfstest"
+)
+
+func TestStore_AddListRemove(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "silences.json"))
+	if silences, err := store.List(); err != nil || len(silences) != 0 {
+		t.Fatalf("List on a missing file = %v, %v, want no silences", silences, err)
+	}
+	now := time.Now()
+	expired, err := store.Add(&Silence{Component: consts.ComponentNameNvidia, Reason: "done", StartsAt: now.Add(-2 * time.Hour), EndsAt: now.Add(-time.Hour)})
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	added, err := store.Add(&Silence{Component: consts.ComponentNameNvidia, Checker: "xid-79", Reason: "RMA pending", StartsAt: now, EndsAt: now.Add(2 * time.Hour)})
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	silences, err := store.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	// The expired silence is dropped when the next one is added.
+	if len(silences) != 1 || silences[0].ID != added.ID || silences[0].ID == expired.ID {
+		t.Fatalf("List = %+v, want only %s", silences, added.ID)
+	}
+	if err := store.Remove(added.ID); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if err := store.Remove(added.ID); err == nil {
+		t.Errorf("expected an error removing a missing silence")
+	}
+	if _, err := store.Add(&Silence{Component: consts.ComponentNameNvidia, StartsAt: now, EndsAt: now}); err == nil {
+		t.Errorf("expected an error adding an empty silence")
+	}
+}
+
+func TestApply(t *testing.T) {
+	now := time.Now()
+	result := &common.Result{
+		Item:   consts.ComponentNameNvidia,
+		Status: consts.StatusAbnormal,
+		Level:  consts.LevelCritical,
+		Checkers: []*common.CheckerResult{
+			{Name: "xid-79", ErrorName: "xid79-GPUFallenOffBus", Status: consts.StatusAbnormal, Level: consts.LevelCritical, Action: common.LifecycleActionDrainReboot},
+			{Name: "nvlink", Status: consts.StatusAbnormal, Level: consts.LevelWarning, Action: common.LifecycleActionResetGPU},
+			{Name: "pcie", Status: consts.StatusNormal, Level: consts.LevelCritical},
+		},
+	}
+	result.Action = common.AggregateLifecycle(result)
+	xid := &Silence{ID: "a", Component: consts.ComponentNameNvidia, Checker: "xid79-GPUFallenOffBus", StartsAt: now, EndsAt: now.Add(time.Hour)}
+	nvlink := &Silence{ID: "b", Component: consts.ComponentNameNvidia, Checker: "nvlink", StartsAt: now, EndsAt: now.Add(time.Hour)}
+	other := &Silence{ID: "c", Component: consts.ComponentNameInfiniband, StartsAt: now, EndsAt: now.Add(time.Hour)}
+
+	if got := Apply(result, []*Silence{other}); got != result {
+		t.Errorf("expected the result returned as is when no silence matches")
+	}
+
+	got := Apply(result, []*Silence{xid})
+	if got.Status != consts.StatusAbnormal || got.Level != consts.LevelWarning {
+		t.Errorf("with xid silenced: status=%s level=%s, want abnormal warning", got.Status, got.Level)
+	}
+	if got.Action == nil || got.Action.Action != common.LifecycleActionResetGPU {
+		t.Errorf("with xid silenced: action=%+v, want reset-gpu of the nvlink checker", got.Action)
+	}
+	if got.Checkers[0].Status != consts.StatusSilenced || got.Checkers[0].SilencedBy != "a" {
+		t.Errorf("xid checker = %+v, want silenced by a", got.Checkers[0])
+	}
+	if result.Checkers[0].Status != consts.StatusAbnormal {
+		t.Errorf("Apply modified the original result")
+	}
+	if IsSilenced(got) {
+		t.Errorf("IsSilenced = true while the nvlink checker still fails")
+	}
+
+	got = Apply(result, []*Silence{xid, nvlink})
+	if got.Status != consts.StatusNormal || got.Level != consts.LevelInfo || !IsSilenced(got) {
+		t.Errorf("with all failures silenced: status=%s level=%s silenced=%t", got.Status, got.Level, IsSilenced(got))
+	}
+	if got.Action != nil {
+		t.Errorf("with all failures silenced: action=%+v, want none", got.Action)
+	}
+	if IsSilenced(result) {
+		t.Errorf("IsSilenced = true for a result without silences")
+	}
+}
+
+func TestLoadPath(t *testing.T) {
+	hostfstest.Build(t, "")
+	cfgFile := filepath.Join(t.TempDir(), "user_config.yaml")
+	if err := os.WriteFile(cfgFile, []byte("silence:\n  path: /data/silences.json\n"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if got := LoadPath(cfgFile); got != "/data/silences.json" {
+		t.Errorf("LoadPath = %s, want the path of the user config", got)
+	}
+	if err := os.WriteFile(cfgFile, []byte("history:\n  enable: true\n"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if got, want := LoadPath(cfgFile), hostfs.Path(consts.DefaultSilencePath); got != want {
+		t.Errorf("LoadPath = %s, want %s under the host root", got, want)
+	}
+}
@@ -0,0 +1,520 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package specsign verifies the specs downloaded from SICHEK_SPEC_URL
+// against the public keys of the cluster before they are applied, so that a
+// compromised spec endpoint cannot silence the health checks.
+//
+// A spec is signed either by a detached ed25519 signature published next to
+// it as <spec>.sig, or by an entry in the checksum manifest of its directory,
+// manifest.json, itself signed as manifest.json.sig. The manifest signs the
+// spec bundle of a cluster, i.e. all its spec and user config files, at once.
+// The signatures are base64 encoded.
+package specsign
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scitix/sichek/consts"
+	"github.com/scitix/sichek/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// SignatureSuffix is appended to the URL of a spec to get its detached signature.
+	SignatureSuffix = ".sig"
+	// ManifestName is the checksum manifest of the spec bundle of a directory.
+	ManifestName = "manifest.json"
+	// PublicKeyName is the file of the public keys under the config directory.
+	PublicKeyName = "spec_signing.pub"
+	// AppliedName records under the config directory the creation time of the
+	// newest manifest applied, older manifests are refused as rollbacks.
+	AppliedName = "spec_manifest.applied"
+
+	// EnvVerify sets the verification mode, ModeOff, ModeWarn or ModeEnforce.
+	EnvVerify = "SICHEK_SPEC_VERIFY"
+	// EnvPublicKeys lists the public keys, PEM files or base64 keys joined by ','.
+	EnvPublicKeys = "SICHEK_SPEC_PUBLIC_KEYS"
+	// EnvCluster overrides the cluster of the node the manifests are checked
+	// against, derived from the node name by default.
+	EnvCluster = "SICHEK_CLUSTER"
+)
+
+// The verification modes. ModeEnforce refuses the unsigned and tampered specs,
+// it is the default once a public key is configured.
+const (
+	ModeOff     = "off"
+	ModeWarn    = "warn"
+	ModeEnforce = "enforce"
+)
+
+// manifestTTL bounds how long a verified manifest is reused, the components
+// download their specs from the same directory one after the other.
+const manifestTTL = time.Minute
+
+var (
+	ErrUnsigned = errors.New("spec is not signed")
+	ErrTampered = errors.New("spec does not match its signature")
+	// ErrWrongCluster refuses a manifest signed for another cluster.
+	ErrWrongCluster = errors.New("spec manifest is for another cluster")
+	// ErrRollback refuses a manifest older than the one already applied.
+	ErrRollback = errors.New("spec manifest is older than the applied one")
+)
+
+// Manifest is the checksum manifest of a spec bundle.
+type Manifest struct {
+	// Cluster is checked against the cluster of the node, a manifest without
+	// cluster is valid on all the clusters.
+	Cluster string    `json:"cluster,omitempty"`
+	Created time.Time `json:"created"`
+	// Files maps the file names to their sha256:<hex> digest.
+	Files map[string]string `json:"files"`
+}
+
+// NewManifest returns the manifest of files, keyed by file name.
+func NewManifest(cluster string, files map[string][]byte) *Manifest {
+	m := &Manifest{Cluster: cluster, Created: time.Now().UTC(), Files: make(map[string]string, len(files))}
+	for name, data := range files {
+		m.Files[name] = Digest(data)
+	}
+	return m
+}
+
+// Marshal returns the indented JSON of the manifest, the bytes to sign.
+func (m *Manifest) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Digest returns the sha256:<hex> digest of data.
+func Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Sign returns the base64 encoded signature of data, as published in a .sig file.
+func Sign(key ed25519.PrivateKey, data []byte) []byte {
+	return []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(key, data)) + "\n")
+}
+
+// VerifySignature checks the base64 encoded sig of data against keys.
+func VerifySignature(keys []ed25519.PublicKey, data, sig []byte) error {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	for _, key := range keys {
+		if ed25519.Verify(key, data, raw) {
+			return nil
+		}
+	}
+	return ErrTampered
+}
+
+// GenerateKey returns a new key pair as PEM, the public key in PKIX and the
+// private key in PKCS #8, as `openssl genpkey -algorithm ed25519` writes them.
+func GenerateKey() (pubPEM, privPEM []byte, err error) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, nil, err
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), nil
+}
+
+// ParsePublicKeys parses the PEM public keys of data, or a base64 raw key.
+func ParsePublicKeys(data []byte) ([]ed25519.PublicKey, error) {
+	var keys []ed25519.PublicKey
+	rest := data
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key: %w", err)
+		}
+		key, ok := parsed.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("public key is a %T, expected ed25519", parsed)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) > 0 {
+		return keys, nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public key is neither PEM nor a base64 ed25519 key")
+	}
+	return []ed25519.PublicKey{raw}, nil
+}
+
+// ParsePrivateKey parses a PEM PKCS #8 ed25519 private key.
+func ParsePrivateKey(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("private key is not PEM")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is a %T, expected ed25519", parsed)
+	}
+	return key, nil
+}
+
+type cachedManifest struct {
+	manifest *Manifest
+	readAt   time.Time
+}
+
+// Verifier verifies the downloaded specs in its mode.
+type Verifier struct {
+	mode   string
+	keys   []ed25519.PublicKey
+	client *http.Client
+	// cluster is the cluster of the node, empty skips the cluster check.
+	cluster string
+	// appliedPath persists applied across restarts, empty keeps it in memory.
+	appliedPath string
+
+	mu        sync.Mutex
+	manifests map[string]cachedManifest
+	// applied is the creation time of the newest manifest applied.
+	applied time.Time
+}
+
+// NewVerifier creates a Verifier, mode defaults to ModeEnforce with keys and
+// to ModeOff without.
+func NewVerifier(mode string, keys []ed25519.PublicKey) (*Verifier, error) {
+	switch mode {
+	case "":
+		mode = ModeOff
+		if len(keys) > 0 {
+			mode = ModeEnforce
+		}
+	case ModeOff, ModeWarn, ModeEnforce:
+	default:
+		return nil, fmt.Errorf("invalid %s %q, expected %s, %s or %s", EnvVerify, mode, ModeOff, ModeWarn, ModeEnforce)
+	}
+	if mode != ModeOff && len(keys) == 0 {
+		return nil, fmt.Errorf("%s=%s without a public key, set %s or install %s", EnvVerify, mode, EnvPublicKeys, PublicKeyPath())
+	}
+	return &Verifier{
+		mode:      mode,
+		keys:      keys,
+		client:    &http.Client{Timeout: 5 * time.Second},
+		manifests: make(map[string]cachedManifest),
+	}, nil
+}
+
+// PublicKeyPath returns the path of the public keys file of the node.
+func PublicKeyPath() string {
+	return filepath.Join(configDir(), PublicKeyName)
+}
+
+// configDir returns the config directory of the node.
+func configDir() string {
+	if p := os.Getenv("SICHEK_CONFIG_DIR"); p != "" {
+		return p
+	}
+	return consts.DefaultProductionCfgPath
+}
+
+// SetCluster sets the cluster the manifests are checked against.
+func (v *Verifier) SetCluster(cluster string) {
+	v.cluster = cluster
+}
+
+// SetAppliedPath persists the creation time of the applied manifests in path
+// and loads the one recorded by a previous run.
+func (v *Verifier) SetAppliedPath(path string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.appliedPath = path
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	applied, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(string(data)))
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", path, err)
+	}
+	v.applied = applied
+	return nil
+}
+
+// markApplied records created as applied when it is newer than the applied one.
+func (v *Verifier) markApplied(created time.Time) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if !created.After(v.applied) {
+		return
+	}
+	v.applied = created
+	if v.appliedPath == "" {
+		return
+	}
+	if err := os.WriteFile(v.appliedPath, []byte(created.UTC().Format(time.RFC3339Nano)+"\n"), 0644); err != nil {
+		logrus.WithField("component", "specsign").Warnf("failed to record the applied manifest in %s: %v", v.appliedPath, err)
+	}
+}
+
+// LoadVerifier creates the Verifier of the node from EnvVerify, EnvPublicKeys
+// and the PublicKeyPath file. The keys are never read from the downloaded
+// user config, which is itself verified with them. The manifests are checked
+// against the cluster of EnvCluster or of the node name.
+func LoadVerifier() (*Verifier, error) {
+	var keys []ed25519.PublicKey
+	for _, item := range strings.Split(os.Getenv(EnvPublicKeys), ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		data := []byte(item)
+		if file, err := os.ReadFile(item); err == nil {
+			data = file
+		}
+		parsed, err := ParsePublicKeys(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", EnvPublicKeys, err)
+		}
+		keys = append(keys, parsed...)
+	}
+	if len(keys) == 0 {
+		data, err := os.ReadFile(PublicKeyPath())
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err == nil {
+			if keys, err = ParsePublicKeys(data); err != nil {
+				return nil, fmt.Errorf("%s: %w", PublicKeyPath(), err)
+			}
+		}
+	}
+	v, err := NewVerifier(strings.ToLower(strings.TrimSpace(os.Getenv(EnvVerify))), keys)
+	if err != nil {
+		return nil, err
+	}
+	cluster := os.Getenv(EnvCluster)
+	if cluster == "" {
+		cluster = utils.ExtractClusterName()
+	}
+	v.SetCluster(cluster)
+	if err := v.SetAppliedPath(filepath.Join(configDir(), AppliedName)); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+var (
+	defaultVerifier     *Verifier
+	defaultVerifierErr  error
+	defaultVerifierOnce sync.Once
+)
+
+// Default returns the Verifier of the node, loaded once. The error tells why
+// the verification is misconfigured.
+func Default() (*Verifier, error) {
+	defaultVerifierOnce.Do(func() {
+		defaultVerifier, defaultVerifierErr = LoadVerifier()
+		if defaultVerifierErr != nil {
+			defaultVerifierErr = fmt.Errorf("spec signature verification misconfigured: %w", defaultVerifierErr)
+		}
+	})
+	return defaultVerifier, defaultVerifierErr
+}
+
+// Verify checks data downloaded from fileURL with the Default Verifier. A
+// misconfigured verification refuses all the remote specs rather than
+// silently skipping verification.
+func Verify(fileURL string, data []byte) error {
+	v, err := Default()
+	if err != nil {
+		return fmt.Errorf("refusing spec %s: %w", fileURL, err)
+	}
+	return v.Verify(fileURL, data)
+}
+
+// Mode returns the verification mode.
+func (v *Verifier) Mode() string {
+	return v.mode
+}
+
+// Verify checks data downloaded from fileURL against its detached signature
+// or the manifest of its directory. In ModeWarn a failure is only logged.
+func (v *Verifier) Verify(fileURL string, data []byte) error {
+	if v.mode == ModeOff {
+		return nil
+	}
+	err := v.verify(fileURL, data)
+	if err == nil {
+		logrus.WithField("component", "specsign").Debugf("verified the signature of %s", fileURL)
+		return nil
+	}
+	if v.mode == ModeWarn {
+		logrus.WithField("component", "specsign").Warnf("applying %s despite its signature: %v", fileURL, err)
+		return nil
+	}
+	return fmt.Errorf("refusing spec %s: %w", fileURL, err)
+}
+
+func (v *Verifier) verify(fileURL string, data []byte) error {
+	if len(v.keys) == 0 {
+		return fmt.Errorf("no public key to verify with")
+	}
+	sig, found, err := v.fetch(fileURL + SignatureSuffix)
+	if err != nil {
+		return err
+	}
+	if found {
+		return VerifySignature(v.keys, data, sig)
+	}
+	u, err := url.Parse(fileURL)
+	if err != nil {
+		return err
+	}
+	name := path.Base(u.Path)
+	u.Path = path.Dir(u.Path)
+	u.RawQuery = ""
+	manifest, err := v.manifest(strings.TrimRight(u.String(), "/"))
+	if err != nil {
+		return err
+	}
+	if manifest == nil {
+		return fmt.Errorf("%w: no %s nor %s", ErrUnsigned, name+SignatureSuffix, ManifestName)
+	}
+	if err := v.checkManifest(manifest); err != nil {
+		return err
+	}
+	want, ok := manifest.Files[name]
+	if !ok {
+		return fmt.Errorf("%w: %s is not listed in %s", ErrUnsigned, name, ManifestName)
+	}
+	if got := Digest(data); got != want {
+		return fmt.Errorf("%w: %s is %s, %s lists %s", ErrTampered, name, got, ManifestName, want)
+	}
+	v.markApplied(manifest.Created)
+	return nil
+}
+
+// checkManifest refuses a manifest of another cluster or older than the
+// applied one, a validly signed manifest could otherwise be replayed.
+func (v *Verifier) checkManifest(manifest *Manifest) error {
+	if manifest.Cluster != "" && v.cluster != "" && manifest.Cluster != v.cluster {
+		return fmt.Errorf("%w: %s is signed for %s, the node is in %s", ErrWrongCluster, ManifestName, manifest.Cluster, v.cluster)
+	}
+	v.mu.Lock()
+	applied := v.applied
+	v.mu.Unlock()
+	if manifest.Created.Before(applied) {
+		return fmt.Errorf("%w: %s was created at %s, the applied one at %s", ErrRollback, ManifestName,
+			manifest.Created.Format(time.RFC3339), applied.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// manifest returns the verified manifest of dirURL, nil when there is none.
+func (v *Verifier) manifest(dirURL string) (*Manifest, error) {
+	v.mu.Lock()
+	cached, ok := v.manifests[dirURL]
+	v.mu.Unlock()
+	if ok && time.Since(cached.readAt) < manifestTTL {
+		return cached.manifest, nil
+	}
+
+	manifestURL := dirURL + "/" + ManifestName
+	data, found, err := v.fetch(manifestURL)
+	if err != nil || !found {
+		return nil, err
+	}
+	sig, found, err := v.fetch(manifestURL + SignatureSuffix)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %s has no %s", ErrUnsigned, ManifestName, ManifestName+SignatureSuffix)
+	}
+	if err := VerifySignature(v.keys, data, sig); err != nil {
+		return nil, fmt.Errorf("%s: %w", manifestURL, err)
+	}
+	manifest := &Manifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", manifestURL, err)
+	}
+	v.mu.Lock()
+	v.manifests[dirURL] = cachedManifest{manifest: manifest, readAt: time.Now()}
+	v.mu.Unlock()
+	return manifest, nil
+}
+
+// fetch returns the content at fileURL, found is false on a 404.
+func (v *Verifier) fetch(fileURL string) (data []byte, found bool, err error) {
+	resp, err := v.client.Get(fileURL)
+	if err != nil {
+		return nil, false, fmt.Errorf("GET %s: %w", fileURL, err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusForbidden:
+		// object stores answer 403 for the missing objects of a private bucket
+		return nil, false, nil
+	default:
+		return nil, false, fmt.Errorf("GET %s: status %d", fileURL, resp.StatusCode)
+	}
+	data, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, fmt.Errorf("read body from %s: %w", fileURL, err)
+	}
+	return data, true, nil
+}
@@ -0,0 +1,247 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package specsign
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newSpecServer serves files, a missing file answers 404.
+func newSpecServer(t *testing.T, files map[string][]byte) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	pubPEM, privPEM, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	pubs, err := ParsePublicKeys(pubPEM)
+	if err != nil || len(pubs) != 1 {
+		t.Fatalf("ParsePublicKeys: %v %v", pubs, err)
+	}
+	priv, err := ParsePrivateKey(privPEM)
+	if err != nil {
+		t.Fatalf("ParsePrivateKey: %v", err)
+	}
+	return pubs[0], priv
+}
+
+func TestVerifyDetachedSignature(t *testing.T) {
+	pub, priv := newTestKey(t)
+	spec := []byte("nvidia:\n  gpu_nums: 8\n")
+	srv := newSpecServer(t, map[string][]byte{
+		"/default_spec.yaml":     spec,
+		"/default_spec.yaml.sig": Sign(priv, spec),
+		"/unsigned_spec.yaml":    spec,
+	})
+	v, err := NewVerifier("", []ed25519.PublicKey{pub})
+	if err != nil || v.Mode() != ModeEnforce {
+		t.Fatalf("expected enforce mode with a key, got %v %v", v, err)
+	}
+
+	if err := v.Verify(srv.URL+"/default_spec.yaml", spec); err != nil {
+		t.Errorf("signed spec refused: %v", err)
+	}
+	if err := v.Verify(srv.URL+"/default_spec.yaml", []byte("nvidia: {}\n")); !errors.Is(err, ErrTampered) {
+		t.Errorf("expected tampered spec to be refused, got %v", err)
+	}
+	if err := v.Verify(srv.URL+"/unsigned_spec.yaml", spec); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("expected unsigned spec to be refused, got %v", err)
+	}
+
+	other, _ := newTestKey(t)
+	v, _ = NewVerifier(ModeEnforce, []ed25519.PublicKey{other})
+	if err := v.Verify(srv.URL+"/default_spec.yaml", spec); !errors.Is(err, ErrTampered) {
+		t.Errorf("expected spec signed by another key to be refused, got %v", err)
+	}
+}
+
+func TestVerifyManifest(t *testing.T) {
+	pub, priv := newTestKey(t)
+	spec := []byte("hca: {}\n")
+	cfg := []byte("nvidia:\n  query_interval: 30s\n")
+	manifest, err := NewManifest("cluster-a", map[string][]byte{"default_spec.yaml": spec, "default_user_config.yaml": cfg}).Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	files := map[string][]byte{
+		"/cluster-a/manifest.json":     manifest,
+		"/cluster-a/manifest.json.sig": Sign(priv, manifest),
+		"/cluster-b/manifest.json":     manifest,
+		"/cluster-b/manifest.json.sig": Sign(priv, []byte("{}")),
+		"/cluster-c/manifest.json":     manifest,
+	}
+	srv := newSpecServer(t, files)
+	v, _ := NewVerifier(ModeEnforce, []ed25519.PublicKey{pub})
+
+	if err := v.Verify(srv.URL+"/cluster-a/default_spec.yaml", spec); err != nil {
+		t.Errorf("spec listed in the manifest refused: %v", err)
+	}
+	if err := v.Verify(srv.URL+"/cluster-a/default_user_config.yaml?v=2", cfg); err != nil {
+		t.Errorf("user config listed in the manifest refused: %v", err)
+	}
+	if err := v.Verify(srv.URL+"/cluster-a/default_spec.yaml", cfg); !errors.Is(err, ErrTampered) {
+		t.Errorf("expected checksum mismatch to be refused, got %v", err)
+	}
+	if err := v.Verify(srv.URL+"/cluster-a/other_spec.yaml", spec); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("expected spec missing from the manifest to be refused, got %v", err)
+	}
+	if err := v.Verify(srv.URL+"/cluster-b/default_spec.yaml", spec); !errors.Is(err, ErrTampered) {
+		t.Errorf("expected tampered manifest to be refused, got %v", err)
+	}
+	if err := v.Verify(srv.URL+"/cluster-c/default_spec.yaml", spec); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("expected unsigned manifest to be refused, got %v", err)
+	}
+
+	// the verified manifest is reused for the next downloads of the directory
+	delete(files, "/cluster-a/manifest.json")
+	if err := v.Verify(srv.URL+"/cluster-a/default_spec.yaml", spec); err != nil {
+		t.Errorf("cached manifest not reused: %v", err)
+	}
+}
+
+func TestVerifyModes(t *testing.T) {
+	pub, _ := newTestKey(t)
+	srv := newSpecServer(t, map[string][]byte{})
+	url := srv.URL + "/default_spec.yaml"
+
+	warn, err := NewVerifier(ModeWarn, []ed25519.PublicKey{pub})
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	if err := warn.Verify(url, []byte("x")); err != nil {
+		t.Errorf("warn mode should accept the unsigned spec, got %v", err)
+	}
+	off, err := NewVerifier("", nil)
+	if err != nil || off.Mode() != ModeOff {
+		t.Fatalf("expected off mode without a key, got %v %v", off, err)
+	}
+	if err := off.Verify(url, []byte("x")); err != nil {
+		t.Errorf("off mode should accept the unsigned spec, got %v", err)
+	}
+	if _, err := NewVerifier(ModeEnforce, nil); err == nil {
+		t.Errorf("expected enforce mode without a key to be rejected")
+	}
+	if _, err := NewVerifier("strict", []ed25519.PublicKey{pub}); err == nil {
+		t.Errorf("expected unknown mode to be rejected")
+	}
+}
+
+func TestLoadVerifier(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("SICHEK_CONFIG_DIR", dir)
+	t.Setenv(EnvPublicKeys, "")
+	t.Setenv(EnvVerify, "")
+
+	v, err := LoadVerifier()
+	if err != nil || v.Mode() != ModeOff {
+		t.Fatalf("expected off mode without a key file, got %v %v", v, err)
+	}
+
+	pubPEM, _, _ := GenerateKey()
+	if err := os.WriteFile(filepath.Join(dir, PublicKeyName), pubPEM, 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	v, err = LoadVerifier()
+	if err != nil || v.Mode() != ModeEnforce || len(v.keys) != 1 {
+		t.Fatalf("expected enforce mode with the key file, got %v %v", v, err)
+	}
+
+	t.Setenv(EnvVerify, "warn")
+	t.Setenv(EnvPublicKeys, filepath.Join(dir, PublicKeyName)+",not-a-key")
+	if _, err := LoadVerifier(); err == nil {
+		t.Errorf("expected invalid public key to be rejected")
+	}
+}
+
+func TestVerifyManifestClusterAndRollback(t *testing.T) {
+	pub, priv := newTestKey(t)
+	spec := []byte("hca: {}\n")
+	files := map[string][]byte{}
+	publish := func(dir, cluster string, created time.Time) {
+		m := NewManifest(cluster, map[string][]byte{"default_spec.yaml": spec})
+		m.Created = created
+		data, err := m.Marshal()
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		files["/"+dir+"/manifest.json"] = data
+		files["/"+dir+"/manifest.json.sig"] = Sign(priv, data)
+	}
+	now := time.Now().UTC()
+	publish("new", "prod-a", now)
+	publish("old", "prod-a", now.Add(-time.Hour))
+	publish("other", "prod-b", now)
+	publish("any", "", now)
+	srv := newSpecServer(t, files)
+
+	appliedPath := filepath.Join(t.TempDir(), AppliedName)
+	v, _ := NewVerifier(ModeEnforce, []ed25519.PublicKey{pub})
+	v.SetCluster("prod-a")
+	if err := v.SetAppliedPath(appliedPath); err != nil {
+		t.Fatalf("SetAppliedPath: %v", err)
+	}
+
+	if err := v.Verify(srv.URL+"/other/default_spec.yaml", spec); !errors.Is(err, ErrWrongCluster) {
+		t.Errorf("expected manifest of another cluster to be refused, got %v", err)
+	}
+	if err := v.Verify(srv.URL+"/any/default_spec.yaml", spec); err != nil {
+		t.Errorf("manifest without cluster refused: %v", err)
+	}
+	if err := v.Verify(srv.URL+"/new/default_spec.yaml", spec); err != nil {
+		t.Errorf("manifest of the cluster refused: %v", err)
+	}
+	if err := v.Verify(srv.URL+"/old/default_spec.yaml", spec); !errors.Is(err, ErrRollback) {
+		t.Errorf("expected older manifest to be refused, got %v", err)
+	}
+
+	// the applied manifest survives a restart
+	restarted, _ := NewVerifier(ModeEnforce, []ed25519.PublicKey{pub})
+	if err := restarted.SetAppliedPath(appliedPath); err != nil {
+		t.Fatalf("SetAppliedPath: %v", err)
+	}
+	if err := restarted.Verify(srv.URL+"/old/default_spec.yaml", spec); !errors.Is(err, ErrRollback) {
+		t.Errorf("expected older manifest to be refused after a restart, got %v", err)
+	}
+}
+
+func TestLoadVerifierMisconfigured(t *testing.T) {
+	t.Setenv("SICHEK_CONFIG_DIR", t.TempDir())
+	t.Setenv(EnvPublicKeys, "")
+	t.Setenv(EnvVerify, ModeEnforce)
+	if _, err := LoadVerifier(); err == nil {
+		t.Errorf("expected enforce mode without a key to be an error")
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package specvalidate checks sichek spec files before they are uploaded to
+// SICHEK_SPEC_URL. The structure of each section is checked against a JSON
+// schema generated from the Go types the components load it into, and the
+// values against the cross-field rules the types can not express, e.g. the
+// NVLinks of the GPU model. Every issue carries the line and column it was
+// found at.
+package specvalidate
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Schema is the subset of JSON schema the spec types map to.
+type Schema struct {
+	Ref        string             `json:"$ref,omitempty"`
+	Type       string             `json:"type,omitempty"`
+	Minimum    *float64           `json:"minimum,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	// AdditionalProperties is false for structs and the schema of the values
+	// for maps
+	AdditionalProperties interface{} `json:"additionalProperties,omitempty"`
+	// PropertyNames constrains the keys of the maps with integer keys
+	PropertyNames *Schema `json:"propertyNames,omitempty"`
+	Pattern       string  `json:"pattern,omitempty"`
+	Items         *Schema `json:"items,omitempty"`
+	// Defs holds the schemas of the recursive types, referenced through $ref
+	Defs map[string]*Schema `json:"$defs,omitempty"`
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// schemaGenerator generates the schemas of Go types following the
+// encoding/json rules the spec loader uses: fields are named by their json
+// tag, "-" and unexported fields are skipped and untagged embedded structs
+// are flattened.
+type schemaGenerator struct {
+	building  map[reflect.Type]*Schema
+	recursive map[reflect.Type]bool
+	defs      map[string]*Schema
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{
+		building:  make(map[reflect.Type]*Schema),
+		recursive: make(map[reflect.Type]bool),
+		defs:      make(map[string]*Schema),
+	}
+}
+
+func (g *schemaGenerator) schemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	// the types unmarshaling themselves, e.g. common.Duration, accept any value
+	if t.Implements(jsonUnmarshalerType) || reflect.PointerTo(t).Implements(jsonUnmarshalerType) ||
+		t.Implements(textUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return &Schema{}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := 0.0
+		return &Schema{Type: "integer", Minimum: &zero}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string"}
+		}
+		return &Schema{Type: "array", Items: g.schemaFor(t.Elem())}
+	case reflect.Map:
+		s := &Schema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem())}
+		switch t.Key().Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			s.PropertyNames = &Schema{Pattern: `^-?[0-9]+$`}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			s.PropertyNames = &Schema{Pattern: `^[0-9]+$`}
+		}
+		return s
+	case reflect.Struct:
+		return g.structSchema(t)
+	default:
+		return &Schema{}
+	}
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) *Schema {
+	if _, ok := g.building[t]; ok {
+		g.recursive[t] = true
+		return &Schema{Ref: "#/$defs/" + t.Name()}
+	}
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema), AdditionalProperties: false}
+	g.building[t] = s
+	g.addFields(s, t)
+	delete(g.building, t)
+	if g.recursive[t] {
+		g.defs[t.Name()] = s
+	}
+	return s
+}
+
+func (g *schemaGenerator) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.addFields(s, ft)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		s.Properties[name] = g.schemaFor(field.Type)
+	}
+}
+
+// ObjectSchema returns the schema of an object whose properties hold the
+// values of the given Go types, e.g. a spec file with one property per
+// section. The schemas of the recursive types are collected in $defs.
+func ObjectSchema(properties map[string]reflect.Type) *Schema {
+	g := newSchemaGenerator()
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema), AdditionalProperties: false}
+	for name, t := range properties {
+		if t == nil {
+			s.Properties[name] = &Schema{}
+			continue
+		}
+		s.Properties[name] = g.schemaFor(t)
+	}
+	if len(g.defs) > 0 {
+		s.Defs = g.defs
+	}
+	return s
+}
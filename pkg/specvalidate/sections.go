@@ -0,0 +1,269 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package specvalidate
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	amdConfig "github.com/scitix/sichek/components/amd/config"
+	bmcConfig "github.com/scitix/sichek/components/bmc/config"
+	containerRuntimeConfig "github.com/scitix/sichek/components/containerruntime/config"
+	ethernetConfig "github.com/scitix/sichek/components/ethernet/config"
+	hcaConfig "github.com/scitix/sichek/components/hca/config"
+	ibConfig "github.com/scitix/sichek/components/infiniband/config"
+	memoryConfig "github.com/scitix/sichek/components/memory/config"
+	nvidiaConfig "github.com/scitix/sichek/components/nvidia/config"
+	pcieConfig "github.com/scitix/sichek/components/pcie/config"
+	storageConfig "github.com/scitix/sichek/components/storage/config"
+	transceiverConfig "github.com/scitix/sichek/components/transceiver/config"
+	"github.com/scitix/sichek/pkg/httpclient"
+	"gopkg.in/yaml.v3"
+)
+
+// sections maps the top level keys of a spec file to the types the
+// components load them into. A nil type accepts any value.
+var sections = map[string]reflect.Type{
+	"nvidia":            reflect.TypeOf(map[string]*nvidiaConfig.NvidiaSpec{}),
+	"amd":               reflect.TypeOf(map[string]*amdConfig.AmdSpec{}),
+	"infiniband":        reflect.TypeOf(map[string]*ibConfig.InfinibandSpec{}),
+	"hca":               reflect.TypeOf(map[string]*hcaConfig.HCASpec{}),
+	"hca_specs":         reflect.TypeOf(map[string]*hcaConfig.HCASpec{}),
+	"pcie_topo":         reflect.TypeOf(map[string]*pcieConfig.PcieTopoSpec{}),
+	"pcie":              reflect.TypeOf(map[string]*pcieConfig.PcieSpec{}),
+	"ethernet":          reflect.TypeOf(map[string]*ethernetConfig.EthernetSpecConfig{}),
+	"bmc":               reflect.TypeOf(map[string]*bmcConfig.BmcSpec{}),
+	"storage":           reflect.TypeOf(map[string]*storageConfig.StorageSpec{}),
+	"container_runtime": reflect.TypeOf(map[string]*containerRuntimeConfig.ContainerRuntimeSpec{}),
+	"transceiver":       reflect.TypeOf(map[string]*transceiverConfig.TransceiverSpec{}),
+	"memory":            reflect.TypeOf(map[string]*memoryConfig.MemorySpec{}),
+	// a file name or URL, or a list of them, resolved by httpclient.ResolveSpecOverlay
+	httpclient.SpecBaseKey: nil,
+}
+
+// SpecSchema returns the JSON schema of a spec file.
+func SpecSchema() *Schema {
+	return ObjectSchema(sections)
+}
+
+// rules are the semantic checks run on the root mapping of a spec file.
+var rules = []func(root *yaml.Node, r *report){
+	checkGPUSpecs,
+	checkPortSpeeds,
+	checkTransceiverThresholds,
+}
+
+const (
+	minGPUNums = 1
+	maxGPUNums = 16
+)
+
+// nvidiaDeviceIDPattern is the format of the PCI device IDs the nvidia
+// specs are looked up by, e.g. 0x233510de.
+var nvidiaDeviceIDPattern = regexp.MustCompile(`^0x[0-9a-f]{8}$`)
+
+// nvlinksPerGPU is the number of NVLinks of a GPU model, matched against the
+// spec name. The first match wins.
+var nvlinksPerGPU = []struct {
+	model string
+	links int
+}{
+	{"H800", 8},
+	{"A800", 8},
+	{"H100", 18},
+	{"H200", 18},
+	{"H20", 18},
+	{"B200", 18},
+	{"B300", 18},
+	{"A100", 12},
+	{"V100", 6},
+}
+
+func gpuNVLinks(name string) (string, int) {
+	upper := strings.ToUpper(name)
+	for _, m := range nvlinksPerGPU {
+		if strings.Contains(upper, m.model) {
+			return m.model, m.links
+		}
+	}
+	return "", 0
+}
+
+// checkGPUSpecs checks the device IDs, the GPU count and the NVLinks of the
+// nvidia specs and the GPU count of the amd ones.
+func checkGPUSpecs(root *yaml.Node, r *report) {
+	for _, section := range []string{"nvidia", "amd"} {
+		_, specs := lookup(root, section)
+		for _, entry := range entries(specs) {
+			key, spec := entry[0], entry[1]
+			path := joinPath(section, key.Value)
+			if section == "nvidia" && !nvidiaDeviceIDPattern.MatchString(key.Value) {
+				r.errorf(key, path, "device ID %q does not match the lowercase 0x<device><vendor> format, e.g. \"0x233510de\"", key.Value)
+			}
+			if k, v := lookup(spec, "gpu_nums"); k != nil {
+				if n, ok := intValue(v); ok && (n < minGPUNums || n > maxGPUNums) {
+					r.errorf(v, joinPath(path, "gpu_nums"), "gpu_nums %d is not within %d..%d", n, minGPUNums, maxGPUNums)
+				}
+			}
+			if section == "nvidia" {
+				checkNVLinks(spec, path, r)
+			}
+		}
+	}
+}
+
+func checkNVLinks(spec *yaml.Node, path string, r *report) {
+	_, nvlink := lookup(spec, "nvlink")
+	_, supportedNode := lookup(nvlink, "nvlink_supported")
+	_, numNode := lookup(nvlink, "active_nvlink_num")
+	if supportedNode == nil || numNode == nil {
+		return
+	}
+	supported, ok := boolValue(supportedNode)
+	num, ok2 := intValue(numNode)
+	if !ok || !ok2 {
+		return
+	}
+	numPath := joinPath(path, "nvlink.active_nvlink_num")
+	if !supported {
+		if num != 0 {
+			r.errorf(numNode, numPath, "active_nvlink_num is %d but nvlink_supported is false", num)
+		}
+		return
+	}
+	if num <= 0 {
+		r.errorf(numNode, numPath, "active_nvlink_num is %d but nvlink_supported is true", num)
+		return
+	}
+	_, nameNode := lookup(spec, "name")
+	if nameNode == nil {
+		return
+	}
+	if model, links := gpuNVLinks(deref(nameNode).Value); links > 0 && num > links {
+		r.errorf(numNode, numPath, "active_nvlink_num %d exceeds the %d NVLinks of the %s", num, links, model)
+	}
+}
+
+// portSpeedPattern is the format of the IB port rates in sysfs, e.g.
+// "400 Gb/sec (4X NDR)".
+var portSpeedPattern = regexp.MustCompile(`^(\d+(?:\.\d+)?) Gb/sec \((\d+)X ([A-Z0-9]+)\)$`)
+
+// laneRates is the Gb/s of a lane at each IB speed.
+var laneRates = map[string]float64{
+	"SDR":   2.5,
+	"DDR":   5,
+	"QDR":   10,
+	"FDR10": 10,
+	"FDR":   14.0625,
+	"EDR":   25,
+	"HDR":   50,
+	"NDR":   100,
+	"XDR":   200,
+}
+
+// checkPortSpeeds checks the port speeds of the hca specs, which the
+// infiniband component compares verbatim with the rate in sysfs.
+func checkPortSpeeds(root *yaml.Node, r *report) {
+	for _, section := range []string{"hca", "hca_specs"} {
+		_, specs := lookup(root, section)
+		for _, entry := range entries(specs) {
+			_, hardware := lookup(entry[1], "hardware")
+			_, speed := lookup(hardware, "port_speed")
+			if speed == nil || isNull(deref(speed)) {
+				continue
+			}
+			path := joinPath(section, entry[0].Value) + ".hardware.port_speed"
+			if msg := portSpeedError(deref(speed).Value); msg != "" {
+				r.errorf(speed, path, "%s", msg)
+			}
+		}
+	}
+}
+
+func portSpeedError(speed string) string {
+	m := portSpeedPattern.FindStringSubmatch(speed)
+	if m == nil {
+		return fmt.Sprintf("port speed %q does not match the sysfs format, e.g. \"400 Gb/sec (4X NDR)\"", speed)
+	}
+	lane, ok := laneRates[m[3]]
+	if !ok {
+		return fmt.Sprintf("unknown IB speed %s in %q", m[3], speed)
+	}
+	rate, _ := strconv.ParseFloat(m[1], 64)
+	width, _ := strconv.Atoi(m[2])
+	if expected := lane * float64(width); rate < expected-1 || rate > expected+1 {
+		return fmt.Sprintf("rate %s Gb/sec of %q does not match %dX %s, expected %g Gb/sec", m[1], speed, width, m[3], float64(int(expected)))
+	}
+	return ""
+}
+
+// checkTransceiverThresholds checks that the warning temperature and the
+// minimal Rx power of each network are below their critical and maximal
+// counterparts.
+func checkTransceiverThresholds(root *yaml.Node, r *report) {
+	_, specs := lookup(root, "transceiver")
+	for _, entry := range entries(specs) {
+		_, networks := lookup(entry[1], "networks")
+		for _, network := range entries(networks) {
+			path := joinPath(joinPath("transceiver", entry[0].Value), "networks."+network[0].Value+".thresholds")
+			_, thresholds := lookup(network[1], "thresholds")
+			_, warningNode := lookup(thresholds, "temperature_warning_c")
+			_, criticalNode := lookup(thresholds, "temperature_critical_c")
+			warning, ok := floatValue(warningNode)
+			critical, ok2 := floatValue(criticalNode)
+			if ok && ok2 && warning > 0 && critical > 0 && warning >= critical {
+				r.errorf(warningNode, path+".temperature_warning_c", "temperature_warning_c %g is not below temperature_critical_c %g", warning, critical)
+			}
+			_, minNode := lookup(thresholds, "rx_power_min_dbm")
+			_, maxNode := lookup(thresholds, "rx_power_max_dbm")
+			min, ok := floatValue(minNode)
+			max, ok2 := floatValue(maxNode)
+			if ok && ok2 && (min != 0 || max != 0) && min >= max {
+				r.errorf(minNode, path+".rx_power_min_dbm", "rx_power_min_dbm %g is not below rx_power_max_dbm %g", min, max)
+			}
+		}
+	}
+}
+
+func intValue(node *yaml.Node) (int, bool) {
+	node = deref(node)
+	if node == nil || node.Tag != "!!int" {
+		return 0, false
+	}
+	var n int
+	return n, node.Decode(&n) == nil
+}
+
+func floatValue(node *yaml.Node) (float64, bool) {
+	node = deref(node)
+	if node == nil || (node.Tag != "!!int" && node.Tag != "!!float") {
+		return 0, false
+	}
+	var f float64
+	return f, node.Decode(&f) == nil
+}
+
+func boolValue(node *yaml.Node) (bool, bool) {
+	node = deref(node)
+	if node == nil || node.Tag != "!!bool" {
+		return false, false
+	}
+	var b bool
+	return b, node.Decode(&b) == nil
+}
@@ -0,0 +1,304 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package specvalidate
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Issue is a problem found in a spec file. Errors make the components load a
+// different spec than intended or fail to load it at all, warnings are
+// settings the components ignore, e.g. a misspelled field.
+type Issue struct {
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Path     string `json:"path"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+func (i Issue) String() string {
+	if i.Path == "" {
+		return fmt.Sprintf("%d:%d: %s: %s", i.Line, i.Column, i.Severity, i.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s: %s: %s", i.Line, i.Column, i.Severity, i.Path, i.Message)
+}
+
+// HasErrors reports whether issues holds an error, or a warning when strict.
+func HasErrors(issues []Issue, strict bool) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError || strict {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateFile validates the spec file at path, see Validate.
+func ValidateFile(path string) ([]Issue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec file %s: %w", path, err)
+	}
+	return Validate(data)
+}
+
+// Validate checks a spec file against the schema of its sections and the
+// semantic rules, and returns the issues sorted by position. The error is
+// only set when data is not valid YAML. A spec inheriting from base specs is
+// validated as written, without its bases.
+func Validate(data []byte) ([]Issue, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	r := &report{}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	v := &validator{schema: SpecSchema(), report: r}
+	v.validate(v.schema, root, "")
+	for _, rule := range rules {
+		rule(root, r)
+	}
+	sort.SliceStable(r.issues, func(i, j int) bool {
+		if r.issues[i].Line != r.issues[j].Line {
+			return r.issues[i].Line < r.issues[j].Line
+		}
+		return r.issues[i].Column < r.issues[j].Column
+	})
+	return r.issues, nil
+}
+
+type report struct {
+	issues []Issue
+}
+
+func (r *report) add(severity string, node *yaml.Node, path, format string, args ...interface{}) {
+	r.issues = append(r.issues, Issue{
+		Line:     node.Line,
+		Column:   node.Column,
+		Path:     path,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (r *report) errorf(node *yaml.Node, path, format string, args ...interface{}) {
+	r.add(SeverityError, node, path, format, args...)
+}
+
+func (r *report) warnf(node *yaml.Node, path, format string, args ...interface{}) {
+	r.add(SeverityWarning, node, path, format, args...)
+}
+
+type validator struct {
+	schema *Schema
+	report *report
+}
+
+func (v *validator) resolve(s *Schema) *Schema {
+	if s.Ref != "" {
+		if def, ok := v.schema.Defs[strings.TrimPrefix(s.Ref, "#/$defs/")]; ok {
+			return def
+		}
+	}
+	return s
+}
+
+func (v *validator) validate(s *Schema, node *yaml.Node, path string) {
+	s = v.resolve(s)
+	// an alias is validated where its anchor is defined
+	if s.Type == "" || node.Kind == yaml.AliasNode || isNull(node) {
+		return
+	}
+	switch s.Type {
+	case "object":
+		v.validateObject(s, node, path)
+	case "array":
+		if node.Kind != yaml.SequenceNode {
+			v.report.errorf(node, path, "expected a list, got %s", describe(node))
+			return
+		}
+		for i, item := range node.Content {
+			v.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i))
+		}
+	case "string":
+		// the loader converts any scalar to the string fields
+		if node.Kind != yaml.ScalarNode {
+			v.report.errorf(node, path, "expected a string, got %s", describe(node))
+		}
+	case "integer":
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!int" {
+			v.report.errorf(node, path, "expected an integer, got %s", describe(node))
+			return
+		}
+		if s.Minimum != nil {
+			var n float64
+			if err := node.Decode(&n); err == nil && n < *s.Minimum {
+				v.report.errorf(node, path, "expected an integer >= %g, got %s", *s.Minimum, node.Value)
+			}
+		}
+	case "number":
+		if node.Kind != yaml.ScalarNode || (node.Tag != "!!int" && node.Tag != "!!float") {
+			v.report.errorf(node, path, "expected a number, got %s", describe(node))
+		}
+	case "boolean":
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!bool" {
+			v.report.errorf(node, path, "expected true or false, got %s", describe(node))
+		}
+	}
+}
+
+func (v *validator) validateObject(s *Schema, node *yaml.Node, path string) {
+	if node.Kind != yaml.MappingNode {
+		v.report.errorf(node, path, "expected a mapping, got %s", describe(node))
+		return
+	}
+	var keyPattern *regexp.Regexp
+	if s.PropertyNames != nil && s.PropertyNames.Pattern != "" {
+		keyPattern = regexp.MustCompile(s.PropertyNames.Pattern)
+	}
+	seen := make(map[string]bool, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		// the merged mappings are validated where their anchors are defined
+		if key.Value == "<<" {
+			continue
+		}
+		keyPath := joinPath(path, key.Value)
+		if seen[key.Value] {
+			v.report.errorf(key, keyPath, "duplicate key %q", key.Value)
+		}
+		seen[key.Value] = true
+		if keyPattern != nil && !keyPattern.MatchString(key.Value) {
+			v.report.errorf(key, keyPath, "key %q does not match %s", key.Value, keyPattern)
+		}
+		if prop, ok := s.Properties[key.Value]; ok {
+			v.validate(prop, value, keyPath)
+			continue
+		}
+		if additional, ok := s.AdditionalProperties.(*Schema); ok {
+			v.validate(additional, value, keyPath)
+			continue
+		}
+		msg := fmt.Sprintf("unknown field %q is ignored", key.Value)
+		if hint := closestProperty(s, key.Value); hint != "" {
+			msg += fmt.Sprintf(", did you mean %q?", hint)
+		}
+		v.report.warnf(key, keyPath, "%s", msg)
+	}
+}
+
+// closestProperty returns the property of s name is probably a typo of: the
+// same name in another case or with '-' and '_' swapped.
+func closestProperty(s *Schema, name string) string {
+	normalize := func(n string) string {
+		return strings.ToLower(strings.ReplaceAll(n, "-", "_"))
+	}
+	for prop := range s.Properties {
+		if normalize(prop) == normalize(name) {
+			return prop
+		}
+	}
+	return ""
+}
+
+func isNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null"
+}
+
+func describe(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a list"
+	default:
+		return fmt.Sprintf("%q", node.Value)
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// lookup returns the key and value nodes of key in the mapping node, looking
+// into the mappings merged with "<<" when node does not set it itself.
+func lookup(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	node = deref(node)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	var merged []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		k, value := node.Content[i], node.Content[i+1]
+		if k.Value == key {
+			return k, value
+		}
+		if k.Value == "<<" {
+			if value = deref(value); value.Kind == yaml.SequenceNode {
+				merged = append(merged, value.Content...)
+			} else {
+				merged = append(merged, value)
+			}
+		}
+	}
+	for _, m := range merged {
+		if k, value := lookup(m, key); k != nil {
+			return k, value
+		}
+	}
+	return nil, nil
+}
+
+// entries returns the key and value nodes of the mapping node, without the
+// merge keys.
+func entries(node *yaml.Node) [][2]*yaml.Node {
+	node = deref(node)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	var pairs [][2]*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != "<<" {
+			pairs = append(pairs, [2]*yaml.Node{node.Content[i], node.Content[i+1]})
+		}
+	}
+	return pairs
+}
+
+func deref(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package specvalidate
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateDefaultSpecs(t *testing.T) {
+	files, err := filepath.Glob("../../components/*/config/*spec*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files = append(files, "../../config/default_spec.yaml")
+	for _, file := range files {
+		issues, err := ValidateFile(file)
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		for _, issue := range issues {
+			if issue.Severity == SeverityError {
+				t.Errorf("%s:%s", file, issue)
+			}
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	spec := `nvidia:
+  "0x233010DE":
+    name: NVIDIA H100 80GB HBM3
+    gpu_nums: 32
+    nvlink:
+      nvlink_supported: true
+      active_nvlink_num: 20
+    temperature_threshold:
+      gpu: hot
+      Memory: 95
+  "0x20f110de":
+    name: NVIDIA A100-PCIE-40GB
+    nvlink:
+      nvlink_supported: false
+      active_nvlink_num: 12
+    critical_xid_events:
+      xid79: fallen off the bus
+infiniband:
+  ib_base: &ib_base
+    ib_devs:
+      mlx5_0: ib0
+    default_ports: [1, 2]
+  default:
+    <<: *ib_base
+hca:
+  MT_0000000838:
+    hardware:
+      port_speed: "400 Gb/s (4X NDR)"
+  MT_0000000970:
+    hardware:
+      port_speed: "200 Gb/sec (4X NDR)"
+transceiver:
+  default:
+    networks:
+      business:
+        thresholds:
+          temperature_warning_c: 80
+          temperature_critical_c: 75
+          rx_power_min_dbm: 5
+          rx_power_max_dbm: -10
+storage:
+  default:
+    nvme:
+      max_media_errors: -1
+storage:
+  default: {}
+`
+	issues, err := Validate([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		`2:3: error: nvidia.0x233010DE: device ID "0x233010DE" does not match`,
+		`4:15: error: nvidia.0x233010DE.gpu_nums: gpu_nums 32 is not within 1..16`,
+		`7:26: error: nvidia.0x233010DE.nvlink.active_nvlink_num: active_nvlink_num 20 exceeds the 18 NVLinks of the H100`,
+		`9:12: error: nvidia.0x233010DE.temperature_threshold.gpu: expected an integer, got "hot"`,
+		`10:7: warning: nvidia.0x233010DE.temperature_threshold.Memory: unknown field "Memory" is ignored, did you mean "memory"?`,
+		`15:26: error: nvidia.0x20f110de.nvlink.active_nvlink_num: active_nvlink_num is 12 but nvlink_supported is false`,
+		`17:7: error: nvidia.0x20f110de.critical_xid_events.xid79: key "xid79" does not match`,
+		`28:19: error: hca.MT_0000000838.hardware.port_speed: port speed "400 Gb/s (4X NDR)" does not match the sysfs format`,
+		`31:19: error: hca.MT_0000000970.hardware.port_speed: rate 200 Gb/sec of "200 Gb/sec (4X NDR)" does not match 4X NDR, expected 400 Gb/sec`,
+		`37:34: error: transceiver.default.networks.business.thresholds.temperature_warning_c: temperature_warning_c 80 is not below temperature_critical_c 75`,
+		`39:29: error: transceiver.default.networks.business.thresholds.rx_power_min_dbm: rx_power_min_dbm 5 is not below rx_power_max_dbm -10`,
+		`44:25: error: storage.default.nvme.max_media_errors: expected an integer >= 0, got -1`,
+		`45:1: error: storage: duplicate key "storage"`,
+	}
+	if len(issues) != len(expected) {
+		for _, issue := range issues {
+			t.Log(issue)
+		}
+		t.Fatalf("expected %d issues, got %d", len(expected), len(issues))
+	}
+	for i, issue := range issues {
+		if !strings.HasPrefix(issue.String(), expected[i]) {
+			t.Errorf("issue %d: expected %q, got %q", i, expected[i], issue.String())
+		}
+	}
+	if !HasErrors(issues, false) || HasErrors(issues[4:5], false) || !HasErrors(issues[4:5], true) {
+		t.Errorf("unexpected HasErrors")
+	}
+
+	if _, err := Validate([]byte("nvidia: [")); err == nil {
+		t.Errorf("expected an error for invalid YAML")
+	}
+}
+
+func TestSpecSchema(t *testing.T) {
+	s := SpecSchema()
+	// the boards of the infiniband spec are infiniband specs themselves
+	if s.Defs["InfinibandSpec"] == nil {
+		t.Fatalf("expected the recursive InfinibandSpec in $defs, got %v", s.Defs)
+	}
+	ib := s.Properties["infiniband"].AdditionalProperties.(*Schema)
+	boards := ib.Properties["boards"].AdditionalProperties.(*Schema)
+	if boards.Ref != "#/$defs/InfinibandSpec" {
+		t.Errorf("expected the boards to reference InfinibandSpec, got %+v", boards)
+	}
+	// common.Duration unmarshals itself and accepts any value
+	sel := s.Properties["bmc"].AdditionalProperties.(*Schema).Properties["sel"]
+	if lookback := sel.Properties["lookback"]; lookback == nil || lookback.Type != "" {
+		t.Errorf("expected any value for the lookback, got %+v", lookback)
+	}
+	if _, err := json.Marshal(s); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -95,6 +95,26 @@ func IsActive(service string) (bool, error) {
 	return strings.TrimSpace(string(output)) == "active", nil
 }
 
+// IsEnabled returns true if the systemd service is enabled to start on boot.
+func IsEnabled(service string) (bool, error) {
+	exist, err := SystemctlExists()
+	if !exist {
+		return false, fmt.Errorf("systemd enabled check requires systemctl (%w)", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	output, err := utils.ExecCommand(ctx, "systemctl", "is-enabled", service)
+	if err != nil {
+		// e.g., "disabled" or "not-found" with exit status 1
+		if out := strings.TrimSpace(string(output)); out != "" && !strings.Contains(out, "Failed") {
+			return false, nil
+		}
+		return false, err
+	}
+	return strings.TrimSpace(string(output)) == "enabled", nil
+}
+
 func EnableSystemdService(service string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -0,0 +1,252 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package telemetry exports OpenTelemetry traces and metrics of the health
+// checks over OTLP, so that slow checkers, e.g. the ones exec'ing lspci, show
+// up in the observability stack next to the load of the node. Without Setup
+// the global no-op providers are used and the instrumentation costs nothing.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gopkg.in/yaml.v3"
+)
+
+const instrumentationName = "github.com/scitix/sichek"
+
+// the span kinds, also the prefix of their duration histograms
+const (
+	KindHealthCheck = "healthcheck"
+	KindCollect     = "collect"
+	KindChecker     = "checker"
+	KindExec        = "exec"
+)
+
+// attribute keys of the spans and metrics
+const (
+	ComponentKey = attribute.Key("sichek.component")
+	CheckerKey   = attribute.Key("sichek.checker")
+	CommandKey   = attribute.Key("sichek.command")
+	StatusKey    = attribute.Key("sichek.status")
+)
+
+// Config is the `telemetry` section of the user config.
+type Config struct {
+	Telemetry struct {
+		Enable bool `json:"enable" yaml:"enable"`
+		// Endpoint is the host:port of the OTLP/HTTP receiver, the
+		// OTEL_EXPORTER_OTLP_ENDPOINT environment variable applies if empty.
+		Endpoint string `json:"endpoint" yaml:"endpoint"`
+		Insecure bool   `json:"insecure" yaml:"insecure"`
+		// Interval is the export interval of the metrics.
+		Interval time.Duration `json:"interval" yaml:"interval"`
+	} `json:"telemetry" yaml:"telemetry"`
+}
+
+// LoadConfig loads the telemetry config from cfgFile, missing fields keep
+// their defaults.
+func LoadConfig(cfgFile string) *Config {
+	config := &Config{}
+	config.Telemetry.Insecure = true
+	config.Telemetry.Interval = 60 * time.Second
+
+	if cfgFile != "" {
+		data, err := os.ReadFile(cfgFile)
+		if err == nil {
+			err = yaml.Unmarshal(data, config)
+		}
+		if err != nil {
+			logrus.WithField("telemetry", "config").Warnf("Failed to load telemetry config from %s, using defaults: %v", cfgFile, err)
+		}
+	}
+	if config.Telemetry.Interval <= 0 {
+		config.Telemetry.Interval = 60 * time.Second
+	}
+	return config
+}
+
+// Setup installs the OTLP exporting trace and meter providers as the global
+// ones. The returned func flushes and stops the exporters.
+func Setup(ctx context.Context, config *Config, node string) (func(context.Context) error, error) {
+	cfg := config.Telemetry
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName("sichek"),
+		semconv.HostName(node),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("create telemetry resource: %w", err)
+	}
+
+	var traceOpts []otlptracehttp.Option
+	var metricOpts []otlpmetrichttp.Option
+	if cfg.Endpoint != "" {
+		traceOpts = append(traceOpts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		metricOpts = append(metricOpts, otlpmetrichttp.WithEndpoint(cfg.Endpoint))
+	}
+	if cfg.Insecure {
+		traceOpts = append(traceOpts, otlptracehttp.WithInsecure())
+		metricOpts = append(metricOpts, otlpmetrichttp.WithInsecure())
+	}
+	traceExporter, err := otlptracehttp.New(ctx, traceOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("create OTLP trace exporter: %w", err)
+	}
+	metricExporter, err := otlpmetrichttp.New(ctx, metricOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("create OTLP metric exporter: %w", err)
+	}
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(traceExporter),
+		sdktrace.WithResource(res),
+	)
+	meterProvider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter, sdkmetric.WithInterval(cfg.Interval))),
+		sdkmetric.WithResource(res),
+	)
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetMeterProvider(meterProvider)
+	logrus.WithField("telemetry", "setup").Infof("exporting OpenTelemetry traces and metrics to %s", endpointOrEnv(cfg.Endpoint))
+
+	return func(ctx context.Context) error {
+		return errors.Join(tracerProvider.Shutdown(ctx), meterProvider.Shutdown(ctx))
+	}, nil
+}
+
+func endpointOrEnv(endpoint string) string {
+	if endpoint != "" {
+		return endpoint
+	}
+	if env := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); env != "" {
+		return env
+	}
+	return "the default OTLP endpoint"
+}
+
+type instruments struct {
+	durations map[string]metric.Float64Histogram
+	failures  metric.Int64Counter
+}
+
+var (
+	instrumentsOnce sync.Once
+	instrs          *instruments
+)
+
+// getInstruments creates the instruments once from the global meter provider,
+// which forwards them to the provider installed later by Setup.
+func getInstruments() *instruments {
+	instrumentsOnce.Do(func() {
+		meter := otel.Meter(instrumentationName)
+		instrs = &instruments{durations: make(map[string]metric.Float64Histogram)}
+		for _, kind := range []string{KindHealthCheck, KindCollect, KindChecker, KindExec} {
+			histogram, err := meter.Float64Histogram("sichek."+kind+".duration",
+				metric.WithUnit("s"),
+				metric.WithDescription("Duration of the sichek "+kind+" runs"))
+			if err != nil {
+				logrus.WithField("telemetry", "instruments").Warnf("create %s histogram failed: %v", kind, err)
+				continue
+			}
+			instrs.durations[kind] = histogram
+		}
+		failures, err := meter.Int64Counter("sichek.failures",
+			metric.WithDescription("Count of the sichek runs that failed or found the node abnormal"))
+		if err != nil {
+			logrus.WithField("telemetry", "instruments").Warnf("create failures counter failed: %v", err)
+		}
+		instrs.failures = failures
+	})
+	return instrs
+}
+
+// Span is a traced run of a health check, a collector, a checker or a command,
+// its duration is also recorded in the histogram of its kind.
+type Span struct {
+	ctx   context.Context
+	span  trace.Span
+	kind  string
+	start time.Time
+	attrs []attribute.KeyValue
+}
+
+// Start starts a span of kind named name, ctx then carries it to the nested spans.
+func Start(ctx context.Context, kind, name string, attrs ...attribute.KeyValue) (context.Context, *Span) {
+	ctx, span := otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+	return ctx, &Span{ctx: ctx, span: span, kind: kind, start: time.Now(), attrs: attrs}
+}
+
+// StartHealthCheck starts the span of a HealthCheck of component.
+func StartHealthCheck(ctx context.Context, component string) (context.Context, *Span) {
+	return Start(ctx, KindHealthCheck, component+" HealthCheck", ComponentKey.String(component))
+}
+
+// StartCollect starts the span of a Collect of component.
+func StartCollect(ctx context.Context, component string) (context.Context, *Span) {
+	return Start(ctx, KindCollect, component+" Collect", ComponentKey.String(component))
+}
+
+// StartChecker starts the span of a checker of component.
+func StartChecker(ctx context.Context, component, checker string) (context.Context, *Span) {
+	return Start(ctx, KindChecker, checker, ComponentKey.String(component), CheckerKey.String(checker))
+}
+
+// StartExec starts the span of an external command.
+func StartExec(ctx context.Context, command string) (context.Context, *Span) {
+	return Start(ctx, KindExec, "exec "+command, CommandKey.String(command))
+}
+
+// End ends the span with the status of the result, "" if it has none, and
+// records its duration. An error or an abnormal status counts as a failure.
+func (s *Span) End(status string, err error) {
+	attrs := s.attrs
+	if status != "" {
+		attrs = append(attrs[:len(attrs):len(attrs)], StatusKey.String(status))
+		s.span.SetAttributes(StatusKey.String(status))
+	}
+	failed := err != nil || status == consts.StatusAbnormal
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+
+	instrs := getInstruments()
+	set := metric.WithAttributes(attrs...)
+	if histogram, ok := instrs.durations[s.kind]; ok {
+		histogram.Record(s.ctx, time.Since(s.start).Seconds(), set)
+	}
+	if failed && instrs.failures != nil {
+		instrs.failures.Add(s.ctx, 1, metric.WithAttributes(append(attrs[:len(attrs):len(attrs)], attribute.String("sichek.kind", s.kind))...))
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/consts"
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestLoadConfig(t *testing.T) {
+	cfgFile := filepath.Join(t.TempDir(), "user.yaml")
+	if err := os.WriteFile(cfgFile, []byte("telemetry:\n  enable: true\n  endpoint: otel-collector:4318\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := LoadConfig(cfgFile)
+	if !cfg.Telemetry.Enable || cfg.Telemetry.Endpoint != "otel-collector:4318" || !cfg.Telemetry.Insecure || cfg.Telemetry.Interval != time.Minute {
+		t.Errorf("unexpected config %+v", cfg.Telemetry)
+	}
+}
+
+func TestSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	reader := sdkmetric.NewManualReader()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+	ctx, healthCheck := StartHealthCheck(context.Background(), consts.ComponentNameCPU)
+	_, checker := StartChecker(ctx, consts.ComponentNameCPU, "cpu-perf")
+	checker.End(consts.StatusAbnormal, nil)
+	_, exec := StartExec(ctx, "lspci")
+	exec.End("", errors.New("exit status 1"))
+	healthCheck.End(consts.StatusAbnormal, nil)
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+	parent := spans[2]
+	if parent.Name() != "cpu HealthCheck" {
+		t.Errorf("unexpected health check span %s", parent.Name())
+	}
+	for _, span := range spans[:2] {
+		if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("span %s is not a child of the health check", span.Name())
+		}
+	}
+	if spans[1].Name() != "exec lspci" || len(spans[1].Events()) != 1 {
+		t.Errorf("exec span %s should record its error, got %d events", spans[1].Name(), len(spans[1].Events()))
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("collect metrics: %v", err)
+	}
+	found := make(map[string]bool)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			found[m.Name] = true
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok && m.Name == "sichek.failures" {
+				var total int64
+				for _, point := range sum.DataPoints {
+					total += point.Value
+				}
+				if total != 3 {
+					t.Errorf("expected 3 failures, got %d", total)
+				}
+			}
+		}
+	}
+	for _, name := range []string{"sichek.healthcheck.duration", "sichek.checker.duration", "sichek.exec.duration", "sichek.failures"} {
+		if !found[name] {
+			t.Errorf("metric %s not recorded", name)
+		}
+	}
+}
@@ -19,11 +19,14 @@ import (
 	"fmt"
 	"os"
 	"strings"
+
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/sirupsen/logrus"
 )
 
 // IsKernalModuleLoaded checks if a specific kernel module is loaded
 func IsKernalModuleLoaded(moduleName string) (bool, error) {
-	data, err := os.ReadFile("/proc/modules")
+	data, err := os.ReadFile(hostfs.Path("/proc/modules"))
 	if err != nil {
 		return false, fmt.Errorf("failed to read /proc/modules: %w", err)
 	}
@@ -33,11 +36,11 @@ func IsKernalModuleLoaded(moduleName string) (bool, error) {
 
 // IsKernalModuleHolder checks if a specific module is holding another module
 func IsKernalModuleHolder(holder, module string) (bool, error) {
-	path := fmt.Sprintf("/sys/module/%s/holders", holder)
+	path := hostfs.Path("/sys/module", holder, "holders")
 	files, err := os.ReadDir(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			fmt.Printf("Holder module or path does not exist")
+			logrus.WithField("component", "utils").Debugf("holder module %s or its path does not exist", holder)
 			return false, nil // Holder module or path does not exist
 		}
 		return false, fmt.Errorf("failed to read holders for %s: %w", holder, err)
@@ -55,7 +58,7 @@ func IsKernalModuleHolder(holder, module string) (bool, error) {
 
 // HasIOMMUGroups checks if IOMMU groups are present in /sys/kernel/iommu_groups
 func HasIOMMUGroups() (bool, error) {
-	const iommuPath = "/sys/kernel/iommu_groups"
+	iommuPath := hostfs.Path("/sys/kernel/iommu_groups")
 
 	// Check if the path exists
 	_, err := os.Stat(iommuPath)
@@ -28,6 +28,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/scitix/sichek/pkg/printer"
+	"github.com/scitix/sichek/pkg/telemetry"
 	"github.com/sirupsen/logrus"
 )
 
@@ -64,7 +67,10 @@ func IsRunningInKubernetes() bool {
 	return hasServiceHost && hasPort
 }
 
-func ExecCommand(ctx context.Context, command string, args ...string) ([]byte, error) {
+func ExecCommand(ctx context.Context, command string, args ...string) (output []byte, err error) {
+	// traced so that the slow commands, e.g. lspci, show up under their checker
+	ctx, span := telemetry.StartExec(ctx, command)
+	defer func() { span.End("", err) }()
 	if IsRunningInKubernetes() {
 		output, stderr, err := execOnHost(ctx, command, args...)
 		if err != nil {
@@ -136,7 +142,7 @@ func execOnHost(ctx context.Context, command string, args ...string) ([]byte, []
 // TimeTrack Measure execution time of a function
 func TimeTrack(start time.Time, name string) {
 	elapsed := time.Since(start)
-	fmt.Printf("%s took %d ns\n", name, elapsed.Nanoseconds())
+	printer.Progressf("%s took %d ns\n", name, elapsed.Nanoseconds())
 }
 
 func IsNvidiaGPUExist() bool {
@@ -147,8 +153,38 @@ func IsNvidiaGPUExist() bool {
 	return false
 }
 
+// IsAmdGPUExist reports whether a GPU driven by amdgpu is present, i.e. the
+// vendor of at least one DRM card is AMD (0x1002) and the KFD device exists.
+func IsAmdGPUExist() bool {
+	if _, err := os.Stat("/dev/kfd"); err != nil {
+		return false
+	}
+	vendors, err := filepath.Glob(hostfs.Path("/sys/class/drm/card[0-9]*/device/vendor"))
+	if err != nil {
+		return false
+	}
+	for _, vendor := range vendors {
+		content, err := os.ReadFile(vendor)
+		if err == nil && strings.TrimSpace(string(content)) == "0x1002" {
+			return true
+		}
+	}
+	return false
+}
+
+// IsIPMIExist reports whether the BMC is reachable in-band, i.e. a device node
+// of the IPMI driver exists.
+func IsIPMIExist() bool {
+	for _, path := range []string{"/dev/ipmi0", "/dev/ipmi/0", "/dev/ipmidev/0"} {
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
 func IsInfinibandExist() bool {
-	const dir = "/sys/class/infiniband"
+	dir := hostfs.Path("/sys/class/infiniband")
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
 		return false
 	}
@@ -175,7 +211,7 @@ func IsLowSpeedIBBond(ibDev string) bool {
 	if !strings.Contains(ibDev, "bond") {
 		return false
 	}
-	ratePath := filepath.Join("/sys/class/infiniband", ibDev, "ports/1/rate")
+	ratePath := hostfs.Path("/sys/class/infiniband", ibDev, "ports/1/rate")
 	content, err := os.ReadFile(ratePath)
 	if err != nil {
 		logrus.WithField("component", "utils").Debugf("unable to read IB rate for %s at %s: %v, not treating as low-speed bond", ibDev, ratePath, err)
@@ -0,0 +1,234 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scitix/sichek/consts"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+var logFormat = LogFormatText
+
+// SetLogFormat switches the logrus formatter to text or json and records the
+// format for later InitLogger calls.
+func SetLogFormat(format string) error {
+	format = strings.ToLower(format)
+	if format != LogFormatText && format != LogFormatJSON {
+		return fmt.Errorf("invalid log format %q, must be %s or %s", format, LogFormatText, LogFormatJSON)
+	}
+	logFormat = format
+	logrus.SetFormatter(newLogFormatter(IsJSONLogFormat(), true))
+	return nil
+}
+
+// IsJSONLogFormat reports whether the json log format was selected.
+func IsJSONLogFormat() bool {
+	return logFormat == LogFormatJSON
+}
+
+func newLogFormatter(isJSON bool, colors bool) logrus.Formatter {
+	if isJSON {
+		return &logrus.JSONFormatter{
+			TimestampFormat: time.RFC3339,
+		}
+	}
+	return &logrus.TextFormatter{
+		FullTimestamp:   true,
+		TimestampFormat: "2006-01-02 15:04:05.000",
+		ForceColors:     colors,
+		DisableColors:   !colors,
+	}
+}
+
+// LogRouteConfig is the `log` section of the user config.
+type LogRouteConfig struct {
+	Log struct {
+		// Dir holds the <component>.log files, defaults to /var/log/sichek.
+		Dir        string                        `json:"dir" yaml:"dir"`
+		MaxSize    int                           `json:"max_size" yaml:"max_size"`
+		MaxBackups int                           `json:"max_backups" yaml:"max_backups"`
+		MaxAge     int                           `json:"max_age" yaml:"max_age"`
+		Compress   bool                          `json:"compress" yaml:"compress"`
+		Components map[string]ComponentLogConfig `json:"components" yaml:"components"`
+	} `json:"log" yaml:"log"`
+}
+
+// ComponentLogConfig routes the entries with a matching `component` field.
+type ComponentLogConfig struct {
+	Level string `json:"level" yaml:"level"`
+	// File overrides <dir>/<component>.log.
+	File string `json:"file" yaml:"file"`
+}
+
+// LoadLogRouteConfig loads the log section from cfgFile. A missing section
+// disables the per component routing.
+func LoadLogRouteConfig(cfgFile string) (*LogRouteConfig, error) {
+	config := &LogRouteConfig{}
+	if cfgFile != "" {
+		if err := LoadFromYaml(cfgFile, config); err != nil {
+			return config, err
+		}
+	}
+	if config.Log.Dir == "" {
+		config.Log.Dir = consts.DefaultLogDir
+	}
+	return config, nil
+}
+
+type logRoute struct {
+	level  logrus.Level
+	writer io.Writer
+}
+
+// logRouter is a logrus hook that replaces the logger output. Every entry is
+// written to the original output if it passes the base level, and to the file
+// of its component if it passes the level of that component, so that a single
+// component can log at debug without flooding the main log.
+type logRouter struct {
+	mu            sync.Mutex
+	out           io.Writer
+	outFormatter  logrus.Formatter
+	level         logrus.Level
+	fileFormatter logrus.Formatter
+	routes        map[string]*logRoute
+}
+
+func (r *logRouter) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (r *logRouter) Fire(entry *logrus.Entry) error {
+	var route *logRoute
+	if component, ok := entry.Data["component"].(string); ok {
+		route = r.routes[strings.ToLower(component)]
+	}
+	toOut := entry.Level <= r.level
+	toRoute := route != nil && entry.Level <= route.level
+	if !toOut && !toRoute {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if toOut {
+		line, err := r.outFormatter.Format(entry)
+		if err != nil {
+			return err
+		}
+		if _, err := r.out.Write(line); err != nil {
+			return err
+		}
+	}
+	if toRoute {
+		line, err := r.fileFormatter.Format(entry)
+		if err != nil {
+			return err
+		}
+		if _, err := route.writer.Write(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newLogRouter(logger *logrus.Logger, config *LogRouteConfig, isJSON bool, openFile func(string) io.Writer) (*logRouter, error) {
+	router := &logRouter{
+		out:           logger.Out,
+		outFormatter:  logger.Formatter,
+		level:         logger.GetLevel(),
+		fileFormatter: newLogFormatter(isJSON, false),
+		routes:        make(map[string]*logRoute),
+	}
+	for component, c := range config.Log.Components {
+		level := router.level
+		if c.Level != "" {
+			parsed, err := logrus.ParseLevel(c.Level)
+			if err != nil {
+				return nil, fmt.Errorf("invalid log level %q of component %s: %w", c.Level, component, err)
+			}
+			level = parsed
+		}
+		file := c.File
+		if file == "" {
+			file = filepath.Join(config.Log.Dir, strings.ToLower(component)+".log")
+		}
+		router.routes[strings.ToLower(component)] = &logRoute{level: level, writer: openFile(file)}
+	}
+	return router, nil
+}
+
+// InitLogRouting routes the entries of the components in config to their own
+// rotating files. It must be called after InitLoggerWithConfig, whose output
+// and level become the main log. The logger level is raised to the most
+// verbose component level and the filtering is done by the router.
+func InitLogRouting(config *LogRouteConfig, isJSON bool) error {
+	if config == nil || len(config.Log.Components) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(config.Log.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create log directory %s: %w", config.Log.Dir, err)
+	}
+	openFile := func(file string) io.Writer {
+		return &lumberjack.Logger{
+			Filename:   file,
+			MaxSize:    defaultIfZero(config.Log.MaxSize, 100),
+			MaxBackups: defaultIfZero(config.Log.MaxBackups, 10),
+			MaxAge:     defaultIfZero(config.Log.MaxAge, 30),
+			Compress:   config.Log.Compress,
+		}
+	}
+	logger := logrus.StandardLogger()
+	router, err := newLogRouter(logger, config, isJSON, openFile)
+	if err != nil {
+		return err
+	}
+	installLogRouter(logger, router)
+	logrus.WithField("log", "router").Infof("routing logs of %d components to %s", len(router.routes), config.Log.Dir)
+	return nil
+}
+
+func installLogRouter(logger *logrus.Logger, router *logRouter) {
+	maxLevel := router.level
+	for _, route := range router.routes {
+		if route.level > maxLevel {
+			maxLevel = route.level
+		}
+	}
+	logger.SetLevel(maxLevel)
+	logger.SetOutput(io.Discard)
+	logger.AddHook(router)
+}
+
+func defaultIfZero(v, def int) int {
+	if v == 0 {
+		return def
+	}
+	return v
+}
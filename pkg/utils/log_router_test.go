@@ -0,0 +1,115 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestLogRouter(t *testing.T) {
+	var out bytes.Buffer
+	files := make(map[string]*bytes.Buffer)
+	openFile := func(file string) io.Writer {
+		files[file] = &bytes.Buffer{}
+		return files[file]
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(&out)
+	logger.SetLevel(logrus.WarnLevel)
+	logger.SetFormatter(newLogFormatter(false, false))
+
+	config := &LogRouteConfig{}
+	config.Log.Dir = "/var/log/sichek"
+	config.Log.Components = map[string]ComponentLogConfig{
+		"nvidia": {Level: "debug"},
+		"Dmesg":  {File: "/tmp/dmesg.log"},
+	}
+	router, err := newLogRouter(logger, config, true, openFile)
+	if err != nil {
+		t.Fatalf("newLogRouter failed: %v", err)
+	}
+	installLogRouter(logger, router)
+	if logger.GetLevel() != logrus.DebugLevel {
+		t.Fatalf("expected logger level raised to debug, got %s", logger.GetLevel())
+	}
+
+	logger.WithField("component", "nvidia").Debug("nvidia debug")
+	logger.WithField("component", "nvidia").Warn("nvidia warn")
+	logger.WithField("component", "dmesg").Info("dmesg info")
+	logger.WithField("component", "dmesg").Error("dmesg error")
+	logger.WithField("component", "cpu").Info("cpu info")
+	logger.WithField("component", "cpu").Error("cpu error")
+
+	main := out.String()
+	for _, msg := range []string{"nvidia warn", "dmesg error", "cpu error"} {
+		if !strings.Contains(main, msg) {
+			t.Errorf("expected %q in main log, got %q", msg, main)
+		}
+	}
+	for _, msg := range []string{"nvidia debug", "dmesg info", "cpu info"} {
+		if strings.Contains(main, msg) {
+			t.Errorf("unexpected %q in main log", msg)
+		}
+	}
+
+	nvidia := files["/var/log/sichek/nvidia.log"]
+	if nvidia == nil {
+		t.Fatalf("nvidia.log not opened, got %v", files)
+	}
+	lines := strings.Split(strings.TrimSpace(nvidia.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines in nvidia.log, got %q", nvidia.String())
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("expected json line in nvidia.log: %v", err)
+	}
+	if entry["msg"] != "nvidia debug" || entry["component"] != "nvidia" {
+		t.Errorf("unexpected entry %v", entry)
+	}
+
+	// dmesg inherits the base level
+	dmesg := files["/tmp/dmesg.log"].String()
+	if strings.Contains(dmesg, "dmesg info") || !strings.Contains(dmesg, "dmesg error") {
+		t.Errorf("unexpected dmesg.log %q", dmesg)
+	}
+}
+
+func TestNewLogRouterInvalidLevel(t *testing.T) {
+	config := &LogRouteConfig{}
+	config.Log.Components = map[string]ComponentLogConfig{"nvidia": {Level: "loud"}}
+	_, err := newLogRouter(logrus.New(), config, false, func(string) io.Writer { return io.Discard })
+	if err == nil {
+		t.Fatal("expected error for invalid level")
+	}
+}
+
+func TestSetLogFormat(t *testing.T) {
+	defer func() { _ = SetLogFormat(LogFormatText) }()
+	if err := SetLogFormat("JSON"); err != nil || !IsJSONLogFormat() {
+		t.Fatalf("expected json format, err=%v", err)
+	}
+	if err := SetLogFormat("xml"); err == nil {
+		t.Fatal("expected error for xml format")
+	}
+}
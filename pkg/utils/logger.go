@@ -19,7 +19,6 @@ import (
 	"io"
 	"os"
 	"path/filepath"
-	"time"
 
 	"github.com/sirupsen/logrus"
 	"gopkg.in/natefinch/lumberjack.v2"
@@ -51,18 +50,7 @@ func InitLoggerWithConfig(level logrus.Level, isJSON bool, config LogConfig) {
 	logrus.SetLevel(level)
 
 	// set formatter: support JSON format or custom text format
-	var formatter logrus.Formatter
-	if isJSON {
-		formatter = &logrus.JSONFormatter{
-			TimestampFormat: time.RFC3339,
-		}
-	} else {
-		formatter = &logrus.TextFormatter{
-			FullTimestamp:   true,
-			TimestampFormat: "2006-01-02 15:04:05.000",
-			ForceColors:     true,
-		}
-	}
+	formatter := newLogFormatter(isJSON, true)
 
 	// set log output
 	var writers []io.Writer
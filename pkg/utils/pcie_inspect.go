@@ -19,8 +19,10 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"strings"
+	"strconv"
 
+	"github.com/scitix/sichek/pkg/hostfs"
+	"github.com/scitix/sichek/pkg/pci"
 	"github.com/sirupsen/logrus"
 )
 
@@ -30,7 +32,7 @@ type PCIeACS struct {
 }
 
 func GetAllPCIeBDF(ctx context.Context) ([]string, error) {
-	devices, err := os.ReadDir("/sys/bus/pci/devices")
+	devices, err := os.ReadDir(hostfs.Path(pci.SysfsPath))
 	if err != nil {
 		return nil, fmt.Errorf("failed to list PCI devices: %w", err)
 	}
@@ -44,25 +46,41 @@ func GetAllPCIeBDF(ctx context.Context) ([]string, error) {
 	return deviceBDFs, nil
 }
 
+// GetACSStatus returns the ACS control register of the device in the format
+// of `setpci -s BDF ecap_acs+6.w`, e.g. "001d".
 func GetACSStatus(ctx context.Context, BDF string) (string, error) {
-	// Read ACS control register from PCIe device
-	acsCtl, err := ExecCommand(ctx, "setpci", "-s", BDF, "ecap_acs+6.w")
+	cfg, err := pci.ReadConfig(BDF)
 	if err != nil {
-		return "", fmt.Errorf("failed to execute command: %w", err)
+		return "", err
 	}
-	return strings.TrimSpace(string(acsCtl)), nil
+	acsCtl, err := cfg.ACSControl()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%04x", acsCtl), nil
 }
 
 func IsACSDisabled(ctx context.Context, BDF string) (bool, string, error) {
-	// Read ACS control register from PCIe device
-	acsCtl, err := ExecCommand(ctx, "setpci", "-s", BDF, "ecap_acs+6.w")
+	acsStatus, err := GetACSStatus(ctx, BDF)
 	if err != nil {
-		return false, "", fmt.Errorf("failed to execute command: %w", err)
+		return false, "", err
 	}
-	acsStatus := strings.TrimSpace(string(acsCtl))
 	return acsStatus == "0000", acsStatus, nil
 }
 
+// writeACSControl sets the ACS control register of the device to value.
+func writeACSControl(BDF string, value uint16) error {
+	cfg, err := pci.ReadConfig(BDF)
+	if err != nil {
+		return err
+	}
+	off, err := cfg.ACSControlOffset()
+	if err != nil {
+		return err
+	}
+	return pci.WriteWord(BDF, off, value)
+}
+
 func GetACSEnabledDevices(ctx context.Context) ([]PCIeACS, error) {
 	var acsEnabledDevices []PCIeACS
 	BDFs, err := GetAllPCIeBDF(ctx)
@@ -96,12 +114,11 @@ func DisableACS(ctx context.Context, BDF string) error {
 		return fmt.Errorf("failed to check ACS status for device %s: %w", BDF, err)
 	}
 	if !acsDisabled {
-		output, err := ExecCommand(ctx, "setpci", "-v", "-s", BDF, "ecap_acs+6.w=0")
-		if err != nil {
+		if err := writeACSControl(BDF, 0); err != nil {
 			logrus.WithField("component", "Utils").Errorf("Error disabling ACS on device %s: %v", BDF, err)
 			return fmt.Errorf("error disabling ACS on device %s: %w", BDF, err)
 		}
-		logrus.WithField("component", "Utils").Infof("Disabled ACS on device %s successfully. Output: %s", BDF, output)
+		logrus.WithField("component", "Utils").Infof("Disabled ACS on device %s successfully", BDF)
 	} else {
 		logrus.WithField("component", "Utils").Infof("ACS already disabled on device %s", BDF)
 	}
@@ -157,11 +174,12 @@ func GetACSCapablePCIEDevices(ctx context.Context) ([]string, error) {
 	devices, _ := GetAllPCIeBDF(ctx)
 	var acsCapDevices []string
 	for _, deviceBDF := range devices {
-		acsCap, err := ExecCommand(ctx, "setpci", "-s", deviceBDF, "ecap_acs+4.w")
-		if err == nil {
-			if strings.TrimSpace(string(acsCap)) != "0000" {
-				acsCapDevices = append(acsCapDevices, deviceBDF)
-			}
+		cfg, err := pci.ReadConfig(deviceBDF)
+		if err != nil {
+			continue
+		}
+		if acsCap, err := cfg.ACSCapability(); err == nil && acsCap != 0 {
+			acsCapDevices = append(acsCapDevices, deviceBDF)
 		}
 	}
 	return acsCapDevices, nil
@@ -171,8 +189,7 @@ func EnableACS(ctx context.Context, deviceBDF string) error {
 	isACSDisable, _, _ := IsACSDisabled(ctx, deviceBDF)
 	if isACSDisable {
 		logrus.WithField("component", "Utils").Infof("Enabling ACS on device %v", deviceBDF)
-		// Construct and run the setpci command
-		_, err := ExecCommand(ctx, "setpci", "-v", "-s", deviceBDF, "ecap_acs+6.w=f")
+		err := writeACSControl(deviceBDF, 0xf)
 		if err != nil {
 			logrus.WithField("component", "Utils").Errorf("Error enable ACS on device %v: %v", deviceBDF, err)
 			return err
@@ -186,3 +203,62 @@ func EnableACS(ctx context.Context, deviceBDF string) error {
 	}
 	return nil
 }
+
+// ModifyPCIeMaxReadRequest modifies the Max Read Request Size of a PCIe device
+// deviceAddr: PCI device address, e.g., "80:00.0"
+// offset: Register offset address, e.g., "68", empty locates the Device Control register
+// newHighNibble: New high nibble value (0-F)
+func ModifyPCIeMaxReadRequest(ctx context.Context, deviceAddr string, offset string, newHighNibble int) error {
+	// Validate input parameters
+	if newHighNibble < 0 || newHighNibble > 0xF {
+		return fmt.Errorf("new high nibble value must be between 0-F")
+	}
+
+	cfg, err := pci.ReadConfig(deviceAddr)
+	if err != nil {
+		return fmt.Errorf("failed to read PCI register: %v", err)
+	}
+	var off int
+	if offset == "" {
+		off, err = cfg.DevCtlOffset()
+		if err != nil {
+			return fmt.Errorf("failed to locate the Device Control register: %v", err)
+		}
+	} else {
+		parsed, err := strconv.ParseUint(offset, 16, 12)
+		if err != nil {
+			return fmt.Errorf("failed to parse offset %q: %v", offset, err)
+		}
+		off = int(parsed)
+	}
+
+	// Read current value
+	currentValue, err := cfg.Word(off)
+	if err != nil {
+		return fmt.Errorf("failed to read PCI register: %v", err)
+	}
+
+	// Modify the high nibble
+	// Clear the top 4 bits (0x0FFF mask)
+	newValue := currentValue & 0x0FFF
+	// Set the new high nibble
+	newValue |= uint16(newHighNibble) << 12
+
+	logrus.WithField("component", "Utils").Infof("Modifying PCIe Max Read Request for device %s at offset 0x%x: current value 0x%04X, new value 0x%04X", deviceAddr, off, currentValue, newValue)
+
+	// Write back the new value
+	if err := pci.WriteWord(deviceAddr, off, newValue); err != nil {
+		return fmt.Errorf("failed to write PCI register: %v", err)
+	}
+
+	// Verify the write was successful
+	verifiedValue, err := pci.ReadWord(deviceAddr, off)
+	if err != nil {
+		return fmt.Errorf("failed to verify write result: %v", err)
+	}
+
+	if verifiedValue != newValue {
+		return fmt.Errorf("write verification failed: expected 0x%04X, got 0x%04X", newValue, verifiedValue)
+	}
+	return nil
+}
@@ -16,45 +16,24 @@ limitations under the License.
 package utils
 
 import (
-	"fmt"
 	"os"
-	"strings"
 
+	"github.com/scitix/sichek/pkg/printer"
 	"golang.org/x/term"
 )
 
+// GetTerminalWidth returns 80% of the width of the terminal of stdout, it
+// fails when stdout is not a terminal.
 func GetTerminalWidth() (int, error) {
 	width, _, err := term.GetSize(int(os.Stdout.Fd()))
 	if err != nil {
-		fmt.Println("Error getting terminal size:", err)
 		return 0, err
 	}
 	return int(float64(width) * 0.8), nil
 }
 
+// PrintTitle prints text centered in a line of paddingChar through the
+// printer of the CLI.
 func PrintTitle(text, paddingChar string) {
-	width, err := GetTerminalWidth()
-	if err != nil {
-		return
-	}
-
-	textLength := len(text)
-	paddingLength := width - textLength
-	if paddingLength <= 0 {
-		fmt.Println(text)
-		return
-	}
-
-	charLen := len(paddingChar)
-	if charLen == 0 {
-		fmt.Println(text)
-		return
-	}
-
-	leftPadding := paddingLength / 2
-	rightPadding := paddingLength - leftPadding
-
-	left := strings.Repeat(paddingChar, leftPadding/charLen) + paddingChar[:leftPadding%charLen]
-	right := strings.Repeat(paddingChar, rightPadding/charLen) + paddingChar[:rightPadding%charLen]
-	fmt.Println(left + text + right)
+	printer.Title(text, paddingChar)
 }
@@ -0,0 +1,167 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// cuda_sanity checks that a GPU runs kernels: it copies two matrices to
+// every visible GPU, multiplies them and compares the product with the one
+// computed on the host. `sichek cudatest` runs it once per GPU and parses the
+// RESULT line it prints for each device:
+//
+//	RESULT device=0 bdf=0000:18:00.0 status=ok launch_ms=35.2 matmul_ms=1.3 h2d_GBps=11.8 d2h_GBps=12.4 max_err=0
+//	RESULT device=0 bdf=0000:18:00.0 status=fail stage=launch error=unspecified launch failure
+//
+// Build: nvcc -O2 -o cuda_sanity cuda_sanity.cu
+#include <cuda_runtime.h>
+
+#include <chrono>
+#include <cmath>
+#include <cstdio>
+#include <cstdlib>
+#include <cstring>
+#include <vector>
+
+__global__ void matmul(const float *a, const float *b, float *c, int n) {
+  int row = blockIdx.y * blockDim.y + threadIdx.y;
+  int col = blockIdx.x * blockDim.x + threadIdx.x;
+  if (row >= n || col >= n) {
+    return;
+  }
+  float sum = 0.0f;
+  for (int k = 0; k < n; k++) {
+    sum += a[row * n + k] * b[k * n + col];
+  }
+  c[row * n + col] = sum;
+}
+
+__global__ void noop() {}
+
+static double elapsedMs(std::chrono::steady_clock::time_point start) {
+  return std::chrono::duration<double, std::milli>(std::chrono::steady_clock::now() - start).count();
+}
+
+static void fail(int dev, const char *bdf, const char *stage, cudaError_t err) {
+  printf("RESULT device=%d bdf=%s status=fail stage=%s error=%s\n", dev, bdf, stage, cudaGetErrorString(err));
+  fflush(stdout);
+}
+
+static int testDevice(int dev, int n) {
+  char bdf[32] = "unknown";
+  cudaDeviceGetPCIBusId(bdf, sizeof(bdf), dev);
+  cudaError_t err = cudaSetDevice(dev);
+  if (err != cudaSuccess) {
+    fail(dev, bdf, "init", err);
+    return 1;
+  }
+
+  // The first launch creates the context and loads the module, the step a
+  // GPU that only enumerates fails at.
+  auto start = std::chrono::steady_clock::now();
+  noop<<<1, 1>>>();
+  if ((err = cudaGetLastError()) != cudaSuccess || (err = cudaDeviceSynchronize()) != cudaSuccess) {
+    fail(dev, bdf, "launch", err);
+    return 1;
+  }
+  double launchMs = elapsedMs(start);
+
+  size_t bytes = size_t(n) * n * sizeof(float);
+  std::vector<float> a(size_t(n) * n), b(size_t(n) * n), c(size_t(n) * n), want(size_t(n) * n);
+  srand(dev + 1);
+  for (size_t i = 0; i < a.size(); i++) {
+    // small integers keep the float sums exact
+    a[i] = float(rand() % 8);
+    b[i] = float(rand() % 8);
+  }
+  for (int i = 0; i < n; i++) {
+    for (int j = 0; j < n; j++) {
+      float sum = 0.0f;
+      for (int k = 0; k < n; k++) {
+        sum += a[size_t(i) * n + k] * b[size_t(k) * n + j];
+      }
+      want[size_t(i) * n + j] = sum;
+    }
+  }
+
+  float *da = nullptr, *db = nullptr, *dc = nullptr;
+  if ((err = cudaMalloc(&da, bytes)) != cudaSuccess || (err = cudaMalloc(&db, bytes)) != cudaSuccess ||
+      (err = cudaMalloc(&dc, bytes)) != cudaSuccess) {
+    fail(dev, bdf, "malloc", err);
+    return 1;
+  }
+  start = std::chrono::steady_clock::now();
+  if ((err = cudaMemcpy(da, a.data(), bytes, cudaMemcpyHostToDevice)) != cudaSuccess ||
+      (err = cudaMemcpy(db, b.data(), bytes, cudaMemcpyHostToDevice)) != cudaSuccess) {
+    fail(dev, bdf, "memcpy_h2d", err);
+    return 1;
+  }
+  double h2dMs = elapsedMs(start);
+
+  dim3 block(16, 16);
+  dim3 grid((n + block.x - 1) / block.x, (n + block.y - 1) / block.y);
+  start = std::chrono::steady_clock::now();
+  matmul<<<grid, block>>>(da, db, dc, n);
+  if ((err = cudaGetLastError()) != cudaSuccess || (err = cudaDeviceSynchronize()) != cudaSuccess) {
+    fail(dev, bdf, "matmul", err);
+    return 1;
+  }
+  double matmulMs = elapsedMs(start);
+
+  start = std::chrono::steady_clock::now();
+  if ((err = cudaMemcpy(c.data(), dc, bytes, cudaMemcpyDeviceToHost)) != cudaSuccess) {
+    fail(dev, bdf, "memcpy_d2h", err);
+    return 1;
+  }
+  double d2hMs = elapsedMs(start);
+  cudaFree(da);
+  cudaFree(db);
+  cudaFree(dc);
+
+  double maxErr = 0;
+  for (size_t i = 0; i < c.size(); i++) {
+    maxErr = fmax(maxErr, fabs(double(c[i]) - double(want[i])));
+  }
+  if (maxErr > 0) {
+    printf("RESULT device=%d bdf=%s status=fail stage=verify error=max error %g\n", dev, bdf, maxErr);
+    fflush(stdout);
+    return 1;
+  }
+  printf("RESULT device=%d bdf=%s status=ok launch_ms=%.1f matmul_ms=%.1f h2d_GBps=%.1f d2h_GBps=%.1f max_err=0\n", dev, bdf,
+         launchMs, matmulMs, 2 * bytes / h2dMs / 1e6, bytes / d2hMs / 1e6);
+  fflush(stdout);
+  return 0;
+}
+
+int main(int argc, char **argv) {
+  int n = 512;
+  for (int i = 1; i < argc; i++) {
+    if (strcmp(argv[i], "-n") == 0 && i + 1 < argc) {
+      n = atoi(argv[++i]);
+    }
+  }
+  if (n <= 0) {
+    fprintf(stderr, "usage: %s [-n matrix size]\n", argv[0]);
+    return 2;
+  }
+  int count = 0;
+  cudaError_t err = cudaGetDeviceCount(&count);
+  if (err != cudaSuccess) {
+    printf("RESULT device=-1 bdf=unknown status=fail stage=init error=%s\n", cudaGetErrorString(err));
+    return 1;
+  }
+  int failed = 0;
+  for (int dev = 0; dev < count; dev++) {
+    failed += testDevice(dev, n);
+  }
+  return failed > 0 ? 1 : 0;
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package service
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/scitix/sichek/components/common"
+	"gopkg.in/yaml.v3"
+)
+
+type adaptiveIntervalFile struct {
+	AdaptiveInterval common.AdaptiveIntervalConfig `json:"adaptive_interval" yaml:"adaptive_interval"`
+}
+
+// LoadAdaptiveIntervalConfig parses the adaptive_interval block from cfgFile.
+// If cfgFile is "" or missing, returns defaults.
+func LoadAdaptiveIntervalConfig(cfgFile string) (common.AdaptiveIntervalConfig, error) {
+	cfg := common.DefaultAdaptiveIntervalConfig()
+	if cfgFile == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(cfgFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return common.AdaptiveIntervalConfig{}, fmt.Errorf("load adaptive interval config: %w", err)
+	}
+	f := adaptiveIntervalFile{AdaptiveInterval: cfg}
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return common.AdaptiveIntervalConfig{}, fmt.Errorf("load adaptive interval config: %w", err)
+	}
+	return f.AdaptiveInterval, nil
+}
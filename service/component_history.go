@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package service
+
+import (
+	"sort"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+)
+
+// HistoryEntry is a result cached by a component, with the info it was
+// checked on when requested.
+type HistoryEntry struct {
+	Result *common.Result `json:"result"`
+	Info   any            `json:"info,omitempty"`
+}
+
+// CheckerFlap summarizes the statuses of a checker across the cached results.
+// A checker missing from a result is counted as normal, e.g. an event checker
+// only reported when it fires.
+type CheckerFlap struct {
+	Name         string    `json:"name"`
+	Samples      int       `json:"samples"`
+	Abnormal     int       `json:"abnormal"`
+	Transitions  int       `json:"transitions"`
+	LastAbnormal time.Time `json:"last_abnormal"`
+}
+
+// ComponentHistory is returned by GET /v1/components/{name}/history, the
+// cached results of a component sorted by time, oldest first, and the
+// checkers whose status changed within them.
+type ComponentHistory struct {
+	Component string         `json:"component"`
+	Node      string         `json:"node"`
+	Entries   []HistoryEntry `json:"entries"`
+	Flapping  []CheckerFlap  `json:"flapping"`
+}
+
+// NewComponentHistory reads the cache of a component and keeps its last n
+// results, all of them when n <= 0.
+func NewComponentHistory(component common.Component, node string, n int, withInfos bool) (*ComponentHistory, error) {
+	results, err := component.CacheResults()
+	if err != nil {
+		return nil, err
+	}
+	var infos []common.Info
+	if withInfos {
+		if infos, err = component.CacheInfos(); err != nil {
+			return nil, err
+		}
+	}
+	// the cache is a ring buffer, results and infos share their slot
+	entries := make([]HistoryEntry, 0, len(results))
+	for i, result := range results {
+		if result == nil {
+			continue
+		}
+		entry := HistoryEntry{Result: result}
+		if i < len(infos) && infos[i] != nil {
+			entry.Info = infos[i]
+		}
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Result.Time.Before(entries[j].Result.Time)
+	})
+	if n > 0 && len(entries) > n {
+		entries = entries[len(entries)-n:]
+	}
+	sorted := make([]*common.Result, len(entries))
+	for i, entry := range entries {
+		sorted[i] = entry.Result
+	}
+	return &ComponentHistory{
+		Component: component.Name(),
+		Node:      node,
+		Entries:   entries,
+		Flapping:  FlappingCheckers(sorted),
+	}, nil
+}
+
+// FlappingCheckers returns the checkers that were abnormal in some but not all
+// of results, sorted by their number of status changes, most first. results
+// are sorted by time.
+func FlappingCheckers(results []*common.Result) []CheckerFlap {
+	var names []string
+	seen := make(map[string]bool)
+	abnormal := make([]map[string]bool, len(results))
+	for i, result := range results {
+		abnormal[i] = make(map[string]bool)
+		for _, checker := range result.Checkers {
+			if checker == nil {
+				continue
+			}
+			if !seen[checker.Name] {
+				seen[checker.Name] = true
+				names = append(names, checker.Name)
+			}
+			abnormal[i][checker.Name] = abnormal[i][checker.Name] || checker.Status == consts.StatusAbnormal
+		}
+	}
+	var flaps []CheckerFlap
+	for _, name := range names {
+		flap := CheckerFlap{Name: name, Samples: len(results)}
+		for i, result := range results {
+			if abnormal[i][name] {
+				flap.Abnormal++
+				flap.LastAbnormal = result.Time
+			}
+			if i > 0 && abnormal[i][name] != abnormal[i-1][name] {
+				flap.Transitions++
+			}
+		}
+		if flap.Abnormal > 0 && flap.Abnormal < flap.Samples {
+			flaps = append(flaps, flap)
+		}
+	}
+	sort.SliceStable(flaps, func(i, j int) bool {
+		return flaps[i].Transitions > flaps[j].Transitions
+	})
+	return flaps
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+)
+
+// ringComponent caches its results in a ring buffer like the components do.
+type ringComponent struct {
+	fakeComponent
+	results []*common.Result
+	infos   []common.Info
+}
+
+func (r *ringComponent) CacheResults() ([]*common.Result, error) { return r.results, nil }
+func (r *ringComponent) CacheInfos() ([]common.Info, error)      { return r.infos, nil }
+
+type fakeInfo struct {
+	Seq int `json:"seq"`
+}
+
+func (i *fakeInfo) JSON() (string, error) { return "", nil }
+
+func historyResult(at time.Time, abnormal ...string) *common.Result {
+	result := &common.Result{Item: consts.ComponentNameCPU, Status: consts.StatusNormal, Level: consts.LevelInfo, Time: at}
+	for _, name := range []string{"cpu-performance", "cpu-lockup"} {
+		checker := &common.CheckerResult{Name: name, Status: consts.StatusNormal}
+		for _, a := range abnormal {
+			if a == name {
+				checker.Status = consts.StatusAbnormal
+				result.Status = consts.StatusAbnormal
+				result.Level = consts.LevelWarning
+			}
+		}
+		result.Checkers = append(result.Checkers, checker)
+	}
+	return result
+}
+
+func TestNewComponentHistory(t *testing.T) {
+	base := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	at := func(i int) time.Time { return base.Add(time.Duration(i) * time.Minute) }
+	// a ring of 6 slots, written 8 times: slot 2 holds the oldest result
+	cpu := &ringComponent{
+		fakeComponent: fakeComponent{name: consts.ComponentNameCPU},
+		results: []*common.Result{
+			historyResult(at(6), "cpu-performance"),
+			historyResult(at(7)),
+			historyResult(at(2), "cpu-lockup"),
+			historyResult(at(3)),
+			historyResult(at(4), "cpu-performance"),
+			historyResult(at(5)),
+		},
+		infos: []common.Info{&fakeInfo{6}, &fakeInfo{7}, &fakeInfo{2}, &fakeInfo{3}, &fakeInfo{4}, &fakeInfo{5}},
+	}
+
+	history, err := NewComponentHistory(cpu, "node-1", 4, true)
+	if err != nil {
+		t.Fatalf("NewComponentHistory: %v", err)
+	}
+	if len(history.Entries) != 4 {
+		t.Fatalf("expected 4 entries, got %d", len(history.Entries))
+	}
+	for i, entry := range history.Entries {
+		if !entry.Result.Time.Equal(at(i+4)) || entry.Info.(*fakeInfo).Seq != i+4 {
+			t.Errorf("entry %d: got result of %s and info %v", i, entry.Result.Time, entry.Info)
+		}
+	}
+	// cpu-lockup was abnormal before the last 4 results only
+	if len(history.Flapping) != 1 {
+		t.Fatalf("expected 1 flapping checker, got %+v", history.Flapping)
+	}
+	flap := history.Flapping[0]
+	if flap.Name != "cpu-performance" || flap.Abnormal != 2 || flap.Samples != 4 || flap.Transitions != 3 || !flap.LastAbnormal.Equal(at(6)) {
+		t.Errorf("unexpected flap %+v", flap)
+	}
+
+	// a component that has not cached every slot yet
+	cpu.results = []*common.Result{historyResult(at(0)), nil, nil}
+	history, err = NewComponentHistory(cpu, "node-1", 0, false)
+	if err != nil {
+		t.Fatalf("NewComponentHistory: %v", err)
+	}
+	if len(history.Entries) != 1 || history.Entries[0].Info != nil || len(history.Flapping) != 0 {
+		t.Errorf("unexpected history %+v", history)
+	}
+}
+
+func TestFlappingCheckersEventOnly(t *testing.T) {
+	base := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	event := &common.CheckerResult{Name: "xid-event", Status: consts.StatusAbnormal}
+	results := []*common.Result{
+		{Time: base},
+		{Time: base.Add(time.Minute), Checkers: []*common.CheckerResult{event}},
+		{Time: base.Add(2 * time.Minute)},
+	}
+	flaps := FlappingCheckers(results)
+	if len(flaps) != 1 || flaps[0].Name != "xid-event" || flaps[0].Transitions != 2 {
+		t.Errorf("unexpected flaps %+v", flaps)
+	}
+}
+
+func TestHTTPServer_History(t *testing.T) {
+	now := time.Now()
+	cpu := &ringComponent{
+		fakeComponent: fakeComponent{name: consts.ComponentNameCPU},
+		results:       []*common.Result{historyResult(now.Add(-time.Minute), "cpu-lockup"), historyResult(now)},
+	}
+	srv := NewHTTPServer(defaultAPIServerConfig(), map[string]common.Component{cpu.name: cpu}, "node-1")
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/v1/components/cpu/history?n=5")
+	if err != nil {
+		t.Fatalf("GET history: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("history: status=%d", resp.StatusCode)
+	}
+	var history ComponentHistory
+	if err := json.NewDecoder(resp.Body).Decode(&history); err != nil {
+		t.Fatalf("decode history: %v", err)
+	}
+	if history.Node != "node-1" || len(history.Entries) != 2 || len(history.Flapping) != 1 || history.Flapping[0].Name != "cpu-lockup" {
+		t.Errorf("unexpected history %+v", history)
+	}
+
+	for _, path := range []string{"/v1/components/cpu/history?n=x", "/v1/components/gpu/history"} {
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			t.Errorf("GET %s: expected an error", path)
+		}
+	}
+}
@@ -17,14 +17,24 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/components/common/remediator"
 	"github.com/scitix/sichek/consts"
 	"github.com/scitix/sichek/metrics"
+	"github.com/scitix/sichek/pkg/alert"
+	"github.com/scitix/sichek/pkg/history"
+	"github.com/scitix/sichek/pkg/k8s"
+	"github.com/scitix/sichek/pkg/npd"
+	resultreporter "github.com/scitix/sichek/pkg/reporter"
+	"github.com/scitix/sichek/pkg/silence"
+	"github.com/scitix/sichek/pkg/telemetry"
 
 	"github.com/sirupsen/logrus"
 )
@@ -49,9 +59,20 @@ type DaemonService struct {
 	notifier             Notifier
 	snapshotMgr          *SnapshotManager
 	reporter             *Reporter
+	resultReporter       *resultreporter.Reporter
+	alerts               *alert.Notifier
+	history              history.Store
+	nodeHealth           *k8s.NodeHealthController
+	nodeStatus           *k8s.NodeStatusPublisher
+	npdExporter          *npd.Exporter
+	apiServer            *HTTPServer
+	grpcServer           *GRPCServer
+	specWatcher          *SpecWatcher
+	silences             *silence.Store
+	otelShutdown         func(context.Context) error
 }
 
-func NewService(components map[string]common.Component, annoKey string, cfgFile string, metricsPort int, metricsSocket string) (s Service, err error) {
+func NewService(components map[string]common.Component, annoKey string, cfgFile string, specName string, specFile string, metricsPort int, metricsSocket string) (s Service, err error) {
 	go metrics.InitPrometheus(cfgFile, metricsPort, metricsSocket)
 	ctx, cancel := context.WithCancel(context.Background())
 	defer func() {
@@ -60,6 +81,7 @@ func NewService(components map[string]common.Component, annoKey string, cfgFile
 			cancel()
 		}
 	}()
+	go metrics.StartTextfileExporter(ctx, cfgFile)
 	notifier, err := NewNotifier(annoKey)
 	if err != nil {
 		logrus.WithField("daemon", "new").Warnf("create notifier failed (non-K8s environment?): %v, continuing without K8s annotation support", err)
@@ -89,6 +111,117 @@ func NewService(components map[string]common.Component, annoKey string, cfgFile
 		reporter = NewReporter(reporterCfg, snapPath, ResolveNodeName())
 	}
 
+	// Result reporter: push abnormal results and heartbeats when SICHEK_REPORT_URL is set.
+	resultReporter := resultreporter.New(resultreporter.ConfigFromEnv(), ResolveNodeName())
+
+	// Alerts: notify the webhooks of the alerting integrations on critical results.
+	alerts := alert.New(alert.LoadConfig(cfgFile), ResolveNodeName())
+
+	// NPD: export the results as node problem detector conditions and events.
+	npdExporter := npd.New(npd.LoadConfig(cfgFile))
+
+	// History: persist results on local storage to review past failures after a reboot.
+	var historyStore history.Store
+	historyCfg := history.LoadConfig(cfgFile)
+	if historyCfg.History.Enable {
+		historyStore, err = history.NewJSONLStore(historyCfg.History.Path, historyCfg.History.Retention)
+		if err != nil {
+			logrus.WithField("daemon", "new").Errorf("create history store failed: %v", err)
+			historyStore = nil
+		}
+	}
+
+	// Node health: reflect the results on the Node conditions and taint.
+	var nodeHealth *k8s.NodeHealthController
+	nodeHealthCfg := k8s.LoadNodeHealthConfig(cfgFile)
+	if nodeHealthCfg.NodeHealth.Enable {
+		nodeHealth, err = k8s.NewNodeHealthController(nodeHealthCfg)
+		if err != nil {
+			logrus.WithField("daemon", "new").Warnf("create node health controller failed (non-K8s environment?): %v", err)
+			nodeHealth = nil
+		}
+	}
+	var nodeStatus *k8s.NodeStatusPublisher
+	if nodeHealthCfg.NodeHealth.Status.Enable {
+		nodeStatus, err = k8s.NewNodeStatusPublisher(nodeHealthCfg)
+		if err != nil {
+			logrus.WithField("daemon", "new").Warnf("create node status publisher failed (non-K8s environment?): %v", err)
+			nodeStatus = nil
+		}
+	}
+
+	// API server: on-demand health checks and result queries over HTTP.
+	apiServerCfg, err := LoadAPIServerConfig(cfgFile)
+	if err != nil {
+		logrus.WithField("daemon", "new").Warnf("load api server config failed: %v", err)
+		apiServerCfg = defaultAPIServerConfig()
+	}
+	var apiServer *HTTPServer
+	if apiServerCfg.Enable {
+		apiServer = NewHTTPServer(apiServerCfg, components, hostname)
+	}
+
+	// Adaptive intervals: check the healthy components less often, the failing ones more often.
+	adaptiveCfg, err := LoadAdaptiveIntervalConfig(cfgFile)
+	if err != nil {
+		logrus.WithField("daemon", "new").Warnf("load adaptive interval config failed: %v", err)
+		adaptiveCfg = common.DefaultAdaptiveIntervalConfig()
+	}
+	common.GetFreqController().SetAdaptive(adaptiveCfg)
+
+	// Duration budgets: warn about the checkers repeatedly running longer than their budget.
+	budgetCfg, err := LoadDurationBudgetConfig(cfgFile)
+	if err != nil {
+		logrus.WithField("daemon", "new").Warnf("load duration budget config failed: %v", err)
+		budgetCfg = common.DefaultDurationBudgetConfig()
+	}
+	common.GetDurationTracker().SetConfig(budgetCfg)
+
+	// gRPC server: the same queries plus a stream of the results for node agents.
+	grpcServerCfg, err := LoadGRPCServerConfig(cfgFile)
+	if err != nil {
+		logrus.WithField("daemon", "new").Warnf("load grpc server config failed: %v", err)
+		grpcServerCfg = defaultGRPCServerConfig()
+	}
+	var grpcServer *GRPCServer
+	if grpcServerCfg.Enable {
+		grpcServer = NewGRPCServer(grpcServerCfg, components, hostname)
+	}
+
+	// Telemetry: export OpenTelemetry traces and metrics of the health checks over OTLP.
+	var telemetryShutdown func(context.Context) error
+	telemetryCfg := telemetry.LoadConfig(cfgFile)
+	if telemetryCfg.Telemetry.Enable {
+		telemetryShutdown, err = telemetry.Setup(ctx, telemetryCfg, hostname)
+		if err != nil {
+			logrus.WithField("daemon", "new").Errorf("setup telemetry failed: %v", err)
+			telemetryShutdown = nil
+		}
+	}
+
+	// Spec reload: apply a changed local or remote spec without a restart.
+	specReloadCfg, err := LoadSpecReloadConfig(cfgFile)
+	if err != nil {
+		logrus.WithField("daemon", "new").Warnf("load spec reload config failed: %v", err)
+		specReloadCfg = defaultSpecReloadConfig()
+	}
+	var specWatcher *SpecWatcher
+	if specReloadCfg.Enable && specFile != "" {
+		specWatcher = NewSpecWatcher(specReloadCfg, specFile, common.SpecSourceURL(specName, consts.DefaultSpecCfgName), components)
+	}
+
+	// Remediation: the config enables it for the daemon, the --auto-fix and
+	// --dry-run flags of the command line still apply.
+	remediationCfg, err := remediator.LoadConfig(cfgFile)
+	if err != nil {
+		logrus.WithField("daemon", "new").Warnf("load remediation config failed: %v", err)
+	} else {
+		flags := remediator.Default().Config()
+		remediationCfg.Enable = remediationCfg.Enable || flags.Enable
+		remediationCfg.DryRun = remediationCfg.DryRun || flags.DryRun
+		remediator.SetDefault(remediator.New(remediationCfg))
+	}
+
 	daemonService := &DaemonService{
 		ctx:              ctx,
 		cancel:           cancel,
@@ -100,6 +233,17 @@ func NewService(components map[string]common.Component, annoKey string, cfgFile
 		node:             hostname,
 		snapshotMgr:      snapshotMgr,
 		reporter:         reporter,
+		resultReporter:   resultReporter,
+		alerts:           alerts,
+		history:          historyStore,
+		nodeHealth:       nodeHealth,
+		nodeStatus:       nodeStatus,
+		npdExporter:      npdExporter,
+		apiServer:        apiServer,
+		grpcServer:       grpcServer,
+		specWatcher:      specWatcher,
+		silences:         silence.NewStore(silence.LoadPath(cfgFile)),
+		otelShutdown:     telemetryShutdown,
 	}
 
 	return daemonService, nil
@@ -117,6 +261,21 @@ func (d *DaemonService) Run() {
 	if d.reporter != nil {
 		go d.reporter.Run(d.ctx)
 	}
+	if d.resultReporter != nil {
+		go d.resultReporter.Run(d.ctx)
+	}
+	if d.alerts != nil {
+		go d.alerts.Run(d.ctx)
+	}
+	if d.apiServer != nil {
+		go d.apiServer.Run(d.ctx)
+	}
+	if d.grpcServer != nil {
+		go d.grpcServer.Run(d.ctx)
+	}
+	if d.specWatcher != nil {
+		go d.specWatcher.Run(d.ctx)
+	}
 
 	for componentName, resultChan := range d.componentResults {
 		go d.monitorComponent(componentName, resultChan)
@@ -134,53 +293,97 @@ func (d *DaemonService) monitorComponent(componentName string, resultChan <-chan
 	d.componentsStatusLock.Unlock()
 	for {
 		logrus.WithField("daemon", "run").Infof("start to listen component %s result channel", componentName)
-		select {
-		case <-d.ctx.Done():
-			logrus.WithField("daemon", "run").Warnf("component %s stop listen as d.ctx.Done()", componentName)
-			return
-		case result, ok := <-resultChan:
-			if !ok {
-				logrus.WithField("daemon", "run").Infof("component %s result channel has closed", componentName)
-				return
+		// the results queued while the previous batch was handled are handled
+		// together, the snapshot is only refreshed once per batch
+		batch, ok := common.ReceiveBatch(d.ctx, resultChan, common.DefaultResultQueueSize)
+		if !ok {
+			if d.ctx.Err() != nil {
+				logrus.WithField("daemon", "run").Warnf("component %s stop listen as d.ctx.Done()", componentName)
 			} else {
-				logrus.WithField("daemon", "run").Infof("Get component %s result", componentName)
+				logrus.WithField("daemon", "run").Infof("component %s result channel has closed", componentName)
 			}
-			var err error
+			return
+		}
+		logrus.WithField("daemon", "run").Infof("Get %d results of component %s", len(batch), componentName)
+		for _, result := range batch {
 			if result != nil {
-				result.Node = d.node
-				if d.notifier != nil {
-					if len(result.Checkers) > 0 && strings.Contains(result.Checkers[0].Name, "HealthCheckTimeout") && result.Status == consts.StatusAbnormal {
-						err = d.notifier.AppendNodeAnnotation(d.ctx, result)
-					} else {
-						err = d.notifier.SetNodeAnnotation(d.ctx, result)
-					}
-				}
-				d.metrics.ExportMetrics(result)
+				d.handleResult(componentName, result)
 			}
+		}
+		d.metrics.ExportDroppedResults(common.DroppedResults())
 
-			if d.snapshotMgr != nil {
-				info, err := d.components[componentName].LastInfo()
-				if err != nil {
+		if d.snapshotMgr != nil {
+			info, err := d.components[componentName].LastInfo()
+			if err != nil {
+				logrus.WithFields(logrus.Fields{
+					"daemon":    "run",
+					"component": componentName,
+				}).Errorf("LastInfo failed: %v", err)
+			} else {
+				if info == nil {
 					logrus.WithFields(logrus.Fields{
 						"daemon":    "run",
 						"component": componentName,
-					}).Errorf("LastInfo failed: %v", err)
-				} else {
-					if info == nil {
-						logrus.WithFields(logrus.Fields{
-							"daemon":    "run",
-							"component": componentName,
-						}).Warnf("LastInfo returned nil")
-					}
-					d.snapshotMgr.Update(componentName, info)
+					}).Warnf("LastInfo returned nil")
 				}
+				d.snapshotMgr.Update(componentName, info)
 			}
+		}
+	}
+}
 
-			if err != nil {
-				logrus.WithField("daemon", "run").Errorf("set node annotation failed: %v", err)
-			}
+// handleResult remediates, annotates, exports, reports and records a result
+// of a component.
+func (d *DaemonService) handleResult(componentName string, result *common.Result) {
+	var err error
+	// the remediation is recorded on a copy, the component keeps
+	// serving the result it found
+	result, _ = remediator.Default().Remediate(d.ctx, result)
+	// silenced checkers no longer fail the node, they are still exported and recorded
+	result = d.silences.Apply(result)
+	result.Node = d.node
+	common.GetFreqController().Observe(componentName, result)
+	if d.notifier != nil {
+		if len(result.Checkers) > 0 && strings.Contains(result.Checkers[0].Name, "HealthCheckTimeout") && result.Status == consts.StatusAbnormal {
+			err = d.notifier.AppendNodeAnnotation(d.ctx, result)
+		} else {
+			err = d.notifier.SetNodeAnnotation(d.ctx, result)
 		}
 	}
+	d.metrics.ExportMetrics(result)
+	d.resultReporter.Report(result)
+	d.alerts.Notify(result)
+	d.npdExporter.Export(result)
+	d.recordHistory(componentName, result)
+	if d.grpcServer != nil {
+		d.grpcServer.Publish(result)
+	}
+	if d.nodeHealth != nil {
+		if nodeErr := d.nodeHealth.Update(d.ctx, componentName, result); nodeErr != nil {
+			logrus.WithField("daemon", "run").Errorf("update node health of %s failed: %v", componentName, nodeErr)
+		}
+	}
+	if d.nodeStatus != nil {
+		if statusErr := d.nodeStatus.Update(d.ctx, componentName, result); statusErr != nil {
+			logrus.WithField("daemon", "run").Errorf("publish node status of %s failed: %v", componentName, statusErr)
+		}
+	}
+	if err != nil {
+		logrus.WithField("daemon", "run").Errorf("set node annotation failed: %v", err)
+	}
+}
+
+func (d *DaemonService) recordHistory(componentName string, result *common.Result) {
+	if d.history == nil {
+		return
+	}
+	var info common.Info
+	if result.Status == consts.StatusAbnormal {
+		info, _ = d.components[componentName].LastInfo()
+	}
+	if err := d.history.Append(history.NewRecord(componentName, result, info)); err != nil {
+		logrus.WithField("daemon", "run").Errorf("append %s result to history failed: %v", componentName, err)
+	}
 }
 
 func (d *DaemonService) Status() (interface{}, error) {
@@ -191,16 +394,52 @@ func (d *DaemonService) Metrics(ctx context.Context, since time.Time) (interface
 	return nil, nil
 }
 
+// Stop stops all the components and waits for them to finish, at most
+// consts.DaemonStopTimeout, so that a systemd stop does not cut a health
+// check or a history write in the middle.
 func (d *DaemonService) Stop() error {
-	var err error
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
 	for _, component := range d.components {
+		wg.Add(1)
 		go func() {
-			err = component.Stop()
-			if err != nil {
+			defer wg.Done()
+			if err := component.Stop(); err != nil {
 				logrus.WithField("daemon", "stop").Errorf("component %s stop failed: %v", component.Name(), err)
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("stop %s: %w", component.Name(), err))
+				mu.Unlock()
 			}
 		}()
 	}
+	stopped := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(consts.DaemonStopTimeout):
+		logrus.WithField("daemon", "stop").Warnf("components did not stop within %s", consts.DaemonStopTimeout)
+	}
 	d.cancel()
-	return err
+	if d.otelShutdown != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if shutdownErr := d.otelShutdown(ctx); shutdownErr != nil {
+			logrus.WithField("daemon", "stop").Errorf("flush telemetry failed: %v", shutdownErr)
+		}
+		cancel()
+	}
+	if d.history != nil {
+		if closeErr := d.history.Close(); closeErr != nil {
+			logrus.WithField("daemon", "stop").Errorf("close history store failed: %v", closeErr)
+		}
+	}
+	d.npdExporter.Close()
+	mu.Lock()
+	defer mu.Unlock()
+	return errors.Join(errs...)
 }
@@ -0,0 +1,49 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package service
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/scitix/sichek/components/common"
+	"sigs.k8s.io/yaml"
+)
+
+type durationBudgetFile struct {
+	DurationBudgets common.DurationBudgetConfig `json:"duration_budgets" yaml:"duration_budgets"`
+}
+
+// LoadDurationBudgetConfig parses the duration_budgets block from cfgFile.
+// If cfgFile is "" or missing, returns defaults.
+func LoadDurationBudgetConfig(cfgFile string) (common.DurationBudgetConfig, error) {
+	cfg := common.DefaultDurationBudgetConfig()
+	if cfgFile == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(cfgFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return common.DurationBudgetConfig{}, fmt.Errorf("load duration budget config: %w", err)
+	}
+	f := durationBudgetFile{DurationBudgets: cfg}
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return common.DurationBudgetConfig{}, fmt.Errorf("load duration budget config: %w", err)
+	}
+	return f.DurationBudgets, nil
+}
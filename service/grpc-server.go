@@ -0,0 +1,247 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	apiv1 "github.com/scitix/sichek/api/v1"
+	"github.com/scitix/sichek/components/common"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gopkg.in/yaml.v3"
+)
+
+// watchBufferSize bounds the results queued for a slow watcher, the results
+// beyond it are dropped for that watcher rather than blocking the daemon.
+const watchBufferSize = 64
+
+// GRPCServerConfig controls the daemon gRPC API.
+type GRPCServerConfig struct {
+	Enable bool `json:"enable" yaml:"enable"`
+	// Addr is a host:port or a unix:///path socket.
+	Addr string `json:"addr" yaml:"addr"`
+}
+
+type grpcServerFile struct {
+	GRPCServer GRPCServerConfig `json:"grpc_server" yaml:"grpc_server"`
+}
+
+func defaultGRPCServerConfig() GRPCServerConfig {
+	return GRPCServerConfig{
+		Enable: false,
+		Addr:   "unix:///var/run/sichek/grpc.sock",
+	}
+}
+
+// LoadGRPCServerConfig parses the grpc_server block from cfgFile.
+// If cfgFile is "" or missing, returns defaults.
+func LoadGRPCServerConfig(cfgFile string) (GRPCServerConfig, error) {
+	cfg := defaultGRPCServerConfig()
+	if cfgFile == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(cfgFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return GRPCServerConfig{}, fmt.Errorf("load grpc server config: %w", err)
+	}
+	f := grpcServerFile{GRPCServer: cfg}
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return GRPCServerConfig{}, fmt.Errorf("load grpc server config: %w", err)
+	}
+	if f.GRPCServer.Addr == "" {
+		f.GRPCServer.Addr = cfg.Addr
+	}
+	return f.GRPCServer, nil
+}
+
+// GRPCServer exposes the components of the daemon over the sichek.v1.Sichek
+// gRPC service, WatchResults streams the results the daemon receives from the
+// components so that node agents do not have to poll.
+type GRPCServer struct {
+	apiv1.UnimplementedSichekServer
+
+	cfg        GRPCServerConfig
+	components map[string]common.Component
+	node       string
+	server     *grpc.Server
+
+	mu       sync.Mutex
+	watchers map[chan *apiv1.Result]struct{}
+}
+
+// NewGRPCServer constructs a GRPCServer. Call Run(ctx) to start serving.
+func NewGRPCServer(cfg GRPCServerConfig, components map[string]common.Component, node string) *GRPCServer {
+	s := &GRPCServer{
+		cfg:        cfg,
+		components: components,
+		node:       node,
+		server:     grpc.NewServer(),
+		watchers:   make(map[chan *apiv1.Result]struct{}),
+	}
+	apiv1.RegisterSichekServer(s.server, s)
+	return s
+}
+
+// Run serves the API until ctx is canceled.
+func (s *GRPCServer) Run(ctx context.Context) {
+	listener, err := listen(s.cfg.Addr)
+	if err != nil {
+		logrus.WithField("service", "grpc-server").Errorf("grpc server listen failed: %v", err)
+		return
+	}
+	go func() {
+		<-ctx.Done()
+		s.server.GracefulStop()
+	}()
+	logrus.WithField("service", "grpc-server").Infof("grpc server listening on %s", s.cfg.Addr)
+	if err := s.server.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		logrus.WithField("service", "grpc-server").Errorf("grpc server stopped: %v", err)
+	}
+}
+
+// listen listens on a host:port or a unix:///path socket.
+func listen(addr string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, "unix://")
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	// a socket left by a previous daemon that did not exit cleanly
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return net.Listen("unix", path)
+}
+
+// Publish sends the result to the watchers, it never blocks the caller.
+func (s *GRPCServer) Publish(result *common.Result) {
+	msg := resultToProto(result)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for watcher := range s.watchers {
+		select {
+		case watcher <- msg:
+		default:
+			logrus.WithField("service", "grpc-server").Warnf("watcher is too slow, drop %s result", result.Item)
+		}
+	}
+}
+
+func (s *GRPCServer) ListComponents(ctx context.Context, req *apiv1.ListComponentsRequest) (*apiv1.ListComponentsResponse, error) {
+	resp := &apiv1.ListComponentsResponse{}
+	for name, component := range s.components {
+		resp.Components = append(resp.Components, &apiv1.Component{Name: name, Running: component.Status()})
+	}
+	sort.Slice(resp.Components, func(i, j int) bool {
+		return resp.Components[i].Name < resp.Components[j].Name
+	})
+	return resp, nil
+}
+
+func (s *GRPCServer) GetLastResult(ctx context.Context, req *apiv1.GetLastResultRequest) (*apiv1.Result, error) {
+	component, ok := s.components[req.GetComponent()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "component %s not found", req.GetComponent())
+	}
+	result, err := component.LastResult()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if result == nil {
+		return nil, status.Errorf(codes.NotFound, "component %s has no result yet", req.GetComponent())
+	}
+	msg := resultToProto(result)
+	msg.Node = s.node
+	return msg, nil
+}
+
+func (s *GRPCServer) WatchResults(req *apiv1.WatchResultsRequest, stream grpc.ServerStreamingServer[apiv1.Result]) error {
+	for _, name := range req.GetComponents() {
+		if _, ok := s.components[name]; !ok {
+			return status.Errorf(codes.NotFound, "component %s not found", name)
+		}
+	}
+	watcher := make(chan *apiv1.Result, watchBufferSize)
+	s.mu.Lock()
+	s.watchers[watcher] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.watchers, watcher)
+		s.mu.Unlock()
+	}()
+
+	filter := make(map[string]bool, len(req.GetComponents()))
+	for _, name := range req.GetComponents() {
+		filter[name] = true
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case msg := <-watcher:
+			if len(filter) > 0 && !filter[msg.GetItem()] {
+				continue
+			}
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func resultToProto(result *common.Result) *apiv1.Result {
+	msg := &apiv1.Result{
+		Item:   result.Item,
+		Node:   result.Node,
+		Status: result.Status,
+		Level:  result.Level,
+		Time:   timestamppb.New(result.Time),
+	}
+	for _, checker := range result.Checkers {
+		msg.Checkers = append(msg.Checkers, &apiv1.CheckerResult{
+			Name:        checker.Name,
+			Description: checker.Description,
+			Device:      checker.Device,
+			Spec:        checker.Spec,
+			Curr:        checker.Curr,
+			Status:      checker.Status,
+			Level:       checker.Level,
+			Suggestion:  checker.Suggestion,
+			Detail:      checker.Detail,
+			ErrorName:   checker.ErrorName,
+			SilencedBy:  checker.SilencedBy,
+		})
+	}
+	return msg
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package service
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	apiv1 "github.com/scitix/sichek/api/v1"
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newTestGRPCClient(t *testing.T, s *GRPCServer) apiv1.SichekClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	go func() { _ = s.server.Serve(listener) }()
+	t.Cleanup(s.server.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial grpc server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return apiv1.NewSichekClient(conn)
+}
+
+func TestGRPCServerQueries(t *testing.T) {
+	nvidia := &fakeComponent{name: "nvidia"}
+	components := map[string]common.Component{"nvidia": nvidia, "cpu": &fakeComponent{name: "cpu"}}
+	client := newTestGRPCClient(t, NewGRPCServer(defaultGRPCServerConfig(), components, "node-1"))
+	ctx := context.Background()
+
+	list, err := client.ListComponents(ctx, &apiv1.ListComponentsRequest{})
+	if err != nil {
+		t.Fatalf("ListComponents: %v", err)
+	}
+	if len(list.Components) != 2 || list.Components[0].Name != "cpu" {
+		t.Errorf("expected the sorted components, got %v", list.Components)
+	}
+
+	if _, err := client.GetLastResult(ctx, &apiv1.GetLastResultRequest{Component: "nvidia"}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound before the first check, got %v", err)
+	}
+	if _, err := nvidia.HealthCheck(ctx); err != nil {
+		t.Fatal(err)
+	}
+	result, err := client.GetLastResult(ctx, &apiv1.GetLastResultRequest{Component: "nvidia"})
+	if err != nil {
+		t.Fatalf("GetLastResult: %v", err)
+	}
+	if result.Status != consts.StatusAbnormal || result.Node != "node-1" {
+		t.Errorf("unexpected result %v", result)
+	}
+	if _, err := client.GetLastResult(ctx, &apiv1.GetLastResultRequest{Component: "gpfs"}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for an unknown component, got %v", err)
+	}
+}
+
+func TestGRPCServerWatchResults(t *testing.T) {
+	components := map[string]common.Component{"nvidia": &fakeComponent{name: "nvidia"}, "cpu": &fakeComponent{name: "cpu"}}
+	s := NewGRPCServer(defaultGRPCServerConfig(), components, "node-1")
+	client := newTestGRPCClient(t, s)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.WatchResults(ctx, &apiv1.WatchResultsRequest{Components: []string{"nvidia"}})
+	if err != nil {
+		t.Fatalf("WatchResults: %v", err)
+	}
+	// the watcher is registered once the stream reached the handler
+	for {
+		s.mu.Lock()
+		watchers := len(s.watchers)
+		s.mu.Unlock()
+		if watchers == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	s.Publish(&common.Result{Item: "cpu", Status: consts.StatusNormal})
+	s.Publish(&common.Result{Item: "nvidia", Status: consts.StatusAbnormal, Checkers: []*common.CheckerResult{{Name: "ecc-trend"}}})
+
+	result, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+	if result.Item != "nvidia" || len(result.Checkers) != 1 || result.Checkers[0].Name != "ecc-trend" {
+		t.Errorf("expected the nvidia result only, got %v", result)
+	}
+}
@@ -14,3 +14,287 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// APIServerConfig controls the daemon HTTP API.
+type APIServerConfig struct {
+	Enable bool `json:"enable" yaml:"enable"`
+	// Addr is a host:port or a unix:///path socket.
+	Addr string `json:"addr" yaml:"addr"`
+}
+
+type apiServerFile struct {
+	APIServer APIServerConfig `json:"api_server" yaml:"api_server"`
+}
+
+func defaultAPIServerConfig() APIServerConfig {
+	return APIServerConfig{
+		Enable: false,
+		Addr:   "127.0.0.1:19092",
+	}
+}
+
+// LoadAPIServerConfig parses the api_server block from cfgFile.
+// If cfgFile is "" or missing, returns defaults.
+func LoadAPIServerConfig(cfgFile string) (APIServerConfig, error) {
+	cfg := defaultAPIServerConfig()
+	if cfgFile == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(cfgFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return APIServerConfig{}, fmt.Errorf("load api server config: %w", err)
+	}
+	f := apiServerFile{APIServer: cfg}
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return APIServerConfig{}, fmt.Errorf("load api server config: %w", err)
+	}
+	if f.APIServer.Addr == "" {
+		f.APIServer.Addr = cfg.Addr
+	}
+	return f.APIServer, nil
+}
+
+// ComponentStatus is the item returned by GET /v1/components.
+type ComponentStatus struct {
+	Name    string `json:"name"`
+	Running bool   `json:"running"`
+}
+
+// HTTPServer exposes the components of the daemon over a small REST API:
+//
+//	GET  /v1/components                   list the components and their running status
+//	GET  /v1/components/{name}/last-result the last cached result of a component
+//	GET  /v1/components/{name}/history    the cached results and flapping checkers, e.g. ?n=10&infos=true
+//	POST /v1/components/{name}/check      run an immediate HealthCheck
+//	GET  /v1/components/{name}/interval   the query interval of a component
+//	PUT  /v1/components/{name}/interval   change the query interval, e.g. {"interval": "10s"}
+//	GET  /v1/summary                      the aggregated last results of all components
+type HTTPServer struct {
+	cfg        APIServerConfig
+	components map[string]common.Component
+	node       string
+	server     *http.Server
+}
+
+// NewHTTPServer constructs an HTTPServer. Call Run(ctx) to start serving.
+func NewHTTPServer(cfg APIServerConfig, components map[string]common.Component, node string) *HTTPServer {
+	s := &HTTPServer{
+		cfg:        cfg,
+		components: components,
+		node:       node,
+	}
+	s.server = &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// Handler returns the router of the API, exposed for tests.
+func (s *HTTPServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/components", s.handleListComponents)
+	mux.HandleFunc("GET /v1/components/{name}/last-result", s.handleLastResult)
+	mux.HandleFunc("GET /v1/components/{name}/history", s.handleHistory)
+	mux.HandleFunc("POST /v1/components/{name}/check", s.handleCheck)
+	mux.HandleFunc("GET /v1/components/{name}/interval", s.handleGetInterval)
+	mux.HandleFunc("PUT /v1/components/{name}/interval", s.handleSetInterval)
+	mux.HandleFunc("GET /v1/summary", s.handleSummary)
+	return mux
+}
+
+// Run serves the API until ctx is canceled.
+func (s *HTTPServer) Run(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.server.Shutdown(shutdownCtx); err != nil {
+			logrus.WithField("service", "api-server").Errorf("shutdown api server failed: %v", err)
+		}
+	}()
+	listener, err := listen(s.cfg.Addr)
+	if err != nil {
+		logrus.WithField("service", "api-server").Errorf("api server listen failed: %v", err)
+		return
+	}
+	logrus.WithField("service", "api-server").Infof("api server listening on %s", s.cfg.Addr)
+	if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logrus.WithField("service", "api-server").Errorf("api server stopped: %v", err)
+	}
+}
+
+func (s *HTTPServer) handleListComponents(w http.ResponseWriter, r *http.Request) {
+	statuses := make([]ComponentStatus, 0, len(s.components))
+	for name, component := range s.components {
+		statuses = append(statuses, ComponentStatus{Name: name, Running: component.Status()})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	writeJSON(w, http.StatusOK, statuses)
+}
+
+func (s *HTTPServer) handleLastResult(w http.ResponseWriter, r *http.Request) {
+	component, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	result, err := component.LastResult()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if result == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("component %s has no result yet", component.Name()))
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *HTTPServer) handleHistory(w http.ResponseWriter, r *http.Request) {
+	component, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	n := 0
+	if v := r.URL.Query().Get("n"); v != "" {
+		var err error
+		if n, err = strconv.Atoi(v); err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid n %q", v))
+			return
+		}
+	}
+	withInfos, _ := strconv.ParseBool(r.URL.Query().Get("infos"))
+	history, err := NewComponentHistory(component, s.node, n, withInfos)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, history)
+}
+
+func (s *HTTPServer) handleCheck(w http.ResponseWriter, r *http.Request) {
+	component, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	result, err := common.RunHealthCheckWithTimeout(r.Context(), component.GetTimeout(), component.Name(), component.HealthCheck)
+	if result != nil {
+		result.Node = s.node
+	}
+	switch {
+	case err != nil:
+		// the partial result of a failed check is returned next to the error
+		writeJSON(w, http.StatusInternalServerError, CheckErrorResponse{Error: err.Error(), Result: result})
+	case result == nil:
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("component %s returned no result", component.Name()))
+	default:
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// CheckErrorResponse is the body of a failed POST /v1/components/{name}/check.
+type CheckErrorResponse struct {
+	Error  string         `json:"error"`
+	Result *common.Result `json:"result,omitempty"`
+}
+
+// SetIntervalRequest is the body of PUT /v1/components/{name}/interval.
+type SetIntervalRequest struct {
+	Interval string `json:"interval"`
+}
+
+func (s *HTTPServer) handleGetInterval(w http.ResponseWriter, r *http.Request) {
+	component, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	interval, ok := common.GetFreqController().ModuleInterval(component.Name())
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("component %s has no query interval", component.Name()))
+		return
+	}
+	writeJSON(w, http.StatusOK, interval)
+}
+
+func (s *HTTPServer) handleSetInterval(w http.ResponseWriter, r *http.Request) {
+	component, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	var req SetIntervalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+	interval, err := time.ParseDuration(req.Interval)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid interval %q: %w", req.Interval, err))
+		return
+	}
+	fc := common.GetFreqController()
+	if err := fc.SetModuleBaseInterval(component.Name(), interval); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	logrus.WithField("service", "api-server").Infof("query interval of %s set to %s", component.Name(), interval)
+	current, _ := fc.ModuleInterval(component.Name())
+	writeJSON(w, http.StatusOK, current)
+}
+
+func (s *HTTPServer) handleSummary(w http.ResponseWriter, r *http.Request) {
+	report := common.NewReport(s.node)
+	for name, component := range s.components {
+		result, err := component.LastResult()
+		if err != nil {
+			report.AddError(name, err)
+			continue
+		}
+		report.AddResult(result, nil)
+	}
+	report.Sort()
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (s *HTTPServer) lookup(w http.ResponseWriter, r *http.Request) (common.Component, bool) {
+	name := r.PathValue("name")
+	component, ok := s.components[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("component %s not found", name))
+		return nil, false
+	}
+	return component, true
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logrus.WithField("service", "api-server").Errorf("encode response failed: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+	"github.com/scitix/sichek/consts"
+)
+
+type fakeComponent struct {
+	name   string
+	last   *common.Result
+	checks int
+}
+
+func (f *fakeComponent) Name() string { return f.name }
+func (f *fakeComponent) HealthCheck(ctx context.Context) (*common.Result, error) {
+	f.checks++
+	f.last = &common.Result{Item: f.name, Status: consts.StatusAbnormal, Level: consts.LevelWarning, Time: time.Now()}
+	return f.last, nil
+}
+func (f *fakeComponent) GetTimeout() time.Duration { return time.Second }
+func (f *fakeComponent) CacheResults() ([]*common.Result, error) {
+	return []*common.Result{f.last}, nil
+}
+func (f *fakeComponent) LastResult() (*common.Result, error)              { return f.last, nil }
+func (f *fakeComponent) CacheInfos() ([]common.Info, error)               { return nil, nil }
+func (f *fakeComponent) LastInfo() (common.Info, error)                   { return nil, nil }
+func (f *fakeComponent) PrintInfo(common.Info, *common.Result, bool) bool { return true }
+func (f *fakeComponent) Start() <-chan *common.Result                     { return nil }
+func (f *fakeComponent) Update(cfg common.ComponentUserConfig) error      { return nil }
+func (f *fakeComponent) Status() bool                                     { return true }
+func (f *fakeComponent) Stop() error                                      { return nil }
+
+func TestLoadAPIServerConfig(t *testing.T) {
+	cfg, err := LoadAPIServerConfig(writeCfg(t, "api_server:\n  enable: true\n"))
+	if err != nil {
+		t.Fatalf("LoadAPIServerConfig: %v", err)
+	}
+	if !cfg.Enable || cfg.Addr != "127.0.0.1:19092" {
+		t.Errorf("got %+v", cfg)
+	}
+}
+
+func TestHTTPServer_Endpoints(t *testing.T) {
+	cpu := &fakeComponent{name: consts.ComponentNameCPU}
+	srv := NewHTTPServer(defaultAPIServerConfig(), map[string]common.Component{cpu.name: cpu}, "node-1")
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/v1/components/" + cpu.name + "/last-result")
+	if err != nil {
+		t.Fatalf("GET last-result: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("last-result before any check: status=%d, want 404", resp.StatusCode)
+	}
+
+	resp, err = http.Post(ts.URL+"/v1/components/"+cpu.name+"/check", "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST check: %v", err)
+	}
+	var result common.Result
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("decode check result: %v", err)
+	}
+	resp.Body.Close()
+	if cpu.checks != 1 || result.Node != "node-1" || result.Level != consts.LevelWarning {
+		t.Errorf("unexpected check result %+v after %d checks", result, cpu.checks)
+	}
+
+	resp, err = http.Get(ts.URL + "/v1/summary")
+	if err != nil {
+		t.Fatalf("GET summary: %v", err)
+	}
+	var report common.Report
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		t.Fatalf("decode summary: %v", err)
+	}
+	resp.Body.Close()
+	if report.Status != consts.StatusAbnormal || len(report.Components) != 1 {
+		t.Errorf("unexpected summary %+v", report)
+	}
+
+	resp, err = http.Get(ts.URL + "/v1/components/unknown/last-result")
+	if err != nil {
+		t.Fatalf("GET unknown: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown component: status=%d, want 404", resp.StatusCode)
+	}
+}
+
+type fakeIntervalConfig struct {
+	interval common.Duration
+}
+
+func (c *fakeIntervalConfig) GetQueryInterval() common.Duration  { return c.interval }
+func (c *fakeIntervalConfig) SetQueryInterval(d common.Duration) { c.interval = d }
+
+func TestHTTPServer_Interval(t *testing.T) {
+	memory := &fakeComponent{name: consts.ComponentNameMemory}
+	cfg := &fakeIntervalConfig{interval: common.Duration{Duration: 10 * time.Second}}
+	common.GetFreqController().RegisterModule(memory.name, cfg)
+	srv := NewHTTPServer(defaultAPIServerConfig(), map[string]common.Component{memory.name: memory}, "node-1")
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	put := func(body string) *http.Response {
+		req, err := http.NewRequest(http.MethodPut, ts.URL+"/v1/components/"+memory.name+"/interval", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("new request: %v", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("PUT interval: %v", err)
+		}
+		return resp
+	}
+
+	resp := put(`{"interval": "30s"}`)
+	var interval common.ModuleInterval
+	if err := json.NewDecoder(resp.Body).Decode(&interval); err != nil {
+		t.Fatalf("decode interval: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || interval.Interval != "30s" || cfg.interval.Duration != 30*time.Second {
+		t.Errorf("PUT interval: status=%d, got %+v, config %s", resp.StatusCode, interval, cfg.interval.Duration)
+	}
+
+	resp = put(`{"interval": "soon"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid interval: status=%d, want 400", resp.StatusCode)
+	}
+
+	resp, err := http.Get(ts.URL + "/v1/components/" + memory.name + "/interval")
+	if err != nil {
+		t.Fatalf("GET interval: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET interval: status=%d, want 200", resp.StatusCode)
+	}
+}
+
+// failingComponent returns result and err from its health checks.
+type failingComponent struct {
+	fakeComponent
+	result *common.Result
+	err    error
+}
+
+func (f *failingComponent) HealthCheck(ctx context.Context) (*common.Result, error) {
+	return f.result, f.err
+}
+
+func TestHTTPServer_CheckFailure(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		component *failingComponent
+		wantError string
+	}{
+		{"error", &failingComponent{err: errors.New("collect failed")}, "collect failed"},
+		{"no result", &failingComponent{}, "component cpu returned no result"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.component.name = consts.ComponentNameCPU
+			srv := NewHTTPServer(defaultAPIServerConfig(), map[string]common.Component{tc.component.name: tc.component}, "node-1")
+			ts := httptest.NewServer(srv.Handler())
+			defer ts.Close()
+
+			resp, err := http.Post(ts.URL+"/v1/components/"+tc.component.name+"/check", "application/json", nil)
+			if err != nil {
+				t.Fatalf("POST check: %v", err)
+			}
+			defer resp.Body.Close()
+			var body CheckErrorResponse
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("decode check response: %v", err)
+			}
+			if resp.StatusCode != http.StatusInternalServerError || body.Error != tc.wantError {
+				t.Errorf("status=%d body=%+v, want 500 with %q", resp.StatusCode, body, tc.wantError)
+			}
+		})
+	}
+}
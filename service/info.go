@@ -143,6 +143,7 @@ func (a *nodeAnnotation) updateAnnotations(annotations map[string][]*annotation,
 				deduplicatedAnnotation[checkResult.Level][checkResult.ErrorName] = &annotation{
 					ErrorName: checkResult.ErrorName,
 					Device:    checkResult.Device,
+					Devices:   checkResult.Devices,
 				}
 			}
 		}
@@ -201,8 +202,9 @@ func (a *nodeAnnotation) AppendFromResult(result *common.Result) error {
 }
 
 type annotation struct {
-	ErrorName string `json:"error_name"`
-	Device    string `json:"device"`
+	ErrorName string                 `json:"error_name"`
+	Device    string                 `json:"device"`
+	Devices   []*common.DeviceResult `json:"devices,omitempty"`
 }
 
 func (a *annotation) JSON() (string, error) {
@@ -14,11 +14,11 @@ import (
 	"compress/gzip"
 	"context"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"time"
 
+	"github.com/scitix/sichek/pkg/httpclient"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 )
@@ -112,19 +112,10 @@ func NewReporter(cfg ReporterConfig, snapshotPath, nodeName string) *Reporter {
 		client: &http.Client{
 			Timeout: cfg.Timeout,
 		},
-		backoff: defaultBackoff,
+		backoff: httpclient.ExponentialBackoff,
 	}
 }
 
-func defaultBackoff(attempt int) time.Duration {
-	// 1s, 2s, 4s, 8s capped at 30s.
-	d := time.Second << attempt
-	if d > 30*time.Second {
-		d = 30 * time.Second
-	}
-	return d
-}
-
 // pushOnce reads the snapshot file and POSTs it, with retries on 5xx or
 // transport errors up to cfg.RetryMax. 4xx responses abort immediately.
 func (r *Reporter) pushOnce(ctx context.Context) error {
@@ -146,47 +137,13 @@ func (r *Reporter) pushOnce(ctx context.Context) error {
 		body = buf.Bytes()
 	}
 
-	var lastErr error
-	attempts := r.cfg.RetryMax
-	if attempts < 1 {
-		attempts = 1
-	}
-	for i := 0; i < attempts; i++ {
-		if i > 0 {
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-time.After(r.backoff(i - 1)):
-			}
-		}
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.cfg.Endpoint, bytes.NewReader(body))
-		if err != nil {
-			return fmt.Errorf("new request: %w", err)
-		}
-		req.Header.Set("X-Sichek-Node", r.nodeName)
-		req.Header.Set("Content-Type", "application/json")
-		if r.cfg.Gzip {
-			req.Header.Set("Content-Encoding", "gzip")
-		}
-
-		resp, err := r.client.Do(req)
-		if err != nil {
-			lastErr = err
-			continue
-		}
-		_, _ = io.Copy(io.Discard, resp.Body)
-		_ = resp.Body.Close()
-
-		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			return nil
-		}
-		// 4xx: don't retry — configuration/auth errors won't fix themselves.
-		if resp.StatusCode >= 400 && resp.StatusCode < 500 {
-			return fmt.Errorf("collector returned %d", resp.StatusCode)
-		}
-		lastErr = fmt.Errorf("collector returned %d", resp.StatusCode)
+	header := http.Header{}
+	header.Set("X-Sichek-Node", r.nodeName)
+	header.Set("Content-Type", "application/json")
+	if r.cfg.Gzip {
+		header.Set("Content-Encoding", "gzip")
 	}
-	return lastErr
+	return httpclient.PostWithRetry(ctx, r.client, r.cfg.Endpoint, header, body, r.cfg.RetryMax, r.backoff)
 }
 
 // logEntry returns a structured logger with the standard reporter fields.
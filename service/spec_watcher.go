@@ -0,0 +1,241 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/scitix/sichek/components/common"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// SpecReloadConfig controls the reload of the spec while the daemon runs.
+type SpecReloadConfig struct {
+	Enable   bool          `json:"enable"   yaml:"enable"`
+	Interval time.Duration `json:"interval" yaml:"interval"`
+	// Remote also polls the spec server the spec was downloaded from, with the
+	// ETag of the last download so that an unchanged spec is not transferred.
+	Remote bool `json:"remote" yaml:"remote"`
+}
+
+type specReloadFile struct {
+	SpecReload SpecReloadConfig `json:"spec_reload" yaml:"spec_reload"`
+}
+
+func defaultSpecReloadConfig() SpecReloadConfig {
+	return SpecReloadConfig{
+		Enable:   false,
+		Interval: 60 * time.Second,
+		Remote:   true,
+	}
+}
+
+// LoadSpecReloadConfig parses the spec_reload block from cfgFile.
+// If cfgFile is "" or missing, returns defaults.
+func LoadSpecReloadConfig(cfgFile string) (SpecReloadConfig, error) {
+	cfg := defaultSpecReloadConfig()
+	if cfgFile == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(cfgFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return SpecReloadConfig{}, fmt.Errorf("load spec reload config: %w", err)
+	}
+	f := specReloadFile{SpecReload: cfg}
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return SpecReloadConfig{}, fmt.Errorf("load spec reload config: %w", err)
+	}
+	if f.SpecReload.Interval <= 0 {
+		f.SpecReload.Interval = cfg.Interval
+	}
+	return f.SpecReload, nil
+}
+
+// SpecWatcher applies a changed spec to the running components. It watches the
+// spec file with fsnotify for content changes, e.g. an edit or a rollout of a
+// new config map, polls it as a fallback, and optionally downloads a newer spec
+// from the remote spec server.
+type SpecWatcher struct {
+	cfg        SpecReloadConfig
+	specFile   string
+	remoteURL  string
+	components map[string]common.Component
+
+	hash string
+	etag string
+}
+
+// NewSpecWatcher constructs a SpecWatcher of specFile, remoteURL is the spec
+// server URL of the file or "" to only watch the local file. Call Run(ctx) to
+// start polling.
+func NewSpecWatcher(cfg SpecReloadConfig, specFile, remoteURL string, components map[string]common.Component) *SpecWatcher {
+	return &SpecWatcher{
+		cfg:        cfg,
+		specFile:   specFile,
+		remoteURL:  remoteURL,
+		components: components,
+		hash:       fileHash(specFile),
+	}
+}
+
+// specEventDebounce coalesces the burst of events of a single save, e.g. the
+// truncate and write of an editor or the symlink swap of a config map.
+const specEventDebounce = time.Second
+
+// Run watches the spec file and polls it every cfg.Interval until ctx is done.
+func (w *SpecWatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+
+	events, closeWatcher := w.watch()
+	defer closeWatcher()
+	debounce := time.NewTimer(specEventDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.poll()
+		case _, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			debounce.Reset(specEventDebounce)
+		case <-debounce.C:
+			w.poll()
+		}
+	}
+}
+
+// watch returns the fsnotify events of the spec file. The directory is watched
+// rather than the file, as the file is usually replaced by a rename and config
+// maps swap a symlink of the directory. A nil channel is returned if fsnotify
+// is unavailable, leaving the periodic poll only.
+func (w *SpecWatcher) watch() (<-chan fsnotify.Event, func()) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		logrus.WithField("daemon", "spec-watcher").Warnf("fsnotify unavailable, polling %s every %s: %v", w.specFile, w.cfg.Interval, err)
+		return nil, func() {}
+	}
+	dir := filepath.Dir(w.specFile)
+	if err := watcher.Add(dir); err != nil {
+		logrus.WithField("daemon", "spec-watcher").Warnf("watch %s failed, polling %s every %s: %v", dir, w.specFile, w.cfg.Interval, err)
+		watcher.Close()
+		return nil, func() {}
+	}
+	events := make(chan fsnotify.Event)
+	done := make(chan struct{})
+	go func() {
+		defer close(events)
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !w.isSpecEvent(event) {
+					continue
+				}
+				select {
+				case events <- event:
+				case <-done:
+					return
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logrus.WithField("daemon", "spec-watcher").Warnf("fsnotify error: %v", err)
+			}
+		}
+	}()
+	return events, func() {
+		close(done)
+		watcher.Close()
+	}
+}
+
+func (w *SpecWatcher) isSpecEvent(event fsnotify.Event) bool {
+	if event.Has(fsnotify.Chmod) {
+		return false
+	}
+	// config maps update the file through the ..data symlink of the directory
+	name := filepath.Base(event.Name)
+	return filepath.Clean(event.Name) == filepath.Clean(w.specFile) || name == "..data"
+}
+
+// poll refreshes the spec file from the remote server and reloads the
+// components if its content changed, it reports whether they were reloaded.
+func (w *SpecWatcher) poll() bool {
+	if w.cfg.Remote && w.remoteURL != "" {
+		etag, _, err := common.DownloadSpecFileIfModified(w.remoteURL, w.specFile, w.etag, "spec-watcher")
+		if err != nil {
+			logrus.WithField("daemon", "spec-watcher").Warnf("refresh spec from %s failed: %v", w.remoteURL, err)
+		}
+		w.etag = etag
+	}
+	hash := fileHash(w.specFile)
+	if hash == "" || hash == w.hash {
+		return false
+	}
+	logrus.WithField("daemon", "spec-watcher").Infof("spec %s changed, reloading the components", w.specFile)
+	w.reload()
+	// the components may rewrite the file with the entries of this node when
+	// they load it, so hash it again to not take that for another change
+	w.hash = fileHash(w.specFile)
+	return true
+}
+
+func (w *SpecWatcher) reload() {
+	names := make([]string, 0, len(w.components))
+	for name := range w.components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		updater, ok := w.components[name].(common.SpecUpdater)
+		if !ok {
+			continue
+		}
+		if err := updater.UpdateSpec(w.specFile); err != nil {
+			logrus.WithField("daemon", "spec-watcher").Errorf("reload spec of %s failed, keeping the previous checkers: %v", name, err)
+		}
+	}
+}
+
+func fileHash(file string) string {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/scitix/sichek/components/common"
+)
+
+type fakeSpecComponent struct {
+	common.Component
+	reloads []string
+}
+
+func (c *fakeSpecComponent) UpdateSpec(specFile string) error {
+	data, err := os.ReadFile(specFile)
+	if err != nil {
+		return err
+	}
+	c.reloads = append(c.reloads, string(data))
+	return nil
+}
+
+func TestLoadSpecReloadConfig(t *testing.T) {
+	cfg, err := LoadSpecReloadConfig(writeCfg(t, "spec_reload:\n  enable: true\n  remote: false\n"))
+	if err != nil {
+		t.Fatalf("LoadSpecReloadConfig: %v", err)
+	}
+	if !cfg.Enable || cfg.Remote || cfg.Interval != 60*time.Second {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+}
+
+func TestSpecWatcher_LocalChange(t *testing.T) {
+	specFile := filepath.Join(t.TempDir(), "spec.yaml")
+	if err := os.WriteFile(specFile, []byte("v1"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	comp := &fakeSpecComponent{}
+	w := NewSpecWatcher(SpecReloadConfig{Enable: true, Interval: time.Second}, specFile, "",
+		map[string]common.Component{"nvidia": comp, "cpu": &struct{ common.Component }{}})
+
+	if w.poll() {
+		t.Errorf("unchanged spec should not be reloaded")
+	}
+	if err := os.WriteFile(specFile, []byte("v2"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if !w.poll() || len(comp.reloads) != 1 || comp.reloads[0] != "v2" {
+		t.Errorf("expected one reload with v2, got %v", comp.reloads)
+	}
+	if w.poll() {
+		t.Errorf("spec should not be reloaded twice")
+	}
+}
+
+type notifySpecComponent struct {
+	common.Component
+	reloaded chan string
+}
+
+func (c *notifySpecComponent) UpdateSpec(specFile string) error {
+	data, err := os.ReadFile(specFile)
+	if err != nil {
+		return err
+	}
+	c.reloaded <- string(data)
+	return nil
+}
+
+func TestSpecWatcher_Fsnotify(t *testing.T) {
+	specFile := filepath.Join(t.TempDir(), "spec.yaml")
+	if err := os.WriteFile(specFile, []byte("v1"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	comp := &notifySpecComponent{reloaded: make(chan string, 1)}
+	// the poll interval is far beyond the test, only fsnotify can trigger the reload
+	w := NewSpecWatcher(SpecReloadConfig{Enable: true, Interval: time.Hour}, specFile, "",
+		map[string]common.Component{"nvidia": comp})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+
+	// give Run the time to add the watch
+	time.Sleep(200 * time.Millisecond)
+	tmp := specFile + ".tmp"
+	if err := os.WriteFile(tmp, []byte("v2"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := os.Rename(tmp, specFile); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	select {
+	case got := <-comp.reloaded:
+		if got != "v2" {
+			t.Errorf("expected reload with v2, got %s", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("spec change was not picked up by fsnotify")
+	}
+}
+
+func TestSpecWatcher_RemoteETag(t *testing.T) {
+	body, etag := "remote-v1", `"v1"`
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	specFile := filepath.Join(t.TempDir(), "spec.yaml")
+	if err := os.WriteFile(specFile, []byte("local"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	comp := &fakeSpecComponent{}
+	w := NewSpecWatcher(SpecReloadConfig{Enable: true, Interval: time.Second, Remote: true}, specFile, srv.URL+"/spec.yaml",
+		map[string]common.Component{"nvidia": comp})
+
+	if !w.poll() || len(comp.reloads) != 1 || comp.reloads[0] != "remote-v1" {
+		t.Fatalf("expected reload with the remote spec, got %v", comp.reloads)
+	}
+	if w.poll() {
+		t.Errorf("a 304 response should not reload the spec")
+	}
+	body, etag = "remote-v2", `"v2"`
+	if !w.poll() || len(comp.reloads) != 2 || comp.reloads[1] != "remote-v2" {
+		t.Errorf("expected reload with the new remote spec, got %v", comp.reloads)
+	}
+	if requests != 3 {
+		t.Errorf("requests=%d, want 3", requests)
+	}
+}
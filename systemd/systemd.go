@@ -45,22 +45,69 @@ func CreateDefaultEnvFile() error {
 	if _, err := os.Stat(DefaultEnvFile); err == nil { // to not overwrite
 		return nil
 	}
+	return WriteEnvFile(nil)
+}
+
+// WriteEnvFile writes the `sichek daemon run` flags of the unit to the env file.
+func WriteEnvFile(flags []string) error {
+	if err := os.WriteFile(DefaultEnvFile, []byte(renderEnvFile(flags)), 0644); err != nil {
+		return fmt.Errorf("failed to write env file %s: %w", DefaultEnvFile, err)
+	}
+	return nil
+}
 
-	f, err := os.OpenFile(DefaultEnvFile, os.O_WRONLY|os.O_CREATE, 0644)
+// ReadEnvFlags returns the `sichek daemon run` flags set in the env file.
+func ReadEnvFlags() (string, error) {
+	content, err := os.ReadFile(DefaultEnvFile)
 	if err != nil {
-		return err
+		return "", err
 	}
-	defer func(f *os.File) {
-		err := f.Close()
-		if err != nil {
-			fmt.Printf("failed to close file %s : %v", DefaultEnvFile, err)
+	return parseEnvFlags(string(content)), nil
+}
+
+func renderEnvFile(flags []string) string {
+	quoted := make([]string, 0, len(flags))
+	for _, flag := range flags {
+		quoted = append(quoted, strings.ReplaceAll(flag, `"`, `\"`))
+	}
+	return fmt.Sprintf("# sichek environment variables are set here\nFLAGS=\"%s\"\n", strings.Join(quoted, " "))
+}
+
+func parseEnvFlags(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if value, ok := strings.CutPrefix(line, "FLAGS="); ok {
+			value = strings.TrimSuffix(strings.TrimPrefix(value, `"`), `"`)
+			return strings.ReplaceAll(value, `\"`, `"`)
 		}
-	}(f)
+	}
+	return ""
+}
+
+// UnitInstalled reports whether the sichek unit file was installed.
+func UnitInstalled() bool {
+	_, err := os.Stat(DefaultUnitFile)
+	return err == nil
+}
 
-	_, err = f.WriteString(`# sichek environment variables are set here
-FLAGS=""
-`)
-	return err
+// InstallUnit writes the unit file, the env file holding the daemon flags and
+// the logrotate config. With nil flags an existing env file is kept, so that
+// reinstalling the unit does not drop the flags of a previous install.
+func InstallUnit(flags []string) error {
+	if !DefaultBinExists() {
+		return fmt.Errorf("sichek binary not found at %s", DefaultBinPath)
+	}
+	if flags == nil {
+		if err := CreateDefaultEnvFile(); err != nil {
+			return fmt.Errorf("failed to create env file %s: %w", DefaultEnvFile, err)
+		}
+	} else if err := WriteEnvFile(flags); err != nil {
+		return err
+	}
+	if err := os.WriteFile(DefaultUnitFile, []byte(SichekService), 0644); err != nil {
+		return fmt.Errorf("failed to write unit file %s: %w", DefaultUnitFile, err)
+	}
+	return LogrotateInit()
 }
 
 func LogrotateInit() error {
@@ -0,0 +1,42 @@
+/*
+Copyright 2024 The Scitix Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package systemd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEnvFileFlags(t *testing.T) {
+	flags := []string{"--cfg=/etc/sichek/config.yaml", "--spec=hpc-h100", "--metrics-port=19091"}
+	content := renderEnvFile(flags)
+	if got := parseEnvFlags(content); got != strings.Join(flags, " ") {
+		t.Errorf("parseEnvFlags(renderEnvFile) = %q, want %q", got, strings.Join(flags, " "))
+	}
+	if got := parseEnvFlags(renderEnvFile(nil)); got != "" {
+		t.Errorf("expected no flags in the default env file, got %q", got)
+	}
+}
+
+func TestUnitUsesEnvFile(t *testing.T) {
+	// The flags written by install must be the ones the unit reads.
+	if !strings.Contains(SichekService, "EnvironmentFile=-"+DefaultEnvFile) {
+		t.Errorf("unit does not read %s:\n%s", DefaultEnvFile, SichekService)
+	}
+	if !strings.Contains(SichekService, "ExecStart="+DefaultBinPath+" ") {
+		t.Errorf("unit does not run %s:\n%s", DefaultBinPath, SichekService)
+	}
+}